@@ -0,0 +1,116 @@
+package bacalhau
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+// attachReadSize is the most stdin sent to the job at once.
+const attachReadSize = 4096
+
+var (
+	attachShortDesc = templates.LongDesc(i18n.T(`
+		Attach to the stdin, stdout and stderr of a running interactive job
+`))
+
+	//nolint:lll // Documentation
+	attachExample = templates.Examples(i18n.T(`
+		# Run a Python REPL on the network, and attach to it
+		bacalhau docker run --interactive --wait=false python:3.10 -- python -i
+		bacalhau attach 51225160-807e-48b8-88c9-28311c7899e1
+`))
+)
+
+func newAttachCmd() *cobra.Command {
+	attachCmd := &cobra.Command{
+		Use:     "attach [id]",
+		Short:   attachShortDesc,
+		Example: attachExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE:    attach,
+	}
+	return attachCmd
+}
+
+func attach(cmd *cobra.Command, cmdArgs []string) error {
+	ctx := cmd.Context()
+	apiClient := GetAPIClient()
+
+	job, jobFound, err := apiClient.Get(ctx, cmdArgs[0])
+	if err != nil {
+		Fatal(cmd, err.Error(), 1)
+		return nil
+	}
+	if !jobFound {
+		Fatal(cmd, fmt.Sprintf("could not find job %s", cmdArgs[0]), 1)
+		return nil
+	}
+	if !job.Job.Spec.Interactive {
+		Fatal(cmd, fmt.Sprintf("job %s is not interactive, it must be submitted with --interactive", job.Job.ID()), 1)
+		return nil
+	}
+
+	executionID := ""
+	for _, execution := range job.State.Executions {
+		if execution.State.IsActive() {
+			executionID = execution.ComputeReference
+		}
+	}
+	if executionID == "" {
+		Fatal(cmd, fmt.Sprintf("Unable to find an active execution for job (ID: %s)", job.Job.ID()), 1)
+		return nil
+	}
+
+	conn, err := apiClient.Attach(ctx, job.Job.ID(), executionID)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Unknown error trying to attach to job (ID: %s): %s", job.Job.ID(), err), 1)
+		return nil
+	}
+	defer conn.Close()
+
+	// stdin is sent until it ends, which closes the stdin of the job
+	go func() {
+		in := cmd.InOrStdin()
+		buf := make([]byte, attachReadSize)
+		for {
+			n, readErr := in.Read(buf)
+			if n > 0 {
+				if conn.WriteJSON(publicapi.AttachInput{Data: string(buf[:n])}) != nil {
+					return
+				}
+			}
+			if readErr != nil {
+				_ = conn.WriteJSON(publicapi.AttachInput{Close: true})
+				return
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_ = conn.Close()
+	}()
+
+	for {
+		var msg Msg
+		if err = conn.ReadJSON(&msg); err != nil {
+			if closeErr, ok := err.(*websocket.CloseError); ok && closeErr.Code != websocket.CloseNormalClosure {
+				Fatal(cmd, fmt.Sprintf("Attaching to job %s failed: %s", job.Job.ID(), closeErr.Text), 1)
+			}
+			return nil
+		}
+		out := cmd.OutOrStdout()
+		if msg.Tag == 2 {
+			out = cmd.ErrOrStderr()
+		}
+		_, _ = io.WriteString(out, msg.Data)
+	}
+}
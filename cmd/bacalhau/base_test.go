@@ -24,6 +24,8 @@ type BaseSuite struct {
 func (s *BaseSuite) SetupTest() {
 	logger.ConfigureTestLogging(s.T())
 	Fatal = FakeFatalErrorHandler
+	// keep the CLI configuration, including the requesters trusted on first use, out of the user's home
+	s.T().Setenv("BACALHAU_PATH", s.T().TempDir())
 
 	ctx := context.Background()
 	stack, _ := testutils.SetupTest(ctx, s.T(), 1, 0, false,
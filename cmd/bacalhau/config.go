@@ -0,0 +1,340 @@
+package bacalhau
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	configLong = templates.LongDesc(i18n.T(`
+		Manage the contexts of the CLI configuration.
+
+		A context names a cluster to talk to, with its API host and port, an optional token sent with every request,
+		the namespace to act in and default settings for downloading results. Contexts only apply to the commands that
+		talk to a cluster, not to those that run a node such as serve and devstack. Commands use the current context, unless another one is selected
+		with --context or the BACALHAU_CONTEXT environment variable. Flags and environment variables such as
+		BACALHAU_API_HOST take precedence over the settings of the context.
+`))
+
+	//nolint:lll // Documentation
+	configExample = templates.Examples(i18n.T(`
+		# Add a context for a production cluster and switch to it
+		bacalhau config set-context prod --host bacalhau.example.com --port 1234 --token $TOKEN
+		bacalhau config use-context prod
+
+		# Run a single command against another context
+		bacalhau list --context dev
+
+		# Export the settings of a context to the environment
+		eval $(bacalhau config env prod)
+`))
+)
+
+type SetContextOptions struct {
+	Host            string        // API host of the cluster
+	Port            uint16        // API port of the cluster
+	Token           string        // Bearer token sent with every request
+	Namespace       string        // Namespace to act in
+	DownloadTimeout time.Duration // Default timeout for downloading results
+	OutputDir       string        // Default directory to download results to
+	IPFSSwarmAddrs  string        // Default IPFS nodes to connect to when downloading results
+	IPFSGateways    string        // Default HTTP gateways to fall back to when downloading results
+}
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:     "config",
+		Short:   "Manage CLI contexts",
+		Long:    configLong,
+		Example: configExample,
+	}
+
+	OS := &SetContextOptions{}
+	setContextCmd := &cobra.Command{
+		Use:   "set-context NAME",
+		Short: "Create or update a context. Only the given settings of an existing context are updated",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setContext(cmd, args[0], OS)
+		},
+	}
+	setContextCmd.Flags().StringVar(&OS.Host, "host", OS.Host, "The API host of the cluster.")
+	setContextCmd.Flags().Uint16Var(&OS.Port, "port", OS.Port, "The API port of the cluster.")
+	setContextCmd.Flags().StringVar(&OS.Token, "token", OS.Token, "A bearer token to send with every request to the API.")
+	setContextCmd.Flags().StringVar(&OS.Namespace, "namespace", OS.Namespace,
+		"The namespace to act in. Only admins can act in a namespace other than the one of their token.")
+	setContextCmd.Flags().DurationVar(&OS.DownloadTimeout, "download-timeout", OS.DownloadTimeout,
+		"Default timeout for downloading results.")
+	setContextCmd.Flags().StringVar(&OS.OutputDir, "output-dir", OS.OutputDir, "Default directory to download results to.")
+	setContextCmd.Flags().StringVar(&OS.IPFSSwarmAddrs, "ipfs-swarm-addrs", OS.IPFSSwarmAddrs,
+		"Default comma-separated list of IPFS nodes to connect to when downloading results.")
+	setContextCmd.Flags().StringVar(&OS.IPFSGateways, "ipfs-gateways", OS.IPFSGateways,
+		"Default comma-separated list of HTTP gateways to fall back to when downloading results.")
+
+	useContextCmd := &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Set the current context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+				if _, ok := clientConfig.Contexts[args[0]]; !ok {
+					return fmt.Errorf("context %q not found", args[0])
+				}
+				clientConfig.CurrentContext = args[0]
+				cmd.Printf("Switched to context %q.\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	deleteContextCmd := &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "Delete a context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+				if _, ok := clientConfig.Contexts[args[0]]; !ok {
+					return fmt.Errorf("context %q not found", args[0])
+				}
+				delete(clientConfig.Contexts, args[0])
+				if clientConfig.CurrentContext == args[0] {
+					clientConfig.CurrentContext = ""
+				}
+				return nil
+			})
+		},
+	}
+
+	getContextsCmd := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the contexts, marking the current one with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientConfig, err := config.LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			for _, name := range clientConfig.ContextNames() {
+				clientContext := clientConfig.Contexts[name]
+				current := " "
+				if name == clientConfig.CurrentContext {
+					current = "*"
+				}
+				cmd.Printf("%s %s\t%s:%d\n", current, name, clientContext.APIHost, clientContext.APIPort)
+			}
+			return nil
+		},
+	}
+
+	currentContextCmd := &cobra.Command{
+		Use:   "current-context",
+		Short: "Print the name of the current context",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientConfig, err := config.LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			if clientConfig.CurrentContext == "" {
+				return fmt.Errorf("no current context is set")
+			}
+			cmd.Println(clientConfig.CurrentContext)
+			return nil
+		},
+	}
+
+	envCmd := &cobra.Command{
+		Use:   "env [NAME]",
+		Short: "Print shell commands exporting the settings of a context, or of the current context",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return printContextEnv(cmd, name)
+		},
+	}
+
+	configCmd.AddCommand(setContextCmd, useContextCmd, deleteContextCmd, getContextsCmd, currentContextCmd, envCmd,
+		newConfigTrustCmd())
+	return configCmd
+}
+
+func updateClientConfig(update func(*config.ClientConfig) error) error {
+	clientConfig, err := config.LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	if err = update(clientConfig); err != nil {
+		return err
+	}
+	return clientConfig.Save()
+}
+
+func setContext(cmd *cobra.Command, name string, OS *SetContextOptions) error {
+	return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+		clientContext := clientConfig.Contexts[name]
+		flags := cmd.Flags()
+		if flags.Changed("host") {
+			clientContext.APIHost = OS.Host
+		}
+		if flags.Changed("port") {
+			clientContext.APIPort = OS.Port
+		}
+		if flags.Changed("token") {
+			clientContext.Token = OS.Token
+		}
+		if flags.Changed("namespace") {
+			clientContext.Namespace = OS.Namespace
+		}
+		if flags.Changed("download-timeout") {
+			clientContext.Download.Timeout = OS.DownloadTimeout
+		}
+		if flags.Changed("output-dir") {
+			clientContext.Download.OutputDir = OS.OutputDir
+		}
+		if flags.Changed("ipfs-swarm-addrs") {
+			clientContext.Download.IPFSSwarmAddrs = OS.IPFSSwarmAddrs
+		}
+		if flags.Changed("ipfs-gateways") {
+			clientContext.Download.IPFSGateways = OS.IPFSGateways
+		}
+		clientConfig.Contexts[name] = clientContext
+		if clientConfig.CurrentContext == "" {
+			clientConfig.CurrentContext = name
+		}
+		cmd.Printf("Context %q set.\n", name)
+		return nil
+	})
+}
+
+func printContextEnv(cmd *cobra.Command, name string) error {
+	clientConfig, err := config.LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	name, clientContext, err := clientConfig.Context(name)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("no current context is set")
+	}
+
+	cmd.Printf("export BACALHAU_CONTEXT=%s\n", strconv.Quote(name))
+	if clientContext.APIHost != "" {
+		cmd.Printf("export BACALHAU_API_HOST=%s\n", strconv.Quote(clientContext.APIHost))
+	}
+	if clientContext.APIPort != 0 {
+		cmd.Printf("export BACALHAU_API_PORT=%d\n", clientContext.APIPort)
+	}
+	if clientContext.Token != "" {
+		cmd.Printf("export BACALHAU_API_TOKEN=%s\n", strconv.Quote(clientContext.Token))
+	}
+	if clientContext.Namespace != "" {
+		cmd.Printf("export BACALHAU_NAMESPACE=%s\n", strconv.Quote(clientContext.Namespace))
+	}
+	if clientContext.Download.IPFSGateways != "" {
+		cmd.Printf("export BACALHAU_IPFS_GATEWAYS=%s\n", strconv.Quote(clientContext.Download.IPFSGateways))
+	}
+	return nil
+}
+
+// serverCommands are the commands that run a node, whose flags such as --api-host configure the node rather than the
+// cluster to talk to, so the CLI context doesn't apply to them.
+var serverCommands = map[string]bool{"serve": true, "devstack": true, "simulator": true, "id": true}
+
+// localCommands are the commands that don't talk to the API, which run even if the CLI context can't be applied.
+var localCommands = map[string]bool{"config": true, "init": true, "validate": true, "completion": true, "help": true}
+
+// topLevelCommand returns the name of the command under the root command that cmd is, or is a subcommand of.
+func topLevelCommand(cmd *cobra.Command) string {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd.Name()
+}
+
+// usesAPI returns whether the command talks to the API of a cluster.
+func usesAPI(cmd *cobra.Command) bool {
+	name := topLevelCommand(cmd)
+	return !serverCommands[name] && !localCommands[name]
+}
+
+// applyClientContext applies the settings of the selected context to the flags and environment variables that
+// weren't set explicitly, unless the command runs a node.
+func applyClientContext(cmd *cobra.Command) error {
+	apiToken = os.Getenv("BACALHAU_API_TOKEN")
+	apiNamespace = os.Getenv("BACALHAU_NAMESPACE")
+	if serverCommands[topLevelCommand(cmd)] {
+		return nil
+	}
+
+	name := clientContextName
+	if name == "" {
+		name = os.Getenv("BACALHAU_CONTEXT")
+	}
+	clientConfig, err := config.LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	_, clientContext, err := clientConfig.Context(name)
+	if err != nil {
+		return err
+	}
+
+	if apiToken == "" {
+		apiToken = clientContext.Token
+	}
+	if apiNamespace == "" {
+		apiNamespace = clientContext.Namespace
+	}
+
+	var timeout string
+	if clientContext.Download.Timeout != 0 {
+		timeout = clientContext.Download.Timeout.String()
+	}
+	var port string
+	if clientContext.APIPort != 0 {
+		port = strconv.Itoa(int(clientContext.APIPort))
+	}
+	for _, setting := range []struct {
+		flag  string
+		value string
+		envs  []string
+	}{
+		{flag: "api-host", value: clientContext.APIHost, envs: []string{"BACALHAU_API_HOST", "BACALHAU_HOST"}},
+		{flag: "api-port", value: port, envs: []string{"BACALHAU_API_PORT", "BACALHAU_PORT"}},
+		{flag: "download-timeout-secs", value: timeout},
+		{flag: "output-dir", value: clientContext.Download.OutputDir},
+		{flag: "ipfs-swarm-addrs", value: clientContext.Download.IPFSSwarmAddrs},
+		{flag: "ipfs-gateways", value: clientContext.Download.IPFSGateways, envs: []string{"BACALHAU_IPFS_GATEWAYS"}},
+	} {
+		flag := cmd.Flags().Lookup(setting.flag)
+		if setting.value == "" || flag == nil || flag.Changed || anyEnvSet(setting.envs...) {
+			continue
+		}
+		if err := flag.Value.Set(setting.value); err != nil {
+			return fmt.Errorf("invalid %s in context: %w", setting.flag, err)
+		}
+	}
+	return nil
+}
+
+func anyEnvSet(names ...string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigContexts(t *testing.T) {
+	t.Setenv("BACALHAU_PATH", t.TempDir())
+	t.Setenv("BACALHAU_CONTEXT", "")
+	t.Setenv("BACALHAU_API_HOST", "")
+	t.Setenv("BACALHAU_HOST", "")
+
+	_, _, err := ExecuteTestCobraCommand("config", "set-context", "dev", "--host", "dev.example.com", "--port", "1111")
+	require.NoError(t, err)
+	_, _, err = ExecuteTestCobraCommand("config", "set-context", "prod", "--host", "prod.example.com", "--token", "secret")
+	require.NoError(t, err)
+
+	// the first context becomes the current one
+	_, out, err := ExecuteTestCobraCommand("config", "get-contexts")
+	require.NoError(t, err)
+	require.Equal(t, "* dev\tdev.example.com:1111\n  prod\tprod.example.com:0\n", out)
+	require.Equal(t, "dev.example.com", apiHost)
+	require.Equal(t, uint16(1111), apiPort)
+	require.Empty(t, apiToken)
+
+	_, _, err = ExecuteTestCobraCommand("config", "use-context", "prod")
+	require.NoError(t, err)
+	_, out, err = ExecuteTestCobraCommand("config", "current-context")
+	require.NoError(t, err)
+	require.Equal(t, "prod\n", out)
+	require.Equal(t, "prod.example.com", apiHost)
+	require.Equal(t, "secret", apiToken)
+
+	// flags take precedence over the context, which can be overridden
+	_, _, err = ExecuteTestCobraCommand("config", "current-context", "--context", "dev", "--api-host", "other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "other.example.com", apiHost)
+	require.Equal(t, uint16(1111), apiPort)
+
+	_, out, err = ExecuteTestCobraCommand("config", "env", "prod")
+	require.NoError(t, err)
+	require.Equal(t, "export BACALHAU_CONTEXT=\"prod\"\n"+
+		"export BACALHAU_API_HOST=\"prod.example.com\"\n"+
+		"export BACALHAU_API_TOKEN=\"secret\"\n", out)
+
+	// a context that doesn't exist only fails the commands that talk to a cluster
+	_, _, err = ExecuteTestCobraCommand("config", "current-context", "--context", "missing")
+	require.NoError(t, err)
+
+	_, _, err = ExecuteTestCobraCommand("config", "delete-context", "prod")
+	require.NoError(t, err)
+	_, _, err = ExecuteTestCobraCommand("config", "current-context")
+	require.Error(t, err)
+}
@@ -5,9 +5,31 @@ Examples:
 # Mount IPFS CID to /inputs directory
 -i ipfs://QmeZRGhe4PmjctYVSVHuEiA9oSXnqmYa4kQubSHgWbjv72
 
+# Mount only a directory within an IPFS CID, without fetching the rest of it
+-i ipfs://QmeZRGhe4PmjctYVSVHuEiA9oSXnqmYa4kQubSHgWbjv72/path/to/subdir,dst=/inputs
+
+# Mount the CID an IPNS name or DNSLink domain points to when the job is submitted
+-i ipns://docs.ipfs.tech,dst=/inputs/docs
+
 # Mount S3 object to a specific path
 -i s3://bucket/key,dst=/my/input/path
 
 # Mount S3 object with specific endpoint and region
 -i src=s3://bucket/key,dst=/my/input/path,opt=endpoint=https://s3.example.com,opt=region=us-east-1
+
+# Mount the contents of a tar, tar.gz or CAR archive as a directory, without extracting it (wasm jobs only)
+-i src=https://example.com/dataset.tar.gz,dst=/inputs/dataset,opt=mount-archive=true
+`
+
+const outputContractUsageMsg = `A file the job is expected to produce, checked by the compute node before the results are published. ` +
+	`Can be specified multiple times. Format: path[,schema=FILE][,columns=COLUMN;COLUMN]
+Examples:
+# Fail the job if it does not write outputs/result.json
+--output-contract outputs/result.json
+
+# Fail the job if outputs/result.json does not match the JSON Schema in the local file schema.json
+--output-contract outputs/result.json,schema=schema.json
+
+# Fail the job if the header of outputs/table.csv does not have the columns id and name
+--output-contract outputs/table.csv,columns=id;name
 `
@@ -25,13 +25,39 @@ var (
 	createLong = templates.LongDesc(i18n.T(`
 		Create a job from a file or from stdin.
 
-		JSON and YAML formats are accepted.
+		JSON and YAML formats are accepted. The job is validated against the schema of its API version, which
+		'bacalhau validate --output-schema' prints, and is built with the same defaults as the jobs of 'bacalhau docker
+		run', so that a file and the equivalent flags give the same job.
+
+		A job file can extend other job files with an "extends" key holding a path, or a list of paths, relative to
+		the file. The files extended are merged in order under the file, which takes precedence: maps are merged key
+		by key, and any other value, including lists, replaces the one it is merged over. The merged job is what is
+		submitted.
+
+		With --set or --values, the job file is rendered as a Go template before it is parsed, and the variables given
+		replace the {{ .vars.<name> }} placeholders of the file. Values files are YAML or JSON maps of variables, merged
+		in order, and --set variables are merged over them. A placeholder for a variable that isn't given is an error.
 	`))
 	//nolint:lll // Documentation
 	createExample = templates.Examples(i18n.T(`
 		# Create a job using the data in job.yaml
 		bacalhau create ./job.yaml
 
+		# Create a job using the data in job.yaml, given with a flag
+		bacalhau create -f ./job.yaml
+
+		# Create a job using the data in job.yaml, overlaid with its "prod" profile
+		bacalhau create --profile prod ./job.yaml
+
+		# Create a job from job.yaml, which has "extends: base.yaml" to share the settings of base.yaml
+		bacalhau create ./job.yaml
+
+		# Create a job from job.yaml, replacing {{ .vars.input }} and {{ .vars.image.tag }} with the values given
+		bacalhau create ./job.yaml --set input=ipfs://QmX... --set image.tag=22.04
+
+		# Create a job from job.yaml, with the variables of sweep.yaml
+		bacalhau create ./job.yaml --values sweep.yaml
+
 		# Create a new job from an already executed job
 		bacalhau describe 6e51df50 | bacalhau create -`))
 )
@@ -43,6 +69,9 @@ type CreateOptions struct {
 	RunTimeSettings RunTimeSettings          // Run time settings for execution (e.g. wait, get, etc after submission)
 	DownloadFlags   model.DownloaderSettings // Settings for running Download
 	DryRun          bool
+	Profile         string   // Profile of the job file to overlay on the rest of the file
+	Values          []string // Files of variables to render the job file with
+	Set             []string // Variables to render the job file with, as key=value
 }
 
 func NewCreateOptions() *CreateOptions {
@@ -71,11 +100,30 @@ func newCreateCmd() *cobra.Command {
 	}
 
 	createCmd.Flags().AddFlagSet(NewIPFSDownloadFlags(&OC.DownloadFlags))
-	createCmd.Flags().AddFlagSet(NewRunTimeSettingsFlags(&OC.RunTimeSettings))
+	runTimeFlags := NewRunTimeSettingsFlags(&OC.RunTimeSettings)
+	// -f is the job file, as in kubectl create -f, so --follow has no shorthand here
+	runTimeFlags.Lookup("follow").Shorthand = ""
+	createCmd.Flags().AddFlagSet(runTimeFlags)
 	createCmd.PersistentFlags().BoolVar(
 		&OC.DryRun, "dry-run", OC.DryRun,
 		`Do not submit the job, but instead print out what will be submitted`,
 	)
+	createCmd.PersistentFlags().StringVarP(
+		&OC.Filename, "filename", "f", OC.Filename,
+		`Path to the job file, or - to read it from stdin. The job is validated against its schema before it is submitted.`,
+	)
+	createCmd.PersistentFlags().StringVar(
+		&OC.Profile, "profile", OC.Profile,
+		`Name of a profile from the "profiles" section of the job file to merge over the rest of the file.`,
+	)
+	createCmd.PersistentFlags().StringArrayVar(
+		&OC.Values, "values", OC.Values,
+		`Path to a YAML or JSON file of variables to render the job file with. Can be given several times.`,
+	)
+	createCmd.PersistentFlags().StringArrayVar(
+		&OC.Set, "set", OC.Set,
+		`Variable to render the job file with, as key=value, where key can be a dotted path. Can be given several times.`,
+	)
 
 	return createCmd
 }
@@ -93,20 +141,21 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 	var byteResult []byte
 	var rawMap map[string]interface{}
 
-	j, err = model.NewJobWithSaneProductionDefaults()
-	if err != nil {
-		return err
+	if len(cmdArgs) > 0 {
+		if OC.Filename != "" {
+			Fatal(cmd, "Give the job file either as an argument or with --filename, not both", 1)
+			return nil
+		}
+		OC.Filename = cmdArgs[0]
 	}
 
-	if len(cmdArgs) == 0 {
-		byteResult, err = ReadFromStdinIfAvailable(cmd, cmdArgs)
+	if OC.Filename == "" || OC.Filename == "-" {
+		byteResult, err = ReadFromStdinIfAvailable(cmd, nil)
 		if err != nil {
 			Fatal(cmd, fmt.Sprintf("Unknown error reading from file or stdin: %s\n", err), 1)
 			return err
 		}
 	} else {
-		OC.Filename = cmdArgs[0]
-
 		var fileContent *os.File
 		fileContent, err = os.Open(OC.Filename)
 
@@ -122,6 +171,21 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 		}
 	}
 
+	// Render the job file with the variables given for its placeholders
+	if len(OC.Values) > 0 || len(OC.Set) > 0 {
+		var vars map[string]interface{}
+		vars, err = jobutils.LoadTemplateVars(OC.Values, OC.Set)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error loading template variables: %s", err), 1)
+			return err
+		}
+		byteResult, err = jobutils.RenderTemplate(byteResult, vars)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error rendering job file: %s", err), 1)
+			return err
+		}
+	}
+
 	// Do a first pass for parsing to see if it's a Job or JobWithInfo
 	err = model.YAMLUnmarshalWithMax(byteResult, &rawMap)
 	if err != nil {
@@ -129,6 +193,30 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 		return err
 	}
 
+	// Merge the files the job file extends under it, so that the job submitted is the result
+	extended, err := jobutils.ResolveExtends(rawMap, OC.Filename)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error resolving extended job files: %s", err), 1)
+		return err
+	}
+
+	// Overlay the selected profile, and drop the profiles so they don't end up in the job
+	profiled, err := jobutils.ApplyProfile(extended, OC.Profile)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error applying profile: %s", err), 1)
+		return err
+	}
+	// an empty file is left empty so that it is reported as such
+	if OC.Profile != "" || (len(rawMap) > 0 && !reflect.DeepEqual(profiled, rawMap)) {
+		rawMap = profiled
+		// JSON is also YAML, and is what IPVM tasks are decoded from
+		byteResult, err = model.JSONMarshalWithMax(rawMap)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error applying profile: %s", err), 1)
+			return err
+		}
+	}
+
 	// If it's a JobWithInfo, we need to convert it to a Job
 	if _, isJobWithInfo := rawMap["Job"]; isJobWithInfo {
 		err = model.YAMLUnmarshalWithMax(byteResult, &jwi)
@@ -174,10 +262,12 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 		return err
 	}
 
-	// Turns out the yaml parser supports both yaml & json (because json is a subset of yaml)
-	// so we can just use that
-	err = model.YAMLUnmarshalWithMax(byteResult, &j)
-	if err != nil {
+	// Validates the job against the schema, and parses it like the jobs constructed from flags
+	j, err = jobutils.ParseJobDocument(ctx, byteResult)
+	if invalid, ok := err.(jobutils.ErrInvalidJobDocument); ok {
+		Fatal(cmd, invalid.Error(), 1)
+		return err
+	} else if err != nil {
 		Fatal(cmd, userstrings.JobSpecBad, 1)
 		return err
 	}
@@ -211,12 +301,6 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 		j.Metadata.Requester.RequesterPublicKey = nil
 	}
 
-	if !model.IsValidPublisher(j.Spec.PublisherSpec.Type) {
-		j.Spec.PublisherSpec = model.PublisherSpec{
-			Type: j.Spec.Publisher,
-		}
-	}
-
 	// Warn on fields with data that will be ignored
 	if len(unusedFieldList) > 0 {
 		cmd.Printf("WARNING: The following fields have data in them and will be ignored on creation: %s\n", strings.Join(unusedFieldList, ", "))
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -74,6 +75,104 @@ func (s *CreateSuite) TestCreateGenericSubmit() {
 		}
 	}
 }
+func (s *CreateSuite) TestCreateWithProfile() {
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"--profile", "prod",
+		"../../testdata/job-noop-profiles.yaml",
+	)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), out, "- prod")
+	require.NotContains(s.T(), out, "- base")
+	require.Contains(s.T(), out, "Concurrency: 2")
+	require.NotContains(s.T(), out, "profiles")
+}
+
+func (s *CreateSuite) TestCreateWithTemplate() {
+	valuesFile := filepath.Join(s.T().TempDir(), "values.yaml")
+	require.NoError(s.T(), os.WriteFile(valuesFile, []byte("annotation: from-values\ndeal:\n  concurrency: 2\n"), 0644))
+
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"--values", valuesFile,
+		"--set", "annotation=swept",
+		"../../testdata/job-noop-template.yaml",
+	)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), out, "- swept")
+	require.NotContains(s.T(), out, "from-values")
+	require.Contains(s.T(), out, "Concurrency: 2")
+
+	_, out, err = ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"--set", "annotation=swept",
+		"../../testdata/job-noop-template.yaml",
+	)
+	require.Error(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), fatalError.Message, "deal")
+}
+
+func (s *CreateSuite) TestCreateRequireDigest() {
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--require-digest",
+		"../../testdata/job.yaml",
+	)
+	require.Error(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), fatalError.Message, "ubuntu:latest is not pinned to a digest")
+}
+
+func (s *CreateSuite) TestCreateWithExtends() {
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"../../testdata/job-noop-extends.yaml",
+	)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), out, "- extended")
+	require.NotContains(s.T(), out, "- base")
+	require.Contains(s.T(), out, "Engine: Noop")
+	require.Contains(s.T(), out, "Concurrency: 2")
+	require.NotContains(s.T(), out, "extends")
+}
+
+func (s *CreateSuite) TestCreateWithFilenameFlag() {
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"-f", "../../testdata/job-noop.yaml",
+	)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), out, "Engine: Noop")
+}
+
+func (s *CreateSuite) TestCreateRejectsInvalidFile() {
+	Fatal = FakeFatalErrorHandler
+	_, out, err := ExecuteTestCobraCommand("create",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"-f", "../../testdata/job-noop-invalid.yml",
+	)
+	require.Error(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), fatalError.Message, "The Job is not valid.")
+	require.Contains(s.T(), fatalError.Message, "APIVersion is required")
+}
+
 func (s *CreateSuite) TestCreateFromStdin() {
 	testFile := "../../testdata/job-noop.yaml"
 
@@ -1,21 +1,27 @@
 package bacalhau
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/i18n"
-	"sigs.k8s.io/yaml"
 )
 
 var (
 	//nolint:lll // Documentation
 	describeLong = templates.LongDesc(i18n.T(`
-		Full description of a job, in yaml format. Use 'bacalhau list' to get a list of all ids. Short form and long form of the job id are accepted.
+		Full description of a job, in yaml or json format: its spec and deal, the state of its execution on each node, and with --include-events the history of the job and its executions in the order it happened. Use --output table for a summary of the job and its executions instead. Use 'bacalhau list' to get a list of all ids. Short form and long form of the job id are accepted.
 `))
 	//nolint:lll // Documentation
 	describeExample = templates.Examples(i18n.T(`
@@ -27,14 +33,34 @@ var (
 
 		# Describe a job and include all server and local events
 		bacalhau describe --include-events b6ad164a
+
+		# Describe a job as JSON, to process it with a script
+		bacalhau describe --output json b6ad164a
+
+		# Summarize a job and its executions as a table
+		bacalhau describe --output table b6ad164a
+
+		# Describe the state a job was in at a point in time
+		bacalhau describe --at 2023-05-01T12:00:00Z b6ad164a
+
+		# Show where the time of a job went, per execution
+		bacalhau describe --timeline b6ad164a
+
+		# Export the timeline of a job to view in chrome://tracing or Perfetto
+		bacalhau describe --timeline --timeline-export trace.json b6ad164a
 `))
 )
 
 type DescribeOptions struct {
-	Filename      string // Filename for job (can be .json or .yaml)
-	IncludeEvents bool   // Include events in the description
-	OutputSpec    bool   // Print Just the jobspec to stdout
-	JSON          bool   // Print description as JSON
+	Filename      string        // Filename for job (can be .json or .yaml)
+	IncludeEvents bool          // Include events in the description
+	OutputSpec    bool          // Print Just the jobspec to stdout
+	JSON          bool          // Print description as JSON
+	Output        OutputOptions // Format of the description: yaml, json or a table summarizing it
+	At            string        // Describe the state of the job at this RFC3339 timestamp
+	Timeline      bool          // Print a breakdown of the time spent in each phase of each execution
+	TimelineFile  string        // Export the timeline to this file
+	TimelineFmt   string        // The format to export the timeline in
 }
 
 func NewDescribeOptions() *DescribeOptions {
@@ -42,6 +68,8 @@ func NewDescribeOptions() *DescribeOptions {
 		IncludeEvents: false,
 		OutputSpec:    false,
 		JSON:          false,
+		Output:        NewOutputOptions(YAMLFormat),
+		TimelineFmt:   string(job.TimelineFormatChromeTrace),
 	}
 }
 
@@ -70,7 +98,26 @@ func newDescribeCmd() *cobra.Command {
 	)
 	describeCmd.PersistentFlags().BoolVar(
 		&OD.JSON, "json", OD.JSON,
-		`Output description as JSON (if not included will be outputted as YAML by default)`,
+		`Output description as JSON (if not included will be outputted as YAML by default). Same as --output json`,
+	)
+	describeCmd.PersistentFlags().AddFlagSet(OutputFormatFlags(&OD.Output))
+	describeCmd.PersistentFlags().AddFlagSet(TableFlags(&OD.Output))
+	describeCmd.PersistentFlags().StringVar(
+		&OD.At, "at", OD.At,
+		`Describe the state the job was in at this point in time (RFC3339 timestamp)`,
+	)
+	describeCmd.PersistentFlags().BoolVar(
+		&OD.Timeline, "timeline", OD.Timeline,
+		`Print a Gantt-style breakdown of the time each execution spent queued, bidding, fetching inputs, running, `+
+			`verifying and publishing, instead of the description`,
+	)
+	describeCmd.PersistentFlags().StringVar(
+		&OD.TimelineFile, "timeline-export", OD.TimelineFile,
+		`Also export the timeline to this file (requires --timeline)`,
+	)
+	describeCmd.PersistentFlags().StringVar(
+		&OD.TimelineFmt, "timeline-format", OD.TimelineFmt,
+		fmt.Sprintf(`The format to export the timeline in, one of %v`, job.TimelineFormats),
 	)
 
 	return describeCmd
@@ -83,7 +130,22 @@ func describe(cmd *cobra.Command, cmdArgs []string, OD *DescribeOptions) error {
 		Fatal(cmd, fmt.Sprintf("Failed to parse flags: %v\n", err), 1)
 	}
 
+	if OD.JSON {
+		OD.Output.Format = JSONFormat
+	}
+	if !validateOutput(cmd, &OD.Output) {
+		return nil
+	}
+
 	var err error
+	var at time.Time
+	if OD.At != "" {
+		at, err = time.Parse(time.RFC3339, OD.At)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Invalid --at timestamp '%s': %s\n", OD.At, err), 1)
+		}
+	}
+
 	inputJobID := cmdArgs[0]
 	if inputJobID == "" {
 		var byteResult []byte
@@ -114,31 +176,136 @@ func describe(cmd *cobra.Command, cmdArgs []string, OD *DescribeOptions) error {
 
 	jobDesc := j
 
+	if !at.IsZero() {
+		if at.Before(j.Job.Metadata.CreatedAt) {
+			Fatal(cmd, fmt.Sprintf("Job '%s' was created at %s, after %s\n",
+				j.Job.Metadata.ID, j.Job.Metadata.CreatedAt.Format(time.RFC3339), OD.At), 1)
+		}
+		jobState, innerErr := GetAPIClient().GetJobStateAt(ctx, j.Job.Metadata.ID, at)
+		if innerErr != nil {
+			Fatal(cmd, fmt.Sprintf("Failure retrieving state of job '%s' at %s: %s\n", j.Job.Metadata.ID, OD.At, innerErr), 1)
+		}
+		jobDesc.State = jobState
+	}
+
+	if OD.Timeline {
+		return describeTimeline(cmd, jobDesc.State, at, OD)
+	}
+	if OD.TimelineFile != "" {
+		Fatal(cmd, "--timeline-export requires --timeline", 1)
+	}
+
 	if OD.IncludeEvents {
 		jobEvents, innerErr := GetAPIClient().GetEvents(ctx, j.Job.Metadata.ID, publicapi.EventFilterOptions{})
 		if innerErr != nil {
 			Fatal(cmd, fmt.Sprintf("Failure retrieving job events '%s': %s\n", j.Job.Metadata.ID, innerErr), 1)
 		}
+		if !at.IsZero() {
+			eventsAt := make([]model.JobHistory, 0, len(jobEvents))
+			for _, event := range jobEvents {
+				if !event.Time.After(at) {
+					eventsAt = append(eventsAt, event)
+				}
+			}
+			jobEvents = eventsAt
+		}
 		jobDesc.History = jobEvents
 	}
 
-	//b, err := model.JSONMarshalIndentWithMax(jobDesc, 3)
-	b, err := json.Marshal(jobDesc)
+	err = printOutput(cmd, OD.Output, jobDesc, func(w io.Writer) error {
+		describeTable(w, jobDesc, OD)
+		return nil
+	})
 	if err != nil {
-		Fatal(cmd, fmt.Sprintf("Failure marshaling job description '%s': %s\n", j.Job.Metadata.ID, err), 1)
+		Fatal(cmd, fmt.Sprintf("Failure printing job description '%s': %s\n", j.Job.Metadata.ID, err), 1)
 	}
 
-	if !OD.JSON {
-		// Convert Json to Yaml
-		y, err := yaml.JSONToYAML(b)
-		if err != nil {
-			Fatal(cmd, fmt.Sprintf("Able to marshal to YAML but not JSON whatttt '%s': %s\n", j.Job.Metadata.ID, err), 1)
+	return nil
+}
+
+// describeTable prints a summary of the job and of the state of its executions.
+func describeTable(w io.Writer, jobDesc *model.JobWithInfo, OD *DescribeOptions) {
+	name := jobDesc.Job.Metadata.Name
+	if name == "" {
+		name = "-"
+	}
+	jobTable := newOutputTable(w, OD.Output, table.StyleLight)
+	jobTable.AppendRows([]table.Row{
+		{"id", jobDesc.Job.Metadata.ID},
+		{"name", name},
+		{"created", shortenTime(OD.Output.Wide, jobDesc.Job.Metadata.CreatedAt)},
+		{"engine", jobDesc.Job.Spec.Engine.String()},
+		{"state", jobDesc.State.State.String()},
+	})
+	jobTable.Render()
+	if len(jobDesc.State.Executions) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	executionsTable := newOutputTable(w, OD.Output, table.StyleLight)
+	if !OD.Output.HideHeader {
+		executionsTable.AppendHeader(table.Row{"node", "state", "updated", "status", "published"})
+	}
+	for _, execution := range jobDesc.State.Executions {
+		published := downloader.ResultIdentifier(execution.PublishedResult)
+		if published == "" {
+			published = "-"
 		}
-		cmd.Print(string(y))
-	} else {
-		// Print as Json
-		cmd.Print(string(b))
+		executionsTable.AppendRow(table.Row{
+			shortID(OD.Output.Wide, execution.NodeID),
+			execution.State.String(),
+			shortenTime(OD.Output.Wide, execution.UpdateTime),
+			shortenString(OD.Output.Wide, execution.Status),
+			published,
+		})
 	}
+	executionsTable.Render()
+}
+
+// describeTimeline prints the timeline of the job computed from its events, as it was at the given time if set, and
+// exports it if requested.
+func describeTimeline(cmd *cobra.Command, jobState model.JobState, at time.Time, OD *DescribeOptions) error {
+	ctx := cmd.Context()
 
+	var format job.TimelineFormat
+	if OD.TimelineFile != "" {
+		var err error
+		if format, err = job.ParseTimelineFormat(OD.TimelineFmt); err != nil {
+			Fatal(cmd, fmt.Sprintf("Invalid --timeline-format: %s\n", err), 1)
+		}
+	}
+
+	jobEvents, err := GetAPIClient().GetEvents(ctx, jobState.JobID, publicapi.EventFilterOptions{})
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure retrieving job events '%s': %s\n", jobState.JobID, err), 1)
+	}
+	now := time.Now()
+	if !at.IsZero() {
+		now = at
+		eventsAt := make([]model.JobHistory, 0, len(jobEvents))
+		for _, event := range jobEvents {
+			if !event.Time.After(at) {
+				eventsAt = append(eventsAt, event)
+			}
+		}
+		jobEvents = eventsAt
+	}
+
+	timeline := job.NewTimeline(jobState, jobEvents, now)
+	if err = timeline.WriteText(cmd.OutOrStdout()); err != nil {
+		return err
+	}
+
+	if OD.TimelineFile != "" {
+		exported, err := timeline.Export(format)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Failure exporting timeline of job '%s': %s\n", jobState.JobID, err), 1)
+		}
+		if err = os.WriteFile(OD.TimelineFile, exported, util.OS_USER_RW); err != nil {
+			Fatal(cmd, fmt.Sprintf("Failure writing timeline to %s: %s\n", OD.TimelineFile, err), 1)
+		}
+		cmd.Printf("\nTimeline written to %s\n", OD.TimelineFile)
+	}
 	return nil
 }
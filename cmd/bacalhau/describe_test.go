@@ -236,6 +236,40 @@ func (s *DescribeSuite) TestDescribeJobEdgeCases() {
 
 }
 
+func (s *DescribeSuite) TestDescribeJobOutputFormat() {
+	ctx := context.Background()
+	submittedJob, err := s.client.Submit(ctx, testutils.MakeNoopJob())
+	s.Require().NoError(err)
+
+	_, out, err := ExecuteTestCobraCommand("describe",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--output", "json",
+		"--include-events",
+		submittedJob.Metadata.ID,
+	)
+	s.Require().NoError(err)
+	description := model.JobWithInfo{}
+	s.Require().NoError(model.JSONUnmarshalWithMax([]byte(out), &description), "the description is JSON")
+	s.Require().Equal(submittedJob.Metadata.ID, description.Job.Metadata.ID)
+	s.Require().NotEmpty(description.History)
+	for i := 1; i < len(description.History); i++ {
+		s.Require().False(description.History[i].Time.Before(description.History[i-1].Time), "events are in order")
+	}
+
+	Fatal = FakeFatalErrorHandler
+	_, out, err = ExecuteTestCobraCommand("describe",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--output", "xml",
+		submittedJob.Metadata.ID,
+	)
+	s.Require().NoError(err)
+	c := &model.TestFatalErrorHandlerContents{}
+	s.Require().NoError(model.JSONUnmarshalWithMax([]byte(out), &c))
+	s.Require().Contains(c.Message, "Unsupported output format")
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestDescribeSuite(t *testing.T) {
@@ -12,6 +12,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/telemetry"
+	"github.com/bacalhau-project/bacalhau/pkg/util/multiaddresses"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
 	"k8s.io/kubectl/pkg/util/i18n"
 
@@ -33,6 +34,9 @@ var (
 
 		# Create a devstack cluster with a single hybrid (requester and compute) nodes
 		bacalhau devstack  --requester-nodes 0 --compute-nodes 0 --hybrid-nodes 1
+
+		# Create a devstack cluster whose nodes each run in their own docker container
+		bacalhau devstack  --containers
 `))
 )
 
@@ -49,6 +53,8 @@ func newDevStackOptions() *devstack.DevStackOptions {
 		SimulatorMode:              false,
 		CPUProfilingFile:           "",
 		MemoryProfilingFile:        "",
+		ContainerMode:              false,
+		ContainerImage:             devstack.DefaultContainerImage,
 	}
 }
 
@@ -112,10 +118,24 @@ func newDevStackCmd() *cobra.Command {
 		&ODs.SimulatorMode, "simulator-mode", false,
 		`If set, one of the nodes will act as a simulator and will proxy all requests to the other nodes`,
 	)
+	devstackCmd.PersistentFlags().StringVar(
+		(*string)(&ODs.NetworkStack), "network-stack", string(ODs.NetworkStack),
+		fmt.Sprintf(`The IP versions nodes listen on, one of %v. `, multiaddresses.NetworkStacks)+
+			`By default libp2p listens on both, and the API and IPFS on IPv4 only.`,
+	)
 	devstackCmd.PersistentFlags().BoolVar(
 		&ODs.PublicIPFSMode, "public-ipfs", ODs.PublicIPFSMode,
 		`Connect devstack to public IPFS`,
 	)
+	devstackCmd.PersistentFlags().BoolVar(
+		&ODs.ContainerMode, "containers", ODs.ContainerMode,
+		"Run each node and its IPFS node in docker containers on a shared docker network, instead of in-process. "+
+			"Nodes run with the default serve configuration, so node configuration flags are ignored.",
+	)
+	devstackCmd.PersistentFlags().StringVar(
+		&ODs.ContainerImage, "container-image", ODs.ContainerImage,
+		"The bacalhau image to run nodes with when using --containers",
+	)
 	devstackCmd.PersistentFlags().StringVar(
 		&ODs.CPUProfilingFile, "cpu-profiling-file", ODs.CPUProfilingFile,
 		"File to save CPU profiling to",
@@ -126,7 +146,9 @@ func newDevStackCmd() *cobra.Command {
 	)
 	devstackCmd.PersistentFlags().StringSliceVar(
 		&ODs.AllowListedLocalPaths, "allow-listed-local-paths", ODs.AllowListedLocalPaths,
-		"Local paths that are allowed to be mounted into jobs",
+		"Local paths that are allowed to be mounted into jobs, in the PATH[:ro|:rw[@CLIENT_ID]] format. "+
+			"Paths are read-only unless suffixed with :rw, and can be restricted to the jobs of a single client by "+
+			"following the access mode with @CLIENT_ID.",
 	)
 	devstackCmd.PersistentFlags().Var(
 		URLFlag(&OS.ExternalVerifierHook, "http"), "external-verifier-http",
@@ -181,14 +203,28 @@ func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOpt
 		}
 	}
 
+	if ODs.NetworkStack != "" {
+		networkStack, err := multiaddresses.ParseNetworkStack(string(ODs.NetworkStack))
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Invalid --network-stack: %s", err), 1)
+		}
+		ODs.NetworkStack = networkStack
+	}
+
 	computeConfig := getComputeConfig(OS)
-	requestorConfig := getRequesterConfig(OS)
+	requestorConfig, err := getRequesterConfig(OS)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Invalid requester config: %s", err), 1)
+	}
 	if ODs.LocalNetworkLotus {
 		cmd.Println("Note that starting up the Lotus node can take many minutes!")
 	}
 
 	var stack *devstack.DevStack
 	var stackErr error
+	if IsNoop && ODs.ContainerMode {
+		Fatal(cmd, "--noop cannot be used with --containers", 1)
+	}
 	if IsNoop {
 		stack, stackErr = devstack.NewNoopDevStack(ctx, cm, *ODs, computeConfig, requestorConfig)
 	} else {
@@ -209,8 +245,13 @@ func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOpt
 		Fatal(cmd, fmt.Sprintf("Error writing out port file to %v", portFileName), 1)
 	}
 	defer os.Remove(portFileName)
-	firstNode := stack.Nodes[0]
-	_, err = f.WriteString(strconv.FormatUint(uint64(firstNode.APIServer.Port), 10))
+	var apiPort uint16
+	if stack.Containers != nil {
+		apiPort = stack.Containers.Nodes[0].APIPort
+	} else {
+		apiPort = stack.Nodes[0].APIServer.Port
+	}
+	_, err = f.WriteString(strconv.FormatUint(uint64(apiPort), 10))
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Error writing out port file: %v", portFileName), 1)
 	}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/cmd/bacalhau/opts"
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
 	jobutils "github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -42,38 +43,62 @@ var (
 
 		# Specify an image digest
 		bacalhau docker run ubuntu@sha256:35b4f89ec2ee42e7e12db3d107fe6a487137650a2af379bbd49165a1494246ea echo hello
+
+		# Mount the local src directory at /inputs/src and run the job again whenever a file in it changes
+		bacalhau docker run --watch ./src python:3.10 -- python /inputs/src/main.py
+
+		# Spool a job while offline, and submit it once connected
+		bacalhau docker run --offline ubuntu:22.04 echo hello
+		bacalhau spool flush
 		`))
 )
 
 // DockerRunOptions declares the arguments accepted by the `docker run` command
 type DockerRunOptions struct {
-	Engine           string            // Executor - executor.Executor
-	Verifier         string            // Verifier - verifier.Verifier
-	Publisher        opts.PublisherOpt // Publisher - publisher.Publisher
-	Inputs           opts.StorageOpt   // Array of inputs
-	OutputVolumes    []string          // Array of output volumes in 'name:mount point' form
-	Env              []string          // Array of environment variables
-	IDOnly           bool              // Only print the job ID
-	Concurrency      int               // Number of concurrent jobs to run
-	Confidence       int               // Minimum number of nodes that must agree on a verification result
-	MinBids          int               // Minimum number of bids before they will be accepted (at random)
-	Timeout          float64           // Job execution timeout in seconds
+	Engine           string                   // Executor - executor.Executor
+	Verifier         string                   // Verifier - verifier.Verifier
+	Publisher        opts.PublisherOpt        // Publisher - publisher.Publisher
+	Inputs           opts.StorageOpt          // Array of inputs
+	OutputVolumes    []string                 // Array of output volumes in 'name:mount point' form
+	OutputPublishers opts.OutputPublishersOpt // Publishers of output volumes published on their own
+	OutputContracts  opts.OutputContractOpt   // Files the job is expected to produce
+	Env              []string                 // Array of environment variables
+	IDOnly           bool                     // Only print the job ID
+	Concurrency      int                      // Number of concurrent jobs to run
+	Adaptive         bool                     // Only run more than one execution when the results need it
+	Confidence       int                      // Minimum number of nodes that must agree on a verification result
+	MinBids          int                      // Minimum number of bids before they will be accepted (at random)
+	Timeout          float64                  // Job execution timeout in seconds
+	Priority         int                      // Priority of the job on compute nodes that allow preemption
+	ResultSizeLimit  string                   // Largest result the job may publish
+	Reservation      string                   // Token of the capacity reservation the job runs on
+	Interactive      bool                     // Keep stdin open for 'bacalhau attach'
+	Watch            []string                 // Local paths in 'path[:mount]' form uploaded as inputs, re-running the job on change
 	CPU              string
 	Memory           string
 	GPU              string
+	CUDAVersion      string // Minimum CUDA version required by the image
 	Networking       model.Network
 	NetworkDomains   []string
 	WorkingDirectory string   // Working directory for docker
 	Labels           []string // Labels for the job on the Bacalhau network (for searching)
 	NodeSelector     string   // Selector (label query) to filter nodes on which this job can be executed
+	Residency        []string // Jurisdictions the job and its data must stay in
+	// EngineRequirements are the version and features the docker engine must have
+	EngineRequirements model.EngineRequirements
 
 	Image      string   // Image to execute
 	Entrypoint []string // Entrypoint to the docker image
 
+	VerificationImage   string // Image of the validation job run by the command verifier
+	VerificationCommand string // Shell command of the validation job run by the command verifier
+
 	SkipSyntaxChecking bool // Verify the syntax using shellcheck
 
 	DryRun bool // Don't submit the jobspec, print it to STDOUT
 
+	Offline bool // Write the job to the spool to be submitted later, instead of submitting it
+
 	RunTimeSettings RunTimeSettings // Settings for running the job
 
 	DownloadFlags model.DownloaderSettings // Settings for running Download
@@ -144,6 +169,15 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.Verifier, "verifier", ODR.Verifier,
 		`What verification engine to use to run the job`,
 	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.VerificationImage, "verification-image", ODR.VerificationImage,
+		`Image of a validation job to run against the results of each execution (implies --verifier command). `+
+			`The results are mounted at `+model.VerifierCommandResultsPath+` and accepted if the job exits with code 0.`,
+	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.VerificationCommand, "verification-command", ODR.VerificationCommand,
+		`Shell command the validation job runs (e.g. "test $(wc -l < /inputs/outputs/data.csv) -gt 1")`,
+	)
 	dockerRunCmd.PersistentFlags().VarP(&ODR.Publisher, "publisher", "p",
 		`Where to publish the result of the job`,
 	)
@@ -153,6 +187,11 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.OutputVolumes, "output-volumes", "o", ODR.OutputVolumes,
 		`name:path of the output data volumes. 'outputs:/outputs' is always added.`,
 	)
+	dockerRunCmd.PersistentFlags().Var(&ODR.OutputPublishers, "output-publisher",
+		`name=publisher to publish an output volume on its own rather than with the rest of the results `+
+			`(e.g. --output-publisher model=s3://bucket/model/). Takes the same values as --publisher.`,
+	)
+	dockerRunCmd.PersistentFlags().Var(&ODR.OutputContracts, "output-contract", outputContractUsageMsg)
 	dockerRunCmd.PersistentFlags().StringSliceVarP(
 		&ODR.Env, "env", "e", ODR.Env,
 		`The environment variables to supply to the job (e.g. --env FOO=bar --env BAR=baz)`,
@@ -161,6 +200,11 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.Concurrency, "concurrency", "c", ODR.Concurrency,
 		`How many nodes should run the job`,
 	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Adaptive, "adaptive-concurrency", ODR.Adaptive,
+		`Start with a single execution, and only run more of them, up to the concurrency, when the verifier needs more `+
+			`results or the node that ran the job is not trusted yet`,
+	)
 	dockerRunCmd.PersistentFlags().IntVar(
 		&ODR.Confidence, "confidence", ODR.Confidence,
 		`The minimum number of nodes that must agree on a verification result`,
@@ -173,6 +217,35 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.Timeout, "timeout", ODR.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
 	)
+	dockerRunCmd.PersistentFlags().IntVar(
+		&ODR.Priority, "priority", ODR.Priority,
+		`Job priority. Compute nodes that allow preemption stop running jobs of lower priority to make room for the job`,
+	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.ResultSizeLimit, "result-size-limit", ODR.ResultSizeLimit,
+		`Largest result the job may publish (e.g. 500Mb, 10Gb). Larger results are rejected. Unlimited if not set.`,
+	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.Reservation, "reservation", ODR.Reservation,
+		`Token of a capacity reservation made with 'bacalhau node reserve', to run the job on the reserved capacity`,
+	)
+	dockerRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.Residency, "residency", ODR.Residency,
+		`Jurisdictions the job and its data must stay in (e.g. eu). Only nodes labelled with one of them run the job`,
+	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.EngineRequirements.MinVersion, "engine-min-version", ODR.EngineRequirements.MinVersion,
+		`Oldest version of docker that can run the job. Nodes with an older docker are not selected`,
+	)
+	dockerRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.EngineRequirements.Features, "engine-feature", ODR.EngineRequirements.Features,
+		`Feature docker must support (e.g. nvidia-runtime). Nodes whose docker lacks it are not selected`,
+	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Interactive, "interactive", ODR.Interactive,
+		`Keep the stdin of the job open, to stream input to it with 'bacalhau attach' while it runs. `+
+			`Only compute nodes that accept interactive jobs run it`,
+	)
 	dockerRunCmd.PersistentFlags().StringVar(
 		&ODR.CPU, "cpu", ODR.CPU,
 		`Job CPU cores (e.g. 500m, 2, 8).`,
@@ -185,6 +258,10 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.GPU, "gpu", ODR.GPU,
 		`Job GPU requirement (e.g. 1, 2, 8).`,
 	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.CUDAVersion, "cuda-version", ODR.CUDAVersion,
+		`Minimum CUDA version the image requires (e.g. 11.8). Nodes whose NVIDIA driver cannot support it will not bid.`,
+	)
 	dockerRunCmd.PersistentFlags().Var(
 		NetworkFlag(&ODR.Networking), "network",
 		`Networking capability required by the job`,
@@ -193,6 +270,11 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.NetworkDomains, "domain", ODR.NetworkDomains,
 		`Domain(s) that the job needs to access (for HTTP networking)`,
 	)
+	dockerRunCmd.PersistentFlags().StringArrayVar(
+		&ODR.Watch, "watch", ODR.Watch,
+		`Local file or directory to upload and mount, in 'path[:mount]' form (default mount /inputs/<name>). `+
+			`The job is run again, and the previous run canceled, whenever the files change`,
+	)
 	dockerRunCmd.PersistentFlags().BoolVar(
 		&ODR.SkipSyntaxChecking, "skip-syntax-checking", ODR.SkipSyntaxChecking,
 		`Skip having 'shellchecker' verify syntax of the command`,
@@ -202,6 +284,10 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.DryRun, "dry-run", ODR.DryRun,
 		`Do not submit the job, but instead print out what will be submitted`,
 	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Offline, "offline", ODR.Offline,
+		`Do not submit the job, but write it to the spool to be submitted by 'bacalhau spool flush' once connected`,
+	)
 
 	dockerRunCmd.PersistentFlags().StringVarP(
 		&ODR.WorkingDirectory, "workdir", "w", ODR.WorkingDirectory,
@@ -258,6 +344,17 @@ func dockerRun(cmd *cobra.Command, cmdArgs []string, ODR *DockerRunOptions) erro
 		}
 	}
 
+	if len(ODR.Watch) > 0 {
+		if ODR.DryRun || ODR.Offline || ODR.RunTimeSettings.IsLocal {
+			Fatal(cmd, "--watch cannot be used with --dry-run, --offline or --local", 1)
+			return nil
+		}
+		if err = watchJob(ctx, cmd, cmdArgs, ODR); err != nil {
+			Fatal(cmd, fmt.Sprintf("Error watching job: %s", err), 1)
+		}
+		return nil
+	}
+
 	if ODR.DryRun {
 		// Converting job to yaml
 		var yamlBytes []byte
@@ -270,6 +367,14 @@ func dockerRun(cmd *cobra.Command, cmdArgs []string, ODR *DockerRunOptions) erro
 		return nil
 	}
 
+	if ODR.Offline {
+		if ODR.RunTimeSettings.IsLocal {
+			Fatal(cmd, "Jobs run with --local cannot be spooled with --offline", 1)
+			return nil
+		}
+		return spoolJob(cmd, j, quiet)
+	}
+
 	return ExecuteJob(ctx,
 		cm,
 		cmd,
@@ -291,9 +396,15 @@ func CreateJob(ctx context.Context, cmdArgs []string, odr *DockerRunOptions) (*m
 	}
 
 	odr.DownloadFlags = model.DownloaderSettings{
-		Timeout:        odr.DownloadFlags.Timeout,
-		OutputDir:      odr.DownloadFlags.OutputDir,
-		IPFSSwarmAddrs: swarmAddresses,
+		Timeout:                 odr.DownloadFlags.Timeout,
+		OutputDir:               odr.DownloadFlags.OutputDir,
+		IPFSSwarmAddrs:          swarmAddresses,
+		IPFSGateways:            odr.DownloadFlags.IPFSGateways,
+		GatewayFallbackTimeout:  odr.DownloadFlags.GatewayFallbackTimeout,
+		Retries:                 odr.DownloadFlags.Retries,
+		MaxDownloadSize:         odr.DownloadFlags.MaxDownloadSize,
+		LocalResultsDirectories: odr.DownloadFlags.LocalResultsDirectories,
+		Dedupe:                  odr.DownloadFlags.Dedupe,
 	}
 
 	engineType, err := model.ParseEngine(odr.Engine)
@@ -305,6 +416,9 @@ func CreateJob(ctx context.Context, cmdArgs []string, odr *DockerRunOptions) (*m
 	if err != nil {
 		return &model.Job{}, err
 	}
+	if odr.VerificationImage != "" {
+		verifierType = model.VerifierCommand
+	}
 
 	if len(odr.WorkingDirectory) > 0 {
 		err = system.ValidateWorkingDir(odr.WorkingDirectory)
@@ -347,6 +461,34 @@ func CreateJob(ctx context.Context, cmdArgs []string, odr *DockerRunOptions) (*m
 	if err != nil {
 		return &model.Job{}, errors.Wrap(err, "CreateJobSpecAndDeal")
 	}
+	j.Spec.Docker.CUDAVersion = odr.CUDAVersion
+	j.Spec.Priority = odr.Priority
+	j.Spec.Reservation = odr.Reservation
+	j.Spec.Residency = odr.Residency
+	if !odr.EngineRequirements.IsEmpty() {
+		requirements := odr.EngineRequirements
+		j.Spec.EngineRequirements = &requirements
+	}
+	j.Spec.Interactive = odr.Interactive
+	j.Spec.Deal.Adaptive = odr.Adaptive
+	if j.Spec.ResultSizeLimit, err = capacity.ParseBytesString(odr.ResultSizeLimit); err != nil {
+		return &model.Job{}, errors.Wrapf(err, "invalid result size limit %q", odr.ResultSizeLimit)
+	}
+
+	if err = jobutils.SetOutputPublishers(j, odr.OutputPublishers.Values()); err != nil {
+		return &model.Job{}, err
+	}
+	j.Spec.OutputContracts = odr.OutputContracts.Values()
+
+	if verifierType == model.VerifierCommand {
+		j.Spec.VerifierCommand = &model.VerifierCommandSpec{
+			Engine: model.EngineDocker,
+			Docker: model.JobSpecDocker{Image: odr.VerificationImage},
+		}
+		if odr.VerificationCommand != "" {
+			j.Spec.VerifierCommand.Docker.Entrypoint = []string{"sh", "-c", odr.VerificationCommand}
+		}
+	}
 
 	return j, nil
 }
@@ -944,3 +944,27 @@ func (s *DockerRunSuite) TestRun_Timeout_DefinedValue() {
 
 	s.Require().Equal(j.Spec.Timeout, expectedTimeout)
 }
+
+func (s *DockerRunSuite) TestRun_ResultSizeLimit() {
+	ctx := context.Background()
+	_, out, err := ExecuteTestCobraCommand("docker", "run",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--result-size-limit", "10Mb",
+		"ubuntu",
+		"echo", "'hello world'",
+	)
+	s.Require().NoError(err, "Error submitting job with a result size limit")
+
+	j := testutils.GetJobFromTestOutput(ctx, s.T(), s.client, out)
+	s.Require().Equal(uint64(10*datasize.MB), j.Spec.ResultSizeLimit)
+
+	_, _, err = ExecuteTestCobraCommand("docker", "run",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--result-size-limit", "lots",
+		"ubuntu",
+		"echo", "'hello world'",
+	)
+	s.Require().Error(err, "Submitted a job with an invalid result size limit")
+}
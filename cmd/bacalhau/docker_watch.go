@@ -0,0 +1,338 @@
+package bacalhau
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// watchDebounce is how long watch mode waits for files to stop changing before running the job again, so that
+	// saving many files at once runs the job once.
+	watchDebounce = 500 * time.Millisecond
+
+	// watchInputsDir is where watched paths are mounted when no mount point is given.
+	watchInputsDir = "/inputs"
+)
+
+// watchedPath is a local file or directory mounted in the job at Mount, in 'path[:mount]' form on the command line.
+type watchedPath struct {
+	Local string
+	Mount string
+}
+
+func parseWatchedPath(value string) (watchedPath, error) {
+	local, mount := value, ""
+	// the mount point is absolute, which tells it apart from colons in the local path
+	if i := strings.LastIndex(value, ":/"); i > 0 {
+		local, mount = value[:i], value[i+1:]
+	}
+	if local == "" {
+		return watchedPath{}, fmt.Errorf("no local path to watch in %q", value)
+	}
+	if mount == "" {
+		mount = path.Join(watchInputsDir, filepath.Base(filepath.Clean(local)))
+	}
+	return watchedPath{Local: local, Mount: path.Clean(mount)}, nil
+}
+
+// fileUploader stages local files on the requester node, which RequesterAPIClient does.
+type fileUploader interface {
+	Upload(ctx context.Context, data io.ReaderAt, size int64) (model.StorageSpec, error)
+}
+
+// watchUploader uploads the files of watched paths. Uploads are content-addressed, so files that did not change
+// since they were last uploaded are not uploaded again.
+type watchUploader struct {
+	client fileUploader
+	// uploaded maps the sha256 of the files uploaded so far to where they were staged
+	uploaded map[string]model.StorageSpec
+}
+
+func newWatchUploader(client fileUploader) *watchUploader {
+	return &watchUploader{
+		client:   client,
+		uploaded: make(map[string]model.StorageSpec),
+	}
+}
+
+// inputs returns the storage specs mounting every file of the watched paths, uploading the ones that changed. Files
+// and directories whose name starts with a dot, like .git or editor swap files, are skipped.
+func (u *watchUploader) inputs(ctx context.Context, paths []watchedPath) ([]model.StorageSpec, int, error) {
+	var inputs []model.StorageSpec
+	uploads := 0
+	for _, watched := range paths {
+		root := filepath.Clean(watched.Local)
+		err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if file != root && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			spec, uploaded, err := u.upload(ctx, file)
+			if err != nil {
+				return fmt.Errorf("failed to upload %s: %w", file, err)
+			}
+			if uploaded {
+				uploads++
+			}
+			spec.Path = path.Join(watched.Mount, filepath.ToSlash(rel))
+			inputs = append(inputs, spec)
+			return nil
+		})
+		if err != nil {
+			return nil, uploads, err
+		}
+	}
+	return inputs, uploads, nil
+}
+
+// upload uploads the file unless a file with the same content was uploaded before, and returns true if it did.
+func (u *watchUploader) upload(ctx context.Context, file string) (model.StorageSpec, bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return model.StorageSpec{}, false, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return model.StorageSpec{}, false, err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if spec, ok := u.uploaded[sum]; ok {
+		return spec, false, nil
+	}
+
+	spec, err := u.client.Upload(ctx, f, size)
+	if err != nil {
+		return model.StorageSpec{}, false, err
+	}
+	u.uploaded[sum] = spec
+	return spec, true, nil
+}
+
+// sameInputs returns true if the inputs mount the same content at the same paths.
+func sameInputs(a, b []model.StorageSpec) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].CID != b[i].CID || a[i].URL != b[i].URL || a[i].Path != b[i].Path {
+			return false
+		}
+	}
+	return true
+}
+
+// watchJob runs the job, then runs it again with the watched files re-uploaded whenever they change. The run that
+// is superseded is canceled, and the logs of the current run are streamed until the command is interrupted, which
+// leaves the current run going.
+func watchJob(ctx context.Context, cmd *cobra.Command, cmdArgs []string, odr *DockerRunOptions) error {
+	paths := make([]watchedPath, 0, len(odr.Watch))
+	for _, value := range odr.Watch {
+		watched, err := parseWatchedPath(value)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, watched)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch files: %w", err)
+	}
+	defer watcher.Close()
+	for _, watched := range paths {
+		if err = watchTree(watcher, watched.Local); err != nil {
+			return err
+		}
+	}
+
+	apiClient := GetAPIClient()
+	uploader := newWatchUploader(apiClient)
+	var current *model.Job
+	var currentInputs []model.StorageSpec
+	stopLogs := func() {}
+	defer func() { stopLogs() }()
+
+	run := func() error {
+		inputs, uploads, err := uploader.inputs(ctx, paths)
+		if err != nil {
+			return err
+		}
+		if current != nil && sameInputs(inputs, currentInputs) {
+			log.Ctx(ctx).Debug().Msg("watched files changed without changing their content")
+			return nil
+		}
+
+		j, err := CreateJob(ctx, cmdArgs, odr)
+		if err != nil {
+			return err
+		}
+		j.Spec.Inputs = append(j.Spec.Inputs, inputs...)
+		j, err = submitJob(ctx, apiClient, j)
+		if err != nil {
+			return err
+		}
+		cmd.PrintErrf("Uploaded %d changed file(s) and submitted job %s\n", uploads, j.Metadata.ID)
+
+		stopLogs()
+		if current != nil {
+			if _, err = apiClient.Cancel(ctx, current.Metadata.ID, "superseded by job "+j.Metadata.ID); err != nil {
+				cmd.PrintErrf("Failed to cancel superseded job %s: %s\n", current.Metadata.ID, err)
+			}
+		}
+		current, currentInputs = j, inputs
+
+		logsCtx, cancelLogs := context.WithCancel(ctx)
+		stopLogs = cancelLogs
+		go streamJobLogs(logsCtx, cmd, apiClient, j.Metadata.ID)
+		return nil
+	}
+
+	if err = run(); err != nil {
+		return err
+	}
+	cmd.PrintErrf("Watching %s for changes (Enter Ctrl+C to exit at any time, your job will continue running)\n",
+		strings.Join(odr.Watch, ", "))
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if current != nil {
+				cmd.PrintErrf("\nStopped watching, job %s continues running\n", current.Metadata.ID)
+			}
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				// directories created in watched directories are watched too
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+					_ = watchTree(watcher, event.Name)
+				}
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			cmd.PrintErrf("Error watching files: %s\n", err)
+		case <-debounce.C:
+			if err = run(); err != nil {
+				cmd.PrintErrf("Failed to run the job again: %s\n", err)
+			}
+		}
+	}
+}
+
+// watchTree watches the file, or the directory and the directories in it, since watches are not recursive.
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != root && entry.IsDir() && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if file == root || entry.IsDir() {
+			if err = watcher.Add(file); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", file, err)
+			}
+		}
+		return nil
+	})
+}
+
+// streamJobLogs waits for the job to start executing, and writes the logs of the execution until the context is
+// canceled or the execution ends.
+func streamJobLogs(ctx context.Context, cmd *cobra.Command, apiClient *publicapi.RequesterAPIClient, jobID string) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	executionID := ""
+	for executionID == "" {
+		state, err := apiClient.GetJobState(ctx, jobID)
+		if err == nil {
+			if state.State.IsTerminal() {
+				cmd.PrintErrf("Job %s ended as %s before its logs could be streamed\n", jobID, state.State)
+				return
+			}
+			for _, execution := range state.Executions {
+				if execution.State.IsActive() {
+					executionID = execution.ComputeReference
+				}
+			}
+		}
+		if executionID != "" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	conn, err := apiClient.Logs(ctx, jobID, executionID, true, true, 0)
+	if err != nil {
+		if ctx.Err() == nil {
+			cmd.PrintErrf("Failed to stream logs of job %s: %s\n", jobID, err)
+		}
+		return
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_ = conn.Close()
+	}()
+
+	for {
+		var msg Msg
+		if err = conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() == nil && websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
+				cmd.PrintErrf("Failed to read logs of job %s: %s\n", jobID, err)
+			}
+			return
+		}
+		out := cmd.OutOrStdout()
+		if msg.Tag == 2 {
+			out = cmd.ErrOrStderr()
+		}
+		_, _ = io.WriteString(out, msg.Data)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWatchedPath(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  watchedPath
+	}{
+		{value: "./src", want: watchedPath{Local: "./src", Mount: "/inputs/src"}},
+		{value: "./src/", want: watchedPath{Local: "./src/", Mount: "/inputs/src"}},
+		{value: "main.py:/app/main.py", want: watchedPath{Local: "main.py", Mount: "/app/main.py"}},
+		{value: "dir:with:colons:/data/", want: watchedPath{Local: "dir:with:colons", Mount: "/data"}},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseWatchedPath(tc.value)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := parseWatchedPath("")
+	require.Error(t, err)
+}
+
+type fakeFileUploader struct {
+	uploads int
+}
+
+func (f *fakeFileUploader) Upload(_ context.Context, data io.ReaderAt, size int64) (model.StorageSpec, error) {
+	f.uploads++
+	content, err := io.ReadAll(io.NewSectionReader(data, 0, size))
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	sum := sha256.Sum256(content)
+	return model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: hex.EncodeToString(sum[:])}, nil
+}
+
+func TestWatchUploader(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("main.py", "print('hello')")
+	write("lib/util.py", "x = 1")
+	write(".git/HEAD", "ref: refs/heads/main")
+
+	client := &fakeFileUploader{}
+	uploader := newWatchUploader(client)
+	paths := []watchedPath{{Local: dir, Mount: "/inputs/src"}}
+
+	first, uploads, err := uploader.inputs(ctx, paths)
+	require.NoError(t, err)
+	require.Equal(t, 2, uploads)
+	require.Len(t, first, 2)
+	require.Equal(t, "/inputs/src/lib/util.py", first[0].Path)
+	require.Equal(t, "/inputs/src/main.py", first[1].Path)
+
+	// unchanged files are not uploaded again
+	second, uploads, err := uploader.inputs(ctx, paths)
+	require.NoError(t, err)
+	require.Zero(t, uploads)
+	require.True(t, sameInputs(first, second))
+
+	write("main.py", "print('hello again')")
+	third, uploads, err := uploader.inputs(ctx, paths)
+	require.NoError(t, err)
+	require.Equal(t, 1, uploads)
+	require.False(t, sameInputs(second, third))
+	require.Equal(t, first[0], third[0])
+	require.Equal(t, 3, client.uploads)
+}
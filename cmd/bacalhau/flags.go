@@ -5,11 +5,13 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/url/urldownload"
+	"github.com/c2h5oh/datasize"
 	"github.com/spf13/pflag"
 	"golang.org/x/exp/slices"
 )
@@ -317,6 +319,18 @@ func URLFlag(value **url.URL, schemes ...string) *ValueFlag[*url.URL] {
 	}
 }
 
+func ByteSizeFlag(value *datasize.ByteSize) *ValueFlag[datasize.ByteSize] {
+	return &ValueFlag[datasize.ByteSize]{
+		value: value,
+		parser: func(s string) (datasize.ByteSize, error) {
+			size, err := capacity.ParseBytesString(s)
+			return datasize.ByteSize(size), err
+		},
+		stringer: func(b *datasize.ByteSize) string { return b.HR() },
+		typeStr:  "size",
+	}
+}
+
 func parseTag(s string) (string, error) {
 	var err error
 	if !job.IsSafeAnnotation(s) {
@@ -364,6 +378,10 @@ func JobSelectionCLIFlags(policy *model.JobSelectionPolicy) *pflag.FlagSet {
 		&policy.AcceptNetworkedJobs, "job-selection-accept-networked", policy.AcceptNetworkedJobs,
 		`Accept jobs that require network access.`,
 	)
+	flags.BoolVar(
+		&policy.AcceptInteractiveJobs, "job-selection-accept-interactive", policy.AcceptInteractiveJobs,
+		`Accept interactive jobs, whose clients can stream input to them while they run. Only meant for trusted clusters.`,
+	)
 	flags.StringVar(
 		&policy.ProbeHTTP, "job-selection-probe-http", policy.ProbeHTTP,
 		`Use the result of a HTTP POST to decide if we should take on the job.`,
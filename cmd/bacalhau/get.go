@@ -1,13 +1,20 @@
 package bacalhau
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/i18n"
@@ -16,6 +23,19 @@ import (
 var (
 	getLong = templates.LongDesc(i18n.T(`
 		Get the results of the job, including stdout and stderr.
+
+		Each node that ran the job publishes its own results, which are merged when downloaded. Use --node to get
+		the results of a single node instead, and --list to see where the results of each node are published
+		without downloading them.
+
+		Give several job IDs, or a file listing them one per line with --jobs-file, to get the results of many jobs at
+		once. The results of each job are downloaded concurrently into a job-<id> directory of the output directory,
+		and a summary of the downloads is printed once they have all finished. The command fails if the results of any
+		of the jobs could not be downloaded.
+
+		Use --include and --exclude to download only the files of the results whose path matches glob patterns,
+		such as outputs/*.csv. Stdout and stderr are selected by the same patterns. Files of results published to IPFS
+		are then fetched on their own, rather than fetching the whole of the results.
 `))
 
 	//nolint:lll // Documentation
@@ -25,35 +45,93 @@ var (
 
 		# Get the results of a job, with a short ID.
 		bacalhau get ebd9bf2f
+
+		# List the results published by each node, without downloading them.
+		bacalhau get ebd9bf2f --list
+
+		# Get only the results published by one node, with a prefix of its ID.
+		bacalhau get ebd9bf2f --node QmXaXu9N
+
+		# Print a single file from the results of one node.
+		bacalhau get ebd9bf2f/outputs/summary.csv --node QmXaXu9N --stdout
+
+		# Get the results of a job, printing where they were written as JSON.
+		bacalhau get ebd9bf2f --output json
+
+		# Get only the CSV files of the outputs of a job.
+		bacalhau get ebd9bf2f --include 'outputs/*.csv'
+
+		# Get the results of a job, without its logs.
+		bacalhau get ebd9bf2f --exclude stdout --exclude stderr
+
+		# Get the results of several jobs into ./sweep/job-<id> directories.
+		bacalhau get ebd9bf2f 51225160 --output-dir ./sweep
+
+		# Get the results of the jobs listed in ids.txt, 8 at a time.
+		bacalhau get --jobs-file ids.txt --concurrency 8
 `))
 )
 
 type GetOptions struct {
 	IPFSDownloadSettings *model.DownloaderSettings
+	List                 bool          // List the published results instead of downloading them
+	Stdout               bool          // Write a single file of the results to stdout
+	Output               OutputOptions // How the downloaded or listed results are printed
+	JobsFile             string        // File listing the jobs to get the results of, one per line
+	Concurrency          int           // Number of jobs whose results are downloaded at once
 }
 
 func NewGetOptions() *GetOptions {
 	return &GetOptions{
 		IPFSDownloadSettings: util.NewDownloadSettings(),
+		Output:               NewOutputOptions(TableFormat),
+		Concurrency:          DefaultGetConcurrency,
 	}
 }
 
+// GetResult is what get prints once the results of a job have been downloaded.
+type GetResult struct {
+	JobID     string `json:"JobID"`
+	OutputDir string `json:"OutputDir"`
+}
+
 func newGetCmd() *cobra.Command {
 	OG := NewGetOptions()
 
 	getCmd := &cobra.Command{
-		Use:     "get [id]",
+		Use:     "get [id]...",
 		Short:   "Get the results of a job",
 		Long:    getLong,
 		Example: getExample,
-		Args:    cobra.ExactArgs(1),
-		PreRun:  applyPorcelainLogLevel,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if OG.JobsFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		PreRun: applyPorcelainLogLevel,
 		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
 			return get(cmd, cmdArgs, OG)
 		},
 	}
 
 	getCmd.PersistentFlags().AddFlagSet(NewIPFSDownloadFlags(OG.IPFSDownloadSettings))
+	getCmd.PersistentFlags().StringVar(&OG.IPFSDownloadSettings.NodeID, "node", OG.IPFSDownloadSettings.NodeID,
+		"Only get the results published by this node, given its ID or a prefix of it.")
+	getCmd.PersistentFlags().BoolVar(&OG.List, "list", OG.List,
+		"List the results published by each node and where they are stored, without downloading them.")
+	getCmd.PersistentFlags().BoolVar(&OG.Stdout, "stdout", OG.Stdout,
+		"Write a single file of the results to stdout instead of the output directory, as in JOB_ID/outputs/file.")
+	getCmd.PersistentFlags().StringVar(&OG.JobsFile, "jobs-file", OG.JobsFile,
+		"File listing the IDs of the jobs to get the results of, one per line, or - to read them from stdin.")
+	getCmd.PersistentFlags().StringArrayVar(&OG.IPFSDownloadSettings.Include, "include", OG.IPFSDownloadSettings.Include,
+		"Only download the files of the results whose path matches this glob pattern, as in outputs/*.csv, "+
+			"including stdout and stderr. A pattern matching a folder selects everything within it. Can be repeated.")
+	getCmd.PersistentFlags().StringArrayVar(&OG.IPFSDownloadSettings.Exclude, "exclude", OG.IPFSDownloadSettings.Exclude,
+		"Do not download the files of the results whose path matches this glob pattern. Can be repeated.")
+	getCmd.PersistentFlags().IntVar(&OG.Concurrency, "concurrency", OG.Concurrency,
+		"The number of jobs whose results are downloaded at once when getting the results of several jobs.")
+	getCmd.PersistentFlags().AddFlagSet(OutputFormatFlags(&OG.Output))
 
 	return getCmd
 }
@@ -62,10 +140,42 @@ func get(cmd *cobra.Command, cmdArgs []string, OG *GetOptions) error {
 	ctx := cmd.Context()
 
 	cm := cmd.Context().Value(systemManagerKey).(*system.CleanupManager)
+	if !validateOutput(cmd, &OG.Output) {
+		return nil
+	}
 
 	var err error
+	if _, err = downloader.NewPathFilter(OG.IPFSDownloadSettings.Include, OG.IPFSDownloadSettings.Exclude); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error parsing --include or --exclude: %s", err), 1)
+		return nil
+	}
+
+	jobIDs := cmdArgs
+	if OG.JobsFile != "" {
+		var fileJobIDs []string
+		fileJobIDs, err = readJobIDs(cmd, OG.JobsFile)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error reading jobs file: %s", err), 1)
+			return nil
+		}
+		jobIDs = append(jobIDs, fileJobIDs...)
+		if len(jobIDs) == 0 {
+			Fatal(cmd, fmt.Sprintf("No job IDs found in %s", OG.JobsFile), 1)
+			return nil
+		}
+	}
+	if len(jobIDs) > 1 || OG.JobsFile != "" {
+		if OG.List || OG.Stdout {
+			Fatal(cmd, "--list and --stdout get the results of a single job", 1)
+			return nil
+		}
+		if err = downloadBatchResults(ctx, cm, cmd, jobIDs, OG); err != nil {
+			Fatal(cmd, err.Error(), 1)
+		}
+		return nil
+	}
 
-	jobID := cmdArgs[0]
+	jobID := jobIDs[0]
 	if jobID == "" {
 		var byteResult []byte
 		byteResult, err = ReadFromStdinIfAvailable(cmd, cmdArgs)
@@ -81,15 +191,20 @@ func get(cmd *cobra.Command, cmdArgs []string, OG *GetOptions) error {
 	parts := strings.SplitN(jobID, "/", 2)
 	if len(parts) == 2 {
 		jobID, OG.IPFSDownloadSettings.SingleFile = parts[0], parts[1]
+		if len(OG.IPFSDownloadSettings.Include) > 0 || len(OG.IPFSDownloadSettings.Exclude) > 0 {
+			Fatal(cmd, "--include and --exclude can't be used when getting a single file", 1)
+			return nil
+		}
 	}
 
-	err = downloadResultsHandler(
-		ctx,
-		cm,
-		cmd,
-		jobID,
-		*OG.IPFSDownloadSettings,
-	)
+	switch {
+	case OG.List:
+		err = listResults(cmd, jobID, OG)
+	case OG.Stdout:
+		err = streamResultFile(cmd, cm, jobID, *OG.IPFSDownloadSettings)
+	default:
+		err = downloadResults(ctx, cm, cmd, jobID, OG)
+	}
 
 	if err != nil {
 		return errors.Wrap(err, "error downloading job")
@@ -97,3 +212,73 @@ func get(cmd *cobra.Command, cmdArgs []string, OG *GetOptions) error {
 
 	return nil
 }
+
+// downloadResults downloads the results of the job, and prints where they were written.
+func downloadResults(ctx context.Context, cm *system.CleanupManager, cmd *cobra.Command, jobID string, OG *GetOptions) error {
+	outputDir, err := downloadJobResults(ctx, cm, cmd, jobID, *OG.IPFSDownloadSettings)
+	if err != nil || outputDir == "" {
+		return err
+	}
+
+	return printOutput(cmd, OG.Output, GetResult{JobID: jobID, OutputDir: outputDir}, func(w io.Writer) error {
+		fmt.Fprintf(w, "Results for job '%s' have been written to...\n", jobID)
+		fmt.Fprintf(w, "%s\n", outputDir)
+		return nil
+	})
+}
+
+// listResults prints where each published result of the job is stored.
+func listResults(cmd *cobra.Command, jobID string, OG *GetOptions) error {
+	_, results, err := fetchJobResults(cmd.Context(), cmd, jobID, OG.IPFSDownloadSettings.NodeID)
+	if err != nil {
+		return err
+	}
+
+	return printOutput(cmd, OG.Output, results, func(w io.Writer) error {
+		tw := newOutputTable(w, OG.Output, table.StyleLight)
+		tw.AppendHeader(table.Row{"node", "volume", "source", "result", "expires"})
+		for _, result := range results {
+			volume := result.Volume
+			if volume == "" {
+				volume = "-"
+			}
+			expires := "-"
+			if result.Data.ExpiresAt != nil {
+				expires = result.Data.ExpiresAt.Format(time.RFC3339)
+			}
+			tw.AppendRow(table.Row{
+				result.NodeID, volume, result.Data.StorageSource, downloader.ResultIdentifier(result.Data), expires,
+			})
+		}
+		tw.Render()
+		return nil
+	})
+}
+
+// streamResultFile downloads a single file of the results to a temporary directory, and copies it to stdout.
+func streamResultFile(cmd *cobra.Command, cm *system.CleanupManager, jobID string, settings model.DownloaderSettings) error {
+	if settings.SingleFile == "" {
+		return fmt.Errorf("--stdout needs a single file to write, as in %s/outputs/file", jobID)
+	}
+
+	dir, err := os.MkdirTemp("", "bacalhau-get-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	settings.OutputDir = dir
+	settings.Raw = false
+	outputDir, err := downloadJobResults(cmd.Context(), cm, cmd, jobID, settings)
+	if err != nil || outputDir == "" {
+		return err
+	}
+
+	file, err := os.Open(filepath.Join(outputDir, settings.SingleFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(cmd.OutOrStdout(), file)
+	return err
+}
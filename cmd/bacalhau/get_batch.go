@@ -0,0 +1,134 @@
+package bacalhau
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultGetConcurrency is the number of jobs whose results are downloaded at once when getting several jobs.
+const DefaultGetConcurrency = 4
+
+// BatchGetResult is the outcome of downloading the results of one of the jobs of a batch.
+type BatchGetResult struct {
+	JobID     string `json:"JobID"`
+	OutputDir string `json:"OutputDir,omitempty"`
+	Error     string `json:"Error,omitempty"`
+}
+
+// readJobIDs returns the job IDs listed in the file, or in stdin if path is "-", one per line. Blank lines and lines
+// starting with # are skipped.
+func readJobIDs(cmd *cobra.Command, path string) ([]string, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var jobIDs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		jobIDs = append(jobIDs, line)
+	}
+	return jobIDs, scanner.Err()
+}
+
+// downloadBatchResults downloads the results of each job into its own directory of the output directory, running
+// up to OG.Concurrency downloads at once, and prints a summary of the downloads. It fails once every download has
+// finished if any of them failed.
+func downloadBatchResults(ctx context.Context, cm *system.CleanupManager, cmd *cobra.Command, jobIDs []string, OG *GetOptions) error {
+	root := OG.IPFSDownloadSettings.OutputDir
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		root = cwd
+	}
+
+	results := make([]BatchGetResult, len(jobIDs))
+	group := errgroup.Group{}
+	group.SetLimit(system.Max(OG.Concurrency, 1))
+	for i, jobID := range jobIDs {
+		i, jobID := i, jobID
+		group.Go(func() error {
+			results[i] = downloadBatchResult(ctx, cm, cmd, jobID, root, *OG.IPFSDownloadSettings)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	var failed int
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	err := printOutput(cmd, OG.Output, results, func(w io.Writer) error {
+		tw := newOutputTable(w, OG.Output, table.StyleLight)
+		tw.AppendHeader(table.Row{"job", "status", "output"})
+		for _, result := range results {
+			if result.Error != "" {
+				tw.AppendRow(table.Row{result.JobID, "failed", result.Error})
+			} else {
+				tw.AppendRow(table.Row{result.JobID, "downloaded", result.OutputDir})
+			}
+		}
+		tw.Render()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to get the results of %d of %d jobs", failed, len(jobIDs))
+	}
+	return nil
+}
+
+// downloadBatchResult downloads the results of a job of a batch into its own directory of root.
+func downloadBatchResult(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	cmd *cobra.Command,
+	jobID string,
+	root string,
+	settings model.DownloaderSettings,
+) BatchGetResult {
+	result := BatchGetResult{JobID: jobID}
+	jobID, settings.SingleFile, _ = strings.Cut(jobID, "/")
+	settings.OutputDir = filepath.Join(root, getDefaultJobFolder(jobID))
+	if err := os.MkdirAll(settings.OutputDir, AutoDownloadFolderPerm); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	outputDir, err := downloadJobResults(ctx, cm, cmd, jobID, settings)
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+	case outputDir == "":
+		result.Error = "no supported downloader found for the published results"
+	default:
+		result.OutputDir = outputDir
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+)
+
+func TestReadJobIDs(t *testing.T) {
+	content := "51225160\n\n  # a comment\nebd9bf2f/outputs/file.txt \n"
+	want := []string{"51225160", "ebd9bf2f/outputs/file.txt"}
+
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	jobIDs, err := readJobIDs(&cobra.Command{}, path)
+	require.NoError(t, err)
+	require.Equal(t, want, jobIDs)
+
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(content))
+	jobIDs, err = readJobIDs(cmd, "-")
+	require.NoError(t, err)
+	require.Equal(t, want, jobIDs)
+
+	_, err = readJobIDs(&cobra.Command{}, filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
+
+type GetBatchSuite struct {
+	BaseSuite
+}
+
+func TestGetBatchSuite(t *testing.T) {
+	suite.Run(t, new(GetBatchSuite))
+}
+
+func (s *GetBatchSuite) TestGetBatchReportsFailures() {
+	jobsFile := filepath.Join(s.T().TempDir(), "ids.txt")
+	require.NoError(s.T(), os.WriteFile(jobsFile, []byte("unknown-job-1\nunknown-job-2\n"), 0644))
+	outputDir := s.T().TempDir()
+
+	_, out, err := ExecuteTestCobraCommand("get",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--jobs-file", jobsFile,
+		"--output-dir", outputDir,
+		"--output", "json",
+		"unknown-job-0",
+	)
+	require.NoError(s.T(), err)
+
+	// the summary is printed on its own line, among the progress of each download
+	var summary, fatal string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "[") {
+			summary = line
+		} else if strings.HasPrefix(line, "{") {
+			fatal = line
+		}
+	}
+	var results []BatchGetResult
+	require.NoError(s.T(), model.JSONUnmarshalWithMax([]byte(summary), &results), out)
+	require.Len(s.T(), results, 3)
+	for i, result := range results {
+		require.Equal(s.T(), fmt.Sprintf("unknown-job-%d", i), result.JobID)
+		require.Contains(s.T(), result.Error, "not found")
+		require.Empty(s.T(), result.OutputDir)
+	}
+
+	fatalError, err := testutils.FirstFatalError(s.T(), fatal)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "failed to get the results of 3 of 3 jobs")
+}
+
+func (s *GetBatchSuite) TestGetBatchRejectsList() {
+	_, out, err := ExecuteTestCobraCommand("get",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--list",
+		"51225160", "ebd9bf2f",
+	)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "single job")
+}
+
+func (s *GetBatchSuite) TestGetRejectsInvalidPattern() {
+	_, out, err := ExecuteTestCobraCommand("get",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--include", "outputs/[",
+		"51225160",
+	)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "invalid pattern")
+}
+
+func (s *GetBatchSuite) TestGetRejectsPatternsForSingleFile() {
+	_, out, err := ExecuteTestCobraCommand("get",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--include", "outputs/*.csv",
+		"51225160/outputs/summary.csv",
+	)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "single file")
+}
@@ -298,3 +298,32 @@ func (s *GetSuite) TestGetWriteToJobFolderNamedDownload() {
 	testDownloadOutput(s.T(), getOutput, jobID, tempDir)
 	testResultsFolderStructure(s.T(), tempDir, hostID, nil)
 }
+
+func (s *GetSuite) TestGetBatchWritesEachJobToItsFolder() {
+	swarmAddresses, err := s.node.IPFSClient.SwarmAddresses(context.Background())
+	require.NoError(s.T(), err)
+	tempDir := s.T().TempDir()
+	hostID := s.node.Host.ID().String()
+
+	var jobIDs []string
+	for i := 0; i < 2; i++ {
+		_, out, err := ExecuteTestCobraCommand(s.getDockerRunArgs([]string{"--wait"})...)
+		require.NoError(s.T(), err, "Error submitting job")
+		jobIDs = append(jobIDs, system.FindJobIDInTestOutput(out))
+	}
+
+	args := []string{"get",
+		"--api-host", s.node.APIServer.Address,
+		"--api-port", fmt.Sprintf("%d", s.node.APIServer.Port),
+		"--ipfs-swarm-addrs", strings.Join(swarmAddresses, ","),
+		"--output-dir", tempDir,
+	}
+	_, getOutput, err := ExecuteTestCobraCommand(append(args, jobIDs...)...)
+	require.NoError(s.T(), err, "Error getting results")
+
+	for _, jobID := range jobIDs {
+		outputDir := filepath.Join(tempDir, getDefaultJobFolder(jobID))
+		require.Contains(s.T(), getOutput, outputDir)
+		testResultsFolderStructure(s.T(), outputDir, hostID, nil)
+	}
+}
@@ -1,11 +1,11 @@
 package bacalhau
 
 import (
-	"encoding/json"
-	"os"
+	"io"
 
 	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
 
@@ -20,21 +20,27 @@ func newIDCmd() *cobra.Command {
 	// make sure serve options point to local mode
 	OS.PeerConnect = DefaultPeerConnect
 	OS.PrivateInternalIPFS = true
+	// the ID has always been printed as JSON, so that stays the default
+	OO := NewOutputOptions(JSONFormat)
 
 	idCmd := &cobra.Command{
 		Use:   "id",
 		Short: "Show bacalhau node id info",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return id(cmd, OS)
+			return id(cmd, OS, OO)
 		},
 	}
 
 	setupLibp2pCLIFlags(idCmd, OS)
+	idCmd.Flags().AddFlagSet(OutputFormatFlags(&OO))
 
 	return idCmd
 }
 
-func id(_ *cobra.Command, OS *ServeOptions) error {
+func id(cmd *cobra.Command, OS *ServeOptions, OO OutputOptions) error {
+	if err := OO.Validate(); err != nil {
+		return err
+	}
 	libp2pHost, err := libp2p.NewHost(OS.SwarmPort)
 	if err != nil {
 		return err
@@ -46,11 +52,10 @@ func id(_ *cobra.Command, OS *ServeOptions) error {
 	}
 	_ = libp2pHost.Close()
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "    ")
-	if err := enc.Encode(info); err != nil {
-		return err
-	}
-
-	return nil
+	return printOutput(cmd, OO, info, func(w io.Writer) error {
+		tw := newOutputTable(w, OO, table.StyleLight)
+		tw.AppendRows([]table.Row{{"id", info.ID}, {"client id", info.ClientID}})
+		tw.Render()
+		return nil
+	})
 }
@@ -0,0 +1,199 @@
+package bacalhau
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"github.com/vincent-petithory/dataurl"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+// scaffolds holds a directory per example that init can generate. Files ending in .tmpl are rendered with the
+// scaffoldData of the example and written without the suffix, and the other files are copied as they are.
+//
+//go:embed scaffolds
+var scaffolds embed.FS
+
+const (
+	scaffoldsRoot      = "scaffolds"
+	scaffoldSample     = "sample.txt"
+	scaffoldSampleDest = "inputs/sample.txt"
+	scaffoldTemplate   = ".tmpl"
+)
+
+var (
+	//nolint:lll // Documentation
+	initLong = templates.LongDesc(i18n.T(`
+		Generate a runnable example in a new directory, to get started with bacalhau.
+
+		The example counts the words of a sample input, and comes with a Makefile whose targets submit it with this
+		CLI and download its results:
+		- docker: a job file that runs a shell command in a docker image.
+		- wasm: a Rust program compiled to a WASM module, which requires Rust and its wasm32-wasi target.
+		- pipeline: two docker jobs, the second of which processes the results of the first one.
+
+		The sample input is in inputs/sample.txt. Jobs can't read local files, so it is inlined in the job files.
+`))
+
+	//nolint:lll // Documentation
+	initExample = templates.Examples(i18n.T(`
+		# Generate the docker example in ./bacalhau-docker, then run it and download its results
+		bacalhau init docker
+		cd bacalhau-docker && make run results
+
+		# Generate the pipeline example in a directory of your choice, with another image
+		bacalhau init pipeline ./my-pipeline --image alpine:3.18
+`))
+)
+
+type InitOptions struct {
+	Image string // Docker image of the jobs of the example
+	Force bool   // Overwrite the files of the example that already exist
+}
+
+func NewInitOptions() *InitOptions {
+	return &InitOptions{
+		Image: "ubuntu:22.04",
+	}
+}
+
+// scaffoldData is what the templates of the examples are rendered with.
+type scaffoldData struct {
+	APIVersion string
+	Image      string
+	// SampleInput is the sample input as a data URL, to inline it in jobs
+	SampleInput string
+}
+
+func newInitCmd() *cobra.Command {
+	OI := NewInitOptions()
+
+	initCmd := &cobra.Command{
+		Use:       fmt.Sprintf("init {%s} [directory]", strings.Join(scaffoldNames(), "|")),
+		Short:     "Generate a runnable example to get started",
+		Long:      initLong,
+		Example:   initExample,
+		Args:      cobra.RangeArgs(1, 2),
+		ValidArgs: scaffoldNames(),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			dir := "bacalhau-" + cmdArgs[0]
+			if len(cmdArgs) > 1 {
+				dir = cmdArgs[1]
+			}
+			files, err := scaffold(cmdArgs[0], dir, OI)
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				cmd.Printf("created %s\n", filepath.Join(dir, file))
+			}
+			cmd.Printf("\nRun the example with: cd %s && make run results\n", dir)
+			return nil
+		},
+	}
+
+	initCmd.Flags().StringVar(&OI.Image, "image", OI.Image,
+		`The docker image of the jobs of the docker and pipeline examples`)
+	initCmd.Flags().BoolVar(&OI.Force, "force", OI.Force,
+		`Overwrite the files of the example that already exist in the directory`)
+	return initCmd
+}
+
+// scaffoldNames returns the examples init can generate.
+func scaffoldNames() []string {
+	entries, err := scaffolds.ReadDir(scaffoldsRoot)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scaffold writes the example to dir, and returns the files it wrote relative to dir.
+func scaffold(name, dir string, OI *InitOptions) ([]string, error) {
+	root := path.Join(scaffoldsRoot, name)
+	if info, err := fs.Stat(scaffolds, root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("unknown example %q, must be one of %s", name, strings.Join(scaffoldNames(), ", "))
+	}
+	sample, err := scaffolds.ReadFile(path.Join(scaffoldsRoot, scaffoldSample))
+	if err != nil {
+		return nil, err
+	}
+	data := scaffoldData{
+		APIVersion:  model.APIVersionLatest().String(),
+		Image:       OI.Image,
+		SampleInput: dataurl.EncodeBytes(sample),
+	}
+
+	// render everything before writing anything, so that a failure doesn't leave a partial example behind
+	files := map[string][]byte{scaffoldSampleDest: sample}
+	err = fs.WalkDir(scaffolds, root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := scaffolds.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(filePath, root+"/")
+		if strings.HasSuffix(name, scaffoldTemplate) {
+			name = strings.TrimSuffix(name, scaffoldTemplate)
+			if content, err = renderScaffold(name, content, data); err != nil {
+				return err
+			}
+		}
+		files[name] = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+		if _, err = os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && !OI.Force {
+			return nil, fmt.Errorf("%s already exists, use --force to overwrite it", filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil { //nolint:gomnd
+			return nil, err
+		}
+		if err = os.WriteFile(target, files[name], 0644); err != nil { //nolint:gosec,gomnd // example files
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+func renderScaffold(name string, content []byte, data scaffoldData) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
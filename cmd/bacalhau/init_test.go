@@ -0,0 +1,83 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/jobtransform"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInit(t *testing.T) {
+	for _, tc := range []struct {
+		example  string
+		files    []string
+		jobFiles []string
+	}{
+		{
+			example:  "docker",
+			files:    []string{"Makefile", "inputs/sample.txt", "job.yaml"},
+			jobFiles: []string{"job.yaml"},
+		},
+		{
+			example: "wasm",
+			files:   []string{"Cargo.toml", "Makefile", "inputs/sample.txt", "src/main.rs"},
+		},
+		{
+			example:  "pipeline",
+			files:    []string{"Makefile", "inputs/sample.txt", "stage1.yaml"},
+			jobFiles: []string{"stage1.yaml"},
+		},
+	} {
+		t.Run(tc.example, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "example")
+			_, out, err := ExecuteTestCobraCommand("init", tc.example, dir, "--image", "alpine:3.18")
+			require.NoError(t, err)
+			require.Contains(t, out, "make run results")
+			for _, file := range tc.files {
+				require.FileExists(t, filepath.Join(dir, file))
+			}
+
+			sample, err := os.ReadFile(filepath.Join(dir, "inputs", "sample.txt"))
+			require.NoError(t, err)
+			for _, jobFile := range tc.jobFiles {
+				content, err := os.ReadFile(filepath.Join(dir, jobFile))
+				require.NoError(t, err)
+				jsonContent, err := yaml.YAMLToJSON(content)
+				require.NoError(t, err)
+				var j model.Job
+				require.NoError(t, json.Unmarshal(jsonContent, &j))
+				_, err = jobtransform.NewPublisherMigrator()(context.Background(), &j)
+				require.NoError(t, err)
+				require.NoError(t, job.VerifyJob(context.Background(), &j))
+				require.Equal(t, "alpine:3.18", j.Spec.Docker.Image)
+
+				// the sample input is inlined in the job
+				require.Len(t, j.Spec.Inputs, 1)
+				require.Equal(t, model.StorageSourceInline, j.Spec.Inputs[0].StorageSource)
+				require.Contains(t, string(sample), "quick brown fox")
+
+				_, out, err = ExecuteTestCobraCommand("validate", filepath.Join(dir, jobFile))
+				require.NoError(t, err)
+				require.Contains(t, out, "The Job is valid")
+			}
+
+			// existing examples are only overwritten when forced
+			_, _, err = ExecuteTestCobraCommand("init", tc.example, dir)
+			require.ErrorContains(t, err, "already exists")
+			_, _, err = ExecuteTestCobraCommand("init", tc.example, dir, "--force")
+			require.NoError(t, err)
+		})
+	}
+
+	_, _, err := ExecuteTestCobraCommand("init", "cobol", t.TempDir())
+	require.ErrorContains(t, err, "unknown example")
+}
@@ -0,0 +1,18 @@
+package bacalhau
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newJobCmd() *cobra.Command {
+	jobCmd := &cobra.Command{
+		Use:               "job",
+		Short:             "Commands to troubleshoot jobs",
+		PersistentPreRunE: checkVersion,
+	}
+
+	jobCmd.AddCommand(newJobDebugBundleCmd())
+	jobCmd.AddCommand(newJobEventsCmd())
+	jobCmd.AddCommand(newJobDiffCmd())
+	return jobCmd
+}
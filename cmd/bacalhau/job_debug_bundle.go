@@ -0,0 +1,231 @@
+package bacalhau
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/bacalhau-project/bacalhau/pkg/version"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	jobDebugBundleLong = templates.LongDesc(i18n.T(`
+		Collect everything known about a job into a single tar.gz, to attach to bug reports.
+
+		The bundle holds the spec of the job, its state and history, the output of each execution, the info of the
+		nodes it was scheduled on, and the recent logs and scheduling decisions of the requester node about it. Only
+		admins of the requester node can collect the nodes, logs and decisions.
+
+		The values of environment variables, annotations starting with "sensitive:", and credentials in the URLs of
+		inputs are redacted from the spec, and from the logs and decisions of the requester node. Outputs are included
+		as they are, so pass any other secret that could appear in them with --redact to have it replaced throughout the
+		bundle.
+`))
+
+	//nolint:lll // Documentation
+	jobDebugBundleExample = templates.Examples(i18n.T(`
+		# Write the debug bundle of a job to bacalhau-debug-51225160.tar.gz
+		bacalhau job debug-bundle 51225160
+
+		# Write the debug bundle to a given file, replacing a token wherever it appears
+		bacalhau job debug-bundle 51225160 --output bundle.tar.gz --redact "$API_TOKEN"
+`))
+)
+
+type JobDebugBundleOptions struct {
+	OutputFile string   // Where to write the bundle to
+	Redact     []string // Values replaced wherever they appear in the bundle
+}
+
+func NewJobDebugBundleOptions() *JobDebugBundleOptions {
+	return &JobDebugBundleOptions{}
+}
+
+func newJobDebugBundleCmd() *cobra.Command {
+	OB := NewJobDebugBundleOptions()
+
+	bundleCmd := &cobra.Command{
+		Use:     "debug-bundle [id]",
+		Short:   "Collect the details of a job into a tar.gz for bug reports",
+		Long:    jobDebugBundleLong,
+		Example: jobDebugBundleExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return jobDebugBundle(cmd, cmdArgs[0], OB)
+		},
+	}
+	bundleCmd.Flags().StringVarP(&OB.OutputFile, "output", "o", OB.OutputFile,
+		`File to write the bundle to. Defaults to bacalhau-debug-<short job id>.tar.gz in the current directory.`)
+	bundleCmd.Flags().StringArrayVar(&OB.Redact, "redact", OB.Redact,
+		`A value to replace wherever it appears in the bundle, such as a secret passed to the job. Can be repeated.`)
+	return bundleCmd
+}
+
+// debugBundleFile is a file written to the debug bundle.
+type debugBundleFile struct {
+	Name    string
+	Content []byte
+}
+
+type debugBundleManifest struct {
+	JobID         string                  `json:"JobID"`
+	CreatedAt     time.Time               `json:"CreatedAt"`
+	ClientVersion *model.BuildVersionInfo `json:"ClientVersion"`
+	ServerVersion *model.BuildVersionInfo `json:"ServerVersion,omitempty"`
+	// Errors are the parts of the bundle that could not be collected.
+	Errors []string `json:"Errors,omitempty"`
+}
+
+func jobDebugBundle(cmd *cobra.Command, jobID string, OB *JobDebugBundleOptions) error {
+	ctx := cmd.Context()
+	apiClient := GetAPIClient()
+
+	j, found, err := apiClient.Get(ctx, jobID)
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error trying to get job (ID: %s): %+v", jobID, err), 1)
+		return nil
+	}
+	if !found {
+		Fatal(cmd, bacerrors.NewJobNotFound(jobID).Error(), 1)
+		return nil
+	}
+	jobID = j.Job.ID()
+
+	manifest := debugBundleManifest{
+		JobID:         jobID,
+		CreatedAt:     time.Now().UTC(),
+		ClientVersion: version.Get(),
+	}
+	manifest.ServerVersion, err = apiClient.Version(ctx)
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("server version: %s", err))
+	}
+
+	var files []debugBundleFile
+	addJSON := func(name string, v interface{}) {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, fmt.Sprintf("%s: %s", name, err))
+			return
+		}
+		files = append(files, debugBundleFile{Name: name, Content: b})
+	}
+
+	addJSON("job.json", job.Redact(j.Job))
+	addJSON("state.json", j.State)
+
+	history, err := apiClient.GetEvents(ctx, jobID, publicapi.EventFilterOptions{})
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("history: %s", err))
+	} else {
+		addJSON("history.json", history)
+		var timeline bytes.Buffer
+		if err = job.NewTimeline(j.State, history, time.Now()).WriteText(&timeline); err == nil {
+			files = append(files, debugBundleFile{Name: "timeline.txt", Content: timeline.Bytes()})
+		}
+	}
+
+	for _, execution := range j.State.Executions {
+		if execution.RunOutput == nil {
+			continue
+		}
+		dir := path.Join("executions", system.GetShortID(execution.NodeID)+"-"+execution.ComputeReference)
+		files = append(files,
+			debugBundleFile{Name: path.Join(dir, model.DownloadFilenameStdout), Content: []byte(execution.RunOutput.STDOUT)},
+			debugBundleFile{Name: path.Join(dir, model.DownloadFilenameStderr), Content: []byte(execution.RunOutput.STDERR)},
+			debugBundleFile{Name: path.Join(dir, model.DownloadFilenameExitCode), Content: []byte(fmt.Sprint(execution.RunOutput.ExitCode))},
+		)
+	}
+
+	debugInfo, err := apiClient.DebugJob(ctx, jobID)
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("nodes and requester logs: %s", err))
+	} else {
+		addJSON("nodes.json", debugInfo.Nodes)
+		addJSON("scheduler.json", debugInfo.Scheduler)
+		files = append(files, debugBundleFile{Name: "requester.log", Content: []byte(strings.Join(debugInfo.Logs, "\n"))})
+	}
+
+	// the manifest goes first, so that it is what is seen first when listing the bundle
+	manifestFiles := files
+	files = nil
+	addJSON("bundle.json", manifest)
+	files = append(files, manifestFiles...)
+
+	outputFile := OB.OutputFile
+	if outputFile == "" {
+		outputFile = fmt.Sprintf("bacalhau-debug-%s.tar.gz", system.GetShortID(jobID))
+	}
+	out, err := os.Create(outputFile)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error creating debug bundle: %s", err), 1)
+		return nil
+	}
+	defer out.Close()
+
+	root := fmt.Sprintf("bacalhau-debug-%s", system.GetShortID(jobID))
+	if err = writeDebugBundle(out, root, files, OB.Redact); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error writing debug bundle: %s", err), 1)
+		return nil
+	}
+	for _, bundleErr := range manifest.Errors {
+		cmd.PrintErrf("Could not collect %s\n", bundleErr)
+	}
+	cmd.Printf("Debug bundle of job '%s' has been written to %s\n", jobID, outputFile)
+	return nil
+}
+
+// writeDebugBundle writes the files as a tar.gz under the root folder, with the redacted values replaced in them.
+func writeDebugBundle(w io.Writer, root string, files []debugBundleFile, redact []string) error {
+	var replacements []string
+	for _, value := range redact {
+		if value != "" {
+			replacements = append(replacements, value, job.RedactedValue)
+		}
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+	for _, file := range files {
+		content := []byte(replacer.Replace(string(file.Content)))
+		err := tw.WriteHeader(&tar.Header{
+			Name:    path.Join(root, file.Name),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,42 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDebugBundle(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeDebugBundle(&buf, "bundle", []debugBundleFile{
+		{Name: "bundle.json", Content: []byte(`{"JobID":"job"}`)},
+		{Name: "executions/node-e1/stdout", Content: []byte("token=hunter2, again hunter2")},
+	}, []string{"hunter2", ""})
+	require.NoError(t, err)
+
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	contents := map[string]string{}
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		names = append(names, header.Name)
+		contents[header.Name] = string(content)
+	}
+	require.Equal(t, []string{"bundle/bundle.json", "bundle/executions/node-e1/stdout"}, names)
+	require.Equal(t, `{"JobID":"job"}`, contents["bundle/bundle.json"])
+	require.Equal(t, "token=REDACTED, again REDACTED", contents["bundle/executions/node-e1/stdout"])
+}
@@ -0,0 +1,305 @@
+package bacalhau
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/c2h5oh/datasize"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	jobDiffLong = templates.LongDesc(i18n.T(`
+		Compare the results of two jobs, such as two runs of a spec before and after a change, to check that the change
+		did not alter the outputs unexpectedly.
+
+		The files that were added, removed or changed from the first job to the second are listed with their sizes and
+		SHA-256 hashes, from the manifests compute nodes publish with the results, so that nothing is downloaded. Results
+		published without a manifest, such as those with too many files or of jobs run by several nodes, are downloaded
+		to be compared. Stdout, stderr and the exit code are compared as files.
+
+		With --text, the changes to small text files are also printed as unified diffs, downloading only those files.
+`))
+
+	//nolint:lll // Documentation
+	jobDiffExample = templates.Examples(i18n.T(`
+		# List the files that differ between the results of two jobs
+		bacalhau job diff 51225160 ebd9bf2f
+
+		# Also print the changes to the text files that differ
+		bacalhau job diff 51225160 ebd9bf2f --text
+
+		# Print the differences as JSON
+		bacalhau job diff 51225160 ebd9bf2f --output json
+`))
+)
+
+type JobDiffOptions struct {
+	IPFSDownloadSettings *model.DownloaderSettings
+	Text                 bool          // Print unified diffs of the text files that changed
+	MaxTextSize          string        // Largest file that is diffed as text
+	Output               OutputOptions // Format of the output: table, json or yaml
+}
+
+func NewJobDiffOptions() *JobDiffOptions {
+	return &JobDiffOptions{
+		IPFSDownloadSettings: util.NewDownloadSettings(),
+		MaxTextSize:          "64Kb",
+		Output:               NewOutputOptions(TableFormat),
+	}
+}
+
+// jobDiffResult is the JSON or YAML output of the job diff command.
+type jobDiffResult struct {
+	Identical int                   `json:"Identical"`
+	Files     []downloader.FileDiff `json:"Files"`
+	// TextDiffs are the unified diffs of the text files that changed, by path, if --text is given.
+	TextDiffs map[string]string `json:"TextDiffs,omitempty"`
+}
+
+func newJobDiffCmd() *cobra.Command {
+	OD := NewJobDiffOptions()
+
+	diffCmd := &cobra.Command{
+		Use:     "diff [id] [id]",
+		Short:   "Compare the results of two jobs",
+		Long:    jobDiffLong,
+		Example: jobDiffExample,
+		Args:    cobra.ExactArgs(2), //nolint:gomnd
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return jobDiff(cmd, cmdArgs[0], cmdArgs[1], OD)
+		},
+	}
+
+	diffCmd.Flags().AddFlagSet(NewIPFSDownloadFlags(OD.IPFSDownloadSettings))
+	diffCmd.Flags().BoolVar(&OD.Text, "text", OD.Text,
+		"Print unified diffs of the text files that changed.")
+	diffCmd.Flags().StringVar(&OD.MaxTextSize, "max-text-size", OD.MaxTextSize,
+		"Largest file that is diffed as text with --text. Larger files are only compared by their hashes.")
+	diffCmd.Flags().AddFlagSet(OutputFormatFlags(&OD.Output))
+	return diffCmd
+}
+
+func jobDiff(cmd *cobra.Command, oldJobID, newJobID string, OD *JobDiffOptions) error {
+	if !validateOutput(cmd, &OD.Output) {
+		return nil
+	}
+	maxTextSize, err := capacity.ParseBytesString(OD.MaxTextSize)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Invalid --max-text-size %q: %s", OD.MaxTextSize, err), 1)
+		return nil
+	}
+
+	cm := cmd.Context().Value(systemManagerKey).(*system.CleanupManager)
+	dir, err := os.MkdirTemp("", "bacalhau-diff-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	jobIDs := [2]string{oldJobID, newJobID}
+	var manifests [2]model.ResultManifest
+	published := true
+	for i, jobID := range jobIDs {
+		var ok bool
+		manifests[i], ok, err = publishedResultManifest(cmd.Context(), jobID, OD.IPFSDownloadSettings.NodeID)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error getting job '%s': %s", jobID, err), 1)
+			return nil
+		}
+		published = published && ok
+	}
+
+	var dirs [2]string
+	if !published {
+		// results published without a manifest can only be compared once downloaded
+		dirs, err = downloadDiffResults(cmd, cm, dir, jobIDs, OD.IPFSDownloadSettings, nil)
+		if err != nil {
+			Fatal(cmd, err.Error(), 1)
+			return nil
+		}
+		for i := range dirs {
+			manifests[i], err = downloader.BuildResultManifest(dirs[i], 0)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	result := jobDiffResult{}
+	result.Files, result.Identical = downloader.DiffResultManifests(manifests[0], manifests[1])
+	if OD.Text {
+		if published {
+			// only the files that are small enough to be diffed as text are downloaded
+			include := textDiffFiles(result.Files, int64(maxTextSize))
+			dirs, err = downloadDiffResults(cmd, cm, dir, jobIDs, OD.IPFSDownloadSettings, &include)
+			if err != nil {
+				Fatal(cmd, err.Error(), 1)
+				return nil
+			}
+		}
+		result.TextDiffs = make(map[string]string)
+		for _, diff := range result.Files {
+			text, ok := diffTextFile(dirs, diff, int64(maxTextSize))
+			if ok {
+				result.TextDiffs[diff.Path] = text
+			}
+		}
+	}
+
+	return printOutput(cmd, OD.Output, result, func(w io.Writer) error {
+		if len(result.Files) == 0 {
+			fmt.Fprintf(w, "The results are identical (%d files)\n", result.Identical)
+			return nil
+		}
+		tw := newOutputTable(w, OD.Output, table.StyleLight)
+		tw.AppendHeader(table.Row{"change", "path", oldJobID, newJobID})
+		for _, diff := range result.Files {
+			tw.AppendRow(table.Row{diff.Change, diff.Path, describeResultFile(diff.Old), describeResultFile(diff.New)})
+		}
+		tw.Render()
+		fmt.Fprintf(w, "%d files differ, %d are identical\n", len(result.Files), result.Identical)
+		for _, diff := range result.Files {
+			if text, ok := result.TextDiffs[diff.Path]; ok {
+				fmt.Fprint(w, "\n"+text)
+			}
+		}
+		return nil
+	})
+}
+
+// publishedResultManifest returns the manifest the compute node published with the results of the job, and false if
+// the results can only be compared once downloaded, as they were published without a manifest or by several nodes.
+func publishedResultManifest(ctx context.Context, jobID string, nodeID string) (model.ResultManifest, bool, error) {
+	j, _, err := GetAPIClient().Get(ctx, jobID)
+	if err != nil {
+		return model.ResultManifest{}, false, err
+	}
+	var manifests []*model.ResultManifest
+	for _, execution := range j.State.Executions { //nolint:gocritic
+		if execution.State != model.ExecutionStateCompleted || !strings.HasPrefix(execution.NodeID, nodeID) {
+			continue
+		}
+		var manifest *model.ResultManifest
+		if execution.RunOutput != nil {
+			manifest = execution.RunOutput.ResultManifest
+		}
+		manifests = append(manifests, manifest)
+	}
+	if len(manifests) != 1 || manifests[0] == nil {
+		return model.ResultManifest{}, false, nil
+	}
+	return *manifests[0], true, nil
+}
+
+// textDiffFiles returns the paths of the files that differ and are no larger than maxSize in the results of each job,
+// which are the only files needed to diff the results as text.
+func textDiffFiles(diffs []downloader.FileDiff, maxSize int64) [2][]string {
+	var paths [2][]string
+	for _, diff := range diffs {
+		if (diff.Old != nil && diff.Old.Size > maxSize) || (diff.New != nil && diff.New.Size > maxSize) {
+			continue
+		}
+		for i, file := range []*model.ResultFile{diff.Old, diff.New} {
+			if file != nil {
+				paths[i] = append(paths[i], file.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// downloadDiffResults downloads the results of both jobs into folders of dir, and returns the folders. Only the files
+// at the paths of include are downloaded if it is not nil, and the results of a job are not downloaded at all if it
+// has none of the files.
+func downloadDiffResults(
+	cmd *cobra.Command,
+	cm *system.CleanupManager,
+	dir string,
+	jobIDs [2]string,
+	downloadSettings *model.DownloaderSettings,
+	include *[2][]string,
+) ([2]string, error) {
+	var dirs [2]string
+	for i, jobID := range jobIDs {
+		settings := *downloadSettings
+		settings.OutputDir = filepath.Join(dir, fmt.Sprint(i))
+		settings.Raw = false
+		if include != nil {
+			if len(include[i]) == 0 {
+				continue
+			}
+			settings.Include = make([]string, 0, len(include[i]))
+			for _, path := range include[i] {
+				settings.Include = append(settings.Include, literalPattern(path))
+			}
+			settings.Exclude = nil
+		}
+		if err := os.Mkdir(settings.OutputDir, AutoDownloadFolderPerm); err != nil {
+			return dirs, err
+		}
+		var err error
+		dirs[i], err = downloadJobResults(cmd.Context(), cm, cmd, jobID, settings)
+		if err != nil {
+			return dirs, fmt.Errorf("error downloading the results of job '%s': %w", jobID, err)
+		}
+		if dirs[i] == "" {
+			return dirs, fmt.Errorf("the results of job '%s' can't be downloaded to be compared", jobID)
+		}
+	}
+	return dirs, nil
+}
+
+// literalPattern returns the include pattern that only matches the path.
+func literalPattern(path string) string {
+	var pattern strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			pattern.WriteRune('\\')
+		}
+		pattern.WriteRune(r)
+	}
+	return pattern.String()
+}
+
+// diffTextFile returns a unified diff of a file that differs between the results downloaded to dirs, if it is a text
+// file no larger than maxSize in either of them.
+func diffTextFile(dirs [2]string, diff downloader.FileDiff, maxSize int64) (string, bool) {
+	var contents [2][]byte
+	for i, file := range []*model.ResultFile{diff.Old, diff.New} {
+		if file == nil {
+			continue
+		}
+		if file.Size > maxSize || dirs[i] == "" {
+			return "", false
+		}
+		content, err := os.ReadFile(filepath.Join(dirs[i], filepath.FromSlash(file.Path)))
+		if err != nil {
+			return "", false
+		}
+		contents[i] = content
+	}
+	return downloader.DiffText(diff.Path, contents[0], contents[1])
+}
+
+// describeResultFile returns the size and short hash of a file, or a dash if it is missing from the results.
+func describeResultFile(file *model.ResultFile) string {
+	if file == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s sha256:%s", datasize.ByteSize(file.Size).HR(), file.SHA256[:12])
+}
@@ -0,0 +1,31 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextDiffFiles(t *testing.T) {
+	small := &model.ResultFile{Path: "outputs/data.csv", Size: 10}
+	large := &model.ResultFile{Path: "outputs/data.csv", Size: 1000}
+	added := &model.ResultFile{Path: "outputs/new.txt", Size: 10}
+
+	paths := textDiffFiles([]downloader.FileDiff{
+		{Path: "outputs/data.csv", Change: downloader.FileChanged, Old: small, New: small},
+		{Path: "outputs/big.csv", Change: downloader.FileChanged, Old: small, New: large},
+		{Path: "outputs/new.txt", Change: downloader.FileAdded, New: added},
+	}, 100)
+	require.Equal(t, [2][]string{{"outputs/data.csv"}, {"outputs/data.csv", "outputs/new.txt"}}, paths)
+}
+
+func TestLiteralPattern(t *testing.T) {
+	filter, err := downloader.NewPathFilter([]string{literalPattern("outputs/[a]*.txt")}, nil)
+	require.NoError(t, err)
+	require.True(t, filter.Matches("outputs/[a]*.txt"))
+	require.False(t, filter.Matches("outputs/a.txt"))
+}
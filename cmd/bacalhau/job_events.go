@@ -0,0 +1,101 @@
+package bacalhau
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	jobEventsLong = templates.LongDesc(i18n.T(`
+		Print the history of a job, from its creation to its latest state, as JSON.
+
+		With --cloudevents, each change to the state of the job or of one of its executions is printed as a CloudEvent
+		(https://cloudevents.io), and the output is a batch in the application/cloudevents-batch+json format that can be
+		forwarded as is to event-driven systems. The type of each event is org.bacalhau.job.state.<state> or
+		org.bacalhau.execution.state.<state>, and its subject is jobs/<job id> or jobs/<job id>/executions/<execution id>.
+
+		Live events can be followed as CloudEvents by adding format=cloudevents to the query of the
+		/requester/websocket/events endpoint.
+`))
+
+	//nolint:lll // Documentation
+	jobEventsExample = templates.Examples(i18n.T(`
+		# Print the history of a job
+		bacalhau job events 51225160
+
+		# Print the history of a job as CloudEvents
+		bacalhau job events 51225160 --cloudevents
+`))
+)
+
+type JobEventsOptions struct {
+	CloudEvents bool // Print the events as CloudEvents
+}
+
+func NewJobEventsOptions() *JobEventsOptions {
+	return &JobEventsOptions{}
+}
+
+func newJobEventsCmd() *cobra.Command {
+	OE := NewJobEventsOptions()
+
+	eventsCmd := &cobra.Command{
+		Use:     "events [id]",
+		Short:   "Print the history of a job, optionally as CloudEvents",
+		Long:    jobEventsLong,
+		Example: jobEventsExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return jobEvents(cmd, cmdArgs[0], OE)
+		},
+	}
+	eventsCmd.Flags().BoolVar(&OE.CloudEvents, "cloudevents", OE.CloudEvents,
+		`Print the events as a batch of CloudEvents.`)
+	return eventsCmd
+}
+
+func jobEvents(cmd *cobra.Command, jobID string, OE *JobEventsOptions) error {
+	ctx := cmd.Context()
+	apiClient := GetAPIClient()
+
+	j, found, err := apiClient.Get(ctx, jobID)
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error trying to get job (ID: %s): %+v", jobID, err), 1)
+		return nil
+	}
+	if !found {
+		Fatal(cmd, bacerrors.NewJobNotFound(jobID).Error(), 1)
+		return nil
+	}
+
+	var events interface{}
+	if OE.CloudEvents {
+		events, err = apiClient.GetCloudEvents(ctx, j.Job.ID(), publicapi.EventFilterOptions{})
+	} else {
+		events, err = apiClient.GetEvents(ctx, j.Job.ID(), publicapi.EventFilterOptions{})
+	}
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure retrieving job events '%s': %s\n", j.Job.ID(), err), 1)
+		return nil
+	}
+
+	b, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling job events: %s\n", err), 1)
+		return nil
+	}
+	cmd.Println(string(b))
+	return nil
+}
@@ -3,10 +3,13 @@ package bacalhau
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
 	"github.com/jedib0t/go-pretty/v6/table"
@@ -25,7 +28,10 @@ var (
 		bacalhau list
 
 		# List jobs and output as json
-		bacalhau list --output json`))
+		bacalhau list --output json
+
+		# List the jobs of the ml team that completed or failed in the last day
+		bacalhau list --labels team=ml --state Completed,Error --since 24h`))
 
 	// The tags that will be excluded by default, if the user does not pass any
 	// others to the list command.
@@ -35,32 +41,29 @@ var (
 )
 
 type ListOptions struct {
-	HideHeader   bool                // Hide the column headers
-	IDFilter     string              // Filter by Job List to IDs matching substring.
-	IncludeTags  []model.IncludedTag // Only return jobs with these annotations
-	ExcludeTags  []model.ExcludedTag // Only return jobs without these annotations
-	NoStyle      bool                // Remove all styling from table output.
-	MaxJobs      int                 // Print the first NUM jobs instead of the first 10.
-	OutputFormat string              // The output format for the list of jobs (json or text)
-	SortReverse  bool                // Reverse order of table - for time sorting, this will be newest first.
-	SortBy       ColumnEnum          // Sort by field, defaults to creation time, with newest first [Allowed "id", "created_at"].
-	OutputWide   bool                // Print full values in the table results
-	ReturnAll    bool                // Return all jobs, not just those that belong to the user
+	IDFilter    string              // Filter by Job List to IDs matching substring.
+	IncludeTags []model.IncludedTag // Only return jobs with these annotations
+	ExcludeTags []model.ExcludedTag // Only return jobs without these annotations
+	MaxJobs     int                 // Print the first NUM jobs instead of the first 10.
+	SortReverse bool                // Reverse order of table - for time sorting, this will be newest first.
+	SortBy      ColumnEnum          // Sort by field, defaults to creation time, with newest first [Allowed "id", "created_at"].
+	ReturnAll   bool                // Return all jobs, not just those that belong to the user
+	Labels      []string            // Only return jobs with labels matching all of these selectors
+	States      []string            // Only return jobs in one of these states
+	Since       time.Duration       // Only return jobs created within this duration
+	Output      OutputOptions       // How the list of jobs is printed
 }
 
 func NewListOptions() *ListOptions {
 	return &ListOptions{
-		HideHeader:   false,
-		IDFilter:     "",
-		IncludeTags:  model.IncludeAny,
-		ExcludeTags:  defaultExcludedTags,
-		NoStyle:      false,
-		MaxJobs:      10,
-		OutputFormat: "text",
-		SortReverse:  true,
-		SortBy:       ColumnCreatedAt,
-		OutputWide:   false,
-		ReturnAll:    false,
+		IDFilter:    "",
+		IncludeTags: model.IncludeAny,
+		ExcludeTags: defaultExcludedTags,
+		MaxJobs:     10,
+		SortReverse: true,
+		SortBy:      ColumnCreatedAt,
+		ReturnAll:   false,
+		Output:      NewOutputOptions(TableFormat),
 	}
 }
 
@@ -78,22 +81,15 @@ func newListCmd() *cobra.Command {
 		},
 	}
 
-	listCmd.PersistentFlags().BoolVar(&OL.HideHeader, "hide-header", OL.HideHeader,
-		`do not print the column headers.`)
 	listCmd.PersistentFlags().StringVar(&OL.IDFilter, "id-filter", OL.IDFilter, `filter by Job List to IDs matching substring.`)
 	listCmd.PersistentFlags().Var(IncludedTagFlag(&OL.IncludeTags), "include-tag",
 		`Only return jobs that have the passed tag in their annotations`)
 	listCmd.PersistentFlags().Var(ExcludedTagFlag(&OL.ExcludeTags), "exclude-tag",
 		`Only return jobs that do not have the passed tag in their annotations`)
-	listCmd.PersistentFlags().BoolVar(&OL.NoStyle, "no-style", OL.NoStyle, `remove all styling from table output.`)
 	listCmd.PersistentFlags().IntVarP(
 		&OL.MaxJobs, "number", "n", OL.MaxJobs,
 		`print the first NUM jobs instead of the first 10.`,
 	)
-	listCmd.PersistentFlags().StringVar(
-		&OL.OutputFormat, "output", OL.OutputFormat,
-		`The output format for the list of jobs (json or text)`,
-	)
 	listCmd.PersistentFlags().BoolVar(&OL.SortReverse, "reverse", OL.SortReverse,
 		//nolint:lll // Documentation
 		`reverse order of table - for time sorting, this will be newest first. Use '--reverse=false' to sort oldest first (single quotes are required).`)
@@ -105,15 +101,19 @@ func newListCmd() *cobra.Command {
 		OL.SortBy = ColumnCreatedAt
 	}
 
-	listCmd.PersistentFlags().BoolVar(
-		&OL.OutputWide, "wide", OL.OutputWide,
-		`Print full values in the table results`,
-	)
 	listCmd.PersistentFlags().BoolVar(
 		&OL.ReturnAll, "all", OL.ReturnAll,
 		//nolint:lll // Documentation
 		`Fetch all jobs from the network (default is to filter those belonging to the user). This option may take a long time to return, please use with caution.`,
 	)
+	listCmd.PersistentFlags().StringSliceVar(&OL.Labels, "labels", OL.Labels,
+		`Only return jobs with labels matching all of these selectors, such as team=ml or team=ml-*.`)
+	listCmd.PersistentFlags().StringSliceVar(&OL.States, "state", OL.States,
+		fmt.Sprintf(`Only return jobs in one of these states: %s.`, strings.Join(jobStateNames(), ", ")))
+	listCmd.PersistentFlags().DurationVar(&OL.Since, "since", OL.Since,
+		`Only return jobs created within this duration, such as 24h.`)
+	listCmd.PersistentFlags().AddFlagSet(OutputFormatFlags(&OL.Output))
+	listCmd.PersistentFlags().AddFlagSet(TableFlags(&OL.Output))
 
 	return listCmd
 }
@@ -148,27 +148,48 @@ func (c *ColumnEnum) Set(v string) error {
 
 func list(cmd *cobra.Command, OL *ListOptions) error {
 	ctx := cmd.Context()
+	if !validateOutput(cmd, &OL.Output) {
+		return nil
+	}
 
 	log.Ctx(ctx).Debug().Msgf("Table filter flag set to: %s", OL.IDFilter)
 	log.Ctx(ctx).Debug().Msgf("Table limit flag set to: %d", OL.MaxJobs)
-	log.Ctx(ctx).Debug().Msgf("Table output format flag set to: %s", OL.OutputFormat)
+	log.Ctx(ctx).Debug().Msgf("Table output format flag set to: %s", OL.Output.Format)
 	log.Ctx(ctx).Debug().Msgf("Table reverse flag set to: %t", OL.SortReverse)
 	log.Ctx(ctx).Debug().Msgf("Found return all flag: %t", OL.ReturnAll)
 	log.Ctx(ctx).Debug().Msgf("Found sort flag: %s", OL.SortBy)
-	log.Ctx(ctx).Debug().Msgf("Found hide header flag set to: %t", OL.HideHeader)
-	log.Ctx(ctx).Debug().Msgf("Found no-style header flag set to: %t", OL.NoStyle)
-	log.Ctx(ctx).Debug().Msgf("Found output wide flag set to: %t", OL.OutputWide)
-
-	jobs, err := GetAPIClient().List(
-		ctx,
-		OL.IDFilter,
-		OL.IncludeTags,
-		OL.ExcludeTags,
-		OL.MaxJobs,
-		OL.ReturnAll,
-		OL.SortBy.String(),
-		OL.SortReverse,
-	)
+	log.Ctx(ctx).Debug().Msgf("Found hide header flag set to: %t", OL.Output.HideHeader)
+	log.Ctx(ctx).Debug().Msgf("Found no-style header flag set to: %t", OL.Output.NoStyle)
+	log.Ctx(ctx).Debug().Msgf("Found output wide flag set to: %t", OL.Output.Wide)
+
+	// the jobs are filtered by the requester, so that only the jobs asked for are returned
+	listReq := publicapi.ListRequest{
+		JobID:       OL.IDFilter,
+		IncludeTags: OL.IncludeTags,
+		ExcludeTags: OL.ExcludeTags,
+		MaxJobs:     OL.MaxJobs,
+		ReturnAll:   OL.ReturnAll,
+		SortBy:      OL.SortBy.String(),
+		SortReverse: OL.SortReverse,
+		Labels:      OL.Labels,
+	}
+	for _, name := range OL.States {
+		state, err := model.ParseJobStateType(strings.TrimSpace(name))
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Invalid --state: %s", err), 1)
+			return nil
+		}
+		listReq.States = append(listReq.States, state)
+	}
+	if OL.Since < 0 {
+		Fatal(cmd, "--since must not be negative", 1)
+		return nil
+	} else if OL.Since > 0 {
+		createdAfter := time.Now().Add(-OL.Since)
+		listReq.CreatedAfter = &createdAfter
+	}
+
+	jobs, err := GetAPIClient().ListJobs(ctx, listReq)
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Error listing jobs: %s", err), 1)
 	}
@@ -176,62 +197,37 @@ func list(cmd *cobra.Command, OL *ListOptions) error {
 	numberInTable := system.Min(OL.MaxJobs, len(jobs))
 	log.Ctx(ctx).Debug().Msgf("Number of jobs printing: %d", numberInTable)
 
-	var msgBytes []byte
-	if OL.OutputFormat == JSONFormat {
-		msgBytes, err = model.JSONMarshalWithMax(jobs)
-		if err != nil {
-			Fatal(cmd, fmt.Sprintf("Error marshaling jobs to JSON: %s", err), 1)
-		}
-		cmd.Printf("%s\n", msgBytes)
-	} else {
-		tw := table.NewWriter()
-		tw.SetOutputMirror(cmd.OutOrStderr())
-		if !OL.HideHeader {
+	err = printOutput(cmd, OL.Output, jobs, func(w io.Writer) error {
+		tw := newOutputTable(w, OL.Output, table.StyleColoredGreenWhiteOnBlack)
+		if !OL.Output.HideHeader {
 			tw.AppendHeader(table.Row{"created", "id", "job", "state", "verified", "published"})
 		}
-		columnConfig := []table.ColumnConfig{}
-		tw.SetColumnConfigs(columnConfig)
-
-		var rows []table.Row
 		for _, j := range jobs {
-			var summaryRow table.Row
-			summaryRow, err = summarizeJob(j, OL)
+			summaryRow, err := summarizeJob(j, OL)
 			if err != nil {
-				Fatal(cmd, fmt.Sprintf("Error summarizing job: %s", err), 1)
+				return err
 			}
-			rows = append(rows, summaryRow)
-		}
-		if err != nil {
-			return err
-		}
-		tw.AppendRows(rows)
-
-		if OL.NoStyle {
-			tw.SetStyle(table.Style{
-				Name:   "StyleDefault",
-				Box:    table.StyleBoxDefault,
-				Color:  table.ColorOptionsDefault,
-				Format: table.FormatOptionsDefault,
-				HTML:   table.DefaultHTMLOptions,
-				Options: table.Options{
-					DrawBorder:      false,
-					SeparateColumns: false,
-					SeparateFooter:  false,
-					SeparateHeader:  false,
-					SeparateRows:    false,
-				},
-				Title: table.TitleOptionsDefault,
-			})
-		} else {
-			tw.SetStyle(table.StyleColoredGreenWhiteOnBlack)
+			tw.AppendRow(summaryRow)
 		}
-
 		tw.Render()
+		return nil
+	})
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error printing jobs: %s", err), 1)
 	}
 
 	return nil
 }
 
+// jobStateNames returns the names of the states jobs can be in.
+func jobStateNames() []string {
+	var names []string
+	for _, state := range model.JobStateTypes() {
+		names = append(names, state.String())
+	}
+	return names
+}
+
 // Renders job details into a table row
 func summarizeJob(j *model.JobWithInfo, OL *ListOptions) (table.Row, error) {
 	jobDesc := []string{
@@ -253,12 +249,12 @@ func summarizeJob(j *model.JobWithInfo, OL *ListOptions) (table.Row, error) {
 	resultSummary := job.ComputeResultsSummary(j)
 
 	row := table.Row{
-		shortenTime(OL.OutputWide, j.Job.Metadata.CreatedAt),
-		shortID(OL.OutputWide, j.Job.Metadata.ID),
-		shortenString(OL.OutputWide, strings.Join(jobDesc, " ")),
-		shortenString(OL.OutputWide, stateSummary),
-		shortenString(OL.OutputWide, verifiedSummary),
-		shortenString(OL.OutputWide, resultSummary),
+		shortenTime(OL.Output.Wide, j.Job.Metadata.CreatedAt),
+		shortID(OL.Output.Wide, j.Job.Metadata.ID),
+		shortenString(OL.Output.Wide, strings.Join(jobDesc, " ")),
+		shortenString(OL.Output.Wide, stateSummary),
+		shortenString(OL.Output.Wide, verifiedSummary),
+		shortenString(OL.Output.Wide, resultSummary),
 	}
 
 	return row, nil
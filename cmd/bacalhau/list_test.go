@@ -130,6 +130,41 @@ func (suite *ListSuite) TestList_IdFilter() {
 	require.Equal(suite.T(), 1, len(response.Jobs), "The list of jobs is not strictly filtered to the requested job id")
 }
 
+func (suite *ListSuite) TestList_NameFilter() {
+	ctx := context.Background()
+
+	j := testutils.MakeNoopJob()
+	j.Metadata.Name = "named-job"
+	named, err := suite.client.Submit(ctx, j)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "named-job", named.Metadata.Name)
+
+	// names are unique among the jobs of a namespace
+	_, err = suite.client.Submit(ctx, j)
+	require.ErrorContains(suite.T(), err, "already taken")
+
+	_, out, err := ExecuteTestCobraCommand("list",
+		"--api-host", suite.host,
+		"--api-port", fmt.Sprint(suite.port),
+		"--id-filter", "named-job",
+		"--output", "json",
+	)
+	require.NoError(suite.T(), err)
+
+	var jobs []*model.JobWithInfo
+	require.NoError(suite.T(), model.JSONUnmarshalWithMax([]byte(out), &jobs))
+	require.Len(suite.T(), jobs, 1)
+	require.Equal(suite.T(), named.Metadata.ID, jobs[0].Job.Metadata.ID)
+
+	_, out, err = ExecuteTestCobraCommand("describe",
+		"--api-host", suite.host,
+		"--api-port", fmt.Sprint(suite.port),
+		"named-job",
+	)
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), out, named.Metadata.ID)
+}
+
 func (suite *ListSuite) TestList_AnnotationFilter() {
 	type testCase struct {
 		Name                                              string
@@ -353,3 +388,49 @@ Compare Ids:
 		}
 	}
 }
+
+func (suite *ListSuite) TestList_FilterFlags() {
+	ctx := context.Background()
+
+	submit := func(annotations ...string) string {
+		j := testutils.MakeNoopJob()
+		j.Spec.Annotations = annotations
+		j, err := suite.client.Submit(ctx, j)
+		require.NoError(suite.T(), err)
+		return j.Metadata.ID
+	}
+	mlJob := submit("team=ml")
+	webJob := submit("team=web")
+
+	list := func(flags ...string) []string {
+		args := append([]string{"list",
+			"--api-host", suite.host,
+			"--api-port", fmt.Sprint(suite.port),
+			"--output", "json",
+		}, flags...)
+		_, out, err := ExecuteTestCobraCommand(args...)
+		require.NoError(suite.T(), err)
+
+		var jobs []*model.JobWithInfo
+		require.NoError(suite.T(), model.JSONUnmarshalWithMax([]byte(out), &jobs))
+		var ids []string
+		for _, j := range jobs {
+			ids = append(ids, j.Job.Metadata.ID)
+		}
+		return ids
+	}
+
+	require.Equal(suite.T(), []string{mlJob}, list("--labels", "team=ml"))
+	require.ElementsMatch(suite.T(), []string{mlJob, webJob}, list("--labels", "team", "--since", "1h"))
+	require.Empty(suite.T(), list("--labels", "team=ml", "--state", "Cancelled"))
+
+	_, out, err := ExecuteTestCobraCommand("list",
+		"--api-host", suite.host,
+		"--api-port", fmt.Sprint(suite.port),
+		"--state", "Unknown",
+	)
+	require.NoError(suite.T(), err)
+	fatalError, err := testutils.FirstFatalError(suite.T(), out)
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), fatalError.Message, "unknown job state")
+}
@@ -26,17 +26,22 @@ var (
 
 		# Follow output with a short ID 
 		bacalhau logs ebd9bf2f
+
+		# Follow the logs, starting from the last 100 lines
+		bacalhau logs --follow --tail 100 ebd9bf2f
 `))
 )
 
 type Msg struct {
 	Tag  uint8
 	Data string
+	Seq  uint64
 }
 
 type LogCommandOptions struct {
 	Follow      bool
 	WithHistory bool
+	Tail        int
 }
 
 func newLogsCmd() *cobra.Command {
@@ -57,6 +62,10 @@ func newLogsCmd() *cobra.Command {
 		&options.Follow, "follow", "f", false,
 		`Follow the logs in real-time after retrieving the current logs.`,
 	)
+	logsCmd.PersistentFlags().IntVarP(
+		&options.Tail, "tail", "n", 0,
+		`Only show the last N lines of the current logs. All of them are shown if 0.`,
+	)
 
 	return logsCmd
 }
@@ -105,7 +114,7 @@ func logs(cmd *cobra.Command, cmdArgs []string, options LogCommandOptions) error
 
 	// Get a websocket connection to the requester node from where we will be streamed
 	// any dataframes that are logged from the requested execution/job.
-	conn, err := apiClient.Logs(ctx, jobID, executionID, true, options.Follow)
+	conn, err := apiClient.Logs(ctx, jobID, executionID, true, options.Follow, options.Tail)
 	if err != nil {
 		if er, ok := err.(*bacerrors.ErrorResponse); ok {
 			Fatal(cmd, er.Error(), 1)
@@ -132,10 +141,11 @@ func readLogoutput(ctx context.Context, cmd *cobra.Command, conn *websocket.Conn
 
 	go func() {
 		var fd *os.File
-		var msg Msg
+		var lastSeq uint64
 
 		defer close(done)
 		for !exiting {
+			var msg Msg
 			err := conn.ReadJSON(&msg)
 			if err != nil {
 				// If the error is NOT a CloseNormal then log the error
@@ -144,6 +154,15 @@ func readLogoutput(ctx context.Context, cmd *cobra.Command, conn *websocket.Conn
 				}
 
 				exiting = true
+				break
+			}
+
+			// frames are numbered from 1 by the requesters that number them, so a repeated frame is skipped
+			if msg.Seq != 0 {
+				if msg.Seq <= lastSeq {
+					continue
+				}
+				lastSeq = msg.Seq
 			}
 
 			if msg.Tag == 1 {
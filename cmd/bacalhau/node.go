@@ -0,0 +1,25 @@
+package bacalhau
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newNodeCmd() *cobra.Command {
+	nodeCmd := &cobra.Command{
+		Use:               "node",
+		Short:             "Commands to query and manage bacalhau nodes",
+		PersistentPreRunE: checkVersion,
+	}
+
+	nodeCmd.AddCommand(
+		newNodeListCmd(),
+		newNodeAdminCmd(),
+		newNodeMigrateCmd(),
+		newNodeExportCmd(),
+		newNodeImportCmd(),
+		newNodeReserveCmd(),
+		newNodeReservationsCmd(),
+		newNodeReleaseCmd(),
+	)
+	return nodeCmd
+}
@@ -0,0 +1,342 @@
+package bacalhau
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/audit"
+	computenodeapi "github.com/bacalhau-project/bacalhau/pkg/compute/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	//nolint:lll // Documentation
+	nodeAdminLong = templates.LongDesc(i18n.T(`
+		Inspect and adjust a running compute node without restarting it.
+
+		Commands are sent to the node addressed by --api-host and --api-port, and are signed with your client key.
+		The node only accepts them if your client ID was passed to 'bacalhau serve --admin-client-id'.
+
+		With --node, commands are sent to the requester addressed by --api-host and --api-port, which forwards them to
+		the compute node with that ID. Both nodes must list your client ID as an admin, and the compute node must list
+		the requester in --trusted-requester.
+`))
+
+	//nolint:lll // Documentation
+	nodeAdminExample = templates.Examples(i18n.T(`
+		# Show whether the node is bidding, its capacity and the executions it is handling
+		bacalhau node admin status
+
+		# Stop the node from bidding on new jobs, e.g. before maintenance
+		bacalhau node admin bidding disable
+
+		# Lower the total CPU and memory the node will allocate to jobs
+		bacalhau node admin limits --cpu 2 --memory 4Gb
+
+		# Stop a compute node from bidding through the requester it is connected to
+		bacalhau node admin bidding disable --node QmNodeID
+
+		# Clear cached image tags and manifests
+		bacalhau node admin purge-caches
+
+		# Check the node can still run jobs, publish results and keep time
+		bacalhau node admin self-test --json
+
+		# Export the log of the executions the node performed and verify it was not tampered with
+		bacalhau node admin audit --json
+`))
+)
+
+type NodeAdminOptions struct {
+	JSON bool   // Print the node status as JSON
+	Node string // The compute node to administer through the requester, rather than directly
+}
+
+func NewNodeAdminOptions() *NodeAdminOptions {
+	return &NodeAdminOptions{
+		JSON: false,
+	}
+}
+
+func newNodeAdminCmd() *cobra.Command {
+	OA := NewNodeAdminOptions()
+
+	adminCmd := &cobra.Command{
+		Use:     "admin",
+		Short:   "Administer a running compute node",
+		Long:    nodeAdminLong,
+		Example: nodeAdminExample,
+	}
+	adminCmd.PersistentFlags().BoolVar(
+		&OA.JSON, "json", OA.JSON,
+		`Output the node status as JSON (if not included will be outputted as YAML by default)`,
+	)
+
+	statusCmd := &cobra.Command{
+		Use:    "status",
+		Short:  "Show the admin status of the node",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeAdmin(cmd, OA, compute.NodeAdminChanges{})
+		},
+	}
+
+	biddingCmd := &cobra.Command{
+		Use:       "bidding enable|disable",
+		Short:     "Enable or disable bidding on new jobs",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"enable", "disable"},
+		PreRun:    applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			enabled := cmdArgs[0] == "enable"
+			return nodeAdmin(cmd, OA, compute.NodeAdminChanges{BiddingEnabled: &enabled})
+		},
+	}
+
+	limits := model.ResourceUsageConfig{}
+	limitsCmd := &cobra.Command{
+		Use:    "limits",
+		Short:  "Adjust the total resource limits of the node",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeAdmin(cmd, OA, compute.NodeAdminChanges{TotalResourceLimits: &limits})
+		},
+	}
+	limitsCmd.Flags().StringVar(&limits.CPU, "cpu", limits.CPU, `Total CPU core limit to run all jobs (e.g. 500m, 2, 8).`)
+	limitsCmd.Flags().StringVar(&limits.Memory, "memory", limits.Memory, `Total Memory limit to run all jobs (e.g. 500Mb, 2Gb, 8Gb).`)
+	limitsCmd.Flags().StringVar(&limits.GPU, "gpu", limits.GPU, `Total GPU limit to run all jobs (e.g. 1, 2, or 8).`)
+
+	purgeCmd := &cobra.Command{
+		Use:    "purge-caches",
+		Short:  "Clear the caches held by the node",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeAdmin(cmd, OA, compute.NodeAdminChanges{PurgeCaches: true})
+		},
+	}
+
+	selfTestCmd := &cobra.Command{
+		Use:    "self-test",
+		Short:  "Run the self-test of the node and show its report",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeAdmin(cmd, OA, compute.NodeAdminChanges{SelfTest: true})
+		},
+	}
+
+	auditRequest := computenodeapi.AuditRequest{}
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Export and verify the log of the executions the node performed",
+		Long: `Export the log of the executions the node performed, with the hash of the spec, the image digest, the inputs,
+the outputs and the wall time of each of them. Entries are chained by their hashes and signed by the node, and
+the command verifies them itself. It fails if the chain is broken, i.e. if entries were modified or removed from
+the log, or if the log no longer goes through the last entry verified by a previous audit of the node.`,
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeAudit(cmd, OA, auditRequest)
+		},
+	}
+	auditCmd.Flags().Uint64Var(&auditRequest.FromSequence, "from", auditRequest.FromSequence,
+		`Only print the entries from this sequence number onwards. The log is verified regardless.`)
+
+	// the audit log is verified against the API endpoint of the node, so it can't be exported through the requester
+	for _, changeCmd := range []*cobra.Command{statusCmd, biddingCmd, limitsCmd, purgeCmd, selfTestCmd} {
+		changeCmd.Flags().StringVar(
+			&OA.Node, "node", OA.Node,
+			`The ID of a compute node to administer through the requester, rather than the node the API is served by`,
+		)
+	}
+
+	adminCmd.AddCommand(statusCmd, biddingCmd, limitsCmd, purgeCmd, selfTestCmd, auditCmd)
+	return adminCmd
+}
+
+// auditAnchor is the last entry of the audit log of a node verified by the CLI, which later audits of the node verify
+// the log still goes through.
+type auditAnchor struct {
+	NodeID string `json:"NodeID"`
+	audit.Anchor
+}
+
+func getAuditAnchorsPath() string {
+	return filepath.Join(config.GetConfigPath(), "audit-anchors.json")
+}
+
+// loadAuditAnchors returns the anchors of the audit logs verified before, by API endpoint of the nodes.
+func loadAuditAnchors() (map[string]auditAnchor, error) {
+	anchors := make(map[string]auditAnchor)
+	data, err := os.ReadFile(getAuditAnchorsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return anchors, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &anchors); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", getAuditAnchorsPath(), err)
+	}
+	return anchors, nil
+}
+
+func saveAuditAnchors(anchors map[string]auditAnchor) error {
+	data, err := json.Marshal(anchors)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getAuditAnchorsPath(), data, util.OS_USER_RW)
+}
+
+// verifyAuditLog verifies the entries exported by a node from the anchor, rather than trusting the node's own
+// verification, and returns the anchor to verify the next audit from.
+func verifyAuditLog(anchor auditAnchor, response computenodeapi.AuditResponse) (auditAnchor, error) {
+	if len(response.PublicKey) == 0 {
+		return anchor, errors.New("the node does not sign its audit log")
+	}
+	publicKey, err := crypto.UnmarshalPublicKey(response.PublicKey)
+	if err != nil {
+		return anchor, fmt.Errorf("invalid public key of the node: %w", err)
+	}
+	nodeID, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		return anchor, fmt.Errorf("invalid public key of the node: %w", err)
+	}
+	if nodeID.String() != response.NodeID {
+		return anchor, fmt.Errorf("the public key of the node is not the key of node %s", response.NodeID)
+	}
+	if anchor.NodeID != "" && anchor.NodeID != response.NodeID {
+		return anchor, fmt.Errorf("the node is %s, but the audit log verified before was the log of node %s",
+			response.NodeID, anchor.NodeID)
+	}
+	if err = audit.VerifyChainFrom(anchor.Anchor, response.Entries, publicKey); err != nil {
+		return anchor, err
+	}
+	anchor.NodeID = response.NodeID
+	if len(response.Entries) > 0 {
+		last := response.Entries[len(response.Entries)-1]
+		anchor.Anchor = audit.Anchor{Sequence: last.Sequence, Hash: last.Hash}
+	}
+	return anchor, nil
+}
+
+func nodeAudit(cmd *cobra.Command, OA *NodeAdminOptions, request computenodeapi.AuditRequest) error {
+	ctx := cmd.Context()
+
+	anchors, err := loadAuditAnchors()
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure loading verified audit logs: %s", err), 1)
+		return nil
+	}
+	endpoint := GetAPIHostAndPort()
+	anchor := anchors[endpoint]
+
+	// the entries are verified from the last one verified before, or from the start of the log
+	printFrom := request.FromSequence
+	request.FromSequence = anchor.Sequence
+	response, err := GetComputeAPIClient().Audit(ctx, request)
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error sending audit request to node: %+v", err), 1)
+		return nil
+	}
+	nextAnchor, verifyErr := verifyAuditLog(anchor, response)
+	if len(response.InvalidLines) > 0 {
+		cmd.PrintErrf("Warning: lines %v of the audit log of the node are not entries and were skipped\n", response.InvalidLines)
+	}
+
+	printed := response
+	printed.Entries = make([]audit.Entry, 0, len(response.Entries))
+	for _, entry := range response.Entries {
+		if entry.Sequence >= printFrom {
+			printed.Entries = append(printed.Entries, entry)
+		}
+	}
+	printed.Verified = verifyErr == nil
+	printed.VerificationError = ""
+	if verifyErr != nil {
+		printed.VerificationError = verifyErr.Error()
+	}
+
+	b, err := json.Marshal(printed)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling audit log: %s\n", err), 1)
+	}
+
+	if OA.JSON {
+		cmd.Print(string(b))
+	} else {
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Failure converting audit log to YAML: %s\n", err), 1)
+		}
+		cmd.Print(string(y))
+	}
+
+	if verifyErr != nil {
+		Fatal(cmd, fmt.Sprintf("Audit log failed verification: %s", verifyErr), 1)
+		return nil
+	}
+	anchors[endpoint] = nextAnchor
+	if err = saveAuditAnchors(anchors); err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure saving verified audit log: %s", err), 1)
+	}
+	return nil
+}
+
+func nodeAdmin(cmd *cobra.Command, OA *NodeAdminOptions, changes compute.NodeAdminChanges) error {
+	ctx := cmd.Context()
+
+	var status compute.AdminNodeResponse
+	var err error
+	if OA.Node != "" {
+		status, err = GetAPIClient().AdminNode(ctx, publicapi.NodeAdminRequest{NodeID: OA.Node, NodeAdminChanges: changes})
+	} else {
+		status, err = GetComputeAPIClient().Admin(ctx, computenodeapi.AdminRequest{NodeAdminChanges: changes})
+	}
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error sending admin request to node: %+v", err), 1)
+		return nil
+	}
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling node status: %s\n", err), 1)
+	}
+
+	if OA.JSON {
+		cmd.Print(string(b))
+		return nil
+	}
+
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure converting node status to YAML: %s\n", err), 1)
+	}
+	cmd.Print(string(y))
+	return nil
+}
@@ -0,0 +1,121 @@
+package bacalhau
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/c2h5oh/datasize"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	nodeListLong = templates.LongDesc(i18n.T(`
+		List the compute nodes known to the requester, with their labels, engines, capacity and the executions they
+		are running.
+
+		Capacity is shown as what is available out of the maximum of the node, as last reported by the node.
+`))
+
+	nodeListExample = templates.Examples(i18n.T(`
+		# List the compute nodes of the network
+		bacalhau node list
+
+		# List the compute nodes as JSON
+		bacalhau node list --output json`))
+)
+
+type NodeListOptions struct {
+	Output OutputOptions // How the list of nodes is printed
+}
+
+func NewNodeListOptions() *NodeListOptions {
+	return &NodeListOptions{
+		Output: NewOutputOptions(TableFormat),
+	}
+}
+
+func newNodeListCmd() *cobra.Command {
+	OL := NewNodeListOptions()
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the compute nodes known to the requester",
+		Long:    nodeListLong,
+		Example: nodeListExample,
+		Args:    cobra.NoArgs,
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeList(cmd, OL)
+		},
+	}
+
+	listCmd.Flags().AddFlagSet(OutputFormatFlags(&OL.Output))
+	listCmd.Flags().AddFlagSet(TableFlags(&OL.Output))
+
+	return listCmd
+}
+
+func nodeList(cmd *cobra.Command, OL *NodeListOptions) error {
+	if !validateOutput(cmd, &OL.Output) {
+		return nil
+	}
+
+	nodes, err := GetAPIClient().Nodes(cmd.Context())
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error listing nodes: %s", err), 1)
+		return nil
+	}
+
+	err = printOutput(cmd, OL.Output, nodes, func(w io.Writer) error {
+		tw := newOutputTable(w, OL.Output, table.StyleColoredGreenWhiteOnBlack)
+		if !OL.Output.HideHeader {
+			tw.AppendHeader(table.Row{"id", "engines", "labels", "cpu", "memory", "disk", "gpu", "running", "queued"})
+		}
+		for _, node := range nodes {
+			tw.AppendRow(summarizeNode(node, OL))
+		}
+		tw.Render()
+		return nil
+	})
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error printing nodes: %s", err), 1)
+	}
+	return nil
+}
+
+// Renders node details into a table row
+func summarizeNode(node model.NodeInfo, OL *NodeListOptions) table.Row {
+	labels := make([]string, 0, len(node.Labels))
+	for key, value := range node.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	info := node.ComputeNodeInfo
+	if info == nil {
+		info = &model.ComputeNodeInfo{}
+	}
+	engines := make([]string, 0, len(info.ExecutionEngines))
+	for _, engine := range info.ExecutionEngines {
+		engines = append(engines, engine.String())
+	}
+
+	available, maximum := info.AvailableCapacity, info.MaxCapacity
+	return table.Row{
+		shortID(OL.Output.Wide, node.PeerInfo.ID.String()),
+		strings.Join(engines, ","),
+		shortenString(OL.Output.Wide, strings.Join(labels, ",")),
+		fmt.Sprintf("%.1f/%.1f", available.CPU, maximum.CPU),
+		fmt.Sprintf("%s/%s", datasize.ByteSize(available.Memory).HR(), datasize.ByteSize(maximum.Memory).HR()),
+		fmt.Sprintf("%s/%s", datasize.ByteSize(available.Disk).HR(), datasize.ByteSize(maximum.Disk).HR()),
+		fmt.Sprintf("%d/%d", available.GPU, maximum.GPU),
+		info.RunningExecutions,
+		info.EnqueuedExecutions,
+	}
+}
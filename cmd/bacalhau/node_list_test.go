@@ -0,0 +1,68 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type NodeListSuite struct {
+	BaseSuite
+}
+
+func TestNodeListSuite(t *testing.T) {
+	suite.Run(t, new(NodeListSuite))
+}
+
+func (s *NodeListSuite) listNodes(args ...string) string {
+	args = append([]string{"node", "list", "--api-host", s.host, "--api-port", fmt.Sprint(s.port)}, args...)
+	var out string
+	// the requester learns about the compute node from the info it publishes
+	s.Require().Eventually(func() bool {
+		var err error
+		_, out, err = ExecuteTestCobraCommand(args...)
+		s.Require().NoError(err)
+		return strings.Contains(out, s.node.Host.ID().String()[:model.ShortIDLength])
+	}, 10*time.Second, 100*time.Millisecond)
+	return out
+}
+
+func (s *NodeListSuite) TestListNodesAsTable() {
+	out := s.listNodes("--hide-header", "--no-style")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	s.Require().Len(lines, 1)
+	s.Require().Contains(lines[0], model.EngineWasm.String())
+}
+
+func (s *NodeListSuite) TestListNodesAsJSON() {
+	out := s.listNodes("--output", "json")
+	var nodes []model.NodeInfo
+	s.Require().NoError(json.Unmarshal([]byte(out), &nodes))
+	s.Require().Len(nodes, 1)
+	s.Require().Equal(s.node.Host.ID(), nodes[0].PeerInfo.ID)
+	s.Require().NotNil(nodes[0].ComputeNodeInfo)
+}
+
+func (s *NodeListSuite) TestListNodesAsYAML() {
+	out := s.listNodes("--output", "yaml")
+	var nodes []model.NodeInfo
+	s.Require().NoError(model.YAMLUnmarshalWithMax([]byte(out), &nodes))
+	s.Require().Len(nodes, 1)
+	s.Require().Equal(s.node.Host.ID(), nodes[0].PeerInfo.ID)
+}
+
+func (s *NodeListSuite) TestUnsupportedOutputFormat() {
+	_, out, err := ExecuteTestCobraCommand("node", "list", "--api-host", s.host, "--api-port", fmt.Sprint(s.port), "--output", "xml")
+	s.Require().NoError(err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	s.Require().NoError(err)
+	s.Require().Contains(fatalError.Message, "Unsupported output format")
+}
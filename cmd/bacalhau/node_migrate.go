@@ -0,0 +1,114 @@
+package bacalhau
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	//nolint:lll // Documentation
+	nodeMigrateLong = templates.LongDesc(i18n.T(`
+		Move the executions of a compute node that have not started yet to other nodes.
+
+		This is meant for incidents where a node is degraded but still reachable. Executions still waiting for a bid,
+		and accepted executions the node reports as queued, are moved to other nodes. Other nodes are asked to bid in
+		their place, and each execution is only canceled on the node once another node accepts to take it over.
+		Executions that are already running are left alone. The node is not chosen again for the jobs whose executions
+		were moved.
+
+		The request is sent to the requester node addressed by --api-host and --api-port, and is signed with your
+		client key. The requester only accepts it if your client ID was passed to 'bacalhau serve --admin-client-id'.
+`))
+
+	//nolint:lll // Documentation
+	nodeMigrateExample = templates.Examples(i18n.T(`
+		# Show which executions would be moved away from a node, and where to
+		bacalhau node migrate QmXaXu9N5GNetatsvwnTfQqNtSeKAD6uCmarbh3LMRYAcF --dry-run
+
+		# Move the queued executions of a single job away from a node
+		bacalhau node migrate QmXaXu9N5GNetatsvwnTfQqNtSeKAD6uCmarbh3LMRYAcF --job 51225160-807e-48b8-88c9-28311c7899e1 --reason "disk failing"
+`))
+)
+
+type NodeMigrateOptions struct {
+	JobID  string // Only migrate the executions of this job
+	Reason string // Reason recorded in the history of the jobs
+	DryRun bool   // List the executions that would be migrated without moving them
+	JSON   bool   // Print the migrated executions as JSON
+}
+
+func NewNodeMigrateOptions() *NodeMigrateOptions {
+	return &NodeMigrateOptions{
+		JSON: false,
+	}
+}
+
+func newNodeMigrateCmd() *cobra.Command {
+	OM := NewNodeMigrateOptions()
+
+	migrateCmd := &cobra.Command{
+		Use:     "migrate [node-id]",
+		Short:   "Move the queued executions of a compute node to other nodes",
+		Long:    nodeMigrateLong,
+		Example: nodeMigrateExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return nodeMigrate(cmd, cmdArgs[0], OM)
+		},
+	}
+	migrateCmd.Flags().StringVar(&OM.JobID, "job", OM.JobID, `Only migrate the executions of this job.`)
+	migrateCmd.Flags().StringVar(&OM.Reason, "reason", OM.Reason, `Reason for the migration, recorded in the history of the jobs.`)
+	migrateCmd.Flags().BoolVar(
+		&OM.DryRun, "dry-run", OM.DryRun,
+		`List the executions that would be migrated and where to, without moving them.`,
+	)
+	migrateCmd.Flags().BoolVar(
+		&OM.JSON, "json", OM.JSON,
+		`Output the migrated executions as JSON (if not included will be outputted as YAML by default)`,
+	)
+	return migrateCmd
+}
+
+func nodeMigrate(cmd *cobra.Command, nodeID string, OM *NodeMigrateOptions) error {
+	ctx := cmd.Context()
+
+	response, err := GetAPIClient().Migrate(ctx, publicapi.MigrateRequest{
+		NodeID: nodeID,
+		JobID:  OM.JobID,
+		Reason: OM.Reason,
+		DryRun: OM.DryRun,
+	})
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error sending migrate request to requester: %+v", err), 1)
+		return nil
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling migrated executions: %s\n", err), 1)
+	}
+
+	if OM.JSON {
+		cmd.Print(string(b))
+		return nil
+	}
+
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure converting migrated executions to YAML: %s\n", err), 1)
+	}
+	cmd.Print(string(y))
+	return nil
+}
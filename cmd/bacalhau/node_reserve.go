@@ -0,0 +1,181 @@
+package bacalhau
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	//nolint:lll // Documentation
+	nodeReserveLong = templates.LongDesc(i18n.T(`
+		Reserve capacity across the network for a time window, ahead of large jobs.
+
+		The requester asks compute nodes to hold parts of the capacity, starting with the nodes that have the most
+		capacity left. While the reservation is active, the nodes don't offer the capacity it holds to other jobs. Jobs
+		submitted by you with the token of the reservation, e.g. with 'bacalhau docker run --reservation TOKEN', run on
+		the nodes holding it and can use its capacity. Each job must fit in what a single node holds.
+
+		The token is only shown once. The request is signed with your client key, and the requester only accepts it if
+		your client ID was passed to 'bacalhau serve --admin-client-id'.
+`))
+
+	//nolint:lll // Documentation
+	nodeReserveExample = templates.Examples(i18n.T(`
+		# Reserve 32 CPUs and 4 GPUs for the next 6 hours
+		bacalhau node reserve --cpu 32 --gpu 4 --duration 6h
+
+		# Reserve 64 CPUs for 2 hours, starting tomorrow morning
+		bacalhau node reserve --cpu 64 --start 2023-07-01T08:00:00Z --duration 2h
+`))
+)
+
+type NodeReserveOptions struct {
+	CPU      string        // CPUs to reserve
+	GPU      string        // GPUs to reserve
+	Start    string        // When the reservation starts, in RFC3339
+	Duration time.Duration // How long the reservation lasts
+	JSON     bool          // Print the reservation as JSON
+}
+
+func NewNodeReserveOptions() *NodeReserveOptions {
+	return &NodeReserveOptions{
+		Duration: time.Hour,
+	}
+}
+
+func newNodeReserveCmd() *cobra.Command {
+	OR := NewNodeReserveOptions()
+
+	reserveCmd := &cobra.Command{
+		Use:     "reserve",
+		Short:   "Reserve capacity across the network for a time window",
+		Long:    nodeReserveLong,
+		Example: nodeReserveExample,
+		Args:    cobra.NoArgs,
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeReserve(cmd, OR)
+		},
+	}
+	reserveCmd.Flags().StringVar(&OR.CPU, "cpu", OR.CPU, `CPU cores to reserve (e.g. 500m, 2, 64).`)
+	reserveCmd.Flags().StringVar(&OR.GPU, "gpu", OR.GPU, `GPUs to reserve (e.g. 1, 4).`)
+	reserveCmd.Flags().StringVar(&OR.Start, "start", OR.Start, `When the reservation starts, in RFC3339 format. Now if not set.`)
+	reserveCmd.Flags().DurationVar(&OR.Duration, "duration", OR.Duration, `How long the reservation lasts.`)
+	reserveCmd.Flags().BoolVar(
+		&OR.JSON, "json", OR.JSON,
+		`Output the reservation as JSON (if not included will be outputted as YAML by default)`,
+	)
+	return reserveCmd
+}
+
+func nodeReserve(cmd *cobra.Command, OR *NodeReserveOptions) error {
+	ctx := cmd.Context()
+
+	start := time.Now()
+	if OR.Start != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, OR.Start); err != nil {
+			Fatal(cmd, fmt.Sprintf("Invalid start time %q: %s", OR.Start, err), 1)
+			return nil
+		}
+	}
+	if OR.Duration <= 0 {
+		Fatal(cmd, "The duration of the reservation must be positive", 1)
+		return nil
+	}
+
+	reservation, err := GetAPIClient().Reserve(ctx, publicapi.ReserveRequest{
+		CPU:   OR.CPU,
+		GPU:   OR.GPU,
+		Start: start,
+		End:   start.Add(OR.Duration),
+	})
+	if err != nil {
+		fatalAdminError(cmd, "reserve", err)
+		return nil
+	}
+	printAdminResponse(cmd, reservation, OR.JSON)
+	return nil
+}
+
+func newNodeReservationsCmd() *cobra.Command {
+	var outputJSON bool
+	reservationsCmd := &cobra.Command{
+		Use:    "reservations",
+		Short:  "List the capacity reservations that have not ended yet",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reservations, err := GetAPIClient().Reservations(cmd.Context())
+			if err != nil {
+				fatalAdminError(cmd, "reservations", err)
+				return nil
+			}
+			printAdminResponse(cmd, reservations, outputJSON)
+			return nil
+		},
+	}
+	reservationsCmd.Flags().BoolVar(
+		&outputJSON, "json", outputJSON,
+		`Output the reservations as JSON (if not included will be outputted as YAML by default)`,
+	)
+	return reservationsCmd
+}
+
+func newNodeReleaseCmd() *cobra.Command {
+	var outputJSON bool
+	releaseCmd := &cobra.Command{
+		Use:    "release [reservation-id]",
+		Short:  "Release a capacity reservation before its time window is over",
+		Args:   cobra.ExactArgs(1),
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			reservation, err := GetAPIClient().Release(cmd.Context(), cmdArgs[0])
+			if err != nil {
+				fatalAdminError(cmd, "release", err)
+				return nil
+			}
+			printAdminResponse(cmd, reservation, outputJSON)
+			return nil
+		},
+	}
+	releaseCmd.Flags().BoolVar(
+		&outputJSON, "json", outputJSON,
+		`Output the released reservation as JSON (if not included will be outputted as YAML by default)`,
+	)
+	return releaseCmd
+}
+
+func fatalAdminError(cmd *cobra.Command, request string, err error) {
+	if er, ok := err.(*bacerrors.ErrorResponse); ok {
+		Fatal(cmd, er.Message, 1)
+		return
+	}
+	Fatal(cmd, fmt.Sprintf("Unknown error sending %s request to requester: %+v", request, err), 1)
+}
+
+func printAdminResponse(cmd *cobra.Command, response interface{}, outputJSON bool) {
+	b, err := json.Marshal(response)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling response: %s\n", err), 1)
+	}
+
+	if outputJSON {
+		cmd.Print(string(b))
+		return
+	}
+
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure converting response to YAML: %s\n", err), 1)
+	}
+	cmd.Print(string(y))
+}
@@ -0,0 +1,176 @@
+package bacalhau
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	nodeExportLong = templates.LongDesc(i18n.T(`
+		Export every job of a requester, with its state, executions and history, as a snapshot.
+
+		The snapshot can be imported into another requester with 'bacalhau node import', to move a requester to
+		another host or to recover its jobs after its host is lost. Export snapshots regularly if the jobs of the
+		requester need to survive the loss of its host.
+
+		The request is sent to the requester node addressed by --api-host and --api-port, and is signed with your
+		client key. The requester only accepts it if your client ID was passed to 'bacalhau serve --admin-client-id'.
+`))
+
+	//nolint:lll // Documentation
+	nodeExportExample = templates.Examples(i18n.T(`
+		# Export the jobs of the requester to a file
+		bacalhau node export --output requester-snapshot.json
+`))
+
+	//nolint:lll // Documentation
+	nodeImportLong = templates.LongDesc(i18n.T(`
+		Import a snapshot exported from another requester with 'bacalhau node export'.
+
+		The jobs of the snapshot are added with their state, executions and history. Jobs the requester already has
+		are skipped by default, which makes importing the same snapshot again safe. Use --on-conflict overwrite to
+		replace them with the jobs of the snapshot, or --on-conflict fail to import nothing if there are any.
+
+		Snapshots exported by a newer release of bacalhau are refused, unless --allow-newer is set. Jobs that were
+		in progress when the snapshot was exported stay in progress. They are only timed out by the requester if it
+		runs with the identity of the requester that exported them, e.g. when its keys were moved to the new host.
+
+		The request is sent to the requester node addressed by --api-host and --api-port, and is signed with your
+		client key. The requester only accepts it if your client ID was passed to 'bacalhau serve --admin-client-id'.
+`))
+
+	//nolint:lll // Documentation
+	nodeImportExample = templates.Examples(i18n.T(`
+		# Import the jobs of a snapshot, keeping the jobs the requester already has
+		bacalhau node import requester-snapshot.json
+
+		# Import the jobs of a snapshot, refusing to import anything if the requester already has some of them
+		bacalhau node import requester-snapshot.json --on-conflict fail
+`))
+)
+
+type NodeExportOptions struct {
+	OutputFile string // Where to write the snapshot to
+}
+
+func NewNodeExportOptions() *NodeExportOptions {
+	return &NodeExportOptions{}
+}
+
+func newNodeExportCmd() *cobra.Command {
+	OE := NewNodeExportOptions()
+
+	exportCmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export every job of the requester as a snapshot",
+		Long:    nodeExportLong,
+		Example: nodeExportExample,
+		Args:    cobra.NoArgs,
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return nodeExport(cmd, OE)
+		},
+	}
+	exportCmd.Flags().StringVarP(&OE.OutputFile, "output", "o", OE.OutputFile,
+		`File to write the snapshot to. Defaults to bacalhau-snapshot-<timestamp>.json in the current directory.`)
+	return exportCmd
+}
+
+func nodeExport(cmd *cobra.Command, OE *NodeExportOptions) error {
+	snapshot, err := GetAPIClient().Export(cmd.Context())
+	if err != nil {
+		fatalAdminError(cmd, "export", err)
+		return nil
+	}
+
+	outputFile := OE.OutputFile
+	if outputFile == "" {
+		outputFile = fmt.Sprintf("bacalhau-snapshot-%s.json", snapshot.CreatedAt.Format("20060102T150405Z"))
+	}
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Failure marshaling snapshot: %s", err), 1)
+		return nil
+	}
+	if err = os.WriteFile(outputFile, b, 0o600); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error writing snapshot: %s", err), 1)
+		return nil
+	}
+	cmd.Printf("Snapshot of %d jobs has been written to %s\n", len(snapshot.Jobs), outputFile)
+	return nil
+}
+
+type NodeImportOptions struct {
+	OnConflict string // What happens to jobs the requester already has
+	AllowNewer bool   // Import snapshots exported by a newer release
+	JSON       bool   // Print the result as JSON
+}
+
+func NewNodeImportOptions() *NodeImportOptions {
+	return &NodeImportOptions{
+		OnConflict: string(jobstore.ConflictPolicySkip),
+	}
+}
+
+func newNodeImportCmd() *cobra.Command {
+	OI := NewNodeImportOptions()
+
+	importCmd := &cobra.Command{
+		Use:     "import [snapshot-file]",
+		Short:   "Import a snapshot of the jobs of another requester",
+		Long:    nodeImportLong,
+		Example: nodeImportExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return nodeImport(cmd, cmdArgs[0], OI)
+		},
+	}
+	importCmd.Flags().StringVar(&OI.OnConflict, "on-conflict", OI.OnConflict,
+		`What to do with jobs the requester already has: skip, overwrite or fail.`)
+	importCmd.Flags().BoolVar(&OI.AllowNewer, "allow-newer", OI.AllowNewer,
+		`Import snapshots exported by a newer release of bacalhau than the requester's.`)
+	importCmd.Flags().BoolVar(
+		&OI.JSON, "json", OI.JSON,
+		`Output the result of the import as JSON (if not included will be outputted as YAML by default)`,
+	)
+	return importCmd
+}
+
+func nodeImport(cmd *cobra.Command, snapshotFile string, OI *NodeImportOptions) error {
+	onConflict, err := jobstore.ParseConflictPolicy(OI.OnConflict)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Invalid --on-conflict: %s", err), 1)
+		return nil
+	}
+	b, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error reading snapshot: %s", err), 1)
+		return nil
+	}
+	var snapshot jobstore.Snapshot
+	if err = json.Unmarshal(b, &snapshot); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error parsing snapshot %s: %s", snapshotFile, err), 1)
+		return nil
+	}
+
+	result, err := GetAPIClient().Import(cmd.Context(), publicapi.ImportRequest{
+		Snapshot:   snapshot,
+		OnConflict: onConflict,
+		AllowNewer: OI.AllowNewer,
+	})
+	if err != nil {
+		fatalAdminError(cmd, "import", err)
+		return nil
+	}
+	printAdminResponse(cmd, result, OI.JSON)
+	return nil
+}
@@ -0,0 +1,88 @@
+package opts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	flag "github.com/spf13/pflag"
+)
+
+// compile-time check to ensure type implements the flag.Value interface
+var _ flag.Value = &OutputContractOpt{}
+
+// OutputContractOpt holds the files a job is expected to produce, given as path[,schema=file][,columns=a;b;c] where
+// path is in the results of the job, schema is a local file with a JSON Schema the file must match, and columns are
+// the columns the header of a CSV file must have.
+type OutputContractOpt struct {
+	values []model.OutputContract
+}
+
+func (o *OutputContractOpt) Set(value string) error {
+	fields, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return err
+	}
+
+	var contract model.OutputContract
+	for i, field := range fields {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			if i == 0 {
+				contract.Path = field
+				continue
+			}
+			return fmt.Errorf("invalid output contract option: %s. Must be a key=value pair", field)
+		}
+
+		switch strings.ToLower(key) {
+		case "path":
+			contract.Path = val
+		case "schema":
+			contract.JSONSchema, err = readJSONSchema(val)
+			if err != nil {
+				return err
+			}
+		case "columns":
+			contract.CSVColumns = strings.Split(val, ";")
+		default:
+			return fmt.Errorf("invalid output contract option: %s", field)
+		}
+	}
+	if contract.Path == "" {
+		return fmt.Errorf("invalid output contract: %s. Must start with the path of the expected file", value)
+	}
+	o.values = append(o.values, contract)
+	return nil
+}
+
+func readJSONSchema(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON schema: %w", err)
+	}
+	var schema map[string]interface{}
+	if err = json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("JSON schema %s is not a JSON object: %w", path, err)
+	}
+	return schema, nil
+}
+
+func (o *OutputContractOpt) Type() string {
+	return "output-contract"
+}
+
+func (o *OutputContractOpt) String() string {
+	paths := make([]string, 0, len(o.values))
+	for _, contract := range o.values {
+		paths = append(paths, contract.Path)
+	}
+	return strings.Join(paths, ",")
+}
+
+func (o *OutputContractOpt) Values() []model.OutputContract {
+	return o.values
+}
@@ -0,0 +1,40 @@
+//go:build unit || !integration
+
+package opts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputContract(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["total"]}`), 0600))
+
+	var opt OutputContractOpt
+	require.NoError(t, opt.Set("outputs/done"))
+	require.NoError(t, opt.Set("outputs/result.json,schema="+schemaPath))
+	require.NoError(t, opt.Set("path=outputs/table.csv,columns=id;name"))
+	require.Equal(t, []model.OutputContract{
+		{Path: "outputs/done"},
+		{
+			Path:       "outputs/result.json",
+			JSONSchema: map[string]interface{}{"type": "object", "required": []interface{}{"total"}},
+		},
+		{Path: "outputs/table.csv", CSVColumns: []string{"id", "name"}},
+	}, opt.Values())
+	require.Equal(t, "outputs/done,outputs/result.json,outputs/table.csv", opt.String())
+
+	for _, invalid := range []string{
+		"columns=id",
+		"outputs/result.json,schema=" + filepath.Join(t.TempDir(), "missing.json"),
+		"outputs/result.json,format=json",
+		"outputs/result.json,json",
+	} {
+		require.Error(t, (&OutputContractOpt{}).Set(invalid), invalid)
+	}
+}
@@ -3,6 +3,7 @@ package opts
 import (
 	"encoding/csv"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/job"
@@ -72,3 +73,45 @@ func (o *PublisherOpt) String() string {
 func (o *PublisherOpt) Value() model.PublisherSpec {
 	return o.value
 }
+
+// compile-time check to ensure type implements the flag.Value interface
+var _ flag.Value = &OutputPublishersOpt{}
+
+// OutputPublishersOpt holds the publishers of output volumes that are published on their own, given as
+// name=publisher where publisher is in the same format as PublisherOpt.
+type OutputPublishersOpt struct {
+	values map[string]model.PublisherSpec
+}
+
+func (o *OutputPublishersOpt) Set(value string) error {
+	name, publisher, ok := strings.Cut(value, "=")
+	if !ok || name == "" || publisher == "" {
+		return fmt.Errorf("invalid output publisher: %s. Must be name=publisher", value)
+	}
+	var opt PublisherOpt
+	if err := opt.Set(publisher); err != nil {
+		return err
+	}
+	if o.values == nil {
+		o.values = make(map[string]model.PublisherSpec)
+	}
+	o.values[name] = opt.Value()
+	return nil
+}
+
+func (o *OutputPublishersOpt) Type() string {
+	return "output-publisher"
+}
+
+func (o *OutputPublishersOpt) String() string {
+	var publishers []string
+	for name, spec := range o.values {
+		publishers = append(publishers, name+"="+spec.Type.String())
+	}
+	sort.Strings(publishers)
+	return strings.Join(publishers, ",")
+}
+
+func (o *OutputPublishersOpt) Values() map[string]model.PublisherSpec {
+	return o.values
+}
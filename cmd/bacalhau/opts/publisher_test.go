@@ -84,3 +84,24 @@ func TestParsePublisher(t *testing.T) {
 		})
 	}
 }
+
+func TestParseOutputPublishers(t *testing.T) {
+	opt := OutputPublishersOpt{}
+	require.NoError(t, opt.Set("model=s3://myBucket/model/,opt=region=us-east-1"))
+	require.NoError(t, opt.Set("metrics=ipfs"))
+	assert.Equal(t, map[string]model.PublisherSpec{
+		"model": {
+			Type: model.PublisherS3,
+			Params: map[string]interface{}{
+				"bucket": "myBucket",
+				"key":    "model/",
+				"region": "us-east-1",
+			},
+		},
+		"metrics": {Type: model.PublisherIpfs},
+	}, opt.Values())
+	assert.Equal(t, "metrics=Ipfs,model=S3", opt.String())
+
+	require.Error(t, opt.Set("ipfs"))
+	require.Error(t, opt.Set("metrics="))
+}
@@ -0,0 +1,117 @@
+package bacalhau
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// textFormat is the name list and node list used to give to the table format, which is still accepted.
+const textFormat = "text"
+
+// OutputOptions are the options of the commands that print a result, either as a table for people to read or as JSON
+// or YAML for scripts. JSON and YAML use the field names of the API types, so they are stable across releases.
+type OutputOptions struct {
+	Format     string // The format of the output: table, json or yaml
+	HideHeader bool   // Hide the column headers of tables
+	NoStyle    bool   // Remove all styling from tables
+	Wide       bool   // Print full values in tables
+}
+
+func NewOutputOptions(format string) OutputOptions {
+	return OutputOptions{Format: format}
+}
+
+// OutputFormatFlags returns the --output flag shared by the commands that print a result.
+func OutputFormatFlags(settings *OutputOptions) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Output format", pflag.ContinueOnError)
+	flags.StringVarP(
+		&settings.Format, "output", "o", settings.Format,
+		fmt.Sprintf(`The format of the output, one of %s, %s or %s.`, TableFormat, JSONFormat, YAMLFormat),
+	)
+	return flags
+}
+
+// TableFlags returns the flags changing how commands print their result as a table.
+func TableFlags(settings *OutputOptions) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Table output", pflag.ContinueOnError)
+	flags.BoolVar(&settings.HideHeader, "hide-header", settings.HideHeader, `do not print the column headers.`)
+	flags.BoolVar(&settings.NoStyle, "no-style", settings.NoStyle, `remove all styling from table output.`)
+	flags.BoolVar(&settings.Wide, "wide", settings.Wide, `Print full values in the table results`)
+	return flags
+}
+
+// Validate normalizes the format of the output and returns an error if it is not supported.
+func (o *OutputOptions) Validate() error {
+	o.Format = strings.TrimSpace(strings.ToLower(o.Format))
+	if o.Format == textFormat {
+		o.Format = TableFormat
+	}
+	switch o.Format {
+	case TableFormat, JSONFormat, YAMLFormat:
+		return nil
+	default:
+		return fmt.Errorf("--output must be one of %s, %s or %s, got %q", TableFormat, JSONFormat, YAMLFormat, o.Format)
+	}
+}
+
+// validateOutput fails the command if the format of its output is not supported.
+func validateOutput(cmd *cobra.Command, OO *OutputOptions) bool {
+	if err := OO.Validate(); err != nil {
+		Fatal(cmd, fmt.Sprintf("Unsupported output format: %s", err), 1)
+		return false
+	}
+	return true
+}
+
+// printOutput prints the result of a command to its output as JSON or YAML, or as the table printed by printTable.
+func printOutput(cmd *cobra.Command, OO OutputOptions, result interface{}, printTable func(w io.Writer) error) error {
+	switch OO.Format {
+	case JSONFormat:
+		b, err := model.JSONMarshalWithMax(result)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", b)
+	case YAMLFormat:
+		b, err := model.YAMLMarshalWithMax(result)
+		if err != nil {
+			return err
+		}
+		cmd.Print(string(b))
+	default:
+		return printTable(cmd.OutOrStdout())
+	}
+	return nil
+}
+
+// newOutputTable returns a table writing to w in the given style, or without any styling if the options ask for it.
+func newOutputTable(w io.Writer, OO OutputOptions, style table.Style) table.Writer {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	if OO.NoStyle {
+		tw.SetStyle(table.Style{
+			Name:   "StyleDefault",
+			Box:    table.StyleBoxDefault,
+			Color:  table.ColorOptionsDefault,
+			Format: table.FormatOptionsDefault,
+			HTML:   table.DefaultHTMLOptions,
+			Options: table.Options{
+				DrawBorder:      false,
+				SeparateColumns: false,
+				SeparateFooter:  false,
+				SeparateHeader:  false,
+				SeparateRows:    false,
+			},
+			Title: table.TitleOptionsDefault,
+		})
+	} else {
+		tw.SetStyle(style)
+	}
+	return tw
+}
@@ -0,0 +1,56 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputOptionsValidate(t *testing.T) {
+	for format, expected := range map[string]string{
+		"table":  TableFormat,
+		"text":   TableFormat,
+		" JSON ": JSONFormat,
+		"yaml":   YAMLFormat,
+	} {
+		OO := NewOutputOptions(format)
+		require.NoError(t, OO.Validate(), format)
+		require.Equal(t, expected, OO.Format)
+	}
+
+	OO := NewOutputOptions("xml")
+	require.Error(t, OO.Validate())
+}
+
+func TestPrintOutput(t *testing.T) {
+	result := GetResult{JobID: "92d5d4ee", OutputDir: "/tmp/results"}
+	render := func(format string) string {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&out)
+		err := printOutput(cmd, NewOutputOptions(format), result, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "%s in %s\n", result.JobID, result.OutputDir)
+			return err
+		})
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	require.Equal(t, "92d5d4ee in /tmp/results\n", render(TableFormat))
+
+	var fromJSON map[string]string
+	require.NoError(t, json.Unmarshal([]byte(render(JSONFormat)), &fromJSON))
+	require.Equal(t, map[string]string{"JobID": "92d5d4ee", "OutputDir": "/tmp/results"}, fromJSON)
+
+	var fromYAML GetResult
+	require.NoError(t, model.YAMLUnmarshalWithMax([]byte(render(YAMLFormat)), &fromYAML))
+	require.Equal(t, result, fromYAML)
+}
@@ -0,0 +1,161 @@
+package bacalhau
+
+import (
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/cmd/bacalhau/opts"
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	resubmitLong = templates.LongDesc(i18n.T(`
+		Submit a new job with the spec of a previously submitted job, such as one that failed.
+
+		The spec is the one stored by the requester, so the new job runs the same image, inputs and settings as the
+		original, including the image digest the requester pinned it to, if any. The concurrency, the inputs and the
+		tag of the docker image can be overridden. The name of the original job is not reused, as names are unique.
+`))
+
+	//nolint:lll // Documentation
+	resubmitExample = templates.Examples(i18n.T(`
+		# Run a job again
+		bacalhau resubmit 51225160
+
+		# Run a job again on three nodes, with another version of its image
+		bacalhau resubmit 51225160 --concurrency 3 --image-tag 22.04
+
+		# Run a job again with other inputs
+		bacalhau resubmit 51225160 -i ipfs://QmeZRGhe4PmjctYVSVHuEiA9oSXnqmYa4kQubSHgWbjv72
+
+		# Print the job that would be submitted, without submitting it
+		bacalhau resubmit 51225160 --dry-run
+`))
+)
+
+type ResubmitOptions struct {
+	Concurrency     int                      // Number of nodes to run the job on, if overridden
+	Inputs          opts.StorageOpt          // Inputs replacing those of the original job, if any
+	ImageTag        string                   // Tag replacing the tag of the docker image of the original job
+	DryRun          bool                     // Print the job instead of submitting it
+	RunTimeSettings RunTimeSettings          // Settings for running the job
+	DownloadFlags   model.DownloaderSettings // Settings for downloading the results
+}
+
+func NewResubmitOptions() *ResubmitOptions {
+	return &ResubmitOptions{
+		Concurrency:     1,
+		Inputs:          opts.StorageOpt{},
+		DownloadFlags:   *util.NewDownloadSettings(),
+		RunTimeSettings: *NewRunTimeSettings(),
+	}
+}
+
+func newResubmitCmd() *cobra.Command {
+	OR := NewResubmitOptions()
+
+	resubmitCmd := &cobra.Command{
+		Use:     "resubmit [id]",
+		Aliases: []string{"clone"},
+		Short:   "Submit a new job with the spec of a previous job",
+		Long:    resubmitLong,
+		Example: resubmitExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return resubmit(cmd, cmdArgs[0], OR)
+		},
+	}
+
+	resubmitCmd.Flags().IntVarP(&OR.Concurrency, "concurrency", "c", OR.Concurrency,
+		`How many nodes should run the job, instead of as many as for the original job.`)
+	resubmitCmd.Flags().VarP(&OR.Inputs, "input", "i",
+		`Input replacing the inputs of the original job. Can be specified multiple times, in the format of docker run.`)
+	resubmitCmd.Flags().StringVar(&OR.ImageTag, "image-tag", OR.ImageTag,
+		`Tag of the docker image to run instead of the tag or digest of the image of the original job.`)
+	resubmitCmd.Flags().BoolVar(&OR.DryRun, "dry-run", OR.DryRun,
+		`Do not submit the job, but instead print out what will be submitted`)
+	resubmitCmd.Flags().AddFlagSet(NewRunTimeSettingsFlags(&OR.RunTimeSettings))
+	resubmitCmd.Flags().AddFlagSet(NewIPFSDownloadFlags(&OR.DownloadFlags))
+
+	return resubmitCmd
+}
+
+func resubmit(cmd *cobra.Command, jobID string, OR *ResubmitOptions) error {
+	ctx := cmd.Context()
+	cm := ctx.Value(systemManagerKey).(*system.CleanupManager)
+
+	original, found, err := GetAPIClient().Get(ctx, jobID)
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Unknown error trying to get job (ID: %s): %+v", jobID, err), 1)
+		return nil
+	}
+	if !found {
+		Fatal(cmd, bacerrors.NewJobNotFound(jobID).Error(), 1)
+		return nil
+	}
+
+	j, err := cloneJob(&original.Job, OR, cmd.Flags().Changed("concurrency"))
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error resubmitting job %s: %s", jobID, err), 1)
+		return nil
+	}
+
+	if OR.DryRun {
+		yamlBytes, err := yaml.Marshal(j)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error converting job to yaml: %s", err), 1)
+			return nil
+		}
+		cmd.Print(string(yamlBytes))
+		return nil
+	}
+
+	if err = ExecuteJob(ctx, cm, cmd, j, OR.RunTimeSettings, OR.DownloadFlags); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error executing job: %s", err), 1)
+	}
+	return nil
+}
+
+// cloneJob returns a new job with the spec of the original job, and the overrides of the options.
+func cloneJob(original *model.Job, OR *ResubmitOptions, overrideConcurrency bool) (*model.Job, error) {
+	j, err := model.NewJobWithSaneProductionDefaults()
+	if err != nil {
+		return nil, err
+	}
+	j.APIVersion = original.APIVersion
+	j.Spec = original.Spec
+
+	if overrideConcurrency {
+		j.Spec.Deal.Concurrency = OR.Concurrency
+	}
+	if inputs := OR.Inputs.Values(); len(inputs) > 0 {
+		j.Spec.Inputs = inputs
+	}
+	if OR.ImageTag != "" {
+		if j.Spec.Engine != model.EngineDocker {
+			return nil, fmt.Errorf("--image-tag only applies to docker jobs, and the job runs on %s", j.Spec.Engine)
+		}
+		image, err := docker.NewImageID(j.Spec.Docker.Image)
+		if err != nil {
+			return nil, err
+		}
+		tagged, err := image.WithTag(OR.ImageTag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image tag %q: %w", OR.ImageTag, err)
+		}
+		j.Spec.Docker.Image = tagged.String()
+	}
+	return j, nil
+}
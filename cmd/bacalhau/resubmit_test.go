@@ -0,0 +1,100 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+)
+
+type ResubmitSuite struct {
+	BaseSuite
+}
+
+func TestResubmitSuite(t *testing.T) {
+	suite.Run(t, new(ResubmitSuite))
+}
+
+func (s *ResubmitSuite) TestResubmit() {
+	ctx := context.Background()
+	original := testutils.MakeNoopJob()
+	original.Spec.Annotations = []string{"resubmitted"}
+	original, err := s.client.Submit(ctx, original)
+	require.NoError(s.T(), err)
+
+	_, out, err := ExecuteTestCobraCommand("resubmit",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--wait=false",
+		original.Metadata.ID,
+	)
+	require.NoError(s.T(), err)
+	jobID := strings.TrimSpace(out)
+	require.NotEqual(s.T(), original.Metadata.ID, jobID)
+
+	resubmitted, found, err := s.client.Get(ctx, jobID)
+	require.NoError(s.T(), err)
+	require.True(s.T(), found)
+	require.Equal(s.T(), original.Spec.Engine, resubmitted.Job.Spec.Engine)
+	require.Equal(s.T(), original.Spec.Annotations, resubmitted.Job.Spec.Annotations)
+	require.Equal(s.T(), original.Spec.Deal.Concurrency, resubmitted.Job.Spec.Deal.Concurrency)
+}
+
+func (s *ResubmitSuite) TestResubmitOverrides() {
+	original, err := s.client.Submit(context.Background(), testutils.MakeNoopJob())
+	require.NoError(s.T(), err)
+
+	_, out, err := ExecuteTestCobraCommand("resubmit",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--dry-run",
+		"--concurrency", "2",
+		"-i", "ipfs://QmeZRGhe4PmjctYVSVHuEiA9oSXnqmYa4kQubSHgWbjv72",
+		original.Metadata.ID,
+	)
+	require.NoError(s.T(), err)
+
+	var j model.Job
+	require.NoError(s.T(), model.YAMLUnmarshalWithMax([]byte(out), &j), out)
+	require.Empty(s.T(), j.Metadata.ID)
+	require.Equal(s.T(), 2, j.Spec.Deal.Concurrency)
+	require.Len(s.T(), j.Spec.Inputs, 1)
+	require.Equal(s.T(), "QmeZRGhe4PmjctYVSVHuEiA9oSXnqmYa4kQubSHgWbjv72", j.Spec.Inputs[0].CID)
+}
+
+func (s *ResubmitSuite) TestResubmitUnknownJob() {
+	_, out, err := ExecuteTestCobraCommand("resubmit",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"unknown-job",
+	)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "unknown-job")
+}
+
+func TestCloneJobImageTag(t *testing.T) {
+	original := &model.Job{Spec: model.Spec{
+		Engine: model.EngineDocker,
+		Docker: model.JobSpecDocker{Image: "ubuntu:kinetic@sha256:" + strings.Repeat("a", 64)},
+	}}
+	j, err := cloneJob(original, &ResubmitOptions{ImageTag: "jammy"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "ubuntu:jammy", j.Spec.Docker.Image)
+	require.Equal(t, "ubuntu:kinetic@sha256:"+strings.Repeat("a", 64), original.Spec.Docker.Image)
+
+	_, err = cloneJob(original, &ResubmitOptions{ImageTag: "not a tag"}, false)
+	require.Error(t, err)
+
+	_, err = cloneJob(&model.Job{Spec: model.Spec{Engine: model.EngineWasm}}, &ResubmitOptions{ImageTag: "jammy"}, false)
+	require.ErrorContains(t, err, "only applies to docker jobs")
+}
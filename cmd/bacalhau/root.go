@@ -2,6 +2,7 @@ package bacalhau
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -21,6 +22,9 @@ import (
 
 var apiHost string
 var apiPort uint16
+var apiToken string
+var apiNamespace string
+var clientContextName string
 
 var loggingMode = logger.LogModeDefault
 
@@ -58,6 +62,14 @@ func NewRootCmd() *cobra.Command {
 			ctx := cmd.Context()
 
 			logger.ConfigureLogging(loggingMode)
+			activeRequesterTrust = nil
+
+			if err := applyClientContext(cmd); err != nil {
+				if usesAPI(cmd) {
+					Fatal(cmd, fmt.Sprintf("Error applying CLI context: %s", err), 1)
+				}
+				log.Ctx(ctx).Warn().Err(err).Msg("ignoring CLI context")
+			}
 
 			cm := system.NewCleanupManager()
 			cm.RegisterCallback(telemetry.Cleanup)
@@ -94,6 +106,9 @@ func NewRootCmd() *cobra.Command {
 
 	RootCmd.AddCommand(newValidateCmd())
 
+	// Generate an example to get started
+	RootCmd.AddCommand(newInitCmd())
+
 	RootCmd.AddCommand(newVersionCmd())
 
 	// ====== Get information or results about a job
@@ -103,15 +118,33 @@ func NewRootCmd() *cobra.Command {
 	// Get logs
 	RootCmd.AddCommand(newLogsCmd())
 
+	// Attach to an interactive job
+	RootCmd.AddCommand(newAttachCmd())
+
 	// Get the results of a job
 	RootCmd.AddCommand(newGetCmd())
 
 	// Cancel a job
 	RootCmd.AddCommand(newCancelCmd())
 
+	// Run a previous job again
+	RootCmd.AddCommand(newResubmitCmd())
+
 	// List jobs
 	RootCmd.AddCommand(newListCmd())
 
+	// Follow the state of a job
+	RootCmd.AddCommand(newWatchCmd())
+
+	// Watch the cluster
+	RootCmd.AddCommand(newTopCmd())
+
+	// Troubleshoot jobs
+	RootCmd.AddCommand(newJobCmd())
+
+	// Submit jobs created while offline
+	RootCmd.AddCommand(newSpoolCmd())
+
 	// ====== Run a server
 
 	// Serve commands
@@ -120,6 +153,12 @@ func NewRootCmd() *cobra.Command {
 	RootCmd.AddCommand(newIDCmd())
 	RootCmd.AddCommand(newDevStackCmd())
 
+	// ====== Manage nodes
+	RootCmd.AddCommand(newNodeCmd())
+
+	// ====== Manage the CLI configuration
+	RootCmd.AddCommand(newConfigCmd())
+
 	RootCmd.PersistentFlags().StringVar(
 		&apiHost, "api-host", defaultAPIHost,
 		`The host for the client and server to communicate on (via REST).
@@ -129,6 +168,11 @@ Ignored if BACALHAU_API_HOST environment variable is set.`,
 		&apiPort, "api-port", defaultAPIPort,
 		`The port for the client and server to communicate on (via REST).
 Ignored if BACALHAU_API_PORT environment variable is set.`,
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&clientContextName, "context", "",
+		`The CLI context to use instead of the current one (see 'bacalhau config').
+Can also be set with the BACALHAU_CONTEXT environment variable.`,
 	)
 	RootCmd.PersistentFlags().Var(
 		LoggingFlag(&loggingMode), "log-mode",
@@ -199,8 +243,20 @@ func checkVersion(cmd *cobra.Command, args []string) error {
 	}
 	root.PersistentPreRun(cmd, args)
 
+	// commands run offline don't reach the requester
+	if offline, err := cmd.Flags().GetBool("offline"); err == nil && offline {
+		return nil
+	}
+
+	// Check that the requester signs its responses as the peer trusted on first use
+	activeRequesterTrust = newRequesterTrust(cmd)
+
 	// Check that the server version is compatible with the client version
-	serverVersion, _ := GetAPIClient().Version(ctx) // Ok if this fails, version validation will skip
+	serverVersion, err := GetAPIClient().Version(ctx) // Ok if this fails, version validation will skip
+	if errors.Is(err, errUntrustedRequester) {
+		Fatal(cmd, err.Error(), 1)
+		return err
+	}
 	if err := ensureValidVersion(ctx, version.Get(), serverVersion); err != nil {
 		Fatal(cmd, fmt.Sprintf("version validation failed: %s", err), 1)
 		return err
@@ -0,0 +1,14 @@
+use std::env;
+use std::fs;
+use std::process;
+
+// Counts the words passed as arguments, and writes the count to stdout and to /outputs/wordcount.txt.
+fn main() {
+    // the first argument is the name of the module
+    let words = env::args().skip(1).count();
+    println!("{}", words);
+    if let Err(err) = fs::write("/outputs/wordcount.txt", format!("{}\n", words)) {
+        eprintln!("{}", err);
+        process::exit(1);
+    }
+}
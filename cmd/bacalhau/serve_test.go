@@ -4,6 +4,7 @@ package bacalhau
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -19,6 +21,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/types"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/multiformats/go-multiaddr"
 	"github.com/phayes/freeport"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/sync/errgroup"
@@ -171,6 +174,7 @@ func (s *ServeSuite) TestCanSubmitJob() {
 
 	job, err := model.NewJobWithSaneProductionDefaults()
 	s.Require().NoError(err)
+	job.Spec.Docker.Image = "ubuntu"
 
 	_, err = client.Submit(s.ctx, job)
 	s.NoError(err)
@@ -184,6 +188,7 @@ func (s *ServeSuite) TestAppliesJobSelectionPolicy() {
 
 	job, err := model.NewJobWithSaneProductionDefaults()
 	s.Require().NoError(err)
+	job.Spec.Docker.Image = "ubuntu"
 
 	job.Spec.Network.Type = model.NetworkHTTP
 	job, err = client.Submit(s.ctx, job)
@@ -198,7 +203,7 @@ func (s *ServeSuite) TestDefaultServeOptionsConnectToLocalIpfs() {
 	cm := system.NewCleanupManager()
 	OS := NewServeOptions()
 
-	client, err := ipfsClient(s.ctx, OS, cm)
+	client, err := ipfsClient(s.ctx, OS, "", cm)
 	s.Require().NoError(err)
 
 	swarmAddresses, err := client.SwarmAddresses(s.ctx)
@@ -207,6 +212,26 @@ func (s *ServeSuite) TestDefaultServeOptionsConnectToLocalIpfs() {
 	s.Require().Equal(2, len(swarmAddresses))
 }
 
+func (s *ServeSuite) TestPickP2pAddress() {
+	parse := func(addrs ...string) []multiaddr.Multiaddr {
+		var res []multiaddr.Multiaddr
+		for _, addr := range addrs {
+			res = append(res, multiaddr.StringCast(addr))
+		}
+		return res
+	}
+
+	picked := pickP2pAddress(parse("/ip4/127.0.0.1/tcp/1", "/ip6/::1/tcp/1", "/ip6/2001:db8::1/tcp/1", "/ip4/10.0.0.1/tcp/1"))
+	s.Equal("/ip4/10.0.0.1/tcp/1", picked.String())
+
+	// a global IPv6 address is preferred over the IPv4 loopback on dual-stack hosts
+	picked = pickP2pAddress(parse("/ip4/127.0.0.1/tcp/1", "/ip6/::1/tcp/1", "/ip6/2001:db8::1/tcp/1"))
+	s.Equal("/ip6/2001:db8::1/tcp/1", picked.String())
+
+	picked = pickP2pAddress(parse("/ip6/::1/tcp/1", "/ip6/2001:db8::1/udp/1/quic", "/ip6/2001:db8::1/tcp/1"))
+	s.Equal("/ip6/2001:db8::1/tcp/1", picked.String())
+}
+
 func (s *ServeSuite) TestGetPeers() {
 	// by default it should return no peers
 	OS := NewServeOptions()
@@ -274,3 +299,40 @@ func (s *ServeSuite) TestGetPeers() {
 // 	s.Require().Contains(s.out.String(), "Could not write to")
 // 	s.Error(err)
 // }
+
+func (s *ServeSuite) TestSelfTest() {
+	bigPort, err := freeport.GetFreePort()
+	s.Require().NoError(err)
+
+	var out strings.Builder
+	cmd := NewRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&s.err)
+	cmd.SetArgs([]string{
+		"serve",
+		"--peer", DefaultPeerConnect,
+		"--private-internal-ipfs",
+		"--api-port", fmt.Sprint(bigPort),
+		"--node-type", "compute",
+		"--disable-engine", "docker",
+		"--self-test",
+		"--self-test-ntp-server", "",
+	})
+	_, err = cmd.ExecuteContextC(s.ctx)
+	s.Require().NoError(err)
+
+	var report selftest.Report
+	s.Require().NoError(json.Unmarshal([]byte(out.String()), &report))
+	s.Require().True(report.Passed, "%+v", report)
+
+	statuses := make(map[string]selftest.Status)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	s.Require().Equal(map[string]selftest.Status{
+		"wasm":           selftest.StatusPassed,
+		"ipfs":           selftest.StatusPassed,
+		"publisher ipfs": selftest.StatusPassed,
+		"clock sync":     selftest.StatusSkipped,
+	}, statuses)
+}
@@ -0,0 +1,141 @@
+package bacalhau
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/job/spool"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/jobtransform"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	spoolLong = templates.LongDesc(i18n.T(`
+		Manage jobs created while offline.
+
+		Jobs run with --offline are validated and written to the spool in the bacalhau config directory instead of
+		being submitted, with their docker image pinned to a digest when the local docker daemon can resolve one.
+		Once the network is reachable, flush the spool to submit them in the order they were created.
+`))
+
+	spoolExample = templates.Examples(i18n.T(`
+		# Spool a job while offline
+		bacalhau docker run --offline ubuntu:22.04 echo hello
+
+		# List the jobs waiting to be submitted
+		bacalhau spool list
+
+		# Submit the spooled jobs
+		bacalhau spool flush
+`))
+)
+
+func newSpoolCmd() *cobra.Command {
+	spoolCmd := &cobra.Command{
+		Use:     "spool",
+		Short:   "Manage jobs spooled while offline",
+		Long:    spoolLong,
+		Example: spoolExample,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the spooled jobs, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			entries, err := spool.NewSpool(config.GetSpoolPath()).List()
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				cmd.Printf("%s\t%s\t%s\n", entry.Name, entry.Job.Spec.Engine, describeSpooledJob(entry.Job))
+			}
+			return nil
+		},
+	}
+
+	flushCmd := &cobra.Command{
+		Use:               "flush",
+		Short:             "Submit the spooled jobs, removing each one from the spool once it is submitted",
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: checkVersion,
+		RunE:              flushSpool,
+	}
+
+	spoolCmd.AddCommand(listCmd, flushCmd)
+	return spoolCmd
+}
+
+// spoolJob writes the job to the spool to be submitted later by `bacalhau spool flush`, pinning its docker image to a
+// digest if the local docker daemon can resolve one.
+func spoolJob(cmd *cobra.Command, j *model.Job, quiet bool) error {
+	// the transform leaves the image as it is if the digest can't be resolved
+	_, _ = jobtransform.DockerImageDigest()(cmd.Context(), j)
+
+	name, err := spool.NewSpool(config.GetSpoolPath()).Add(j)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error spooling job: %s", err), 1)
+		return nil
+	}
+	if quiet {
+		cmd.Println(name)
+	} else {
+		cmd.Printf("Job spooled as %s. Submit it with 'bacalhau spool flush' once connected.\n", name)
+	}
+	return nil
+}
+
+// flushSpool submits each of the spooled jobs in turn. Jobs that fail to submit stay in the spool to be retried. Each
+// job is submitted with an idempotency key of its entry, so that a job submitted again by a flush that follows a
+// crashed one is created only once.
+func flushSpool(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	s := spool.NewSpool(config.GetSpoolPath())
+	entries, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	apiClient := GetAPIClient()
+	for _, entry := range entries {
+		entry := entry
+		entryCtx := publicapi.ContextWithIdempotencyKey(ctx, spoolIdempotencyKey(entry.Name))
+		submitted, submitErr := submitJob(entryCtx, apiClient, &entry.Job)
+		if submitErr != nil {
+			err = multierr.Append(err, fmt.Errorf("%s: %w", entry.Name, submitErr))
+			continue
+		}
+		if removeErr := s.Remove(entry.Name); removeErr != nil {
+			// the job would be submitted again by the next flush
+			err = multierr.Append(err, fmt.Errorf("%s: submitted as job %s, but %w", entry.Name, submitted.ID(), removeErr))
+			continue
+		}
+		cmd.Printf("%s\t%s\n", entry.Name, submitted.ID())
+	}
+	return err
+}
+
+// spoolIdempotencyKey returns the idempotency key the job of the spool entry is submitted with.
+func spoolIdempotencyKey(name string) string {
+	return "spool-" + name
+}
+
+func describeSpooledJob(j model.Job) string {
+	switch j.Spec.Engine {
+	case model.EngineDocker:
+		return j.Spec.Docker.Image
+	case model.EngineWasm:
+		return j.Spec.Wasm.EntryPoint
+	case model.EngineProcess:
+		return strings.Join(j.Spec.Process.Entrypoint, " ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,99 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+)
+
+type SpoolSuite struct {
+	BaseSuite
+}
+
+func TestSpoolSuite(t *testing.T) {
+	suite.Run(t, new(SpoolSuite))
+}
+
+func (s *SpoolSuite) TestSpoolAndFlush() {
+	s.T().Setenv("BACALHAU_PATH", s.T().TempDir())
+	ctx := context.Background()
+
+	var names []string
+	for _, image := range []string{"ubuntu:22.04", "alpine:3.18"} {
+		_, out, err := ExecuteTestCobraCommand("docker", "run", "--offline", "--id-only", image, "echo", "hello")
+		s.Require().NoError(err)
+		names = append(names, strings.TrimSpace(out))
+	}
+
+	_, out, err := ExecuteTestCobraCommand("spool", "list")
+	s.Require().NoError(err)
+	s.Require().Equal(fmt.Sprintf("%s\tDocker\tubuntu:22.04\n%s\tDocker\talpine:3.18\n", names[0], names[1]), out)
+
+	_, out, err = ExecuteTestCobraCommand("spool", "flush",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+	)
+	s.Require().NoError(err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	s.Require().Len(lines, 2)
+	for i, line := range lines {
+		name, jobID, found := strings.Cut(line, "\t")
+		s.Require().True(found, line)
+		s.Require().Equal(names[i], name)
+
+		j, _, err := s.client.Get(ctx, jobID)
+		s.Require().NoError(err)
+		s.Require().Contains(j.Job.Spec.Docker.Image, []string{"ubuntu", "alpine"}[i])
+	}
+
+	_, out, err = ExecuteTestCobraCommand("spool", "list")
+	s.Require().NoError(err)
+	s.Require().Empty(out)
+}
+
+func (s *SpoolSuite) TestFlushKeepsJobsThatFailToSubmit() {
+	s.T().Setenv("BACALHAU_PATH", s.T().TempDir())
+
+	_, out, err := ExecuteTestCobraCommand("docker", "run", "--offline", "--id-only", "ubuntu:22.04", "echo", "hello")
+	s.Require().NoError(err)
+	name := strings.TrimSpace(out)
+
+	// nothing listens on port 1, as if the network was still unreachable
+	_, _, err = ExecuteTestCobraCommand("spool", "flush", "--api-host", "127.0.0.1", "--api-port", "1")
+	s.Require().Error(err)
+
+	_, out, err = ExecuteTestCobraCommand("spool", "list")
+	s.Require().NoError(err)
+	s.Require().True(strings.HasPrefix(out, name), out)
+}
+
+func (s *SpoolSuite) TestFlushAfterCrashSubmitsJobsOnce() {
+	s.T().Setenv("BACALHAU_PATH", s.T().TempDir())
+
+	_, out, err := ExecuteTestCobraCommand("docker", "run", "--offline", "--id-only", "ubuntu:22.04", "echo", "hello")
+	s.Require().NoError(err)
+	path := filepath.Join(config.GetSpoolPath(), strings.TrimSpace(out)+".json")
+	spooled, err := os.ReadFile(path)
+	s.Require().NoError(err)
+
+	flush := func() string {
+		_, out, err := ExecuteTestCobraCommand("spool", "flush", "--api-host", s.host, "--api-port", fmt.Sprint(s.port))
+		s.Require().NoError(err)
+		return out
+	}
+	first := flush()
+
+	// the flush crashed after submitting the job, before removing it from the spool
+	s.Require().NoError(os.WriteFile(path, spooled, 0600))
+	s.Require().Equal(first, flush(), "the job is not created again")
+}
@@ -0,0 +1,262 @@
+package bacalhau
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	topLong = templates.LongDesc(i18n.T(`
+		Show a live view of the cluster, refreshed until interrupted: the number of jobs in each state, the
+		utilization of each compute node, and the most recent job and execution events.
+
+		The view is built by polling the requester for its jobs and compute nodes, so only the most recent jobs,
+		up to --number, are counted.
+`))
+
+	topExample = templates.Examples(i18n.T(`
+		# Watch the cluster, refreshing every 2 seconds
+		bacalhau top
+
+		# Refresh every 5 seconds, counting the last 500 jobs of every user
+		bacalhau top --interval 5s --number 500 --all
+
+		# Print the view once and exit
+		bacalhau top --once`))
+)
+
+// clearScreen moves the cursor to the top left of the terminal and clears it.
+const clearScreen = "\033[H\033[2J"
+
+type TopOptions struct {
+	Interval  time.Duration // How often the view is refreshed
+	MaxJobs   int           // How many of the most recent jobs are counted
+	MaxEvents int           // How many of the most recent events are shown
+	ReturnAll bool          // Count the jobs of every user, not just those that belong to the user
+	Once      bool          // Print the view once and exit
+}
+
+func NewTopOptions() *TopOptions {
+	return &TopOptions{
+		Interval:  2 * time.Second,
+		MaxJobs:   100,
+		MaxEvents: 10,
+	}
+}
+
+func newTopCmd() *cobra.Command {
+	OT := NewTopOptions()
+
+	topCmd := &cobra.Command{
+		Use:     "top",
+		Short:   "Show a live view of the jobs and compute nodes of the cluster",
+		Long:    topLong,
+		Example: topExample,
+		Args:    cobra.NoArgs,
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return top(cmd, OT)
+		},
+	}
+
+	topCmd.Flags().DurationVar(&OT.Interval, "interval", OT.Interval, `How often the view is refreshed.`)
+	topCmd.Flags().IntVarP(&OT.MaxJobs, "number", "n", OT.MaxJobs, `How many of the most recent jobs are counted.`)
+	topCmd.Flags().IntVar(&OT.MaxEvents, "events", OT.MaxEvents, `How many of the most recent events are shown.`)
+	topCmd.Flags().BoolVar(&OT.ReturnAll, "all", OT.ReturnAll,
+		`Count the jobs of every user (default is to count those belonging to the user).`)
+	topCmd.Flags().BoolVar(&OT.Once, "once", OT.Once, `Print the view once and exit, rather than refreshing it.`)
+
+	return topCmd
+}
+
+func top(cmd *cobra.Command, OT *TopOptions) error {
+	ctx := cmd.Context()
+	if OT.Interval <= 0 {
+		Fatal(cmd, fmt.Sprintf("The refresh interval must be positive, got %s", OT.Interval), 1)
+		return nil
+	}
+
+	apiClient := GetAPIClient()
+	if OT.Once {
+		snapshot, err := pollTop(ctx, apiClient, OT)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error reading the state of the cluster: %s", err), 1)
+			return nil
+		}
+		renderTop(cmd.OutOrStdout(), snapshot, OT)
+		return nil
+	}
+
+	// the screen is only cleared between refreshes on a terminal, so that the output can be piped to a file
+	out := cmd.OutOrStdout()
+	clearBetween := false
+	if f, ok := out.(*os.File); ok {
+		clearBetween = isatty.IsTerminal(f.Fd())
+	}
+
+	ticker := time.NewTicker(OT.Interval)
+	defer ticker.Stop()
+	for {
+		snapshot, err := pollTop(ctx, apiClient, OT)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var view bytes.Buffer
+		if clearBetween {
+			view.WriteString(clearScreen)
+		}
+		if err != nil {
+			// the requester may be restarting, so keep polling rather than exiting
+			fmt.Fprintf(&view, "%s  Error reading the state of the cluster: %s\n", time.Now().Format(time.TimeOnly), err)
+		} else {
+			renderTop(&view, snapshot, OT)
+		}
+		_, _ = out.Write(view.Bytes())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// topSnapshot is the state of the cluster shown by one refresh of the view.
+type topSnapshot struct {
+	Time  time.Time
+	Jobs  []*model.JobWithInfo
+	Nodes []model.NodeInfo
+}
+
+func pollTop(ctx context.Context, apiClient *publicapi.RequesterAPIClient, OT *TopOptions) (topSnapshot, error) {
+	jobs, err := apiClient.List(
+		ctx, "", model.IncludeAny, defaultExcludedTags, OT.MaxJobs, OT.ReturnAll, string(ColumnCreatedAt), true)
+	if err != nil {
+		return topSnapshot{}, err
+	}
+	nodes, err := apiClient.Nodes(ctx)
+	if err != nil {
+		return topSnapshot{}, err
+	}
+	return topSnapshot{Time: time.Now(), Jobs: jobs, Nodes: nodes}, nil
+}
+
+// topEvent is a change of state of a job or of one of its executions.
+type topEvent struct {
+	Time    time.Time
+	JobID   string
+	NodeID  string
+	Message string
+}
+
+// recentEvents returns the latest changes of state of the jobs and their executions, newest first.
+func recentEvents(jobs []*model.JobWithInfo, maxEvents int) []topEvent {
+	var events []topEvent
+	for _, j := range jobs {
+		events = append(events, topEvent{
+			Time: j.State.UpdateTime, JobID: j.State.JobID, Message: "job " + j.State.State.String()})
+		for _, execution := range j.State.Executions {
+			message := "execution " + execution.State.String()
+			// the status of the other states is the reasoning of the bid, which is too long to be shown
+			if execution.State == model.ExecutionStateFailed && execution.Status != "" {
+				message += ": " + execution.Status
+			}
+			events = append(events, topEvent{
+				Time: execution.UpdateTime, JobID: execution.JobID, NodeID: execution.NodeID, Message: message})
+		}
+	}
+	sort.SliceStable(events, func(i, k int) bool {
+		return events[i].Time.After(events[k].Time)
+	})
+	if len(events) > maxEvents {
+		events = events[:maxEvents]
+	}
+	return events
+}
+
+// utilization returns the percentage of the capacity of the node that is in use, or zero if it has none.
+func utilization(available, maximum float64) float64 {
+	if maximum <= 0 {
+		return 0
+	}
+	return 100 * (maximum - available) / maximum
+}
+
+func renderTop(w io.Writer, snapshot topSnapshot, OT *TopOptions) {
+	running := 0
+	for _, node := range snapshot.Nodes {
+		if node.ComputeNodeInfo != nil {
+			running += node.ComputeNodeInfo.RunningExecutions
+		}
+	}
+	fmt.Fprintf(w, "bacalhau top - %s  %s  %d nodes, %d running executions, last %d jobs\n\n",
+		snapshot.Time.Format(time.TimeOnly), GetAPIHostAndPort(), len(snapshot.Nodes), running, len(snapshot.Jobs))
+
+	counts := make(map[model.JobStateType]int)
+	for _, j := range snapshot.Jobs {
+		counts[j.State.State]++
+	}
+	jobsTable := newTopTable(w)
+	header, row := table.Row{}, table.Row{}
+	for _, state := range model.JobStateTypes() {
+		header = append(header, state.String())
+		row = append(row, counts[state])
+	}
+	jobsTable.AppendHeader(header)
+	jobsTable.AppendRow(row)
+	jobsTable.Render()
+	fmt.Fprintln(w)
+
+	nodesTable := newTopTable(w)
+	nodesTable.AppendHeader(table.Row{"node", "cpu", "memory", "disk", "gpu", "running", "queued"})
+	for _, node := range snapshot.Nodes {
+		info := node.ComputeNodeInfo
+		if info == nil {
+			info = &model.ComputeNodeInfo{}
+		}
+		available, maximum := info.AvailableCapacity, info.MaxCapacity
+		nodesTable.AppendRow(table.Row{
+			shortID(false, node.PeerInfo.ID.String()),
+			fmt.Sprintf("%.0f%%", utilization(available.CPU, maximum.CPU)),
+			fmt.Sprintf("%.0f%%", utilization(float64(available.Memory), float64(maximum.Memory))),
+			fmt.Sprintf("%.0f%%", utilization(float64(available.Disk), float64(maximum.Disk))),
+			fmt.Sprintf("%.0f%%", utilization(float64(available.GPU), float64(maximum.GPU))),
+			info.RunningExecutions,
+			info.EnqueuedExecutions,
+		})
+	}
+	nodesTable.Render()
+	fmt.Fprintln(w)
+
+	eventsTable := newTopTable(w)
+	eventsTable.AppendHeader(table.Row{"time", "job", "node", "event"})
+	for _, event := range recentEvents(snapshot.Jobs, OT.MaxEvents) {
+		eventsTable.AppendRow(table.Row{
+			shortenTime(false, event.Time),
+			shortID(false, event.JobID),
+			shortID(false, event.NodeID),
+			event.Message,
+		})
+	}
+	eventsTable.Render()
+}
+
+func newTopTable(w io.Writer) table.Writer {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	tw.SetStyle(table.StyleLight)
+	return tw
+}
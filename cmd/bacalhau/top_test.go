@@ -0,0 +1,84 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRecentEvents(t *testing.T) {
+	start := time.Date(2023, 7, 1, 8, 0, 0, 0, time.UTC)
+	jobs := []*model.JobWithInfo{
+		{State: model.JobState{JobID: "job-1", State: model.JobStateCompleted, UpdateTime: start.Add(3 * time.Second),
+			Executions: []model.ExecutionState{
+				{JobID: "job-1", NodeID: "node-1", State: model.ExecutionStateCompleted, UpdateTime: start.Add(2 * time.Second)},
+			}}},
+		{State: model.JobState{JobID: "job-2", State: model.JobStateInProgress, UpdateTime: start,
+			Executions: []model.ExecutionState{
+				{JobID: "job-2", NodeID: "node-2", State: model.ExecutionStateFailed, Status: "out of memory",
+					UpdateTime: start.Add(4 * time.Second)},
+			}}},
+	}
+
+	events := recentEvents(jobs, 3)
+	require.Len(t, events, 3)
+	require.Equal(t, "job-2", events[0].JobID)
+	require.Equal(t, "node-2", events[0].NodeID)
+	require.Equal(t, "execution Failed: out of memory", events[0].Message)
+	require.Equal(t, "job Completed", events[1].Message)
+	require.Equal(t, "execution Completed", events[2].Message)
+}
+
+func TestUtilization(t *testing.T) {
+	require.Equal(t, 75.0, utilization(1, 4))
+	require.Equal(t, 0.0, utilization(0, 0))
+}
+
+type TopSuite struct {
+	BaseSuite
+}
+
+func TestTopSuite(t *testing.T) {
+	suite.Run(t, new(TopSuite))
+}
+
+func (s *TopSuite) TestTopOnce() {
+	_, err := s.client.Submit(context.Background(), testutils.MakeNoopJob())
+	s.Require().NoError(err)
+
+	_, out, err := ExecuteTestCobraCommand("top", "--once",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+	)
+	s.Require().NoError(err)
+	s.Require().Contains(out, "last 1 jobs")
+	s.Require().Contains(out, "job Completed")
+	s.Require().Contains(out, "execution Completed ")
+	s.Require().NotContains(out, clearScreen)
+}
+
+func (s *TopSuite) TestInvalidInterval() {
+	_, out, err := ExecuteTestCobraCommand("top", "--interval", "0s",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+	)
+	s.Require().NoError(err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	s.Require().NoError(err)
+	s.Require().Contains(fatalError.Message, "interval must be positive")
+}
+
+func TestRenderTopWithoutComputeInfo(t *testing.T) {
+	var out bytes.Buffer
+	renderTop(&out, topSnapshot{Nodes: []model.NodeInfo{{}}}, NewTopOptions())
+	require.Contains(t, out.String(), "1 nodes")
+}
@@ -0,0 +1,195 @@
+package bacalhau
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	trustLong = templates.LongDesc(i18n.T(`
+		Manage the identities requesters are trusted with.
+
+		The requester signs every response to the CLI, along with a random nonce and the request, with the private key
+		of its libp2p peer. The first time the CLI talks to a requester, it trusts the peer that signed the response from
+		then on. If the responses of the requester are later signed by another peer, or not signed at all, someone could
+		be impersonating it: the CLI warns, or refuses the responses if the trust mode is "refuse". Remove a requester to
+		trust its new identity when the change is expected.
+`))
+
+	trustExample = templates.Examples(i18n.T(`
+		# List the trusted requesters
+		bacalhau config trust list
+
+		# Trust a requester with a peer ID obtained out of band, before talking to it
+		bacalhau config trust add bacalhau.example.com:1234 QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL
+
+		# Trust the new identity of a requester whose key was rotated
+		bacalhau config trust remove bacalhau.example.com:1234
+
+		# Refuse to talk to requesters whose identity changed
+		bacalhau config trust mode refuse
+`))
+)
+
+func newConfigTrustCmd() *cobra.Command {
+	trustCmd := &cobra.Command{
+		Use:     "trust",
+		Short:   "Manage the identities requesters are trusted with",
+		Long:    trustLong,
+		Example: trustExample,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the trusted requesters and their peer IDs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientConfig, err := config.LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			for _, endpoint := range clientConfig.TrustedEndpoints() {
+				peerID, _ := clientConfig.TrustedPeer(endpoint)
+				cmd.Printf("%s\t%s\n", endpoint, peerID)
+			}
+			return nil
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add HOST:PORT PEER_ID",
+		Short: "Trust the requester at an endpoint with a peer ID, replacing the one it was trusted with",
+		Args:  cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, _, err := net.SplitHostPort(args[0]); err != nil {
+				return fmt.Errorf("invalid endpoint %q: %w", args[0], err)
+			}
+			if _, err := peer.Decode(args[1]); err != nil {
+				return fmt.Errorf("invalid peer ID %q: %w", args[1], err)
+			}
+			return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+				clientConfig.Trust(args[0], args[1])
+				return nil
+			})
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:   "remove HOST:PORT",
+		Short: "Forget the identity of the requester at an endpoint, so that it is trusted again on next use",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+				if !clientConfig.Untrust(args[0]) {
+					return fmt.Errorf("requester at %s is not trusted", args[0])
+				}
+				return nil
+			})
+		},
+	}
+
+	modeCmd := &cobra.Command{
+		Use:   "mode [warn|refuse]",
+		Short: "Print or set what to do when a requester's identity changes",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				clientConfig, err := config.LoadClientConfig()
+				if err != nil {
+					return err
+				}
+				cmd.Println(clientConfig.GetTrustMode())
+				return nil
+			}
+			mode, err := config.ParseTrustMode(args[0])
+			if err != nil {
+				return err
+			}
+			return updateClientConfig(func(clientConfig *config.ClientConfig) error {
+				clientConfig.TrustMode = mode
+				return nil
+			})
+		},
+	}
+
+	trustCmd.AddCommand(listCmd, addCmd, removeCmd, modeCmd)
+	return trustCmd
+}
+
+// requesterTrust checks that the responses of the requester are signed by the peer it is trusted with, trusting it on
+// first use, and warns or fails if they are signed by another peer.
+type requesterTrust struct {
+	cmd      *cobra.Command
+	endpoint string
+	mu       sync.Mutex
+	warned   bool
+}
+
+// errUntrustedRequester is returned for the responses of requesters that are not the peer they are trusted with when
+// the trust mode is "refuse".
+var errUntrustedRequester = errors.New("refusing to talk to the requester")
+
+// activeRequesterTrust checks the responses of the requester to the running command, if the command talks to it.
+var activeRequesterTrust *requesterTrust
+
+func newRequesterTrust(cmd *cobra.Command) *requesterTrust {
+	return &requesterTrust{cmd: cmd, endpoint: net.JoinHostPort(apiHost, strconv.Itoa(int(apiPort)))}
+}
+
+// check is called with the peer that signed every response of the requester, or the error verifying the signature.
+func (t *requesterTrust) check(req *http.Request, peerID peer.ID, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx := req.Context()
+	clientConfig, loadErr := config.LoadClientConfig()
+	if loadErr != nil {
+		return loadErr
+	}
+	trustedPeerID, trusted := clientConfig.TrustedPeer(t.endpoint)
+
+	var problem string
+	switch {
+	case err != nil && !trusted:
+		// the requester is too old to sign its responses
+		log.Ctx(ctx).Debug().Err(err).Msgf("Requester at %s did not prove its identity", t.endpoint)
+		return nil
+	case err != nil:
+		problem = fmt.Sprintf("failed to prove it is peer %s, which it was trusted with: %s", trustedPeerID, err)
+	case !trusted:
+		clientConfig.Trust(t.endpoint, peerID.String())
+		if err = clientConfig.Save(); err != nil {
+			return err
+		}
+		log.Ctx(ctx).Info().Msgf("Trusting the requester at %s as peer %s from now on", t.endpoint, peerID)
+		return nil
+	case peerID.String() != trustedPeerID:
+		problem = fmt.Sprintf("proved to be peer %s, but it was trusted as peer %s", peerID, trustedPeerID)
+	default:
+		return nil
+	}
+
+	message := fmt.Sprintf("The requester at %s %s. Someone could be impersonating it! "+
+		"If the change is expected, run 'bacalhau config trust remove %s' to trust its new identity.",
+		t.endpoint, problem, t.endpoint)
+	if clientConfig.GetTrustMode() == config.TrustModeRefuse {
+		return fmt.Errorf("%w. %s", errUntrustedRequester, message)
+	}
+	if !t.warned {
+		t.warned = true
+		t.cmd.PrintErrln("WARNING: " + message)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TrustSuite struct {
+	BaseSuite
+}
+
+func TestTrustSuite(t *testing.T) {
+	suite.Run(t, new(TrustSuite))
+}
+
+func (s *TrustSuite) endpoint() string {
+	return net.JoinHostPort(s.host, fmt.Sprint(s.port))
+}
+
+// talkToRequester runs a command that submits to the requester, which has nothing to submit
+func (s *TrustSuite) talkToRequester() (string, error) {
+	_, out, err := ExecuteTestCobraCommand("spool", "flush", "--api-host", s.host, "--api-port", fmt.Sprint(s.port))
+	return out, err
+}
+
+func (s *TrustSuite) TestTrustOnFirstUse() {
+	out, err := s.talkToRequester()
+	s.Require().NoError(err)
+	s.Require().Empty(out)
+
+	_, out, err = ExecuteTestCobraCommand("config", "trust", "list")
+	s.Require().NoError(err)
+	s.Require().Equal(fmt.Sprintf("%s\t%s\n", s.endpoint(), s.node.Host.ID()), out)
+
+	// the requester proves the identity it was trusted with
+	out, err = s.talkToRequester()
+	s.Require().NoError(err)
+	s.Require().Empty(out)
+}
+
+func (s *TrustSuite) TestChangedIdentity() {
+	otherPeerID := "QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL"
+	_, _, err := ExecuteTestCobraCommand("config", "trust", "add", s.endpoint(), otherPeerID)
+	s.Require().NoError(err)
+
+	out, err := s.talkToRequester()
+	s.Require().NoError(err)
+	s.Require().True(strings.HasPrefix(out, "WARNING: "), out)
+	s.Require().Contains(out, otherPeerID)
+
+	_, _, err = ExecuteTestCobraCommand("config", "trust", "mode", "refuse")
+	s.Require().NoError(err)
+	_, out, err = ExecuteTestCobraCommand("config", "trust", "mode")
+	s.Require().NoError(err)
+	s.Require().Equal("refuse\n", out)
+
+	out, err = s.talkToRequester()
+	s.Require().Error(err)
+	s.Require().Contains(out, "refusing to talk to the requester")
+
+	// removing the requester trusts its new identity on next use
+	_, _, err = ExecuteTestCobraCommand("config", "trust", "remove", s.endpoint())
+	s.Require().NoError(err)
+	out, err = s.talkToRequester()
+	s.Require().NoError(err)
+	s.Require().Empty(out)
+}
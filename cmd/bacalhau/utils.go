@@ -7,20 +7,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	computenodeapi "github.com/bacalhau-project/bacalhau/pkg/compute/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/devstack"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/version"
@@ -35,6 +40,7 @@ import (
 const (
 	JSONFormat                         string = "json"
 	YAMLFormat                         string = "yaml"
+	TableFormat                        string = "table"
 	DefaultDockerRunWaitSeconds               = 600
 	PrintoutCanceledButRunningNormally string = "printout canceled but running normally"
 	// AutoDownloadFolderPerm is what permissions we give to a folder we create when downloading results
@@ -99,11 +105,32 @@ func shortID(outputWide bool, id string) string {
 }
 
 func GetAPIHostAndPort() string {
-	return fmt.Sprintf("%s:%d", apiHost, apiPort)
+	return net.JoinHostPort(strings.Trim(apiHost, "[]"), strconv.Itoa(int(apiPort)))
 }
 
 func GetAPIClient() *publicapi.RequesterAPIClient {
-	return publicapi.NewRequesterAPIClient(apiHost, apiPort)
+	client := publicapi.NewRequesterAPIClient(apiHost, apiPort)
+	setAPIHeaders(client.DefaultHeaders)
+	if activeRequesterTrust != nil {
+		client.VerifyResponses(activeRequesterTrust.check)
+	}
+	return client
+}
+
+func GetComputeAPIClient() *computenodeapi.ComputeAPIClient {
+	client := computenodeapi.NewComputeAPIClient(apiHost, apiPort)
+	setAPIHeaders(client.DefaultHeaders)
+	return client
+}
+
+// setAPIHeaders sets the token and namespace of the CLI context in the headers of the requests to the API.
+func setAPIHeaders(headers map[string]string) {
+	if apiToken != "" {
+		headers["Authorization"] = "Bearer " + apiToken
+	}
+	if apiNamespace != "" {
+		headers[handlerwrapper.HTTPHeaderNamespace] = apiNamespace
+	}
 }
 
 // ensureValidVersion checks that the server version is the same or less than the client version
@@ -161,6 +188,23 @@ func NewIPFSDownloadFlags(settings *model.DownloaderSettings) *pflag.FlagSet {
 		settings.OutputDir, "Directory to write the output to.")
 	flags.StringVar(&settings.IPFSSwarmAddrs, "ipfs-swarm-addrs",
 		settings.IPFSSwarmAddrs, "Comma-separated list of IPFS nodes to connect to.")
+	flags.StringVar(&settings.IPFSGateways, "ipfs-gateways",
+		settings.IPFSGateways, "Comma-separated list of HTTP gateways to fetch results from if the IPFS network fails, "+
+			"such as https://ipfs.io. None by default. Content fetched from gateways is verified against its CID.")
+	flags.DurationVar(&settings.GatewayFallbackTimeout, "gateway-fallback-timeout",
+		settings.GatewayFallbackTimeout, "How long to try fetching results from the IPFS network before falling back to gateways.")
+	flags.IntVar(&settings.Retries, "download-retries",
+		settings.Retries, "How many times to retry fetching a file of the results from the IPFS network, with backoff. "+
+			"Files already in the output directory are skipped, and partly downloaded files are resumed.")
+	flags.Var(ByteSizeFlag(&settings.MaxDownloadSize), "max-download-size",
+		"The largest a result published as an archive can be once extracted, or as a single file.")
+	flags.StringSliceVar(&settings.LocalResultsDirectories, "local-results-dir", settings.LocalResultsDirectories,
+		"Directories results published to a local directory can be copied from, such as the local publisher directory "+
+			"of a node on this machine. Such results are not downloaded from other directories.")
+	flags.BoolVar(&settings.Dedupe, "dedupe",
+		settings.Dedupe, "Store files that are identical across results only once, as copy-on-write clones where the "+
+			"filesystem supports them or as hardlinks otherwise, and merge them without conflict. Hardlinked files are "+
+			"made read-only, as writing to one would change the others.")
 	return flags
 }
 
@@ -195,14 +239,16 @@ func processDownloadSettings(settings model.DownloaderSettings, jobID string) (m
 }
 
 type RunTimeSettings struct {
-	AutoDownloadResults   bool // Automatically download the results after finishing
-	IPFSGetTimeOut        int  // Timeout for IPFS in seconds
-	IsLocal               bool // Job should be executed locally
-	WaitForJobToFinish    bool // Wait for the job to finish before returning
-	WaitForJobTimeoutSecs int  // Timeout for waiting for the job to finish
-	PrintJobIDOnly        bool // Only print the Job ID as output
-	PrintNodeDetails      bool // Print the node details as output
-	Follow                bool // Follow along with the output of the job
+	AutoDownloadResults   bool   // Automatically download the results after finishing
+	IPFSGetTimeOut        int    // Timeout for IPFS in seconds
+	IsLocal               bool   // Job should be executed locally
+	WaitForJobToFinish    bool   // Wait for the job to finish before returning
+	WaitForJobTimeoutSecs int    // Timeout for waiting for the job to finish
+	PrintJobIDOnly        bool   // Only print the Job ID as output
+	PrintNodeDetails      bool   // Print the node details as output
+	Follow                bool   // Follow along with the output of the job
+	Name                  string // The name of the job, which can be used in place of its ID
+	RequireDigest         bool   // Refuse to submit docker jobs whose image is not pinned to a digest
 }
 
 func NewRunTimeSettings() *RunTimeSettings {
@@ -236,6 +282,10 @@ func NewRunTimeSettingsFlags(settings *RunTimeSettings) *pflag.FlagSet {
 		`Should we download the results once the job is complete?`)
 	flags.BoolVarP(&settings.Follow, "follow", "f", settings.Follow,
 		`When specified will follow the output from the job as it runs`)
+	flags.StringVar(&settings.Name, "name", settings.Name,
+		`The name of the job, unique among your jobs, which can be used in place of its ID.`)
+	flags.BoolVar(&settings.RequireDigest, "require-digest", settings.RequireDigest,
+		`Refuse to submit a docker job whose image is given by tag rather than pinned to a digest, as in image@sha256:...`)
 
 	return flags
 }
@@ -266,16 +316,37 @@ func ExecuteJob(ctx context.Context,
 		apiClient = GetAPIClient()
 	}
 
+	if runtimeSettings.Name != "" {
+		j.Metadata.Name = runtimeSettings.Name
+	}
+	if err := job.VerifyJobName(j.Metadata.Name); err != nil {
+		return err
+	}
+
 	err := job.VerifyJob(ctx, j)
 	if err != nil {
 		log.Ctx(ctx).Err(err).Msg("Job failed to validate.")
 		return err
 	}
+	if runtimeSettings.RequireDigest {
+		if err = verifyImageDigest(j); err != nil {
+			return err
+		}
+	}
 
+	submittedImage := j.Spec.Docker.Image
 	j, err = submitJob(ctx, apiClient, j)
 	if err != nil {
 		return err
 	}
+	if j.Spec.Engine == model.EngineDocker && j.Spec.Docker.Image != submittedImage {
+		cmd.PrintErrf("Image %s pinned to %s\n", submittedImage, j.Spec.Docker.Image)
+	}
+	for _, input := range j.Spec.Inputs {
+		if input.IPNSName != "" {
+			cmd.PrintErrf("Input ipns://%s resolved to %s\n", input.IPNSName, input.CID)
+		}
+	}
 
 	// if we are in --wait=false - print the id then exit
 	// because all code after this point is related to
@@ -398,6 +469,10 @@ func summariseExecutions(state model.JobState) map[string][]string {
 		} else if execution.State.IsDiscarded() {
 			message = execution.Status
 		}
+		if execution.FailureReason != nil {
+			message = strings.TrimSpace(strings.TrimSpace(message) + "\nLikely cause: " +
+				execution.FailureReason.Message + "\nHint: " + execution.FailureReason.Hint)
+		}
 
 		if message != "" {
 			results[message] = append(results[message], system.GetShortID(execution.NodeID))
@@ -413,35 +488,79 @@ func downloadResultsHandler(
 	jobID string,
 	downloadSettings model.DownloaderSettings,
 ) error {
+	outputDir, err := downloadJobResults(ctx, cm, cmd, jobID, downloadSettings)
+	if err != nil || outputDir == "" {
+		return err
+	}
+
+	cmd.Printf("Results for job '%s' have been written to...\n", jobID)
+	cmd.Printf("%s\n", outputDir)
+
+	return nil
+}
+
+// fetchJobResults returns the full ID of the job and the results that can still be downloaded, limited to those of a
+// single node if one is set.
+func fetchJobResults(ctx context.Context, cmd *cobra.Command, jobID string, nodeID string) (string, []model.PublishedResult, error) {
 	cmd.PrintErrf("Fetching results of job '%s'...\n", jobID)
 	j, _, err := GetAPIClient().Get(ctx, jobID)
 
 	if err != nil {
 		if _, ok := err.(*bacerrors.JobNotFound); ok {
-			return err
-		} else {
-			Fatal(cmd, fmt.Sprintf("Unknown error trying to get job (ID: %s): %+v", jobID, err), 1)
+			return "", nil, err
+		}
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			return "", nil, fmt.Errorf("%s", er.Message)
 		}
+		// the results of other jobs may still be downloading, so this is not fatal here
+		return "", nil, fmt.Errorf("unknown error trying to get job (ID: %s): %w", jobID, err)
 	}
 
 	results, err := GetAPIClient().GetResults(ctx, j.Job.Metadata.ID)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	if len(results) == 0 {
-		return fmt.Errorf("no results found")
+		return "", nil, fmt.Errorf("no results found")
 	}
 
-	processedDownloadSettings, err := processDownloadSettings(downloadSettings, j.Job.Metadata.ID)
+	results, err = downloader.ResultsOfNode(results, nodeID)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	downloaderProvider := util.NewStandardDownloaders(cm, &processedDownloadSettings)
+	// results past their retention period have been unpinned by the node that published them
+	results, err = skipExpiredResults(cmd, jobID, results)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
+	return j.Job.Metadata.ID, results, nil
+}
+
+// downloadJobResults downloads the results of the job and returns the directory they were written to, which is empty
+// if they cannot be downloaded by the client.
+func downloadJobResults(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	cmd *cobra.Command,
+	jobID string,
+	downloadSettings model.DownloaderSettings,
+) (string, error) {
+	fullJobID, results, err := fetchJobResults(ctx, cmd, jobID, downloadSettings.NodeID)
+	if err != nil {
+		return "", err
+	}
+
+	processedDownloadSettings, err := processDownloadSettings(downloadSettings, fullJobID)
+	if err != nil {
+		return "", err
+	}
+	// the results compute nodes serve themselves are only served to the clients allowed to see the job
+	processedDownloadSettings.NodeHeaders = map[string]string{handlerwrapper.HTTPHeaderClientID: system.GetClientID()}
+	setAPIHeaders(processedDownloadSettings.NodeHeaders)
+
+	downloaderProvider := util.NewStandardDownloaders(cm, &processedDownloadSettings)
 
 	// check if we don't support downloading the results
 	for _, result := range results {
@@ -450,10 +569,10 @@ func downloadResultsHandler(
 				"No supported downloader found for the published results. You will have to download the results differently.")
 			b, err := json.MarshalIndent(results, "", "    ")
 			if err != nil {
-				return err
+				return "", err
 			}
 			cmd.PrintErrln(string(b))
-			return nil
+			return "", nil
 		}
 	}
 
@@ -465,12 +584,49 @@ func downloadResultsHandler(
 	)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	cmd.Printf("Results for job '%s' have been written to...\n", jobID)
-	cmd.Printf("%s\n", processedDownloadSettings.OutputDir)
+	return processedDownloadSettings.OutputDir, nil
+}
+
+// skipExpiredResults reports and removes the results that have expired, and fails if all of them have.
+func skipExpiredResults(cmd *cobra.Command, jobID string, results []model.PublishedResult) ([]model.PublishedResult, error) {
+	now := time.Now()
+	available := make([]model.PublishedResult, 0, len(results))
+	var lastExpiry time.Time
+	for _, result := range results {
+		if !result.Expired(now) {
+			available = append(available, result)
+			continue
+		}
+		expiresAt := *result.Data.ExpiresAt
+		cmd.PrintErrf("Results of job '%s' published by node %s expired at %s and are no longer retained.\n",
+			jobID, result.NodeID, expiresAt.Format(time.RFC3339))
+		if expiresAt.After(lastExpiry) {
+			lastExpiry = expiresAt
+		}
+	}
+	if len(available) == 0 {
+		return nil, fmt.Errorf("results of job '%s' expired at %s", jobID, lastExpiry.Format(time.RFC3339))
+	}
+	return available, nil
+}
 
+// verifyImageDigest returns an error if the job runs a docker image that is not pinned to a digest, and so could run
+// a different image than the one the tag points to now.
+func verifyImageDigest(j *model.Job) error {
+	if j.Spec.Engine != model.EngineDocker {
+		return nil
+	}
+	image, err := docker.NewImageID(j.Spec.Docker.Image)
+	if err != nil {
+		return fmt.Errorf("invalid docker image %q: %w", j.Spec.Docker.Image, err)
+	}
+	if !image.HasDigest() {
+		return fmt.Errorf("docker image %s is not pinned to a digest, give it as %s@sha256:<digest> to submit it "+
+			"with --require-digest", j.Spec.Docker.Image, j.Spec.Docker.Image)
+	}
 	return nil
 }
 
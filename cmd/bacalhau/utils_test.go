@@ -4,6 +4,7 @@ package bacalhau
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/bacalhau-project/bacalhau/pkg/job"
@@ -155,3 +156,25 @@ func (s *UtilsSuite) TestImages() {
 		})
 	}
 }
+
+func (s *UtilsSuite) TestVerifyImageDigest() {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	for image, pinned := range map[string]bool{
+		"ubuntu":                      false,
+		"ubuntu:latest":               false,
+		"ghcr.io/org/image:v1":        false,
+		"ubuntu@" + digest:            true,
+		"ubuntu:22.04@" + digest:      true,
+		"ghcr.io/org/image@" + digest: true,
+	} {
+		j := &model.Job{Spec: model.Spec{Engine: model.EngineDocker, Docker: model.JobSpecDocker{Image: image}}}
+		err := verifyImageDigest(j)
+		if pinned {
+			s.Require().NoError(err, image)
+		} else {
+			s.Require().ErrorContains(err, "not pinned to a digest", image)
+		}
+	}
+
+	s.Require().NoError(verifyImageDigest(&model.Job{Spec: model.Spec{Engine: model.EngineWasm}}))
+}
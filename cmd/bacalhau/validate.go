@@ -2,28 +2,30 @@ package bacalhau
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	jobutils "github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
-	"github.com/invopop/jsonschema"
 	"github.com/spf13/cobra"
 
 	"k8s.io/kubectl/pkg/util/i18n"
-	"sigs.k8s.io/yaml"
-
-	"github.com/xeipuuv/gojsonschema"
-
-	"github.com/tidwall/sjson"
 )
 
 var (
 	validateLong = templates.LongDesc(i18n.T(`
 		Validate a job from a file
 
-		JSON and YAML formats are accepted.
+		JSON and YAML formats are accepted. The job is checked against its schema, and by the same validation the
+		requester performs when the job is submitted: that its engine has what it needs to run, its storage specs are
+		well-formed and its resources can be parsed. Every problem found is listed.
+
+		The environment variables the job sets are checked against the patterns given with --env-allowlist and
+		--env-denylist, which are those of the requester the job is submitted to.
 `))
 
 	//nolint:lll // Documentation
@@ -31,19 +33,27 @@ var (
 		# Validate a job using the data in job.yaml
 		bacalhau validate ./job.yaml
 
+		# Or with the --filename flag, as with create
+		bacalhau validate -f ./job.yaml
+
 		# Validate a job using stdin
 		cat job.yaml | bacalhau validate
 
+		# Validate a job submitted to a requester that denies the AWS_* environment variables
+		bacalhau validate --env-denylist 'AWS_*' ./job.yaml
+
 		# Output the jsonschema for a bacalhau job
 		bacalhau validate --output-schema
 `))
 )
 
 type ValidateOptions struct {
-	Filename        string // Filename for job (can be .json or .yaml)
-	OutputFormat    string // Output format (json or yaml)
-	OutputSchema    bool   // Output the schema to stdout
-	OutputDirectory string // Output directory for the job
+	Filename        string   // Filename for job (can be .json or .yaml)
+	OutputFormat    string   // Output format (json or yaml)
+	OutputSchema    bool     // Output the schema to stdout
+	OutputDirectory string   // Output directory for the job
+	EnvAllowList    []string // Patterns of the environment variables the job can set
+	EnvDenyList     []string // Patterns of the environment variables the job can't set
 }
 
 func NewValidateOptions() *ValidateOptions {
@@ -69,17 +79,28 @@ func newValidateCmd() *cobra.Command {
 		},
 	}
 
+	validateCmd.PersistentFlags().StringVarP(
+		&OV.Filename, "filename", "f", OV.Filename,
+		`Path to the job file, or - to read it from stdin.`,
+	)
 	validateCmd.PersistentFlags().BoolVar(
 		&OV.OutputSchema, "output-schema", OV.OutputSchema,
 		`Output the JSON schema for a Job to stdout then exit`,
 	)
+	validateCmd.PersistentFlags().StringSliceVar(
+		&OV.EnvAllowList, "env-allowlist", OV.EnvAllowList,
+		`Patterns of the names of the environment variables the job can set, as given to the requester with the same flag.`,
+	)
+	validateCmd.PersistentFlags().StringSliceVar(
+		&OV.EnvDenyList, "env-denylist", OV.EnvDenyList,
+		`Patterns of the names of the environment variables the job can't set, as given to the requester with the same flag.`,
+	)
 
 	return validateCmd
 }
 
 func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
-	j := &model.Job{}
-	jsonSchemaData, err := GenerateJobJSONSchema()
+	jsonSchemaData, err := jobutils.JobJSONSchema()
 	if err != nil {
 		return err
 	}
@@ -90,115 +111,87 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 		return nil
 	}
 
-	if len(cmdArgs) == 0 {
-		_ = cmd.Usage()
-		Fatal(cmd, "You must specify a filename or provide the content to be validated via stdin.", 1)
+	for _, patterns := range [][]string{OV.EnvAllowList, OV.EnvDenyList} {
+		if err = semantic.ValidateEnvironmentVariablePatterns(patterns); err != nil {
+			Fatal(cmd, err.Error(), 1)
+			return nil
+		}
 	}
 
-	OV.Filename = cmdArgs[0]
-	var byteResult []byte
+	if len(cmdArgs) > 0 {
+		if OV.Filename != "" {
+			Fatal(cmd, "Give the job file either as an argument or with --filename, not both", 1)
+			return nil
+		}
+		OV.Filename = cmdArgs[0]
+	}
 
-	if OV.Filename == "" {
-		// Read from stdin
-		byteResult, err = io.ReadAll(cmd.InOrStdin())
+	var byteResult []byte
+	if OV.Filename == "" || OV.Filename == "-" {
+		byteResult, err = ReadFromStdinIfAvailable(cmd, nil)
 		if err != nil {
 			Fatal(cmd, fmt.Sprintf("Error reading from stdin: %s", err), 1)
+			return nil
 		}
-		if byteResult == nil {
-			// Can you ever get here?
-			Fatal(cmd, "No filename provided.", 1)
+		if len(byteResult) == 0 {
+			_ = cmd.Usage()
+			Fatal(cmd, "You must specify a filename or provide the content to be validated via stdin.", 1)
+			return nil
 		}
 	} else {
-		var file *os.File
 		fileextension := filepath.Ext(OV.Filename)
-		file, err = os.Open(OV.Filename)
-
-		if err != nil {
-			Fatal(cmd, fmt.Sprintf("Error opening file (%s): %s", OV.Filename, err), 1)
+		if fileextension != ".json" && fileextension != ".yaml" && fileextension != ".yml" {
+			Fatal(cmd, fmt.Sprintf("File extension (%s) not supported. The file must end in either .yaml, .yml or .json.", fileextension), 1)
+			return nil
 		}
-
-		byteResult, err = io.ReadAll(file)
-
+		byteResult, err = os.ReadFile(OV.Filename)
 		if err != nil {
-			return err
-		}
-
-		if fileextension == ".json" || fileextension == ".yaml" || fileextension == ".yml" {
-			// Yaml can parse json
-			err = model.YAMLUnmarshalWithMax(byteResult, &j)
-			if err != nil {
-				Fatal(cmd, fmt.Sprintf("Error unmarshaling yaml from file (%s): %s", OV.Filename, err), 1)
-			}
-		} else {
-			Fatal(cmd, fmt.Sprintf("File extension (%s) not supported. The file must end in either .yaml, .yml or .json.", fileextension), 1)
+			Fatal(cmd, fmt.Sprintf("Error opening file (%s): %s", OV.Filename, err), 1)
+			return nil
 		}
 	}
 
-	// Convert the schema to JSON - this is required for the gojsonschema library
-	// Noop if you pass JSON through
-	fileContentsAsJSONBytes, err := yaml.YAMLToJSON(byteResult)
-	if err != nil {
-		Fatal(cmd, fmt.Sprintf("Error converting yaml to json: %s", err), 1)
+	// the files the job file extends are merged like create does, and the profiles are only validated once applied,
+	// so they are dropped like create does without --profile
+	var rawMap map[string]interface{}
+	if err = model.YAMLUnmarshalWithMax(byteResult, &rawMap); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error unmarshaling yaml from file (%s): %s", OV.Filename, err), 1)
+		return nil
 	}
-
-	// println(str)
-	schemaLoader := gojsonschema.NewStringLoader(string(jsonSchemaData))
-	documentLoader := gojsonschema.NewStringLoader(string(fileContentsAsJSONBytes))
-
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	extended, err := jobutils.ResolveExtends(rawMap, OV.Filename)
 	if err != nil {
-		Fatal(cmd, fmt.Sprintf("Error validating json: %s", err), 1)
+		Fatal(cmd, fmt.Sprintf("Error resolving extended job files: %s", err), 1)
+		return nil
 	}
-
-	if result.Valid() {
-		cmd.Println("The Job is valid")
-	} else {
-		msg := "The Job is not valid. See errors:\n"
-		for _, desc := range result.Errors() {
-			msg += fmt.Sprintf("- %s\n", desc)
+	if profiled, _ := jobutils.ApplyProfile(extended, ""); len(rawMap) > 0 && !reflect.DeepEqual(profiled, rawMap) {
+		if byteResult, err = model.JSONMarshalWithMax(profiled); err != nil {
+			return err
 		}
-		Fatal(cmd, msg, 1)
 	}
-	return nil
-}
-
-func GenerateJobJSONSchema() ([]byte, error) {
-	s := jsonschema.Reflect(&model.Job{})
-	// Find key in a json document in Golang
-	// https://stackoverflow.com/questions/52953282/how-to-find-a-key-in-a-json-document
 
-	jsonSchemaData, err := model.JSONMarshalIndentWithMax(s, 2)
-	if err != nil {
-		return nil, fmt.Errorf("error indenting %s", err)
+	// The same validation as the requester performs when the job is submitted
+	j, err := jobutils.ParseJobDocument(cmd.Context(), byteResult)
+	if err == nil {
+		err = jobutils.AdmitJob(cmd.Context(), j)
 	}
-
-	// JSON String
-	jsonString := string(jsonSchemaData)
-
-	enumTypes := []struct {
-		Name  string
-		Path  string
-		Enums []string
-	}{
-		{Name: "Engine",
-			Path:  "$defs.Spec.properties.Engine",
-			Enums: model.EngineNames()},
-		{Name: "Verifier",
-			Path:  "$defs.Spec.properties.Verifier",
-			Enums: model.VerifierNames()},
-		{Name: "Publisher",
-			Path:  "$defs.Spec.properties.Publisher",
-			Enums: model.PublisherNames()},
-		{Name: "StorageSource",
-			Path:  "$defs.StorageSpec.properties.StorageSource",
-			Enums: model.StorageSourceNames()},
+	if err == nil {
+		err = jobutils.VerifyEnvironmentVariables(&j.Spec, model.EnvironmentVariablePolicy{
+			Allow: OV.EnvAllowList,
+			Deny:  OV.EnvDenyList,
+		})
 	}
-	for _, enumType := range enumTypes {
-		// Use sjson to find the enum type path in the JSON
-		jsonString, _ = sjson.Set(jsonString, enumType.Path+".type", "string")
-
-		jsonString, _ = sjson.Set(jsonString, enumType.Path+".enum", enumType.Enums)
+	if invalid, ok := err.(jobutils.ErrInvalidJobDocument); ok {
+		Fatal(cmd, invalid.Error(), 1)
+	} else if invalid, ok := err.(*bacerrors.JobSpecInvalid); ok {
+		problems := jobutils.ErrInvalidJobDocument{}
+		for _, problem := range invalid.GetProblems() {
+			problems.Errors = append(problems.Errors, problem.String())
+		}
+		Fatal(cmd, problems.Error(), 1)
+	} else if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error validating job: %s", err), 1)
+	} else {
+		cmd.Println("The Job is valid")
 	}
-
-	return []byte(jsonString), nil
+	return nil
 }
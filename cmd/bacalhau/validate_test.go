@@ -4,6 +4,8 @@ package bacalhau
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
@@ -25,8 +27,9 @@ func (s *ValidateSuite) TestValidate() {
 		testFile string
 		valid    bool
 	}{
-		"validJobFile":   {testFile: "../../testdata/job-noop.yaml", valid: true},
-		"InvalidJobFile": {testFile: "../../testdata/job-noop-invalid.yml", valid: false},
+		"validJobFile":    {testFile: "../../testdata/job-noop.yaml", valid: true},
+		"InvalidJobFile":  {testFile: "../../testdata/job-noop-invalid.yml", valid: false},
+		"extendedJobFile": {testFile: "../../testdata/job-noop-extends.yaml", valid: true},
 	}
 	for name, test := range tests {
 		s.Run(name, func() {
@@ -53,3 +56,30 @@ func (s *ValidateSuite) TestValidate() {
 
 	}
 }
+
+func (s *ValidateSuite) TestValidateRunsAdmissionChecks() {
+	Fatal = FakeFatalErrorHandler
+
+	filename := filepath.Join(s.T().TempDir(), "job.yaml")
+	require.NoError(s.T(), os.WriteFile(filename, []byte(`
+APIVersion: V1beta1
+Spec:
+  Engine: Docker
+  Docker:
+    Image: ubuntu
+  Resources:
+    Memory: lots
+  Inputs:
+    - StorageSource: URLDownload
+      URL: ftp://example.com/data.csv
+      path: /inputs
+`), 0600))
+
+	_, out, err := ExecuteTestCobraCommand("validate", "-f", filename)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), fatalError.Message, "The Job is not valid.")
+	require.Contains(s.T(), fatalError.Message, "- Spec.Resources.Memory: invalid size \"lots\"")
+	require.Contains(s.T(), fatalError.Message, "- Spec.Inputs[0].URL: \"ftp://example.com/data.csv\" is not an http or https URL")
+}
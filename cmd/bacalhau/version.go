@@ -18,12 +18,12 @@ package bacalhau
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
+	"io"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/version"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
@@ -37,14 +37,16 @@ type Versions struct {
 // VersionOptions is a struct to support version command
 type VersionOptions struct {
 	ClientOnly bool
-	Output     string
+	Output     OutputOptions
 
 	args []string
 }
 
 // NewVersionOptions returns initialized Options
 func NewVersionOptions() *VersionOptions {
-	return &VersionOptions{}
+	return &VersionOptions{
+		Output: NewOutputOptions(TableFormat),
+	}
 }
 
 func newVersionCmd() *cobra.Command {
@@ -58,7 +60,7 @@ func newVersionCmd() *cobra.Command {
 		},
 	}
 	versionCmd.Flags().BoolVar(&oV.ClientOnly, "client", oV.ClientOnly, "If true, shows client version only (no server required).")
-	versionCmd.Flags().StringVarP(&oV.Output, "output", "o", oV.Output, "One of 'yaml' or 'json'.")
+	versionCmd.Flags().AddFlagSet(OutputFormatFlags(&oV.Output))
 
 	return versionCmd
 }
@@ -66,8 +68,6 @@ func newVersionCmd() *cobra.Command {
 func runVersion(cmd *cobra.Command, oV *VersionOptions) error {
 	ctx := cmd.Context()
 
-	oV.Output = strings.TrimSpace(strings.ToLower(oV.Output))
-
 	err := oV.Validate(cmd)
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Error validating version: %s\n", err), 1)
@@ -87,11 +87,7 @@ func (oV *VersionOptions) Validate(*cobra.Command) error {
 		return fmt.Errorf("extra arguments: %v", oV.args)
 	}
 
-	if oV.Output != "" && oV.Output != YAMLFormat && oV.Output != JSONFormat {
-		return errors.New(`--output must be 'yaml' or 'json'`)
-	}
-
-	return nil
+	return oV.Output.Validate()
 }
 
 // Run executes version command
@@ -112,29 +108,18 @@ func (oV *VersionOptions) Run(ctx context.Context, cmd *cobra.Command) error {
 		versions.ServerVersion = serverVersion
 	}
 
-	switch oV.Output {
-	case "":
-		cmd.Printf("Client Version: %s\n", versions.ClientVersion.GitVersion)
-		if versions.ServerVersion != nil {
-			cmd.Printf("Server Version: %s\n", versions.ServerVersion.GitVersion)
-		}
-	case YAMLFormat:
-		marshaled, err := model.YAMLMarshalWithMax(versions)
-		if err != nil {
-			return err
-		}
-		cmd.Println(string(marshaled))
-	case JSONFormat:
-		marshaled, err := model.JSONMarshalWithMax(versions)
-		if err != nil {
-			return err
+	return printOutput(cmd, oV.Output, versions, func(w io.Writer) error {
+		tw := newOutputTable(w, oV.Output, table.StyleLight)
+		tw.AppendHeader(table.Row{"", "version", "commit", "os", "arch"})
+		for _, v := range []struct {
+			name string
+			info *model.BuildVersionInfo
+		}{{"Client Version", versions.ClientVersion}, {"Server Version", versions.ServerVersion}} {
+			if v.info != nil {
+				tw.AppendRow(table.Row{v.name, v.info.GitVersion, v.info.GitCommit, v.info.GOOS, v.info.GOARCH})
+			}
 		}
-		cmd.Println(string(marshaled))
-	default:
-		// There is a bug in the program if we hit this case.
-		// However, we follow a policy of never panicking.
-		return fmt.Errorf("VersionOptions were not validated: --output=%q should have been rejected", oV.Output)
-	}
-
-	return nil
+		tw.Render()
+		return nil
+	})
 }
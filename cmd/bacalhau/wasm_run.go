@@ -49,6 +49,9 @@ type WasmRunOptions struct {
 	NodeSelector    string // Selector (label query) to filter nodes on which this job can be executed
 	Publisher       opts.PublisherOpt
 	Inputs          opts.StorageOpt
+	OutputContracts opts.OutputContractOpt
+	// EngineRequirements are the version and features the wasm engine must have
+	EngineRequirements model.EngineRequirements
 }
 
 func NewRunWasmOptions() *WasmRunOptions {
@@ -128,6 +131,11 @@ func newRunWasmCmd() *cobra.Command {
 		&ODR.Job.Spec.Deal.Concurrency, "concurrency", "c", ODR.Job.Spec.Deal.Concurrency,
 		`How many nodes should run the job`,
 	)
+	wasmRunCmd.PersistentFlags().BoolVar(
+		&ODR.Job.Spec.Deal.Adaptive, "adaptive-concurrency", ODR.Job.Spec.Deal.Adaptive,
+		`Start with a single execution, and only run more of them, up to the concurrency, when the verifier needs more `+
+			`results or the node that ran the job is not trusted yet`,
+	)
 	wasmRunCmd.PersistentFlags().IntVar(
 		&ODR.Job.Spec.Deal.Confidence, "confidence", ODR.Job.Spec.Deal.Confidence,
 		`The minimum number of nodes that must agree on a verification result`,
@@ -140,12 +148,37 @@ func newRunWasmCmd() *cobra.Command {
 		&ODR.Job.Spec.Timeout, "timeout", ODR.Job.Spec.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
 	)
+	wasmRunCmd.PersistentFlags().IntVar(
+		&ODR.Job.Spec.Priority, "priority", ODR.Job.Spec.Priority,
+		`Job priority. Compute nodes that allow preemption stop running jobs of lower priority to make room for the job`,
+	)
+	wasmRunCmd.PersistentFlags().StringVar(
+		&ODR.Job.Spec.Reservation, "reservation", ODR.Job.Spec.Reservation,
+		`Token of a capacity reservation made with 'bacalhau node reserve', to run the job on the reserved capacity`,
+	)
+	wasmRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.Job.Spec.Residency, "residency", ODR.Job.Spec.Residency,
+		`Jurisdictions the job and its data must stay in (e.g. eu). Only nodes labelled with one of them run the job`,
+	)
+	wasmRunCmd.PersistentFlags().StringVar(
+		&ODR.EngineRequirements.MinVersion, "engine-min-version", ODR.EngineRequirements.MinVersion,
+		`Oldest version of the wasm engine that can run the job. Nodes with an older engine are not selected`,
+	)
+	wasmRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.EngineRequirements.Features, "engine-feature", ODR.EngineRequirements.Features,
+		`Feature the wasm engine must support (e.g. wasi-p2). Nodes whose engine lacks it are not selected`,
+	)
 	wasmRunCmd.PersistentFlags().StringVar(
 		&ODR.Job.Spec.Wasm.EntryPoint, "entry-point", ODR.Job.Spec.Wasm.EntryPoint,
 		`The name of the WASM function in the entry module to call. This should be a zero-parameter zero-result function that
 		will execute the job.`,
 	)
+	wasmRunCmd.PersistentFlags().StringVar(
+		&ODR.Job.Spec.Resources.Fuel, "fuel", ODR.Job.Spec.Resources.Fuel,
+		`The maximum number of WASM function calls the job may make before it is stopped (e.g. 1000000). Unlimited if not set.`,
+	)
 	wasmRunCmd.PersistentFlags().VarP(&ODR.Inputs, "input", "i", inputUsageMsg)
+	wasmRunCmd.PersistentFlags().Var(&ODR.OutputContracts, "output-contract", outputContractUsageMsg)
 	wasmRunCmd.PersistentFlags().VarP(
 		EnvVarMapFlag(&ODR.Job.Spec.Wasm.EnvironmentVariables), "env", "e",
 		`The environment variables to supply to the job (e.g. --env FOO=bar --env BAR=baz)`,
@@ -180,7 +213,11 @@ func runWasm(
 	}
 	ODR.Job.Spec.NodeSelectors = nodeSelectorRequirements
 	ODR.Job.Spec.Inputs = ODR.Inputs.Values()
+	ODR.Job.Spec.OutputContracts = ODR.OutputContracts.Values()
 	ODR.Job.Spec.PublisherSpec = ODR.Publisher.Value()
+	if !ODR.EngineRequirements.IsEmpty() {
+		ODR.Job.Spec.EngineRequirements = &ODR.EngineRequirements
+	}
 
 	// Try interpreting this as a CID.
 	wasmCid, err := cid.Parse(wasmCidOrPath)
@@ -0,0 +1,124 @@
+package bacalhau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	//nolint:lll // Documentation
+	watchLong = templates.LongDesc(i18n.T(`
+		Print the changes to the state of a job and of its executions as they happen, starting with the changes the job
+		went through before the command was run.
+
+		The command returns once the job has ended, and exits with a non-zero code if the job ended in the Error state.
+		With --output json or --output yaml, each change is printed as a JSON object on its own line or as a YAML document.
+`))
+
+	//nolint:lll // Documentation
+	watchExample = templates.Examples(i18n.T(`
+		# Follow the state of a job until it ends
+		bacalhau watch 51225160
+
+		# Follow the state of a job as JSON lines
+		bacalhau watch 51225160 --output json
+`))
+)
+
+type WatchOptions struct {
+	Output OutputOptions // The format to print the changes in
+}
+
+func NewWatchOptions() *WatchOptions {
+	return &WatchOptions{
+		Output: NewOutputOptions(TableFormat),
+	}
+}
+
+func newWatchCmd() *cobra.Command {
+	OW := NewWatchOptions()
+
+	watchCmd := &cobra.Command{
+		Use:     "watch [id]",
+		Short:   "Follow the state of a job until it ends",
+		Long:    watchLong,
+		Example: watchExample,
+		Args:    cobra.ExactArgs(1),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return watch(cmd, cmdArgs[0], OW)
+		},
+	}
+	watchCmd.Flags().AddFlagSet(OutputFormatFlags(&OW.Output))
+	return watchCmd
+}
+
+func watch(cmd *cobra.Command, jobID string, OW *WatchOptions) error {
+	if !validateOutput(cmd, &OW.Output) {
+		return nil
+	}
+
+	finalState := model.JobStateNew
+	err := GetAPIClient().FollowJobStates(cmd.Context(), jobID, func(event model.JobHistory) error {
+		if event.Type == model.JobHistoryTypeJobLevel && event.JobState != nil {
+			finalState = event.JobState.New
+		}
+		if OW.Output.Format == YAMLFormat {
+			cmd.Println("---")
+		}
+		return printOutput(cmd, OW.Output, event, func(w io.Writer) error {
+			return printStateChange(w, event)
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		if er, ok := err.(*bacerrors.ErrorResponse); ok {
+			Fatal(cmd, er.Message, 1)
+			return nil
+		}
+		Fatal(cmd, fmt.Sprintf("Error following job %s: %s", jobID, err), 1)
+		return nil
+	}
+
+	if finalState == model.JobStateError {
+		Fatal(cmd, fmt.Sprintf("Job %s ended in the %s state", jobID, finalState), 1)
+	}
+	return nil
+}
+
+// printStateChange prints a change to the state of a job or of one of its executions on a single line. Changes that
+// only update the job, without moving it to another state, are skipped.
+func printStateChange(w io.Writer, event model.JobHistory) error {
+	var subject, previous, next string
+	switch {
+	case event.JobState != nil:
+		if event.JobState.Previous == event.JobState.New {
+			return nil
+		}
+		subject = "job " + shortID(false, event.JobID)
+		previous, next = event.JobState.Previous.String(), event.JobState.New.String()
+	case event.ExecutionState != nil:
+		subject = fmt.Sprintf("execution %s on %s", event.ComputeReference, shortID(false, event.NodeID))
+		previous, next = event.ExecutionState.Previous.String(), event.ExecutionState.New.String()
+	default:
+		return nil
+	}
+
+	line := fmt.Sprintf("%s  %s: %s -> %s", event.Time.Format(time.RFC3339), subject, previous, next)
+	if event.Comment != "" {
+		line += " (" + event.Comment + ")"
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
@@ -0,0 +1,77 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type WatchSuite struct {
+	BaseSuite
+}
+
+func TestWatchSuite(t *testing.T) {
+	suite.Run(t, new(WatchSuite))
+}
+
+func (s *WatchSuite) TestWatchCompletedJob() {
+	j, err := s.client.Submit(context.Background(), testutils.MakeNoopJob())
+	require.NoError(s.T(), err)
+
+	_, out, err := ExecuteTestCobraCommand("watch",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		j.Metadata.ID,
+	)
+	require.NoError(s.T(), err)
+	_, err = testutils.FirstFatalError(s.T(), out)
+	require.Error(s.T(), err, out)
+	require.Contains(s.T(), out, "job "+shortID(false, j.Metadata.ID)+": ")
+	require.Contains(s.T(), out, "-> "+model.JobStateCompleted.String())
+}
+
+func (s *WatchSuite) TestWatchJSON() {
+	j, err := s.client.Submit(context.Background(), testutils.MakeNoopJob())
+	require.NoError(s.T(), err)
+
+	_, out, err := ExecuteTestCobraCommand("watch",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--output", "json",
+		j.Metadata.ID,
+	)
+	require.NoError(s.T(), err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.NotEmpty(s.T(), lines)
+	var last model.JobHistory
+	for _, line := range lines {
+		var event model.JobHistory
+		require.NoError(s.T(), model.JSONUnmarshalWithMax([]byte(line), &event), line)
+		require.Equal(s.T(), j.Metadata.ID, event.JobID)
+		if event.Type == model.JobHistoryTypeJobLevel {
+			last = event
+		}
+	}
+	require.Equal(s.T(), model.JobStateCompleted, last.JobState.New)
+}
+
+func (s *WatchSuite) TestWatchUnknownJob() {
+	_, out, err := ExecuteTestCobraCommand("watch",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"unknown-job",
+	)
+	require.NoError(s.T(), err)
+	fatalError, err := testutils.FirstFatalError(s.T(), out)
+	require.NoError(s.T(), err, out)
+	require.Contains(s.T(), fatalError.Message, "unknown-job")
+}
@@ -0,0 +1,66 @@
+package bacerrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AmbiguousReference GenericError
+
+// NewAmbiguousReference returns the error of a reference to a job or node that matches more than one of them, listing
+// the candidates so the caller can pick one.
+func NewAmbiguousReference(kind string, ref string, candidates []string) *AmbiguousReference {
+	var e AmbiguousReference
+	e.Code = ErrorCodeAmbiguousReference
+	e.Message = fmt.Sprintf(ErrorMessageAmbiguousReference, kind, ref, strings.Join(candidates, ", "))
+	e.Details = make(map[string]interface{})
+	e.Details["kind"] = kind
+	e.Details["ref"] = ref
+	e.Details["candidates"] = candidates
+	e.SetError(fmt.Errorf("%s", e.Message))
+	return &e
+}
+
+func (e *AmbiguousReference) GetMessage() string {
+	return e.Message
+}
+func (e *AmbiguousReference) SetMessage(s string) {
+	e.Message = s
+}
+
+func (e *AmbiguousReference) Error() string {
+	return e.GetError().Error()
+}
+func (e *AmbiguousReference) GetError() error {
+	return e.Err
+}
+func (e *AmbiguousReference) SetError(err error) {
+	e.Err = err
+}
+
+func (e *AmbiguousReference) GetCode() string {
+	return ErrorCodeAmbiguousReference
+}
+func (e *AmbiguousReference) SetCode(string) {
+	e.Code = ErrorCodeAmbiguousReference
+}
+
+func (e *AmbiguousReference) GetDetails() map[string]interface{} {
+	return e.Details
+}
+
+// GetCandidates returns the jobs or nodes the reference matches.
+func (e *AmbiguousReference) GetCandidates() []string {
+	if candidates, ok := e.Details["candidates"].([]string); ok {
+		return candidates
+	}
+	return nil
+}
+
+const (
+	ErrorCodeAmbiguousReference = "error-ambiguous-reference"
+
+	ErrorMessageAmbiguousReference = "%s %q is ambiguous, it matches: %s"
+)
+
+var _ BacalhauErrorInterface = (*AmbiguousReference)(nil)
@@ -0,0 +1,78 @@
+package bacerrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JobSpecProblem is a malformed part of a job spec, by the path of its field in the spec.
+type JobSpecProblem struct {
+	Field   string `json:"Field"`
+	Message string `json:"Message"`
+}
+
+func (p JobSpecProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Field, p.Message)
+}
+
+type JobSpecInvalid GenericError
+
+// NewJobSpecInvalid returns an error for a job spec that is rejected when the job is submitted, listing every problem
+// found in the spec in its details.
+func NewJobSpecInvalid(problems []JobSpecProblem) *JobSpecInvalid {
+	var e JobSpecInvalid
+	e.Code = ErrorCodeJobSpecInvalid
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	e.Message = fmt.Sprintf(ErrorMessageJobSpecInvalid, strings.Join(messages, "; "))
+	e.Details = make(map[string]interface{})
+	e.Details["problems"] = problems
+	e.SetError(fmt.Errorf("%s", e.Message))
+	return &e
+}
+
+func (e *JobSpecInvalid) GetMessage() string {
+	return e.Message
+}
+func (e *JobSpecInvalid) SetMessage(s string) {
+	e.Message = s
+}
+
+func (e *JobSpecInvalid) Error() string {
+	return e.GetError().Error()
+}
+func (e *JobSpecInvalid) GetError() error {
+	return e.Err
+}
+func (e *JobSpecInvalid) SetError(err error) {
+	e.Err = err
+}
+
+func (e *JobSpecInvalid) GetCode() string {
+	return ErrorCodeJobSpecInvalid
+}
+func (e *JobSpecInvalid) SetCode(string) {
+	e.Code = ErrorCodeJobSpecInvalid
+}
+
+func (e *JobSpecInvalid) GetDetails() map[string]interface{} {
+	return e.Details
+}
+
+// GetProblems returns the problems found in the job spec.
+func (e *JobSpecInvalid) GetProblems() []JobSpecProblem {
+	if problems, ok := e.Details["problems"].([]JobSpecProblem); ok {
+		return problems
+	}
+	return nil
+}
+
+const (
+	ErrorCodeJobSpecInvalid = "error-job-spec-invalid"
+
+	ErrorMessageJobSpecInvalid = "Job spec is invalid: %s"
+)
+
+var _ BacalhauErrorInterface = (*JobSpecInvalid)(nil)
@@ -0,0 +1,64 @@
+package bacerrors
+
+import (
+	"fmt"
+)
+
+type JobSpecTooLarge GenericError
+
+// NewJobSpecTooLarge returns an error for a part of a job spec, e.g. its environment variables, that exceeds the size
+// accepted by the requester.
+func NewJobSpecTooLarge(field string, size uint64, limit uint64) *JobSpecTooLarge {
+	var e JobSpecTooLarge
+	e.Code = ErrorCodeJobSpecTooLarge
+	e.Message = fmt.Sprintf(ErrorMessageJobSpecTooLarge, field, size, limit)
+	e.Details = make(map[string]interface{})
+	e.Details["field"] = field
+	e.Details["size"] = size
+	e.Details["limit"] = limit
+	e.SetError(fmt.Errorf("%s", e.Message))
+	return &e
+}
+
+func (e *JobSpecTooLarge) GetMessage() string {
+	return e.Message
+}
+func (e *JobSpecTooLarge) SetMessage(s string) {
+	e.Message = s
+}
+
+func (e *JobSpecTooLarge) Error() string {
+	return e.GetError().Error()
+}
+func (e *JobSpecTooLarge) GetError() error {
+	return e.Err
+}
+func (e *JobSpecTooLarge) SetError(err error) {
+	e.Err = err
+}
+
+func (e *JobSpecTooLarge) GetCode() string {
+	return ErrorCodeJobSpecTooLarge
+}
+func (e *JobSpecTooLarge) SetCode(string) {
+	e.Code = ErrorCodeJobSpecTooLarge
+}
+
+func (e *JobSpecTooLarge) GetDetails() map[string]interface{} {
+	return e.Details
+}
+
+func (e *JobSpecTooLarge) GetField() string {
+	if field, ok := e.Details["field"]; ok {
+		return field.(string)
+	}
+	return ""
+}
+
+const (
+	ErrorCodeJobSpecTooLarge = "error-job-spec-too-large"
+
+	ErrorMessageJobSpecTooLarge = "Job %s is too large: %d bytes exceeds the limit of %d bytes"
+)
+
+var _ BacalhauErrorInterface = (*JobSpecTooLarge)(nil)
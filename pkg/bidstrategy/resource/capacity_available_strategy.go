@@ -2,6 +2,7 @@ package resource
 
 import (
 	"context"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
@@ -11,17 +12,21 @@ import (
 type AvailableCapacityStrategyParams struct {
 	RunningCapacityTracker  capacity.Tracker
 	EnqueuedCapacityTracker capacity.Tracker
+	// Reservations is the capacity held for capacity reservations, which is only offered to their jobs. Optional.
+	Reservations *capacity.Reservations
 }
 
 type AvailableCapacityStrategy struct {
 	runningCapacityTracker  capacity.Tracker
 	enqueuedCapacityTracker capacity.Tracker
+	reservations            *capacity.Reservations
 }
 
 func NewAvailableCapacityStrategy(ctx context.Context, params AvailableCapacityStrategyParams) *AvailableCapacityStrategy {
 	s := &AvailableCapacityStrategy{
 		runningCapacityTracker:  params.RunningCapacityTracker,
 		enqueuedCapacityTracker: params.EnqueuedCapacityTracker,
+		reservations:            params.Reservations,
 	}
 	return s
 }
@@ -30,6 +35,9 @@ func (s *AvailableCapacityStrategy) ShouldBidBasedOnUsage(
 	ctx context.Context, request bidstrategy.BidStrategyRequest, usage model.ResourceUsageData) (bidstrategy.BidStrategyResponse, error) {
 	// skip bidding if we don't have enough capacity available
 	availableCapacity := s.runningCapacityTracker.GetAvailableCapacity(ctx).Add(s.enqueuedCapacityTracker.GetAvailableCapacity(ctx))
+	if s.reservations != nil {
+		availableCapacity = s.reservations.Unreserved(availableCapacity, s.reservations.ReservationOf(request.Job), time.Now())
+	}
 	if !usage.LessThanEq(availableCapacity) {
 		return bidstrategy.BidStrategyResponse{
 			ShouldBid: false,
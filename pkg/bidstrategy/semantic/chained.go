@@ -3,6 +3,7 @@ package semantic
 import (
 	"context"
 	"reflect"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
@@ -23,7 +24,8 @@ func (c *ChainedBidStrategy) AddStrategy(strategy bidstrategy.SemanticBidStrateg
 }
 
 // ShouldBid Iterate over all strategies, and return shouldBid if no error is thrown
-// and none of the strategies return should not bid.
+// and none of the strategies return should not bid. Reasons given by strategies that
+// still bid, such as warnings, are kept in the response.
 func (c *ChainedBidStrategy) ShouldBid(
 	ctx context.Context,
 	request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
@@ -36,6 +38,7 @@ func (c *ChainedBidStrategy) delegate(
 	ctx context.Context,
 	f func(strategy bidstrategy.SemanticBidStrategy) (bidstrategy.BidStrategyResponse, error),
 ) (bidstrategy.BidStrategyResponse, error) {
+	var warnings []string
 	for _, strategy := range c.Strategies {
 		response, err := f(strategy)
 		if err != nil {
@@ -54,7 +57,10 @@ func (c *ChainedBidStrategy) delegate(
 				reflect.TypeOf(strategy).String(), status, response.Reason)
 			return response, nil
 		}
+		if response.Reason != "" {
+			warnings = append(warnings, response.Reason)
+		}
 	}
 
-	return bidstrategy.BidStrategyResponse{ShouldBid: true}, nil
+	return bidstrategy.BidStrategyResponse{ShouldBid: true, Reason: strings.Join(warnings, "; ")}, nil
 }
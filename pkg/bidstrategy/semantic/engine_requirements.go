@@ -0,0 +1,43 @@
+package semantic
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type EngineRequirementsStrategyParams struct {
+	// EngineInfo returns the version and features of the installed engine.
+	EngineInfo func(context.Context, model.Engine) (model.EngineInfo, error)
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*EngineRequirementsStrategy)(nil)
+
+// EngineRequirementsStrategy rejects jobs whose engine requirements the engine of the node does not meet, with the
+// requirements that are not met as the reason.
+type EngineRequirementsStrategy struct {
+	engineInfo func(context.Context, model.Engine) (model.EngineInfo, error)
+}
+
+func NewEngineRequirementsStrategy(params EngineRequirementsStrategyParams) *EngineRequirementsStrategy {
+	return &EngineRequirementsStrategy{
+		engineInfo: params.EngineInfo,
+	}
+}
+
+func (s *EngineRequirementsStrategy) ShouldBid(
+	ctx context.Context, request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	requirements := request.Job.Spec.EngineRequirements
+	if requirements.IsEmpty() {
+		return bidstrategy.NewShouldBidResponse(), nil
+	}
+	info, err := s.engineInfo(ctx, request.Job.Spec.Engine)
+	if err != nil {
+		return bidstrategy.BidStrategyResponse{}, err
+	}
+	if unmet := info.Check(requirements); !unmet.IsEmpty() {
+		return bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: unmet.String()}, nil
+	}
+	return bidstrategy.NewShouldBidResponse(), nil
+}
@@ -0,0 +1,69 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestEngineRequirementsStrategy(t *testing.T) {
+	installed := model.EngineInfo{
+		Engine:   model.EngineDocker,
+		Version:  "24.0.5",
+		Features: []string{model.EngineFeatureNvidiaRuntime},
+	}
+	for _, test := range []struct {
+		name         string
+		info         model.EngineInfo
+		requirements *model.EngineRequirements
+		shouldBid    bool
+		reason       string
+	}{
+		{name: "no requirements", info: model.EngineInfo{Engine: model.EngineDocker}, shouldBid: true},
+		{
+			name:         "requirements met",
+			info:         installed,
+			requirements: &model.EngineRequirements{MinVersion: "24.0", Features: []string{model.EngineFeatureNvidiaRuntime}},
+			shouldBid:    true,
+		},
+		{
+			name:         "older version",
+			info:         installed,
+			requirements: &model.EngineRequirements{MinVersion: "25"},
+			reason:       "version 25 is required but the engine version is 24.0.5",
+		},
+		{
+			name:         "unknown version",
+			info:         model.EngineInfo{Engine: model.EngineDocker},
+			requirements: &model.EngineRequirements{MinVersion: "20.10"},
+			reason:       "version 20.10 is required but the engine version is unknown",
+		},
+		{
+			name:         "missing feature",
+			info:         installed,
+			requirements: &model.EngineRequirements{Features: []string{model.EngineFeatureNvidiaRuntime, "rootless"}},
+			reason:       "features rootless are not supported",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := semantic.NewEngineRequirementsStrategy(semantic.EngineRequirementsStrategyParams{
+				EngineInfo: func(context.Context, model.Engine) (model.EngineInfo, error) { return test.info, nil },
+			})
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+				Job: model.Job{Spec: model.Spec{Engine: model.EngineDocker, EngineRequirements: test.requirements}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid, response.Reason)
+			if !test.shouldBid {
+				require.Equal(t, "Docker engine does not meet the requirements of the job: "+test.reason, response.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type EnvironmentVariablesStrategyParams struct {
+	// Allow are the patterns, such as AWS_* or MY_VAR, of the names of the environment variables jobs can set. Jobs can
+	// set any variable that is not denied if empty.
+	Allow []string
+	// Deny are the patterns of the names of the environment variables jobs can't set, even if they are allowed.
+	Deny []string
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*EnvironmentVariablesStrategy)(nil)
+
+// EnvironmentVariablesStrategy rejects jobs setting environment variables the operator of the compute node does not
+// allow, so that jobs can't change how the executors of the node behave.
+type EnvironmentVariablesStrategy struct {
+	policy model.EnvironmentVariablePolicy
+}
+
+func NewEnvironmentVariablesStrategy(params EnvironmentVariablesStrategyParams) *EnvironmentVariablesStrategy {
+	return &EnvironmentVariablesStrategy{
+		policy: model.EnvironmentVariablePolicy{Allow: params.Allow, Deny: params.Deny},
+	}
+}
+
+// ValidateEnvironmentVariablePatterns returns an error if a pattern is malformed.
+func ValidateEnvironmentVariablePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid environment variable pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (s *EnvironmentVariablesStrategy) ShouldBid(
+	_ context.Context, request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	for _, name := range request.Job.Spec.EnvironmentVariableNames() {
+		if err := s.policy.Check(name); err != nil {
+			return bidstrategy.BidStrategyResponse{
+				ShouldBid: false,
+				Reason:    fmt.Sprintf("%s by the node", err),
+			}, nil
+		}
+	}
+	return bidstrategy.NewShouldBidResponse(), nil
+}
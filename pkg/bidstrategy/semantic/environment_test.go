@@ -0,0 +1,65 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestEnvironmentVariablesStrategy(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		params    semantic.EnvironmentVariablesStrategyParams
+		spec      model.Spec
+		shouldBid bool
+	}{
+		{name: "no policy", spec: model.Spec{Docker: model.JobSpecDocker{EnvironmentVariables: []string{"ANY=1"}}}, shouldBid: true},
+		{
+			name:      "allowed",
+			params:    semantic.EnvironmentVariablesStrategyParams{Allow: []string{"AWS_*"}},
+			spec:      model.Spec{Docker: model.JobSpecDocker{EnvironmentVariables: []string{"AWS_REGION=eu-west-1"}}},
+			shouldBid: true,
+		},
+		{
+			name:   "not allowed",
+			params: semantic.EnvironmentVariablesStrategyParams{Allow: []string{"AWS_*"}},
+			spec:   model.Spec{Wasm: model.JobSpecWasm{EnvironmentVariables: map[string]string{"HOME": "/"}}},
+		},
+		{
+			name:   "denied",
+			params: semantic.EnvironmentVariablesStrategyParams{Deny: []string{"LD_*"}},
+			spec:   model.Spec{Process: model.JobSpecProcess{EnvironmentVariables: []string{"LD_PRELOAD=/evil.so"}}},
+		},
+		{
+			name:   "denied even if allowed",
+			params: semantic.EnvironmentVariablesStrategyParams{Allow: []string{"*"}, Deny: []string{"SECRET"}},
+			spec:   model.Spec{Docker: model.JobSpecDocker{EnvironmentVariables: []string{"SECRET=x"}}},
+		},
+		{
+			name:      "nothing set",
+			params:    semantic.EnvironmentVariablesStrategyParams{Allow: []string{"AWS_*"}},
+			shouldBid: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := semantic.NewEnvironmentVariablesStrategy(test.params)
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+				Job: model.Job{Spec: test.spec},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid, response.Reason)
+		})
+	}
+}
+
+func TestValidateEnvironmentVariablePatterns(t *testing.T) {
+	require.NoError(t, semantic.ValidateEnvironmentVariablePatterns([]string{"AWS_*", "MY_VAR", "[AB]_?"}))
+	require.Error(t, semantic.ValidateEnvironmentVariablePatterns([]string{"[AWS"}))
+}
@@ -0,0 +1,31 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+)
+
+// InteractiveStrategy rejects interactive jobs unless the node accepts them, as their clients stream input to them
+// while they run.
+type InteractiveStrategy struct {
+	Accept bool
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*InteractiveStrategy)(nil)
+
+func NewInteractiveStrategy(accept bool) *InteractiveStrategy {
+	return &InteractiveStrategy{accept}
+}
+
+// ShouldBid implements BidStrategy
+func (s *InteractiveStrategy) ShouldBid(
+	ctx context.Context,
+	request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	shouldBid := s.Accept || !request.Job.Spec.Interactive
+	return bidstrategy.BidStrategyResponse{
+		ShouldBid: shouldBid,
+		Reason:    fmt.Sprintf("interactive jobs are accepted: %t", s.Accept),
+	}, nil
+}
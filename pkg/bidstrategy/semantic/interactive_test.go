@@ -0,0 +1,38 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestInteractiveStrategy(t *testing.T) {
+	for _, test := range []struct {
+		accept      bool
+		interactive bool
+		shouldBid   bool
+	}{
+		{accept: false, interactive: false, shouldBid: true},
+		{accept: false, interactive: true, shouldBid: false},
+		{accept: true, interactive: false, shouldBid: true},
+		{accept: true, interactive: true, shouldBid: true},
+	} {
+		name := fmt.Sprintf("accept=%t/interactive=%t", test.accept, test.interactive)
+		t.Run(name, func(t *testing.T) {
+			strategy := semantic.NewInteractiveStrategy(test.accept)
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+				Job: model.Job{Spec: model.Spec{Interactive: test.interactive}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	localdirectory "github.com/bacalhau-project/bacalhau/pkg/storage/local_directory"
+)
+
+// LocalPathSandboxStrategy rejects jobs mounting local paths that are not allowed for their namespace, so that
+// violations are caught before anything is executed.
+type LocalPathSandboxStrategy struct {
+	sandbox *localdirectory.Sandbox
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*LocalPathSandboxStrategy)(nil)
+
+// NewLocalPathSandboxStrategy returns a strategy rejecting the local paths the sandbox does not allow. No local path is
+// allowed if the sandbox is nil.
+func NewLocalPathSandboxStrategy(sandbox *localdirectory.Sandbox) *LocalPathSandboxStrategy {
+	if sandbox == nil {
+		sandbox = localdirectory.NewSandbox(nil)
+	}
+	return &LocalPathSandboxStrategy{sandbox: sandbox}
+}
+
+// ShouldBid implements BidStrategy
+func (s *LocalPathSandboxStrategy) ShouldBid(
+	ctx context.Context,
+	request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	for _, spec := range request.Job.Spec.AllStorageSpecs() {
+		if spec.StorageSource != model.StorageSourceLocalDirectory {
+			continue
+		}
+		_, _, err := s.sandbox.Authorize(ctx, *spec, request.Job.Metadata.ClientID)
+		var violation *localdirectory.SandboxViolation
+		if errors.As(err, &violation) {
+			return bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: violation.Error()}, nil
+		} else if errors.Is(err, os.ErrNotExist) {
+			return bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: "local path " + spec.SourcePath + " does not exist"}, nil
+		} else if err != nil {
+			return bidstrategy.BidStrategyResponse{}, err
+		}
+	}
+	return bidstrategy.NewShouldBidResponse(), nil
+}
@@ -0,0 +1,73 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	localdirectory "github.com/bacalhau-project/bacalhau/pkg/storage/local_directory"
+)
+
+func TestLocalPathSandboxStrategy(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(allowed, "escape")))
+	require.NoError(t, os.Mkdir(filepath.Join(allowed, "team"), 0755))
+
+	sandbox := localdirectory.NewSandbox(localdirectory.ParseAllowPaths([]string{
+		filepath.Join(allowed, "**"),
+		filepath.Join(allowed, "team") + ":rw@team-client",
+	}))
+	strategy := semantic.NewLocalPathSandboxStrategy(sandbox)
+
+	for _, test := range []struct {
+		name       string
+		sourcePath string
+		readWrite  bool
+		clientID   string
+		shouldBid  bool
+	}{
+		{name: "allowed path", sourcePath: allowed, shouldBid: true},
+		{name: "relative path", sourcePath: "relative", shouldBid: false},
+		{name: "parent path escape", sourcePath: filepath.Join(allowed, "..", filepath.Base(outside)), shouldBid: false},
+		{name: "symlink escape", sourcePath: filepath.Join(allowed, "escape"), shouldBid: false},
+		{name: "missing path", sourcePath: filepath.Join(allowed, "missing"), shouldBid: false},
+		{name: "read-write without namespace", sourcePath: filepath.Join(allowed, "team"), readWrite: true, shouldBid: false},
+		{name: "read-write in namespace", sourcePath: filepath.Join(allowed, "team"), readWrite: true, clientID: "team-client", shouldBid: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			request := getBidStrategyRequest()
+			request.Job.Metadata.ClientID = test.clientID
+			request.Job.Spec.Inputs = []model.StorageSpec{{
+				StorageSource: model.StorageSourceLocalDirectory,
+				SourcePath:    test.sourcePath,
+				ReadWrite:     test.readWrite,
+				Path:          "/inputs",
+			}}
+
+			response, err := strategy.ShouldBid(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid, response.Reason)
+		})
+	}
+}
+
+func TestLocalPathSandboxStrategyWithoutSandbox(t *testing.T) {
+	request := getBidStrategyRequest()
+	request.Job.Spec.Inputs = []model.StorageSpec{{
+		StorageSource: model.StorageSourceLocalDirectory,
+		SourcePath:    t.TempDir(),
+		Path:          "/inputs",
+	}}
+
+	response, err := semantic.NewLocalPathSandboxStrategy(nil).ShouldBid(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, response.ShouldBid)
+}
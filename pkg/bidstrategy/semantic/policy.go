@@ -9,6 +9,7 @@ import (
 func FromJobSelectionPolicy(jsp model.JobSelectionPolicy) bidstrategy.SemanticBidStrategy {
 	return NewChainedSemanticBidStrategy(
 		NewNetworkingStrategy(jsp.AcceptNetworkedJobs),
+		NewInteractiveStrategy(jsp.AcceptInteractiveJobs),
 		NewExternalCommandStrategy(ExternalCommandStrategyParams{
 			Command: jsp.ProbeExec,
 		}),
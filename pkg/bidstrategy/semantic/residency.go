@@ -0,0 +1,43 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+)
+
+type ResidencyStrategyParams struct {
+	// Jurisdiction is the jurisdiction the compute node declares with its jurisdiction label, if any.
+	Jurisdiction string
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*ResidencyStrategy)(nil)
+
+// ResidencyStrategy rejects jobs whose data residency does not include the jurisdiction of the compute node, so that
+// jobs handling regulated data never run outside the jurisdictions they allow, even if a requester asks the node to.
+type ResidencyStrategy struct {
+	jurisdiction string
+}
+
+func NewResidencyStrategy(params ResidencyStrategyParams) *ResidencyStrategy {
+	return &ResidencyStrategy{
+		jurisdiction: params.Jurisdiction,
+	}
+}
+
+func (s *ResidencyStrategy) ShouldBid(_ context.Context, request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	if request.Job.Spec.AllowsJurisdiction(s.jurisdiction) {
+		return bidstrategy.NewShouldBidResponse(), nil
+	}
+	jurisdiction := s.jurisdiction
+	if jurisdiction == "" {
+		jurisdiction = "no jurisdiction"
+	}
+	return bidstrategy.BidStrategyResponse{
+		ShouldBid: false,
+		Reason: fmt.Sprintf("job residency %s does not include the jurisdiction of the node (%s)",
+			strings.Join(request.Job.Spec.Residency, ", "), jurisdiction),
+	}, nil
+}
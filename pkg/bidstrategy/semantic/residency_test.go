@@ -0,0 +1,37 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestResidencyStrategy(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		jurisdiction string
+		residency    []string
+		shouldBid    bool
+	}{
+		{name: "no residency", jurisdiction: "us", shouldBid: true},
+		{name: "in residency", jurisdiction: "eu", residency: []string{"eu"}, shouldBid: true},
+		{name: "outside residency", jurisdiction: "us", residency: []string{"eu"}},
+		{name: "no jurisdiction", residency: []string{"eu"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := semantic.NewResidencyStrategy(semantic.ResidencyStrategyParams{Jurisdiction: test.jurisdiction})
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+				Job: model.Job{Spec: model.Spec{Residency: test.residency}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid, response.Reason)
+		})
+	}
+}
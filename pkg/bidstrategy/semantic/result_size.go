@@ -0,0 +1,40 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/c2h5oh/datasize"
+)
+
+type ResultSizeStrategyParams struct {
+	// MaxResultSize is the largest result in bytes the compute node is willing to publish. There is no limit if zero.
+	MaxResultSize uint64
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*ResultSizeStrategy)(nil)
+
+// ResultSizeStrategy rejects jobs that allow results larger than the compute node is willing to publish, as the node
+// would otherwise fail the job for results the user asked to be accepted.
+type ResultSizeStrategy struct {
+	maxResultSize uint64
+}
+
+func NewResultSizeStrategy(params ResultSizeStrategyParams) *ResultSizeStrategy {
+	return &ResultSizeStrategy{
+		maxResultSize: params.MaxResultSize,
+	}
+}
+
+func (s *ResultSizeStrategy) ShouldBid(_ context.Context, request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	limit := request.Job.Spec.ResultSizeLimit
+	if s.maxResultSize == 0 || limit == 0 || limit <= s.maxResultSize {
+		return bidstrategy.NewShouldBidResponse(), nil
+	}
+	return bidstrategy.BidStrategyResponse{
+		ShouldBid: false,
+		Reason: fmt.Sprintf("job result size limit %s exceeds maximum allowed %s",
+			datasize.ByteSize(limit).HR(), datasize.ByteSize(s.maxResultSize).HR()),
+	}, nil
+}
@@ -0,0 +1,51 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestResultSizeStrategy(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxResultSize uint64
+		limit         uint64
+		shouldBid     bool
+		reason        string
+	}{
+		{name: "no-node-limit", maxResultSize: 0, limit: 1 << 40, shouldBid: true},
+		{name: "no-job-limit", maxResultSize: 1 << 20, limit: 0, shouldBid: true},
+		{name: "within-node-limit", maxResultSize: 1 << 20, limit: 1 << 10, shouldBid: true},
+		{name: "equal-to-node-limit", maxResultSize: 1 << 20, limit: 1 << 20, shouldBid: true},
+		{
+			name:          "exceeds-node-limit",
+			maxResultSize: 5 << 20,
+			limit:         3 << 30,
+			shouldBid:     false,
+			reason:        "job result size limit 3.0 GB exceeds maximum allowed 5.0 MB",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			subject := semantic.NewResultSizeStrategy(semantic.ResultSizeStrategyParams{MaxResultSize: test.maxResultSize})
+
+			response, err := subject.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+				Job: model.Job{Spec: model.Spec{ResultSizeLimit: test.limit}},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.shouldBid, response.ShouldBid)
+			assert.Equal(t, test.reason, response.Reason)
+		})
+	}
+}
@@ -78,6 +78,15 @@ func (c *BasicCache[T]) Delete(key string) {
 	c.items.Delete(key)
 }
 
+// Purge removes all items from the cache, regardless of their expiry.
+func (c *BasicCache[T]) Purge() {
+	c.items.Iter(func(key string, item CacheItem[T]) bool {
+		c.items.Delete(key)
+		c.cost.Dec(item.cost)
+		return true
+	})
+}
+
 func (c *BasicCache[T]) Close() {
 	close(c.closer)
 }
@@ -104,3 +104,21 @@ func (s *BasicCacheSuite) TestExpiry() {
 	_, found := c.Get(k)
 	require.Equal(s.T(), false, found)
 }
+
+func (s *BasicCacheSuite) TestPurge() {
+	c, err := s.createTestCache("TestPurge", 2, oneHour, nil)
+	require.NoError(s.T(), err)
+	defer c.Close()
+
+	require.NoError(s.T(), c.Set("one", "value", 1, int64(oneHour.Seconds())))
+	require.NoError(s.T(), c.Set("two", "value", 1, int64(oneHour.Seconds())))
+
+	c.Purge()
+
+	_, found := c.Get("one")
+	require.Equal(s.T(), false, found)
+
+	// the cost of purged items is released
+	err = c.Set("three", "value", 2, int64(oneHour.Seconds()))
+	require.NoError(s.T(), err)
+}
@@ -47,6 +47,10 @@ func (m *FakeCache[T]) Delete(key string) {
 	delete(m.inner, key)
 }
 
+func (m *FakeCache[T]) Purge() {
+	m.inner = make(map[string]FakeCacheItem[T])
+}
+
 func (m *FakeCache[T]) Close() {}
 
 func (m *FakeCache[T]) ItemCount() int {
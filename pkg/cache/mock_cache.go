@@ -39,4 +39,10 @@ func (m MockCache[T]) Delete(key string) {
 	delete(m.inner, key)
 }
 
+func (m MockCache[T]) Purge() {
+	for key := range m.inner {
+		delete(m.inner, key)
+	}
+}
+
 func (m MockCache[T]) Close() {}
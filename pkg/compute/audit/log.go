@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// Outcome is how an execution ended.
+type Outcome string
+
+const (
+	OutcomeCompleted Outcome = "Completed"
+	OutcomeFailed    Outcome = "Failed"
+	OutcomeCancelled Outcome = "Cancelled"
+)
+
+// Volume identifies the data an execution read or wrote.
+type Volume struct {
+	Name          string `json:"Name,omitempty"`
+	StorageSource string `json:"StorageSource,omitempty"`
+	CID           string `json:"CID,omitempty"`
+	URL           string `json:"URL,omitempty"`
+	Path          string `json:"Path,omitempty"`
+}
+
+// NewVolume returns the identity of the data of a storage spec.
+func NewVolume(spec model.StorageSpec) Volume {
+	volume := Volume{
+		Name:          spec.Name,
+		StorageSource: spec.StorageSource.String(),
+		CID:           spec.CID,
+		URL:           spec.URL,
+		Path:          spec.Path,
+	}
+	if spec.CID != "" && spec.SubPath != "" {
+		if ipfsPath, err := spec.IPFSPath(); err == nil {
+			volume.CID = ipfsPath
+		}
+	}
+	return volume
+}
+
+// Entry is the record of an execution in the audit log. Each entry carries the hash of the entry before it, so that
+// changing, removing or reordering entries breaks the chain from that point on.
+type Entry struct {
+	// Sequence is the position of the entry in the log, starting at 1.
+	Sequence    uint64    `json:"Sequence"`
+	Time        time.Time `json:"Time"`
+	ExecutionID string    `json:"ExecutionID"`
+	JobID       string    `json:"JobID"`
+	// RequesterNodeID is the node the execution was run for.
+	RequesterNodeID string `json:"RequesterNodeID,omitempty"`
+	// SpecHash is the hex encoded SHA-256 of the JSON encoding of the spec of the job.
+	SpecHash string `json:"SpecHash"`
+	// ImageDigest is the digest of the image the execution ran, if it ran in a container.
+	ImageDigest string        `json:"ImageDigest,omitempty"`
+	Inputs      []Volume      `json:"Inputs,omitempty"`
+	Outputs     []Volume      `json:"Outputs,omitempty"`
+	WallTime    time.Duration `json:"WallTime"`
+	ExitCode    *int          `json:"ExitCode,omitempty"`
+	Outcome     Outcome       `json:"Outcome"`
+	Error       string        `json:"Error,omitempty"`
+
+	// PreviousHash is the hash of the previous entry, empty for the first entry.
+	PreviousHash string `json:"PreviousHash"`
+	// Hash is the hex encoded SHA-256 of the JSON encoding of the entry without its hash and signature.
+	Hash string `json:"Hash"`
+	// Signature is the signature of the hash by the key of the node, so that only the node can append to its log.
+	Signature []byte `json:"Signature,omitempty"`
+}
+
+// ComputeHash returns the hash of the entry, which covers every field but the hash and the signature.
+func (e Entry) ComputeHash() (string, error) {
+	e.Hash = ""
+	e.Signature = nil
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyChain checks that the entries are a complete chain, starting from the first entry of a log, in which no entry
+// was modified. It returns an error describing the first entry that breaks the chain.
+func VerifyChain(entries []Entry) error {
+	return VerifyChainFrom(Anchor{}, entries, nil)
+}
+
+// Anchor is an entry of a log that was verified before. A log must still go through it to be verified again, so that
+// entries verified once can't be rewritten or removed without it being detected.
+type Anchor struct {
+	Sequence uint64 `json:"Sequence"`
+	Hash     string `json:"Hash"`
+}
+
+// VerifyChainFrom checks that the entries are a complete chain starting from the anchor, or from the first entry of
+// the log if the anchor is empty, in which no entry was modified. If a public key is given, every entry must also be
+// signed by it. It returns an error describing the first entry that breaks the chain.
+func VerifyChainFrom(anchor Anchor, entries []Entry, publicKey crypto.PubKey) error {
+	first := uint64(1)
+	previousHash := ""
+	if anchor.Sequence > 0 {
+		if len(entries) == 0 || entries[0].Sequence != anchor.Sequence {
+			return fmt.Errorf("entry %d, verified before, is missing, the log was truncated or rewritten", anchor.Sequence)
+		}
+		if entries[0].Hash != anchor.Hash {
+			return fmt.Errorf("entry %d, verified before, was rewritten, its hash is %s instead of %s",
+				anchor.Sequence, entries[0].Hash, anchor.Hash)
+		}
+		first = anchor.Sequence
+		previousHash = entries[0].PreviousHash
+	}
+	for i, entry := range entries {
+		if entry.Sequence != first+uint64(i) {
+			return fmt.Errorf("entry %d has sequence %d, entries are missing or out of order", first+uint64(i), entry.Sequence)
+		}
+		if entry.PreviousHash != previousHash {
+			return fmt.Errorf("entry %d does not follow entry %d, its previous hash is %s instead of %s",
+				entry.Sequence, entry.Sequence-1, entry.PreviousHash, previousHash)
+		}
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+		if entry.Hash != hash {
+			return fmt.Errorf("entry %d was modified, its hash is %s instead of %s", entry.Sequence, entry.Hash, hash)
+		}
+		if publicKey != nil {
+			if valid, err := publicKey.Verify([]byte(entry.Hash), entry.Signature); err != nil || !valid {
+				return fmt.Errorf("entry %d is not signed by the node", entry.Sequence)
+			}
+		}
+		previousHash = entry.Hash
+	}
+	return nil
+}
+
+type LogParams struct {
+	// Path is the file the log is kept in. It is created if it does not exist.
+	Path string
+	// PrivateKey signs the entries appended to the log, which are not signed if nil.
+	PrivateKey crypto.PrivKey
+}
+
+// Log is an append-only log of the executions a compute node performed, kept in a file as one JSON entry per line.
+// Entries are chained by their hashes so that the log can be verified not to have been tampered with.
+type Log struct {
+	path       string
+	privateKey crypto.PrivKey
+
+	mu       sync.Mutex
+	lastSeq  uint64
+	lastHash string
+}
+
+// NewLog opens the log at the path, and continues its chain from its last entry.
+func NewLog(params LogParams) (*Log, error) {
+	l := &Log{path: params.Path, privateKey: params.PrivateKey}
+	entries, _, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		l.lastSeq = last.Sequence
+		l.lastHash = last.Hash
+	}
+	return l, nil
+}
+
+// Append chains the entry to the log and writes it to the file. The sequence, previous hash and hash of the entry are
+// set by the log, which returns the entry as it was written.
+func (l *Log) Append(entry Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = l.lastSeq + 1
+	entry.Time = entry.Time.UTC()
+	entry.PreviousHash = l.lastHash
+	hash, err := entry.ComputeHash()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Hash = hash
+	if l.privateKey != nil {
+		if entry.Signature, err = l.privateKey.Sign([]byte(hash)); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer file.Close() //nolint:errcheck // closing again after a successful sync
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		return Entry{}, err
+	}
+	if err = file.Sync(); err != nil {
+		return Entry{}, err
+	}
+	if err = file.Close(); err != nil {
+		return Entry{}, err
+	}
+
+	l.lastSeq = entry.Sequence
+	l.lastHash = entry.Hash
+	return entry, nil
+}
+
+// PublicKey returns the key that verifies the signatures of the entries, or nil if they are not signed.
+func (l *Log) PublicKey() crypto.PubKey {
+	if l.privateKey == nil {
+		return nil
+	}
+	return l.privateKey.GetPublic()
+}
+
+// Entries returns all the entries of the log, in the order they were appended, and the numbers of the lines of the file
+// that are not entries, which are skipped so that the rest of the log can still be read. The chain breaks where
+// lines are skipped.
+func (l *Log) Entries() ([]Entry, []int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close() //nolint:errcheck // read only
+
+	var entries []Entry
+	var invalidLines []int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			invalidLines = append(invalidLines, line)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, invalidLines, scanner.Err()
+}
+
+// Verify checks the chain of the entries of the log, and the signatures of the entries if the log signs them. It
+// returns the entries and the lines that are not entries even if the chain is broken.
+func (l *Log) Verify() ([]Entry, []int, error) {
+	entries, invalidLines, err := l.Entries()
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, invalidLines, VerifyChainFrom(Anchor{}, entries, l.PublicKey())
+}
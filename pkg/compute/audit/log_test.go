@@ -0,0 +1,127 @@
+//go:build unit || !integration
+
+package audit
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLog(LogParams{Path: path})
+	require.NoError(t, err)
+
+	first, err := l.Append(Entry{ExecutionID: "e-1", JobID: "job-1", Time: time.Now(), Outcome: OutcomeCompleted})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), first.Sequence)
+	require.Empty(t, first.PreviousHash)
+	require.NotEmpty(t, first.Hash)
+
+	second, err := l.Append(Entry{ExecutionID: "e-2", JobID: "job-2", Time: time.Now(), Outcome: OutcomeFailed})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), second.Sequence)
+	require.Equal(t, first.Hash, second.PreviousHash)
+
+	// the chain continues from the last entry when the log is reopened
+	l, err = NewLog(LogParams{Path: path})
+	require.NoError(t, err)
+	third, err := l.Append(Entry{ExecutionID: "e-3", JobID: "job-3", Time: time.Now(), Outcome: OutcomeCancelled})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), third.Sequence)
+	require.Equal(t, second.Hash, third.PreviousHash)
+
+	entries, invalidLines, err := l.Verify()
+	require.NoError(t, err)
+	require.Empty(t, invalidLines)
+	require.Equal(t, []Entry{first, second, third}, entries)
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLog(LogParams{Path: path})
+	require.NoError(t, err)
+	for _, executionID := range []string{"e-1", "e-2", "e-3"} {
+		_, err = l.Append(Entry{ExecutionID: executionID, WallTime: time.Minute, Outcome: OutcomeCompleted})
+		require.NoError(t, err)
+	}
+	entries, _, err := l.Entries()
+	require.NoError(t, err)
+	require.NoError(t, VerifyChain(entries))
+
+	modified := append([]Entry{}, entries...)
+	modified[1].WallTime = time.Second
+	require.ErrorContains(t, VerifyChain(modified), "entry 2 was modified")
+
+	removed := []Entry{entries[0], entries[2]}
+	require.ErrorContains(t, VerifyChain(removed), "missing or out of order")
+
+	// rewriting an entry with a valid hash still breaks the link to the next entry
+	rehashed := append([]Entry{}, entries...)
+	rehashed[1].WallTime = time.Second
+	rehashed[1].Hash, err = rehashed[1].ComputeHash()
+	require.NoError(t, err)
+	require.ErrorContains(t, VerifyChain(rehashed), "entry 3 does not follow entry 2")
+
+	// edits to the file are detected too
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), `"e-2"`, `"e-4"`, 1)), 0600))
+	_, _, err = l.Verify()
+	require.ErrorContains(t, err, "entry 2 was modified")
+}
+
+func TestLogSkipsInvalidLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLog(LogParams{Path: path})
+	require.NoError(t, err)
+	for _, executionID := range []string{"e-1", "e-2", "e-3"} {
+		_, err = l.Append(Entry{ExecutionID: executionID, Outcome: OutcomeCompleted})
+		require.NoError(t, err)
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	lines[1] = "{corrupt"
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+
+	entries, invalidLines, err := l.Verify()
+	require.ErrorContains(t, err, "missing or out of order")
+	require.Equal(t, []int{2}, invalidLines)
+	require.Len(t, entries, 2)
+}
+
+func TestVerifyChainFrom(t *testing.T) {
+	privateKey, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLog(LogParams{Path: path, PrivateKey: privateKey})
+	require.NoError(t, err)
+	for _, executionID := range []string{"e-1", "e-2", "e-3"} {
+		_, err = l.Append(Entry{ExecutionID: executionID, Outcome: OutcomeCompleted})
+		require.NoError(t, err)
+	}
+	entries, _, err := l.Verify()
+	require.NoError(t, err)
+	require.NoError(t, VerifyChainFrom(Anchor{}, entries, publicKey))
+
+	// entries rehashed by someone without the key of the node are not signed by it
+	_, otherKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	require.ErrorContains(t, VerifyChainFrom(Anchor{}, entries, otherKey), "entry 1 is not signed by the node")
+
+	anchor := Anchor{Sequence: 2, Hash: entries[1].Hash}
+	require.NoError(t, VerifyChainFrom(anchor, entries[1:], publicKey))
+	require.ErrorContains(t, VerifyChainFrom(anchor, entries[2:], publicKey), "entry 2, verified before, is missing")
+	require.ErrorContains(t, VerifyChainFrom(Anchor{Sequence: 3, Hash: entries[2].Hash}, nil, publicKey),
+		"the log was truncated")
+	require.ErrorContains(t, VerifyChainFrom(Anchor{Sequence: 2, Hash: "other"}, entries[1:], publicKey),
+		"entry 2, verified before, was rewritten")
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+type RecorderParams struct {
+	Log   *Log
+	Store store.ExecutionStore
+}
+
+// Recorder is a Callback that appends an entry to the audit log for every execution that completes, fails or is
+// cancelled on the node once it started running. Bids that fail or are cancelled before running are not recorded.
+// It is meant to be chained with the callback that notifies the requester.
+type Recorder struct {
+	log   *Log
+	store store.ExecutionStore
+
+	mu   sync.Mutex
+	runs map[string]runSummary
+}
+
+// runSummary is what is known of an execution that ran, until its results are published.
+type runSummary struct {
+	imageDigest string
+	exitCode    int
+	wallTime    time.Duration
+}
+
+func NewRecorder(params RecorderParams) *Recorder {
+	return &Recorder{
+		log:   params.Log,
+		store: params.Store,
+		runs:  make(map[string]runSummary),
+	}
+}
+
+func (r *Recorder) OnBidComplete(ctx context.Context, result compute.BidResult) {}
+
+func (r *Recorder) OnRunComplete(ctx context.Context, result compute.RunResult) {
+	wallTime, _ := r.wallTime(ctx, result.ExecutionID, time.Now())
+	summary := runSummary{wallTime: wallTime}
+	if result.RunCommandResult != nil {
+		summary.exitCode = result.RunCommandResult.ExitCode
+		if result.RunCommandResult.Environment != nil {
+			summary.imageDigest = result.RunCommandResult.Environment.ImageDigest
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[result.ExecutionID] = summary
+}
+
+func (r *Recorder) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
+	outputs := []Volume{NewVolume(result.PublishResult)}
+	names := make([]string, 0, len(result.PublishedVolumes))
+	for name := range result.PublishedVolumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		volume := NewVolume(result.PublishedVolumes[name])
+		volume.Name = name
+		outputs = append(outputs, volume)
+	}
+	r.record(ctx, result.ExecutionMetadata, OutcomeCompleted, outputs, "")
+}
+
+func (r *Recorder) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
+	r.record(ctx, result.ExecutionMetadata, OutcomeCancelled, nil, "")
+}
+
+func (r *Recorder) OnComputeFailure(ctx context.Context, err compute.ComputeError) {
+	r.record(ctx, err.ExecutionMetadata, OutcomeFailed, nil, err.Err)
+}
+
+func (r *Recorder) record(
+	ctx context.Context, metadata compute.ExecutionMetadata, outcome Outcome, outputs []Volume, errMessage string) {
+	now := time.Now()
+	r.mu.Lock()
+	summary, ran := r.runs[metadata.ExecutionID]
+	delete(r.runs, metadata.ExecutionID)
+	r.mu.Unlock()
+
+	entry := Entry{
+		Time:        now,
+		ExecutionID: metadata.ExecutionID,
+		JobID:       metadata.JobID,
+		Outputs:     outputs,
+		Outcome:     outcome,
+		Error:       errMessage,
+	}
+	if ran {
+		entry.ImageDigest = summary.imageDigest
+		entry.WallTime = summary.wallTime
+		exitCode := summary.exitCode
+		entry.ExitCode = &exitCode
+	} else {
+		var started bool
+		entry.WallTime, started = r.wallTime(ctx, metadata.ExecutionID, now)
+		if !started {
+			return
+		}
+	}
+
+	execution, err := r.store.GetExecution(ctx, metadata.ExecutionID)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("auditing execution %s without its job", metadata.ExecutionID)
+	} else {
+		entry.JobID = execution.Job.ID()
+		entry.RequesterNodeID = execution.RequesterNodeID
+		entry.SpecHash, err = SpecHash(execution.Job.Spec)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("could not hash the spec of job %s", execution.Job.ID())
+		}
+		for _, input := range execution.Job.Spec.Inputs {
+			entry.Inputs = append(entry.Inputs, NewVolume(input))
+		}
+	}
+
+	if _, err = r.log.Append(entry); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to append execution %s to the audit log", metadata.ExecutionID)
+	}
+}
+
+// wallTime is how long the execution has been running at the given time, and whether it started to run at all.
+func (r *Recorder) wallTime(ctx context.Context, executionID string, at time.Time) (time.Duration, bool) {
+	history, err := r.store.GetExecutionHistory(ctx, executionID)
+	if err != nil {
+		return 0, false
+	}
+	for _, change := range history {
+		if change.NewState == store.ExecutionStateRunning {
+			return at.Sub(change.Time), true
+		}
+	}
+	return 0, false
+}
+
+// SpecHash returns the hex encoded SHA-256 of the JSON encoding of a job spec.
+func SpecHash(spec model.Spec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// compile-time interface check
+var _ compute.Callback = (*Recorder)(nil)
@@ -0,0 +1,83 @@
+//go:build unit || !integration
+
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLog(LogParams{Path: filepath.Join(t.TempDir(), "audit.jsonl")})
+	require.NoError(t, err)
+	executionStore := inmemory.NewStore()
+	recorder := NewRecorder(RecorderParams{Log: l, Store: executionStore})
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "audited-job"},
+		Spec: model.Spec{
+			Engine: model.EngineDocker,
+			Inputs: []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, CID: "QmInput", Path: "/inputs"}},
+		},
+	}
+	createExecution := func(id string, run bool) compute.ExecutionMetadata {
+		execution := store.NewExecution(id, job, "QmRequester", model.ResourceUsageData{})
+		require.NoError(t, executionStore.CreateExecution(ctx, *execution))
+		if run {
+			require.NoError(t, executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+				ExecutionID: id,
+				NewState:    store.ExecutionStateRunning,
+			}))
+		}
+		return compute.ExecutionMetadata{ExecutionID: id, JobID: job.ID()}
+	}
+
+	completed := createExecution("e-completed", true)
+	recorder.OnRunComplete(ctx, compute.RunResult{
+		ExecutionMetadata: completed,
+		RunCommandResult: &model.RunCommandResult{
+			ExitCode:    0,
+			Environment: &model.ExecutionEnvironment{ImageDigest: "sha256:abc"},
+		},
+	})
+	recorder.OnPublishComplete(ctx, compute.PublishResult{
+		ExecutionMetadata: completed,
+		PublishResult:     model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmOutput"},
+	})
+
+	failed := createExecution("e-failed", true)
+	recorder.OnComputeFailure(ctx, compute.ComputeError{ExecutionMetadata: failed, Err: "boom"})
+
+	// bids that never ran are not audited
+	rejected := createExecution("e-rejected", false)
+	recorder.OnComputeFailure(ctx, compute.ComputeError{ExecutionMetadata: rejected, Err: "no capacity"})
+
+	entries, _, err := l.Verify()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	specHash, err := SpecHash(job.Spec)
+	require.NoError(t, err)
+	require.Equal(t, "e-completed", entries[0].ExecutionID)
+	require.Equal(t, job.ID(), entries[0].JobID)
+	require.Equal(t, "QmRequester", entries[0].RequesterNodeID)
+	require.Equal(t, specHash, entries[0].SpecHash)
+	require.Equal(t, "sha256:abc", entries[0].ImageDigest)
+	require.Equal(t, []Volume{{StorageSource: "IPFS", CID: "QmInput", Path: "/inputs"}}, entries[0].Inputs)
+	require.Equal(t, []Volume{{StorageSource: "IPFS", CID: "QmOutput"}}, entries[0].Outputs)
+	require.NotNil(t, entries[0].ExitCode)
+	require.Equal(t, OutcomeCompleted, entries[0].Outcome)
+
+	require.Equal(t, "e-failed", entries[1].ExecutionID)
+	require.Equal(t, OutcomeFailed, entries[1].Outcome)
+	require.Equal(t, "boom", entries[1].Error)
+	require.Nil(t, entries[1].ExitCode)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sync/atomic"
 
 	"github.com/rs/zerolog/log"
 
@@ -20,6 +21,12 @@ type BidderParams struct {
 	Store            store.ExecutionStore
 	Callback         Callback
 	GetApproveURL    func() *url.URL
+	// Prefetcher optionally starts fetching the inputs of executions as soon as they are bid on.
+	Prefetcher InputPrefetcher
+	// InputEstimator optionally checks the inputs of jobs can be fetched before bidding, and estimates fetching them.
+	InputEstimator InputEstimator
+	// MaxResultSize is the largest result in bytes the node publishes, which is offered in bids. There is no limit if zero.
+	MaxResultSize uint64
 }
 
 type Bidder struct {
@@ -27,16 +34,27 @@ type Bidder struct {
 	store         store.ExecutionStore
 	callback      Callback
 	getApproveURL func() *url.URL
+	prefetcher    InputPrefetcher
+	estimator     InputEstimator
+	maxResultSize uint64
+	// enabled is shared by copies of the bidder so that toggling bidding through the admin API is seen everywhere
+	enabled *atomic.Bool
 
 	semanticStrategy bidstrategy.SemanticBidStrategy
 	resourceStrategy bidstrategy.ResourceBidStrategy
 }
 
 func NewBidder(params BidderParams) Bidder {
+	enabled := new(atomic.Bool)
+	enabled.Store(true)
 	return Bidder{
 		nodeID:           params.NodeID,
+		enabled:          enabled,
 		store:            params.Store,
 		getApproveURL:    params.GetApproveURL,
+		prefetcher:       params.Prefetcher,
+		estimator:        params.InputEstimator,
+		maxResultSize:    params.MaxResultSize,
 		callback:         params.Callback,
 		semanticStrategy: params.SemanticStrategy,
 		resourceStrategy: params.ResourceStrategy,
@@ -76,12 +94,26 @@ func (b Bidder) RunBidding(ctx context.Context, request AskForBidRequest, usageC
 		return
 	}
 
+	var estimate InputEstimate
+	if response.ShouldBid || response.ShouldWait {
+		estimate, err = b.estimateInputs(ctx, request.Job)
+		if err != nil {
+			response = &bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: err.Error()}
+		}
+	}
+
 	result := BidResult{
 		RoutingMetadata:   routingMetadata,
 		ExecutionMetadata: executionMetadata,
 		Accepted:          response.ShouldBid,
 		Reason:            response.Reason,
 	}
+	if response.ShouldBid {
+		result.ResultSizeLimit = request.Job.Spec.GetResultSizeLimit(b.maxResultSize)
+		result.InputsSize = estimate.Size
+		result.InputsFetchEstimate = estimate.Duration
+		result.InputsEstimated = estimate.Known
+	}
 
 	// if we are not bidding and not wait return a response, we can't do this job. mark as complete then bail
 	if !response.ShouldBid && !response.ShouldWait {
@@ -102,6 +134,19 @@ func (b Bidder) RunBidding(ctx context.Context, request AskForBidRequest, usageC
 	if !response.ShouldWait {
 		b.callback.OnBidComplete(ctx, result)
 	}
+	if response.ShouldBid {
+		b.prefetchInputs(ctx, request.ExecutionID, request.Job)
+	}
+}
+
+// SetBiddingEnabled enables or disables bidding on new jobs. Executions that have already been bid on are not affected.
+func (b Bidder) SetBiddingEnabled(enabled bool) {
+	b.enabled.Store(enabled)
+}
+
+// IsBiddingEnabled returns true if the bidder is currently bidding on new jobs.
+func (b Bidder) IsBiddingEnabled() bool {
+	return b.enabled.Load()
 }
 
 func (b Bidder) ReturnBidResult(ctx context.Context, execution store.Execution, response *bidstrategy.BidStrategyResponse) {
@@ -118,7 +163,41 @@ func (b Bidder) ReturnBidResult(ctx context.Context, execution store.Execution,
 		Accepted:          response.ShouldBid,
 		Reason:            response.Reason,
 	}
+	if response.ShouldBid {
+		result.ResultSizeLimit = execution.Job.Spec.GetResultSizeLimit(b.maxResultSize)
+		// the inputs were found available when the node decided to wait, so failing to estimate them again is
+		// left for the execution to report
+		estimate, err := b.estimateInputs(ctx, execution.Job)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to estimate inputs of execution %s", execution.ID)
+		}
+		result.InputsSize = estimate.Size
+		result.InputsFetchEstimate = estimate.Duration
+		result.InputsEstimated = estimate.Known
+	}
 	b.callback.OnBidComplete(ctx, result)
+	if response.ShouldBid {
+		b.prefetchInputs(ctx, execution.ID, execution.Job)
+	}
+}
+
+// estimateInputs checks the inputs of the job can be fetched, and estimates fetching them. Nothing is checked if the
+// bidder has no estimator, and the estimate is then unknown.
+func (b Bidder) estimateInputs(ctx context.Context, job model.Job) (InputEstimate, error) {
+	if b.estimator == nil {
+		return InputEstimate{}, nil
+	}
+	if len(job.Spec.Inputs) == 0 {
+		return InputEstimate{Known: true}, nil
+	}
+	return b.estimator.EstimateInputs(ctx, job.Spec.Inputs)
+}
+
+func (b Bidder) prefetchInputs(ctx context.Context, executionID string, job model.Job) {
+	if b.prefetcher != nil && len(job.Spec.Inputs) > 0 {
+		// an execution that hasn't started by the time it would have timed out is not going to use its inputs
+		b.prefetcher.Prefetch(ctx, executionID, job.ID(), job.Spec.Inputs, job.Spec.GetTimeout())
+	}
 }
 
 // doBidding returns a response based on the below semantics. It should never be the case that semantic or resource
@@ -138,6 +217,13 @@ func (b Bidder) doBidding(
 	request bidstrategy.BidStrategyRequest,
 	calculator capacity.UsageCalculator,
 ) (*bidstrategy.BidStrategyResponse, *model.ResourceUsageData, error) {
+	if !b.IsBiddingEnabled() {
+		return &bidstrategy.BidStrategyResponse{
+			ShouldBid: false,
+			Reason:    "bidding has been disabled by the node administrator",
+		}, nil, nil
+	}
+
 	// Check semantic bidding strategies before calculating resource usage.
 	semanticResponse, err := b.semanticStrategy.ShouldBid(ctx, request)
 	if err != nil {
@@ -159,9 +245,14 @@ func (b Bidder) doBidding(
 		return nil, nil, fmt.Errorf("error asking bidding strategy if we should bid: %w", err)
 	}
 
+	// keep any warnings from the semantic strategies if the resource strategies agree to bid
+	reason := resourceResponse.Reason
+	if resourceResponse.ShouldBid {
+		reason = semanticResponse.Reason
+	}
 	return &bidstrategy.BidStrategyResponse{
 		ShouldBid:  resourceResponse.ShouldBid,
 		ShouldWait: semanticResponse.ShouldWait || resourceResponse.ShouldWait,
-		Reason:     "",
+		Reason:     reason,
 	}, &resourceUsage, nil
 }
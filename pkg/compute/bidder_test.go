@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -162,3 +163,133 @@ func TestRunBidding(t *testing.T) {
 		})
 	}
 }
+
+func TestRunBiddingWhenDisabled(t *testing.T) {
+	ctx := context.Background()
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	semanticStrategy := new(semantic.MockSemanticBidStrategy)
+	callback := new(compute.MockCallback)
+	disabledBidder := compute.NewBidder(compute.BidderParams{
+		NodeID:           "testNodeID",
+		SemanticStrategy: semanticStrategy,
+		ResourceStrategy: new(resource.MockResourceBidStrategy),
+		Store:            new(mockstore.MockExecutionStore),
+		Callback:         callback,
+		GetApproveURL: func() *url.URL {
+			return &url.URL{}
+		},
+	})
+	require.True(t, disabledBidder.IsBiddingEnabled())
+	disabledBidder.SetBiddingEnabled(false)
+	require.False(t, disabledBidder.IsBiddingEnabled())
+
+	callback.On("OnBidComplete", ctx, mock.MatchedBy(func(result compute.BidResult) bool {
+		return !result.Accepted && result.Reason != ""
+	})).Return()
+
+	disabledBidder.RunBidding(ctx, compute.AskForBidRequest{Job: *job}, capacity.NewDefaultsUsageCalculator(
+		capacity.DefaultsUsageCalculatorParams{Defaults: model.ResourceUsageData{}}))
+
+	semanticStrategy.AssertNotCalled(t, "ShouldBid", mock.Anything, mock.Anything)
+	callback.AssertExpectations(t)
+}
+
+func TestRunBiddingOffersResultSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+	job.Spec.ResultSizeLimit = 1 << 30
+
+	semanticStrategy := new(semantic.MockSemanticBidStrategy)
+	resourceStrategy := new(resource.MockResourceBidStrategy)
+	executionStore := new(mockstore.MockExecutionStore)
+	callback := new(compute.MockCallback)
+	limitedBidder := compute.NewBidder(compute.BidderParams{
+		NodeID:           "testNodeID",
+		SemanticStrategy: semanticStrategy,
+		ResourceStrategy: resourceStrategy,
+		Store:            executionStore,
+		Callback:         callback,
+		MaxResultSize:    1 << 20,
+		GetApproveURL: func() *url.URL {
+			return &url.URL{}
+		},
+	})
+
+	semanticStrategy.On("ShouldBid", ctx, mock.Anything).Return(bidstrategy.NewShouldBidResponse(), nil)
+	resourceStrategy.On("ShouldBidBasedOnUsage", ctx, mock.Anything, mock.Anything).Return(bidstrategy.NewShouldBidResponse(), nil)
+	executionStore.On("CreateExecution", ctx, mock.Anything).Return(nil)
+	callback.On("OnBidComplete", ctx, mock.MatchedBy(func(result compute.BidResult) bool {
+		return result.Accepted && result.ResultSizeLimit == 1<<20
+	})).Return()
+
+	limitedBidder.RunBidding(ctx, compute.AskForBidRequest{Job: *job}, capacity.NewDefaultsUsageCalculator(
+		capacity.DefaultsUsageCalculatorParams{Defaults: model.ResourceUsageData{}}))
+
+	callback.AssertExpectations(t)
+}
+
+type inputEstimatorFunc func(ctx context.Context, inputs []model.StorageSpec) (compute.InputEstimate, error)
+
+func (f inputEstimatorFunc) EstimateInputs(ctx context.Context, inputs []model.StorageSpec) (compute.InputEstimate, error) {
+	return f(ctx, inputs)
+}
+
+func TestRunBiddingEstimatesInputs(t *testing.T) {
+	ctx := context.Background()
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+	job.Spec.Inputs = []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, CID: "QmData"}}
+
+	for _, tc := range []struct {
+		name     string
+		estimate compute.InputEstimate
+		err      error
+		accepted bool
+	}{
+		{name: "available", estimate: compute.InputEstimate{Size: 1 << 20, Duration: time.Second, Known: true}, accepted: true},
+		{name: "unknown size", estimate: compute.InputEstimate{Known: false}, accepted: true},
+		{name: "not found", err: errors.New("input QmData is not available")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			semanticStrategy := new(semantic.MockSemanticBidStrategy)
+			resourceStrategy := new(resource.MockResourceBidStrategy)
+			executionStore := new(mockstore.MockExecutionStore)
+			callback := new(compute.MockCallback)
+			estimatingBidder := compute.NewBidder(compute.BidderParams{
+				NodeID:           "testNodeID",
+				SemanticStrategy: semanticStrategy,
+				ResourceStrategy: resourceStrategy,
+				Store:            executionStore,
+				Callback:         callback,
+				InputEstimator: inputEstimatorFunc(func(context.Context, []model.StorageSpec) (compute.InputEstimate, error) {
+					return tc.estimate, tc.err
+				}),
+				GetApproveURL: func() *url.URL {
+					return &url.URL{}
+				},
+			})
+
+			semanticStrategy.On("ShouldBid", ctx, mock.Anything).Return(bidstrategy.NewShouldBidResponse(), nil)
+			resourceStrategy.On("ShouldBidBasedOnUsage", ctx, mock.Anything, mock.Anything).Return(bidstrategy.NewShouldBidResponse(), nil)
+			if tc.accepted {
+				executionStore.On("CreateExecution", ctx, mock.Anything).Return(nil)
+			}
+			callback.On("OnBidComplete", ctx, mock.MatchedBy(func(result compute.BidResult) bool {
+				if !tc.accepted {
+					return !result.Accepted && result.Reason == tc.err.Error()
+				}
+				return result.Accepted && result.InputsSize == tc.estimate.Size &&
+					result.InputsFetchEstimate == tc.estimate.Duration && result.InputsEstimated == tc.estimate.Known
+			})).Return()
+
+			estimatingBidder.RunBidding(ctx, compute.AskForBidRequest{Job: *job}, capacity.NewDefaultsUsageCalculator(
+				capacity.DefaultsUsageCalculatorParams{Defaults: model.ResourceUsageData{}}))
+
+			executionStore.AssertExpectations(t)
+			callback.AssertExpectations(t)
+		})
+	}
+}
@@ -15,10 +15,11 @@ func ParseResourceUsageConfig(usage model.ResourceUsageConfig) model.ResourceUsa
 		Memory: ConvertBytesString(usage.Memory),
 		Disk:   ConvertBytesString(usage.Disk),
 		GPU:    ConvertGPUString(usage.GPU),
+		Fuel:   ConvertFuelString(usage.Fuel),
 	}
 }
 func ConvertCPUString(val string) float64 {
-	ret, err := convertCPUStringWithError(val)
+	ret, err := ParseCPUString(val)
 	if err != nil {
 		return 0
 	}
@@ -26,7 +27,15 @@ func ConvertCPUString(val string) float64 {
 }
 
 func ConvertBytesString(val string) uint64 {
-	ret, err := convertBytesStringWithError(val)
+	ret, err := ParseBytesString(val)
+	if err != nil {
+		return 0
+	}
+	return ret
+}
+
+func ConvertFuelString(val string) uint64 {
+	ret, err := strconv.ParseUint(val, 10, 64) //nolint:gomnd
 	if err != nil {
 		return 0
 	}
@@ -41,7 +50,8 @@ func ConvertGPUString(val string) uint64 {
 	return ret
 }
 
-func convertCPUStringWithError(val string) (float64, error) {
+// ParseCPUString parses a number of CPUs such as 0.5 or 500m. An empty string is zero CPUs.
+func ParseCPUString(val string) (float64, error) {
 	if val == "" {
 		return 0, nil
 	}
@@ -52,7 +62,8 @@ func convertCPUStringWithError(val string) (float64, error) {
 	return cpu.ToFloat64(), nil
 }
 
-func convertBytesStringWithError(val string) (uint64, error) {
+// ParseBytesString parses a size such as 500Mb or 2Gi into bytes. An empty string is zero bytes.
+func ParseBytesString(val string) (uint64, error) {
 	if val == "" {
 		return 0, nil
 	}
@@ -28,6 +28,16 @@ func TestResourceUsageConfigParser(t *testing.T) {
 				GPU:    0,
 			},
 		},
+		{
+			model.ResourceUsageConfig{
+				CPU:  "1",
+				Fuel: "1000000",
+			},
+			model.ResourceUsageData{
+				CPU:  1,
+				Fuel: 1000000,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -35,3 +45,16 @@ func TestResourceUsageConfigParser(t *testing.T) {
 		require.Equal(t, tc.expectedData, data)
 	}
 }
+
+func TestParseBytesString(t *testing.T) {
+	size, err := ParseBytesString("10Mb")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10*1024*1024), size)
+
+	size, err = ParseBytesString("")
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	_, err = ParseBytesString("lots")
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+)
+
+// Reservations keeps track of the capacity the compute node holds for capacity reservations. The capacity of an active
+// reservation that its jobs are not using is not available to other jobs, while the jobs of the reservation can use
+// it on top of the capacity that is not reserved.
+type Reservations struct {
+	reservations map[string]*heldReservation
+	mu           sync.Mutex
+}
+
+type heldReservation struct {
+	// reservation holds the capacity reserved on this node in its Resources
+	reservation model.CapacityReservation
+	// requesterID is the requester that made the reservation, which is the only one that can change or release it
+	requesterID string
+	used        model.ResourceUsageData
+}
+
+func NewReservations() *Reservations {
+	return &Reservations{
+		reservations: make(map[string]*heldReservation),
+	}
+}
+
+// Reserve holds the capacity of the reservation on the node for the requester making it, if the node has enough
+// capacity left during the time window of the reservation. The Resources of the reservation are what is held on this
+// node. Reserving a reservation again replaces it, which only the requester that made it can do.
+func (r *Reservations) Reserve(
+	reservation model.CapacityReservation, requesterID string, maxCapacity model.ResourceUsageData, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+
+	if reservation.IsExpired(now) {
+		return fmt.Errorf("reservation %s ended at %s", reservation.ID, reservation.End)
+	}
+	if !reservation.Start.Before(reservation.End) {
+		return fmt.Errorf("reservation %s must start before it ends", reservation.ID)
+	}
+	if err := reservation.CheckDuration(now); err != nil {
+		return err
+	}
+	previous, ok := r.reservations[reservation.ID]
+	if ok && previous.requesterID != requesterID {
+		return fmt.Errorf("reservation %s is held for another requester", reservation.ID)
+	}
+
+	// the reservations that overlap are added up as if they were all active at once, which is pessimistic but keeps
+	// every instant of the time window within the capacity of the node
+	reserved := reservation.Resources
+	for id, held := range r.reservations {
+		if id != reservation.ID && held.reservation.Overlaps(reservation) {
+			reserved = reserved.Add(held.reservation.Resources)
+		}
+	}
+	if !reserved.LessThanEq(maxCapacity) {
+		return fmt.Errorf("not enough capacity to reserve %s, %s is reserved during the time window out of %s",
+			reservation.Resources, reserved.Sub(reservation.Resources), maxCapacity)
+	}
+
+	held := &heldReservation{reservation: reservation, requesterID: requesterID}
+	if ok {
+		held.used = previous.used
+	}
+	r.reservations[reservation.ID] = held
+	return nil
+}
+
+// Release stops holding the capacity of the reservation, and returns false if the node does not hold it for the
+// requester.
+func (r *Reservations) Release(reservationID string, requesterID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	held, ok := r.reservations[reservationID]
+	if !ok || held.requesterID != requesterID {
+		return false
+	}
+	delete(r.reservations, reservationID)
+	return true
+}
+
+// ReservationOf returns the ID of the reservation held on the node that the job runs on, which is empty if the job
+// is not submitted with a reservation held on the node or if the reservation belongs to another client.
+func (r *Reservations) ReservationOf(job model.Job) string {
+	if job.Spec.Reservation == "" {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	held, ok := r.reservations[job.Spec.Reservation]
+	if !ok || held.reservation.ClientID != job.Metadata.ClientID {
+		return ""
+	}
+	return job.Spec.Reservation
+}
+
+// Get returns the reservation held on the node, and false if the node does not hold it.
+func (r *Reservations) Get(reservationID string) (model.CapacityReservation, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	held, ok := r.reservations[reservationID]
+	if !ok {
+		return model.CapacityReservation{}, false
+	}
+	return held.reservation, true
+}
+
+// List returns the reservations held on the node, by start time.
+func (r *Reservations) List(now time.Time) []model.CapacityReservation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+	reservations := make([]model.CapacityReservation, 0, len(r.reservations))
+	for _, held := range r.reservations {
+		reservations = append(reservations, held.reservation)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if !reservations[i].Start.Equal(reservations[j].Start) {
+			return reservations[i].Start.Before(reservations[j].Start)
+		}
+		return reservations[i].ID < reservations[j].ID
+	})
+	return reservations
+}
+
+// Unreserved returns what is left of the available capacity once the unused capacity of the reservations active at
+// the given time is taken out, except for the reservation with the given ID whose jobs can use its own capacity. The
+// ID is expected to come from ReservationOf.
+func (r *Reservations) Unreserved(available model.ResourceUsageData, reservationID string, now time.Time) model.ResourceUsageData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+	for id, held := range r.reservations {
+		if id == reservationID || !held.reservation.IsActive(now) {
+			continue
+		}
+		available = subtractFloor(available, subtractFloor(held.reservation.Resources, held.used))
+	}
+	return available
+}
+
+// Reserved returns the capacity held for the reservations that have not ended yet, whether they started or not.
+func (r *Reservations) Reserved(now time.Time) model.ResourceUsageData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+	var reserved model.ResourceUsageData
+	for _, held := range r.reservations {
+		reserved = reserved.Add(held.reservation.Resources)
+	}
+	return reserved
+}
+
+// Use records that an execution of a job of the reservation started using the given capacity. It is a no-op if the
+// node does not hold the reservation.
+func (r *Reservations) Use(reservationID string, usage model.ResourceUsageData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if held, ok := r.reservations[reservationID]; ok {
+		held.used = held.used.Add(usage)
+	}
+}
+
+// Unuse records that an execution of a job of the reservation stopped using the given capacity.
+func (r *Reservations) Unuse(reservationID string, usage model.ResourceUsageData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if held, ok := r.reservations[reservationID]; ok {
+		held.used = subtractFloor(held.used, usage)
+	}
+}
+
+// prune forgets the reservations that ended. It is called with the lock held.
+func (r *Reservations) prune(now time.Time) {
+	for id, held := range r.reservations {
+		if held.reservation.IsExpired(now) {
+			delete(r.reservations, id)
+		}
+	}
+}
+
+// subtractFloor subtracts b from a, flooring every resource at zero.
+func subtractFloor(a, b model.ResourceUsageData) model.ResourceUsageData {
+	floor := func(x, y uint64) uint64 {
+		if y > x {
+			return 0
+		}
+		return x - y
+	}
+	cpu := a.CPU - b.CPU
+	if cpu < 0 {
+		cpu = 0
+	}
+	return model.ResourceUsageData{
+		CPU:    cpu,
+		Memory: floor(a.Memory, b.Memory),
+		Disk:   floor(a.Disk, b.Disk),
+		GPU:    floor(a.GPU, b.GPU),
+		Fuel:   floor(a.Fuel, b.Fuel),
+	}
+}
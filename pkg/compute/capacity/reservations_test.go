@@ -0,0 +1,78 @@
+//go:build unit || !integration
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservations(t *testing.T) {
+	now := time.Now()
+	maxCapacity := model.ResourceUsageData{CPU: 8, GPU: 2}
+	reservations := NewReservations()
+
+	first := model.CapacityReservation{
+		ID:        "first",
+		Resources: model.ResourceUsageData{CPU: 4, GPU: 1},
+		Start:     now.Add(-time.Minute),
+		End:       now.Add(time.Hour),
+	}
+	require.NoError(t, reservations.Reserve(first, "requester", maxCapacity, now))
+
+	// overlapping reservations can't hold more than the node has
+	tooLarge := model.CapacityReservation{
+		ID:        "too-large",
+		Resources: model.ResourceUsageData{CPU: 6},
+		Start:     now.Add(30 * time.Minute),
+		End:       now.Add(2 * time.Hour),
+	}
+	require.Error(t, reservations.Reserve(tooLarge, "requester", maxCapacity, now))
+
+	// reservations that don't overlap can
+	later := tooLarge
+	later.ID = "later"
+	later.Start = now.Add(time.Hour)
+	require.NoError(t, reservations.Reserve(later, "requester", maxCapacity, now))
+	require.Equal(t, model.ResourceUsageData{CPU: 10, GPU: 1}, reservations.Reserved(now))
+
+	// only the unused capacity of active reservations is taken out of the available capacity, except for their jobs
+	available := model.ResourceUsageData{CPU: 8, GPU: 2}
+	require.Equal(t, model.ResourceUsageData{CPU: 4, GPU: 1}, reservations.Unreserved(available, "", now))
+	require.Equal(t, available, reservations.Unreserved(available, first.ID, now))
+
+	reservations.Use(first.ID, model.ResourceUsageData{CPU: 3, GPU: 1})
+	require.Equal(t, model.ResourceUsageData{CPU: 4, GPU: 1},
+		reservations.Unreserved(model.ResourceUsageData{CPU: 5, GPU: 1}, "", now))
+	reservations.Unuse(first.ID, model.ResourceUsageData{CPU: 3, GPU: 1})
+	require.Equal(t, model.ResourceUsageData{CPU: 1}, reservations.Unreserved(model.ResourceUsageData{CPU: 5, GPU: 1}, "", now))
+
+	// jobs only run on the reservations of their client
+	j := model.Job{Metadata: model.Metadata{ClientID: "client"}, Spec: model.Spec{Reservation: first.ID}}
+	require.Empty(t, reservations.ReservationOf(j))
+	first.ClientID = "client"
+	require.NoError(t, reservations.Reserve(first, "requester", maxCapacity, now))
+	require.Equal(t, first.ID, reservations.ReservationOf(j))
+
+	// only the requester that made a reservation can change or release it
+	require.Error(t, reservations.Reserve(first, "other", maxCapacity, now))
+	require.False(t, reservations.Release(first.ID, "other"))
+
+	// released and ended reservations hold nothing
+	require.True(t, reservations.Release(first.ID, "requester"))
+	require.False(t, reservations.Release(first.ID, "requester"))
+	require.Equal(t, available, reservations.Unreserved(available, "", now))
+	require.Empty(t, reservations.List(now.Add(3*time.Hour)))
+
+	ended := first
+	ended.End = now
+	require.Error(t, reservations.Reserve(ended, "requester", maxCapacity, now))
+
+	// reservations can't hold capacity indefinitely
+	tooLong := first
+	tooLong.End = now.Add(model.MaxReservationDuration + time.Minute)
+	require.Error(t, reservations.Reserve(tooLong, "requester", maxCapacity, now))
+}
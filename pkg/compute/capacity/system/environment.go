@@ -0,0 +1,52 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+const (
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+	cpuInfoPath       = "/proc/cpuinfo"
+)
+
+// Environment describes the environment executions run in on this node. Details that can't be read on this platform
+// are left empty.
+func Environment(ctx context.Context) model.ExecutionEnvironment {
+	env := model.ExecutionEnvironment{
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+	}
+	if release, err := os.ReadFile(kernelReleasePath); err == nil {
+		env.Kernel = strings.TrimSpace(string(release))
+	}
+	if cpuInfo, err := os.ReadFile(cpuInfoPath); err == nil {
+		env.CPUModel = parseCPUModel(cpuInfo)
+	}
+	driver, err := DriverVersion(ctx)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("failed to read NVIDIA driver version")
+	}
+	env.GPUDriver = driver
+	return env
+}
+
+// parseCPUModel reads the model of the first CPU from the contents of /proc/cpuinfo.
+func parseCPUModel(cpuInfo []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(cpuInfo))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
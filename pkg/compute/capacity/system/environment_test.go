@@ -0,0 +1,41 @@
+//go:build unit || !integration
+
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCPUModel(t *testing.T) {
+	cpuInfo := `processor	: 0
+vendor_id	: GenuineIntel
+model		: 85
+model name	: Intel(R) Xeon(R) Platinum 8259CL CPU @ 2.50GHz
+
+processor	: 1
+model name	: Intel(R) Xeon(R) Platinum 8259CL CPU @ 2.50GHz
+`
+	require.Equal(t, "Intel(R) Xeon(R) Platinum 8259CL CPU @ 2.50GHz", parseCPUModel([]byte(cpuInfo)))
+	require.Empty(t, parseCPUModel([]byte("processor	: 0\nBogoMIPS	: 50.00\n")))
+}
+
+func TestParseDriverInfo(t *testing.T) {
+	output := `NVRM version,CUDA version
+525.60.13,12.0
+
+Device Index,Device Minor,Model,Brand,UUID,Bus Location,Architecture
+0,0,Tesla T4,Nvidia,GPU-5f1ad5ef,00000000:00:1e.0,7.5
+`
+	version, err := parseDriverInfo(output, "NVRM version")
+	require.NoError(t, err)
+	require.Equal(t, "525.60.13", version)
+
+	version, err = parseDriverInfo(output, "CUDA version")
+	require.NoError(t, err)
+	require.Equal(t, "12.0", version)
+
+	_, err = parseDriverInfo("Device Index,Device Minor\n", "NVRM version")
+	require.Error(t, err)
+}
@@ -25,7 +25,7 @@ func NewPhysicalCapacityProvider() *PhysicalCapacityProvider {
 }
 
 func (p *PhysicalCapacityProvider) GetAvailableCapacity(ctx context.Context) (model.ResourceUsageData, error) {
-	diskSpace, err := getFreeDiskSpace(config.GetStoragePath())
+	diskSpace, err := FreeDiskSpace(config.GetStoragePath())
 	if err != nil {
 		return model.ResourceUsageData{}, err
 	}
@@ -43,12 +43,11 @@ func (p *PhysicalCapacityProvider) GetAvailableCapacity(ctx context.Context) (mo
 	}, nil
 }
 
-// get free disk space for storage path
-// returns bytes
-func getFreeDiskSpace(path string) (uint64, error) {
+// FreeDiskSpace returns the free disk space for storage path, in bytes
+func FreeDiskSpace(path string) (uint64, error) {
 	usage := du.NewDiskUsage(path)
 	if usage == nil {
-		return 0, fmt.Errorf("getFreeDiskSpace: unable to get disk space for path %s", path)
+		return 0, fmt.Errorf("FreeDiskSpace: unable to get disk space for path %s", path)
 	}
 	return usage.Free(), nil
 }
@@ -94,5 +93,55 @@ func numSystemGPUs() (uint64, error) {
 	return numDevices, nil
 }
 
+// DriverCUDAVersion wraps nvidia-container-cli to get the highest CUDA version supported by the installed NVIDIA
+// driver. An empty version is returned if the NVIDIA CLI is not installed.
+func DriverCUDAVersion(ctx context.Context) (string, error) {
+	return driverInfo(ctx, "CUDA version")
+}
+
+// DriverVersion wraps nvidia-container-cli to get the version of the installed NVIDIA driver. An empty version is
+// returned if the NVIDIA CLI is not installed.
+func DriverVersion(ctx context.Context) (string, error) {
+	return driverInfo(ctx, "NVRM version")
+}
+
+func driverInfo(ctx context.Context, field string) (string, error) {
+	nvidiaPath, err := exec.LookPath(NvidiaCLI)
+	if err != nil {
+		if (err.(*exec.Error)).Unwrap() == exec.ErrNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	resp, err := exec.CommandContext(ctx, nvidiaPath, "info", "--csv").Output()
+	if err != nil {
+		return "", err
+	}
+	return parseDriverInfo(string(resp), field)
+}
+
+// parseDriverInfo reads a field from the driver section of nvidia-container-cli output, which looks like:
+//
+//	NVRM version,CUDA version
+//	525.60.13,12.0
+//
+//	Device Index,Device Minor,Model,...
+func parseDriverInfo(output string, field string) (string, error) {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "NVRM version") || i+1 >= len(lines) {
+			continue
+		}
+		headers := strings.Split(line, ",")
+		values := strings.Split(lines[i+1], ",")
+		for j, header := range headers {
+			if strings.TrimSpace(header) == field && j < len(values) {
+				return strings.TrimSpace(values[j]), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("could not find %s in nvidia-container-cli output", field)
+}
+
 // compile-time check that the provider implements the interface
 var _ capacity.Provider = (*PhysicalCapacityProvider)(nil)
@@ -25,6 +25,8 @@ func NewLocalTracker(params LocalTrackerParams) *LocalTracker {
 }
 
 func (t *LocalTracker) IsWithinLimits(ctx context.Context, usage model.ResourceUsageData) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return usage.LessThanEq(t.maxCapacity)
 }
 
@@ -47,9 +49,17 @@ func (t *LocalTracker) GetAvailableCapacity(ctx context.Context) model.ResourceU
 }
 
 func (t *LocalTracker) GetMaxCapacity(ctx context.Context) model.ResourceUsageData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.maxCapacity
 }
 
+func (t *LocalTracker) SetMaxCapacity(ctx context.Context, capacity model.ResourceUsageData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxCapacity = capacity
+}
+
 func (t *LocalTracker) Remove(ctx context.Context, usage model.ResourceUsageData) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -19,6 +19,9 @@ type Tracker interface {
 	GetAvailableCapacity(ctx context.Context) model.ResourceUsageData
 	// GetMaxCapacity returns the total capacity of the compute node.
 	GetMaxCapacity(ctx context.Context) model.ResourceUsageData
+	// SetMaxCapacity updates the total capacity of the compute node. Capacity already in use is not released, so
+	// lowering the limit below current usage will only prevent new executions from being added.
+	SetMaxCapacity(ctx context.Context, capacity model.ResourceUsageData)
 	// Remove removes the given resource usage from the tracker.
 	Remove(ctx context.Context, usage model.ResourceUsageData)
 }
@@ -2,10 +2,13 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slices"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/logstream"
@@ -20,6 +23,19 @@ type BaseEndpointParams struct {
 	Bidder          Bidder
 	Executor        Executor
 	LogServer       logstream.LogStreamServer
+	// Prefetcher is notified when executions whose inputs may have been prefetched are not going to run.
+	Prefetcher InputPrefetcher
+	// Reservations holds the capacity reserved on the node, within the max capacity of CapacityTracker. The node
+	// does not take capacity reservations if nil.
+	Reservations    *capacity.Reservations
+	CapacityTracker capacity.Tracker
+	// MaxJobExecutionTimeout is the longest the timeout of an execution can be extended to, unless its client is in
+	// JobExecutionTimeoutClientIDBypassList. Timeouts can be extended without limit if zero.
+	MaxJobExecutionTimeout                time.Duration
+	JobExecutionTimeoutClientIDBypassList []string
+	// Admin applies the changes of the admins forwarded by requesters. The node can't be administered through
+	// requesters if nil.
+	Admin *NodeAdmin
 }
 
 // Base implementation of Endpoint
@@ -30,6 +46,12 @@ type BaseEndpoint struct {
 	bidder          Bidder
 	executor        Executor
 	logServer       logstream.LogStreamServer
+	prefetcher      InputPrefetcher
+	reservations    *capacity.Reservations
+	capacityTracker capacity.Tracker
+	maxTimeout      time.Duration
+	timeoutBypass   []string
+	admin           *NodeAdmin
 }
 
 func NewBaseEndpoint(params BaseEndpointParams) BaseEndpoint {
@@ -40,6 +62,12 @@ func NewBaseEndpoint(params BaseEndpointParams) BaseEndpoint {
 		bidder:          params.Bidder,
 		executor:        params.Executor,
 		logServer:       params.LogServer,
+		prefetcher:      params.Prefetcher,
+		reservations:    params.Reservations,
+		capacityTracker: params.CapacityTracker,
+		maxTimeout:      params.MaxJobExecutionTimeout,
+		timeoutBypass:   params.JobExecutionTimeoutClientIDBypassList,
+		admin:           params.Admin,
 	}
 }
 
@@ -91,6 +119,7 @@ func (s BaseEndpoint) BidAccepted(ctx context.Context, request BidAcceptedReques
 
 func (s BaseEndpoint) BidRejected(ctx context.Context, request BidRejectedRequest) (BidRejectedResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("bid rejected: %s", request.ExecutionID)
+	s.cancelPrefetch(ctx, request.ExecutionID)
 	err := s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   request.ExecutionID,
 		ExpectedState: store.ExecutionStateCreated,
@@ -155,6 +184,7 @@ func (s BaseEndpoint) ResultRejected(ctx context.Context, request ResultRejected
 
 func (s BaseEndpoint) CancelExecution(ctx context.Context, request CancelExecutionRequest) (CancelExecutionResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("canceling execution %s due to %s", request.ExecutionID, request.Justification)
+	s.cancelPrefetch(ctx, request.ExecutionID)
 	execution, err := s.executionStore.GetExecution(ctx, request.ExecutionID)
 	if err != nil {
 		return CancelExecutionResponse{}, err
@@ -163,7 +193,8 @@ func (s BaseEndpoint) CancelExecution(ctx context.Context, request CancelExecuti
 		return CancelExecutionResponse{}, fmt.Errorf("cannot cancel execution %s in state %s", execution.ID, execution.State)
 	}
 
-	if execution.State.IsExecuting() {
+	// accepted executions may be waiting in the queue of the executor for capacity to run
+	if execution.State.IsExecuting() || execution.State == store.ExecutionStateBidAccepted {
 		err = s.executor.Cancel(ctx, execution)
 		if err != nil {
 			return CancelExecutionResponse{}, err
@@ -196,5 +227,82 @@ func (s BaseEndpoint) ExecutionLogs(ctx context.Context, request ExecutionLogsRe
 	}, nil
 }
 
+func (s BaseEndpoint) ReserveCapacity(ctx context.Context, request ReserveCapacityRequest) (ReserveCapacityResponse, error) {
+	log.Ctx(ctx).Debug().Msgf("asked to reserve %s for reservation %s", request.Reservation.Resources, request.Reservation.ID)
+	if s.reservations == nil {
+		return ReserveCapacityResponse{Reason: "node does not take capacity reservations"}, nil
+	}
+	maxCapacity := s.capacityTracker.GetMaxCapacity(ctx)
+	if err := s.reservations.Reserve(request.Reservation, request.SourcePeerID, maxCapacity, time.Now()); err != nil {
+		return ReserveCapacityResponse{Reason: err.Error()}, nil
+	}
+	return ReserveCapacityResponse{Accepted: true}, nil
+}
+
+func (s BaseEndpoint) ReleaseCapacity(ctx context.Context, request ReleaseCapacityRequest) (ReleaseCapacityResponse, error) {
+	log.Ctx(ctx).Debug().Msgf("releasing reservation %s", request.ReservationID)
+	if s.reservations == nil {
+		return ReleaseCapacityResponse{}, nil
+	}
+	return ReleaseCapacityResponse{Released: s.reservations.Release(request.ReservationID, request.SourcePeerID)}, nil
+}
+
+func (s BaseEndpoint) AdminNode(ctx context.Context, request AdminNodeRequest) (AdminNodeResponse, error) {
+	log.Ctx(ctx).Debug().Msgf("applying changes of admin %s forwarded by %s", request.ClientID, request.SourcePeerID)
+	if s.admin == nil || !s.admin.Enabled() {
+		return AdminNodeResponse{}, errors.New("admin API is not enabled on this node")
+	}
+	return s.admin.Apply(ctx, request.ClientID, request.NodeAdminChanges)
+}
+
+func (s BaseEndpoint) ExtendTimeout(ctx context.Context, request ExtendTimeoutRequest) (ExtendTimeoutResponse, error) {
+	log.Ctx(ctx).Debug().Msgf("extending timeout of execution %s to %v seconds", request.ExecutionID, request.Timeout)
+	execution, err := s.executionStore.GetExecution(ctx, request.ExecutionID)
+	if err != nil {
+		return ExtendTimeoutResponse{}, err
+	}
+	if err = s.checkTimeout(execution, request.Timeout); err != nil {
+		return ExtendTimeoutResponse{}, err
+	}
+	err = s.executionStore.UpdateExecutionTimeout(ctx, request.ExecutionID, request.Timeout)
+	if err != nil {
+		return ExtendTimeoutResponse{}, err
+	}
+	execution, err = s.executionStore.GetExecution(ctx, request.ExecutionID)
+	if err != nil {
+		return ExtendTimeoutResponse{}, err
+	}
+
+	// executions that have not been accepted yet are run with the timeout in the store
+	if execution.State == store.ExecutionStateBidAccepted || execution.State == store.ExecutionStateRunning {
+		err = s.executor.ExtendTimeout(ctx, execution, execution.Job.Spec.GetTimeout())
+		if err != nil {
+			return ExtendTimeoutResponse{}, err
+		}
+	}
+	return ExtendTimeoutResponse{
+		ExecutionMetadata: NewExecutionMetadata(execution),
+	}, nil
+}
+
+// checkTimeout rejects extending the timeout of an execution beyond the maximum this node runs executions for, the
+// same way the node would not have bid on its job with that timeout in the first place.
+func (s BaseEndpoint) checkTimeout(execution store.Execution, timeout float64) error {
+	if s.maxTimeout <= 0 || slices.Contains(s.timeoutBypass, execution.Job.Metadata.ClientID) {
+		return nil
+	}
+	if timeout > s.maxTimeout.Seconds() {
+		return fmt.Errorf("timeout of execution %s cannot be extended to %v seconds, beyond the maximum allowed %s",
+			execution.ID, timeout, s.maxTimeout)
+	}
+	return nil
+}
+
+func (s BaseEndpoint) cancelPrefetch(ctx context.Context, executionID string) {
+	if s.prefetcher != nil {
+		s.prefetcher.Cancel(ctx, executionID)
+	}
+}
+
 // Compile-time interface check:
 var _ Endpoint = (*BaseEndpoint)(nil)
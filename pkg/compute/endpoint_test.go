@@ -0,0 +1,42 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendTimeoutWithinMaximum(t *testing.T) {
+	ctx := context.Background()
+	executionStore := inmemory.NewStore()
+	endpoint := NewBaseEndpoint(BaseEndpointParams{
+		ExecutionStore:                        executionStore,
+		MaxJobExecutionTimeout:                time.Hour,
+		JobExecutionTimeoutClientIDBypassList: []string{"bypass"},
+	})
+
+	for _, clientID := range []string{"client", "bypass"} {
+		job := model.Job{Metadata: model.Metadata{ID: clientID, ClientID: clientID}, Spec: model.Spec{Timeout: 60}}
+		require.NoError(t, executionStore.CreateExecution(ctx, *store.NewExecution(clientID, job, "requester", model.ResourceUsageData{})))
+	}
+
+	_, err := endpoint.ExtendTimeout(ctx, ExtendTimeoutRequest{ExecutionID: "client", Timeout: 3600})
+	require.NoError(t, err)
+
+	_, err = endpoint.ExtendTimeout(ctx, ExtendTimeoutRequest{ExecutionID: "client", Timeout: 3601})
+	require.Error(t, err)
+	execution, err := executionStore.GetExecution(ctx, "client")
+	require.NoError(t, err)
+	require.Equal(t, float64(3600), execution.Job.Spec.Timeout)
+
+	// clients allowed to submit jobs with longer timeouts can also extend them further
+	_, err = endpoint.ExtendTimeout(ctx, ExtendTimeoutRequest{ExecutionID: "bypass", Timeout: 7200})
+	require.NoError(t, err)
+}
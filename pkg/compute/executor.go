@@ -2,15 +2,23 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	localdirectory "github.com/bacalhau-project/bacalhau/pkg/storage/local_directory"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/prefetch"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/c2h5oh/datasize"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,6 +30,9 @@ type BaseExecutorParams struct {
 	Verifiers       verifier.VerifierProvider
 	Publishers      publisher.PublisherProvider
 	SimulatorConfig model.SimulatorConfigCompute
+	// MaxResultSize is the largest result in bytes the node publishes. Executions with larger results fail. There is
+	// no limit if zero.
+	MaxResultSize uint64
 }
 
 // BaseExecutor is the base implementation for backend service.
@@ -31,10 +42,12 @@ type BaseExecutor struct {
 	callback        Callback
 	store           store.ExecutionStore
 	cancellers      generic.SyncMap[string, context.CancelFunc]
+	preemptions     generic.SyncMap[string, string]
 	executors       executor.ExecutorProvider
 	verifiers       verifier.VerifierProvider
 	publishers      publisher.PublisherProvider
 	simulatorConfig model.SimulatorConfigCompute
+	maxResultSize   uint64
 }
 
 func NewBaseExecutor(params BaseExecutorParams) *BaseExecutor {
@@ -46,6 +59,7 @@ func NewBaseExecutor(params BaseExecutorParams) *BaseExecutor {
 		verifiers:       params.Verifiers,
 		publishers:      params.Publishers,
 		simulatorConfig: params.SimulatorConfig,
+		maxResultSize:   params.MaxResultSize,
 	}
 }
 
@@ -56,6 +70,11 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 		Str("execution", execution.ID).
 		Logger().WithContext(ctx)
 
+	// local paths are mounted as allowed for the client of the job
+	ctx = localdirectory.ContextWithNamespace(ctx, execution.Job.Metadata.ClientID)
+	// and the inputs prefetched for the job are picked up
+	ctx = prefetch.ContextWithJobID(ctx, execution.Job.ID())
+
 	ctx, cancel := context.WithCancel(ctx)
 	e.cancellers.Put(execution.ID, cancel)
 	defer func() {
@@ -65,9 +84,15 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 		}
 	}()
 
+	var failureReason *model.FailureReason
 	defer func() {
-		if err != nil {
-			e.handleFailure(ctx, execution, err, "Running")
+		// an execution preempted too late to be stopped completes normally
+		reason, preempted := e.preemptions.Get(execution.ID)
+		e.preemptions.Delete(execution.ID)
+		if err != nil && preempted {
+			e.handlePreemption(ctx, execution, reason)
+		} else if err != nil {
+			e.handleFailure(ctx, execution, err, "Running", failureReason)
 		}
 	}()
 
@@ -81,6 +106,11 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 		return
 	}
 
+	// the names of outputs become folders of the results, which are published and then removed
+	if err = checkOutputNames(execution.Job.Spec.Outputs); err != nil {
+		return
+	}
+
 	jobVerifier, err := e.verifiers.Get(ctx, execution.Job.Spec.Verifier)
 	if err != nil {
 		err = fmt.Errorf("failed to get verifier %s: %w", execution.Job.Spec.Verifier, err)
@@ -109,12 +139,28 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 			jobsCompleted.Add(ctx, 1)
 		}
 
+		if reason, preempted := e.preemptions.Get(execution.ID); preempted {
+			err = errors.New(reason)
+			return
+		}
+		failureReason = ClassifyFailure(execution.Job, runCommandResult, err)
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msg("failed to run execution")
 			return
 		}
 	}
 
+	if runCommandResult != nil {
+		runCommandResult.ResultSize, err = e.checkResultSize(execution, resultFolder)
+		if err != nil {
+			return
+		}
+		runCommandResult.ResultManifest = buildResultManifest(ctx, resultFolder)
+		if err = CheckOutputContracts(resultFolder, execution.Job.Spec.OutputContracts); err != nil {
+			return
+		}
+	}
+
 	proposal, err := jobVerifier.GetProposal(ctx, execution.Job, execution.ID, resultFolder)
 	if err != nil {
 		err = fmt.Errorf("failed to get proposal: %w", err)
@@ -138,15 +184,26 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 		},
 		ResultProposal:   proposal,
 		RunCommandResult: runCommandResult,
+		FailureReason:    failureReason,
 	})
 	return err
 }
 
 // Publish the result of an execution after it has been verified.
 func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (err error) {
+	// publishing is canceled along with the execution, as results can take minutes to publish
+	ctx, cancel := context.WithCancel(ctx)
+	e.cancellers.Put(execution.ID, cancel)
+	defer func() {
+		if cancel, found := e.cancellers.Get(execution.ID); found {
+			e.cancellers.Delete(execution.ID)
+			cancel()
+		}
+	}()
+
 	defer func() {
 		if err != nil {
-			e.handleFailure(ctx, execution, err, "Publishing")
+			e.handleFailure(ctx, execution, err, "Publishing", nil)
 		}
 	}()
 	log.Ctx(ctx).Debug().Msgf("Publishing execution %s", execution.ID)
@@ -168,6 +225,10 @@ func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (
 		err = fmt.Errorf("failed to get result path: %w", err)
 		return
 	}
+	publishedVolumes, err := e.publishVolumes(ctx, execution, resultFolder)
+	if err != nil {
+		return
+	}
 	jobPublisher, err := e.publishers.Get(ctx, execution.Job.Spec.PublisherSpec.Type)
 	if err != nil {
 		err = fmt.Errorf("failed to get publisher %s: %w", execution.Job.Spec.PublisherSpec.Type, err)
@@ -205,16 +266,122 @@ func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (
 			SourcePeerID: e.ID,
 			TargetPeerID: execution.RequesterNodeID,
 		},
-		PublishResult: publishedResult,
+		PublishResult:    publishedResult,
+		PublishedVolumes: publishedVolumes,
 	})
 	return err
 }
 
+// publishVolumes publishes the output volumes that have their own publisher, and removes them from the result folder
+// so that they are not published again with the rest of the results.
+func (e *BaseExecutor) publishVolumes(
+	ctx context.Context, execution store.Execution, resultFolder string,
+) (map[string]model.StorageSpec, error) {
+	var publishedVolumes map[string]model.StorageSpec
+	for _, output := range execution.Job.Spec.Outputs {
+		if output.Publisher == nil {
+			continue
+		}
+		volumePublisher, err := e.publishers.Get(ctx, output.Publisher.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get publisher %s for output %s: %w", output.Publisher.Type, output.Name, err)
+		}
+
+		// publishers read their configuration from the job
+		volumeJob := execution.Job
+		volumeJob.Spec.PublisherSpec = *output.Publisher
+		volumeFolder, err := outputFolder(resultFolder, output.Name)
+		if err != nil {
+			return nil, err
+		}
+		published, err := volumePublisher.PublishResult(ctx, execution.ID, volumeJob, volumeFolder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish output %s: %w", output.Name, err)
+		}
+		log.Ctx(ctx).Debug().
+			Str("execution", execution.ID).
+			Str("output", output.Name).
+			Str("publisher", output.Publisher.Type.String()).
+			Msg("Output published")
+
+		if publishedVolumes == nil {
+			publishedVolumes = make(map[string]model.StorageSpec)
+		}
+		publishedVolumes[output.Name] = published
+		if err = os.RemoveAll(volumeFolder); err != nil {
+			return nil, fmt.Errorf("failed to remove published output %s: %w", output.Name, err)
+		}
+	}
+	return publishedVolumes, nil
+}
+
+// checkOutputNames returns an error if the name of an output can't be used as a folder of the results.
+func checkOutputNames(outputs []model.StorageSpec) error {
+	for _, output := range outputs {
+		if !model.ValidFileName(output.Name) {
+			return fmt.Errorf("invalid output volume name %q", output.Name)
+		}
+	}
+	return nil
+}
+
+// outputFolder returns the folder of the results that holds an output, and an error if it would not be within them.
+func outputFolder(resultFolder, name string) (string, error) {
+	if !model.ValidFileName(name) {
+		return "", fmt.Errorf("invalid output volume name %q", name)
+	}
+	folder := filepath.Join(resultFolder, name)
+	if filepath.Dir(folder) != filepath.Clean(resultFolder) {
+		return "", fmt.Errorf("output volume %q is not within the results", name)
+	}
+	return folder, nil
+}
+
+// ResultSize returns the size in bytes of the result of an execution waiting to be published.
+func (e *BaseExecutor) ResultSize(ctx context.Context, execution store.Execution) (uint64, error) {
+	jobVerifier, err := e.verifiers.Get(ctx, execution.Job.Spec.Verifier)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get verifier %s: %w", execution.Job.Spec.Verifier, err)
+	}
+	resultFolder, err := jobVerifier.GetResultPath(ctx, execution.ID, execution.Job)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get result path: %w", err)
+	}
+	return util.DirSize(resultFolder)
+}
+
+// checkResultSize returns the size in bytes of the result in resultFolder, and an error if it exceeds the result size
+// limit of the execution.
+func (e *BaseExecutor) checkResultSize(execution store.Execution, resultFolder string) (uint64, error) {
+	size, err := util.DirSize(resultFolder)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get result size: %w", err)
+	}
+	if limit := execution.Job.Spec.GetResultSizeLimit(e.maxResultSize); limit > 0 && size > limit {
+		return size, fmt.Errorf("result size %s exceeds limit of %s",
+			datasize.ByteSize(size).HR(), datasize.ByteSize(limit).HR())
+	}
+	return size, nil
+}
+
+// buildResultManifest returns the manifest of the result in resultFolder, which is published with the result so that
+// clients can compare results without downloading them, or nil if the result has too many files to list.
+func buildResultManifest(ctx context.Context, resultFolder string) *model.ResultManifest {
+	manifest, err := downloader.BuildResultManifest(resultFolder, model.MaxResultManifestFiles)
+	if err != nil {
+		if !errors.Is(err, downloader.ErrTooManyResultFiles) {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to build the manifest of the result")
+		}
+		return nil
+	}
+	return &manifest
+}
+
 // Cancel the execution.
 func (e *BaseExecutor) Cancel(ctx context.Context, execution store.Execution) (err error) {
 	defer func() {
 		if err != nil {
-			e.handleFailure(ctx, execution, err, "Canceling")
+			e.handleFailure(ctx, execution, err, "Canceling", nil)
 		}
 	}()
 
@@ -234,7 +401,47 @@ func (e *BaseExecutor) Cancel(ctx context.Context, execution store.Execution) (e
 	return err
 }
 
-func (e *BaseExecutor) handleFailure(ctx context.Context, execution store.Execution, err error, operation string) {
+// Preempt the execution while it is running. The execution is reported as preempted once it has stopped.
+func (e *BaseExecutor) Preempt(ctx context.Context, execution store.Execution, reason string) error {
+	cancel, found := e.cancellers.Get(execution.ID)
+	if !found {
+		return fmt.Errorf("execution %s is not running", execution.ID)
+	}
+	log.Ctx(ctx).Info().Str("Execution", execution.ID).Msgf("Preempting execution: %s", reason)
+	e.preemptions.Put(execution.ID, reason)
+	cancel()
+	return nil
+}
+
+// ExtendTimeout does nothing, as the timeout of executions is enforced by the ExecutorBuffer.
+func (e *BaseExecutor) ExtendTimeout(ctx context.Context, execution store.Execution, timeout time.Duration) error {
+	return nil
+}
+
+func (e *BaseExecutor) handlePreemption(ctx context.Context, execution store.Execution, reason string) {
+	updateError := e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: execution.ID,
+		NewState:    store.ExecutionStateFailed,
+		Comment:     reason,
+	})
+
+	if updateError != nil {
+		log.Ctx(ctx).Error().Err(updateError).Msgf("Failed to update execution (%s) state to failed: %s", execution.ID, updateError)
+	} else {
+		e.callback.OnComputeFailure(ctx, ComputeError{
+			ExecutionMetadata: NewExecutionMetadata(execution),
+			RoutingMetadata: RoutingMetadata{
+				SourcePeerID: e.ID,
+				TargetPeerID: execution.RequesterNodeID,
+			},
+			Err:       reason,
+			Preempted: true,
+		})
+	}
+}
+
+func (e *BaseExecutor) handleFailure(
+	ctx context.Context, execution store.Execution, err error, operation string, reason *model.FailureReason) {
 	log.Ctx(ctx).Error().Err(err).Msgf("%s execution %s failed", operation, execution.ID)
 	updateError := e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID: execution.ID,
@@ -251,7 +458,8 @@ func (e *BaseExecutor) handleFailure(ctx context.Context, execution store.Execut
 				SourcePeerID: e.ID,
 				TargetPeerID: execution.RequesterNodeID,
 			},
-			Err: err.Error(),
+			Err:           err.Error(),
+			FailureReason: reason,
 		})
 	}
 }
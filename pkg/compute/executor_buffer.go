@@ -3,18 +3,29 @@ package compute
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	sync "github.com/bacalhau-project/golang-mutex-tracer"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 )
 
 type bufferTask struct {
 	execution  store.Execution
 	enqueuedAt time.Time
+	startedAt  time.Time
+	preempted  bool
+	// reservation is the capacity reservation the task runs on, if its job is entitled to one held on the node
+	reservation string
+	// timeout is how long the task is allowed to run for once started, and deadline fires when it has passed
+	timeout  time.Duration
+	deadline *time.Timer
 }
 
 func newBufferTask(execution store.Execution) *bufferTask {
@@ -24,6 +35,31 @@ func newBufferTask(execution store.Execution) *bufferTask {
 	}
 }
 
+type publishTask struct {
+	execution store.Execution
+	// resultSize is the size of the result to publish in bytes
+	resultSize uint64
+}
+
+// publishAgingInterval is how long a result waits to be published for its priority to be raised by one, so that the
+// results of low priority jobs are not starved by a steady stream of higher priority ones.
+const publishAgingInterval = time.Minute
+
+// publishPriority returns the priority of the job of the task, raised by how long it has waited to be published.
+func publishPriority(task *publishTask, waited time.Duration) int {
+	return task.execution.Job.Spec.Priority + int(waited/publishAgingInterval)
+}
+
+// publishOrder publishes the results of higher priority jobs first, then smaller results before larger ones, so that
+// a single huge result does not starve the network link or IPFS node while many small results wait behind it.
+func publishOrder(a, b *publishTask, aWaited, bWaited time.Duration) bool {
+	aPriority, bPriority := publishPriority(a, aWaited), publishPriority(b, bWaited)
+	if aPriority != bPriority {
+		return aPriority > bPriority
+	}
+	return a.resultSize < b.resultSize
+}
+
 type ExecutorBufferParams struct {
 	ID                         string
 	DelegateExecutor           Executor
@@ -32,6 +68,18 @@ type ExecutorBufferParams struct {
 	EnqueuedCapacityTracker    capacity.Tracker
 	DefaultJobExecutionTimeout time.Duration
 	BackoffDuration            time.Duration
+	// EnablePreemption allows executions to be preempted by executions of higher priority when there isn't enough
+	// capacity to run them.
+	EnablePreemption bool
+	// MaxConcurrentPublishes is the maximum number of results published at once. There is no limit if zero.
+	MaxConcurrentPublishes int
+	// MaxConcurrentCancels is the maximum number of executions canceled at once. There is no limit if zero.
+	MaxConcurrentCancels int
+	// ResultSize returns the size of the result of an execution, to publish smaller results first. Optional.
+	ResultSize func(ctx context.Context, execution store.Execution) (uint64, error)
+	// Reservations is the capacity held for capacity reservations, which only the executions of their jobs can run
+	// on. Optional.
+	Reservations *capacity.Reservations
 }
 
 // ExecutorBuffer is a backend.Executor implementation that buffers executions locally until enough capacity is
@@ -40,6 +88,9 @@ type ExecutorBufferParams struct {
 // they were enqueued. However, an execution with high resource usage requirements might be skipped if there are newer
 // jobs with lower resource usage requirements that can be executed immediately. This is done to improve utilization
 // of compute nodes, though it might result in starvation and should be re-evaluated in the future.
+//
+// Publishing results and canceling executions are queued in lanes of their own, with their own concurrency limits,
+// so that they are not held up by executions waiting for capacity, and executions are not held up by them.
 type ExecutorBuffer struct {
 	ID                         string
 	runningCapacity            capacity.Tracker
@@ -52,6 +103,11 @@ type ExecutorBuffer struct {
 	defaultJobExecutionTimeout time.Duration
 	backoffDuration            time.Duration
 	backoffUntil               time.Time
+	enablePreemption           bool
+	publishes                  *workLane[*publishTask]
+	cancels                    *workLane[store.Execution]
+	resultSize                 func(ctx context.Context, execution store.Execution) (uint64, error)
+	reservations               *capacity.Reservations
 	mu                         sync.Mutex
 }
 
@@ -67,7 +123,12 @@ func NewExecutorBuffer(params ExecutorBufferParams) *ExecutorBuffer {
 		enqueuedList:               make([]string, 0),
 		defaultJobExecutionTimeout: params.DefaultJobExecutionTimeout,
 		backoffDuration:            params.BackoffDuration,
+		enablePreemption:           params.EnablePreemption,
+		resultSize:                 params.ResultSize,
+		reservations:               params.Reservations,
 	}
+	r.publishes = newWorkLane(PublishLane, params.MaxConcurrentPublishes, publishOrder, r.doPublish)
+	r.cancels = newWorkLane(CancelLane, params.MaxConcurrentCancels, nil, r.doCancel)
 
 	r.mu.EnableTracerWithOpts(sync.Opts{
 		Threshold: 10 * time.Millisecond,
@@ -114,12 +175,82 @@ func (s *ExecutorBuffer) Run(ctx context.Context, execution store.Execution) (er
 		return
 	}
 
-	s.enqueued[execution.ID] = newBufferTask(execution)
+	task := newBufferTask(execution)
+	s.enqueued[execution.ID] = task
 	s.enqueuedList = append(s.enqueuedList, execution.ID)
+	workLaneQueueLength.Add(ctx, 1, laneAttributes(ExecutionLane)...)
 	s.deque()
+	if _, stillEnqueued := s.enqueued[execution.ID]; stillEnqueued && s.enablePreemption {
+		s.preemptFor(ctx, task)
+	}
 	return err
 }
 
+// preemptFor preempts running executions of lower priority than the enqueued task if that frees up enough capacity
+// to run it. The executions of lowest priority are preempted first, and the most recently started ones among them as
+// they lose the least work.
+func (s *ExecutorBuffer) preemptFor(ctx context.Context, task *bufferTask) {
+	priority := task.execution.Job.Spec.Priority
+	available := s.availableFor(ctx, task.execution)
+	if task.execution.ResourceUsage.LessThanEq(available) {
+		// there is already enough capacity, and the task will run once the backoff is over
+		return
+	}
+
+	var reservation string
+	if s.reservations != nil {
+		reservation = s.reservations.ReservationOf(task.execution.Job)
+	}
+	candidates := make([]*bufferTask, 0, len(s.running))
+	for _, running := range s.running {
+		// the capacity of executions of other reservations would go back to their reservation, not to the task
+		if running.reservation != "" && running.reservation != reservation {
+			continue
+		}
+		if running.execution.Job.Spec.Priority < priority {
+			candidates = append(candidates, running)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := candidates[i].execution.Job.Spec.Priority, candidates[j].execution.Job.Spec.Priority
+		if pi != pj {
+			return pi < pj
+		}
+		return candidates[i].startedAt.After(candidates[j].startedAt)
+	})
+
+	var victims []*bufferTask
+	for _, candidate := range candidates {
+		if task.execution.ResourceUsage.LessThanEq(available) {
+			break
+		}
+		victims = append(victims, candidate)
+		available = available.Add(candidate.execution.ResourceUsage)
+	}
+	if !task.execution.ResourceUsage.LessThanEq(available) {
+		return
+	}
+
+	reason := fmt.Sprintf("preempted by job %s of higher priority %d", task.execution.Job.ID(), priority)
+	for _, victim := range victims {
+		if err := s.preempt(ctx, victim, reason); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to preempt execution %s", victim.execution.ID)
+		}
+	}
+
+	// start the task right away, rather than letting executions queued ahead of it take the freed capacity
+	if !s.runningCapacity.AddIfHasCapacity(ctx, task.execution.ResourceUsage) {
+		return
+	}
+	s.start(ctx, task)
+	for i, executionID := range s.enqueuedList {
+		if executionID == task.execution.ID {
+			s.enqueuedList = append(s.enqueuedList[:i], s.enqueuedList[i+1:]...)
+			break
+		}
+	}
+}
+
 // doRun triggers the execution by the delegate backend.Executor and frees up the capacity when the execution is done.
 func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 	ctx = system.AddJobIDToBaggage(ctx, task.execution.Job.Metadata.ID)
@@ -127,12 +258,9 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Run")
 	defer span.End()
 
-	timeout := task.execution.Job.Spec.GetTimeout()
-	if timeout == 0 {
-		timeout = s.defaultJobExecutionTimeout
-	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	defer task.deadline.Stop()
 
 	ch := make(chan error)
 	go func() {
@@ -140,7 +268,11 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 	}()
 
 	select {
-	case <-ctx.Done():
+	case <-task.deadline.C:
+		cancel()
+		s.mu.Lock()
+		timeout := task.timeout
+		s.mu.Unlock()
 		s.callback.OnComputeFailure(ctx, ComputeError{
 			ExecutionMetadata: NewExecutionMetadata(task.execution),
 			RoutingMetadata: RoutingMetadata{
@@ -156,8 +288,10 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.runningCapacity.Remove(ctx, task.execution.ResourceUsage)
-	delete(s.running, task.execution.ID)
+	if !task.preempted {
+		// the capacity of preempted executions has already been released
+		s.release(ctx, task)
+	}
 	s.deque()
 }
 
@@ -179,11 +313,9 @@ func (s *ExecutorBuffer) deque() {
 	for _, executionID := range s.enqueuedList {
 		task := s.enqueued[executionID]
 
-		if s.runningCapacity.AddIfHasCapacity(ctx, task.execution.ResourceUsage) {
-			s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
-			delete(s.enqueued, executionID)
-			s.running[executionID] = task
-			go s.doRun(logger.ContextWithNodeIDLogger(context.Background(), s.ID), task)
+		if task.execution.ResourceUsage.LessThanEq(s.availableFor(ctx, task.execution)) &&
+			s.runningCapacity.AddIfHasCapacity(ctx, task.execution.ResourceUsage) {
+			s.start(ctx, task)
 		} else {
 			remainingEnqueuedList = append(remainingEnqueuedList, executionID)
 		}
@@ -192,39 +324,153 @@ func (s *ExecutorBuffer) deque() {
 	s.backoffUntil = time.Now().Add(s.backoffDuration)
 }
 
-func (s *ExecutorBuffer) Publish(_ context.Context, execution store.Execution) error {
-	// TODO: Enqueue publish tasks
-	go func() {
-		ctx := logger.ContextWithNodeIDLogger(context.Background(), s.ID)
-		ctx = system.AddJobIDToBaggage(ctx, execution.Job.Metadata.ID)
-		ctx = system.AddNodeIDToBaggage(ctx, s.ID)
-		ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Publish")
-		defer span.End()
-		_ = s.delegateService.Publish(ctx, execution)
-	}()
+// Preempt stops a running execution, releasing its capacity right away.
+func (s *ExecutorBuffer) Preempt(ctx context.Context, execution store.Execution, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.running[execution.ID]
+	if !ok {
+		return fmt.Errorf("execution %s is not running", execution.ID)
+	}
+	if err := s.preempt(ctx, task, reason); err != nil {
+		return err
+	}
+	s.deque()
 	return nil
 }
 
-func (s *ExecutorBuffer) Cancel(_ context.Context, execution store.Execution) error {
-	// TODO: Enqueue cancel tasks
-	go func() {
-		ctx := logger.ContextWithNodeIDLogger(context.Background(), s.ID)
-		ctx = system.AddJobIDToBaggage(ctx, execution.Job.Metadata.ID)
-		ctx = system.AddNodeIDToBaggage(ctx, s.ID)
-		ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Cancel")
-		defer span.End()
-
-		err := s.delegateService.Cancel(ctx, execution)
-		if err == nil {
-			s.mu.Lock()
-			defer s.mu.Unlock()
-
-			delete(s.running, execution.ID)
+// ExtendTimeout gives an execution that is running or waiting to run longer to run, counted from when it started.
+func (s *ExecutorBuffer) ExtendTimeout(ctx context.Context, execution store.Execution, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task, ok := s.enqueued[execution.ID]; ok {
+		task.execution.Job.Spec.Timeout = timeout.Seconds()
+		return nil
+	}
+	task, ok := s.running[execution.ID]
+	if !ok {
+		return fmt.Errorf("execution %s is not running", execution.ID)
+	}
+	if !task.deadline.Stop() {
+		return fmt.Errorf("execution %s already timed out", execution.ID)
+	}
+	task.timeout = timeout
+	task.deadline.Reset(time.Until(task.startedAt.Add(timeout)))
+	return s.delegateService.ExtendTimeout(ctx, execution, timeout)
+}
+
+// preempt stops a running task and releases its capacity. It is called with the lock held.
+func (s *ExecutorBuffer) preempt(ctx context.Context, task *bufferTask, reason string) error {
+	if err := s.delegateService.Preempt(ctx, task.execution, reason); err != nil {
+		return err
+	}
+	task.preempted = true
+	s.release(ctx, task)
+	return nil
+}
+
+// availableFor returns the running capacity available to the execution, which excludes the capacity held for
+// capacity reservations other than the one of its job. It is called with the lock held.
+func (s *ExecutorBuffer) availableFor(ctx context.Context, execution store.Execution) model.ResourceUsageData {
+	available := s.runningCapacity.GetAvailableCapacity(ctx)
+	if s.reservations == nil {
+		return available
+	}
+	return s.reservations.Unreserved(available, s.reservations.ReservationOf(execution.Job), time.Now())
+}
+
+// release frees up the capacity of a running task. It is called with the lock held.
+func (s *ExecutorBuffer) release(ctx context.Context, task *bufferTask) {
+	s.runningCapacity.Remove(ctx, task.execution.ResourceUsage)
+	if s.reservations != nil && task.reservation != "" {
+		s.reservations.Unuse(task.reservation, task.execution.ResourceUsage)
+	}
+	delete(s.running, task.execution.ID)
+}
+
+// start runs an enqueued task, for which running capacity has already been reserved. It is called with the lock held.
+func (s *ExecutorBuffer) start(ctx context.Context, task *bufferTask) {
+	s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
+	if s.reservations != nil {
+		task.reservation = s.reservations.ReservationOf(task.execution.Job)
+		s.reservations.Use(task.reservation, task.execution.ResourceUsage)
+	}
+	delete(s.enqueued, task.execution.ID)
+	workLaneQueueLength.Add(ctx, -1, laneAttributes(ExecutionLane)...)
+	task.startedAt = time.Now()
+	task.timeout = s.timeout(task.execution)
+	task.deadline = time.NewTimer(task.timeout)
+	workLaneWait.Record(ctx, task.startedAt.Sub(task.enqueuedAt).Seconds(), laneAttributes(ExecutionLane)...)
+	s.running[task.execution.ID] = task
+	go s.doRun(logger.ContextWithNodeIDLogger(context.Background(), s.ID), task)
+}
+
+// dequeue removes an enqueued task from the queue without running it, and frees up its enqueued capacity. It is
+// called with the lock held.
+func (s *ExecutorBuffer) dequeue(ctx context.Context, task *bufferTask) {
+	s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
+	delete(s.enqueued, task.execution.ID)
+	workLaneQueueLength.Add(ctx, -1, laneAttributes(ExecutionLane)...)
+	if i := slices.Index(s.enqueuedList, task.execution.ID); i >= 0 {
+		s.enqueuedList = slices.Delete(s.enqueuedList, i, i+1)
+	}
+}
+
+// Publish enqueues the result of the execution to be published once its turn comes.
+func (s *ExecutorBuffer) Publish(ctx context.Context, execution store.Execution) error {
+	task := &publishTask{execution: execution}
+	if s.resultSize != nil {
+		size, err := s.resultSize(ctx, execution)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to get result size of execution %s", execution.ID)
 		}
-	}()
+		task.resultSize = size
+	}
+	s.publishes.enqueue(task)
+	return nil
+}
+
+func (s *ExecutorBuffer) doPublish(task *publishTask) {
+	ctx := logger.ContextWithNodeIDLogger(context.Background(), s.ID)
+	ctx = system.AddJobIDToBaggage(ctx, task.execution.Job.Metadata.ID)
+	ctx = system.AddNodeIDToBaggage(ctx, s.ID)
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Publish")
+	defer span.End()
+	log.Ctx(ctx).Debug().
+		Str("execution", task.execution.ID).
+		Uint64("resultSize", task.resultSize).
+		Msg("Publishing result after waiting in the publish lane")
+	_ = s.delegateService.Publish(ctx, task.execution)
+}
+
+func (s *ExecutorBuffer) Cancel(ctx context.Context, execution store.Execution) error {
+	// executions still waiting for capacity are dropped from the queue, so that they never start
+	s.mu.Lock()
+	if task, ok := s.enqueued[execution.ID]; ok {
+		s.dequeue(ctx, task)
+	}
+	s.mu.Unlock()
+
+	s.cancels.enqueue(execution)
 	return nil
 }
 
+func (s *ExecutorBuffer) doCancel(execution store.Execution) {
+	ctx := logger.ContextWithNodeIDLogger(context.Background(), s.ID)
+	ctx = system.AddJobIDToBaggage(ctx, execution.Job.Metadata.ID)
+	ctx = system.AddNodeIDToBaggage(ctx, s.ID)
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Cancel")
+	defer span.End()
+
+	err := s.delegateService.Cancel(ctx, execution)
+	if err == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.running, execution.ID)
+	}
+}
+
 // RunningExecutions return list of running executions
 func (s *ExecutorBuffer) RunningExecutions() []store.Execution {
 	return s.mapValues(s.running)
@@ -235,6 +481,68 @@ func (s *ExecutorBuffer) EnqueuedExecutions() []store.Execution {
 	return s.mapValues(s.enqueued)
 }
 
+// LaneQueueLengths returns the number of tasks waiting for their turn in each lane of the buffer.
+func (s *ExecutorBuffer) LaneQueueLengths() map[string]int {
+	s.mu.Lock()
+	executions := len(s.enqueuedList)
+	s.mu.Unlock()
+	return map[string]int{
+		ExecutionLane: executions,
+		PublishLane:   s.publishes.len(),
+		CancelLane:    s.cancels.len(),
+	}
+}
+
+// QueuedExecutions returns the enqueued executions in queue order, with an estimate of when they will start. The
+// estimate is pessimistic, as it assumes every execution runs until its timeout, and that each queued execution
+// takes over from the running or queued execution that will finish first.
+func (s *ExecutorBuffer) QueuedExecutions() []model.QueuedExecution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// the time at which each execution ahead in the queue is expected to free up its capacity
+	endTimes := make([]time.Time, 0, len(s.running)+len(s.enqueuedList))
+	for _, task := range s.running {
+		endTimes = append(endTimes, task.startedAt.Add(task.timeout))
+	}
+
+	queue := make([]model.QueuedExecution, 0, len(s.enqueuedList))
+	for i, executionID := range s.enqueuedList {
+		task := s.enqueued[executionID]
+		startTime := now
+		if len(endTimes) > 0 {
+			first := 0
+			for j := range endTimes {
+				if endTimes[j].Before(endTimes[first]) {
+					first = j
+				}
+			}
+			if endTimes[first].After(now) {
+				startTime = endTimes[first]
+			}
+			endTimes = append(endTimes[:first], endTimes[first+1:]...)
+		}
+		endTimes = append(endTimes, startTime.Add(s.timeout(task.execution)))
+		queue = append(queue, model.QueuedExecution{
+			ExecutionID:        executionID,
+			JobID:              task.execution.Job.Metadata.ID,
+			Position:           i + 1,
+			EstimatedStartTime: startTime,
+		})
+	}
+	return queue
+}
+
+// timeout returns how long the execution is allowed to run for.
+func (s *ExecutorBuffer) timeout(execution store.Execution) time.Duration {
+	timeout := execution.Job.Spec.GetTimeout()
+	if timeout == 0 {
+		timeout = s.defaultJobExecutionTimeout
+	}
+	return timeout
+}
+
 func (s *ExecutorBuffer) mapValues(m map[string]*bufferTask) []store.Execution {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -0,0 +1,302 @@
+//go:build unit || !integration
+
+package compute_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// blockingExecutor runs executions until it is released.
+type blockingExecutor struct {
+	release   chan struct{}
+	mu        sync.Mutex
+	preempted []string
+}
+
+func (e *blockingExecutor) Run(ctx context.Context, _ store.Execution) error {
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (e *blockingExecutor) Publish(context.Context, store.Execution) error {
+	return nil
+}
+
+func (e *blockingExecutor) Cancel(context.Context, store.Execution) error {
+	return nil
+}
+
+func (e *blockingExecutor) Preempt(_ context.Context, execution store.Execution, _ string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.preempted = append(e.preempted, execution.ID)
+	return nil
+}
+
+func (e *blockingExecutor) ExtendTimeout(context.Context, store.Execution, time.Duration) error {
+	return nil
+}
+
+func newTestExecution(t *testing.T, id string, timeout time.Duration, priority int) store.Execution {
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+	job.Metadata.ID = "job-" + id
+	job.Spec.Timeout = timeout.Seconds()
+	job.Spec.Priority = priority
+	return *store.NewExecution(id, *job, "requesterNodeID", model.ResourceUsageData{CPU: 1})
+}
+
+func newTestExecutorBuffer(delegate compute.Executor, enablePreemption bool) *compute.ExecutorBuffer {
+	return compute.NewExecutorBuffer(compute.ExecutorBufferParams{
+		ID:               "testNodeID",
+		DelegateExecutor: delegate,
+		Callback:         compute.CallbackMock{},
+		RunningCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 1},
+		}),
+		EnqueuedCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 10},
+		}),
+		DefaultJobExecutionTimeout: time.Hour,
+		EnablePreemption:           enablePreemption,
+	})
+}
+
+func TestExecutorBufferQueuedExecutions(t *testing.T) {
+	ctx := context.Background()
+	delegate := &blockingExecutor{release: make(chan struct{})}
+	defer close(delegate.release)
+
+	buffer := newTestExecutorBuffer(delegate, false)
+
+	before := time.Now()
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "running", time.Hour, 0)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "first", 30*time.Minute, 0)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "second", 10*time.Minute, 0)))
+	after := time.Now()
+
+	queue := buffer.QueuedExecutions()
+	require.Len(t, queue, 2)
+
+	require.Equal(t, "first", queue[0].ExecutionID)
+	require.Equal(t, "job-first", queue[0].JobID)
+	require.Equal(t, 1, queue[0].Position)
+	require.WithinRange(t, queue[0].EstimatedStartTime, before.Add(time.Hour), after.Add(time.Hour))
+
+	require.Equal(t, "second", queue[1].ExecutionID)
+	require.Equal(t, 2, queue[1].Position)
+	require.WithinRange(t, queue[1].EstimatedStartTime, before.Add(90*time.Minute), after.Add(90*time.Minute))
+}
+
+func TestExecutorBufferPreemption(t *testing.T) {
+	ctx := context.Background()
+	delegate := &blockingExecutor{release: make(chan struct{})}
+	defer close(delegate.release)
+	buffer := newTestExecutorBuffer(delegate, true)
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "low", time.Hour, 0)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "same", time.Hour, 0)))
+	require.Empty(t, delegate.preempted, "executions of the same priority should not be preempted")
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "high", time.Hour, 1)))
+	require.Equal(t, []string{"low"}, delegate.preempted)
+
+	running := buffer.RunningExecutions()
+	require.Len(t, running, 1)
+	require.Equal(t, "high", running[0].ID)
+
+	queue := buffer.QueuedExecutions()
+	require.Len(t, queue, 1)
+	require.Equal(t, "same", queue[0].ExecutionID)
+}
+
+func TestExecutorBufferPreemptionDisabled(t *testing.T) {
+	ctx := context.Background()
+	delegate := &blockingExecutor{release: make(chan struct{})}
+	defer close(delegate.release)
+	buffer := newTestExecutorBuffer(delegate, false)
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "low", time.Hour, 0)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "high", time.Hour, 1)))
+	require.Empty(t, delegate.preempted)
+	require.Len(t, buffer.QueuedExecutions(), 1)
+}
+
+func TestExecutorBufferExtendTimeout(t *testing.T) {
+	ctx := context.Background()
+	delegate := &blockingExecutor{release: make(chan struct{})}
+	defer close(delegate.release)
+
+	failures := make(chan string, 2)
+	buffer := compute.NewExecutorBuffer(compute.ExecutorBufferParams{
+		ID:               "testNodeID",
+		DelegateExecutor: delegate,
+		Callback: compute.CallbackMock{
+			OnComputeFailureHandler: func(ctx context.Context, result compute.ComputeError) {
+				failures <- result.ExecutionID
+			},
+		},
+		RunningCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 1},
+		}),
+		EnqueuedCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 10},
+		}),
+		DefaultJobExecutionTimeout: time.Hour,
+	})
+
+	running := newTestExecution(t, "running", 200*time.Millisecond, 0)
+	queued := newTestExecution(t, "queued", 200*time.Millisecond, 0)
+	require.NoError(t, buffer.Run(ctx, running))
+	require.NoError(t, buffer.Run(ctx, queued))
+	require.NoError(t, buffer.ExtendTimeout(ctx, running, time.Hour))
+	require.NoError(t, buffer.ExtendTimeout(ctx, queued, time.Hour))
+
+	// the execution keeps running past its original timeout
+	require.Never(t, func() bool { return len(failures) > 0 }, time.Second, 50*time.Millisecond)
+	require.Len(t, buffer.RunningExecutions(), 1)
+	require.WithinDuration(t, time.Now().Add(time.Hour), buffer.QueuedExecutions()[0].EstimatedStartTime, 5*time.Second)
+	require.Equal(t, time.Hour, buffer.EnqueuedExecutions()[0].Job.Spec.GetTimeout())
+}
+
+// publishingExecutor publishes one result at a time, each until it is released.
+type publishingExecutor struct {
+	blockingExecutor
+	published chan string
+}
+
+func (e *publishingExecutor) Publish(_ context.Context, execution store.Execution) error {
+	e.published <- execution.ID
+	<-e.release
+	return nil
+}
+
+func TestExecutorBufferPublishOrder(t *testing.T) {
+	ctx := context.Background()
+	delegate := &publishingExecutor{
+		blockingExecutor: blockingExecutor{release: make(chan struct{})},
+		published:        make(chan string),
+	}
+	resultSizes := map[string]uint64{"first": 100, "large": 50, "small": 10, "important": 1000}
+	buffer := compute.NewExecutorBuffer(compute.ExecutorBufferParams{
+		ID:                      "testNodeID",
+		DelegateExecutor:        delegate,
+		Callback:                compute.CallbackMock{},
+		RunningCapacityTracker:  capacity.NewLocalTracker(capacity.LocalTrackerParams{}),
+		EnqueuedCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{}),
+		MaxConcurrentPublishes:  1,
+		ResultSize: func(_ context.Context, execution store.Execution) (uint64, error) {
+			return resultSizes[execution.ID], nil
+		},
+	})
+
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "first", time.Hour, 0)))
+	require.Equal(t, "first", <-delegate.published)
+
+	// results enqueued while the first one is being published wait for it
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "large", time.Hour, 0)))
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "small", time.Hour, 0)))
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "important", time.Hour, 1)))
+
+	for _, expected := range []string{"important", "small", "large"} {
+		delegate.release <- struct{}{}
+		require.Equal(t, expected, <-delegate.published)
+	}
+	delegate.release <- struct{}{}
+}
+
+func TestExecutorBufferCancelEnqueued(t *testing.T) {
+	ctx := context.Background()
+	delegate := &blockingExecutor{release: make(chan struct{})}
+	defer close(delegate.release)
+	buffer := newTestExecutorBuffer(delegate, false)
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "running", time.Hour, 0)))
+	canceled := newTestExecution(t, "canceled", time.Hour, 0)
+	require.NoError(t, buffer.Run(ctx, canceled))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "next", time.Hour, 0)))
+
+	require.NoError(t, buffer.Cancel(ctx, canceled))
+	queue := buffer.QueuedExecutions()
+	require.Len(t, queue, 1)
+	require.Equal(t, "next", queue[0].ExecutionID)
+
+	// the canceled execution does not start once capacity frees up
+	delegate.release <- struct{}{}
+	require.Eventually(t, func() bool {
+		running := buffer.RunningExecutions()
+		return len(running) == 1 && running[0].ID == "next"
+	}, time.Second, 10*time.Millisecond)
+}
+
+// laneExecutor runs and publishes executions until they are released, with runs and publishes released separately.
+type laneExecutor struct {
+	blockingExecutor
+	published      chan string
+	releasePublish chan struct{}
+}
+
+func (e *laneExecutor) Publish(_ context.Context, execution store.Execution) error {
+	e.published <- execution.ID
+	<-e.releasePublish
+	return nil
+}
+
+func TestExecutorBufferLanes(t *testing.T) {
+	ctx := context.Background()
+	delegate := &laneExecutor{
+		blockingExecutor: blockingExecutor{release: make(chan struct{})},
+		published:        make(chan string),
+		releasePublish:   make(chan struct{}),
+	}
+	buffer := compute.NewExecutorBuffer(compute.ExecutorBufferParams{
+		ID:               "testNodeID",
+		DelegateExecutor: delegate,
+		Callback:         compute.CallbackMock{},
+		RunningCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 1},
+		}),
+		EnqueuedCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 10},
+		}),
+		DefaultJobExecutionTimeout: time.Hour,
+		MaxConcurrentPublishes:     1,
+	})
+
+	// executions waiting for capacity do not hold up publishing
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "running", time.Hour, 0)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution(t, "waiting", time.Hour, 0)))
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "publishing", time.Hour, 0)))
+	require.Equal(t, "publishing", <-delegate.published)
+
+	require.NoError(t, buffer.Publish(ctx, newTestExecution(t, "next", time.Hour, 0)))
+	require.Equal(t, map[string]int{
+		compute.ExecutionLane: 1,
+		compute.PublishLane:   1,
+		compute.CancelLane:    0,
+	}, buffer.LaneQueueLengths())
+
+	// and publishing does not hold up executions
+	delegate.release <- struct{}{}
+	require.Eventually(t, func() bool {
+		return buffer.LaneQueueLengths()[compute.ExecutionLane] == 0
+	}, time.Second, 10*time.Millisecond)
+
+	delegate.releasePublish <- struct{}{}
+	require.Equal(t, "next", <-delegate.published)
+	close(delegate.releasePublish)
+	close(delegate.release)
+}
@@ -0,0 +1,41 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFolder(t *testing.T) {
+	resultFolder := t.TempDir()
+	folder, err := outputFolder(resultFolder, "outputs")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(resultFolder, "outputs"), folder)
+
+	for _, name := range []string{"", ".", "..", "../..", "/etc", "a/b", `a\b`} {
+		_, err = outputFolder(resultFolder, name)
+		require.Error(t, err, name)
+	}
+
+	require.NoError(t, checkOutputNames([]model.StorageSpec{{Name: "outputs"}, {Name: "logs"}}))
+	require.Error(t, checkOutputNames([]model.StorageSpec{{Name: "outputs"}, {Name: ".."}}))
+}
+
+func TestPublishOrderAgesWaitingResults(t *testing.T) {
+	newTask := func(priority int, resultSize uint64) *publishTask {
+		task := &publishTask{resultSize: resultSize}
+		task.execution.Job.Spec.Priority = priority
+		return task
+	}
+	low, high := newTask(0, 10), newTask(2, 10)
+
+	require.True(t, publishOrder(high, low, 0, 0))
+	require.True(t, publishOrder(high, low, 0, publishAgingInterval))
+	require.False(t, publishOrder(low, high, 2*publishAgingInterval, 0), "equal priorities publish smaller results first")
+	require.True(t, publishOrder(low, high, 3*publishAgingInterval, 0), "results that waited long enough overtake higher priorities")
+	require.True(t, publishOrder(newTask(0, 1), low, 0, 0))
+}
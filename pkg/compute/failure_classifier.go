@@ -0,0 +1,121 @@
+package compute
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// maxEvidenceLength is the longest line of output kept as the evidence of a failure reason.
+const maxEvidenceLength = 200
+
+type failureRule struct {
+	category model.FailureCategory
+	message  string
+	hint     string
+	pattern  *regexp.Regexp
+}
+
+// failureRules are matched in order against each line of the output of a failed execution, so the more specific
+// causes come first.
+var failureRules = []failureRule{
+	{
+		category: model.FailureCUDAVersionMismatch,
+		message:  "The CUDA version the job was built with is not supported by the GPU driver of the compute node.",
+		hint:     "Use an image built for an older CUDA version, or target nodes with a newer GPU driver with --selector.",
+		pattern: regexp.MustCompile(`(?i)CUDA driver version is insufficient|forward compatibility was attempted|` +
+			`no kernel image is available for execution|nvidia-container-cli: requirement error|` +
+			`unsatisfied condition: cuda`),
+	},
+	{
+		category: model.FailureOutOfMemory,
+		message:  "The job ran out of memory.",
+		hint: "Request more memory with --memory, or a GPU with more memory if the error came from CUDA, " +
+			"or process the data in smaller chunks.",
+		pattern: regexp.MustCompile(`(?i)OOMKilled|out of memory|cannot allocate memory|\bMemoryError\b|` +
+			`std::bad_alloc|java\.lang\.OutOfMemoryError`),
+	},
+	{
+		category: model.FailureModuleImport,
+		message:  "A module or library the job imports is missing from its image.",
+		hint:     "Install the missing module in the image, or use an image that already has it, and check its name.",
+		pattern: regexp.MustCompile(`ModuleNotFoundError: No module named|ImportError: |Cannot find module '|` +
+			`cannot open shared object file|cannot load such file --|there is no package called`),
+	},
+}
+
+var permissionDeniedPattern = regexp.MustCompile(`(?i)permission denied|read-only file system|EACCES|EROFS`)
+
+// ClassifyFailure recognises the likely cause of a failed execution of the job from its result and error, so that
+// users can tell why it failed without reading through its output. It returns nil if the execution did not fail,
+// or if the cause is not one it knows.
+func ClassifyFailure(job model.Job, result *model.RunCommandResult, err error) *model.FailureReason {
+	var output []string
+	exitCode := 0
+	if result != nil {
+		output = append(output, result.STDERR, result.ErrorMsg)
+		exitCode = result.ExitCode
+	}
+	if err != nil {
+		output = append(output, err.Error())
+	}
+	if err == nil && exitCode == 0 {
+		return nil
+	}
+	// processes killed for other reasons, such as timing out, exit with the same code, so only trust the executor
+	if result != nil && result.OOMKilled {
+		return newFailureReason(model.FailureOutOfMemory,
+			"The job was killed as it ran out of memory.",
+			"Request more memory with --memory, or process the data in smaller chunks.",
+			"")
+	}
+	lines := strings.Split(strings.Join(output, "\n"), "\n")
+
+	for _, rule := range failureRules {
+		for _, line := range lines {
+			if rule.pattern.MatchString(line) {
+				return newFailureReason(rule.category, rule.message, rule.hint, line)
+			}
+		}
+	}
+
+	mounts := mountPaths(job)
+	for _, line := range lines {
+		if !permissionDeniedPattern.MatchString(line) {
+			continue
+		}
+		for _, mount := range mounts {
+			if strings.Contains(line, mount) {
+				return newFailureReason(model.FailureMountPermissionDenied,
+					fmt.Sprintf("The job was denied access to the volume mounted at %s.", mount),
+					"Inputs are mounted read-only, so write results to an output volume instead. "+
+						"If the image runs as a user other than root, make sure that user can write to the output volumes.",
+					line)
+			}
+		}
+	}
+	return nil
+}
+
+func newFailureReason(category model.FailureCategory, message, hint, evidence string) *model.FailureReason {
+	evidence = strings.TrimSpace(evidence)
+	if len(evidence) > maxEvidenceLength {
+		evidence = evidence[:maxEvidenceLength] + "..."
+	}
+	return &model.FailureReason{Category: category, Message: message, Hint: hint, Evidence: evidence}
+}
+
+// mountPaths returns the paths the input and output volumes of the job are mounted at.
+func mountPaths(job model.Job) []string {
+	var paths []string
+	for _, volumes := range [][]model.StorageSpec{job.Spec.Inputs, job.Spec.Outputs} {
+		for _, volume := range volumes {
+			if path := strings.TrimSuffix(volume.Path, "/"); path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,108 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	job := model.Job{Spec: model.Spec{
+		Inputs:  []model.StorageSpec{{Path: "/inputs"}},
+		Outputs: []model.StorageSpec{{Name: "outputs", Path: "/outputs/"}},
+	}}
+
+	for name, test := range map[string]struct {
+		result   *model.RunCommandResult
+		err      error
+		category model.FailureCategory
+		evidence string
+	}{
+		"oom killed": {
+			result:   &model.RunCommandResult{ExitCode: 137, OOMKilled: true},
+			category: model.FailureOutOfMemory,
+		},
+		"killed by the timeout": {
+			result: &model.RunCommandResult{ExitCode: 137},
+		},
+		"python memory error": {
+			result:   &model.RunCommandResult{ExitCode: 1, STDERR: "Traceback (most recent call last):\nMemoryError\n"},
+			category: model.FailureOutOfMemory,
+			evidence: "MemoryError",
+		},
+		"cuda out of memory": {
+			result:   &model.RunCommandResult{ExitCode: 1, STDERR: "RuntimeError: CUDA out of memory. Tried to allocate 2.00 GiB"},
+			category: model.FailureOutOfMemory,
+			evidence: "RuntimeError: CUDA out of memory. Tried to allocate 2.00 GiB",
+		},
+		"cuda driver too old": {
+			result: &model.RunCommandResult{ExitCode: 1,
+				STDERR: "RuntimeError: CUDA driver version is insufficient for CUDA runtime version"},
+			category: model.FailureCUDAVersionMismatch,
+		},
+		"cuda requirement of the image": {
+			err: errors.New("failed to start container: nvidia-container-cli: requirement error: " +
+				"unsatisfied condition: cuda>=12.2, please update your driver to a newer version"),
+			category: model.FailureCUDAVersionMismatch,
+		},
+		"missing python module": {
+			result: &model.RunCommandResult{ExitCode: 1,
+				STDERR: "Traceback (most recent call last):\n  File \"main.py\", line 1\nModuleNotFoundError: No module named 'numpy'\n"},
+			category: model.FailureModuleImport,
+			evidence: "ModuleNotFoundError: No module named 'numpy'",
+		},
+		"missing node module": {
+			result:   &model.RunCommandResult{ExitCode: 1, STDERR: "Error: Cannot find module 'express'"},
+			category: model.FailureModuleImport,
+		},
+		"write to input": {
+			result:   &model.RunCommandResult{ExitCode: 1, STDERR: "touch: cannot touch '/inputs/result.txt': Read-only file system"},
+			category: model.FailureMountPermissionDenied,
+		},
+		"write to output": {
+			result: &model.RunCommandResult{ExitCode: 1,
+				STDERR: "PermissionError: [Errno 13] Permission denied: '/outputs/result.csv'"},
+			category: model.FailureMountPermissionDenied,
+		},
+		"permission denied elsewhere": {
+			result: &model.RunCommandResult{ExitCode: 1, STDERR: "open /etc/shadow: permission denied"},
+		},
+		"unknown cause": {
+			result: &model.RunCommandResult{ExitCode: 2, STDERR: "usage: main.py [-h]"},
+		},
+		"success": {
+			result: &model.RunCommandResult{ExitCode: 0, STDERR: "warning: out of memory soon"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reason := ClassifyFailure(job, test.result, test.err)
+			if test.category == "" {
+				require.Nil(t, reason)
+				return
+			}
+			require.NotNil(t, reason)
+			require.Equal(t, test.category, reason.Category)
+			require.NotEmpty(t, reason.Message)
+			require.NotEmpty(t, reason.Hint)
+			if test.evidence != "" {
+				require.Equal(t, test.evidence, reason.Evidence)
+			}
+		})
+	}
+}
+
+func TestClassifyFailureMountPath(t *testing.T) {
+	job := model.Job{Spec: model.Spec{Outputs: []model.StorageSpec{{Name: "outputs", Path: "/outputs"}}}}
+	reason := ClassifyFailure(job, &model.RunCommandResult{
+		ExitCode: 1,
+		STDERR:   strings.Repeat("x", 300) + " /outputs/a: Permission denied",
+	}, nil)
+	require.NotNil(t, reason)
+	require.Contains(t, reason.Message, "/outputs")
+	require.Len(t, reason.Evidence, maxEvidenceLength+len("..."))
+}
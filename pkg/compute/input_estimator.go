@@ -0,0 +1,134 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/multierr"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+)
+
+type StorageInputEstimatorParams struct {
+	Storages storage.StorageProvider
+	// Timeout is how long each input is given to be resolved before it is considered unavailable.
+	Timeout time.Duration
+	// FetchRate is the number of bytes per second the node expects to fetch inputs at.
+	FetchRate uint64
+}
+
+// StorageInputEstimator probes the inputs of jobs with their storage, without fetching them. Only inputs that are
+// found not to exist make the estimate fail, as other errors can be transient and are retried when fetching. Fetching
+// is estimated to take as long as the slowest input took to resolve, plus the time to transfer all inputs at the
+// fetch rate. URL inputs are not probed, as the requester checks them once when the job is submitted, and they
+// take as long to fetch from any node.
+type StorageInputEstimator struct {
+	storages  storage.StorageProvider
+	timeout   time.Duration
+	fetchRate uint64
+}
+
+func NewStorageInputEstimator(params StorageInputEstimatorParams) *StorageInputEstimator {
+	return &StorageInputEstimator{
+		storages:  params.Storages,
+		timeout:   params.Timeout,
+		fetchRate: params.FetchRate,
+	}
+}
+
+// EstimateInputs implements InputEstimator
+func (e *StorageInputEstimator) EstimateInputs(ctx context.Context, inputs []model.StorageSpec) (InputEstimate, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		estimate = InputEstimate{Known: true}
+		latency  time.Duration
+		errs     error
+	)
+	for _, input := range inputs {
+		if input.StorageSource == model.StorageSourceURLDownload {
+			continue
+		}
+		wg.Add(1)
+		go func(input model.StorageSpec) {
+			defer wg.Done()
+			size, took, known, err := e.probe(ctx, input)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("input %s is not available: %w", describeInput(input), err))
+				return
+			}
+			estimate.Size += size
+			estimate.Known = estimate.Known && known
+			if took > latency {
+				latency = took
+			}
+		}(input)
+	}
+	wg.Wait()
+	if errs != nil {
+		return InputEstimate{}, errs
+	}
+
+	estimate.Duration = latency
+	if e.fetchRate > 0 {
+		estimate.Duration += time.Duration(float64(estimate.Size) / float64(e.fetchRate) * float64(time.Second))
+	}
+	return estimate, nil
+}
+
+// probe returns the size of an input that has to be fetched and how long it took to resolve, which are zero if the
+// input is already local, and whether its size is known.
+func (e *StorageInputEstimator) probe(ctx context.Context, input model.StorageSpec) (uint64, time.Duration, bool, error) {
+	s, err := e.storages.Get(ctx, input.StorageSource)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	local, err := s.HasStorageLocally(ctx, input)
+	if err == nil && local {
+		return 0, 0, true, nil
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	start := time.Now()
+	size, err := storage.Probe(probeCtx, s, input)
+	took := time.Since(start)
+	switch {
+	case err == nil:
+		// storages size inputs they can't size without fetching them as zero
+		return size, took, size > 0, nil
+	case probeCtx.Err() != nil && ctx.Err() == nil:
+		return 0, took, false, fmt.Errorf("%w: not resolved within %s", storage.ErrInputNotFound, e.timeout)
+	case errors.Is(err, storage.ErrInputNotFound):
+		return 0, took, false, err
+	default:
+		log.Ctx(ctx).Debug().Err(err).Msgf("failed to probe input %s, assuming it is available", describeInput(input))
+		return 0, took, false, nil
+	}
+}
+
+// describeInput names an input by where it comes from.
+func describeInput(input model.StorageSpec) string {
+	switch {
+	case input.CID != "":
+		return input.CID
+	case input.URL != "":
+		return input.URL
+	case input.Repo != "":
+		return input.Repo
+	case input.S3 != nil:
+		return fmt.Sprintf("s3://%s/%s", input.S3.Bucket, input.S3.Key)
+	case input.Name != "":
+		return input.Name
+	default:
+		return input.StorageSource.String()
+	}
+}
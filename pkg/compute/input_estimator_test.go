@@ -0,0 +1,106 @@
+//go:build unit || !integration
+
+package compute_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	noop_storage "github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+)
+
+func newTestInputEstimator(hooks noop_storage.StorageConfigExternalHooks) *compute.StorageInputEstimator {
+	noopStorage := noop_storage.NewNoopStorageWithConfig(noop_storage.StorageConfig{ExternalHooks: hooks})
+	return compute.NewStorageInputEstimator(compute.StorageInputEstimatorParams{
+		Storages:  model.NewNoopProvider[model.StorageSourceType, storage.Storage](noopStorage),
+		Timeout:   50 * time.Millisecond,
+		FetchRate: 1024,
+	})
+}
+
+func TestStorageInputEstimator(t *testing.T) {
+	estimator := newTestInputEstimator(noop_storage.StorageConfigExternalHooks{
+		HasStorageLocally: func(_ context.Context, volume model.StorageSpec) (bool, error) {
+			return volume.CID == "local", nil
+		},
+		GetVolumeSize: func(_ context.Context, volume model.StorageSpec) (uint64, error) {
+			return 2048, nil
+		},
+	})
+
+	estimate, err := estimator.EstimateInputs(context.Background(), []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, CID: "local"},
+		{StorageSource: model.StorageSourceIPFS, CID: "remote1"},
+		{StorageSource: model.StorageSourceIPFS, CID: "remote2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4096), estimate.Size)
+	require.True(t, estimate.Known)
+	require.GreaterOrEqual(t, estimate.Duration, 4*time.Second)
+	require.Less(t, estimate.Duration, 5*time.Second)
+}
+
+func TestStorageInputEstimatorUnresolvedInput(t *testing.T) {
+	estimator := newTestInputEstimator(noop_storage.StorageConfigExternalHooks{
+		HasStorageLocally: func(context.Context, model.StorageSpec) (bool, error) {
+			return false, nil
+		},
+		GetVolumeSize: func(ctx context.Context, volume model.StorageSpec) (uint64, error) {
+			if volume.CID == "missing" {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return 0, nil
+		},
+	})
+
+	_, err := estimator.EstimateInputs(context.Background(), []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, CID: "present"},
+		{StorageSource: model.StorageSourceIPFS, CID: "missing"},
+	})
+	require.ErrorIs(t, err, storage.ErrInputNotFound)
+	require.ErrorContains(t, err, "input missing is not available")
+}
+
+func TestStorageInputEstimatorIgnoresTransientErrors(t *testing.T) {
+	estimator := newTestInputEstimator(noop_storage.StorageConfigExternalHooks{
+		HasStorageLocally: func(context.Context, model.StorageSpec) (bool, error) {
+			return false, nil
+		},
+		GetVolumeSize: func(context.Context, model.StorageSpec) (uint64, error) {
+			return 0, errors.New("401 Unauthorized")
+		},
+	})
+
+	estimate, err := estimator.EstimateInputs(context.Background(), []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, CID: "QmData"},
+	})
+	require.NoError(t, err)
+	require.Zero(t, estimate.Size)
+	require.False(t, estimate.Known, "inputs that fail to be probed have an unknown size")
+}
+
+func TestStorageInputEstimatorSkipsURLInputs(t *testing.T) {
+	estimator := newTestInputEstimator(noop_storage.StorageConfigExternalHooks{
+		HasStorageLocally: func(context.Context, model.StorageSpec) (bool, error) {
+			return false, nil
+		},
+		GetVolumeSize: func(context.Context, model.StorageSpec) (uint64, error) {
+			require.Fail(t, "URL inputs are probed by the requester")
+			return 0, nil
+		},
+	})
+
+	estimate, err := estimator.EstimateInputs(context.Background(), []model.StorageSpec{
+		{StorageSource: model.StorageSourceURLDownload, URL: "http://example.com/data"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, compute.InputEstimate{Known: true}, estimate)
+}
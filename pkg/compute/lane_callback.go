@@ -0,0 +1,71 @@
+package compute
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+type LaneCallbackParams struct {
+	Callback Callback
+	// MaxConcurrent is the maximum number of results delivered at once. There is no limit if zero.
+	MaxConcurrent int
+}
+
+// LaneCallback is a Callback that delivers the results of executions to be verified, and the results that have been
+// published, from a lane of their own. Delivering a result can take as long as dialing the requester, so this keeps
+// executions from holding on to their capacity while their results are delivered, and results from waiting behind
+// executions. Other callbacks are delivered right away.
+type LaneCallback struct {
+	callback Callback
+	lane     *workLane[func()]
+}
+
+func NewLaneCallback(params LaneCallbackParams) *LaneCallback {
+	return &LaneCallback{
+		callback: params.Callback,
+		lane: newWorkLane(CallbackLane, params.MaxConcurrent, nil, func(deliver func()) {
+			deliver()
+		}),
+	}
+}
+
+// detach returns a context that keeps the logger of ctx but not its deadline, as results are delivered after the
+// work that produced them is done.
+func detach(ctx context.Context) context.Context {
+	return log.Ctx(ctx).WithContext(context.Background())
+}
+
+// QueueLength returns the number of results waiting to be delivered.
+func (c *LaneCallback) QueueLength() int {
+	return c.lane.len()
+}
+
+func (c *LaneCallback) OnBidComplete(ctx context.Context, result BidResult) {
+	c.callback.OnBidComplete(ctx, result)
+}
+
+func (c *LaneCallback) OnRunComplete(ctx context.Context, result RunResult) {
+	ctx = detach(ctx)
+	c.lane.enqueue(func() {
+		c.callback.OnRunComplete(ctx, result)
+	})
+}
+
+func (c *LaneCallback) OnPublishComplete(ctx context.Context, result PublishResult) {
+	ctx = detach(ctx)
+	c.lane.enqueue(func() {
+		c.callback.OnPublishComplete(ctx, result)
+	})
+}
+
+func (c *LaneCallback) OnCancelComplete(ctx context.Context, result CancelResult) {
+	c.callback.OnCancelComplete(ctx, result)
+}
+
+func (c *LaneCallback) OnComputeFailure(ctx context.Context, err ComputeError) {
+	c.callback.OnComputeFailure(ctx, err)
+}
+
+// compile-time interface check
+var _ Callback = (*LaneCallback)(nil)
@@ -0,0 +1,56 @@
+//go:build unit || !integration
+
+package compute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+)
+
+func TestLaneCallback(t *testing.T) {
+	ctx := context.Background()
+	delivered := make(chan string)
+	release := make(chan struct{})
+	var failures []string
+	callback := compute.NewLaneCallback(compute.LaneCallbackParams{
+		Callback: compute.CallbackMock{
+			OnRunCompleteHandler: func(_ context.Context, result compute.RunResult) {
+				delivered <- result.ExecutionID
+				<-release
+			},
+			OnPublishCompleteHandler: func(_ context.Context, result compute.PublishResult) {
+				delivered <- result.ExecutionID
+				<-release
+			},
+			OnComputeFailureHandler: func(_ context.Context, err compute.ComputeError) {
+				failures = append(failures, err.ExecutionID)
+			},
+		},
+		MaxConcurrent: 1,
+	})
+
+	// results are delivered in the background, one at a time
+	callback.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "run"}})
+	require.Equal(t, "run", <-delivered)
+	callback.OnPublishComplete(ctx, compute.PublishResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "publish"}})
+	require.Equal(t, 1, callback.QueueLength())
+
+	// other callbacks are delivered right away
+	callback.OnComputeFailure(ctx, compute.ComputeError{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "failed"}})
+	require.Equal(t, []string{"failed"}, failures)
+
+	release <- struct{}{}
+	select {
+	case executionID := <-delivered:
+		require.Equal(t, "publish", executionID)
+	case <-time.After(time.Second):
+		require.Fail(t, "the published result was not delivered")
+	}
+	require.Equal(t, 0, callback.QueueLength())
+	close(release)
+}
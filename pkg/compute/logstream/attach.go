@@ -0,0 +1,148 @@
+package logstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/rs/zerolog/log"
+)
+
+// HandleAttach attaches the stream to a running execution of an interactive job. What is written to the stream after
+// the request goes to the stdin of the execution, and its output is written back as sequenced dataframes.
+func (s *LogStreamServer) HandleAttach(stream network.Stream) {
+	log.Ctx(s.ctx).Debug().Msg("Handling new attach request")
+
+	defer stream.Close()
+
+	request := AttachRequest{}
+	decoder := json.NewDecoder(stream)
+	err := decoder.Decode(&request)
+	if err != nil {
+		log.Ctx(s.ctx).Error().Msgf("error decoding %s: %s", reflect.TypeOf(request), err)
+		_ = stream.Reset()
+		return
+	}
+
+	execution, err := s.executionStore.GetExecution(s.ctx, request.ExecutionID)
+	if err != nil {
+		log.Ctx(s.ctx).Error().Msgf("error retrieving execution: %s", request.ExecutionID)
+		_ = stream.Reset()
+		return
+	}
+
+	// Only the requester of the job, which checked the client attaching is the one that submitted it, can write to
+	// the stdin of its executions.
+	if remote := stream.Conn().RemotePeer().String(); remote != execution.Job.Metadata.Requester.RequesterNodeID {
+		log.Ctx(s.ctx).Warn().Msgf("refusing to attach peer %s to execution %s, which is not its requester", remote, execution.ID)
+		_ = stream.Reset()
+		return
+	}
+
+	if execution.State.IsTerminal() || !execution.Job.Spec.Interactive {
+		log.Ctx(s.ctx).Error().Msgf("cannot attach to execution %s, which ended or is not interactive", execution.ID)
+		_ = stream.Reset()
+		return
+	}
+
+	e, err := s.executors.Get(s.ctx, execution.Job.Spec.Engine)
+	if err != nil {
+		log.Ctx(s.ctx).Error().Msgf("failed to find executor for engine: %s", execution.Job.Spec.Engine)
+		_ = stream.Reset()
+		return
+	}
+	interactive, ok := e.(executor.InteractiveExecutor)
+	if !ok {
+		log.Ctx(s.ctx).Error().Msgf("executor for engine %s does not run interactive jobs", execution.Job.Spec.Engine)
+		_ = stream.Reset()
+		return
+	}
+
+	stdin, muxed, err := interactive.Attach(s.ctx, execution.ID)
+	if err != nil {
+		log.Ctx(s.ctx).Error().Err(err).Msgf("failed to attach to execution %s", execution.ID)
+		_ = stream.Reset()
+		return
+	}
+	output := streamOutput(stream, muxed)
+	defer output.Close()
+
+	go func() {
+		// the decoder may have read some of the input along with the request
+		_, copyErr := io.Copy(stdin, io.MultiReader(decoder.Buffered(), stream))
+		if copyErr != nil {
+			log.Ctx(s.ctx).Debug().Err(copyErr).Msgf("stopped writing to stdin of execution %s", execution.ID)
+		}
+		_ = stdin.Close()
+	}()
+
+	_, err = io.Copy(stream, output)
+	if err != nil {
+		log.Ctx(s.ctx).Error().Msgf("problem reading from executor streams: %s", err)
+	}
+}
+
+// AttachClient attaches to a running execution of an interactive job through the log server of the compute node
+// running it.
+type AttachClient struct {
+	stream    network.Stream
+	connected bool
+}
+
+// NewAttachClient creates a new client communicating with the provided multiaddr string. The stream is opened from
+// the host of the requester of the job, as the log server only attaches the requester of the job to its executions.
+func NewAttachClient(ctx context.Context, requesterHost host.Host, address string) (*AttachClient, error) {
+	stream, err := openStreamFrom(ctx, requesterHost, address, AttachProtocolID, AttachProtocolIDV1)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachClient{stream: stream}, nil
+}
+
+// Connect sends the request attaching to the execution.
+func (c *AttachClient) Connect(ctx context.Context, executionID string) error {
+	if c.connected {
+		return fmt.Errorf("attach client is already connected")
+	}
+
+	err := json.NewEncoder(c.stream).Encode(AttachRequest{ExecutionID: executionID})
+	if err != nil {
+		return fmt.Errorf("attach client failed to encode initial request when connecting: %s", err)
+	}
+
+	c.connected = true
+	return nil
+}
+
+// Write writes to the stdin of the execution.
+func (c *AttachClient) Write(p []byte) (int, error) {
+	if !c.connected {
+		return 0, fmt.Errorf("attach client connection state is %t", c.connected)
+	}
+	return c.stream.Write(p)
+}
+
+// CloseStdin closes the stdin of the execution, while its output can still be read.
+func (c *AttachClient) CloseStdin() error {
+	return c.stream.CloseWrite()
+}
+
+// ReadDataFrame reads a single dataframe of the output of the execution.
+func (c *AttachClient) ReadDataFrame(ctx context.Context) (logframe.DataFrame, error) {
+	if !c.connected {
+		return logframe.EmptyDataFrame, fmt.Errorf("attach client connection state is %t", c.connected)
+	}
+	return readDataFrame(c.stream)
+}
+
+// Close will close the underlying stream and resources in-use.
+func (c *AttachClient) Close() {
+	c.connected = false
+	c.stream.Close()
+}
@@ -0,0 +1,112 @@
+//go:build unit || !integration
+
+package logstream
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/stretchr/testify/require"
+)
+
+// attachableExecutor writes the same output to whoever attaches to it.
+type attachableExecutor struct {
+	*noop.NoopExecutor
+	output []byte
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func (e attachableExecutor) Attach(context.Context, string) (io.WriteCloser, io.ReadCloser, error) {
+	return nopWriteCloser{io.Discard}, io.NopCloser(bytes.NewReader(e.output)), nil
+}
+
+func newTestHost(t *testing.T) host.Host {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.DisableRelay())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+// newTestAttachServer serves the output of an interactive execution whose requester is requesterHost.
+func newTestAttachServer(t *testing.T, requesterHost host.Host) (*LogStreamServer, string) {
+	ctx := context.Background()
+	job := model.Job{Metadata: model.Metadata{
+		ID:        "job-1",
+		Requester: model.JobRequester{RequesterNodeID: requesterHost.ID().String()},
+	}}
+	job.Spec.Engine = model.EngineDocker
+	job.Spec.Interactive = true
+	executionStore := inmemory.NewStore()
+	execution := store.NewExecution("execution-1", job, requesterHost.ID().String(), model.ResourceUsageData{})
+	require.NoError(t, executionStore.CreateExecution(ctx, *execution))
+
+	server := NewLogStreamServer(LogStreamServerOptions{
+		Ctx:            ctx,
+		Host:           newTestHost(t),
+		ExecutionStore: executionStore,
+		Executors: model.NewMappedProvider(map[model.Engine]executor.Executor{
+			model.EngineDocker: attachableExecutor{
+				NoopExecutor: noop.NewNoopExecutor(),
+				output:       logframe.NewDataFrameFromData(logframe.StdoutStreamTag, []byte("hello")).ToMuxedBytes(),
+			},
+		}),
+	})
+	return server, execution.ID
+}
+
+func TestAttachOnlyFromRequester(t *testing.T) {
+	ctx := context.Background()
+	requesterHost := newTestHost(t)
+	strangerHost := newTestHost(t)
+	server, executionID := newTestAttachServer(t, requesterHost)
+
+	client, err := NewAttachClient(ctx, requesterHost, server.Address)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Connect(ctx, executionID))
+	frame, err := client.ReadDataFrame(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), frame.Data)
+	require.Equal(t, uint64(1), frame.Seq)
+
+	stranger, err := NewAttachClient(ctx, strangerHost, server.Address)
+	require.NoError(t, err)
+	defer stranger.Close()
+	require.NoError(t, stranger.Connect(ctx, executionID))
+	_, err = stranger.ReadDataFrame(ctx)
+	require.Error(t, err, "peers other than the requester of the job must not be attached")
+}
+
+func TestAttachWithPreviousProtocol(t *testing.T) {
+	ctx := context.Background()
+	requesterHost := newTestHost(t)
+	server, executionID := newTestAttachServer(t, requesterHost)
+
+	// nodes of older versions only speak 1.0.0, which streams the muxed frames of the executors
+	stream, err := openStreamFrom(ctx, requesterHost, server.Address, AttachProtocolIDV1)
+	require.NoError(t, err)
+	client := &AttachClient{stream: stream}
+	defer client.Close()
+	require.NoError(t, client.Connect(ctx, executionID))
+	frame, err := client.ReadDataFrame(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), frame.Data)
+	require.Zero(t, frame.Seq)
+}
@@ -11,8 +11,9 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
 
-	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 
 	ma "github.com/multiformats/go-multiaddr"
 )
@@ -28,11 +29,37 @@ type LogStreamClient struct {
 // NewLogStreamClient creates a new client communicating with the
 // provided multiaddr string.
 func NewLogStreamClient(ctx context.Context, address string) (*LogStreamClient, error) {
+	host, stream, err := openStream(ctx, address, LogsProcotolID, LogsProtocolIDV1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogStreamClient{
+		host:      host,
+		stream:    stream,
+		connected: false,
+	}, nil
+}
+
+// openStream opens a stream of the first protocol the server at the provided multiaddr string supports, from a host
+// of its own.
+func openStream(ctx context.Context, address string, protocolIDs ...protocol.ID) (host.Host, network.Stream, error) {
 	host, err := libp2p.New([]libp2p.Option{libp2p.DisableRelay()}...)
 	if err != nil {
-		return nil, fmt.Errorf("logstreamclient failed to create host: %s", err)
+		return nil, nil, fmt.Errorf("logstreamclient failed to create host: %s", err)
 	}
 
+	stream, err := openStreamFrom(ctx, host, address, protocolIDs...)
+	if err != nil {
+		host.Close()
+		return nil, nil, err
+	}
+	return host, stream, nil
+}
+
+// openStreamFrom opens a stream of the first protocol the server at the provided multiaddr string supports, from the
+// host.
+func openStreamFrom(ctx context.Context, host host.Host, address string, protocolIDs ...protocol.ID) (network.Stream, error) {
 	maddr, err := ma.NewMultiaddr(address)
 	if err != nil {
 		return nil, fmt.Errorf("logstreamclient failed to parse address: %s", err)
@@ -48,20 +75,15 @@ func NewLogStreamClient(ctx context.Context, address string) (*LogStreamClient,
 		host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.TempAddrTTL)
 	}
 
-	stream, err := host.NewStream(ctx, info.ID, "/bacalhau/compute/logs/1.0.0")
+	stream, err := host.NewStream(ctx, info.ID, protocolIDs...)
 	if err != nil {
 		return nil, fmt.Errorf("logstreamclient failed to open stream: %s", err)
 	}
-
-	return &LogStreamClient{
-		host:      host,
-		stream:    stream,
-		connected: false,
-	}, nil
+	return stream, nil
 }
 
 // Connect sends the initial request to the logserver before
-func (c *LogStreamClient) Connect(ctx context.Context, executionID string, withHistory bool, follow bool) error {
+func (c *LogStreamClient) Connect(ctx context.Context, executionID string, withHistory bool, follow bool, tail int) error {
 	if c.connected {
 		return fmt.Errorf("logstream client is already connected")
 	}
@@ -70,6 +92,7 @@ func (c *LogStreamClient) Connect(ctx context.Context, executionID string, withH
 		ExecutionID: executionID,
 		WithHistory: withHistory,
 		Follow:      follow,
+		Tail:        tail,
 	}
 
 	err := json.NewEncoder(c.stream).Encode(streamRequest)
@@ -94,18 +117,18 @@ func (c *LogStreamClient) Close() {
 }
 
 // ReadDataFrame reads a single dataframe from the client's stream (if connected)
-func (c *LogStreamClient) ReadDataFrame(ctx context.Context) (logger.DataFrame, error) {
+func (c *LogStreamClient) ReadDataFrame(ctx context.Context) (logframe.DataFrame, error) {
 	if !c.connected {
-		return logger.EmptyDataFrame, fmt.Errorf("logstream client connection state is %t", c.connected)
+		return logframe.EmptyDataFrame, fmt.Errorf("logstream client connection state is %t", c.connected)
 	}
 
-	frame, err := logger.NewDataFrameFromReader(c.stream)
+	frame, err := readDataFrame(c.stream)
 	if err == io.EOF {
-		return logger.EmptyDataFrame, fmt.Errorf("logstreamclient connection closed by peer: %s", err)
+		return logframe.EmptyDataFrame, fmt.Errorf("logstreamclient connection closed by peer: %s", err)
 	}
 
 	if err != nil {
-		return logger.EmptyDataFrame, fmt.Errorf("logstreamclient error reading dataframe: %s", err)
+		return logframe.EmptyDataFrame, fmt.Errorf("logstreamclient error reading dataframe: %s", err)
 	}
 
 	return frame, nil
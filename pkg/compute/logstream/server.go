@@ -9,6 +9,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/multiformats/go-multiaddr"
 
@@ -18,7 +19,12 @@ import (
 )
 
 const (
-	LogsProcotolID = "/bacalhau/compute/logs/1.0.0"
+	// The output of executions is streamed as sequenced logframe frames since 1.1.0, which 1.0.0 streamed as the
+	// frames muxed by the executors. Both are served, so that nodes of older versions can still read the logs.
+	LogsProcotolID     = "/bacalhau/compute/logs/1.1.0"
+	AttachProtocolID   = "/bacalhau/compute/attach/1.1.0"
+	LogsProtocolIDV1   = "/bacalhau/compute/logs/1.0.0"
+	AttachProtocolIDV1 = "/bacalhau/compute/attach/1.0.0"
 )
 
 type LogStreamServerOptions struct {
@@ -37,9 +43,33 @@ func NewLogStreamServer(options LogStreamServerOptions) *LogStreamServer {
 		Address:        findTCPAddress(options.Host),
 	}
 	svr.host.SetStreamHandler(LogsProcotolID, svr.Handle)
+	svr.host.SetStreamHandler(AttachProtocolID, svr.HandleAttach)
+	svr.host.SetStreamHandler(LogsProtocolIDV1, svr.Handle)
+	svr.host.SetStreamHandler(AttachProtocolIDV1, svr.HandleAttach)
 	return svr
 }
 
+// isSequenced returns true if the output of executions is streamed as sequenced frames by the protocol of the stream.
+func isSequenced(stream network.Stream) bool {
+	return stream.Protocol() != LogsProtocolIDV1 && stream.Protocol() != AttachProtocolIDV1
+}
+
+// streamOutput returns the muxed output of an execution as it is streamed by the protocol of the stream.
+func streamOutput(stream network.Stream, muxed io.ReadCloser) io.ReadCloser {
+	if !isSequenced(stream) {
+		return muxed
+	}
+	return logframe.NewSequencer(muxed)
+}
+
+// readDataFrame reads a single frame of the output of an execution, as it is streamed by the protocol of the stream.
+func readDataFrame(stream network.Stream) (logframe.DataFrame, error) {
+	if !isSequenced(stream) {
+		return logframe.ReadMuxedFrame(stream)
+	}
+	return logframe.ReadDataFrame(stream)
+}
+
 func findTCPAddress(host host.Host) string {
 	peerID := host.ID().Pretty()
 	hostAddr, _ := multiaddr.NewMultiaddr(fmt.Sprintf("/p2p/%s", peerID))
@@ -104,12 +134,13 @@ func (s *LogStreamServer) Handle(stream network.Stream) {
 
 	log.Ctx(s.ctx).Debug().Msgf("Logserver getting output stream")
 
-	reader, err := e.GetOutputStream(s.ctx, execution.ID, request.WithHistory, request.Follow)
+	output, err := e.GetOutputStream(s.ctx, execution.ID, request.WithHistory, request.Follow, request.Tail)
 	if err != nil {
 		log.Ctx(s.ctx).Error().Msgf("failed to get output streams from job: %s", execution.Job.ID())
 		_ = stream.Reset()
 		return
 	}
+	reader := streamOutput(stream, output)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -16,8 +16,15 @@ type LogStreamServer struct {
 	executors      executor.ExecutorProvider
 }
 
+// AttachRequest is sent at the start of an attach stream, and is followed by the data for the stdin of the execution.
+type AttachRequest struct {
+	ExecutionID string
+}
+
 type LogStreamRequest struct {
 	ExecutionID string
 	WithHistory bool
 	Follow      bool
+	// Tail is the number of the last lines of the history to send. All of the history is sent if zero.
+	Tail int
 }
@@ -1,6 +1,7 @@
 package compute
 
 import (
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/metric/instrument"
 )
@@ -27,4 +28,20 @@ var (
 		"jobs_failed",
 		instrument.WithDescription("Number of jobs failed by the compute node."),
 	)
+
+	workLaneQueueLength, _ = meter.Int64UpDownCounter(
+		"work_lane_queue_length",
+		instrument.WithDescription("Number of tasks waiting for their turn in a lane of the compute node."),
+	)
+
+	workLaneWait, _ = meter.Float64Histogram(
+		"work_lane_wait_seconds",
+		instrument.WithDescription("Time tasks waited in a lane of the compute node before being run."),
+	)
 )
+
+func laneAttributes(lane string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("lane", lane),
+	}
+}
@@ -0,0 +1,166 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// ErrUnknownAdmin is returned for the admin requests of clients that are not admins of the node.
+var ErrUnknownAdmin = errors.New("admin request submitted by unknown client")
+
+type NodeAdminParams struct {
+	Bidder          Bidder
+	CapacityTracker capacity.Tracker
+	ExecutorBuffer  *ExecutorBuffer
+	// AdminClientIDs are the clients allowed to administer the node. The node can't be administered if empty.
+	AdminClientIDs []string
+	// CachePurgers are called when an admin requests the node's caches to be cleared.
+	CachePurgers []func()
+	// SelfTest is run when an admin requests it. Self-tests are not available if nil.
+	SelfTest *selftest.SelfTest
+	// MaxResourceLimits are the total resource limits the node was started with, which admins can lower the limits
+	// from and raise them back up to.
+	MaxResourceLimits model.ResourceUsageData
+	// JobResourceLimits are the most resources a job can ask for on the node, which the total resource limits can't
+	// be lowered below, so that the jobs the node bids on can still run.
+	JobResourceLimits model.ResourceUsageData
+}
+
+// NodeAdmin applies the changes admins make to a running compute node, whether they are sent to the node itself or
+// forwarded by a requester.
+type NodeAdmin struct {
+	bidder            Bidder
+	capacityTracker   capacity.Tracker
+	executorBuffer    *ExecutorBuffer
+	adminClientIDs    []string
+	cachePurgers      []func()
+	selfTest          *selftest.SelfTest
+	maxResourceLimits model.ResourceUsageData
+	jobResourceLimits model.ResourceUsageData
+}
+
+func NewNodeAdmin(params NodeAdminParams) *NodeAdmin {
+	return &NodeAdmin{
+		bidder:            params.Bidder,
+		capacityTracker:   params.CapacityTracker,
+		executorBuffer:    params.ExecutorBuffer,
+		adminClientIDs:    params.AdminClientIDs,
+		cachePurgers:      params.CachePurgers,
+		selfTest:          params.SelfTest,
+		maxResourceLimits: params.MaxResourceLimits,
+		jobResourceLimits: params.JobResourceLimits,
+	}
+}
+
+// Enabled returns true if the node has admins.
+func (a *NodeAdmin) Enabled() bool {
+	return len(a.adminClientIDs) > 0
+}
+
+// IsAdmin returns true if the client is an admin of the node.
+func (a *NodeAdmin) IsAdmin(clientID string) bool {
+	return slices.Contains(a.adminClientIDs, clientID)
+}
+
+// Apply applies the changes of the admin to the node, and returns the resulting state of the node. No change is
+// applied if any of them is invalid.
+func (a *NodeAdmin) Apply(ctx context.Context, clientID string, changes NodeAdminChanges) (AdminNodeResponse, error) {
+	if !a.IsAdmin(clientID) {
+		return AdminNodeResponse{}, ErrUnknownAdmin
+	}
+	if changes.SelfTest && a.selfTest == nil {
+		return AdminNodeResponse{}, errors.New("self-test is not available on this node")
+	}
+	var limits model.ResourceUsageData
+	if changes.TotalResourceLimits != nil {
+		var err error
+		if limits, err = a.totalResourceLimits(ctx, *changes.TotalResourceLimits); err != nil {
+			return AdminNodeResponse{}, err
+		}
+	}
+
+	if changes.BiddingEnabled != nil {
+		a.bidder.SetBiddingEnabled(*changes.BiddingEnabled)
+	}
+	if changes.TotalResourceLimits != nil {
+		a.capacityTracker.SetMaxCapacity(ctx, limits)
+	}
+	if changes.PurgeCaches {
+		for _, purge := range a.cachePurgers {
+			purge()
+		}
+	}
+
+	var selfTestReport *selftest.Report
+	if changes.SelfTest {
+		report := a.selfTest.Run(ctx)
+		selfTestReport = &report
+	}
+
+	return AdminNodeResponse{
+		BiddingEnabled:     a.bidder.IsBiddingEnabled(),
+		MaxCapacity:        a.capacityTracker.GetMaxCapacity(ctx),
+		AvailableCapacity:  a.capacityTracker.GetAvailableCapacity(ctx),
+		RunningExecutions:  summarize(a.executorBuffer.RunningExecutions()),
+		EnqueuedExecutions: summarize(a.executorBuffer.EnqueuedExecutions()),
+		ExecutionQueue:     a.executorBuffer.QueuedExecutions(),
+		SelfTest:           selfTestReport,
+	}, nil
+}
+
+// totalResourceLimits parses the limits set by the admin, keeping the current limit of the resources that are not
+// set, and checks they are within the limits the node was started with and fit the largest jobs it bids on.
+func (a *NodeAdmin) totalResourceLimits(ctx context.Context, config model.ResourceUsageConfig) (model.ResourceUsageData, error) {
+	var limits model.ResourceUsageData
+	var err error
+	if limits.CPU, err = capacity.ParseCPUString(config.CPU); err != nil {
+		return limits, fmt.Errorf("invalid CPU limit %q: %w", config.CPU, err)
+	}
+	if limits.Memory, err = capacity.ParseBytesString(config.Memory); err != nil {
+		return limits, fmt.Errorf("invalid memory limit %q: %w", config.Memory, err)
+	}
+	if limits.Disk, err = capacity.ParseBytesString(config.Disk); err != nil {
+		return limits, fmt.Errorf("invalid disk limit %q: %w", config.Disk, err)
+	}
+	if config.GPU != "" {
+		if limits.GPU, err = strconv.ParseUint(config.GPU, 10, 64); err != nil {
+			return limits, fmt.Errorf("invalid GPU limit %q: %w", config.GPU, err)
+		}
+	}
+	if config.Fuel != "" {
+		if limits.Fuel, err = strconv.ParseUint(config.Fuel, 10, 64); err != nil {
+			return limits, fmt.Errorf("invalid fuel limit %q: %w", config.Fuel, err)
+		}
+	}
+	if limits.CPU < 0 {
+		return limits, fmt.Errorf("invalid CPU limit %q: must not be negative", config.CPU)
+	}
+
+	limits = limits.Intersect(a.capacityTracker.GetMaxCapacity(ctx))
+	if !limits.LessThanEq(a.maxResourceLimits) {
+		return limits, fmt.Errorf("total resource limits %s exceed the limits %s the node was started with",
+			limits, a.maxResourceLimits)
+	}
+	if !a.jobResourceLimits.LessThanEq(limits) {
+		return limits, fmt.Errorf("total resource limits %s are below the limits %s of the jobs the node bids on",
+			limits, a.jobResourceLimits)
+	}
+	return limits, nil
+}
+
+func summarize(executions []store.Execution) []store.ExecutionSummary {
+	summaries := make([]store.ExecutionSummary, 0, len(executions))
+	for _, execution := range executions {
+		summaries = append(summaries, store.NewExecutionSummary(execution))
+	}
+	return summaries
+}
@@ -0,0 +1,47 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeAdminTotalResourceLimits(t *testing.T) {
+	ctx := context.Background()
+	maxLimits := model.ResourceUsageData{CPU: 8, Memory: 8 << 30, GPU: 2}
+	tracker := capacity.NewLocalTracker(capacity.LocalTrackerParams{MaxCapacity: maxLimits})
+	admin := NewNodeAdmin(NodeAdminParams{
+		CapacityTracker:   tracker,
+		AdminClientIDs:    []string{"admin"},
+		MaxResourceLimits: maxLimits,
+		JobResourceLimits: model.ResourceUsageData{CPU: 2, Memory: 1 << 30},
+	})
+
+	// resources that are not set keep their current limit
+	limits, err := admin.totalResourceLimits(ctx, model.ResourceUsageConfig{CPU: "4"})
+	require.NoError(t, err)
+	require.Equal(t, model.ResourceUsageData{CPU: 4, Memory: 8 << 30, GPU: 2}, limits)
+
+	for name, config := range map[string]model.ResourceUsageConfig{
+		"malformed CPU":    {CPU: "four"},
+		"negative CPU":     {CPU: "-1"},
+		"malformed memory": {Memory: "lots"},
+		"malformed GPU":    {GPU: "-1"},
+		"above maximum":    {CPU: "16"},
+		"below job limits": {CPU: "1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := admin.Apply(ctx, "admin", NodeAdminChanges{TotalResourceLimits: &config})
+			require.Error(t, err)
+			require.Equal(t, maxLimits, tracker.GetMaxCapacity(ctx), "invalid limits are not applied")
+		})
+	}
+
+	_, err = admin.Apply(ctx, "someone", NodeAdminChanges{})
+	require.ErrorIs(t, err, ErrUnknownAdmin)
+}
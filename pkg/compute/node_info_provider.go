@@ -2,15 +2,24 @@ package compute
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
+	"github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/rs/zerolog/log"
 )
 
+// maxPublishedQueuedExecutions is the most executions of the queue of the node published with its info, so that the
+// size of the info gossiped to other nodes does not grow with the queue. The executions next to run are published.
+const maxPublishedQueuedExecutions = 100
+
 type NodeInfoProviderParams struct {
 	Executors          executor.ExecutorProvider
 	Verifiers          verifier.VerifierProvider
@@ -19,6 +28,8 @@ type NodeInfoProviderParams struct {
 	CapacityTracker    capacity.Tracker
 	ExecutorBuffer     *ExecutorBuffer
 	MaxJobRequirements model.ResourceUsageData
+	// Reservations is the capacity held for capacity reservations, which is not reported as available. Optional.
+	Reservations *capacity.Reservations
 }
 
 type NodeInfoProvider struct {
@@ -29,6 +40,7 @@ type NodeInfoProvider struct {
 	capacityTracker    capacity.Tracker
 	executorBuffer     *ExecutorBuffer
 	maxJobRequirements model.ResourceUsageData
+	reservations       *capacity.Reservations
 }
 
 func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
@@ -40,11 +52,12 @@ func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
 		capacityTracker:    params.CapacityTracker,
 		executorBuffer:     params.ExecutorBuffer,
 		maxJobRequirements: params.MaxJobRequirements,
+		reservations:       params.Reservations,
 	}
 }
 
 func (n *NodeInfoProvider) GetComputeInfo(ctx context.Context) model.ComputeNodeInfo {
-	return model.ComputeNodeInfo{
+	info := model.ComputeNodeInfo{
 		ExecutionEngines:   model.InstalledTypes(ctx, n.executors, model.EngineTypes()),
 		Verifiers:          model.InstalledTypes(ctx, n.verifiers, model.VerifierTypes()),
 		Publishers:         model.InstalledTypes(ctx, n.publishers, model.PublisherTypes()),
@@ -55,6 +68,60 @@ func (n *NodeInfoProvider) GetComputeInfo(ctx context.Context) model.ComputeNode
 		RunningExecutions:  len(n.executorBuffer.RunningExecutions()),
 		EnqueuedExecutions: len(n.executorBuffer.EnqueuedExecutions()),
 	}
+	info.ExecutionQueue = n.executorBuffer.QueuedExecutions()
+	if len(info.ExecutionQueue) > maxPublishedQueuedExecutions {
+		info.ExecutionQueue = info.ExecutionQueue[:maxPublishedQueuedExecutions:maxPublishedQueuedExecutions]
+	}
+	if n.reservations != nil {
+		now := time.Now()
+		info.AvailableCapacity = n.reservations.Unreserved(info.AvailableCapacity, "", now)
+		info.ReservedCapacity = n.reservations.Reserved(now)
+	}
+	info.Load = n.load(ctx, info)
+	info.EngineInfo = n.engineInfo(ctx, info.ExecutionEngines)
+	return info
+}
+
+// engineInfo returns the version and features of the installed engines, leaving out the ones that fail to report them.
+func (n *NodeInfoProvider) engineInfo(ctx context.Context, engines []model.Engine) []model.EngineInfo {
+	var infos []model.EngineInfo
+	for _, engine := range engines {
+		info, err := executor.GetEngineInfo(ctx, n.executors, engine)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Stringer("Engine", engine).Msg("not reporting the version of the engine")
+			continue
+		}
+		if info.Version != "" || len(info.Features) > 0 {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// load returns the current load of the node, based on the capacity used by its executions.
+func (n *NodeInfoProvider) load(ctx context.Context, info model.ComputeNodeInfo) *model.NodeLoad {
+	load := &model.NodeLoad{
+		Time:              time.Now().UTC(),
+		RunningExecutions: info.RunningExecutions,
+		QueueDepth:        info.EnqueuedExecutions,
+		CPUUtilization:    utilization(info.MaxCapacity.CPU, info.AvailableCapacity.CPU),
+		MemoryUtilization: utilization(float64(info.MaxCapacity.Memory), float64(info.AvailableCapacity.Memory)),
+	}
+	diskFree, err := system.FreeDiskSpace(config.GetStoragePath())
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("not reporting free disk space in the load of the node")
+	} else {
+		load.DiskFree = diskFree
+	}
+	return load
+}
+
+// utilization is the fraction of the capacity that is not available, from 0 to 1.
+func utilization(capacity, available float64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return math.Min(math.Max(1-available/capacity, 0), 1)
 }
 
 // compile-time interface check
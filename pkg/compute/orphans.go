@@ -0,0 +1,157 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// OrphanPolicy is what the compute node does on startup with the workloads of executions it no longer tracks, which
+// are left behind when it crashes instead of shutting down.
+type OrphanPolicy string
+
+const (
+	// OrphanPolicyReap removes orphaned workloads, running or not.
+	OrphanPolicyReap OrphanPolicy = "reap"
+	// OrphanPolicyAdopt lets running orphaned workloads run to completion while holding the capacity they use, and
+	// removes them once they exit. Workloads that already exited, or that don't fit in the capacity left, are removed.
+	OrphanPolicyAdopt OrphanPolicy = "adopt"
+)
+
+func ParseOrphanPolicy(policy string) (OrphanPolicy, error) {
+	switch OrphanPolicy(policy) {
+	case OrphanPolicyReap, OrphanPolicyAdopt:
+		return OrphanPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown orphan policy %q, expected %q or %q", policy, OrphanPolicyReap, OrphanPolicyAdopt)
+	}
+}
+
+type OrphanReaperParams struct {
+	Executors       executor.ExecutorProvider
+	Store           store.ExecutionStore
+	CapacityTracker capacity.Tracker
+	Policy          OrphanPolicy
+}
+
+// OrphanReaper finds the workloads of executors that the execution store does not track as active, and reaps or
+// adopts them according to its policy.
+type OrphanReaper struct {
+	executors       executor.ExecutorProvider
+	store           store.ExecutionStore
+	capacityTracker capacity.Tracker
+	policy          OrphanPolicy
+}
+
+// OrphanReport is what the reaper did with the orphaned workloads it found.
+type OrphanReport struct {
+	// Reaped are the executions whose workloads were removed.
+	Reaped []string
+	// Adopted are the executions whose workloads are left running until they exit.
+	Adopted []string
+	// ReclaimedDisk is the disk written to by the removed workloads.
+	ReclaimedDisk uint64
+}
+
+func NewOrphanReaper(params OrphanReaperParams) *OrphanReaper {
+	policy := params.Policy
+	if policy == "" {
+		policy = OrphanPolicyReap
+	}
+	return &OrphanReaper{
+		executors:       params.Executors,
+		store:           params.Store,
+		capacityTracker: params.CapacityTracker,
+		policy:          policy,
+	}
+}
+
+// Reap reaps or adopts the orphaned workloads of all executors. Adopted workloads are removed in the background once
+// they exit, unless ctx is canceled first. Executors that fail to list their workloads are skipped.
+func (r *OrphanReaper) Reap(ctx context.Context) OrphanReport {
+	report := OrphanReport{}
+	for _, engine := range model.EngineTypes() {
+		if !r.executors.Has(ctx, engine) {
+			continue
+		}
+		e, err := r.executors.Get(ctx, engine)
+		if err != nil {
+			continue
+		}
+		orphanExecutor, ok := e.(executor.OrphanExecutor)
+		if !ok {
+			continue
+		}
+		workloads, err := orphanExecutor.ListWorkloads(ctx)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to list the workloads of the %s executor", engine)
+			continue
+		}
+		for _, workload := range workloads {
+			if r.isTracked(ctx, workload.ExecutionID) {
+				continue
+			}
+			r.handleOrphan(ctx, orphanExecutor, workload, &report)
+		}
+	}
+
+	if len(report.Reaped) > 0 || len(report.Adopted) > 0 {
+		log.Ctx(ctx).Info().Msgf("Reaped %d orphaned workloads, reclaiming %d bytes of disk, and adopted %d",
+			len(report.Reaped), report.ReclaimedDisk, len(report.Adopted))
+	}
+	return report
+}
+
+// isTracked returns true if the store has the execution, and it hasn't ended. Executions the store can't be asked
+// about are assumed to be tracked, so that their workloads are left alone.
+func (r *OrphanReaper) isTracked(ctx context.Context, executionID string) bool {
+	execution, err := r.store.GetExecution(ctx, executionID)
+	if err != nil {
+		return !errors.As(err, &store.ErrExecutionNotFound{})
+	}
+	return !execution.State.IsTerminal()
+}
+
+func (r *OrphanReaper) handleOrphan(
+	ctx context.Context, orphanExecutor executor.OrphanExecutor, workload executor.Workload, report *OrphanReport) {
+	logger := log.Ctx(ctx).With().Str("ExecutionID", workload.ExecutionID).Logger()
+
+	if r.policy == OrphanPolicyAdopt && workload.Running {
+		if r.capacityTracker.AddIfHasCapacity(ctx, workload.Resources) {
+			report.Adopted = append(report.Adopted, workload.ExecutionID)
+			logger.Info().Msgf("Adopted orphaned workload using %s", workload.Resources)
+			go r.removeOnExit(ctx, orphanExecutor, workload)
+			return
+		}
+		logger.Warn().Msgf("Not enough capacity to adopt orphaned workload using %s", workload.Resources)
+	}
+
+	if err := orphanExecutor.RemoveWorkload(ctx, workload.ExecutionID); err != nil {
+		logger.Error().Err(err).Msg("failed to reap orphaned workload")
+		return
+	}
+	report.Reaped = append(report.Reaped, workload.ExecutionID)
+	report.ReclaimedDisk += workload.Resources.Disk
+	logger.Debug().Msg("Reaped orphaned workload")
+}
+
+// removeOnExit waits for the adopted workload to exit, then removes it and releases the capacity it held.
+func (r *OrphanReaper) removeOnExit(ctx context.Context, orphanExecutor executor.OrphanExecutor, workload executor.Workload) {
+	defer r.capacityTracker.Remove(ctx, workload.Resources)
+	if err := orphanExecutor.WaitWorkload(ctx, workload.ExecutionID); err != nil {
+		if ctx.Err() == nil {
+			log.Ctx(ctx).Error().Err(err).Str("ExecutionID", workload.ExecutionID).Msg("failed to wait for adopted workload")
+		}
+		return
+	}
+	if err := orphanExecutor.RemoveWorkload(ctx, workload.ExecutionID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("ExecutionID", workload.ExecutionID).Msg("failed to remove adopted workload")
+	}
+}
@@ -0,0 +1,137 @@
+//go:build unit || !integration
+
+package compute_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// orphanExecutor has workloads that run until they are released.
+type orphanExecutor struct {
+	*noop.NoopExecutor
+	workloads []executor.Workload
+	release   chan struct{}
+	mu        sync.Mutex
+	removed   []string
+}
+
+func (e *orphanExecutor) ListWorkloads(context.Context) ([]executor.Workload, error) {
+	return e.workloads, nil
+}
+
+func (e *orphanExecutor) WaitWorkload(ctx context.Context, _ string) error {
+	select {
+	case <-e.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *orphanExecutor) RemoveWorkload(_ context.Context, executionID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removed = append(e.removed, executionID)
+	return nil
+}
+
+func (e *orphanExecutor) getRemoved() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.removed...)
+}
+
+func newOrphanReaperFixture(t *testing.T, policy compute.OrphanPolicy) (*compute.OrphanReaper, *orphanExecutor, capacity.Tracker) {
+	ctx := context.Background()
+	executionStore := inmemory.NewStore()
+	running := newTestExecution(t, "running", time.Minute, 0)
+	require.NoError(t, executionStore.CreateExecution(ctx, running))
+	require.NoError(t, executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: running.ID,
+		NewState:    store.ExecutionStateRunning,
+	}))
+	ended := newTestExecution(t, "ended", time.Minute, 0)
+	require.NoError(t, executionStore.CreateExecution(ctx, ended))
+	require.NoError(t, executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: ended.ID,
+		NewState:    store.ExecutionStateCompleted,
+	}))
+
+	e := &orphanExecutor{
+		NoopExecutor: noop.NewNoopExecutor(),
+		workloads: []executor.Workload{
+			{ExecutionID: "running", Running: true, Resources: model.ResourceUsageData{CPU: 1, Disk: 10}},
+			{ExecutionID: "ended", Resources: model.ResourceUsageData{Disk: 20}},
+			{ExecutionID: "orphan-running", Running: true, Resources: model.ResourceUsageData{CPU: 1, Disk: 30}},
+			{ExecutionID: "orphan-large", Running: true, Resources: model.ResourceUsageData{CPU: 4, Disk: 40}},
+			{ExecutionID: "orphan-exited", Resources: model.ResourceUsageData{CPU: 1, Disk: 50}},
+		},
+		release: make(chan struct{}),
+	}
+	tracker := capacity.NewLocalTracker(capacity.LocalTrackerParams{
+		MaxCapacity: model.ResourceUsageData{CPU: 2, Disk: 1000},
+	})
+	reaper := compute.NewOrphanReaper(compute.OrphanReaperParams{
+		Executors:       model.NewMappedProvider(map[model.Engine]executor.Executor{model.EngineDocker: e}),
+		Store:           executionStore,
+		CapacityTracker: tracker,
+		Policy:          policy,
+	})
+	return reaper, e, tracker
+}
+
+func TestOrphanReaperReap(t *testing.T) {
+	ctx := context.Background()
+	reaper, e, tracker := newOrphanReaperFixture(t, compute.OrphanPolicyReap)
+
+	report := reaper.Reap(ctx)
+	require.Equal(t, []string{"ended", "orphan-running", "orphan-large", "orphan-exited"}, report.Reaped)
+	require.Empty(t, report.Adopted)
+	require.Equal(t, uint64(140), report.ReclaimedDisk)
+	require.Equal(t, report.Reaped, e.getRemoved())
+	require.Equal(t, tracker.GetMaxCapacity(ctx), tracker.GetAvailableCapacity(ctx))
+}
+
+func TestOrphanReaperAdopt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reaper, e, tracker := newOrphanReaperFixture(t, compute.OrphanPolicyAdopt)
+
+	report := reaper.Reap(ctx)
+	require.Equal(t, []string{"orphan-running"}, report.Adopted)
+	require.Equal(t, []string{"ended", "orphan-large", "orphan-exited"}, report.Reaped)
+	require.Equal(t, uint64(110), report.ReclaimedDisk)
+	require.Equal(t, model.ResourceUsageData{CPU: 1, Disk: 970}, tracker.GetAvailableCapacity(ctx))
+
+	// the adopted workload is removed and its capacity released once it exits
+	close(e.release)
+	require.Eventually(t, func() bool {
+		return tracker.GetAvailableCapacity(ctx) == tracker.GetMaxCapacity(ctx)
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return len(e.getRemoved()) == 4
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "orphan-running", e.getRemoved()[3])
+}
+
+func TestParseOrphanPolicy(t *testing.T) {
+	policy, err := compute.ParseOrphanPolicy("adopt")
+	require.NoError(t, err)
+	require.Equal(t, compute.OrphanPolicyAdopt, policy)
+
+	_, err = compute.ParseOrphanPolicy("ignore")
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package compute
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ContractViolationError is returned when the results of an execution do not match the output contracts of its job.
+type ContractViolationError struct {
+	// Violations describe each way the results break the contracts.
+	Violations []string
+}
+
+func (e *ContractViolationError) Error() string {
+	return "output contract violation: " + strings.Join(e.Violations, "; ")
+}
+
+// CheckOutputContracts checks that the results in resultFolder have the files the contracts expect, with the content
+// they expect. It returns a ContractViolationError listing every violation.
+func CheckOutputContracts(resultFolder string, contracts []model.OutputContract) error {
+	var violations []string
+	for _, contract := range contracts {
+		violations = append(violations, checkOutputContract(resultFolder, contract)...)
+	}
+	if len(violations) > 0 {
+		return &ContractViolationError{Violations: violations}
+	}
+	return nil
+}
+
+func checkOutputContract(resultFolder string, contract model.OutputContract) []string {
+	if !filepath.IsLocal(filepath.FromSlash(contract.Path)) {
+		return []string{fmt.Sprintf("%s is not a path in the results of the job", contract.Path)}
+	}
+	path := filepath.Join(resultFolder, filepath.FromSlash(contract.Path))
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{fmt.Sprintf("%s was not produced", contract.Path)}
+	} else if err != nil {
+		return []string{fmt.Sprintf("%s can't be read: %s", contract.Path, err)}
+	} else if info.IsDir() {
+		return []string{fmt.Sprintf("%s is a directory rather than a file", contract.Path)}
+	}
+
+	var violations []string
+	if contract.JSONSchema != nil {
+		violations = append(violations, checkJSONSchema(path, contract)...)
+	}
+	if len(contract.CSVColumns) > 0 {
+		violations = append(violations, checkCSVColumns(path, contract)...)
+	}
+	return violations
+}
+
+func checkJSONSchema(path string, contract model.OutputContract) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("%s can't be read: %s", contract.Path, err)}
+	}
+	schema, err := job.NewOutputContractSchema(contract.JSONSchema)
+	if err != nil {
+		return []string{fmt.Sprintf("%s can't be validated against its schema: %s", contract.Path, err)}
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return []string{fmt.Sprintf("%s can't be validated against its schema: %s", contract.Path, err)}
+	}
+	var violations []string
+	for _, resultErr := range result.Errors() {
+		violations = append(violations, fmt.Sprintf("%s does not match its schema: %s", contract.Path, resultErr))
+	}
+	return violations
+}
+
+func checkCSVColumns(path string, contract model.OutputContract) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{fmt.Sprintf("%s can't be read: %s", contract.Path, err)}
+	}
+	defer file.Close() //nolint:errcheck // read only
+
+	header, err := csv.NewReader(file).Read()
+	if errors.Is(err, io.EOF) {
+		return []string{fmt.Sprintf("%s is empty, and has none of the columns %s", contract.Path,
+			strings.Join(contract.CSVColumns, ", "))}
+	} else if err != nil {
+		return []string{fmt.Sprintf("%s is not a CSV file: %s", contract.Path, err)}
+	}
+	columns := make(map[string]bool, len(header))
+	for _, column := range header {
+		columns[strings.TrimSpace(column)] = true
+	}
+	var missing []string
+	for _, column := range contract.CSVColumns {
+		if !columns[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return []string{fmt.Sprintf("%s is missing the columns %s", contract.Path, strings.Join(missing, ", "))}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOutputContracts(t *testing.T) {
+	resultFolder := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(resultFolder, "outputs"), 0700))
+	writeResult := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(resultFolder, "outputs", name), []byte(content), 0600))
+	}
+	writeResult("result.json", `{"total": 3, "items": ["a", "b", "c"]}`)
+	writeResult("table.csv", "id, name,size\n1,a,10\n")
+	writeResult("empty.csv", "")
+	require.NoError(t, os.WriteFile(filepath.Join(resultFolder, "stdout"), nil, 0600))
+
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"total"},
+		"properties": map[string]interface{}{
+			"total": map[string]interface{}{"type": "integer"},
+		},
+	}
+	require.NoError(t, CheckOutputContracts(resultFolder, []model.OutputContract{
+		{Path: "outputs/result.json", JSONSchema: schema},
+		{Path: "outputs/table.csv", CSVColumns: []string{"id", "name"}},
+		{Path: "stdout"},
+	}))
+
+	err := CheckOutputContracts(resultFolder, []model.OutputContract{
+		{Path: "outputs/result.json", JSONSchema: map[string]interface{}{"required": []interface{}{"count"}}},
+		{Path: "outputs/table.csv", CSVColumns: []string{"id", "color"}},
+		{Path: "outputs/empty.csv", CSVColumns: []string{"id"}},
+		{Path: "outputs/missing.json"},
+		{Path: "outputs"},
+		{Path: "../outside"},
+	})
+	var violation *ContractViolationError
+	require.ErrorAs(t, err, &violation)
+	require.Len(t, violation.Violations, 6)
+	require.Contains(t, violation.Violations[0], "count is required")
+	require.Equal(t, "outputs/table.csv is missing the columns color", violation.Violations[1])
+	require.Contains(t, violation.Violations[2], "outputs/empty.csv is empty")
+	require.Equal(t, "outputs/missing.json was not produced", violation.Violations[3])
+	require.Contains(t, violation.Violations[4], "is a directory")
+	require.Contains(t, violation.Violations[5], "not a path in the results")
+	require.Contains(t, err.Error(), "output contract violation: ")
+}
@@ -2,6 +2,9 @@ package publicapi
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
@@ -37,3 +40,34 @@ func (apiClient *ComputeAPIClient) Debug(ctx context.Context) (map[string]model.
 
 	return res, nil
 }
+
+// Admin sends a signed admin request to the compute node and returns the resulting state of the node.
+func (apiClient *ComputeAPIClient) Admin(ctx context.Context, req AdminRequest) (AdminResponse, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute/publicapi.ComputeAPIClient.Admin")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	req.IssuedAt = time.Now()
+	req.Nonce = uuid.NewString()
+	var res AdminResponse
+	if err := apiClient.PostSigned(ctx, APIPrefix+APIAdminSuffix, req, &res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Audit sends a signed request for the execution audit log of the compute node, and returns the log with the result
+// of its verification.
+func (apiClient *ComputeAPIClient) Audit(ctx context.Context, req AuditRequest) (AuditResponse, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute/publicapi.ComputeAPIClient.Audit")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	var res AuditResponse
+	if err := apiClient.PostSigned(ctx, APIPrefix+APIAuditSuffix, req, &res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
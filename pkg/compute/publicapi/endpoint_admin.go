@@ -0,0 +1,88 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+)
+
+// AdminRequest is the signed payload sent to the admin endpoint of a compute node. All changes are optional, and a
+// request with no changes simply returns the current status of the node.
+type AdminRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	// IssuedAt and Nonce stop the request from being replayed. Requests issued too long ago are rejected, and each
+	// nonce is only accepted once.
+	IssuedAt time.Time `json:"IssuedAt" validate:"required"`
+	Nonce    string    `json:"Nonce" validate:"required"`
+
+	compute.NodeAdminChanges
+}
+
+func (r AdminRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type adminRequest = publicapi.SignedRequest[AdminRequest] //nolint:unused // Swagger wants this
+
+// AdminResponse describes the state of a compute node after an admin request has been applied.
+type AdminResponse = compute.AdminNodeResponse
+
+// admin godoc
+//
+//	@ID				apiServer/admin
+//	@Summary		Inspects and adjusts the configuration of this compute node.
+//	@Description	Only clients passed to --admin-client-id can administer the node, and each request is only accepted once,
+//	@Description	within minutes of being issued.
+//	@Tags			Compute
+//	@Accept			json
+//	@Produce		json
+//	@Param			adminRequest	body		adminRequest	true	" "
+//	@Success		200				{object}	AdminResponse
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/compute/admin [post]
+func (s *ComputeAPIServer) admin(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if s.nodeAdmin == nil || !s.nodeAdmin.Enabled() {
+		err := errors.New("admin API is not enabled on this node")
+		publicapi.HTTPError(ctx, res, err, http.StatusForbidden)
+		return
+	}
+
+	request, err := publicapi.UnmarshalSigned[AdminRequest](ctx, req.Body)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	if !s.nodeAdmin.IsAdmin(request.ClientID) {
+		publicapi.HTTPError(ctx, res, compute.ErrUnknownAdmin, http.StatusUnauthorized)
+		return
+	}
+
+	if err = s.adminReplays.Check(request.Nonce, request.IssuedAt, time.Now()); err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("rejected admin request: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.nodeAdmin.Apply(ctx, request.ClientID, request.NodeAdminChanges)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(response)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
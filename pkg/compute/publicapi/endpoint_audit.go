@@ -0,0 +1,123 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/audit"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/exp/slices"
+)
+
+// AuditRequest is the signed payload sent to the audit endpoint of a compute node.
+type AuditRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	// FromSequence only exports the entries from this position in the log onwards. Clients verifying the log ask for
+	// the entries from the last one they verified.
+	FromSequence uint64 `json:"FromSequence,omitempty"`
+}
+
+func (r AuditRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type auditRequest = publicapi.SignedRequest[AuditRequest] //nolint:unused // Swagger wants this
+
+// AuditResponse is an export of the execution audit log of a compute node, and whether its chain is intact.
+type AuditResponse struct {
+	Entries []audit.Entry `json:"Entries"`
+	// NodeID is the ID of the compute node, derived from PublicKey, if the entries are signed.
+	NodeID string `json:"NodeID,omitempty"`
+	// PublicKey is the marshaled public key of the node that verifies the signatures of the entries, if they are
+	// signed.
+	PublicKey []byte `json:"PublicKey,omitempty"`
+	// InvalidLines are the lines of the log that are not entries, and were skipped.
+	InvalidLines []int `json:"InvalidLines,omitempty"`
+	// Verified is set if the hashes of all the entries of the log chain up, i.e. no entry was modified or removed. It
+	// is reported by the node, and clients should verify the entries themselves.
+	Verified bool `json:"Verified"`
+	// VerificationError describes the first entry that breaks the chain if the log could not be verified.
+	VerificationError string `json:"VerificationError,omitempty"`
+}
+
+// audit godoc
+//
+//	@ID			apiServer/audit
+//	@Summary	Exports the log of the executions this compute node performed, and verifies its hash chain.
+//	@Tags		Compute
+//	@Accept		json
+//	@Produce	json
+//	@Param		auditRequest	body		auditRequest	true	" "
+//	@Success	200				{object}	AuditResponse
+//	@Failure	400				{object}	string
+//	@Failure	401				{object}	string
+//	@Failure	403				{object}	string
+//	@Failure	500				{object}	string
+//	@Router		/compute/audit [post]
+func (s *ComputeAPIServer) audit(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if len(s.adminClientIDs) == 0 {
+		err := errors.New("admin API is not enabled on this node")
+		publicapi.HTTPError(ctx, res, err, http.StatusForbidden)
+		return
+	}
+	if s.auditLog == nil {
+		err := errors.New("execution audit log is not enabled on this node")
+		publicapi.HTTPError(ctx, res, err, http.StatusForbidden)
+		return
+	}
+
+	request, err := publicapi.UnmarshalSigned[AuditRequest](ctx, req.Body)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	if !slices.Contains(s.adminClientIDs, request.ClientID) {
+		err = errors.New("audit request submitted by unknown client")
+		publicapi.HTTPError(ctx, res, err, http.StatusUnauthorized)
+		return
+	}
+
+	entries, invalidLines, verifyErr := s.auditLog.Verify()
+	if entries == nil && invalidLines == nil && verifyErr != nil {
+		publicapi.HTTPError(ctx, res, verifyErr, http.StatusInternalServerError)
+		return
+	}
+
+	response := AuditResponse{
+		Entries:      []audit.Entry{},
+		InvalidLines: invalidLines,
+		Verified:     verifyErr == nil,
+	}
+	if verifyErr != nil {
+		response.VerificationError = verifyErr.Error()
+	}
+	if publicKey := s.auditLog.PublicKey(); publicKey != nil {
+		nodeID, err := peer.IDFromPublicKey(publicKey)
+		if err == nil {
+			response.NodeID = nodeID.String()
+			response.PublicKey, err = crypto.MarshalPublicKey(publicKey)
+		}
+		if err != nil {
+			publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	for _, entry := range entries {
+		if entry.Sequence >= request.FromSequence {
+			response.Entries = append(response.Entries, entry)
+		}
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(response)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
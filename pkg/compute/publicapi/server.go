@@ -4,20 +4,34 @@ import (
 	"net/http"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/audit"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/local"
 )
 
 const APIPrefix = "compute/"
 const APIDebugSuffix = "debug"
 const APIApproveSuffix = "approve"
+const APIAdminSuffix = "admin"
+const APIAuditSuffix = "audit"
+const APIResultsSuffix = "results"
 
 type ComputeAPIServerParams struct {
 	APIServer          *publicapi.APIServer
 	Bidder             compute.Bidder
 	Store              store.ExecutionStore
 	DebugInfoProviders []model.DebugInfoProvider
+	// AdminClientIDs are the clients allowed to use the admin API. The admin API is disabled if empty.
+	AdminClientIDs []string
+	// NodeAdmin applies the changes of admins to the node. The admin endpoint is disabled if nil.
+	NodeAdmin *compute.NodeAdmin
+	// AuditLog is exported to admins with its verification. The audit API is disabled if nil.
+	AuditLog *audit.Log
+	// ResultsDirectory holds the results published with the local publisher. Results are not served if empty.
+	ResultsDirectory string
 }
 
 type ComputeAPIServer struct {
@@ -25,6 +39,11 @@ type ComputeAPIServer struct {
 	bidder             compute.Bidder
 	store              store.ExecutionStore
 	debugInfoProviders []model.DebugInfoProvider
+	adminClientIDs     []string
+	nodeAdmin          *compute.NodeAdmin
+	adminReplays       *publicapi.ReplayGuard
+	auditLog           *audit.Log
+	resultsDirectory   string
 }
 
 func NewComputeAPIServer(params ComputeAPIServerParams) *ComputeAPIServer {
@@ -33,19 +52,51 @@ func NewComputeAPIServer(params ComputeAPIServerParams) *ComputeAPIServer {
 		bidder:             params.Bidder,
 		store:              params.Store,
 		debugInfoProviders: params.DebugInfoProviders,
+		adminClientIDs:     params.AdminClientIDs,
+		nodeAdmin:          params.NodeAdmin,
+		adminReplays:       publicapi.NewReplayGuard(publicapi.AdminRequestTTL),
+		auditLog:           params.AuditLog,
+		resultsDirectory:   params.ResultsDirectory,
 	}
 }
 
 func (s *ComputeAPIServer) RegisterAllHandlers() error {
 	handlerConfigs := []publicapi.HandlerConfig{
-		{Path: "/" + APIPrefix + APIDebugSuffix, Handler: http.HandlerFunc(s.debug)},
-		{Path: "/" + APIPrefix + APIApproveSuffix, Handler: http.HandlerFunc(s.approve)},
+		{Path: "/" + APIPrefix + APIDebugSuffix, Handler: http.HandlerFunc(s.debug), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + APIApproveSuffix, Handler: http.HandlerFunc(s.approve), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + APIAdminSuffix, Handler: http.HandlerFunc(s.admin), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + APIAuditSuffix, Handler: http.HandlerFunc(s.audit), Scope: publicapi.ScopeAdmin},
 	}
 	// register URIs at root prefix for backward compatibility before migrating to API versioning
 	// we should remove these eventually, or have throttling limits shared across versions
-	err := s.apiServer.RegisterHandlers(publicapi.LegacyAPIPrefix, handlerConfigs...)
+	err := s.apiServer.RegisterHandlers(publicapi.LegacyAPIPrefix, s.withResults(publicapi.LegacyAPIPrefix, handlerConfigs)...)
 	if err != nil {
 		return err
 	}
-	return s.apiServer.RegisterHandlers(publicapi.V1APIPrefix, handlerConfigs...)
+	return s.apiServer.RegisterHandlers(publicapi.V1APIPrefix, s.withResults(publicapi.V1APIPrefix, handlerConfigs)...)
+}
+
+// withResults adds the handler serving the results published with the local publisher below the API prefix, if the
+// node publishes results locally. Results are streamed without the middleware of the other handlers so that large
+// results are not cut by their timeout, and are only served to the clients allowed to see their job.
+func (s *ComputeAPIServer) withResults(apiPrefix string, handlerConfigs []publicapi.HandlerConfig) []publicapi.HandlerConfig {
+	if s.resultsDirectory == "" {
+		return handlerConfigs
+	}
+	path := "/" + APIPrefix + APIResultsSuffix
+	return append(handlerConfigs, publicapi.HandlerConfig{
+		Path:    path + "/",
+		Handler: http.StripPrefix(apiPrefix+path, local.NewHandler(s.resultsDirectory, canSeeResults)),
+		Raw:     true,
+		Scope:   publicapi.ScopeRead,
+	})
+}
+
+// canSeeResults returns true if the results of the job can be served to the client of the request, which must be in
+// the namespace of the job and be its submitter or one of its watchers.
+func canSeeResults(r *http.Request, job model.Metadata) bool {
+	if namespace := publicapi.RequestNamespace(r.Context()); namespace != "" && namespace != job.Namespace {
+		return false
+	}
+	return job.IsVisibleTo(r.Header.Get(handlerwrapper.HTTPHeaderClientID))
 }
@@ -0,0 +1,193 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/google/uuid"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// DefaultDockerImage is the image run to check the docker engine.
+const DefaultDockerImage = "hello-world:latest"
+
+// selfTestFile is the name of the file written by checks that round trip data.
+const selfTestFile = "self-test.txt"
+
+// noopWasmModule is a WASI module that exits with code 0 as soon as it is started.
+var noopWasmModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+	// types: (func (param i32)) and (func)
+	0x01, 0x08, 0x02, 0x60, 0x01, 0x7f, 0x00, 0x60, 0x00, 0x00,
+	// imports: wasi_snapshot_preview1.proc_exit of type 0
+	0x02, 0x24, 0x01,
+	0x16, 'w', 'a', 's', 'i', '_', 's', 'n', 'a', 'p', 's', 'h', 'o', 't', '_', 'p', 'r', 'e', 'v', 'i', 'e', 'w', '1',
+	0x09, 'p', 'r', 'o', 'c', '_', 'e', 'x', 'i', 't', 0x00, 0x00,
+	// functions: one of type 1
+	0x03, 0x02, 0x01, 0x01,
+	// exports: _start as function 1
+	0x07, 0x0a, 0x01, 0x06, '_', 's', 't', 'a', 'r', 't', 0x00, 0x01,
+	// code: proc_exit(0)
+	0x0a, 0x08, 0x01, 0x06, 0x00, 0x41, 0x00, 0x10, 0x00, 0x0b,
+}
+
+// newSelfTestJob returns a job with the given engine and a unique ID, so that self-tests don't clash with each
+// other or with real executions.
+func newSelfTestJob(engine model.Engine) model.Job {
+	return model.Job{
+		APIVersion: model.APIVersionLatest().String(),
+		Metadata: model.Metadata{
+			ID:       "self-test-" + uuid.NewString(),
+			ClientID: "self-test",
+		},
+		Spec: model.Spec{
+			Engine:        engine,
+			Verifier:      model.VerifierNoop,
+			PublisherSpec: model.PublisherSpec{Type: model.PublisherIpfs},
+			Network:       model.NetworkConfig{Type: model.NetworkNone},
+		},
+	}
+}
+
+// DockerJob returns a job that runs the image, which is expected to exit successfully.
+func DockerJob(image string) model.Job {
+	j := newSelfTestJob(model.EngineDocker)
+	j.Spec.Docker = model.JobSpecDocker{Image: image}
+	return j
+}
+
+// WasmJob returns a job that runs a trivial wasm module, which exits successfully without doing anything.
+func WasmJob() model.Job {
+	j := newSelfTestJob(model.EngineWasm)
+	j.Spec.Wasm = model.JobSpecWasm{
+		EntryPoint: "_start",
+		EntryModule: model.StorageSpec{
+			StorageSource: model.StorageSourceInline,
+			Name:          "self-test.wasm",
+			URL:           dataurl.New(noopWasmModule, "application/wasm").String(),
+		},
+	}
+	return j
+}
+
+// NewExecutorCheck returns a check that runs the job with the executor of its engine, and passes if the job exits
+// with code 0.
+func NewExecutorCheck(name string, executors executor.ExecutorProvider, j model.Job) Check {
+	return CheckFunc{CheckName: name, Fn: func(ctx context.Context) error {
+		e, err := executors.Get(ctx, j.Spec.Engine)
+		if err != nil {
+			return err
+		}
+
+		resultsDir, err := os.MkdirTemp("", "bacalhau-self-test-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(resultsDir)
+
+		result, err := e.Run(ctx, j.ID(), j, resultsDir)
+		if err != nil {
+			return err
+		}
+		if result.ErrorMsg != "" {
+			return fmt.Errorf("job failed: %s", result.ErrorMsg)
+		}
+		if result.ExitCode != 0 {
+			return fmt.Errorf("job exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.STDERR))
+		}
+		return nil
+	}}
+}
+
+// ContentStore is the subset of an IPFS client used to round trip data.
+type ContentStore interface {
+	Put(ctx context.Context, inputPath string) (string, error)
+	Get(ctx context.Context, cid, outputPath string) error
+}
+
+// NewIPFSCheck returns a check that adds a file to IPFS and passes if the same content is read back.
+func NewIPFSCheck(store ContentStore) Check {
+	return CheckFunc{CheckName: "ipfs", Fn: func(ctx context.Context) error {
+		return withSelfTestFile(func(dir string, content []byte) error {
+			cid, err := store.Put(ctx, dir)
+			if err != nil {
+				return fmt.Errorf("failed to add to IPFS: %w", err)
+			}
+			return fetchAndCompare(ctx, store, cid, content)
+		})
+	}}
+}
+
+// NewPublisherCheck returns a check that publishes a small result with the publisher, and passes if the published
+// content can be read back. Only results published to a CID can be read back, through the store, and other
+// publishers pass as long as publishing succeeds.
+func NewPublisherCheck(publishers publisher.PublisherProvider, spec model.PublisherSpec, store ContentStore) Check {
+	name := "publisher " + strings.ToLower(spec.Type.String())
+	return CheckFunc{CheckName: name, Fn: func(ctx context.Context) error {
+		p, err := publishers.Get(ctx, spec.Type)
+		if err != nil {
+			return err
+		}
+
+		j := newSelfTestJob(model.EngineNoop)
+		j.Spec.PublisherSpec = spec
+		return withSelfTestFile(func(dir string, content []byte) error {
+			published, err := p.PublishResult(ctx, j.ID(), j, dir)
+			if err != nil {
+				return fmt.Errorf("failed to publish: %w", err)
+			}
+			if published.CID == "" || store == nil {
+				return nil
+			}
+			return fetchAndCompare(ctx, store, published.CID, content)
+		})
+	}}
+}
+
+// withSelfTestFile calls fn with a directory holding a file of random content.
+func withSelfTestFile(fn func(dir string, content []byte) error) error {
+	dir, err := os.MkdirTemp("", "bacalhau-self-test-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	content := make([]byte, 32) //nolint:gomnd
+	if _, err = rand.Read(content); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(dir, selfTestFile), content, model.DownloadFilePerm); err != nil {
+		return err
+	}
+	return fn(dir, content)
+}
+
+// fetchAndCompare reads the directory with the CID back and checks it holds the self-test file with the content.
+func fetchAndCompare(ctx context.Context, store ContentStore, cid string, content []byte) error {
+	dir, err := os.MkdirTemp("", "bacalhau-self-test-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, cid)
+	if err = store.Get(ctx, cid, output); err != nil {
+		return fmt.Errorf("failed to read back %s: %w", cid, err)
+	}
+	fetched, err := os.ReadFile(filepath.Join(output, selfTestFile))
+	if err != nil {
+		return fmt.Errorf("failed to read back %s: %w", cid, err)
+	}
+	if !bytes.Equal(fetched, content) {
+		return fmt.Errorf("content read back from %s does not match what was written", cid)
+	}
+	return nil
+}
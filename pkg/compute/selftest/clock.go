@@ -0,0 +1,88 @@
+package selftest
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultNTPServer is the server the clock of the node is compared against.
+	DefaultNTPServer = "pool.ntp.org"
+	// DefaultMaxClockOffset is how far the clock of the node can drift before the clock check fails.
+	DefaultMaxClockOffset = time.Second
+
+	ntpPort       = "123"
+	ntpPacketSize = 48
+	// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the unix epoch (1970).
+	ntpEpochOffset = 2208988800
+	// ntpClientRequest sets leap indicator 0, version 4 and mode 3 (client) in the first byte of a request.
+	ntpClientRequest = 0x23
+)
+
+// NewClockCheck returns a check that queries the SNTP server, and passes if the clock of the node is within
+// maxOffset of the server. The check is skipped if no server is given.
+func NewClockCheck(server string, maxOffset time.Duration) Check {
+	return CheckFunc{CheckName: "clock sync", Fn: func(ctx context.Context) error {
+		if server == "" {
+			return skipped("no NTP server configured")
+		}
+		offset, err := clockOffset(ctx, server)
+		if err != nil {
+			return fmt.Errorf("failed to query NTP server %s: %w", server, err)
+		}
+		if offset.Abs() > maxOffset {
+			return fmt.Errorf("clock is %s off from %s, more than the allowed %s", offset, server, maxOffset)
+		}
+		return nil
+	}}
+}
+
+// clockOffset returns how far the server's clock is ahead of the local clock, using a single SNTP exchange.
+func clockOffset(ctx context.Context, server string) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, ntpPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+
+	request := make([]byte, ntpPacketSize)
+	request[0] = ntpClientRequest
+	sent := time.Now()
+	if _, err = conn.Write(request); err != nil {
+		return 0, err
+	}
+	response := make([]byte, ntpPacketSize)
+	n, err := conn.Read(response)
+	received := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	if n < ntpPacketSize {
+		return 0, fmt.Errorf("short NTP response of %d bytes", n)
+	}
+
+	// the server's receive and transmit timestamps are at offsets 32 and 40
+	serverReceived := ntpTime(response[32:40])
+	serverSent := ntpTime(response[40:48])
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil //nolint:gomnd
+}
+
+// ntpTime decodes an NTP timestamp, which is 32 bits of seconds since 1900 followed by 32 bits of fraction.
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, fraction*int64(time.Second)>>32) //nolint:gomnd
+}
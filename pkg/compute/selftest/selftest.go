@@ -0,0 +1,141 @@
+// Package selftest checks that a compute node is able to run jobs, by running a small workload through each of the
+// components that jobs depend on, such as the executors, IPFS and publishers, and checking the host clock.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrSkipped is returned by checks that do not apply to the node, such as checks of an engine that is disabled.
+var ErrSkipped = errors.New("skipped")
+
+// skipped returns an error that marks a check as skipped for the given reason.
+func skipped(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrSkipped, fmt.Sprintf(format, args...))
+}
+
+// Check is a single test of a component of the node.
+type Check interface {
+	// Name identifies the check in reports.
+	Name() string
+	// Run returns nil if the check passed, an error wrapping ErrSkipped if it does not apply to the node, or any
+	// other error if it failed.
+	Run(ctx context.Context) error
+}
+
+// CheckFunc adapts a function to a Check.
+type CheckFunc struct {
+	CheckName string
+	Fn        func(ctx context.Context) error
+}
+
+func (c CheckFunc) Name() string {
+	return c.CheckName
+}
+
+func (c CheckFunc) Run(ctx context.Context) error {
+	return c.Fn(ctx)
+}
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// CheckResult is the outcome of running a check.
+type CheckResult struct {
+	Name     string        `json:"Name"`
+	Status   Status        `json:"Status"`
+	Message  string        `json:"Message,omitempty"`
+	Duration time.Duration `json:"Duration"`
+}
+
+// Report is the machine-readable result of a self-test.
+type Report struct {
+	NodeID    string        `json:"NodeID"`
+	StartTime time.Time     `json:"StartTime"`
+	Duration  time.Duration `json:"Duration"`
+	// Passed is true if none of the checks failed.
+	Passed bool          `json:"Passed"`
+	Checks []CheckResult `json:"Checks"`
+}
+
+type SelfTestParams struct {
+	NodeID string
+	Checks []Check
+	// CheckTimeout is how long each check is given to complete. Checks are not timed out if zero.
+	CheckTimeout time.Duration
+}
+
+// SelfTest runs a battery of checks against a node.
+type SelfTest struct {
+	nodeID       string
+	checks       []Check
+	checkTimeout time.Duration
+}
+
+func NewSelfTest(params SelfTestParams) *SelfTest {
+	return &SelfTest{
+		nodeID:       params.NodeID,
+		checks:       params.Checks,
+		checkTimeout: params.CheckTimeout,
+	}
+}
+
+// Run runs each of the checks in turn and reports their outcome.
+func (s *SelfTest) Run(ctx context.Context) Report {
+	report := Report{
+		NodeID:    s.nodeID,
+		StartTime: time.Now(),
+		Passed:    true,
+		Checks:    make([]CheckResult, 0, len(s.checks)),
+	}
+	for _, check := range s.checks {
+		result := s.runCheck(ctx, check)
+		log.Ctx(ctx).Debug().
+			Str("Check", result.Name).
+			Str("Status", string(result.Status)).
+			Str("Message", result.Message).
+			Msg("Self-test check completed")
+		if result.Status == StatusFailed {
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	report.Duration = time.Since(report.StartTime)
+	return report
+}
+
+func (s *SelfTest) runCheck(ctx context.Context, check Check) CheckResult {
+	if s.checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.checkTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{
+		Name:     check.Name(),
+		Status:   StatusPassed,
+		Duration: time.Since(start),
+	}
+	switch {
+	case errors.Is(err, ErrSkipped):
+		result.Status = StatusSkipped
+		result.Message = err.Error()
+	case err != nil:
+		result.Status = StatusFailed
+		result.Message = err.Error()
+	}
+	return result
+}
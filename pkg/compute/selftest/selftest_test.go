@@ -0,0 +1,148 @@
+//go:build unit || !integration
+
+package selftest
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/wasm"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTestRun(t *testing.T) {
+	report := NewSelfTest(SelfTestParams{
+		NodeID:       "node",
+		CheckTimeout: 10 * time.Millisecond,
+		Checks: []Check{
+			CheckFunc{CheckName: "passes", Fn: func(context.Context) error { return nil }},
+			CheckFunc{CheckName: "skips", Fn: func(context.Context) error { return skipped("not configured") }},
+			CheckFunc{CheckName: "times out", Fn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+	}).Run(context.Background())
+
+	require.Equal(t, "node", report.NodeID)
+	require.False(t, report.Passed)
+	require.Len(t, report.Checks, 3)
+	require.Equal(t, StatusPassed, report.Checks[0].Status)
+	require.Equal(t, StatusSkipped, report.Checks[1].Status)
+	require.Equal(t, "skipped: not configured", report.Checks[1].Message)
+	require.Equal(t, StatusFailed, report.Checks[2].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Message)
+}
+
+func TestSelfTestPassesWithSkippedChecks(t *testing.T) {
+	report := NewSelfTest(SelfTestParams{
+		Checks: []Check{NewClockCheck("", DefaultMaxClockOffset)},
+	}).Run(context.Background())
+	require.True(t, report.Passed)
+	require.Equal(t, StatusSkipped, report.Checks[0].Status)
+}
+
+// fakeNTPServer answers SNTP requests with its clock set offset ahead of the local clock.
+func fakeNTPServer(t *testing.T, offset time.Duration) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		request := make([]byte, ntpPacketSize)
+		for {
+			_, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+			response := make([]byte, ntpPacketSize)
+			now := time.Now().Add(offset)
+			encodeNTPTime(response[32:40], now)
+			encodeNTPTime(response[40:48], now)
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func encodeNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/int64(time.Second)))
+}
+
+func TestClockCheck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, NewClockCheck(fakeNTPServer(t, 0), DefaultMaxClockOffset).Run(ctx))
+
+	err := NewClockCheck(fakeNTPServer(t, 5*time.Second), DefaultMaxClockOffset).Run(ctx)
+	// the offset measured is a little off the one of the server, as the request takes time
+	require.ErrorContains(t, err, "off from")
+	require.ErrorContains(t, err, "more than the allowed 1s")
+}
+
+// fakeContentStore stores directories in memory by a CID derived from their order.
+type fakeContentStore struct {
+	contents map[string][]byte
+	corrupt  bool
+}
+
+func (s *fakeContentStore) Put(_ context.Context, inputPath string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(inputPath, selfTestFile))
+	if err != nil {
+		return "", err
+	}
+	cid := fmt.Sprintf("cid-%d", len(s.contents))
+	s.contents[cid] = content
+	return cid, nil
+}
+
+func (s *fakeContentStore) Get(_ context.Context, cid, outputPath string) error {
+	content, ok := s.contents[cid]
+	if !ok {
+		return errors.New("not found")
+	}
+	if s.corrupt {
+		content = []byte("corrupt")
+	}
+	if err := os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputPath, selfTestFile), content, os.ModePerm)
+}
+
+func TestIPFSCheck(t *testing.T) {
+	store := &fakeContentStore{contents: make(map[string][]byte)}
+	require.NoError(t, NewIPFSCheck(store).Run(context.Background()))
+
+	store.corrupt = true
+	require.ErrorContains(t, NewIPFSCheck(store).Run(context.Background()), "does not match")
+}
+
+func TestWasmExecutorCheck(t *testing.T) {
+	ctx := context.Background()
+	storages := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceInline: inline.NewStorage(),
+	})
+	wasmExecutor, err := wasm.NewExecutor(ctx, storages, warmpool.Config{})
+	require.NoError(t, err)
+	executors := model.NewMappedProvider(map[model.Engine]executor.Executor{
+		model.EngineWasm: wasmExecutor,
+	})
+
+	require.NoError(t, NewExecutorCheck("wasm", executors, WasmJob()).Run(ctx))
+	require.Error(t, NewExecutorCheck("docker", executors, DockerJob(DefaultDockerImage)).Run(ctx))
+}
@@ -101,6 +101,11 @@ func (proxy *PersistentExecutionStore) GetExecutions(ctx context.Context, shared
 	return proxy.store.GetExecutions(ctx, sharedID)
 }
 
+// UpdateExecutionTimeout implements store.ExecutionStore
+func (proxy *PersistentExecutionStore) UpdateExecutionTimeout(ctx context.Context, id string, timeout float64) error {
+	return proxy.store.UpdateExecutionTimeout(ctx, id, timeout)
+}
+
 // UpdateExecutionState implements store.ExecutionStore
 func (proxy *PersistentExecutionStore) UpdateExecutionState(ctx context.Context, request store.UpdateExecutionStateRequest) error {
 	err := proxy.store.UpdateExecutionState(ctx, request)
@@ -106,6 +106,23 @@ func (s *Store) UpdateExecutionState(ctx context.Context, request store.UpdateEx
 	return nil
 }
 
+func (s *Store) UpdateExecutionTimeout(ctx context.Context, id string, timeout float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	execution, ok := s.executionMap[id]
+	if !ok {
+		return store.NewErrExecutionNotFound(id)
+	}
+	if execution.State.IsTerminal() {
+		return fmt.Errorf("cannot extend the timeout of execution %s in terminal state %s", id, execution.State)
+	}
+	execution.Job.Spec.Timeout = timeout
+	execution.Version += 1
+	execution.UpdateTime = time.Now()
+	s.executionMap[execution.ID] = execution
+	return nil
+}
+
 func (s *Store) appendHistory(updatedExecution store.Execution, previousState store.ExecutionState, comment string) {
 	historyEntry := store.ExecutionHistory{
 		ExecutionID:   updatedExecution.ID,
@@ -32,6 +32,11 @@ func (m *MockExecutionStore) UpdateExecutionState(ctx context.Context, request s
 	return args.Error(0)
 }
 
+func (m *MockExecutionStore) UpdateExecutionTimeout(ctx context.Context, id string, timeout float64) error {
+	args := m.Called(ctx, id, timeout)
+	return args.Error(0)
+}
+
 func (m *MockExecutionStore) DeleteExecution(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
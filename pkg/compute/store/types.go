@@ -89,6 +89,8 @@ type ExecutionStore interface {
 	CreateExecution(ctx context.Context, execution Execution) error
 	// UpdateExecutionState updates the execution state
 	UpdateExecutionState(ctx context.Context, request UpdateExecutionStateRequest) error
+	// UpdateExecutionTimeout updates the timeout in seconds of the job of an execution, after it was extended
+	UpdateExecutionTimeout(ctx context.Context, id string, timeout float64) error
 	// DeleteExecution deletes an execution
 	DeleteExecution(ctx context.Context, id string) error
 	// GetExecutionCount returns a count of all executions that completed
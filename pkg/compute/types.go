@@ -2,7 +2,9 @@ package compute
 
 import (
 	"context"
+	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
@@ -27,6 +29,14 @@ type Endpoint interface {
 	CancelExecution(context.Context, CancelExecutionRequest) (CancelExecutionResponse, error)
 	// ExecutionLogs returns the address of a suitable log server
 	ExecutionLogs(context.Context, ExecutionLogsRequest) (ExecutionLogsResponse, error)
+	// ReserveCapacity holds capacity of the node for the jobs of a capacity reservation during its time window.
+	ReserveCapacity(context.Context, ReserveCapacityRequest) (ReserveCapacityResponse, error)
+	// ReleaseCapacity stops holding the capacity of a capacity reservation.
+	ReleaseCapacity(context.Context, ReleaseCapacityRequest) (ReleaseCapacityResponse, error)
+	// ExtendTimeout extends the timeout of an execution, after the timeout of its job was extended.
+	ExtendTimeout(context.Context, ExtendTimeoutRequest) (ExtendTimeoutResponse, error)
+	// AdminNode applies the changes of an admin to the node, and returns the resulting state of the node.
+	AdminNode(context.Context, AdminNodeRequest) (AdminNodeResponse, error)
 }
 
 // Executor Backend service that is responsible for running and publishing executions.
@@ -38,6 +48,10 @@ type Executor interface {
 	Publish(ctx context.Context, execution store.Execution) error
 	// Cancel cancels the execution of a job.
 	Cancel(ctx context.Context, execution store.Execution) error
+	// Preempt stops a running execution to make room for one of higher priority, and reports it as preempted.
+	Preempt(ctx context.Context, execution store.Execution, reason string) error
+	// ExtendTimeout gives the execution longer to run, counted from when it started.
+	ExtendTimeout(ctx context.Context, execution store.Execution, timeout time.Duration) error
 }
 
 // Callback Callbacks are used to notify the caller of the result of a job execution.
@@ -49,6 +63,33 @@ type Callback interface {
 	OnComputeFailure(ctx context.Context, err ComputeError)
 }
 
+// InputPrefetcher fetches the inputs of executions that have been bid on, ahead of the bid being accepted.
+type InputPrefetcher interface {
+	// Prefetch starts fetching the inputs of an execution of the job in the background, and cleans them up if they
+	// are not picked up by the job within the TTL.
+	Prefetch(ctx context.Context, executionID string, jobID string, inputs []model.StorageSpec, ttl time.Duration)
+	// Cancel stops fetching the inputs of an execution and cleans up the ones already fetched.
+	Cancel(ctx context.Context, executionID string)
+}
+
+// InputEstimator checks the inputs of a job can be fetched before the node bids on it, and estimates what fetching
+// them takes so that the requester can prefer nodes that get the data faster.
+type InputEstimator interface {
+	// EstimateInputs returns an error if any of the inputs can't be resolved.
+	EstimateInputs(ctx context.Context, inputs []model.StorageSpec) (InputEstimate, error)
+}
+
+// InputEstimate is what fetching the inputs of a job is expected to take.
+type InputEstimate struct {
+	// Size is the number of bytes to fetch, not counting inputs that are already local or can't be sized.
+	Size uint64
+	// Duration is how long fetching the inputs is expected to take.
+	Duration time.Duration
+	// Known is false if an input the node has to fetch could not be sized, in which case the estimate only covers
+	// the inputs that could.
+	Known bool
+}
+
 ///////////////////////////////////
 // Endpoint request/response models
 ///////////////////////////////////
@@ -143,6 +184,76 @@ type ExecutionLogsResponse struct {
 	ExecutionFinished bool
 }
 
+// ReserveCapacityRequest asks the node to hold capacity for a reservation. The Resources of the reservation are what
+// the node is asked to hold, rather than what is reserved across the network.
+type ReserveCapacityRequest struct {
+	RoutingMetadata
+	Reservation model.CapacityReservation
+}
+
+type ReserveCapacityResponse struct {
+	Accepted bool
+	Reason   string
+}
+
+type ReleaseCapacityRequest struct {
+	RoutingMetadata
+	ReservationID string
+}
+
+type ReleaseCapacityResponse struct {
+	// Released is false if the node did not hold the reservation.
+	Released bool
+}
+
+// ExtendTimeoutRequest extends the timeout of an execution to the timeout of its job after it was extended.
+type ExtendTimeoutRequest struct {
+	RoutingMetadata
+	ExecutionID string
+	// Timeout is the new timeout of the job in seconds.
+	Timeout float64
+}
+
+type ExtendTimeoutResponse struct {
+	ExecutionMetadata
+}
+
+// NodeAdminChanges are the changes an admin makes to a running compute node. All of them are optional, and no
+// changes simply return the current state of the node.
+type NodeAdminChanges struct {
+	// BiddingEnabled enables or disables bidding on new jobs when set.
+	BiddingEnabled *bool `json:"BiddingEnabled,omitempty"`
+
+	// TotalResourceLimits replaces the total resource limits of the node when set.
+	// Resources that are not specified keep their current limit.
+	TotalResourceLimits *model.ResourceUsageConfig `json:"TotalResourceLimits,omitempty"`
+
+	// PurgeCaches clears the caches held by the node.
+	PurgeCaches bool `json:"PurgeCaches,omitempty"`
+
+	// SelfTest runs the self-test of the node and includes its report in the response.
+	SelfTest bool `json:"SelfTest,omitempty"`
+}
+
+// AdminNodeRequest applies the changes of an admin to the node. Requesters only forward the requests of the admins
+// they authenticated, and the node only accepts the requests of its own admins.
+type AdminNodeRequest struct {
+	RoutingMetadata
+	NodeAdminChanges
+	ClientID string
+}
+
+// AdminNodeResponse describes the state of a compute node after the changes of an admin have been applied.
+type AdminNodeResponse struct {
+	BiddingEnabled     bool                     `json:"BiddingEnabled"`
+	MaxCapacity        model.ResourceUsageData  `json:"MaxCapacity"`
+	AvailableCapacity  model.ResourceUsageData  `json:"AvailableCapacity"`
+	RunningExecutions  []store.ExecutionSummary `json:"RunningExecutions"`
+	EnqueuedExecutions []store.ExecutionSummary `json:"EnqueuedExecutions"`
+	ExecutionQueue     []model.QueuedExecution  `json:"ExecutionQueue"`
+	SelfTest           *selftest.Report         `json:"SelfTest,omitempty"`
+}
+
 ///////////////////////////////////
 // Callback result models
 ///////////////////////////////////
@@ -154,6 +265,15 @@ type BidResult struct {
 	ExecutionMetadata
 	Accepted bool
 	Reason   string
+	// ResultSizeLimit is the largest result in bytes the node agrees to publish for the job if the bid is accepted.
+	// There is no limit if zero.
+	ResultSizeLimit uint64
+	// InputsSize and InputsFetchEstimate are the bytes of inputs the node has to fetch for the job, and how long it
+	// expects fetching them to take. InputsEstimated is false if the node could not estimate them, in which case the
+	// bid is ranked after those that could.
+	InputsSize          uint64
+	InputsFetchEstimate time.Duration
+	InputsEstimated     bool
 }
 
 // RunResult Result of a job execution that is returned to the caller through a Callback.
@@ -162,6 +282,8 @@ type RunResult struct {
 	ExecutionMetadata
 	ResultProposal   []byte
 	RunCommandResult *model.RunCommandResult
+	// FailureReason is the likely cause of the run exiting with a non-zero exit code, if it is a common one
+	FailureReason *model.FailureReason
 }
 
 // PublishResult Result of a job publish that is returned to the caller through a Callback.
@@ -169,6 +291,8 @@ type PublishResult struct {
 	RoutingMetadata
 	ExecutionMetadata
 	PublishResult model.StorageSpec
+	// PublishedVolumes are the output volumes published on their own, by volume name
+	PublishedVolumes map[string]model.StorageSpec
 }
 
 // CancelResult Result of a job cancel that is returned to the caller through a Callback.
@@ -181,6 +305,10 @@ type ComputeError struct {
 	RoutingMetadata
 	ExecutionMetadata
 	Err string
+	// Preempted is set when the execution was stopped to make room for an execution of higher priority
+	Preempted bool
+	// FailureReason is the likely cause of the failure, if it is a common one
+	FailureReason *model.FailureReason
 }
 
 func (e ComputeError) Error() string {
@@ -0,0 +1,111 @@
+package compute
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+	"github.com/rs/zerolog/log"
+)
+
+// Names of the lanes the work of a compute node is split into.
+const (
+	// ExecutionLane runs executions once there is enough capacity for them.
+	ExecutionLane = "execution"
+	// PublishLane publishes the results of executions once they have been verified.
+	PublishLane = "publish"
+	// CallbackLane delivers results to be verified, and published results, to the requester.
+	CallbackLane = "callback"
+	// CancelLane cancels executions.
+	CancelLane = "cancel"
+)
+
+// laneOrder reports whether task a runs before task b, given how long each has waited in the lane.
+type laneOrder[T any] func(a, b T, aWaited, bWaited time.Duration) bool
+
+type laneItem[T any] struct {
+	task       T
+	enqueuedAt time.Time
+}
+
+// workLane runs the work of one kind, such as publishing results, with its own concurrency limit, so that work of
+// one kind waiting for its turn does not hold up the work of other kinds behind it. Work is run in the order of less,
+// which is passed how long each task has waited so far, or in the order it was enqueued if less is nil or considers
+// two tasks equal.
+type workLane[T any] struct {
+	name string
+	// maxConcurrent is the maximum number of tasks run at once. There is no limit if zero.
+	maxConcurrent int
+	less          laneOrder[T]
+	run           func(task T)
+	queue         []laneItem[T]
+	running       int
+	mu            sync.Mutex
+}
+
+func newWorkLane[T any](name string, maxConcurrent int, less laneOrder[T], run func(task T)) *workLane[T] {
+	l := &workLane[T]{
+		name:          name,
+		maxConcurrent: maxConcurrent,
+		less:          less,
+		run:           run,
+	}
+	l.mu.EnableTracerWithOpts(sync.Opts{
+		Threshold: 10 * time.Millisecond,
+		Id:        "workLane.mu",
+	})
+	return l
+}
+
+// enqueue adds a task to the lane and runs it as soon as its turn comes.
+func (l *workLane[T]) enqueue(task T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queue = append(l.queue, laneItem[T]{task: task, enqueuedAt: time.Now()})
+	workLaneQueueLength.Add(context.Background(), 1, laneAttributes(l.name)...)
+	l.dispatch()
+}
+
+// len returns the number of tasks waiting for their turn.
+func (l *workLane[T]) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// dispatch starts the next tasks in the queue while below the concurrency limit. It is called with the lock held.
+func (l *workLane[T]) dispatch() {
+	if l.less != nil {
+		now := time.Now()
+		sort.SliceStable(l.queue, func(i, j int) bool {
+			a, b := l.queue[i], l.queue[j]
+			return l.less(a.task, b.task, now.Sub(a.enqueuedAt), now.Sub(b.enqueuedAt))
+		})
+	}
+
+	for len(l.queue) > 0 && (l.maxConcurrent <= 0 || l.running < l.maxConcurrent) {
+		item := l.queue[0]
+		l.queue = l.queue[1:]
+		l.running++
+		workLaneQueueLength.Add(context.Background(), -1, laneAttributes(l.name)...)
+		go l.runItem(item)
+	}
+}
+
+func (l *workLane[T]) runItem(item laneItem[T]) {
+	defer func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.running--
+		l.dispatch()
+	}()
+
+	wait := time.Since(item.enqueuedAt)
+	workLaneWait.Record(context.Background(), wait.Seconds(), laneAttributes(l.name)...)
+	log.Trace().
+		Str("lane", l.name).
+		Dur("queueWait", wait).
+		Msg("Running task after waiting in its lane")
+	l.run(item.task)
+}
@@ -188,6 +188,23 @@ func GetEventTracerPath() string {
 	return filepath.Join(configPath, "bacalhau-event-tracer.json")
 }
 
+// GetSpoolPath returns the directory that jobs created while offline are written to until they are submitted.
+func GetSpoolPath() string {
+	return filepath.Join(GetConfigPath(), "spool")
+}
+
+// GetImageGCRecordPath returns the file the docker images the node pulled for jobs are recorded in, which are the
+// only images it removes to free disk space.
+func GetImageGCRecordPath() string {
+	return filepath.Join(GetConfigPath(), "docker-images.json")
+}
+
+// GetIPFSRetentionRecordPath returns the file the results the node published to IPFS are recorded in until they expire
+// and are unpinned.
+func GetIPFSRetentionRecordPath(nodeID string) string {
+	return filepath.Join(GetConfigPath(), "ipfs-retention-"+nodeID+".json")
+}
+
 func GetConfigPath() string {
 	suffix := ".bacalhau"
 	env := os.Getenv("BACALHAU_PATH")
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+)
+
+const ClientConfigFilename = "config.yaml"
+
+// ClientContext holds the settings used by the CLI to talk to a cluster.
+type ClientContext struct {
+	APIHost string `json:"APIHost,omitempty"`
+	APIPort uint16 `json:"APIPort,omitempty"`
+	// Token is sent as a bearer token with every request to the API.
+	Token string `json:"Token,omitempty"`
+	// Namespace is the namespace the commands act in, which admins use to act in the namespace of other clients.
+	Namespace string                 `json:"Namespace,omitempty"`
+	Download  ClientDownloadDefaults `json:"Download,omitempty"`
+}
+
+// ClientDownloadDefaults are the default settings used when downloading results in a context.
+type ClientDownloadDefaults struct {
+	Timeout        time.Duration `json:"Timeout,omitempty"`
+	OutputDir      string        `json:"OutputDir,omitempty"`
+	IPFSSwarmAddrs string        `json:"IPFSSwarmAddrs,omitempty"`
+	IPFSGateways   string        `json:"IPFSGateways,omitempty"`
+}
+
+// ClientConfig is the configuration of the CLI, made of named contexts that can be switched between, like a
+// kubeconfig.
+type ClientConfig struct {
+	CurrentContext string                   `json:"CurrentContext,omitempty"`
+	Contexts       map[string]ClientContext `json:"Contexts,omitempty"`
+	// TrustedPeers maps the endpoints (host:port) of requesters to the libp2p peer ID they proved on first use.
+	TrustedPeers map[string]string `json:"TrustedPeers,omitempty"`
+	TrustMode    TrustMode         `json:"TrustMode,omitempty"`
+}
+
+func GetClientConfigPath() string {
+	return filepath.Join(GetConfigPath(), ClientConfigFilename)
+}
+
+// LoadClientConfig reads the CLI configuration, which is empty if it has never been saved.
+func LoadClientConfig() (*ClientConfig, error) {
+	clientConfig := &ClientConfig{Contexts: map[string]ClientContext{}}
+	data, err := os.ReadFile(GetClientConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return clientConfig, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, clientConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", GetClientConfigPath(), err)
+	}
+	if clientConfig.Contexts == nil {
+		clientConfig.Contexts = map[string]ClientContext{}
+	}
+	return clientConfig, nil
+}
+
+// Save writes the CLI configuration, readable only by the user as it can hold tokens.
+func (c *ClientConfig) Save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetClientConfigPath(), data, util.OS_USER_RW)
+}
+
+// Context returns the named context, or the current context if name is empty. The returned name is empty if no
+// context is selected.
+func (c *ClientConfig) Context(name string) (string, ClientContext, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return "", ClientContext{}, nil
+	}
+	clientContext, ok := c.Contexts[name]
+	if !ok {
+		return "", ClientContext{}, fmt.Errorf("context %q not found", name)
+	}
+	return name, clientContext, nil
+}
+
+// ContextNames returns the names of all contexts, sorted.
+func (c *ClientConfig) ContextNames() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
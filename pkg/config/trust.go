@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TrustMode is what the CLI does when a requester proves a different identity than the one it was trusted with.
+type TrustMode string
+
+const (
+	// TrustModeWarn warns that the requester could be impersonated, and talks to it anyway.
+	TrustModeWarn TrustMode = "warn"
+	// TrustModeRefuse refuses to talk to the requester.
+	TrustModeRefuse TrustMode = "refuse"
+)
+
+func ParseTrustMode(mode string) (TrustMode, error) {
+	switch TrustMode(mode) {
+	case TrustModeWarn, TrustModeRefuse:
+		return TrustMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown trust mode %q, expected %q or %q", mode, TrustModeWarn, TrustModeRefuse)
+	}
+}
+
+// GetTrustMode returns the configured trust mode, which is to warn by default.
+func (c *ClientConfig) GetTrustMode() TrustMode {
+	if c.TrustMode == "" {
+		return TrustModeWarn
+	}
+	return c.TrustMode
+}
+
+// TrustedPeer returns the peer ID the requester at the endpoint (host:port) was trusted with, if any.
+func (c *ClientConfig) TrustedPeer(endpoint string) (string, bool) {
+	peerID, ok := c.TrustedPeers[endpoint]
+	return peerID, ok
+}
+
+// Trust records the peer ID of the requester at the endpoint, replacing the one it was trusted with before.
+func (c *ClientConfig) Trust(endpoint string, peerID string) {
+	if c.TrustedPeers == nil {
+		c.TrustedPeers = map[string]string{}
+	}
+	c.TrustedPeers[endpoint] = peerID
+}
+
+// Untrust forgets the peer ID of the requester at the endpoint, so that it is trusted again on next use. It returns
+// whether the requester was trusted.
+func (c *ClientConfig) Untrust(endpoint string) bool {
+	_, ok := c.TrustedPeers[endpoint]
+	delete(c.TrustedPeers, endpoint)
+	return ok
+}
+
+// TrustedEndpoints returns the endpoints of all trusted requesters, sorted.
+func (c *ClientConfig) TrustedEndpoints() []string {
+	endpoints := make([]string, 0, len(c.TrustedPeers))
+	for endpoint := range c.TrustedPeers {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
@@ -0,0 +1,462 @@
+package devstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+)
+
+const (
+	DefaultContainerImage = "ghcr.io/bacalhau-project/bacalhau:latest"
+	defaultIPFSImage      = "ipfs/kubo:v0.18.1"
+
+	containerLabel     = "bacalhau-devstack"
+	containerAPIPort   = 1234
+	containerSwarmPort = 1235
+	ipfsAPIPort        = 5001
+	ipfsSwarmPort      = 4001
+
+	containerStartTimeout = 2 * time.Minute
+)
+
+// privateIPFSInitScript is run by the IPFS containers before their daemon starts, so that they only connect to
+// each other rather than to the public IPFS network.
+const privateIPFSInitScript = `#!/bin/sh
+ipfs bootstrap rm --all
+ipfs config --json Discovery.MDNS.Enabled false
+`
+
+// ContainerNode is a devstack node running in a docker container. Each node is paired with an IPFS container that
+// owns the network namespace of both, so disconnecting the pair from the devstack network partitions the node
+// together with its IPFS node.
+type ContainerNode struct {
+	stack *ContainerStack
+	// Name is the name of the node on the devstack network
+	Name            string
+	ID              string
+	IsRequesterNode bool
+	IsComputeNode   bool
+	// APIHost and APIPort are where the node's API is published on the docker host
+	APIHost string
+	APIPort uint16
+	// IPFSAPIAddress is the multiaddress where the node's IPFS API is published on the docker host
+	IPFSAPIAddress string
+
+	networkContainer string
+	nodeContainer    string
+}
+
+// ContainerStack is a devstack whose nodes run in docker containers on a shared docker network, rather than in the
+// current process. This gives each node real process isolation, and lets tests restart nodes and partition them from
+// the network.
+type ContainerStack struct {
+	client    *docker.Client
+	runID     string
+	network   string
+	image     string
+	ipfsImage string
+	// sharedDir is mounted at the same path in every container, and used as the temp dir of the nodes, so that the
+	// paths compute nodes mount into job containers exist on the docker host too.
+	sharedDir      string
+	publicIPFSMode bool
+
+	Nodes []*ContainerNode
+}
+
+func newContainerStack(ctx context.Context, options DevStackOptions) (*ContainerStack, error) {
+	image := options.ContainerImage
+	if image == "" {
+		image = DefaultContainerImage
+	}
+
+	dockerClient, err := docker.NewDockerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := config.GetDockerCredentials()
+	for _, img := range []string{image, defaultIPFSImage} {
+		if err = dockerClient.PullImage(ctx, img, creds); err != nil {
+			closer.CloseWithLogOnError("docker", dockerClient)
+			return nil, err
+		}
+	}
+
+	return &ContainerStack{
+		client:         dockerClient,
+		runID:          uuid.NewString()[:8],
+		image:          image,
+		ipfsImage:      defaultIPFSImage,
+		publicIPFSMode: options.PublicIPFSMode,
+	}, nil
+}
+
+// start creates the devstack network and starts the nodes on it. This is separated from the constructor so the user
+// can cancel and still have the containers cleaned up via Close.
+func (s *ContainerStack) start(ctx context.Context, options DevStackOptions) error {
+	sharedDir, err := os.MkdirTemp("", "bacalhau-devstack-shared-dir")
+	if err != nil {
+		return err
+	}
+	// Containers may be running as a different user, so need to be sure that they can use the directory
+	if err = os.Chmod(sharedDir, util.OS_ALL_RWX); err != nil {
+		return err
+	}
+	s.sharedDir = sharedDir
+
+	if !s.publicIPFSMode {
+		scriptPath := filepath.Join(sharedDir, "ipfs-init.d", "private.sh")
+		if err = os.MkdirAll(filepath.Dir(scriptPath), util.OS_ALL_RWX); err != nil {
+			return err
+		}
+		if err = os.WriteFile(scriptPath, []byte(privateIPFSInitScript), util.OS_ALL_RWX); err != nil {
+			return err
+		}
+	}
+
+	networkResp, err := s.client.NetworkCreate(ctx, s.objectName("network"), dockertypes.NetworkCreate{
+		Driver:     "bridge",
+		Attachable: true,
+		Labels:     s.labels(),
+	})
+	if err != nil {
+		return err
+	}
+	s.network = networkResp.ID
+
+	totalNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes + options.NumberOfComputeOnlyNodes
+	requesterNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes
+	computeNodeCount := options.NumberOfHybridNodes + options.NumberOfComputeOnlyNodes
+
+	for i := 0; i < totalNodeCount; i++ {
+		n := &ContainerNode{
+			stack:           s,
+			Name:            s.objectName(fmt.Sprintf("node-%d", i)),
+			IsRequesterNode: i < requesterNodeCount,
+			IsComputeNode:   (totalNodeCount - i) <= computeNodeCount,
+		}
+		// the node must be tracked before it is started so that it is still closed if starting fails
+		s.Nodes = append(s.Nodes, n)
+
+		peers := "none"
+		if i > 0 {
+			peers = s.Nodes[0].PeerAddress()
+		} else if options.Peer != "" {
+			peers = options.Peer
+		}
+		if err = n.start(ctx, peers); err != nil {
+			return fmt.Errorf("failed to start node %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (n *ContainerNode) start(ctx context.Context, peers string) error {
+	s := n.stack
+
+	// The IPFS container owns the network of the pair, so it publishes the ports of both
+	ipfsMounts := []mount.Mount{}
+	if !s.publicIPFSMode {
+		ipfsMounts = append(ipfsMounts, mount.Mount{
+			Type:     mount.TypeBind,
+			ReadOnly: true,
+			Source:   filepath.Join(s.sharedDir, "ipfs-init.d"),
+			Target:   "/container-init.d",
+		})
+	}
+	apiPort, ipfsPort := nat.Port(fmt.Sprintf("%d/tcp", containerAPIPort)), nat.Port(fmt.Sprintf("%d/tcp", ipfsAPIPort))
+	networkContainer, err := s.client.ContainerCreate(ctx, &container.Config{
+		Image:        s.ipfsImage,
+		Labels:       s.labels(),
+		ExposedPorts: nat.PortSet{apiPort: {}, ipfsPort: {}},
+	}, &container.HostConfig{
+		PortBindings: nat.PortMap{
+			apiPort:  {{HostIP: "127.0.0.1"}},
+			ipfsPort: {{HostIP: "127.0.0.1"}},
+		},
+		Mounts: ipfsMounts,
+	}, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			s.network: {Aliases: []string{n.Name}},
+		},
+	}, nil, n.Name)
+	if err != nil {
+		return err
+	}
+	n.networkContainer = networkContainer.ID
+	if err = s.client.ContainerStart(ctx, n.networkContainer, dockertypes.ContainerStartOptions{}); err != nil {
+		return err
+	}
+
+	state, err := s.client.ContainerInspect(ctx, n.networkContainer)
+	if err != nil {
+		return err
+	}
+	publishedAPIPort, err := strconv.ParseUint(hostPort(state, containerAPIPort), 10, 16)
+	if err != nil {
+		return fmt.Errorf("failed to find published API port: %w", err)
+	}
+	n.APIHost = "127.0.0.1"
+	n.APIPort = uint16(publishedAPIPort)
+	n.IPFSAPIAddress = fmt.Sprintf("/ip4/127.0.0.1/tcp/%s", hostPort(state, ipfsAPIPort))
+
+	ipfsClient, err := n.waitForIPFS(ctx)
+	if err != nil {
+		return err
+	}
+	if first := s.Nodes[0]; first != n && !s.publicIPFSMode {
+		if err = connectIPFS(ctx, ipfsClient, first); err != nil {
+			return err
+		}
+	}
+
+	nodeTypes := []string{}
+	if n.IsRequesterNode {
+		nodeTypes = append(nodeTypes, "requester")
+	}
+	mounts := []mount.Mount{{
+		Type:   mount.TypeBind,
+		Source: s.sharedDir,
+		Target: s.sharedDir,
+	}}
+	if n.IsComputeNode {
+		nodeTypes = append(nodeTypes, "compute")
+		// compute nodes run jobs as sibling containers on the docker host
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: "/var/run/docker.sock",
+			Target: "/var/run/docker.sock",
+		})
+	}
+
+	nodeContainer, err := s.client.ContainerCreate(ctx, &container.Config{
+		Image: s.image,
+		Cmd: []string{
+			"serve",
+			"--node-type", strings.Join(nodeTypes, ","),
+			"--peer", peers,
+			"--ipfs-connect", fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", ipfsAPIPort),
+			"--host", "0.0.0.0",
+			"--api-port", strconv.Itoa(containerAPIPort),
+			"--swarm-port", strconv.Itoa(containerSwarmPort),
+			"--labels", fmt.Sprintf("name=%s,env=devstack", n.Name),
+		},
+		Env:    []string{"TMPDIR=" + s.sharedDir},
+		Labels: s.labels(),
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + n.networkContainer),
+		Mounts:      mounts,
+	}, nil, nil, n.Name+"-bacalhau")
+	if err != nil {
+		return err
+	}
+	n.nodeContainer = nodeContainer.ID
+
+	log.Ctx(ctx).Debug().
+		Str("Name", n.Name).
+		Strs("NodeTypes", nodeTypes).
+		Str("Peers", peers).
+		Msg("Starting devstack node container")
+	if err = s.client.ContainerStart(ctx, n.nodeContainer, dockertypes.ContainerStartOptions{}); err != nil {
+		return err
+	}
+	return n.waitForNode(ctx)
+}
+
+// hostPort returns the port on the docker host that the container port is published to.
+func hostPort(state dockertypes.ContainerJSON, port int) string {
+	bindings := state.NetworkSettings.Ports[nat.Port(fmt.Sprintf("%d/tcp", port))]
+	if len(bindings) == 0 {
+		return ""
+	}
+	return bindings[0].HostPort
+}
+
+func (n *ContainerNode) waitForIPFS(ctx context.Context) (ipfs.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, containerStartTimeout)
+	defer cancel()
+
+	for {
+		client, err := ipfs.NewClientUsingRemoteHandler(ctx, n.IPFSAPIAddress)
+		if err == nil {
+			return client, nil
+		}
+		if ctx.Err() != nil {
+			return ipfs.Client{}, fmt.Errorf("IPFS node of %s did not start: %w", n.Name, err)
+		}
+		log.Ctx(ctx).Debug().Err(err).Str("Name", n.Name).Msg("IPFS node not ready yet")
+		time.Sleep(time.Second)
+	}
+}
+
+// connectIPFS connects the IPFS node to the IPFS node of another devstack node, over the devstack network.
+func connectIPFS(ctx context.Context, client ipfs.Client, to *ContainerNode) error {
+	toClient, err := ipfs.NewClientUsingRemoteHandler(ctx, to.IPFSAPIAddress)
+	if err != nil {
+		return err
+	}
+	toID, err := toClient.ID(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := peer.Decode(toID)
+	if err != nil {
+		return err
+	}
+	addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/dns4/%s/tcp/%d", to.Name, ipfsSwarmPort))
+	if err != nil {
+		return err
+	}
+	return client.API.Swarm().Connect(ctx, peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{addr}})
+}
+
+// waitForNode waits for the node's API to come up, and records the node's ID.
+func (n *ContainerNode) waitForNode(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, containerStartTimeout)
+	defer cancel()
+
+	client := publicapi.NewAPIClient(n.APIHost, n.APIPort)
+	for {
+		if alive, _ := client.Alive(ctx); alive {
+			break
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("node %s did not start: %w", n.Name, ctx.Err())
+		}
+		log.Ctx(ctx).Debug().Str("Name", n.Name).Msg("Node not ready yet")
+		time.Sleep(time.Second)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.BaseURI.JoinPath("id").String(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closer.DrainAndCloseWithLogOnError(ctx, "node id response", res.Body)
+	return json.NewDecoder(res.Body).Decode(&n.ID)
+}
+
+// PeerAddress returns the libp2p address of the node on the devstack network.
+func (n *ContainerNode) PeerAddress() string {
+	return fmt.Sprintf("/dns4/%s/tcp/%d/p2p/%s", n.Name, containerSwarmPort, n.ID)
+}
+
+// Restart restarts the bacalhau process of the node, and waits for it to come back up.
+func (n *ContainerNode) Restart(ctx context.Context) error {
+	if err := n.stack.client.ContainerRestart(ctx, n.nodeContainer, 10*time.Second); err != nil { //nolint:gomnd
+		return err
+	}
+	return n.waitForNode(ctx)
+}
+
+// Disconnect partitions the node and its IPFS node from the rest of the devstack, like `docker network disconnect`.
+// Note that the node's API is not reachable from the docker host either while it is disconnected.
+func (n *ContainerNode) Disconnect(ctx context.Context) error {
+	return n.stack.client.NetworkDisconnect(ctx, n.stack.network, n.networkContainer, false)
+}
+
+// Reconnect reconnects a disconnected node to the rest of the devstack.
+func (n *ContainerNode) Reconnect(ctx context.Context) error {
+	return n.stack.client.NetworkConnect(ctx, n.stack.network, n.networkContainer, &network.EndpointSettings{
+		Aliases: []string{n.Name},
+	})
+}
+
+func (s *ContainerStack) printNodeInfo(ctx context.Context, cm *system.CleanupManager) (string, error) {
+	nodeInfo := ""
+	for i, n := range s.Nodes {
+		nodeInfo += fmt.Sprintf(`
+export BACALHAU_IPFS_%d=%s
+export BACALHAU_PEER_CONNECT_%d=%s
+export BACALHAU_API_HOST_%d=%s
+export BACALHAU_API_PORT_%d=%d`,
+			i, n.IPFSAPIAddress,
+			i, n.PeerAddress(),
+			i, n.APIHost,
+			i, n.APIPort,
+		)
+	}
+
+	first := s.Nodes[0]
+	summaryShellVariablesString := fmt.Sprintf(`
+export BACALHAU_API_HOST=%s
+export BACALHAU_API_PORT=%d`,
+		first.APIHost,
+		first.APIPort,
+	)
+	err := config.WriteRunInfoFile(ctx, summaryShellVariablesString)
+	if err != nil {
+		return "", err
+	}
+	cm.RegisterCallback(config.CleanupRunInfoFile)
+
+	return fmt.Sprintf(`
+Devstack is ready, with %d nodes running in docker containers on the %s network!
+Node containers can be restarted with "docker restart <name>-bacalhau", and partitioned from the
+network with "docker network disconnect %s <name>".
+
+Nodes: %s
+
+To use the devstack, run the following commands in your shell: %s
+
+The above variables were also written to this file (will be deleted when devstack exits): %s`,
+		len(s.Nodes),
+		s.objectName("network"),
+		s.objectName("network"),
+		nodeInfo,
+		summaryShellVariablesString,
+		config.GetRunInfoFilePath()), nil
+}
+
+func (s *ContainerStack) objectName(name string) string {
+	return fmt.Sprintf("%s-%s-%s", containerLabel, s.runID, name)
+}
+
+func (s *ContainerStack) labels() map[string]string {
+	return map[string]string{containerLabel: s.runID}
+}
+
+// Close removes the containers and network of the devstack.
+func (s *ContainerStack) Close(ctx context.Context) error {
+	var errs error
+
+	defer closer.CloseWithLogOnError("Docker client", s.client)
+	if err := s.client.RemoveObjectsWithLabel(ctx, containerLabel, s.runID); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if s.sharedDir != "" {
+		if err := os.RemoveAll(s.sharedDir); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	return errs
+}
@@ -41,11 +41,17 @@ type DevStackOptions struct {
 	MemoryProfilingFile        string
 	DisabledFeatures           node.FeatureConfig
 	AllowListedLocalPaths      []string // Local paths that are allowed to be mounted into jobs
+	ContainerMode              bool     // Run each node and its IPFS node in docker containers instead of in-process
+	ContainerImage             string   // The bacalhau image to run nodes with in container mode
+	// The IP versions in-process nodes listen on. If empty, libp2p listens on both, and the API and IPFS on IPv4.
+	NetworkStack multiaddresses.NetworkStack
 }
 type DevStack struct {
 	Nodes          []*node.Node
 	Lotus          *LotusNode
 	PublicIPFSMode bool
+	// Containers are the nodes of the devstack when running in container mode, in which case Nodes is empty
+	Containers *ContainerStack
 }
 
 func NewDevStackForRunLocal(
@@ -109,6 +115,15 @@ func NewDevStack(
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/devstack.NewDevStack")
 	defer span.End()
 
+	if options.ContainerMode {
+		return newContainerDevStack(ctx, cm, options)
+	}
+
+	hostAddress, networkStack := "0.0.0.0", multiaddresses.NetworkStackDual
+	if options.NetworkStack != "" {
+		hostAddress, networkStack = options.NetworkStack.UnspecifiedHost(), options.NetworkStack
+	}
+
 	var nodes []*node.Node
 	var lotus *LotusNode
 	var err error
@@ -146,6 +161,7 @@ func NewDevStack(
 	if requesterNodeCount == 0 {
 		return nil, fmt.Errorf("at least one requester node is required")
 	}
+	var requesterIDs []string
 	for i := 0; i < totalNodeCount; i++ {
 		isRequesterNode := i < requesterNodeCount
 		isComputeNode := (totalNodeCount - i) <= computeNodeCount
@@ -167,7 +183,7 @@ func NewDevStack(
 			ipfsSwarmAddresses = append(ipfsSwarmAddresses, addresses[0])
 		}
 
-		ipfsNode, err := createIPFSNode(ctx, cm, options.PublicIPFSMode, ipfsSwarmAddresses)
+		ipfsNode, err := createIPFSNode(ctx, cm, options.PublicIPFSMode, options.NetworkStack, ipfsSwarmAddresses)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create ipfs node: %w", err)
 		}
@@ -207,7 +223,7 @@ func NewDevStack(
 			log.Ctx(ctx).Debug().Msgf("Connecting to first libp2p requester node: %s", libp2pPeer)
 		}
 
-		libp2pHost, err := libp2p.NewHost(libp2pPort)
+		libp2pHost, err := libp2p.NewHostWithNetworkStack(libp2pPort, networkStack)
 		if err != nil {
 			return nil, err
 		}
@@ -252,6 +268,12 @@ func NewDevStack(
 			simulatorNodeID = libp2pHost.ID().String()
 		}
 
+		// the compute nodes take capacity reservations from the requesters of the stack, which are created first
+		computeConfig.TrustedRequesters = append([]string(nil), requesterIDs...)
+		if isRequesterNode {
+			requesterIDs = append(requesterIDs, libp2pHost.ID().String())
+		}
+
 		nodeConfig := node.NodeConfig{
 			IPFSClient:           ipfsNode.Client(),
 			CleanupManager:       cm,
@@ -259,7 +281,7 @@ func NewDevStack(
 			Host:                 libp2pHost,
 			FilecoinUnsealedPath: options.FilecoinUnsealedPath,
 			EstuaryAPIKey:        options.EstuaryAPIKey,
-			HostAddress:          "0.0.0.0",
+			HostAddress:          hostAddress,
 			APIPort:              apiPort,
 			ComputeConfig:        computeConfig,
 			RequesterNodeConfig:  requesterNodeConfig,
@@ -331,29 +353,62 @@ func NewDevStack(
 	}, nil
 }
 
+// newContainerDevStack starts the devstack nodes as docker containers. The nodes run with the default configuration of
+// `bacalhau serve`, so the compute and requester configs and dependency injectors of in-process nodes don't apply.
+func newContainerDevStack(ctx context.Context, cm *system.CleanupManager, options DevStackOptions) (*DevStack, error) {
+	if options.NumberOfHybridNodes+options.NumberOfRequesterOnlyNodes == 0 {
+		return nil, fmt.Errorf("at least one requester node is required")
+	}
+	if options.SimulatorMode || options.SimulatorAddr != "" || options.LocalNetworkLotus ||
+		options.NumberOfBadComputeActors > 0 || options.NumberOfBadRequesterActors > 0 {
+		return nil, fmt.Errorf("simulator, lotus and bad actor nodes are not supported in container mode")
+	}
+	if options.NetworkStack == multiaddresses.NetworkStackIPv6 {
+		return nil, fmt.Errorf("IPv6-only nodes are not supported in container mode, as docker networks use IPv4")
+	}
+
+	containers, err := newContainerStack(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	cm.RegisterCallbackWithContext(containers.Close)
+
+	if err = containers.start(ctx, options); err != nil {
+		return nil, err
+	}
+
+	return &DevStack{
+		PublicIPFSMode: options.PublicIPFSMode,
+		Containers:     containers,
+	}, nil
+}
+
 func createIPFSNode(ctx context.Context,
 	cm *system.CleanupManager,
 	publicIPFSMode bool,
+	networkStack multiaddresses.NetworkStack,
 	ipfsSwarmAddresses []string) (*ipfs.Node, error) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/devstack.createIPFSNode")
 	defer span.End()
 	//////////////////////////////////////
 	// IPFS
 	//////////////////////////////////////
-	var err error
-	var ipfsNode *ipfs.Node
-
+	cfg := ipfs.Config{
+		Mode:         ipfs.ModeLocal,
+		PeerAddrs:    ipfsSwarmAddresses,
+		NetworkStack: networkStack,
+	}
 	if publicIPFSMode {
-		ipfsNode, err = ipfs.NewNode(ctx, cm, []string{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create ipfs node: %w", err)
-		}
-	} else {
-		ipfsNode, err = ipfs.NewLocalNode(ctx, cm, ipfsSwarmAddresses)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create ipfs node: %w", err)
+		cfg = ipfs.Config{
+			Mode:         ipfs.ModeDefault,
+			NetworkStack: networkStack,
 		}
 	}
+
+	ipfsNode, err := ipfs.NewNodeWithConfig(ctx, cm, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ipfs node: %w", err)
+	}
 	return ipfsNode, nil
 }
 
@@ -362,6 +417,9 @@ func (stack *DevStack) PrintNodeInfo(ctx context.Context, cm *system.CleanupMana
 	if !config.DevstackGetShouldPrintInfo() {
 		return "", nil
 	}
+	if stack.Containers != nil {
+		return stack.Containers.printNodeInfo(ctx, cm)
+	}
 
 	logString := ""
 	devStackAPIPort := fmt.Sprintf("%d", stack.Nodes[0].APIServer.Port)
@@ -0,0 +1,252 @@
+package devstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/node"
+)
+
+// cpuTolerance is the difference in CPU below which capacity is considered released.
+const cpuTolerance = 1e-6
+
+// InvariantCheck checks an invariant that must hold for the nodes of the devstack once they are idle, such as at the
+// end of a test, and returns an error describing every violation it finds.
+type InvariantCheck func(ctx context.Context, stack *DevStack) error
+
+// DefaultInvariantChecks are the invariants checked by CheckInvariants if it is not given any.
+var DefaultInvariantChecks = []InvariantCheck{
+	CheckNoOrphanedWorkloads,
+	CheckCapacityReleased,
+	CheckJobStoreConsistency,
+}
+
+// CheckInvariants runs the checks, or DefaultInvariantChecks if none are given, against the in-process nodes of the
+// devstack, and returns the violations they found. The nodes of a devstack in container mode are not checked.
+//
+// Some invariants only hold once the nodes have finished handling the jobs of the test, so callers should retry the
+// checks for a while rather than fail on the first violation.
+func (stack *DevStack) CheckInvariants(ctx context.Context, checks ...InvariantCheck) error {
+	if len(checks) == 0 {
+		checks = DefaultInvariantChecks
+	}
+	var errs []error
+	for _, check := range checks {
+		if err := check(ctx, stack); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckNoOrphanedWorkloads checks that the executors of the compute nodes have no workloads, such as containers,
+// left behind by executions that have ended or that the nodes don't know about.
+func CheckNoOrphanedWorkloads(ctx context.Context, stack *DevStack) error {
+	var errs []error
+	for _, n := range stack.computeNodes() {
+		for _, engine := range model.EngineTypes() {
+			if !n.ComputeNode.Executors.Has(ctx, engine) {
+				continue
+			}
+			e, err := n.ComputeNode.Executors.Get(ctx, engine)
+			if err != nil {
+				continue
+			}
+			orphanExecutor, ok := e.(executor.OrphanExecutor)
+			if !ok {
+				continue
+			}
+			workloads, err := orphanExecutor.ListWorkloads(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("node %s: listing the workloads of the %s executor: %w", n.Host.ID(), engine, err))
+				continue
+			}
+			for _, workload := range workloads {
+				execution, err := n.ComputeNode.ExecutionStore.GetExecution(ctx, workload.ExecutionID)
+				switch {
+				case errors.As(err, &store.ErrExecutionNotFound{}):
+					errs = append(errs, fmt.Errorf("node %s: the %s executor has a workload of unknown execution %s",
+						n.Host.ID(), engine, workload.ExecutionID))
+				case err != nil:
+					errs = append(errs, fmt.Errorf("node %s: getting execution %s: %w", n.Host.ID(), workload.ExecutionID, err))
+				case execution.State.IsTerminal():
+					errs = append(errs, fmt.Errorf("node %s: the %s executor still has the workload of execution %s, which is %s",
+						n.Host.ID(), engine, workload.ExecutionID, execution.State))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckCapacityReleased checks that the compute nodes that have no active executions have all their capacity
+// available, so that no execution kept hold of its resources once it ended.
+func CheckCapacityReleased(ctx context.Context, stack *DevStack) error {
+	jobs, err := stack.allJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, n := range stack.computeNodes() {
+		active, err := activeExecutions(ctx, n, jobs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(active) > 0 {
+			continue
+		}
+		available := n.ComputeNode.Capacity.GetAvailableCapacity(ctx)
+		maxCapacity := n.ComputeNode.Capacity.GetMaxCapacity(ctx)
+		inUse := maxCapacity.Sub(available)
+		// CPU is tracked as a float, so allow for the rounding of adding and removing fractions of a core
+		if math.Abs(inUse.CPU) < cpuTolerance {
+			inUse.CPU = 0
+		}
+		if !inUse.IsZero() {
+			errs = append(errs, fmt.Errorf("node %s: has no active executions but %s of its capacity is still in use",
+				n.Host.ID(), inUse.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckJobStoreConsistency checks that the state of every job held by the requester nodes is the state their history
+// of events replays to, and that jobs that ended have no execution that is still active, either in the job store or
+// on the compute nodes.
+func CheckJobStoreConsistency(ctx context.Context, stack *DevStack) error {
+	var errs []error
+	for _, n := range stack.Nodes {
+		if n.RequesterNode == nil {
+			continue
+		}
+		jobStore := n.RequesterNode.JobStore
+		jobs, err := jobStore.GetJobs(ctx, jobstore.JobQuery{ReturnAll: true})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: listing jobs: %w", n.Host.ID(), err))
+			continue
+		}
+		for _, j := range jobs {
+			if err = checkJobState(ctx, jobStore, j.Metadata.ID); err != nil {
+				errs = append(errs, fmt.Errorf("node %s: %w", n.Host.ID(), err))
+			}
+		}
+	}
+
+	jobs, err := stack.allJobs(ctx)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, n := range stack.computeNodes() {
+		active, err := activeExecutions(ctx, n, jobs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, execution := range active {
+			if jobs[execution.Job.Metadata.ID] {
+				errs = append(errs, fmt.Errorf("node %s: execution %s is %s but job %s has ended",
+					n.Host.ID(), execution.ID, execution.State, execution.Job.Metadata.ID))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkJobState compares the state of the job with the state its events replay to.
+func checkJobState(ctx context.Context, jobStore jobstore.Store, jobID string) error {
+	state, err := jobStore.GetJobState(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("getting the state of job %s: %w", jobID, err)
+	}
+	// events are recorded after the state they lead to is updated, so the events are replayed up to now
+	replayed, err := jobStore.GetJobStateAt(ctx, jobID, time.Now())
+	if err != nil {
+		return fmt.Errorf("replaying the events of job %s: %w", jobID, err)
+	}
+
+	var errs []error
+	if replayed.State != state.State {
+		errs = append(errs, fmt.Errorf("job %s is %s but its events replay to %s", jobID, state.State, replayed.State))
+	}
+	replayedExecutions := make(map[string]model.ExecutionStateType, len(replayed.Executions))
+	for _, execution := range replayed.Executions {
+		replayedExecutions[execution.ID().String()] = execution.State
+	}
+	for _, execution := range state.Executions {
+		replayedState, ok := replayedExecutions[execution.ID().String()]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("job %s has execution %s that is missing from its events", jobID, execution.ID()))
+		case replayedState != execution.State:
+			errs = append(errs, fmt.Errorf("execution %s of job %s is %s but its events replay to %s",
+				execution.ID(), jobID, execution.State, replayedState))
+		}
+		if state.State.IsTerminal() && !execution.State.IsTerminal() {
+			errs = append(errs, fmt.Errorf("job %s is %s but its execution %s is still %s",
+				jobID, state.State, execution.ID(), execution.State))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// computeNodes returns the nodes of the devstack that run a compute node.
+func (stack *DevStack) computeNodes() []*node.Node {
+	var nodes []*node.Node
+	for _, n := range stack.Nodes {
+		if n.ComputeNode != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// allJobs returns the IDs of the jobs of every requester node of the devstack, and whether each has ended.
+func (stack *DevStack) allJobs(ctx context.Context) (map[string]bool, error) {
+	jobs := make(map[string]bool)
+	for _, n := range stack.Nodes {
+		if n.RequesterNode == nil {
+			continue
+		}
+		states, err := n.RequesterNode.JobStore.GetJobs(ctx, jobstore.JobQuery{ReturnAll: true})
+		if err != nil {
+			return nil, fmt.Errorf("node %s: listing jobs: %w", n.Host.ID(), err)
+		}
+		for _, j := range states {
+			state, err := n.RequesterNode.JobStore.GetJobState(ctx, j.Metadata.ID)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: getting the state of job %s: %w", n.Host.ID(), j.Metadata.ID, err)
+			}
+			jobs[j.Metadata.ID] = state.State.IsTerminal()
+		}
+	}
+	return jobs, nil
+}
+
+// activeExecutions returns the executions of the jobs that the compute node has not finished with.
+func activeExecutions(ctx context.Context, n *node.Node, jobs map[string]bool) ([]store.Execution, error) {
+	var active []store.Execution
+	for jobID := range jobs {
+		executions, err := n.ComputeNode.ExecutionStore.GetExecutions(ctx, jobID)
+		if err != nil {
+			if errors.As(err, &store.ErrExecutionsNotFoundForJob{}) {
+				continue
+			}
+			return nil, fmt.Errorf("node %s: getting the executions of job %s: %w", n.Host.ID(), jobID, err)
+		}
+		for _, execution := range executions {
+			if execution.State.IsActive() {
+				active = append(active, execution)
+			}
+		}
+	}
+	return active, nil
+}
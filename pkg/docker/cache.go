@@ -15,6 +15,8 @@ var DockerTagCache cache.Cache[string]
 //nolint:unused
 var DockerManifestCache cache.Cache[ImageManifest]
 
+var DockerScanCache cache.Cache[ImageScanResult]
+
 const DefaultCacheSize = uint64(1000)
 const DefaultCacheDuration = time.Hour
 
@@ -24,6 +26,9 @@ const tagCacheDurationEnvVar = "DOCKER_TAG_CACHE_DURATION"
 const manifestCacheSizeEnvVar = "DOCKER_MANIFEST_CACHE_SIZE"
 const manifestCacheDurationEnvVar = "DOCKER_MANIFEST_CACHE_DURATION"
 
+const scanCacheSizeEnvVar = "DOCKER_SCAN_CACHE_SIZE"
+const scanCacheDurationEnvVar = "DOCKER_SCAN_CACHE_DURATION"
+
 func init() { //nolint:gochecknoinits
 	tagCacheDuration := util.GetEnvAs[time.Duration](
 		tagCacheDurationEnvVar, DefaultCacheDuration, time.ParseDuration,
@@ -31,6 +36,9 @@ func init() { //nolint:gochecknoinits
 	manifestCacheDuration := util.GetEnvAs[time.Duration](
 		manifestCacheDurationEnvVar, DefaultCacheDuration, time.ParseDuration,
 	)
+	scanCacheDuration := util.GetEnvAs[time.Duration](
+		scanCacheDurationEnvVar, DefaultCacheDuration, time.ParseDuration,
+	)
 
 	tagCacheSize := util.GetEnvAs[uint64](
 		tagCacheSizeEnvVar, DefaultCacheSize, func(k string) (uint64, error) {
@@ -40,6 +48,10 @@ func init() { //nolint:gochecknoinits
 		manifestCacheSizeEnvVar, DefaultCacheSize, func(k string) (uint64, error) {
 			return strconv.ParseUint(k, 10, 64)
 		})
+	scanCacheSize := util.GetEnvAs[uint64](
+		scanCacheSizeEnvVar, DefaultCacheSize, func(k string) (uint64, error) {
+			return strconv.ParseUint(k, 10, 64)
+		})
 
 	// Used by the requester node to map user provided docker image identifiers
 	// to a version of the identifier with a digest.
@@ -54,4 +66,19 @@ func init() { //nolint:gochecknoinits
 		basic.WithCleanupFrequency(manifestCacheDuration),
 		basic.WithMaxCost(manifestCacheSize),
 	)
+
+	// Used by compute nodes to map image digests to the vulnerabilities found
+	// when they were scanned, so each image is only scanned once.
+	DockerScanCache, _ = basic.NewCache[ImageScanResult](
+		basic.WithCleanupFrequency(scanCacheDuration),
+		basic.WithMaxCost(scanCacheSize),
+	)
+}
+
+// PurgeCaches drops every cached tag and manifest lookup and image scan,
+// forcing them to be resolved again against the registry on next use.
+func PurgeCaches() {
+	DockerTagCache.Purge()
+	DockerManifestCache.Purge()
+	DockerScanCache.Purge()
 }
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,8 +38,16 @@ const ImagePullError = `Could not pull image %q - could be due to repo/image not
 const DistributionInspectError = `Could not inspect image %q - could be due to repo/image not existing, ` +
 	`or registry needing authorization`
 
+// infoCacheDuration is how long the info of the docker server is reused for. It is read whenever the node bids and
+// publishes its info, and rarely changes.
+const infoCacheDuration = 10 * time.Second
+
 type Client struct {
 	tracing.TracedClient
+
+	infoMu      sync.Mutex
+	info        types.Info
+	infoExpires time.Time
 }
 
 func NewDockerClient() (*Client, error) {
@@ -47,10 +56,27 @@ func NewDockerClient() (*Client, error) {
 		return nil, err
 	}
 	return &Client{
-		client,
+		TracedClient: client,
 	}, nil
 }
 
+// Info returns the info of the docker server, reusing the info read within the last infoCacheDuration. Failures are
+// not cached, so that a docker server that comes up is noticed.
+func (c *Client) Info(ctx context.Context) (types.Info, error) {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if time.Now().Before(c.infoExpires) {
+		return c.info, nil
+	}
+	info, err := c.TracedClient.Info(ctx)
+	if err != nil {
+		return info, err
+	}
+	c.info = info
+	c.infoExpires = time.Now().Add(infoCacheDuration)
+	return info, nil
+}
+
 func (c *Client) IsInstalled(ctx context.Context) bool {
 	_, err := c.Info(ctx)
 	return err == nil
@@ -164,7 +190,9 @@ func (c *Client) FollowLogs(ctx context.Context, id string) (stdout, stderr io.R
 	return stdoutReader, stderrReader, nil
 }
 
-func (c *Client) GetOutputStream(ctx context.Context, id string, since string, follow bool) (io.ReadCloser, error) {
+// GetOutputStream returns the muxed output of the container since the given timestamp, or only its last tail lines if
+// tail is positive.
+func (c *Client) GetOutputStream(ctx context.Context, id string, since string, follow bool, tail int) (io.ReadCloser, error) {
 	cont, err := c.ContainerInspect(ctx, id)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get container")
@@ -182,6 +210,9 @@ func (c *Client) GetOutputStream(ctx context.Context, id string, since string, f
 	if since != "" {
 		logOptions.Since = since
 	}
+	if tail > 0 {
+		logOptions.Tail = strconv.Itoa(tail)
+	}
 
 	ctx = log.Ctx(ctx).With().Str("ContainerID", cont.ID).Str("Image", cont.Image).Logger().WithContext(ctx)
 	logsReader, err := c.ContainerLogs(ctx, cont.ID, logOptions)
@@ -342,6 +373,37 @@ func (c *Client) ImageDistribution(
 	return manifest, nil
 }
 
+// cudaVersionLabel is the image label NVIDIA CUDA base images use to declare the CUDA runtime they ship with.
+const cudaVersionLabel = "com.nvidia.cuda.version"
+
+// cudaVersionEnv is the environment variable NVIDIA CUDA base images set to the CUDA runtime they ship with.
+const cudaVersionEnv = "CUDA_VERSION"
+
+// ImageCUDAVersion returns the CUDA version declared by an image through its labels or environment. Only images already
+// present on the local docker daemon are inspected, and an empty version is returned if the image is missing or does
+// not declare a CUDA version, so images that are missing should be checked again once pulled.
+func (c *Client) ImageCUDAVersion(ctx context.Context, image string) (string, error) {
+	info, _, err := c.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		if dockerclient.IsErrNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if info.Config == nil {
+		return "", nil
+	}
+	if version, ok := info.Config.Labels[cudaVersionLabel]; ok {
+		return version, nil
+	}
+	for _, env := range info.Config.Env {
+		if name, value, found := strings.Cut(env, "="); found && name == cudaVersionEnv {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
 func (c *Client) PullImage(ctx context.Context, image string, dockerCreds config.DockerCredentials) error {
 	_, _, err := c.ImageInspectWithRaw(ctx, image)
 	if err == nil {
@@ -0,0 +1,21 @@
+package docker
+
+// ImageGCConfig configures the garbage collection of the images pulled for jobs, which runs between executions and
+// removes the least recently used images first. Only the images the node pulled itself are removed. Collection is
+// disabled if both limits are zero.
+type ImageGCConfig struct {
+	// MaxTotalSize is the most bytes the images of the docker server can take, counting shared layers once, before
+	// images are removed.
+	MaxTotalSize uint64
+	// MinFreeDisk is the fewest bytes that must be free on the disk of the docker server before images are removed.
+	// It is only enforced if the data directory of the docker server is on the host of the node.
+	MinFreeDisk uint64
+	// RecordPath is the file the images the node pulled are recorded in, so that they are still removed once the
+	// node restarts. They are only recorded in memory if empty.
+	RecordPath string
+}
+
+// Enabled returns true if images are collected.
+func (c ImageGCConfig) Enabled() bool {
+	return c.MaxTotalSize > 0 || c.MinFreeDisk > 0
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
 )
 
 const (
@@ -80,8 +81,7 @@ func NewImageID(imageID string) (*ImageID, error) {
 
 	if digested, ok := repo.(reference.Digested); ok {
 		obj := digested.Digest()
-		digest := fmt.Sprintf("%s:%s", obj.Algorithm().String(), obj.Encoded())
-		id.tag = DigestTag(digest)
+		id.tag = DigestTag(fmt.Sprintf("%s:%s", obj.Algorithm().String(), obj.Encoded()))
 	} else if tagged, ok := repo.(reference.Tagged); ok {
 		id.tag = NameTag(tagged.Tag())
 	} else {
@@ -91,6 +91,21 @@ func NewImageID(imageID string) (*ImageID, error) {
 	return id, nil
 }
 
+// WithTag returns the image with the given tag in place of its tag or digest.
+func (i *ImageID) WithTag(tag string) (*ImageID, error) {
+	tagged := &ImageID{repository: i.repository, name: i.name, tag: NameTag(tag)}
+	// parse the result so that invalid tags are rejected
+	if _, err := reference.Parse(tagged.String()); err != nil {
+		return nil, err
+	}
+	return tagged, nil
+}
+
+// WithDigest returns the image pinned to the given digest in place of its tag or digest.
+func (i *ImageID) WithDigest(imageDigest digest.Digest) *ImageID {
+	return &ImageID{repository: i.repository, name: i.name, tag: DigestTag(imageDigest.String())}
+}
+
 func (i *ImageID) HasDigest() bool {
 	_, ok := i.tag.(DigestTag)
 	return ok
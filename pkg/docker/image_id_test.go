@@ -62,3 +62,26 @@ func (s *ImageIDSuite) TestImageIDStringer() {
 	}
 
 }
+
+func (s *ImageIDSuite) TestImageIDWithTag() {
+	for imageID, expected := range map[string]string{
+		"ubuntu":                            "ubuntu:jammy",
+		"ubuntu:kinetic":                    "ubuntu:jammy",
+		"ghcr.io/organisation/ubuntu:v1":    "ghcr.io/organisation/ubuntu:jammy",
+		"ubuntu:kinetic@sha256:" + digest64: "ubuntu:jammy",
+	} {
+		id, err := NewImageID(imageID)
+		require.NoError(s.T(), err)
+		tagged, err := id.WithTag("jammy")
+		require.NoError(s.T(), err)
+		require.False(s.T(), tagged.HasDigest())
+		require.Equal(s.T(), expected, tagged.String(), imageID)
+	}
+
+	id, err := NewImageID("ubuntu")
+	require.NoError(s.T(), err)
+	_, err = id.WithTag("not a tag")
+	require.Error(s.T(), err)
+}
+
+const digest64 = "6f4ca5ddeb85491f815d6ec8179c72e88ba207fadfaedb130d5c839a6f9e83c7"
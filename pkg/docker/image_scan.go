@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
+)
+
+// ImageScanConfig configures the optional vulnerability scan of job images. Scanning is disabled if Command is empty.
+type ImageScanConfig struct {
+	// Command is run with bash to scan an image, such as a script wrapping Trivy or Grype. The image is passed in the
+	// BACALHAU_IMAGE and BACALHAU_IMAGE_DIGEST environment variables, and the command must print an ImageScanResult
+	// as JSON to stdout.
+	Command string
+	// MaxCritical is the number of critical vulnerabilities an image can have before it is gated.
+	MaxCritical int
+	// WarnOnly bids on gated images anyway, with a warning, instead of rejecting them.
+	WarnOnly bool
+}
+
+// ImageScanResult is the number of vulnerabilities found in an image, by severity.
+type ImageScanResult struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+}
+
+// ScanImage runs the scan command against the image with the given digest.
+func ScanImage(ctx context.Context, command string, image string, imageDigest digest.Digest) (ImageScanResult, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", command) //nolint:gosec
+	cmd.Env = []string{
+		"BACALHAU_IMAGE=" + image,
+		"BACALHAU_IMAGE_DIGEST=" + imageDigest.String(),
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + os.Getenv("HOME"),
+	}
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		logger.LogStream(ctx, &stderr)
+		return ImageScanResult{}, fmt.Errorf("image scan command `%s` failed: %w", command, err)
+	}
+
+	var result ImageScanResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return ImageScanResult{}, fmt.Errorf("image scan command `%s` returned invalid result: %w", command, err)
+	}
+	log.Ctx(ctx).Debug().Str("Image", image).Interface("Result", result).Msg("Scanned image for vulnerabilities")
+	return result, nil
+}
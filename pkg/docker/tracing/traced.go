@@ -68,6 +68,14 @@ func (c TracedClient) ContainerList(ctx context.Context, options types.Container
 	return telemetry.RecordErrorOnSpanTwo[[]types.Container](span)(c.client.ContainerList(ctx, options))
 }
 
+func (c TracedClient) ContainerAttach(
+	ctx context.Context, containerID string, options types.ContainerAttachOptions) (types.HijackedResponse, error) {
+	ctx, span := c.span(ctx, "container.attach")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpanTwo[types.HijackedResponse](span)(c.client.ContainerAttach(ctx, containerID, options))
+}
+
 func (c TracedClient) ContainerLogs(ctx context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
 	ctx, span := c.span(ctx, "container.logs")
 	// span ends when the io.ReadCloser is closed
@@ -82,6 +90,13 @@ func (c TracedClient) ContainerRemove(ctx context.Context, containerID string, o
 	return telemetry.RecordErrorOnSpan(span)(c.client.ContainerRemove(ctx, containerID, options))
 }
 
+func (c TracedClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	ctx, span := c.span(ctx, "container.rename")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpan(span)(c.client.ContainerRename(ctx, containerID, newContainerName))
+}
+
 func (c TracedClient) ContainerStart(ctx context.Context, id string, options types.ContainerStartOptions) error {
 	ctx, span := c.span(ctx, "container.start")
 	defer span.End()
@@ -89,6 +104,16 @@ func (c TracedClient) ContainerStart(ctx context.Context, id string, options typ
 	return telemetry.RecordErrorOnSpan(span)(c.client.ContainerStart(ctx, id, options))
 }
 
+func (c TracedClient) ContainerRestart(ctx context.Context, containerID string, timeout time.Duration) error {
+	ctx, span := c.span(ctx, "container.restart")
+	defer span.End()
+
+	timeoutHelper := int(timeout.Seconds())
+	return telemetry.RecordErrorOnSpan(span)(c.client.ContainerRestart(ctx, containerID, container.StopOptions{
+		Timeout: &timeoutHelper,
+	}))
+}
+
 func (c TracedClient) ContainerStop(ctx context.Context, containerID string, timeout time.Duration) error {
 	ctx, span := c.span(ctx, "container.stop")
 	defer span.End()
@@ -142,6 +167,22 @@ func (c TracedClient) ImagePull(ctx context.Context, refStr string, options type
 	return telemetry.RecordErrorOnSpanReadCloserAndClose(span)(c.client.ImagePull(ctx, refStr, options))
 }
 
+func (c TracedClient) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
+	ctx, span := c.span(ctx, "image.list")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpanTwo[[]types.ImageSummary](span)(c.client.ImageList(ctx, options))
+}
+
+func (c TracedClient) ImageRemove(
+	ctx context.Context, imageID string, options types.ImageRemoveOptions,
+) ([]types.ImageDeleteResponseItem, error) {
+	ctx, span := c.span(ctx, "image.remove")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpanTwo[[]types.ImageDeleteResponseItem](span)(c.client.ImageRemove(ctx, imageID, options))
+}
+
 func (c TracedClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
 	ctx, span := c.span(ctx, "network.connect")
 	defer span.End()
@@ -149,6 +190,13 @@ func (c TracedClient) NetworkConnect(ctx context.Context, networkID, containerID
 	return telemetry.RecordErrorOnSpan(span)(c.client.NetworkConnect(ctx, networkID, containerID, config))
 }
 
+func (c TracedClient) NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error {
+	ctx, span := c.span(ctx, "network.disconnect")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpan(span)(c.client.NetworkDisconnect(ctx, networkID, containerID, force))
+}
+
 func (c TracedClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
 	ctx, span := c.span(ctx, "network.create")
 	defer span.End()
@@ -181,6 +229,13 @@ func (c TracedClient) NetworkRemove(ctx context.Context, networkID string) error
 	return telemetry.RecordErrorOnSpan(span)(c.client.NetworkRemove(ctx, networkID))
 }
 
+func (c TracedClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	ctx, span := c.span(ctx, "system.df")
+	defer span.End()
+
+	return telemetry.RecordErrorOnSpanTwo[types.DiskUsage](span)(c.client.DiskUsage(ctx, options))
+}
+
 func (c TracedClient) Info(ctx context.Context) (types.Info, error) {
 	ctx, span := c.span(ctx, "info")
 	defer span.End()
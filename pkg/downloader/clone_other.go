@@ -0,0 +1,13 @@
+//go:build !linux
+
+package downloader
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile makes target a copy-on-write clone of source, which isn't supported on this platform.
+func cloneFile(_, _ *os.File) error {
+	return errors.New("copy-on-write clones are not supported on this platform")
+}
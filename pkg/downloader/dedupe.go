@@ -0,0 +1,153 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// dedupeFiles addresses the regular files under root by their contents, and replaces the files whose contents have
+// already been seen with a copy-on-write clone of the first one where the filesystem supports it, or a hardlink
+// otherwise. Hardlinked files are made read-only, so that writing to one fails rather than changing the others. Files
+// that can be neither cloned nor linked are left as they are. It returns the number of bytes saved.
+//
+// Results that are identical across executions have the same CID and are only fetched once, and an IPFS node only
+// fetches the blocks shared by different results once, so this only saves the space taken by the files on disk.
+func dedupeFiles(ctx context.Context, root string) (int64, error) {
+	// files are hashed only when another file has the same size
+	bySize := map[int64][]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > 0 {
+			bySize[info.Size()] = append(bySize[info.Size()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var saved int64
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		byHash := map[string]string{}
+		for _, path := range paths {
+			hash, err := hashFile(path)
+			if err != nil {
+				return saved, err
+			}
+			original, ok := byHash[hash]
+			if !ok {
+				byHash[hash] = path
+				continue
+			}
+			if err = linkFile(original, path); err != nil {
+				log.Ctx(ctx).Debug().Err(err).Msgf("Keeping a copy of %s, as it can't be linked to %s", path, original)
+				continue
+			}
+			saved += size
+		}
+	}
+	return saved, nil
+}
+
+// linkFile replaces target with a clone of, or failing that a read-only hardlink to, source.
+func linkFile(source, target string) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	temp := target + ".dedupe"
+	if err = cloneFileAt(source, temp, info.Mode()); err != nil {
+		_ = os.Remove(temp)
+		sourceInfo, err := os.Stat(source)
+		if err != nil {
+			return err
+		}
+		// the linked files share their mode too
+		if err = os.Chmod(source, sourceInfo.Mode().Perm()&^0222); err != nil { //nolint:gomnd
+			return err
+		}
+		if err = os.Link(source, temp); err != nil {
+			return err
+		}
+	}
+	if err = os.Rename(temp, target); err != nil {
+		_ = os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+func cloneFileAt(source, target string, mode fs.FileMode) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	targetFile, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if err = cloneFile(sourceFile, targetFile); err != nil {
+		_ = targetFile.Close()
+		return err
+	}
+	return targetFile.Close()
+}
+
+// sameContents returns true if the two files are the same file or have identical contents.
+func sameContents(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(aInfo, bInfo) {
+		return true, nil
+	}
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	aHash, err := hashFile(a)
+	if err != nil {
+		return false, err
+	}
+	bHash, err := hashFile(b)
+	if err != nil {
+		return false, err
+	}
+	return aHash == bHash, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,121 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrTooManyResultFiles is returned when building the manifest of results with more files than allowed.
+var ErrTooManyResultFiles = errors.New("results have too many files for a manifest")
+
+// BuildResultManifest lists the files under the directory that results were written or downloaded to, with their
+// sizes and hashes. It returns ErrTooManyResultFiles if there are more than maxFiles files, unless maxFiles is zero.
+func BuildResultManifest(dir string, maxFiles int) (model.ResultManifest, error) {
+	var manifest model.ResultManifest
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		if maxFiles > 0 && len(manifest.Files) == maxFiles {
+			return ErrTooManyResultFiles
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		hash := sha256.New()
+		size, err := io.Copy(hash, file)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, model.ResultFile{
+			Path:   filepath.ToSlash(relPath),
+			Size:   size,
+			SHA256: hex.EncodeToString(hash.Sum(nil)),
+		})
+		return nil
+	})
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	return manifest, err
+}
+
+// FileChange is how a file differs between two results.
+type FileChange string
+
+const (
+	FileAdded   FileChange = "added"
+	FileRemoved FileChange = "removed"
+	FileChanged FileChange = "changed"
+)
+
+// FileDiff is a file that differs between two results. Old is nil if the file was added, and New if it was removed.
+type FileDiff struct {
+	Path   string            `json:"Path"`
+	Change FileChange        `json:"Change"`
+	Old    *model.ResultFile `json:"Old,omitempty"`
+	New    *model.ResultFile `json:"New,omitempty"`
+}
+
+// DiffResultManifests returns the files that were added, removed or changed from the old results to the new ones,
+// ordered by path, and how many files are identical in both.
+func DiffResultManifests(oldManifest, newManifest model.ResultManifest) (diffs []FileDiff, identical int) {
+	oldFiles := make(map[string]model.ResultFile, len(oldManifest.Files))
+	for _, file := range oldManifest.Files {
+		oldFiles[file.Path] = file
+	}
+	for _, newFile := range newManifest.Files {
+		newFile := newFile
+		oldFile, found := oldFiles[newFile.Path]
+		delete(oldFiles, newFile.Path)
+		switch {
+		case !found:
+			diffs = append(diffs, FileDiff{Path: newFile.Path, Change: FileAdded, New: &newFile})
+		case oldFile.SHA256 != newFile.SHA256:
+			diffs = append(diffs, FileDiff{Path: newFile.Path, Change: FileChanged, Old: &oldFile, New: &newFile})
+		default:
+			identical++
+		}
+	}
+	for _, oldFile := range oldFiles {
+		oldFile := oldFile
+		diffs = append(diffs, FileDiff{Path: oldFile.Path, Change: FileRemoved, Old: &oldFile})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, identical
+}
+
+// DiffText returns a unified diff of two versions of a text file, or false if either version is binary.
+func DiffText(path string, oldContent, newContent []byte) (string, bool) {
+	if !isText(oldContent) || !isText(newContent) {
+		return "", false
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(oldContent)),
+		B:        difflib.SplitLines(string(newContent)),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+	return diff, err == nil
+}
+
+// isText returns whether the content looks like text, rather than binary data.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+}
@@ -0,0 +1,89 @@
+//go:build unit || !integration
+
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeResults(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDiffResultManifests(t *testing.T) {
+	oldManifest, err := BuildResultManifest(writeResults(t, map[string]string{
+		"stdout":            "hello\n",
+		"exitCode":          "0",
+		"outputs/data.csv":  "a,b\n1,2\n",
+		"outputs/old.txt":   "removed",
+		"outputs/image.bin": "\x00\x01",
+	}), 0)
+	require.NoError(t, err)
+	newManifest, err := BuildResultManifest(writeResults(t, map[string]string{
+		"stdout":            "hello\n",
+		"exitCode":          "0",
+		"outputs/data.csv":  "a,b\n1,3\n",
+		"outputs/new.txt":   "added",
+		"outputs/image.bin": "\x00\x01",
+	}), 0)
+	require.NoError(t, err)
+
+	require.Len(t, oldManifest.Files, 5)
+	require.Equal(t, "exitCode", oldManifest.Files[0].Path, "files are ordered by path")
+	require.Equal(t, "outputs/data.csv", oldManifest.Files[1].Path)
+	require.Equal(t, int64(8), oldManifest.Files[1].Size)
+	require.Len(t, oldManifest.Files[1].SHA256, 64)
+
+	diffs, identical := DiffResultManifests(oldManifest, newManifest)
+	require.Equal(t, 3, identical)
+	require.Len(t, diffs, 3)
+	require.Equal(t, "outputs/data.csv", diffs[0].Path)
+	require.Equal(t, FileChanged, diffs[0].Change)
+	require.NotEqual(t, diffs[0].Old.SHA256, diffs[0].New.SHA256)
+	require.Equal(t, "outputs/new.txt", diffs[1].Path)
+	require.Equal(t, FileAdded, diffs[1].Change)
+	require.Nil(t, diffs[1].Old)
+	require.Equal(t, "outputs/old.txt", diffs[2].Path)
+	require.Equal(t, FileRemoved, diffs[2].Change)
+	require.Nil(t, diffs[2].New)
+
+	diffs, identical = DiffResultManifests(oldManifest, oldManifest)
+	require.Empty(t, diffs)
+	require.Equal(t, 5, identical)
+}
+
+func TestBuildResultManifestMaxFiles(t *testing.T) {
+	dir := writeResults(t, map[string]string{"stdout": "", "stderr": "", "exitCode": "0"})
+	manifest, err := BuildResultManifest(dir, 3)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 3)
+
+	_, err = BuildResultManifest(dir, 2)
+	require.ErrorIs(t, err, ErrTooManyResultFiles)
+}
+
+func TestDiffText(t *testing.T) {
+	diff, ok := DiffText("outputs/data.csv", []byte("a,b\n1,2\n"), []byte("a,b\n1,3\n"))
+	require.True(t, ok)
+	require.Contains(t, diff, "--- a/outputs/data.csv")
+	require.Contains(t, diff, "+++ b/outputs/data.csv")
+	require.Contains(t, diff, "-1,2\n+1,3\n")
+
+	// added files are diffed against nothing
+	diff, ok = DiffText("outputs/new.txt", nil, []byte("added\n"))
+	require.True(t, ok)
+	require.Contains(t, diff, "+added")
+
+	_, ok = DiffText("outputs/image.bin", []byte("\x00\x01"), []byte("\x00\x02"))
+	require.False(t, ok)
+}
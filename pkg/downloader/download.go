@@ -2,10 +2,12 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
@@ -79,14 +81,34 @@ func DownloadResults( //nolint:funlen,gocyclo
 	downloadedCids := map[string]string{}
 	var downloader Downloader
 
+	// results of output volumes that were published on their own are moved into the folder of the volume, and the
+	// rest of the results are merged into the output folder
+	volumeOf := map[string]string{}
+	separateVolumes := map[string]bool{}
+	for _, publishedResult := range publishedResults {
+		if publishedResult.Volume != "" {
+			separateVolumes[publishedResult.Volume] = true
+		}
+	}
+
+	filter, err := NewPathFilter(settings.Include, settings.Exclude)
+	if err != nil {
+		return err
+	}
+	if filter.Enabled() && settings.SingleFile != "" {
+		return errors.New("a single file can't be downloaded with include or exclude patterns")
+	}
+	// the number of files selected by the filter across all results
+	filtered := 0
+
 	if settings.SingleFile != "" {
 		for _, publishedResult := range publishedResults {
-			downloader, err = downloadProvider.Get(ctx, publishedResult.Data.StorageSource) //nolint
-			if err != nil {
-				return err
+			singleFile, found := singleFileIn(publishedResult, settings.SingleFile, separateVolumes)
+			if !found {
+				continue
 			}
 
-			cid, err := findSingleEntry(ctx, publishedResult, downloader, settings.SingleFile)
+			downloader, err = downloadProvider.Get(ctx, publishedResult.Data.StorageSource) //nolint
 			if err != nil {
 				return err
 			}
@@ -109,6 +131,19 @@ func DownloadResults( //nolint:funlen,gocyclo
 				}
 			}
 
+			cid, err := findSingleEntry(ctx, publishedResult, downloader, singleFile)
+			if errors.Is(err, ErrDescribeNotSupported) {
+				// the file can only be found by fetching the whole result
+				err = fetchSingleFile(ctx, publishedResult, downloader, singleFile, cidParentDir, targetFile)
+				if err != nil {
+					return err
+				}
+				downloadedCids[ResultIdentifier(publishedResult.Data)] = cidParentDir
+				continue
+			} else if err != nil {
+				return err
+			}
+
 			// We want to specify the target directory to copy from as the key
 			// but the DownloadItem itself specifies the target file to be
 			// written to.
@@ -127,51 +162,89 @@ func DownloadResults( //nolint:funlen,gocyclo
 			downloadedCids[item.CID] = cidParentDir
 		}
 	} else {
-		for _, publishedResult := range publishedResults {
+		for i, publishedResult := range publishedResults {
 			downloader, err = downloadProvider.Get(ctx, publishedResult.Data.StorageSource) //nolint
 			if err != nil {
 				return err
 			}
 
-			cidDownloadDir := filepath.Join(cidParentDir, publishedResult.Data.CID)
-			_, alreadyExists := downloadedCids[publishedResult.Data.CID]
+			ident := ResultIdentifier(publishedResult.Data)
+			_, alreadyExists := downloadedCids[ident]
 			if alreadyExists {
 				// We don't want to download the same CID twice, so we will just move
 				// on to the next item
 				log.Ctx(ctx).Debug().
-					Str("CID", publishedResult.Data.CID).
+					Str("CID", ident).
 					Msg("asked to download a CID a second time")
 				continue
 			}
 
-			item := model.DownloadItem{
-				Name:       publishedResult.Data.Name,
-				CID:        publishedResult.Data.CID,
-				SourceType: publishedResult.Data.StorageSource,
-				Target:     cidDownloadDir,
+			// results without a CID, such as those published to S3, are downloaded into a folder named by position
+			cidDownloadDir := filepath.Join(cidParentDir, publishedResult.Data.CID)
+			if publishedResult.Data.CID == "" {
+				cidDownloadDir = filepath.Join(cidParentDir, fmt.Sprintf("result-%d", i))
 			}
 
-			err = downloader.FetchResult(ctx, item)
+			if filter.Enabled() {
+				var fetched int
+				fetched, err = fetchFilteredResult(ctx, publishedResult, downloader, filter, cidDownloadDir)
+				if err != nil {
+					return err
+				}
+				filtered += fetched
+			} else {
+				err = downloader.FetchResult(ctx, newDownloadItem(publishedResult, cidDownloadDir))
+				if err != nil {
+					return err
+				}
+			}
+
+			downloadedCids[ident] = cidDownloadDir
+			volumeOf[ident] = publishedResult.Volume
+		}
+
+		if filter.Enabled() && filtered == 0 {
+			return errors.New("no files of the results match the include and exclude patterns")
+		}
+
+		if settings.Dedupe && len(downloadedCids) > 1 {
+			saved, err := dedupeFiles(ctx, cidParentDir)
 			if err != nil {
 				return err
 			}
-
-			downloadedCids[item.CID] = cidDownloadDir
+			log.Ctx(ctx).Debug().Msgf("Deduplicated %d bytes of identical files across results", saved)
 		}
 	}
 
 	if settings.Raw {
 		return nil
 	} else {
+		// logs are only appended to when there are several results holding them
+		withLogs := 0
+		for ident := range downloadedCids {
+			if volumeOf[ident] == "" {
+				withLogs++
+			}
+		}
+		appendMode := withLogs > 1
+
 		// for since file cidDownloadDir is parentid, otherwise it is a cid folder
 		for ident, cidDownloadDir := range downloadedCids {
+			targetDir := resultsOutputDir
+			if volume := volumeOf[ident]; volume != "" {
+				targetDir = filepath.Join(resultsOutputDir, volume)
+				err = os.MkdirAll(targetDir, model.DownloadFolderPerm)
+				if err != nil {
+					return err
+				}
+			}
 			log.Ctx(ctx).Debug().
 				Str("CID", ident).
 				Str("Source", cidDownloadDir).
-				Str("Target", resultsOutputDir).
+				Str("Target", targetDir).
 				Msg("Copying downloaded data to target")
 
-			err = moveData(ctx, cidDownloadDir, resultsOutputDir, len(downloadedCids) > 1)
+			err = moveData(ctx, cidDownloadDir, targetDir, appendMode, settings.Dedupe)
 			if err != nil {
 				return err
 			}
@@ -181,6 +254,71 @@ func DownloadResults( //nolint:funlen,gocyclo
 	}
 }
 
+// newDownloadItem returns the item to fetch the whole of a result into the target folder.
+func newDownloadItem(result model.PublishedResult, target string) model.DownloadItem {
+	return model.DownloadItem{
+		Name:       result.Data.Name,
+		CID:        result.Data.CID,
+		URL:        result.Data.URL,
+		S3:         result.Data.S3,
+		SourcePath: result.Data.SourcePath,
+		SourceType: result.Data.StorageSource,
+		Target:     target,
+	}
+}
+
+// fetchSingleFile fetches the whole result next to the target file, and moves the file at the path within the result
+// to the target. It is used for results whose contents can't be listed to fetch the file on its own.
+func fetchSingleFile(
+	ctx context.Context,
+	result model.PublishedResult,
+	downloader Downloader,
+	path string,
+	cidParentDir string,
+	targetFile string,
+) error {
+	tempDir, err := os.MkdirTemp(cidParentDir, "result-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir) //nolint:errcheck
+
+	resultDir := filepath.Join(tempDir, "result")
+	if err = downloader.FetchResult(ctx, newDownloadItem(result, resultDir)); err != nil {
+		return err
+	}
+	if err = os.Rename(filepath.Join(resultDir, path), targetFile); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("failed to find %s in the result", path)
+		}
+		return err
+	}
+	return nil
+}
+
+// ResultIdentifier returns what identifies the data of a result, such as its CID, which is also used to avoid
+// downloading the same data twice.
+func ResultIdentifier(data model.StorageSpec) string {
+	switch {
+	case data.CID != "":
+		return data.CID
+	case data.S3 != nil:
+		return fmt.Sprintf("s3://%s/%s", data.S3.Bucket, data.S3.Key)
+	default:
+		return data.URL
+	}
+}
+
+// singleFileIn returns the path of the file within the result, and false if the result does not hold the file. Files
+// of output volumes that were published on their own are only held by the result of the volume.
+func singleFileIn(result model.PublishedResult, name string, separateVolumes map[string]bool) (string, bool) {
+	volume, rest, _ := strings.Cut(filepath.ToSlash(name), "/")
+	if result.Volume != "" {
+		return rest, volume == result.Volume && rest != ""
+	}
+	return name, !separateVolumes[volume]
+}
+
 func findSingleEntry(ctx context.Context, result model.PublishedResult, downloader Downloader, name string) (string, error) {
 	filemap, err := downloader.DescribeResult(ctx, result)
 	if err != nil {
@@ -203,6 +341,7 @@ func moveData(
 	fromFolder string,
 	toFolder string,
 	appendMode bool,
+	dedupe bool,
 ) error {
 	// the recursive function that will scan our source volume folder
 	moveFunc := func(path string, d os.DirEntry, err error) error {
@@ -242,6 +381,7 @@ func moveData(
 				err = moveFile(
 					path,
 					globalTargetPath,
+					dedupe,
 				)
 				if err != nil {
 					return err
@@ -290,7 +430,9 @@ func appendFile(sourcePath, targetPath string) error {
 	return nil
 }
 
-func moveFile(sourcePath, targetPath string) error {
+// moveFile moves the file to targetPath, failing if it already exists. When deduplicating, identical files are merged
+// rather than conflicting.
+func moveFile(sourcePath, targetPath string, dedupe bool) error {
 	_, err := os.Stat(targetPath)
 	if err != nil {
 		// we got some other type of error
@@ -299,6 +441,15 @@ func moveFile(sourcePath, targetPath string) error {
 		}
 		// file doesn't exist
 	} else {
+		if dedupe {
+			same, err := sameContents(sourcePath, targetPath)
+			if err != nil {
+				return err
+			}
+			if same {
+				return os.Remove(sourcePath)
+			}
+		}
 		return fmt.Errorf(
 			"cannot merge results as output already exists: %s. Try --raw to download raw results instead of merging them", targetPath)
 	}
@@ -5,6 +5,7 @@ package downloader
 import (
 	"context"
 	"crypto/rand"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -341,6 +342,85 @@ func (ds *DownloaderSuite) TestMultiMergeConflictingOutput() {
 	require.Error(ds.T(), err)
 }
 
+// mockIdenticalOutputs generates results that have the same outputs, but different logs.
+func (ds *DownloaderSuite) mockIdenticalOutputs(count int) ([]model.PublishedResult, []byte) {
+	output := make([]byte, 128)
+	_, err := rand.Read(output)
+	require.NoError(ds.T(), err)
+
+	results := make([]model.PublishedResult, count)
+	for i := range results {
+		cid := mockOutput(ds, func(dir string) {
+			mockFile(ds, dir, model.DownloadFilenameStdout)
+			require.NoError(ds.T(), os.MkdirAll(filepath.Join(dir, "outputs"), os.ModePerm))
+			require.NoError(ds.T(), os.WriteFile(filepath.Join(dir, "outputs", "same.txt"), output, model.DownloadFilePerm))
+		})
+		results[i] = model.PublishedResult{
+			NodeID: "testnode",
+			Data: model.StorageSpec{
+				StorageSource: model.StorageSourceIPFS,
+				Name:          fmt.Sprintf("result-%d", i),
+				CID:           cid,
+			},
+		}
+	}
+	return results, output
+}
+
+func (ds *DownloaderSuite) TestMultiMergeIdenticalOutput() {
+	results, output := ds.mockIdenticalOutputs(2)
+
+	settings := ds.downloadSettings
+	settings.Dedupe = true
+	err := DownloadResults(context.Background(), results, ds.downloadProvider, settings)
+	require.NoError(ds.T(), err)
+	requireFile(ds, output, "outputs", "same.txt")
+}
+
+func (ds *DownloaderSuite) TestMultiRawDedupedOutput() {
+	results, output := ds.mockIdenticalOutputs(2)
+
+	settings := ds.downloadSettings
+	settings.Raw = true
+	settings.Dedupe = true
+	err := DownloadResults(context.Background(), results, ds.downloadProvider, settings)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, output, model.DownloadCIDsFolderName, results[0].Data.CID, "outputs", "same.txt")
+	requireFile(ds, output, model.DownloadCIDsFolderName, results[1].Data.CID, "outputs", "same.txt")
+}
+
+func (ds *DownloaderSuite) TestDedupeFiles() {
+	dir := ds.T().TempDir()
+	contents := mockFile(ds, dir, "a", "file")
+	require.NoError(ds.T(), os.MkdirAll(filepath.Join(dir, "b"), os.ModePerm))
+	require.NoError(ds.T(), os.WriteFile(filepath.Join(dir, "b", "file"), contents, model.DownloadFilePerm))
+	other := mockFile(ds, dir, "c", "file")
+
+	saved, err := dedupeFiles(context.Background(), dir)
+	require.NoError(ds.T(), err)
+	require.Equal(ds.T(), int64(len(contents)), saved)
+
+	for _, path := range []string{"a", "b"} {
+		actual, err := os.ReadFile(filepath.Join(dir, path, "file"))
+		require.NoError(ds.T(), err)
+		require.Equal(ds.T(), contents, actual)
+	}
+	actual, err := os.ReadFile(filepath.Join(dir, "c", "file"))
+	require.NoError(ds.T(), err)
+	require.Equal(ds.T(), other, actual)
+	require.NoFileExists(ds.T(), filepath.Join(dir, "b", "file.dedupe"))
+
+	// hardlinked files can't be written to, as that would change both
+	aInfo, err := os.Stat(filepath.Join(dir, "a", "file"))
+	require.NoError(ds.T(), err)
+	bInfo, err := os.Stat(filepath.Join(dir, "b", "file"))
+	require.NoError(ds.T(), err)
+	if os.SameFile(aInfo, bInfo) {
+		require.Zero(ds.T(), bInfo.Mode().Perm()&0222)
+	}
+}
+
 func (ds *DownloaderSuite) TestOutputWithNoStdFiles() {
 	cid := mockOutput(ds, func(dir string) {
 		mockFile(ds, dir, "outputs", "lonely.txt")
@@ -390,3 +470,296 @@ func (ds *DownloaderSuite) TestCustomVolumeNames() {
 
 	requireFileExists(ds, "secrets", "private.pem")
 }
+
+func (ds *DownloaderSuite) TestSeparatelyPublishedVolumes() {
+	res := ds.easyMockOutput("hello.txt")
+	metrics := mockOutput(ds, func(s string) {
+		mockFile(ds, s, "metrics.json")
+	})
+
+	results := []model.PublishedResult{
+		{
+			NodeID: "testnode",
+			Data: model.StorageSpec{
+				StorageSource: model.StorageSourceIPFS,
+				Name:          "result-0",
+				CID:           res.cid,
+			},
+		},
+		{
+			NodeID: "testnode",
+			Data: model.StorageSpec{
+				StorageSource: model.StorageSourceIPFS,
+				Name:          "metrics",
+				CID:           metrics,
+			},
+			Volume: "metrics",
+		},
+	}
+	err := DownloadResults(context.Background(), results, ds.downloadProvider, ds.downloadSettings)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, res.stdout, "stdout")
+	requireFile(ds, res.outputs["hello.txt"], "outputs", "hello.txt")
+	requireFileExists(ds, "metrics", "metrics.json")
+}
+
+func (ds *DownloaderSuite) TestIncludedFilesOnly() {
+	res := ds.easyMockOutput("table.csv", "notes.txt")
+
+	settings := ds.downloadSettings
+	settings.Include = []string{"outputs/*.csv", "stdout"}
+	err := DownloadResults(
+		context.Background(),
+		[]model.PublishedResult{
+			{
+				NodeID: "testnode",
+				Data: model.StorageSpec{
+					StorageSource: model.StorageSourceIPFS,
+					Name:          "result-0",
+					CID:           res.cid,
+				},
+			},
+		},
+		ds.downloadProvider,
+		settings,
+	)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, res.stdout, "stdout")
+	requireFile(ds, res.outputs["table.csv"], "outputs", "table.csv")
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, "stderr"))
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, "exitCode"))
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, "outputs", "notes.txt"))
+}
+
+func (ds *DownloaderSuite) TestExcludedFilesOfSeparateVolume() {
+	res := ds.easyMockOutput("hello.txt")
+	metrics := mockOutput(ds, func(s string) {
+		mockFile(ds, s, "metrics.json")
+		mockFile(ds, s, "debug", "trace.json")
+	})
+
+	settings := ds.downloadSettings
+	settings.Exclude = []string{"metrics/debug", "std*"}
+	err := DownloadResults(
+		context.Background(),
+		[]model.PublishedResult{
+			{
+				NodeID: "testnode",
+				Data: model.StorageSpec{
+					StorageSource: model.StorageSourceIPFS,
+					Name:          "result-0",
+					CID:           res.cid,
+				},
+			},
+			{
+				NodeID: "testnode",
+				Data: model.StorageSpec{
+					StorageSource: model.StorageSourceIPFS,
+					Name:          "metrics",
+					CID:           metrics,
+				},
+				Volume: "metrics",
+			},
+		},
+		ds.downloadProvider,
+		settings,
+	)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, res.exitCode, "exitCode")
+	requireFile(ds, res.outputs["hello.txt"], "outputs", "hello.txt")
+	requireFileExists(ds, "metrics", "metrics.json")
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, "stdout"))
+	require.NoDirExists(ds.T(), filepath.Join(ds.outputDir, "metrics", "debug"))
+}
+
+func (ds *DownloaderSuite) TestIncludedFilesOfUndescribableResult() {
+	source := ds.T().TempDir()
+	mockFile(ds, source, model.DownloadFilenameStdout)
+	csv := mockFile(ds, source, "outputs", "table.csv")
+	mockFile(ds, source, "outputs", "logs", "run.txt")
+
+	settings := ds.downloadSettings
+	settings.Include = []string{"outputs/*.csv"}
+	provider := model.NewMappedProvider(map[model.StorageSourceType]Downloader{
+		model.StorageSourceLocalDirectory: copyingDownloader{},
+	})
+	results := []model.PublishedResult{
+		{
+			NodeID: "testnode",
+			Data: model.StorageSpec{
+				StorageSource: model.StorageSourceLocalDirectory,
+				Name:          "result-0",
+				SourcePath:    source,
+			},
+		},
+	}
+	err := DownloadResults(context.Background(), results, provider, settings)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, csv, "outputs", "table.csv")
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, "stdout"))
+	require.NoDirExists(ds.T(), filepath.Join(ds.outputDir, "outputs", "logs"))
+
+	settings.Include = []string{"outputs/*.parquet"}
+	err = DownloadResults(context.Background(), results, provider, settings)
+	require.ErrorContains(ds.T(), err, "no files of the results match")
+}
+
+// copyingDownloader copies results from their source path, and can't list their contents.
+type copyingDownloader struct{}
+
+func (copyingDownloader) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (copyingDownloader) DescribeResult(context.Context, model.PublishedResult) (map[string]string, error) {
+	return nil, ErrDescribeNotSupported
+}
+
+func (copyingDownloader) FetchResult(_ context.Context, item model.DownloadItem) error {
+	return filepath.WalkDir(item.SourcePath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(item.SourcePath, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(item.Target, rel), model.DownloadFolderPerm)
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(item.Target, rel), contents, model.DownloadFilePerm)
+	})
+}
+
+func TestPathFilter(t *testing.T) {
+	filter, err := NewPathFilter([]string{"outputs/*.csv", "stdout"}, []string{"outputs/skip.csv"})
+	require.NoError(t, err)
+	require.True(t, filter.Enabled())
+	require.True(t, filter.Matches("outputs/table.csv"))
+	require.True(t, filter.Matches("stdout"))
+	require.False(t, filter.Matches("outputs/skip.csv"))
+	require.False(t, filter.Matches("outputs/notes.txt"))
+	require.False(t, filter.Matches("stderr"))
+
+	// patterns matching a folder select everything within it
+	filter, err = NewPathFilter(nil, []string{"outputs/logs"})
+	require.NoError(t, err)
+	require.True(t, filter.Matches("outputs/table.csv"))
+	require.False(t, filter.Matches("outputs/logs/run/trace.txt"))
+
+	filter, err = NewPathFilter(nil, nil)
+	require.NoError(t, err)
+	require.False(t, filter.Enabled())
+	require.True(t, filter.Matches("anything"))
+
+	_, err = NewPathFilter([]string{"outputs/["}, nil)
+	require.ErrorContains(t, err, "invalid pattern")
+}
+
+func TestSingleFileIn(t *testing.T) {
+	volume := model.PublishedResult{Volume: "metrics"}
+	separateVolumes := map[string]bool{"metrics": true}
+
+	name, found := singleFileIn(volume, "metrics/run/metrics.json", separateVolumes)
+	require.True(t, found)
+	require.Equal(t, "run/metrics.json", name)
+
+	_, found = singleFileIn(volume, "outputs/hello.txt", separateVolumes)
+	require.False(t, found)
+
+	_, found = singleFileIn(model.PublishedResult{}, "metrics/metrics.json", separateVolumes)
+	require.False(t, found)
+
+	name, found = singleFileIn(model.PublishedResult{}, "outputs/hello.txt", separateVolumes)
+	require.True(t, found)
+	require.Equal(t, "outputs/hello.txt", name)
+}
+
+func TestResultsOfNode(t *testing.T) {
+	results := []model.PublishedResult{
+		{NodeID: "QmNodeA1", Data: model.StorageSpec{CID: "cid-a"}},
+		{NodeID: "QmNodeA1", Volume: "logs", Data: model.StorageSpec{CID: "cid-a-logs"}},
+		{NodeID: "QmNodeA2", Data: model.StorageSpec{CID: "cid-a2"}},
+		{NodeID: "QmNodeB", Data: model.StorageSpec{CID: "cid-b"}},
+	}
+
+	all, err := ResultsOfNode(results, "")
+	require.NoError(t, err)
+	require.Equal(t, results, all)
+
+	selected, err := ResultsOfNode(results, "QmNodeA1")
+	require.NoError(t, err)
+	require.Equal(t, results[:2], selected)
+
+	selected, err = ResultsOfNode(results, "QmNodeB")
+	require.NoError(t, err)
+	require.Equal(t, results[3:], selected)
+
+	_, err = ResultsOfNode(results, "QmNodeA")
+	require.ErrorContains(t, err, "ambiguous")
+
+	_, err = ResultsOfNode(results, "QmNodeC")
+	require.ErrorContains(t, err, "no results published by node QmNodeC")
+}
+
+// describingDownloader describes results with a fixed list of files, and records what it is asked to fetch.
+type describingDownloader struct {
+	files   map[string]string
+	fetched []string
+}
+
+func (*describingDownloader) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (d *describingDownloader) DescribeResult(context.Context, model.PublishedResult) (map[string]string, error) {
+	return d.files, nil
+}
+
+func (d *describingDownloader) FetchResult(_ context.Context, item model.DownloadItem) error {
+	d.fetched = append(d.fetched, item.Target)
+	return nil
+}
+
+func TestFetchFilteredResultRejectsEscapingPaths(t *testing.T) {
+	filter, err := NewPathFilter([]string{"outputs"}, nil)
+	require.NoError(t, err)
+
+	for _, name := range []string{"../evil", "outputs/../../evil", "/etc/passwd", "outputs//file", `outputs\..\evil`} {
+		downloader := &describingDownloader{files: map[string]string{"outputs/ok": "cid-1", name: "cid-2"}}
+		_, err = fetchFilteredResult(context.Background(), model.PublishedResult{}, downloader, filter, t.TempDir())
+		require.Error(t, err, name)
+		require.Empty(t, downloader.fetched, name)
+	}
+}
+
+func TestPruneFilesRemovesEscapingLinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), model.DownloadFilePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "outputs"), model.DownloadFolderPerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "outputs", "table.csv"), nil, model.DownloadFilePerm))
+	require.NoError(t, os.Symlink("table.csv", filepath.Join(dir, "outputs", "inside.csv")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "outputs", "absolute.csv")))
+	require.NoError(t, os.Symlink("../../secret", filepath.Join(dir, "outputs", "relative.csv")))
+
+	filter, err := NewPathFilter([]string{"outputs"}, nil)
+	require.NoError(t, err)
+	kept, err := pruneFiles(dir, "", filter)
+	require.NoError(t, err)
+	require.Equal(t, 2, kept)
+	require.FileExists(t, filepath.Join(dir, "outputs", "inside.csv"))
+	for _, link := range []string{"absolute.csv", "relative.csv"} {
+		_, err = os.Lstat(filepath.Join(dir, "outputs", link))
+		require.ErrorIs(t, err, os.ErrNotExist, link)
+	}
+	require.FileExists(t, outside)
+}
@@ -0,0 +1,205 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// PathFilter selects the files of results to download by glob patterns matched against their paths, such as
+// outputs/*.csv. A pattern also matches everything within the folders it matches, so outputs matches all the
+// outputs. Files are selected if they match an include pattern, or if there are none, and match no exclude pattern.
+type PathFilter struct {
+	include []string
+	exclude []string
+}
+
+// NewPathFilter returns the filter for the patterns, and an error if any of them is malformed.
+func NewPathFilter(include, exclude []string) (*PathFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return &PathFilter{include: trimSlashes(include), exclude: trimSlashes(exclude)}, nil
+}
+
+// trimSlashes returns the patterns without leading or trailing slashes, as paths are matched without them.
+func trimSlashes(patterns []string) []string {
+	trimmed := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		trimmed = append(trimmed, strings.Trim(pattern, "/"))
+	}
+	return trimmed
+}
+
+// Enabled returns whether the filter leaves out any file.
+func (f *PathFilter) Enabled() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// Matches returns whether the file at the slash-separated path is selected.
+func (f *PathFilter) Matches(name string) bool {
+	name = strings.Trim(path.Clean(filepath.ToSlash(name)), "/")
+	if len(f.include) > 0 && !matchesPathOrFolder(f.include, name) {
+		return false
+	}
+	return !matchesPathOrFolder(f.exclude, name)
+}
+
+// matchesPathOrFolder returns whether any of the patterns matches the path or one of the folders holding it.
+func matchesPathOrFolder(patterns []string, name string) bool {
+	for p := name; p != "." && p != ""; p = path.Dir(p) {
+		if model.MatchesAny(patterns, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchFilteredResult fetches the files of the result selected by the filter into the target folder, and returns
+// how many were fetched. Files are fetched one by one when the downloader can list the contents of the result, and
+// otherwise the whole result is fetched and the other files are removed. Files of output volumes that were
+// published on their own are matched by their path within the volume folder.
+func fetchFilteredResult(
+	ctx context.Context,
+	result model.PublishedResult,
+	downloader Downloader,
+	filter *PathFilter,
+	target string,
+) (int, error) {
+	filemap, err := downloader.DescribeResult(ctx, result)
+	if errors.Is(err, ErrDescribeNotSupported) {
+		if err = downloader.FetchResult(ctx, newDownloadItem(result, target)); err != nil {
+			return 0, err
+		}
+		return pruneFiles(target, result.Volume, filter)
+	} else if err != nil {
+		return 0, err
+	}
+
+	// folders are left out as fetching them would fetch everything within them
+	folders := map[string]bool{}
+	for name := range filemap {
+		// the names of files come from the network, so they must not escape the target
+		if !validResultPath(name) {
+			return 0, fmt.Errorf("result has a file with invalid path %q", name)
+		}
+		for p := path.Dir(name); p != "." && p != "/"; p = path.Dir(p) {
+			folders[p] = true
+		}
+	}
+
+	if err = os.MkdirAll(target, model.DownloadFolderPerm); err != nil {
+		return 0, err
+	}
+
+	var names []string
+	for name := range filemap {
+		if !folders[name] && filter.Matches(path.Join(result.Volume, name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		log.Ctx(ctx).Debug().Str("CID", filemap[name]).Msgf("Fetching %s of the result", name)
+		targetFile := filepath.Join(target, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(targetFile), model.DownloadFolderPerm); err != nil {
+			return 0, err
+		}
+		err = downloader.FetchResult(ctx, model.DownloadItem{
+			Name:       name,
+			CID:        filemap[name],
+			SourceType: result.Data.StorageSource,
+			Target:     targetFile,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(names), nil
+}
+
+// validResultPath returns true if every part of the slash-separated path of a file of a result can be joined to
+// the folder the result is fetched to without escaping it.
+func validResultPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if !model.ValidFileName(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneFiles removes the files within the folder that the filter doesn't select, and the folders left empty, and
+// returns how many files were kept. Links pointing outside of the folder are removed whether they are selected or
+// not, so that the files the filter selects are always within the folder.
+func pruneFiles(dir string, volume string, filter *PathFilter) (int, error) {
+	kept := 0
+	var folders []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			folders = append(folders, p)
+			return nil
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			if escapes, err := linkEscapes(dir, p); err != nil || escapes {
+				return os.Remove(p)
+			}
+		}
+		if filter.Matches(path.Join(volume, filepath.ToSlash(rel))) {
+			kept++
+			return nil
+		}
+		return os.Remove(p)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// folders are visited before their contents, so removing them in reverse empties nested folders first
+	for i := len(folders) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(folders[i])
+		if err != nil {
+			return 0, err
+		}
+		if len(entries) == 0 {
+			if err = os.Remove(folders[i]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return kept, nil
+}
+
+// linkEscapes returns true if the symbolic link points outside of the folder.
+func linkEscapes(dir string, link string) (bool, error) {
+	target, err := os.Readlink(link)
+	if err != nil {
+		return false, err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return true, nil
+	}
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
@@ -3,17 +3,30 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/bacalhau-project/bacalhau/pkg/util/targzip"
+	"github.com/c2h5oh/datasize"
 	"github.com/rs/zerolog/log"
 )
 
+// archiveExtension is the suffix of URLs that hold results as a compressed archive, which is extracted on download.
+const archiveExtension = ".tar.gz"
+
+// Downloader downloads results published to an HTTP(S) URL. Results served as a .tar.gz archive are extracted into
+// the target folder, and any other file is saved into it under the name of the last segment of its URL.
 type Downloader struct {
 	Settings *model.DownloaderSettings
 }
@@ -29,7 +42,7 @@ func (httpDownloader *Downloader) IsInstalled(context.Context) (bool, error) {
 }
 
 func (httpDownloader *Downloader) DescribeResult(ctx context.Context, result model.PublishedResult) (map[string]string, error) {
-	return nil, errors.New("not implemented for httpdownloader")
+	return nil, fmt.Errorf("http downloader: %w", downloader.ErrDescribeNotSupported)
 }
 
 func (httpDownloader *Downloader) FetchResult(ctx context.Context, item model.DownloadItem) error {
@@ -40,13 +53,18 @@ func (httpDownloader *Downloader) FetchResult(ctx context.Context, item model.Do
 		log.Ctx(ctx).Debug().Msgf(
 			"Downloading result URL %s '%s' to '%s'...",
 			item.Name,
-			item.CID, item.Target,
+			item.URL, item.Target,
 		)
 
 		innerCtx, cancel := context.WithDeadline(ctx, time.Now().Add(httpDownloader.Settings.Timeout))
 		defer cancel()
 
-		return fetch(innerCtx, item.CID, item.Target)
+		// the clients allowed to see the results nodes serve themselves are told by headers, which other URLs never see
+		var headers map[string]string
+		if strings.HasPrefix(item.Name, model.LocalResultNamePrefix) {
+			headers = httpDownloader.Settings.NodeHeaders
+		}
+		return fetch(innerCtx, item.URL, item.Target, headers, httpDownloader.maxDownloadSize())
 	}()
 
 	if err != nil {
@@ -59,32 +77,64 @@ func (httpDownloader *Downloader) FetchResult(ctx context.Context, item model.Do
 	return nil
 }
 
-func fetch(ctx context.Context, url string, filepath string) error {
-	// Create a new file at the specified filepath
-	out, err := os.OpenFile(filepath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, model.DownloadFilePerm)
+// maxDownloadSize returns the largest a result can be, once extracted if it is an archive.
+func (httpDownloader *Downloader) maxDownloadSize() datasize.ByteSize {
+	if httpDownloader.Settings == nil || httpDownloader.Settings.MaxDownloadSize == 0 {
+		return model.DefaultMaxDownloadSize
+	}
+	return httpDownloader.Settings.MaxDownloadSize
+}
+
+func fetch(ctx context.Context, rawURL string, targetDir string, headers map[string]string, maxSize datasize.ByteSize) error {
+	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return err
 	}
-	defer closer.CloseWithLogOnError("file", out)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("cannot download result from %q, only http and https URLs are supported", rawURL)
+	}
+	name := path.Base(parsed.Path)
+	if name == "/" || name == "." {
+		return fmt.Errorf("cannot download result from %q, the URL does not name a file", rawURL)
+	}
 
 	// Make an HTTP GET request to the URL
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
 		return err
 	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	response, err := http.DefaultClient.Do(req) //nolint
 	if err != nil {
 		return err
 	}
-
 	defer closer.DrainAndCloseWithLogOnError(ctx, "http response", response.Body)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading result from %s failed with status %s", rawURL, response.Status)
+	}
+
+	if strings.HasSuffix(parsed.Path, archiveExtension) {
+		return targzip.DecompressWithMaxTotalBytes(response.Body, targetDir, maxSize)
+	}
 
-	// Write the contents of the response body to the file
-	_, err = io.Copy(out, response.Body)
+	if err = os.MkdirAll(targetDir, model.DownloadFolderPerm); err != nil {
+		return err
+	}
+
+	// Create a new file in the target folder
+	out, err := os.OpenFile(filepath.Join(targetDir, name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, model.DownloadFilePerm)
 	if err != nil {
 		return err
 	}
+	defer closer.CloseWithLogOnError("file", out)
 
-	return nil
+	// Write the contents of the response body to the file, reading one byte past the limit to tell if it is exceeded
+	written, err := io.Copy(out, io.LimitReader(response.Body, int64(maxSize)+1))
+	if err == nil && written > int64(maxSize) {
+		err = fmt.Errorf("result from %s is bigger than the maximum download size of %s", rawURL, maxSize.HR())
+	}
+	return err
 }
@@ -0,0 +1,69 @@
+//go:build unit || !integration
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchResult(t *testing.T) {
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(zw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: model.DownloadFilenameStdout, Mode: 0600, Size: 5, Typeflag: tar.TypeReg,
+	}))
+	_, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/result.tar.gz":
+			_, _ = w.Write(archive.Bytes())
+		case "/report.txt":
+			_, _ = w.Write([]byte("report"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	d := NewHTTPDownloader(&model.DownloaderSettings{Timeout: 10 * time.Second})
+	ctx := context.Background()
+
+	target := filepath.Join(t.TempDir(), "archive")
+	require.NoError(t, d.FetchResult(ctx, model.DownloadItem{URL: server.URL + "/result.tar.gz", Target: target}))
+	content, err := os.ReadFile(filepath.Join(target, model.DownloadFilenameStdout))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+
+	target = filepath.Join(t.TempDir(), "file")
+	require.NoError(t, d.FetchResult(ctx, model.DownloadItem{URL: server.URL + "/report.txt", Target: target}))
+	content, err = os.ReadFile(filepath.Join(target, "report.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "report", string(content))
+
+	err = d.FetchResult(ctx, model.DownloadItem{URL: server.URL + "/missing.txt", Target: t.TempDir()})
+	require.ErrorContains(t, err, "404")
+
+	// results bigger than the maximum download size are not written past it
+	d = NewHTTPDownloader(&model.DownloaderSettings{Timeout: 10 * time.Second, MaxDownloadSize: 4})
+	err = d.FetchResult(ctx, model.DownloadItem{URL: server.URL + "/report.txt", Target: t.TempDir()})
+	require.ErrorContains(t, err, "bigger than the maximum download size")
+	err = d.FetchResult(ctx, model.DownloadItem{URL: server.URL + "/result.tar.gz", Target: t.TempDir()})
+	require.Error(t, err)
+}
@@ -3,11 +3,15 @@ package ipfs
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
 	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/c2h5oh/datasize"
+	"github.com/ipfs/go-cid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -97,31 +101,85 @@ func (d *Downloader) FetchResult(ctx context.Context, item model.DownloadItem) e
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/ipfs.Downloader.FetchResult")
 	defer span.End()
 
-	ipfsClient, err := d.getClient(ctx)
-
-	if err != nil {
+	gateways := d.gateways()
+	err := d.fetchFromNetwork(ctx, item, len(gateways) > 0)
+	if err == nil {
+		log.Ctx(ctx).Info().Msgf("Fetched %s from the IPFS network", item.CID)
+		return nil
+	}
+	if len(gateways) == 0 {
 		return err
 	}
 
-	err = func() error {
-		log.Ctx(ctx).Debug().
-			Str("cid", item.CID).
-			Str("name", item.Name).
-			Str("path", item.Target).
-			Msg("Downloading result CID")
-
-		innerCtx, cancel := context.WithTimeout(ctx, d.settings.Timeout)
-		defer cancel()
+	log.Ctx(ctx).Warn().Err(err).Msgf("Failed to fetch %s from the IPFS network, falling back to gateways", item.CID)
+	c, cidErr := cid.Decode(item.CID)
+	if cidErr != nil {
+		return cidErr
+	}
 
-		return ipfsClient.Get(innerCtx, item.CID, item.Target)
-	}()
+	for _, gateway := range gateways {
+		// clear anything left behind by the previous attempt
+		if err = os.RemoveAll(item.Target); err != nil {
+			return err
+		}
 
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Ctx(ctx).Error().Msg("Timed out while downloading result")
+		err = func() error {
+			innerCtx, cancel := context.WithTimeout(ctx, d.settings.Timeout)
+			defer cancel()
+			return fetchFromGateway(innerCtx, gateway, c, item.Target, d.maxDownloadSize())
+		}()
+		if err == nil {
+			log.Ctx(ctx).Info().Msgf("Fetched %s from gateway %s", item.CID, gateway)
+			return nil
 		}
+		log.Ctx(ctx).Warn().Err(err).Msgf("Failed to fetch %s from gateway %s", item.CID, gateway)
+	}
+
+	return fmt.Errorf("failed to fetch %s from the IPFS network and from gateways: %w", item.CID, err)
+}
+
+// maxDownloadSize returns the largest a result fetched from a gateway can be.
+func (d *Downloader) maxDownloadSize() datasize.ByteSize {
+	if d.settings.MaxDownloadSize == 0 {
+		return model.DefaultMaxDownloadSize
+	}
+	return d.settings.MaxDownloadSize
+}
 
+// fetchFromNetwork fetches the item through an IPFS node, resuming what an earlier attempt left in the target. When
+// there are gateways to fall back to, the node only gets the fallback timeout to retrieve the item.
+func (d *Downloader) fetchFromNetwork(ctx context.Context, item model.DownloadItem, canFallBack bool) error {
+	ipfsClient, err := d.getClient(ctx)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	log.Ctx(ctx).Debug().
+		Str("cid", item.CID).
+		Str("name", item.Name).
+		Str("path", item.Target).
+		Msg("Downloading result CID")
+
+	timeout := d.settings.Timeout
+	if canFallBack && d.settings.GatewayFallbackTimeout > 0 && d.settings.GatewayFallbackTimeout < timeout {
+		timeout = d.settings.GatewayFallbackTimeout
+	}
+	innerCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = newResumableFetch(ipfsClient, d.settings.Retries).fetch(innerCtx, item.CID, item.Target)
+	if errors.Is(err, context.DeadlineExceeded) && !canFallBack {
+		log.Ctx(ctx).Error().Msg("Timed out while downloading result")
+	}
+	return err
+}
+
+func (d *Downloader) gateways() []string {
+	var gateways []string
+	for _, gateway := range strings.Split(d.settings.IPFSGateways, ",") {
+		if gateway = strings.TrimSpace(gateway); gateway != "" {
+			gateways = append(gateways, gateway)
+		}
+	}
+	return gateways
 }
@@ -0,0 +1,146 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs/car"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/c2h5oh/datasize"
+	"github.com/ipfs/go-cid"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/rs/zerolog/log"
+)
+
+const carContentType = "application/vnd.ipld.car"
+
+// fetchFromGateway downloads the DAG of a CID from an HTTP gateway as a CAR and writes its contents to target.
+// Gateways are not trusted: every block is verified against its CID as it is read, and only blocks reachable
+// from the requested CID are written out. Gateways that respond with more than maxSize are given up on.
+func fetchFromGateway(ctx context.Context, gateway string, c cid.Cid, target string, maxSize datasize.ByteSize) error {
+	base, err := url.Parse(strings.TrimSuffix(gateway, "/"))
+	if err != nil {
+		return fmt.Errorf("invalid gateway %q: %w", gateway, err)
+	}
+	base.Path += "/ipfs/" + c.String()
+	base.RawQuery = "format=car"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", carContentType)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closer.DrainAndCloseWithLogOnError(ctx, "gateway response", res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("gateway %s responded with %s", gateway, res.Status)
+	}
+
+	// the blocks are spilled to a temporary file rather than held in memory, as results can be large
+	blocks, err := newDiskBlockstore()
+	if err != nil {
+		return err
+	}
+	defer blocks.Close()
+	// read one byte past the limit to tell if it is exceeded
+	body := &io.LimitedReader{R: res.Body, N: int64(maxSize) + 1}
+	err = blocks.readVerifiedBlocks(body)
+	if body.N == 0 {
+		return fmt.Errorf("CAR from gateway %s is bigger than the maximum download size of %s", gateway, maxSize.HR())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CAR from gateway %s: %w", gateway, err)
+	}
+
+	ls := cidlink.DefaultLinkSystem()
+	ls.StorageReadOpener = func(_ ipld.LinkContext, l ipld.Link) (io.Reader, error) {
+		cl, ok := l.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("not a cidlink")
+		}
+		block, ok := blocks.get(cl.Cid)
+		if !ok {
+			return nil, fmt.Errorf("gateway %s did not return block %s", gateway, cl)
+		}
+		return block, nil
+	}
+
+	return car.Extract(ctx, &ls, c, target)
+}
+
+// blockLocation is where a block is stored in the file of a diskBlockstore.
+type blockLocation struct {
+	offset int64
+	size   int64
+}
+
+// diskBlockstore stores the blocks of a CAR in a temporary file, so that only their locations are held in memory.
+// Blocks are keyed by multihash so that CIDv0 and CIDv1 links to the same block both resolve.
+type diskBlockstore struct {
+	file      *os.File
+	size      int64
+	locations map[string]blockLocation
+}
+
+func newDiskBlockstore() (*diskBlockstore, error) {
+	file, err := os.CreateTemp("", "bacalhau-gateway-blocks-*")
+	if err != nil {
+		return nil, err
+	}
+	return &diskBlockstore{file: file, locations: make(map[string]blockLocation)}, nil
+}
+
+// readVerifiedBlocks stores all the blocks of a CAR.
+func (s *diskBlockstore) readVerifiedBlocks(r io.Reader) error {
+	// the block reader verifies that each block hashes to its CID, as the CAR is not trusted
+	reader, err := carv2.NewBlockReader(r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		block, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		n, err := s.file.Write(block.RawData())
+		if err != nil {
+			return err
+		}
+		s.locations[string(block.Cid().Hash())] = blockLocation{offset: s.size, size: int64(n)}
+		s.size += int64(n)
+	}
+}
+
+// get returns a reader of the block of the CID, and false if the CAR did not hold it.
+func (s *diskBlockstore) get(c cid.Cid) (io.Reader, bool) {
+	location, ok := s.locations[string(c.Hash())]
+	if !ok {
+		return nil, false
+	}
+	return io.NewSectionReader(s.file, location.offset, location.size), true
+}
+
+// Close removes the temporary file of the blocks.
+func (s *diskBlockstore) Close() {
+	closer.CloseWithLogOnError("gateway blocks", s.file)
+	if err := os.Remove(s.file.Name()); err != nil {
+		log.Warn().Err(err).Msgf("failed to remove %s", s.file.Name())
+	}
+}
@@ -0,0 +1,103 @@
+//go:build unit || !integration
+
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs/car"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// newTestGateway serves a CAR of a directory holding a single file, optionally tampering with its contents.
+func newTestGateway(t *testing.T, tamper bool) (*httptest.Server, cid.Cid) {
+	inputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "hello.txt"), []byte("hello world"), 0644))
+	return newTestGatewayOf(t, inputDir, tamper)
+}
+
+// newTestGatewayOf serves a CAR of the directory, optionally tampering with the contents of its hello.txt file.
+func newTestGatewayOf(t *testing.T, inputDir string, tamper bool) (*httptest.Server, cid.Cid) {
+	ctx := context.Background()
+	carFile := filepath.Join(t.TempDir(), "test.car")
+	root, err := car.CreateCar(ctx, inputDir, carFile, 1)
+	require.NoError(t, err)
+	rootCID, err := cid.Decode(root)
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(carFile)
+	require.NoError(t, err)
+	if tamper {
+		contents = bytes.Replace(contents, []byte("hello world"), []byte("HELLO WORLD"), 1)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipfs/"+root || r.URL.Query().Get("format") != "car" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", carContentType)
+		_, _ = w.Write(contents)
+	}))
+	t.Cleanup(server.Close)
+	return server, rootCID
+}
+
+func TestFetchFromGateway(t *testing.T) {
+	server, root := newTestGateway(t, false)
+	target := filepath.Join(t.TempDir(), "output")
+
+	require.NoError(t, fetchFromGateway(context.Background(), server.URL, root, target, model.DefaultMaxDownloadSize))
+
+	contents, err := os.ReadFile(filepath.Join(target, "hello.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(contents))
+}
+
+func TestFetchFromGatewayRejectsTamperedBlocks(t *testing.T) {
+	server, root := newTestGateway(t, true)
+	target := filepath.Join(t.TempDir(), "output")
+
+	require.Error(t, fetchFromGateway(context.Background(), server.URL, root, target, model.DefaultMaxDownloadSize))
+	require.NoFileExists(t, filepath.Join(target, "hello.txt"))
+}
+
+func TestFetchFromGatewayMissingCID(t *testing.T) {
+	server, _ := newTestGateway(t, false)
+	other, err := cid.Decode("QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn")
+	require.NoError(t, err)
+
+	require.Error(t, fetchFromGateway(context.Background(), server.URL, other, filepath.Join(t.TempDir(), "output"), model.DefaultMaxDownloadSize))
+}
+
+func TestFetchFromGatewayRejectsLargeResponses(t *testing.T) {
+	server, root := newTestGateway(t, false)
+	target := filepath.Join(t.TempDir(), "output")
+
+	err := fetchFromGateway(context.Background(), server.URL, root, target, 16)
+	require.ErrorContains(t, err, "bigger than the maximum download size")
+	require.NoFileExists(t, filepath.Join(target, "hello.txt"))
+}
+
+func TestFetchFromGatewayRejectsEscapingSymlinks(t *testing.T) {
+	for _, link := range []string{"../../secret", "/etc/passwd"} {
+		inputDir := t.TempDir()
+		require.NoError(t, os.Symlink(link, filepath.Join(inputDir, "escape")))
+		server, root := newTestGatewayOf(t, inputDir, false)
+		target := filepath.Join(t.TempDir(), "output")
+
+		err := fetchFromGateway(context.Background(), server.URL, root, target, model.DefaultMaxDownloadSize)
+		require.ErrorContains(t, err, link)
+		_, err = os.Lstat(filepath.Join(target, "escape"))
+		require.True(t, os.IsNotExist(err), "link to %s is not written", link)
+	}
+}
@@ -0,0 +1,328 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/ipfs/go-cid"
+	files "github.com/ipfs/go-libipfs/files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	icoreoptions "github.com/ipfs/interface-go-ipfs-core/options"
+	icorepath "github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// retryInitialBackoff is how long to wait before fetching a file again. The wait doubles on every retry.
+	retryInitialBackoff = time.Second
+	// retryMaxBackoff caps how long to wait before fetching a file again.
+	retryMaxBackoff = 30 * time.Second
+	// progressInterval is how often the progress of fetching a file is logged.
+	progressInterval = 5 * time.Second
+)
+
+// resumableFile is a file of a CID to fetch, and where to write it.
+type resumableFile struct {
+	cid    cid.Cid
+	size   int64
+	target string
+}
+
+// resumableFetch fetches a CID through an IPFS node one file at a time, so that an interrupted download can carry on
+// where it stopped:
+//   - files already in the target with the size and hash of the file in the CID are skipped,
+//   - files that were only partly written are resumed from their current size, and checked against their CID once
+//     they are complete,
+//   - files that fail to be fetched are retried with backoff.
+//
+// Files are hashed with the default options of `ipfs add`, which results are published with, so files that were
+// added with other options are never skipped and are always fetched again.
+type resumableFetch struct {
+	client   ipfs.Client
+	retries  int
+	progress *fetchProgress
+}
+
+func newResumableFetch(client ipfs.Client, retries int) *resumableFetch {
+	return &resumableFetch{
+		client:  client,
+		retries: retries,
+	}
+}
+
+// fetch writes the file or directory of the CID to the target, which may hold what an earlier fetch left behind.
+func (f *resumableFetch) fetch(ctx context.Context, c string, target string) error {
+	root, err := cid.Decode(c)
+	if err != nil {
+		return err
+	}
+	toFetch, err := f.listFiles(ctx, root, target, target)
+	if err != nil {
+		return err
+	}
+
+	f.progress = newFetchProgress(toFetch)
+	for _, file := range toFetch {
+		if err = f.fetchFileWithRetries(ctx, file); err != nil {
+			return err
+		}
+	}
+	log.Ctx(ctx).Debug().Msgf("Fetched %s: %s", c, f.progress)
+	return nil
+}
+
+// listFiles creates the directories and symlinks of the node in the target, and returns the files left to fetch. Root
+// is the target of the whole CID, which nothing is written outside of.
+func (f *resumableFetch) listFiles(ctx context.Context, c cid.Cid, target, root string) ([]resumableFile, error) {
+	node, err := f.client.API.Unixfs().Get(ctx, icorepath.IpfsPath(c))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ipfs cid '%s': %w", c, err)
+	}
+	defer node.Close()
+
+	switch n := node.(type) {
+	case files.Directory:
+		return f.listDirectory(ctx, c, target, root)
+	case *files.Symlink:
+		return nil, util.WriteSymlink(n.Target, target, root)
+	case files.File:
+		size, err := n.Size()
+		if err != nil {
+			return nil, err
+		}
+		return []resumableFile{{cid: c, size: size, target: target}}, nil
+	default:
+		return nil, fmt.Errorf("ipfs cid '%s' is an unsupported type of node %T", c, node)
+	}
+}
+
+func (f *resumableFetch) listDirectory(ctx context.Context, c cid.Cid, target, root string) ([]resumableFile, error) {
+	// the target may have been left by an earlier fetch, and files must not be written through a symlink left there
+	info, err := os.Lstat(target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err = os.MkdirAll(target, model.DownloadFolderPerm); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !info.IsDir():
+		return nil, fmt.Errorf("failed to write to '%s': already exists and is not a directory", target)
+	}
+
+	listing, err := f.client.API.Unixfs().Ls(ctx, icorepath.IpfsPath(c))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ipfs cid '%s': %w", c, err)
+	}
+	// the whole listing is read before fetching anything so that it is not left blocked on an error
+	var entries []icore.DirEntry
+	for entry := range listing {
+		if entry.Err != nil {
+			return nil, fmt.Errorf("failed to list ipfs cid '%s': %w", c, entry.Err)
+		}
+		entries = append(entries, entry)
+	}
+
+	var toFetch []resumableFile
+	for _, entry := range entries {
+		// the names of entries come from the network, so they must not escape the target
+		if !model.ValidFileName(entry.Name) {
+			return nil, fmt.Errorf("ipfs cid '%s' has an entry with invalid name %q", c, entry.Name)
+		}
+		entryTarget := filepath.Join(target, entry.Name)
+		switch entry.Type {
+		case icore.TDirectory:
+			dirFiles, err := f.listDirectory(ctx, entry.Cid, entryTarget, root)
+			if err != nil {
+				return nil, err
+			}
+			toFetch = append(toFetch, dirFiles...)
+		case icore.TSymlink:
+			if err = util.WriteSymlink(entry.Target, entryTarget, root); err != nil {
+				return nil, err
+			}
+		default:
+			toFetch = append(toFetch, resumableFile{cid: entry.Cid, size: int64(entry.Size), target: entryTarget})
+		}
+	}
+	return toFetch, nil
+}
+
+// fetchFileWithRetries fetches the file, trying again with backoff if it fails and there are retries left.
+func (f *resumableFetch) fetchFileWithRetries(ctx context.Context, file resumableFile) error {
+	backoff := retryInitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := f.fetchFile(ctx, file)
+		if err == nil || attempt >= f.retries || ctx.Err() != nil {
+			return err
+		}
+
+		log.Ctx(ctx).Warn().Err(err).Msgf("Failed to fetch %s, retrying in %s", file.target, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
+
+// fetchFile fetches the file, skipping it if the target already holds it, or resuming it if the target holds the
+// start of it.
+func (f *resumableFetch) fetchFile(ctx context.Context, file resumableFile) error {
+	var offset int64
+	info, err := os.Lstat(file.target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("failed to write to '%s': already exists and is not a file", file.target)
+	case info.Size() == file.size:
+		matches, err := f.matches(ctx, file)
+		if err != nil {
+			return err
+		}
+		if matches {
+			log.Ctx(ctx).Debug().Msgf("Skipping %s, which has already been fetched", file.target)
+			f.progress.done(ctx, file, true)
+			return nil
+		}
+	case info.Size() < file.size:
+		offset = info.Size()
+		log.Ctx(ctx).Debug().Msgf("Resuming %s from byte %d", file.target, offset)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(file.target, flags, model.DownloadFilePerm)
+	if err != nil {
+		return err
+	}
+	err = f.copyFile(ctx, file, offset, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write to '%s': %w", file.target, err)
+	}
+
+	if offset > 0 {
+		// the start of the file was written by an earlier fetch, which may have written something else
+		matches, err := f.matches(ctx, file)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			if err = os.Remove(file.target); err != nil {
+				return err
+			}
+			return fmt.Errorf("resumed file '%s' does not match ipfs cid '%s'", file.target, file.cid)
+		}
+	}
+	f.progress.done(ctx, file, false)
+	return nil
+}
+
+// copyFile copies the contents of the file from the offset to out.
+func (f *resumableFetch) copyFile(ctx context.Context, file resumableFile, offset int64, out io.Writer) error {
+	node, err := f.client.API.Unixfs().Get(ctx, icorepath.IpfsPath(file.cid))
+	if err != nil {
+		return fmt.Errorf("failed to get ipfs cid '%s': %w", file.cid, err)
+	}
+	defer node.Close()
+
+	contents, ok := node.(files.File)
+	if !ok {
+		return fmt.Errorf("ipfs cid '%s' is not a file", file.cid)
+	}
+	if _, err = contents.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(&progressWriter{ctx: ctx, w: out, file: file, offset: offset}, contents)
+	return err
+}
+
+// matches returns true if the target holds the contents of the file, by hashing it the way it was added to IPFS.
+func (f *resumableFetch) matches(ctx context.Context, file resumableFile) (bool, error) {
+	in, err := os.Open(file.target)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	resolved, err := f.client.API.Unixfs().Add(ctx, files.NewReaderFile(in), icoreoptions.Unixfs.HashOnly(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to hash '%s': %w", file.target, err)
+	}
+	// CIDv0 and CIDv1 of the same contents share their multihash
+	return bytes.Equal(resolved.Cid().Hash(), file.cid.Hash()), nil
+}
+
+// fetchProgress tracks how many of the files of a CID, and of their bytes, have been fetched.
+type fetchProgress struct {
+	files, totalFiles int
+	bytes, totalBytes int64
+	skipped           int
+}
+
+func newFetchProgress(toFetch []resumableFile) *fetchProgress {
+	p := &fetchProgress{totalFiles: len(toFetch)}
+	for _, file := range toFetch {
+		p.totalBytes += file.size
+	}
+	return p
+}
+
+// done records that the file has been fetched, or skipped because it already had been.
+func (p *fetchProgress) done(ctx context.Context, file resumableFile, skipped bool) {
+	p.files++
+	p.bytes += file.size
+	if skipped {
+		p.skipped++
+	}
+	log.Ctx(ctx).Info().Msgf("Fetched %s (%s)", file.target, p)
+}
+
+func (p *fetchProgress) String() string {
+	s := fmt.Sprintf("%d/%d files, %d/%d bytes", p.files, p.totalFiles, p.bytes, p.totalBytes)
+	if p.skipped > 0 {
+		s += fmt.Sprintf(", %d already fetched", p.skipped)
+	}
+	return s
+}
+
+// progressWriter logs how much of a file has been written every progressInterval.
+type progressWriter struct {
+	ctx        context.Context
+	w          io.Writer
+	file       resumableFile
+	offset     int64
+	lastReport time.Time
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	if w.lastReport.IsZero() {
+		w.lastReport = time.Now()
+	} else if time.Since(w.lastReport) >= progressInterval {
+		w.lastReport = time.Now()
+		log.Ctx(w.ctx).Info().Msgf("Fetching %s: %d/%d bytes", w.file.target, w.offset, w.file.size)
+	}
+	return n, err
+}
@@ -0,0 +1,172 @@
+//go:build unit || !integration
+
+package ipfs
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+type ResumableFetchSuite struct {
+	suite.Suite
+	ctx    context.Context
+	client ipfs.Client
+	large  []byte
+	cid    string
+}
+
+func TestResumableFetchSuite(t *testing.T) {
+	suite.Run(t, new(ResumableFetchSuite))
+}
+
+func (s *ResumableFetchSuite) SetupSuite() {
+	logger.ConfigureTestLogging(s.T())
+	system.InitConfigForTesting(s.T())
+}
+
+func (s *ResumableFetchSuite) SetupTest() {
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.T().Cleanup(cancel)
+	s.ctx = ctx
+
+	node, err := ipfs.NewLocalNode(ctx, cm, nil)
+	s.Require().NoError(err)
+	s.client = node.Client()
+
+	// the large file spans several blocks, so that resuming it seeks past the first ones
+	s.large = make([]byte, 600*1024)
+	_, err = rand.Read(s.large)
+	s.Require().NoError(err)
+
+	inputDir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(inputDir, "large.bin"), s.large, 0644))
+	s.Require().NoError(os.Mkdir(filepath.Join(inputDir, "outputs"), 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(inputDir, "outputs", "small.txt"), []byte("hello world"), 0644))
+	s.Require().NoError(os.WriteFile(filepath.Join(inputDir, "empty"), nil, 0644))
+
+	s.cid, err = s.client.Put(ctx, inputDir)
+	s.Require().NoError(err)
+}
+
+func (s *ResumableFetchSuite) fetch(target string, retries int) {
+	s.Require().NoError(newResumableFetch(s.client, retries).fetch(s.ctx, s.cid, target))
+	s.requireFetched(target)
+}
+
+func (s *ResumableFetchSuite) requireFetched(target string) {
+	large, err := os.ReadFile(filepath.Join(target, "large.bin"))
+	s.Require().NoError(err)
+	s.Require().Equal(s.large, large)
+
+	small, err := os.ReadFile(filepath.Join(target, "outputs", "small.txt"))
+	s.Require().NoError(err)
+	s.Require().Equal("hello world", string(small))
+
+	s.Require().FileExists(filepath.Join(target, "empty"))
+}
+
+func (s *ResumableFetchSuite) TestFetch() {
+	s.fetch(filepath.Join(s.T().TempDir(), "output"), 0)
+}
+
+func (s *ResumableFetchSuite) TestSkipsFetchedFiles() {
+	target := filepath.Join(s.T().TempDir(), "output")
+	s.fetch(target, 0)
+
+	// files that are skipped are not written again
+	small := filepath.Join(target, "outputs", "small.txt")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s.Require().NoError(os.Chtimes(small, past, past))
+
+	s.fetch(target, 0)
+	info, err := os.Stat(small)
+	s.Require().NoError(err)
+	s.Require().Equal(past, info.ModTime())
+}
+
+func (s *ResumableFetchSuite) TestResumesPartialFiles() {
+	target := filepath.Join(s.T().TempDir(), "output")
+	s.Require().NoError(os.MkdirAll(target, 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(target, "large.bin"), s.large[:300*1024+7], 0644))
+
+	s.fetch(target, 0)
+}
+
+func (s *ResumableFetchSuite) TestRefetchesMismatchedFiles() {
+	target := filepath.Join(s.T().TempDir(), "output")
+	s.Require().NoError(os.MkdirAll(filepath.Join(target, "outputs"), 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(target, "outputs", "small.txt"), []byte("HELLO WORLD"), 0644))
+
+	s.fetch(target, 0)
+}
+
+func (s *ResumableFetchSuite) TestRetriesCorruptedPartialFiles() {
+	target := filepath.Join(s.T().TempDir(), "output")
+	s.Require().NoError(os.MkdirAll(target, 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(target, "large.bin"), make([]byte, 1024), 0644))
+
+	// the resumed file does not match its CID, so it can only be fetched again from the start
+	err := newResumableFetch(s.client, 0).fetch(s.ctx, s.cid, target)
+	s.Require().ErrorContains(err, "does not match")
+	s.Require().NoFileExists(filepath.Join(target, "large.bin"))
+
+	s.Require().NoError(os.WriteFile(filepath.Join(target, "large.bin"), make([]byte, 1024), 0644))
+	s.fetch(target, 1)
+}
+
+func (s *ResumableFetchSuite) TestFetchSingleFile() {
+	root, err := s.client.GetTreeNode(s.ctx, s.cid)
+	s.Require().NoError(err)
+	var fileCID string
+	for _, child := range root.Children {
+		if len(child.Path) == 1 && child.Path[0] == "large.bin" {
+			fileCID = child.Cid.String()
+		}
+	}
+	s.Require().NotEmpty(fileCID)
+
+	target := filepath.Join(s.T().TempDir(), "large.bin")
+	s.Require().NoError(os.WriteFile(target, s.large[:1024], 0644))
+	s.Require().NoError(newResumableFetch(s.client, 0).fetch(s.ctx, fileCID, target))
+
+	large, err := os.ReadFile(target)
+	s.Require().NoError(err)
+	s.Require().Equal(s.large, large)
+}
+
+func (s *ResumableFetchSuite) TestRefusesSymlinkLeftAtDirectory() {
+	target := filepath.Join(s.T().TempDir(), "output")
+	elsewhere := s.T().TempDir()
+	s.Require().NoError(os.MkdirAll(target, 0755))
+	s.Require().NoError(os.Symlink(elsewhere, filepath.Join(target, "outputs")))
+
+	err := newResumableFetch(s.client, 0).fetch(s.ctx, s.cid, target)
+	s.Require().ErrorContains(err, "is not a directory")
+	s.Require().NoFileExists(filepath.Join(elsewhere, "small.txt"))
+}
+
+func (s *ResumableFetchSuite) TestRefusesEscapingSymlinks() {
+	inputDir := s.T().TempDir()
+	s.Require().NoError(os.Symlink("../../secret", filepath.Join(inputDir, "escape")))
+	c, err := s.client.Put(s.ctx, inputDir)
+	s.Require().NoError(err)
+
+	target := filepath.Join(s.T().TempDir(), "output")
+	err = newResumableFetch(s.client, 0).fetch(s.ctx, c, target)
+	s.Require().ErrorContains(err, "outside of")
+	s.Require().NoFileExists(filepath.Join(target, "escape"))
+}
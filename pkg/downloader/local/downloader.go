@@ -0,0 +1,109 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Downloader copies results that were published to a directory of the local filesystem, such as results of nodes
+// running on the same machine as the client. Results are only copied from within the local results directories of
+// the settings, as the path of a result is set by the node that published it.
+type Downloader struct {
+	settings *model.DownloaderSettings
+}
+
+func NewDownloader(settings *model.DownloaderSettings) *Downloader {
+	return &Downloader{settings: settings}
+}
+
+func (d *Downloader) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (d *Downloader) DescribeResult(context.Context, model.PublishedResult) (map[string]string, error) {
+	return nil, fmt.Errorf("local downloader: %w", downloader.ErrDescribeNotSupported)
+}
+
+func (d *Downloader) FetchResult(ctx context.Context, item model.DownloadItem) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/local.Downloader.FetchResult")
+	defer span.End()
+
+	if item.SourcePath == "" {
+		return fmt.Errorf("no local path to copy %s from", item.Name)
+	}
+	sourcePath, err := d.allowedPath(item.SourcePath)
+	if err != nil {
+		return err
+	}
+	log.Ctx(ctx).Debug().Msgf("Copying result %s to '%s'...", sourcePath, item.Target)
+
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err = os.MkdirAll(item.Target, model.DownloadFolderPerm); err != nil {
+			return err
+		}
+		return util.CopyFile(sourcePath, filepath.Join(item.Target, filepath.Base(sourcePath)), model.DownloadFilePerm)
+	}
+
+	return filepath.WalkDir(sourcePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(item.Target, relPath)
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, model.DownloadFolderPerm)
+		case entry.Type().IsRegular():
+			return util.CopyFile(path, target, model.DownloadFilePerm)
+		default:
+			log.Ctx(ctx).Debug().Msgf("Skipping %s of result, it is not a regular file", path)
+			return nil
+		}
+	})
+}
+
+// allowedPath returns the path of a result with its symlinks resolved, or an error if it is not within one of the local
+// results directories.
+func (d *Downloader) allowedPath(sourcePath string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(sourcePath)
+	if err != nil {
+		return "", err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	if d.settings != nil {
+		for _, directory := range d.settings.LocalResultsDirectories {
+			allowed, err := filepath.EvalSymlinks(directory)
+			if err != nil {
+				continue
+			}
+			if allowed, err = filepath.Abs(allowed); err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(allowed, resolved); err == nil && rel != ".." &&
+				!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return resolved, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("not copying result from %s, it is not in a local results directory", sourcePath)
+}
@@ -0,0 +1,108 @@
+//go:build unit || !integration
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+// writeResult writes the files of a result to a new directory, by their path within the result.
+func writeResult(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), model.DownloadFolderPerm))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), model.DownloadFilePerm))
+	}
+	return dir
+}
+
+// newProvider returns a provider that copies results from the directories.
+func newProvider(directories ...string) downloader.DownloaderProvider {
+	settings := &model.DownloaderSettings{LocalResultsDirectories: directories}
+	return model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
+		model.StorageSourceLocalDirectory: NewDownloader(settings),
+	})
+}
+
+func TestDownloadResults(t *testing.T) {
+	source := writeResult(t, map[string]string{
+		model.DownloadFilenameStdout: "hello",
+		"outputs/data/result.csv":    "a,b",
+	})
+	outputDir := t.TempDir()
+	results := []model.PublishedResult{{
+		NodeID: "QmNode",
+		Data:   model.StorageSpec{StorageSource: model.StorageSourceLocalDirectory, Name: "result", SourcePath: source},
+	}}
+
+	err := downloader.DownloadResults(context.Background(), results, newProvider(source), &model.DownloaderSettings{
+		OutputDir: outputDir,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, model.DownloadFilenameStdout))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+	content, err = os.ReadFile(filepath.Join(outputDir, "outputs", "data", "result.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "a,b", string(content))
+}
+
+func TestDownloadResults_SingleFile(t *testing.T) {
+	source := writeResult(t, map[string]string{
+		"outputs/data/result.csv": "a,b",
+		"outputs/other.txt":       "other",
+	})
+	outputDir := t.TempDir()
+	results := []model.PublishedResult{{
+		NodeID: "QmNode",
+		Data:   model.StorageSpec{StorageSource: model.StorageSourceLocalDirectory, Name: "result", SourcePath: source},
+	}}
+
+	// the result is fetched as a whole and only the requested file is kept, as it can't be described
+	err := downloader.DownloadResults(context.Background(), results, newProvider(source), &model.DownloaderSettings{
+		OutputDir:  outputDir,
+		SingleFile: "outputs/data/result.csv",
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "outputs", "data", "result.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "a,b", string(content))
+	require.NoFileExists(t, filepath.Join(outputDir, "outputs", "other.txt"))
+
+	err = downloader.DownloadResults(context.Background(), results, newProvider(source), &model.DownloaderSettings{
+		OutputDir:  t.TempDir(),
+		SingleFile: "outputs/missing.txt",
+	})
+	require.ErrorContains(t, err, "failed to find outputs/missing.txt")
+}
+
+func TestDownloadResults_OnlyFromLocalResultsDirectories(t *testing.T) {
+	source := writeResult(t, map[string]string{model.DownloadFilenameStdout: "hello"})
+	results := []model.PublishedResult{{
+		NodeID: "QmNode",
+		Data:   model.StorageSpec{StorageSource: model.StorageSourceLocalDirectory, Name: "result", SourcePath: source},
+	}}
+
+	err := downloader.DownloadResults(context.Background(), results, newProvider(t.TempDir()), &model.DownloaderSettings{
+		OutputDir: t.TempDir(),
+	})
+	require.ErrorContains(t, err, "not in a local results directory")
+
+	// symlinks out of the directory are resolved before checking the path
+	allowed := t.TempDir()
+	require.NoError(t, os.Symlink(source, filepath.Join(allowed, "link")))
+	results[0].Data.SourcePath = filepath.Join(allowed, "link")
+	err = downloader.DownloadResults(context.Background(), results, newProvider(allowed), &model.DownloaderSettings{
+		OutputDir: t.TempDir(),
+	})
+	require.ErrorContains(t, err, "not in a local results directory")
+}
@@ -0,0 +1,39 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+// ResultsOfNode returns the results published by a single compute node, given its ID or a prefix of it. Each node that
+// ran the job publishes its own results, so this selects the results of one execution instead of merging them all.
+func ResultsOfNode(results []model.PublishedResult, nodeID string) ([]model.PublishedResult, error) {
+	if nodeID == "" {
+		return results, nil
+	}
+
+	var selected []model.PublishedResult
+	matched := map[string]bool{}
+	for _, result := range results {
+		if strings.HasPrefix(result.NodeID, nodeID) {
+			selected = append(selected, result)
+			matched[result.NodeID] = true
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		nodes := make([]string, 0, len(results))
+		for _, result := range results {
+			nodes = append(nodes, system.GetShortID(result.NodeID))
+		}
+		return nil, fmt.Errorf("no results published by node %s, results were published by: %s", nodeID, strings.Join(nodes, ", "))
+	case 1:
+		return selected, nil
+	default:
+		return nil, fmt.Errorf("node ID %s is ambiguous, it matches the results of %d nodes", nodeID, len(matched))
+	}
+}
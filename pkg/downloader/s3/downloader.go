@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	basedownloader "github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/s3"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/targzip"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+// archiveExtension is the suffix of keys that the S3 publisher uploads compressed results to.
+const archiveExtension = ".tar.gz"
+
+type DownloaderParams struct {
+	ClientProvider *s3helper.ClientProvider
+	Settings       *model.DownloaderSettings
+}
+
+// Downloader downloads results published to S3 compatible storage, reusing the S3 storage provider to fetch objects
+// and extracting results that were published as an archive.
+type Downloader struct {
+	clientProvider *s3helper.ClientProvider
+	settings       *model.DownloaderSettings
+}
+
+func NewDownloader(params DownloaderParams) *Downloader {
+	return &Downloader{
+		clientProvider: params.ClientProvider,
+		settings:       params.Settings,
+	}
+}
+
+func (downloader *Downloader) IsInstalled(context.Context) (bool, error) {
+	return downloader.clientProvider.IsInstalled(), nil
+}
+
+func (downloader *Downloader) DescribeResult(context.Context, model.PublishedResult) (map[string]string, error) {
+	return nil, fmt.Errorf("s3 downloader: %w", basedownloader.ErrDescribeNotSupported)
+}
+
+func (downloader *Downloader) FetchResult(ctx context.Context, item model.DownloadItem) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/s3.Downloader.FetchResult")
+	defer span.End()
+
+	if item.S3 == nil {
+		return fmt.Errorf("no S3 location to download %s from", item.Name)
+	}
+	log.Ctx(ctx).Debug().Msgf("Downloading result s3://%s/%s to '%s'...", item.S3.Bucket, item.S3.Key, item.Target)
+
+	// download next to the target so that the result can be moved into place
+	storage := s3.NewStorage(s3.StorageProviderParams{
+		LocalDir:       filepath.Dir(item.Target),
+		ClientProvider: downloader.clientProvider,
+	})
+	spec := model.StorageSpec{StorageSource: model.StorageSourceS3, S3: item.S3}
+	volume, err := storage.PrepareStorage(ctx, spec)
+	if err != nil {
+		return err
+	}
+	defer storage.CleanupStorage(ctx, spec, volume) //nolint:errcheck
+
+	if !strings.HasSuffix(item.S3.Key, archiveExtension) {
+		return os.Rename(volume.Source, item.Target)
+	}
+
+	archive, err := os.Open(filepath.Join(volume.Source, path.Base(item.S3.Key)))
+	if err != nil {
+		return err
+	}
+	defer archive.Close() //nolint:errcheck
+	return targzip.DecompressWithMaxTotalBytes(archive, item.Target, downloader.maxDownloadSize())
+}
+
+// maxDownloadSize returns the largest an archived result can be once extracted.
+func (downloader *Downloader) maxDownloadSize() datasize.ByteSize {
+	if downloader.settings == nil || downloader.settings.MaxDownloadSize == 0 {
+		return model.DefaultMaxDownloadSize
+	}
+	return downloader.settings.MaxDownloadSize
+}
@@ -2,22 +2,29 @@ package downloader
 
 import (
 	"context"
+	"errors"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
 
+// ErrDescribeNotSupported is returned by downloaders that cannot list the contents of a result without fetching it.
+// Single files are then picked out of the whole result once it has been fetched.
+var ErrDescribeNotSupported = errors.New("describing the contents of results is not supported")
+
 type Downloader interface {
 	model.Providable
 
 	// DescribeResult provides information on the contents of the result,
 	// providing a mapping between the 'path' of the contents and the
-	// identifier used to fetch it (by this Downloader).
+	// identifier used to fetch it (by this Downloader). Returns
+	// ErrDescribeNotSupported if the contents can't be listed.
 	DescribeResult(ctx context.Context, result model.PublishedResult) (map[string]string, error)
 
 	// FetchResult fetches item and saves to disk (as per item's Target)
 	FetchResult(ctx context.Context, item model.DownloadItem) error
 }
 
+// DownloaderProvider is the registry of downloaders, keyed by the storage source results were published to.
 type DownloaderProvider interface {
 	model.Provider[model.StorageSourceType, Downloader]
 }
@@ -6,35 +6,64 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/estuary"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/http"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/local"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/rs/zerolog/log"
 )
 
 func NewDownloadSettings() *model.DownloaderSettings {
 	settings := model.DownloaderSettings{
 		Timeout: model.DefaultIPFSTimeout,
 		// we leave this blank so the CLI will auto-create a job folder in pwd
-		SingleFile:     "",
-		OutputDir:      "",
-		IPFSSwarmAddrs: "",
+		SingleFile:             "",
+		OutputDir:              "",
+		IPFSSwarmAddrs:         "",
+		IPFSGateways:           strings.Join(model.DefaultIPFSGateways, ","),
+		GatewayFallbackTimeout: model.DefaultGatewayFallbackTimeout,
+		Retries:                model.DefaultDownloadRetries,
+		MaxDownloadSize:        model.DefaultMaxDownloadSize,
 	}
 	if os.Getenv("BACALHAU_IPFS_SWARM_ADDRESSES") != "" {
 		settings.IPFSSwarmAddrs = os.Getenv("BACALHAU_IPFS_SWARM_ADDRESSES")
 	} else {
 		settings.IPFSSwarmAddrs = strings.Join(system.Envs[system.GetEnvironment()].IPFSSwarmAddresses, ",")
 	}
+	if os.Getenv("BACALHAU_IPFS_GATEWAYS") != "" {
+		settings.IPFSGateways = os.Getenv("BACALHAU_IPFS_GATEWAYS")
+	}
 	return &settings
 }
 
+// NewStandardDownloaders returns the registry of downloaders for every storage source results can be published to,
+// which is shared by the CLI and anything else fetching results. Results published to IPFS are fetched from HTTP
+// gateways if the IPFS network fails.
 func NewStandardDownloaders(
 	cm *system.CleanupManager,
 	settings *model.DownloaderSettings) downloader.DownloaderProvider {
 	ipfsDownloader := ipfs.NewIPFSDownloader(cm, settings)
 	estuaryDownloader := estuary.NewEstuaryDownloader(cm, settings)
 
-	return model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
-		model.StorageSourceIPFS:    ipfsDownloader,
-		model.StorageSourceEstuary: estuaryDownloader,
-	})
+	downloaders := map[model.StorageSourceType]downloader.Downloader{
+		model.StorageSourceIPFS:           ipfsDownloader,
+		model.StorageSourceEstuary:        estuaryDownloader,
+		model.StorageSourceURLDownload:    http.NewHTTPDownloader(settings),
+		model.StorageSourceLocalDirectory: local.NewDownloader(settings),
+	}
+
+	// results published to S3 can only be downloaded if AWS is configured
+	cfg, err := s3helper.DefaultAWSConfig()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to load AWS config, S3 results will not be downloaded")
+	} else {
+		downloaders[model.StorageSourceS3] = s3.NewDownloader(s3.DownloaderParams{
+			ClientProvider: s3helper.NewClientProvider(s3helper.ClientProviderParams{AWSConfig: cfg}),
+			Settings:       settings,
+		})
+	}
+	return model.NewMappedProvider(downloaders)
 }
@@ -0,0 +1,112 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type GPUCompatibilityBidStrategyParams struct {
+	// DriverCUDAVersion returns the highest CUDA version supported by the NVIDIA driver of this node,
+	// or an empty string if no driver is installed.
+	DriverCUDAVersion func(ctx context.Context) (string, error)
+	// ImageCUDAVersion returns the CUDA version declared by an image, or an empty string if it declares none or was not
+	// pulled yet.
+	ImageCUDAVersion func(ctx context.Context, image string) (string, error)
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*GPUCompatibilityBidStrategy)(nil)
+
+// GPUCompatibilityBidStrategy declines GPU jobs whose image needs a newer CUDA runtime than the node's NVIDIA driver
+// supports. Checking this before bidding avoids pulling large GPU images only to fail when the container starts. The
+// CUDA version declared by images that are not pulled yet can't be known before bidding, and is checked with
+// CheckPulledImage once they are pulled.
+type GPUCompatibilityBidStrategy struct {
+	driverCUDAVersion func(ctx context.Context) (string, error)
+	imageCUDAVersion  func(ctx context.Context, image string) (string, error)
+}
+
+func NewGPUCompatibilityBidStrategy(params GPUCompatibilityBidStrategyParams) *GPUCompatibilityBidStrategy {
+	return &GPUCompatibilityBidStrategy{
+		driverCUDAVersion: params.DriverCUDAVersion,
+		imageCUDAVersion:  params.ImageCUDAVersion,
+	}
+}
+
+// ShouldBid implements semantic.SemanticBidStrategy
+func (s *GPUCompatibilityBidStrategy) ShouldBid(
+	ctx context.Context,
+	request bidstrategy.BidStrategyRequest,
+) (bidstrategy.BidStrategyResponse, error) {
+	reason, err := s.incompatibility(ctx, request.Job.Spec, request.Job.Spec.Docker.Image)
+	if err != nil {
+		return bidstrategy.BidStrategyResponse{}, err
+	}
+	if reason != "" {
+		return bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: reason}, nil
+	}
+	return bidstrategy.NewShouldBidResponse(), nil
+}
+
+// CheckPulledImage returns an error if the pulled image of the job needs a newer CUDA runtime than the node's NVIDIA
+// driver supports. Images are only inspected once pulled, so the CUDA version declared by images that were not pulled
+// yet when the node bid on the job is checked before the job runs.
+func (s *GPUCompatibilityBidStrategy) CheckPulledImage(ctx context.Context, spec model.Spec, image string) error {
+	reason, err := s.incompatibility(ctx, spec, image)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return errors.New(reason)
+	}
+	return nil
+}
+
+// incompatibility returns why the job can't run with the image on this node, or an empty string if it can.
+func (s *GPUCompatibilityBidStrategy) incompatibility(ctx context.Context, spec model.Spec, image string) (string, error) {
+	if spec.Engine != model.EngineDocker || capacity.ParseResourceUsageConfig(spec.Resources).GPU == 0 {
+		return "", nil
+	}
+
+	required := spec.Docker.CUDAVersion
+	if required == "" {
+		var err error
+		required, err = s.imageCUDAVersion(ctx, image)
+		if err != nil {
+			return "", err
+		}
+	}
+	if required == "" {
+		// nothing declared, so there is nothing to check against
+		return "", nil
+	}
+
+	requiredVersion, err := semver.NewVersion(required)
+	if err != nil {
+		return fmt.Sprintf("job requires unrecognized CUDA version %q", required), nil
+	}
+
+	supported, err := s.driverCUDAVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	if supported == "" {
+		return fmt.Sprintf("job requires CUDA %s but no NVIDIA driver was found on this node", required), nil
+	}
+
+	supportedVersion, err := semver.NewVersion(supported)
+	if err != nil {
+		return "", fmt.Errorf("unrecognized driver CUDA version %q: %w", supported, err)
+	}
+	if requiredVersion.GreaterThan(supportedVersion) {
+		return fmt.Sprintf("job requires CUDA %s but the NVIDIA driver on this node supports up to CUDA %s",
+			required, supported), nil
+	}
+	return "", nil
+}
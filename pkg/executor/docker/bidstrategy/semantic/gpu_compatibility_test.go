@@ -0,0 +1,90 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/docker/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestGPUCompatibilityBidStrategy(t *testing.T) {
+	gpuJob := func(specVersion string) model.Job {
+		return model.Job{
+			Spec: model.Spec{
+				Engine:    model.EngineDocker,
+				Docker:    model.JobSpecDocker{Image: "nvidia/cuda", CUDAVersion: specVersion},
+				Resources: model.ResourceUsageConfig{GPU: "1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		job           model.Job
+		imageVersion  string
+		driverVersion string
+		shouldBid     bool
+	}{
+		{name: "no gpu requested", job: model.Job{Spec: model.Spec{Engine: model.EngineDocker}}, shouldBid: true},
+		{name: "nothing declared", job: gpuJob(""), driverVersion: "11.8", shouldBid: true},
+		{name: "spec version supported", job: gpuJob("11.8"), driverVersion: "12.0", shouldBid: true},
+		{name: "spec version too new", job: gpuJob("12.1"), driverVersion: "11.8", shouldBid: false},
+		{name: "image label too new", job: gpuJob(""), imageVersion: "12.1.0", driverVersion: "12.0", shouldBid: false},
+		{name: "spec overrides image", job: gpuJob("11.2"), imageVersion: "12.1.0", driverVersion: "12.0", shouldBid: true},
+		{name: "no driver", job: gpuJob("11.8"), shouldBid: false},
+		{name: "invalid version", job: gpuJob("latest"), driverVersion: "12.0", shouldBid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy := semantic.NewGPUCompatibilityBidStrategy(semantic.GPUCompatibilityBidStrategyParams{
+				DriverCUDAVersion: func(context.Context) (string, error) { return test.driverVersion, nil },
+				ImageCUDAVersion:  func(context.Context, string) (string, error) { return test.imageVersion, nil },
+			})
+
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{Job: test.job})
+			require.NoError(t, err)
+			require.Equal(t, test.shouldBid, response.ShouldBid, response.Reason)
+			if !test.shouldBid {
+				require.NotEmpty(t, response.Reason)
+			}
+		})
+	}
+}
+
+func TestGPUCompatibilityCheckPulledImage(t *testing.T) {
+	pulled := false
+	strategy := semantic.NewGPUCompatibilityBidStrategy(semantic.GPUCompatibilityBidStrategyParams{
+		DriverCUDAVersion: func(context.Context) (string, error) { return "12.0", nil },
+		ImageCUDAVersion: func(context.Context, string) (string, error) {
+			if !pulled {
+				return "", nil
+			}
+			return "12.1.0", nil
+		},
+	})
+	job := model.Job{
+		Spec: model.Spec{
+			Engine:    model.EngineDocker,
+			Docker:    model.JobSpecDocker{Image: "nvidia/cuda"},
+			Resources: model.ResourceUsageConfig{GPU: "1"},
+		},
+	}
+
+	// the version declared by the image is unknown until it is pulled
+	response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{Job: job})
+	require.NoError(t, err)
+	require.True(t, response.ShouldBid)
+
+	pulled = true
+	require.Error(t, strategy.CheckPulledImage(context.Background(), job.Spec, job.Spec.Docker.Image))
+
+	job.Spec.Resources.GPU = ""
+	require.NoError(t, strategy.CheckPulledImage(context.Background(), job.Spec, job.Spec.Docker.Image))
+}
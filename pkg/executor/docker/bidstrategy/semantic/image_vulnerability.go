@@ -0,0 +1,134 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/cache"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+var ScanCache *cache.Cache[docker.ImageScanResult] = &docker.DockerScanCache
+
+type ImageVulnerabilityBidStrategyParams struct {
+	Config docker.ImageScanConfig
+	// ImageManifest resolves an image to its manifest, to find the digest that scan results are cached by.
+	ImageManifest func(ctx context.Context, image string) (*docker.ImageManifest, error)
+	// Scan scans the image with the given digest for vulnerabilities.
+	Scan func(ctx context.Context, image string, imageDigest digest.Digest) (docker.ImageScanResult, error)
+}
+
+var _ bidstrategy.SemanticBidStrategy = (*ImageVulnerabilityBidStrategy)(nil)
+
+// ImageVulnerabilityBidStrategy gates jobs whose image has more critical vulnerabilities than the node allows, either
+// by declining to bid or by bidding with a warning. Scan results are cached by image digest.
+type ImageVulnerabilityBidStrategy struct {
+	config        docker.ImageScanConfig
+	imageManifest func(ctx context.Context, image string) (*docker.ImageManifest, error)
+	scan          func(ctx context.Context, image string, imageDigest digest.Digest) (docker.ImageScanResult, error)
+}
+
+func NewImageVulnerabilityBidStrategy(params ImageVulnerabilityBidStrategyParams) *ImageVulnerabilityBidStrategy {
+	return &ImageVulnerabilityBidStrategy{
+		config:        params.Config,
+		imageManifest: params.ImageManifest,
+		scan:          params.Scan,
+	}
+}
+
+// ShouldBid implements semantic.SemanticBidStrategy
+func (s *ImageVulnerabilityBidStrategy) ShouldBid(
+	ctx context.Context,
+	request bidstrategy.BidStrategyRequest,
+) (bidstrategy.BidStrategyResponse, error) {
+	if s.config.Command == "" || request.Job.Spec.Engine != model.EngineDocker {
+		return bidstrategy.NewShouldBidResponse(), nil
+	}
+
+	image := request.Job.Spec.Docker.Image
+	_, result, err := s.scanImage(ctx, image)
+	if err != nil {
+		return s.gate(fmt.Sprintf("failed to scan image %s for vulnerabilities: %s", image, err)), nil
+	}
+	if result.Critical > s.config.MaxCritical {
+		return s.gate(fmt.Sprintf("image %s has %d critical vulnerabilities, more than the %d allowed by this node",
+			image, result.Critical, s.config.MaxCritical)), nil
+	}
+	return bidstrategy.NewShouldBidResponse(), nil
+}
+
+// gate declines to bid for the reason given, or bids with the reason as a warning if the node only warns.
+func (s *ImageVulnerabilityBidStrategy) gate(reason string) bidstrategy.BidStrategyResponse {
+	if s.config.WarnOnly {
+		return bidstrategy.BidStrategyResponse{
+			ShouldBid: true,
+			Reason:    "warning: " + reason,
+		}
+	}
+	return bidstrategy.BidStrategyResponse{
+		ShouldBid: false,
+		Reason:    reason,
+	}
+}
+
+// ScannedImage returns the image pinned to the digest that was scanned for it, so that the image that runs is the
+// one that was checked even if its tag has moved since. It scans the image again if its scan is no longer cached, and
+// fails if the image is gated and the node does not only warn. The image is returned as is if scanning is disabled.
+func (s *ImageVulnerabilityBidStrategy) ScannedImage(ctx context.Context, image string) (string, error) {
+	if s.config.Command == "" {
+		return image, nil
+	}
+	imageDigest, result, err := s.scanImage(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan image %s for vulnerabilities: %w", image, err)
+	}
+	if result.Critical > s.config.MaxCritical && !s.config.WarnOnly {
+		return "", fmt.Errorf("image %s has %d critical vulnerabilities, more than the %d allowed by this node",
+			image, result.Critical, s.config.MaxCritical)
+	}
+	id, err := docker.NewImageID(image)
+	if err != nil {
+		return "", err
+	}
+	return id.WithDigest(imageDigest).String(), nil
+}
+
+func (s *ImageVulnerabilityBidStrategy) scanImage(
+	ctx context.Context,
+	image string,
+) (digest.Digest, docker.ImageScanResult, error) {
+	manifest, found := (*ManifestCache).Get(image)
+	if !found {
+		m, err := s.imageManifest(ctx, image)
+		if err != nil {
+			return "", docker.ImageScanResult{}, err
+		}
+		manifest = *m
+	}
+
+	if result, found := (*ScanCache).Get(manifest.Digest.String()); found {
+		log.Ctx(ctx).Debug().Str("Image", image).Msg("Image found in scan cache")
+		return manifest.Digest, result, nil
+	}
+
+	result, err := s.scan(ctx, image, manifest.Digest)
+	if err != nil {
+		return "", docker.ImageScanResult{}, err
+	}
+
+	// An image digest always refers to the same content, but new vulnerabilities are published all the time, so
+	// results only live as long as a manifest.
+	err = (*ScanCache).Set(manifest.Digest.String(), result, 1, oneDayInSeconds)
+	if err != nil {
+		log.Ctx(ctx).Warn().
+			Str("Image", image).
+			Str("Error", err.Error()).
+			Msg("Failed to save to scan cache")
+	}
+	return manifest.Digest, result, nil
+}
@@ -0,0 +1,202 @@
+//go:build unit || !integration
+
+package semantic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/cache"
+	"github.com/bacalhau-project/bacalhau/pkg/cache/fake"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/docker/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+const scannedImageDigest = digest.Digest("sha256:0a3f1a5e1f6e3ed4cd2b73f3ac4ff1c0f0c4d2c3c7f6d5e4b3a29180706f5e4d")
+
+func scannedJob(image string) model.Job {
+	return model.Job{Spec: model.Spec{Engine: model.EngineDocker, Docker: model.JobSpecDocker{Image: image}}}
+}
+
+func useFakeScanCaches(t *testing.T) *fake.FakeCache[docker.ImageScanResult] {
+	previousManifestCache, previousScanCache := semantic.ManifestCache, semantic.ScanCache
+	t.Cleanup(func() {
+		semantic.ManifestCache, semantic.ScanCache = previousManifestCache, previousScanCache
+	})
+
+	var manifestCache cache.Cache[docker.ImageManifest] = fake.NewFakeCache[docker.ImageManifest]()
+	semantic.ManifestCache = &manifestCache
+	fc := fake.NewFakeCache[docker.ImageScanResult]()
+	var scanCache cache.Cache[docker.ImageScanResult] = fc
+	semantic.ScanCache = &scanCache
+	return fc
+}
+
+func newImageVulnerabilityStrategy(
+	config docker.ImageScanConfig, result docker.ImageScanResult, scans *int,
+) *semantic.ImageVulnerabilityBidStrategy {
+	return semantic.NewImageVulnerabilityBidStrategy(semantic.ImageVulnerabilityBidStrategyParams{
+		Config: config,
+		ImageManifest: func(ctx context.Context, image string) (*docker.ImageManifest, error) {
+			return &docker.ImageManifest{Digest: scannedImageDigest}, nil
+		},
+		Scan: func(ctx context.Context, image string, imageDigest digest.Digest) (docker.ImageScanResult, error) {
+			*scans++
+			if imageDigest != scannedImageDigest {
+				return docker.ImageScanResult{}, errors.New("unexpected digest")
+			}
+			return result, nil
+		},
+	})
+}
+
+func TestImageVulnerabilityBidStrategy(t *testing.T) {
+	vulnerable := docker.ImageScanResult{Critical: 3, High: 5}
+
+	testCases := []struct {
+		name      string
+		job       model.Job
+		config    docker.ImageScanConfig
+		result    docker.ImageScanResult
+		shouldBid bool
+		warning   bool
+	}{
+		{
+			name:      "scanning disabled",
+			job:       scannedJob("ubuntu"),
+			result:    vulnerable,
+			shouldBid: true,
+		},
+		{
+			name:      "not a docker job",
+			job:       model.Job{Spec: model.Spec{Engine: model.EngineWasm}},
+			config:    docker.ImageScanConfig{Command: "scan"},
+			result:    vulnerable,
+			shouldBid: true,
+		},
+		{
+			name:      "no critical vulnerabilities",
+			job:       scannedJob("ubuntu"),
+			config:    docker.ImageScanConfig{Command: "scan"},
+			result:    docker.ImageScanResult{High: 5},
+			shouldBid: true,
+		},
+		{
+			name:      "critical vulnerabilities within threshold",
+			job:       scannedJob("ubuntu"),
+			config:    docker.ImageScanConfig{Command: "scan", MaxCritical: 3},
+			result:    vulnerable,
+			shouldBid: true,
+		},
+		{
+			name:      "critical vulnerabilities above threshold",
+			job:       scannedJob("ubuntu"),
+			config:    docker.ImageScanConfig{Command: "scan", MaxCritical: 2},
+			result:    vulnerable,
+			shouldBid: false,
+		},
+		{
+			name:      "critical vulnerabilities above threshold with warning",
+			job:       scannedJob("ubuntu"),
+			config:    docker.ImageScanConfig{Command: "scan", WarnOnly: true},
+			result:    vulnerable,
+			shouldBid: true,
+			warning:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			useFakeScanCaches(t)
+			scans := 0
+			strategy := newImageVulnerabilityStrategy(tc.config, tc.result, &scans)
+
+			response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{Job: tc.job})
+			require.NoError(t, err)
+			require.Equal(t, tc.shouldBid, response.ShouldBid)
+			if tc.warning || !tc.shouldBid {
+				require.Contains(t, response.Reason, "3 critical vulnerabilities")
+			} else {
+				require.Empty(t, response.Reason)
+			}
+		})
+	}
+}
+
+func TestImageVulnerabilityBidStrategyCachesScansByDigest(t *testing.T) {
+	fc := useFakeScanCaches(t)
+	scans := 0
+	strategy := newImageVulnerabilityStrategy(docker.ImageScanConfig{Command: "scan"}, docker.ImageScanResult{}, &scans)
+
+	for _, image := range []string{"ubuntu:latest", "ubuntu@" + scannedImageDigest.String()} {
+		response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+			Job: scannedJob(image),
+		})
+		require.NoError(t, err)
+		require.True(t, response.ShouldBid)
+	}
+
+	require.Equal(t, 1, scans)
+	require.Equal(t, 1, fc.ItemCount())
+	require.Equal(t, 1, fc.SuccessfulGetCalls)
+}
+
+func TestImageVulnerabilityBidStrategyScanFailure(t *testing.T) {
+	useFakeScanCaches(t)
+	strategy := semantic.NewImageVulnerabilityBidStrategy(semantic.ImageVulnerabilityBidStrategyParams{
+		Config: docker.ImageScanConfig{Command: "scan"},
+		ImageManifest: func(ctx context.Context, image string) (*docker.ImageManifest, error) {
+			return nil, errors.New("manifest unknown")
+		},
+	})
+
+	response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{
+		Job: scannedJob("ubuntu"),
+	})
+	require.NoError(t, err)
+	require.False(t, response.ShouldBid)
+	require.Contains(t, response.Reason, "manifest unknown")
+}
+
+func TestImageVulnerabilityBidStrategyScannedImage(t *testing.T) {
+	useFakeScanCaches(t)
+	require.NoError(t, (*semantic.ManifestCache).Set("ubuntu:latest", docker.ImageManifest{Digest: scannedImageDigest}, 1, 3600))
+	scans := 0
+	strategy := semantic.NewImageVulnerabilityBidStrategy(semantic.ImageVulnerabilityBidStrategyParams{
+		Config: docker.ImageScanConfig{Command: "scan", MaxCritical: 1},
+		// the tag has moved since the image was scanned
+		ImageManifest: func(ctx context.Context, image string) (*docker.ImageManifest, error) {
+			return &docker.ImageManifest{Digest: digest.FromString("moved")}, nil
+		},
+		Scan: func(ctx context.Context, image string, imageDigest digest.Digest) (docker.ImageScanResult, error) {
+			scans++
+			if imageDigest != scannedImageDigest {
+				return docker.ImageScanResult{Critical: 2}, nil
+			}
+			return docker.ImageScanResult{}, nil
+		},
+	})
+
+	response, err := strategy.ShouldBid(context.Background(), bidstrategy.BidStrategyRequest{Job: scannedJob("ubuntu:latest")})
+	require.NoError(t, err)
+	require.True(t, response.ShouldBid)
+
+	image, err := strategy.ScannedImage(context.Background(), "ubuntu:latest")
+	require.NoError(t, err)
+	require.Equal(t, "ubuntu@"+scannedImageDigest.String(), image)
+	require.Equal(t, 1, scans, "the image is not scanned again")
+
+	_, err = strategy.ScannedImage(context.Background(), "ubuntu:other")
+	require.ErrorContains(t, err, "2 critical vulnerabilities")
+
+	disabled := newImageVulnerabilityStrategy(docker.ImageScanConfig{}, docker.ImageScanResult{}, &scans)
+	image, err = disabled.ScannedImage(context.Background(), "ubuntu:other")
+	require.NoError(t, err)
+	require.Equal(t, "ubuntu:other", image)
+}
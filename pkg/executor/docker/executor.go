@@ -13,6 +13,7 @@ import (
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,20 +21,27 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/resource"
+	bidsemantic "github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	capacitysystem "github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
 	"github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/executor/docker/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
 )
 
 const NanoCPUCoefficient = 1000000000
 
+var _ executor.InteractiveExecutor = (*Executor)(nil)
+var _ executor.VersionedExecutor = (*Executor)(nil)
+
 const (
 	labelExecutorName = "bacalhau-executor"
 	labelJobName      = "bacalhau-jobID"
@@ -47,13 +55,28 @@ type Executor struct {
 	StorageProvider storage.StorageProvider
 	activeFlags     map[string]chan struct{}
 	client          *docker.Client
+	// imageScanner scans job images for vulnerabilities before bidding, and pins executions to the scanned digest
+	imageScanner *semantic.ImageVulnerabilityBidStrategy
+	// gpuCompatibility checks GPU jobs can run with the CUDA runtime of their image, before bidding on them and once
+	// their image is pulled
+	gpuCompatibility *semantic.GPUCompatibilityBidStrategy
+	// imageGC removes the least recently used images between executions
+	imageGC *imageGC
+	// warmPool keeps containers created ahead of time for the most run job configs. Nil if disabled.
+	warmPool *warmpool.Pool[*warmContainer]
+	// warmContainers holds the warm containers taken by running executions, which are not labeled with the execution
+	// they run.
+	warmContainers generic.SyncMap[string, *warmContainer]
 }
 
 func NewExecutor(
-	_ context.Context,
+	ctx context.Context,
 	cm *system.CleanupManager,
 	id string,
 	storageProvider storage.StorageProvider,
+	imageScan docker.ImageScanConfig,
+	imageGC docker.ImageGCConfig,
+	warmPool warmpool.Config,
 ) (*Executor, error) {
 	dockerClient, err := docker.NewDockerClient()
 	if err != nil {
@@ -65,6 +88,36 @@ func NewExecutor(
 		StorageProvider: storageProvider,
 		client:          dockerClient,
 		activeFlags:     make(map[string]chan struct{}),
+		imageGC:         newImageGC(dockerClient, imageGC),
+	}
+	de.imageScanner = semantic.NewImageVulnerabilityBidStrategy(semantic.ImageVulnerabilityBidStrategyParams{
+		Config: imageScan,
+		ImageManifest: func(ctx context.Context, image string) (*docker.ImageManifest, error) {
+			return dockerClient.ImageDistribution(ctx, image, config.GetDockerCredentials())
+		},
+		Scan: func(ctx context.Context, image string, imageDigest digest.Digest) (docker.ImageScanResult, error) {
+			return docker.ScanImage(ctx, imageScan.Command, image, imageDigest)
+		},
+	})
+	de.gpuCompatibility = semantic.NewGPUCompatibilityBidStrategy(semantic.GPUCompatibilityBidStrategyParams{
+		DriverCUDAVersion: capacitysystem.DriverCUDAVersion,
+		ImageCUDAVersion:  dockerClient.ImageCUDAVersion,
+	})
+	if warmPool.Enabled() {
+		de.warmPool = warmpool.NewPool(warmpool.PoolParams[*warmContainer]{
+			Size:    warmPool.Size,
+			MaxKeys: warmPool.MaxKeys,
+			Destroy: func(warm *warmContainer) {
+				de.destroyWarmContainer(context.Background(), warm)
+			},
+		})
+	}
+
+	// warm containers left behind by a previous run of the node can't be used, as their mounts are gone
+	if !config.ShouldKeepStack() && dockerClient.IsInstalled(ctx) {
+		if err = dockerClient.RemoveObjectsWithLabel(ctx, labelWarm, id); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to remove the warm containers of a previous run")
+		}
 	}
 
 	cm.RegisterCallbackWithContext(de.cleanupAll)
@@ -81,9 +134,27 @@ func (e *Executor) IsInstalled(ctx context.Context) (bool, error) {
 	return e.client.IsInstalled(ctx), nil
 }
 
+// EngineInfo implements executor.VersionedExecutor, reporting the version of the docker server and whether it has the
+// nvidia runtime.
+func (e *Executor) EngineInfo(ctx context.Context) (model.EngineInfo, error) {
+	info, err := e.client.Info(ctx)
+	if err != nil {
+		return model.EngineInfo{}, err
+	}
+	engineInfo := model.EngineInfo{Engine: model.EngineDocker, Version: info.ServerVersion}
+	if _, ok := info.Runtimes["nvidia"]; ok {
+		engineInfo.Features = append(engineInfo.Features, model.EngineFeatureNvidiaRuntime)
+	}
+	return engineInfo, nil
+}
+
 // GetBidStrategy implements executor.Executor
 func (e *Executor) GetSemanticBidStrategy(context.Context) (bidstrategy.SemanticBidStrategy, error) {
-	return semantic.NewImagePlatformBidStrategy(e.client), nil
+	return bidsemantic.NewChainedSemanticBidStrategy(
+		semantic.NewImagePlatformBidStrategy(e.client),
+		e.gpuCompatibility,
+		e.imageScanner,
+	), nil
 }
 
 func (e *Executor) GetResourceBidStrategy(context.Context) (bidstrategy.ResourceBidStrategy, error) {
@@ -125,15 +196,16 @@ func (e *Executor) Run(
 
 	e.activeFlags[executionID] = make(chan struct{}, 1)
 
+	fetchStart := time.Now()
 	inputVolumes, err := storage.ParallelPrepareStorage(ctx, e.StorageProvider, job.Spec.Inputs)
-	if err != nil {
-		return executor.FailResult(err)
-	}
+	// clean up the inputs that were prepared even if others failed or the execution was canceled
 	defer func() {
 		log.Ctx(ctx).Debug().
 			Str("Execution", executionID).
 			Msg("attempting cleanup of inputs for execution")
-		err := storage.ParallelCleanStorage(ctx, e.StorageProvider, inputVolumes)
+		cleanupCtx, cancel := pkgUtil.NewCleanupContext(ctx)
+		defer cancel()
+		err := storage.ParallelCleanStorage(cleanupCtx, e.StorageProvider, inputVolumes)
 		if err != nil {
 			log.Ctx(ctx).Error().
 				Err(err).
@@ -141,6 +213,10 @@ func (e *Executor) Run(
 				Msg("errors occurred when cleaning up inputs")
 		}
 	}()
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	inputsFetchDuration := time.Since(fetchStart)
 
 	// the actual mounts we will give to the container
 	// these are paths for both input and output data
@@ -160,6 +236,20 @@ func (e *Executor) Run(
 		}
 	}
 
+	// mount the manifest of the inputs so the job can find exactly which data it was given
+	inputManifest, err := storage.WriteInputManifest(inputVolumes)
+	if err != nil {
+		return executor.FailResult(fmt.Errorf("failed to write input manifest: %w", err))
+	}
+	defer os.Remove(inputManifest) //nolint:errcheck
+	mounts = append(mounts, mount.Mount{
+		Type:     mount.TypeBind,
+		ReadOnly: true,
+		Source:   inputManifest,
+		Target:   storage.InputManifestPath,
+	})
+
+	inputMounts := mounts
 	// for this phase of the outputs we ignore the engine because it's just about collecting the
 	// data from the job and keeping it locally
 	// the engine property of the output storage spec is how we will "publish" the output volume
@@ -198,12 +288,30 @@ func (e *Executor) Run(
 		})
 	}
 
-	if _, set := os.LookupEnv("SKIP_IMAGE_PULL"); !set {
+	outputMounts := mounts[len(inputMounts):]
+
+	startupStart := time.Now()
+	// run the digest of the image that was scanned, rather than whatever its tag points to now
+	image, err := e.imageScanner.ScannedImage(ctx, job.Spec.Docker.Image)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	releaseImage, err := e.imageGC.use(ctx, image, func() error {
+		if _, set := os.LookupEnv("SKIP_IMAGE_PULL"); set {
+			return nil
+		}
 		dockerCreds := config.GetDockerCredentials()
-		if pullErr := e.client.PullImage(ctx, job.Spec.Docker.Image, dockerCreds); pullErr != nil {
-			pullErr = errors.Wrapf(pullErr, docker.ImagePullError, job.Spec.Docker.Image)
-			return executor.FailResult(pullErr)
+		if pullErr := e.client.PullImage(ctx, image, dockerCreds); pullErr != nil {
+			return errors.Wrapf(pullErr, docker.ImagePullError, image)
 		}
+		return nil
+	})
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	defer releaseImage()
+	if err = e.gpuCompatibility.CheckPulledImage(ctx, job.Spec, image); err != nil {
+		return executor.FailResult(err)
 	}
 
 	// json the job spec and pass it into all containers
@@ -221,12 +329,14 @@ func (e *Executor) Run(
 	)
 
 	containerConfig := &container.Config{
-		Image:      job.Spec.Docker.Image,
+		Image:      image,
 		Tty:        false,
 		Env:        useEnv,
 		Entrypoint: job.Spec.Docker.Entrypoint,
 		Labels:     e.containerLabels(executionID, job),
 		WorkingDir: job.Spec.Docker.WorkingDirectory,
+		// interactive jobs keep stdin open for clients to attach to it while they run
+		OpenStdin: job.Spec.Interactive,
 	}
 
 	log.Ctx(ctx).Trace().Msgf("Container: %+v %+v", containerConfig, mounts)
@@ -260,25 +370,33 @@ func (e *Executor) Run(
 		return executor.FailResult(err)
 	}
 
-	jobContainer, err := e.client.ContainerCreate(
-		ctx,
-		containerConfig,
-		hostConfig,
-		nil,
-		nil,
-		e.containerName(executionID, job),
-	)
-	if err != nil {
-		return executor.FailResult(errors.Wrap(err, "failed to create container"))
+	// Take a container created ahead of time from the warm pool if there is one ready, and create one otherwise
+	warm, layout, warmPoolResult := e.takeWarmContainer(ctx, executionID, job, containerConfig, hostConfig, inputMounts, outputMounts)
+	var containerID string
+	if warm != nil {
+		containerID = warm.id
+	} else {
+		jobContainer, createErr := e.client.ContainerCreate(
+			ctx,
+			containerConfig,
+			hostConfig,
+			nil,
+			nil,
+			e.containerName(executionID, job),
+		)
+		if createErr != nil {
+			return executor.FailResult(errors.Wrap(createErr, "failed to create container"))
+		}
+		containerID = jobContainer.ID
 	}
 
-	ctx = log.Ctx(ctx).With().Str("Container", jobContainer.ID).Logger().WithContext(ctx)
+	ctx = log.Ctx(ctx).With().Str("Container", containerID).Logger().WithContext(ctx)
 
 	e.activeFlags[executionID] <- struct{}{}
 
 	containerStartError := e.client.ContainerStart(
 		ctx,
-		jobContainer.ID,
+		containerID,
 		dockertypes.ContainerStartOptions{},
 	)
 	if containerStartError != nil {
@@ -290,6 +408,7 @@ func (e *Executor) Run(
 		internalContainerStartError := errors.Wrap(containerStartError, internalContainerStartErrorMsg)
 		return executor.FailResult(internalContainerStartError)
 	}
+	startupDuration := time.Since(startupStart)
 
 	// the idea here is even if the container errors
 	// we want to capture stdout, stderr and feed it back to the user
@@ -297,7 +416,7 @@ func (e *Executor) Run(
 	var containerExitStatusCode int64
 	statusCh, errCh := e.client.ContainerWait(
 		ctx,
-		jobContainer.ID,
+		containerID,
 		container.WaitConditionNotRunning,
 	)
 	select {
@@ -310,22 +429,76 @@ func (e *Executor) Run(
 		}
 	}
 
+	// the container of a canceled execution is stopped and removed when cleaning up, and its output is not collected.
+	// The output of an execution that timed out is still collected.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return executor.FailResult(fmt.Errorf("execution canceled while running: %w", ctx.Err()))
+	}
+
+	if warm != nil {
+		if err = warm.moveOutputs(layout); err != nil {
+			return executor.FailResult(err)
+		}
+	}
+
 	// Can't use the original context as it may have already been timed out
 	detachedContext, cancel := context.WithTimeout(pkgUtil.NewDetachedContext(ctx), 3*time.Second)
 	defer cancel()
-	stdoutPipe, stderrPipe, logsErr := e.client.FollowLogs(detachedContext, jobContainer.ID)
+	stdoutPipe, stderrPipe, logsErr := e.client.FollowLogs(detachedContext, containerID)
 	log.Ctx(detachedContext).Debug().Err(logsErr).Msg("Captured stdout/stderr for container")
 
-	return executor.WriteJobResults(
+	result, err := executor.WriteJobResults(
 		jobResultsDir,
 		stdoutPipe,
 		stderrPipe,
 		int(containerExitStatusCode),
 		multierr.Combine(containerError, logsErr),
 	)
+	var inspected *dockertypes.ContainerJSON
+	if info, inspectErr := e.client.ContainerInspect(detachedContext, containerID); inspectErr == nil {
+		inspected = &info
+	} else {
+		log.Ctx(detachedContext).Debug().Err(inspectErr).Msg("failed to inspect container")
+	}
+	if result != nil {
+		result.Environment = e.environment(detachedContext, inspected)
+		result.OOMKilled = inspected != nil && inspected.State != nil && inspected.State.OOMKilled
+		result.InputsFetchDuration = inputsFetchDuration
+		result.StartupDuration = startupDuration
+		result.WarmPool = warmPoolResult
+	}
+	return result, err
 }
 
-func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
+// environment describes the environment the container ran in, including the digest of the image it actually ran if
+// the container could be inspected.
+func (e *Executor) environment(ctx context.Context, info *dockertypes.ContainerJSON) *model.ExecutionEnvironment {
+	env := capacitysystem.Environment(ctx)
+	if version, err := e.client.ServerVersion(ctx); err == nil {
+		env.DockerVersion = version.Version
+	} else {
+		log.Ctx(ctx).Debug().Err(err).Msg("failed to read docker version")
+	}
+
+	if info == nil {
+		return &env
+	}
+	// prefer the digest of the manifest pulled from the registry, as that is what users reference images by
+	env.ImageDigest = info.Image
+	if image, _, err := e.client.ImageInspectWithRaw(ctx, info.Image); err == nil && len(image.RepoDigests) > 0 {
+		if _, repoDigest, found := strings.Cut(image.RepoDigests[0], "@"); found {
+			env.ImageDigest = repoDigest
+		}
+	}
+	return &env
+}
+
+func (e *Executor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
 	// We have to wait until the condition is met otherwise we may be here too early and
 	// the container isn't created yet. The channel in the activeFlags map will either have
 	// a value waiting, or have one written to it shortly
@@ -339,7 +512,7 @@ func (e *Executor) GetOutputStream(ctx context.Context, executionID string, with
 		delete(e.activeFlags, executionID)
 	}
 
-	ctrID, err := e.client.FindContainer(ctx, labelExecutionID, e.labelExecutionValue(executionID))
+	ctrID, err := e.findContainer(ctx, executionID)
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +525,7 @@ func (e *Executor) GetOutputStream(ctx context.Context, executionID string, with
 	// Gets the underlying reader, and provides data since the value of the `since` timestamp.
 	// If we want everything, we specify 1, a timestamp which we are confident we don't have
 	// logs before. If we want to just follow new logs, we pass `time.Now()` as a string.
-	reader, err := e.client.GetOutputStream(ctx, ctrID, since, follow)
+	reader, err := e.client.GetOutputStream(ctx, ctrID, since, follow, tail)
 	if err != nil {
 		return nil, err
 	}
@@ -360,6 +533,53 @@ func (e *Executor) GetOutputStream(ctx context.Context, executionID string, with
 	return reader, nil
 }
 
+// Attach attaches to the stdin, stdout and stderr of the container of an execution of an interactive job.
+func (e *Executor) Attach(ctx context.Context, executionID string) (io.WriteCloser, io.ReadCloser, error) {
+	ctrID, err := e.findContainer(ctx, executionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the output is muxed as it is for GetOutputStream, since the container has no TTY
+	attached, err := e.client.ContainerAttach(ctx, ctrID, dockertypes.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to attach to container")
+	}
+	return attachedStdin{attached}, attachedOutput{attached}, nil
+}
+
+// attachedStdin writes to the stdin of an attached container, and closes it without detaching.
+type attachedStdin struct {
+	dockertypes.HijackedResponse
+}
+
+func (a attachedStdin) Write(p []byte) (int, error) {
+	return a.Conn.Write(p)
+}
+
+func (a attachedStdin) Close() error {
+	return a.CloseWrite()
+}
+
+// attachedOutput reads the muxed output of an attached container, and detaches from it when closed.
+type attachedOutput struct {
+	dockertypes.HijackedResponse
+}
+
+func (a attachedOutput) Read(p []byte) (int, error) {
+	return a.Reader.Read(p)
+}
+
+func (a attachedOutput) Close() error {
+	a.HijackedResponse.Close()
+	return nil
+}
+
 func (e *Executor) cleanupExecution(ctx context.Context, executionID string) {
 	// Use a detached context in case the current one has already been canceled
 	separateCtx, cancel := context.WithTimeout(pkgUtil.NewDetachedContext(ctx), 1*time.Minute)
@@ -375,7 +595,7 @@ func (e *Executor) cleanupExecution(ctx context.Context, executionID string) {
 		delete(e.activeFlags, executionID)
 	}
 
-	err := e.client.RemoveObjectsWithLabel(separateCtx, labelExecutionID, e.labelExecutionValue(executionID))
+	err := e.removeExecutionObjects(separateCtx, executionID)
 	logLevel := map[bool]zerolog.Level{true: zerolog.DebugLevel, false: zerolog.ErrorLevel}[err == nil]
 	log.Ctx(ctx).WithLevel(logLevel).Err(err).Msg("Cleaned up job Docker resources")
 }
@@ -384,6 +604,9 @@ func (e *Executor) cleanupAll(ctx context.Context) error {
 	// We have to use a detached context, rather than the one passed in to `NewExecutor`, as it may have already been
 	// canceled and so would prevent us from performing any cleanup work.
 	safeCtx := pkgUtil.NewDetachedContext(ctx)
+	if e.warmPool != nil {
+		e.warmPool.Close()
+	}
 	if config.ShouldKeepStack() || !e.client.IsInstalled(safeCtx) {
 		return nil
 	}
@@ -395,6 +618,24 @@ func (e *Executor) cleanupAll(ctx context.Context) error {
 	return nil
 }
 
+// findContainer returns the ID of the container of the execution, which is either a warm container it took or a
+// container labeled with the execution.
+func (e *Executor) findContainer(ctx context.Context, executionID string) (string, error) {
+	if warm, ok := e.warmContainers.Get(executionID); ok {
+		return warm.id, nil
+	}
+	return e.client.FindContainer(ctx, labelExecutionID, e.labelExecutionValue(executionID))
+}
+
+// removeExecutionObjects removes the container and network of the execution, including the warm container it took.
+func (e *Executor) removeExecutionObjects(ctx context.Context, executionID string) error {
+	if warm, ok := e.warmContainers.Get(executionID); ok {
+		e.warmContainers.Delete(executionID)
+		e.destroyWarmContainer(ctx, warm)
+	}
+	return e.client.RemoveObjectsWithLabel(ctx, labelExecutionID, e.labelExecutionValue(executionID))
+}
+
 func (e *Executor) dockerObjectName(executionID string, job model.Job, parts ...string) string {
 	strs := []string{"bacalhau", e.ID, job.ID(), executionID}
 	strs = append(strs, parts...)
@@ -16,7 +16,8 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/docker"
-	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
@@ -49,6 +50,9 @@ func (s *ExecutorTestSuite) SetupTest() {
 		s.cm,
 		"bacalhau-executor-unittest",
 		model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{}),
+		docker.ImageScanConfig{},
+		docker.ImageGCConfig{},
+		warmpool.Config{},
 	)
 	require.NoError(s.T(), err)
 
@@ -347,7 +351,7 @@ func (s *ExecutorTestSuite) TestDockerStreamsAlreadyComplete() {
 		done <- true
 	}()
 
-	reader, err := s.executor.GetOutputStream(ctx, id, true, true)
+	reader, err := s.executor.GetOutputStream(ctx, id, true, true, 0)
 
 	<-done
 	require.Nil(s.T(), reader)
@@ -381,14 +385,14 @@ func (s *ExecutorTestSuite) TestDockerStreamsSlowTask() {
 	// be nothing to retrieve the output from.
 	time.Sleep(time.Duration(500) * time.Millisecond)
 
-	reader, err := s.executor.GetOutputStream(ctx, id, true, true)
+	reader, err := s.executor.GetOutputStream(ctx, id, true, true, 0)
 
 	require.NotNil(s.T(), reader)
 	require.NoError(s.T(), err)
 
-	df, err := logger.NewDataFrameFromReader(reader)
+	df, err := logframe.ReadMuxedFrame(reader)
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), string(df.Data), "hello\n")
 	require.Equal(s.T(), df.Size, 6)
-	require.Equal(s.T(), df.Tag, logger.StdoutStreamTag)
+	require.Equal(s.T(), df.Tag, logframe.StdoutStreamTag)
 }
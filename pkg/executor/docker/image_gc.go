@@ -0,0 +1,300 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+
+	capacitysystem "github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
+)
+
+// maxImagePullAttempts is how many times the image of an execution is pulled if collections keep removing it
+// before it is marked in use.
+const maxImagePullAttempts = 3
+
+// imageGC removes the least recently used images the node pulled for jobs once the images of the docker server take
+// more space than allowed, or the disk runs low, so that nodes don't fill their disks with the images of one-off jobs.
+// Images are ranked by when an execution of this node last used them. Images the node did not pull, and images used
+// by running executions or by any container, are never removed.
+type imageGC struct {
+	config docker.ImageGCConfig
+	client *docker.Client
+
+	mu    sync.Mutex
+	inUse map[string]int
+	// pulled holds when the images the node pulled were last used, by ID. It is saved to the record path of the
+	// config whenever it changes.
+	pulled map[string]time.Time
+	// removingID is the image a collection is removing, which executions wait for before marking it in use.
+	removingID string
+	removed    *sync.Cond
+	collecting bool
+}
+
+func newImageGC(client *docker.Client, config docker.ImageGCConfig) *imageGC {
+	g := &imageGC{
+		config: config,
+		client: client,
+		inUse:  make(map[string]int),
+		pulled: make(map[string]time.Time),
+	}
+	g.removed = sync.NewCond(&g.mu)
+	if config.Enabled() && config.RecordPath != "" {
+		if data, err := os.ReadFile(config.RecordPath); err == nil {
+			if err = json.Unmarshal(data, &g.pulled); err != nil {
+				log.Warn().Err(err).Str("Path", config.RecordPath).Msg("ignoring invalid record of pulled docker images")
+				g.pulled = make(map[string]time.Time)
+			}
+		}
+	}
+	return g
+}
+
+// use calls pull, and marks the image in use until the returned release is called. A collection is started once the
+// image is released, as that is when space may be needed for the images of the next executions. Collections only
+// hold off executions while they mark an image in use, and the image is pulled again if a collection removed it in
+// the meantime.
+func (g *imageGC) use(ctx context.Context, image string, pull func() error) (release func(), err error) {
+	if !g.config.Enabled() {
+		if err = pull(); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, _, err = g.client.ImageInspectWithRaw(ctx, image)
+		present := err == nil
+		if err = pull(); err != nil {
+			return nil, err
+		}
+		info, _, err := g.client.ImageInspectWithRaw(ctx, image)
+		if err != nil {
+			if dockerclient.IsErrNotFound(err) && present && attempt < maxImagePullAttempts {
+				// a collection removed the image between checking it was there and pulling it
+				continue
+			}
+			// the image was not pulled, such as when pulls are skipped, so there is nothing to mark in use
+			return func() {}, nil //nolint:nilerr
+		}
+		if g.markInUse(ctx, info.ID, !present) {
+			// a collection may have removed the image before it was marked, and none can once it is
+			if _, _, err = g.client.ImageInspectWithRaw(ctx, info.ID); err == nil {
+				return func() {
+					g.markUnused(ctx, info.ID)
+					go g.collect(pkgUtil.NewDetachedContext(ctx))
+				}, nil
+			}
+			g.markUnused(ctx, info.ID)
+		}
+		if attempt >= maxImagePullAttempts {
+			return nil, errors.New("docker image " + image + " keeps being removed to free disk space")
+		}
+	}
+}
+
+// markInUse marks the image in use, and records it as pulled by the node if it was. It returns false if the image
+// was removed by a collection before it could be marked.
+func (g *imageGC) markInUse(ctx context.Context, imageID string, pulled bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.removingID == imageID {
+		for g.removingID == imageID {
+			g.removed.Wait()
+		}
+		return false
+	}
+	g.inUse[imageID]++
+	if pulled {
+		g.pulled[imageID] = time.Now()
+		g.saveRecord(ctx)
+	}
+	return true
+}
+
+// markUnused marks the image as used one less time, and used last now.
+func (g *imageGC) markUnused(ctx context.Context, imageID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inUse[imageID]--
+	if g.inUse[imageID] <= 0 {
+		delete(g.inUse, imageID)
+	}
+	if _, pulled := g.pulled[imageID]; pulled {
+		g.pulled[imageID] = time.Now()
+		g.saveRecord(ctx)
+	}
+}
+
+// saveRecord saves the images the node pulled to the record path of the config, if any. It is called with the lock
+// held.
+func (g *imageGC) saveRecord(ctx context.Context) {
+	if g.config.RecordPath == "" {
+		return
+	}
+	data, err := json.Marshal(g.pulled)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(g.config.RecordPath), os.ModePerm)
+	}
+	if err == nil {
+		temp := g.config.RecordPath + ".tmp"
+		if err = os.WriteFile(temp, data, 0600); err == nil { //nolint:gomnd
+			err = os.Rename(temp, g.config.RecordPath)
+		}
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("Path", g.config.RecordPath).Msg("failed to record pulled docker images")
+	}
+}
+
+// collect removes the least recently used images until the limits are met. Only one collection runs at a time.
+func (g *imageGC) collect(ctx context.Context) {
+	g.mu.Lock()
+	if g.collecting {
+		g.mu.Unlock()
+		return
+	}
+	g.collecting = true
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.collecting = false
+		g.mu.Unlock()
+	}()
+
+	if err := g.removeImages(ctx); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to garbage collect docker images")
+	}
+}
+
+func (g *imageGC) removeImages(ctx context.Context) error {
+	// the disk usage of the docker server counts the layers images share once, and how much of each image is shared
+	usage, err := g.client.DiskUsage(ctx, dockertypes.DiskUsageOptions{Types: []dockertypes.DiskUsageObject{dockertypes.ImageObject}})
+	if err != nil {
+		return err
+	}
+
+	var toFree uint64
+	if g.config.MinFreeDisk > 0 {
+		if freeDisk, known := g.freeDisk(ctx); known && freeDisk < g.config.MinFreeDisk {
+			toFree = g.config.MinFreeDisk - freeDisk
+		}
+	}
+
+	candidates := make([]imageGCCandidate, 0, len(usage.Images))
+	existing := make(map[string]bool, len(usage.Images))
+	g.mu.Lock()
+	for _, image := range usage.Images {
+		existing[image.ID] = true
+		lastUsed, pulled := g.pulled[image.ID]
+		if !pulled || g.inUse[image.ID] > 0 || image.Containers > 0 {
+			continue
+		}
+		candidates = append(candidates, imageGCCandidate{ID: image.ID, Size: uniqueSize(image), LastUsed: lastUsed})
+	}
+	// forget the images that were removed by someone else, but not those pulled since they were listed
+	for imageID := range g.pulled {
+		if !existing[imageID] && g.inUse[imageID] == 0 {
+			delete(g.pulled, imageID)
+		}
+	}
+	g.mu.Unlock()
+
+	for _, image := range imagesToRemove(g.config.MaxTotalSize, toFree, uint64(usage.LayersSize), candidates) {
+		if !g.startRemoving(image.ID) {
+			continue
+		}
+		// images are forced to be removed when they have several tags, as none of their containers exist
+		_, err = g.client.ImageRemove(ctx, image.ID, dockertypes.ImageRemoveOptions{Force: true, PruneChildren: true})
+		g.finishRemoving(ctx, image.ID, err == nil)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("Image", image.ID).Msg("failed to remove docker image")
+			continue
+		}
+		log.Ctx(ctx).Info().Str("Image", image.ID).Uint64("Size", image.Size).
+			Time("LastUsed", image.LastUsed).Msg("removed docker image to free disk space")
+	}
+	return nil
+}
+
+// startRemoving marks the image as being removed, unless an execution started using it since it was listed.
+func (g *imageGC) startRemoving(imageID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inUse[imageID] > 0 {
+		return false
+	}
+	g.removingID = imageID
+	return true
+}
+
+// finishRemoving forgets the image if it was removed, and wakes up the executions waiting to use it.
+func (g *imageGC) finishRemoving(ctx context.Context, imageID string, removed bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removingID = ""
+	if removed {
+		delete(g.pulled, imageID)
+		g.saveRecord(ctx)
+	}
+	g.removed.Broadcast()
+}
+
+// uniqueSize returns the bytes removing the image frees, which are those of the layers it shares with no other image.
+func uniqueSize(image *dockertypes.ImageSummary) uint64 {
+	if image.SharedSize < 0 || image.SharedSize > image.Size {
+		return uint64(image.Size)
+	}
+	return uint64(image.Size - image.SharedSize)
+}
+
+// freeDisk returns the free space of the disk the docker server stores its data on, if it is on the host of the node.
+func (g *imageGC) freeDisk(ctx context.Context) (uint64, bool) {
+	info, err := g.client.Info(ctx)
+	if err != nil || info.DockerRootDir == "" {
+		return 0, false
+	}
+	if _, err = os.Stat(info.DockerRootDir); err != nil {
+		return 0, false
+	}
+	free, err := capacitysystem.FreeDiskSpace(info.DockerRootDir)
+	if err != nil {
+		return 0, false
+	}
+	return free, true
+}
+
+type imageGCCandidate struct {
+	ID       string
+	Size     uint64
+	LastUsed time.Time
+}
+
+// imagesToRemove returns the least recently used candidates to remove for the images to take at most maxTotalSize
+// bytes, and for at least toFree bytes to be freed. A zero maxTotalSize is no limit.
+func imagesToRemove(maxTotalSize, toFree, totalSize uint64, candidates []imageGCCandidate) []imageGCCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+	var removed []imageGCCandidate
+	var freed uint64
+	for _, candidate := range candidates {
+		if (maxTotalSize == 0 || totalSize-freed <= maxTotalSize) && freed >= toFree {
+			break
+		}
+		removed = append(removed, candidate)
+		freed += candidate.Size
+	}
+	return removed
+}
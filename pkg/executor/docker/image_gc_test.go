@@ -0,0 +1,86 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+)
+
+func TestImagesToRemove(t *testing.T) {
+	now := time.Now()
+	candidates := func() []imageGCCandidate {
+		return []imageGCCandidate{
+			{ID: "recent", Size: 30, LastUsed: now},
+			{ID: "oldest", Size: 20, LastUsed: now.Add(-2 * time.Hour)},
+			{ID: "old", Size: 10, LastUsed: now.Add(-time.Hour)},
+		}
+	}
+	ids := func(images []imageGCCandidate) []string {
+		var ids []string
+		for _, image := range images {
+			ids = append(ids, image.ID)
+		}
+		return ids
+	}
+
+	// the least recently used images are removed until the images fit
+	require.Equal(t, []string{"oldest"}, ids(imagesToRemove(100, 0, 110, candidates())))
+	require.Equal(t, []string{"oldest", "old"}, ids(imagesToRemove(100, 0, 125, candidates())))
+	require.Empty(t, imagesToRemove(100, 0, 100, candidates()))
+
+	// and until enough disk space is freed
+	require.Equal(t, []string{"oldest", "old"}, ids(imagesToRemove(0, 25, 60, candidates())))
+	require.Equal(t, []string{"oldest", "old", "recent"}, ids(imagesToRemove(0, 1000, 60, candidates())))
+
+	// images in use are not candidates, so the limit may not be met
+	require.Equal(t, []string{"oldest"}, ids(imagesToRemove(10, 0, 1000, candidates()[1:2])))
+}
+
+func TestUniqueSize(t *testing.T) {
+	require.Equal(t, uint64(30), uniqueSize(&dockertypes.ImageSummary{Size: 100, SharedSize: 70}))
+	require.Equal(t, uint64(100), uniqueSize(&dockertypes.ImageSummary{Size: 100, SharedSize: -1}),
+		"images whose shared size is unknown are counted whole")
+}
+
+func TestImageGCRecordsPulledImages(t *testing.T) {
+	ctx := context.Background()
+	config := docker.ImageGCConfig{MaxTotalSize: 100, RecordPath: filepath.Join(t.TempDir(), "images.json")}
+	gc := newImageGC(nil, config)
+
+	require.True(t, gc.markInUse(ctx, "pulled", true))
+	require.True(t, gc.markInUse(ctx, "present", false))
+	gc.markUnused(ctx, "pulled")
+	gc.markUnused(ctx, "present")
+	require.Empty(t, gc.inUse)
+
+	restarted := newImageGC(nil, config)
+	require.Contains(t, restarted.pulled, "pulled", "the images the node pulled are remembered across restarts")
+	require.NotContains(t, restarted.pulled, "present", "images the node did not pull are never removed")
+}
+
+func TestImageGCWaitsForRemoval(t *testing.T) {
+	ctx := context.Background()
+	gc := newImageGC(nil, docker.ImageGCConfig{MaxTotalSize: 100})
+	require.True(t, gc.startRemoving("image"))
+
+	marked := make(chan bool)
+	go func() { marked <- gc.markInUse(ctx, "image", false) }()
+	select {
+	case <-marked:
+		require.Fail(t, "images being removed are not marked in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+	gc.finishRemoving(ctx, "image", true)
+	require.False(t, <-marked, "images removed while waiting are pulled again")
+
+	require.True(t, gc.markInUse(ctx, "image", false))
+	require.False(t, gc.startRemoving("image"), "images in use are not removed")
+}
@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+var _ executor.OrphanExecutor = (*Executor)(nil)
+
+// ListWorkloads returns the containers of the executor, including the ones left behind by a previous run of the node
+// with the same executor ID.
+func (e *Executor) ListWorkloads(ctx context.Context) ([]executor.Workload, error) {
+	// containers are kept on purpose when debugging, so none are orphaned
+	if config.ShouldKeepStack() || !e.client.IsInstalled(ctx) {
+		return nil, nil
+	}
+
+	containers, err := e.client.ContainerList(ctx, dockertypes.ContainerListOptions{
+		All:     true,
+		Size:    true,
+		Filters: filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", labelExecutorName, e.ID))),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]executor.Workload, 0, len(containers))
+	for _, ctr := range containers {
+		executionID, ok := strings.CutPrefix(ctr.Labels[labelExecutionID], e.ID)
+		if !ok || executionID == "" {
+			// warm containers are not labeled with the execution that took them, if any
+			if executionID, ok = e.warmContainerExecution(ctr.ID); !ok {
+				continue
+			}
+		}
+		inspected, err := e.client.ContainerInspect(ctx, ctr.ID)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, executor.Workload{
+			ExecutionID: executionID,
+			Running:     inspected.State != nil && inspected.State.Running,
+			Resources:   containerResources(inspected, ctr.SizeRw),
+		})
+	}
+	return workloads, nil
+}
+
+// warmContainerExecution returns the execution that took the warm container, if any.
+func (e *Executor) warmContainerExecution(containerID string) (string, bool) {
+	var executionID string
+	e.warmContainers.Iter(func(id string, warm *warmContainer) bool {
+		if warm.id == containerID {
+			executionID = id
+			return false
+		}
+		return true
+	})
+	return executionID, executionID != ""
+}
+
+// containerResources returns the resources the container was created with, and the size of its writable layer as
+// its disk usage.
+func containerResources(ctr dockertypes.ContainerJSON, sizeRw int64) model.ResourceUsageData {
+	usage := model.ResourceUsageData{}
+	if sizeRw > 0 {
+		usage.Disk = uint64(sizeRw)
+	}
+	if ctr.ContainerJSONBase == nil || ctr.HostConfig == nil {
+		return usage
+	}
+	resources := ctr.HostConfig.Resources
+	usage.CPU = float64(resources.NanoCPUs) / NanoCPUCoefficient
+	if resources.Memory > 0 {
+		usage.Memory = uint64(resources.Memory)
+	}
+	for _, request := range resources.DeviceRequests {
+		usage.GPU += uint64(len(request.DeviceIDs))
+	}
+	return usage
+}
+
+// WaitWorkload blocks until the container of the execution stops running.
+func (e *Executor) WaitWorkload(ctx context.Context, executionID string) error {
+	ctrID, err := e.findContainer(ctx, executionID)
+	if err != nil {
+		return err
+	}
+	statusCh, errCh := e.client.ContainerWait(ctx, ctrID, container.WaitConditionNotRunning)
+	select {
+	case <-statusCh:
+		return nil
+	case err = <-errCh:
+		return err
+	}
+}
+
+// RemoveWorkload removes the container and network of the execution.
+func (e *Executor) RemoveWorkload(ctx context.Context, executionID string) error {
+	return e.removeExecutionObjects(ctx, executionID)
+}
@@ -0,0 +1,297 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
+)
+
+// labelWarm marks the containers created ahead of executions for the warm pool, with the executor ID as value.
+const labelWarm = "bacalhau-warm"
+
+// maxWarmInputsSize is the most data the inputs of an execution can hold for it to run in a warm container, as they
+// are copied into the container rather than mounted.
+const maxWarmInputsSize = 64 * datasize.MB
+
+// warmContainer is a container created, but not started, ahead of the execution that runs in it. Containers can't be
+// given mounts once created, so a warm container mounts folders of its own, which the inputs of the execution are
+// copied into before it starts, and its outputs are moved out of once it exits.
+type warmContainer struct {
+	id string
+	// dir holds the files and folders mounted in the container, named after the index of their mount in the layout
+	dir string
+}
+
+// warmMount is a mount of an execution, as laid out in the warm containers that can run it.
+type warmMount struct {
+	// Source is the path of the mount for the execution, which inputs are copied from and outputs are moved to.
+	Source string `json:"-"`
+	Target string
+	// ReadOnly is true for the inputs of the execution.
+	ReadOnly bool
+	// Output is true if the mount collects an output of the execution.
+	Output bool
+	// Dir is true if the mount is a folder rather than a file.
+	Dir bool
+}
+
+// path returns where the mount at index i of the layout is kept for the container.
+func (c *warmContainer) path(i int) string {
+	return filepath.Join(c.dir, strconv.Itoa(i))
+}
+
+// warmLayout returns the layout of the mounts of an execution in a warm container, sorted by target. It returns false
+// if the inputs hold too much data to be copied into the container.
+func warmLayout(inputs, outputs []mount.Mount) ([]warmMount, bool) {
+	layout := make([]warmMount, 0, len(inputs)+len(outputs))
+	var inputsSize int64
+	for _, input := range inputs {
+		info, err := os.Stat(input.Source)
+		if err != nil {
+			return nil, false
+		}
+		size, err := pathSize(input.Source)
+		if err != nil {
+			return nil, false
+		}
+		if inputsSize += size; inputsSize > int64(maxWarmInputsSize) {
+			return nil, false
+		}
+		layout = append(layout, warmMount{Source: input.Source, Target: input.Target, ReadOnly: input.ReadOnly, Dir: info.IsDir()})
+	}
+	for _, output := range outputs {
+		layout = append(layout, warmMount{Source: output.Source, Target: output.Target, Output: true, Dir: true})
+	}
+	sort.Slice(layout, func(i, j int) bool {
+		return layout[i].Target < layout[j].Target
+	})
+	return layout, true
+}
+
+// warmPoolKey returns the key of the warm containers that can run an execution, which is the hash of everything the
+// container is created with, apart from its labels and the sources of its mounts.
+func warmPoolKey(containerConfig container.Config, hostConfig container.HostConfig, layout []warmMount) (string, error) {
+	containerConfig.Labels = nil
+	hostConfig.Mounts = nil
+	data, err := json.Marshal(struct {
+		Config container.Config
+		Host   container.HostConfig
+		Mounts []warmMount
+	}{containerConfig, hostConfig, layout})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// warmContainerCreator returns a function that creates containers with the passed config, mounting folders of their
+// own in place of the mounts of the layout.
+func (e *Executor) warmContainerCreator(
+	containerConfig container.Config,
+	hostConfig container.HostConfig,
+	layout []warmMount,
+) warmpool.Creator[*warmContainer] {
+	return func(ctx context.Context) (*warmContainer, error) {
+		dir, err := os.MkdirTemp("", "bacalhau-docker-warm-")
+		if err != nil {
+			return nil, err
+		}
+		warm := &warmContainer{dir: dir}
+
+		hostConfig.Mounts = make([]mount.Mount, 0, len(layout))
+		for i, m := range layout {
+			if m.Dir {
+				err = os.Mkdir(warm.path(i), util.OS_ALL_R|util.OS_ALL_X|util.OS_USER_W)
+			} else {
+				err = os.WriteFile(warm.path(i), nil, util.OS_ALL_R|util.OS_USER_W)
+			}
+			if err != nil {
+				e.destroyWarmContainer(ctx, warm)
+				return nil, err
+			}
+			hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				ReadOnly: m.ReadOnly,
+				Source:   warm.path(i),
+				Target:   m.Target,
+			})
+		}
+
+		containerConfig.Labels = map[string]string{
+			labelExecutorName: e.ID,
+			labelWarm:         e.ID,
+		}
+		created, err := e.client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, nil, "")
+		if err != nil {
+			e.destroyWarmContainer(ctx, warm)
+			return nil, err
+		}
+		warm.id = created.ID
+		return warm, nil
+	}
+}
+
+// destroyWarmContainer removes the container and the files mounted in it.
+func (e *Executor) destroyWarmContainer(ctx context.Context, warm *warmContainer) {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+	if warm.id != "" {
+		if err := e.client.RemoveContainer(ctx, warm.id); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("Container", warm.id).Msg("failed to remove warm container")
+		}
+	}
+	if err := os.RemoveAll(warm.dir); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("Path", warm.dir).Msg("failed to remove the mounts of warm container")
+	}
+}
+
+// copyInputs copies the inputs of the layout into the container.
+func (c *warmContainer) copyInputs(layout []warmMount) error {
+	for i, m := range layout {
+		if m.Output {
+			continue
+		}
+		if err := copyPath(m.Source, c.path(i)); err != nil {
+			return fmt.Errorf("copying input %s into warm container: %w", m.Target, err)
+		}
+	}
+	return nil
+}
+
+// moveOutputs moves the outputs of the container to the sources of the layout, copying them if they can't be moved.
+func (c *warmContainer) moveOutputs(layout []warmMount) error {
+	for i, m := range layout {
+		if !m.Output {
+			continue
+		}
+		if err := os.Remove(m.Source); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Rename(c.path(i), m.Source); err == nil {
+			continue
+		}
+		if err := os.Mkdir(m.Source, util.OS_ALL_R|util.OS_ALL_X|util.OS_USER_W); err != nil {
+			return err
+		}
+		if err := copyPath(c.path(i), m.Source); err != nil {
+			return fmt.Errorf("copying output %s out of warm container: %w", m.Target, err)
+		}
+	}
+	return nil
+}
+
+// pathSize returns the size of the regular files at the path.
+func pathSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// copyPath copies the file or folder at source into the existing file or folder at target. Files are written in
+// place, so that a file bind mounted at target sees the copy.
+func copyPath(source, target string) error {
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, relPath)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			if relPath == "." {
+				return nil
+			}
+			return os.Mkdir(dest, info.Mode().Perm())
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, dest)
+		case entry.Type().IsRegular():
+			return pkgUtil.CopyFile(path, dest, info.Mode().Perm())
+		default:
+			return nil
+		}
+	})
+}
+
+// takeWarmContainer takes a warm container that can run the execution from the pool, and gets it ready to start by
+// copying the inputs into it and naming it after the execution. It returns nil if none was ready, or the execution
+// can't run in a warm container, in which case the execution creates its own. Only executions without network access
+// run in warm containers, as the network of the others is set up for each execution.
+func (e *Executor) takeWarmContainer(
+	ctx context.Context,
+	executionID string,
+	job model.Job,
+	containerConfig *container.Config,
+	hostConfig *container.HostConfig,
+	inputs, outputs []mount.Mount,
+) (*warmContainer, []warmMount, model.WarmPoolResult) {
+	if e.warmPool == nil || job.Spec.Network.Type != model.NetworkNone {
+		return nil, nil, ""
+	}
+	layout, ok := warmLayout(inputs, outputs)
+	if !ok {
+		return nil, nil, ""
+	}
+	key, err := warmPoolKey(*containerConfig, *hostConfig, layout)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("failed to derive the warm pool key of the execution")
+		return nil, nil, ""
+	}
+
+	warm, hit := e.warmPool.Take(ctx, key, e.warmContainerCreator(*containerConfig, *hostConfig, layout))
+	if !hit {
+		return nil, nil, model.WarmPoolMiss
+	}
+	err = warm.copyInputs(layout)
+	if err == nil {
+		err = e.client.ContainerRename(ctx, warm.id, e.containerName(executionID, job))
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to prepare warm container, creating one instead")
+		e.destroyWarmContainer(ctx, warm)
+		return nil, nil, model.WarmPoolMiss
+	}
+	e.warmContainers.Put(executionID, warm)
+	return warm, layout, model.WarmPoolHit
+}
@@ -0,0 +1,111 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmLayout(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(inputFile, []byte("data"), 0600))
+	inputDir := filepath.Join(dir, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0700))
+
+	layout, ok := warmLayout(
+		[]mount.Mount{
+			{Source: inputFile, Target: "/inputs/file", ReadOnly: true},
+			{Source: inputDir, Target: "/data", ReadOnly: true},
+		},
+		[]mount.Mount{{Source: filepath.Join(dir, "outputs"), Target: "/outputs"}},
+	)
+	require.True(t, ok)
+	require.Equal(t, []warmMount{
+		{Source: inputDir, Target: "/data", ReadOnly: true, Dir: true},
+		{Source: inputFile, Target: "/inputs/file", ReadOnly: true},
+		{Source: filepath.Join(dir, "outputs"), Target: "/outputs", Output: true, Dir: true},
+	}, layout)
+
+	big := filepath.Join(dir, "big")
+	require.NoError(t, os.WriteFile(big, nil, 0600))
+	require.NoError(t, os.Truncate(big, int64(maxWarmInputsSize)+1))
+	_, ok = warmLayout([]mount.Mount{{Source: big, Target: "/big", ReadOnly: true}}, nil)
+	require.False(t, ok, "inputs too big to be copied can't use warm containers")
+}
+
+func TestWarmPoolKey(t *testing.T) {
+	config := container.Config{Image: "ubuntu", Env: []string{"A=1"}, Labels: map[string]string{labelExecutionID: "one"}}
+	host := container.HostConfig{Mounts: []mount.Mount{{Source: "/one", Target: "/outputs"}}}
+	layout := []warmMount{{Source: "/one", Target: "/outputs", Output: true, Dir: true}}
+	key, err := warmPoolKey(config, host, layout)
+	require.NoError(t, err)
+
+	// the labels and sources of the mounts differ between executions that can share warm containers
+	otherConfig := config
+	otherConfig.Labels = map[string]string{labelExecutionID: "two"}
+	otherHost := container.HostConfig{Mounts: []mount.Mount{{Source: "/two", Target: "/outputs"}}}
+	otherKey, err := warmPoolKey(otherConfig, otherHost, []warmMount{{Source: "/two", Target: "/outputs", Output: true, Dir: true}})
+	require.NoError(t, err)
+	require.Equal(t, key, otherKey)
+	require.Equal(t, map[string]string{labelExecutionID: "one"}, config.Labels, "the config of the execution is left as is")
+
+	otherConfig.Env = []string{"A=2"}
+	otherKey, err = warmPoolKey(otherConfig, host, layout)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	otherKey, err = warmPoolKey(config, host, []warmMount{{Target: "/results", Output: true, Dir: true}})
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+}
+
+func TestWarmContainerInputsAndOutputs(t *testing.T) {
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(inputFile, []byte("file"), 0600))
+	inputDir := filepath.Join(dir, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(inputDir, "sub"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "sub", "a.txt"), []byte("a"), 0600))
+	require.NoError(t, os.Symlink("sub/a.txt", filepath.Join(inputDir, "link")))
+	output := filepath.Join(dir, "output")
+	require.NoError(t, os.Mkdir(output, 0700))
+
+	layout := []warmMount{
+		{Source: inputDir, Target: "/data", ReadOnly: true, Dir: true},
+		{Source: inputFile, Target: "/inputs/file", ReadOnly: true},
+		{Source: output, Target: "/outputs", Output: true, Dir: true},
+	}
+	warm := &warmContainer{dir: t.TempDir()}
+	require.NoError(t, os.Mkdir(warm.path(0), 0700))
+	// files are bind mounted, so they are written in place
+	require.NoError(t, os.WriteFile(warm.path(1), nil, 0600))
+	mounted, err := os.Open(warm.path(1))
+	require.NoError(t, err)
+	defer mounted.Close()
+	require.NoError(t, os.Mkdir(warm.path(2), 0700))
+
+	require.NoError(t, warm.copyInputs(layout))
+	data, err := os.ReadFile(filepath.Join(warm.path(0), "sub", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data))
+	link, err := os.Readlink(filepath.Join(warm.path(0), "link"))
+	require.NoError(t, err)
+	require.Equal(t, "sub/a.txt", link)
+	data = make([]byte, 4)
+	_, err = mounted.Read(data)
+	require.NoError(t, err)
+	require.Equal(t, "file", string(data))
+
+	require.NoError(t, os.WriteFile(filepath.Join(warm.path(2), "result.txt"), []byte("result"), 0600))
+	require.NoError(t, warm.moveOutputs(layout))
+	data, err = os.ReadFile(filepath.Join(output, "result.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "result", string(data))
+}
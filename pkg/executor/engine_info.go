@@ -0,0 +1,26 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// GetEngineInfo returns the version and features of the engine. Engines whose executor does not report them have an
+// unknown version and no features.
+func GetEngineInfo(ctx context.Context, provider ExecutorProvider, engine model.Engine) (model.EngineInfo, error) {
+	e, err := provider.Get(ctx, engine)
+	if err != nil {
+		return model.EngineInfo{}, err
+	}
+	versioned, ok := e.(VersionedExecutor)
+	if !ok {
+		return model.EngineInfo{Engine: engine}, nil
+	}
+	info, err := versioned.EngineInfo(ctx)
+	if err != nil {
+		return model.EngineInfo{}, err
+	}
+	info.Engine = engine
+	return info, nil
+}
@@ -82,12 +82,17 @@ func (e *Executor) Run(
 	return executor.Run(ctx, executionID, job, jobResultsDir)
 }
 
-func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
+func (e *Executor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
 	executor, exists := e.delegatedExecutors.Get(executionID)
 	if !exists {
 		return nil, fmt.Errorf("execution %v not found", executionID)
 	}
-	return executor.GetOutputStream(ctx, executionID, withHistory, follow)
+	return executor.GetOutputStream(ctx, executionID, withHistory, follow, tail)
 }
 
 func (e *Executor) getDelegateExecutor(ctx context.Context, job model.Job) (executor.Executor, error) {
@@ -116,7 +116,12 @@ func (e *NoopExecutor) Run(
 	return &model.RunCommandResult{}, nil
 }
 
-func (e *NoopExecutor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
+func (e *NoopExecutor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
 	return nil, fmt.Errorf("not implemented for NoopExecutor")
 }
 
@@ -0,0 +1,28 @@
+package process
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// networkBidStrategy rejects jobs that need networking restricted to some domains, as the sandbox can only give a
+// process all of the host's network or none of it.
+type networkBidStrategy struct{}
+
+var _ bidstrategy.SemanticBidStrategy = networkBidStrategy{}
+
+// ShouldBid implements semantic.SemanticBidStrategy
+func (networkBidStrategy) ShouldBid(
+	_ context.Context,
+	request bidstrategy.BidStrategyRequest,
+) (bidstrategy.BidStrategyResponse, error) {
+	if request.Job.Spec.Engine != model.EngineProcess || request.Job.Spec.Network.Type != model.NetworkHTTP {
+		return bidstrategy.NewShouldBidResponse(), nil
+	}
+	return bidstrategy.BidStrategyResponse{
+		ShouldBid: false,
+		Reason:    "the process engine cannot restrict networking to domains",
+	}, nil
+}
@@ -0,0 +1,322 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/resource"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	capacitysystem "github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	wasmlogs "github.com/bacalhau-project/bacalhau/pkg/logger/wasm"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
+)
+
+const (
+	DefaultBubblewrapPath = "bwrap"
+
+	// sandboxPath is the PATH of processes in the sandbox, unless the job sets its own.
+	sandboxPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	// sandboxHome is the HOME of processes in the sandbox, which is their default working directory too.
+	sandboxHome = "/tmp"
+)
+
+// DefaultReadOnlyPaths are the directories of the host a sandboxed process can read, which hold the system software
+// jobs run.
+var DefaultReadOnlyPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt"}
+
+// Config configures the process executor. Jobs run by it are not isolated from the host as well as docker jobs are,
+// so it must only be enabled on clusters whose users are trusted.
+type Config struct {
+	Enabled bool
+	// BubblewrapPath is the bwrap binary sandboxes are made with. It is looked up in the PATH if it is not absolute.
+	BubblewrapPath string
+	// ReadOnlyPaths are the directories of the host visible to jobs, such as shared software installations.
+	// DefaultReadOnlyPaths are used if empty.
+	ReadOnlyPaths []string
+}
+
+// Executor runs the entrypoint of jobs as plain processes on the host, in a bubblewrap sandbox that only sees the
+// read-only system directories of the host, the inputs and the outputs of the job. The resource limits of the job are
+// applied to the process as rlimits.
+type Executor struct {
+	StorageProvider storage.StorageProvider
+	config          Config
+	logManagers     generic.SyncMap[string, *wasmlogs.LogManager]
+}
+
+func NewExecutor(_ context.Context, storageProvider storage.StorageProvider, config Config) (*Executor, error) {
+	if config.BubblewrapPath == "" {
+		config.BubblewrapPath = DefaultBubblewrapPath
+	}
+	if len(config.ReadOnlyPaths) == 0 {
+		config.ReadOnlyPaths = DefaultReadOnlyPaths
+	}
+	for _, path := range config.ReadOnlyPaths {
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("read-only path %q of the process executor is not absolute", path)
+		}
+	}
+	return &Executor{
+		StorageProvider: storageProvider,
+		config:          config,
+	}, nil
+}
+
+func (e *Executor) IsInstalled(context.Context) (bool, error) {
+	_, err := exec.LookPath(e.config.BubblewrapPath)
+	return err == nil, nil
+}
+
+func (e *Executor) HasStorageLocally(ctx context.Context, volume model.StorageSpec) (bool, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/executor/process.Executor.HasStorageLocally")
+	defer span.End()
+
+	s, err := e.StorageProvider.Get(ctx, volume.StorageSource)
+	if err != nil {
+		return false, err
+	}
+
+	return s.HasStorageLocally(ctx, volume)
+}
+
+func (e *Executor) GetVolumeSize(ctx context.Context, volume model.StorageSpec) (uint64, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/executor/process.Executor.GetVolumeSize")
+	defer span.End()
+
+	storageProvider, err := e.StorageProvider.Get(ctx, volume.StorageSource)
+	if err != nil {
+		return 0, err
+	}
+	return storageProvider.GetVolumeSize(ctx, volume)
+}
+
+// GetBidStrategy implements executor.Executor
+func (*Executor) GetSemanticBidStrategy(context.Context) (bidstrategy.SemanticBidStrategy, error) {
+	return semantic.NewChainedSemanticBidStrategy(networkBidStrategy{}), nil
+}
+
+func (*Executor) GetResourceBidStrategy(context.Context) (bidstrategy.ResourceBidStrategy, error) {
+	return resource.NewChainedResourceBidStrategy(), nil
+}
+
+// sandboxMount is a path of the host made visible in the sandbox.
+type sandboxMount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// sandboxArgs returns the bwrap arguments that run the entrypoint of the job in a sandbox with the mounts. The sandbox
+// has its own namespaces, including the network one unless the job needs full networking, and sees nothing of the
+// host but the read-only paths.
+func (e *Executor) sandboxArgs(job model.Job, mounts []sandboxMount) ([]string, error) {
+	args := []string{"--die-with-parent", "--new-session", "--unshare-all"}
+	if job.Spec.Network.Type == model.NetworkFull {
+		args = append(args, "--share-net")
+	}
+
+	for _, path := range e.config.ReadOnlyPaths {
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			// e.g. /lib on merged-usr systems, which must stay a link for the loader to be found at its usual path
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--symlink", target, path)
+		} else {
+			args = append(args, "--ro-bind", path, path)
+		}
+	}
+	args = append(args, "--proc", "/proc", "--dev", "/dev", "--tmpfs", sandboxHome)
+
+	for _, mount := range mounts {
+		bind := "--bind"
+		if mount.ReadOnly {
+			bind = "--ro-bind"
+		}
+		args = append(args, bind, mount.Source, mount.Target)
+	}
+
+	workingDirectory := job.Spec.Process.WorkingDirectory
+	if workingDirectory == "" {
+		workingDirectory = sandboxHome
+	}
+	args = append(args, "--chdir", workingDirectory, "--clearenv",
+		"--setenv", "PATH", sandboxPath, "--setenv", "HOME", sandboxHome)
+	for _, env := range job.Spec.Process.EnvironmentVariables {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", env)
+		}
+		args = append(args, "--setenv", key, value)
+	}
+
+	args = append(args, "--")
+	return append(args, job.Spec.Process.Entrypoint...), nil
+}
+
+// rlimitScript returns a shell script that sets the rlimits of the job before replacing itself with its arguments,
+// so that the limits are inherited by the sandbox and every process of the job. Memory limits the data segment of
+// each process, and CPU the CPU time each process can use within the timeout of the job.
+func rlimitScript(job model.Job) string {
+	var limits []string
+	usage := capacity.ParseResourceUsageConfig(job.Spec.Resources)
+	if usage.Memory > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -d %d", (usage.Memory+1023)/1024)) //nolint:gomnd
+	}
+	if usage.CPU > 0 && job.Spec.Timeout > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", int64(math.Ceil(usage.CPU*job.Spec.Timeout))))
+	}
+	return strings.Join(append(limits, `exec "$@"`), " && ")
+}
+
+//nolint:funlen
+func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, jobResultsDir string) (*model.RunCommandResult, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/executor/process.Executor.Run")
+	defer span.End()
+
+	fetchStart := time.Now()
+	inputVolumes, err := storage.ParallelPrepareStorage(ctx, e.StorageProvider, job.Spec.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	inputsFetchDuration := time.Since(fetchStart)
+	defer func() {
+		log.Ctx(ctx).Debug().
+			Str("Execution", executionID).
+			Msg("attempting cleanup of inputs for execution")
+		err := storage.ParallelCleanStorage(ctx, e.StorageProvider, inputVolumes)
+		if err != nil {
+			log.Ctx(ctx).Error().
+				Err(err).
+				Str("Execution", executionID).
+				Msg("errors occurred when cleaning up inputs")
+		}
+	}()
+
+	var mounts []sandboxMount
+	for _, volume := range inputVolumes {
+		mounts = append(mounts, sandboxMount{Source: volume.Source, Target: volume.Target, ReadOnly: volume.ReadOnly})
+	}
+
+	inputManifest, err := storage.WriteInputManifest(inputVolumes)
+	if err != nil {
+		return executor.FailResult(fmt.Errorf("failed to write input manifest: %w", err))
+	}
+	defer os.Remove(inputManifest) //nolint:errcheck
+	mounts = append(mounts, sandboxMount{Source: inputManifest, Target: storage.InputManifestPath, ReadOnly: true})
+
+	for _, output := range job.Spec.Outputs {
+		if output.Name == "" {
+			return executor.FailResult(fmt.Errorf("output volume has no name: %+v", output))
+		}
+		if output.Path == "" {
+			return executor.FailResult(fmt.Errorf("output volume has no path: %+v", output))
+		}
+
+		srcd := filepath.Join(jobResultsDir, output.Name)
+		err = os.Mkdir(srcd, util.OS_ALL_R|util.OS_ALL_X|util.OS_USER_W)
+		if err != nil {
+			return executor.FailResult(err)
+		}
+		mounts = append(mounts, sandboxMount{Source: srcd, Target: output.Path})
+	}
+
+	args, err := e.sandboxArgs(job, mounts)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+
+	// Create a new log manager and obtain some writers that we can pass to the process
+	logs, err := wasmlogs.NewLogManager(ctx, executionID)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	stdout, stderr := logs.GetWriters()
+
+	// Store the LogManager for the lifetime of the execution, making sure to tidy up
+	// once complete.
+	e.logManagers.Put(executionID, logs)
+	defer func() {
+		log.Ctx(ctx).Debug().Str("Execution", executionID).Msg("cleaning up logmanager for execution")
+		logs.Close()
+		e.logManagers.Delete(executionID)
+		log.Ctx(ctx).Debug().Str("Execution", executionID).Msg("logmanager being removed")
+	}()
+
+	log.Ctx(ctx).Debug().
+		Strs("entrypoint", job.Spec.Process.Entrypoint).
+		Str("job", job.ID()).
+		Str("execution", executionID).
+		Msg("Running process job")
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", rlimitScript(job), "sh", e.config.BubblewrapPath}, args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// A process killed by a signal has no exit code, so we keep the error to
+	// report why it stopped.
+	exitCode := -1
+	runErr := cmd.Run()
+	var errExit *exec.ExitError
+	if runErr == nil {
+		exitCode = 0
+	} else if errors.As(runErr, &errExit) && errExit.ExitCode() >= 0 {
+		exitCode = errExit.ExitCode()
+		runErr = nil
+	}
+
+	// execution has finished and there's nothing else to read from so inform
+	// the logs that it is time to drain any remaining items.
+	logs.Drain()
+
+	stdoutReader, stderrReader := logs.GetDefaultReaders(false)
+	result, err := executor.WriteJobResults(jobResultsDir, stdoutReader, stderrReader, exitCode, runErr)
+	if result != nil {
+		env := capacitysystem.Environment(ctx)
+		result.Environment = &env
+		result.InputsFetchDuration = inputsFetchDuration
+	}
+	return result, err
+}
+
+func (e *Executor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
+	logs, present := e.logManagers.Get(executionID)
+	if !present {
+		log.Ctx(ctx).Debug().Str("Execution", executionID).Msg("logmanager for process execution was already removed")
+		return nil, fmt.Errorf("logmanager has completed, no logs available")
+	}
+
+	return logs.GetMuxedReader(withHistory, follow, tail), nil
+}
+
+// Compile-time check that Executor implements the Executor interface.
+var _ executor.Executor = (*Executor)(nil)
@@ -0,0 +1,117 @@
+//go:build unit || !integration
+
+package process
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+)
+
+func TestSandboxArgs(t *testing.T) {
+	host := t.TempDir()
+	usr := filepath.Join(host, "usr")
+	lib := filepath.Join(host, "lib")
+	require.NoError(t, os.Mkdir(usr, 0755))
+	require.NoError(t, os.Symlink("usr/lib", lib))
+
+	e, err := NewExecutor(context.Background(), nil, Config{
+		ReadOnlyPaths: []string{usr, lib, filepath.Join(host, "missing")},
+	})
+	require.NoError(t, err)
+
+	job := model.Job{Spec: model.Spec{
+		Engine:  model.EngineProcess,
+		Network: model.NetworkConfig{Type: model.NetworkFull},
+		Process: model.JobSpecProcess{
+			Entrypoint:           []string{"python3", "main.py"},
+			EnvironmentVariables: []string{"FOO=bar=baz"},
+			WorkingDirectory:     "/inputs",
+		},
+	}}
+	args, err := e.sandboxArgs(job, []sandboxMount{
+		{Source: "/data/in", Target: "/inputs", ReadOnly: true},
+		{Source: "/data/out", Target: "/outputs"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"--die-with-parent", "--new-session", "--unshare-all", "--share-net",
+		"--ro-bind", usr, usr,
+		"--symlink", "usr/lib", lib,
+		"--proc", "/proc", "--dev", "/dev", "--tmpfs", "/tmp",
+		"--ro-bind", "/data/in", "/inputs",
+		"--bind", "/data/out", "/outputs",
+		"--chdir", "/inputs", "--clearenv",
+		"--setenv", "PATH", sandboxPath, "--setenv", "HOME", "/tmp",
+		"--setenv", "FOO", "bar=baz",
+		"--", "python3", "main.py",
+	}, args)
+
+	job.Spec.Network.Type = model.NetworkNone
+	job.Spec.Process.EnvironmentVariables = []string{"FOO"}
+	_, err = e.sandboxArgs(job, nil)
+	require.Error(t, err)
+}
+
+func TestNewExecutorRejectsRelativePaths(t *testing.T) {
+	_, err := NewExecutor(context.Background(), nil, Config{ReadOnlyPaths: []string{"usr"}})
+	require.Error(t, err)
+}
+
+func TestRlimitScript(t *testing.T) {
+	require.Equal(t, `exec "$@"`, rlimitScript(model.Job{}))
+
+	job := model.Job{Spec: model.Spec{
+		Resources: model.ResourceUsageConfig{CPU: "1.5", Memory: "1Mb"},
+		Timeout:   10,
+	}}
+	require.Equal(t, `ulimit -d 1024 && ulimit -t 15 && exec "$@"`, rlimitScript(job))
+}
+
+// fakeBubblewrap writes a bwrap that runs the entrypoint directly, as the sandbox can't be made in every test
+// environment.
+func fakeBubblewrap(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "bwrap")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift\nexec \"$@\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	storages := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{})
+	e, err := NewExecutor(ctx, storages, Config{Enabled: true, BubblewrapPath: fakeBubblewrap(t)})
+	require.NoError(t, err)
+
+	installed, err := e.IsInstalled(ctx)
+	require.NoError(t, err)
+	require.True(t, installed)
+
+	job := model.Job{Spec: model.Spec{
+		Engine: model.EngineProcess,
+		Process: model.JobSpecProcess{
+			Entrypoint: []string{"/bin/sh", "-c", "echo hello; echo oops >&2; exit 3"},
+		},
+	}}
+	result, err := e.Run(ctx, "execution", job, t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", result.STDOUT)
+	require.Equal(t, "oops\n", result.STDERR)
+	require.Equal(t, 3, result.ExitCode)
+	require.Empty(t, result.ErrorMsg)
+}
+
+func TestIsInstalledWithoutBubblewrap(t *testing.T) {
+	e, err := NewExecutor(context.Background(), nil, Config{BubblewrapPath: filepath.Join(t.TempDir(), "bwrap")})
+	require.NoError(t, err)
+
+	installed, err := e.IsInstalled(context.Background())
+	require.NoError(t, err)
+	require.False(t, installed)
+}
@@ -97,12 +97,17 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, r
 	return dockerExecutor.Run(ctx, executionID, job, resultsDir)
 }
 
-func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
+func (e *Executor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
 	dockerExecutor, err := e.executors.Get(ctx, model.EngineDocker)
 	if err != nil {
 		return nil, err
 	}
-	return dockerExecutor.GetOutputStream(ctx, executionID, withHistory, follow)
+	return dockerExecutor.GetOutputStream(ctx, executionID, withHistory, follow, tail)
 }
 
 // Compile-time check that Executor implements the Executor interface.
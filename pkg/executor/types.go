@@ -31,8 +31,10 @@ type Executor interface {
 	//    alongside cpu & memory usage
 	GetVolumeSize(context.Context, model.StorageSpec) (uint64, error)
 
-	// GetOutputStream retrieves a muxed stream from the executor
-	GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error)
+	// GetOutputStream retrieves the output of the execution muxed in the logframe format, whatever the engine. Only
+	// the output from now on is included without history, and only the last tail lines of the history if tail is
+	// positive.
+	GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool, tail int) (io.ReadCloser, error)
 
 	// run the given job - it's expected that we have already prepared the job
 	// this will return a local filesystem path to the jobs results
@@ -43,3 +45,37 @@ type Executor interface {
 		resultsDir string,
 	) (*model.RunCommandResult, error)
 }
+
+// InteractiveExecutor is implemented by executors that can run interactive jobs, whose clients stream input to them
+// while they run.
+type InteractiveExecutor interface {
+	// Attach returns a writer to the stdin of the running execution, and a muxed stream of its stdout and stderr in
+	// the same format as GetOutputStream. Closing the writer closes stdin.
+	Attach(ctx context.Context, executionID string) (io.WriteCloser, io.ReadCloser, error)
+}
+
+// Workload is what an executor runs for an execution, such as a container.
+type Workload struct {
+	ExecutionID string
+	// Running is false once the workload has exited.
+	Running bool
+	// Resources are what the workload was given to run with, and the disk it has written to.
+	Resources model.ResourceUsageData
+}
+
+// OrphanExecutor is implemented by executors whose workloads outlive the compute node when it crashes, so that the
+// node can find the workloads it no longer tracks when it restarts.
+type OrphanExecutor interface {
+	// ListWorkloads returns the workloads of the executor, whether they are still tracked or not.
+	ListWorkloads(ctx context.Context) ([]Workload, error)
+	// WaitWorkload blocks until the workload of the execution exits.
+	WaitWorkload(ctx context.Context, executionID string) error
+	// RemoveWorkload removes the workload of the execution, stopping it if it runs, along with the disk it wrote to.
+	RemoveWorkload(ctx context.Context, executionID string) error
+}
+
+// VersionedExecutor is implemented by executors that report the version of their engine and the features it supports,
+// so that jobs with EngineRequirements are only run by engines that meet them.
+type VersionedExecutor interface {
+	EngineInfo(ctx context.Context) (model.EngineInfo, error)
+}
@@ -20,6 +20,11 @@ func NewExecutorSpecificBidStrategy(provider executor.ExecutorProvider) bidstrat
 				provider,
 				func(j *model.Job) model.Engine { return j.Spec.Engine },
 			),
+			semantic.NewEngineRequirementsStrategy(semantic.EngineRequirementsStrategyParams{
+				EngineInfo: func(ctx context.Context, engine model.Engine) (model.EngineInfo, error) {
+					return executor.GetEngineInfo(ctx, provider, engine)
+				},
+			}),
 			&bidStrategyFromExecutor{
 				provider: provider,
 			},
@@ -6,11 +6,14 @@ import (
 	"os"
 
 	"github.com/bacalhau-project/bacalhau/pkg/config"
+	pkgdocker "github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/executor/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/executor/language"
 	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/process"
 	pythonwasm "github.com/bacalhau-project/bacalhau/pkg/executor/python_wasm"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
 	"github.com/bacalhau-project/bacalhau/pkg/executor/wasm"
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -30,7 +33,9 @@ import (
 )
 
 type StandardStorageProviderOptions struct {
-	API                   ipfs.Client
+	API ipfs.Client
+	// IPFSBackends are the backends IPFS storage is routed to. If empty, API is used for everything.
+	IPFSBackends          ipfs.Backends
 	FilecoinUnsealedPath  string
 	DownloadPath          string
 	EstuaryAPIKey         string
@@ -39,6 +44,16 @@ type StandardStorageProviderOptions struct {
 
 type StandardExecutorOptions struct {
 	DockerID string
+	// DockerImageScan configures the vulnerability scan of docker job images
+	DockerImageScan pkgdocker.ImageScanConfig
+	// DockerImageGC configures the garbage collection of the images pulled for docker jobs
+	DockerImageGC pkgdocker.ImageGCConfig
+	// Process configures the process executor, which is only added if enabled
+	Process process.Config
+	// DockerWarmPool configures the containers the docker executor keeps warm for the most run job configs
+	DockerWarmPool warmpool.Config
+	// WasmWarmPool configures the runtimes the wasm executor keeps warm for the most run modules
+	WasmWarmPool warmpool.Config
 }
 
 func NewStandardStorageProvider(
@@ -46,7 +61,11 @@ func NewStandardStorageProvider(
 	cm *system.CleanupManager,
 	options StandardStorageProviderOptions,
 ) (storage.StorageProvider, error) {
-	ipfsAPICopyStorage, err := ipfs_storage.NewStorage(cm, options.API)
+	ipfsBackends := options.IPFSBackends
+	if len(ipfsBackends) == 0 {
+		ipfsBackends = ipfs.Backends{ipfs.NewDefaultBackend(options.API)}
+	}
+	ipfsAPICopyStorage, err := ipfs_storage.NewStorageWithBackends(cm, ipfsBackends)
 	if err != nil {
 		return nil, err
 	}
@@ -171,21 +190,33 @@ func NewStandardExecutorProvider(
 	storageProvider storage.StorageProvider,
 	executorOptions StandardExecutorOptions,
 ) (executor.ExecutorProvider, error) {
-	dockerExecutor, err := docker.NewExecutor(ctx, cm, executorOptions.DockerID, storageProvider)
+	dockerExecutor, err := docker.NewExecutor(
+		ctx, cm, executorOptions.DockerID, storageProvider, executorOptions.DockerImageScan, executorOptions.DockerImageGC,
+		executorOptions.DockerWarmPool,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	wasmExecutor, err := wasm.NewExecutor(ctx, storageProvider)
+	wasmExecutor, err := wasm.NewExecutor(ctx, storageProvider, executorOptions.WasmWarmPool)
 	if err != nil {
 		return nil, err
 	}
+	cm.RegisterCallbackWithContext(wasmExecutor.Close)
 
 	executors := model.NewMappedProvider(map[model.Engine]executor.Executor{
 		model.EngineDocker: dockerExecutor,
 		model.EngineWasm:   wasmExecutor,
 	})
 
+	if executorOptions.Process.Enabled {
+		processExecutor, err := process.NewExecutor(ctx, storageProvider, executorOptions.Process)
+		if err != nil {
+			return nil, err
+		}
+		executors.Add(model.EngineProcess, processExecutor)
+	}
+
 	// language executors wrap other executors, so pass them a reference to all
 	// the executors so they can look up the ones they need
 	exLang, err := language.NewExecutor(ctx, cm, executors)
@@ -0,0 +1,17 @@
+package warmpool
+
+// DefaultMaxKeys is the number of most used keys kept warm if the pool is enabled without saying how many.
+const DefaultMaxKeys = 5
+
+// Config configures the warm pool of an executor. The pool is disabled if Size is zero.
+type Config struct {
+	// Size is the number of warm instances kept for each of the most used keys.
+	Size int
+	// MaxKeys is the number of most used keys kept warm. DefaultMaxKeys is used if zero.
+	MaxKeys int
+}
+
+// Enabled returns true if the pool keeps any warm instances.
+func (c Config) Enabled() bool {
+	return c.Size > 0
+}
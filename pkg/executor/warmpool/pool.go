@@ -0,0 +1,209 @@
+package warmpool
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Creator creates a new instance of a resource for a key.
+type Creator[T any] func(ctx context.Context) (T, error)
+
+type PoolParams[T any] struct {
+	// Size is the number of warm instances kept for each key.
+	Size int
+	// MaxKeys is the number of most used keys that are kept warm. DefaultMaxKeys is used if zero.
+	MaxKeys int
+	// Destroy releases an instance that is dropped from the pool without being used.
+	Destroy func(T)
+}
+
+// Stats counts how often executions found a warm instance in the pool.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	// Idle is the number of warm instances currently in the pool
+	Idle int
+}
+
+// Pool keeps instances of an expensive resource created ahead of time for the keys used the most, so that executions
+// can take a warm instance instead of creating one when they start. Instances are used once and never returned to the
+// pool; the pool is refilled in the background as instances are taken.
+type Pool[T any] struct {
+	size    int
+	maxKeys int
+	destroy func(T)
+
+	mu       sync.Mutex
+	idle     map[string][]T
+	uses     map[string]uint64
+	creators map[string]Creator[T]
+	filling  map[string]bool
+	stats    Stats
+	closed   bool
+}
+
+// maxTrackedKeys is the number of keys whose uses are counted before the counts are halved, so that keys that
+// are no longer used are eventually forgotten.
+const maxTrackedKeys = 1000
+
+func NewPool[T any](params PoolParams[T]) *Pool[T] {
+	destroy := params.Destroy
+	if destroy == nil {
+		destroy = func(T) {}
+	}
+	maxKeys := params.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = DefaultMaxKeys
+	}
+	return &Pool[T]{
+		size:     params.Size,
+		maxKeys:  maxKeys,
+		destroy:  destroy,
+		idle:     make(map[string][]T),
+		uses:     make(map[string]uint64),
+		creators: make(map[string]Creator[T]),
+		filling:  make(map[string]bool),
+	}
+}
+
+// Take returns a warm instance for the key if there is one, and false otherwise, in which case the caller creates its
+// own. create is remembered to refill the pool for the key in the background if the key is among the most used.
+func (p *Pool[T]) Take(ctx context.Context, key string, create Creator[T]) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var instance T
+	if p.closed || p.size <= 0 || p.maxKeys <= 0 {
+		return instance, false
+	}
+
+	p.uses[key]++
+	p.creators[key] = create
+	idle := p.idle[key]
+	hit := len(idle) > 0
+	if hit {
+		instance = idle[len(idle)-1]
+		p.idle[key] = idle[:len(idle)-1]
+		p.stats.Hits++
+	} else {
+		p.stats.Misses++
+	}
+
+	p.forget()
+	warm := p.warmKeys()
+	for idleKey, instances := range p.idle {
+		if _, ok := warm[idleKey]; !ok {
+			log.Ctx(ctx).Debug().Str("Key", idleKey).Msg("dropping warm instances of a key that is no longer used much")
+			for _, dropped := range instances {
+				p.destroy(dropped)
+			}
+			delete(p.idle, idleKey)
+		}
+	}
+	if _, ok := warm[key]; ok && !p.filling[key] {
+		p.filling[key] = true
+		go p.fill(key)
+	}
+	return instance, hit
+}
+
+// Stats returns how often executions found a warm instance in the pool.
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	for _, instances := range p.idle {
+		stats.Idle += len(instances)
+	}
+	return stats
+}
+
+// Close destroys the warm instances in the pool, and stops refilling it.
+func (p *Pool[T]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for key, instances := range p.idle {
+		for _, instance := range instances {
+			p.destroy(instance)
+		}
+		delete(p.idle, key)
+	}
+}
+
+// fill creates instances for the key until the pool holds Size of them, or the key is no longer among the most used.
+func (p *Pool[T]) fill(key string) {
+	ctx := context.Background()
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.filling, key)
+	}()
+
+	for {
+		p.mu.Lock()
+		_, warm := p.warmKeys()[key]
+		create := p.creators[key]
+		if p.closed || !warm || create == nil || len(p.idle[key]) >= p.size {
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		instance, err := create(ctx)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("Key", key).Msg("failed to create warm instance")
+			return
+		}
+
+		p.mu.Lock()
+		_, warm = p.warmKeys()[key]
+		if p.closed || !warm || len(p.idle[key]) >= p.size {
+			p.mu.Unlock()
+			p.destroy(instance)
+			return
+		}
+		p.idle[key] = append(p.idle[key], instance)
+		p.mu.Unlock()
+	}
+}
+
+// warmKeys returns the keys that are used the most, which are the ones kept warm.
+func (p *Pool[T]) warmKeys() map[string]struct{} {
+	keys := make([]string, 0, len(p.uses))
+	for key := range p.uses {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if p.uses[keys[i]] != p.uses[keys[j]] {
+			return p.uses[keys[i]] > p.uses[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > p.maxKeys {
+		keys = keys[:p.maxKeys]
+	}
+	warm := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		warm[key] = struct{}{}
+	}
+	return warm
+}
+
+// forget halves the uses of every key once too many keys are tracked, and drops the keys that are no longer used.
+func (p *Pool[T]) forget() {
+	if len(p.uses) <= maxTrackedKeys {
+		return
+	}
+	for key, uses := range p.uses {
+		if uses /= 2; uses == 0 {
+			delete(p.uses, key)
+			delete(p.creators, key)
+		} else {
+			p.uses[key] = uses
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//go:build unit || !integration
+
+package warmpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	created   atomic.Int64
+	destroyed atomic.Int64
+}
+
+func (c *counter) create(context.Context) (int64, error) {
+	return c.created.Add(1), nil
+}
+
+func (c *counter) destroy(int64) {
+	c.destroyed.Add(1)
+}
+
+func waitForIdle(t *testing.T, pool *Pool[int64], idle int) {
+	require.Eventually(t, func() bool {
+		return pool.Stats().Idle == idle
+	}, time.Second, time.Millisecond)
+}
+
+func TestPoolTake(t *testing.T) {
+	ctx := context.Background()
+	c := &counter{}
+	pool := NewPool(PoolParams[int64]{Size: 2, MaxKeys: 1, Destroy: c.destroy})
+
+	// the first execution of a key misses, and fills the pool for the next ones
+	_, hit := pool.Take(ctx, "a", c.create)
+	require.False(t, hit)
+	waitForIdle(t, pool, 2)
+
+	instance, hit := pool.Take(ctx, "a", c.create)
+	require.True(t, hit)
+	require.NotZero(t, instance)
+	waitForIdle(t, pool, 2)
+
+	stats := pool.Stats()
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+
+	// a key used more often takes the place of the warm key, whose instances are dropped
+	pool.Take(ctx, "b", c.create)
+	pool.Take(ctx, "b", c.create)
+	_, hit = pool.Take(ctx, "b", c.create)
+	require.False(t, hit)
+	require.Equal(t, int64(2), c.destroyed.Load())
+	waitForIdle(t, pool, 2)
+
+	pool.Close()
+	require.Equal(t, 0, pool.Stats().Idle)
+	require.Equal(t, int64(4), c.destroyed.Load())
+	_, hit = pool.Take(ctx, "b", c.create)
+	require.False(t, hit)
+}
+
+func TestPoolDisabled(t *testing.T) {
+	c := &counter{}
+	pool := NewPool(PoolParams[int64]{Size: 0})
+	_, hit := pool.Take(context.Background(), "a", c.create)
+	require.False(t, hit)
+	require.Never(t, func() bool {
+		return c.created.Load() > 0
+	}, 50*time.Millisecond, time.Millisecond)
+}
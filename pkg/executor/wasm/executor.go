@@ -8,7 +8,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/c2h5oh/datasize"
 	"github.com/rs/zerolog/log"
@@ -20,12 +23,17 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/resource"
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	capacitysystem "github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
 	wasmlogs "github.com/bacalhau-project/bacalhau/pkg/logger/wasm"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/bacalhau-project/bacalhau/pkg/util/archivefs"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/bacalhau-project/bacalhau/pkg/util/filefs"
 	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
@@ -36,12 +44,51 @@ import (
 type Executor struct {
 	StorageProvider storage.StorageProvider
 	logManagers     generic.SyncMap[string, *wasmlogs.LogManager]
+	// warmPool keeps runtimes with the modules of the most run jobs already compiled. Nil if disabled.
+	warmPool *warmpool.Pool[*warmRuntime]
 }
 
-func NewExecutor(_ context.Context, storageProvider storage.StorageProvider) (*Executor, error) {
-	return &Executor{
+func NewExecutor(_ context.Context, storageProvider storage.StorageProvider, warmPool warmpool.Config) (*Executor, error) {
+	e := &Executor{
 		StorageProvider: storageProvider,
-	}, nil
+	}
+	if warmPool.Enabled() {
+		e.warmPool = warmpool.NewPool(warmpool.PoolParams[*warmRuntime]{
+			Size:    warmPool.Size,
+			MaxKeys: warmPool.MaxKeys,
+			Destroy: func(runtime *warmRuntime) {
+				closer.ContextCloserWithLogOnError(context.Background(), "warm runtime", runtime.runtime)
+			},
+		})
+	}
+	return e, nil
+}
+
+// Close releases the warm runtimes of the executor.
+func (e *Executor) Close(context.Context) error {
+	if e.warmPool != nil {
+		e.warmPool.Close()
+	}
+	return nil
+}
+
+var _ executor.VersionedExecutor = (*Executor)(nil)
+
+// EngineInfo implements executor.VersionedExecutor. The version of the engine is the version of wazero it is built
+// with, which only implements WASI preview 1.
+func (e *Executor) EngineInfo(context.Context) (model.EngineInfo, error) {
+	engineInfo := model.EngineInfo{
+		Engine:   model.EngineWasm,
+		Features: []string{model.EngineFeatureWASIPreview1},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/tetratelabs/wazero" {
+				engineInfo.Version = strings.TrimPrefix(dep.Version, "v")
+			}
+		}
+	}
+	return engineInfo, nil
 }
 
 func (e *Executor) IsInstalled(context.Context) (bool, error) {
@@ -84,16 +131,31 @@ func (*Executor) GetResourceBidStrategy(context.Context) (bidstrategy.ResourceBi
 // makeFsFromStorage sets up a virtual filesystem (represented by an fs.FS) that
 // will be the filesystem exposed to our WASM. The strategy for this is to:
 //
-//   - mount each input at the name specified by Path
+//   - mount each input at the name specified by Path, or the contents of the
+//     archive if the input mounts an archive
+//   - mount the manifest of the inputs at storage.InputManifestPath
 //   - make a directory in the job results directory for each output and mount that
 //     at the name specified by Name
+//
+// The archives mounted are returned to be closed once the filesystem is no
+// longer used.
+//
+//nolint:funlen
 func (e *Executor) makeFsFromStorage(
 	ctx context.Context,
 	jobResultsDir string,
 	volumes map[*model.StorageSpec]storage.StorageVolume,
-	outputs []model.StorageSpec) (fs.FS, error) {
-	var err error
+	inputManifest string,
+	outputs []model.StorageSpec) (_ fs.FS, archives []archivefs.FS, err error) {
 	rootFs := mountfs.New()
+	defer func() {
+		if err != nil {
+			for _, archive := range archives {
+				closer.CloseWithLogOnError("archive input", archive)
+			}
+			archives = nil
+		}
+	}()
 
 	for input, volume := range volumes {
 		log.Ctx(ctx).Debug().
@@ -104,11 +166,22 @@ func (e *Executor) makeFsFromStorage(
 		var stat os.FileInfo
 		stat, err = os.Stat(volume.Source)
 		if err != nil {
-			return nil, err
+			return nil, archives, err
 		}
 
 		var inputFs fs.FS
-		if stat.IsDir() {
+		if input.MountArchive {
+			if stat.IsDir() {
+				return nil, archives, fmt.Errorf("input %s is a directory, not an archive", input.Name)
+			}
+			var archive archivefs.FS
+			archive, err = archivefs.Open(volume.Source)
+			if err != nil {
+				return nil, archives, fmt.Errorf("failed to mount the contents of input %s: %w", input.Name, err)
+			}
+			archives = append(archives, archive)
+			inputFs = archive
+		} else if stat.IsDir() {
 			inputFs = os.DirFS(volume.Source)
 		} else {
 			inputFs = filefs.New(volume.Source)
@@ -116,17 +189,22 @@ func (e *Executor) makeFsFromStorage(
 
 		err = rootFs.Mount(input.Path, inputFs)
 		if err != nil {
-			return nil, err
+			return nil, archives, err
 		}
 	}
 
+	err = rootFs.Mount(storage.InputManifestPath, filefs.New(inputManifest))
+	if err != nil {
+		return nil, archives, err
+	}
+
 	for _, output := range outputs {
 		if output.Name == "" {
-			return nil, fmt.Errorf("output volume has no name: %+v", output)
+			return nil, archives, fmt.Errorf("output volume has no name: %+v", output)
 		}
 
 		if output.Path == "" {
-			return nil, fmt.Errorf("output volume has no path: %+v", output)
+			return nil, archives, fmt.Errorf("output volume has no path: %+v", output)
 		}
 
 		srcd := filepath.Join(jobResultsDir, output.Name)
@@ -137,16 +215,16 @@ func (e *Executor) makeFsFromStorage(
 
 		err = os.Mkdir(srcd, util.OS_ALL_R|util.OS_ALL_X|util.OS_USER_W)
 		if err != nil {
-			return nil, err
+			return nil, archives, err
 		}
 
 		err = rootFs.Mount(output.Name, touchfs.New(srcd))
 		if err != nil {
-			return nil, err
+			return nil, archives, err
 		}
 	}
 
-	return rootFs, nil
+	return rootFs, archives, nil
 }
 
 //nolint:funlen
@@ -159,6 +237,7 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 	// Apply memory limits to the runtime. We have to do this in multiples of
 	// the WASM page size of 64kb, so round up to the nearest page size if the
 	// limit is not specified as a multiple of that.
+	var pageLimit uint64
 	if job.Spec.Resources.Memory != "" {
 		memoryLimit, err := datasize.ParseString(job.Spec.Resources.Memory)
 		if err != nil {
@@ -166,22 +245,20 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		}
 
 		const pageSize = 65536
-		pageLimit := memoryLimit.Bytes()/pageSize + system.Min(memoryLimit.Bytes()%pageSize, 1)
+		pageLimit = memoryLimit.Bytes()/pageSize + system.Min(memoryLimit.Bytes()%pageSize, 1)
 		engineConfig = engineConfig.WithMemoryLimitPages(uint32(pageLimit))
 	}
 
-	engine := tracedRuntime{wazero.NewRuntimeWithConfig(ctx, engineConfig)}
-	defer closer.ContextCloserWithLogOnError(ctx, "engine", engine)
-
+	fetchStart := time.Now()
 	inputVolumes, err := storage.ParallelPrepareStorage(ctx, e.StorageProvider, job.Spec.Inputs)
-	if err != nil {
-		return nil, err
-	}
+	// clean up the inputs that were prepared even if others failed or the execution was canceled
 	defer func() {
 		log.Ctx(ctx).Debug().
 			Str("Execution", executionID).
 			Msg("attempting cleanup of inputs for execution")
-		err := storage.ParallelCleanStorage(ctx, e.StorageProvider, inputVolumes)
+		cleanupCtx, cancel := pkgUtil.NewCleanupContext(ctx)
+		defer cancel()
+		err := storage.ParallelCleanStorage(cleanupCtx, e.StorageProvider, inputVolumes)
 		if err != nil {
 			log.Ctx(ctx).Error().
 				Err(err).
@@ -189,11 +266,27 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 				Msg("errors occurred when cleaning up inputs")
 		}
 	}()
+	if err != nil {
+		return nil, err
+	}
+	inputsFetchDuration := time.Since(fetchStart)
+	startupStart := time.Now()
 
-	rootFs, err := e.makeFsFromStorage(ctx, jobResultsDir, inputVolumes, job.Spec.Outputs)
+	inputManifest, err := storage.WriteInputManifest(inputVolumes)
+	if err != nil {
+		return executor.FailResult(fmt.Errorf("failed to write input manifest: %w", err))
+	}
+	defer os.Remove(inputManifest) //nolint:errcheck
+
+	rootFs, archives, err := e.makeFsFromStorage(ctx, jobResultsDir, inputVolumes, inputManifest, job.Spec.Outputs)
 	if err != nil {
 		return executor.FailResult(err)
 	}
+	defer func() {
+		for _, archive := range archives {
+			closer.CloseWithLogOnError("archive input", archive)
+		}
+	}()
 
 	// Create a new log manager and obtain some writers that we can pass to the wasm
 	// configuration
@@ -236,15 +329,45 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		config = config.WithEnv(key, job.Spec.Wasm.EnvironmentVariables[key])
 	}
 
+	// Take a runtime with the modules of the job already compiled from the warm pool if there is one ready, and
+	// create one otherwise.
+	create := e.runtimeCreator(engineConfig, jobModules(job))
+	var runtime *warmRuntime
+	var warmPoolResult model.WarmPoolResult
+	if key, ok := warmPoolKey(job, pageLimit); ok && e.warmPool != nil {
+		var hit bool
+		runtime, hit = e.warmPool.Take(ctx, key, create)
+		warmPoolResult = model.WarmPoolMiss
+		if hit {
+			warmPoolResult = model.WarmPoolHit
+		}
+	}
+	if runtime == nil {
+		runtime, err = create(ctx)
+		if err != nil {
+			return executor.FailResult(err)
+		}
+	}
+	engine := runtime.runtime
+	defer closer.ContextCloserWithLogOnError(ctx, "engine", engine)
+
+	// Meter the fuel consumed by the job. The meter is attached to the modules as they are compiled, and stops the
+	// job by canceling the context of the call once the fuel budget of the job is exhausted.
+	callCtx, exhaustFuel := context.WithCancel(ctx)
+	defer exhaustFuel()
+	fuel := runtime.meter
+	fuel.reset(capacity.ConvertFuelString(job.Spec.Resources.Fuel), exhaustFuel)
+	meteredCtx := withFuelMeter(callCtx, fuel)
+
 	// Load and instantiate imported modules
-	loader := NewModuleLoader(engine, config, e.StorageProvider)
+	loader := NewModuleLoader(engine, config, e.StorageProvider).WithCompiledModules(runtime.modules)
 	for _, importModule := range job.Spec.Wasm.ImportModules {
-		_, ierr := loader.InstantiateRemoteModule(ctx, importModule)
+		_, ierr := loader.InstantiateRemoteModule(meteredCtx, importModule)
 		err = multierr.Append(err, ierr)
 	}
 
 	// Load and instantiate the entry module.
-	instance, err := loader.InstantiateRemoteModule(ctx, job.Spec.Wasm.EntryModule)
+	instance, err := loader.InstantiateRemoteModule(meteredCtx, job.Spec.Wasm.EntryModule)
 	if err != nil {
 		return executor.FailResult(err)
 	}
@@ -259,11 +382,18 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		Str("execution", executionID).
 		Msg("Running WASM job")
 	entryFunc := instance.ExportedFunction(job.Spec.Wasm.EntryPoint)
+	startupDuration := time.Since(startupStart)
 	exitCode := -1
-	_, wasmErr := entryFunc.Call(ctx)
+	_, wasmErr := entryFunc.Call(meteredCtx)
 
 	var errExit *sys.ExitError
-	if errors.As(wasmErr, &errExit) {
+	if fuel.Exhausted() {
+		wasmErr = fmt.Errorf("job exhausted its fuel budget of %s", job.Spec.Resources.Fuel)
+	} else if errors.Is(ctx.Err(), context.Canceled) {
+		// the runtime closes the module as soon as the context is done, so a canceled job stops where it was. The
+		// output of a job that timed out is still collected.
+		return executor.FailResult(fmt.Errorf("execution canceled while running: %w", ctx.Err()))
+	} else if errors.As(wasmErr, &errExit) {
 		exitCode = int(errExit.ExitCode())
 		wasmErr = nil
 	}
@@ -273,17 +403,31 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 	logs.Drain()
 
 	stdoutReader, stderrReader := logs.GetDefaultReaders(false)
-	return executor.WriteJobResults(jobResultsDir, stdoutReader, stderrReader, exitCode, wasmErr)
+	result, err := executor.WriteJobResults(jobResultsDir, stdoutReader, stderrReader, exitCode, wasmErr)
+	if result != nil {
+		result.ResourceUsage = &model.ResourceUsageData{Fuel: fuel.Consumed()}
+		env := capacitysystem.Environment(ctx)
+		result.Environment = &env
+		result.InputsFetchDuration = inputsFetchDuration
+		result.StartupDuration = startupDuration
+		result.WarmPool = warmPoolResult
+	}
+	return result, err
 }
 
-func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
+func (e *Executor) GetOutputStream(
+	ctx context.Context,
+	executionID string,
+	withHistory bool,
+	follow bool,
+	tail int) (io.ReadCloser, error) {
 	logs, present := e.logManagers.Get(executionID)
 	if !present {
 		log.Ctx(ctx).Debug().Str("Execution", executionID).Msg("logmanager for wasm execution was already removed")
 		return nil, fmt.Errorf("logmanager has completed, no logs available")
 	}
 
-	return logs.GetMuxedReader(follow), nil
+	return logs.GetMuxedReader(withHistory, follow, tail), nil
 }
 
 // Compile-time check that Executor implements the Executor interface.
@@ -0,0 +1,63 @@
+package wasm
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// fuelMeter charges a unit of fuel for every function called by a WASM job, and stops the job once it has consumed
+// its budget. wazero does not meter individual instructions, so function calls are the finest measure of work we get.
+type fuelMeter struct {
+	budget   uint64 // zero for an unlimited budget
+	consumed atomic.Uint64
+	exhaust  context.CancelFunc
+}
+
+func newFuelMeter(budget uint64, exhaust context.CancelFunc) *fuelMeter {
+	return &fuelMeter{budget: budget, exhaust: exhaust}
+}
+
+// reset sets the budget of a meter that was compiled into the modules of a warm runtime ahead of the job that uses it.
+// It must be called before any function of the modules is called.
+func (m *fuelMeter) reset(budget uint64, exhaust context.CancelFunc) {
+	m.budget = budget
+	m.exhaust = exhaust
+	m.consumed.Store(0)
+}
+
+// withFuelMeter returns a context that attaches the meter to the modules compiled with it.
+func withFuelMeter(ctx context.Context, meter *fuelMeter) context.Context {
+	return context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, meter)
+}
+
+// Consumed returns the fuel consumed so far.
+func (m *fuelMeter) Consumed() uint64 {
+	return m.consumed.Load()
+}
+
+// Exhausted returns true if the job tried to consume more than its budget.
+func (m *fuelMeter) Exhausted() bool {
+	return m.budget > 0 && m.consumed.Load() > m.budget
+}
+
+// NewListener implements experimental.FunctionListenerFactory
+func (m *fuelMeter) NewListener(api.FunctionDefinition) experimental.FunctionListener {
+	return m
+}
+
+// Before implements experimental.FunctionListener
+func (m *fuelMeter) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64) context.Context {
+	if consumed := m.consumed.Add(1); m.budget > 0 && consumed > m.budget {
+		m.exhaust()
+	}
+	return ctx
+}
+
+// After implements experimental.FunctionListener
+func (m *fuelMeter) After(context.Context, api.Module, api.FunctionDefinition, error, []uint64) {}
+
+var _ experimental.FunctionListenerFactory = (*fuelMeter)(nil)
+var _ experimental.FunctionListener = (*fuelMeter)(nil)
@@ -0,0 +1,39 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuelMeterUnlimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	meter := newFuelMeter(0, cancel)
+
+	for i := 0; i < 100; i++ {
+		meter.Before(ctx, nil, nil, nil)
+	}
+	require.Equal(t, uint64(100), meter.Consumed())
+	require.False(t, meter.Exhausted())
+	require.NoError(t, ctx.Err())
+}
+
+func TestFuelMeterExhaustsBudget(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	meter := newFuelMeter(10, cancel)
+
+	for i := 0; i < 10; i++ {
+		meter.Before(ctx, nil, nil, nil)
+	}
+	require.False(t, meter.Exhausted())
+	require.NoError(t, ctx.Err())
+
+	meter.Before(ctx, nil, nil, nil)
+	require.True(t, meter.Exhausted())
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+}
@@ -29,6 +29,8 @@ type ModuleLoader struct {
 	runtime  wazero.Runtime
 	config   wazero.ModuleConfig
 	provider storage.StorageProvider
+	// compiled holds the modules compiled ahead of time, by name
+	compiled map[string]wazero.CompiledModule
 
 	// Runtime will throw an error if the same module is instantiated more than
 	// once. So we use this mutex around checking for modules and instantiating
@@ -39,6 +41,13 @@ func NewModuleLoader(runtime wazero.Runtime, config wazero.ModuleConfig, proivde
 	return &ModuleLoader{runtime: runtime, config: config, provider: proivder}
 }
 
+// WithCompiledModules makes the loader use modules that have already been compiled instead of loading them again,
+// such as the modules of a warm runtime. The modules are keyed by the name they are imported or instantiated with.
+func (loader *ModuleLoader) WithCompiledModules(modules map[string]wazero.CompiledModule) *ModuleLoader {
+	loader.compiled = modules
+	return loader
+}
+
 // Load comiples and returns a module located at the passed path.
 func (loader *ModuleLoader) Load(ctx context.Context, path string) (wazero.CompiledModule, error) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/executor/wasm.ModuleLoader.Load")
@@ -126,12 +135,15 @@ func (loader *ModuleLoader) InstantiateRemoteModule(ctx context.Context, spec mo
 		return module, nil
 	}
 
-	// Get the remote module.
-	modules, err := loader.LoadRemoteModules(ctx, spec)
-	if err != nil {
-		return nil, err
+	// Get the remote module, unless it has already been compiled.
+	module, compiled := loader.compiled[spec.Name]
+	if !compiled {
+		modules, err := loader.LoadRemoteModules(ctx, spec)
+		if err != nil {
+			return nil, err
+		}
+		module = modules[0]
 	}
-	module := modules[0]
 
 	// Examine its imports and recursively load them.
 	var wg multierrgroup.Group
@@ -142,7 +154,7 @@ func (loader *ModuleLoader) InstantiateRemoteModule(ctx context.Context, spec mo
 			return err
 		})
 	}
-	if err = wg.Wait(); err != nil {
+	if err := wg.Wait(); err != nil {
 		return nil, err
 	}
 
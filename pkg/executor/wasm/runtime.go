@@ -0,0 +1,65 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+)
+
+// warmRuntime is a runtime with the modules of a job already compiled into it, and the fuel meter attached to them.
+// Runtimes are created ahead of the jobs that use them when they come from the warm pool.
+type warmRuntime struct {
+	runtime tracedRuntime
+	modules map[string]wazero.CompiledModule
+	meter   *fuelMeter
+}
+
+// runtimeCreator returns a function that creates a runtime with the passed config, and compiles the passed modules
+// into it.
+func (e *Executor) runtimeCreator(engineConfig wazero.RuntimeConfig, specs []model.StorageSpec) warmpool.Creator[*warmRuntime] {
+	return func(ctx context.Context) (*warmRuntime, error) {
+		meter := newFuelMeter(0, func() {})
+		runtime := tracedRuntime{wazero.NewRuntimeWithConfig(ctx, engineConfig)}
+		loader := NewModuleLoader(runtime, nil, e.StorageProvider)
+
+		modules := make(map[string]wazero.CompiledModule, len(specs))
+		for _, spec := range specs {
+			compiled, err := loader.LoadRemoteModules(withFuelMeter(ctx, meter), spec)
+			if err != nil {
+				closer.ContextCloserWithLogOnError(ctx, "runtime", runtime)
+				return nil, err
+			}
+			modules[spec.Name] = compiled[0]
+		}
+		return &warmRuntime{runtime: runtime, modules: modules, meter: meter}, nil
+	}
+}
+
+// jobModules returns the modules of a job, with the entry module last.
+func jobModules(job model.Job) []model.StorageSpec {
+	modules := make([]model.StorageSpec, 0, len(job.Spec.Wasm.ImportModules)+1)
+	modules = append(modules, job.Spec.Wasm.ImportModules...)
+	return append(modules, job.Spec.Wasm.EntryModule)
+}
+
+// warmPoolKey returns the key of the warm runtimes that can run the job, which is made of the digests of its modules
+// and its memory limit in pages. Only jobs whose modules are all addressed by content can use warm runtimes, as the
+// module behind a URL may change between executions.
+func warmPoolKey(job model.Job, pageLimit uint64) (string, bool) {
+	modules := jobModules(job)
+	parts := make([]string, 0, len(modules)+1)
+	for _, module := range modules {
+		if module.CID == "" {
+			return "", false
+		}
+		parts = append(parts, module.Name+"="+module.CID)
+	}
+	parts = append(parts, fmt.Sprintf("pages=%d", pageLimit))
+	return strings.Join(parts, ","), true
+}
@@ -0,0 +1,31 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmPoolKey(t *testing.T) {
+	job := model.Job{Spec: model.Spec{Wasm: model.JobSpecWasm{
+		EntryModule:   model.StorageSpec{Name: "main.wasm", CID: "QmEntry"},
+		ImportModules: []model.StorageSpec{{Name: "QmLib", CID: "QmLib"}},
+	}}}
+
+	key, ok := warmPoolKey(job, 16)
+	require.True(t, ok)
+	require.Equal(t, "QmLib=QmLib,main.wasm=QmEntry,pages=16", key)
+
+	// runtimes are not shared between jobs with different memory limits
+	other, ok := warmPoolKey(job, 32)
+	require.True(t, ok)
+	require.NotEqual(t, key, other)
+
+	// modules downloaded from a URL may change, so their runtimes are not kept warm
+	job.Spec.Wasm.ImportModules = append(job.Spec.Wasm.ImportModules, model.StorageSpec{URL: "https://example.com/lib.wasm"})
+	_, ok = warmPoolKey(job, 16)
+	require.False(t, ok)
+}
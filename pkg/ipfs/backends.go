@@ -0,0 +1,114 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// DefaultBackendName is the name of the IPFS backend that a node is started with.
+const DefaultBackendName = "default"
+
+// Backend is an IPFS node that storage operations can be routed to.
+type Backend struct {
+	Name   string
+	Client Client
+	// Fetch is true if job inputs can be fetched from the backend.
+	Fetch bool
+	// Publish is true if job results can be published to the backend.
+	Publish bool
+}
+
+// NewDefaultBackend returns a backend that serves both fetches and publishes from the client.
+func NewDefaultBackend(client Client) Backend {
+	return Backend{Name: DefaultBackendName, Client: client, Fetch: true, Publish: true}
+}
+
+// ParseBackend parses a backend from a NAME[:ROLES]=MULTIADDR specification, where ROLES is a comma-separated list
+// of "fetch" and "publish", and defaults to both. It returns the backend without a client, and the API address of
+// the IPFS node to create one for.
+func ParseBackend(spec string) (Backend, string, error) {
+	nameAndRoles, address, found := strings.Cut(spec, "=")
+	if !found || address == "" {
+		return Backend{}, "", fmt.Errorf("invalid IPFS backend %q: expected NAME[:ROLES]=MULTIADDR", spec)
+	}
+
+	name, roles, hasRoles := strings.Cut(nameAndRoles, ":")
+	if name == "" {
+		return Backend{}, "", fmt.Errorf("invalid IPFS backend %q: missing name", spec)
+	}
+
+	backend := Backend{Name: name, Fetch: !hasRoles, Publish: !hasRoles}
+	if hasRoles {
+		for _, role := range strings.Split(roles, ",") {
+			switch strings.TrimSpace(role) {
+			case "fetch":
+				backend.Fetch = true
+			case "publish":
+				backend.Publish = true
+			default:
+				return Backend{}, "", fmt.Errorf("invalid IPFS backend %q: unknown role %q", spec, role)
+			}
+		}
+	}
+	return backend, address, nil
+}
+
+// NewBackend connects to the IPFS node of a backend specification, see ParseBackend.
+func NewBackend(ctx context.Context, spec string) (Backend, error) {
+	backend, address, err := ParseBackend(spec)
+	if err != nil {
+		return Backend{}, err
+	}
+	backend.Client, err = NewClientUsingRemoteHandler(ctx, address)
+	if err != nil {
+		return Backend{}, fmt.Errorf("IPFS backend %s: %w", backend.Name, err)
+	}
+	return backend, nil
+}
+
+// Backends is an ordered list of IPFS backends, e.g. a local daemon and an ipfs-cluster. Operations are tried on
+// each backend in order until one succeeds, so that a node fails over to the next backend when one is unavailable.
+type Backends []Backend
+
+// Fetchers returns the backends that job inputs can be fetched from.
+func (b Backends) Fetchers() Backends {
+	var res Backends
+	for _, backend := range b {
+		if backend.Fetch {
+			res = append(res, backend)
+		}
+	}
+	return res
+}
+
+// Publishers returns the backends that job results can be published to.
+func (b Backends) Publishers() Backends {
+	var res Backends
+	for _, backend := range b {
+		if backend.Publish {
+			res = append(res, backend)
+		}
+	}
+	return res
+}
+
+// Try calls f with each backend in order until it succeeds, and returns the backend that served the operation.
+func (b Backends) Try(f func(Backend) error) (Backend, error) {
+	if len(b) == 0 {
+		return Backend{}, errors.New("no IPFS backend is available for this operation")
+	}
+
+	var errs error
+	for _, backend := range b {
+		err := f(backend)
+		if err == nil {
+			return backend, nil
+		}
+		errs = multierr.Append(errs, fmt.Errorf("IPFS backend %s: %w", backend.Name, err))
+	}
+	return Backend{}, errs
+}
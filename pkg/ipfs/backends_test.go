@@ -0,0 +1,74 @@
+//go:build unit || !integration
+
+package ipfs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackend(t *testing.T) {
+	for _, tc := range []struct {
+		spec    string
+		backend Backend
+		address string
+	}{
+		{
+			spec:    "local=/ip4/127.0.0.1/tcp/5001",
+			backend: Backend{Name: "local", Fetch: true, Publish: true},
+			address: "/ip4/127.0.0.1/tcp/5001",
+		},
+		{
+			spec:    "cluster:publish=/dns4/cluster/tcp/9095",
+			backend: Backend{Name: "cluster", Publish: true},
+			address: "/dns4/cluster/tcp/9095",
+		},
+		{
+			spec:    "remote:fetch,publish=/ip4/10.0.0.1/tcp/5001",
+			backend: Backend{Name: "remote", Fetch: true, Publish: true},
+			address: "/ip4/10.0.0.1/tcp/5001",
+		},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			backend, address, err := ParseBackend(tc.spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.backend, backend)
+			require.Equal(t, tc.address, address)
+		})
+	}
+
+	for _, spec := range []string{"", "local", "=/ip4/127.0.0.1/tcp/5001", "local:pin=/ip4/127.0.0.1/tcp/5001"} {
+		_, _, err := ParseBackend(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestBackendsFailover(t *testing.T) {
+	backends := Backends{
+		{Name: "cluster", Publish: true},
+		{Name: "local", Fetch: true, Publish: true},
+	}
+	require.Equal(t, Backends{backends[1]}, backends.Fetchers())
+	require.Equal(t, backends, backends.Publishers())
+
+	var tried []string
+	served, err := backends.Publishers().Try(func(backend Backend) error {
+		tried = append(tried, backend.Name)
+		if backend.Name == "cluster" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "local", served.Name)
+	require.Equal(t, []string{"cluster", "local"}, tried)
+
+	_, err = backends.Try(func(Backend) error { return errors.New("unavailable") })
+	require.ErrorContains(t, err, "IPFS backend cluster")
+	require.ErrorContains(t, err, "IPFS backend local")
+
+	_, err = Backends{}.Try(func(Backend) error { return nil })
+	require.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package car
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-unixfsnode"
+	"github.com/ipfs/go-unixfsnode/data"
+	"github.com/ipfs/go-unixfsnode/file"
+	dagpb "github.com/ipld/go-codec-dagpb"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+// Extract writes the UnixFS DAG rooted at root to target, which must not exist yet. target becomes a directory if
+// the root is a directory, and a file otherwise. Every block of the DAG is loaded through the link system. Symlinks
+// that are absolute or lead outside of target are refused.
+func Extract(ctx context.Context, ls *ipld.LinkSystem, root cid.Cid, target string) error {
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("output path '%s' already exists", target)
+	}
+	return extractLink(ctx, ls, cidlink.Link{Cid: root}, target, target)
+}
+
+// extractLink writes the UnixFS node of the link to target, within the extraction root.
+func extractLink(ctx context.Context, ls *ipld.LinkSystem, link ipld.Link, target, root string) error {
+	n, err := ls.Load(ipld.LinkContext{Ctx: ctx}, link, basicnode.Prototype.Any)
+	if err != nil {
+		return err
+	}
+
+	// raw leaves hold the file contents directly
+	if n.Kind() == ipld.Kind_Bytes {
+		contents, err := n.AsBytes()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, contents, model.DownloadFilePerm)
+	}
+
+	builder := dagpb.Type.PBNode.NewBuilder()
+	if err = builder.AssignNode(n); err != nil {
+		return fmt.Errorf("%s is neither raw nor dag-pb: %w", link, err)
+	}
+	pbnode := builder.Build().(dagpb.PBNode) //nolint:errcheck // the builder always builds a PBNode
+	if !pbnode.FieldData().Exists() {
+		return fmt.Errorf("%s is not a UnixFS node", link)
+	}
+	ufsNode, err := data.DecodeUnixFSData(pbnode.FieldData().Must().Bytes())
+	if err != nil {
+		return err
+	}
+
+	switch ufsNode.DataType.Int() {
+	case data.Data_Directory, data.Data_HAMTShard:
+		return extractDir(ctx, ls, pbnode, target, root)
+	case data.Data_File, data.Data_Raw:
+		return extractFile(ctx, ls, pbnode, target)
+	case data.Data_Symlink:
+		return util.WriteSymlink(string(ufsNode.Data.Must().Bytes()), target, root)
+	default:
+		return fmt.Errorf("%s has unsupported UnixFS type %s", link, data.DataTypeNames[ufsNode.DataType.Int()])
+	}
+}
+
+func extractDir(ctx context.Context, ls *ipld.LinkSystem, pbnode dagpb.PBNode, target, root string) error {
+	dir, err := unixfsnode.Reify(ipld.LinkContext{Ctx: ctx}, pbnode, ls)
+	if err != nil {
+		return err
+	}
+	if err = os.Mkdir(target, model.DownloadFolderPerm); err != nil {
+		return err
+	}
+
+	entries := dir.MapIterator()
+	for !entries.Done() {
+		key, value, err := entries.Next()
+		if err != nil {
+			return err
+		}
+		name, err := key.AsString()
+		if err != nil {
+			return err
+		}
+		// names come from the DAG, so make sure they can't escape the target directory
+		if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+			return fmt.Errorf("invalid directory entry name %q", name)
+		}
+		link, err := value.AsLink()
+		if err != nil {
+			return err
+		}
+		if err = extractLink(ctx, ls, link, filepath.Join(target, name), root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(ctx context.Context, ls *ipld.LinkSystem, pbnode dagpb.PBNode, target string) error {
+	node, err := file.NewUnixFSFile(ctx, pbnode, ls)
+	if err != nil {
+		return err
+	}
+	// the reader loads the blocks of the file as it is read, so the file is written incrementally rather than held
+	// in memory
+	contents, err := node.AsLargeBytes()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, model.DownloadFilePerm)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError("file", f)
+
+	_, err = io.Copy(f, contents)
+	return err
+}
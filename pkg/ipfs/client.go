@@ -3,6 +3,7 @@ package ipfs
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 
@@ -188,6 +189,25 @@ func (cl Client) Put(ctx context.Context, inputPath string) (string, error) {
 	return cid, nil
 }
 
+// Unpin removes the pin on a CID, allowing its data to be removed from local storage by garbage collection.
+func (cl Client) Unpin(ctx context.Context, cid string) error {
+	if err := cl.API.Pin().Rm(ctx, icorepath.New(cid)); err != nil {
+		return fmt.Errorf("failed to unpin '%s': %w", cid, err)
+	}
+	return nil
+}
+
+// PutReader uploads and pins the data read from a stream as a single file to the ipfs network,
+// without writing it to the local filesystem first.
+func (cl Client) PutReader(ctx context.Context, r io.Reader) (string, error) {
+	// Pin uploaded file to local storage to prevent deletion by GC.
+	ipfsPath, err := cl.API.Unixfs().Add(ctx, files.NewReaderFile(r), icoreoptions.Unixfs.Pin(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to add stream: %w", err)
+	}
+	return ipfsPath.Cid().String(), nil
+}
+
 type IPLDType int
 
 const (
@@ -217,6 +237,15 @@ func (cl Client) Stat(ctx context.Context, cid string) (*StatResult, error) {
 	}, nil
 }
 
+// ResolveName returns the CID that an IPNS name, or a domain with a DNSLink record, currently points to.
+func (cl Client) ResolveName(ctx context.Context, name string) (string, error) {
+	resolved, err := cl.API.ResolvePath(ctx, icorepath.New("/ipns/"+name))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve IPNS name '%s': %w", name, err)
+	}
+	return resolved.Cid().String(), nil
+}
+
 func (cl Client) GetCidSize(ctx context.Context, cid string) (uint64, error) {
 	stat, err := cl.API.Object().Stat(ctx, icorepath.New(cid))
 	if err != nil {
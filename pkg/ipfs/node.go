@@ -11,6 +11,7 @@ import (
 
 	bac_config "github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/multiaddresses"
 	"github.com/hashicorp/go-multierror"
 	icore "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -91,6 +92,10 @@ type Config struct {
 	// KeypairSize is the number of bits to use for the node's repo keypair. If
 	// nil, then a default value of 2048 is used.
 	KeypairSize int
+
+	// NetworkStack is the IP versions the node listens on. If empty, local
+	// nodes listen on IPv4 only and other nodes listen on both.
+	NetworkStack multiaddresses.NetworkStack
 }
 
 func (cfg *Config) getKeypairSize() int {
@@ -105,6 +110,22 @@ func (cfg *Config) getMode() NodeMode {
 	return cfg.Mode
 }
 
+func (cfg *Config) getNetworkStack() multiaddresses.NetworkStack {
+	if cfg.NetworkStack != "" {
+		return cfg.NetworkStack
+	}
+	if cfg.getMode() == ModeLocal {
+		return multiaddresses.NetworkStackIPv4
+	}
+	return multiaddresses.NetworkStackDual
+}
+
+// listenAddresses returns multiaddresses to listen on a random TCP port on
+// every interface of the node's network stack.
+func (cfg *Config) listenAddresses() []string {
+	return cfg.getNetworkStack().Filter([]string{"/ip4/0.0.0.0/tcp/0", "/ip6/::/tcp/0"})
+}
+
 // NewNode creates a new IPFS node in default mode, which creates an IPFS
 // repo in a temporary directory, uses the public libp2p nodes as peers and
 // generates a repo keypair with 2048 bits.
@@ -119,21 +140,28 @@ func NewLocalNode(ctx context.Context, cm *system.CleanupManager, peerAddrs []st
 }
 
 func newNode(ctx context.Context, cm *system.CleanupManager, peerAddrs []string, mode NodeMode) (*Node, error) {
+	return NewNodeWithConfig(ctx, cm, Config{
+		Mode:      mode,
+		PeerAddrs: peerAddrs,
+	})
+}
+
+// NewNodeWithConfig creates a new IPFS node with the given configuration,
+// such as to only listen on IPv6.
+func NewNodeWithConfig(ctx context.Context, cm *system.CleanupManager, cfg Config) (*Node, error) {
 	// filter out any empty peer addresses
-	filteredPeerAddrs := make([]string, 0, len(peerAddrs))
-	for _, addr := range peerAddrs {
+	filteredPeerAddrs := make([]string, 0, len(cfg.PeerAddrs))
+	for _, addr := range cfg.PeerAddrs {
 		if addr != "" {
 			filteredPeerAddrs = append(filteredPeerAddrs, addr)
 		}
 	}
-	return newNodeWithConfig(ctx, cm, Config{
-		Mode:      mode,
-		PeerAddrs: filteredPeerAddrs,
-	})
+	cfg.PeerAddrs = filteredPeerAddrs
+	return newNodeWithConfig(ctx, cm, cfg)
 }
 
 // newNodeWithConfig creates a new IPFS node with the given configuration.
-// NOTE: use NewNode() or NewLocalNode() unless you know what you're doing.
+// NOTE: use NewNode(), NewLocalNode() or NewNodeWithConfig() unless you know what you're doing.
 func newNodeWithConfig(ctx context.Context, cm *system.CleanupManager, cfg Config) (*Node, error) {
 	var err error
 	pluginOnce.Do(func() {
@@ -411,16 +439,19 @@ func createRepo(path string, nodeConfig Config) error {
 		cfg.Swarm.RelayService.Enabled = config.False
 		cfg.Swarm.Transports.Network.Relay = config.False
 		cfg.Discovery.MDNS.Enabled = false
-		cfg.Addresses.Gateway = []string{"/ip4/0.0.0.0/tcp/0"}
-		cfg.Addresses.API = []string{"/ip4/0.0.0.0/tcp/0"}
-		cfg.Addresses.Swarm = []string{"/ip4/0.0.0.0/tcp/0"}
+		cfg.Addresses.Gateway = nodeConfig.listenAddresses()
+		cfg.Addresses.API = nodeConfig.listenAddresses()
+		cfg.Addresses.Swarm = nodeConfig.listenAddresses()
 	} else {
-		cfg.Addresses.API = []string{"/ip4/127.0.0.1/tcp/0"}
+		stack := nodeConfig.getNetworkStack()
+		cfg.Addresses.API = []string{multiaddresses.HostComponent(stack.LoopbackHost()) + "/tcp/0"}
+		cfg.Addresses.Gateway = stack.Filter(cfg.Addresses.Gateway)
+		cfg.Addresses.Swarm = stack.Filter(cfg.Addresses.Swarm)
 	}
 
 	preferredAddress := bac_config.PreferredAddress()
 	if preferredAddress != "" {
-		cfg.Addresses.Swarm = []string{fmt.Sprintf("/ip4/%s/tcp/0", preferredAddress)}
+		cfg.Addresses.Swarm = []string{multiaddresses.HostComponent(preferredAddress) + "/tcp/0"}
 	}
 
 	// establish peering with the passed nodes. This is different than bootstrapping or manually connecting to peers,
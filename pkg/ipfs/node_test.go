@@ -6,11 +6,14 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/multiaddresses"
+	icoreoptions "github.com/ipfs/interface-go-ipfs-core/options"
 	icorepath "github.com/ipfs/interface-go-ipfs-core/path"
 	"github.com/stretchr/testify/suite"
 )
@@ -99,7 +102,73 @@ func (s *NodeSuite) TestFunctionality() {
 	}, 500*time.Millisecond, 10*time.Millisecond, "a local node should never auto-discover anyone")
 }
 
+// TestIPv6LocalNode tests that local nodes on IPv6-only networks only listen on, and connect to each other over, IPv6.
+func (s *NodeSuite) TestIPv6LocalNode() {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(10*time.Second))
+	defer cancel()
+
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	n1, err := NewNodeWithConfig(ctx, cm, Config{Mode: ModeLocal, NetworkStack: multiaddresses.NetworkStackIPv6})
+	s.Require().NoError(err)
+
+	addrs, err := n1.SwarmAddresses()
+	s.Require().NoError(err)
+	s.Require().NotEmpty(addrs)
+	for _, addr := range addrs {
+		s.Require().True(strings.HasPrefix(addr, "/ip6/"), "expected an IPv6 swarm address, got %s", addr)
+	}
+
+	n2, err := NewNodeWithConfig(ctx, cm, Config{
+		Mode:         ModeLocal,
+		PeerAddrs:    addrs,
+		NetworkStack: multiaddresses.NetworkStackIPv6,
+	})
+	s.Require().NoError(err)
+
+	peers, err := n2.Client().API.Swarm().Peers(ctx)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(peers)
+	for _, p := range peers {
+		s.Require().Equal(n1.ID(), p.ID().String())
+		s.Require().True(strings.HasPrefix(p.Address().String(), "/ip6/"), "expected an IPv6 connection, got %s", p.Address())
+	}
+}
+
 // a normal test function and pass our suite to suite.Run
+// TestResolveName tests that the IPNS name of a node resolves to the CID published under it.
+func (s *NodeSuite) TestResolveName() {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(10*time.Second))
+	defer cancel()
+
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	n, err := NewLocalNode(ctx, cm, nil)
+	s.Require().NoError(err)
+	cl := n.Client()
+
+	filePath := filepath.Join(s.T().TempDir(), "test.txt")
+	s.Require().NoError(os.WriteFile(filePath, []byte(testString), 0644))
+	cid, err := cl.Put(ctx, filePath)
+	s.Require().NoError(err)
+
+	entry, err := cl.API.Name().Publish(ctx, icorepath.New(cid), icoreoptions.Name.AllowOffline(true))
+	s.Require().NoError(err)
+
+	resolved, err := cl.ResolveName(ctx, entry.Name())
+	s.Require().NoError(err)
+	s.Require().Equal(cid, resolved)
+
+	_, err = cl.ResolveName(ctx, "not-a-name.invalid")
+	s.Require().Error(err)
+}
+
 func TestNodeSuite(t *testing.T) {
 	suite.Run(t, new(NodeSuite))
 }
@@ -0,0 +1,166 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// AdmitJob runs the validation the requester performs when a job is submitted: the checks of VerifyJob, and that
+// the engine of the job has what it needs to run, its storage specs are well-formed and its resources can be parsed.
+// It returns a bacerrors.JobSpecInvalid listing every problem found, rather than only the first.
+func AdmitJob(ctx context.Context, j *model.Job) error {
+	var problems []bacerrors.JobSpecProblem
+	if err := VerifyJob(ctx, j); err != nil {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: "Spec", Message: err.Error()})
+	}
+	if err := VerifyJobName(j.Metadata.Name); err != nil {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: "Metadata.Name", Message: err.Error()})
+	}
+	problems = append(problems, engineProblems(j.Spec)...)
+	problems = append(problems, resourceProblems(j.Spec.Resources)...)
+	for i, input := range j.Spec.Inputs {
+		problems = append(problems, inputProblems(fmt.Sprintf("Spec.Inputs[%d]", i), input)...)
+	}
+	names := make(map[string]bool, len(j.Spec.Outputs))
+	for i, output := range j.Spec.Outputs {
+		field := fmt.Sprintf("Spec.Outputs[%d]", i)
+		problems = append(problems, outputProblems(field, output)...)
+		if output.Name != "" && names[output.Name] {
+			problems = append(problems, bacerrors.JobSpecProblem{
+				Field: field + ".Name", Message: fmt.Sprintf("output volume %s is defined more than once", output.Name)})
+		}
+		names[output.Name] = true
+	}
+	if j.Spec.Timeout < 0 {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: "Spec.Timeout", Message: "timeout can't be negative"})
+	}
+	if j.Spec.MaxRetries != nil && *j.Spec.MaxRetries < 0 {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: "Spec.MaxRetries", Message: "max retries can't be negative"})
+	}
+	if j.Spec.MaxRetriesPerShard != nil && *j.Spec.MaxRetriesPerShard < 0 {
+		problems = append(problems, bacerrors.JobSpecProblem{
+			Field: "Spec.MaxRetriesPerShard", Message: "max retries per shard can't be negative"})
+	}
+
+	if len(problems) > 0 {
+		return bacerrors.NewJobSpecInvalid(problems)
+	}
+	return nil
+}
+
+// VerifyEnvironmentVariables checks that the job only sets environment variables the policy allows. It returns a
+// bacerrors.JobSpecInvalid listing every variable that is refused, so that jobs compute nodes would not bid on are
+// rejected when they are submitted.
+func VerifyEnvironmentVariables(spec *model.Spec, policy model.EnvironmentVariablePolicy) error {
+	field := "Spec.EnvironmentVariables"
+	switch spec.Engine {
+	case model.EngineDocker:
+		field = "Spec.Docker.EnvironmentVariables"
+	case model.EngineProcess:
+		field = "Spec.Process.EnvironmentVariables"
+	case model.EngineWasm:
+		field = "Spec.Wasm.EnvironmentVariables"
+	}
+	var problems []bacerrors.JobSpecProblem
+	for _, name := range spec.EnvironmentVariableNames() {
+		if err := policy.Check(name); err != nil {
+			problems = append(problems, bacerrors.JobSpecProblem{Field: field, Message: err.Error()})
+		}
+	}
+	if len(problems) > 0 {
+		return bacerrors.NewJobSpecInvalid(problems)
+	}
+	return nil
+}
+
+func engineProblems(spec model.Spec) []bacerrors.JobSpecProblem {
+	switch spec.Engine {
+	case model.EngineDocker:
+		if spec.Docker.Image == "" {
+			return []bacerrors.JobSpecProblem{{Field: "Spec.Docker.Image", Message: "docker jobs must have an image"}}
+		}
+	case model.EngineWasm:
+		if !model.IsValidStorageSourceType(spec.Wasm.EntryModule.StorageSource) {
+			return []bacerrors.JobSpecProblem{{Field: "Spec.Wasm.EntryModule", Message: "wasm jobs must have an entry module"}}
+		}
+		return inputProblems("Spec.Wasm.EntryModule", spec.Wasm.EntryModule)
+	}
+	return nil
+}
+
+func resourceProblems(resources model.ResourceUsageConfig) []bacerrors.JobSpecProblem {
+	var problems []bacerrors.JobSpecProblem
+	if _, err := capacity.ParseCPUString(resources.CPU); err != nil {
+		problems = append(problems, bacerrors.JobSpecProblem{
+			Field: "Spec.Resources.CPU", Message: fmt.Sprintf("invalid number of CPUs %q, e.g. 0.5 or 500m", resources.CPU)})
+	}
+	for _, size := range []struct{ field, value string }{{"Memory", resources.Memory}, {"Disk", resources.Disk}} {
+		if _, err := capacity.ParseBytesString(size.value); err != nil {
+			problems = append(problems, bacerrors.JobSpecProblem{
+				Field: "Spec.Resources." + size.field, Message: fmt.Sprintf("invalid size %q, e.g. 500Mb or 2Gb", size.value)})
+		}
+	}
+	if resources.GPU != "" {
+		if _, err := strconv.ParseUint(resources.GPU, 10, 64); err != nil {
+			problems = append(problems, bacerrors.JobSpecProblem{
+				Field: "Spec.Resources.GPU", Message: fmt.Sprintf("invalid number of GPUs %q, e.g. 1", resources.GPU)})
+		}
+	}
+	return problems
+}
+
+// inputProblems checks that an input volume has what its storage source needs to fetch it.
+func inputProblems(field string, input model.StorageSpec) []bacerrors.JobSpecProblem {
+	problem := func(subfield, message string) []bacerrors.JobSpecProblem {
+		return []bacerrors.JobSpecProblem{{Field: field + "." + subfield, Message: message}}
+	}
+	switch input.StorageSource {
+	case model.StorageSourceIPFS:
+		if input.CID == "" && input.IPNSName == "" {
+			return problem("CID", "IPFS inputs must have a CID or an IPNS name")
+		}
+	case model.StorageSourceURLDownload:
+		if input.URL == "" {
+			return problem("URL", "URL inputs must have a URL")
+		}
+		// quotes and spaces around the URL are ignored when it is downloaded
+		if u, err := url.Parse(strings.Trim(input.URL, " '\"")); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return problem("URL", fmt.Sprintf("%q is not an http or https URL", input.URL))
+		}
+	case model.StorageSourceRepoClone, model.StorageSourceRepoCloneLFS:
+		if input.Repo == "" {
+			return problem("Repo", "git repository inputs must have the URL of the repository")
+		}
+	case model.StorageSourceInline:
+		if input.URL == "" {
+			return problem("URL", "inline inputs must have their data as a data URL")
+		}
+	case model.StorageSourceLocalDirectory:
+		if input.SourcePath == "" {
+			return problem("SourcePath", "local directory inputs must have the path of the directory")
+		}
+	}
+	return nil
+}
+
+// outputProblems checks that an output volume has what the executors need to collect it.
+func outputProblems(field string, output model.StorageSpec) []bacerrors.JobSpecProblem {
+	var problems []bacerrors.JobSpecProblem
+	if output.Name == "" {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: field + ".Name", Message: "output volumes must have a name"})
+	} else if !model.ValidFileName(output.Name) {
+		problems = append(problems, bacerrors.JobSpecProblem{
+			Field: field + ".Name", Message: fmt.Sprintf("output volume name %q must not be a path", output.Name)})
+	}
+	if output.Path == "" {
+		problems = append(problems, bacerrors.JobSpecProblem{Field: field + ".Path", Message: "output volumes must have a path"})
+	}
+	return problems
+}
@@ -0,0 +1,87 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmitJob(t *testing.T) {
+	newJob := func() *model.Job {
+		j, err := model.NewJobWithSaneProductionDefaults()
+		require.NoError(t, err)
+		j.Spec.Docker.Image = "ubuntu"
+		j.Spec.Resources = model.ResourceUsageConfig{CPU: "500m", Memory: "1Gi", Disk: "10 GB", GPU: "1"}
+		j.Spec.Inputs = []model.StorageSpec{
+			{StorageSource: model.StorageSourceIPFS, CID: "QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe", Path: "/inputs"},
+			{StorageSource: model.StorageSourceURLDownload, URL: "'https://example.com/data.csv'", Path: "/data"},
+		}
+		j.Spec.Outputs = []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, Name: "outputs", Path: "/outputs"}}
+		return j
+	}
+	require.NoError(t, AdmitJob(context.Background(), newJob()))
+
+	// inputs given by an IPNS name are resolved to a CID by the requester
+	j := newJob()
+	j.Spec.Inputs[0].CID = ""
+	j.Spec.Inputs[0].IPNSName = "docs.ipfs.tech"
+	require.NoError(t, AdmitJob(context.Background(), j))
+
+	j = newJob()
+	j.Spec.Docker.Image = ""
+	j.Spec.Resources = model.ResourceUsageConfig{CPU: "lots", Memory: "1 gigabyte", GPU: "one"}
+	j.Spec.Inputs[0].CID = ""
+	j.Spec.Inputs[1].URL = "ftp://example.com/data.csv"
+	j.Spec.Outputs = append(j.Spec.Outputs,
+		model.StorageSpec{Name: "outputs"}, model.StorageSpec{Name: "../..", Path: "/escape"})
+	j.Spec.Deal.Concurrency = 0
+
+	err := AdmitJob(context.Background(), j)
+	var invalid *bacerrors.JobSpecInvalid
+	require.True(t, errors.As(err, &invalid))
+	var fields []string
+	for _, problem := range invalid.GetProblems() {
+		fields = append(fields, problem.Field)
+	}
+	require.Equal(t, []string{
+		"Spec",
+		"Spec.Docker.Image",
+		"Spec.Resources.CPU",
+		"Spec.Resources.Memory",
+		"Spec.Resources.GPU",
+		"Spec.Inputs[0].CID",
+		"Spec.Inputs[1].URL",
+		"Spec.Outputs[1].Path",
+		"Spec.Outputs[1].Name",
+		"Spec.Outputs[2].Name",
+	}, fields)
+	require.Contains(t, err.Error(), "Spec.Resources.CPU: invalid number of CPUs \"lots\"")
+
+	// the problems are kept in the details of the response to the client
+	response := bacerrors.ErrorToErrorResponseObject(err)
+	require.Equal(t, bacerrors.ErrorCodeJobSpecInvalid, response.Code)
+	require.Len(t, response.Details["problems"], len(fields))
+}
+
+func TestVerifyEnvironmentVariables(t *testing.T) {
+	spec := &model.Spec{
+		Engine:  model.EngineProcess,
+		Process: model.JobSpecProcess{EnvironmentVariables: []string{"AWS_REGION=eu-west-1", "HOME=/", "SECRET=x"}},
+	}
+	require.NoError(t, VerifyEnvironmentVariables(spec, model.EnvironmentVariablePolicy{}))
+	require.NoError(t, VerifyEnvironmentVariables(spec, model.EnvironmentVariablePolicy{Allow: []string{"*"}}))
+
+	err := VerifyEnvironmentVariables(spec, model.EnvironmentVariablePolicy{Allow: []string{"AWS_*", "SECRET"}, Deny: []string{"SECRET"}})
+	var invalid *bacerrors.JobSpecInvalid
+	require.True(t, errors.As(err, &invalid), err)
+	require.Equal(t, []bacerrors.JobSpecProblem{
+		{Field: "Spec.Process.EnvironmentVariables", Message: "environment variable HOME is not allowed"},
+		{Field: "Spec.Process.EnvironmentVariables", Message: "environment variable SECRET is denied"},
+	}, invalid.GetProblems())
+}
@@ -0,0 +1,194 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/invopop/jsonschema"
+	"github.com/tidwall/sjson"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrInvalidJobDocument is returned when a job file does not match the schema of a job.
+type ErrInvalidJobDocument struct {
+	Errors []string
+}
+
+func (e ErrInvalidJobDocument) Error() string {
+	var sb strings.Builder
+	sb.WriteString("The Job is not valid. See errors:\n")
+	for _, err := range e.Errors {
+		fmt.Fprintf(&sb, "- %s\n", err)
+	}
+	return sb.String()
+}
+
+// JobJSONSchema returns the JSON schema of a job file. The fields of a job are optional, except its APIVersion, and
+// API versions and the names of engines, verifiers, publishers, storage sources and networks are matched case
+// insensitively, as they are when parsed.
+func JobJSONSchema() ([]byte, error) {
+	// the enums of the model are integers in Go, and their names in job files
+	enums := map[reflect.Type][]string{
+		reflect.TypeOf(model.Engine(0)):            model.EngineNames(),
+		reflect.TypeOf(model.Verifier(0)):          model.VerifierNames(),
+		reflect.TypeOf(model.Publisher(0)):         model.PublisherNames(),
+		reflect.TypeOf(model.StorageSourceType(0)): model.StorageSourceNames(),
+		reflect.TypeOf(model.Network(0)):           model.NetworkNames(),
+	}
+	reflector := jsonschema.Reflector{
+		RequiredFromJSONSchemaTags: true,
+		Mapper: func(t reflect.Type) *jsonschema.Schema {
+			if names, ok := enums[t]; ok {
+				return &jsonschema.Schema{Type: "string", Pattern: enumPattern(names)}
+			}
+			return nil
+		},
+	}
+	jsonSchemaData, err := model.JSONMarshalIndentWithMax(reflector.Reflect(&model.Job{}), 2)
+	if err != nil {
+		return nil, fmt.Errorf("error indenting %s", err)
+	}
+	jsonString := string(jsonSchemaData)
+
+	var apiVersions []string
+	for version := model.V1alpha1; version <= model.APIVersionLatest(); version++ {
+		apiVersions = append(apiVersions, version.String())
+	}
+	if jsonString, err = sjson.Set(jsonString, "$defs.Job.properties.APIVersion.pattern", enumPattern(apiVersions)); err != nil {
+		return nil, err
+	}
+	if jsonString, err = sjson.Set(jsonString, "$defs.Job.required", []string{"APIVersion"}); err != nil {
+		return nil, err
+	}
+	// profiles are partial jobs overlaid on the rest of the file, so they are only validated once applied
+	profiles := `{"type":"object","additionalProperties":{"type":"object"}}`
+	if jsonString, err = sjson.SetRaw(jsonString, "$defs.Job.properties."+ProfilesKey, profiles); err != nil {
+		return nil, err
+	}
+	return []byte(jsonString), nil
+}
+
+// enumPattern returns a regular expression matching any of the values case insensitively, as JSON schemas have no
+// case insensitive enums.
+func enumPattern(values []string) string {
+	alternatives := make([]string, 0, len(values))
+	for _, value := range values {
+		var sb strings.Builder
+		for _, r := range value {
+			lower, upper := strings.ToLower(string(r)), strings.ToUpper(string(r))
+			if lower == upper {
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+			} else {
+				fmt.Fprintf(&sb, "[%s%s]", lower, upper)
+			}
+		}
+		alternatives = append(alternatives, sb.String())
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// ValidateJobDocument validates a job file, in JSON or YAML, against the schema of a job, and returns
+// ErrInvalidJobDocument listing what does not match. The keys of the file are matched to the fields of a job case
+// insensitively, as they are when the job is parsed.
+func ValidateJobDocument(document []byte) error {
+	jsonSchemaData, err := JobJSONSchema()
+	if err != nil {
+		return err
+	}
+	var schema map[string]interface{}
+	if err = model.JSONUnmarshalWithMax(jsonSchemaData, &schema); err != nil {
+		return err
+	}
+
+	// JSON is also YAML
+	var value interface{}
+	if err = yaml.Unmarshal(document, &value); err != nil {
+		return fmt.Errorf("error parsing job: %w", err)
+	}
+	defs, _ := schema["$defs"].(map[string]interface{})
+	value = canonicalizeKeys(value, schema, defs)
+
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return fmt.Errorf("error validating job: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	invalid := ErrInvalidJobDocument{}
+	for _, desc := range result.Errors() {
+		invalid.Errors = append(invalid.Errors, desc.String())
+	}
+	return invalid
+}
+
+// canonicalizeKeys renames the keys of the objects in the value that match a property of their schema case
+// insensitively to the name of the property.
+func canonicalizeKeys(value interface{}, schema map[string]interface{}, defs map[string]interface{}) interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema, _ = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		canonical := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			name := key
+			if _, ok := properties[key]; !ok {
+				for property := range properties {
+					if strings.EqualFold(property, key) {
+						name = property
+						break
+					}
+				}
+			}
+			childSchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				childSchema, _ = schema["additionalProperties"].(map[string]interface{})
+			}
+			canonical[name] = canonicalizeKeys(child, childSchema, defs)
+		}
+		return canonical
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, child := range v {
+			v[i] = canonicalizeKeys(child, items, defs)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// ParseJobDocument validates a job file, in JSON or YAML, against the schema of a job and parses it over the same
+// defaults as the jobs constructed from command line flags, so that a file and the equivalent flags give the same job.
+func ParseJobDocument(ctx context.Context, document []byte) (*model.Job, error) {
+	if err := ValidateJobDocument(document); err != nil {
+		return nil, err
+	}
+	j, err := model.NewJobWithSaneProductionDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if err = model.YAMLUnmarshalWithMax(document, j); err != nil {
+		return nil, fmt.Errorf("error parsing job: %w", err)
+	}
+	normalizeJob(ctx, j)
+	return j, nil
+}
+
+// normalizeJob applies to a job parsed from a file what constructing a job from command line flags does: unsafe
+// annotations are dropped, and the legacy publisher is moved to the publisher spec.
+func normalizeJob(ctx context.Context, j *model.Job) {
+	j.Spec.Annotations = safeAnnotations(ctx, j.Spec.Annotations)
+	if !model.IsValidPublisher(j.Spec.PublisherSpec.Type) {
+		j.Spec.PublisherSpec = model.PublisherSpec{
+			Type: j.Spec.Publisher,
+		}
+	}
+}
@@ -0,0 +1,130 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestValidateJobDocumentTestdata(t *testing.T) {
+	for _, name := range []string{
+		"job.json", "job.yaml", "job-noop.json", "job-noop.yaml", "job-noop-url.yaml", "job-s3.yaml", "job-url.yaml",
+		"job-noop-profiles.yaml",
+	} {
+		document, err := os.ReadFile(filepath.Join("..", "..", "testdata", name))
+		require.NoError(t, err)
+		require.NoError(t, ValidateJobDocument(document), name)
+	}
+
+	document, err := os.ReadFile(filepath.Join("..", "..", "testdata", "job-noop-invalid.yml"))
+	require.NoError(t, err)
+	err = ValidateJobDocument(document)
+	var invalid ErrInvalidJobDocument
+	require.ErrorAs(t, err, &invalid)
+	require.Contains(t, err.Error(), "APIVersion is required")
+}
+
+func TestValidateJobDocumentErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		document string
+		error    string
+	}{
+		"unknown api version": {
+			document: "APIVersion: v2\nSpec:\n  Engine: docker\n",
+			error:    "APIVersion",
+		},
+		"unknown engine": {
+			document: "APIVersion: V1beta1\nSpec:\n  Engine: kubernetes\n",
+			error:    "Spec.Engine",
+		},
+		"unknown field": {
+			document: "APIVersion: V1beta1\nSpec:\n  Engine: docker\n  Imagee: ubuntu\n",
+			error:    "Additional property Imagee is not allowed",
+		},
+		"wrong type": {
+			document: `{"APIVersion": "V1beta1", "Spec": {"Deal": {"Concurrency": "one"}}}`,
+			error:    "Spec.Deal.Concurrency",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateJobDocument([]byte(test.document))
+			require.ErrorAs(t, err, &ErrInvalidJobDocument{})
+			require.Contains(t, err.Error(), test.error)
+		})
+	}
+}
+
+func TestParseJobDocumentMatchesFlags(t *testing.T) {
+	ctx := context.Background()
+	fromFlags, err := ConstructDockerJob(ctx,
+		model.V1beta1,
+		model.EngineDocker,
+		model.VerifierNoop,
+		model.PublisherSpec{Type: model.PublisherIpfs},
+		"1", "1Gb", "",
+		model.NetworkHTTP,
+		[]string{"example.com"},
+		[]model.StorageSpec{{StorageSource: model.StorageSourceURLDownload, URL: "https://example.com/data", Path: "/inputs"}},
+		[]string{"outputs:/outputs"},
+		[]string{"KEY=value"},
+		[]string{"echo", "hello"},
+		"ubuntu",
+		1, 0, 0,
+		300,
+		[]string{"team-a"},
+		"region=eu",
+		"/work",
+	)
+	require.NoError(t, err)
+
+	document, err := model.YAMLMarshalWithMax(fromFlags)
+	require.NoError(t, err)
+	fromFile, err := ParseJobDocument(ctx, document)
+	require.NoError(t, err)
+	require.Equal(t, fromFlags, fromFile)
+
+	// a job written with different cases and unsafe annotations gives the same job
+	fromFile, err = ParseJobDocument(ctx, []byte(`
+apiversion: V1beta1
+spec:
+  engine: docker
+  publisherSpec:
+    type: ipfs
+  docker:
+    image: ubuntu
+  annotations: ["team-a", "<script>"]
+`))
+	require.NoError(t, err)
+	require.Equal(t, model.PublisherIpfs, fromFile.Spec.PublisherSpec.Type)
+	require.Equal(t, []string{"team-a"}, fromFile.Spec.Annotations)
+	require.Equal(t, 1, fromFile.Spec.Deal.Concurrency, "defaults are applied")
+}
+
+func TestValidateJobDocumentDescribedJob(t *testing.T) {
+	j, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+	j.Metadata = model.Metadata{
+		ID:        "job-id",
+		ClientID:  "client-id",
+		CreatedAt: time.Now(),
+		Requester: model.JobRequester{RequesterNodeID: "node-id", RequesterPublicKey: []byte("key")},
+	}
+	j.Spec.Docker.Image = "ubuntu"
+	j.Spec.Outputs = []model.StorageSpec{{
+		StorageSource: model.StorageSourceIPFS,
+		Name:          "outputs",
+		Path:          "/outputs",
+		Publisher:     &model.PublisherSpec{Type: model.PublisherS3},
+	}}
+	document, err := model.YAMLMarshalWithMax(j)
+	require.NoError(t, err)
+	require.NoError(t, ValidateJobDocument(document), "jobs printed by describe can be created again")
+}
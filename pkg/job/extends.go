@@ -0,0 +1,113 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+// ExtendsKey is the top level key of a job file naming the job files it is based on.
+const ExtendsKey = "extends"
+
+// ResolveExtends merges the job files that a parsed job file extends under it, and returns the result without the
+// extends key, which is the job the file describes. The file read from path, or from stdin if path is empty or "-",
+// extends either a single file or a list of files, given by paths relative to the directory of the file, or to the
+// working directory for stdin. The files extended are resolved the same way, merged in the order they are listed,
+// and the file extending them is merged last. Values are merged like profiles: maps key by key, and any other value
+// replaces the value it is merged over, including lists. A file can't extend a file that extends it.
+func ResolveExtends(raw map[string]interface{}, path string) (map[string]interface{}, error) {
+	if path == "" || path == "-" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		return resolveExtends(raw, dir, nil)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveExtends(raw, filepath.Dir(abs), []string{abs})
+}
+
+// resolveExtends resolves the files the job file extends, relative to dir. chain is the files being resolved, from
+// the first to the one raw was read from.
+func resolveExtends(raw map[string]interface{}, dir string, chain []string) (map[string]interface{}, error) {
+	extendsKey := matchKey(raw, ExtendsKey)
+	extends, ok := raw[extendsKey]
+	if !ok {
+		return raw, nil
+	}
+	own := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		if key != extendsKey {
+			own[key] = value
+		}
+	}
+	paths, err := extendedPaths(extends)
+	if err != nil || len(paths) == 0 {
+		return own, err
+	}
+
+	merged := map[string]interface{}{}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path = filepath.Clean(path)
+		if slices.Contains(chain, path) {
+			return nil, fmt.Errorf("job files extend each other: %s", strings.Join(append(chain, path), " -> "))
+		}
+
+		base, err := readJobFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// the chain is copied so that resolving each file extended starts from the same chain
+		baseChain := append(slices.Clone(chain), path)
+		if base, err = resolveExtends(base, filepath.Dir(path), baseChain); err != nil {
+			return nil, err
+		}
+		merged = mergeOverlay(merged, base)
+	}
+	return mergeOverlay(merged, own), nil
+}
+
+// extendedPaths returns the paths of the value of the extends key, which is a path or a list of paths.
+func extendedPaths(extends interface{}) ([]string, error) {
+	switch v := extends.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		paths := make([]string, 0, len(v))
+		for _, item := range v {
+			path, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a path or a list of paths, got %v", ExtendsKey, item)
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("%s must be a path or a list of paths, got %v", ExtendsKey, extends)
+	}
+}
+
+// readJobFile parses the job file at path, in JSON or YAML.
+func readJobFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading extended job file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err = model.YAMLUnmarshalWithMax(content, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing extended job file %s: %w", path, err)
+	}
+	return raw, nil
+}
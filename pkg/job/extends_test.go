@@ -0,0 +1,106 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeJobFiles writes the job files, keyed by their path relative to a temporary directory, and returns the
+// directory.
+func writeJobFiles(t *testing.T, jobFiles map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range jobFiles {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestResolveExtends(t *testing.T) {
+	dir := writeJobFiles(t, map[string]string{
+		"shared/resources.yaml": `
+Spec:
+  Resources:
+    CPU: "1"
+    Memory: 1Gb
+  Annotations: [resources]
+`,
+		"shared/base.yaml": `
+extends: resources.yaml
+APIVersion: v1beta1
+Spec:
+  Engine: Docker
+  Resources:
+    Memory: 2Gb
+  Network:
+    Type: HTTP
+`,
+		"shared/publisher.json": `{"Spec": {"PublisherSpec": {"Type": "IPFS"}, "Annotations": ["publisher"]}}`,
+	})
+
+	resolved, err := ResolveExtends(map[string]interface{}{
+		"Extends": []interface{}{"shared/base.yaml", "shared/publisher.json"},
+		"spec": map[string]interface{}{
+			"Engine": "Wasm",
+		},
+	}, filepath.Join(dir, "job.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"APIVersion": "v1beta1",
+		"Spec": map[string]interface{}{
+			"Engine":        "Wasm",
+			"Annotations":   []interface{}{"publisher"},
+			"Resources":     map[string]interface{}{"CPU": "1", "Memory": "2Gb"},
+			"Network":       map[string]interface{}{"Type": "HTTP"},
+			"PublisherSpec": map[string]interface{}{"Type": "IPFS"},
+		},
+	}, resolved)
+}
+
+func TestResolveExtendsWithoutExtends(t *testing.T) {
+	raw := testJobFile()
+	resolved, err := ResolveExtends(raw, "job.yaml")
+	require.NoError(t, err)
+	require.Equal(t, raw, resolved)
+}
+
+func TestResolveExtendsSharedBase(t *testing.T) {
+	// files extending the same file is not a cycle
+	dir := writeJobFiles(t, map[string]string{
+		"common.yaml": `APIVersion: v1beta1`,
+		"a.yaml":      `{extends: common.yaml, Spec: {Engine: Docker}}`,
+		"b.yaml":      `{extends: common.yaml, Spec: {Verifier: Noop}}`,
+	})
+	resolved, err := ResolveExtends(map[string]interface{}{
+		"extends": []interface{}{"a.yaml", "b.yaml"},
+	}, filepath.Join(dir, "job.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"APIVersion": "v1beta1",
+		"Spec":       map[string]interface{}{"Engine": "Docker", "Verifier": "Noop"},
+	}, resolved)
+}
+
+func TestResolveExtendsCycle(t *testing.T) {
+	dir := writeJobFiles(t, map[string]string{
+		"job.yaml":  `extends: base.yaml`,
+		"base.yaml": `extends: ./job.yaml`,
+	})
+	_, err := ResolveExtends(map[string]interface{}{"extends": "base.yaml"}, filepath.Join(dir, "job.yaml"))
+	require.ErrorContains(t, err, "job files extend each other: "+
+		filepath.Join(dir, "job.yaml")+" -> "+filepath.Join(dir, "base.yaml")+" -> "+filepath.Join(dir, "job.yaml"))
+}
+
+func TestResolveExtendsInvalid(t *testing.T) {
+	_, err := ResolveExtends(map[string]interface{}{"extends": 3}, "job.yaml")
+	require.ErrorContains(t, err, "extends must be a path or a list of paths")
+
+	_, err = ResolveExtends(map[string]interface{}{"extends": "missing.yaml"}, filepath.Join(t.TempDir(), "job.yaml"))
+	require.ErrorContains(t, err, "error reading extended job file")
+}
@@ -45,21 +45,7 @@ func ConstructDockerJob( //nolint:funlen
 		return &model.Job{}, err
 	}
 
-	var jobAnnotations []string
-	var unSafeAnnotations []string
-	for _, a := range annotations {
-		if IsSafeAnnotation(a) && a != "" {
-			jobAnnotations = append(jobAnnotations, a)
-		} else {
-			unSafeAnnotations = append(unSafeAnnotations, a)
-		}
-	}
-
-	if len(unSafeAnnotations) > 0 {
-		log.Ctx(ctx).Error().Msgf("The following labels are unsafe. Labels must fit the regex '/%s/' (and all emjois): %+v",
-			RegexString,
-			strings.Join(unSafeAnnotations, ", "))
-	}
+	jobAnnotations := safeAnnotations(ctx, annotations)
 
 	nodeSelectorRequirements, err := ParseNodeSelector(nodeSelector)
 	if err != nil {
@@ -138,21 +124,7 @@ func ConstructLanguageJob(
 		return &model.Job{}, err
 	}
 
-	var jobAnnotations []string
-	var unSafeAnnotations []string
-	for _, a := range annotations {
-		if IsSafeAnnotation(a) && a != "" {
-			jobAnnotations = append(jobAnnotations, a)
-		} else {
-			unSafeAnnotations = append(unSafeAnnotations, a)
-		}
-	}
-
-	if len(unSafeAnnotations) > 0 {
-		log.Ctx(ctx).Error().Msgf("The following labels are unsafe. Labels must fit the regex '/%s/' (and all emjois): %+v",
-			RegexString,
-			strings.Join(unSafeAnnotations, ", "))
-	}
+	jobAnnotations := safeAnnotations(ctx, annotations)
 
 	j, err := model.NewJobWithSaneProductionDefaults()
 	if err != nil {
@@ -182,3 +154,23 @@ func ConstructLanguageJob(
 
 	return j, err
 }
+
+// safeAnnotations returns the annotations that are safe to add to a job, and logs the others.
+func safeAnnotations(ctx context.Context, annotations []string) []string {
+	var jobAnnotations []string
+	var unSafeAnnotations []string
+	for _, a := range annotations {
+		if IsSafeAnnotation(a) && a != "" {
+			jobAnnotations = append(jobAnnotations, a)
+		} else {
+			unSafeAnnotations = append(unSafeAnnotations, a)
+		}
+	}
+
+	if len(unSafeAnnotations) > 0 {
+		log.Ctx(ctx).Error().Msgf("The following labels are unsafe. Labels must fit the regex '/%s/' (and all emjois): %+v",
+			RegexString,
+			strings.Join(unSafeAnnotations, ", "))
+	}
+	return jobAnnotations
+}
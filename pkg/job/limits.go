@@ -0,0 +1,130 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/c2h5oh/datasize"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// SpecLimits are the maximum sizes of the parts of a job spec that a requester accepts.
+// A zero value means that part of the spec is not limited.
+type SpecLimits struct {
+	// MaxSpecSize is the maximum size of the whole spec, serialized as JSON.
+	MaxSpecSize datasize.ByteSize
+	// MaxInlinePayloadSize is the maximum total size of the data stored inline in the storage specs of the job.
+	MaxInlinePayloadSize datasize.ByteSize
+	// MaxEntrypointSize is the maximum total size of the entrypoint of the job and its arguments.
+	MaxEntrypointSize datasize.ByteSize
+	// MaxEnvSize is the maximum total size of the environment variables of the job.
+	MaxEnvSize datasize.ByteSize
+}
+
+var DefaultSpecLimits = SpecLimits{
+	MaxSpecSize:          8 * datasize.MB,
+	MaxInlinePayloadSize: 5 * datasize.MB,
+	MaxEntrypointSize:    64 * datasize.KB,
+	MaxEnvSize:           64 * datasize.KB,
+}
+
+// VerifySpecLimits checks that none of the parts of the job spec exceed the given limits. The returned error is
+// a *bacerrors.JobSpecTooLarge naming the part of the spec that is too large.
+func VerifySpecLimits(spec *model.Spec, limits SpecLimits) error {
+	if limits.MaxSpecSize > 0 {
+		specJSON, err := model.JSONMarshalWithMax(spec)
+		if err != nil {
+			return err
+		}
+		if err := checkLimit("spec", uint64(len(specJSON)), limits.MaxSpecSize); err != nil {
+			return err
+		}
+	}
+
+	var inlineSize uint64
+	for _, storageSpec := range spec.AllStorageSpecs() {
+		if storageSpec.StorageSource == model.StorageSourceInline {
+			inlineSize += uint64(len(storageSpec.URL))
+		}
+	}
+	if err := checkLimit("inline payload", inlineSize, limits.MaxInlinePayloadSize); err != nil {
+		return err
+	}
+
+	entrypointSize := totalSize(spec.Docker.Entrypoint) +
+		uint64(len(spec.Wasm.EntryPoint)) + totalSize(spec.Wasm.Parameters) +
+		uint64(len(spec.Language.Command)) + uint64(len(spec.Language.ProgramPath))
+	if err := checkLimit("entrypoint", entrypointSize, limits.MaxEntrypointSize); err != nil {
+		return err
+	}
+
+	envSize := totalSize(spec.Docker.EnvironmentVariables)
+	for key, value := range spec.Wasm.EnvironmentVariables {
+		envSize += uint64(len(key) + len(value))
+	}
+	return checkLimit("environment variables", envSize, limits.MaxEnvSize)
+}
+
+func checkLimit(field string, size uint64, limit datasize.ByteSize) error {
+	if limit > 0 && size > limit.Bytes() {
+		return bacerrors.NewJobSpecTooLarge(field, size, limit.Bytes())
+	}
+	return nil
+}
+
+func totalSize(values []string) (size uint64) {
+	for _, value := range values {
+		size += uint64(len(value))
+	}
+	return size
+}
+
+// PriorityRange is the range of priorities, inclusive, that a requester accepts jobs with. Priorities are set by
+// clients, and decide which jobs preempt others on compute nodes, so they are not left unbounded.
+// A zero value accepts any priority.
+type PriorityRange struct {
+	Min int
+	Max int
+}
+
+var DefaultPriorityRange = PriorityRange{Min: 0, Max: 100}
+
+// VerifyPriority checks that the priority of the job spec is within the given range.
+func VerifyPriority(spec *model.Spec, priorities PriorityRange) error {
+	if priorities == (PriorityRange{}) {
+		return nil
+	}
+	if spec.Priority < priorities.Min || spec.Priority > priorities.Max {
+		return fmt.Errorf("job priority %d is not within the range of %d to %d accepted by the requester",
+			spec.Priority, priorities.Min, priorities.Max)
+	}
+	return nil
+}
+
+// SubmissionPolicy is what a requester accepts in the jobs submitted to it, whichever way they are submitted.
+type SubmissionPolicy struct {
+	// SpecLimits are the maximum sizes of the parts of the job specs.
+	SpecLimits SpecLimits
+	// EnvPolicy is the environment variables the jobs can set.
+	EnvPolicy model.EnvironmentVariablePolicy
+	// PriorityRange is the priorities the jobs can have.
+	PriorityRange PriorityRange
+}
+
+// VerifySubmission runs the checks every submission path of the requester performs before submitting a job: that the
+// payload is valid, and that the spec is within the limits and policies of the requester. Specs that exceed the limits
+// are rejected with a *bacerrors.JobSpecTooLarge.
+func VerifySubmission(ctx context.Context, payload *model.JobCreatePayload, policy SubmissionPolicy) error {
+	if err := VerifyJobCreatePayload(ctx, payload); err != nil {
+		return err
+	}
+	if err := VerifySpecLimits(payload.Spec, policy.SpecLimits); err != nil {
+		return err
+	}
+	if err := VerifyEnvironmentVariables(payload.Spec, policy.EnvPolicy); err != nil {
+		return err
+	}
+	return VerifyPriority(payload.Spec, policy.PriorityRange)
+}
@@ -0,0 +1,92 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestVerifySpecLimits(t *testing.T) {
+	limits := SpecLimits{
+		MaxSpecSize:          4 * datasize.KB,
+		MaxInlinePayloadSize: 1 * datasize.KB,
+		MaxEntrypointSize:    100 * datasize.B,
+		MaxEnvSize:           100 * datasize.B,
+	}
+	large := strings.Repeat("a", 101)
+
+	for _, test := range []struct {
+		name  string
+		spec  model.Spec
+		field string
+	}{
+		{
+			name: "within limits",
+			spec: model.Spec{Docker: model.JobSpecDocker{Entrypoint: []string{"echo", "hello"}, EnvironmentVariables: []string{"A=B"}}},
+		},
+		{
+			name:  "docker entrypoint",
+			spec:  model.Spec{Docker: model.JobSpecDocker{Entrypoint: []string{"echo", large}}},
+			field: "entrypoint",
+		},
+		{
+			name:  "wasm parameters",
+			spec:  model.Spec{Wasm: model.JobSpecWasm{EntryPoint: "_start", Parameters: []string{large}}},
+			field: "entrypoint",
+		},
+		{
+			name:  "docker env",
+			spec:  model.Spec{Docker: model.JobSpecDocker{EnvironmentVariables: []string{"A=" + large}}},
+			field: "environment variables",
+		},
+		{
+			name:  "wasm env",
+			spec:  model.Spec{Wasm: model.JobSpecWasm{EnvironmentVariables: map[string]string{"A": large}}},
+			field: "environment variables",
+		},
+		{
+			name: "inline payload",
+			spec: model.Spec{Inputs: []model.StorageSpec{
+				{StorageSource: model.StorageSourceInline, URL: strings.Repeat("a", 600)},
+				{StorageSource: model.StorageSourceInline, URL: strings.Repeat("a", 600)},
+			}},
+			field: "inline payload",
+		},
+		{
+			name:  "whole spec",
+			spec:  model.Spec{Annotations: []string{strings.Repeat("a", 5000)}},
+			field: "spec",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySpecLimits(&test.spec, limits)
+			if test.field == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			tooLarge, ok := err.(*bacerrors.JobSpecTooLarge)
+			require.True(t, ok, "unexpected error %T", err)
+			require.Equal(t, test.field, tooLarge.GetField())
+			require.Equal(t, bacerrors.ErrorCodeJobSpecTooLarge, tooLarge.GetCode())
+		})
+	}
+
+	require.NoError(t, VerifySpecLimits(&model.Spec{Annotations: []string{strings.Repeat("a", 5000)}}, SpecLimits{}))
+}
+
+func TestVerifyPriority(t *testing.T) {
+	priorities := PriorityRange{Min: 0, Max: 10}
+	require.NoError(t, VerifyPriority(&model.Spec{Priority: 0}, priorities))
+	require.NoError(t, VerifyPriority(&model.Spec{Priority: 10}, priorities))
+	require.Error(t, VerifyPriority(&model.Spec{Priority: 11}, priorities))
+	require.Error(t, VerifyPriority(&model.Spec{Priority: -1}, priorities))
+	require.NoError(t, VerifyPriority(&model.Spec{Priority: 1000}, PriorityRange{}), "the zero range accepts any priority")
+}
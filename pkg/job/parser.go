@@ -22,12 +22,34 @@ func ParseStorageString(sourceURI, destinationPath string, options map[string]st
 		return model.StorageSpec{}, err
 	}
 
+	// mounting archives applies to inputs of any source, so it is taken out of the options specific to each source
+	mountArchive, options, err := parseMountArchiveOption(options)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+
 	var res model.StorageSpec
 	switch parsedURI.Scheme {
 	case "ipfs":
 		res = model.StorageSpec{
 			StorageSource: model.StorageSourceIPFS,
 			CID:           parsedURI.Host,
+			SubPath:       strings.Trim(parsedURI.Path, "/"),
+		}
+		if _, err = res.IPFSPath(); err != nil {
+			return model.StorageSpec{}, err
+		}
+	case "ipns":
+		res = model.StorageSpec{
+			StorageSource: model.StorageSourceIPFS,
+			IPNSName:      parsedURI.Host,
+			SubPath:       strings.Trim(parsedURI.Path, "/"),
+		}
+		if res.IPNSName == "" {
+			return model.StorageSpec{}, fmt.Errorf("%s does not give an IPNS name", sourceURI)
+		}
+		if _, err = res.IPFSPath(); err != nil {
+			return model.StorageSpec{}, err
 		}
 	case "http", "https":
 		u, err := urldownload.IsURLSupported(sourceURI)
@@ -104,9 +126,28 @@ func ParseStorageString(sourceURI, destinationPath string, options map[string]st
 	if res.Path == "" {
 		res.Path = defaultStoragePath
 	}
+	res.MountArchive = mountArchive
 	return res, nil
 }
 
+func parseMountArchiveOption(options map[string]string) (bool, map[string]string, error) {
+	mountArchive := false
+	rest := make(map[string]string, len(options))
+	for key, value := range options {
+		switch key {
+		case "mount-archive", "mount_archive", "mountarchive":
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return false, nil, fmt.Errorf("failed to parse mount-archive option: %s", err)
+			}
+			mountArchive = parsed
+		default:
+			rest[key] = value
+		}
+	}
+	return mountArchive, rest, nil
+}
+
 func ParsePublisherString(destinationURI string, options map[string]interface{}) (model.PublisherSpec, error) {
 	destinationURI = strings.Trim(destinationURI, " '\"")
 	parsedURI, err := url.Parse(destinationURI)
@@ -133,6 +174,10 @@ func ParsePublisherString(destinationURI string, options map[string]interface{})
 		res = model.PublisherSpec{
 			Type: model.PublisherEstuary,
 		}
+	case "local":
+		res = model.PublisherSpec{
+			Type: model.PublisherLocal,
+		}
 	case "s3":
 		if _, ok := options["bucket"]; !ok {
 			options["bucket"] = parsedURI.Host
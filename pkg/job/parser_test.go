@@ -40,6 +40,38 @@ func TestParseStorageString(t *testing.T) {
 				CID:           "QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA",
 			},
 		},
+		{
+			name:   "ipfs with sub-path",
+			source: "ipfs://QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA/path/to/subdir/",
+			expected: model.StorageSpec{
+				StorageSource: model.StorageSourceIPFS,
+				Name:          "ipfs://QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA/path/to/subdir/",
+				Path:          "/inputs",
+				CID:           "QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA",
+				SubPath:       "path/to/subdir",
+			},
+		},
+		{
+			name:   "ipfs with sub-path leaving the CID",
+			source: "ipfs://QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA/../QmOther",
+			error:  true,
+		},
+		{
+			name:   "ipns with sub-path",
+			source: "ipns://docs.ipfs.tech/path/to/subdir",
+			expected: model.StorageSpec{
+				StorageSource: model.StorageSourceIPFS,
+				Name:          "ipns://docs.ipfs.tech/path/to/subdir",
+				Path:          "/inputs",
+				IPNSName:      "docs.ipfs.tech",
+				SubPath:       "path/to/subdir",
+			},
+		},
+		{
+			name:   "ipns without a name",
+			source: "ipns:///path",
+			error:  true,
+		},
 		{
 			name:   "s3",
 			source: "s3://myBucket/dir/file-001.txt",
@@ -72,6 +104,43 @@ func TestParseStorageString(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "mount archive",
+			source:  "https://example.com/dataset.tar.gz",
+			options: map[string]string{"mount-archive": "true"},
+			expected: model.StorageSpec{
+				StorageSource: model.StorageSourceURLDownload,
+				Name:          "https://example.com/dataset.tar.gz",
+				Path:          "/inputs",
+				URL:           "https://example.com/dataset.tar.gz",
+				MountArchive:  true,
+			},
+		},
+		{
+			name:   "s3 mount archive",
+			source: "s3://myBucket/dataset.car",
+			options: map[string]string{
+				"region":        "us-east-1",
+				"mount_archive": "1",
+			},
+			expected: model.StorageSpec{
+				StorageSource: model.StorageSourceS3,
+				Name:          "s3://myBucket/dataset.car",
+				Path:          "/inputs",
+				S3: &model.S3StorageSpec{
+					Bucket: "myBucket",
+					Key:    "dataset.car",
+					Region: "us-east-1",
+				},
+				MountArchive: true,
+			},
+		},
+		{
+			name:    "invalid mount archive",
+			source:  "https://example.com/dataset.tar.gz",
+			options: map[string]string{"mount-archive": "sometimes"},
+			error:   true,
+		},
 		{
 			name:   "empty",
 			source: "",
@@ -131,6 +200,13 @@ func TestParsePublisherString(t *testing.T) {
 				Type: model.PublisherEstuary,
 			},
 		},
+		{
+			name:         "local",
+			publisherURI: "local",
+			expected: model.PublisherSpec{
+				Type: model.PublisherLocal,
+			},
+		},
 		{
 			name:         "s3",
 			publisherURI: "s3://myBucket/dir/file-001.txt",
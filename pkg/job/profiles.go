@@ -0,0 +1,90 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfilesKey is the top level key of a job file holding the profiles that can be overlaid on the rest of the file.
+const ProfilesKey = "profiles"
+
+// ApplyProfile overlays the named profile of a parsed job file on the rest of the file, and returns the result
+// without the profiles. Maps are merged key by key, and any other value of the profile replaces the value of the
+// base file, including lists. Keys are matched case insensitively, as they are when decoding a job. If profile is
+// empty, the profiles are removed without being applied.
+func ApplyProfile(raw map[string]interface{}, profile string) (map[string]interface{}, error) {
+	base := make(map[string]interface{}, len(raw))
+	var profiles map[string]interface{}
+	for key, value := range raw {
+		if !strings.EqualFold(key, ProfilesKey) {
+			base[key] = value
+			continue
+		}
+		var ok bool
+		if profiles, ok = value.(map[string]interface{}); !ok && value != nil {
+			return nil, fmt.Errorf("%s must be a map of profile names to job overlays", key)
+		}
+	}
+
+	if profile == "" {
+		return base, nil
+	}
+	overlay, ok := profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found in job file, available profiles: %v", profile, names)
+	}
+	overlayMap, ok := overlay.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profile %q must be a map", profile)
+	}
+	return mergeOverlay(base, overlayMap), nil
+}
+
+// mergeOverlay returns the base with the overlay merged over it: maps are merged key by key, and any other value of
+// the overlay replaces the value of the base. Neither map is modified.
+func mergeOverlay(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	// iterate over the overlay in a fixed order so that clashing keys always resolve the same way
+	keys := make([]string, 0, len(overlay))
+	for key := range overlay {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := overlay[key]
+		baseKey := matchKey(merged, key)
+		baseMap, baseIsMap := merged[baseKey].(map[string]interface{})
+		overlayMap, overlayIsMap := value.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			merged[baseKey] = mergeOverlay(baseMap, overlayMap)
+		} else {
+			merged[baseKey] = value
+		}
+	}
+	return merged
+}
+
+// matchKey returns the key of m matching key case insensitively, or key itself if there is none.
+func matchKey(m map[string]interface{}, key string) string {
+	if _, ok := m[key]; ok {
+		return key
+	}
+	match := key
+	for existing := range m {
+		if strings.EqualFold(existing, key) && (match == key || existing < match) {
+			match = existing
+		}
+	}
+	return match
+}
@@ -0,0 +1,62 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testJobFile() map[string]interface{} {
+	return map[string]interface{}{
+		"APIVersion": "v1beta1",
+		"Spec": map[string]interface{}{
+			"Engine":      "Docker",
+			"Annotations": []interface{}{"base"},
+			"Docker": map[string]interface{}{
+				"Image":      "ubuntu",
+				"Entrypoint": []interface{}{"echo", "hello"},
+			},
+		},
+		"profiles": map[string]interface{}{
+			"prod": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"Annotations": []interface{}{"prod"},
+					"Docker": map[string]interface{}{
+						"Image": "ubuntu:22.04",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	applied, err := ApplyProfile(testJobFile(), "prod")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"APIVersion": "v1beta1",
+		"Spec": map[string]interface{}{
+			"Engine":      "Docker",
+			"Annotations": []interface{}{"prod"},
+			"Docker": map[string]interface{}{
+				"Image":      "ubuntu:22.04",
+				"Entrypoint": []interface{}{"echo", "hello"},
+			},
+		},
+	}, applied)
+}
+
+func TestApplyNoProfileRemovesProfiles(t *testing.T) {
+	applied, err := ApplyProfile(testJobFile(), "")
+	require.NoError(t, err)
+	require.NotContains(t, applied, ProfilesKey)
+	require.Equal(t, "ubuntu", applied["Spec"].(map[string]interface{})["Docker"].(map[string]interface{})["Image"])
+}
+
+func TestApplyUnknownProfile(t *testing.T) {
+	_, err := ApplyProfile(testJobFile(), "staging")
+	require.ErrorContains(t, err, `profile "staging" not found`)
+	require.ErrorContains(t, err, "[prod]")
+}
@@ -0,0 +1,151 @@
+package job
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// SensitiveAnnotationPrefix marks annotations whose value is redacted when the job is shared outside the network,
+// such as in debug bundles, e.g. "sensitive:customer=acme".
+const SensitiveAnnotationPrefix = "sensitive:"
+
+// RedactedValue replaces the values that are redacted.
+const RedactedValue = "REDACTED"
+
+// Redact returns a copy of the job where the values that can hold secrets are replaced: the values of environment
+// variables, annotations marked sensitive, and credentials and query parameters in the URLs of inputs.
+func Redact(j model.Job) model.Job {
+	j.Spec.Docker.EnvironmentVariables = redactEnvironment(j.Spec.Docker.EnvironmentVariables)
+	j.Spec.Process.EnvironmentVariables = redactEnvironment(j.Spec.Process.EnvironmentVariables)
+	if j.Spec.Wasm.EnvironmentVariables != nil {
+		env := make(map[string]string, len(j.Spec.Wasm.EnvironmentVariables))
+		for key := range j.Spec.Wasm.EnvironmentVariables {
+			env[key] = RedactedValue
+		}
+		j.Spec.Wasm.EnvironmentVariables = env
+	}
+
+	if j.Spec.Annotations != nil {
+		annotations := make([]string, len(j.Spec.Annotations))
+		for i, annotation := range j.Spec.Annotations {
+			if strings.HasPrefix(annotation, SensitiveAnnotationPrefix) {
+				annotation = SensitiveAnnotationPrefix + RedactedValue
+			}
+			annotations[i] = annotation
+		}
+		j.Spec.Annotations = annotations
+	}
+
+	j.Spec.Inputs = redactStorageSpecs(j.Spec.Inputs)
+	j.Spec.Wasm.EntryModule.URL = redactURL(j.Spec.Wasm.EntryModule.URL)
+	j.Spec.Wasm.EntryModule.Repo = redactURL(j.Spec.Wasm.EntryModule.Repo)
+	j.Spec.Wasm.ImportModules = redactStorageSpecs(j.Spec.Wasm.ImportModules)
+	return j
+}
+
+// Secrets returns the values of the job that Redact replaces, so that they can also be replaced wherever else they
+// appear, such as in logs.
+func Secrets(j model.Job) []string {
+	var secrets []string
+	for _, env := range [][]string{j.Spec.Docker.EnvironmentVariables, j.Spec.Process.EnvironmentVariables} {
+		for _, variable := range env {
+			if _, value, ok := strings.Cut(variable, "="); ok {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	for _, value := range j.Spec.Wasm.EnvironmentVariables {
+		secrets = append(secrets, value)
+	}
+	for _, annotation := range j.Spec.Annotations {
+		if value, ok := strings.CutPrefix(annotation, SensitiveAnnotationPrefix); ok {
+			secrets = append(secrets, value)
+		}
+	}
+	urls := []string{j.Spec.Wasm.EntryModule.URL, j.Spec.Wasm.EntryModule.Repo}
+	for _, spec := range append(append([]model.StorageSpec(nil), j.Spec.Inputs...), j.Spec.Wasm.ImportModules...) {
+		urls = append(urls, spec.URL, spec.Repo)
+	}
+	for _, raw := range urls {
+		secrets = append(secrets, urlSecrets(raw)...)
+	}
+
+	// values that are too short would replace unrelated parts of what is redacted
+	nonTrivial := secrets[:0]
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			nonTrivial = append(nonTrivial, secret)
+		}
+	}
+	return nonTrivial
+}
+
+// minSecretLength is the length from which the values returned by Secrets are worth replacing.
+const minSecretLength = 4
+
+// urlSecrets returns the values of a URL that redactURL replaces.
+func urlSecrets(raw string) []string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	var secrets []string
+	if password, ok := u.User.Password(); ok {
+		secrets = append(secrets, password)
+	} else if u.User != nil {
+		secrets = append(secrets, u.User.Username())
+	}
+	for _, values := range u.Query() {
+		secrets = append(secrets, values...)
+	}
+	return secrets
+}
+
+func redactEnvironment(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	redacted := make([]string, len(env))
+	for i, variable := range env {
+		key, _, _ := strings.Cut(variable, "=")
+		redacted[i] = key + "=" + RedactedValue
+	}
+	return redacted
+}
+
+func redactStorageSpecs(specs []model.StorageSpec) []model.StorageSpec {
+	if specs == nil {
+		return nil
+	}
+	redacted := make([]model.StorageSpec, len(specs))
+	for i, spec := range specs {
+		spec.URL = redactURL(spec.URL)
+		spec.Repo = redactURL(spec.Repo)
+		redacted[i] = spec
+	}
+	return redacted
+}
+
+// redactURL replaces the password and query parameters of a URL, which are where credentials are usually passed, such
+// as in presigned URLs.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.User == nil && u.RawQuery == "") {
+		return raw
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), RedactedValue)
+	} else if u.User != nil {
+		u.User = url.User(RedactedValue)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{RedactedValue}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,107 @@
+// Package spool stores jobs created while the requester is unreachable, so that they can be submitted once
+// connectivity returns.
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	"github.com/google/uuid"
+)
+
+const (
+	fileExtension = ".json"
+	// nameTimeFormat sorts lexically in the order jobs were spooled.
+	nameTimeFormat = "20060102T150405.000000000Z"
+)
+
+// Entry is a job waiting in the spool.
+type Entry struct {
+	// Name identifies the entry, and orders entries by the time they were spooled.
+	Name string
+	Job  model.Job
+}
+
+// Spool is a directory of job documents, one file per job.
+type Spool struct {
+	dir string
+}
+
+func NewSpool(dir string) *Spool {
+	return &Spool{dir: dir}
+}
+
+// Add writes the job to the spool and returns the name of its entry. The file is written under a temporary name
+// and renamed into place, so that a partially written job is never submitted.
+func (s *Spool) Add(j *model.Job) (string, error) {
+	if err := os.MkdirAll(s.dir, util.OS_USER_RWX); err != nil {
+		return "", err
+	}
+	data, err := model.JSONMarshalIndentWithMax(j, 2) //nolint:gomnd
+	if err != nil {
+		return "", fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	name := time.Now().UTC().Format(nameTimeFormat) + "-" + uuid.NewString()[:8]
+	tmp, err := os.CreateTemp(s.dir, ".spool-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp.Name(), s.path(name)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// List returns the entries in the spool in the order they were added. There are no entries if the spool directory
+// does not exist.
+func (s *Spool) List() ([]Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || filepath.Ext(file.Name()) != fileExtension {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), fileExtension)
+		data, err := os.ReadFile(s.path(name))
+		if err != nil {
+			return nil, err
+		}
+		var j model.Job
+		if err = model.JSONUnmarshalWithMax(data, &j); err != nil {
+			return nil, fmt.Errorf("failed to decode spooled job %s: %w", name, err)
+		}
+		entries = append(entries, Entry{Name: name, Job: j})
+	}
+	sort.Slice(entries, func(i, k int) bool { return entries[i].Name < entries[k].Name })
+	return entries, nil
+}
+
+// Remove deletes the entry from the spool, once its job has been submitted.
+func (s *Spool) Remove(name string) error {
+	return os.Remove(s.path(name))
+}
+
+func (s *Spool) path(name string) string {
+	return filepath.Join(s.dir, name+fileExtension)
+}
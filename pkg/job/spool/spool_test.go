@@ -0,0 +1,57 @@
+//go:build unit || !integration
+
+package spool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool(t *testing.T) {
+	s := NewSpool(filepath.Join(t.TempDir(), "spool"))
+
+	entries, err := s.List()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	var names []string
+	for _, image := range []string{"ubuntu:22.04", "alpine@sha256:abc"} {
+		j, err := model.NewJobWithSaneProductionDefaults()
+		require.NoError(t, err)
+		j.Spec.Engine = model.EngineDocker
+		j.Spec.Docker.Image = image
+		name, err := s.Add(j)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+
+	entries, err = s.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, names, []string{entries[0].Name, entries[1].Name})
+	require.Equal(t, "ubuntu:22.04", entries[0].Job.Spec.Docker.Image)
+	require.Equal(t, "alpine@sha256:abc", entries[1].Job.Spec.Docker.Image)
+
+	require.NoError(t, s.Remove(entries[0].Name))
+	entries, err = s.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, names[1], entries[0].Name)
+}
+
+func TestSpoolIgnoresPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".spool-123"), []byte("{"), os.ModePerm))
+
+	entries, err := NewSpool(dir).List()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "corrupt.json"), []byte("{"), os.ModePerm))
+	_, err = NewSpool(dir).List()
+	require.ErrorContains(t, err, "corrupt")
+}
@@ -3,23 +3,34 @@ package job
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/maps"
 )
 
 type JobLoader func(ctx context.Context, id string) (model.Job, error)
 type StateLoader func(ctx context.Context, id string) (model.JobState, error)
 
+// StateWatcher returns a channel that receives a value whenever the state of the job may have changed. The channel is
+// closed once the context is done, or if the job can no longer be watched.
+type StateWatcher func(ctx context.Context, id string) (<-chan struct{}, error)
+
 // a function that is given a map of nodeid -> job states
 // and will throw an error if anything about that is wrong
 type CheckStatesFunction func(model.JobState) (bool, error)
 
+// maxPollInterval is the longest the state of a job goes without being checked while waiting for it, however long it
+// stays the same.
+const maxPollInterval = 2 * time.Second
+
 type StateResolver struct {
 	jobLoader       JobLoader
 	stateLoader     StateLoader
+	watcher         StateWatcher
 	maxWaitAttempts int
 	waitDelay       time.Duration
 }
@@ -44,11 +55,18 @@ func (resolver *StateResolver) GetJobState(ctx context.Context, id string) (mode
 	return resolver.stateLoader(ctx, id)
 }
 
+// SetWaitTime sets how long waiting for a job lasts, which is maxWaitAttempts times the delay, and how soon its state is
+// checked again after it changed.
 func (resolver *StateResolver) SetWaitTime(maxWaitAttempts int, delay time.Duration) {
 	resolver.maxWaitAttempts = maxWaitAttempts
 	resolver.waitDelay = delay
 }
 
+// SetWatcher makes waiting for jobs check their state as soon as it changes, rather than only polling for it.
+func (resolver *StateResolver) SetWatcher(watcher StateWatcher) {
+	resolver.watcher = watcher
+}
+
 func (resolver *StateResolver) GetExecutions(ctx context.Context, jobID string) ([]model.ExecutionState, error) {
 	jobState, err := resolver.stateLoader(ctx, jobID)
 	if err != nil {
@@ -133,55 +151,110 @@ type WaitOptions struct {
 	AllowAllTerminal bool
 }
 
+// WaitWithOptions checks the state of the job until the check functions are all met. The state is checked whenever the
+// watcher reports it changed, if there is one, and polled in case changes are missed. Polling starts at the wait delay
+// and backs off while the state stays the same, up to maxPollInterval.
 func (resolver *StateResolver) WaitWithOptions(
 	ctx context.Context,
 	options WaitOptions,
 	checkJobStateFunctions ...CheckStatesFunction,
 ) error {
-	waiter := &system.FunctionWaiter{
-		Name:        "wait for job",
-		MaxAttempts: resolver.maxWaitAttempts,
-		Delay:       resolver.waitDelay,
-		Handler: func() (bool, error) {
-			jobState, err := resolver.stateLoader(ctx, options.JobID)
-			if err != nil {
-				return false, err
-			}
+	timeout := time.Duration(resolver.maxWaitAttempts) * resolver.waitDelay
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var changes <-chan struct{}
+	if resolver.watcher != nil {
+		var err error
+		if changes, err = resolver.watcher(waitCtx, options.JobID); err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("failed to watch job %s, polling its state instead", options.JobID)
+		}
+	}
 
-			allOk := true
-			for _, checkFunction := range checkJobStateFunctions {
-				stepOk, checkErr := checkFunction(jobState)
-				if checkErr != nil {
-					return false, checkErr
-				}
-				if !stepOk {
-					allOk = false
-				}
+	interval := resolver.waitDelay
+	lastVersion := -1
+	for {
+		jobState, err := resolver.stateLoader(waitCtx, options.JobID)
+		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return fmt.Errorf("wait for job timed out after %s", timeout)
 			}
+			return err
+		}
+		done, err := checkJobState(ctx, jobState, options, checkJobStateFunctions)
+		if err != nil || done {
+			return err
+		}
 
-			if allOk {
-				return allOk, nil
-			}
+		// poll again soon after the state changed, as more changes tend to follow
+		if version := stateVersion(jobState); version != lastVersion {
+			lastVersion = version
+			interval = resolver.waitDelay
+		} else {
+			interval = system.Min(interval*2, system.Max(maxPollInterval, resolver.waitDelay))
+		}
 
-			// some of the check functions returned false
-			// let's see if we can quit early because all expected states are
-			// in terminal state
-			allTerminal, err := WaitForTerminalStates()(jobState)
-			if err != nil {
-				return false, err
+		timer := time.NewTimer(interval)
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
-
-			// If all the jobs are in terminal states, then nothing is going
-			// to change if we keep polling, so we should exit early.
-			if allTerminal && !options.AllowAllTerminal {
-				log.Ctx(ctx).Error().Msgf("all executions are in terminal state, but not all expected states are met: %+v", jobState)
-				return false, fmt.Errorf("all jobs are in terminal states and conditions aren't met")
+			return fmt.Errorf("wait for job timed out after %s", timeout)
+		case _, ok := <-changes:
+			if !ok {
+				// the job can no longer be watched, so only poll its state from now on
+				changes = nil
 			}
-			return false, nil
-		},
+		case <-timer.C:
+		}
+		timer.Stop()
 	}
+}
+
+// checkJobState returns true if the state of the job meets all the check functions, and an error if it never will.
+func checkJobState(
+	ctx context.Context, jobState model.JobState, options WaitOptions, checkJobStateFunctions []CheckStatesFunction) (bool, error) {
+	allOk := true
+	for _, checkFunction := range checkJobStateFunctions {
+		stepOk, checkErr := checkFunction(jobState)
+		if checkErr != nil {
+			return false, checkErr
+		}
+		if !stepOk {
+			allOk = false
+		}
+	}
+
+	if allOk {
+		return allOk, nil
+	}
+
+	// some of the check functions returned false
+	// let's see if we can quit early because all expected states are
+	// in terminal state
+	allTerminal, err := WaitForTerminalStates()(jobState)
+	if err != nil {
+		return false, err
+	}
+
+	// If all the jobs are in terminal states, then nothing is going
+	// to change if we keep polling, so we should exit early.
+	if allTerminal && !options.AllowAllTerminal {
+		log.Ctx(ctx).Error().Msgf("all executions are in terminal state, but not all expected states are met: %+v", jobState)
+		return false, fmt.Errorf("all jobs are in terminal states and conditions aren't met")
+	}
+	return false, nil
+}
 
-	return waiter.Wait(ctx)
+// stateVersion changes whenever the job or any of its executions changes.
+func stateVersion(jobState model.JobState) int {
+	version := jobState.Version + len(jobState.Executions)
+	for _, execution := range jobState.Executions {
+		version += execution.Version
+	}
+	return version
 }
 
 // this is an auto wait where we auto calculate how many execution
@@ -212,6 +285,15 @@ func (resolver *StateResolver) GetResults(ctx context.Context, jobID string) ([]
 			NodeID: executionState.NodeID,
 			Data:   executionState.PublishedResult,
 		})
+		volumes := maps.Keys(executionState.PublishedVolumes)
+		sort.Strings(volumes)
+		for _, volume := range volumes {
+			results = append(results, model.PublishedResult{
+				NodeID: executionState.NodeID,
+				Data:   executionState.PublishedVolumes[volume],
+				Volume: volume,
+			})
+		}
 	}
 
 	return results, nil
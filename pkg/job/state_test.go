@@ -0,0 +1,107 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetResultsWithPublishedVolumes(t *testing.T) {
+	main := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmMain"}
+	metrics := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmMetrics"}
+	weights := model.StorageSpec{StorageSource: model.StorageSourceS3, S3: &model.S3StorageSpec{Bucket: "models", Key: "weights/"}}
+
+	resolver := NewStateResolver(nil, func(context.Context, string) (model.JobState, error) {
+		return model.JobState{Executions: []model.ExecutionState{{
+			NodeID:             "node-1",
+			State:              model.ExecutionStateCompleted,
+			VerificationResult: model.VerificationResult{Complete: true, Result: true},
+			PublishedResult:    main,
+			PublishedVolumes:   map[string]model.StorageSpec{"weights": weights, "metrics": metrics},
+		}}}, nil
+	})
+
+	results, err := resolver.GetResults(context.Background(), "job")
+	require.NoError(t, err)
+	require.Equal(t, []model.PublishedResult{
+		{NodeID: "node-1", Data: main},
+		{NodeID: "node-1", Data: metrics, Volume: "metrics"},
+		{NodeID: "node-1", Data: weights, Volume: "weights"},
+	}, results)
+}
+
+// changingState is the state of a job that is changed by tests while it is waited for.
+type changingState struct {
+	mu    sync.Mutex
+	state model.JobState
+	loads int
+}
+
+func (s *changingState) load(context.Context, string) (model.JobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return s.state, nil
+}
+
+func (s *changingState) set(state model.JobStateType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.State = state
+	s.state.Version++
+}
+
+func TestWaitWakesUpOnChanges(t *testing.T) {
+	state := &changingState{state: model.JobState{State: model.JobStateInProgress, Version: 1}}
+	changes := make(chan struct{}, 1)
+	resolver := NewStateResolver(nil, state.load)
+	resolver.SetWatcher(func(context.Context, string) (<-chan struct{}, error) {
+		return changes, nil
+	})
+	// the state is only polled again after a minute, so waking up on time relies on the change being watched
+	resolver.SetWaitTime(10, time.Minute)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		state.set(model.JobStateCompleted)
+		changes <- struct{}{}
+	}()
+	start := time.Now()
+	require.NoError(t, resolver.WaitUntilComplete(context.Background(), "job"))
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestWaitPollsWithoutWatcher(t *testing.T) {
+	state := &changingState{state: model.JobState{State: model.JobStateInProgress, Version: 1}}
+	resolver := NewStateResolver(nil, state.load)
+	resolver.SetWatcher(func(context.Context, string) (<-chan struct{}, error) {
+		return nil, errors.New("can't watch")
+	})
+	resolver.SetWaitTime(500, 10*time.Millisecond)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		state.set(model.JobStateCompleted)
+	}()
+	require.NoError(t, resolver.WaitUntilComplete(context.Background(), "job"))
+}
+
+func TestWaitBacksOffAndTimesOut(t *testing.T) {
+	state := &changingState{state: model.JobState{State: model.JobStateInProgress, Version: 1}}
+	resolver := NewStateResolver(nil, state.load)
+	resolver.SetWaitTime(30, 10*time.Millisecond)
+
+	err := resolver.WaitUntilComplete(context.Background(), "job")
+	require.ErrorContains(t, err, "timed out")
+	// polling backs off while the state stays the same, rather than checking it every 10ms
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	require.Less(t, state.loads, 10)
+}
@@ -0,0 +1,64 @@
+package job
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// VarsKey is the key under which the variables a job file is rendered with are available to its placeholders.
+const VarsKey = "vars"
+
+// LoadTemplateVars returns the variables to render a job file with. The variables of the YAML or JSON values files
+// are merged in order, and the key=value assignments are merged over them. Keys of assignments can be dotted paths,
+// such as image.tag, to set a variable nested in a map. Variables are merged like profiles.
+func LoadTemplateVars(valuesFiles []string, assignments []string) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	for _, path := range valuesFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		var values map[string]interface{}
+		if err = model.YAMLUnmarshalWithMax(content, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+		}
+		vars = mergeOverlay(vars, values)
+	}
+
+	for _, assignment := range assignments {
+		key, value, found := strings.Cut(assignment, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("variable %q must be given as key=value", assignment)
+		}
+		var overlay interface{} = value
+		path := strings.Split(key, ".")
+		for i := len(path) - 1; i >= 0; i-- {
+			if path[i] == "" {
+				return nil, fmt.Errorf("variable %q has an empty key", assignment)
+			}
+			overlay = map[string]interface{}{path[i]: overlay}
+		}
+		vars = mergeOverlay(vars, overlay.(map[string]interface{}))
+	}
+	return vars, nil
+}
+
+// RenderTemplate renders a job file as a Go template, with the variables available under .vars, so that
+// {{ .vars.foo }} is replaced by the value of the variable foo. A placeholder for a variable that isn't set is an
+// error rather than an empty value, so that a missing variable isn't submitted as part of the job.
+func RenderTemplate(content []byte, vars map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New("job").Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse job template: %w", err)
+	}
+	var rendered bytes.Buffer
+	if err = tmpl.Execute(&rendered, map[string]interface{}{VarsKey: vars}); err != nil {
+		return nil, fmt.Errorf("failed to render job template: %w", err)
+	}
+	return rendered.Bytes(), nil
+}
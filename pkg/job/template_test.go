@@ -0,0 +1,75 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplateVars(t *testing.T) {
+	dir := writeJobFiles(t, map[string]string{
+		"defaults.yaml": `
+image:
+  name: ubuntu
+  tag: latest
+count: 1
+`,
+		"sweep.yaml": `
+image:
+  tag: "22.04"
+`,
+	})
+
+	vars, err := LoadTemplateVars(
+		[]string{filepath.Join(dir, "defaults.yaml"), filepath.Join(dir, "sweep.yaml")},
+		[]string{"count=3", "image.name=debian", "message=a=b"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{
+			"name": "debian",
+			"tag":  "22.04",
+		},
+		"count":   "3",
+		"message": "a=b",
+	}, vars)
+
+	for _, assignment := range []string{"count", "=3", "image..tag=1"} {
+		_, err = LoadTemplateVars(nil, []string{assignment})
+		require.Error(t, err, assignment)
+	}
+	_, err = LoadTemplateVars([]string{filepath.Join(dir, "missing.yaml")}, nil)
+	require.Error(t, err)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "22.04"},
+		"count": "3",
+	}
+
+	rendered, err := RenderTemplate([]byte(`
+Spec:
+  Docker:
+    Image: ubuntu:{{ .vars.image.tag }}
+  Deal:
+    Concurrency: {{ .vars.count }}
+`), vars)
+	require.NoError(t, err)
+	require.Equal(t, `
+Spec:
+  Docker:
+    Image: ubuntu:22.04
+  Deal:
+    Concurrency: 3
+`, string(rendered))
+
+	_, err = RenderTemplate([]byte(`Image: {{ .vars.missing }}`), vars)
+	require.ErrorContains(t, err, "missing")
+
+	_, err = RenderTemplate([]byte(`Image: {{ .vars.count `), vars)
+	require.Error(t, err)
+}
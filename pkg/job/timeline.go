@@ -0,0 +1,237 @@
+package job
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// TimelinePhase is a stage that an execution of a job goes through.
+type TimelinePhase string
+
+const (
+	TimelinePhaseQueued         TimelinePhase = "queued"
+	TimelinePhaseBidding        TimelinePhase = "bidding"
+	TimelinePhaseFetchingInputs TimelinePhase = "fetching inputs"
+	TimelinePhaseStarting       TimelinePhase = "starting"
+	TimelinePhaseRunning        TimelinePhase = "running"
+	TimelinePhaseVerifying      TimelinePhase = "verifying"
+	TimelinePhasePublishing     TimelinePhase = "publishing"
+)
+
+// TimelineSpan is the time an execution spent in a phase.
+type TimelineSpan struct {
+	Phase TimelinePhase
+	Start time.Time
+	End   time.Time
+	// Ongoing is true if the execution is still in the phase, in which case End is the time the timeline was computed.
+	Ongoing bool
+}
+
+func (s TimelineSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// ExecutionTimeline is the phases of a single execution of a job, in order.
+type ExecutionTimeline struct {
+	NodeID           string
+	ComputeReference string
+	State            model.ExecutionStateType
+	Spans            []TimelineSpan
+	// WarmPool tells whether the execution started from a warm instance, if the executor has a warm pool.
+	WarmPool model.WarmPoolResult
+}
+
+// Timeline is a breakdown of where the time of a job went, per execution.
+type Timeline struct {
+	JobID      string
+	Start      time.Time
+	End        time.Time
+	Executions []ExecutionTimeline
+}
+
+// timelinePhaseOf returns the phase an execution is in when it reaches a state, or false if the execution is over.
+func timelinePhaseOf(state model.ExecutionStateType) (TimelinePhase, bool) {
+	switch state {
+	case model.ExecutionStateNew, model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted:
+		return TimelinePhaseBidding, true
+	case model.ExecutionStateBidAccepted:
+		return TimelinePhaseRunning, true
+	case model.ExecutionStateResultProposed:
+		return TimelinePhaseVerifying, true
+	case model.ExecutionStateResultAccepted:
+		return TimelinePhasePublishing, true
+	default:
+		return "", false
+	}
+}
+
+// NewTimeline computes the timeline of a job from its event history. Executions are queued from the creation of the
+// job until they are first asked to bid, and the time spent fetching inputs and starting the job is taken from the start
+// of the running phase, as reported by the compute node. Phases that have not ended yet end at now.
+func NewTimeline(jobState model.JobState, events []model.JobHistory, now time.Time) Timeline {
+	events = append([]model.JobHistory(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	timeline := Timeline{
+		JobID: jobState.JobID,
+		Start: jobState.CreateTime,
+	}
+	for _, event := range events {
+		if event.Type == model.JobHistoryTypeJobLevel {
+			timeline.Start = event.Time
+			break
+		}
+	}
+
+	executionEvents := make(map[string][]model.JobHistory)
+	var references []string
+	for _, event := range events {
+		if event.Type != model.JobHistoryTypeExecutionLevel || event.ExecutionState == nil {
+			continue
+		}
+		if _, ok := executionEvents[event.ComputeReference]; !ok {
+			references = append(references, event.ComputeReference)
+		}
+		executionEvents[event.ComputeReference] = append(executionEvents[event.ComputeReference], event)
+	}
+
+	executions := make(map[string]model.ExecutionState, len(jobState.Executions))
+	for _, execution := range jobState.Executions {
+		executions[execution.ComputeReference] = execution
+	}
+
+	timeline.End = timeline.Start
+	for _, reference := range references {
+		execution := newExecutionTimeline(timeline.Start, executionEvents[reference], executions[reference], now)
+		for _, span := range execution.Spans {
+			if span.End.After(timeline.End) {
+				timeline.End = span.End
+			}
+		}
+		timeline.Executions = append(timeline.Executions, execution)
+	}
+	return timeline
+}
+
+func newExecutionTimeline(
+	jobStart time.Time, events []model.JobHistory, execution model.ExecutionState, now time.Time,
+) ExecutionTimeline {
+	first := events[0]
+	timeline := ExecutionTimeline{
+		NodeID:           first.NodeID,
+		ComputeReference: first.ComputeReference,
+		State:            events[len(events)-1].ExecutionState.New,
+	}
+	if first.Time.After(jobStart) {
+		timeline.Spans = append(timeline.Spans, TimelineSpan{Phase: TimelinePhaseQueued, Start: jobStart, End: first.Time})
+	}
+
+	var current *TimelineSpan
+	for _, event := range events {
+		phase, active := timelinePhaseOf(event.ExecutionState.New)
+		if current != nil && (!active || phase != current.Phase) {
+			current.End = event.Time
+			timeline.Spans = append(timeline.Spans, *current)
+			current = nil
+		}
+		if active && current == nil {
+			current = &TimelineSpan{Phase: phase, Start: event.Time}
+		}
+	}
+	if current != nil {
+		current.End = now
+		current.Ongoing = true
+		timeline.Spans = append(timeline.Spans, *current)
+	}
+
+	if execution.RunOutput != nil {
+		if execution.RunOutput.InputsFetchDuration > 0 {
+			timeline.Spans = splitRunning(timeline.Spans, TimelinePhaseFetchingInputs, execution.RunOutput.InputsFetchDuration)
+		}
+		if execution.RunOutput.StartupDuration > 0 {
+			timeline.Spans = splitRunning(timeline.Spans, TimelinePhaseStarting, execution.RunOutput.StartupDuration)
+		}
+		timeline.WarmPool = execution.RunOutput.WarmPool
+	}
+	return timeline
+}
+
+// splitRunning splits the start of the running phase off into the passed phase, such as the time spent fetching
+// inputs.
+func splitRunning(spans []TimelineSpan, phase TimelinePhase, duration time.Duration) []TimelineSpan {
+	for i, span := range spans {
+		if span.Phase != TimelinePhaseRunning {
+			continue
+		}
+		split := span.Start.Add(duration)
+		if split.After(span.End) {
+			split = span.End
+		}
+		before := TimelineSpan{Phase: phase, Start: span.Start, End: split}
+		spans[i].Start = split
+		return append(spans[:i], append([]TimelineSpan{before}, spans[i:]...)...)
+	}
+	return spans
+}
+
+const timelineBarWidth = 40
+
+// WriteText writes the timeline as a Gantt chart, with a bar per phase of each execution scaled to the whole job.
+func (t Timeline) WriteText(w io.Writer) error {
+	total := t.End.Sub(t.Start)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Job %s took %s\n", t.JobID, total.Round(time.Millisecond))
+	if len(t.Executions) == 0 {
+		b.WriteString("No executions yet\n")
+	}
+	for _, execution := range t.Executions {
+		state := execution.State.String()
+		if execution.WarmPool != "" {
+			state += fmt.Sprintf(", warm pool %s", execution.WarmPool)
+		}
+		fmt.Fprintf(&b, "\nExecution %s on node %s (%s)\n",
+			execution.ComputeReference, model.ShortID(execution.NodeID), state)
+		for _, span := range execution.Spans {
+			duration := span.Duration().Round(time.Millisecond).String()
+			if span.Ongoing {
+				duration += "+"
+			}
+			fmt.Fprintf(&b, "  %-16s %10s %10s  |%s|\n",
+				span.Phase,
+				span.Start.Sub(t.Start).Round(time.Millisecond),
+				duration,
+				timelineBar(t.Start, total, span))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// timelineBar draws the span as a bar, offset and scaled to where it falls in the job. Spans that are too short to
+// show at this scale are drawn a single character wide.
+func timelineBar(jobStart time.Time, total time.Duration, span TimelineSpan) string {
+	if total <= 0 {
+		return strings.Repeat("#", timelineBarWidth)
+	}
+	scale := func(t time.Time) int {
+		return int(float64(t.Sub(jobStart)) / float64(total) * timelineBarWidth)
+	}
+	start, end := scale(span.Start), scale(span.End)
+	if start >= timelineBarWidth {
+		start = timelineBarWidth - 1
+	}
+	if end <= start {
+		end = start + 1
+	}
+	if end > timelineBarWidth {
+		end = timelineBarWidth
+	}
+	return strings.Repeat(" ", start) + strings.Repeat("#", end-start) + strings.Repeat(" ", timelineBarWidth-end)
+}
@@ -0,0 +1,171 @@
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// TimelineFormat is a file format that a timeline can be exported to.
+type TimelineFormat string
+
+const (
+	// TimelineFormatChromeTrace is the Chrome trace event format, which can be loaded in chrome://tracing or Perfetto.
+	TimelineFormatChromeTrace TimelineFormat = "chrome"
+	// TimelineFormatOTLP is the JSON encoding of OpenTelemetry traces, which can be sent to any OTLP collector.
+	TimelineFormatOTLP TimelineFormat = "otlp"
+)
+
+// TimelineFormats lists the formats timelines can be exported to.
+var TimelineFormats = []TimelineFormat{TimelineFormatChromeTrace, TimelineFormatOTLP}
+
+// ParseTimelineFormat returns the timeline format with the given name.
+func ParseTimelineFormat(value string) (TimelineFormat, error) {
+	for _, format := range TimelineFormats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown timeline format %q, expected one of %v", value, TimelineFormats)
+}
+
+// Export encodes the timeline in the given format.
+func (t Timeline) Export(format TimelineFormat) ([]byte, error) {
+	switch format {
+	case TimelineFormatChromeTrace:
+		return t.chromeTrace()
+	case TimelineFormatOTLP:
+		return t.otlp()
+	default:
+		return nil, fmt.Errorf("unknown timeline format %q", format)
+	}
+}
+
+type chromeTraceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat,omitempty"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"`
+	Duration  int64             `json:"dur,omitempty"`
+	ProcessID int               `json:"pid"`
+	ThreadID  int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// chromeTrace encodes the timeline as complete events, with each execution on its own thread of a single process.
+func (t Timeline) chromeTrace() ([]byte, error) {
+	events := []chromeTraceEvent{{
+		Name:  "process_name",
+		Phase: "M",
+		Args:  map[string]string{"name": "Job " + t.JobID},
+	}}
+	for i, execution := range t.Executions {
+		tid := i + 1
+		events = append(events, chromeTraceEvent{
+			Name:     "thread_name",
+			Phase:    "M",
+			ThreadID: tid,
+			Args:     map[string]string{"name": fmt.Sprintf("%s on %s", execution.ComputeReference, model.ShortID(execution.NodeID))},
+		})
+		for _, span := range execution.Spans {
+			events = append(events, chromeTraceEvent{
+				Name:      string(span.Phase),
+				Category:  "execution",
+				Phase:     "X",
+				Timestamp: span.Start.UnixMicro(),
+				Duration:  span.Duration().Microseconds(),
+				ThreadID:  tid,
+				Args: map[string]string{
+					"NodeID":           execution.NodeID,
+					"ComputeReference": execution.ComputeReference,
+					"Ongoing":          fmt.Sprint(span.Ongoing),
+				},
+			})
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
+
+// otlp encodes the timeline as a trace with a root span for the job, a span per execution and a span per phase.
+// IDs are derived from the job ID and compute references, so exporting the same job twice updates the same trace.
+func (t Timeline) otlp() ([]byte, error) {
+	traceID := timelineID(16, t.JobID)
+	jobSpanID := timelineID(8, t.JobID)
+	spans := []*tracepb.Span{{
+		TraceId:           traceID,
+		SpanId:            jobSpanID,
+		Name:              "job",
+		Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: uint64(t.Start.UnixNano()),
+		EndTimeUnixNano:   uint64(t.End.UnixNano()),
+		Attributes:        []*commonpb.KeyValue{otlpAttribute("job.id", t.JobID)},
+	}}
+	for _, execution := range t.Executions {
+		if len(execution.Spans) == 0 {
+			continue
+		}
+		executionSpanID := timelineID(8, t.JobID, execution.ComputeReference)
+		attributes := []*commonpb.KeyValue{
+			otlpAttribute("job.id", t.JobID),
+			otlpAttribute("node.id", execution.NodeID),
+			otlpAttribute("execution.id", execution.ComputeReference),
+		}
+		spans = append(spans, &tracepb.Span{
+			TraceId:           traceID,
+			SpanId:            executionSpanID,
+			ParentSpanId:      jobSpanID,
+			Name:              "execution",
+			Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: uint64(execution.Spans[0].Start.UnixNano()),
+			EndTimeUnixNano:   uint64(execution.Spans[len(execution.Spans)-1].End.UnixNano()),
+			Attributes:        append(attributes, otlpAttribute("execution.state", execution.State.String())),
+		})
+		for _, span := range execution.Spans {
+			spans = append(spans, &tracepb.Span{
+				TraceId:           traceID,
+				SpanId:            timelineID(8, t.JobID, execution.ComputeReference, string(span.Phase)),
+				ParentSpanId:      executionSpanID,
+				Name:              string(span.Phase),
+				Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+				StartTimeUnixNano: uint64(span.Start.UnixNano()),
+				EndTimeUnixNano:   uint64(span.End.UnixNano()),
+				Attributes:        attributes,
+			})
+		}
+	}
+
+	return protojson.Marshal(&tracepb.TracesData{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{otlpAttribute("service.name", "bacalhau")},
+			},
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Scope: &commonpb.InstrumentationScope{Name: "bacalhau/timeline"},
+				Spans: spans,
+			}},
+		}},
+	})
+}
+
+func otlpAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+// timelineID derives a trace or span ID of the given size from the parts.
+func timelineID(size int, parts ...string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	return sum[:size]
+}
@@ -0,0 +1,197 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var timelineStart = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return timelineStart.Add(time.Duration(seconds) * time.Second)
+}
+
+func executionEvent(reference string, seconds int, previous, state model.ExecutionStateType) model.JobHistory {
+	return model.JobHistory{
+		Type:             model.JobHistoryTypeExecutionLevel,
+		JobID:            "timeline-job",
+		NodeID:           "node-" + reference,
+		ComputeReference: reference,
+		ExecutionState:   &model.StateChange[model.ExecutionStateType]{Previous: previous, New: state},
+		Time:             at(seconds),
+	}
+}
+
+func timelineFixture() (model.JobState, []model.JobHistory) {
+	jobState := model.JobState{
+		JobID:      "timeline-job",
+		CreateTime: at(0),
+		Executions: []model.ExecutionState{{
+			ComputeReference: "e-1",
+			RunOutput:        &model.RunCommandResult{InputsFetchDuration: 2 * time.Second},
+		}},
+	}
+	events := []model.JobHistory{
+		executionEvent("e-1", 10, model.ExecutionStateResultAccepted, model.ExecutionStateCompleted),
+		{
+			Type:     model.JobHistoryTypeJobLevel,
+			JobID:    "timeline-job",
+			JobState: &model.StateChange[model.JobStateType]{New: model.JobStateNew},
+			Time:     at(0),
+		},
+		executionEvent("e-1", 1, model.ExecutionStateNew, model.ExecutionStateAskForBid),
+		executionEvent("e-1", 2, model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted),
+		executionEvent("e-1", 3, model.ExecutionStateAskForBidAccepted, model.ExecutionStateBidAccepted),
+		executionEvent("e-1", 8, model.ExecutionStateBidAccepted, model.ExecutionStateResultProposed),
+		executionEvent("e-1", 9, model.ExecutionStateResultProposed, model.ExecutionStateResultAccepted),
+		executionEvent("e-2", 1, model.ExecutionStateNew, model.ExecutionStateAskForBid),
+		executionEvent("e-2", 4, model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted),
+		executionEvent("e-2", 5, model.ExecutionStateAskForBidAccepted, model.ExecutionStateBidAccepted),
+	}
+	return jobState, events
+}
+
+func TestNewTimeline(t *testing.T) {
+	jobState, events := timelineFixture()
+	timeline := NewTimeline(jobState, events, at(12))
+
+	require.Equal(t, at(0), timeline.Start)
+	require.Equal(t, at(12), timeline.End)
+	require.Len(t, timeline.Executions, 2)
+
+	completed := timeline.Executions[0]
+	require.Equal(t, "e-1", completed.ComputeReference)
+	require.Equal(t, model.ExecutionStateCompleted, completed.State)
+	require.Equal(t, []TimelineSpan{
+		{Phase: TimelinePhaseQueued, Start: at(0), End: at(1)},
+		{Phase: TimelinePhaseBidding, Start: at(1), End: at(3)},
+		{Phase: TimelinePhaseFetchingInputs, Start: at(3), End: at(5)},
+		{Phase: TimelinePhaseRunning, Start: at(5), End: at(8)},
+		{Phase: TimelinePhaseVerifying, Start: at(8), End: at(9)},
+		{Phase: TimelinePhasePublishing, Start: at(9), End: at(10)},
+	}, completed.Spans)
+
+	// executions that are still running end now, and don't know how long fetching inputs took yet
+	running := timeline.Executions[1]
+	require.Equal(t, []TimelineSpan{
+		{Phase: TimelinePhaseQueued, Start: at(0), End: at(1)},
+		{Phase: TimelinePhaseBidding, Start: at(1), End: at(5)},
+		{Phase: TimelinePhaseRunning, Start: at(5), End: at(12), Ongoing: true},
+	}, running.Spans)
+}
+
+func TestNewTimelineFailedExecution(t *testing.T) {
+	timeline := NewTimeline(model.JobState{JobID: "timeline-job", CreateTime: at(0)}, []model.JobHistory{
+		executionEvent("e-1", 0, model.ExecutionStateNew, model.ExecutionStateAskForBid),
+		executionEvent("e-1", 1, model.ExecutionStateAskForBid, model.ExecutionStateBidAccepted),
+		executionEvent("e-1", 4, model.ExecutionStateBidAccepted, model.ExecutionStateFailed),
+	}, at(10))
+
+	require.Equal(t, at(4), timeline.End)
+	require.Equal(t, model.ExecutionStateFailed, timeline.Executions[0].State)
+	require.Equal(t, []TimelineSpan{
+		{Phase: TimelinePhaseBidding, Start: at(0), End: at(1)},
+		{Phase: TimelinePhaseRunning, Start: at(1), End: at(4)},
+	}, timeline.Executions[0].Spans)
+}
+
+func TestNewTimelineStartup(t *testing.T) {
+	timeline := NewTimeline(model.JobState{
+		JobID:      "timeline-job",
+		CreateTime: at(0),
+		Executions: []model.ExecutionState{{
+			ComputeReference: "e-1",
+			RunOutput: &model.RunCommandResult{
+				InputsFetchDuration: 2 * time.Second,
+				StartupDuration:     time.Second,
+				WarmPool:            model.WarmPoolHit,
+			},
+		}},
+	}, []model.JobHistory{
+		executionEvent("e-1", 0, model.ExecutionStateNew, model.ExecutionStateAskForBid),
+		executionEvent("e-1", 1, model.ExecutionStateAskForBid, model.ExecutionStateBidAccepted),
+		executionEvent("e-1", 5, model.ExecutionStateBidAccepted, model.ExecutionStateCompleted),
+	}, at(10))
+
+	require.Equal(t, model.WarmPoolHit, timeline.Executions[0].WarmPool)
+	require.Equal(t, []TimelineSpan{
+		{Phase: TimelinePhaseBidding, Start: at(0), End: at(1)},
+		{Phase: TimelinePhaseFetchingInputs, Start: at(1), End: at(3)},
+		{Phase: TimelinePhaseStarting, Start: at(3), End: at(4)},
+		{Phase: TimelinePhaseRunning, Start: at(4), End: at(5)},
+	}, timeline.Executions[0].Spans)
+
+	var out strings.Builder
+	require.NoError(t, timeline.WriteText(&out))
+	require.Contains(t, out.String(), "Execution e-1 on node node-e-1 (Completed, warm pool hit)")
+}
+
+func TestTimelineWriteText(t *testing.T) {
+	jobState, events := timelineFixture()
+	var out strings.Builder
+	require.NoError(t, NewTimeline(jobState, events, at(12)).WriteText(&out))
+
+	text := out.String()
+	require.Contains(t, text, "Job timeline-job took 12s")
+	require.Contains(t, text, "Execution e-1 on node node-e-1 (Completed)")
+	require.Contains(t, text, "7s+")
+	for _, line := range strings.Split(text, "\n") {
+		if strings.Contains(line, "|") {
+			require.Len(t, line[strings.Index(line, "|"):], timelineBarWidth+2, line)
+		}
+	}
+}
+
+func TestTimelineExportChromeTrace(t *testing.T) {
+	jobState, events := timelineFixture()
+	exported, err := NewTimeline(jobState, events, at(12)).Export(TimelineFormatChromeTrace)
+	require.NoError(t, err)
+
+	var trace struct {
+		TraceEvents []chromeTraceEvent `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(exported, &trace))
+
+	var phases []string
+	for _, event := range trace.TraceEvents {
+		if event.Phase == "X" && event.ThreadID == 1 {
+			phases = append(phases, event.Name)
+			if event.Name == string(TimelinePhaseRunning) {
+				require.Equal(t, at(5).UnixMicro(), event.Timestamp)
+				require.Equal(t, (3 * time.Second).Microseconds(), event.Duration)
+			}
+		}
+	}
+	require.Equal(t, []string{"queued", "bidding", "fetching inputs", "running", "verifying", "publishing"}, phases)
+}
+
+func TestTimelineExportOTLP(t *testing.T) {
+	jobState, events := timelineFixture()
+	exported, err := NewTimeline(jobState, events, at(12)).Export(TimelineFormatOTLP)
+	require.NoError(t, err)
+
+	var traces tracepb.TracesData
+	require.NoError(t, protojson.Unmarshal(exported, &traces))
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	// a job span, a span for each execution and a span for each phase
+	require.Len(t, spans, 1+2+6+3)
+
+	ids := make(map[string]bool)
+	for _, span := range spans {
+		require.Equal(t, spans[0].TraceId, span.TraceId)
+		ids[string(span.SpanId)] = true
+	}
+	require.Len(t, ids, len(spans))
+
+	_, err = ParseTimelineFormat("svg")
+	require.Error(t, err)
+}
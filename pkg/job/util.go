@@ -86,6 +86,24 @@ func buildJobOutputs(ctx context.Context, outputVolumes []string) ([]model.Stora
 	return returnOutputVolumes, nil
 }
 
+// SetOutputPublishers sets the publishers of output volumes that are published on their own, by volume name.
+func SetOutputPublishers(j *model.Job, publishers map[string]model.PublisherSpec) error {
+	for name, publisher := range publishers {
+		found := false
+		for i := range j.Spec.Outputs {
+			if j.Spec.Outputs[i].Name == name {
+				publisher := publisher
+				j.Spec.Outputs[i].Publisher = &publisher
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("cannot set publisher of output volume %s as the job has no such output", name)
+		}
+	}
+	return nil
+}
+
 // ShortID shortens a Job ID e.g. `c42603b4-b418-4827-a9ca-d5a43338f2fe` to `c42603b4`
 func ShortID(id string) string {
 	if len(id) < model.ShortIDLength {
@@ -155,3 +155,18 @@ func (s *JobUtilSuite) TestStateSummary() {
 		})
 	}
 }
+
+func (s *JobUtilSuite) TestSetOutputPublishers() {
+	j := &model.Job{Spec: model.Spec{
+		PublisherSpec: model.PublisherSpec{Type: model.PublisherIpfs},
+		Outputs:       []model.StorageSpec{{Name: "outputs", Path: "/outputs"}, {Name: "model", Path: "/model"}},
+	}}
+	s3Publisher := model.PublisherSpec{Type: model.PublisherS3, Params: map[string]interface{}{"bucket": "models"}}
+
+	s.Require().NoError(SetOutputPublishers(j, map[string]model.PublisherSpec{"model": s3Publisher}))
+	s.Require().Nil(j.Spec.Outputs[0].Publisher)
+	s.Require().Equal(&s3Publisher, j.Spec.Outputs[1].Publisher)
+	s.Require().Equal([]model.Publisher{model.PublisherIpfs, model.PublisherS3}, j.Spec.AllPublishers())
+
+	s.Require().Error(SetOutputPublishers(j, map[string]model.PublisherSpec{"missing": s3Publisher}))
+}
@@ -3,12 +3,17 @@ package job
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"strings"
 
+	"github.com/Masterminds/semver"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-// VerifyJobCreatePayload verifies the values in a job creation request are legal.
+// VerifyJobCreatePayload verifies the values in a job creation request are legal, and admits its job with AdmitJob.
 func VerifyJobCreatePayload(ctx context.Context, jc *model.JobCreatePayload) error {
 	if jc.ClientID == "" {
 		return fmt.Errorf("ClientID is empty")
@@ -18,12 +23,27 @@ func VerifyJobCreatePayload(ctx context.Context, jc *model.JobCreatePayload) err
 		return fmt.Errorf("APIVersion is empty")
 	}
 
-	return VerifyJob(ctx, &model.Job{
+	return AdmitJob(ctx, &model.Job{
 		APIVersion: jc.APIVersion,
+		Metadata:   model.Metadata{Name: jc.Name},
 		Spec:       *jc.Spec,
 	})
 }
 
+// VerifyJobName verifies that a job can be given the name, which is used in place of its ID. Jobs need no name.
+func VerifyJobName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+		return fmt.Errorf("invalid job name %q: %s", name, strings.Join(errs, "; "))
+	}
+	if model.IsJobIDPrefix(name) {
+		return fmt.Errorf("invalid job name %q: it could be mistaken for the ID of a job", name)
+	}
+	return nil
+}
+
 // VerifyJob verifies that job object passed is valid.
 func VerifyJob(ctx context.Context, j *model.Job) error {
 	if reflect.DeepEqual(model.Spec{}, j.Spec) {
@@ -46,14 +66,56 @@ func VerifyJob(ctx context.Context, j *model.Job) error {
 		return fmt.Errorf("invalid executor type: %s", j.Spec.Engine.String())
 	}
 
+	if j.Spec.Engine == model.EngineProcess && len(j.Spec.Process.Entrypoint) == 0 {
+		return fmt.Errorf("the process engine requires an entrypoint")
+	}
+
+	if j.Spec.Interactive && j.Spec.Engine != model.EngineDocker {
+		return fmt.Errorf("only docker jobs can be interactive")
+	}
+
+	for _, jurisdiction := range j.Spec.Residency {
+		if jurisdiction == "" {
+			return fmt.Errorf("residency jurisdictions can't be empty")
+		}
+		if errs := validation.IsValidLabelValue(jurisdiction); len(errs) > 0 {
+			return fmt.Errorf("invalid residency jurisdiction %q: %s", jurisdiction, strings.Join(errs, "; "))
+		}
+	}
+
+	if requirements := j.Spec.EngineRequirements; requirements != nil {
+		if requirements.MinVersion != "" {
+			if _, err := semver.NewVersion(requirements.MinVersion); err != nil {
+				return fmt.Errorf("invalid engine min version %q: %w", requirements.MinVersion, err)
+			}
+		}
+		for _, feature := range requirements.Features {
+			if feature == "" {
+				return fmt.Errorf("engine features can't be empty")
+			}
+		}
+	}
+
 	if !model.IsValidVerifier(j.Spec.Verifier) {
 		return fmt.Errorf("invalid verifier type: %s", j.Spec.Verifier.String())
 	}
 
+	if j.Spec.Verifier == model.VerifierCommand {
+		if err := verifyVerifierCommand(j.Spec.VerifierCommand); err != nil {
+			return err
+		}
+	}
+
 	if !model.IsValidPublisher(j.Spec.PublisherSpec.Type) {
 		return fmt.Errorf("invalid publisher type: %s", j.Spec.PublisherSpec.Type.String())
 	}
 
+	for _, outputVolume := range j.Spec.Outputs {
+		if outputVolume.Publisher != nil && !model.IsValidPublisher(outputVolume.Publisher.Type) {
+			return fmt.Errorf("invalid publisher type for output volume %s: %s", outputVolume.Name, outputVolume.Publisher.Type.String())
+		}
+	}
+
 	if err := j.Spec.Network.IsValid(); err != nil {
 		return err
 	}
@@ -66,7 +128,93 @@ func VerifyJob(ctx context.Context, j *model.Job) error {
 		if !model.IsValidStorageSourceType(inputVolume.StorageSource) {
 			return fmt.Errorf("invalid input volume type: %s", inputVolume.StorageSource.String())
 		}
+		if inputVolume.MountArchive && j.Spec.Engine != model.EngineWasm {
+			return fmt.Errorf("only wasm jobs can mount the contents of archive inputs")
+		}
+		if inputVolume.MountArchive && inputVolume.ReadWrite {
+			return fmt.Errorf("the contents of archive inputs are read-only, so %s can't be mounted read-write", inputVolume.Name)
+		}
+	}
+
+	for _, contract := range j.Spec.OutputContracts {
+		if err := verifyOutputContract(contract); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyOutputContract(contract model.OutputContract) error {
+	if contract.Path == "" {
+		return fmt.Errorf("output contracts must have the path of the file they expect")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(contract.Path)) {
+		return fmt.Errorf("the path %s of an output contract must be relative to the results of the job", contract.Path)
+	}
+	if contract.JSONSchema != nil {
+		if _, err := NewOutputContractSchema(contract.JSONSchema); err != nil {
+			return fmt.Errorf("the JSON schema of the output contract for %s is invalid: %w", contract.Path, err)
+		}
 	}
+	for _, column := range contract.CSVColumns {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("the output contract for %s expects an empty CSV column name", contract.Path)
+		}
+	}
+	return nil
+}
+
+// NewOutputContractSchema compiles the JSON schema of an output contract. Only references within the schema itself
+// are followed, so that compiling a schema never reads local files or fetches URLs.
+func NewOutputContractSchema(schema interface{}) (*gojsonschema.Schema, error) {
+	return gojsonschema.NewSchema(localSchemaLoader{JSONLoader: gojsonschema.NewGoLoader(schema)})
+}
+
+// localSchemaLoader loads a schema, and refuses to load the other documents it references.
+type localSchemaLoader struct {
+	gojsonschema.JSONLoader
+}
 
+func (l localSchemaLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return refusingLoaderFactory{}
+}
+
+type refusingLoaderFactory struct{}
+
+func (refusingLoaderFactory) New(source string) gojsonschema.JSONLoader {
+	return refusedLoader{JSONLoader: gojsonschema.NewStringLoader(source), source: source}
+}
+
+// refusedLoader fails to load the document it is created for.
+type refusedLoader struct {
+	gojsonschema.JSONLoader
+	source string
+}
+
+func (l refusedLoader) LoadJSON() (interface{}, error) {
+	return nil, fmt.Errorf("references to other documents such as %s are not allowed", l.source)
+}
+
+func (l refusedLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return refusingLoaderFactory{}
+}
+
+func verifyVerifierCommand(spec *model.VerifierCommandSpec) error {
+	if spec == nil {
+		return fmt.Errorf("the command verifier requires a validation job")
+	}
+	switch spec.Engine {
+	case model.EngineDocker:
+		if spec.Docker.Image == "" {
+			return fmt.Errorf("the validation job of the command verifier has no image")
+		}
+	case model.EngineWasm:
+		if !model.IsValidStorageSourceType(spec.Wasm.EntryModule.StorageSource) {
+			return fmt.Errorf("the validation job of the command verifier has no entry module")
+		}
+	default:
+		return fmt.Errorf("the validation job of the command verifier must use the docker or wasm engine, not %s", spec.Engine)
+	}
 	return nil
 }
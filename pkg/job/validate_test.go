@@ -0,0 +1,210 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyJobOutputContracts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		contract model.OutputContract
+		valid    bool
+	}{
+		{name: "file", contract: model.OutputContract{Path: "outputs/result.json"}, valid: true},
+		{
+			name: "json schema",
+			contract: model.OutputContract{
+				Path:       "outputs/result.json",
+				JSONSchema: map[string]interface{}{"type": "object"},
+			},
+			valid: true,
+		},
+		{name: "csv columns", contract: model.OutputContract{Path: "table.csv", CSVColumns: []string{"id"}}, valid: true},
+		{name: "no path", contract: model.OutputContract{}},
+		{name: "absolute path", contract: model.OutputContract{Path: "/outputs/result.json"}},
+		{name: "path outside results", contract: model.OutputContract{Path: "../result.json"}},
+		{
+			name:     "invalid json schema",
+			contract: model.OutputContract{Path: "result.json", JSONSchema: map[string]interface{}{"type": 12}},
+		},
+		{
+			name: "local reference",
+			contract: model.OutputContract{Path: "result.json", JSONSchema: map[string]interface{}{
+				"definitions": map[string]interface{}{"id": map[string]interface{}{"type": "string"}},
+				"properties":  map[string]interface{}{"id": map[string]interface{}{"$ref": "#/definitions/id"}},
+			}},
+			valid: true,
+		},
+		{
+			name: "remote reference",
+			contract: model.OutputContract{Path: "result.json", JSONSchema: map[string]interface{}{
+				"properties": map[string]interface{}{"id": map[string]interface{}{"$ref": "http://169.254.169.254/latest"}},
+			}},
+		},
+		{
+			name: "file reference",
+			contract: model.OutputContract{Path: "result.json", JSONSchema: map[string]interface{}{
+				"$ref": "file:///etc/passwd",
+			}},
+		},
+		{name: "empty column", contract: model.OutputContract{Path: "table.csv", CSVColumns: []string{"id", " "}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = model.EngineDocker
+			j.Spec.Verifier = model.VerifierNoop
+			j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+			j.Spec.OutputContracts = []model.OutputContract{tc.contract}
+			err = VerifyJob(context.Background(), j)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJobInteractive(t *testing.T) {
+	for _, engine := range []model.Engine{model.EngineDocker, model.EngineWasm} {
+		t.Run(engine.String(), func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = engine
+			j.Spec.Verifier = model.VerifierNoop
+			j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+			j.Spec.Interactive = true
+			err = VerifyJob(context.Background(), j)
+			if engine == model.EngineDocker {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJobMountArchive(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		engine    model.Engine
+		readWrite bool
+		valid     bool
+	}{
+		{name: "wasm", engine: model.EngineWasm, valid: true},
+		{name: "docker", engine: model.EngineDocker},
+		{name: "read-write", engine: model.EngineWasm, readWrite: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = tc.engine
+			j.Spec.Verifier = model.VerifierNoop
+			j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+			j.Spec.Inputs = []model.StorageSpec{{
+				StorageSource: model.StorageSourceURLDownload,
+				URL:           "https://example.com/dataset.tar.gz",
+				Path:          "/inputs",
+				ReadWrite:     tc.readWrite,
+				MountArchive:  true,
+			}}
+			err = VerifyJob(context.Background(), j)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJobResidency(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		residency []string
+		valid     bool
+	}{
+		{name: "none", valid: true},
+		{name: "jurisdictions", residency: []string{"eu", "ch"}, valid: true},
+		{name: "empty jurisdiction", residency: []string{""}},
+		{name: "invalid jurisdiction", residency: []string{"eu west"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = model.EngineDocker
+			j.Spec.Verifier = model.VerifierNoop
+			j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+			j.Spec.Residency = tc.residency
+			err = VerifyJob(context.Background(), j)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJobName(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		valid bool
+	}{
+		{name: "", valid: true},
+		{name: "my-training-run", valid: true},
+		{name: "run.2", valid: true},
+		{name: "my training run"},
+		{name: "-run"},
+		{name: "4d3e2a1b"},
+		{name: "92d5d4ee-3765-4f78-8353-623f5f26df08"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyJobName(tc.name)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJobEngineRequirements(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		requirements *model.EngineRequirements
+		valid        bool
+	}{
+		{name: "none", valid: true},
+		{
+			name:         "version and features",
+			requirements: &model.EngineRequirements{MinVersion: "24.0", Features: []string{"nvidia-runtime"}},
+			valid:        true,
+		},
+		{name: "invalid version", requirements: &model.EngineRequirements{MinVersion: "latest"}},
+		{name: "empty feature", requirements: &model.EngineRequirements{Features: []string{""}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = model.EngineDocker
+			j.Spec.Verifier = model.VerifierNoop
+			j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+			j.Spec.EngineRequirements = tc.requirements
+			err = VerifyJob(context.Background(), j)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package jobstore
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
@@ -32,6 +33,20 @@ func (e ErrJobAlreadyExists) Error() string {
 	return "job already exists: " + e.JobID
 }
 
+// ErrJobNameTaken is returned when a job is given the name of another job of its namespace
+type ErrJobNameTaken struct {
+	Name  string
+	JobID string
+}
+
+func NewErrJobNameTaken(name string, id string) ErrJobNameTaken {
+	return ErrJobNameTaken{Name: name, JobID: id}
+}
+
+func (e ErrJobNameTaken) Error() string {
+	return fmt.Sprintf("job name %q is already taken by job %s", e.Name, e.JobID)
+}
+
 // ErrInvalidJobState is returned when an job is in an invalid state.
 type ErrInvalidJobState struct {
 	JobID    string
@@ -65,6 +80,21 @@ func (e ErrInvalidJobVersion) Error() string {
 	return fmt.Sprintf("job %s has version %d but expected %d", e.JobID, e.Actual, e.Expected)
 }
 
+// ErrInvalidJobSpecVersion is returned when the spec of a job was updated since the version an update is based on.
+type ErrInvalidJobSpecVersion struct {
+	JobID    string
+	Actual   int
+	Expected int
+}
+
+func NewErrInvalidJobSpecVersion(id string, actual int, expected int) ErrInvalidJobSpecVersion {
+	return ErrInvalidJobSpecVersion{JobID: id, Actual: actual, Expected: expected}
+}
+
+func (e ErrInvalidJobSpecVersion) Error() string {
+	return fmt.Sprintf("job %s has spec version %d but expected %d", e.JobID, e.Actual, e.Expected)
+}
+
 // ErrJobAlreadyTerminal is returned when an job is already in terminal state and cannot be updated.
 type ErrJobAlreadyTerminal struct {
 	JobID    string
@@ -154,3 +184,43 @@ func (e ErrExecutionAlreadyTerminal) Error() string {
 	return fmt.Sprintf("execution %s is in terminal state %s and cannot transition to %s",
 		e.ExecutionID, e.Actual.String(), e.NewState.String())
 }
+
+// ErrIncompatibleSnapshot is returned when a snapshot can't be imported by this release.
+type ErrIncompatibleSnapshot struct {
+	Reason string
+}
+
+func NewErrIncompatibleSnapshot(reason string) ErrIncompatibleSnapshot {
+	return ErrIncompatibleSnapshot{Reason: reason}
+}
+
+func (e ErrIncompatibleSnapshot) Error() string {
+	return "incompatible snapshot: " + e.Reason
+}
+
+// ErrSnapshotConflict is returned when jobs of a snapshot are already in the store it is imported into.
+type ErrSnapshotConflict struct {
+	JobIDs []string
+}
+
+func NewErrSnapshotConflict(jobIDs []string) ErrSnapshotConflict {
+	return ErrSnapshotConflict{JobIDs: jobIDs}
+}
+
+func (e ErrSnapshotConflict) Error() string {
+	return fmt.Sprintf("%d jobs of the snapshot already exist: %s", len(e.JobIDs), strings.Join(e.JobIDs, ", "))
+}
+
+// ErrInvalidJobRecord is returned when an imported job record is not consistent.
+type ErrInvalidJobRecord struct {
+	JobID  string
+	Reason string
+}
+
+func NewErrInvalidJobRecord(id string, reason string) ErrInvalidJobRecord {
+	return ErrInvalidJobRecord{JobID: id, Reason: reason}
+}
+
+func (e ErrInvalidJobRecord) Error() string {
+	return "invalid record of job " + e.JobID + ": " + e.Reason
+}
@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -11,28 +12,65 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
-	jobutils "github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/resolver"
 )
 
-const newJobComment = "Job created"
+const (
+	newJobComment = "Job created"
+
+	// DefaultSnapshotInterval is the number of events appended to the log of a job
+	// between two snapshots of its state.
+	DefaultSnapshotInterval = 32
+	// DefaultMaxSnapshots is the most snapshots kept for a job. Older states of a job
+	// with more snapshots are rebuilt from the oldest snapshot kept, or from the start
+	// of its log.
+	DefaultMaxSnapshots = 16
+)
+
+// jobEvent is a single entry in the append-only log of a job. The state of a job
+// is never mutated directly, but derived by replaying its events on top of the
+// most recent snapshot.
+type jobEvent struct {
+	history model.JobHistory
+	// execution holds the resulting execution for execution level events
+	execution model.ExecutionState
+	// recordedAt is when the event was appended to the log
+	recordedAt time.Time
+}
+
+// jobSnapshot is the state of a job after the first offset events of its log were applied.
+type jobSnapshot struct {
+	offset int
+	state  model.JobState
+}
 
 type JobStore struct {
-	// we keep pointers to these things because we will update them partially
 	jobs       map[string]model.Job
-	states     map[string]model.JobState
-	history    map[string][]model.JobHistory
+	events     map[string][]jobEvent
+	snapshots  map[string][]jobSnapshot
+	states     map[string]model.JobState // latest state of each job, derived from its events
 	inprogress map[string]struct{}
+	labels     map[string]map[string]struct{} // job IDs by annotation, to search jobs by label
+	watchers   map[string]map[chan struct{}]struct{}
 	mtx        sync.RWMutex
+
+	snapshotInterval int
+	maxSnapshots     int
 }
 
 func NewJobStore() *JobStore {
 	res := &JobStore{
-		jobs:       make(map[string]model.Job),
-		states:     make(map[string]model.JobState),
-		history:    make(map[string][]model.JobHistory),
-		inprogress: make(map[string]struct{}),
+		jobs:             make(map[string]model.Job),
+		events:           make(map[string][]jobEvent),
+		snapshots:        make(map[string][]jobSnapshot),
+		states:           make(map[string]model.JobState),
+		inprogress:       make(map[string]struct{}),
+		labels:           make(map[string]map[string]struct{}),
+		watchers:         make(map[string]map[chan struct{}]struct{}),
+		snapshotInterval: DefaultSnapshotInterval,
+		maxSnapshots:     DefaultMaxSnapshots,
 	}
 	res.mtx.EnableTracerWithOpts(sync.Opts{
 		Threshold: 10 * time.Millisecond,
@@ -58,20 +96,21 @@ func (d *JobStore) GetJobs(ctx context.Context, query jobstore.JobQuery) ([]mode
 	var result []model.Job
 
 	if query.ID != "" {
-		j, err := d.getJob(query.ID)
+		id, err := resolver.MatchJob(query.ID, query.Namespace, maps.Values(d.jobs))
 		if err != nil {
 			return nil, err
 		}
-		return []model.Job{j}, nil
+		return []model.Job{d.jobs[id]}, nil
 	}
 
 	for _, j := range maps.Values(d.jobs) {
-		if query.Limit > 0 && len(result) == query.Limit {
-			break
+		if !query.ReturnAll && query.ClientID != "" && !j.Metadata.IsVisibleTo(query.ClientID) {
+			// Job is not for the requesting client, so ignore it.
+			continue
 		}
 
-		if !query.ReturnAll && query.ClientID != "" && query.ClientID != j.Metadata.ClientID {
-			// Job is not for the requesting client, so ignore it.
+		if query.Namespace != "" && j.Metadata.Namespace != query.Namespace {
+			// Namespaces are enforced even when returning all jobs
 			continue
 		}
 
@@ -93,6 +132,16 @@ func (d *JobStore) GetJobs(ctx context.Context, query jobstore.JobQuery) ([]mode
 			continue
 		}
 
+		if !jobstore.MatchesAll(query.Labels, j.Spec.Annotations) {
+			continue
+		}
+		if len(query.States) > 0 && !slices.Contains(query.States, d.states[j.Metadata.ID].State) {
+			continue
+		}
+		if !query.CreatedAfter.IsZero() && j.Metadata.CreatedAt.Before(query.CreatedAfter) {
+			continue
+		}
+
 		result = append(result, j)
 	}
 
@@ -116,6 +165,10 @@ func (d *JobStore) GetJobs(ctx context.Context, query jobstore.JobQuery) ([]mode
 		}
 	}
 	sort.Slice(result, listSorter)
+	// the jobs are limited once sorted, so that the first jobs in the order asked for are returned
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
 	return result, nil
 }
 
@@ -129,6 +182,39 @@ func (d *JobStore) GetJobState(_ context.Context, jobID string) (model.JobState,
 	return state, nil
 }
 
+// GetJobStateAt rebuilds the state of the job as it was at the given time, by replaying
+// the events recorded up to that time on top of the closest preceding snapshot. The job
+// is not found at times before it was created.
+func (d *JobStore) GetJobStateAt(_ context.Context, jobID string, at time.Time) (model.JobState, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	events, ok := d.events[jobID]
+	if !ok {
+		return model.JobState{}, bacerrors.NewJobNotFound(jobID)
+	}
+
+	// events are appended in order, so find how many of them were recorded by then
+	count := sort.Search(len(events), func(i int) bool { return events[i].recordedAt.After(at) })
+	if count == 0 {
+		return model.JobState{}, bacerrors.NewJobNotFound(jobID)
+	}
+
+	var state model.JobState
+	offset := 0
+	snapshots := d.snapshots[jobID]
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].offset <= count {
+			state = snapshots[i].state
+			offset = snapshots[i].offset
+			break
+		}
+	}
+	for _, event := range events[offset:count] {
+		state = applyEvent(state, event)
+	}
+	return state, nil
+}
+
 func (d *JobStore) GetInProgressJobs(ctx context.Context) ([]model.JobWithInfo, error) {
 	d.mtx.RLock()
 	defer d.mtx.RUnlock()
@@ -145,15 +231,16 @@ func (d *JobStore) GetInProgressJobs(ctx context.Context) ([]model.JobWithInfo,
 func (d *JobStore) GetJobHistory(_ context.Context, jobID string, options jobstore.JobHistoryFilterOptions) ([]model.JobHistory, error) {
 	d.mtx.RLock()
 	defer d.mtx.RUnlock()
-	history, ok := d.history[jobID]
+	events, ok := d.events[jobID]
 	if !ok {
 		return nil, jobstore.NewErrJobNotFound(jobID)
 	}
 
 	// We want to filter events to only those that happened after the timestamp provided
 	sinceTime := options.Since
-	eventList := make([]model.JobHistory, 0, len(history))
-	for _, event := range history {
+	eventList := make([]model.JobHistory, 0, len(events))
+	for _, e := range events {
+		event := e.history
 		if options.ExcludeExecutionLevel && event.Type == model.JobHistoryTypeExecutionLevel {
 			continue
 		}
@@ -167,8 +254,9 @@ func (d *JobStore) GetJobHistory(_ context.Context, jobID string, options jobsto
 		}
 	}
 
-	history = eventList
-	sort.Slice(history, func(i, j int) bool { return history[i].Time.UTC().Before(history[j].Time.UTC()) })
+	// events are appended in order, which a stable sort keeps for events recorded at the same time
+	history := eventList
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time.UTC().Before(history[j].Time.UTC()) })
 
 	return history, nil
 }
@@ -184,6 +272,88 @@ func (d *JobStore) GetJobsCount(ctx context.Context, query jobstore.JobQuery) (i
 	return len(jobs), nil
 }
 
+// SearchJobs finds the jobs matching each selector from the index of labels, and keeps the jobs matching all of them.
+func (d *JobStore) SearchJobs(_ context.Context, query jobstore.JobSearchQuery) (jobstore.JobSearchResult, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	var matched map[string]struct{}
+	if len(query.Labels) == 0 {
+		matched = make(map[string]struct{}, len(d.jobs))
+		for jobID := range d.jobs {
+			matched[jobID] = struct{}{}
+		}
+	}
+	for i, selector := range query.Labels {
+		jobIDs := make(map[string]struct{})
+		for label, labelled := range d.labels {
+			if !selector.Matches(label) {
+				continue
+			}
+			for jobID := range labelled {
+				if _, ok := matched[jobID]; ok || i == 0 {
+					jobIDs[jobID] = struct{}{}
+				}
+			}
+		}
+		matched = jobIDs
+	}
+
+	result := jobstore.JobSearchResult{Jobs: []model.Job{}, Counts: map[string]int{}}
+	for jobID := range matched {
+		job := d.jobs[jobID]
+		if !query.ReturnAll && query.ClientID != "" && !job.Metadata.IsVisibleTo(query.ClientID) {
+			continue
+		}
+		if query.Namespace != "" && job.Metadata.Namespace != query.Namespace {
+			continue
+		}
+		result.Jobs = append(result.Jobs, job)
+		for _, label := range uniqueLabels(job.Spec.Annotations) {
+			if len(query.Labels) == 0 || slices.ContainsFunc(query.Labels, func(s jobstore.LabelSelector) bool {
+				return s.Matches(label)
+			}) {
+				result.Counts[label]++
+			}
+		}
+	}
+
+	result.Total = len(result.Jobs)
+	sort.Slice(result.Jobs, func(i, j int) bool {
+		if !result.Jobs[i].Metadata.CreatedAt.Equal(result.Jobs[j].Metadata.CreatedAt) {
+			return result.Jobs[i].Metadata.CreatedAt.After(result.Jobs[j].Metadata.CreatedAt)
+		}
+		return result.Jobs[i].Metadata.ID < result.Jobs[j].Metadata.ID
+	})
+	if query.Limit > 0 && len(result.Jobs) > query.Limit {
+		result.Jobs = result.Jobs[:query.Limit]
+	}
+	return result, nil
+}
+
+// indexLabels updates the index of labels after the annotations of the job changed from previous to current.
+func (d *JobStore) indexLabels(jobID string, previous []string, current []string) {
+	for _, label := range previous {
+		delete(d.labels[label], jobID)
+		if len(d.labels[label]) == 0 {
+			delete(d.labels, label)
+		}
+	}
+	for _, label := range current {
+		if d.labels[label] == nil {
+			d.labels[label] = make(map[string]struct{})
+		}
+		d.labels[label][jobID] = struct{}{}
+	}
+}
+
+// uniqueLabels returns the annotations without duplicates, so that a job is counted once for each of its labels.
+func uniqueLabels(annotations []string) []string {
+	labels := slices.Clone(annotations)
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
 func (d *JobStore) CreateJob(_ context.Context, job model.Job) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
@@ -191,17 +361,26 @@ func (d *JobStore) CreateJob(_ context.Context, job model.Job) error {
 	if ok {
 		return jobstore.NewErrJobAlreadyExists(existingJob.Metadata.ID)
 	}
+	if job.Metadata.Name != "" {
+		for _, j := range d.jobs {
+			if j.Metadata.Name == job.Metadata.Name && j.Metadata.Namespace == job.Metadata.Namespace {
+				return jobstore.NewErrJobNameTaken(job.Metadata.Name, j.Metadata.ID)
+			}
+		}
+	}
+	if job.Metadata.SpecVersion == 0 {
+		job.Metadata.SpecVersion = 1
+	}
 	d.jobs[job.Metadata.ID] = job
+	d.indexLabels(job.Metadata.ID, nil, job.Spec.Annotations)
 
 	// populate job state
 	jobState := model.JobState{
 		JobID:      job.Metadata.ID,
 		State:      model.JobStateNew,
 		Version:    1,
-		CreateTime: time.Now(),
 		UpdateTime: time.Now(),
 	}
-	d.states[job.Metadata.ID] = jobState
 	d.inprogress[job.Metadata.ID] = struct{}{}
 	d.appendJobHistory(jobState, model.JobStateNew, newJobComment)
 	return nil
@@ -211,28 +390,16 @@ func (d *JobStore) CreateJob(_ context.Context, job model.Job) error {
 // It is important that we don't attempt to acquire a lock inside this method to avoid deadlocks since
 // the callers are expected to be holding a lock, and golang doesn't support reentrant locks.
 func (d *JobStore) getJob(id string) (model.Job, error) {
-	if len(id) < model.ShortIDLength {
-		return model.Job{}, bacerrors.NewJobNotFound(id)
+	if j, ok := d.jobs[id]; ok {
+		return j, nil
 	}
 
-	// support for short job IDs
-	if jobutils.ShortID(id) == id {
-		// passed in a short id, need to resolve the long id first
-		for k := range d.jobs {
-			if jobutils.ShortID(k) == id {
-				id = k
-				break
-			}
-		}
-	}
-
-	j, ok := d.jobs[id]
-	if !ok {
-		returnError := bacerrors.NewJobNotFound(id)
-		return model.Job{}, returnError
+	// support for short job IDs and names
+	id, err := resolver.MatchJob(id, "", maps.Values(d.jobs))
+	if err != nil {
+		return model.Job{}, err
 	}
-
-	return j, nil
+	return d.jobs[id], nil
 }
 
 func (d *JobStore) UpdateJobState(_ context.Context, request jobstore.UpdateJobStateRequest) error {
@@ -258,7 +425,6 @@ func (d *JobStore) UpdateJobState(_ context.Context, request jobstore.UpdateJobS
 	jobState.State = request.NewState
 	jobState.Version++
 	jobState.UpdateTime = time.Now()
-	d.states[request.JobID] = jobState
 	if request.NewState.IsTerminal() {
 		delete(d.inprogress, request.JobID)
 	}
@@ -266,6 +432,78 @@ func (d *JobStore) UpdateJobState(_ context.Context, request jobstore.UpdateJobS
 	return nil
 }
 
+func (d *JobStore) UpdateJobSpec(_ context.Context, request jobstore.UpdateJobSpecRequest) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	job, ok := d.jobs[request.JobID]
+	if !ok {
+		return jobstore.NewErrJobNotFound(request.JobID)
+	}
+	jobState := d.states[request.JobID]
+	if jobState.State.IsTerminal() {
+		return jobstore.NewErrJobAlreadyTerminal(request.JobID, jobState.State, jobState.State)
+	}
+	if job.Metadata.SpecVersion != request.ExpectedSpecVersion {
+		return jobstore.NewErrInvalidJobSpecVersion(request.JobID, job.Metadata.SpecVersion, request.ExpectedSpecVersion)
+	}
+
+	d.indexLabels(request.JobID, job.Spec.Annotations, request.NewSpec.Annotations)
+	job.Spec = request.NewSpec
+	job.Metadata.SpecVersion++
+	d.jobs[request.JobID] = job
+
+	// record the update in the history of the job, without changing its state
+	jobState.Version++
+	jobState.UpdateTime = time.Now()
+	d.appendJobHistory(jobState, jobState.State, request.Comment)
+	return nil
+}
+
+// WatchJob returns a channel signaled whenever an event is appended to the log of the job. Signals are not queued, so
+// a reader that falls behind sees a single signal for several events.
+func (d *JobStore) WatchJob(ctx context.Context, jobID string) (<-chan struct{}, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.states[jobID]; !ok {
+		return nil, jobstore.NewErrJobNotFound(jobID)
+	}
+
+	changes := make(chan struct{}, 1)
+	if d.watchers[jobID] == nil {
+		d.watchers[jobID] = make(map[chan struct{}]struct{})
+	}
+	d.watchers[jobID][changes] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+		delete(d.watchers[jobID], changes)
+		if len(d.watchers[jobID]) == 0 {
+			delete(d.watchers, jobID)
+		}
+		close(changes)
+	}()
+	return changes, nil
+}
+
+func (d *JobStore) AddJobWatcher(_ context.Context, jobID string, clientID string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	job, ok := d.jobs[jobID]
+	if !ok {
+		return jobstore.NewErrJobNotFound(jobID)
+	}
+	if job.Metadata.IsVisibleTo(clientID) {
+		return nil
+	}
+	// the watchers are shared with jobs returned by the store, so they are copied before being changed
+	job.Metadata.Watchers = append(slices.Clone(job.Metadata.Watchers), clientID)
+	d.jobs[jobID] = job
+	return nil
+}
+
 func (d *JobStore) CreateExecution(_ context.Context, execution model.ExecutionState) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
@@ -287,8 +525,6 @@ func (d *JobStore) CreateExecution(_ context.Context, execution model.ExecutionS
 	if execution.Version == 0 {
 		execution.Version = 1
 	}
-	jobState.Executions = append(jobState.Executions, execution)
-	d.states[execution.JobID] = jobState
 	d.appendExecutionHistory(execution, model.ExecutionStateNew, "")
 	return nil
 }
@@ -342,12 +578,97 @@ func (d *JobStore) UpdateExecution(_ context.Context, request jobstore.UpdateExe
 
 	// update the execution
 	previousState := existingExecution.State
-	jobState.Executions[executionIndex] = newExecution
-	d.states[newExecution.JobID] = jobState
 	d.appendExecutionHistory(newExecution, previousState, request.Comment)
 	return nil
 }
 
+func (d *JobStore) ExportJob(_ context.Context, jobID string) (jobstore.JobRecord, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	job, ok := d.jobs[jobID]
+	if !ok {
+		return jobstore.JobRecord{}, jobstore.NewErrJobNotFound(jobID)
+	}
+
+	events := d.events[jobID]
+	record := jobstore.JobRecord{
+		Job:    job,
+		State:  d.states[jobID],
+		Events: make([]jobstore.JobEvent, 0, len(events)),
+	}
+	for _, e := range events {
+		event := jobstore.JobEvent{History: e.history, RecordedAt: e.recordedAt}
+		if e.history.Type == model.JobHistoryTypeExecutionLevel {
+			execution := e.execution
+			event.Execution = &execution
+		}
+		record.Events = append(record.Events, event)
+	}
+	return record, nil
+}
+
+// ImportJob rebuilds the state of the job by replaying the events of the record, keeping the time they were
+// recorded at so that past states of the job can still be looked up.
+func (d *JobStore) ImportJob(_ context.Context, request jobstore.ImportJobRequest) error {
+	record := request.Record
+	jobID := record.Job.Metadata.ID
+	if jobID == "" {
+		return jobstore.NewErrInvalidJobRecord(jobID, "job has no ID")
+	}
+
+	events := make([]jobEvent, 0, len(record.Events))
+	var state model.JobState
+	for i, e := range record.Events {
+		if e.History.JobID != jobID {
+			return jobstore.NewErrInvalidJobRecord(jobID, fmt.Sprintf("event %d is for job %s", i, e.History.JobID))
+		}
+		event := jobEvent{history: e.History, recordedAt: e.RecordedAt}
+		switch e.History.Type {
+		case model.JobHistoryTypeJobLevel:
+			if e.History.JobState == nil {
+				return jobstore.NewErrInvalidJobRecord(jobID, fmt.Sprintf("event %d has no job state", i))
+			}
+		case model.JobHistoryTypeExecutionLevel:
+			if e.Execution == nil {
+				return jobstore.NewErrInvalidJobRecord(jobID, fmt.Sprintf("event %d has no execution", i))
+			}
+			event.execution = *e.Execution
+		default:
+			return jobstore.NewErrInvalidJobRecord(jobID, fmt.Sprintf("event %d has unknown type %s", i, e.History.Type))
+		}
+		state = applyEvent(state, event)
+		events = append(events, event)
+	}
+	if state.JobID != jobID {
+		return jobstore.NewErrInvalidJobRecord(jobID, "no event created the job")
+	}
+	if state.State != record.State.State || state.Version != record.State.Version {
+		return jobstore.NewErrInvalidJobRecord(jobID, fmt.Sprintf("events lead to state %s version %d, but the job is %s version %d",
+			state.State, state.Version, record.State.State, record.State.Version))
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	existing, ok := d.jobs[jobID]
+	if ok && !request.Replace {
+		return jobstore.NewErrJobAlreadyExists(jobID)
+	}
+	d.indexLabels(jobID, existing.Spec.Annotations, record.Job.Spec.Annotations)
+	d.jobs[jobID] = record.Job
+	delete(d.events, jobID)
+	delete(d.states, jobID)
+	delete(d.snapshots, jobID)
+	for _, event := range events {
+		d.addEvent(event)
+	}
+	if state.State.IsTerminal() {
+		delete(d.inprogress, jobID)
+	} else {
+		d.inprogress[jobID] = struct{}{}
+	}
+	return nil
+}
+
 func (d *JobStore) appendJobHistory(updateJob model.JobState, previousState model.JobStateType, comment string) {
 	historyEntry := model.JobHistory{
 		Type:  model.JobHistoryTypeJobLevel,
@@ -360,7 +681,7 @@ func (d *JobStore) appendJobHistory(updateJob model.JobState, previousState mode
 		Comment:    comment,
 		Time:       updateJob.UpdateTime,
 	}
-	d.history[updateJob.JobID] = append(d.history[updateJob.JobID], historyEntry)
+	d.appendEvent(jobEvent{history: historyEntry})
 }
 
 func (d *JobStore) appendExecutionHistory(updatedExecution model.ExecutionState, previousState model.ExecutionStateType, comment string) {
@@ -377,7 +698,73 @@ func (d *JobStore) appendExecutionHistory(updatedExecution model.ExecutionState,
 		Comment:    comment,
 		Time:       updatedExecution.UpdateTime,
 	}
-	d.history[updatedExecution.JobID] = append(d.history[updatedExecution.JobID], historyEntry)
+	d.appendEvent(jobEvent{history: historyEntry, execution: updatedExecution})
+}
+
+// appendEvent appends the event to the log of its job, updates the latest state of the job
+// and takes a snapshot of that state every snapshotInterval events, until the job ends.
+func (d *JobStore) appendEvent(event jobEvent) {
+	event.recordedAt = time.Now()
+	d.addEvent(event)
+}
+
+// addEvent adds the event to the log of its job as recorded.
+func (d *JobStore) addEvent(event jobEvent) {
+	jobID := event.history.JobID
+	d.events[jobID] = append(d.events[jobID], event)
+
+	state := applyEvent(d.states[jobID], event)
+	d.states[jobID] = state
+
+	// the log of a job barely grows once it ended, so its state is rebuilt from the start
+	// of the log rather than kept in snapshots
+	if state.State.IsTerminal() {
+		delete(d.snapshots, jobID)
+	} else if count := len(d.events[jobID]); d.snapshotInterval > 0 && count%d.snapshotInterval == 0 {
+		snapshots := append(d.snapshots[jobID], jobSnapshot{offset: count, state: state})
+		if d.maxSnapshots > 0 && len(snapshots) > d.maxSnapshots {
+			snapshots = slices.Delete(snapshots, 0, len(snapshots)-d.maxSnapshots)
+		}
+		d.snapshots[jobID] = snapshots
+	}
+
+	for changes := range d.watchers[jobID] {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// applyEvent returns the state of a job after the event is applied to it. The given state is
+// left untouched so that it can safely be held by a snapshot.
+func applyEvent(state model.JobState, event jobEvent) model.JobState {
+	switch event.history.Type {
+	case model.JobHistoryTypeJobLevel:
+		state.JobID = event.history.JobID
+		state.State = event.history.JobState.New
+		state.Version = event.history.NewVersion
+		state.UpdateTime = event.history.Time
+		if state.CreateTime.IsZero() {
+			state.CreateTime = event.history.Time
+		}
+	case model.JobHistoryTypeExecutionLevel:
+		executions := make([]model.ExecutionState, 0, len(state.Executions)+1)
+		replaced := false
+		for _, e := range state.Executions {
+			if e.ID() == event.execution.ID() {
+				e = event.execution
+				replaced = true
+			}
+			executions = append(executions, e)
+		}
+		if !replaced {
+			executions = append(executions, event.execution)
+		}
+		state.Executions = executions
+		state.Version++
+	}
+	return state
 }
 
 // Static check to ensure that Transport implements Transport:
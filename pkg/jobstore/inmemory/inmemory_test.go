@@ -4,11 +4,15 @@ package inmemory
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/version"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -124,3 +128,495 @@ func (s *InMemoryTestSuite) TestLevelFilteredJobHistory() {
 	require.Equal(s.T(), 4, len(history))
 	require.Equal(s.T(), model.ExecutionStateAskForBid, history[0].ExecutionState.New)
 }
+
+func (s *InMemoryTestSuite) TestJobStateAt() {
+	s.store.snapshotInterval = 2
+	job := model.Job{Metadata: model.Metadata{ID: "2"}}
+	execution := model.ExecutionState{JobID: "2", NodeID: "node", State: model.ExecutionStateAskForBid}
+
+	beforeCreate := time.Now()
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	afterCreate := time.Now()
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{JobID: "2", NewState: model.JobStateQueued}))
+	require.NoError(s.T(), s.store.CreateExecution(s.ctx, execution))
+	afterExecution := time.Now()
+	require.NoError(s.T(), s.store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		NewValues:   model.ExecutionState{State: model.ExecutionStateCompleted},
+	}))
+	require.Len(s.T(), s.store.snapshots["2"], 2)
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{JobID: "2", NewState: model.JobStateCompleted}))
+	require.Empty(s.T(), s.store.snapshots["2"], "the snapshots of jobs that ended are dropped")
+
+	// the job did not exist yet
+	_, err := s.store.GetJobStateAt(s.ctx, "2", beforeCreate)
+	var notFound *bacerrors.JobNotFound
+	require.ErrorAs(s.T(), err, &notFound)
+
+	state, err := s.store.GetJobStateAt(s.ctx, "2", afterCreate)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), model.JobStateNew, state.State)
+	require.Empty(s.T(), state.Executions)
+
+	state, err = s.store.GetJobStateAt(s.ctx, "2", afterExecution)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), model.JobStateQueued, state.State)
+	require.Equal(s.T(), 3, state.Version)
+	require.Len(s.T(), state.Executions, 1)
+	require.Equal(s.T(), model.ExecutionStateAskForBid, state.Executions[0].State)
+
+	current, err := s.store.GetJobState(s.ctx, "2")
+	require.NoError(s.T(), err)
+	state, err = s.store.GetJobStateAt(s.ctx, "2", time.Now())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), current, state)
+	require.Equal(s.T(), model.JobStateCompleted, state.State)
+	require.Equal(s.T(), model.ExecutionStateCompleted, state.Executions[0].State)
+}
+
+func (s *InMemoryTestSuite) TestExecutionUpdatesJobVersion() {
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, model.Job{Metadata: model.Metadata{ID: "2"}}))
+	read, err := s.store.GetJobState(s.ctx, "2")
+	require.NoError(s.T(), err)
+
+	// an execution created after the state was read makes updates based on that state fail
+	require.NoError(s.T(), s.store.CreateExecution(s.ctx, model.ExecutionState{
+		JobID: "2", NodeID: "node", State: model.ExecutionStateAskForBid,
+	}))
+	err = s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:     "2",
+		NewState:  model.JobStateCompleted,
+		Condition: jobstore.UpdateJobCondition{ExpectedVersion: read.Version},
+	})
+	require.ErrorAs(s.T(), err, &jobstore.ErrInvalidJobVersion{})
+
+	current, err := s.store.GetJobState(s.ctx, "2")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), read.Version+1, current.Version)
+	require.Equal(s.T(), model.JobStateNew, current.State)
+}
+
+func (s *InMemoryTestSuite) TestMaxSnapshots() {
+	s.store.snapshotInterval = 1
+	s.store.maxSnapshots = 2
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, model.Job{Metadata: model.Metadata{ID: "2"}}))
+	for i := 0; i < 4; i++ {
+		require.NoError(s.T(), s.store.CreateExecution(s.ctx, model.ExecutionState{
+			JobID: "2", NodeID: "node", ComputeReference: fmt.Sprintf("e-%d", i), State: model.ExecutionStateAskForBid,
+		}))
+	}
+
+	// only the latest snapshots are kept, and earlier states are rebuilt from the start of the log
+	snapshots := s.store.snapshots["2"]
+	require.Len(s.T(), snapshots, 2)
+	require.Equal(s.T(), []int{4, 5}, []int{snapshots[0].offset, snapshots[1].offset})
+	state, err := s.store.GetJobStateAt(s.ctx, "2", s.store.events["2"][1].recordedAt)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), state.Executions, 1)
+}
+
+func (s *InMemoryTestSuite) TestUpdateJobSpec() {
+	const updatedJobID = "updated-job"
+	job := model.Job{Metadata: model.Metadata{ID: updatedJobID}, Spec: model.Spec{Timeout: 60}}
+	execution := model.ExecutionState{JobID: updatedJobID, NodeID: "node", State: model.ExecutionStateBidAccepted}
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	require.NoError(s.T(), s.store.CreateExecution(s.ctx, execution))
+
+	newSpec := model.Spec{Timeout: 120, Annotations: []string{"long"}}
+	require.NoError(s.T(), s.store.UpdateJobSpec(s.ctx, jobstore.UpdateJobSpecRequest{
+		JobID:               updatedJobID,
+		ExpectedSpecVersion: 1,
+		NewSpec:             newSpec,
+	}))
+
+	updated, err := s.store.GetJob(s.ctx, updatedJobID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), newSpec, updated.Spec)
+	require.Equal(s.T(), 2, updated.Metadata.SpecVersion)
+
+	// the executions of the job are left untouched
+	state, err := s.store.GetJobState(s.ctx, updatedJobID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), model.JobStateNew, state.State)
+	require.Len(s.T(), state.Executions, 1)
+	require.Equal(s.T(), model.ExecutionStateBidAccepted, state.Executions[0].State)
+
+	// an update based on a stale spec version is rejected
+	err = s.store.UpdateJobSpec(s.ctx, jobstore.UpdateJobSpecRequest{
+		JobID:               updatedJobID,
+		ExpectedSpecVersion: 1,
+		NewSpec:             model.Spec{Timeout: 180},
+	})
+	require.ErrorAs(s.T(), err, &jobstore.ErrInvalidJobSpecVersion{})
+
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{JobID: updatedJobID, NewState: model.JobStateCancelled}))
+	err = s.store.UpdateJobSpec(s.ctx, jobstore.UpdateJobSpecRequest{
+		JobID:               updatedJobID,
+		ExpectedSpecVersion: 2,
+		NewSpec:             model.Spec{Timeout: 180},
+	})
+	require.ErrorAs(s.T(), err, &jobstore.ErrJobAlreadyTerminal{})
+}
+
+func (s *InMemoryTestSuite) TestGetJobsNamespace() {
+	for _, job := range []model.Job{
+		{Metadata: model.Metadata{ID: "research-job", Namespace: "research"}},
+		{Metadata: model.Metadata{ID: "finance-job", Namespace: "finance"}},
+	} {
+		require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	}
+
+	jobs, err := s.store.GetJobs(s.ctx, jobstore.JobQuery{Namespace: "research", ReturnAll: true})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), jobs, 1)
+	require.Equal(s.T(), "research-job", jobs[0].Metadata.ID)
+
+	// jobs of other namespaces are not found when queried by ID
+	_, err = s.store.GetJobs(s.ctx, jobstore.JobQuery{Namespace: "research", ID: "finance-job"})
+	require.Error(s.T(), err)
+
+	jobs, err = s.store.GetJobs(s.ctx, jobstore.JobQuery{ReturnAll: true})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), jobs, 2)
+}
+
+func (s *InMemoryTestSuite) TestGetJobsFilters() {
+	for i, job := range []model.Job{
+		{Metadata: model.Metadata{ID: "ml-old"}, Spec: model.Spec{Annotations: []string{"team=ml"}}},
+		{Metadata: model.Metadata{ID: "ml-new"}, Spec: model.Spec{Annotations: []string{"team=ml", "env=prod"}}},
+		{Metadata: model.Metadata{ID: "web-new"}, Spec: model.Spec{Annotations: []string{"team=web"}}},
+	} {
+		job.Metadata.CreatedAt = time.Unix(int64(100+i), 0)
+		require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	}
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    "ml-new",
+		NewState: model.JobStateCompleted,
+	}))
+	getJobs := func(query jobstore.JobQuery, selectors ...string) []string {
+		for _, selector := range selectors {
+			labelSelector, err := jobstore.ParseLabelSelector(selector)
+			require.NoError(s.T(), err)
+			query.Labels = append(query.Labels, labelSelector)
+		}
+		query.ReturnAll = true
+		query.SortBy = "created_at"
+		jobs, err := s.store.GetJobs(s.ctx, query)
+		require.NoError(s.T(), err)
+		ids := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.Metadata.ID)
+		}
+		return ids
+	}
+
+	require.Equal(s.T(), []string{"ml-old", "ml-new"}, getJobs(jobstore.JobQuery{}, "team=ml"))
+	require.Equal(s.T(), []string{"ml-new"}, getJobs(jobstore.JobQuery{}, "team=ml", "env"))
+	require.Equal(s.T(), []string{"ml-new"}, getJobs(jobstore.JobQuery{States: []model.JobStateType{model.JobStateCompleted}}))
+	require.Equal(s.T(), []string{"ml-old", "web-new"}, getJobs(jobstore.JobQuery{
+		States: []model.JobStateType{model.JobStateNew, model.JobStateError},
+	}))
+	require.Equal(s.T(), []string{"ml-new", "web-new"}, getJobs(jobstore.JobQuery{CreatedAfter: time.Unix(101, 0)}))
+
+	// the limit applies to the jobs once filtered and sorted
+	require.Equal(s.T(), []string{"ml-old"}, getJobs(jobstore.JobQuery{Limit: 1}, "team=ml"))
+	require.Equal(s.T(), []string{"web-new"}, getJobs(jobstore.JobQuery{Limit: 1, SortReverse: true}))
+}
+
+func (s *InMemoryTestSuite) TestJobNames() {
+	for _, job := range []model.Job{
+		{Metadata: model.Metadata{ID: "1b2c3d4e-research", Namespace: "research", Name: "training-run"}},
+		{Metadata: model.Metadata{ID: "1b2c3d4f-finance", Namespace: "finance", Name: "training-run"}},
+	} {
+		require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	}
+
+	// names are unique within a namespace only
+	err := s.store.CreateJob(s.ctx, model.Job{Metadata: model.Metadata{ID: "other", Namespace: "research", Name: "training-run"}})
+	require.ErrorAs(s.T(), err, &jobstore.ErrJobNameTaken{})
+
+	jobs, err := s.store.GetJobs(s.ctx, jobstore.JobQuery{Namespace: "finance", ID: "training-run"})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), jobs, 1)
+	require.Equal(s.T(), "1b2c3d4f-finance", jobs[0].Metadata.ID)
+
+	// without a namespace, the name matches the jobs of both namespaces, while their short IDs still differ
+	_, err = s.store.GetJob(s.ctx, "training-run")
+	require.ErrorContains(s.T(), err, "1b2c3d4e-research (training-run), 1b2c3d4f-finance (training-run)")
+	job, err := s.store.GetJob(s.ctx, "1b2c3d4e")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "1b2c3d4e-research", job.Metadata.ID)
+}
+
+func (s *InMemoryTestSuite) TestAddJobWatcher() {
+	const watchedJobID = "watched-job"
+	job := model.Job{Metadata: model.Metadata{ID: watchedJobID, ClientID: "owner"}}
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+
+	require.NoError(s.T(), s.store.AddJobWatcher(s.ctx, watchedJobID, "watcher"))
+	// adding the owner or an existing watcher again is a no-op
+	require.NoError(s.T(), s.store.AddJobWatcher(s.ctx, watchedJobID, "watcher"))
+	require.NoError(s.T(), s.store.AddJobWatcher(s.ctx, watchedJobID, "owner"))
+
+	watched, err := s.store.GetJob(s.ctx, watchedJobID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []string{"watcher"}, watched.Metadata.Watchers)
+
+	// the job is listed for its watchers as well as its owner
+	for _, clientID := range []string{"owner", "watcher"} {
+		jobs, err := s.store.GetJobs(s.ctx, jobstore.JobQuery{ClientID: clientID})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), jobs, 1, clientID)
+		require.Equal(s.T(), watchedJobID, jobs[0].ID())
+	}
+
+	require.ErrorAs(s.T(), s.store.AddJobWatcher(s.ctx, "unknown", "watcher"), &jobstore.ErrJobNotFound{})
+}
+
+func (s *InMemoryTestSuite) createExportedJobs() {
+	for _, id := range []string{"ended-job", "running-job"} {
+		require.NoError(s.T(), s.store.CreateJob(s.ctx, model.Job{Metadata: model.Metadata{ID: id, ClientID: "owner"}}))
+		require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{JobID: id, NewState: model.JobStateInProgress}))
+		execution := model.ExecutionState{JobID: id, NodeID: "node", State: model.ExecutionStateBidAccepted}
+		require.NoError(s.T(), s.store.CreateExecution(s.ctx, execution))
+	}
+	require.NoError(s.T(), s.store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: model.ExecutionID{JobID: "ended-job", NodeID: "node"},
+		NewValues:   model.ExecutionState{State: model.ExecutionStateCompleted},
+	}))
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    "ended-job",
+		NewState: model.JobStateCompleted,
+	}))
+}
+
+// exportSnapshot exports the jobs of the store, and sends the snapshot through JSON as it would be between requesters.
+func (s *InMemoryTestSuite) exportSnapshot() jobstore.Snapshot {
+	exported, err := jobstore.ExportSnapshot(s.ctx, s.store, "requester")
+	require.NoError(s.T(), err)
+	b, err := json.Marshal(exported)
+	require.NoError(s.T(), err)
+	var snapshot jobstore.Snapshot
+	require.NoError(s.T(), json.Unmarshal(b, &snapshot))
+	return snapshot
+}
+
+func (s *InMemoryTestSuite) TestExportImportSnapshot() {
+	s.createExportedJobs()
+	snapshot := s.exportSnapshot()
+	require.Equal(s.T(), jobstore.SnapshotFormatVersion, snapshot.FormatVersion)
+	require.Equal(s.T(), "requester", snapshot.RequesterID)
+	require.Len(s.T(), snapshot.Jobs, 2)
+
+	imported := NewJobStore()
+	result, err := jobstore.ImportSnapshot(s.ctx, imported, snapshot, jobstore.ImportSnapshotOptions{})
+	require.NoError(s.T(), err)
+	require.ElementsMatch(s.T(), []string{"ended-job", "running-job"}, result.Imported)
+	require.Empty(s.T(), result.Failed)
+
+	for _, id := range []string{"ended-job", "running-job"} {
+		job, err := s.store.GetJob(s.ctx, id)
+		require.NoError(s.T(), err)
+		importedJob, err := imported.GetJob(s.ctx, id)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), job.Metadata.ClientID, importedJob.Metadata.ClientID)
+
+		state, err := s.store.GetJobState(s.ctx, id)
+		require.NoError(s.T(), err)
+		importedState, err := imported.GetJobState(s.ctx, id)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), state.State, importedState.State)
+		require.Equal(s.T(), state.Version, importedState.Version)
+		require.Len(s.T(), importedState.Executions, 1)
+		require.Equal(s.T(), state.Executions[0].State, importedState.Executions[0].State)
+
+		history, err := s.store.GetJobHistory(s.ctx, id, jobstore.JobHistoryFilterOptions{})
+		require.NoError(s.T(), err)
+		importedHistory, err := imported.GetJobHistory(s.ctx, id, jobstore.JobHistoryFilterOptions{})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), importedHistory, len(history))
+	}
+
+	// past states of the jobs can still be looked up, as events keep the time they were recorded at
+	firstEvent := snapshot.Jobs[0].Events[0]
+	state, err := imported.GetJobStateAt(s.ctx, firstEvent.History.JobID, firstEvent.RecordedAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), model.JobStateNew, state.State)
+
+	inProgress, err := imported.GetInProgressJobs(s.ctx)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), inProgress, 1)
+	require.Equal(s.T(), "running-job", inProgress[0].Job.ID())
+}
+
+func (s *InMemoryTestSuite) TestImportSnapshotConflicts() {
+	s.createExportedJobs()
+	snapshot := s.exportSnapshot()
+
+	// importing a snapshot again skips the jobs by default
+	result, err := jobstore.ImportSnapshot(s.ctx, s.store, snapshot, jobstore.ImportSnapshotOptions{})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), result.Imported)
+	require.ElementsMatch(s.T(), []string{"ended-job", "running-job"}, result.Skipped)
+
+	// nothing is imported if conflicts fail the import
+	snapshot.Jobs = append(snapshot.Jobs, s.renamedRecord(snapshot.Jobs[0], "new-job"))
+	_, err = jobstore.ImportSnapshot(s.ctx, s.store, snapshot, jobstore.ImportSnapshotOptions{OnConflict: jobstore.ConflictPolicyFail})
+	require.ErrorAs(s.T(), err, &jobstore.ErrSnapshotConflict{})
+	_, err = s.store.GetJobState(s.ctx, "new-job")
+	require.Error(s.T(), err)
+
+	// jobs are replaced by the ones of the snapshot when overwriting
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    "running-job",
+		NewState: model.JobStateCancelled,
+	}))
+	overwrite := jobstore.ImportSnapshotOptions{OnConflict: jobstore.ConflictPolicyOverwrite}
+	result, err = jobstore.ImportSnapshot(s.ctx, s.store, snapshot, overwrite)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []string{"new-job"}, result.Imported)
+	require.ElementsMatch(s.T(), []string{"ended-job", "running-job"}, result.Replaced)
+	state, err := s.store.GetJobState(s.ctx, "running-job")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), model.JobStateInProgress, state.State)
+}
+
+func (s *InMemoryTestSuite) TestImportSnapshotChecks() {
+	s.createExportedJobs()
+	snapshot := s.exportSnapshot()
+	imported := NewJobStore()
+
+	unsupported := snapshot
+	unsupported.FormatVersion = jobstore.SnapshotFormatVersion + 1
+	_, err := jobstore.ImportSnapshot(s.ctx, imported, unsupported, jobstore.ImportSnapshotOptions{})
+	require.ErrorAs(s.T(), err, &jobstore.ErrIncompatibleSnapshot{})
+
+	previousVersion := version.GITVERSION
+	defer func() { version.GITVERSION = previousVersion }()
+	version.GITVERSION = "v1.0.0"
+	newer := snapshot
+	newer.BacalhauVersion = "v1.1.0"
+	_, err = jobstore.ImportSnapshot(s.ctx, imported, newer, jobstore.ImportSnapshotOptions{})
+	require.ErrorAs(s.T(), err, &jobstore.ErrIncompatibleSnapshot{})
+
+	// records whose events don't lead to their state are not imported
+	newer.Jobs = append([]jobstore.JobRecord{}, newer.Jobs...)
+	newer.Jobs[0].State.Version++
+	result, err := jobstore.ImportSnapshot(s.ctx, imported, newer, jobstore.ImportSnapshotOptions{AllowNewer: true})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), result.Imported, 1)
+	require.Contains(s.T(), result.Failed, newer.Jobs[0].Job.ID())
+}
+
+// renamedRecord returns a copy of the record for a job with another ID.
+func (s *InMemoryTestSuite) renamedRecord(record jobstore.JobRecord, id string) jobstore.JobRecord {
+	record.Job.Metadata.ID = id
+	record.State.JobID = id
+	events := make([]jobstore.JobEvent, 0, len(record.Events))
+	for _, event := range record.Events {
+		event.History.JobID = id
+		if event.Execution != nil {
+			execution := *event.Execution
+			execution.JobID = id
+			event.Execution = &execution
+		}
+		events = append(events, event)
+	}
+	record.Events = events
+	return record
+}
+
+func (s *InMemoryTestSuite) TestSearchJobs() {
+	for i, job := range []model.Job{
+		{Metadata: model.Metadata{ID: "alpha-1", ClientID: "owner"}, Spec: model.Spec{Annotations: []string{"project=alpha", "team=ml-vision"}}},
+		{Metadata: model.Metadata{ID: "alpha-2", ClientID: "other"}, Spec: model.Spec{Annotations: []string{"project=alpha", "project=alpha"}}},
+		{Metadata: model.Metadata{ID: "beta-1", ClientID: "owner"}, Spec: model.Spec{Annotations: []string{"project=beta", "team=ml-nlp"}}},
+		{Metadata: model.Metadata{ID: "unlabelled", ClientID: "owner"}},
+	} {
+		job.Metadata.CreatedAt = time.Unix(int64(i), 0)
+		require.NoError(s.T(), s.store.CreateJob(s.ctx, job))
+	}
+	search := func(query jobstore.JobSearchQuery, selectors ...string) jobstore.JobSearchResult {
+		for _, selector := range selectors {
+			labelSelector, err := jobstore.ParseLabelSelector(selector)
+			require.NoError(s.T(), err)
+			query.Labels = append(query.Labels, labelSelector)
+		}
+		result, err := s.store.SearchJobs(s.ctx, query)
+		require.NoError(s.T(), err)
+		return result
+	}
+	jobIDs := func(result jobstore.JobSearchResult) []string {
+		ids := make([]string, 0, len(result.Jobs))
+		for _, job := range result.Jobs {
+			ids = append(ids, job.ID())
+		}
+		return ids
+	}
+
+	// the most recent jobs come first, and jobs are counted once per label
+	result := search(jobstore.JobSearchQuery{ReturnAll: true}, "project=alpha")
+	require.Equal(s.T(), []string{"alpha-2", "alpha-1"}, jobIDs(result))
+	require.Equal(s.T(), map[string]int{"project=alpha": 2}, result.Counts)
+
+	result = search(jobstore.JobSearchQuery{ReturnAll: true}, "project")
+	require.Equal(s.T(), 3, result.Total)
+	require.Equal(s.T(), map[string]int{"project=alpha": 2, "project=beta": 1}, result.Counts)
+
+	// jobs must match all the selectors
+	result = search(jobstore.JobSearchQuery{ReturnAll: true}, "project", "team=ml-*")
+	require.Equal(s.T(), []string{"beta-1", "alpha-1"}, jobIDs(result))
+
+	// the limit applies to the jobs returned, but not to the total and counts
+	result = search(jobstore.JobSearchQuery{ReturnAll: true, Limit: 1}, "project=alpha")
+	require.Equal(s.T(), []string{"alpha-2"}, jobIDs(result))
+	require.Equal(s.T(), 2, result.Total)
+	require.Equal(s.T(), 2, result.Counts["project=alpha"])
+
+	// only the jobs visible to the client are searched
+	result = search(jobstore.JobSearchQuery{ClientID: "owner"}, "project=alpha")
+	require.Equal(s.T(), []string{"alpha-1"}, jobIDs(result))
+
+	// every job matches without selectors
+	result = search(jobstore.JobSearchQuery{ReturnAll: true})
+	require.Equal(s.T(), 4, result.Total)
+	require.Equal(s.T(), 1, result.Counts["team=ml-nlp"])
+
+	// the index follows changes to the annotations of jobs
+	require.NoError(s.T(), s.store.UpdateJobSpec(s.ctx, jobstore.UpdateJobSpecRequest{
+		JobID:               "beta-1",
+		ExpectedSpecVersion: 1,
+		NewSpec:             model.Spec{Annotations: []string{"project=alpha"}},
+	}))
+	result = search(jobstore.JobSearchQuery{ReturnAll: true}, "project=alpha")
+	require.Equal(s.T(), []string{"beta-1", "alpha-2", "alpha-1"}, jobIDs(result))
+	require.Empty(s.T(), search(jobstore.JobSearchQuery{ReturnAll: true}, "project=beta").Jobs)
+}
+
+func (s *InMemoryTestSuite) TestWatchJob() {
+	const watchedJobID = "watched-state-job"
+	require.NoError(s.T(), s.store.CreateJob(s.ctx, model.Job{Metadata: model.Metadata{ID: watchedJobID}}))
+	ctx, cancel := context.WithCancel(s.ctx)
+	changes, err := s.store.WatchJob(ctx, watchedJobID)
+	require.NoError(s.T(), err)
+
+	// several changes before the watcher reads are seen as one
+	for _, state := range []model.JobStateType{model.JobStateQueued, model.JobStateInProgress} {
+		require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{JobID: watchedJobID, NewState: state}))
+	}
+	require.Len(s.T(), changes, 1)
+	<-changes
+
+	cancel()
+	require.Eventually(s.T(), func() bool {
+		_, ok := <-changes
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(s.T(), s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    watchedJobID,
+		NewState: model.JobStateCompleted,
+	}))
+
+	_, err = s.store.WatchJob(s.ctx, "unknown")
+	require.ErrorAs(s.T(), err, &jobstore.ErrJobNotFound{})
+}
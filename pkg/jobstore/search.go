@@ -0,0 +1,98 @@
+package jobstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+// LabelSelector matches the labels of jobs, which are their annotations of the form key=value. Annotations without
+// a value are labels with an empty value.
+type LabelSelector struct {
+	Key string
+	// Value is the value the label must have.
+	Value string
+	// KeyPrefix matches labels whose key starts with Key.
+	KeyPrefix bool
+	// ValuePrefix matches labels whose value starts with Value.
+	ValuePrefix bool
+}
+
+// ParseLabelSelector parses a selector of labels, which is one of:
+//
+//   - key, or key=*, for the label with any value
+//   - key=value for the label with the value, where key= is the label without a value
+//   - key=prefix* for the label with a value starting with the prefix
+//   - prefix* for the labels whose key starts with the prefix, with any value
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	key, value, hasValue := strings.Cut(selector, "=")
+	var result LabelSelector
+	result.Key, result.KeyPrefix = strings.CutSuffix(key, "*")
+	result.Value, result.ValuePrefix = strings.CutSuffix(value, "*")
+	if !hasValue {
+		result.ValuePrefix = true
+	}
+
+	if result.Key == "" && !result.KeyPrefix {
+		return LabelSelector{}, fmt.Errorf("invalid label selector %q: the key is empty", selector)
+	}
+	if result.KeyPrefix && hasValue {
+		return LabelSelector{}, fmt.Errorf("invalid label selector %q: a key prefix can't be combined with a value", selector)
+	}
+	if strings.Contains(result.Key+result.Value, "*") {
+		return LabelSelector{}, fmt.Errorf("invalid label selector %q: only a trailing * is supported", selector)
+	}
+	return result, nil
+}
+
+// Matches returns true if the annotation is a label selected by the selector.
+func (s LabelSelector) Matches(annotation string) bool {
+	key, value := SplitLabel(annotation)
+	if s.KeyPrefix {
+		return strings.HasPrefix(key, s.Key)
+	}
+	if key != s.Key {
+		return false
+	}
+	if s.ValuePrefix {
+		return strings.HasPrefix(value, s.Value)
+	}
+	return value == s.Value
+}
+
+// MatchesAll returns true if each of the selectors matches a label of the annotations.
+func MatchesAll(selectors []LabelSelector, annotations []string) bool {
+	for _, selector := range selectors {
+		if !slices.ContainsFunc(annotations, selector.Matches) {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitLabel returns the key and value of the label of an annotation.
+func SplitLabel(annotation string) (key string, value string) {
+	key, value, _ = strings.Cut(annotation, "=")
+	return key, value
+}
+
+// JobSearchQuery finds the jobs with labels matching all of the selectors, or every job if there are none.
+type JobSearchQuery struct {
+	Labels    []LabelSelector
+	ClientID  string
+	Namespace string
+	ReturnAll bool
+	// Limit is the most jobs returned, the most recent first. The total and counts include every matching job.
+	Limit int
+}
+
+type JobSearchResult struct {
+	Jobs []model.Job
+	// Total is the number of jobs matching the query, which may be more than the jobs returned.
+	Total int
+	// Counts is the number of matching jobs with each label matched by the selectors, or with each label if there
+	// are no selectors.
+	Counts map[string]int
+}
@@ -0,0 +1,64 @@
+//go:build unit || !integration
+
+package jobstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	for _, tc := range []struct {
+		selector string
+		expected LabelSelector
+		matches  []string
+		misses   []string
+	}{
+		{
+			selector: "project=alpha",
+			expected: LabelSelector{Key: "project", Value: "alpha"},
+			matches:  []string{"project=alpha"},
+			misses:   []string{"project=alphabet", "project", "projects=alpha"},
+		},
+		{
+			selector: "project",
+			expected: LabelSelector{Key: "project", ValuePrefix: true},
+			matches:  []string{"project=alpha", "project=beta", "project"},
+			misses:   []string{"projects=alpha"},
+		},
+		{
+			selector: "project=",
+			expected: LabelSelector{Key: "project"},
+			matches:  []string{"project", "project="},
+			misses:   []string{"project=alpha"},
+		},
+		{
+			selector: "team=ml-*",
+			expected: LabelSelector{Key: "team", Value: "ml-", ValuePrefix: true},
+			matches:  []string{"team=ml-vision", "team=ml-"},
+			misses:   []string{"team=data", "team"},
+		},
+		{
+			selector: "cost*",
+			expected: LabelSelector{Key: "cost", KeyPrefix: true, ValuePrefix: true},
+			matches:  []string{"cost-center=42", "cost"},
+			misses:   []string{"project=cost"},
+		},
+	} {
+		selector, err := ParseLabelSelector(tc.selector)
+		require.NoError(t, err, tc.selector)
+		require.Equal(t, tc.expected, selector, tc.selector)
+		for _, annotation := range tc.matches {
+			require.True(t, selector.Matches(annotation), "%s should match %s", tc.selector, annotation)
+		}
+		for _, annotation := range tc.misses {
+			require.False(t, selector.Matches(annotation), "%s should not match %s", tc.selector, annotation)
+		}
+	}
+
+	for _, invalid := range []string{"", "=alpha", "cost*=42", "pro*ject", "project=al*pha"} {
+		_, err := ParseLabelSelector(invalid)
+		require.Error(t, err, invalid)
+	}
+}
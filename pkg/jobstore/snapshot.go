@@ -0,0 +1,182 @@
+package jobstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/version"
+)
+
+// SnapshotFormatVersion is the version of the format of snapshots exported by this release. It is bumped whenever a
+// change to the format would make older releases import snapshots wrongly.
+const SnapshotFormatVersion = 1
+
+// Snapshot is the full content of a job store, in a form that can be imported into the store of another requester.
+type Snapshot struct {
+	// FormatVersion is the version of the format of the snapshot, checked before it is imported.
+	FormatVersion int `json:"FormatVersion"`
+	// BacalhauVersion is the release of the requester that exported the snapshot.
+	BacalhauVersion string `json:"BacalhauVersion"`
+	// RequesterID is the requester node the snapshot was exported from.
+	RequesterID string      `json:"RequesterID,omitempty"`
+	CreatedAt   time.Time   `json:"CreatedAt"`
+	Jobs        []JobRecord `json:"Jobs"`
+}
+
+// JobRecord is everything a store holds about a job: its spec, its state and the log of events its state is derived
+// from.
+type JobRecord struct {
+	Job    model.Job      `json:"Job"`
+	State  model.JobState `json:"State"`
+	Events []JobEvent     `json:"Events"`
+}
+
+// JobEvent is an entry of the log of a job.
+type JobEvent struct {
+	History model.JobHistory `json:"History"`
+	// Execution is the resulting execution of execution level events.
+	Execution *model.ExecutionState `json:"Execution,omitempty"`
+	// RecordedAt is when the event was appended to the log.
+	RecordedAt time.Time `json:"RecordedAt"`
+}
+
+// ConflictPolicy is what happens when a job of a snapshot is already in the store it is imported into.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicySkip keeps the job already in the store.
+	ConflictPolicySkip ConflictPolicy = "skip"
+	// ConflictPolicyOverwrite replaces the job already in the store with the one of the snapshot.
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+	// ConflictPolicyFail imports nothing if any job of the snapshot is already in the store.
+	ConflictPolicyFail ConflictPolicy = "fail"
+)
+
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch ConflictPolicy(policy) {
+	case ConflictPolicySkip, ConflictPolicyOverwrite, ConflictPolicyFail:
+		return ConflictPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q, expected %q, %q or %q",
+			policy, ConflictPolicySkip, ConflictPolicyOverwrite, ConflictPolicyFail)
+	}
+}
+
+type ImportSnapshotOptions struct {
+	// OnConflict is what happens to jobs already in the store. They are skipped by default.
+	OnConflict ConflictPolicy
+	// AllowNewer imports snapshots exported by a newer release of bacalhau.
+	AllowNewer bool
+}
+
+// ImportSnapshotResult lists what happened to each job of an imported snapshot.
+type ImportSnapshotResult struct {
+	Imported []string          `json:"Imported"`
+	Replaced []string          `json:"Replaced,omitempty"`
+	Skipped  []string          `json:"Skipped,omitempty"`
+	Failed   map[string]string `json:"Failed,omitempty"`
+}
+
+// ExportSnapshot exports every job of the store. The record of each job is consistent, but jobs that change while
+// the snapshot is taken may be exported before or after the change.
+func ExportSnapshot(ctx context.Context, store Store, requesterID string) (Snapshot, error) {
+	jobs, err := store.GetJobs(ctx, JobQuery{ReturnAll: true, SortBy: "created_at"})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{
+		FormatVersion:   SnapshotFormatVersion,
+		BacalhauVersion: version.GITVERSION,
+		RequesterID:     requesterID,
+		CreatedAt:       time.Now().UTC(),
+		Jobs:            make([]JobRecord, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		record, err := store.ExportJob(ctx, job.Metadata.ID)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("failed to export job %s: %w", job.Metadata.ID, err)
+		}
+		snapshot.Jobs = append(snapshot.Jobs, record)
+	}
+	return snapshot, nil
+}
+
+// ImportSnapshot imports the jobs of a snapshot into the store, after checking this release can read it. Jobs that
+// fail to import are reported and do not stop the others from being imported.
+func ImportSnapshot(
+	ctx context.Context, store Store, snapshot Snapshot, options ImportSnapshotOptions) (ImportSnapshotResult, error) {
+	result := ImportSnapshotResult{Imported: []string{}}
+	if err := checkSnapshotVersion(snapshot, options.AllowNewer); err != nil {
+		return result, err
+	}
+	onConflict := options.OnConflict
+	if onConflict == "" {
+		onConflict = ConflictPolicySkip
+	}
+
+	// find the conflicts first, so that nothing is imported if they fail the import
+	existing := make(map[string]bool, len(snapshot.Jobs))
+	var conflicts []string
+	for _, record := range snapshot.Jobs {
+		_, err := store.GetJobState(ctx, record.Job.Metadata.ID)
+		if err == nil {
+			existing[record.Job.Metadata.ID] = true
+			conflicts = append(conflicts, record.Job.Metadata.ID)
+		}
+	}
+	if onConflict == ConflictPolicyFail && len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return result, NewErrSnapshotConflict(conflicts)
+	}
+
+	for _, record := range snapshot.Jobs {
+		jobID := record.Job.Metadata.ID
+		if existing[jobID] && onConflict == ConflictPolicySkip {
+			result.Skipped = append(result.Skipped, jobID)
+			continue
+		}
+		err := store.ImportJob(ctx, ImportJobRequest{Record: record, Replace: onConflict == ConflictPolicyOverwrite})
+		switch {
+		case err != nil:
+			if result.Failed == nil {
+				result.Failed = map[string]string{}
+			}
+			result.Failed[jobID] = err.Error()
+		case existing[jobID]:
+			result.Replaced = append(result.Replaced, jobID)
+		default:
+			result.Imported = append(result.Imported, jobID)
+		}
+	}
+	return result, nil
+}
+
+// checkSnapshotVersion returns an error if the snapshot can't be imported by this release of bacalhau.
+func checkSnapshotVersion(snapshot Snapshot, allowNewer bool) error {
+	if snapshot.FormatVersion != SnapshotFormatVersion {
+		return NewErrIncompatibleSnapshot(
+			fmt.Sprintf("format version %d is not supported, expected %d", snapshot.FormatVersion, SnapshotFormatVersion))
+	}
+	if allowNewer ||
+		snapshot.BacalhauVersion == version.DevelopmentGitVersion || version.GITVERSION == version.DevelopmentGitVersion {
+		return nil
+	}
+	exported, err := semver.NewVersion(snapshot.BacalhauVersion)
+	if err != nil {
+		return NewErrIncompatibleSnapshot(fmt.Sprintf("invalid bacalhau version %q", snapshot.BacalhauVersion))
+	}
+	current, err := semver.NewVersion(version.GITVERSION)
+	if err != nil {
+		return nil
+	}
+	if exported.GreaterThan(current) {
+		return NewErrIncompatibleSnapshot(fmt.Sprintf(
+			"exported by bacalhau %s, which is newer than this requester's %s", snapshot.BacalhauVersion, version.GITVERSION))
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package jobstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
@@ -9,6 +10,7 @@ import (
 type JobQuery struct {
 	ID          string              `json:"id"`
 	ClientID    string              `json:"clientID"`
+	Namespace   string              `json:"namespace"`
 	IncludeTags []model.IncludedTag `json:"include_tags"`
 	ExcludeTags []model.ExcludedTag `json:"exclude_tags"`
 	Limit       int                 `json:"limit"`
@@ -16,6 +18,12 @@ type JobQuery struct {
 	ReturnAll   bool                `json:"return_all"`
 	SortBy      string              `json:"sort_by"`
 	SortReverse bool                `json:"sort_reverse"`
+	// Labels are the selectors the labels of the jobs must all match.
+	Labels []LabelSelector `json:"labels"`
+	// States are the states the jobs must be in, or any state if empty.
+	States []model.JobStateType `json:"states"`
+	// CreatedAfter excludes the jobs created before it, if set.
+	CreatedAfter time.Time `json:"created_after"`
 }
 
 // A Store will persist jobs and their state to the underlying storage.
@@ -24,16 +32,31 @@ type Store interface {
 	GetJob(ctx context.Context, id string) (model.Job, error)
 	GetJobs(ctx context.Context, query JobQuery) ([]model.Job, error)
 	GetJobState(ctx context.Context, jobID string) (model.JobState, error)
+	// GetJobStateAt returns the state the job was in at the given point in time, or ErrJobNotFound
+	// if the job was not created yet by then
+	GetJobStateAt(ctx context.Context, jobID string, at time.Time) (model.JobState, error)
 	GetInProgressJobs(ctx context.Context) ([]model.JobWithInfo, error)
 	GetJobHistory(ctx context.Context, jobID string, options JobHistoryFilterOptions) ([]model.JobHistory, error)
 	GetJobsCount(ctx context.Context, query JobQuery) (int, error)
+	// SearchJobs finds the jobs whose labels match the query, using an index of the labels of jobs
+	SearchJobs(ctx context.Context, query JobSearchQuery) (JobSearchResult, error)
 	CreateJob(ctx context.Context, j model.Job) error
 	// UpdateJobState updates the Job state
 	UpdateJobState(ctx context.Context, request UpdateJobStateRequest) error
+	// UpdateJobSpec replaces the spec of a job that is not in a terminal state, and bumps its spec version
+	UpdateJobSpec(ctx context.Context, request UpdateJobSpecRequest) error
+	// WatchJob returns a channel that receives a value whenever the state of the job changes, until the context is done
+	WatchJob(ctx context.Context, jobID string) (<-chan struct{}, error)
+	// AddJobWatcher records that the client submitted the same spec as the job, and was given the job to watch
+	AddJobWatcher(ctx context.Context, jobID string, clientID string) error
 	// CreateExecution creates a new execution for a given job
 	CreateExecution(ctx context.Context, execution model.ExecutionState) error
 	// UpdateExecution updates the Job state
 	UpdateExecution(ctx context.Context, request UpdateExecutionRequest) error
+	// ExportJob returns everything the store holds about a job, to be imported into another store
+	ExportJob(ctx context.Context, jobID string) (JobRecord, error)
+	// ImportJob adds a job exported from another store with its history, replacing the job if asked to
+	ImportJob(ctx context.Context, request ImportJobRequest) error
 }
 
 type UpdateJobStateRequest struct {
@@ -43,6 +66,14 @@ type UpdateJobStateRequest struct {
 	Comment   string
 }
 
+type UpdateJobSpecRequest struct {
+	JobID string
+	// ExpectedSpecVersion is the spec version the new spec is based on
+	ExpectedSpecVersion int
+	NewSpec             model.Spec
+	Comment             string
+}
+
 type UpdateExecutionRequest struct {
 	ExecutionID model.ExecutionID
 	Condition   UpdateExecutionCondition
@@ -74,6 +105,12 @@ func (condition UpdateJobCondition) Validate(jobState model.JobState) error {
 	return nil
 }
 
+type ImportJobRequest struct {
+	Record JobRecord
+	// Replace replaces the job if it is already in the store, rather than failing.
+	Replace bool
+}
+
 type UpdateExecutionCondition struct {
 	ExpectedState    model.ExecutionStateType
 	ExpectedVersion  int
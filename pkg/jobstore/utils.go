@@ -9,14 +9,18 @@ import (
 )
 
 func GetStateResolver(db Store) *jobutils.StateResolver {
-	return jobutils.NewStateResolver(
+	resolver := jobutils.NewStateResolver(
 		db.GetJob,
 		db.GetJobState,
 	)
+	resolver.SetWatcher(db.WatchJob)
+	return resolver
 }
 
-// StopJob a helper function to fail a job and all its executions.
-func StopJob(ctx context.Context, db Store, jobID string, reason string, userRequested bool) ([]model.ExecutionState, error) {
+// StopJob a helper function to fail a job and all its executions. A non-zero expectedVersion only stops the job
+// if it did not change since that version, and ErrInvalidJobVersion is returned otherwise.
+func StopJob(ctx context.Context, db Store, jobID string, reason string, userRequested bool,
+	expectedVersion int) ([]model.ExecutionState, error) {
 	// update job state
 	newJobState := model.JobStateError
 	unexpectedJobState := model.JobStateCancelled
@@ -31,6 +35,7 @@ func StopJob(ctx context.Context, db Store, jobID string, reason string, userReq
 				model.JobStateCompleted,
 				unexpectedJobState,
 			},
+			ExpectedVersion: expectedVersion,
 		},
 		NewState: newJobState,
 		Comment:  reason,
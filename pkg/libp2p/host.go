@@ -9,6 +9,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
+	"github.com/bacalhau-project/bacalhau/pkg/util/multiaddresses"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -18,32 +19,45 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-const continuouslyConnectPeersLoopDelay = 10 * time.Second
+const (
+	continuouslyConnectPeersLoopDelay = 10 * time.Second
+	reverseConnectionLoopDelay        = 2 * time.Second
+	reverseConnectionTag              = "bacalhau-reverse-connection"
+)
 
-// NewHost creates a new libp2p host with some default configuration. It will continuously connect to bootstrap peers
-// if they are defined.
+// NewHost creates a new libp2p host with some default configuration, listening on both IPv4 and IPv6. It will
+// continuously connect to bootstrap peers if they are defined.
 func NewHost(port int, opts ...libp2p.Option) (host.Host, error) {
-	prvKey, err := config.GetPrivateKey(fmt.Sprintf("private_key.%d", port))
-	if err != nil {
-		return nil, err
-	}
+	return NewHostWithNetworkStack(port, multiaddresses.NetworkStackDual, opts...)
+}
 
-	addrs := []string{
-		fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port),
-		fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic", port),
-		fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", port),
-		fmt.Sprintf("/ip6/::/tcp/%d", port),
-		fmt.Sprintf("/ip6/::/udp/%d/quic", port),
-		fmt.Sprintf("/ip6/::/udp/%d/quic-v1", port),
-	}
+// NewHostWithNetworkStack creates a new libp2p host that only listens on the IP versions of the network stack.
+func NewHostWithNetworkStack(port int, stack multiaddresses.NetworkStack, opts ...libp2p.Option) (host.Host, error) {
+	addrs := stack.ListenAddresses(port)
 
 	preferredAddress := config.PreferredAddress()
 	if preferredAddress != "" {
-		newAddress := fmt.Sprintf("/ip4/%s/tcp/0", preferredAddress)
+		newAddress := multiaddresses.HostComponent(preferredAddress) + "/tcp/0"
 		addrs = append(addrs, newAddress)
 	}
 
 	opts = append(opts, libp2p.ListenAddrStrings(addrs...))
+	return newHost(port, opts...)
+}
+
+// NewOutboundOnlyHost creates a new libp2p host that doesn't listen on any address, and so can only be reached
+// through connections it establishes itself. The port only selects the identity of the host.
+func NewOutboundOnlyHost(port int, opts ...libp2p.Option) (host.Host, error) {
+	opts = append(opts, libp2p.NoListenAddrs)
+	return newHost(port, opts...)
+}
+
+func newHost(port int, opts ...libp2p.Option) (host.Host, error) {
+	prvKey, err := config.GetPrivateKey(fmt.Sprintf("private_key.%d", port))
+	if err != nil {
+		return nil, err
+	}
+
 	opts = append(opts, libp2p.Identity(prvKey))
 	h, err := libp2p.New(opts...)
 	if err != nil {
@@ -84,6 +98,17 @@ func ConnectToPeersContinuouslyWithRetryDuration(
 	if err := connectToPeers(ctx, h, peers); err != nil {
 		return err
 	}
+	startReconnectLoop(ctx, cm, h, peers, tickDuration)
+	return nil
+}
+
+func startReconnectLoop(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	h host.Host,
+	peers []multiaddr.Multiaddr,
+	tickDuration time.Duration,
+) {
 	ticker := time.NewTicker(tickDuration)
 	ctx, cancel := context.WithCancel(ctx)
 	cm.RegisterCallback(func() error {
@@ -108,6 +133,24 @@ func ConnectToPeersContinuouslyWithRetryDuration(
 			}
 		}
 	}()
+}
+
+// MaintainReverseConnection keeps an outbound connection open to the given peer, so that the peer can open streams
+// back to a host that can't be dialed, such as one behind a NAT or without any listening address. The connection is
+// protected from being pruned, and is reestablished shortly after being lost.
+func MaintainReverseConnection(ctx context.Context, cm *system.CleanupManager, h host.Host, target multiaddr.Multiaddr) error {
+	info, err := peer.AddrInfoFromP2pAddr(target)
+	if err != nil {
+		return fmt.Errorf("reverse connection address %s must include the peer ID: %w", target, err)
+	}
+	h.ConnManager().Protect(info.ID, reverseConnectionTag)
+
+	// the peer may not be up yet, in which case the reconnect loop will get to it
+	peers := []multiaddr.Multiaddr{target}
+	if err = connectToPeers(ctx, h, peers); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Reverse connection not established yet, retrying in the background")
+	}
+	startReconnectLoop(ctx, cm, h, peers, reverseConnectionLoopDelay)
 	return nil
 }
 
@@ -0,0 +1,109 @@
+// Package logframe is the framing shared by the executors and the log streams of executions, so that the output of
+// an execution is streamed the same way whichever engine runs it.
+//
+// Executors produce their output muxed with the framing docker uses when a container has no TTY:
+//
+//	[8]byte{STREAM_TYPE, 0, 0, 0, SIZE1, SIZE2, SIZE3, SIZE4}[]byte{OUTPUT}
+//
+// STREAM_TYPE is 1 for stdout and 2 for stderr, and SIZE1 to SIZE4 are the size of OUTPUT as a big endian uint32.
+//
+// The log streams of executions extend this header with the sequence number of the frame in the stream, so that
+// readers can tell when frames were lost or repeated:
+//
+//	[16]byte{STREAM_TYPE, 0, 0, 0, SIZE1, SIZE2, SIZE3, SIZE4, SEQ1, ..., SEQ8}[]byte{OUTPUT}
+//
+// SEQ1 to SEQ8 are a big endian uint64, starting at 1 for the first frame of a stream.
+package logframe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+type StreamTag byte
+
+const (
+	UnknownStreamTag StreamTag = iota
+	StdoutStreamTag
+	StderrStreamTag
+)
+
+const (
+	muxedHeaderLength     = 8
+	sequencedHeaderLength = 16
+)
+
+type DataFrame struct {
+	// Seq is the sequence number of the frame in its stream, or zero for muxed frames which have none.
+	Seq  uint64
+	Tag  StreamTag
+	Size int
+	Data []byte
+}
+
+var EmptyDataFrame DataFrame
+
+// NewDataFrameFromData will compose a new data frame wrapping the provided tag and data with the necessary
+// structure.
+func NewDataFrameFromData(tag StreamTag, data []byte) DataFrame {
+	return DataFrame{
+		Tag:  tag,
+		Size: len(data),
+		Data: append([]byte(nil), data...),
+	}
+}
+
+// ReadMuxedFrame reads a single frame of the muxed output of an executor. It returns io.EOF if the output ended
+// before the frame started.
+func ReadMuxedFrame(reader io.Reader) (DataFrame, error) {
+	header := make([]byte, muxedHeaderLength)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return DataFrame{}, err
+	}
+	return readData(reader, DataFrame{
+		Tag:  StreamTag(binary.LittleEndian.Uint32(header)),
+		Size: int(binary.BigEndian.Uint32(header[4:])),
+	})
+}
+
+// ReadDataFrame reads a single frame of the log stream of an execution. It returns io.EOF if the stream ended before
+// the frame started.
+func ReadDataFrame(reader io.Reader) (DataFrame, error) {
+	header := make([]byte, sequencedHeaderLength)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return DataFrame{}, err
+	}
+	return readData(reader, DataFrame{
+		Tag:  StreamTag(binary.LittleEndian.Uint32(header)),
+		Size: int(binary.BigEndian.Uint32(header[4:])),
+		Seq:  binary.BigEndian.Uint64(header[8:]),
+	})
+}
+
+func readData(reader io.Reader, df DataFrame) (DataFrame, error) {
+	df.Data = make([]byte, df.Size)
+	if n, err := io.ReadFull(reader, df.Data); err != nil {
+		return DataFrame{}, fmt.Errorf("unable to read dataframe data, read %d wanted %d: %w", n, df.Size, err)
+	}
+	return df, nil
+}
+
+// ToMuxedBytes converts the data frame into the muxed output of an executor.
+func (df DataFrame) ToMuxedBytes() []byte {
+	output := make([]byte, muxedHeaderLength+df.Size)
+	binary.LittleEndian.PutUint32(output, uint32(df.Tag))
+	binary.BigEndian.PutUint32(output[4:], uint32(df.Size))
+	copy(output[muxedHeaderLength:], df.Data)
+	return output
+}
+
+// ToBytes converts the data frame into a frame of the log stream of an execution.
+func (df DataFrame) ToBytes() []byte {
+	output := make([]byte, sequencedHeaderLength+df.Size)
+	binary.LittleEndian.PutUint32(output, uint32(df.Tag))
+	binary.BigEndian.PutUint32(output[4:], uint32(df.Size))
+	binary.BigEndian.PutUint64(output[8:], df.Seq)
+	copy(output[sequencedHeaderLength:], df.Data)
+	return output
+}
@@ -0,0 +1,80 @@
+//go:build unit || !integration
+
+package logframe
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type DataFrameTestSuite struct {
+	suite.Suite
+}
+
+func TestDataFrameTestSuite(t *testing.T) {
+	suite.Run(t, new(DataFrameTestSuite))
+}
+
+func (s *DataFrameTestSuite) TestMuxed() {
+	original := NewDataFrameFromData(StdoutStreamTag, []byte("hello"))
+
+	buf := bytes.Buffer{}
+	buf.Write(original.ToMuxedBytes())
+	// the muxed framing is the one docker uses
+	require.Equal(s.T(), []byte{1, 0, 0, 0, 0, 0, 0, 5}, buf.Bytes()[:muxedHeaderLength])
+
+	df, err := ReadMuxedFrame(&buf)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), original, df)
+
+	_, err = ReadMuxedFrame(&buf)
+	require.Equal(s.T(), io.EOF, err)
+}
+
+func (s *DataFrameTestSuite) TestSequenced() {
+	original := NewDataFrameFromData(StderrStreamTag, []byte("hello"))
+	original.Seq = 42
+
+	df, err := ReadDataFrame(bytes.NewReader(original.ToBytes()))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), original, df)
+}
+
+func (s *DataFrameTestSuite) TestTruncated() {
+	data := NewDataFrameFromData(StdoutStreamTag, []byte("hello")).ToMuxedBytes()
+	_, err := ReadMuxedFrame(bytes.NewReader(data[:len(data)-1]))
+	require.ErrorIs(s.T(), err, io.ErrUnexpectedEOF)
+}
+
+func (s *DataFrameTestSuite) TestSequencer() {
+	muxed := bytes.Buffer{}
+	muxed.Write(NewDataFrameFromData(StdoutStreamTag, []byte("one\n")).ToMuxedBytes())
+	muxed.Write(NewDataFrameFromData(StderrStreamTag, []byte("two\n")).ToMuxedBytes())
+	muxed.Write(NewDataFrameFromData(StdoutStreamTag, []byte("three\n")).ToMuxedBytes())
+
+	// read a few bytes at a time, so that frames are split across reads
+	sequencer := NewSequencer(io.NopCloser(&muxed))
+	var stream bytes.Buffer
+	_, err := io.CopyBuffer(&stream, struct{ io.Reader }{sequencer}, make([]byte, 5))
+	require.NoError(s.T(), err)
+
+	var frames []DataFrame
+	for {
+		df, err := ReadDataFrame(&stream)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(s.T(), err)
+		frames = append(frames, df)
+	}
+	require.Len(s.T(), frames, 3)
+	for i, expected := range []string{"one\n", "two\n", "three\n"} {
+		require.Equal(s.T(), uint64(i+1), frames[i].Seq)
+		require.Equal(s.T(), expected, string(frames[i].Data))
+	}
+	require.Equal(s.T(), StderrStreamTag, frames[1].Tag)
+}
@@ -0,0 +1,34 @@
+package logframe
+
+import "io"
+
+// Sequencer reads the muxed output of an executor as the log stream of the execution, numbering its frames.
+type Sequencer struct {
+	muxed   io.ReadCloser
+	seq     uint64
+	pending []byte
+}
+
+// NewSequencer returns a reader of the muxed output as a log stream, whose first frame is numbered 1.
+func NewSequencer(muxed io.ReadCloser) *Sequencer {
+	return &Sequencer{muxed: muxed}
+}
+
+func (s *Sequencer) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		df, err := ReadMuxedFrame(s.muxed)
+		if err != nil {
+			return 0, err
+		}
+		s.seq++
+		df.Seq = s.seq
+		s.pending = df.ToBytes()
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *Sequencer) Close() error {
+	return s.muxed.Close()
+}
@@ -140,7 +140,8 @@ func configureLogging(logWriter io.Writer) {
 		}
 	}
 
-	log.Logger = zerolog.New(logWriter).With().Timestamp().Caller().Stack().Logger()
+	// recent logs are kept in memory as well, so that they can be included in debug bundles
+	log.Logger = zerolog.New(zerolog.MultiLevelWriter(logWriter, recentLogs)).With().Timestamp().Caller().Stack().Logger()
 	// While the normal flow will use ContextWithNodeIDLogger, this won't be so for tests.
 	// Tests will use the DefaultContextLogger instead
 	zerolog.DefaultContextLogger = &log.Logger
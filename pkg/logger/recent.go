@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+)
+
+// recentLogsSize is how many of the latest log lines are kept in memory, to be included in debug bundles.
+const recentLogsSize = 10000
+
+var recentLogs = newRecentLogWriter(recentLogsSize)
+
+// RecentLogsMatching returns the latest log lines logged by this process that contain the given string, oldest first.
+// Only lines at or above the configured log level are kept, and only the last few thousand of them.
+func RecentLogsMatching(substr string) []string {
+	return recentLogs.matching([]byte(substr))
+}
+
+// recentLogWriter is an io.Writer to be used with zerolog which keeps the last lines written to it in a ring buffer.
+type recentLogWriter struct {
+	lines [][]byte
+	next  int
+	full  bool
+	mu    sync.Mutex
+}
+
+func newRecentLogWriter(size int) *recentLogWriter {
+	return &recentLogWriter{lines: make([][]byte, size)}
+}
+
+func (w *recentLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// the slot is reused once the buffer wraps around, and p must not be kept as zerolog reuses it
+	w.lines[w.next] = append(w.lines[w.next][:0], bytes.TrimRight(p, "\n")...)
+	w.next = (w.next + 1) % len(w.lines)
+	if w.next == 0 {
+		w.full = true
+	}
+	return len(p), nil
+}
+
+func (w *recentLogWriter) matching(substr []byte) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var matches []string
+	collect := func(lines [][]byte) {
+		for _, line := range lines {
+			if bytes.Contains(line, substr) {
+				matches = append(matches, string(line))
+			}
+		}
+	}
+	if w.full {
+		collect(w.lines[w.next:])
+	}
+	collect(w.lines[:w.next])
+	return matches
+}
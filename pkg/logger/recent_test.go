@@ -0,0 +1,28 @@
+//go:build unit || !integration
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentLogWriterKeepsLatestLines(t *testing.T) {
+	w := newRecentLogWriter(3)
+	logger := zerolog.New(w)
+	require.Empty(t, w.matching([]byte("job")))
+
+	logger.Info().Str("JobID", "job-1").Msg("first")
+	logger.Info().Str("JobID", "job-2").Msg("second")
+	require.Equal(t, []string{`{"level":"info","JobID":"job-1","message":"first"}`}, w.matching([]byte("job-1")))
+
+	logger.Info().Str("JobID", "job-1").Msg("third")
+	logger.Info().Str("JobID", "job-1").Msg("fourth")
+	require.Equal(t, []string{
+		`{"level":"info","JobID":"job-1","message":"third"}`,
+		`{"level":"info","JobID":"job-1","message":"fourth"}`,
+	}, w.matching([]byte("job-1")))
+	require.Len(t, w.matching([]byte("job-")), 3)
+}
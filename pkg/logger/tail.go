@@ -0,0 +1,24 @@
+package logger
+
+import "strings"
+
+// TailLines returns the last n lines of the output, or all of it if n is not positive.
+func TailLines(output string, n int) string {
+	if n <= 0 {
+		return output
+	}
+	// the newline at the very end terminates the last line rather than starting another
+	end := len(output)
+	if strings.HasSuffix(output, "\n") {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if output[i] == '\n' {
+			n--
+			if n == 0 {
+				return output[i+1:]
+			}
+		}
+	}
+	return output
+}
@@ -0,0 +1,17 @@
+//go:build unit || !integration
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailLines(t *testing.T) {
+	require.Equal(t, "two\nthree\n", TailLines("one\ntwo\nthree\n", 2))
+	require.Equal(t, "two\nthree", TailLines("one\ntwo\nthree", 2))
+	require.Equal(t, "one\ntwo\n", TailLines("one\ntwo\n", 5))
+	require.Equal(t, "one\ntwo\n", TailLines("one\ntwo\n", 0))
+	require.Equal(t, "", TailLines("", 1))
+}
@@ -10,7 +10,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
 	"github.com/rs/zerolog/log"
@@ -182,6 +182,7 @@ func (lm *LogManager) GetDefaultReaders(follow bool) (io.Reader, io.Reader) {
 		ctx:                   lm.ctx,
 		filename:              lm.file.Name(),
 		follow:                follow,
+		withHistory:           true,
 		rawMessageTransformer: nil,
 		broadcaster:           lm.broadcaster,
 		streamName:            LogStreamStdout,
@@ -191,6 +192,7 @@ func (lm *LogManager) GetDefaultReaders(follow bool) (io.Reader, io.Reader) {
 		ctx:                   lm.ctx,
 		filename:              lm.file.Name(),
 		follow:                follow,
+		withHistory:           true,
 		rawMessageTransformer: nil,
 		broadcaster:           lm.broadcaster,
 		streamName:            LogStreamStderr,
@@ -199,20 +201,25 @@ func (lm *LogManager) GetDefaultReaders(follow bool) (io.Reader, io.Reader) {
 	return stdout, stderr
 }
 
-func (lm *LogManager) GetMuxedReader(follow bool) io.ReadCloser {
+// GetMuxedReader returns the logs muxed in the logframe format, as docker muxes the output of containers. Only the
+// logs written from now on are included without history, and only the last tail lines already logged if tail is
+// positive.
+func (lm *LogManager) GetMuxedReader(withHistory bool, follow bool, tail int) io.ReadCloser {
 	transformer := func(msg *LogMessage) []byte {
-		tag := logger.StdoutStreamTag
+		tag := logframe.StdoutStreamTag
 		if msg.Stream == LogStreamStderr {
-			tag = logger.StderrStreamTag
+			tag = logframe.StderrStreamTag
 		}
-		df := logger.NewDataFrameFromData(tag, msg.Data)
-		return df.ToBytes()
+		df := logframe.NewDataFrameFromData(tag, msg.Data)
+		return df.ToMuxedBytes()
 	}
 
 	return NewLogReader(LogReaderOptions{
 		ctx:                   lm.ctx,
 		filename:              lm.file.Name(),
 		follow:                follow,
+		withHistory:           withHistory,
+		tail:                  tail,
 		rawMessageTransformer: transformer,
 		broadcaster:           lm.broadcaster,
 		streamName:            LogStreamStdout,
@@ -4,14 +4,16 @@ package wasmlogs
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"testing"
 	"time"
 
-	_ "github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -72,3 +74,70 @@ func (s *LogManagerTestSuite) TestLogManagerWrittenToDisk() {
 
 	lm.Close()
 }
+
+func (s *LogManagerTestSuite) TestLogManagerTail() {
+	lm, _ := NewLogManager(s.ctx, s.id)
+	defer lm.Close()
+
+	stdout, stderr := lm.GetWriters()
+	stdout.Write([]byte("one\ntwo\n"))
+	stderr.Write([]byte("three\n"))
+	stdout.Write([]byte("four\n"))
+	lm.Drain()
+
+	reader := lm.GetMuxedReader(true, false, 3)
+	defer reader.Close()
+	output, err := io.ReadAll(reader)
+	require.NoError(s.T(), err)
+
+	var lines []string
+	for buffer := bytes.NewBuffer(output); ; {
+		df, err := logframe.ReadMuxedFrame(buffer)
+		if err != nil {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d:%s", df.Tag, df.Data))
+	}
+	require.Equal(s.T(), []string{"1:two\n", "2:three\n", "1:four\n"}, lines)
+}
+
+func (s *LogManagerTestSuite) TestLogManagerWithoutHistory() {
+	lm, _ := NewLogManager(s.ctx, s.id)
+	defer lm.Close()
+
+	stdout, _ := lm.GetWriters()
+	stdout.Write([]byte("one\n"))
+	lm.Drain()
+
+	// as for docker, the history is skipped whatever the tail
+	reader := lm.GetMuxedReader(false, false, 1)
+	defer reader.Close()
+	output, err := io.ReadAll(reader)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), output)
+}
+
+func (s *LogManagerTestSuite) TestLogManagerLargeMessage() {
+	lm, _ := NewLogManager(s.ctx, s.id)
+	defer lm.Close()
+
+	large := bytes.Repeat([]byte("x"), 100_000)
+	stdout, _ := lm.GetWriters()
+	stdout.Write(large)
+	lm.Drain()
+
+	// io.ReadAll reads into a buffer smaller than the frame, which is read across several reads rather than cut short
+	reader := lm.GetMuxedReader(true, false, 0)
+	defer reader.Close()
+	output, err := io.ReadAll(reader)
+	require.NoError(s.T(), err)
+
+	df, err := logframe.ReadMuxedFrame(bytes.NewReader(output))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), large, df.Data)
+
+	stdoutReader, _ := lm.GetDefaultReaders(false)
+	data, err := io.ReadAll(stdoutReader)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), large, data)
+}
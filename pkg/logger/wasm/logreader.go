@@ -28,12 +28,18 @@ type LogReader struct {
 	endOfFileReached      bool
 	follow                bool
 	streamName            LogStreamType
+	// pending is what is left of the last message read when it did not fit in the buffer it was read into
+	pending []byte
+	// tailMessages are the messages of the last lines of the file when it was opened, which are read before the rest
+	tailMessages []*LogMessage
 }
 
 type LogReaderOptions struct {
 	ctx                   context.Context
 	filename              string
 	follow                bool
+	withHistory           bool
+	tail                  int
 	streamName            LogStreamType
 	rawMessageTransformer LogMessageTransformer
 	broadcaster           *generic.Broadcaster[*LogMessage]
@@ -47,7 +53,7 @@ func NewLogReader(options LogReaderOptions) *LogReader {
 	}
 
 	reader := bufio.NewReader(file)
-	return &LogReader{
+	logReader := &LogReader{
 		ctx:                   options.ctx,
 		file:                  file,
 		reader:                reader,
@@ -57,6 +63,64 @@ func NewLogReader(options LogReaderOptions) *LogReader {
 		streamName:            options.streamName,
 		follow:                options.follow,
 	}
+	if !options.withHistory {
+		// as for docker, the history is skipped whatever the tail
+		if _, err = logReader.readHistory(); err != nil {
+			log.Ctx(options.ctx).Err(err).Msg("unable to skip the history of logfile")
+		}
+	} else if options.tail > 0 {
+		messages, err := logReader.readHistory()
+		if err != nil {
+			log.Ctx(options.ctx).Err(err).Msg("unable to read the tail of logfile")
+		}
+		logReader.tailMessages = tailLines(messages, options.tail)
+	}
+	return logReader
+}
+
+// readHistory reads the messages already in the file, so that only the messages written after are read from the
+// file.
+func (r *LogReader) readHistory() ([]*LogMessage, error) {
+	var messages []*LogMessage
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a partially written message is read again once it is complete
+			_, _ = r.file.Seek(-int64(len(data)), io.SeekCurrent)
+			r.reader.Reset(r.file)
+			break
+		}
+		if err != nil {
+			return messages, err
+		}
+		var msg LogMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			return messages, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, nil
+}
+
+// tailLines returns the messages holding the last n lines, with the first one trimmed to the start of its line.
+func tailLines(messages []*LogMessage, n int) []*LogMessage {
+	newlines := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		data := messages[i].Data
+		for j := len(data) - 1; j >= 0; j-- {
+			// the newline at the very end terminates the last line rather than starting another
+			if data[j] != '\n' || (i == len(messages)-1 && j == len(data)-1) {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				trimmed := *messages[i]
+				trimmed.Data = data[j+1:]
+				return append([]*LogMessage{&trimmed}, messages[i+1:]...)
+			}
+		}
+	}
+	return messages
 }
 
 func (r *LogReader) Read(b []byte) (int, error) {
@@ -64,6 +128,21 @@ func (r *LogReader) Read(b []byte) (int, error) {
 		return 0, io.EOF
 	}
 
+	if len(r.pending) > 0 {
+		return r.emit(b, r.pending), nil
+	}
+
+	for len(r.tailMessages) > 0 {
+		msg := r.tailMessages[0]
+		r.tailMessages = r.tailMessages[1:]
+		if r.rawMessageTransformer != nil {
+			return r.emit(b, r.rawMessageTransformer(msg)), nil
+		}
+		if msg.Stream == r.streamName {
+			return r.emit(b, msg.Data), nil
+		}
+	}
+
 	if !r.endOfFileReached {
 		// If we are not at the end of the file, read some more. If we didn't
 		// reach the end of the file, then return the (n, error) pair for the
@@ -89,15 +168,11 @@ func (r *LogReader) Read(b []byte) (int, error) {
 
 		select {
 		case m, more := <-r.subscriptionCh:
-			copied := 0
 			if more {
 				if r.rawMessageTransformer != nil {
-					data := r.rawMessageTransformer(m)
-					copied = copy(b, data)
-				} else {
-					copied = copy(b, m.Data)
+					return r.emit(b, r.rawMessageTransformer(m)), nil
 				}
-				return copied, nil
+				return r.emit(b, m.Data), nil
 			} else {
 				// If the channel was closed, then there is nothing else
 				// for us to do.
@@ -140,20 +215,25 @@ func (r *LogReader) readFile(b []byte) (int, error) {
 		// given one of those functions, use it to transform the data
 		// into a format we want.
 		if r.rawMessageTransformer != nil {
-			data := r.rawMessageTransformer(&msg)
-			copied := copy(b, data)
-			return copied, nil
+			return r.emit(b, r.rawMessageTransformer(&msg)), nil
 		}
 
 		if msg.Stream == r.streamName {
-			copied := copy(b, msg.Data)
-			return copied, nil
+			return r.emit(b, msg.Data), nil
 		}
 	}
 
 	// unreachable
 }
 
+// emit copies the data into the buffer, and keeps what does not fit to be read next, so that messages larger than
+// the buffer are not cut short.
+func (r *LogReader) emit(b []byte, data []byte) int {
+	copied := copy(b, data)
+	r.pending = data[copied:]
+	return copied
+}
+
 func (r *LogReader) Close() error {
 	r.file.Close()
 	if r.subscribed {
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification events are encoded with.
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventContentType is the media type of a single event in the structured mode of CloudEvents.
+	CloudEventContentType = "application/cloudevents+json"
+	// CloudEventsBatchContentType is the media type of a batch of events in the structured mode of CloudEvents.
+	CloudEventsBatchContentType = "application/cloudevents-batch+json"
+
+	// CloudEventTypeJobEvent prefixes the type of events emitted by nodes, followed by the name of the event
+	// (e.g. org.bacalhau.job.event.ResultsProposed). Their data is a JobEvent.
+	CloudEventTypeJobEvent = "org.bacalhau.job.event."
+	// CloudEventTypeJobState prefixes the type of changes to the state of a job, followed by the new state
+	// (e.g. org.bacalhau.job.state.Completed). Their data is a JobHistory.
+	CloudEventTypeJobState = "org.bacalhau.job.state."
+	// CloudEventTypeExecutionState prefixes the type of changes to the state of an execution, followed by the new
+	// state (e.g. org.bacalhau.execution.state.BidAccepted). Their data is a JobHistory.
+	CloudEventTypeExecutionState = "org.bacalhau.execution.state."
+)
+
+// CloudEvent is a job lifecycle event in the JSON format of CloudEvents (https://cloudevents.io), so that it can be
+// routed by event-driven systems without knowing about bacalhau. The job and execution the event is about are also
+// set as the jobid and executionid extension attributes, for systems that filter events on their attributes.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	JobID       string `json:"jobid,omitempty"`
+	ExecutionID string `json:"executionid,omitempty"`
+}
+
+// CloudEventSource is the source of the events exported by a node.
+func CloudEventSource(nodeID string) string {
+	return "/bacalhau/nodes/" + nodeID
+}
+
+// NewJobEventCloudEvent encodes an event emitted by a node. The ID of the event is derived from its content, so that
+// the same event delivered twice can be deduplicated.
+func NewJobEventCloudEvent(source string, event JobEvent) (CloudEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s\x00%d",
+		event.JobID, event.ExecutionID, event.SourceNodeID, event.EventName, event.EventTime.UnixNano())
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              hex.EncodeToString(hash.Sum(nil)[:16]),
+		Source:          source,
+		Type:            CloudEventTypeJobEvent + event.EventName.String(),
+		Subject:         cloudEventSubject(event.JobID, event.ExecutionID),
+		Time:            event.EventTime.UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+		JobID:           event.JobID,
+		ExecutionID:     event.ExecutionID,
+	}, nil
+}
+
+// NewJobHistoryCloudEvent encodes a change to the state of a job or of one of its executions.
+func NewJobHistoryCloudEvent(source string, history JobHistory) (CloudEvent, error) {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	event := CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Source:          source,
+		Subject:         cloudEventSubject(history.JobID, history.ComputeReference),
+		Time:            history.Time.UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+		JobID:           history.JobID,
+		ExecutionID:     history.ComputeReference,
+	}
+	switch {
+	case history.Type == JobHistoryTypeExecutionLevel && history.ExecutionState != nil:
+		event.ID = fmt.Sprintf("%s/%s/%d", history.JobID, history.ComputeReference, history.NewVersion)
+		event.Type = CloudEventTypeExecutionState + history.ExecutionState.New.String()
+	case history.Type == JobHistoryTypeJobLevel && history.JobState != nil:
+		event.ID = fmt.Sprintf("%s/%d", history.JobID, history.NewVersion)
+		event.Type = CloudEventTypeJobState + history.JobState.New.String()
+	default:
+		return CloudEvent{}, fmt.Errorf("job history of type %s has no state change", history.Type)
+	}
+	return event, nil
+}
+
+func cloudEventSubject(jobID, executionID string) string {
+	if executionID == "" {
+		return "jobs/" + jobID
+	}
+	return "jobs/" + jobID + "/executions/" + executionID
+}
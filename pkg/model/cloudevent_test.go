@@ -0,0 +1,87 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJobHistoryCloudEvent(t *testing.T) {
+	source := CloudEventSource("QmRequester")
+	at := time.Date(2023, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	jobEvent, err := NewJobHistoryCloudEvent(source, JobHistory{
+		Type:       JobHistoryTypeJobLevel,
+		JobID:      "job-1",
+		JobState:   &StateChange[JobStateType]{Previous: JobStateInProgress, New: JobStateCompleted},
+		NewVersion: 3,
+		Time:       at,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "job-1/3", jobEvent.ID)
+	require.Equal(t, "/bacalhau/nodes/QmRequester", jobEvent.Source)
+	require.Equal(t, "org.bacalhau.job.state.Completed", jobEvent.Type)
+	require.Equal(t, "jobs/job-1", jobEvent.Subject)
+	require.Equal(t, at.UTC(), jobEvent.Time)
+	require.Equal(t, "job-1", jobEvent.JobID)
+	require.Empty(t, jobEvent.ExecutionID)
+
+	executionEvent, err := NewJobHistoryCloudEvent(source, JobHistory{
+		Type:             JobHistoryTypeExecutionLevel,
+		JobID:            "job-1",
+		NodeID:           "QmCompute",
+		ComputeReference: "e-1",
+		ExecutionState:   &StateChange[ExecutionStateType]{Previous: ExecutionStateNew, New: ExecutionStateBidAccepted},
+		NewVersion:       2,
+		Time:             at,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "job-1/e-1/2", executionEvent.ID)
+	require.Equal(t, "org.bacalhau.execution.state.BidAccepted", executionEvent.Type)
+	require.Equal(t, "jobs/job-1/executions/e-1", executionEvent.Subject)
+	require.Equal(t, "e-1", executionEvent.ExecutionID)
+
+	var data JobHistory
+	require.NoError(t, json.Unmarshal(executionEvent.Data, &data))
+	require.Equal(t, "QmCompute", data.NodeID)
+
+	_, err = NewJobHistoryCloudEvent(source, JobHistory{Type: JobHistoryTypeJobLevel, JobID: "job-1"})
+	require.Error(t, err)
+}
+
+func TestNewJobEventCloudEvent(t *testing.T) {
+	event := JobEvent{
+		JobID:        "job-1",
+		ExecutionID:  "e-1",
+		SourceNodeID: "QmCompute",
+		EventName:    JobEventResultsProposed,
+		EventTime:    time.Now(),
+	}
+	cloudEvent, err := NewJobEventCloudEvent(CloudEventSource("QmRequester"), event)
+	require.NoError(t, err)
+	require.Equal(t, "org.bacalhau.job.event.ResultsProposed", cloudEvent.Type)
+	require.Equal(t, "jobs/job-1/executions/e-1", cloudEvent.Subject)
+
+	// the same event gets the same ID, and a different event a different one
+	again, err := NewJobEventCloudEvent(CloudEventSource("QmRequester"), event)
+	require.NoError(t, err)
+	require.Equal(t, cloudEvent.ID, again.ID)
+	event.EventName = JobEventResultsPublished
+	other, err := NewJobEventCloudEvent(CloudEventSource("QmRequester"), event)
+	require.NoError(t, err)
+	require.NotEqual(t, cloudEvent.ID, other.ID)
+
+	// required attributes are always encoded
+	encoded, err := json.Marshal(cloudEvent)
+	require.NoError(t, err)
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &attributes))
+	for _, attribute := range []string{"specversion", "id", "source", "type"} {
+		require.NotEmpty(t, attributes[attribute], attribute)
+	}
+	require.Equal(t, "1.0", attributes["specversion"])
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type RunCommandResult struct {
 	// stdout of the run. Yaml provided for `describe` output
 	STDOUT string `json:"stdout"`
@@ -18,6 +20,74 @@ type RunCommandResult struct {
 
 	// Runner error
 	ErrorMsg string `json:"runnerError"`
+
+	// whether the run was killed for running out of memory, for executors that can tell
+	OOMKilled bool `json:"oomKilled,omitempty"`
+
+	// resources actually consumed by the run, for executors that measure them
+	ResourceUsage *ResourceUsageData `json:"resourceUsage,omitempty"`
+
+	// the environment of the compute node the run happened in
+	Environment *ExecutionEnvironment `json:"environment,omitempty"`
+
+	// how long fetching the inputs took at the start of the run, for executors that fetch them
+	InputsFetchDuration time.Duration `json:"inputsFetchDuration,omitempty"`
+
+	// how long the executor took to get the job ready to run once its inputs were fetched, for executors that measure it
+	StartupDuration time.Duration `json:"startupDuration,omitempty"`
+
+	// whether the run started from an instance taken from the warm pool of the executor, for executors with a warm pool
+	WarmPool WarmPoolResult `json:"warmPool,omitempty"`
+
+	// size in bytes of the result proposed for publishing
+	ResultSize uint64 `json:"resultSize,omitempty"`
+
+	// the files of the result proposed for publishing, unless it has more than MaxResultManifestFiles files
+	ResultManifest *ResultManifest `json:"resultManifest,omitempty"`
+}
+
+// WarmPoolResult tells whether a run found a warm instance in the warm pool of its executor.
+type WarmPoolResult string
+
+const (
+	// WarmPoolHit means the run started from a warm instance and skipped creating its own.
+	WarmPoolHit WarmPoolResult = "hit"
+	// WarmPoolMiss means the run could have used a warm instance, but none was ready so it created its own.
+	WarmPoolMiss WarmPoolResult = "miss"
+)
+
+// ExecutionEnvironment describes the environment an execution actually ran in, so that users can cite it and
+// debug results that differ between nodes. Fields are empty if they are unknown or don't apply to the executor.
+type ExecutionEnvironment struct {
+	OS            string `json:"os,omitempty"`
+	Architecture  string `json:"architecture,omitempty"`
+	Kernel        string `json:"kernel,omitempty"`
+	CPUModel      string `json:"cpuModel,omitempty"`
+	GPUDriver     string `json:"gpuDriver,omitempty"`
+	DockerVersion string `json:"dockerVersion,omitempty"`
+	// the digest of the image that was run, which may differ from the digest the job was submitted with
+	ImageDigest string `json:"imageDigest,omitempty"`
+}
+
+// Metadata returns the known fields of the environment as storage spec metadata, keyed by their JSON name
+// prefixed with "environment.".
+func (e ExecutionEnvironment) Metadata() map[string]string {
+	fields := map[string]string{
+		"os":            e.OS,
+		"architecture":  e.Architecture,
+		"kernel":        e.Kernel,
+		"cpuModel":      e.CPUModel,
+		"gpuDriver":     e.GPUDriver,
+		"dockerVersion": e.DockerVersion,
+		"imageDigest":   e.ImageDigest,
+	}
+	metadata := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if value != "" {
+			metadata["environment."+name] = value
+		}
+	}
+	return metadata
 }
 
 func NewRunCommandResult() *RunCommandResult {
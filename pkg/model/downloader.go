@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/c2h5oh/datasize"
+)
 
 const (
 	DownloadFilenameStdout   = "stdout"
@@ -10,13 +14,54 @@ const (
 	DownloadFolderPerm       = 0755
 	DownloadFilePerm         = 0644
 	DefaultIPFSTimeout       = 5 * time.Minute
+	// DefaultGatewayFallbackTimeout is how long to try fetching results from the IPFS network before falling back
+	// to the configured gateways.
+	DefaultGatewayFallbackTimeout = 1 * time.Minute
+	// DefaultDownloadRetries is how many times fetching a file of a result from the IPFS network is retried.
+	DefaultDownloadRetries = 3
+	// DefaultMaxDownloadSize is the largest archived result that is extracted when results are downloaded.
+	DefaultMaxDownloadSize = 10 * datasize.GB
 )
 
+// DefaultIPFSGateways are the gateways results are fetched from when they can't be retrieved from the IPFS network.
+// There are none by default, as gateways learn which results are fetched, so users opt in to the gateways they
+// trust with that, such as https://ipfs.io. Content fetched from gateways is verified against its CID.
+var DefaultIPFSGateways []string
+
+// LocalResultNamePrefix prefixes the names of the results published with the local publisher, which the compute
+// nodes serve themselves to the clients allowed to see their job.
+const LocalResultNamePrefix = "local-"
+
 type DownloaderSettings struct {
 	Timeout        time.Duration
 	OutputDir      string
 	IPFSSwarmAddrs string
-	SingleFile     string
-	LocalIPFS      bool
-	Raw            bool
+	// IPFSGateways is a comma-separated list of HTTP gateways to fall back to.
+	IPFSGateways string
+	// GatewayFallbackTimeout is how long to try the IPFS network before falling back to the gateways.
+	GatewayFallbackTimeout time.Duration
+	// Retries is how many times fetching a file of a result from the IPFS network is retried, with backoff, before
+	// giving up on it.
+	Retries int
+	// MaxDownloadSize is the largest a result published as an archive can be once extracted, so that a result can't
+	// fill the disk it is downloaded to.
+	MaxDownloadSize datasize.ByteSize
+	SingleFile      string
+	// NodeID restricts the download to the results published by a single node, given its ID or a prefix of it.
+	NodeID    string
+	LocalIPFS bool
+	Raw       bool
+	// Dedupe materializes files that are identical across results only once, and merges them without conflict.
+	Dedupe bool
+	// LocalResultsDirectories are the directories of the local filesystem that results published to a local directory
+	// are copied from, such as the --local-publisher-directory of a node on the same machine. The path of those results
+	// is set by the node, so results elsewhere are not downloaded.
+	LocalResultsDirectories []string
+	// NodeHeaders are sent with the requests for the results served by compute nodes, such as the client ID and API
+	// token that allow the client to see them. They are never sent to other URLs.
+	NodeHeaders map[string]string
+	// Include and Exclude are glob patterns selecting the files of the results to download by their path, such as
+	// outputs/*.csv. Everything is downloaded when both are empty.
+	Include []string
+	Exclude []string
 }
@@ -15,6 +15,7 @@ const (
 	EngineWasm
 	EngineLanguage   // wraps python_wasm
 	EnginePythonWasm // wraps docker
+	EngineProcess    // runs the entrypoint as a sandboxed process on the host
 	engineDone       // must be last
 )
 
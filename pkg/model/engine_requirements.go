@@ -0,0 +1,113 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"golang.org/x/exp/slices"
+)
+
+// Features of engines that jobs can require.
+const (
+	// EngineFeatureNvidiaRuntime is supported by docker engines with the nvidia container runtime installed.
+	EngineFeatureNvidiaRuntime = "nvidia-runtime"
+	// EngineFeatureWASIPreview1 is supported by wasm engines implementing wasi_snapshot_preview1.
+	EngineFeatureWASIPreview1 = "wasi-p1"
+	// EngineFeatureWASIPreview2 is supported by wasm engines implementing the WASI preview 2 component model.
+	EngineFeatureWASIPreview2 = "wasi-p2"
+)
+
+// EngineRequirements are what a job needs from the engine running it beyond its type, so that nodes whose engine is
+// too old or lacks a feature decline the job when it is scheduled rather than fail it while it runs.
+type EngineRequirements struct {
+	// MinVersion is the oldest version of the engine that can run the job, such as 24.0 for docker.
+	MinVersion string `json:"MinVersion,omitempty"`
+	// Features are the features the engine must support, such as nvidia-runtime for docker or wasi-p2 for wasm.
+	Features []string `json:"Features,omitempty"`
+}
+
+// IsEmpty returns true if the job has no requirements beyond the type of its engine.
+func (r *EngineRequirements) IsEmpty() bool {
+	return r == nil || (r.MinVersion == "" && len(r.Features) == 0)
+}
+
+// EngineInfo is the version of an engine installed on a compute node and the features it supports.
+type EngineInfo struct {
+	Engine Engine `json:"Engine"`
+	// Version is the version of the engine, or empty if the engine does not report it.
+	Version  string   `json:"Version,omitempty"`
+	Features []string `json:"Features,omitempty"`
+}
+
+// UnmetEngineRequirements are the requirements of a job that an engine does not meet.
+type UnmetEngineRequirements struct {
+	Engine Engine `json:"Engine"`
+	// MinVersion is the version the job requires, if the engine is older or does not report its version.
+	MinVersion string `json:"MinVersion,omitempty"`
+	// Version is the version of the engine.
+	Version string `json:"Version,omitempty"`
+	// MissingFeatures are the features the job requires that the engine does not support.
+	MissingFeatures []string `json:"MissingFeatures,omitempty"`
+}
+
+// IsEmpty returns true if the engine meets every requirement of the job.
+func (u UnmetEngineRequirements) IsEmpty() bool {
+	return u.MinVersion == "" && len(u.MissingFeatures) == 0
+}
+
+func (u UnmetEngineRequirements) String() string {
+	var unmet []string
+	if u.MinVersion != "" {
+		version := u.Version
+		if version == "" {
+			version = "unknown"
+		}
+		unmet = append(unmet, fmt.Sprintf("version %s is required but the engine version is %s", u.MinVersion, version))
+	}
+	if len(u.MissingFeatures) > 0 {
+		unmet = append(unmet, fmt.Sprintf("features %s are not supported", strings.Join(u.MissingFeatures, ", ")))
+	}
+	return fmt.Sprintf("%s engine does not meet the requirements of the job: %s", u.Engine, strings.Join(unmet, "; "))
+}
+
+// Check returns the requirements the engine does not meet. Engines that do not report their version never meet a
+// minimum version.
+func (info EngineInfo) Check(requirements *EngineRequirements) UnmetEngineRequirements {
+	unmet := UnmetEngineRequirements{Engine: info.Engine, Version: info.Version}
+	if requirements.IsEmpty() {
+		return unmet
+	}
+	if requirements.MinVersion != "" && !info.HasMinVersion(requirements.MinVersion) {
+		unmet.MinVersion = requirements.MinVersion
+	}
+	for _, feature := range requirements.Features {
+		if !slices.Contains(info.Features, feature) {
+			unmet.MissingFeatures = append(unmet.MissingFeatures, feature)
+		}
+	}
+	return unmet
+}
+
+// HasMinVersion returns true if the version of the engine is known and at least the minimum version.
+func (info EngineInfo) HasMinVersion(minVersion string) bool {
+	required, err := semver.NewVersion(minVersion)
+	if err != nil {
+		return false
+	}
+	version, err := semver.NewVersion(info.Version)
+	if err != nil {
+		return false
+	}
+	return !version.LessThan(required)
+}
+
+// GetEngineInfo returns the info the node reports about the engine, if any.
+func (n ComputeNodeInfo) GetEngineInfo(engine Engine) (EngineInfo, bool) {
+	for _, info := range n.EngineInfo {
+		if info.Engine == engine {
+			return info, true
+		}
+	}
+	return EngineInfo{}, false
+}
@@ -0,0 +1,27 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineInfoCheck(t *testing.T) {
+	info := EngineInfo{Engine: EngineWasm, Version: "1.0.1", Features: []string{EngineFeatureWASIPreview1}}
+
+	require.True(t, info.Check(nil).IsEmpty())
+	require.True(t, info.Check(&EngineRequirements{MinVersion: "1.0", Features: []string{EngineFeatureWASIPreview1}}).IsEmpty())
+
+	unmet := info.Check(&EngineRequirements{MinVersion: "1.2", Features: []string{EngineFeatureWASIPreview2}})
+	require.Equal(t, UnmetEngineRequirements{
+		Engine:          EngineWasm,
+		MinVersion:      "1.2",
+		Version:         "1.0.1",
+		MissingFeatures: []string{EngineFeatureWASIPreview2},
+	}, unmet)
+
+	// the version of engines that don't report it never meets a minimum version
+	require.False(t, EngineInfo{Engine: EngineWasm}.Check(&EngineRequirements{MinVersion: "0.1"}).IsEmpty())
+}
@@ -14,12 +14,13 @@ func _() {
 	_ = x[EngineWasm-3]
 	_ = x[EngineLanguage-4]
 	_ = x[EnginePythonWasm-5]
-	_ = x[engineDone-6]
+	_ = x[EngineProcess-6]
+	_ = x[engineDone-7]
 }
 
-const _Engine_name = "engineUnknownNoopDockerWasmLanguagePythonWasmengineDone"
+const _Engine_name = "engineUnknownNoopDockerWasmLanguagePythonWasmProcessengineDone"
 
-var _Engine_index = [...]uint8{0, 13, 17, 23, 27, 35, 45, 55}
+var _Engine_index = [...]uint8{0, 13, 17, 23, 27, 35, 45, 52, 62}
 
 func (i Engine) String() string {
 	if i < 0 || i >= Engine(len(_Engine_index)-1) {
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// DangerousEnvironmentVariables change how the dynamic linker loads programs, which lets a job inject code into every
+// process of its container, including the tools the image runs on its behalf. They are stripped from docker and process
+// jobs when they are submitted.
+var DangerousEnvironmentVariables = []string{
+	"LD_PRELOAD",
+	"LD_LIBRARY_PATH",
+	"LD_AUDIT",
+	"LD_DEBUG_OUTPUT",
+	"LD_PROFILE",
+	"LD_PROFILE_OUTPUT",
+	"GCONV_PATH",
+	"DYLD_INSERT_LIBRARIES",
+	"DYLD_LIBRARY_PATH",
+	"DYLD_FRAMEWORK_PATH",
+}
+
+// IsDangerousEnvironmentVariable returns true if the variable is one of DangerousEnvironmentVariables.
+func IsDangerousEnvironmentVariable(name string) bool {
+	return slices.Contains(DangerousEnvironmentVariables, name)
+}
+
+// EnvironmentVariableName returns the name of an environment variable given as KEY=VALUE.
+func EnvironmentVariableName(env string) string {
+	name, _, _ := strings.Cut(env, "=")
+	return name
+}
+
+// EnvironmentVariableNames returns the sorted names of the environment variables the job sets, whatever its engine.
+func (s Spec) EnvironmentVariableNames() []string {
+	names := make(map[string]bool)
+	for _, env := range s.Docker.EnvironmentVariables {
+		names[EnvironmentVariableName(env)] = true
+	}
+	for _, env := range s.Process.EnvironmentVariables {
+		names[EnvironmentVariableName(env)] = true
+	}
+	for name := range s.Wasm.EnvironmentVariables {
+		names[name] = true
+	}
+	sorted := maps.Keys(names)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// EnvironmentVariablePolicy restricts the environment variables jobs can set by patterns of their names, such as AWS_*
+// or MY_VAR. Malformed patterns match nothing.
+type EnvironmentVariablePolicy struct {
+	// Allow are the patterns of the variables jobs can set. Any variable that is not denied can be set if empty.
+	Allow []string
+	// Deny are the patterns of the variables jobs can't set, even if they are allowed.
+	Deny []string
+}
+
+// Check returns an error if the policy does not let jobs set the variable.
+func (p EnvironmentVariablePolicy) Check(name string) error {
+	if MatchesAny(p.Deny, name) {
+		return fmt.Errorf("environment variable %s is denied", name)
+	}
+	if len(p.Allow) > 0 && !MatchesAny(p.Allow, name) {
+		return fmt.Errorf("environment variable %s is not allowed", name)
+	}
+	return nil
+}
+
+// MatchesAny returns true if the name matches one of the glob patterns. Malformed patterns match nothing.
+func MatchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
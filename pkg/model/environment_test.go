@@ -0,0 +1,38 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentVariableNames(t *testing.T) {
+	spec := Spec{
+		Docker:  JobSpecDocker{EnvironmentVariables: []string{"B=1", "A=x=y", "EMPTY"}},
+		Process: JobSpecProcess{EnvironmentVariables: []string{"B=2"}},
+		Wasm:    JobSpecWasm{EnvironmentVariables: map[string]string{"C": "3"}},
+	}
+	require.Equal(t, []string{"A", "B", "C", "EMPTY"}, spec.EnvironmentVariableNames())
+	require.Empty(t, Spec{}.EnvironmentVariableNames())
+}
+
+func TestIsDangerousEnvironmentVariable(t *testing.T) {
+	require.True(t, IsDangerousEnvironmentVariable("LD_PRELOAD"))
+	require.False(t, IsDangerousEnvironmentVariable("LD_PRELOAD_NOT"))
+	require.False(t, IsDangerousEnvironmentVariable("PATH"))
+}
+
+func TestEnvironmentVariablePolicy(t *testing.T) {
+	require.NoError(t, EnvironmentVariablePolicy{}.Check("ANY"))
+
+	policy := EnvironmentVariablePolicy{Allow: []string{"AWS_*", "HOME"}, Deny: []string{"AWS_SECRET_*"}}
+	require.NoError(t, policy.Check("AWS_REGION"))
+	require.NoError(t, policy.Check("HOME"))
+	require.ErrorContains(t, policy.Check("PATH"), "not allowed")
+	require.ErrorContains(t, policy.Check("AWS_SECRET_ACCESS_KEY"), "denied")
+
+	// malformed patterns match nothing
+	require.NoError(t, EnvironmentVariablePolicy{Deny: []string{"[AWS"}}.Check("[AWS"))
+}
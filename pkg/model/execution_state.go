@@ -100,16 +100,45 @@ type ExecutionState struct {
 	// Set to true iff the compute node accepted the ask for a bid, and intends
 	// to run the job if the bid is accepted by the requester.
 	AcceptedAskForBid bool `json:"AcceptedAskForBid"`
+	// ResultSizeLimit is the largest result in bytes the compute node agreed to publish when it bid on the job.
+	// Zero if the node and the job set no limit.
+	ResultSizeLimit uint64 `json:"ResultSizeLimit,omitempty"`
+	// InputsSize is the number of bytes of inputs the compute node said it has to fetch when it bid on the job.
+	InputsSize uint64 `json:"InputsSize,omitempty"`
+	// InputsFetchEstimate is how long the compute node expected fetching the inputs to take when it bid on the job.
+	InputsFetchEstimate time.Duration `json:"InputsFetchEstimate,omitempty"`
+	// InputsEstimated is true if the compute node could estimate fetching the inputs when it bid on the job.
+	InputsEstimated bool `json:"InputsEstimated,omitempty"`
 	// an arbitrary status message
 	Status string `json:"Status,omitempty"`
+	// Set to true if the execution failed because the compute node preempted it for a job of higher priority,
+	// in which case the job is always retried
+	Preempted bool `json:"Preempted,omitempty"`
+	// Set to true if the execution was canceled to move it away from its compute node, in which case the job is not
+	// scheduled on that node again
+	Migrated bool `json:"Migrated,omitempty"`
+	// Migration is set on an execution asked to take over an execution migrated away from its node. The migrated
+	// execution is only canceled once the bid of this one is accepted.
+	Migration *ExecutionMigration `json:"Migration,omitempty"`
+	// Shard is the index of the shard of the job the execution runs, among the executions the job runs at once.
+	// An execution retrying a failed one takes over its shard.
+	Shard int `json:"Shard,omitempty"`
 	// the proposed results for this execution
 	// this will be resolved by the verifier somehow
 	VerificationProposal []byte             `json:"VerificationProposal,omitempty"`
 	VerificationResult   VerificationResult `json:"VerificationResult,omitempty"`
 	PublishedResult      StorageSpec        `json:"PublishedResults,omitempty"`
+	// PublishedVolumes are the output volumes that were published on their own, by volume name
+	PublishedVolumes map[string]StorageSpec `json:"PublishedVolumes,omitempty"`
 
 	// RunOutput of the job
 	RunOutput *RunCommandResult `json:"RunOutput,omitempty"`
+	// FailureReason is the likely cause of the execution failing or exiting with a non-zero exit code, as classified
+	// by the compute node, if it is a common one
+	FailureReason *FailureReason `json:"FailureReason,omitempty"`
+	// Queue is the position of the execution in the queue of its compute node, if it is waiting there for capacity.
+	// It is taken from the latest info published by the node when the state is read, and is not stored.
+	Queue *QueuedExecution `json:"Queue,omitempty"`
 	// Version is the version of the job state. It is incremented every time the job state is updated.
 	Version int `json:"Version"`
 	// CreateTime is the time when the job was created.
@@ -118,6 +147,21 @@ type ExecutionState struct {
 	UpdateTime time.Time `json:"UpdateTime"`
 }
 
+// ExecutionMigration is the execution an execution takes over once its bid is accepted, when migrating executions
+// away from a node.
+type ExecutionMigration struct {
+	// From is the compute reference of the execution migrated away from its node.
+	From string `json:"From"`
+	// Status is the status the migrated execution is canceled with.
+	Status string `json:"Status,omitempty"`
+}
+
+// IsPendingMigration returns true if the execution is asked to take over a migrated execution, and its bid has not
+// been accepted yet.
+func (e ExecutionState) IsPendingMigration() bool {
+	return e.Migration != nil && (e.State == ExecutionStateAskForBid || e.State == ExecutionStateAskForBidAccepted)
+}
+
 // ID returns the ID for this execution
 func (e ExecutionState) ID() ExecutionID {
 	return ExecutionID{JobID: e.JobID, NodeID: e.NodeID, ExecutionID: e.ComputeReference}
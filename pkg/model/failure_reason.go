@@ -0,0 +1,33 @@
+package model
+
+import "fmt"
+
+// FailureCategory is a common cause of failed executions, recognised from their exit code and output.
+type FailureCategory string
+
+const (
+	// FailureOutOfMemory means the job ran out of memory, either on the host or on its GPU.
+	FailureOutOfMemory FailureCategory = "OutOfMemory"
+	// FailureCUDAVersionMismatch means the CUDA version the job was built for is not supported by the GPU driver.
+	FailureCUDAVersionMismatch FailureCategory = "CUDAVersionMismatch"
+	// FailureModuleImport means a module or library the job imports is missing from its image.
+	FailureModuleImport FailureCategory = "ModuleImportError"
+	// FailureMountPermissionDenied means the job was denied access to one of its input or output volumes.
+	FailureMountPermissionDenied FailureCategory = "MountPermissionDenied"
+)
+
+// FailureReason is the likely cause of a failed execution, classified by the compute node that ran it, with a hint
+// on how to fix it.
+type FailureReason struct {
+	Category FailureCategory `json:"Category"`
+	// Message describes the cause in plain words.
+	Message string `json:"Message"`
+	// Hint suggests how to change the job so that it does not fail again.
+	Hint string `json:"Hint"`
+	// Evidence is the line of output the cause was recognised from, if any.
+	Evidence string `json:"Evidence,omitempty"`
+}
+
+func (r FailureReason) String() string {
+	return fmt.Sprintf("%s: %s %s", r.Category, r.Message, r.Hint)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/imdario/mergo"
+	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/selection"
 )
 
@@ -38,7 +39,27 @@ type Metadata struct {
 	ClientID string `json:"ClientID,omitempty" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
 
 	Requester JobRequester `json:"Requester,omitempty"`
+
+	// The version of the job spec, which is bumped every time the spec of a running job is updated.
+	SpecVersion int `json:"SpecVersion,omitempty" example:"1"`
+
+	// The IDs of other clients that submitted the same spec, and were given this job instead of a new one.
+	Watchers []string `json:"Watchers,omitempty"`
+
+	// The namespace of the authenticated identity that submitted the job, if the requester authenticates its clients.
+	// Only callers of the same namespace can see and change the job.
+	Namespace string `json:"Namespace,omitempty"`
+
+	// The name given to the job when it was submitted, which is unique among the jobs of its namespace and can be used
+	// in place of its ID.
+	Name string `json:"Name,omitempty" example:"my-training-run"`
 }
+
+// IsVisibleTo returns true if the job was submitted by the client, either as its creator or as a watcher.
+func (m Metadata) IsVisibleTo(clientID string) bool {
+	return m.ClientID == clientID || slices.Contains(m.Watchers, clientID)
+}
+
 type JobRequester struct {
 	// The ID of the requester node that owns this job.
 	RequesterNodeID string `json:"RequesterNodeID,omitempty" example:"QmXaXu9N5GNetatsvwnTfQqNtSeKAD6uCmarbh3LMRYAcF"`
@@ -105,6 +126,10 @@ type Deal struct {
 	// jobs will be spread evenly across the network (assuming that this value
 	// is some large proportion of the size of the network).
 	MinBids int `json:"MinBids,omitempty"`
+	// Adaptive makes the requester start with a single execution and only run additional independent executions, up
+	// to Concurrency, when the verifier needs more results to verify the job or the node that ran it is not trusted
+	// yet. This avoids running Concurrency copies of jobs that one execution would have been enough for.
+	Adaptive bool `json:"Adaptive,omitempty"`
 }
 
 // GetConcurrency returns the concurrency value from the deal
@@ -118,6 +143,9 @@ func (d Deal) GetConcurrency() int {
 // GetConfidence returns the confidence value from the deal
 func (d Deal) GetConfidence() int {
 	if d.Confidence == 0 {
+		if d.Adaptive {
+			return 1
+		}
 		return d.GetConcurrency()
 	}
 	return d.Confidence
@@ -139,6 +167,16 @@ type LabelSelectorRequirement struct {
 	Values []string `json:"Values,omitempty"`
 }
 
+// OutputContract describes a file that a job is expected to produce, and optionally what it must contain.
+type OutputContract struct {
+	// Path of the file in the results of the job, e.g. outputs/result.json
+	Path string `json:"Path"`
+	// JSONSchema is the JSON Schema the file must be valid against, for JSON files.
+	JSONSchema map[string]interface{} `json:"JSONSchema,omitempty"`
+	// CSVColumns are the columns the header of the file must have, for CSV files.
+	CSVColumns []string `json:"CSVColumns,omitempty"`
+}
+
 type PublisherSpec struct {
 	Type   Publisher              `json:"Type,omitempty"`
 	Params map[string]interface{} `json:"Params,omitempty"`
@@ -151,6 +189,8 @@ type Spec struct {
 	Engine Engine `json:"Engine,omitempty"`
 
 	Verifier Verifier `json:"Verifier,omitempty"`
+	// the validation job run by the command verifier
+	VerifierCommand *VerifierCommandSpec `json:"VerifierCommand,omitempty"`
 
 	// there can be multiple publishers for the job
 	// deprecated: use PublisherSpec instead
@@ -161,6 +201,7 @@ type Spec struct {
 	Docker   JobSpecDocker   `json:"Docker,omitempty"`
 	Language JobSpecLanguage `json:"Language,omitempty"`
 	Wasm     JobSpecWasm     `json:"Wasm,omitempty"`
+	Process  JobSpecProcess  `json:"Process,omitempty"`
 
 	// the compute (cpu, ram) resources this job requires
 	Resources ResourceUsageConfig `json:"Resources,omitempty"`
@@ -172,6 +213,22 @@ type Spec struct {
 	// This includes the time required to run, verify and publish results
 	Timeout float64 `json:"Timeout,omitempty"`
 
+	// Priority of the job on compute nodes. Compute nodes that allow preemption stop running executions of lower
+	// priority to make room for the job when they are full.
+	Priority int `json:"Priority,omitempty"`
+
+	// MaxRetries is how many times the requester retries failed executions of the job, which are not retried if zero.
+	// If unset, the requester's own retry strategy decides.
+	MaxRetries *int `json:"MaxRetries,omitempty"`
+
+	// MaxRetriesPerShard is how many times the requester retries failed executions of each shard of the job, where
+	// a shard is each of the executions the job runs at once. There is no limit per shard if unset.
+	MaxRetriesPerShard *int `json:"MaxRetriesPerShard,omitempty"`
+
+	// ResultSizeLimit is the largest result in bytes the job may publish. Compute nodes that can't honour it don't
+	// bid, and results larger than it are rejected. There is no limit beyond the compute nodes' own if zero.
+	ResultSizeLimit uint64 `json:"ResultSizeLimit,omitempty"`
+
 	// the data volumes we will read in the job
 	// for example "read this ipfs cid"
 	// TODO: #667 Replace with "Inputs", "Outputs" (note the caps) for yaml/json when we update the n.js file
@@ -181,17 +238,37 @@ type Spec struct {
 	// for example "write the results to ipfs"
 	Outputs []StorageSpec `json:"outputs,omitempty"`
 
+	// OutputContracts are the files the job is expected to produce. Compute nodes check them once the job has run, and
+	// fail the execution before publishing its results if one of them is missing or does not match its schema.
+	OutputContracts []OutputContract `json:"OutputContracts,omitempty"`
+
 	// Annotations on the job - could be user or machine assigned
 	Annotations []string `json:"Annotations,omitempty"`
 
 	// NodeSelectors is a selector which must be true for the compute node to run this job.
 	NodeSelectors []LabelSelectorRequirement `json:"NodeSelectors,omitempty"`
 
+	// Residency lists the jurisdictions, such as eu, the job and its data must stay in. It is enforced against the
+	// jurisdiction compute nodes declare with their JurisdictionLabel, and the job runs anywhere if it is empty.
+	Residency []string `json:"Residency,omitempty"`
+
+	// EngineRequirements are the version and features the engine running the job must have. Compute nodes whose
+	// engine does not meet them are not selected to run the job.
+	EngineRequirements *EngineRequirements `json:"EngineRequirements,omitempty"`
+
 	// Do not track specified by the client
 	DoNotTrack bool `json:"DoNotTrack,omitempty"`
 
 	// The deal the client has made, such as which job bids they have accepted.
 	Deal Deal `json:"Deal,omitempty"`
+
+	// Reservation is the token of the capacity reservation the job runs on when it is submitted, which the requester
+	// replaces with the ID of the reservation.
+	Reservation string `json:"Reservation,omitempty"`
+
+	// Interactive jobs keep their stdin open, so that the client that submitted them can attach to their executions
+	// and stream input to them while they run. Only compute nodes that accept interactive jobs run them.
+	Interactive bool `json:"Interactive,omitempty"`
 }
 
 // Return timeout duration
@@ -199,6 +276,15 @@ func (s *Spec) GetTimeout() time.Duration {
 	return time.Duration(s.Timeout * float64(time.Second))
 }
 
+// GetResultSizeLimit returns the limit on the size of results of the job when run by a compute node that publishes
+// results of up to nodeLimit bytes, which is the smaller of the two, ignoring limits that are not set.
+func (s *Spec) GetResultSizeLimit(nodeLimit uint64) uint64 {
+	if s.ResultSizeLimit == 0 || (nodeLimit > 0 && nodeLimit < s.ResultSizeLimit) {
+		return nodeLimit
+	}
+	return s.ResultSizeLimit
+}
+
 // Return pointers to all the storage specs in the spec.
 func (s *Spec) AllStorageSpecs() []*StorageSpec {
 	storages := []*StorageSpec{
@@ -218,6 +304,17 @@ func (s *Spec) AllStorageSpecs() []*StorageSpec {
 	return storages
 }
 
+// AllPublishers returns the publisher of the job and the publishers of output volumes that are published on their own.
+func (s *Spec) AllPublishers() []Publisher {
+	publishers := []Publisher{s.PublisherSpec.Type}
+	for _, output := range s.Outputs {
+		if output.Publisher != nil && !slices.Contains(publishers, output.Publisher.Type) {
+			publishers = append(publishers, output.Publisher.Type)
+		}
+	}
+	return publishers
+}
+
 // for VM style executors
 type JobSpecDocker struct {
 	// this should be pullable by docker
@@ -228,6 +325,20 @@ type JobSpecDocker struct {
 	EnvironmentVariables []string `json:"EnvironmentVariables,omitempty"`
 	// working directory inside the container
 	WorkingDirectory string `json:"WorkingDirectory,omitempty"`
+	// the minimum CUDA version the image needs (e.g. 11.8). If not set, compute nodes will use the version declared
+	// by the image itself, when they can find one.
+	CUDAVersion string `json:"CUDAVersion,omitempty"`
+}
+
+// JobSpecProcess describes a job run as a plain process on the host of the compute node, in a sandbox that sees the
+// host's system directories read-only.
+type JobSpecProcess struct {
+	// the command to run and its arguments
+	Entrypoint []string `json:"Entrypoint,omitempty"`
+	// environment variables to run the process with, as KEY=VALUE
+	EnvironmentVariables []string `json:"EnvironmentVariables,omitempty"`
+	// working directory inside the sandbox
+	WorkingDirectory string `json:"WorkingDirectory,omitempty"`
 }
 
 // for language style executors (can target docker or wasm)
@@ -318,6 +429,12 @@ type JobCreatePayload struct {
 
 	// The specification of this job.
 	Spec *Spec `json:"Spec,omitempty" validate:"required"`
+
+	// The name to give the job, which must be unique among the jobs of its namespace. Optional.
+	Name string `json:"Name,omitempty"`
+
+	// The namespace of the authenticated identity submitting the job. Set by the requester, never by the client.
+	Namespace string `json:"-"`
 }
 
 func (j JobCreatePayload) GetClientID() string {
@@ -339,6 +456,33 @@ func (j JobCancelPayload) GetClientID() string {
 	return j.ClientID
 }
 
+// JobUpdatePayload amends the spec of a running job. Fields left empty are not changed.
+type JobUpdatePayload struct {
+	// the id of the client that is updating the job
+	ClientID string `json:"ClientID,omitempty" validate:"required"`
+
+	// the job id of the job to be updated
+	JobID string `json:"JobID,omitempty" validate:"required"`
+
+	// the version of the job spec the update is based on. The update is rejected if the spec changed since.
+	SpecVersion int `json:"SpecVersion,omitempty" validate:"required"`
+
+	// annotations to add to and remove from the job
+	AddAnnotations    []string `json:"AddAnnotations,omitempty"`
+	RemoveAnnotations []string `json:"RemoveAnnotations,omitempty"`
+
+	// the new timeout of the job in seconds, which can only be extended
+	Timeout float64 `json:"Timeout,omitempty"`
+
+	// the new limits of retries for failed executions of the job, and of each of its shards
+	MaxRetries         *int `json:"MaxRetries,omitempty"`
+	MaxRetriesPerShard *int `json:"MaxRetriesPerShard,omitempty"`
+}
+
+func (j JobUpdatePayload) GetClientID() string {
+	return j.ClientID
+}
+
 type LogsPayload struct {
 	// the id of the client that is requesting the logs
 	ClientID string `json:"ClientID,omitempty" validate:"required"`
@@ -354,8 +498,26 @@ type LogsPayload struct {
 
 	// whether the logs should be followed after the current logs are shown
 	Follow bool `json:"Follow,omitempty"`
+
+	// the number of the last lines of the logs history to be shown, or all of them if zero
+	Tail int `json:"Tail,omitempty"`
 }
 
 func (j LogsPayload) GetClientID() string {
 	return j.ClientID
 }
+
+type AttachPayload struct {
+	// the id of the client that is attaching, which must have submitted the job
+	ClientID string `json:"ClientID,omitempty" validate:"required"`
+
+	// the job id of the job to attach to
+	JobID string `json:"JobID,omitempty" validate:"required"`
+
+	// the execution to attach to
+	ExecutionID string `json:"ExecutionID,omitempty" validate:"required"`
+}
+
+func (j AttachPayload) GetClientID() string {
+	return j.ClientID
+}
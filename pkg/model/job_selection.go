@@ -34,6 +34,10 @@ type JobSelectionPolicy struct {
 	// should we accept jobs that specify networking
 	// the default is "reject"
 	AcceptNetworkedJobs bool `json:"accept_networked_jobs"`
+	// should we accept interactive jobs, whose clients stream input to them
+	// while they run, which is only meant for trusted clusters
+	// the default is "reject"
+	AcceptInteractiveJobs bool `json:"accept_interactive_jobs,omitempty"`
 	// external hooks that decide if we should take on the job or not
 	// if either of these are given they will override the data locality settings
 	ProbeHTTP string `json:"probe_http,omitempty"`
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -53,6 +54,16 @@ func (s *JobStateType) UnmarshalText(text []byte) (err error) {
 	return
 }
 
+// ParseJobStateType returns the state with the name, matched case insensitively.
+func ParseJobStateType(str string) (JobStateType, error) {
+	for typ := JobStateNew; typ <= JobStateQueued; typ++ {
+		if equal(typ.String(), str) {
+			return typ, nil
+		}
+	}
+	return JobStateNew, fmt.Errorf("unknown job state '%s'", str)
+}
+
 func JobStateTypes() []JobStateType {
 	var res []JobStateType
 	for typ := JobStateNew; typ <= JobStateQueued; typ++ {
@@ -72,7 +83,8 @@ type JobState struct {
 	Executions []ExecutionState `json:"Executions"`
 	// State is the current state of the job
 	State JobStateType `json:"State"`
-	// Version is the version of the job state. It is incremented every time the job state is updated.
+	// Version is the version of the job state. It is incremented every time the job state is updated,
+	// including when one of its executions is created or updated.
 	Version int `json:"Version"`
 	// CreateTime is the time when the job was created.
 	CreateTime time.Time `json:"CreateTime"`
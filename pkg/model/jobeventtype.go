@@ -69,6 +69,20 @@ const (
 	// not hear back it will be stuck in reserving the resources for the job
 	JobEventInvalidRequest
 
+	// a compute node stopped running a job to make room for a job of higher priority
+	JobEventPreempted
+
+	// the published results of a job have passed their retention period
+	// and have been unpinned by the node that published them
+	JobEventResultsExpired
+
+	// a requester node moved an execution that had not started yet away from a compute node, at the request of an
+	// admin
+	JobEventMigrated
+
+	// a requester node excluded compute nodes outside the data residency of a job from running it
+	JobEventResidencyEnforced
+
 	jobEventDone // must be last
 )
 
@@ -82,7 +96,8 @@ func (je JobEventType) IsTerminal() bool {
 // ignore the rest of the job's lifecycle. This is the case for events caused
 // by a node's bid being rejected.
 func (je JobEventType) IsIgnorable() bool {
-	return je.IsTerminal() || je == JobEventComputeError || je == JobEventBidRejected || je == JobEventInvalidRequest
+	return je.IsTerminal() || je == JobEventComputeError || je == JobEventBidRejected || je == JobEventInvalidRequest ||
+		je == JobEventPreempted || je == JobEventResultsExpired || je == JobEventMigrated
 }
 
 func ParseJobEventType(str string) (JobEventType, error) {
@@ -25,12 +25,16 @@ func _() {
 	_ = x[JobEventError-14]
 	_ = x[JobEventCanceled-15]
 	_ = x[JobEventInvalidRequest-16]
-	_ = x[jobEventDone-17]
+	_ = x[JobEventPreempted-17]
+	_ = x[JobEventResultsExpired-18]
+	_ = x[JobEventMigrated-19]
+	_ = x[JobEventResidencyEnforced-20]
+	_ = x[jobEventDone-21]
 }
 
-const _JobEventType_name = "jobEventUnknownInitialSubmissionCreatedDealUpdatedBidBidAcceptedBidRejectedBidCancelledRunningComputeErrorResultsProposedResultsAcceptedResultsRejectedResultsPublishedErrorCanceledInvalidRequestjobEventDone"
+const _JobEventType_name = "jobEventUnknownInitialSubmissionCreatedDealUpdatedBidBidAcceptedBidRejectedBidCancelledRunningComputeErrorResultsProposedResultsAcceptedResultsRejectedResultsPublishedErrorCanceledInvalidRequestPreemptedResultsExpiredMigratedResidencyEnforcedjobEventDone"
 
-var _JobEventType_index = [...]uint8{0, 15, 32, 39, 50, 53, 64, 75, 87, 94, 106, 121, 136, 151, 167, 172, 180, 194, 206}
+var _JobEventType_index = [...]uint8{0, 15, 32, 39, 50, 53, 64, 75, 87, 94, 106, 121, 136, 151, 167, 172, 180, 194, 203, 217, 225, 242, 254}
 
 func (i JobEventType) String() string {
 	if i < 0 || i >= JobEventType(len(_JobEventType_index)-1) {
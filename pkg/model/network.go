@@ -54,6 +54,14 @@ const (
 
 var domainRegex = regexp.MustCompile(`\b([a-z0-9]+(-[a-z0-9]+)*\.)+[a-z]{2,}\b`)
 
+func NetworkNames() []string {
+	var names []string
+	for typ := NetworkNone; typ <= NetworkHTTP; typ++ {
+		names = append(names, typ.String())
+	}
+	return names
+}
+
 func ParseNetwork(s string) (Network, error) {
 	for typ := NetworkNone; typ <= NetworkHTTP; typ++ {
 		if equal(typ.String(), s) {
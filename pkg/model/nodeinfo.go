@@ -2,6 +2,8 @@ package model
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -27,6 +29,18 @@ type NodeInfo struct {
 	NodeType        NodeType          `json:"NodeType"`
 	Labels          map[string]string `json:"Labels"`
 	ComputeNodeInfo *ComputeNodeInfo  `json:"ComputeNodeInfo"`
+	// Timestamp is the time on the clock of the node when it published its info.
+	Timestamp time.Time `json:"Timestamp,omitempty"`
+	// ClockSkew is how far the clock of the node is at least ahead of the clock of the node that received its info,
+	// leaving out the time the info may have taken to be delivered. It is set by the receiving node, and is negative
+	// if the clock is behind.
+	ClockSkew time.Duration `json:"ClockSkew,omitempty"`
+	// Warnings about the node noticed by the node that received its info, such as a clock that is out of sync.
+	Warnings []string `json:"Warnings,omitempty"`
+	// LoadHistory is the load the node reported in the info it published recently, oldest first. It is kept by the
+	// node that received the info, over a rolling window, so that the load of the node can be judged over time rather
+	// than from a single sample.
+	LoadHistory []NodeLoad `json:"LoadHistory,omitempty"`
 }
 
 // IsComputeNode returns true if the node is a compute node
@@ -44,4 +58,71 @@ type ComputeNodeInfo struct {
 	MaxJobRequirements ResourceUsageData   `json:"MaxJobRequirements"`
 	RunningExecutions  int                 `json:"RunningExecutions"`
 	EnqueuedExecutions int                 `json:"EnqueuedExecutions"`
+	// ReservedCapacity is the capacity held for the capacity reservations that have not ended yet. The unused
+	// capacity of the active ones is not part of AvailableCapacity.
+	ReservedCapacity ResourceUsageData `json:"ReservedCapacity,omitempty"`
+	// ExecutionQueue lists the executions waiting for capacity on the node, in queue order. Only the executions next
+	// to run are listed when the queue is long, while EnqueuedExecutions counts all of them.
+	ExecutionQueue []QueuedExecution `json:"ExecutionQueue,omitempty"`
+	// Load is the current load of the node, when it published its info
+	Load *NodeLoad `json:"Load,omitempty"`
+	// EngineInfo is the version and features of the installed engines that report them
+	EngineInfo []EngineInfo `json:"EngineInfo,omitempty"`
+}
+
+// NodeLoad is the load of a compute node at a point in time.
+type NodeLoad struct {
+	// Time is when the load was received by the node keeping the history of the load.
+	Time              time.Time `json:"Time"`
+	RunningExecutions int       `json:"RunningExecutions"`
+	// QueueDepth is the number of executions waiting for capacity on the node
+	QueueDepth int `json:"QueueDepth"`
+	// CPUUtilization is the fraction of the CPU capacity of the node that is used by executions, from 0 to 1
+	CPUUtilization float64 `json:"CPUUtilization"`
+	// MemoryUtilization is the fraction of the memory capacity of the node that is used by executions, from 0 to 1
+	MemoryUtilization float64 `json:"MemoryUtilization"`
+	// DiskFree is the number of bytes free on the disk the node stores data on
+	DiskFree uint64 `json:"DiskFree"`
+}
+
+// Utilization is the fraction of the capacity of the node that is used, which is that of the resource that is the
+// most used.
+func (l NodeLoad) Utilization() float64 {
+	return math.Max(l.CPUUtilization, l.MemoryUtilization)
+}
+
+// AverageLoad returns the average of the loads, and false if there are none. The disk free is the latest one.
+func AverageLoad(loads []NodeLoad) (NodeLoad, bool) {
+	if len(loads) == 0 {
+		return NodeLoad{}, false
+	}
+	var running, queued int
+	var average NodeLoad
+	for _, load := range loads {
+		running += load.RunningExecutions
+		queued += load.QueueDepth
+		average.CPUUtilization += load.CPUUtilization
+		average.MemoryUtilization += load.MemoryUtilization
+	}
+	latest := loads[len(loads)-1]
+	count := len(loads)
+	average.Time = latest.Time
+	average.DiskFree = latest.DiskFree
+	average.RunningExecutions = int(math.Round(float64(running) / float64(count)))
+	average.QueueDepth = int(math.Round(float64(queued) / float64(count)))
+	average.CPUUtilization /= float64(count)
+	average.MemoryUtilization /= float64(count)
+	return average, true
+}
+
+// QueuedExecution describes an execution that a compute node has accepted, but is waiting in its local queue for
+// enough capacity to run.
+type QueuedExecution struct {
+	ExecutionID string `json:"ExecutionID"`
+	JobID       string `json:"JobID"`
+	// Position of the execution in the queue, starting at 1 for the next execution to run
+	Position int `json:"Position"`
+	// EstimatedStartTime is when the execution is expected to start at the latest, assuming that the executions
+	// running or queued ahead of it run until their timeout
+	EstimatedStartTime time.Time `json:"EstimatedStartTime"`
 }
@@ -0,0 +1,30 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAverageLoad(t *testing.T) {
+	_, ok := AverageLoad(nil)
+	require.False(t, ok)
+
+	now := time.Now()
+	average, ok := AverageLoad([]NodeLoad{
+		{Time: now.Add(-time.Minute), RunningExecutions: 1, QueueDepth: 4, CPUUtilization: 0.2, DiskFree: 100},
+		{Time: now, RunningExecutions: 3, CPUUtilization: 0.6, MemoryUtilization: 0.9, DiskFree: 50},
+	})
+	require.True(t, ok)
+	require.Equal(t, now, average.Time)
+	require.Equal(t, 2, average.RunningExecutions)
+	require.Equal(t, 2, average.QueueDepth)
+	require.InDelta(t, 0.4, average.CPUUtilization, 1e-9)
+	require.InDelta(t, 0.45, average.MemoryUtilization, 1e-9)
+	require.InDelta(t, 0.45, average.Utilization(), 1e-9)
+	// the disk free is the latest one, as disks fill up rather than fluctuate
+	require.Equal(t, uint64(50), average.DiskFree)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "context"
+
+// PeerScore is the score a node gives to one of its peers for its behaviour in the gossip of bacalhau topics. Peers
+// whose score falls below the graylist threshold have their messages ignored until their score recovers.
+type PeerScore struct {
+	PeerID string  `json:"PeerID"`
+	Score  float64 `json:"Score"`
+	// Graylisted is whether the messages of the peer are being ignored.
+	Graylisted bool `json:"Graylisted"`
+	// InvalidMessages is the decaying count of the invalid messages the peer delivered, by topic. Invalid messages
+	// include unsigned messages, messages with a bad signature, malformed messages and messages over the rate limit.
+	InvalidMessages map[string]float64 `json:"InvalidMessages,omitempty"`
+	// BehaviourPenalty is the decaying penalty of the peer for misbehaving in the gossip protocol itself.
+	BehaviourPenalty float64 `json:"BehaviourPenalty"`
+}
+
+type PeerScoreProvider interface {
+	GetPeerScores(ctx context.Context) []PeerScore
+}
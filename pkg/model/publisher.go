@@ -14,6 +14,7 @@ const (
 	PublisherFilecoin
 	PublisherEstuary
 	PublisherS3
+	PublisherLocal
 	publisherDone // must be last
 )
 
@@ -14,12 +14,13 @@ func _() {
 	_ = x[PublisherFilecoin-3]
 	_ = x[PublisherEstuary-4]
 	_ = x[PublisherS3-5]
-	_ = x[publisherDone-6]
+	_ = x[PublisherLocal-6]
+	_ = x[publisherDone-7]
 }
 
-const _Publisher_name = "publisherUnknownNoopIpfsFilecoinEstuaryS3publisherDone"
+const _Publisher_name = "publisherUnknownNoopIpfsFilecoinEstuaryS3LocalpublisherDone"
 
-var _Publisher_index = [...]uint8{0, 16, 20, 24, 32, 39, 41, 54}
+var _Publisher_index = [...]uint8{0, 16, 20, 24, 32, 39, 41, 46, 59}
 
 func (i Publisher) String() string {
 	if i < 0 || i >= Publisher(len(_Publisher_index)-1) {
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxReservationDuration is how far in the future capacity reservations can end, so that capacity can't be held
+// indefinitely.
+const MaxReservationDuration = 7 * 24 * time.Hour
+
+// CapacityReservation holds capacity of compute nodes for the jobs of a client during a time window. Compute nodes
+// don't offer the capacity they hold to other jobs, and jobs submitted with the token of the reservation run on it.
+type CapacityReservation struct {
+	ID       string `json:"ID"`
+	ClientID string `json:"ClientID"`
+	// Token is the secret jobs are submitted with to run on the reserved capacity. It is only returned to the client
+	// when the reservation is made.
+	Token string `json:"Token,omitempty"`
+	// Resources is the capacity reserved across the network.
+	Resources ResourceUsageData `json:"Resources"`
+	Start     time.Time         `json:"Start"`
+	End       time.Time         `json:"End"`
+	// Allocations is the capacity held on each compute node, which adds up to Resources.
+	Allocations []ReservationAllocation `json:"Allocations,omitempty"`
+}
+
+// ReservationAllocation is the part of a reservation held on a compute node.
+type ReservationAllocation struct {
+	NodeID    string            `json:"NodeID"`
+	Resources ResourceUsageData `json:"Resources"`
+}
+
+// IsActive returns true if the reservation holds its capacity at the given time.
+func (r CapacityReservation) IsActive(at time.Time) bool {
+	return !at.Before(r.Start) && at.Before(r.End)
+}
+
+// IsExpired returns true if the time window of the reservation is over at the given time.
+func (r CapacityReservation) IsExpired(at time.Time) bool {
+	return !at.Before(r.End)
+}
+
+// CheckDuration returns an error if the reservation ends further in the future than MaxReservationDuration.
+func (r CapacityReservation) CheckDuration(now time.Time) error {
+	if r.End.Sub(now) > MaxReservationDuration {
+		return fmt.Errorf("reservation %s ends at %s, reservations can't end more than %s from now",
+			r.ID, r.End.Format(time.RFC3339), MaxReservationDuration)
+	}
+	return nil
+}
+
+// Overlaps returns true if the time windows of the reservations overlap.
+func (r CapacityReservation) Overlaps(other CapacityReservation) bool {
+	return r.Start.Before(other.End) && other.Start.Before(r.End)
+}
+
+// Allocation returns the capacity the reservation holds on the node, and false if it holds none there.
+func (r CapacityReservation) Allocation(nodeID string) (ResourceUsageData, bool) {
+	for _, allocation := range r.Allocations {
+		if allocation.NodeID == nodeID {
+			return allocation.Resources, true
+		}
+	}
+	return ResourceUsageData{}, false
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"strings"
+)
+
+// JurisdictionLabel is the node label with which compute nodes declare the jurisdiction they run in, such as eu or
+// us, so that jobs with data residency constraints only run in the jurisdictions they allow.
+const JurisdictionLabel = "jurisdiction"
+
+// AllowsJurisdiction returns true if the job may run on a node in the jurisdiction. Jobs without residency constraints
+// run anywhere, while jobs with some never run on nodes that don't declare a jurisdiction.
+func (s Spec) AllowsJurisdiction(jurisdiction string) bool {
+	if len(s.Residency) == 0 {
+		return true
+	}
+	for _, allowed := range s.Residency {
+		if jurisdiction != "" && strings.EqualFold(allowed, jurisdiction) {
+			return true
+		}
+	}
+	return false
+}
+
+// Jurisdiction returns the jurisdiction the node declares in its labels, if any.
+func (n NodeInfo) Jurisdiction() string {
+	return n.Labels[JurisdictionLabel]
+}
@@ -0,0 +1,31 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowsJurisdiction(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		residency    []string
+		jurisdiction string
+		allowed      bool
+	}{
+		{name: "no residency", jurisdiction: "us", allowed: true},
+		{name: "no residency and no jurisdiction", allowed: true},
+		{name: "matching", residency: []string{"eu"}, jurisdiction: "eu", allowed: true},
+		{name: "matching case", residency: []string{"EU"}, jurisdiction: "eu", allowed: true},
+		{name: "one of many", residency: []string{"eu", "ch"}, jurisdiction: "ch", allowed: true},
+		{name: "outside", residency: []string{"eu"}, jurisdiction: "us"},
+		{name: "no jurisdiction", residency: []string{"eu"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := Spec{Residency: tc.residency}
+			require.Equal(t, tc.allowed, spec.AllowsJurisdiction(tc.jurisdiction))
+		})
+	}
+}
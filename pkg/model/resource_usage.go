@@ -18,6 +18,8 @@ type ResourceUsageConfig struct {
 	Disk string `json:"Disk,omitempty"`
 	GPU  string `json:"GPU"` // unsigned integer string
 
+	// Fuel is the number of WASM function calls a job may make, as an unsigned integer string
+	Fuel string `json:"Fuel,omitempty"`
 }
 
 // these are the numeric values in bytes for ResourceUsageConfig
@@ -29,6 +31,8 @@ type ResourceUsageData struct {
 	// bytes
 	Disk uint64 `json:"Disk,omitempty" example:"212663867801"`
 	GPU  uint64 `json:"GPU,omitempty" example:"1"` //nolint:lll // Support whole GPUs only, like https://kubernetes.io/docs/tasks/manage-gpus/scheduling-gpus/
+	// WASM function calls
+	Fuel uint64 `json:"Fuel,omitempty" example:"1000000"`
 }
 
 func (r ResourceUsageData) Add(other ResourceUsageData) ResourceUsageData {
@@ -37,6 +41,7 @@ func (r ResourceUsageData) Add(other ResourceUsageData) ResourceUsageData {
 		Memory: r.Memory + other.Memory,
 		Disk:   r.Disk + other.Disk,
 		GPU:    r.GPU + other.GPU,
+		Fuel:   r.Fuel + other.Fuel,
 	}
 }
 
@@ -46,6 +51,7 @@ func (r ResourceUsageData) Sub(other ResourceUsageData) ResourceUsageData {
 		Memory: r.Memory - other.Memory,
 		Disk:   r.Disk - other.Disk,
 		GPU:    r.GPU - other.GPU,
+		Fuel:   r.Fuel - other.Fuel,
 	}
 
 	if r.LessThan(other) {
@@ -63,6 +69,9 @@ func (r ResourceUsageData) Sub(other ResourceUsageData) ResourceUsageData {
 		if other.GPU > r.GPU {
 			usage.GPU = 0
 		}
+		if other.Fuel > r.Fuel {
+			usage.Fuel = 0
+		}
 	}
 
 	return usage
@@ -74,6 +83,7 @@ func (r ResourceUsageData) Multi(factor float64) ResourceUsageData {
 		Memory: uint64(float64(r.Memory) * factor),
 		Disk:   uint64(float64(r.Disk) * factor),
 		GPU:    uint64(float64(r.GPU) * factor),
+		Fuel:   uint64(float64(r.Fuel) * factor),
 	}
 }
 
@@ -90,6 +100,9 @@ func (r ResourceUsageData) Intersect(other ResourceUsageData) ResourceUsageData
 	if r.GPU <= 0 {
 		r.GPU = other.GPU
 	}
+	if r.Fuel <= 0 {
+		r.Fuel = other.Fuel
+	}
 
 	return r
 }
@@ -107,25 +120,30 @@ func (r ResourceUsageData) Max(other ResourceUsageData) ResourceUsageData {
 	if r.GPU < other.GPU {
 		r.GPU = other.GPU
 	}
+	if r.Fuel < other.Fuel {
+		r.Fuel = other.Fuel
+	}
 
 	return r
 }
 
 func (r ResourceUsageData) LessThan(other ResourceUsageData) bool {
-	return r.CPU < other.CPU && r.Memory < other.Memory && r.Disk < other.Disk && r.GPU < other.GPU
+	return r.CPU < other.CPU && r.Memory < other.Memory && r.Disk < other.Disk && r.GPU < other.GPU &&
+		r.Fuel < other.Fuel
 }
 
 func (r ResourceUsageData) LessThanEq(other ResourceUsageData) bool {
-	return r.CPU <= other.CPU && r.Memory <= other.Memory && r.Disk <= other.Disk && r.GPU <= other.GPU
+	return r.CPU <= other.CPU && r.Memory <= other.Memory && r.Disk <= other.Disk && r.GPU <= other.GPU &&
+		r.Fuel <= other.Fuel
 }
 
 func (r ResourceUsageData) IsZero() bool {
-	return r.CPU == 0 && r.Memory == 0 && r.Disk == 0 && r.GPU == 0
+	return r.CPU == 0 && r.Memory == 0 && r.Disk == 0 && r.GPU == 0 && r.Fuel == 0
 }
 
 // return string representation of ResourceUsageData
 func (r ResourceUsageData) String() string {
-	return fmt.Sprintf("{CPU: %f, Memory: %d, Disk: %d, GPU: %d}", r.CPU, r.Memory, r.Disk, r.GPU)
+	return fmt.Sprintf("{CPU: %f, Memory: %d, Disk: %d, GPU: %d, Fuel: %d}", r.CPU, r.Memory, r.Disk, r.GPU, r.Fuel)
 }
 
 type ResourceUsageProfile struct {
@@ -0,0 +1,18 @@
+package model
+
+// MaxResultManifestFiles is the most files the manifest published with the results of an execution lists. Results
+// with more files are published without a manifest.
+const MaxResultManifestFiles = 1000
+
+// ResultManifest lists the files of results, ordered by path, so that results can be compared without being
+// downloaded.
+type ResultManifest struct {
+	Files []ResultFile `json:"Files"`
+}
+
+// ResultFile is a file of results, at its slash-separated path relative to the results directory.
+type ResultFile struct {
+	Path   string `json:"Path"`
+	Size   int64  `json:"Size"`
+	SHA256 string `json:"SHA256"`
+}
@@ -1,5 +1,12 @@
 package model
 
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
 // StorageSpec represents some data on a storage engine. Storage engines are
 // specific to particular execution engines, as different execution engines
 // will mount data in different ways.
@@ -17,6 +24,14 @@ type StorageSpec struct {
 	// NOTE: The below is capitalized to match IPFS & IPLD (even though it's out of golang fmt)
 	CID string `json:"CID,omitempty" example:"QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe"`
 
+	// SubPath selects a file or directory within the data of the CID (e.g. path/to/subdir), so that only that part of
+	// the data is fetched and mounted. The whole CID is used if empty.
+	SubPath string `json:"SubPath,omitempty" example:"path/to/subdir"`
+
+	// IPNSName is the IPNS name, or the domain with a DNSLink record, that an IPFS input was given by. The requester
+	// resolves it to the CID it points to when the job is submitted, and keeps it to record where the CID came from.
+	IPNSName string `json:"IPNSName,omitempty" example:"docs.ipfs.tech"`
+
 	// Source URL of the data
 	URL string `json:"URL,omitempty"`
 
@@ -31,6 +46,11 @@ type StorageSpec struct {
 	// Allow write access for locally mounted inputs
 	ReadWrite bool `json:"ReadWrite,omitempty"`
 
+	// MountArchive mounts the contents of an input that is a tar archive, optionally gzipped, or a CAR file as a
+	// read-only directory instead of the archive itself. Files are read out of the archive as the job reads them,
+	// without extracting it to disk. Only supported by the wasm engine.
+	MountArchive bool `json:"MountArchive,omitempty"`
+
 	// The path that the spec's data should be mounted on, where it makes
 	// sense (for example, in a Docker storage spec this will be a filesystem
 	// path).
@@ -39,6 +59,40 @@ type StorageSpec struct {
 
 	// Additional properties specific to each driver
 	Metadata map[string]string `json:"Metadata,omitempty"`
+
+	// ExpiresAt is when published data will be unpinned by the node that
+	// published it, if the node retains results for a limited time
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+
+	// Publisher publishes an output volume on its own, instead of with the
+	// rest of the results through the job's publisher. Only applies to outputs.
+	Publisher *PublisherSpec `json:"Publisher,omitempty"`
+}
+
+// IPFSPath returns the path of the data within IPFS, which is the CID followed by the sub-path if there is one.
+// It fails if the sub-path would leave the data of the CID.
+func (s StorageSpec) IPFSPath() (string, error) {
+	if s.SubPath == "" {
+		return s.CID, nil
+	}
+	subPath := path.Clean("/" + s.SubPath)
+	if subPath != "/"+strings.Trim(s.SubPath, "/") {
+		source := s.CID
+		if source == "" {
+			source = s.IPNSName
+		}
+		return "", fmt.Errorf("invalid sub-path %q of %s", s.SubPath, source)
+	}
+	if subPath == "/" {
+		return s.CID, nil
+	}
+	return s.CID + subPath, nil
+}
+
+// ValidFileName returns true if the name can be joined to a folder as a single file or folder name without escaping
+// the folder, such as the name of an output volume or of an entry of a published result.
+func ValidFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
 }
 
 type S3StorageSpec struct {
@@ -56,12 +110,22 @@ type S3StorageSpec struct {
 type PublishedResult struct {
 	NodeID string      `json:"NodeID,omitempty"`
 	Data   StorageSpec `json:"Data,omitempty"`
+	// Volume is the name of the output volume the data holds, if the volume
+	// was published on its own rather than with the rest of the results
+	Volume string `json:"Volume,omitempty"`
+}
+
+// Expired returns true if the published data has passed its retention period
+func (r PublishedResult) Expired(now time.Time) bool {
+	return r.Data.ExpiresAt != nil && !now.Before(*r.Data.ExpiresAt)
 }
 
 type DownloadItem struct {
 	Name       string
 	CID        string
 	URL        string
+	S3         *S3StorageSpec
+	SourcePath string
 	SourceType StorageSourceType
 	Target     string
 }
@@ -28,6 +28,11 @@ func ShortID(id string) string {
 	return id
 }
 
+// IsJobIDPrefix returns true if the string can be a prefix of a job ID at least ShortIDLength long, such as a short ID.
+func IsJobIDPrefix(s string) bool {
+	return len(s) >= ShortIDLength && strings.Trim(s, "0123456789abcdef-") == ""
+}
+
 func equal(a, b string) bool {
 	a = strings.TrimSpace(a)
 	b = strings.TrimSpace(b)
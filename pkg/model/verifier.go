@@ -12,9 +12,31 @@ const (
 	VerifierNoop
 	VerifierDeterministic
 	VerifierExternal
+	VerifierCommand
 	verifierDone // must be last
 )
 
+// VerifierCommandResultsPath is where the results being verified are mounted in the validation job of the command
+// verifier.
+const VerifierCommandResultsPath = "/inputs"
+
+// VerifierCommandSpec is a lightweight validation job that the command verifier runs against the results of each
+// execution, e.g. to check the schema of an output file or the row count of a CSV. The results are mounted at
+// VerifierCommandResultsPath, and are accepted if the validation job exits with code 0.
+type VerifierCommandSpec struct {
+	// e.g. docker or wasm
+	Engine Engine `json:"Engine,omitempty"`
+
+	Docker JobSpecDocker `json:"Docker,omitempty"`
+	Wasm   JobSpecWasm   `json:"Wasm,omitempty"`
+
+	// the compute (cpu, ram) resources the validation job requires
+	Resources ResourceUsageConfig `json:"Resources,omitempty"`
+
+	// How long the validation job can run in seconds before it is killed.
+	Timeout float64 `json:"Timeout,omitempty"`
+}
+
 func ParseVerifier(str string) (Verifier, error) {
 	for typ := verifierUnknown + 1; typ < verifierDone; typ++ {
 		if equal(typ.String(), str) {
@@ -12,12 +12,13 @@ func _() {
 	_ = x[VerifierNoop-1]
 	_ = x[VerifierDeterministic-2]
 	_ = x[VerifierExternal-3]
-	_ = x[verifierDone-4]
+	_ = x[VerifierCommand-4]
+	_ = x[verifierDone-5]
 }
 
-const _Verifier_name = "verifierUnknownNoopDeterministicExternalverifierDone"
+const _Verifier_name = "verifierUnknownNoopDeterministicExternalCommandverifierDone"
 
-var _Verifier_index = [...]uint8{0, 15, 19, 32, 40, 52}
+var _Verifier_index = [...]uint8{0, 15, 19, 32, 40, 47, 59}
 
 func (i Verifier) String() string {
 	if i < 0 || i >= Verifier(len(_Verifier_index)-1) {
@@ -11,6 +11,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/resource"
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/audit"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity/disk"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/logstream"
@@ -19,6 +20,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inlocalstore"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	executor_util "github.com/bacalhau-project/bacalhau/pkg/executor/util"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -78,11 +80,25 @@ func NewComputeNode(
 	enqueuedCapacityTracker := capacity.NewLocalTracker(capacity.LocalTrackerParams{
 		MaxCapacity: config.QueueResourceLimits,
 	})
+	// capacity held for capacity reservations, which only their jobs are offered
+	reservations := capacity.NewReservations()
+
+	// executions left behind by a crash are no longer tracked, so their workloads are reaped or adopted before the
+	// node takes on new executions
+	compute.NewOrphanReaper(compute.OrphanReaperParams{
+		Executors:       executors,
+		Store:           executionStore,
+		CapacityTracker: runningCapacityTracker,
+		Policy:          config.OrphanPolicy,
+	}).Reap(ctx)
 
 	// Callback to send compute events (i.e. requester endpoint)
 	var computeCallback compute.Callback
 	standardComputeCallback := bprotocol.NewCallbackProxy(bprotocol.CallbackProxyParams{
-		Host: host,
+		Host:              host,
+		BatchInterval:     system.Max(config.CallbackBatchInterval, 0),
+		MaxBatchSize:      config.CallbackMaxBatchSize,
+		OfflineBufferSize: config.CallbackOfflineBufferSize,
 	})
 	if simulatorNodeID != "" {
 		simulatorProxy := simulator_protocol.NewCallbackProxy(simulator_protocol.CallbackProxyParams{
@@ -101,14 +117,33 @@ func NewComputeNode(
 		computeCallback = standardComputeCallback
 	}
 
+	// record the executions of the node in its audit log
+	auditLog, err := createAuditLog(host)
+	if err != nil {
+		return nil, err
+	}
+	computeCallback = compute.NewChainedCallback(compute.ChainedCallbackParams{
+		Callbacks: []compute.Callback{
+			computeCallback,
+			audit.NewRecorder(audit.RecorderParams{Log: auditLog, Store: executionStore}),
+		},
+	})
+
+	// results are delivered from their own lane, so that executions don't hold on to their capacity meanwhile
+	resultCallback := compute.NewLaneCallback(compute.LaneCallbackParams{
+		Callback:      computeCallback,
+		MaxConcurrent: config.MaxConcurrentCallbacks,
+	})
+
 	baseExecutor := compute.NewBaseExecutor(compute.BaseExecutorParams{
 		ID:              host.ID().String(),
-		Callback:        computeCallback,
+		Callback:        resultCallback,
 		Store:           executionStore,
 		Executors:       executors,
 		Verifiers:       verifiers,
 		Publishers:      publishers,
 		SimulatorConfig: config.SimulatorConfig,
+		MaxResultSize:   config.MaxResultSize,
 	})
 
 	bufferRunner := compute.NewExecutorBuffer(compute.ExecutorBufferParams{
@@ -119,6 +154,11 @@ func NewComputeNode(
 		EnqueuedCapacityTracker:    enqueuedCapacityTracker,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
 		BackoffDuration:            config.ExecutorBufferBackoffDuration,
+		EnablePreemption:           config.EnablePreemption,
+		MaxConcurrentPublishes:     config.MaxConcurrentPublishes,
+		MaxConcurrentCancels:       config.MaxConcurrentCancels,
+		ResultSize:                 baseExecutor.ResultSize,
+		Reservations:               reservations,
 	})
 	runningInfoProvider := sensors.NewRunningExecutionsInfoProvider(sensors.RunningExecutionsInfoProviderParams{
 		Name:          "ActiveJobs",
@@ -162,11 +202,22 @@ func NewComputeNode(
 				verifiers,
 				func(j *model.Job) model.Verifier { return j.Spec.Verifier },
 			),
-			semantic.NewProviderInstalledStrategy(
+			semantic.NewProviderInstalledArrayStrategy(
 				publishers,
-				func(j *model.Job) model.Publisher { return j.Spec.PublisherSpec.Type },
+				func(j *model.Job) []model.Publisher { return j.Spec.AllPublishers() },
 			),
 			semantic.NewStorageInstalledBidStrategy(storages),
+			semantic.NewLocalPathSandboxStrategy(config.LocalPathSandbox),
+			semantic.NewEnvironmentVariablesStrategy(semantic.EnvironmentVariablesStrategyParams{
+				Allow: config.EnvironmentVariableAllowList,
+				Deny:  config.EnvironmentVariableDenyList,
+			}),
+			semantic.NewResidencyStrategy(semantic.ResidencyStrategyParams{
+				Jurisdiction: config.Jurisdiction,
+			}),
+			semantic.NewResultSizeStrategy(semantic.ResultSizeStrategyParams{
+				MaxResultSize: config.MaxResultSize,
+			}),
 			semantic.NewTimeoutStrategy(semantic.TimeoutStrategyParams{
 				MaxJobExecutionTimeout:                config.MaxJobExecutionTimeout,
 				MinJobExecutionTimeout:                config.MinJobExecutionTimeout,
@@ -190,6 +241,7 @@ func NewComputeNode(
 			resource.NewAvailableCapacityStrategy(ctx, resource.AvailableCapacityStrategyParams{
 				RunningCapacityTracker:  runningCapacityTracker,
 				EnqueuedCapacityTracker: enqueuedCapacityTracker,
+				Reservations:            reservations,
 			}),
 		)
 	}
@@ -217,19 +269,43 @@ func NewComputeNode(
 		CapacityTracker:    runningCapacityTracker,
 		ExecutorBuffer:     bufferRunner,
 		MaxJobRequirements: config.JobResourceLimits,
+		Reservations:       reservations,
 	})
 
+	var inputEstimator compute.InputEstimator
+	if config.InputProbeTimeout > 0 {
+		inputEstimator = compute.NewStorageInputEstimator(compute.StorageInputEstimatorParams{
+			Storages:  storages,
+			Timeout:   config.InputProbeTimeout,
+			FetchRate: config.InputFetchRate,
+		})
+	}
+
 	bidder := compute.NewBidder(compute.BidderParams{
 		NodeID:           host.ID().String(),
 		SemanticStrategy: semanticBidStrat,
 		ResourceStrategy: resourceBidStrat,
 		Store:            executionStore,
 		Callback:         computeCallback,
+		Prefetcher:       config.InputPrefetcher,
+		InputEstimator:   inputEstimator,
+		MaxResultSize:    config.MaxResultSize,
 		GetApproveURL: func() *url.URL {
 			return apiServer.GetURI().JoinPath(compute_publicapi.APIPrefix, compute_publicapi.APIApproveSuffix)
 		},
 	})
 
+	nodeAdmin := compute.NewNodeAdmin(compute.NodeAdminParams{
+		Bidder:            bidder,
+		CapacityTracker:   runningCapacityTracker,
+		ExecutorBuffer:    bufferRunner,
+		AdminClientIDs:    config.AdminClientIDs,
+		CachePurgers:      []func(){docker.PurgeCaches},
+		SelfTest:          config.SelfTestRunner,
+		MaxResourceLimits: config.TotalResourceLimits,
+		JobResourceLimits: config.JobResourceLimits,
+	})
+
 	baseEndpoint := compute.NewBaseEndpoint(compute.BaseEndpointParams{
 		ID:              host.ID().String(),
 		ExecutionStore:  executionStore,
@@ -237,18 +313,27 @@ func NewComputeNode(
 		Bidder:          bidder,
 		Executor:        bufferRunner,
 		LogServer:       *logserver,
+		Prefetcher:      config.InputPrefetcher,
+		Reservations:    reservations,
+		CapacityTracker: runningCapacityTracker,
+		Admin:           nodeAdmin,
+
+		MaxJobExecutionTimeout:                config.MaxJobExecutionTimeout,
+		JobExecutionTimeoutClientIDBypassList: config.JobExecutionTimeoutClientIDBypassList,
 	})
 
 	// if this node is the simulator, then we set the simulator request handler as the stream handler
 	if simulatorRequestHandler != nil {
 		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
-			Host:            host,
-			ComputeEndpoint: simulatorRequestHandler,
+			Host:              host,
+			ComputeEndpoint:   simulatorRequestHandler,
+			TrustedRequesters: config.TrustedRequesters,
 		})
 	} else {
 		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
-			Host:            host,
-			ComputeEndpoint: baseEndpoint,
+			Host:              host,
+			ComputeEndpoint:   baseEndpoint,
+			TrustedRequesters: config.TrustedRequesters,
 		})
 	}
 
@@ -264,15 +349,19 @@ func NewComputeNode(
 		Bidder:             bidder,
 		Store:              executionStore,
 		DebugInfoProviders: debugInfoProviders,
+		AdminClientIDs:     config.AdminClientIDs,
+		NodeAdmin:          nodeAdmin,
+		AuditLog:           auditLog,
+		ResultsDirectory:   config.ResultsDirectory,
 	})
-	err := computeAPIServer.RegisterAllHandlers()
+	err = computeAPIServer.RegisterAllHandlers()
 	if err != nil {
 		return nil, err
 	}
 
 	// A single cleanup function to make sure the order of closing dependencies is correct
 	cleanupFunc := func(ctx context.Context) {
-		// pass
+		standardComputeCallback.Close()
 	}
 
 	return &Compute{
@@ -312,6 +401,17 @@ func createExecutionStore(host host.Host) (store.ExecutionStore, error) {
 	})
 }
 
+func createAuditLog(host host.Host) (*audit.Log, error) {
+	configDir, err := system.EnsureConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return audit.NewLog(audit.LogParams{
+		Path:       filepath.Join(configDir, "execution-audit-"+host.ID().String()+".jsonl"),
+		PrivateKey: host.Peerstore().PrivKey(host.ID()),
+	})
+}
+
 func (c *Compute) cleanup(ctx context.Context) {
 	c.cleanupFunc(ctx)
 }
@@ -3,12 +3,18 @@ package node
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy/semantic"
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	localdirectory "github.com/bacalhau-project/bacalhau/pkg/storage/local_directory"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 type ComputeConfigParams struct {
@@ -41,6 +47,52 @@ type ComputeConfigParams struct {
 	BidSemanticStrategy bidstrategy.SemanticBidStrategy
 
 	BidResourceStrategy bidstrategy.ResourceBidStrategy
+
+	AdminClientIDs []string
+
+	TrustedRequesters []string
+
+	InputPrefetchBudget uint64
+
+	CallbackBatchInterval time.Duration
+
+	CallbackMaxBatchSize int
+
+	InputProbeTimeout time.Duration
+
+	InputFetchRate uint64
+
+	CallbackOfflineBufferSize int
+
+	EnablePreemption bool
+
+	MaxConcurrentPublishes int
+
+	MaxConcurrentCallbacks int
+
+	MaxConcurrentCancels int
+
+	MaxResultSize uint64
+
+	OrphanPolicy compute.OrphanPolicy
+
+	EnvironmentVariableAllowList []string
+
+	EnvironmentVariableDenyList []string
+
+	SelfTest SelfTestConfig
+}
+
+// SelfTestConfig configures the checks run by the self-test of a compute node.
+type SelfTestConfig struct {
+	// DockerImage is the image run to check the docker engine.
+	DockerImage string
+	// NTPServer is the server the clock of the node is compared against. The clock is not checked if empty.
+	NTPServer string
+	// MaxClockOffset is how far the clock of the node can drift from the NTP server.
+	MaxClockOffset time.Duration
+	// CheckTimeout is how long each check is given to complete.
+	CheckTimeout time.Duration
 }
 
 type ComputeConfig struct {
@@ -83,6 +135,83 @@ type ComputeConfig struct {
 	BidResourceStrategy bidstrategy.ResourceBidStrategy
 
 	ExecutionStore store.ExecutionStore
+
+	// AdminClientIDs is the list of clients that are allowed to use the admin API of this node.
+	// The admin API is disabled if the list is empty.
+	AdminClientIDs []string
+
+	// TrustedRequesters are the peer IDs of the requester nodes allowed to reserve and release capacity on this node.
+	// Only the requester of the node itself can if the list is empty.
+	TrustedRequesters []string
+
+	// InputPrefetchBudget is the maximum number of bytes of inputs that can be fetched for executions that have been
+	// bid on but not yet accepted. Inputs are not prefetched if zero.
+	InputPrefetchBudget uint64
+	// InputPrefetcher is set up by the node when InputPrefetchBudget is set.
+	InputPrefetcher compute.InputPrefetcher
+
+	// CallbackBatchInterval is how long results and other events are held to be sent to a requester together, with
+	// the events of each execution coalesced. Events are sent on their own if zero or negative.
+	CallbackBatchInterval time.Duration
+	// CallbackMaxBatchSize is the maximum number of events sent to a requester together.
+	CallbackMaxBatchSize int
+
+	// InputProbeTimeout is how long each input of a job is given to be resolved before bidding, without being fetched.
+	// Jobs with inputs that can't be resolved are not bid on. Inputs are not probed if negative.
+	InputProbeTimeout time.Duration
+	// InputFetchRate is the number of bytes per second the node expects to fetch inputs at, used to estimate how long
+	// fetching the inputs of a job takes in bids.
+	InputFetchRate uint64
+
+	// CallbackOfflineBufferSize is the maximum number of results and other events that are buffered for a requester
+	// while it is unreachable. Events are dropped if zero.
+	CallbackOfflineBufferSize int
+
+	// LocalPathSandbox is set up by the node from its allow listed local paths, and rejects jobs mounting local
+	// paths they are not allowed to at bid time.
+	LocalPathSandbox *localdirectory.Sandbox
+
+	// Jurisdiction is set up by the node from its jurisdiction label, and rejects jobs whose data residency does not
+	// include it.
+	Jurisdiction string
+
+	// EnablePreemption allows executions of higher priority to preempt running executions of lower priority when
+	// there isn't enough capacity to run them.
+	EnablePreemption bool
+
+	// MaxConcurrentPublishes is the maximum number of results published at once. Results waiting to be published are
+	// prioritized by job priority and then by size. There is no limit if zero.
+	MaxConcurrentPublishes int
+
+	// MaxConcurrentCallbacks is the maximum number of results to be verified, and of published results, delivered
+	// to requesters at once. There is no limit if zero.
+	MaxConcurrentCallbacks int
+
+	// MaxConcurrentCancels is the maximum number of executions canceled at once. There is no limit if zero.
+	MaxConcurrentCancels int
+
+	// MaxResultSize is the largest result in bytes the node publishes. Jobs allowing larger results are not bid on,
+	// and executions with larger results fail. There is no limit if zero.
+	MaxResultSize uint64
+
+	// OrphanPolicy is what the node does on startup with the containers of executions it no longer tracks, which are
+	// left behind when it crashes. They are reaped by default.
+	OrphanPolicy compute.OrphanPolicy
+
+	// EnvironmentVariableAllowList are the patterns, such as AWS_*, of the names of the environment variables jobs
+	// can set. Jobs setting other variables are not bid on. Any variable can be set if empty.
+	EnvironmentVariableAllowList []string
+	// EnvironmentVariableDenyList are the patterns of the names of the environment variables jobs can't set. Jobs
+	// setting them are not bid on, even if they are allowed.
+	EnvironmentVariableDenyList []string
+
+	// SelfTest configures the checks run by the self-test of the node.
+	SelfTest SelfTestConfig
+	// SelfTestRunner is set up by the node from SelfTest and the components of the node.
+	SelfTestRunner *selftest.SelfTest
+	// ResultsDirectory holds the results published with the local publisher, which the compute API serves. It is set
+	// up by the node from its LocalPublisher, and results are not served if empty.
+	ResultsDirectory string
 }
 
 func NewComputeConfigWithDefaults() ComputeConfig {
@@ -115,6 +244,27 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if params.ExecutorBufferBackoffDuration == 0 {
 		params.ExecutorBufferBackoffDuration = DefaultComputeConfig.ExecutorBufferBackoffDuration
 	}
+	if params.CallbackBatchInterval == 0 {
+		params.CallbackBatchInterval = DefaultComputeConfig.CallbackBatchInterval
+	}
+	if params.CallbackMaxBatchSize == 0 {
+		params.CallbackMaxBatchSize = DefaultComputeConfig.CallbackMaxBatchSize
+	}
+	if params.InputProbeTimeout == 0 {
+		params.InputProbeTimeout = DefaultComputeConfig.InputProbeTimeout
+	}
+	if params.InputFetchRate == 0 {
+		params.InputFetchRate = DefaultComputeConfig.InputFetchRate
+	}
+	if params.SelfTest.DockerImage == "" {
+		params.SelfTest.DockerImage = DefaultComputeConfig.SelfTest.DockerImage
+	}
+	if params.SelfTest.MaxClockOffset == 0 {
+		params.SelfTest.MaxClockOffset = DefaultComputeConfig.SelfTest.MaxClockOffset
+	}
+	if params.SelfTest.CheckTimeout == 0 {
+		params.SelfTest.CheckTimeout = DefaultComputeConfig.SelfTest.CheckTimeout
+	}
 
 	// Get available physical resources in the host
 	physicalResourcesProvider := params.PhysicalResourcesProvider
@@ -125,6 +275,8 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if err != nil {
 		return
 	}
+	// fuel is a budget rather than a physical resource, so it is only bounded by the configured limits
+	physicalResources.Fuel = math.MaxUint64
 	// populate total resource limits with default values and physical resources if not set
 	totalResourceLimits := params.TotalResourceLimits.
 		Intersect(DefaultComputeConfig.TotalResourceLimits).
@@ -140,6 +292,11 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if params.QueueResourceLimits.IsZero() {
 		params.QueueResourceLimits = totalResourceLimits
 	}
+	// queue limits that don't budget fuel don't limit it, rather than keeping every WASM job with a fuel budget out
+	// of the queue
+	if params.QueueResourceLimits.Fuel == 0 {
+		params.QueueResourceLimits.Fuel = totalResourceLimits.Fuel
+	}
 
 	// populate default job resource limits with default values and job resource limits if not set
 	defaultJobResourceLimits := params.DefaultJobResourceLimits.
@@ -166,6 +323,23 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 		SimulatorConfig:              params.SimulatorConfig,
 		BidSemanticStrategy:          params.BidSemanticStrategy,
 		BidResourceStrategy:          params.BidResourceStrategy,
+		AdminClientIDs:               params.AdminClientIDs,
+		TrustedRequesters:            params.TrustedRequesters,
+		InputPrefetchBudget:          params.InputPrefetchBudget,
+		CallbackBatchInterval:        params.CallbackBatchInterval,
+		CallbackMaxBatchSize:         params.CallbackMaxBatchSize,
+		InputProbeTimeout:            params.InputProbeTimeout,
+		InputFetchRate:               params.InputFetchRate,
+		CallbackOfflineBufferSize:    params.CallbackOfflineBufferSize,
+		EnablePreemption:             params.EnablePreemption,
+		MaxConcurrentPublishes:       params.MaxConcurrentPublishes,
+		MaxConcurrentCallbacks:       params.MaxConcurrentCallbacks,
+		MaxConcurrentCancels:         params.MaxConcurrentCancels,
+		MaxResultSize:                params.MaxResultSize,
+		OrphanPolicy:                 params.OrphanPolicy,
+		EnvironmentVariableAllowList: params.EnvironmentVariableAllowList,
+		EnvironmentVariableDenyList:  params.EnvironmentVariableDenyList,
+		SelfTest:                     params.SelfTest,
 	}
 
 	validateConfig(config, physicalResources)
@@ -201,4 +375,16 @@ func validateConfig(config ComputeConfig, physicalResources model.ResourceUsageD
 			config.DefaultJobResourceLimits, config.JobResourceLimits)
 		return
 	}
+
+	for _, requester := range config.TrustedRequesters {
+		if _, err = peer.Decode(requester); err != nil {
+			err = fmt.Errorf("invalid trusted requester %s: %w", requester, err)
+			return
+		}
+	}
+
+	if err = semantic.ValidateEnvironmentVariablePatterns(config.EnvironmentVariableAllowList); err != nil {
+		return
+	}
+	err = semantic.ValidateEnvironmentVariablePatterns(config.EnvironmentVariableDenyList)
 }
@@ -4,11 +4,21 @@ import (
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
+	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
 )
 
+// DefaultTotalFuel is the fuel nodes allow all running WASM jobs to be budgeted unless configured otherwise.
+// It is large enough not to limit nodes that don't schedule against fuel.
+const DefaultTotalFuel = 1 << 48
+
 var DefaultComputeConfig = ComputeConfigParams{
 	PhysicalResourcesProvider: system.NewPhysicalCapacityProvider(),
+	TotalResourceLimits: model.ResourceUsageData{
+		Fuel: DefaultTotalFuel,
+	},
 	DefaultJobResourceLimits: model.ResourceUsageData{
 		CPU:    0.1,               // 100m
 		Memory: 100 * 1024 * 1024, // 100Mi
@@ -21,11 +31,25 @@ var DefaultComputeConfig = ComputeConfigParams{
 	DefaultJobExecutionTimeout: 10 * time.Minute,
 
 	LogRunningExecutionsInterval: 10 * time.Second,
+
+	// events of executions are sent on their own unless batching is configured, so that none wait to be sent
+	CallbackBatchInterval: 0,
+	CallbackMaxBatchSize:  100,
+
+	InputProbeTimeout: 10 * time.Second,
+	InputFetchRate:    10 * 1024 * 1024, // 10Mi per second
+
+	SelfTest: SelfTestConfig{
+		DockerImage:    selftest.DefaultDockerImage,
+		MaxClockOffset: selftest.DefaultMaxClockOffset,
+		CheckTimeout:   2 * time.Minute,
+	},
 }
 
 var DefaultRequesterConfig = RequesterConfigParams{
 	MinJobExecutionTimeout:     0 * time.Second,
 	DefaultJobExecutionTimeout: 30 * time.Minute,
+	MaxJobExecutionTimeout:     60 * time.Minute,
 
 	HousekeepingBackgroundTaskInterval: 30 * time.Second,
 	NodeRankRandomnessRange:            5,
@@ -34,4 +58,22 @@ var DefaultRequesterConfig = RequesterConfigParams{
 	MinBacalhauVersion: model.BuildVersionInfo{
 		Major: "0", Minor: "3", GitVersion: "v0.3.26",
 	},
+
+	JobSpecLimits: job.DefaultSpecLimits,
+
+	JobPriorityRange: job.DefaultPriorityRange,
+	MaxPreemptions:   requester.DefaultMaxPreemptions,
+
+	// events are gossiped in batches, which a busy requester fills well before the interval
+	JobEventsFlushInterval: 200 * time.Millisecond,
+	JobEventsMaxBatchSize:  100,
+	// stay well below the default gossipsub message size limit of 1MiB
+	JobEventsMaxBufferSize: 512 * 1024,
+
+	// circuit breaking is disabled unless a window is configured
+	CircuitBreaker: requester.CircuitBreakerConfig{
+		MinFailures: 5,
+		FailureRate: 0.9,
+		Cooldown:    10 * time.Minute,
+	},
 }
@@ -5,14 +5,19 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/explorer"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/mqtt"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/notify"
 )
 
 type RequesterConfigParams struct {
 	// Timeout config
 	MinJobExecutionTimeout     time.Duration
 	DefaultJobExecutionTimeout time.Duration
+	MaxJobExecutionTimeout     time.Duration
 
 	HousekeepingBackgroundTaskInterval time.Duration
 	NodeRankRandomnessRange            int
@@ -25,6 +30,38 @@ type RequesterConfigParams struct {
 	MinBacalhauVersion model.BuildVersionInfo
 
 	RetryStrategy requester.RetryStrategy
+
+	JobSpecLimits job.SpecLimits
+
+	EnvironmentVariablePolicy model.EnvironmentVariablePolicy
+
+	JobPriorityRange job.PriorityRange
+
+	MaxPreemptions int
+
+	JobEventsFlushInterval time.Duration
+	JobEventsMaxBatchSize  int
+	JobEventsMaxBufferSize int64
+
+	SubmissionDedupWindow time.Duration
+
+	AdminClientIDs []string
+
+	Notifications notify.Config
+
+	MQTT mqtt.Config
+
+	CircuitBreaker requester.CircuitBreakerConfig
+
+	Explorer explorer.Config
+
+	Sharding requester.ShardingConfig
+
+	PinImageDigests bool
+
+	AutoSplitInputs bool
+
+	ConcurrencyCeiling requester.ConcurrencyCeilingConfig
 }
 
 type RequesterConfig struct {
@@ -34,6 +71,8 @@ type RequesterConfig struct {
 	// DefaultJobExecutionTimeout default value for running, verifying and publishing job results,
 	// if the user didn't define one in the spec
 	DefaultJobExecutionTimeout time.Duration
+	// MaxJobExecutionTimeout the longest clients can extend the timeout of their jobs to after submitting them.
+	MaxJobExecutionTimeout time.Duration
 
 	// HousekeepingBackgroundTaskInterval background task interval that periodically checks for expired states
 	HousekeepingBackgroundTaskInterval time.Duration
@@ -48,6 +87,66 @@ type RequesterConfig struct {
 	MinBacalhauVersion model.BuildVersionInfo
 
 	RetryStrategy requester.RetryStrategy
+
+	// JobSpecLimits are the maximum sizes of the parts of the job specs submitted to the requester
+	JobSpecLimits job.SpecLimits
+
+	// EnvironmentVariablePolicy is the environment variables jobs submitted to the requester can set. Jobs setting
+	// others are rejected rather than left for the compute nodes not to bid on.
+	EnvironmentVariablePolicy model.EnvironmentVariablePolicy
+
+	// JobPriorityRange is the priorities jobs submitted to the requester can have.
+	JobPriorityRange job.PriorityRange
+
+	// MaxPreemptions is how many executions of a job can be preempted on compute nodes before the job fails.
+	MaxPreemptions int
+
+	// JobEventsFlushInterval is the maximum time a job event is buffered before being gossiped to other nodes
+	JobEventsFlushInterval time.Duration
+	// JobEventsMaxBatchSize is the maximum number of job events gossiped together in a single message
+	JobEventsMaxBatchSize int
+	// JobEventsMaxBufferSize is the maximum size in bytes of job events gossiped together in a single message
+	JobEventsMaxBufferSize int64
+
+	// SubmissionDedupWindow is how long after a spec is submitted that submissions of an identical spec, from any
+	// client, are coalesced into the same job. Submissions are not deduplicated if zero.
+	SubmissionDedupWindow time.Duration
+
+	// AdminClientIDs is the list of clients that are allowed to use the admin API of this node.
+	// The admin API is disabled if the list is empty.
+	AdminClientIDs []string
+
+	// Notifications configures the notifications sent when jobs complete or fail. They are not sent if no channel is
+	// configured.
+	Notifications notify.Config
+
+	// MQTT configures the bridge republishing job events to an MQTT broker. There is no bridge if no broker is
+	// configured.
+	MQTT mqtt.Config
+
+	// CircuitBreaker configures when submissions of a spec whose jobs keep failing are rejected. Submissions are never
+	// rejected if its window is zero.
+	CircuitBreaker requester.CircuitBreakerConfig
+
+	// Explorer configures the read-only endpoints served without authentication for public dashboards. They are not
+	// served unless enabled.
+	Explorer explorer.Config
+
+	// Sharding partitions the ownership of jobs among the requesters sharing the cluster by the hash of their job IDs.
+	// Jobs are not sharded unless it lists several requesters.
+	Sharding requester.ShardingConfig
+
+	// PinImageDigests resolves the image tags of submitted docker jobs to digests, which are stored and run in their
+	// place. Docker must be installed on the requester for tags to be resolved.
+	PinImageDigests bool
+
+	// AutoSplitInputs submits jobs whose inputs don't fit on any compute node as several jobs that each fit, rather
+	// than rejecting them with a suggestion of how to split them.
+	AutoSplitInputs bool
+
+	// ConcurrencyCeiling limits the number of executions orchestrated at once, and configures what happens to the jobs
+	// submitted past the limit. Executions are not limited unless it has a maximum.
+	ConcurrencyCeiling requester.ConcurrencyCeilingConfig
 }
 
 func NewRequesterConfigWithDefaults() RequesterConfig {
@@ -69,6 +168,9 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 	if params.DefaultJobExecutionTimeout == 0 {
 		params.DefaultJobExecutionTimeout = DefaultRequesterConfig.DefaultJobExecutionTimeout
 	}
+	if params.MaxJobExecutionTimeout == 0 {
+		params.MaxJobExecutionTimeout = DefaultRequesterConfig.MaxJobExecutionTimeout
+	}
 	if params.HousekeepingBackgroundTaskInterval == 0 {
 		params.HousekeepingBackgroundTaskInterval = DefaultRequesterConfig.HousekeepingBackgroundTaskInterval
 	}
@@ -82,9 +184,39 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 		params.MinBacalhauVersion = DefaultRequesterConfig.MinBacalhauVersion
 	}
 
+	if params.JobSpecLimits == (job.SpecLimits{}) {
+		params.JobSpecLimits = DefaultRequesterConfig.JobSpecLimits
+	}
+	if params.JobPriorityRange == (job.PriorityRange{}) {
+		params.JobPriorityRange = DefaultRequesterConfig.JobPriorityRange
+	}
+	if params.MaxPreemptions == 0 {
+		params.MaxPreemptions = DefaultRequesterConfig.MaxPreemptions
+	}
+	if params.JobEventsFlushInterval == 0 {
+		params.JobEventsFlushInterval = DefaultRequesterConfig.JobEventsFlushInterval
+	}
+	if params.JobEventsMaxBatchSize == 0 {
+		params.JobEventsMaxBatchSize = DefaultRequesterConfig.JobEventsMaxBatchSize
+	}
+	if params.JobEventsMaxBufferSize == 0 {
+		params.JobEventsMaxBufferSize = DefaultRequesterConfig.JobEventsMaxBufferSize
+	}
+
+	if params.CircuitBreaker.MinFailures == 0 {
+		params.CircuitBreaker.MinFailures = DefaultRequesterConfig.CircuitBreaker.MinFailures
+	}
+	if params.CircuitBreaker.FailureRate == 0 {
+		params.CircuitBreaker.FailureRate = DefaultRequesterConfig.CircuitBreaker.FailureRate
+	}
+	if params.CircuitBreaker.Cooldown == 0 {
+		params.CircuitBreaker.Cooldown = DefaultRequesterConfig.CircuitBreaker.Cooldown
+	}
+
 	config = RequesterConfig{
 		MinJobExecutionTimeout:             params.MinJobExecutionTimeout,
 		DefaultJobExecutionTimeout:         params.DefaultJobExecutionTimeout,
+		MaxJobExecutionTimeout:             params.MaxJobExecutionTimeout,
 		HousekeepingBackgroundTaskInterval: params.HousekeepingBackgroundTaskInterval,
 		JobSelectionPolicy:                 params.JobSelectionPolicy,
 		NodeRankRandomnessRange:            params.NodeRankRandomnessRange,
@@ -93,6 +225,23 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 		SimulatorConfig:                    params.SimulatorConfig,
 		MinBacalhauVersion:                 params.MinBacalhauVersion,
 		RetryStrategy:                      params.RetryStrategy,
+		JobSpecLimits:                      params.JobSpecLimits,
+		EnvironmentVariablePolicy:          params.EnvironmentVariablePolicy,
+		JobPriorityRange:                   params.JobPriorityRange,
+		MaxPreemptions:                     params.MaxPreemptions,
+		JobEventsFlushInterval:             params.JobEventsFlushInterval,
+		JobEventsMaxBatchSize:              params.JobEventsMaxBatchSize,
+		JobEventsMaxBufferSize:             params.JobEventsMaxBufferSize,
+		SubmissionDedupWindow:              params.SubmissionDedupWindow,
+		AdminClientIDs:                     params.AdminClientIDs,
+		Notifications:                      params.Notifications,
+		MQTT:                               params.MQTT,
+		CircuitBreaker:                     params.CircuitBreaker,
+		Explorer:                           params.Explorer,
+		Sharding:                           params.Sharding,
+		PinImageDigests:                    params.PinImageDigests,
+		AutoSplitInputs:                    params.AutoSplitInputs,
+		ConcurrencyCeiling:                 params.ConcurrencyCeiling,
 	}
 
 	return config
@@ -3,11 +3,18 @@ package node
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 
+	compute_publicapi "github.com/bacalhau-project/bacalhau/pkg/compute/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	executor_util "github.com/bacalhau-project/bacalhau/pkg/executor/util"
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/local"
 	publisher_util "github.com/bacalhau-project/bacalhau/pkg/publisher/util"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
@@ -83,6 +90,7 @@ func NewStandardStorageProvidersFactory() StorageProvidersFactory {
 			nodeConfig.CleanupManager,
 			executor_util.StandardStorageProviderOptions{
 				API:                   nodeConfig.IPFSClient,
+				IPFSBackends:          ipfsBackends(nodeConfig),
 				EstuaryAPIKey:         nodeConfig.EstuaryAPIKey,
 				FilecoinUnsealedPath:  nodeConfig.FilecoinUnsealedPath,
 				AllowListedLocalPaths: nodeConfig.AllowListedLocalPaths,
@@ -103,7 +111,12 @@ func NewStandardExecutorsFactory() ExecutorsFactory {
 				nodeConfig.CleanupManager,
 				storages,
 				executor_util.StandardExecutorOptions{
-					DockerID: fmt.Sprintf("bacalhau-%s", nodeConfig.Host.ID().String()),
+					DockerID:        fmt.Sprintf("bacalhau-%s", nodeConfig.Host.ID().String()),
+					DockerImageScan: nodeConfig.ImageScan,
+					DockerImageGC:   nodeConfig.ImageGC,
+					Process:         nodeConfig.ProcessExecutor,
+					DockerWarmPool:  nodeConfig.DockerWarmPool,
+					WasmWarmPool:    nodeConfig.WasmWarmPool,
 				},
 			)
 			if err != nil {
@@ -140,9 +153,12 @@ func NewStandardPublishersFactory() PublishersFactory {
 			provider, err := publisher_util.NewIPFSPublishers(
 				ctx,
 				nodeConfig.CleanupManager,
-				nodeConfig.IPFSClient,
+				ipfsBackends(nodeConfig),
 				nodeConfig.EstuaryAPIKey,
 				nodeConfig.LotusConfig,
+				nodeConfig.ResultRetention,
+				config.GetIPFSRetentionRecordPath(nodeConfig.Host.ID().String()),
+				localPublisherConfig(nodeConfig),
 			)
 			if err != nil {
 				return nil, err
@@ -150,3 +166,20 @@ func NewStandardPublishersFactory() PublishersFactory {
 			return model.NewConfiguredProvider(provider, nodeConfig.DisabledFeatures.Publishers), err
 		})
 }
+
+// localPublisherConfig returns the configuration of the local publisher of the node, which serves its results from
+// the results endpoint of its compute API unless configured with another URL.
+func localPublisherConfig(nodeConfig NodeConfig) local.PublisherConfig {
+	config := nodeConfig.LocalPublisher
+	if config.Directory != "" && config.URL == "" {
+		config.URL = "http://" + net.JoinHostPort(nodeConfig.HostAddress, strconv.Itoa(int(nodeConfig.APIPort))) +
+			publicapi.V1APIPrefix + "/" + compute_publicapi.APIPrefix + compute_publicapi.APIResultsSuffix
+	}
+	return config
+}
+
+// ipfsBackends returns the IPFS backends of the node, falling back to its own IPFS client for all operations.
+func ipfsBackends(nodeConfig NodeConfig) ipfs.Backends {
+	backends := append(ipfs.Backends{}, nodeConfig.IPFSBackends...)
+	return append(backends, ipfs.NewDefaultBackend(nodeConfig.IPFSClient))
+}
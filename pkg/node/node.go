@@ -6,17 +6,23 @@ import (
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/process"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/warmpool"
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	filecoinlotus "github.com/bacalhau-project/bacalhau/pkg/publisher/filecoin_lotus"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/local"
 	"github.com/bacalhau-project/bacalhau/pkg/pubsub"
 	"github.com/bacalhau-project/bacalhau/pkg/pubsub/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/routing"
 	"github.com/bacalhau-project/bacalhau/pkg/routing/inmemory"
 	"github.com/bacalhau-project/bacalhau/pkg/simulator"
+	localdirectory "github.com/bacalhau-project/bacalhau/pkg/storage/local_directory"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/prefetch"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/bacalhau-project/bacalhau/pkg/version"
@@ -33,6 +39,21 @@ const JobEventsTopic = "bacalhau-job-events"
 const NodeInfoTopic = "bacalhau-node-info"
 const DefaultNodeInfoPublisherInterval = 30 * time.Second
 
+// DefaultMaxClockSkew is how far the clock of another node can be from the clock of this node, as seen in the info it
+// publishes, before a warning is added to its info.
+const DefaultMaxClockSkew = 5 * time.Second
+
+// NodeLoadWindow is how long the load published by compute nodes is kept to rank them, which covers several
+// publications of their info.
+const NodeLoadWindow = 5 * time.Minute
+
+// MaxPeerMessageRate is how many messages per second each peer can publish to a bacalhau topic, in bursts of up to
+// MaxPeerMessageBurst messages. Job events are batched before being published, so honest nodes stay well below it.
+const (
+	MaxPeerMessageRate  = 20
+	MaxPeerMessageBurst = 200
+)
+
 type FeatureConfig struct {
 	Engines    []model.Engine
 	Verifiers  []model.Verifier
@@ -42,26 +63,48 @@ type FeatureConfig struct {
 
 // Node configuration
 type NodeConfig struct {
-	IPFSClient                ipfs.Client
-	CleanupManager            *system.CleanupManager
-	JobStore                  jobstore.Store
-	Host                      host.Host
-	FilecoinUnsealedPath      string
-	EstuaryAPIKey             string
-	HostAddress               string
-	APIPort                   uint16
-	DisabledFeatures          FeatureConfig
-	ComputeConfig             ComputeConfig
-	RequesterNodeConfig       RequesterConfig
-	APIServerConfig           publicapi.APIServerConfig
-	LotusConfig               *filecoinlotus.PublisherConfig
+	IPFSClient ipfs.Client
+	// IPFSBackends are additional IPFS backends that storage operations are routed to before IPFSClient.
+	IPFSBackends         ipfs.Backends
+	CleanupManager       *system.CleanupManager
+	JobStore             jobstore.Store
+	Host                 host.Host
+	FilecoinUnsealedPath string
+	EstuaryAPIKey        string
+	HostAddress          string
+	APIPort              uint16
+	DisabledFeatures     FeatureConfig
+	ComputeConfig        ComputeConfig
+	RequesterNodeConfig  RequesterConfig
+	APIServerConfig      publicapi.APIServerConfig
+	LotusConfig          *filecoinlotus.PublisherConfig
+	// ResultRetention is how long results published to IPFS stay pinned. Zero keeps them pinned forever.
+	ResultRetention time.Duration
+	// LocalPublisher configures the publisher writing results to a directory of the compute node, which the node
+	// serves them from. The URL defaults to the results endpoint of the node's compute API.
+	LocalPublisher local.PublisherConfig
+	// ImageScan configures the vulnerability scan of docker images before compute nodes bid on jobs.
+	ImageScan docker.ImageScanConfig
+	// ImageGC configures the garbage collection of the docker images compute nodes pulled for jobs.
+	ImageGC docker.ImageGCConfig
+	// ProcessExecutor configures the executor running jobs as sandboxed processes on the host of compute nodes.
+	ProcessExecutor process.Config
+	// DockerWarmPool configures the containers compute nodes keep warm for the most run docker job configs.
+	DockerWarmPool warmpool.Config
+	// WasmWarmPool configures the runtimes compute nodes keep warm for the most run wasm modules.
+	WasmWarmPool              warmpool.Config
 	SimulatorNodeID           string
 	IsRequesterNode           bool
 	IsComputeNode             bool
 	Labels                    map[string]string
 	NodeInfoPublisherInterval time.Duration
-	DependencyInjector        NodeDependencyInjector
-	AllowListedLocalPaths     []string
+	// MaxClockSkew is how far the clock of another node can be from the clock of this node before a warning is added
+	// to its info. DefaultMaxClockSkew is used if zero.
+	MaxClockSkew time.Duration
+	// PubSubCompressionThreshold is the size in bytes from which gossiped messages are compressed. Zero disables it.
+	PubSubCompressionThreshold int
+	DependencyInjector         NodeDependencyInjector
+	AllowListedLocalPaths      []string
 }
 
 // Lazy node dependency injector that generate instances of different
@@ -117,6 +160,23 @@ func NewNode(
 		return nil, err
 	}
 
+	if config.IsComputeNode {
+		config.ComputeConfig.LocalPathSandbox = localdirectory.NewSandbox(localdirectory.ParseAllowPaths(config.AllowListedLocalPaths))
+		config.ComputeConfig.Jurisdiction = config.Labels[model.JurisdictionLabel]
+	}
+
+	if config.IsComputeNode && config.ComputeConfig.InputPrefetchBudget > 0 {
+		// executors go through the prefetcher to pick up inputs fetched while bidding
+		prefetcher := prefetch.NewPrefetcher(prefetch.PrefetcherParams{
+			Storages: storageProviders,
+			Budget:   config.ComputeConfig.InputPrefetchBudget,
+			// jobs without a timeout run with the default timeout of the node
+			DefaultTTL: config.ComputeConfig.DefaultJobExecutionTimeout,
+		})
+		config.ComputeConfig.InputPrefetcher = prefetcher
+		storageProviders = prefetcher
+	}
+
 	publishers, err := config.DependencyInjector.PublishersFactory.Get(ctx, config)
 	if err != nil {
 		return nil, err
@@ -132,6 +192,11 @@ func NewNode(
 		return nil, err
 	}
 
+	if config.IsComputeNode {
+		config.ComputeConfig.SelfTestRunner = newSelfTest(config, executors, publishers)
+		config.ComputeConfig.ResultsDirectory = config.LocalPublisher.Directory
+	}
+
 	var simulatorRequestHandler *simulator.RequestHandler
 	if config.SimulatorNodeID == config.Host.ID().String() {
 		log.Ctx(ctx).Info().Msgf("Node %s is the simulator node. Setting proper event handlers", config.Host.ID().String())
@@ -140,7 +205,10 @@ func NewNode(
 
 	// A single gossipSub instance that will be used by all topics
 	gossipSubCtx, gossipSubCancel := context.WithCancel(ctx)
-	gossipSub, err := newLibp2pPubSub(gossipSubCtx, config)
+	peerScoring := libp2p.NewPeerScoring(libp2p.PeerScoringParams{
+		Topics: []string{JobEventsTopic, NodeInfoTopic},
+	})
+	gossipSub, err := newLibp2pPubSub(gossipSubCtx, config, peerScoring)
 	defer func() {
 		if err != nil {
 			gossipSubCancel()
@@ -153,9 +221,12 @@ func NewNode(
 
 	// PubSub to publish node info to the network
 	nodeInfoPubSub, err := libp2p.NewPubSub[model.NodeInfo](libp2p.PubSubParams{
-		Host:      config.Host,
-		TopicName: NodeInfoTopic,
-		PubSub:    gossipSub,
+		Host:                 config.Host,
+		TopicName:            NodeInfoTopic,
+		PubSub:               gossipSub,
+		CompressionThreshold: config.PubSubCompressionThreshold,
+		MaxPeerMessageRate:   MaxPeerMessageRate,
+		MaxPeerMessageBurst:  MaxPeerMessageBurst,
 	})
 	if err != nil {
 		return nil, err
@@ -185,8 +256,15 @@ func NewNode(
 	})
 
 	// node info store that is used for both discovering compute nodes, as to find addresses of other nodes for routing requests.
+	maxClockSkew := config.MaxClockSkew
+	if maxClockSkew == 0 {
+		maxClockSkew = DefaultMaxClockSkew
+	}
 	nodeInfoStore := inmemory.NewNodeInfoStore(inmemory.NodeInfoStoreParams{
-		TTL: 10 * time.Minute,
+		TTL:              10 * time.Minute,
+		MaxClockSkew:     maxClockSkew,
+		MaxDeliveryDelay: nodeInfoPublisherInterval,
+		LoadWindow:       NodeLoadWindow,
 	})
 	routedHost := routedhost.Wrap(config.Host, nodeInfoStore)
 
@@ -200,11 +278,12 @@ func NewNode(
 
 	// public http api server
 	apiServer, err := publicapi.NewAPIServer(publicapi.APIServerParams{
-		Address:          config.HostAddress,
-		Port:             config.APIPort,
-		Host:             config.Host,
-		Config:           config.APIServerConfig,
-		NodeInfoProvider: nodeInfoProvider,
+		Address:           config.HostAddress,
+		Port:              config.APIPort,
+		Host:              config.Host,
+		Config:            config.APIServerConfig,
+		NodeInfoProvider:  nodeInfoProvider,
+		PeerScoreProvider: peerScoring,
 	})
 	if err != nil {
 		return nil, err
@@ -227,6 +306,7 @@ func NewNode(
 			verifiers,
 			storageProviders,
 			gossipSub,
+			config.PubSubCompressionThreshold,
 			nodeInfoStore,
 		)
 		if err != nil {
@@ -309,7 +389,8 @@ func (n *Node) IsComputeNode() bool {
 	return n.ComputeNode != nil
 }
 
-func newLibp2pPubSub(ctx context.Context, nodeConfig NodeConfig) (*libp2p_pubsub.PubSub, error) {
+func newLibp2pPubSub(
+	ctx context.Context, nodeConfig NodeConfig, peerScoring *libp2p.PeerScoring) (*libp2p_pubsub.PubSub, error) {
 	tracer, err := libp2p_pubsub.NewJSONTracer(config.GetLibp2pTracerPath())
 	if err != nil {
 		return nil, err
@@ -327,6 +408,9 @@ func newLibp2pPubSub(ctx context.Context, nodeConfig NodeConfig) (*libp2p_pubsub
 		libp2p_pubsub.WithPeerExchange(true),
 		libp2p_pubsub.WithPeerGater(pgParams),
 		libp2p_pubsub.WithEventTracer(tracer),
+		// messages that are not signed by their publisher are rejected, and penalize the peer that delivered them
+		libp2p_pubsub.WithMessageSignaturePolicy(libp2p_pubsub.StrictSign),
+		peerScoring.Option(),
 	)
 }
 
@@ -2,8 +2,10 @@ package node
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"net/url"
-	"time"
+	"path/filepath"
 
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/crypto"
@@ -20,8 +22,12 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/pubsub/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/discovery"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/explorer"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/mqtt"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/notify"
 	requester_publicapi "github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/ranking"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/reservation"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/retry"
 	"github.com/bacalhau-project/bacalhau/pkg/routing"
 	"github.com/bacalhau-project/bacalhau/pkg/simulator"
@@ -31,6 +37,7 @@ import (
 	simulator_protocol "github.com/bacalhau-project/bacalhau/pkg/transport/simulator"
 	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/command"
 )
 
 type Requester struct {
@@ -57,8 +64,43 @@ func NewRequesterNode(
 	verifiers verifier.VerifierProvider,
 	storageProviders storage.StorageProvider,
 	gossipSub *libp2p_pubsub.PubSub,
+	pubSubCompressionThreshold int,
 	nodeInfoStore routing.NodeInfoStore,
 ) (*Requester, error) {
+	if err := config.Sharding.Validate(); err != nil {
+		return nil, err
+	}
+
+	// notify of the jobs that end through the store, which all changes of the state of jobs go through
+	if config.Notifications.Enabled() {
+		notifier, err := notify.NewNotifier(notify.NotifierParams{
+			JobStore: jobStore,
+			NodeID:   host.ID().String(),
+			Config:   config.Notifications,
+		})
+		if err != nil {
+			return nil, err
+		}
+		jobStore = notify.NewNotifyingStore(jobStore, notifier)
+	}
+
+	// reject submissions of specs whose jobs keep failing, counting the jobs that end through the store
+	var circuitBreaker *requester.CircuitBreaker
+	if config.CircuitBreaker.Enabled() {
+		circuitBreaker = requester.NewCircuitBreaker(config.CircuitBreaker)
+		jobStore = requester.NewCircuitBreakingStore(jobStore, circuitBreaker)
+	}
+
+	// limit the executions orchestrated at once, releasing those of the jobs that end through the store
+	var concurrencyCeiling *requester.ConcurrencyCeiling
+	if config.ConcurrencyCeiling.Enabled() {
+		if err := config.ConcurrencyCeiling.Validate(); err != nil {
+			return nil, err
+		}
+		concurrencyCeiling = requester.NewConcurrencyCeiling(config.ConcurrencyCeiling)
+		jobStore = requester.NewConcurrencyCeilingStore(jobStore, concurrencyCeiling)
+	}
+
 	// prepare event handlers
 	tracerContextProvider := eventhandler.NewTracerContextProvider(host.ID().String())
 	localJobEventConsumer := eventhandler.NewChainedJobEventHandler(tracerContextProvider)
@@ -97,6 +139,31 @@ func NewRequesterNode(
 		}),
 	)
 
+	// capacity reservations held by compute nodes for the jobs of clients
+	configDir, err := system.EnsureConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := reservation.NewManager(reservation.ManagerParams{
+		NodeID:          host.ID().String(),
+		NodeDiscoverer:  nodeDiscoveryChain,
+		ComputeEndpoint: computeProxy,
+		StateFile:       filepath.Join(configDir, "reservations-"+host.ID().String()+".json"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// statistics of how fast nodes handled the datasets of previous jobs, fed by the results proposed by nodes
+	datasetStats := ranking.NewDatasetStats(ranking.DatasetStatsParams{JobStore: jobStore})
+
+	// trust in compute nodes earned by the results of theirs that were verified, used to run adaptive deals
+	nodeTrust := requester.NewVerificationTrust(requester.VerificationTrustParams{JobStore: jobStore})
+
+	emitter := requester.NewEventEmitter(requester.EventEmitterParams{
+		EventConsumer: localJobEventConsumer,
+	})
+
 	// compute node ranker
 	nodeRankerChain := ranking.NewChain()
 	nodeRankerChain.Add(
@@ -105,11 +172,16 @@ func NewRequesterNode(
 		ranking.NewVerifiersNodeRanker(),
 		ranking.NewPublishersNodeRanker(),
 		ranking.NewStoragesNodeRanker(),
+		ranking.NewEngineRequirementsNodeRanker(),
 		ranking.NewLabelsNodeRanker(),
+		ranking.NewResidencyNodeRanker(ranking.ResidencyNodeRankerParams{Auditor: emitter}),
 		ranking.NewMaxUsageNodeRanker(),
+		ranking.NewReservationNodeRanker(ranking.ReservationNodeRankerParams{Reservations: reservations}),
 		ranking.NewMinVersionNodeRanker(ranking.MinVersionNodeRankerParams{MinVersion: config.MinBacalhauVersion}),
 		ranking.NewPreviousExecutionsNodeRanker(ranking.PreviousExecutionsNodeRankerParams{JobStore: jobStore}),
 		// arbitrary rankers
+		ranking.NewDatasetsNodeRanker(ranking.DatasetsNodeRankerParams{Stats: datasetStats}),
+		ranking.NewLoadNodeRanker(),
 		ranking.NewRandomNodeRanker(ranking.RandomNodeRankerParams{
 			RandomnessRange: config.NodeRankRandomnessRange,
 		}),
@@ -129,9 +201,7 @@ func NewRequesterNode(
 		NodeDiscoverer: nodeDiscoveryChain,
 		NodeRanker:     nodeRankerChain,
 	})
-	emitter := requester.NewEventEmitter(requester.EventEmitterParams{
-		EventConsumer: localJobEventConsumer,
-	})
+	schedulerAudit := requester.NewSchedulerAudit(requester.DefaultSchedulerAuditMaxJobs)
 	scheduler := requester.NewBaseScheduler(requester.BaseSchedulerParams{
 		ID:                   host.ID().String(),
 		Host:                 host,
@@ -143,6 +213,9 @@ func NewRequesterNode(
 		Verifiers:            verifiers,
 		StorageProviders:     storageProviders,
 		EventEmitter:         emitter,
+		NodeTrust:            nodeTrust,
+		MaxPreemptions:       config.MaxPreemptions,
+		Audit:                schedulerAudit,
 		GetVerifyCallback: func() *url.URL {
 			return apiServer.GetURI().JoinPath(requester_publicapi.APIPrefix, requester_publicapi.VerifyRoute)
 		},
@@ -168,16 +241,37 @@ func NewRequesterNode(
 		StorageProviders:           storageProviders,
 		MinJobExecutionTimeout:     config.MinJobExecutionTimeout,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
+		MaxJobExecutionTimeout:     config.MaxJobExecutionTimeout,
 		GetBiddingCallback: func() *url.URL {
 			return apiServer.GetURI().JoinPath(requester_publicapi.APIPrefix, requester_publicapi.ApprovalRoute)
 		},
+		DedupWindow:        config.SubmissionDedupWindow,
+		Reservations:       reservations,
+		CircuitBreaker:     circuitBreaker,
+		NodeDiscoverer:     nodeDiscoveryChain,
+		Sharding:           config.Sharding,
+		PinImageDigests:    config.PinImageDigests,
+		AutoSplitInputs:    config.AutoSplitInputs,
+		ConcurrencyCeiling: concurrencyCeiling,
 	})
 
+	// validation jobs of the command verifier run through this requester node
+	if v, err := verifiers.Get(ctx, model.VerifierCommand); err == nil {
+		if commandVerifier, ok := v.(*command.CommandVerifier); ok {
+			commandVerifier.SetJobRunner(requester.NewValidationJobRunner(requester.ValidationJobRunnerParams{
+				Endpoint:  endpoint,
+				JobStore:  jobStore,
+				Scheduler: scheduler,
+			}))
+		}
+	}
+
 	housekeeping := requester.NewHousekeeping(requester.HousekeepingParams{
-		Endpoint: endpoint,
-		JobStore: jobStore,
-		NodeID:   host.ID().String(),
-		Interval: config.HousekeepingBackgroundTaskInterval,
+		Endpoint:     endpoint,
+		JobStore:     jobStore,
+		EventEmitter: emitter,
+		NodeID:       host.ID().String(),
+		Interval:     config.HousekeepingBackgroundTaskInterval,
 	})
 
 	// if this node is the simulator, then we pass incoming requests to the simulator before passing them to the endpoint
@@ -200,12 +294,44 @@ func NewRequesterNode(
 	}
 
 	// register requester public http apis
+	var requesterExplorer *explorer.Explorer
+	if config.Explorer.Enabled {
+		explorerConfig := config.Explorer
+		if len(explorerConfig.RedactionSecret) == 0 {
+			// derive the secret from the key of the node, so that hashes are stable across restarts
+			explorerConfig.RedactionSecret, err = explorerRedactionSecret(host)
+			if err != nil {
+				return nil, err
+			}
+		}
+		requesterExplorer, err = explorer.NewExplorer(explorer.ExplorerParams{
+			JobStore:      jobStore,
+			NodeInfoStore: nodeInfoStore,
+			Config:        explorerConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	requesterAPIServer := requester_publicapi.NewRequesterAPIServer(requester_publicapi.RequesterAPIServerParams{
 		APIServer:          apiServer,
 		Requester:          endpoint,
 		DebugInfoProviders: debugInfoProviders,
 		JobStore:           jobStore,
 		StorageProviders:   storageProviders,
+		SpecLimits:         config.JobSpecLimits,
+		EnvPolicy:          config.EnvironmentVariablePolicy,
+		PriorityRange:      config.JobPriorityRange,
+		SchedulerAudit:     schedulerAudit,
+		NodeInfoStore:      nodeInfoStore,
+		AdminClientIDs:     config.AdminClientIDs,
+		Reservations:       reservations,
+		NodeID:             host.ID().String(),
+		Explorer:           requesterExplorer,
+		Sharding:           config.Sharding,
+		ComputeEndpoint:    computeProxy,
+		Host:               host,
 	})
 	err = requesterAPIServer.RegisterAllHandlers()
 	if err != nil {
@@ -214,10 +340,13 @@ func NewRequesterNode(
 
 	// PubSub to publish job events to the network
 	libp2p2JobEventPubSub, err := libp2p.NewPubSub[pubsub.BufferingEnvelope](libp2p.PubSubParams{
-		Host:        host,
-		TopicName:   JobEventsTopic,
-		PubSub:      gossipSub,
-		IgnoreLocal: true,
+		Host:                 host,
+		TopicName:            JobEventsTopic,
+		PubSub:               gossipSub,
+		IgnoreLocal:          true,
+		CompressionThreshold: pubSubCompressionThreshold,
+		MaxPeerMessageRate:   MaxPeerMessageRate,
+		MaxPeerMessageBurst:  MaxPeerMessageBurst,
 	})
 	if err != nil {
 		return nil, err
@@ -225,8 +354,9 @@ func NewRequesterNode(
 
 	bufferedJobEventPubSub := pubsub.NewBufferingPubSub[model.JobEvent](pubsub.BufferingPubSubParams{
 		DelegatePubSub: libp2p2JobEventPubSub,
-		MaxBufferSize:  1, //nolint:gomnd // increase this once we move to an external job storage
-		MaxBufferAge:   1 * time.Minute,
+		MaxBufferSize:  config.JobEventsMaxBufferSize,
+		MaxBufferAge:   config.JobEventsFlushInterval,
+		MaxBatchSize:   config.JobEventsMaxBatchSize,
 	})
 
 	// Register event handlers
@@ -246,14 +376,50 @@ func NewRequesterNode(
 		eventTracer,
 		// dispatches events to listening websockets
 		requesterAPIServer,
+		// records how fast nodes fetched and ran the datasets of jobs
+		datasetStats,
+		// records which results of compute nodes were verified
+		nodeTrust,
 		// dispatches events to the network
 		eventhandler.JobEventHandlerFunc(bufferedJobEventPubSub.Publish),
 	)
 
+	// republish events to the MQTT broker, and submit the jobs published to it
+	var mqttClient mqtt.Client
+	if config.MQTT.Enabled() {
+		mqttClient, err = mqtt.NewClient(ctx, config.MQTT, "bacalhau-requester-"+host.ID().String())
+		if err != nil {
+			return nil, err
+		}
+		bridgeParams := mqtt.BridgeParams{
+			Client:        mqttClient,
+			SpecLimits:    config.JobSpecLimits,
+			EnvPolicy:     config.EnvironmentVariablePolicy,
+			PriorityRange: config.JobPriorityRange,
+			Authenticator: apiServer.Authenticator(),
+			JobStore:      jobStore,
+			TopicPrefix:   config.MQTT.TopicPrefix,
+		}
+		if config.MQTT.AcceptSubmissions {
+			bridgeParams.Endpoint = endpoint
+		}
+		bridge := mqtt.NewBridge(bridgeParams)
+		if err = bridge.Start(ctx); err != nil {
+			return nil, err
+		}
+		localJobEventConsumer.AddHandlers(bridge)
+	}
+
+	// the concurrency ceiling is only held in memory, so the jobs it counted and queued are restored from the store
+	if err = endpoint.RestoreConcurrencyCeiling(ctx); err != nil {
+		return nil, err
+	}
+
 	// A single cleanup function to make sure the order of closing dependencies is correct
 	cleanupFunc := func(ctx context.Context) {
 		// stop the housekeeping background task
 		housekeeping.Stop()
+		requesterAPIServer.Close()
 
 		cleanupErr := bufferedJobEventPubSub.Close(ctx)
 		util.LogDebugIfContextCancelled(ctx, cleanupErr, "buffered job event pubsub")
@@ -267,6 +433,10 @@ func NewRequesterNode(
 		if cleanupErr != nil {
 			log.Ctx(ctx).Error().Err(cleanupErr).Msg("failed to shutdown event tracer")
 		}
+		if mqttClient != nil {
+			cleanupErr = mqttClient.Close(ctx)
+			util.LogDebugIfContextCancelled(ctx, cleanupErr, "MQTT client")
+		}
 	}
 
 	return &Requester{
@@ -287,3 +457,19 @@ func (r *Requester) RegisterLocalComputeEndpoint(endpoint compute.Endpoint) {
 func (r *Requester) cleanup(ctx context.Context) {
 	r.cleanupFunc(ctx)
 }
+
+// explorerRedactionSecret derives the secret the explorer keys the hashes of redacted fields with from the private key
+// of the node, or returns nil for a random secret if the node has none.
+func explorerRedactionSecret(host host.Host) ([]byte, error) {
+	privateKey := host.Peerstore().PrivKey(host.ID())
+	if privateKey == nil {
+		return nil, nil
+	}
+	raw, err := privateKey.Raw()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, raw)
+	mac.Write([]byte("bacalhau-explorer-redaction"))
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/selftest"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"golang.org/x/exp/slices"
+)
+
+// newSelfTest creates the self-test of a compute node, with checks for the engines and publishers that are enabled.
+func newSelfTest(
+	config NodeConfig,
+	executors executor.ExecutorProvider,
+	publishers publisher.PublisherProvider,
+) *selftest.SelfTest {
+	selfTestConfig := config.ComputeConfig.SelfTest
+	var checks []selftest.Check
+	if !slices.Contains(config.DisabledFeatures.Engines, model.EngineDocker) {
+		checks = append(checks, selftest.NewExecutorCheck("docker", executors, selftest.DockerJob(selfTestConfig.DockerImage)))
+	}
+	if !slices.Contains(config.DisabledFeatures.Engines, model.EngineWasm) {
+		checks = append(checks, selftest.NewExecutorCheck("wasm", executors, selftest.WasmJob()))
+	}
+
+	var store selftest.ContentStore
+	if config.IPFSClient.API != nil {
+		store = config.IPFSClient
+		checks = append(checks, selftest.NewIPFSCheck(store))
+	}
+	if !slices.Contains(config.DisabledFeatures.Publishers, model.PublisherIpfs) {
+		checks = append(checks, selftest.NewPublisherCheck(publishers, model.PublisherSpec{Type: model.PublisherIpfs}, store))
+	}
+	checks = append(checks, selftest.NewClockCheck(selfTestConfig.NTPServer, selfTestConfig.MaxClockOffset))
+
+	return selftest.NewSelfTest(selftest.SelfTestParams{
+		NodeID:       config.Host.ID().String(),
+		Checks:       checks,
+		CheckTimeout: selfTestConfig.CheckTimeout,
+	})
+}
+
+// RunSelfTest runs the self-test of a compute node with the configuration, without creating the node, so that the
+// node can be validated before it joins the network.
+func RunSelfTest(ctx context.Context, config NodeConfig) (selftest.Report, error) {
+	config.DependencyInjector = mergeDependencyInjectors(config.DependencyInjector, NewStandardNodeDependencyInjector())
+
+	storageProviders, err := config.DependencyInjector.StorageProvidersFactory.Get(ctx, config)
+	if err != nil {
+		return selftest.Report{}, err
+	}
+	publishers, err := config.DependencyInjector.PublishersFactory.Get(ctx, config)
+	if err != nil {
+		return selftest.Report{}, err
+	}
+	executors, err := config.DependencyInjector.ExecutorsFactory.Get(ctx, config, storageProviders)
+	if err != nil {
+		return selftest.Report{}, err
+	}
+	return newSelfTest(config, executors, publishers).Run(ctx), nil
+}
@@ -0,0 +1,124 @@
+package publicapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"golang.org/x/exp/slices"
+)
+
+// Scope is a set of API operations that a caller can be allowed to perform.
+type Scope string
+
+const (
+	// ScopeRead allows reading jobs, their state, events, logs and results.
+	ScopeRead Scope = "read"
+	// ScopeSubmit allows submitting, updating and canceling jobs, and uploading their inputs.
+	ScopeSubmit Scope = "submit"
+	// ScopeAdmin allows operating the node, and reading and changing the jobs of every namespace.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists the scopes that can be granted to callers.
+var Scopes = []Scope{ScopeRead, ScopeSubmit, ScopeAdmin}
+
+// ParseScope returns the scope with the given name.
+func ParseScope(value string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == value {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("unknown scope %q, expected one of %v", value, Scopes)
+}
+
+// Principal is the authenticated identity behind a request.
+type Principal struct {
+	// Subject identifies the caller at its identity provider.
+	Subject string
+	// Namespace is the namespace the jobs of the caller belong to.
+	Namespace string
+	// Scopes are the operations the caller is allowed to perform.
+	Scopes []Scope
+	// ActsInNamespace is whether an admin chose to act in Namespace, which restricts its requests to it.
+	ActsInNamespace bool
+}
+
+// HasScope returns true if the principal is allowed to perform the operations of the scope. Admins can do anything.
+func (p Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// Authenticator validates the bearer tokens sent with requests.
+type Authenticator interface {
+	// Authenticate returns the identity the token was issued to, or an error if the token is not valid.
+	Authenticate(ctx context.Context, token string) (Principal, error)
+}
+
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a context carrying the authenticated identity behind a request.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated identity behind a request, or false if the node does not authenticate
+// its clients.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// RequestNamespace returns the namespace the jobs of a request are restricted to, which is empty if the node does not
+// authenticate its clients or the caller is an admin.
+func RequestNamespace(ctx context.Context) string {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || (principal.HasScope(ScopeAdmin) && !principal.ActsInNamespace) {
+		return ""
+	}
+	return principal.Namespace
+}
+
+// authenticate wraps a handler so that it is only called for requests with a bearer token that the authenticator
+// accepts and that grants the scope. Handlers without a scope are public. Requests can choose the namespace they act in
+// with handlerwrapper.HTTPHeaderNamespace, which must be the namespace of the caller unless it is an admin.
+func authenticate(handler http.Handler, authenticator Authenticator, scope Scope) http.Handler {
+	if authenticator == nil || scope == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			res.Header().Set("WWW-Authenticate", "Bearer")
+			HTTPError(ctx, res, errors.New("missing bearer token"), http.StatusUnauthorized)
+			return
+		}
+		principal, err := authenticator.Authenticate(ctx, token)
+		if err != nil {
+			res.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			HTTPError(ctx, res, fmt.Errorf("invalid bearer token: %w", err), http.StatusUnauthorized)
+			return
+		}
+		if !principal.HasScope(scope) {
+			err = fmt.Errorf("%s is not granted the %s scope", principal.Subject, scope)
+			HTTPError(ctx, res, err, http.StatusForbidden)
+			return
+		}
+		if namespace := req.Header.Get(handlerwrapper.HTTPHeaderNamespace); namespace != "" {
+			if principal.HasScope(ScopeAdmin) {
+				principal.Namespace = namespace
+				principal.ActsInNamespace = true
+			} else if namespace != principal.Namespace {
+				err = fmt.Errorf("%s cannot act in namespace %q", principal.Subject, namespace)
+				HTTPError(ctx, res, err, http.StatusForbidden)
+				return
+			}
+		}
+		handler.ServeHTTP(res, req.WithContext(ContextWithPrincipal(ctx, principal)))
+	})
+}
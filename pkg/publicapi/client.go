@@ -3,18 +3,28 @@ package publicapi
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -24,20 +34,29 @@ import (
 type APIClient struct {
 	BaseURI        *url.URL
 	DefaultHeaders map[string]string
+	// Retry configures how requests that failed transiently are retried.
+	Retry RetryOptions
 
 	Client *http.Client
 }
 
+// hostPort joins a host and port into an address, wrapping IPv6 addresses in brackets.
+func hostPort(host string, port uint16) string {
+	return net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(int(port)))
+}
+
 // NewAPIClient returns a new client for a node's API server against v1 APIs
 // the client will use /api/v1 path by default is no custom path is defined
 func NewAPIClient(host string, port uint16, path ...string) *APIClient {
-	baseURI := system.MustParseURL(fmt.Sprintf("http://%s:%d", host, port)).JoinPath(path...)
+	baseURI := system.MustParseURL("http://" + hostPort(host, port)).JoinPath(path...)
 	if len(path) == 0 {
 		baseURI = baseURI.JoinPath(V1APIPrefix)
 	}
 	return &APIClient{
-		BaseURI:        baseURI,
-		DefaultHeaders: map[string]string{},
+		BaseURI: baseURI,
+		// the server only replays the responses to requests with an idempotency key to the client that sent them
+		DefaultHeaders: map[string]string{handlerwrapper.HTTPHeaderClientID: system.GetClientID()},
+		Retry:          DefaultRetryOptions,
 
 		Client: &http.Client{
 			Timeout: 300 * time.Second,
@@ -87,6 +106,25 @@ func (apiClient *APIClient) Version(ctx context.Context) (*model.BuildVersionInf
 	return res.VersionInfo, nil
 }
 
+// Identity asks the node to prove which libp2p peer it is, by signing a random nonce with its private key, and
+// returns the peer ID it proved to be.
+func (apiClient *APIClient) Identity(ctx context.Context) (peer.ID, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.Identity")
+	defer span.End()
+
+	nonce := make([]byte, 32) //nolint:gomnd
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	req := IdentityRequest{Nonce: base64.StdEncoding.EncodeToString(nonce)}
+
+	var res IdentityResponse
+	if err := apiClient.Post(ctx, "identity", req, &res); err != nil {
+		return "", err
+	}
+	return verifyIdentity(req.Nonce, res)
+}
+
 func (apiClient *APIClient) PostSigned(ctx context.Context, api string, reqData, resData interface{}) error {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.PostSigned")
 	defer span.End()
@@ -99,10 +137,41 @@ func (apiClient *APIClient) PostSigned(ctx context.Context, api string, reqData,
 	return apiClient.Post(ctx, api, req, resData)
 }
 
+// Get sends a GET request to the API with the given query parameters, and decodes the JSON response into resData.
+func (apiClient *APIClient) Get(ctx context.Context, api string, query url.Values, resData interface{}) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.Get")
+	defer span.End()
+
+	addr := apiClient.BaseURI.JoinPath(api)
+	addr.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr.String(), nil)
+	if err != nil {
+		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating Get request: %v", err))
+	}
+	return apiClient.do(req, resData)
+}
+
 func (apiClient *APIClient) Post(ctx context.Context, api string, reqData, resData interface{}) error {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.Post")
 	defer span.End()
 
+	return apiClient.sendJSON(ctx, http.MethodPost, api, reqData, resData)
+}
+
+// PatchSigned signs the request data and sends it to the API as a PATCH request.
+func (apiClient *APIClient) PatchSigned(ctx context.Context, api string, reqData, resData interface{}) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.PatchSigned")
+	defer span.End()
+
+	req, err := SignRequest(reqData)
+	if err != nil {
+		return err
+	}
+
+	return apiClient.sendJSON(ctx, http.MethodPatch, api, req, resData)
+}
+
+func (apiClient *APIClient) sendJSON(ctx context.Context, method string, api string, reqData, resData interface{}) error {
 	var body bytes.Buffer
 	var err error
 	if err = json.NewEncoder(&body).Encode(reqData); err != nil {
@@ -110,26 +179,91 @@ func (apiClient *APIClient) Post(ctx context.Context, api string, reqData, resDa
 	}
 
 	addr := apiClient.BaseURI.JoinPath(api).String()
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, &body)
+	req, err := http.NewRequestWithContext(ctx, method, addr, &body)
 	if err != nil {
-		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating Post request: %v", err))
+		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating %s request: %v", method, err))
 	}
 	req.Header.Set("Content-type", "application/json")
+	return apiClient.do(req, resData)
+}
+
+// PostBytes posts raw data to the API with the given query parameters, and decodes the JSON response into resData.
+func (apiClient *APIClient) PostBytes(ctx context.Context, api string, query url.Values, data []byte, resData interface{}) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/publicapi.Client.PostBytes")
+	defer span.End()
+
+	addr := apiClient.BaseURI.JoinPath(api)
+	addr.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr.String(), bytes.NewReader(data))
+	if err != nil {
+		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating Post request: %v", err))
+	}
+	req.Header.Set("Content-type", "application/octet-stream")
+	return apiClient.do(req, resData)
+}
+
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey returns a context whose mutating requests carry the given idempotency key, rather than a
+// key of their own. Requests sent again with the same key, such as after the client crashed, are handled only once by
+// the server, as long as it still remembers the key.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func (apiClient *APIClient) do(req *http.Request, resData interface{}) error {
 	for header, value := range apiClient.DefaultHeaders {
 		req.Header.Set(header, value)
 	}
 	req.Close = true // don't keep connections lying around
 
-	var res *http.Response
-	res, err = apiClient.Client.Do(req)
+	// every attempt of a mutating request carries the same key, so that the server handles it only once
+	if isMutating(req.Method) && req.Header.Get(handlerwrapper.HTTPHeaderIdempotencyKey) == "" {
+		key, ok := req.Context().Value(idempotencyKeyContextKey{}).(string)
+		if !ok || key == "" {
+			key = uuid.NewString()
+		}
+		req.Header.Set(handlerwrapper.HTTPHeaderIdempotencyKey, key)
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := apiClient.doOnce(req, resData)
+		if err == nil || !retryable || attempt >= apiClient.Retry.MaxAttempts {
+			return err
+		}
+		// the body was consumed by the previous attempt
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return err
+			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error rewinding request body: %v", err))
+			}
+		}
+
+		backoff := apiClient.Retry.backoff(attempt + 1)
+		log.Ctx(req.Context()).Debug().Err(err).Msgf("publicapi: retrying %s %s in %s", req.Method, req.URL.Path, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return bacerrors.NewContextCanceledError(req.Context().Err().Error())
+		}
+	}
+}
+
+// doOnce sends the request once, and returns whether the request may succeed if it is sent again when it fails.
+func (apiClient *APIClient) doOnce(req *http.Request, resData interface{}) (bool, error) {
+	res, err := apiClient.Client.Do(req)
 	if err != nil {
 		errString := err.Error()
 		if errorResponse, ok := err.(*bacerrors.ErrorResponse); ok {
-			return errorResponse
-		} else if errString == "context canceled" {
-			return bacerrors.NewContextCanceledError(err.Error())
+			return false, errorResponse
+		} else if errors.Is(err, ErrResponseRejected) {
+			return false, err
+		} else if errString == "context canceled" || req.Context().Err() != nil {
+			return false, bacerrors.NewContextCanceledError(err.Error())
 		} else {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v", err))
+			return true, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v", err))
 		}
 	}
 
@@ -140,31 +274,32 @@ func (apiClient *APIClient) Post(ctx context.Context, api string, reqData, resDa
 	}()
 
 	if res.StatusCode != http.StatusOK {
+		retryable := isTransientStatus(res.StatusCode)
 		var responseBody []byte
 		responseBody, err = io.ReadAll(res.Body)
 		if err != nil {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error reading response body: %v", err))
+			return retryable, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error reading response body: %v", err))
 		}
 
 		var serverError *bacerrors.ErrorResponse
 		if err = model.JSONUnmarshalWithMax(responseBody, &serverError); err != nil {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v",
+			return retryable, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v",
 				string(responseBody)))
 		}
 
 		if !reflect.DeepEqual(serverError, bacerrors.BacalhauErrorInterface(nil)) {
-			return serverError
+			return retryable, serverError
 		}
 	}
 
 	err = json.NewDecoder(res.Body).Decode(resData)
 	if err != nil {
 		if err == io.EOF {
-			return nil // No error, just no data
+			return false, nil // No error, just no data
 		} else {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error decoding response body: %v", err))
+			return false, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error decoding response body: %v", err))
 		}
 	}
 
-	return nil
+	return false, nil
 }
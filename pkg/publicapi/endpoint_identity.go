@@ -0,0 +1,120 @@
+package publicapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// identityChallengePrefix is signed along with the nonce, so that the signature can't be replayed as anything else.
+const identityChallengePrefix = "bacalhau-identity:"
+
+type IdentityRequest struct {
+	// A random value chosen by the client, so that the signature is fresh
+	Nonce string `json:"nonce" validate:"required" example:"mS3fSAXsm5ZHvDtkxMTqtw=="`
+}
+
+type IdentityResponse struct {
+	PeerID string `json:"peer_id" example:"QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL"`
+	// The base64-encoded libp2p public key of the node
+	PublicKey string `json:"public_key"`
+	// A base64-encoded signature of the nonce by the libp2p private key of the node
+	Signature string `json:"signature"`
+}
+
+func identityChallenge(nonce string) []byte {
+	return []byte(identityChallengePrefix + nonce)
+}
+
+// identity godoc
+//
+//	@ID				identity
+//	@Summary		Proves the identity of the host node.
+//	@Description	Signs the nonce of the client with the libp2p private key of the node, so that the client can check it is talking to the node it expects.
+//	@Tags			Utils
+//	@Accept			json
+//	@Produce		json
+//	@Param			IdentityRequest	body		IdentityRequest	true	" "
+//	@Success		200				{object}	IdentityResponse
+//	@Failure		400				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/identity [post]
+//
+//nolint:lll
+func (apiServer *APIServer) identity(res http.ResponseWriter, req *http.Request) {
+	var identityReq IdentityRequest
+	err := json.NewDecoder(req.Body).Decode(&identityReq)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if identityReq.Nonce == "" {
+		http.Error(res, "nonce is required", http.StatusBadRequest)
+		return
+	}
+
+	privateKey := apiServer.host.Peerstore().PrivKey(apiServer.host.ID())
+	if privateKey == nil {
+		http.Error(res, "node has no private key", http.StatusInternalServerError)
+		return
+	}
+	publicKey, err := crypto.MarshalPublicKey(privateKey.GetPublic())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	signature, err := privateKey.Sign(identityChallenge(identityReq.Nonce))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(IdentityResponse{
+		PeerID:    apiServer.host.ID().String(),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ErrIdentityNotProven is returned when a node fails to prove it holds the key of the peer ID it claims.
+var ErrIdentityNotProven = errors.New("node failed to prove its identity")
+
+// verifyIdentity checks that the response was signed for the nonce by the key of the peer ID it claims, and returns
+// that peer ID.
+func verifyIdentity(nonce string, res IdentityResponse) (peer.ID, error) {
+	data, err := base64.StdEncoding.DecodeString(res.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+	publicKey, err := crypto.UnmarshalPublicKey(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+	peerID, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+	if peerID.String() != res.PeerID {
+		return "", fmt.Errorf("%w: public key is not the key of peer %s", ErrIdentityNotProven, res.PeerID)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(res.Signature)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid signature: %s", ErrIdentityNotProven, err)
+	}
+	ok, err := publicKey.Verify(identityChallenge(nonce), signature)
+	if err != nil || !ok {
+		return "", fmt.Errorf("%w: signature does not match", ErrIdentityNotProven)
+	}
+	return peerID, nil
+}
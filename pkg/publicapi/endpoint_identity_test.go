@@ -0,0 +1,47 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyIdentity(t *testing.T) {
+	signIdentity := func(nonce string) (peer.ID, IdentityResponse) {
+		privateKey, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		peerID, err := peer.IDFromPublicKey(publicKey)
+		require.NoError(t, err)
+		encodedKey, err := crypto.MarshalPublicKey(publicKey)
+		require.NoError(t, err)
+		signature, err := privateKey.Sign(identityChallenge(nonce))
+		require.NoError(t, err)
+		return peerID, IdentityResponse{
+			PeerID:    peerID.String(),
+			PublicKey: base64.StdEncoding.EncodeToString(encodedKey),
+			Signature: base64.StdEncoding.EncodeToString(signature),
+		}
+	}
+
+	peerID, res := signIdentity("nonce")
+	verified, err := verifyIdentity("nonce", res)
+	require.NoError(t, err)
+	require.Equal(t, peerID, verified)
+
+	// a signature of another nonce can't be replayed
+	_, err = verifyIdentity("other nonce", res)
+	require.ErrorIs(t, err, ErrIdentityNotProven)
+
+	// the peer ID must be the one of the key that signed
+	otherPeerID, _ := signIdentity("nonce")
+	impersonation := res
+	impersonation.PeerID = otherPeerID.String()
+	_, err = verifyIdentity("nonce", impersonation)
+	require.ErrorIs(t, err, ErrIdentityNotProven)
+}
@@ -0,0 +1,30 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// peerScores godoc
+//
+//	@ID			peerScores
+//	@Summary	Returns the scores of the peers of the node in the gossip of bacalhau topics, lowest score first.
+//	@Tags		Utils
+//	@Produce	json
+//	@Success	200	{object}	[]model.PeerScore
+//	@Failure	500	{object}	string
+//	@Router		/peer_scores [get]
+func (apiServer *APIServer) peerScores(res http.ResponseWriter, req *http.Request) {
+	scores := []model.PeerScore{}
+	if apiServer.peerScoreProvider != nil {
+		scores = apiServer.peerScoreProvider.GetPeerScores(req.Context())
+	}
+	res.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(res).Encode(scores)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
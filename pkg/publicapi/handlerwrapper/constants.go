@@ -3,3 +3,10 @@ package handlerwrapper
 var HTTPHeaderClientID = "X-Bacalhau-Client-ID"
 
 var HTTPHeaderJobID = "X-Bacalhau-Job-ID"
+
+// HTTPHeaderIdempotencyKey identifies a request across its retries, so that the server handles it only once.
+var HTTPHeaderIdempotencyKey = "Idempotency-Key"
+
+// HTTPHeaderNamespace is the namespace a client acts in. Clients can only act in their own namespace, unless they are
+// admins.
+var HTTPHeaderNamespace = "X-Bacalhau-Namespace"
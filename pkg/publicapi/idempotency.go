@@ -0,0 +1,225 @@
+package publicapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+)
+
+const (
+	// idempotencyKeyTTL is how long the response to a request is kept to be replayed to retries of the request.
+	idempotencyKeyTTL = 10 * time.Minute
+	// maxIdempotentResponses caps how many responses are kept. Requests are handled without being recorded when
+	// the cap is reached, until older responses expire.
+	maxIdempotentResponses = 10000
+	// maxIdempotentResponseSize caps the size of a recorded response body. Retries of requests whose response was
+	// larger are rejected, rather than handled again.
+	maxIdempotentResponseSize = 1024 * 1024
+)
+
+// idempotentMethods are the methods of requests that are safe to handle several times, and so are never recorded.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// isMutating returns true if handling a request with the method more than once may have a different effect than
+// handling it once.
+func isMutating(method string) bool {
+	return !idempotentMethods[method]
+}
+
+// idempotentResponse is the response to a request carrying an idempotency key. It is recorded while the request is
+// handled, and replayed once done is closed.
+type idempotentResponse struct {
+	key        string
+	done       chan struct{}
+	expiresAt  time.Time
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+	// incomplete is set if the response could not be recorded in full, and so cannot be replayed.
+	incomplete bool
+}
+
+// idempotentResponses makes requests that carry an idempotency key safe to retry, by handling the first attempt
+// of a request and replaying its response to the following attempts.
+type idempotentResponses struct {
+	mu           sync.Mutex
+	ttl          time.Duration
+	maxResponses int
+	responses    map[string]*idempotentResponse
+	// expiries holds the responses in the order they were created, which is the order they expire in.
+	expiries []*idempotentResponse
+}
+
+func newIdempotentResponses(ttl time.Duration) *idempotentResponses {
+	return &idempotentResponses{
+		ttl:          ttl,
+		maxResponses: maxIdempotentResponses,
+		responses:    make(map[string]*idempotentResponse),
+	}
+}
+
+// handler wraps next so that mutating requests with the same idempotency key from the same caller are only
+// handled once.
+func (i *idempotentResponses) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(handlerwrapper.HTTPHeaderIdempotencyKey)
+		if key == "" || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		response, found := i.getOrCreate(fmt.Sprintf("%s %s %s %s", caller(r), r.Method, r.URL.Path, key))
+		if response == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if found {
+			select {
+			case <-response.done:
+				response.replay(w, r)
+			case <-r.Context().Done():
+			}
+			return
+		}
+
+		defer close(response.done)
+		next.ServeHTTP(&responseRecorder{ResponseWriter: w, response: response}, r)
+	})
+}
+
+// caller identifies who sent a request, so that the responses recorded for one caller are never replayed to
+// another. Callers are identified by their authenticated identity if the node authenticates its clients.
+func caller(r *http.Request) string {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		return "subject:" + principal.Subject
+	}
+	return "client:" + r.Header.Get(handlerwrapper.HTTPHeaderClientID)
+}
+
+// getOrCreate returns the response recorded for the key, or starts recording a new one if there is none. It returns
+// nil if there is no room to record a new response.
+func (i *idempotentResponses) getOrCreate(key string) (*idempotentResponse, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	i.expire(now)
+
+	if response, ok := i.responses[key]; ok {
+		return response, true
+	}
+	if len(i.responses) >= i.maxResponses {
+		return nil, false
+	}
+	response := &idempotentResponse{
+		key:        key,
+		done:       make(chan struct{}),
+		expiresAt:  now.Add(i.ttl),
+		statusCode: http.StatusOK,
+		header:     http.Header{},
+	}
+	i.responses[key] = response
+	i.expiries = append(i.expiries, response)
+	return response, false
+}
+
+// expire forgets the responses that expired and are finished. It is called with the lock held.
+func (i *idempotentResponses) expire(now time.Time) {
+	expired := 0
+	for _, response := range i.expiries {
+		if !now.After(response.expiresAt) || !response.finished() {
+			break
+		}
+		delete(i.responses, response.key)
+		expired++
+	}
+	i.expiries = i.expiries[expired:]
+}
+
+func (r *idempotentResponse) finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *idempotentResponse) replay(w http.ResponseWriter, req *http.Request) {
+	if r.incomplete {
+		HTTPError(req.Context(), w, errors.New("the request was already handled, but its response cannot be replayed"),
+			http.StatusConflict)
+		return
+	}
+	for name, values := range r.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(r.statusCode)
+	_, _ = w.Write(r.body.Bytes())
+}
+
+// responseRecorder writes the response through to the client while recording it.
+type responseRecorder struct {
+	http.ResponseWriter
+	response    *idempotentResponse
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.response.statusCode = statusCode
+		r.response.header = r.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	// the response is recorded even if the client is gone, as it may come back to retry the request
+	if !r.response.incomplete {
+		if r.response.body.Len()+len(data) > maxIdempotentResponseSize {
+			r.response.incomplete = true
+			r.response.body = bytes.Buffer{}
+		} else {
+			r.response.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush sends the buffered response to the client, if the underlying writer supports it.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack takes over the connection from the server. What is written to the connection is not recorded, so the
+// response cannot be replayed.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking the connection")
+	}
+	r.response.incomplete = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
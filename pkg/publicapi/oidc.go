@@ -0,0 +1,306 @@
+package publicapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// DefaultOIDCNamespaceClaim is the claim whose value is the namespace of the caller, unless configured otherwise.
+	DefaultOIDCNamespaceClaim = "sub"
+	// DefaultOIDCGroupsClaim is the claim listing the groups of the caller, unless configured otherwise.
+	DefaultOIDCGroupsClaim = "groups"
+
+	// oidcKeysRefreshInterval is the minimum time between two fetches of the keys of the issuer, so that tokens
+	// signed with unknown keys can't be used to flood the issuer with requests.
+	oidcKeysRefreshInterval = time.Minute
+)
+
+// OIDCConfig configures how the bearer tokens issued by an OpenID Connect provider are validated, and how their claims
+// map to the namespace and scopes of the caller.
+type OIDCConfig struct {
+	// IssuerURL is the URL of the provider, which must match the iss claim of tokens. Tokens are not validated if empty.
+	IssuerURL string
+	// Audience must be one of the aud claim of tokens. It is required, as the provider may issue tokens to other
+	// applications that must not be accepted by the node.
+	Audience string
+	// NamespaceClaim is the claim whose value is the namespace of the caller. DefaultOIDCNamespaceClaim if empty.
+	NamespaceClaim string
+	// NamespaceMapping maps values of the namespace claim to namespaces. If set, callers whose claim is not mapped are
+	// rejected, otherwise the value of the claim is the namespace.
+	NamespaceMapping map[string]string
+	// GroupsClaim is the claim listing the groups of the caller. DefaultOIDCGroupsClaim if empty.
+	GroupsClaim string
+	// GroupScopes grants scopes to the members of each group.
+	GroupScopes map[string][]Scope
+	// DefaultScopes are granted to every caller with a valid token. None if empty.
+	DefaultScopes []Scope
+}
+
+// Enabled returns true if bearer tokens are validated against the provider.
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// Validate returns an error if tokens cannot be validated safely with the config.
+func (c OIDCConfig) Validate() error {
+	if c.Enabled() && c.Audience == "" {
+		return errors.New("an audience is required to validate OIDC tokens")
+	}
+	return nil
+}
+
+// OIDCAuthenticator validates bearer tokens signed by an OpenID Connect provider, using the keys the provider
+// publishes, and maps their claims to the namespace and scopes of the caller.
+type OIDCAuthenticator struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+}
+
+func NewOIDCAuthenticator(config OIDCConfig) *OIDCAuthenticator {
+	if config.NamespaceClaim == "" {
+		config.NamespaceClaim = DefaultOIDCNamespaceClaim
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+	return &OIDCAuthenticator{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second}, //nolint:gomnd
+		keys:   make(map[string]crypto.PublicKey),
+	}
+}
+
+// Authenticate implements Authenticator
+func (a *OIDCAuthenticator) Authenticate(ctx context.Context, token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return a.key(ctx, kid)
+	})
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// ParseWithClaims checks the times of the token if it has them, but not who issued it and for whom
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Principal{}, errors.New("token has no expiry")
+	}
+	if !claims.VerifyIssuer(a.config.IssuerURL, true) {
+		return Principal{}, fmt.Errorf("token was not issued by %s", a.config.IssuerURL)
+	}
+	if !claims.VerifyAudience(a.config.Audience, true) {
+		return Principal{}, fmt.Errorf("token was not issued for %s", a.config.Audience)
+	}
+	return a.principal(claims)
+}
+
+// principal maps the claims of a valid token to the identity of the caller.
+func (a *OIDCAuthenticator) principal(claims jwt.MapClaims) (Principal, error) {
+	subject, _ := claims["sub"].(string)
+	namespace, ok := claims[a.config.NamespaceClaim].(string)
+	if !ok || namespace == "" {
+		return Principal{}, fmt.Errorf("token has no %s claim", a.config.NamespaceClaim)
+	}
+	if len(a.config.NamespaceMapping) > 0 {
+		mapped, found := a.config.NamespaceMapping[namespace]
+		if !found {
+			return Principal{}, fmt.Errorf("no namespace is mapped to %s %q", a.config.NamespaceClaim, namespace)
+		}
+		namespace = mapped
+	}
+
+	scopes := append([]Scope(nil), a.config.DefaultScopes...)
+	for _, group := range stringsClaim(claims[a.config.GroupsClaim]) {
+		for _, scope := range a.config.GroupScopes[group] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return Principal{Subject: subject, Namespace: namespace, Scopes: scopes}, nil
+}
+
+// stringsClaim returns the values of a claim that is either a string or a list of strings.
+func stringsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// key returns the public key of the issuer with the given ID, fetching the keys of the issuer again if it is unknown
+// so that keys rotated by the issuer are picked up.
+func (a *OIDCAuthenticator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(a.lastRefresh) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	a.lastRefresh = time.Now()
+	keys, err := a.fetchKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the signing keys of %s: %w", a.config.IssuerURL, err)
+	}
+	a.keys = keys
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchKeys reads the JSON web keys of the issuer, found from its discovery document.
+func (a *OIDCAuthenticator) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := a.getJSON(ctx, a.config.IssuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != a.config.IssuerURL {
+		return nil, fmt.Errorf("discovery document is for issuer %s", discovery.Issuer)
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := a.getJSON(ctx, discovery.JWKSURI, &keySet); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Str("KeyID", jwk.KeyID).Msg("ignoring signing key of the OIDC issuer")
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (a *OIDCAuthenticator) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// jsonWebKey is a public key published by an issuer, as defined by RFC 7517.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeKeyParameter(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeKeyParameter(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeKeyParameter(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeKeyParameter(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+func decodeKeyParameter(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// compile-time interface check
+var _ Authenticator = (*OIDCAuthenticator)(nil)
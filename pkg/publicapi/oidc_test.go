@@ -0,0 +1,233 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyID = "test-key"
+
+// testIssuer serves the discovery document and keys of an OIDC provider, and signs tokens with its key.
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuer := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(res http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(res).Encode(map[string]string{
+			"issuer":   issuer.server.URL,
+			"jwks_uri": issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(res http.ResponseWriter, req *http.Request) {
+		encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+		_ = json.NewEncoder(res).Encode(map[string]interface{}{
+			"keys": []jsonWebKey{{
+				KeyType: "RSA",
+				KeyID:   testKeyID,
+				Use:     "sig",
+				N:       encode(key.N),
+				E:       encode(big.NewInt(int64(key.E))),
+			}},
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(i.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (i *testIssuer) claims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":    i.server.URL,
+		"aud":    "bacalhau",
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"operators"},
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+	}
+	return claims
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	config := OIDCConfig{
+		IssuerURL:     issuer.server.URL,
+		Audience:      "bacalhau",
+		GroupScopes:   map[string][]Scope{"operators": {ScopeAdmin}},
+		DefaultScopes: []Scope{ScopeRead},
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		principal, err := NewOIDCAuthenticator(config).Authenticate(context.Background(), issuer.sign(t, issuer.claims(nil)))
+		require.NoError(t, err)
+		require.Equal(t, Principal{Subject: "alice", Namespace: "alice", Scopes: []Scope{ScopeRead, ScopeAdmin}}, principal)
+	})
+
+	for name, overrides := range map[string]jwt.MapClaims{
+		"wrong issuer":     {"iss": "https://elsewhere.example"},
+		"wrong audience":   {"aud": "someone-else"},
+		"expired":          {"exp": time.Now().Add(-time.Minute).Unix()},
+		"no expiry":        {"exp": nil},
+		"no audience":      {"aud": nil},
+		"no namespace":     {"sub": nil},
+		"not yet valid":    {"nbf": time.Now().Add(time.Hour).Unix()},
+		"unmapped subject": {"sub": "mallory"},
+	} {
+		overrides := overrides
+		t.Run(name, func(t *testing.T) {
+			config := config
+			if name == "unmapped subject" {
+				config.NamespaceMapping = map[string]string{"alice": "research"}
+			}
+			_, err := NewOIDCAuthenticator(config).Authenticate(context.Background(), issuer.sign(t, issuer.claims(overrides)))
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("namespace mapping", func(t *testing.T) {
+		config := config
+		config.NamespaceMapping = map[string]string{"alice": "research"}
+		principal, err := NewOIDCAuthenticator(config).Authenticate(context.Background(), issuer.sign(t, issuer.claims(nil)))
+		require.NoError(t, err)
+		require.Equal(t, "research", principal.Namespace)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims(nil))
+		token.Header["kid"] = testKeyID
+		signed, err := token.SignedString(other)
+		require.NoError(t, err)
+		_, err = NewOIDCAuthenticator(config).Authenticate(context.Background(), signed)
+		require.Error(t, err)
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authenticator := NewOIDCAuthenticator(OIDCConfig{
+		IssuerURL:     issuer.server.URL,
+		Audience:      "bacalhau",
+		GroupScopes:   map[string][]Scope{"operators": {ScopeSubmit}},
+		DefaultScopes: []Scope{ScopeRead},
+	})
+
+	var namespace string
+	handler := authenticate(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		namespace = RequestNamespace(req.Context())
+		res.WriteHeader(http.StatusOK)
+	}), authenticator, ScopeSubmit)
+
+	for _, tc := range []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer not-a-token", status: http.StatusUnauthorized},
+		{
+			name:          "missing scope",
+			authorization: "Bearer " + issuer.sign(t, issuer.claims(jwt.MapClaims{"groups": nil})),
+			status:        http.StatusForbidden,
+		},
+		{
+			name:          "granted scope",
+			authorization: "Bearer " + issuer.sign(t, issuer.claims(nil)),
+			status:        http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace = ""
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			require.Equal(t, tc.status, res.Code)
+			if tc.status == http.StatusOK {
+				require.Equal(t, "alice", namespace)
+			} else {
+				require.Empty(t, namespace)
+			}
+		})
+	}
+}
+
+func TestAuthenticateNamespace(t *testing.T) {
+	issuer := newTestIssuer(t)
+	token := "Bearer " + issuer.sign(t, issuer.claims(nil))
+	serve := func(groupScopes []Scope, namespace string) (int, string) {
+		authenticator := NewOIDCAuthenticator(OIDCConfig{
+			IssuerURL:   issuer.server.URL,
+			Audience:    "bacalhau",
+			GroupScopes: map[string][]Scope{"operators": groupScopes},
+		})
+		var requestNamespace string
+		handler := authenticate(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			requestNamespace = RequestNamespace(req.Context())
+		}), authenticator, ScopeSubmit)
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", token)
+		if namespace != "" {
+			req.Header.Set(handlerwrapper.HTTPHeaderNamespace, namespace)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code, requestNamespace
+	}
+
+	status, namespace := serve([]Scope{ScopeSubmit}, "alice")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "alice", namespace)
+	status, _ = serve([]Scope{ScopeSubmit}, "bob")
+	require.Equal(t, http.StatusForbidden, status, "clients cannot act in other namespaces")
+
+	status, namespace = serve([]Scope{ScopeAdmin}, "")
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, namespace, "admins act in every namespace by default")
+	status, namespace = serve([]Scope{ScopeAdmin}, "bob")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "bob", namespace, "admins can act in the namespace of other clients")
+}
+
+func TestOIDCConfigRequiresAudience(t *testing.T) {
+	require.NoError(t, OIDCConfig{}.Validate())
+	require.Error(t, OIDCConfig{IssuerURL: "https://issuer.example"}.Validate())
+	require.NoError(t, OIDCConfig{IssuerURL: "https://issuer.example", Audience: "bacalhau"}.Validate())
+}
@@ -0,0 +1,47 @@
+package publicapi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AdminRequestTTL is how long before or after it was issued an admin request that can't be replayed is accepted,
+// which allows for that much clock skew between clients and nodes.
+const AdminRequestTTL = 5 * time.Minute
+
+// ReplayGuard rejects signed requests that are replayed, by rejecting requests issued too long ago and remembering
+// the nonces of the requests accepted until they are too old to be accepted again.
+type ReplayGuard struct {
+	ttl  time.Duration
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func NewReplayGuard(ttl time.Duration) *ReplayGuard {
+	return &ReplayGuard{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// Check returns an error if the request with the nonce, issued at issuedAt, is stale or was already accepted, and
+// otherwise remembers its nonce.
+func (g *ReplayGuard) Check(nonce string, issuedAt, now time.Time) error {
+	if nonce == "" || issuedAt.IsZero() {
+		return errors.New("request has no nonce or issue time")
+	}
+	if issuedAt.Before(now.Add(-g.ttl)) || issuedAt.After(now.Add(g.ttl)) {
+		return fmt.Errorf("request was issued at %s, more than %s from now", issuedAt.Format(time.RFC3339), g.ttl)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for seenNonce, expiresAt := range g.seen {
+		if now.After(expiresAt) {
+			delete(g.seen, seenNonce)
+		}
+	}
+	if _, ok := g.seen[nonce]; ok {
+		return errors.New("request was already received")
+	}
+	g.seen[nonce] = issuedAt.Add(g.ttl)
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayGuard(t *testing.T) {
+	guard := NewReplayGuard(time.Minute)
+	now := time.Now()
+
+	require.NoError(t, guard.Check("first", now, now))
+	require.Error(t, guard.Check("first", now, now.Add(time.Second)), "nonces are only accepted once")
+	require.Error(t, guard.Check("stale", now.Add(-2*time.Minute), now), "requests issued too long ago are rejected")
+	require.Error(t, guard.Check("future", now.Add(2*time.Minute), now), "requests issued in the future are rejected")
+	require.Error(t, guard.Check("", now, now))
+	require.Error(t, guard.Check("unset", time.Time{}, now))
+
+	// nonces are forgotten once their requests are too old to be accepted again
+	require.NoError(t, guard.Check("second", now, now))
+	later := now.Add(2 * time.Minute)
+	require.Error(t, guard.Check("second", now, later))
+	require.NoError(t, guard.Check("third", later, later))
+	require.Len(t, guard.seen, 1)
+}
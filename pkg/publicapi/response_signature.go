@@ -0,0 +1,204 @@
+package publicapi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// HTTPHeaderResponseNonce carries a random value chosen by the client, asking the node to sign its response.
+	HTTPHeaderResponseNonce = "X-Bacalhau-Response-Nonce"
+	// HTTPHeaderPublicKey carries the base64-encoded libp2p public key of the node that signed the response.
+	HTTPHeaderPublicKey = "X-Bacalhau-Public-Key"
+	// HTTPHeaderSignature carries the base64-encoded signature of the response by the libp2p private key of the node.
+	HTTPHeaderSignature = "X-Bacalhau-Signature"
+
+	// responseSignaturePrefix is signed along with the response, so that the signature can't be replayed as anything else.
+	responseSignaturePrefix = "bacalhau-response:"
+)
+
+// ErrResponseRejected is returned when the client refuses a response, such as one that was not signed by the
+// node it expects.
+var ErrResponseRejected = errors.New("publicapi: response rejected")
+
+// responseDigest returns what the node signs for a response. It covers the nonce of the client, the request the node
+// received and the response it sent, so that a relay between the client and the node can neither replay a
+// signature nor swap the request or the response.
+func responseDigest(nonce string, req *http.Request, requestBody []byte, statusCode int, responseBody []byte) []byte {
+	requestHash := sha256.Sum256(requestBody)
+	responseHash := sha256.Sum256(responseBody)
+	return []byte(fmt.Sprintf("%s%s\n%s %s\n%x\n%d\n%x", responseSignaturePrefix,
+		nonce, req.Method, req.URL.RequestURI(), requestHash, statusCode, responseHash))
+}
+
+// signingResponseWriter holds the response back until it has been signed.
+type signingResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *signingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *signingResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *signingResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// signResponses signs the responses to the requests that carry a nonce with the libp2p key of the node, so that
+// clients can check they are talking to the node they expect on every request. Requests that upgrade the connection
+// are passed through, as their responses are streamed.
+func signResponses(next http.Handler, privateKey crypto.PrivKey, maxBytesToReadInBody int64) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		nonce := req.Header.Get(HTTPHeaderResponseNonce)
+		if nonce == "" || req.Header.Get("Upgrade") != "" || privateKey == nil {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		// the request body is hashed as the handler reads it, and what it left unread is read afterwards
+		var requestBody bytes.Buffer
+		if req.Body != nil {
+			body := req.Body
+			defer body.Close()
+			req.Body = io.NopCloser(io.TeeReader(body, &requestBody))
+		}
+		writer := &signingResponseWriter{header: res.Header()}
+		next.ServeHTTP(writer, req)
+		if req.Body != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(req.Body, maxBytesToReadInBody+1))
+		}
+		if writer.statusCode == 0 {
+			writer.statusCode = http.StatusOK
+		}
+
+		publicKey, err := crypto.MarshalPublicKey(privateKey.GetPublic())
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		digest := responseDigest(nonce, req, requestBody.Bytes(), writer.statusCode, writer.body.Bytes())
+		signature, err := privateKey.Sign(digest)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set(HTTPHeaderPublicKey, base64.StdEncoding.EncodeToString(publicKey))
+		res.Header().Set(HTTPHeaderSignature, base64.StdEncoding.EncodeToString(signature))
+		res.Header().Set("Content-Length", strconv.Itoa(writer.body.Len()))
+		res.WriteHeader(writer.statusCode)
+		_, _ = res.Write(writer.body.Bytes())
+	})
+}
+
+// verifyResponse checks that the response was signed for the nonce and the request by the key it carries, and
+// returns the peer ID of that key.
+func verifyResponse(nonce string, req *http.Request, requestBody []byte, res *http.Response, responseBody []byte) (peer.ID, error) {
+	if res.Header.Get(HTTPHeaderSignature) == "" {
+		return "", fmt.Errorf("%w: response is not signed", ErrIdentityNotProven)
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Header.Get(HTTPHeaderPublicKey))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+	publicKey, err := crypto.UnmarshalPublicKey(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+	peerID, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrIdentityNotProven, err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(res.Header.Get(HTTPHeaderSignature))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid signature: %s", ErrIdentityNotProven, err)
+	}
+	ok, err := publicKey.Verify(responseDigest(nonce, req, requestBody, res.StatusCode, responseBody), signature)
+	if err != nil || !ok {
+		return "", fmt.Errorf("%w: signature does not match", ErrIdentityNotProven)
+	}
+	return peerID, nil
+}
+
+// ResponseCheck decides whether to accept a response from the node given the peer that signed it, or the error
+// verifying its signature. Returning an error rejects the response.
+type ResponseCheck func(req *http.Request, peerID peer.ID, err error) error
+
+// verifyingTransport asks the node to sign every response and checks the signatures.
+type verifyingTransport struct {
+	next  http.RoundTripper
+	check ResponseCheck
+}
+
+func (t *verifyingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Upgrade") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	nonce := make([]byte, 32) //nolint:gomnd
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the request must not be modified, so the nonce and the body that was read go on a copy
+	signedReq := req.Clone(req.Context())
+	signedReq.Header.Set(HTTPHeaderResponseNonce, base64.StdEncoding.EncodeToString(nonce))
+	if req.Body != nil {
+		signedReq.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	res, err := t.next.RoundTrip(signedReq)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	peerID, err := verifyResponse(signedReq.Header.Get(HTTPHeaderResponseNonce), signedReq, requestBody, res, responseBody)
+	if err = t.check(req, peerID, err); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrResponseRejected, err)
+	}
+	return res, nil
+}
+
+// VerifyResponses has the node sign every response to the client, and passes the peer that signed it, or the error
+// verifying the signature, to the check before the response is handled.
+func (apiClient *APIClient) VerifyResponses(check ResponseCheck) {
+	next := apiClient.Client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	apiClient.Client.Transport = &verifyingTransport{next: next, check: check}
+}
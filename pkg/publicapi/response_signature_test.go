@@ -0,0 +1,66 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedResponses(t *testing.T) {
+	privateKey, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	peerID, err := peer.IDFromPublicKey(publicKey)
+	require.NoError(t, err)
+
+	echo := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		_, _ = res.Write(body)
+	})
+	node := httptest.NewServer(signResponses(echo, privateKey, 1024))
+	defer node.Close()
+
+	// a relay that answers with its own body for the responses of the node
+	relay := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		nodeReq, _ := http.NewRequest(req.Method, node.URL, strings.NewReader("other request"))
+		nodeReq.Header = req.Header
+		nodeRes, err := http.DefaultClient.Do(nodeReq)
+		require.NoError(t, err)
+		defer nodeRes.Body.Close()
+		for header, values := range nodeRes.Header {
+			res.Header()[header] = values
+		}
+		_, _ = io.Copy(res, nodeRes.Body)
+	}))
+	defer relay.Close()
+
+	var signedBy peer.ID
+	var verifyErr error
+	client := &APIClient{Client: &http.Client{}}
+	client.VerifyResponses(func(_ *http.Request, peerID peer.ID, err error) error {
+		signedBy, verifyErr = peerID, err
+		return err
+	})
+
+	res, err := client.Client.Post(node.URL, "text/plain", strings.NewReader("request"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, "request", string(body))
+	require.NoError(t, verifyErr)
+	require.Equal(t, peerID, signedBy)
+
+	// the relay swapped the request, so the signature of the node does not match
+	_, err = client.Client.Post(relay.URL, "text/plain", strings.NewReader("request"))
+	require.ErrorIs(t, err, ErrResponseRejected)
+	require.ErrorIs(t, verifyErr, ErrIdentityNotProven)
+}
@@ -0,0 +1,53 @@
+package publicapi
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryOptions configures how the APIClient retries requests that failed transiently, such as on a connection
+// reset, a timeout or a 502, 503 or 504 response. Every attempt of a mutating request carries the same idempotency
+// key, so that the server handles the request only once however many times it is sent.
+type RetryOptions struct {
+	// MaxAttempts is the number of times a request is sent, including the first attempt. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is how long to wait before the first retry. The wait doubles on every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps how long to wait between two attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryOptions are the retry options of clients created with NewAPIClient.
+var DefaultRetryOptions = RetryOptions{
+	MaxAttempts:    4,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// NoRetries sends every request only once.
+var NoRetries = RetryOptions{MaxAttempts: 1}
+
+// backoff returns how long to wait before sending the given attempt, with jitter so that clients that failed at the
+// same time do not retry at the same time.
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	backoff := o.InitialBackoff
+	for i := 2; i < attempt && backoff < o.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if o.MaxBackoff > 0 && backoff > o.MaxBackoff {
+		backoff = o.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1)) //nolint:gosec // jitter does not need a secure source
+}
+
+// isTransientStatus returns true if a response with the given status may succeed if the request is sent again.
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
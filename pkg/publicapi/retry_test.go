@@ -0,0 +1,172 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/require"
+)
+
+type retryTestResponse struct {
+	Calls int `json:"calls"`
+}
+
+// newRetryTestServer returns a server whose handler counts how many times it handled a request. The first attempts
+// of every request fail with a 502 after the request was handled, as if a proxy lost the response.
+func newRetryTestServer(t *testing.T, failedAttempts int) (*APIClient, *int, *[]string) {
+	calls := 0
+	var keys []string
+	handler := newIdempotentResponses(time.Minute).handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_ = json.NewEncoder(w).Encode(retryTestResponse{Calls: calls})
+	}))
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		keys = append(keys, r.Header.Get(handlerwrapper.HTTPHeaderIdempotencyKey))
+		if attempts <= failedAttempts {
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &APIClient{
+		BaseURI:        system.MustParseURL(server.URL),
+		DefaultHeaders: map[string]string{},
+		Retry:          RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+		Client:         server.Client(),
+	}
+	return client, &calls, &keys
+}
+
+func TestClientRetriesTransientFailuresIdempotently(t *testing.T) {
+	client, calls, keys := newRetryTestServer(t, 2)
+
+	var res retryTestResponse
+	require.NoError(t, client.Post(context.Background(), "submit", map[string]string{"job": "1"}, &res))
+	require.Equal(t, 1, res.Calls)
+	require.Equal(t, 1, *calls)
+	require.Len(t, *keys, 3)
+	require.NotEmpty(t, (*keys)[0])
+	require.Equal(t, (*keys)[0], (*keys)[1])
+	require.Equal(t, (*keys)[0], (*keys)[2])
+
+	// a new request gets a new key, and is handled again
+	require.NoError(t, client.Post(context.Background(), "submit", map[string]string{"job": "2"}, &res))
+	require.Equal(t, 2, res.Calls)
+	require.NotEqual(t, (*keys)[0], (*keys)[3])
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	client, calls, keys := newRetryTestServer(t, 3)
+
+	var res retryTestResponse
+	require.Error(t, client.Post(context.Background(), "submit", map[string]string{"job": "1"}, &res))
+	require.Equal(t, 1, *calls)
+	require.Len(t, *keys, 3)
+
+	client, _, keys = newRetryTestServer(t, 1)
+	client.Retry = NoRetries
+	require.Error(t, client.Post(context.Background(), "submit", map[string]string{"job": "1"}, &res))
+	require.Len(t, *keys, 1)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	options := RetryOptions{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, expected := range map[int]time.Duration{
+		2: 100 * time.Millisecond,
+		3: 200 * time.Millisecond,
+		4: 400 * time.Millisecond,
+		9: time.Second,
+	} {
+		backoff := options.backoff(attempt)
+		require.GreaterOrEqual(t, backoff, expected/2, "attempt %d", attempt)
+		require.LessOrEqual(t, backoff, expected, "attempt %d", attempt)
+	}
+}
+
+func TestClientSendsIdempotencyKeysOnlyWhenMutating(t *testing.T) {
+	client, _, keys := newRetryTestServer(t, 0)
+
+	var res retryTestResponse
+	require.NoError(t, client.Get(context.Background(), "jobs", nil, &res))
+	require.Equal(t, []string{""}, *keys)
+}
+
+func TestIdempotentResponsesAreScopedToTheCaller(t *testing.T) {
+	calls := 0
+	handler := newIdempotentResponses(time.Minute).handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_ = json.NewEncoder(w).Encode(retryTestResponse{Calls: calls})
+	}))
+	send := func(method, clientID string) retryTestResponse {
+		req := httptest.NewRequest(method, "/submit", nil)
+		req.Header.Set(handlerwrapper.HTTPHeaderIdempotencyKey, "key")
+		req.Header.Set(handlerwrapper.HTTPHeaderClientID, clientID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var res retryTestResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		return res
+	}
+
+	require.Equal(t, 1, send(http.MethodPost, "a").Calls)
+	require.Equal(t, 1, send(http.MethodPost, "a").Calls)
+	require.Equal(t, 2, send(http.MethodPost, "b").Calls)
+	// requests that are safe to handle again are never replayed
+	require.Equal(t, 3, send(http.MethodGet, "a").Calls)
+}
+
+func TestIdempotentResponsesAreCapped(t *testing.T) {
+	responses := newIdempotentResponses(time.Millisecond)
+	responses.maxResponses = 1
+
+	first, found := responses.getOrCreate("first")
+	require.NotNil(t, first)
+	require.False(t, found)
+	second, _ := responses.getOrCreate("second")
+	require.Nil(t, second, "no room while the first response is kept")
+
+	close(first.done)
+	time.Sleep(2 * time.Millisecond)
+	second, found = responses.getOrCreate("second")
+	require.NotNil(t, second, "the first response expired")
+	require.False(t, found)
+	require.Len(t, responses.responses, 1)
+}
+
+func TestOversizedResponsesAreNotReplayed(t *testing.T) {
+	handler := newIdempotentResponses(time.Minute).handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, maxIdempotentResponseSize+1))
+	}))
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/submit", nil)
+		req.Header.Set(handlerwrapper.HTTPHeaderIdempotencyKey, "key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, maxIdempotentResponseSize+1, send().Body.Len())
+	require.Equal(t, http.StatusConflict, send().Code)
+}
+
+func TestResponseRecorderKeepsWriterInterfaces(t *testing.T) {
+	var w http.ResponseWriter = &responseRecorder{ResponseWriter: httptest.NewRecorder(), response: &idempotentResponse{}}
+	_, ok := w.(http.Flusher)
+	require.True(t, ok)
+	_, ok = w.(http.Hijacker)
+	require.True(t, ok)
+}
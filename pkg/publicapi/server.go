@@ -31,13 +31,18 @@ var DefaultAPIServerConfig = APIServerConfig{
 	RequestHandlerTimeout:      30 * time.Second,
 	RequestHandlerTimeoutByURI: map[string]time.Duration{},
 	MaxBytesToReadInBody:       10 * datasize.MB,
+	MaxBytesToReadInBodyByURI:  map[string]datasize.ByteSize{},
 }
 
 type HandlerConfig struct {
 	Path                  string
 	Handler               http.Handler
 	RequestHandlerTimeout time.Duration
+	MaxBytesToReadInBody  datasize.ByteSize
 	Raw                   bool // don't wrap the handler with middleware
+	// Scope that callers must be granted to call the handler when the server authenticates its clients.
+	// The handler is public if empty.
+	Scope Scope
 }
 
 type APIServerConfig struct {
@@ -52,8 +57,12 @@ type APIServerConfig struct {
 	RequestHandlerTimeout      time.Duration
 	RequestHandlerTimeoutByURI map[string]time.Duration
 
-	// MaxBytesToReadInBody is used by safeHandlerFuncWrapper as the max size of body
-	MaxBytesToReadInBody datasize.ByteSize
+	// MaxBytesToReadInBody is the max size of request bodies, unless a different limit is set for the endpoint
+	MaxBytesToReadInBody      datasize.ByteSize
+	MaxBytesToReadInBodyByURI map[string]datasize.ByteSize
+
+	// OIDC configures the validation of the bearer tokens of clients. Clients are not authenticated if not enabled.
+	OIDC OIDCConfig
 }
 
 type APIServerParams struct {
@@ -61,29 +70,42 @@ type APIServerParams struct {
 	Port             uint16
 	Host             host.Host
 	NodeInfoProvider model.NodeInfoProvider
-	Config           APIServerConfig
+	// PeerScoreProvider returns the scores of the peers of the node, which are not served if nil.
+	PeerScoreProvider model.PeerScoreProvider
+	Config            APIServerConfig
 }
 
 // APIServer configures a node's public REST API.
 type APIServer struct {
-	Address          string
-	Port             uint16
-	host             host.Host
-	nodeInfoProvider model.NodeInfoProvider
-	config           APIServerConfig
-	handlers         map[string]http.Handler
-	handlersMu       sync.Mutex
-	started          bool
+	Address           string
+	Port              uint16
+	host              host.Host
+	nodeInfoProvider  model.NodeInfoProvider
+	peerScoreProvider model.PeerScoreProvider
+	config            APIServerConfig
+	handlers          map[string]http.Handler
+	handlersMu        sync.Mutex
+	idempotency       *idempotentResponses
+	authenticator     Authenticator
+	started           bool
 }
 
 func NewAPIServer(params APIServerParams) (*APIServer, error) {
 	server := &APIServer{
-		Address:          params.Address,
-		Port:             params.Port,
-		host:             params.Host,
-		nodeInfoProvider: params.NodeInfoProvider,
-		config:           params.Config,
-		handlers:         make(map[string]http.Handler),
+		Address:           params.Address,
+		Port:              params.Port,
+		host:              params.Host,
+		nodeInfoProvider:  params.NodeInfoProvider,
+		peerScoreProvider: params.PeerScoreProvider,
+		config:            params.Config,
+		handlers:          make(map[string]http.Handler),
+		idempotency:       newIdempotentResponses(idempotencyKeyTTL),
+	}
+	if params.Config.OIDC.Enabled() {
+		if err := params.Config.OIDC.Validate(); err != nil {
+			return nil, err
+		}
+		server.authenticator = NewOIDCAuthenticator(params.Config.OIDC)
 	}
 
 	server.handlersMu.EnableTracerWithOpts(sync.Opts{
@@ -97,12 +119,14 @@ func NewAPIServer(params APIServerParams) (*APIServer, error) {
 	// Register default handlers
 	handlerConfigs := []HandlerConfig{
 		{Path: "/id", Handler: http.HandlerFunc(server.id)},
-		{Path: "/peers", Handler: http.HandlerFunc(server.peers)},
-		{Path: "/node_info", Handler: http.HandlerFunc(server.nodeInfo)},
+		{Path: "/identity", Handler: http.HandlerFunc(server.identity)},
+		{Path: "/peers", Handler: http.HandlerFunc(server.peers), Scope: ScopeRead},
+		{Path: "/node_info", Handler: http.HandlerFunc(server.nodeInfo), Scope: ScopeRead},
 		{Path: "/version", Handler: http.HandlerFunc(server.version)},
 		{Path: "/healthz", Handler: http.HandlerFunc(server.healthz)},
-		{Path: "/logz", Handler: http.HandlerFunc(server.logz)},
-		{Path: "/varz", Handler: http.HandlerFunc(server.varz)},
+		{Path: "/logz", Handler: http.HandlerFunc(server.logz), Scope: ScopeAdmin},
+		{Path: "/varz", Handler: http.HandlerFunc(server.varz), Scope: ScopeAdmin},
+		{Path: "/peer_scores", Handler: http.HandlerFunc(server.peerScores), Scope: ScopeAdmin},
 		{Path: "/livez", Handler: http.HandlerFunc(server.livez)},
 		{Path: "/readyz", Handler: http.HandlerFunc(server.readyz)},
 		{Path: "/swagger/", Handler: httpSwagger.WrapHandler, Raw: true},
@@ -124,7 +148,7 @@ func NewAPIServer(params APIServerParams) (*APIServer, error) {
 
 // GetURI returns the HTTP URI that the server is listening on.
 func (apiServer *APIServer) GetURI() *url.URL {
-	interpolated := fmt.Sprintf("http://%s:%d", apiServer.Address, apiServer.Port)
+	interpolated := "http://" + hostPort(apiServer.Address, apiServer.Port)
 	url, err := url.Parse(interpolated)
 	if err != nil {
 		panic(fmt.Errorf("callback url must parse: %s", interpolated))
@@ -132,6 +156,11 @@ func (apiServer *APIServer) GetURI() *url.URL {
 	return url
 }
 
+// Authenticator returns what validates the bearer tokens of clients, or nil if the server does not authenticate them.
+func (apiServer *APIServer) Authenticator() Authenticator {
+	return apiServer.authenticator
+}
+
 //	@title			Bacalhau API
 //	@description	This page is the reference of the Bacalhau REST API. Project docs are available at https://docs.bacalhau.org/. Find more information about Bacalhau at https://github.com/bacalhau-project/bacalhau.
 //	@contact.name	Bacalhau Team
@@ -170,7 +199,7 @@ func (apiServer *APIServer) ListenAndServe(ctx context.Context, cm *system.Clean
 		},
 	}
 
-	addr := fmt.Sprintf("%s:%d", apiServer.Address, apiServer.Port)
+	addr := hostPort(apiServer.Address, apiServer.Port)
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return err
@@ -226,8 +255,12 @@ func (apiServer *APIServer) registerHandler(apiPrefix string, config HandlerConf
 
 	handler := config.Handler
 	if !config.Raw {
+		// idempotency handler. Inside the timeout handler, so that requests that time out are still recorded
+		// for their retries
+		handler = apiServer.idempotency.handler(handler)
+
 		// otel handler
-		handler = otelhttp.NewHandler(config.Handler, uri,
+		handler = otelhttp.NewHandler(handler, uri,
 			otelhttp.WithPublicEndpoint(),
 			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
 				return fmt.Sprintf("%s %s", r.Method, operation)
@@ -253,10 +286,31 @@ func (apiServer *APIServer) registerHandler(apiPrefix string, config HandlerConf
 			handlerTimeout = DefaultAPIServerConfig.RequestHandlerTimeout
 		}
 		handler = http.TimeoutHandler(handler, handlerTimeout, "Server Timeout!")
-		handler = http.MaxBytesHandler(handler, int64(apiServer.config.MaxBytesToReadInBody))
+
+		// body size handler. Find the limit for this endpoint, or use the fallback value
+		maxBytesToReadInBody := config.MaxBytesToReadInBody
+		if maxBytesToReadInBody == 0 {
+			maxBytesToReadInBody = apiServer.config.MaxBytesToReadInBodyByURI[uri]
+		}
+		if maxBytesToReadInBody == 0 {
+			maxBytesToReadInBody = apiServer.config.MaxBytesToReadInBody
+		}
+		if maxBytesToReadInBody == 0 {
+			maxBytesToReadInBody = DefaultAPIServerConfig.MaxBytesToReadInBody
+		}
+		handler = http.MaxBytesHandler(handler, int64(maxBytesToReadInBody))
+
+		// authentication handler. Outside the idempotency handler, so that recorded responses are not replayed to
+		// callers that are not allowed to see them
+		handler = authenticate(handler, apiServer.authenticator, config.Scope)
+
+		// signing handler. Outside the authentication handler, so that the errors it returns are signed too
+		handler = signResponses(handler, apiServer.host.Peerstore().PrivKey(apiServer.host.ID()), int64(maxBytesToReadInBody))
 
 		// logging handler. Should be last in the chain.
 		handler = handlerwrapper.NewHTTPHandlerWrapper(apiServer.host.ID().String(), handler, handlerwrapper.NewJSONLogHandler())
+	} else {
+		handler = authenticate(handler, apiServer.authenticator, config.Scope)
 	}
 	apiServer.handlers[uri] = handler
 	return nil
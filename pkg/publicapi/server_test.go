@@ -4,16 +4,22 @@ package publicapi
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/types"
+	"github.com/bacalhau-project/bacalhau/pkg/util/multiaddresses"
+	"github.com/c2h5oh/datasize"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/phayes/freeport"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -81,6 +87,14 @@ func (s *ServerSuite) TestVarz() {
 
 }
 
+func (s *ServerSuite) TestPeerScores() {
+	rawScores := s.testEndpoint(s.T(), "/peer_scores", "[")
+
+	var scores []model.PeerScore
+	require.NoError(s.T(), model.JSONUnmarshalWithMax(rawScores, &scores))
+	require.Empty(s.T(), scores)
+}
+
 func (s *ServerSuite) TestTimeout() {
 	config := APIServerConfig{
 		RequestHandlerTimeoutByURI: map[string]time.Duration{
@@ -147,6 +161,31 @@ func (s *ServerSuite) TestMaxBodyReader() {
 	}
 }
 
+func (s *ServerSuite) TestMaxBodyReaderByURI() {
+	config := APIServerConfig{
+		MaxBytesToReadInBody:      100,
+		MaxBytesToReadInBodyByURI: map[string]datasize.ByteSize{V1APIPrefix + "/version": 500},
+	}
+	s.client = setupNodeForTestWithConfig(s.T(), s.cleanupManager, config)
+
+	var res VersionResponse
+	err := s.client.Post(context.Background(), "version", VersionRequest{ClientID: strings.Repeat("a", 400)}, &res)
+	s.NoError(err)
+
+	err = s.client.Post(context.Background(), "version", VersionRequest{ClientID: strings.Repeat("a", 500)}, &res)
+	require.Error(s.T(), err)
+	require.Contains(s.T(), err.Error(), "http: request body too large")
+}
+
+func (s *ServerSuite) TestIdentity() {
+	var id string
+	require.NoError(s.T(), model.JSONUnmarshalWithMax(s.testEndpoint(s.T(), "/id", ""), &id))
+
+	peerID, err := s.client.Identity(context.Background())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), id, peerID.String())
+}
+
 func (s *ServerSuite) testEndpoint(t *testing.T, endpoint string, contentToCheck string) []byte {
 	res, err := http.Get(s.client.BaseURI.JoinPath(endpoint).String())
 	require.NoError(t, err, "Could not get %s endpoint.", endpoint)
@@ -158,3 +197,32 @@ func (s *ServerSuite) testEndpoint(t *testing.T, endpoint string, contentToCheck
 	require.Contains(t, string(body), contentToCheck, "%s body does not contain '%s'.", endpoint, contentToCheck)
 	return body
 }
+
+func TestServerListensOnIPv6(t *testing.T) {
+	system.InitConfigForTesting(t)
+	ctx := context.Background()
+	cm := system.NewCleanupManager()
+	t.Cleanup(func() { cm.Cleanup(ctx) })
+
+	libp2pPort, err := freeport.GetFreePort()
+	require.NoError(t, err)
+	libp2pHost, err := libp2p.NewHostWithNetworkStack(libp2pPort, multiaddresses.NetworkStackIPv6)
+	require.NoError(t, err)
+	for _, addr := range libp2pHost.Addrs() {
+		_, err := addr.ValueForProtocol(multiaddr.P_IP6)
+		require.NoError(t, err, "libp2p should only listen on IPv6, but listens on %s", addr)
+	}
+
+	apiServer, err := NewAPIServer(APIServerParams{
+		Host:    libp2pHost,
+		Address: "::1",
+		Port:    0,
+	})
+	require.NoError(t, err)
+	require.NoError(t, apiServer.ListenAndServe(ctx, cm))
+	require.Equal(t, fmt.Sprintf("[::1]:%d", apiServer.Port), apiServer.GetURI().Host)
+
+	client := NewAPIClient(apiServer.Address, apiServer.Port)
+	require.Equal(t, fmt.Sprintf("[::1]:%d", apiServer.Port), client.BaseURI.Host)
+	require.NoError(t, waitForHealthy(ctx, client))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net/http"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
@@ -65,6 +66,10 @@ func UnmarshalSigned[PayloadType ContainsClientID](ctx context.Context, body io.
 	var payload PayloadType
 
 	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return payload, errors.Wrapf(err, "request is larger than the %d bytes accepted by this endpoint", maxBytesErr.Limit)
+		}
 		return payload, errors.Wrap(err, "error unmarshalling envelope")
 	}
 
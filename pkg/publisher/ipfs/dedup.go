@@ -0,0 +1,103 @@
+package ipfs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxPublishedDigests is how many of the latest published results are remembered to deduplicate publishes.
+const maxPublishedDigests = 1024
+
+// publishedResult is where a result with the same contents was published.
+type publishedResult struct {
+	cid     string
+	backend string
+}
+
+// publishedDigests remembers the digests of the contents of the latest results published, so that a result that is
+// identical to one already published, such as the identical results of the shards of validation jobs, is published
+// as the same CID without being added to the backend again.
+type publishedDigests struct {
+	mu      sync.Mutex
+	results map[string]publishedResult
+	// digests are the keys of results, oldest first
+	digests []string
+}
+
+func newPublishedDigests() *publishedDigests {
+	return &publishedDigests{results: make(map[string]publishedResult)}
+}
+
+func (d *publishedDigests) get(digest string) (publishedResult, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result, ok := d.results[digest]
+	return result, ok
+}
+
+func (d *publishedDigests) add(digest string, result publishedResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.results[digest]; !ok {
+		if len(d.digests) >= maxPublishedDigests {
+			delete(d.results, d.digests[0])
+			d.digests = d.digests[1:]
+		}
+		d.digests = append(d.digests, digest)
+	}
+	d.results[digest] = result
+}
+
+// resultDigest returns a digest of the names, types and contents of the files under the result path, which is the
+// same for results that IPFS would add as the same CID.
+func resultDigest(resultPath string) (string, error) {
+	hash := sha256.New()
+	writeString := func(s string) {
+		_ = binary.Write(hash, binary.BigEndian, uint64(len(s)))
+		_, _ = io.WriteString(hash, s)
+	}
+	err := filepath.WalkDir(resultPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(resultPath, path)
+		if err != nil {
+			return err
+		}
+		writeString(filepath.ToSlash(rel))
+		writeString(d.Type().String())
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			writeString(target)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			_ = binary.Write(hash, binary.BigEndian, info.Size())
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			if _, err = io.Copy(hash, file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,74 @@
+//go:build unit || !integration
+
+package ipfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	icorepath "github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/stretchr/testify/require"
+)
+
+func writeResult(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	}
+	return dir
+}
+
+func TestResultDigest(t *testing.T) {
+	digest := func(files map[string]string) string {
+		d, err := resultDigest(writeResult(t, files))
+		require.NoError(t, err)
+		return d
+	}
+	result := map[string]string{"stdout": "hello", "outputs/a": "a"}
+	require.Equal(t, digest(result), digest(result))
+	require.NotEqual(t, digest(result), digest(map[string]string{"stdout": "hello", "outputs/b": "a"}))
+	require.NotEqual(t, digest(result), digest(map[string]string{"stdout": "hello", "outputs/a": "b"}))
+	require.NotEqual(t, digest(map[string]string{"ab": "c"}), digest(map[string]string{"a": "bc"}))
+}
+
+func TestIdenticalResultsArePublishedOnce(t *testing.T) {
+	ctx := context.Background()
+	cm := system.NewCleanupManager()
+	t.Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	node, err := ipfs.NewLocalNode(ctx, cm, []string{})
+	require.NoError(t, err)
+	client := node.Client()
+
+	publisher, err := NewIPFSPublisherWithBackends(ctx, cm, ipfs.Backends{ipfs.NewDefaultBackend(client)}, time.Hour, "")
+	require.NoError(t, err)
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	result := map[string]string{"stdout": "same"}
+	first, err := publisher.PublishResult(ctx, "first", *job, writeResult(t, result))
+	require.NoError(t, err)
+	second, err := publisher.PublishResult(ctx, "second", *job, writeResult(t, result))
+	require.NoError(t, err)
+	require.Equal(t, first.CID, second.CID)
+	require.Len(t, publisher.retention.results, 2, "the duplicate keeps the result pinned until it expires too")
+
+	// once the results are unpinned, identical results are added again
+	publisher.retention.unpinExpired(ctx, second.ExpiresAt.Add(time.Second))
+	third, err := publisher.PublishResult(ctx, "third", *job, writeResult(t, result))
+	require.NoError(t, err)
+	require.Equal(t, first.CID, third.CID)
+	_, pinned, err := client.API.Pin().IsPinned(ctx, icorepath.New(third.CID))
+	require.NoError(t, err)
+	require.True(t, pinned)
+}
@@ -3,32 +3,64 @@ package ipfs
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/rs/zerolog/log"
 )
 
 type IPFSPublisher struct {
-	IPFSClient ipfs.Client
+	backends  ipfs.Backends
+	retention *resultRetention
+	published *publishedDigests
 }
 
 func NewIPFSPublisher(
 	ctx context.Context,
-	_ *system.CleanupManager,
+	cm *system.CleanupManager,
 	cl ipfs.Client,
 ) (*IPFSPublisher, error) {
-	log.Ctx(ctx).Debug().Msgf("IPFS publisher initialized for node: %s", cl.APIAddress())
-	return &IPFSPublisher{
-		IPFSClient: cl,
-	}, nil
+	return NewIPFSPublisherWithBackends(ctx, cm, ipfs.Backends{ipfs.NewDefaultBackend(cl)}, 0, "")
+}
+
+// NewIPFSPublisherWithBackends returns a publisher that publishes results to the publish backends, failing over
+// between them in order. If retention is positive, published results are unpinned once they are older than it, and
+// when they expire is recorded in retentionRecordPath, if set, so that they are unpinned after a restart too.
+func NewIPFSPublisherWithBackends(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	backends ipfs.Backends,
+	retention time.Duration,
+	retentionRecordPath string,
+) (*IPFSPublisher, error) {
+	backends = backends.Publishers()
+	for _, backend := range backends {
+		log.Ctx(ctx).Debug().Msgf("IPFS publisher initialized for backend %s at node: %s", backend.Name, backend.Client.APIAddress())
+	}
+	publisher := &IPFSPublisher{
+		backends:  backends,
+		published: newPublishedDigests(),
+	}
+	if retention > 0 {
+		publisher.retention = newResultRetention(retention, retentionRecordPath, backends)
+		cm.RegisterCallback(func() error {
+			publisher.retention.stop()
+			return nil
+		})
+	}
+	return publisher, nil
 }
 
 func (publisher *IPFSPublisher) IsInstalled(ctx context.Context) (bool, error) {
-	_, err := publisher.IPFSClient.ID(ctx)
+	_, err := publisher.backends.Try(func(backend ipfs.Backend) error {
+		_, err := backend.Client.ID(ctx)
+		return err
+	})
 	return err == nil, err
 }
 
@@ -47,11 +79,76 @@ func (publisher *IPFSPublisher) PublishResult(
 	j model.Job,
 	resultPath string,
 ) (model.StorageSpec, error) {
-	cid, err := publisher.IPFSClient.Put(ctx, resultPath)
+	digest, digestErr := resultDigest(resultPath)
+	if digestErr != nil {
+		log.Ctx(ctx).Debug().Err(digestErr).Msgf("not deduplicating results of execution %s", executionID)
+	} else if spec, ok := publisher.publishDuplicate(ctx, executionID, j, digest); ok {
+		return spec, nil
+	}
+
+	var cid string
+	backend, err := publisher.backends.Try(func(backend ipfs.Backend) (err error) {
+		// don't fail over to the next backend once the publish is canceled
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		cid, err = backend.Client.Put(ctx, resultPath)
+		return err
+	})
 	if err != nil {
 		return model.StorageSpec{}, err
 	}
-	return job.GetIPFSPublishedStorageSpec(executionID, j, model.StorageSourceIPFS, cid), nil
+	if ctx.Err() != nil {
+		// the result was added as the publish was canceled, so it is unpinned rather than left behind
+		cleanupCtx, cancel := util.NewCleanupContext(ctx)
+		defer cancel()
+		if unpinErr := backend.Client.Unpin(cleanupCtx, cid); unpinErr != nil {
+			log.Ctx(ctx).Warn().Err(unpinErr).Msgf("failed to unpin result %s of canceled publish", cid)
+		}
+		return model.StorageSpec{}, ctx.Err()
+	}
+	log.Ctx(ctx).Info().Msgf("Published results of execution %s to IPFS backend %s", executionID, backend.Name)
+	spec := job.GetIPFSPublishedStorageSpec(executionID, j, model.StorageSourceIPFS, cid)
+	if publisher.retention != nil {
+		expiresAt := publisher.retention.track(cid, backend)
+		spec.ExpiresAt = &expiresAt
+	}
+	if digestErr == nil {
+		publisher.published.add(digest, publishedResult{cid: cid, backend: backend.Name})
+	}
+	return spec, nil
+}
+
+// publishDuplicate publishes the results as the CID of identical results published before, if they are still pinned
+// by their backend, without adding them again.
+func (publisher *IPFSPublisher) publishDuplicate(
+	ctx context.Context,
+	executionID string,
+	j model.Job,
+	digest string,
+) (model.StorageSpec, bool) {
+	published, ok := publisher.published.get(digest)
+	if !ok {
+		return model.StorageSpec{}, false
+	}
+	for _, backend := range publisher.backends {
+		if backend.Name != published.backend {
+			continue
+		}
+		spec := job.GetIPFSPublishedStorageSpec(executionID, j, model.StorageSourceIPFS, published.cid)
+		if publisher.retention != nil {
+			// results that have been unpinned are added again
+			expiresAt, retained := publisher.retention.extend(published.cid, backend)
+			if !retained {
+				return model.StorageSpec{}, false
+			}
+			spec.ExpiresAt = &expiresAt
+		}
+		log.Ctx(ctx).Info().Msgf("Published results of execution %s to IPFS backend %s as identical results %s",
+			executionID, backend.Name, published.cid)
+		return spec, true
+	}
+	return model.StorageSpec{}, false
 }
 
 // Compile-time check that Verifier implements the correct interface:
@@ -0,0 +1,212 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRetentionCheckInterval is the longest time an expired result stays pinned before it is unpinned.
+const maxRetentionCheckInterval = time.Minute
+
+// retainedResult is a published result that is unpinned from its backend once it expires.
+type retainedResult struct {
+	cid       string
+	backend   ipfs.Backend
+	expiresAt time.Time
+}
+
+// retentionRecord is how a retained result is saved, with the name of its backend.
+type retentionRecord struct {
+	CID       string    `json:"CID"`
+	Backend   string    `json:"Backend"`
+	ExpiresAt time.Time `json:"ExpiresAt"`
+}
+
+// resultRetention records an expiry for each published result, and unpins expired results in the background
+// so that the IPFS repos of the backends do not grow without bound. The records are saved to a file, so that results
+// published before a restart are still unpinned. A CID is only unpinned from a backend once every result published
+// with it has expired, as results with the same content share their pin.
+type resultRetention struct {
+	period     time.Duration
+	recordPath string
+	mu         sync.Mutex
+	results    []retainedResult
+
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+// newResultRetention returns a retention of the period, which saves its records to recordPath if it is set, and
+// continues from the records saved there for the backends.
+func newResultRetention(period time.Duration, recordPath string, backends ipfs.Backends) *resultRetention {
+	r := &resultRetention{
+		period:      period,
+		recordPath:  recordPath,
+		stopChannel: make(chan struct{}),
+	}
+	r.loadRecords(backends)
+	go r.unpinBackgroundTask()
+	return r
+}
+
+// loadRecords reads the records saved to the record path. Records of backends that are no longer configured are
+// dropped, as their results can't be unpinned anymore.
+func (r *resultRetention) loadRecords(backends ipfs.Backends) {
+	if r.recordPath == "" {
+		return
+	}
+	data, err := os.ReadFile(r.recordPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn().Err(err).Str("Path", r.recordPath).Msg("failed to read IPFS result retention records")
+		}
+		return
+	}
+	var records []retentionRecord
+	if err = json.Unmarshal(data, &records); err != nil {
+		log.Warn().Err(err).Str("Path", r.recordPath).Msg("ignoring invalid IPFS result retention records")
+		return
+	}
+	byName := make(map[string]ipfs.Backend, len(backends))
+	for _, backend := range backends {
+		byName[backend.Name] = backend
+	}
+	for _, record := range records {
+		backend, ok := byName[record.Backend]
+		if !ok {
+			log.Warn().Msgf("dropping retention of result %s, IPFS backend %s is not configured", record.CID, record.Backend)
+			continue
+		}
+		r.results = append(r.results, retainedResult{cid: record.CID, backend: backend, expiresAt: record.ExpiresAt})
+	}
+}
+
+// saveRecords writes the records to the record path, if set. It is called with the lock held.
+func (r *resultRetention) saveRecords() {
+	if r.recordPath == "" {
+		return
+	}
+	records := make([]retentionRecord, 0, len(r.results))
+	for _, result := range r.results {
+		records = append(records, retentionRecord{CID: result.cid, Backend: result.backend.Name, ExpiresAt: result.expiresAt})
+	}
+	data, err := json.Marshal(records)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.recordPath), os.ModePerm)
+	}
+	if err == nil {
+		temp := r.recordPath + ".tmp"
+		if err = os.WriteFile(temp, data, 0600); err == nil { //nolint:gomnd
+			err = os.Rename(temp, r.recordPath)
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("Path", r.recordPath).Msg("failed to save IPFS result retention records")
+	}
+}
+
+// track records that the CID was published to the backend, and returns when it will expire.
+func (r *resultRetention) track(cid string, backend ipfs.Backend) time.Time {
+	expiresAt := time.Now().Add(r.period)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, retainedResult{cid: cid, backend: backend, expiresAt: expiresAt})
+	r.saveRecords()
+	return expiresAt
+}
+
+// extend records that the CID was published to the backend again, if a result with the CID is still retained by the
+// backend, and returns when it will expire.
+func (r *resultRetention) extend(cid string, backend ipfs.Backend) (time.Time, bool) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, result := range r.results {
+		if result.cid == cid && result.backend.Name == backend.Name && now.Before(result.expiresAt) {
+			expiresAt := now.Add(r.period)
+			r.results = append(r.results, retainedResult{cid: cid, backend: backend, expiresAt: expiresAt})
+			r.saveRecords()
+			return expiresAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unpinExpired unpins all results that have expired by now, unless a result that has not expired yet shares their
+// CID on the same backend. Results that fail to unpin are retried later.
+func (r *resultRetention) unpinExpired(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	var expired []retainedResult
+	remaining := r.results[:0]
+	for _, result := range r.results {
+		if now.Before(result.expiresAt) {
+			remaining = append(remaining, result)
+		} else {
+			expired = append(expired, result)
+		}
+	}
+	r.results = remaining
+	// the results still retained hold the pins of their CIDs, and a CID expired by several results is unpinned once
+	pinned := make(map[[2]string]bool, len(remaining)+len(expired))
+	for _, result := range remaining {
+		pinned[[2]string{result.backend.Name, result.cid}] = true
+	}
+	var toUnpin []retainedResult
+	for _, result := range expired {
+		key := [2]string{result.backend.Name, result.cid}
+		if !pinned[key] {
+			pinned[key] = true
+			toUnpin = append(toUnpin, result)
+		}
+	}
+	r.mu.Unlock()
+
+	var failed []retainedResult
+	for _, result := range toUnpin {
+		if err := result.backend.Client.Unpin(ctx, result.cid); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to unpin expired result %s from IPFS backend %s", result.cid, result.backend.Name)
+			failed = append(failed, result)
+			continue
+		}
+		log.Ctx(ctx).Debug().Msgf("unpinned expired result %s from IPFS backend %s", result.cid, result.backend.Name)
+	}
+
+	r.mu.Lock()
+	r.results = append(r.results, failed...)
+	if len(expired) > 0 {
+		r.saveRecords()
+	}
+	r.mu.Unlock()
+}
+
+func (r *resultRetention) unpinBackgroundTask() {
+	ctx := context.Background()
+	interval := r.period
+	if interval > maxRetentionCheckInterval {
+		interval = maxRetentionCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case now := <-ticker.C:
+			r.unpinExpired(ctx, now)
+		case <-r.stopChannel:
+			log.Ctx(ctx).Debug().Msg("stopped result retention task")
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func (r *resultRetention) stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChannel)
+	})
+}
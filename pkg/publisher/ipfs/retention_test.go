@@ -0,0 +1,123 @@
+//go:build unit || !integration
+
+package ipfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	icorepath "github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishedResultsAreUnpinnedOnceExpired(t *testing.T) {
+	ctx := context.Background()
+	cm := system.NewCleanupManager()
+	t.Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	node, err := ipfs.NewLocalNode(ctx, cm, []string{})
+	require.NoError(t, err)
+	client := node.Client()
+
+	backends := ipfs.Backends{ipfs.NewDefaultBackend(client)}
+	recordPath := filepath.Join(t.TempDir(), "retention.json")
+	publisher, err := NewIPFSPublisherWithBackends(ctx, cm, backends, time.Hour, recordPath)
+	require.NoError(t, err)
+
+	resultPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(resultPath, "stdout"), []byte("hello"), 0600))
+
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	before := time.Now()
+	spec, err := publisher.PublishResult(ctx, "execution", *job, resultPath)
+	require.NoError(t, err)
+	require.NotNil(t, spec.ExpiresAt)
+	require.WithinRange(t, *spec.ExpiresAt, before.Add(time.Hour), time.Now().Add(time.Hour))
+
+	isPinned := func() bool {
+		_, pinned, pinErr := client.API.Pin().IsPinned(ctx, icorepath.New(spec.CID))
+		require.NoError(t, pinErr)
+		return pinned
+	}
+	require.True(t, isPinned())
+
+	publisher.retention.unpinExpired(ctx, spec.ExpiresAt.Add(-time.Second))
+	require.True(t, isPinned(), "results should stay pinned until they expire")
+
+	// results are still unpinned once expired after a restart
+	restarted, err := NewIPFSPublisherWithBackends(ctx, cm, backends, time.Hour, recordPath)
+	require.NoError(t, err)
+	require.Len(t, restarted.retention.results, 1)
+
+	restarted.retention.unpinExpired(ctx, *spec.ExpiresAt)
+	require.False(t, isPinned())
+	require.Empty(t, restarted.retention.results)
+}
+
+func TestSharedResultsStayPinnedUntilAllExpire(t *testing.T) {
+	ctx := context.Background()
+	cm := system.NewCleanupManager()
+	t.Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	node, err := ipfs.NewLocalNode(ctx, cm, []string{})
+	require.NoError(t, err)
+	client := node.Client()
+
+	publisher, err := NewIPFSPublisherWithBackends(ctx, cm, ipfs.Backends{ipfs.NewDefaultBackend(client)}, time.Hour, "")
+	require.NoError(t, err)
+
+	resultPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(resultPath, "stdout"), []byte("hello"), 0600))
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	first, err := publisher.PublishResult(ctx, "first", *job, resultPath)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	second, err := publisher.PublishResult(ctx, "second", *job, resultPath)
+	require.NoError(t, err)
+	require.Equal(t, first.CID, second.CID)
+
+	isPinned := func() bool {
+		_, pinned, pinErr := client.API.Pin().IsPinned(ctx, icorepath.New(first.CID))
+		require.NoError(t, pinErr)
+		return pinned
+	}
+	publisher.retention.unpinExpired(ctx, *first.ExpiresAt)
+	require.True(t, isPinned(), "the CID is pinned until every result with it expires")
+	publisher.retention.unpinExpired(ctx, *second.ExpiresAt)
+	require.False(t, isPinned())
+}
+
+func TestPublishedResultsAreRetainedWithoutRetention(t *testing.T) {
+	ctx := context.Background()
+	cm := system.NewCleanupManager()
+	t.Cleanup(func() {
+		cm.Cleanup(context.Background())
+	})
+
+	node, err := ipfs.NewLocalNode(ctx, cm, []string{})
+	require.NoError(t, err)
+
+	publisher, err := NewIPFSPublisher(ctx, cm, node.Client())
+	require.NoError(t, err)
+
+	job, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	spec, err := publisher.PublishResult(ctx, "execution", *job, t.TempDir())
+	require.NoError(t, err)
+	require.Nil(t, spec.ExpiresAt)
+}
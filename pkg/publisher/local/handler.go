@@ -0,0 +1,105 @@
+package local
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/rs/zerolog/log"
+)
+
+const archiveExtension = ".tar.gz"
+
+// NewHandler returns the handler that serves the results published to the directory. A request for
+// <job ID>/<execution ID>.tar.gz is answered with the results of the execution as a compressed archive, which is
+// what clients download, and any other path below a job is served as is, with an index of the folders. The root of
+// the directory is not listed, so that results can only be found by the ID of their job, and the results of a job are
+// only served to the requests that authorize accepts for its metadata.
+func NewHandler(directory string, authorize func(r *http.Request, job model.Metadata) bool) http.Handler {
+	files := http.FileServer(http.Dir(directory))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath := strings.Trim(r.URL.Path, "/")
+		if urlPath == "" || strings.HasPrefix(urlPath, ".") {
+			http.NotFound(w, r)
+			return
+		}
+
+		jobID, name, found := strings.Cut(urlPath, "/")
+		metadata, err := ReadJobMetadata(directory, jobID)
+		if !validName(jobID) || err != nil || !authorize(r, metadata) {
+			// results the client may not see are not found, so that clients cannot tell which jobs exist
+			http.NotFound(w, r)
+			return
+		}
+		executionID := strings.TrimSuffix(name, archiveExtension)
+		if !found || executionID == name || !validName(jobID) || !validName(executionID) {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		source := filepath.Join(directory, jobID, executionID)
+		if info, err := os.Stat(source); err != nil || !info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		if err := writeArchive(w, source); err != nil {
+			// the status has already been sent, so the client only sees a truncated archive
+			log.Ctx(r.Context()).Error().Err(err).Msgf("Serving results of %s", urlPath)
+		}
+	})
+}
+
+// writeArchive writes the files of the source directory as a compressed tar archive, with paths relative to it.
+func writeArchive(w io.Writer, source string) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(source, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer closer.CloseWithLogOnError("file", f)
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,227 @@
+// Package local publishes results to a directory on the compute node, from where the node's API server serves them
+// over HTTP. It suits clusters run by a single operator that don't want IPFS in the path of their results.
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// jobsFolder holds the metadata of the jobs whose results are published, which says who they are served to. It
+	// is hidden from the handler, as are all folders starting with a dot.
+	jobsFolder = ".jobs"
+	// maxRetentionCheckInterval is the longest time expired results stay in the directory before they are removed.
+	maxRetentionCheckInterval = time.Minute
+)
+
+// PublisherConfig configures where results are written and the URL they are served from.
+type PublisherConfig struct {
+	// Directory holds the published results, in a folder per job with a subfolder per execution.
+	// The publisher is not installed if empty.
+	Directory string
+	// URL is the base URL the results in Directory are served from, such as the results endpoint of the node's
+	// compute API.
+	URL string
+	// Retention is how long the results of a job are kept after its last result was published. Zero keeps them
+	// forever.
+	Retention time.Duration
+}
+
+// Compile-time check that Publisher implements the correct interface:
+var _ publisher.Publisher = (*Publisher)(nil)
+
+type Publisher struct {
+	directory string
+	url       string
+	retention time.Duration
+
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewPublisher returns a publisher to the directory of the config, which removes expired results in the background
+// until the cleanup manager runs if the config has a retention.
+func NewPublisher(cm *system.CleanupManager, config PublisherConfig) *Publisher {
+	p := &Publisher{
+		directory:   config.Directory,
+		url:         strings.TrimSuffix(config.URL, "/"),
+		retention:   config.Retention,
+		stopChannel: make(chan struct{}),
+	}
+	if p.directory != "" && p.retention > 0 {
+		go p.removeExpiredBackgroundTask()
+		cm.RegisterCallback(func() error {
+			p.stop()
+			return nil
+		})
+	}
+	return p
+}
+
+// IsInstalled returns true if the node was configured with a directory to publish results to.
+func (p *Publisher) IsInstalled(context.Context) (bool, error) {
+	return p.directory != "" && p.url != "", nil
+}
+
+func (p *Publisher) ValidateJob(context.Context, model.Job) error {
+	return nil
+}
+
+// PublishResult copies the results of the execution into the folder of the job, replacing those of any earlier
+// attempt of the execution, and returns the URL the node serves them from as a compressed archive.
+func (p *Publisher) PublishResult(
+	ctx context.Context,
+	executionID string,
+	j model.Job,
+	resultPath string,
+) (model.StorageSpec, error) {
+	jobID := j.Metadata.ID
+	if !validName(jobID) || !validName(executionID) {
+		return model.StorageSpec{}, fmt.Errorf("cannot publish results of execution %q of job %q locally", executionID, jobID)
+	}
+
+	if err := writeJobMetadata(p.directory, j); err != nil {
+		return model.StorageSpec{}, fmt.Errorf("recording who results of job %s are served to: %w", jobID, err)
+	}
+	target := filepath.Join(p.directory, jobID, executionID)
+	if err := os.RemoveAll(target); err != nil {
+		return model.StorageSpec{}, err
+	}
+	if err := copyDir(ctx, resultPath, target); err != nil {
+		return model.StorageSpec{}, fmt.Errorf("copying results to %s: %w", target, err)
+	}
+
+	return model.StorageSpec{
+		StorageSource: model.StorageSourceURLDownload,
+		Name:          model.LocalResultNamePrefix + executionID,
+		URL:           fmt.Sprintf("%s/%s/%s%s", p.url, jobID, executionID, archiveExtension),
+	}, nil
+}
+
+// copyDir copies the regular files of the source directory and its subdirectories into the target directory.
+func copyDir(ctx context.Context, source, target string) error {
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(filepath.Join(target, relPath), model.DownloadFolderPerm)
+		case entry.Type().IsRegular():
+			return util.CopyFile(path, filepath.Join(target, relPath), model.DownloadFilePerm)
+		default:
+			log.Ctx(ctx).Debug().Msgf("Skipping %s of result, it is not a regular file", path)
+			return nil
+		}
+	})
+}
+
+// validName returns true if the name can be used as a folder of the results directory without escaping it.
+func validName(name string) bool {
+	return model.ValidFileName(name)
+}
+
+// writeJobMetadata records who the results of the job are served to.
+func writeJobMetadata(directory string, j model.Job) error {
+	metadata := model.Metadata{
+		ID:        j.Metadata.ID,
+		ClientID:  j.Metadata.ClientID,
+		Namespace: j.Metadata.Namespace,
+		Watchers:  j.Metadata.Watchers,
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Join(directory, jobsFolder), model.DownloadFolderPerm); err != nil {
+		return err
+	}
+	return os.WriteFile(jobMetadataPath(directory, j.Metadata.ID), data, model.DownloadFilePerm)
+}
+
+// ReadJobMetadata returns who the results of the job published to the directory are served to.
+func ReadJobMetadata(directory string, jobID string) (model.Metadata, error) {
+	var metadata model.Metadata
+	data, err := os.ReadFile(jobMetadataPath(directory, jobID))
+	if err != nil {
+		return metadata, err
+	}
+	err = json.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+func jobMetadataPath(directory string, jobID string) string {
+	return filepath.Join(directory, jobsFolder, jobID+".json")
+}
+
+// removeExpired removes the results of the jobs that had no result published for longer than the retention, which
+// is when the folder of the job was last modified.
+func (p *Publisher) removeExpired(ctx context.Context, now time.Time) {
+	entries, err := os.ReadDir(p.directory)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to list published results in %s", p.directory)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < p.retention {
+			continue
+		}
+		jobID := entry.Name()
+		if err = os.RemoveAll(filepath.Join(p.directory, jobID)); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to remove expired results of job %s", jobID)
+			continue
+		}
+		if err = os.Remove(jobMetadataPath(p.directory, jobID)); err != nil && !os.IsNotExist(err) {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to remove metadata of job %s", jobID)
+		}
+		log.Ctx(ctx).Debug().Msgf("removed expired results of job %s", jobID)
+	}
+}
+
+func (p *Publisher) removeExpiredBackgroundTask() {
+	ctx := context.Background()
+	interval := p.retention
+	if interval > maxRetentionCheckInterval {
+		interval = maxRetentionCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			p.removeExpired(ctx, now)
+		case <-p.stopChannel:
+			return
+		}
+	}
+}
+
+func (p *Publisher) stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChannel)
+	})
+}
@@ -0,0 +1,109 @@
+//go:build unit || !integration
+
+package local
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	httpdownloader "github.com/bacalhau-project/bacalhau/pkg/downloader/http"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishAndServe(t *testing.T) {
+	ctx := context.Background()
+	resultPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(resultPath, model.DownloadFilenameStdout), []byte("hello"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(resultPath, "outputs", "nested"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(resultPath, "outputs", "nested", "data.csv"), []byte("a,b"), 0600))
+
+	directory := t.TempDir()
+	authorize := func(r *http.Request, job model.Metadata) bool {
+		return job.IsVisibleTo(r.Header.Get(handlerwrapper.HTTPHeaderClientID))
+	}
+	server := httptest.NewServer(http.StripPrefix("/results", NewHandler(directory, authorize)))
+	defer server.Close()
+
+	p := NewPublisher(system.NewCleanupManager(), PublisherConfig{Directory: directory, URL: server.URL + "/results/"})
+	installed, err := p.IsInstalled(ctx)
+	require.NoError(t, err)
+	require.True(t, installed)
+
+	j := model.Job{Metadata: model.Metadata{ID: "92d5d4ee-3765-4f78-8353-623f5f26df08", ClientID: "client"}}
+	spec, err := p.PublishResult(ctx, "e-1", j, resultPath)
+	require.NoError(t, err)
+	require.Equal(t, model.StorageSourceURLDownload, spec.StorageSource)
+	require.Equal(t, server.URL+"/results/92d5d4ee-3765-4f78-8353-623f5f26df08/e-1.tar.gz", spec.URL)
+	require.FileExists(t, filepath.Join(directory, j.Metadata.ID, "e-1", "outputs", "nested", "data.csv"))
+
+	target := filepath.Join(t.TempDir(), "results")
+	downloader := httpdownloader.NewHTTPDownloader(&model.DownloaderSettings{
+		Timeout:     time.Minute,
+		NodeHeaders: map[string]string{handlerwrapper.HTTPHeaderClientID: "client"},
+	})
+	require.NoError(t, downloader.FetchResult(ctx, model.DownloadItem{Name: spec.Name, URL: spec.URL, Target: target}))
+	stdout, err := os.ReadFile(filepath.Join(target, model.DownloadFilenameStdout))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(stdout))
+	data, err := os.ReadFile(filepath.Join(target, "outputs", "nested", "data.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "a,b", string(data))
+
+	get := func(path string, clientID string) int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(handlerwrapper.HTTPHeaderClientID, clientID)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res.StatusCode
+	}
+	for path, status := range map[string]int{
+		"/results/":                                    http.StatusNotFound,
+		"/results/" + j.Metadata.ID + "/":              http.StatusOK,
+		"/results/" + j.Metadata.ID + "/e-2.tar.gz":    http.StatusNotFound,
+		"/results/.jobs/" + j.Metadata.ID + ".json":    http.StatusNotFound,
+		"/results/../" + j.Metadata.ID + "/e-1.tar.gz": http.StatusNotFound,
+	} {
+		require.Equal(t, status, get(path, "client"), path)
+	}
+	require.Equal(t, http.StatusNotFound, get("/results/"+j.Metadata.ID+"/e-1.tar.gz", "other"),
+		"results are only served to the clients that can see the job")
+	require.Equal(t, http.StatusNotFound, get("/results/"+j.Metadata.ID+"/", "other"))
+}
+
+func TestNotInstalled(t *testing.T) {
+	installed, err := NewPublisher(system.NewCleanupManager(), PublisherConfig{}).IsInstalled(context.Background())
+	require.NoError(t, err)
+	require.False(t, installed)
+}
+
+func TestRemoveExpired(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	p := NewPublisher(system.NewCleanupManager(), PublisherConfig{Directory: directory, URL: "http://node/results", Retention: time.Hour})
+	defer p.stop()
+
+	resultPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(resultPath, model.DownloadFilenameStdout), []byte("hello"), 0600))
+	for _, jobID := range []string{"old", "recent"} {
+		_, err := p.PublishResult(ctx, "e-1", model.Job{Metadata: model.Metadata{ID: jobID}}, resultPath)
+		require.NoError(t, err)
+	}
+	twoHoursAgo := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(directory, "old"), twoHoursAgo, twoHoursAgo))
+
+	p.removeExpired(ctx, time.Now())
+	require.NoDirExists(t, filepath.Join(directory, "old"))
+	require.NoFileExists(t, jobMetadataPath(directory, "old"))
+	require.DirExists(t, filepath.Join(directory, "recent"))
+	require.FileExists(t, jobMetadataPath(directory, "recent"))
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/estuary"
 	filecoinlotus "github.com/bacalhau-project/bacalhau/pkg/publisher/filecoin_lotus"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/local"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/tracing"
@@ -24,13 +25,16 @@ import (
 func NewIPFSPublishers(
 	ctx context.Context,
 	cm *system.CleanupManager,
-	cl ipfsClient.Client,
+	backends ipfsClient.Backends,
 	estuaryAPIKey string,
 	lotusConfig *filecoinlotus.PublisherConfig,
+	resultRetention time.Duration,
+	resultRetentionRecordPath string,
+	localConfig local.PublisherConfig,
 ) (publisher.PublisherProvider, error) {
 	defaultPriorityPublisherTimeout := time.Second * 2
 	noopPublisher := noop.NewNoopPublisher()
-	ipfsPublisher, err := ipfs.NewIPFSPublisher(ctx, cm, cl)
+	ipfsPublisher, err := ipfs.NewIPFSPublisherWithBackends(ctx, cm, backends, resultRetention, resultRetentionRecordPath)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +70,7 @@ func NewIPFSPublishers(
 		model.PublisherIpfs:     tracing.Wrap(ipfsPublisher),
 		model.PublisherS3:       tracing.Wrap(s3Publisher),
 		model.PublisherEstuary:  tracing.Wrap(estuaryPublisher),
+		model.PublisherLocal:    tracing.Wrap(local.NewPublisher(cm, localConfig)),
 		model.PublisherFilecoin: combo.NewPiggybackedPublisher(tracing.Wrap(ipfsPublisher), tracing.Wrap(lotus)),
 	}), nil
 }
@@ -28,14 +28,17 @@ type BufferingPubSubParams struct {
 	DelegatePubSub PubSub[BufferingEnvelope]
 	MaxBufferSize  int64
 	MaxBufferAge   time.Duration
+	// MaxBatchSize is the maximum number of messages in a single flush. Zero means no limit.
+	MaxBatchSize int
 }
 
 // BufferingPubSub is a PubSub implementation that buffers messages in memory and flushes them to the delegate PubSub
-// when the buffer is full or the buffer age is reached
+// when the buffer is full, the batch size is reached or the buffer age is reached
 type BufferingPubSub[T any] struct {
 	delegatePubSub PubSub[BufferingEnvelope]
 	maxBufferSize  int64
 	maxBufferAge   time.Duration
+	maxBatchSize   int
 
 	subscriber     Subscriber[T]
 	subscriberOnce realsync.Once
@@ -54,6 +57,7 @@ func NewBufferingPubSub[T any](params BufferingPubSubParams) *BufferingPubSub[T]
 		delegatePubSub:     params.DelegatePubSub,
 		maxBufferSize:      params.MaxBufferSize,
 		maxBufferAge:       params.MaxBufferAge,
+		maxBatchSize:       params.MaxBatchSize,
 		antiStarvationStop: make(chan struct{}),
 	}
 
@@ -82,7 +86,9 @@ func (p *BufferingPubSub[T]) Publish(ctx context.Context, message T) error {
 	p.currentBuffer.Offsets = append(p.currentBuffer.Offsets, p.currentBuffer.Size())
 	p.currentBuffer.Payloads = append(p.currentBuffer.Payloads, payload...)
 
-	if p.currentBuffer.Size() >= p.maxBufferSize || time.Since(p.oldestMessageTime) > p.maxBufferAge {
+	if p.currentBuffer.Size() >= p.maxBufferSize ||
+		(p.maxBatchSize > 0 && len(p.currentBuffer.Offsets) >= p.maxBatchSize) ||
+		time.Since(p.oldestMessageTime) > p.maxBufferAge {
 		go p.flushBuffer(ctx, p.currentBuffer, p.oldestMessageTime)
 		p.currentBuffer = BufferingEnvelope{} // reset the buffer
 	}
@@ -16,11 +16,13 @@ type BufferingPubSubSuite struct {
 	subscriber    *InMemorySubscriber[string]
 	maxBufferSize int64
 	maxBufferAge  time.Duration
+	maxBatchSize  int
 }
 
 func (s *BufferingPubSubSuite) SetupTest() {
 	s.maxBufferSize = 10
 	s.maxBufferAge = 1 * time.Minute
+	s.maxBatchSize = 0
 	s.setupBuffer()
 }
 
@@ -29,6 +31,7 @@ func (s *BufferingPubSubSuite) setupBuffer() {
 		DelegatePubSub: NewInMemoryPubSub[BufferingEnvelope](),
 		MaxBufferAge:   s.maxBufferAge,
 		MaxBufferSize:  s.maxBufferSize,
+		MaxBatchSize:   s.maxBatchSize,
 	})
 	s.subscriber = NewInMemorySubscriber[string]()
 	s.NoError(s.pusSub.Subscribe(context.Background(), s.subscriber))
@@ -83,6 +86,26 @@ func (s *BufferingPubSubSuite) TestBufferingPubSub_Reset() {
 	s.Equal(toWrite2, s.subscriber.Events())
 }
 
+func (s *BufferingPubSubSuite) TestBufferingPubSub_MaxBatchSize() {
+	ctx := context.Background()
+	s.maxBufferSize = 1024
+	s.maxBatchSize = 2
+	s.setupBuffer()
+
+	s.NoError(s.pusSub.Publish(ctx, "a"))
+	s.Empty(s.subscriber.Events())
+	s.True(s.pusSub.currentBuffer.Size() > 0)
+
+	// the second message fills the batch, which is flushed without waiting for the buffer to fill up or age
+	s.NoError(s.pusSub.Publish(ctx, "b"))
+	var events []string
+	s.Eventually(func() bool {
+		events = append(events, s.subscriber.Events()...)
+		return len(events) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Equal([]string{"a", "b"}, events)
+}
+
 func (s *BufferingPubSubSuite) TestBufferingPubSub_MaxBufferAge() {
 	ctx := context.Background()
 	s.maxBufferAge = 500 * time.Millisecond
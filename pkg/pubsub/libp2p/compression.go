@@ -0,0 +1,47 @@
+package libp2p
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMagic starts every zstd frame, and no JSON document. Each message is decoded according to whether it starts
+// with it, so that nodes understand each other's messages whatever their compression threshold.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	// payloads are not decompressed beyond the size they are allowed to be unmarshalled from
+	zstdDecoder, _ = zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(0),
+		zstd.WithDecoderMaxMemory(uint64(model.MaxSerializedStringInput)),
+	)
+)
+
+// compressPayload compresses payloads of at least threshold bytes, unless compression would not make them smaller.
+// Payloads are never compressed if threshold is not positive. It returns whether the payload was compressed.
+func compressPayload(payload []byte, threshold int) ([]byte, bool) {
+	if threshold <= 0 || len(payload) < threshold {
+		return payload, false
+	}
+	compressed := zstdEncoder.EncodeAll(payload, make([]byte, 0, len(payload)/2)) //nolint:gomnd
+	if len(compressed) >= len(payload) {
+		return payload, false
+	}
+	return compressed, true
+}
+
+// decompressPayload returns the payload of a message, and whether it was compressed.
+func decompressPayload(data []byte) ([]byte, bool, error) {
+	if !bytes.HasPrefix(data, zstdMagic) {
+		return data, false, nil
+	}
+	payload, err := zstdDecoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decompress payload: %w", err)
+	}
+	return payload, true, nil
+}
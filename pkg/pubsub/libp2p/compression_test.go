@@ -0,0 +1,57 @@
+//go:build unit || !integration
+
+package libp2p
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressPayload(t *testing.T) {
+	payload, err := model.JSONMarshalWithMax(strings.Repeat("shard", 1000))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		threshold  int
+		compressed bool
+	}{
+		{name: "disabled", threshold: 0, compressed: false},
+		{name: "below threshold", threshold: len(payload) + 1, compressed: false},
+		{name: "at threshold", threshold: len(payload), compressed: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, compressed := compressPayload(payload, tc.threshold)
+			require.Equal(t, tc.compressed, compressed)
+			if compressed {
+				require.Less(t, len(data), len(payload))
+			} else {
+				require.Equal(t, payload, data)
+			}
+
+			decompressed, wasCompressed, err := decompressPayload(data)
+			require.NoError(t, err)
+			require.Equal(t, tc.compressed, wasCompressed)
+			require.Equal(t, payload, decompressed)
+		})
+	}
+}
+
+func TestCompressPayloadKeepsIncompressiblePayloads(t *testing.T) {
+	payload := []byte(`"a"`)
+	data, compressed := compressPayload(payload, 1)
+	require.False(t, compressed)
+	require.Equal(t, payload, data)
+}
+
+func TestDecompressPayloadRejectsCorruptPayloads(t *testing.T) {
+	data, compressed := compressPayload(bytes.Repeat([]byte("x"), 1000), 1)
+	require.True(t, compressed)
+
+	_, _, err := decompressPayload(data[:len(data)/2])
+	require.Error(t, err)
+}
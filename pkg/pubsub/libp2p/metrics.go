@@ -0,0 +1,64 @@
+package libp2p
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Metrics for monitoring gossiped messages:
+var (
+	meter           = global.MeterProvider().Meter("pubsub")
+	messagesSent, _ = meter.Int64Counter(
+		"pubsub_messages_sent",
+		instrument.WithDescription("Number of messages published to libp2p pubsub topics"),
+	)
+
+	bytesSent, _ = meter.Int64Counter(
+		"pubsub_bytes_sent",
+		instrument.WithDescription("Size in bytes of the messages published to libp2p pubsub topics, after compression"),
+	)
+
+	payloadBytesSent, _ = meter.Int64Counter(
+		"pubsub_payload_bytes_sent",
+		instrument.WithDescription("Size in bytes of the messages published to libp2p pubsub topics, before compression"),
+	)
+
+	messagesReceived, _ = meter.Int64Counter(
+		"pubsub_messages_received",
+		instrument.WithDescription("Number of messages received from libp2p pubsub topics"),
+	)
+
+	bytesReceived, _ = meter.Int64Counter(
+		"pubsub_bytes_received",
+		instrument.WithDescription("Size in bytes of the messages received from libp2p pubsub topics, before decompression"),
+	)
+
+	payloadBytesReceived, _ = meter.Int64Counter(
+		"pubsub_payload_bytes_received",
+		instrument.WithDescription("Size in bytes of the messages received from libp2p pubsub topics, after decompression"),
+	)
+)
+
+func messageAttributes(topic string, messageType string, compressed bool) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("topic", topic),
+		attribute.String("type", messageType),
+		attribute.Bool("compressed", compressed),
+	}
+}
+
+// messagesRejected counts the messages the validator of a topic rejected, by reason. Rejected messages lower the score
+// of the peer that delivered them.
+var messagesRejected, _ = meter.Int64Counter(
+	"pubsub_messages_rejected",
+	instrument.WithDescription("Number of messages received from libp2p pubsub topics that were rejected"),
+)
+
+func rejectionAttributes(topic string, messageType string, reason string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("topic", topic),
+		attribute.String("type", messageType),
+		attribute.String("reason", reason),
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	realsync "sync"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -12,6 +13,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,12 +22,25 @@ type PubSubParams struct {
 	TopicName   string
 	PubSub      *libp2p_pubsub.PubSub
 	IgnoreLocal bool
+	// CompressionThreshold is the size in bytes from which messages are compressed with zstd before being published.
+	// Messages are never compressed if zero, though compressed messages from other nodes are still understood.
+	CompressionThreshold int
+	// MaxPeerMessageRate is how many messages per second each peer can publish to the topic, in bursts of up to
+	// MaxPeerMessageBurst messages. Messages over the rate are rejected. Peers are not rate limited if zero.
+	MaxPeerMessageRate  float64
+	MaxPeerMessageBurst int
 }
 type PubSub[T any] struct {
 	hostID      string
 	topicName   string
 	pubSub      *libp2p_pubsub.PubSub
 	ignoreLocal bool
+	// compressionThreshold is the size in bytes from which published messages are compressed
+	compressionThreshold int
+	// messageType names T in metrics
+	messageType string
+	// rateLimiter limits the messages of each peer, nil if they are not limited
+	rateLimiter *peerRateLimiter
 
 	topic        *libp2p_pubsub.Topic
 	subscription *libp2p_pubsub.Subscription
@@ -36,20 +51,68 @@ type PubSub[T any] struct {
 }
 
 func NewPubSub[T any](params PubSubParams) (*PubSub[T], error) {
-	topic, err := params.PubSub.Join(params.TopicName)
-	if err != nil {
-		return nil, err
-	}
 	newPubSub := &PubSub[T]{
 		hostID:      params.Host.ID().String(),
 		pubSub:      params.PubSub,
-		topic:       topic,
 		topicName:   params.TopicName,
 		ignoreLocal: params.IgnoreLocal,
+
+		compressionThreshold: params.CompressionThreshold,
+		messageType:          reflect.TypeOf((*T)(nil)).Elem().String(),
+	}
+	if params.MaxPeerMessageRate > 0 {
+		newPubSub.rateLimiter = newPeerRateLimiter(params.MaxPeerMessageRate, params.MaxPeerMessageBurst)
+	}
+
+	err := params.PubSub.RegisterTopicValidator(params.TopicName, newPubSub.validate)
+	if err != nil {
+		return nil, err
+	}
+	newPubSub.topic, err = params.PubSub.Join(params.TopicName)
+	if err != nil {
+		_ = params.PubSub.UnregisterTopicValidator(params.TopicName)
+		return nil, err
 	}
 	return newPubSub, nil
 }
 
+// validatedMessage is the payload of a message decoded by the validator, to be handled once the message is delivered.
+type validatedMessage[T any] struct {
+	payload    T
+	compressed bool
+	size       int
+}
+
+// validate rejects the messages that can't be decoded, and the messages published by a peer above its rate limit when
+// they come from that peer. Rejecting a message lowers the score of the peer that delivered it, so rate limited
+// messages relayed by other peers are only ignored. Unsigned messages are rejected by gossipsub before validation.
+func (p *PubSub[T]) validate(ctx context.Context, from peer.ID, msg *libp2p_pubsub.Message) libp2p_pubsub.ValidationResult {
+	origin := msg.GetFrom()
+	if p.rateLimiter != nil && origin.String() != p.hostID && !p.rateLimiter.allow(origin, time.Now()) {
+		if from != origin {
+			return libp2p_pubsub.ValidationIgnore
+		}
+		return p.reject(ctx, from, "rate_limited", nil)
+	}
+
+	data, compressed, err := decompressPayload(msg.Data)
+	if err != nil {
+		return p.reject(ctx, from, "malformed", err)
+	}
+	var payload T
+	if err = model.JSONUnmarshalWithMax(data, &payload); err != nil {
+		return p.reject(ctx, from, "malformed", err)
+	}
+	msg.ValidatorData = validatedMessage[T]{payload: payload, compressed: compressed, size: len(data)}
+	return libp2p_pubsub.ValidationAccept
+}
+
+func (p *PubSub[T]) reject(ctx context.Context, from peer.ID, reason string, err error) libp2p_pubsub.ValidationResult {
+	log.Ctx(ctx).Debug().Err(err).Msgf("rejecting %s message from peer %s on topic %s", reason, from, p.topicName)
+	messagesRejected.Add(ctx, 1, rejectionAttributes(p.topicName, p.messageType, reason)...)
+	return libp2p_pubsub.ValidationReject
+}
+
 func (p *PubSub[T]) Publish(ctx context.Context, message T) error {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/pubsub/libp2p.Publish.Publish")
 	defer span.End()
@@ -59,8 +122,19 @@ func (p *PubSub[T]) Publish(ctx context.Context, message T) error {
 		return err
 	}
 
+	data, compressed := compressPayload(payload, p.compressionThreshold)
+
 	log.Ctx(ctx).Trace().Msgf("Sending message %+v", message)
-	return p.topic.Publish(ctx, payload)
+	err = p.topic.Publish(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	attributes := messageAttributes(p.topicName, p.messageType, compressed)
+	messagesSent.Add(ctx, 1, attributes...)
+	bytesSent.Add(ctx, int64(len(data)), attributes...)
+	payloadBytesSent.Add(ctx, int64(len(payload)), attributes...)
+	return nil
 }
 
 func (p *PubSub[T]) Subscribe(_ context.Context, subscriber pubsub.Subscriber[T]) (err error) {
@@ -110,14 +184,19 @@ func (p *PubSub[T]) listenForEvents() {
 func (p *PubSub[T]) readMessage(ctx context.Context, msg *libp2p_pubsub.Message) {
 	// TODO: we would enforce the claims to SourceNodeID here
 	// i.e. msg.ReceivedFrom() should match msg.Data.JobEvent.SourceNodeID
-	var payload T
-	err := model.JSONUnmarshalWithMax(msg.Data, &payload)
-	if err != nil {
-		log.Ctx(ctx).Error().Msgf("error unmarshalling libp2p payload: %v", err)
+	validated, ok := msg.ValidatorData.(validatedMessage[T])
+	if !ok {
+		log.Ctx(ctx).Error().Msgf("libp2p message on topic %s was not validated", p.topicName)
 		return
 	}
+	payload := validated.payload
 
-	err = p.subscriber.Handle(ctx, payload)
+	attributes := messageAttributes(p.topicName, p.messageType, validated.compressed)
+	messagesReceived.Add(ctx, 1, attributes...)
+	bytesReceived.Add(ctx, int64(len(msg.Data)), attributes...)
+	payloadBytesReceived.Add(ctx, int64(validated.size), attributes...)
+
+	err := p.subscriber.Handle(ctx, payload)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("error in handle message of type: %s", reflect.TypeOf(payload))
 	}
@@ -131,6 +210,9 @@ func (p *PubSub[T]) Close(ctx context.Context) (err error) {
 		if p.topic != nil {
 			err = p.topic.Close()
 		}
+		if unregisterErr := p.pubSub.UnregisterTopicValidator(p.topicName); err == nil {
+			err = unregisterErr
+		}
 	})
 	if err != nil {
 		return err
@@ -4,6 +4,7 @@ package libp2p
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -95,6 +96,15 @@ func (s *PubSubSuite) TestPubSub_IgnoreLocal() {
 	s.Empty(s.subscriber2.Events())
 }
 
+func (s *PubSubSuite) TestPubSub_Compressed() {
+	s.node1.compressionThreshold = 1
+	defer func() { s.node1.compressionThreshold = 0 }()
+
+	msg := strings.Repeat("TestPubSub_Compressed", 100)
+	s.NoError(s.node1.Publish(context.Background(), msg))
+	s.waitForMessage(msg, 10*time.Second, true, true)
+}
+
 func (s *PubSubSuite) waitForMessage(msg string, duration time.Duration, checkSubscriber1, checkSubscriber2 bool) (bool, bool) {
 	waitUntil := time.Now().Add(duration)
 	checkSubscriber := func(subscriber *pubsub.InMemorySubscriber[string]) bool {
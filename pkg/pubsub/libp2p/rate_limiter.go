@@ -0,0 +1,71 @@
+package libp2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// peerRateLimiter limits how many messages each peer can publish with a token bucket per peer.
+type peerRateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // capacity of the buckets
+
+	mu        sync.Mutex
+	buckets   map[peer.ID]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newPeerRateLimiter(rate float64, burst int) *peerRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &peerRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[peer.ID]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of the peer, and returns false if it is empty.
+func (l *peerRateLimiter) allow(peerID peer.ID, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+
+	bucket, ok := l.buckets[peerID]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[peerID] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune forgets the buckets that have refilled since they were last used, as they are no different from new ones.
+func (l *peerRateLimiter) prune(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastPrune) < refill {
+		return
+	}
+	for peerID, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, peerID)
+		}
+	}
+	l.lastPrune = now
+}
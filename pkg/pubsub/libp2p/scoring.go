@@ -0,0 +1,150 @@
+package libp2p
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// invalidMessageWeight is the weight of the square of the count of invalid messages a peer delivered on a topic,
+	// so that peers are graylisted after about ten invalid messages in a row.
+	invalidMessageWeight = -10
+	// behaviourPenaltyWeight is the weight of the square of the gossip protocol violations of a peer beyond
+	// behaviourPenaltyThreshold, such as asking for messages it was not told about or re-grafting too early.
+	behaviourPenaltyWeight    = -10
+	behaviourPenaltyThreshold = 6
+	// penaltyDecay is how long it takes for the penalties of a peer to be forgotten, so that graylisting is temporary.
+	penaltyDecay = 10 * time.Minute
+	// scoreRetention is how long the score of a peer is kept after it disconnects, so that it can't escape its
+	// penalties by reconnecting.
+	scoreRetention = penaltyDecay
+
+	gossipThreshold   = -100
+	publishThreshold  = -500
+	graylistThreshold = -1000
+
+	// DefaultPeerScoreInspectInterval is how often the scores of peers are refreshed for GetPeerScores by default.
+	DefaultPeerScoreInspectInterval = 10 * time.Second
+)
+
+type PeerScoringParams struct {
+	// Topics are the topics the messages of peers are scored on.
+	Topics []string
+	// InspectInterval is how often the scores returned by GetPeerScores are refreshed.
+	// DefaultPeerScoreInspectInterval if zero.
+	InspectInterval time.Duration
+}
+
+// PeerScoring scores the peers of a gossipsub router on the bacalhau topics. Peers are penalized for each message they
+// deliver that is rejected, which includes unsigned messages, messages with a bad signature and the messages rejected
+// by the validator of PubSub, and for violations of the gossip protocol. Peers with a low enough score stop being
+// gossiped with, and are then graylisted until their penalties decay. Connections are not closed, so that peers can
+// recover.
+type PeerScoring struct {
+	topics          []string
+	inspectInterval time.Duration
+
+	mu     sync.RWMutex
+	scores map[peer.ID]*libp2p_pubsub.PeerScoreSnapshot
+}
+
+func NewPeerScoring(params PeerScoringParams) *PeerScoring {
+	if params.InspectInterval == 0 {
+		params.InspectInterval = DefaultPeerScoreInspectInterval
+	}
+	return &PeerScoring{
+		topics:          params.Topics,
+		inspectInterval: params.InspectInterval,
+		scores:          make(map[peer.ID]*libp2p_pubsub.PeerScoreSnapshot),
+	}
+}
+
+// Option enables the scoring of peers on a gossipsub router.
+func (s *PeerScoring) Option() libp2p_pubsub.Option {
+	return func(ps *libp2p_pubsub.PubSub) error {
+		params, thresholds := s.params()
+		if err := libp2p_pubsub.WithPeerScore(params, thresholds)(ps); err != nil {
+			return err
+		}
+		return libp2p_pubsub.WithPeerScoreInspect(s.inspect, s.inspectInterval)(ps)
+	}
+}
+
+func (s *PeerScoring) params() (*libp2p_pubsub.PeerScoreParams, *libp2p_pubsub.PeerScoreThresholds) {
+	topics := make(map[string]*libp2p_pubsub.TopicScoreParams, len(s.topics))
+	for _, topic := range s.topics {
+		topics[topic] = &libp2p_pubsub.TopicScoreParams{
+			SkipAtomicValidation: true,
+			TopicWeight:          1,
+			// time in the mesh is not scored, but the quantum still divides it
+			TimeInMeshQuantum:              time.Second,
+			InvalidMessageDeliveriesWeight: invalidMessageWeight,
+			InvalidMessageDeliveriesDecay:  libp2p_pubsub.ScoreParameterDecay(penaltyDecay),
+		}
+	}
+	params := &libp2p_pubsub.PeerScoreParams{
+		SkipAtomicValidation:      true,
+		Topics:                    topics,
+		AppSpecificScore:          func(peer.ID) float64 { return 0 },
+		BehaviourPenaltyWeight:    behaviourPenaltyWeight,
+		BehaviourPenaltyThreshold: behaviourPenaltyThreshold,
+		BehaviourPenaltyDecay:     libp2p_pubsub.ScoreParameterDecay(penaltyDecay),
+		DecayInterval:             libp2p_pubsub.DefaultDecayInterval,
+		DecayToZero:               libp2p_pubsub.DefaultDecayToZero,
+		RetainScore:               scoreRetention,
+	}
+	thresholds := &libp2p_pubsub.PeerScoreThresholds{
+		SkipAtomicValidation: true,
+		GossipThreshold:      gossipThreshold,
+		PublishThreshold:     publishThreshold,
+		GraylistThreshold:    graylistThreshold,
+	}
+	return params, thresholds
+}
+
+func (s *PeerScoring) inspect(scores map[peer.ID]*libp2p_pubsub.PeerScoreSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scores = scores
+}
+
+// GetPeerScores returns the scores of the peers as of the last inspection, lowest score first.
+func (s *PeerScoring) GetPeerScores(_ context.Context) []model.PeerScore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	scores := make([]model.PeerScore, 0, len(s.scores))
+	for peerID, snapshot := range s.scores {
+		score := model.PeerScore{
+			PeerID:           peerID.String(),
+			Score:            snapshot.Score,
+			Graylisted:       snapshot.Score < graylistThreshold,
+			BehaviourPenalty: snapshot.BehaviourPenalty,
+		}
+		for topic, topicSnapshot := range snapshot.Topics {
+			if topicSnapshot.InvalidMessageDeliveries == 0 {
+				continue
+			}
+			if score.InvalidMessages == nil {
+				score.InvalidMessages = make(map[string]float64)
+			}
+			score.InvalidMessages[topic] = topicSnapshot.InvalidMessageDeliveries
+		}
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].PeerID < scores[j].PeerID
+	})
+	return scores
+}
+
+// compile-time interface assertions
+var _ model.PeerScoreProvider = (*PeerScoring)(nil)
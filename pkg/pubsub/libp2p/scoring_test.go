@@ -0,0 +1,76 @@
+//go:build unit || !integration
+
+package libp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2p_host "github.com/bacalhau-project/bacalhau/pkg/libp2p"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/pubsub"
+	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerScoring_GraylistsMalformedMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	honestHost, err := libp2p_host.NewHostForTest(ctx)
+	require.NoError(t, err)
+	scoring := NewPeerScoring(PeerScoringParams{Topics: []string{testTopic}, InspectInterval: 100 * time.Millisecond})
+	honestGossipSub, err := libp2p_pubsub.NewGossipSub(ctx, honestHost, scoring.Option())
+	require.NoError(t, err)
+	honest, err := NewPubSub[string](PubSubParams{Host: honestHost, TopicName: testTopic, PubSub: honestGossipSub})
+	require.NoError(t, err)
+	subscriber := pubsub.NewInMemorySubscriber[string]()
+	require.NoError(t, honest.Subscribe(ctx, subscriber))
+
+	// the spammer publishes directly to the topic, without going through the validation of PubSub
+	spammerHost, err := libp2p_host.NewHostForTest(ctx, honestHost)
+	require.NoError(t, err)
+	spammerGossipSub, err := libp2p_pubsub.NewGossipSub(ctx, spammerHost)
+	require.NoError(t, err)
+	spammerTopic, err := spammerGossipSub.Join(testTopic)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(spammerTopic.ListPeers()) > 0
+	}, 10*time.Second, 100*time.Millisecond)
+
+	var score model.PeerScore
+	require.Eventually(t, func() bool {
+		require.NoError(t, spammerTopic.Publish(ctx, []byte("not json")))
+		scores := scoring.GetPeerScores(ctx)
+		if len(scores) == 0 {
+			return false
+		}
+		score = scores[0]
+		return score.Graylisted
+	}, 20*time.Second, 100*time.Millisecond)
+
+	require.Equal(t, spammerHost.ID().String(), score.PeerID)
+	require.Less(t, score.Score, float64(graylistThreshold))
+	require.GreaterOrEqual(t, score.InvalidMessages[testTopic], float64(10))
+	require.Empty(t, subscriber.Events())
+}
+
+func TestPeerRateLimiter(t *testing.T) {
+	limiter := newPeerRateLimiter(2, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.allow("peer1", now), "message %d is within the burst", i)
+	}
+	require.False(t, limiter.allow("peer1", now))
+	require.True(t, limiter.allow("peer2", now), "peers are limited separately")
+
+	// a token is added every half second
+	require.True(t, limiter.allow("peer1", now.Add(500*time.Millisecond)))
+	require.False(t, limiter.allow("peer1", now.Add(500*time.Millisecond)))
+
+	// buckets that refilled are forgotten
+	later := now.Add(time.Minute)
+	require.True(t, limiter.allow("peer3", later))
+	require.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,237 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+// checkMaxCapacity returns ErrImpossibleResources if the job requests more of a resource than any of the known compute
+// nodes can ever give a single job, so that the job is rejected when it is submitted instead of waiting for bids that
+// will never come. A node can give a job up to its limit per job, or its total capacity if it has no limit. Nothing is
+// rejected if no compute node is known, or if any of them does not report how much of the resource it has, as they may
+// be able to run the job. Nodes without GPUs report none, so jobs requesting GPUs are rejected by networks without any.
+func checkMaxCapacity(nodes []model.NodeInfo, spec model.Spec) error {
+	requested := capacity.ParseResourceUsageConfig(spec.Resources)
+	resources := []struct {
+		name      string
+		requested float64
+		value     func(model.ResourceUsageData) float64
+		format    func(float64) string
+		// noneIfZero is whether a node reporting zero of the resource has none of it, rather than not knowing.
+		noneIfZero bool
+	}{
+		{
+			name:      "CPU",
+			requested: requested.CPU,
+			value:     func(r model.ResourceUsageData) float64 { return r.CPU },
+			format:    func(v float64) string { return fmt.Sprintf("%g CPU", v) },
+		},
+		{
+			name:      "memory",
+			requested: float64(requested.Memory),
+			value:     func(r model.ResourceUsageData) float64 { return float64(r.Memory) },
+			format:    formatBytes,
+		},
+		{
+			name:      "disk",
+			requested: float64(requested.Disk),
+			value:     func(r model.ResourceUsageData) float64 { return float64(r.Disk) },
+			format:    formatBytes,
+		},
+		{
+			name:       "GPU",
+			requested:  float64(requested.GPU),
+			value:      func(r model.ResourceUsageData) float64 { return float64(r.GPU) },
+			format:     func(v float64) string { return fmt.Sprintf("%g GPU", v) },
+			noneIfZero: true,
+		},
+	}
+
+	var computeNodes []model.ComputeNodeInfo
+	for _, node := range nodes {
+		if !node.IsComputeNode() {
+			continue
+		}
+		if node.ComputeNodeInfo == nil || node.ComputeNodeInfo.MaxCapacity.IsZero() {
+			// a node that does not report its capacity may run any job
+			return nil
+		}
+		computeNodes = append(computeNodes, *node.ComputeNodeInfo)
+	}
+	if len(computeNodes) == 0 {
+		return nil
+	}
+
+	for _, resource := range resources {
+		if resource.requested == 0 {
+			continue
+		}
+		var largest float64
+		known := true
+		for _, info := range computeNodes {
+			ceiling := resource.value(info.MaxJobRequirements)
+			if ceiling == 0 {
+				ceiling = resource.value(info.MaxCapacity)
+			}
+			if ceiling == 0 && !resource.noneIfZero {
+				known = false
+				break
+			}
+			if ceiling > largest {
+				largest = ceiling
+			}
+		}
+		if known && resource.requested > largest {
+			return NewErrImpossibleResources(
+				resource.name, resource.format(resource.requested), resource.format(largest), len(computeNodes))
+		}
+	}
+	return nil
+}
+
+func formatBytes(v float64) string {
+	return datasize.ByteSize(uint64(v)).HR()
+}
+
+// splitAnnotationPrefix prefixes the ID of the first of the jobs the inputs of a job were split across, which annotates
+// each of them.
+const splitAnnotationPrefix = "split-"
+
+// checkInputSizes returns ErrInputsTooLarge if the inputs of the job, such as a directory CID once exploded, take
+// more disk than any of the known compute nodes with enough memory for the job gives a single job, along with how to
+// split them across jobs that fit. Inputs are sized through the sizer, and inputs or nodes whose sizes are unknown
+// pass the check.
+func checkInputSizes(ctx context.Context, nodes []model.NodeInfo, sizer *inputSizer, spec model.Spec) error {
+	if sizer == nil || len(spec.Inputs) == 0 {
+		return nil
+	}
+
+	memory := capacity.ParseResourceUsageConfig(spec.Resources).Memory
+	var largest uint64
+	computeNodes := 0
+	for _, node := range nodes {
+		if !node.IsComputeNode() {
+			continue
+		}
+		if node.ComputeNodeInfo == nil {
+			return nil
+		}
+		// a node without enough memory for the job can't take its inputs either
+		memoryLimit := node.ComputeNodeInfo.MaxJobRequirements.Memory
+		if memoryLimit == 0 {
+			memoryLimit = node.ComputeNodeInfo.MaxCapacity.Memory
+		}
+		if memory > 0 && memoryLimit > 0 && memory > memoryLimit {
+			continue
+		}
+		limit := node.ComputeNodeInfo.MaxJobRequirements.Disk
+		if limit == 0 {
+			limit = node.ComputeNodeInfo.MaxCapacity.Disk
+		}
+		if limit == 0 {
+			// a node that does not report its disk may fit any input
+			return nil
+		}
+		largest = system.Max(largest, limit)
+		computeNodes++
+	}
+	if computeNodes == 0 {
+		// jobs that no node has the memory for are rejected by checkMaxCapacity
+		return nil
+	}
+
+	var inputs []InputSize
+	var total uint64
+	for i, size := range sizer.Sizes(ctx, spec.Inputs, inputSizeWait) {
+		if size == 0 {
+			log.Ctx(ctx).Debug().Str("Input", inputName(spec.Inputs[i])).Msg("input not sized in time, leaving it out of the check")
+			continue
+		}
+		inputs = append(inputs, InputSize{Input: inputName(spec.Inputs[i]), Size: size})
+		total += size
+	}
+	if total <= largest {
+		return nil
+	}
+	return NewErrInputsTooLarge(total, largest, memory, computeNodes, inputs)
+}
+
+// splitInputs returns the specs of the jobs to run instead of the job that does not fit, each with a group of the
+// inputs of the suggestion. It returns false if the suggestion can't be applied, as some inputs don't fit any node on
+// their own, or were not sized.
+func splitInputs(spec model.Spec, tooLarge ErrInputsTooLarge) ([]model.Spec, bool) {
+	if len(tooLarge.Suggestion.TooLarge) > 0 || len(tooLarge.Suggestion.Jobs) < 2 || len(tooLarge.Inputs) != len(spec.Inputs) {
+		return nil, false
+	}
+	byName := make(map[string]model.StorageSpec, len(spec.Inputs))
+	for _, input := range spec.Inputs {
+		byName[inputName(input)] = input
+	}
+	if len(byName) != len(spec.Inputs) {
+		// inputs with the same name can't be told apart in the suggestion
+		return nil, false
+	}
+
+	specs := make([]model.Spec, 0, len(tooLarge.Suggestion.Jobs))
+	for _, names := range tooLarge.Suggestion.Jobs {
+		split := spec
+		split.Inputs = make([]model.StorageSpec, 0, len(names))
+		for _, name := range names {
+			split.Inputs = append(split.Inputs, byName[name])
+		}
+		specs = append(specs, split)
+	}
+	return specs, true
+}
+
+// inputName returns how an input is referred to in suggestions.
+func inputName(input model.StorageSpec) string {
+	switch {
+	case input.Path != "":
+		return input.Path
+	case input.Name != "":
+		return input.Name
+	case input.CID != "":
+		return input.CID
+	default:
+		return input.URL
+	}
+}
+
+// suggestSharding splits the inputs into as few groups as it can whose sizes fit the limit, largest inputs first, so
+// that each group can be submitted as its own job. Inputs larger than the limit on their own are returned apart, as
+// they need to be split within themselves.
+func suggestSharding(inputs []InputSize, limit uint64) ShardingSuggestion {
+	sorted := append([]InputSize{}, inputs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+
+	var suggestion ShardingSuggestion
+	var sizes []uint64
+	for _, input := range sorted {
+		if input.Size > limit {
+			suggestion.TooLarge = append(suggestion.TooLarge, input.Input)
+			continue
+		}
+		placed := false
+		for i := range suggestion.Jobs {
+			if sizes[i]+input.Size <= limit {
+				suggestion.Jobs[i] = append(suggestion.Jobs[i], input.Input)
+				sizes[i] += input.Size
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			suggestion.Jobs = append(suggestion.Jobs, []string{input.Input})
+			sizes = append(sizes, input.Size)
+		}
+	}
+	return suggestion
+}
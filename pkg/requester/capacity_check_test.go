@@ -0,0 +1,269 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+)
+
+func TestCheckMaxCapacity(t *testing.T) {
+	smallNode := newComputeNode("small-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	smallNode.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{CPU: 4, Memory: 8 << 30, Disk: 100 << 30}
+	largeNode := newComputeNode("large-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	largeNode.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{CPU: 32, Memory: 256 << 30, Disk: 1 << 40, GPU: 2}
+	largeNode.ComputeNodeInfo.MaxJobRequirements = model.ResourceUsageData{Memory: 64 << 30}
+	nodes := []model.NodeInfo{smallNode, largeNode, {PeerInfo: peer.AddrInfo{ID: peer.ID("other-node")}}}
+
+	for _, resources := range []model.ResourceUsageConfig{
+		{},
+		{CPU: "16", Memory: "64Gb", Disk: "1Tb", GPU: "2"},
+	} {
+		require.NoError(t, checkMaxCapacity(nodes, model.Spec{Resources: resources}), resources)
+	}
+
+	err := checkMaxCapacity(nodes, model.Spec{Resources: model.ResourceUsageConfig{Memory: "4Tb"}})
+	var impossibleErr ErrImpossibleResources
+	require.ErrorAs(t, err, &impossibleErr)
+	require.Equal(t, "memory", impossibleErr.Resource)
+	require.Equal(t, "4.0 TB", impossibleErr.Requested)
+	require.Equal(t, "64.0 GB", impossibleErr.Largest, "the limit per job is used over the total capacity")
+	require.Equal(t, 2, impossibleErr.Nodes)
+	require.Contains(t, err.Error(), "no node can ever satisfy 4.0 TB memory")
+
+	err = checkMaxCapacity(nodes, model.Spec{Resources: model.ResourceUsageConfig{GPU: "1", CPU: "64"}})
+	require.ErrorAs(t, err, &impossibleErr)
+	require.Equal(t, "CPU", impossibleErr.Resource)
+
+	// nodes without GPUs report none
+	err = checkMaxCapacity([]model.NodeInfo{smallNode}, model.Spec{Resources: model.ResourceUsageConfig{GPU: "1"}})
+	require.ErrorAs(t, err, &impossibleErr)
+	require.Equal(t, "GPU", impossibleErr.Resource)
+	require.Equal(t, "0 GPU", impossibleErr.Largest)
+
+	// jobs are not rejected while a node may be able to run them
+	require.NoError(t, checkMaxCapacity(nil, model.Spec{Resources: model.ResourceUsageConfig{Memory: "4Tb"}}))
+	smallNode.ComputeNodeInfo.MaxCapacity.Memory = 0
+	require.NoError(t, checkMaxCapacity(nodes, model.Spec{Resources: model.ResourceUsageConfig{Memory: "4Tb"}}))
+}
+
+func TestEndpointRejectsImpossibleResources(t *testing.T) {
+	ctx := context.Background()
+	node := newComputeNode("node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	node.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{CPU: 4, Memory: 8 << 30, Disk: 100 << 30}
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, store := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.NodeDiscoverer = fixedNodeDiscoverer{nodes: []model.NodeInfo{node}}
+	})
+
+	job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{
+		Spec: &model.Spec{Resources: model.ResourceUsageConfig{Memory: "4Tb"}},
+	})
+	require.ErrorAs(t, err, &ErrImpossibleResources{})
+	_, err = store.GetJob(ctx, job.ID())
+	require.Error(t, err, "rejected jobs are not stored")
+
+	_, err = endpoint.SubmitJob(ctx, model.JobCreatePayload{
+		Spec: &model.Spec{Resources: model.ResourceUsageConfig{Memory: "4Gb"}},
+	})
+	require.NoError(t, err)
+}
+
+func TestCheckInputSizes(t *testing.T) {
+	smallNode := newComputeNode("small-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	smallNode.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{Disk: 100 << 30}
+	smallNode.ComputeNodeInfo.MaxJobRequirements = model.ResourceUsageData{Disk: 1 << 30}
+	largeNode := newComputeNode("large-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	largeNode.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{Disk: 4 << 30}
+	nodes := []model.NodeInfo{smallNode, largeNode}
+
+	sizes := map[string]uint64{"a": 3 << 30, "b": 2 << 30, "c": 1 << 30, "d": 5 << 30}
+	storages := model.NewNoopProvider[model.StorageSourceType, storage.Storage](noop.NewNoopStorageWithConfig(noop.StorageConfig{
+		ExternalHooks: noop.StorageConfigExternalHooks{
+			GetVolumeSize: func(_ context.Context, volume model.StorageSpec) (uint64, error) {
+				return sizes[volume.CID], nil
+			},
+		},
+	}))
+	specWithInputs := func(cids ...string) model.Spec {
+		var spec model.Spec
+		for _, cid := range cids {
+			spec.Inputs = append(spec.Inputs, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, Path: "/inputs/" + cid})
+		}
+		return spec
+	}
+
+	ctx := context.Background()
+	sizer := newInputSizer(storages)
+	require.NoError(t, checkInputSizes(ctx, nodes, sizer, specWithInputs("a", "c")))
+	require.NoError(t, checkInputSizes(ctx, nodes, sizer, specWithInputs("unknown")))
+
+	err := checkInputSizes(ctx, nodes, sizer, specWithInputs("a", "b", "c", "d"))
+	var tooLarge ErrInputsTooLarge
+	require.ErrorAs(t, err, &tooLarge)
+	require.Equal(t, uint64(11<<30), tooLarge.Total)
+	require.Equal(t, uint64(4<<30), tooLarge.Largest)
+	require.Equal(t, [][]string{{"/inputs/a", "/inputs/c"}, {"/inputs/b"}}, tooLarge.Suggestion.Jobs)
+	require.Equal(t, []string{"/inputs/d"}, tooLarge.Suggestion.TooLarge)
+	require.Contains(t, err.Error(), "Split the inputs across 2 jobs")
+
+	// only the disk of nodes with enough memory for the job counts
+	largeNode.ComputeNodeInfo.MaxCapacity.Memory = 2 << 30
+	smallNode.ComputeNodeInfo.MaxCapacity.Memory = 8 << 30
+	spec := specWithInputs("a", "c")
+	spec.Resources.Memory = "4Gb"
+	err = checkInputSizes(ctx, nodes, sizer, spec)
+	require.ErrorAs(t, err, &tooLarge)
+	require.Equal(t, uint64(1<<30), tooLarge.Largest)
+	require.Equal(t, 1, tooLarge.Nodes)
+	require.Contains(t, err.Error(), "of memory the job requests")
+	spec.Resources.Memory = "1Gb"
+	require.NoError(t, checkInputSizes(ctx, nodes, sizer, spec))
+
+	// nodes that don't report their disk may fit any input
+	largeNode.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{}
+	require.NoError(t, checkInputSizes(ctx, []model.NodeInfo{smallNode, largeNode}, sizer, specWithInputs("a", "b", "c", "d")))
+}
+
+func TestInputSizerDoesNotHoldUpSubmissions(t *testing.T) {
+	release := make(chan struct{})
+	storages := model.NewNoopProvider[model.StorageSourceType, storage.Storage](noop.NewNoopStorageWithConfig(noop.StorageConfig{
+		ExternalHooks: noop.StorageConfigExternalHooks{
+			GetVolumeSize: func(_ context.Context, volume model.StorageSpec) (uint64, error) {
+				if volume.CID == "slow" {
+					<-release
+				}
+				return 1 << 30, nil
+			},
+		},
+	}))
+	sizer := newInputSizer(storages)
+	inputs := []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, CID: "fast"},
+		{StorageSource: model.StorageSourceIPFS, CID: "slow"},
+	}
+
+	// the slow input is left out rather than waited for
+	ctx := context.Background()
+	require.Equal(t, []uint64{1 << 30, 0}, sizer.Sizes(ctx, inputs, 100*time.Millisecond))
+
+	// it keeps being sized in the background, and its size is known to later submissions
+	close(release)
+	require.Eventually(t, func() bool {
+		return sizer.Sizes(ctx, inputs, 0)[1] == 1<<30
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSubmitSplitsJobsWhoseInputsDoNotFit(t *testing.T) {
+	node := newComputeNode("node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	node.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{CPU: 4, Memory: 8 << 30, Disk: 4 << 30}
+	sizes := map[string]uint64{"a": 3 << 30, "b": 2 << 30, "c": 1 << 30}
+	storages := model.NewNoopProvider[model.StorageSourceType, storage.Storage](noop.NewNoopStorageWithConfig(noop.StorageConfig{
+		ExternalHooks: noop.StorageConfigExternalHooks{
+			GetVolumeSize: func(_ context.Context, volume model.StorageSpec) (uint64, error) {
+				return sizes[volume.CID], nil
+			},
+		},
+	}))
+	var spec model.Spec
+	for _, cid := range []string{"a", "b", "c"} {
+		spec.Inputs = append(spec.Inputs, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, Path: "/inputs/" + cid})
+	}
+
+	ctx := context.Background()
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.NodeDiscoverer = fixedNodeDiscoverer{nodes: []model.NodeInfo{node}}
+		params.StorageProviders = storages
+	})
+	_, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.ErrorAs(t, err, &ErrInputsTooLarge{}, "jobs are not split unless enabled")
+
+	endpoint, store := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.NodeDiscoverer = fixedNodeDiscoverer{nodes: []model.NodeInfo{node}}
+		params.StorageProviders = storages
+		params.AutoSplitInputs = true
+	})
+	job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.NoError(t, err)
+	require.Equal(t, []model.StorageSpec{spec.Inputs[0], spec.Inputs[2]}, job.Spec.Inputs)
+	annotation := splitAnnotationPrefix + job.ID()
+	require.Contains(t, job.Spec.Annotations, annotation)
+
+	jobs, err := store.GetJobs(ctx, jobstore.JobQuery{IncludeTags: []model.IncludedTag{model.IncludedTag(annotation)}, ReturnAll: true})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	var groups [][]model.StorageSpec
+	for _, j := range jobs {
+		groups = append(groups, j.Spec.Inputs)
+	}
+	require.ElementsMatch(t, [][]model.StorageSpec{{spec.Inputs[0], spec.Inputs[2]}, {spec.Inputs[1]}}, groups)
+}
+
+// splitBidStrategy fails the jobs with the input and keeps the others waiting.
+type splitBidStrategy struct {
+	mockBidStrategy
+	failingInput string
+}
+
+func (s *splitBidStrategy) ShouldBid(_ context.Context, request bidstrategy.BidStrategyRequest) (bidstrategy.BidStrategyResponse, error) {
+	for _, input := range request.Job.Spec.Inputs {
+		if input.CID == s.failingInput {
+			return bidstrategy.BidStrategyResponse{}, errors.New("failed to select job")
+		}
+	}
+	return bidstrategy.BidStrategyResponse{ShouldWait: true}, nil
+}
+
+func TestSubmitCancelsSplitJobsWhenOneFails(t *testing.T) {
+	node := newComputeNode("node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	node.ComputeNodeInfo.MaxCapacity = model.ResourceUsageData{CPU: 4, Memory: 8 << 30, Disk: 4 << 30}
+	storages := model.NewNoopProvider[model.StorageSourceType, storage.Storage](noop.NewNoopStorageWithConfig(noop.StorageConfig{
+		ExternalHooks: noop.StorageConfigExternalHooks{
+			GetVolumeSize: func(context.Context, model.StorageSpec) (uint64, error) {
+				return 3 << 30, nil
+			},
+		},
+	}))
+	var spec model.Spec
+	for _, cid := range []string{"a", "b", "c"} {
+		spec.Inputs = append(spec.Inputs, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, Path: "/inputs/" + cid})
+	}
+
+	ctx := context.Background()
+	// the inputs are split across three jobs, and the second job split from the submitted one fails
+	endpoint, store := getTestEndpoint(t, &splitBidStrategy{failingInput: "c"}, func(params *BaseEndpointParams) {
+		params.NodeDiscoverer = fixedNodeDiscoverer{nodes: []model.NodeInfo{node}}
+		params.StorageProviders = storages
+		params.AutoSplitInputs = true
+	})
+	job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.ErrorContains(t, err, "submitting the jobs the inputs were split across")
+
+	jobs, err := store.GetJobs(ctx, jobstore.JobQuery{
+		IncludeTags: []model.IncludedTag{model.IncludedTag(splitAnnotationPrefix + job.ID())},
+		ReturnAll:   true,
+	})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	states := make(map[string]model.JobStateType)
+	for _, splitJob := range jobs {
+		state, err := store.GetJobState(ctx, splitJob.ID())
+		require.NoError(t, err)
+		states[splitJob.Spec.Inputs[0].CID] = state.State
+	}
+	require.Equal(t, model.JobStateCancelled, states["b"], "the job submitted before the failure is cancelled")
+	_, err = store.GetJob(ctx, job.ID())
+	require.Error(t, err, "the submitted job is not stored")
+}
@@ -0,0 +1,163 @@
+package requester
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// CircuitBreakerConfig configures when the requester stops accepting submissions of a spec whose jobs keep failing.
+type CircuitBreakerConfig struct {
+	// Window is how long the outcomes of the jobs of a spec are remembered. Circuit breaking is disabled if zero.
+	Window time.Duration
+	// MinFailures is how many jobs of a spec must have failed within the window before its circuit opens.
+	MinFailures int
+	// FailureRate is the fraction of the jobs of a spec that ended within the window that must have failed for its
+	// circuit to open, from 0 to 1.
+	FailureRate float64
+	// Cooldown is how long submissions of a spec are rejected once its circuit opens.
+	Cooldown time.Duration
+}
+
+// Enabled returns whether submissions are ever rejected.
+func (c CircuitBreakerConfig) Enabled() bool {
+	return c.Window > 0
+}
+
+// CircuitBreaker rejects submissions of specs whose jobs keep failing, to protect the network from client automation
+// resubmitting a broken spec in a loop. The circuit of a spec opens once enough of its jobs fail within the window,
+// and closes again after the cooldown, when the outcomes of its jobs start being counted from scratch. Specs are
+// identified by their specHash, which includes the client submitting them, so that the failures of one client don't
+// reject the submissions of the others.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	mu     sync.Mutex
+	// specs are the circuits of the specs with jobs that ended within the window, by spec hash
+	specs map[string]*specCircuit
+	// jobs are the hashes of the specs of the jobs that have not ended yet
+	jobs map[string]string
+}
+
+type specCircuit struct {
+	outcomes  []jobOutcome
+	openUntil time.Time
+	// failures, ended and lastFailure explain why the circuit opened
+	failures    int
+	ended       int
+	lastFailure string
+}
+
+type jobOutcome struct {
+	endedAt time.Time
+	failed  bool
+}
+
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config: config,
+		specs:  make(map[string]*specCircuit),
+		jobs:   make(map[string]string),
+	}
+}
+
+// Allow returns ErrCircuitOpen if submissions of the spec are rejected.
+func (b *CircuitBreaker) Allow(hash string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	circuit, ok := b.specs[hash]
+	if !ok || !now.Before(circuit.openUntil) {
+		return nil
+	}
+	return NewErrCircuitOpen(circuit.failures, circuit.ended, circuit.openUntil, circuit.lastFailure)
+}
+
+// Track remembers the spec of the job, so that its outcome counts towards the circuit of the spec once it ends.
+func (b *CircuitBreaker) Track(jobID string, hash string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[jobID] = hash
+}
+
+// Record counts the outcome of the job towards the circuit of its spec, opening the circuit if too many jobs of the
+// spec failed. Cancelled jobs are not counted, and nor are jobs that were not tracked.
+func (b *CircuitBreaker) Record(jobID string, state model.JobStateType, comment string, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hash, ok := b.jobs[jobID]
+	if !ok {
+		return
+	}
+	delete(b.jobs, jobID)
+	b.prune(now)
+	if state == model.JobStateCancelled {
+		return
+	}
+
+	circuit, ok := b.specs[hash]
+	if !ok {
+		circuit = &specCircuit{}
+		b.specs[hash] = circuit
+	}
+	failed := state == model.JobStateError
+	circuit.outcomes = append(circuit.outcomes, jobOutcome{endedAt: now, failed: failed})
+	if !failed || now.Before(circuit.openUntil) {
+		return
+	}
+
+	failures := 0
+	for _, outcome := range circuit.outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	if failures >= b.config.MinFailures && float64(failures) >= b.config.FailureRate*float64(len(circuit.outcomes)) {
+		circuit.openUntil = now.Add(b.config.Cooldown)
+		circuit.failures = failures
+		circuit.ended = len(circuit.outcomes)
+		circuit.lastFailure = comment
+		// the spec gets a fresh start once the cooldown is over
+		circuit.outcomes = nil
+	}
+}
+
+// prune forgets the outcomes of jobs that ended before the window, and the circuits left without any.
+func (b *CircuitBreaker) prune(now time.Time) {
+	since := now.Add(-b.config.Window)
+	for hash, circuit := range b.specs {
+		kept := circuit.outcomes[:0]
+		for _, outcome := range circuit.outcomes {
+			if outcome.endedAt.After(since) {
+				kept = append(kept, outcome)
+			}
+		}
+		circuit.outcomes = kept
+		if len(circuit.outcomes) == 0 && !now.Before(circuit.openUntil) {
+			delete(b.specs, hash)
+		}
+	}
+}
+
+// CircuitBreakingStore is a job store that records the outcome of jobs in a circuit breaker when they end. Every
+// change of the state of a job goes through the store, so outcomes are recorded however jobs end.
+type CircuitBreakingStore struct {
+	jobstore.Store
+	breaker *CircuitBreaker
+}
+
+func NewCircuitBreakingStore(store jobstore.Store, breaker *CircuitBreaker) *CircuitBreakingStore {
+	return &CircuitBreakingStore{Store: store, breaker: breaker}
+}
+
+func (s *CircuitBreakingStore) UpdateJobState(ctx context.Context, request jobstore.UpdateJobStateRequest) error {
+	err := s.Store.UpdateJobState(ctx, request)
+	if err == nil && request.NewState.IsTerminal() {
+		s.breaker.Record(request.JobID, request.NewState, request.Comment, time.Now())
+	}
+	return err
+}
+
+// compile-time interface check
+var _ jobstore.Store = (*CircuitBreakingStore)(nil)
@@ -0,0 +1,118 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+var testCircuitBreakerConfig = CircuitBreakerConfig{
+	Window:      time.Hour,
+	MinFailures: 3,
+	FailureRate: 0.75,
+	Cooldown:    10 * time.Minute,
+}
+
+func recordOutcomes(breaker *CircuitBreaker, hash string, now time.Time, states ...model.JobStateType) {
+	for i, state := range states {
+		jobID := fmt.Sprintf("%s-%d", hash, i)
+		breaker.Track(jobID, hash)
+		breaker.Record(jobID, state, "exit code 1", now)
+	}
+}
+
+func TestCircuitBreakerOpensAfterRepeatedFailures(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(testCircuitBreakerConfig)
+
+	recordOutcomes(breaker, "broken", now, model.JobStateError, model.JobStateError)
+	require.NoError(t, breaker.Allow("broken", now), "too few jobs failed")
+
+	recordOutcomes(breaker, "broken", now, model.JobStateError)
+	err := breaker.Allow("broken", now)
+	var circuitErr ErrCircuitOpen
+	require.True(t, errors.As(err, &circuitErr))
+	require.Equal(t, 3, circuitErr.Failures)
+	require.Equal(t, now.Add(testCircuitBreakerConfig.Cooldown), circuitErr.Until)
+	require.Contains(t, err.Error(), "exit code 1")
+	require.NoError(t, breaker.Allow("other", now), "other specs are not affected")
+
+	// the circuit closes after the cooldown, and the spec starts from scratch
+	later := now.Add(testCircuitBreakerConfig.Cooldown)
+	require.NoError(t, breaker.Allow("broken", later))
+	recordOutcomes(breaker, "broken", later, model.JobStateError)
+	require.NoError(t, breaker.Allow("broken", later))
+}
+
+func TestCircuitBreakerIgnoresCancelledAndCountsSuccesses(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(testCircuitBreakerConfig)
+
+	// the spec fails too rarely for its circuit to open
+	recordOutcomes(breaker, "flaky", now, model.JobStateCompleted, model.JobStateCompleted,
+		model.JobStateError, model.JobStateError, model.JobStateError)
+	require.NoError(t, breaker.Allow("flaky", now))
+
+	// cancelled jobs say nothing about the spec
+	recordOutcomes(breaker, "cancelled", now, model.JobStateCancelled, model.JobStateCancelled, model.JobStateCancelled)
+	require.NoError(t, breaker.Allow("cancelled", now))
+
+	// untracked jobs are not counted
+	for i := 0; i < 3; i++ {
+		breaker.Record("untracked", model.JobStateError, "", now)
+	}
+	require.NotContains(t, breaker.specs, "untracked")
+}
+
+func TestCircuitBreakerForgetsOutcomesOutsideWindow(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(testCircuitBreakerConfig)
+
+	recordOutcomes(breaker, "broken", now, model.JobStateError, model.JobStateError)
+	recordOutcomes(breaker, "broken", now.Add(testCircuitBreakerConfig.Window), model.JobStateError)
+	require.NoError(t, breaker.Allow("broken", now.Add(testCircuitBreakerConfig.Window)))
+}
+
+func TestEndpointRejectsSpecsThatKeepFailing(t *testing.T) {
+	ctx := context.Background()
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	breaker := NewCircuitBreaker(testCircuitBreakerConfig)
+	var store jobstore.Store
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		store = NewCircuitBreakingStore(params.Store, breaker)
+		params.Store = store
+		params.CircuitBreaker = breaker
+	})
+
+	broken := model.Spec{Annotations: []string{"broken"}}
+	for i := 0; i < testCircuitBreakerConfig.MinFailures; i++ {
+		job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{ClientID: "client", Spec: &broken})
+		require.NoError(t, err)
+		require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+			JobID:    job.ID(),
+			NewState: model.JobStateError,
+			Comment:  "image not found",
+		}))
+	}
+
+	_, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{ClientID: "client", Spec: &broken})
+	require.ErrorAs(t, err, &ErrCircuitOpen{})
+	require.Contains(t, err.Error(), "image not found")
+
+	_, err = endpoint.SubmitJob(ctx, model.JobCreatePayload{ClientID: "client", Spec: &model.Spec{Annotations: []string{"fixed"}}})
+	require.NoError(t, err)
+
+	// the failures of one client don't open the circuit of the same spec for the others
+	_, err = endpoint.SubmitJob(ctx, model.JobCreatePayload{ClientID: "other", Spec: &broken})
+	require.NoError(t, err)
+}
@@ -0,0 +1,317 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+// SheddingPolicy is what the requester does with the jobs submitted once it is orchestrating as many executions as
+// its concurrency ceiling allows.
+type SheddingPolicy string
+
+const (
+	// SheddingQueue keeps the jobs queued until enough executions end, and rejects them once the queue is full.
+	SheddingQueue SheddingPolicy = "queue"
+	// SheddingReject rejects the jobs right away.
+	SheddingReject SheddingPolicy = "reject"
+	// SheddingRejectLowestPriority keeps the jobs queued like SheddingQueue, but once the queue is full, the queued
+	// job with the lowest priority is rejected to make room for a job with a higher priority. As clients set the
+	// priority of their jobs, only the jobs of the same namespace, or of the same client if jobs have no namespace, are
+	// rejected to make room for a job.
+	SheddingRejectLowestPriority SheddingPolicy = "reject-lowest-priority"
+)
+
+// SheddingPolicies are the shedding policies the requester supports.
+var SheddingPolicies = []SheddingPolicy{SheddingQueue, SheddingReject, SheddingRejectLowestPriority}
+
+// ParseSheddingPolicy returns the shedding policy with the given name, or SheddingQueue if it is empty.
+func ParseSheddingPolicy(name string) (SheddingPolicy, error) {
+	if name == "" {
+		return SheddingQueue, nil
+	}
+	for _, policy := range SheddingPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown shedding policy %q, expected one of %v", name, SheddingPolicies)
+}
+
+// ConcurrencyCeilingConfig configures the maximum number of executions the requester orchestrates at once.
+type ConcurrencyCeilingConfig struct {
+	// MaxInFlightExecutions is the maximum number of executions of the jobs that have started and not ended yet. Each
+	// job counts for as many executions as its concurrency. There is no ceiling if zero.
+	MaxInFlightExecutions int
+	// Shedding is what is done with the jobs submitted past the ceiling. Jobs are queued if empty.
+	Shedding SheddingPolicy
+	// MaxQueuedJobs is the maximum number of jobs waiting for executions to end before they start. The queue is
+	// unbounded if zero, which SheddingRejectLowestPriority does not allow.
+	MaxQueuedJobs int
+}
+
+// Enabled returns whether the number of executions is limited.
+func (c ConcurrencyCeilingConfig) Enabled() bool {
+	return c.MaxInFlightExecutions > 0
+}
+
+// Validate returns an error if the shedding policy can't be applied.
+func (c ConcurrencyCeilingConfig) Validate() error {
+	if _, err := ParseSheddingPolicy(string(c.Shedding)); err != nil {
+		return err
+	}
+	if c.MaxInFlightExecutions < 0 || c.MaxQueuedJobs < 0 {
+		return fmt.Errorf("the maximum numbers of in-flight executions and of queued jobs cannot be negative")
+	}
+	if c.Shedding == SheddingRejectLowestPriority && c.MaxQueuedJobs == 0 {
+		return fmt.Errorf("the %s shedding policy needs a maximum number of queued jobs", c.Shedding)
+	}
+	return nil
+}
+
+// Admission is the decision of the concurrency ceiling about a job submitted.
+type Admission struct {
+	// Wait is whether the job waits in the queue, to be started by the ceiling once there is room for it.
+	Wait bool
+	// Shed is the ID of the queued job rejected to make room for the job, if any.
+	Shed string
+	// shed is the queued job rejected to make room for the job, which is put back if the job can't be submitted
+	shed *waitingJob
+}
+
+// ConcurrencyCeiling limits the number of executions the requester orchestrates at once, to protect the scheduler and
+// the transport from spikes of submissions. Jobs past the ceiling wait in a queue ordered by priority, then by
+// submission, or are rejected, depending on the shedding policy. A job that needs more executions than the ceiling
+// only starts once no other job is running, rather than never.
+type ConcurrencyCeiling struct {
+	config   ConcurrencyCeilingConfig
+	mu       sync.Mutex
+	inFlight int
+	// running are the executions counted for each job that has started and not ended yet
+	running map[string]int
+	// waiting are the jobs queued behind the ceiling, in the order they start
+	waiting []*waitingJob
+	// pending are the jobs admitted to wait that have not been queued yet
+	pending map[string]bool
+	// sequence orders the jobs of the same priority by submission
+	sequence uint64
+}
+
+type waitingJob struct {
+	jobID      string
+	owner      string
+	priority   int
+	executions int
+	sequence   uint64
+	start      func()
+}
+
+func NewConcurrencyCeiling(config ConcurrencyCeilingConfig) *ConcurrencyCeiling {
+	if config.Shedding == "" {
+		config.Shedding = SheddingQueue
+	}
+	return &ConcurrencyCeiling{
+		config:  config,
+		running: make(map[string]int),
+		pending: make(map[string]bool),
+	}
+}
+
+// jobOwner returns who the job belongs to, as far as shedding is concerned: its namespace, or its client if it has
+// none.
+func jobOwner(job model.Job) string {
+	if job.Metadata.Namespace != "" {
+		return "namespace:" + job.Metadata.Namespace
+	}
+	return "client:" + job.Metadata.ClientID
+}
+
+// jobExecutions returns the number of executions the job is counted for.
+func jobExecutions(job model.Job) int {
+	return system.Max(job.Spec.Deal.Concurrency, 1)
+}
+
+// Admit decides whether the job starts now, waits in the queue, or is rejected with ErrOverloaded. Jobs that start
+// now are counted right away. Jobs that wait must then be queued with Enqueue, or released if they can't be.
+func (c *ConcurrencyCeiling) Admit(job model.Job) (Admission, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	executions := jobExecutions(job)
+	if len(c.waiting) == 0 && len(c.pending) == 0 && c.fits(executions) {
+		c.running[job.ID()] = executions
+		c.inFlight += executions
+		return Admission{}, nil
+	}
+
+	overloaded := NewErrOverloaded(c.inFlight, c.config.MaxInFlightExecutions)
+	if c.config.Shedding == SheddingReject {
+		return Admission{}, overloaded
+	}
+	admission := Admission{Wait: true}
+	if c.config.MaxQueuedJobs > 0 && len(c.waiting)+len(c.pending) >= c.config.MaxQueuedJobs {
+		if c.config.Shedding != SheddingRejectLowestPriority {
+			return Admission{}, overloaded
+		}
+		lowest := c.lowestOf(jobOwner(job))
+		if lowest < 0 || c.waiting[lowest].priority >= job.Spec.Priority {
+			return Admission{}, overloaded
+		}
+		admission.Shed = c.waiting[lowest].jobID
+		admission.shed = c.waiting[lowest]
+		c.waiting = append(c.waiting[:lowest], c.waiting[lowest+1:]...)
+	}
+	c.pending[job.ID()] = true
+	return admission, nil
+}
+
+// Enqueue queues a job admitted to wait, and starts the jobs that fit under the ceiling, calling start in a goroutine.
+func (c *ConcurrencyCeiling) Enqueue(job model.Job, start func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.pending[job.ID()] {
+		// the job was released before it was queued
+		return
+	}
+	delete(c.pending, job.ID())
+
+	c.queue(job, start)
+	c.dispatch()
+}
+
+// Restore counts the jobs that had started before the requester restarted, and queues those that were waiting to
+// start in the order they were submitted, calling start for each once it fits under the ceiling.
+func (c *ConcurrencyCeiling) Restore(started []model.Job, waiting []model.Job, start func(model.Job)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, job := range started {
+		if _, ok := c.running[job.ID()]; !ok {
+			c.running[job.ID()] = jobExecutions(job)
+			c.inFlight += jobExecutions(job)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return waiting[i].Metadata.CreatedAt.Before(waiting[j].Metadata.CreatedAt)
+	})
+	for _, job := range waiting {
+		job := job
+		c.queue(job, func() { start(job) })
+	}
+	c.dispatch()
+}
+
+// queue adds a job to the queue. It is called with the lock held.
+func (c *ConcurrencyCeiling) queue(job model.Job, start func()) {
+	c.sequence++
+	c.insert(&waitingJob{
+		jobID:      job.ID(),
+		owner:      jobOwner(job),
+		priority:   job.Spec.Priority,
+		executions: jobExecutions(job),
+		sequence:   c.sequence,
+		start:      start,
+	})
+}
+
+// lowestOf returns the index of the queued job of the owner that starts last, which has the lowest priority of the
+// owner's and was submitted last among those of that priority, or -1 if the owner has no queued job.
+func (c *ConcurrencyCeiling) lowestOf(owner string) int {
+	for i := len(c.waiting) - 1; i >= 0; i-- {
+		if c.waiting[i].owner == owner {
+			return i
+		}
+	}
+	return -1
+}
+
+// Reject releases a job that was admitted but could not be submitted, and puts the job it shed, if it was not
+// cancelled yet, back in the queue in its place.
+func (c *ConcurrencyCeiling) Reject(jobID string, admission Admission) {
+	c.mu.Lock()
+	if admission.shed != nil {
+		c.insert(admission.shed)
+	}
+	c.mu.Unlock()
+	c.Release(jobID)
+}
+
+// insert queues a job in the order jobs start.
+func (c *ConcurrencyCeiling) insert(job *waitingJob) {
+	c.waiting = append(c.waiting, job)
+	sort.SliceStable(c.waiting, func(i, j int) bool {
+		if c.waiting[i].priority != c.waiting[j].priority {
+			return c.waiting[i].priority > c.waiting[j].priority
+		}
+		return c.waiting[i].sequence < c.waiting[j].sequence
+	})
+}
+
+// Release stops counting the executions of a job that ended, or removes it from the queue if it had not started, and
+// starts the jobs that now fit under the ceiling.
+func (c *ConcurrencyCeiling) Release(jobID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if executions, ok := c.running[jobID]; ok {
+		delete(c.running, jobID)
+		c.inFlight -= executions
+	}
+	delete(c.pending, jobID)
+	for i, waiting := range c.waiting {
+		if waiting.jobID == jobID {
+			c.waiting = append(c.waiting[:i], c.waiting[i+1:]...)
+			break
+		}
+	}
+	c.dispatch()
+}
+
+// InFlight returns the number of executions counted, and the number of jobs waiting to start.
+func (c *ConcurrencyCeiling) InFlight() (executions int, queued int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight, len(c.waiting) + len(c.pending)
+}
+
+// dispatch starts the jobs at the head of the queue while they fit under the ceiling. Jobs further down the queue
+// don't overtake the head, so that jobs needing many executions are not starved by smaller ones.
+func (c *ConcurrencyCeiling) dispatch() {
+	for len(c.waiting) > 0 && c.fits(c.waiting[0].executions) {
+		next := c.waiting[0]
+		c.waiting = c.waiting[1:]
+		c.running[next.jobID] = next.executions
+		c.inFlight += next.executions
+		go next.start()
+	}
+}
+
+// fits returns whether a job with the given number of executions can start without going over the ceiling.
+func (c *ConcurrencyCeiling) fits(executions int) bool {
+	return c.inFlight == 0 || c.inFlight+executions <= c.config.MaxInFlightExecutions
+}
+
+// ConcurrencyCeilingStore is a job store that releases the executions of jobs from the concurrency ceiling when they
+// end. Every change of the state of a job goes through the store, so executions are released however jobs end.
+type ConcurrencyCeilingStore struct {
+	jobstore.Store
+	ceiling *ConcurrencyCeiling
+}
+
+func NewConcurrencyCeilingStore(store jobstore.Store, ceiling *ConcurrencyCeiling) *ConcurrencyCeilingStore {
+	return &ConcurrencyCeilingStore{Store: store, ceiling: ceiling}
+}
+
+func (s *ConcurrencyCeilingStore) UpdateJobState(ctx context.Context, request jobstore.UpdateJobStateRequest) error {
+	err := s.Store.UpdateJobState(ctx, request)
+	if err == nil && request.NewState.IsTerminal() {
+		s.ceiling.Release(request.JobID)
+	}
+	return err
+}
+
+// compile-time interface check
+var _ jobstore.Store = (*ConcurrencyCeilingStore)(nil)
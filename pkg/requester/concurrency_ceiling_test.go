@@ -0,0 +1,273 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func ceilingTestJob(id string, concurrency, priority int) model.Job {
+	return model.Job{
+		Metadata: model.Metadata{ID: id},
+		Spec:     model.Spec{Priority: priority, Deal: model.Deal{Concurrency: concurrency}},
+	}
+}
+
+// admitAndEnqueue admits the job, and queues it if it has to wait, recording when it starts in started.
+func admitAndEnqueue(t *testing.T, ceiling *ConcurrencyCeiling, job model.Job, started chan<- string) Admission {
+	admission, err := ceiling.Admit(job)
+	require.NoError(t, err)
+	if admission.Wait {
+		ceiling.Enqueue(job, func() { started <- job.ID() })
+	}
+	return admission
+}
+
+// requireStarted requires that exactly the jobs start, in any order as jobs starting together start concurrently.
+func requireStarted(t *testing.T, started <-chan string, jobIDs ...string) {
+	var startedIDs []string
+	for range jobIDs {
+		select {
+		case id := <-started:
+			startedIDs = append(startedIDs, id)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "jobs did not start", jobIDs)
+		}
+	}
+	require.ElementsMatch(t, jobIDs, startedIDs)
+	select {
+	case id := <-started:
+		require.FailNow(t, "unexpected job started", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConcurrencyCeilingQueuesByPriority(t *testing.T) {
+	ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 3})
+	started := make(chan string, 10)
+
+	require.False(t, admitAndEnqueue(t, ceiling, ceilingTestJob("a", 2, 0), started).Wait)
+	require.False(t, admitAndEnqueue(t, ceiling, ceilingTestJob("b", 1, 0), started).Wait)
+	require.True(t, admitAndEnqueue(t, ceiling, ceilingTestJob("low", 1, 0), started).Wait)
+	require.True(t, admitAndEnqueue(t, ceiling, ceilingTestJob("big", 3, 5), started).Wait)
+	require.True(t, admitAndEnqueue(t, ceiling, ceilingTestJob("high", 1, 5), started).Wait)
+	executions, queued := ceiling.InFlight()
+	require.Equal(t, 3, executions)
+	require.Equal(t, 3, queued)
+
+	// the job at the head of the queue needs all the executions, and the jobs behind it don't overtake it
+	ceiling.Release("b")
+	requireStarted(t, started)
+	ceiling.Release("a")
+	requireStarted(t, started, "big")
+
+	ceiling.Release("big")
+	requireStarted(t, started, "high", "low")
+	executions, queued = ceiling.InFlight()
+	require.Equal(t, 2, executions)
+	require.Equal(t, 0, queued)
+}
+
+func TestConcurrencyCeilingStartsOversizedJobsWhenIdle(t *testing.T) {
+	ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 2})
+	started := make(chan string, 10)
+
+	require.False(t, admitAndEnqueue(t, ceiling, ceilingTestJob("huge", 5, 0), started).Wait)
+	require.True(t, admitAndEnqueue(t, ceiling, ceilingTestJob("next", 5, 0), started).Wait)
+	ceiling.Release("huge")
+	requireStarted(t, started, "next")
+}
+
+func TestConcurrencyCeilingReleasesQueuedJobs(t *testing.T) {
+	ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 1})
+	started := make(chan string, 10)
+
+	admitAndEnqueue(t, ceiling, ceilingTestJob("running", 1, 0), started)
+	admitAndEnqueue(t, ceiling, ceilingTestJob("cancelled", 1, 0), started)
+	admitAndEnqueue(t, ceiling, ceilingTestJob("queued", 1, 0), started)
+
+	// a queued job that ends, such as one cancelled, leaves the queue without starting
+	ceiling.Release("cancelled")
+	ceiling.Release("running")
+	requireStarted(t, started, "queued")
+
+	// a job released before it is queued is not queued
+	admission, err := ceiling.Admit(ceilingTestJob("failed", 1, 0))
+	require.NoError(t, err)
+	require.True(t, admission.Wait)
+	ceiling.Release("failed")
+	ceiling.Enqueue(ceilingTestJob("failed", 1, 0), func() { started <- "failed" })
+	ceiling.Release("queued")
+	requireStarted(t, started)
+}
+
+func TestConcurrencyCeilingSheddingPolicies(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 1, Shedding: SheddingReject})
+		_, err := ceiling.Admit(ceilingTestJob("a", 1, 0))
+		require.NoError(t, err)
+		_, err = ceiling.Admit(ceilingTestJob("b", 1, 0))
+		require.ErrorAs(t, err, &ErrOverloaded{})
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 1, MaxQueuedJobs: 1})
+		started := make(chan string, 10)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("a", 1, 0), started)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("b", 1, 0), started)
+		_, err := ceiling.Admit(ceilingTestJob("c", 1, 10))
+		require.ErrorAs(t, err, &ErrOverloaded{})
+	})
+
+	t.Run("reject-lowest-priority", func(t *testing.T) {
+		ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{
+			MaxInFlightExecutions: 1, MaxQueuedJobs: 2, Shedding: SheddingRejectLowestPriority,
+		})
+		started := make(chan string, 10)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("running", 1, 0), started)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("low", 1, 1), started)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("mid", 1, 2), started)
+
+		// jobs with a priority no higher than the lowest queued are rejected
+		_, err := ceiling.Admit(ceilingTestJob("other-low", 1, 1))
+		require.ErrorAs(t, err, &ErrOverloaded{})
+
+		admission := admitAndEnqueue(t, ceiling, ceilingTestJob("high", 1, 3), started)
+		require.True(t, admission.Wait)
+		require.Equal(t, "low", admission.Shed)
+
+		ceiling.Release("running")
+		requireStarted(t, started, "high")
+	})
+
+	t.Run("reject-lowest-priority only sheds the jobs of the same namespace", func(t *testing.T) {
+		ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{
+			MaxInFlightExecutions: 1, MaxQueuedJobs: 2, Shedding: SheddingRejectLowestPriority,
+		})
+		started := make(chan string, 10)
+		inNamespace := func(job model.Job, namespace string) model.Job {
+			job.Metadata.Namespace = namespace
+			return job
+		}
+		admitAndEnqueue(t, ceiling, inNamespace(ceilingTestJob("running", 1, 0), "a"), started)
+		admitAndEnqueue(t, ceiling, inNamespace(ceilingTestJob("a-low", 1, 0), "a"), started)
+		admitAndEnqueue(t, ceiling, inNamespace(ceilingTestJob("b-mid", 1, 1), "b"), started)
+
+		_, err := ceiling.Admit(inNamespace(ceilingTestJob("c-huge", 1, 1000), "c"))
+		require.ErrorAs(t, err, &ErrOverloaded{}, "jobs of other namespaces are not shed")
+		_, err = ceiling.Admit(inNamespace(ceilingTestJob("b-low", 1, 0), "b"))
+		require.ErrorAs(t, err, &ErrOverloaded{})
+
+		admission := admitAndEnqueue(t, ceiling, inNamespace(ceilingTestJob("b-high", 1, 2), "b"), started)
+		require.Equal(t, "b-mid", admission.Shed, "the lowest priority job of the namespace is shed")
+	})
+
+	t.Run("reject-lowest-priority puts back the shed job if the job is not submitted", func(t *testing.T) {
+		ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{
+			MaxInFlightExecutions: 1, MaxQueuedJobs: 1, Shedding: SheddingRejectLowestPriority,
+		})
+		started := make(chan string, 10)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("running", 1, 0), started)
+		admitAndEnqueue(t, ceiling, ceilingTestJob("low", 1, 1), started)
+
+		admission, err := ceiling.Admit(ceilingTestJob("high", 1, 3))
+		require.NoError(t, err)
+		require.Equal(t, "low", admission.Shed)
+		ceiling.Reject("high", admission)
+
+		_, queued := ceiling.InFlight()
+		require.Equal(t, 1, queued)
+		ceiling.Release("running")
+		requireStarted(t, started, "low")
+	})
+}
+
+func TestConcurrencyCeilingRestore(t *testing.T) {
+	ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 2})
+	started := make(chan string, 10)
+	created := time.Now()
+	waiting := func(id string, age time.Duration) model.Job {
+		job := ceilingTestJob(id, 1, 0)
+		job.Metadata.CreatedAt = created.Add(-age)
+		return job
+	}
+
+	ceiling.Restore(
+		[]model.Job{ceilingTestJob("running", 1, 0)},
+		[]model.Job{waiting("second", time.Minute), waiting("first", time.Hour)},
+		func(job model.Job) { started <- job.ID() },
+	)
+	requireStarted(t, started, "first")
+	executions, queued := ceiling.InFlight()
+	require.Equal(t, 2, executions)
+	require.Equal(t, 1, queued)
+
+	ceiling.Release("running")
+	requireStarted(t, started, "second")
+}
+
+func TestConcurrencyCeilingConfig(t *testing.T) {
+	for name, policy := range map[string]SheddingPolicy{
+		"":                       SheddingQueue,
+		"queue":                  SheddingQueue,
+		"reject":                 SheddingReject,
+		"reject-lowest-priority": SheddingRejectLowestPriority,
+	} {
+		parsed, err := ParseSheddingPolicy(name)
+		require.NoError(t, err)
+		require.Equal(t, policy, parsed)
+	}
+	_, err := ParseSheddingPolicy("drop")
+	require.Error(t, err)
+
+	require.False(t, ConcurrencyCeilingConfig{}.Enabled())
+	require.NoError(t, ConcurrencyCeilingConfig{MaxInFlightExecutions: 10}.Validate())
+	require.Error(t, ConcurrencyCeilingConfig{MaxInFlightExecutions: 10, Shedding: SheddingRejectLowestPriority}.Validate())
+	require.NoError(t, ConcurrencyCeilingConfig{
+		MaxInFlightExecutions: 10, Shedding: SheddingRejectLowestPriority, MaxQueuedJobs: 10,
+	}.Validate())
+}
+
+func TestEndpointQueuesJobsPastConcurrencyCeiling(t *testing.T) {
+	ctx := context.Background()
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	ceiling := NewConcurrencyCeiling(ConcurrencyCeilingConfig{MaxInFlightExecutions: 2, MaxQueuedJobs: 1})
+	var store jobstore.Store
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		store = NewConcurrencyCeilingStore(params.Store, ceiling)
+		params.Store = store
+		params.ConcurrencyCeiling = ceiling
+	})
+
+	spec := model.Spec{Deal: model.Deal{Concurrency: 1}}
+	first, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.NoError(t, err)
+	_, err = endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.NoError(t, err)
+	queued, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.NoError(t, err)
+
+	state, err := store.GetJobState(ctx, queued.ID())
+	require.NoError(t, err)
+	require.Equal(t, model.JobStateQueued, state.State)
+
+	_, err = endpoint.SubmitJob(ctx, model.JobCreatePayload{Spec: &spec})
+	require.ErrorAs(t, err, &ErrOverloaded{})
+
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    first.ID(),
+		NewState: model.JobStateCompleted,
+	}))
+	require.Eventually(t, func() bool {
+		state, err := store.GetJobState(ctx, queued.ID())
+		return err == nil && state.State == model.JobStateInProgress
+	}, 5*time.Second, 10*time.Millisecond)
+}
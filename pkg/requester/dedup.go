@@ -0,0 +1,100 @@
+package requester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// submissionDeduplicator remembers the jobs created for recently submitted specs, so that identical specs submitted
+// by a client within a window of each other can be coalesced into a single job.
+type submissionDeduplicator struct {
+	window time.Duration
+	mu     sync.Mutex
+	jobs   map[string]dedupEntry
+}
+
+type dedupEntry struct {
+	jobID     string
+	expiresAt time.Time
+	// settled is closed once the job was created, or failed to be
+	settled chan struct{}
+}
+
+// settle closes the settled channel of the entry, if it is not closed yet. It is called with the lock held.
+func (e dedupEntry) settle() {
+	select {
+	case <-e.settled:
+	default:
+		close(e.settled)
+	}
+}
+
+func newSubmissionDeduplicator(window time.Duration) *submissionDeduplicator {
+	return &submissionDeduplicator{
+		window: window,
+		jobs:   make(map[string]dedupEntry),
+	}
+}
+
+// specHash identifies submissions of the same spec by the same client.
+func specHash(data model.JobCreatePayload) (string, error) {
+	// submissions are only coalesced for the client that made them, so that no client is handed the job, and so the
+	// results, of another. Submissions naming their job are never coalesced with those of another name
+	encoded, err := json.Marshal(struct {
+		APIVersion string
+		Spec       *model.Spec
+		ClientID   string
+		Namespace  string
+		Name       string `json:",omitempty"`
+	}{data.APIVersion, data.Spec, data.ClientID, data.Namespace, data.Name})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// reserve returns the job of an identical spec submitted within the window if there is one, with a channel that is
+// closed once that job was created or failed to be. Otherwise it remembers jobID as the job of the spec, so that
+// submissions racing with the creation of the job wait for it and are coalesced into it.
+func (d *submissionDeduplicator) reserve(hash string, jobID string, now time.Time) (string, <-chan struct{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for h, entry := range d.jobs {
+		if !now.Before(entry.expiresAt) {
+			entry.settle()
+			delete(d.jobs, h)
+		}
+	}
+	if entry, ok := d.jobs[hash]; ok {
+		return entry.jobID, entry.settled, true
+	}
+	entry := dedupEntry{jobID: jobID, expiresAt: now.Add(d.window), settled: make(chan struct{})}
+	d.jobs[hash] = entry
+	return jobID, entry.settled, false
+}
+
+// created wakes up the submissions waiting for the job of the spec to be created.
+func (d *submissionDeduplicator) created(hash string, jobID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.jobs[hash]; ok && entry.jobID == jobID {
+		entry.settle()
+	}
+}
+
+// release forgets the job of the spec, if it is still the one remembered, so that later submissions create a new job.
+func (d *submissionDeduplicator) release(hash string, jobID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.jobs[hash]; ok && entry.jobID == jobID {
+		entry.settle()
+		delete(d.jobs, hash)
+	}
+}
@@ -5,16 +5,20 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slices"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/jobtransform"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
@@ -35,6 +39,31 @@ type BaseEndpointParams struct {
 	MinJobExecutionTimeout     time.Duration
 	DefaultJobExecutionTimeout time.Duration
 	GetBiddingCallback         func() *url.URL
+	// MaxJobExecutionTimeout is the longest the timeout of a job can be extended to. Timeouts can be extended
+	// without limit if zero.
+	MaxJobExecutionTimeout time.Duration
+	// DedupWindow is how long after a spec is submitted that submissions of an identical spec by the same client are
+	// given the same job, instead of creating a new one. Submissions are not deduplicated if zero.
+	DedupWindow time.Duration
+	// Reservations resolves the capacity reservations jobs are submitted with. Jobs can't be submitted with a
+	// reservation if nil.
+	Reservations jobtransform.ReservationResolver
+	// CircuitBreaker rejects submissions of specs whose jobs keep failing. Submissions are never rejected if nil.
+	CircuitBreaker *CircuitBreaker
+	// NodeDiscoverer lists the known nodes, so that jobs requesting more resources than any of them can ever give are
+	// rejected when submitted. Jobs are not checked against the nodes if nil.
+	NodeDiscoverer NodeDiscoverer
+	// Sharding partitions the ownership of jobs among the requesters sharing the cluster. The requester only creates
+	// jobs it owns. Jobs are not sharded if it is not enabled.
+	Sharding ShardingConfig
+	// ConcurrencyCeiling limits the number of executions orchestrated at once. Executions are not limited if nil.
+	ConcurrencyCeiling *ConcurrencyCeiling
+	// PinImageDigests resolves the tags of the images of docker jobs to digests when they are submitted, so that the
+	// job stored and run is pinned to the image the tag pointed to at submission.
+	PinImageDigests bool
+	// AutoSplitInputs submits jobs whose inputs don't fit on any node as several jobs that each fit, rather than
+	// rejecting them with a suggestion of how to split them.
+	AutoSplitInputs bool
 }
 
 // BaseEndpoint base implementation of requester Endpoint
@@ -46,6 +75,15 @@ type BaseEndpoint struct {
 	selector   bidstrategy.SemanticBidStrategy
 	callback   func() *url.URL
 	transforms []jobtransform.Transformer
+	dedup      *submissionDeduplicator
+	breaker    *CircuitBreaker
+	nodes      NodeDiscoverer
+	sharding   ShardingConfig
+	ceiling    *ConcurrencyCeiling
+	storages   storage.StorageProvider
+	sizer      *inputSizer
+	autoSplit  bool
+	maxTimeout time.Duration
 }
 
 func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
@@ -54,8 +92,24 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		jobtransform.NewTimeoutApplier(params.MinJobExecutionTimeout, params.DefaultJobExecutionTimeout),
 		jobtransform.NewRequesterInfo(params.ID, params.PublicKey),
 		jobtransform.RepoExistsOnIPFS(params.StorageProviders),
+		jobtransform.NewIPNSNameResolver(params.StorageProviders),
+		jobtransform.URLInputsExist(params.StorageProviders),
 		jobtransform.NewPublisherMigrator(),
-		// jobtransform.DockerImageDigest(),
+		jobtransform.NewReservationResolver(params.Reservations),
+		jobtransform.NewDangerousEnvStripper(),
+	}
+	if params.PinImageDigests {
+		transforms = append(transforms, jobtransform.DockerImageDigest())
+	}
+
+	var dedup *submissionDeduplicator
+	if params.DedupWindow > 0 {
+		dedup = newSubmissionDeduplicator(params.DedupWindow)
+	}
+
+	var sizer *inputSizer
+	if params.StorageProviders != nil {
+		sizer = newInputSizer(params.StorageProviders)
 	}
 
 	return &BaseEndpoint{
@@ -66,15 +120,80 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		store:      params.Store,
 		transforms: transforms,
 		callback:   params.GetBiddingCallback,
+		dedup:      dedup,
+		breaker:    params.CircuitBreaker,
+		nodes:      params.NodeDiscoverer,
+		sharding:   params.Sharding,
+		ceiling:    params.ConcurrencyCeiling,
+		storages:   params.StorageProviders,
+		sizer:      sizer,
+		autoSplit:  params.AutoSplitInputs,
+		maxTimeout: params.MaxJobExecutionTimeout,
+	}
+}
+
+// newJobID returns a random job ID owned by this requester, which takes as many tries on average as there are
+// requesters sharing the cluster.
+func (node *BaseEndpoint) newJobID() (string, error) {
+	for {
+		jobUUID, err := uuid.NewRandom()
+		if err != nil {
+			return "", err
+		}
+		if jobID := jobUUID.String(); node.sharding.Owns(jobID) {
+			return jobID, nil
+		}
 	}
 }
 
-func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePayload) (*model.Job, error) {
-	jobUUID, err := uuid.NewRandom()
+func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePayload) (job *model.Job, err error) {
+	// names are only unique among the jobs of a requester, so each name is only given by the requester that owns it
+	if data.Name != "" && !node.sharding.OwnsName(data.Name) {
+		return &model.Job{}, fmt.Errorf("jobs named %q are created by requester %s",
+			data.Name, node.sharding.Peers[node.sharding.ShardOfName(data.Name)])
+	}
+	jobID, err := node.newJobID()
 	if err != nil {
 		return &model.Job{}, fmt.Errorf("error creating job id: %w", err)
 	}
-	jobID := jobUUID.String()
+
+	var hash string
+	if node.dedup != nil || node.breaker != nil {
+		hash, err = specHash(data)
+		if err != nil {
+			return &model.Job{}, fmt.Errorf("error hashing job spec: %w", err)
+		}
+	}
+	if node.breaker != nil {
+		if err = node.breaker.Allow(hash, time.Now()); err != nil {
+			return &model.Job{}, err
+		}
+	}
+
+	if node.dedup != nil {
+		for {
+			existingID, settled, found := node.dedup.reserve(hash, jobID, time.Now())
+			if !found {
+				break
+			}
+			// a submission racing with this one may still be creating the job
+			select {
+			case <-settled:
+			case <-ctx.Done():
+				return &model.Job{}, ctx.Err()
+			}
+			if existing, ok := node.coalesce(ctx, existingID, data.ClientID); ok {
+				return existing, nil
+			}
+			// the existing job can't be shared, so it is forgotten and this submission's job tries to take its place
+			node.dedup.release(hash, existingID)
+		}
+		defer func() {
+			if err != nil {
+				node.dedup.release(hash, jobID)
+			}
+		}()
+	}
 
 	// Creates a new root context to track a job's lifecycle for tracing. This
 	// should be fine as only one node will call SubmitJob(...) - the other
@@ -96,12 +215,15 @@ func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePay
 	// ctx, span := system.NewRootSpan(ctx, system.GetTracer(), "pkg/controller.SubmitJob")
 	// defer span.End()
 
-	job := &model.Job{
+	job = &model.Job{
 		APIVersion: data.APIVersion,
 		Metadata: model.Metadata{
-			ID:        jobID,
-			ClientID:  data.ClientID,
-			CreatedAt: time.Now(),
+			ID:          jobID,
+			ClientID:    data.ClientID,
+			CreatedAt:   time.Now(),
+			SpecVersion: 1,
+			Namespace:   data.Namespace,
+			Name:        data.Name,
 		},
 		Spec: *data.Spec,
 	}
@@ -113,27 +235,216 @@ func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePay
 		}
 	}
 
+	var splitJobIDs []string
+	if node.nodes != nil {
+		nodes, err := node.nodes.ListNodes(ctx)
+		if err != nil {
+			return job, err
+		}
+		if err = checkMaxCapacity(nodes, job.Spec); err != nil {
+			return job, err
+		}
+		if err = checkInputSizes(ctx, nodes, node.sizer, job.Spec); err != nil {
+			if splitJobIDs, err = node.splitJob(ctx, data, job, err); err != nil {
+				return job, err
+			}
+		}
+	}
+	// the jobs split from this one only run along with it
+	defer func() {
+		if err != nil {
+			node.cancelSplitJobs(ctx, splitJobIDs, err)
+		}
+	}()
+
+	// jobs past the concurrency ceiling wait in the queue or are rejected, depending on the shedding policy
+	var admission Admission
+	if node.ceiling != nil {
+		admission, err = node.ceiling.Admit(*job)
+		if err != nil {
+			return job, err
+		}
+		defer func() {
+			if err != nil {
+				node.ceiling.Reject(jobID, admission)
+			}
+		}()
+	}
+
 	err = node.store.CreateJob(ctx, *job)
 	if err != nil {
 		return job, err
 	}
+	if node.dedup != nil {
+		node.dedup.created(hash, jobID)
+	}
+	if node.breaker != nil {
+		node.breaker.Track(jobID, hash)
+	}
 
 	err = node.queue.EnqueueJob(ctx, *job)
 	if err != nil {
 		return job, err
 	}
 
-	selectRequest := bidstrategy.BidStrategyRequest{NodeID: node.id, Job: *job}
+	if admission.Shed != "" {
+		node.shed(ctx, admission.Shed, job.Spec.Priority)
+		// the shed job is cancelled, so it must not be put back in the queue from now on
+		admission = Admission{Wait: admission.Wait}
+	}
+	if admission.Wait {
+		queued := *job
+		node.ceiling.Enqueue(queued, func() { node.startQueuedJob(queued) })
+		return job, nil
+	}
+
+	return job, node.startJob(ctx, *job)
+}
+
+// splitJob runs the job with the first group of inputs suggested by the error when its inputs don't fit on any node,
+// and submits a job for each of the other groups, if the requester splits jobs automatically. The jobs are annotated
+// with the ID of the first one, so that they can be listed together, and the IDs of the jobs submitted are returned.
+// The error is returned otherwise, or if any of the jobs fails to be submitted, in which case the jobs already
+// submitted are cancelled.
+func (node *BaseEndpoint) splitJob(ctx context.Context, data model.JobCreatePayload, job *model.Job, err error) ([]string, error) {
+	var tooLarge ErrInputsTooLarge
+	if !node.autoSplit || !errors.As(err, &tooLarge) {
+		return nil, err
+	}
+	specs, ok := splitInputs(job.Spec, tooLarge)
+	if !ok {
+		return nil, err
+	}
+
+	annotation := splitAnnotationPrefix + job.ID()
+	var submitted []string
+	for _, spec := range specs[1:] {
+		spec := spec
+		spec.Annotations = append(append([]string{}, spec.Annotations...), annotation)
+		sibling, submitErr := node.SubmitJob(ctx, model.JobCreatePayload{
+			ClientID:   data.ClientID,
+			APIVersion: data.APIVersion,
+			Namespace:  data.Namespace,
+			Spec:       &spec,
+		})
+		if submitErr != nil {
+			submitErr = fmt.Errorf("submitting the jobs the inputs were split across: %w", submitErr)
+			node.cancelSplitJobs(ctx, submitted, submitErr)
+			return nil, submitErr
+		}
+		submitted = append(submitted, sibling.ID())
+		log.Ctx(ctx).Debug().Str("JobID", job.ID()).Str("SplitJobID", sibling.ID()).Msg("submitted job split from job")
+	}
+	job.Spec = specs[0]
+	job.Spec.Annotations = append(append([]string{}, job.Spec.Annotations...), annotation)
+	log.Ctx(ctx).Info().Str("JobID", job.ID()).Msgf("split the inputs of job across %d jobs so that they fit on the nodes", len(specs))
+	return submitted, nil
+}
+
+// cancelSplitJobs cancels the jobs split from a job that failed to be submitted, so that they don't run without it.
+func (node *BaseEndpoint) cancelSplitJobs(ctx context.Context, jobIDs []string, submitErr error) {
+	for _, jobID := range jobIDs {
+		_, err := node.CancelJob(ctx, CancelJobRequest{
+			JobID:  jobID,
+			Reason: fmt.Sprintf("the job it was split from failed to be submitted: %s", submitErr),
+		})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("JobID", jobID).Msg("failed to cancel job split from job that failed to be submitted")
+		}
+	}
+}
+
+// shed cancels a queued job to make room under the concurrency ceiling for a job with a higher priority.
+func (node *BaseEndpoint) shed(ctx context.Context, jobID string, priority int) {
+	_, err := node.queue.CancelJob(ctx, CancelJobRequest{
+		JobID:  jobID,
+		Reason: fmt.Sprintf("job shed to make room for a job with a higher priority of %d, as the requester is overloaded", priority),
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("JobID", jobID).Msg("failed to shed queued job")
+	}
+}
+
+// failQueuedJob cancels a queued job that failed to start, so that it doesn't hang, and releases the executions it
+// was counted for under the concurrency ceiling.
+func (node *BaseEndpoint) failQueuedJob(ctx context.Context, jobID string, startErr error) {
+	defer node.ceiling.Release(jobID)
+	_, err := node.queue.CancelJob(ctx, CancelJobRequest{
+		JobID:  jobID,
+		Reason: fmt.Sprintf("failed to start queued job: %s", startErr),
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("JobID", jobID).Msg("failed to cancel queued job that failed to start")
+	}
+}
+
+// RestoreConcurrencyCeiling counts the jobs of this requester that are in progress against the concurrency ceiling,
+// and queues again the jobs that were waiting for room under it when the requester stopped, as the ceiling is only
+// held in memory. Jobs that were waiting are those still new that have no execution.
+func (node *BaseEndpoint) RestoreConcurrencyCeiling(ctx context.Context) error {
+	if node.ceiling == nil {
+		return nil
+	}
+	jobs, err := node.store.GetInProgressJobs(ctx)
+	if err != nil {
+		return err
+	}
+	var started, waiting []model.Job
+	for _, job := range jobs {
+		if job.Job.Metadata.Requester.RequesterNodeID != node.id {
+			continue
+		}
+		if job.State.State == model.JobStateNew && len(job.State.Executions) == 0 {
+			waiting = append(waiting, job.Job)
+		} else {
+			started = append(started, job.Job)
+		}
+	}
+	node.ceiling.Restore(started, waiting, node.startQueuedJob)
+	return nil
+}
+
+// startQueuedJob starts a job that waited for room under the concurrency ceiling, and cancels it if it can't start.
+func (node *BaseEndpoint) startQueuedJob(job model.Job) {
+	ctx := logger.ContextWithNodeIDLogger(context.Background(), node.id)
+	if err := node.startJob(ctx, job); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("JobID", job.ID()).Msg("failed to start queued job")
+		node.failQueuedJob(ctx, job.ID(), err)
+	}
+}
+
+// startJob asks the selector whether the job should run, and starts it if so.
+func (node *BaseEndpoint) startJob(ctx context.Context, job model.Job) error {
+	selectRequest := bidstrategy.BidStrategyRequest{NodeID: node.id, Job: job}
 	if url := node.callback(); url != nil {
 		selectRequest.Callback = url
 	}
 
 	response, err := node.selector.ShouldBid(ctx, selectRequest)
 	if err != nil {
-		return job, err
+		return err
 	}
 
-	return job, node.handleBidResponse(ctx, *job, response)
+	return node.handleBidResponse(ctx, job, response)
+}
+
+// coalesce gives the client the existing job of an identical spec, unless the job failed or was cancelled, in which
+// case the client gets a new job.
+func (node *BaseEndpoint) coalesce(ctx context.Context, jobID string, clientID string) (*model.Job, bool) {
+	jobState, err := node.store.GetJobState(ctx, jobID)
+	if err != nil || jobState.State == model.JobStateError || jobState.State == model.JobStateCancelled {
+		return nil, false
+	}
+	if err = node.store.AddJobWatcher(ctx, jobID, clientID); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("JobID", jobID).Msg("failed to add watcher to job")
+		return nil, false
+	}
+	job, err := node.store.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, false
+	}
+	log.Ctx(ctx).Debug().Str("JobID", jobID).Str("ClientID", clientID).Msg("coalesced submission into existing job")
+	return &job, true
 }
 
 func (node *BaseEndpoint) ApproveJob(ctx context.Context, approval bidstrategy.ModerateJobRequest) error {
@@ -170,6 +481,116 @@ func (node *BaseEndpoint) CancelJob(ctx context.Context, request CancelJobReques
 	return node.queue.CancelJob(ctx, request)
 }
 
+func (node *BaseEndpoint) MigrateExecutions(ctx context.Context, request MigrateExecutionsRequest) (MigrateExecutionsResult, error) {
+	return node.queue.MigrateExecutions(ctx, request)
+}
+
+func (node *BaseEndpoint) UpdateJob(ctx context.Context, request UpdateJobRequest) (UpdateJobResult, error) {
+	job, err := node.store.GetJob(ctx, request.JobID)
+	if err != nil {
+		return UpdateJobResult{}, err
+	}
+	if job.Metadata.SpecVersion != request.SpecVersion {
+		return UpdateJobResult{}, jobstore.NewErrInvalidJobSpecVersion(job.ID(), job.Metadata.SpecVersion, request.SpecVersion)
+	}
+
+	// the spec is shared with the job returned by the store, so the slices it holds are copied before being changed
+	spec := job.Spec
+	var changes []string
+	if len(request.AddAnnotations) > 0 || len(request.RemoveAnnotations) > 0 {
+		annotations := make([]string, 0, len(spec.Annotations)+len(request.AddAnnotations))
+		for _, annotation := range spec.Annotations {
+			if !slices.Contains(request.RemoveAnnotations, annotation) {
+				annotations = append(annotations, annotation)
+			}
+		}
+		for _, annotation := range request.AddAnnotations {
+			if !slices.Contains(annotations, annotation) {
+				annotations = append(annotations, annotation)
+			}
+		}
+		spec.Annotations = annotations
+		changes = append(changes, fmt.Sprintf("annotations %v", annotations))
+	}
+	if request.Timeout != 0 {
+		// executions already running were started with the previous timeout, so it can only be extended
+		if request.Timeout < spec.Timeout {
+			return UpdateJobResult{}, fmt.Errorf("timeout of job %s can only be extended, from %v seconds", job.ID(), spec.Timeout)
+		}
+		if node.maxTimeout > 0 && request.Timeout > node.maxTimeout.Seconds() {
+			return UpdateJobResult{}, fmt.Errorf("timeout of job %s cannot be extended beyond the maximum allowed %s",
+				job.ID(), node.maxTimeout)
+		}
+		spec.Timeout = request.Timeout
+		changes = append(changes, fmt.Sprintf("timeout %v seconds", spec.Timeout))
+	}
+	if request.MaxRetries != nil {
+		if *request.MaxRetries < 0 {
+			return UpdateJobResult{}, fmt.Errorf("max retries of job %s cannot be negative", job.ID())
+		}
+		spec.MaxRetries = request.MaxRetries
+		changes = append(changes, fmt.Sprintf("max retries %d", *spec.MaxRetries))
+	}
+	if request.MaxRetriesPerShard != nil {
+		if *request.MaxRetriesPerShard < 0 {
+			return UpdateJobResult{}, fmt.Errorf("max retries per shard of job %s cannot be negative", job.ID())
+		}
+		spec.MaxRetriesPerShard = request.MaxRetriesPerShard
+		changes = append(changes, fmt.Sprintf("max retries per shard %d", *spec.MaxRetriesPerShard))
+	}
+	if len(changes) == 0 {
+		return UpdateJobResult{Job: job}, nil
+	}
+
+	err = node.store.UpdateJobSpec(ctx, jobstore.UpdateJobSpecRequest{
+		JobID:               job.ID(),
+		ExpectedSpecVersion: request.SpecVersion,
+		NewSpec:             spec,
+		Comment:             "Job spec updated: " + strings.Join(changes, ", "),
+	})
+	if err != nil {
+		return UpdateJobResult{}, err
+	}
+
+	job, err = node.store.GetJob(ctx, job.ID())
+	if err != nil {
+		return UpdateJobResult{}, err
+	}
+	if request.Timeout != 0 {
+		node.extendTimeouts(ctx, job)
+	}
+	return UpdateJobResult{Job: job}, nil
+}
+
+// extendTimeouts sends the extended timeout of the job to the compute nodes of its executions that have yet to finish
+// running, as they enforce the timeout from their own copy of the spec. Executions created from now on are given
+// the extended timeout with the spec.
+func (node *BaseEndpoint) extendTimeouts(ctx context.Context, job model.Job) {
+	jobState, err := node.store.GetJobState(ctx, job.ID())
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("failed to extend timeout of executions of job %s", job.ID())
+		return
+	}
+	for _, execution := range jobState.Executions {
+		switch execution.State {
+		case model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted, model.ExecutionStateBidAccepted:
+		default:
+			continue
+		}
+		_, err = node.computesvc.ExtendTimeout(ctx, compute.ExtendTimeoutRequest{
+			RoutingMetadata: compute.RoutingMetadata{
+				SourcePeerID: node.id,
+				TargetPeerID: execution.NodeID,
+			},
+			ExecutionID: execution.ComputeReference,
+			Timeout:     job.Spec.Timeout,
+		})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to extend timeout of execution %s on node %s", execution.ComputeReference, execution.NodeID)
+		}
+	}
+}
+
 func (node *BaseEndpoint) ReadLogs(ctx context.Context, request ReadLogsRequest) (ReadLogsResponse, error) {
 	emptyResponse := ReadLogsResponse{}
 
@@ -5,7 +5,9 @@ package requester
 import (
 	"context"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/eventhandler"
@@ -36,7 +38,7 @@ func (m *mockBidStrategy) ShouldBidBasedOnUsage(context.Context, bidstrategy.Bid
 
 var _ bidstrategy.BidStrategy = (*mockBidStrategy)(nil)
 
-func getTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy) (Endpoint, jobstore.Store) {
+func getTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy, options ...func(*BaseEndpointParams)) (Endpoint, jobstore.Store) {
 	cm := system.NewCleanupManager()
 	t.Cleanup(func() { cm.Cleanup(context.Background()) })
 
@@ -60,14 +62,18 @@ func getTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy) (Endpoint,
 			return nil
 		}),
 	})
-	endpoint := NewBaseEndpoint(&BaseEndpointParams{
+	params := &BaseEndpointParams{
 		Queue:              NewQueue(store, scheduler, emitter),
 		Selector:           strategy,
 		Store:              store,
 		Verifiers:          model.NewNoopProvider[model.Verifier, verifier.Verifier](verifier_mock),
 		StorageProviders:   model.NewNoopProvider[model.StorageSourceType, storage.Storage](storage_mock),
 		GetBiddingCallback: func() *url.URL { return nil },
-	})
+	}
+	for _, option := range options {
+		option(params)
+	}
+	endpoint := NewBaseEndpoint(params)
 
 	return endpoint, store
 }
@@ -153,3 +159,188 @@ func TestEndpointAcceptsApprovals(t *testing.T) {
 		runTest(t, true, model.JobStateQueued)
 	})
 }
+
+func TestEndpointUpdatesRunningJob(t *testing.T) {
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	computeEndpoint := &recordingComputeEndpoint{}
+	endpoint, store := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.ComputeEndpoint = computeEndpoint
+		params.MaxJobExecutionTimeout = time.Hour
+	})
+
+	job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{
+		Spec: &model.Spec{Timeout: 600, Annotations: []string{"a", "b"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, job.Metadata.SpecVersion)
+
+	for _, execution := range []model.ExecutionState{
+		{NodeID: "running-node", ComputeReference: "running", State: model.ExecutionStateBidAccepted},
+		{NodeID: "failed-node", ComputeReference: "failed", State: model.ExecutionStateFailed},
+	} {
+		execution.JobID = job.ID()
+		require.NoError(t, store.CreateExecution(context.Background(), execution))
+	}
+
+	maxRetries := 3
+	result, err := endpoint.UpdateJob(context.Background(), UpdateJobRequest{
+		JobID:             job.ID(),
+		SpecVersion:       1,
+		AddAnnotations:    []string{"c", "a"},
+		RemoveAnnotations: []string{"b"},
+		Timeout:           1200,
+		MaxRetries:        &maxRetries,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Job.Metadata.SpecVersion)
+	require.Equal(t, []string{"a", "c"}, result.Job.Spec.Annotations)
+	require.Equal(t, float64(1200), result.Job.Spec.Timeout)
+	require.Equal(t, 3, *result.Job.Spec.MaxRetries)
+
+	// only the compute node still running the job is told about the extended timeout
+	require.Len(t, computeEndpoint.extended, 1)
+	require.Equal(t, "running-node", computeEndpoint.extended[0].TargetPeerID)
+	require.Equal(t, "running", computeEndpoint.extended[0].ExecutionID)
+	require.Equal(t, float64(1200), computeEndpoint.extended[0].Timeout)
+
+	state, err := store.GetJobState(context.Background(), job.ID())
+	require.NoError(t, err)
+	require.Equal(t, model.JobStateInProgress, state.State)
+
+	t.Run("rejects stale spec version", func(t *testing.T) {
+		_, err := endpoint.UpdateJob(context.Background(), UpdateJobRequest{JobID: job.ID(), SpecVersion: 1, Timeout: 1800})
+		require.ErrorAs(t, err, &jobstore.ErrInvalidJobSpecVersion{})
+	})
+
+	t.Run("rejects shortening the timeout", func(t *testing.T) {
+		_, err := endpoint.UpdateJob(context.Background(), UpdateJobRequest{JobID: job.ID(), SpecVersion: 2, Timeout: 60})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects extending the timeout beyond the maximum", func(t *testing.T) {
+		_, err := endpoint.UpdateJob(context.Background(), UpdateJobRequest{JobID: job.ID(), SpecVersion: 2, Timeout: 3601})
+		require.Error(t, err)
+	})
+}
+
+func TestEndpointCoalescesIdenticalSubmissions(t *testing.T) {
+	ctx := context.Background()
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, store := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.DedupWindow = time.Minute
+	})
+	submit := func(clientID string, spec model.Spec) *model.Job {
+		job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{ClientID: clientID, Spec: &spec})
+		require.NoError(t, err)
+		return job
+	}
+
+	first := submit("alice", model.Spec{Annotations: []string{"popular"}})
+	second := submit("alice", model.Spec{Annotations: []string{"popular"}})
+	require.Equal(t, first.ID(), second.ID())
+	require.Equal(t, "alice", second.Metadata.ClientID)
+	require.Empty(t, second.Metadata.Watchers)
+
+	different := submit("alice", model.Spec{Annotations: []string{"other"}})
+	require.NotEqual(t, first.ID(), different.ID())
+
+	// the job of a client is never handed to another
+	other := submit("bob", model.Spec{Annotations: []string{"popular"}})
+	require.NotEqual(t, first.ID(), other.ID())
+	require.Equal(t, "bob", other.Metadata.ClientID)
+
+	// a job that can no longer complete is not shared, and the new job takes its place
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    first.ID(),
+		NewState: model.JobStateCancelled,
+	}))
+	third := submit("alice", model.Spec{Annotations: []string{"popular"}})
+	require.NotEqual(t, first.ID(), third.ID())
+	require.Equal(t, third.ID(), submit("alice", model.Spec{Annotations: []string{"popular"}}).ID())
+}
+
+func TestEndpointCoalescesRacingSubmissions(t *testing.T) {
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.DedupWindow = time.Minute
+	})
+
+	const submissions = 10
+	ids := make(chan string, submissions)
+	var wg sync.WaitGroup
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{
+				ClientID: "alice",
+				Spec:     &model.Spec{Annotations: []string{"racing"}},
+			})
+			require.NoError(t, err)
+			ids <- job.ID()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := map[string]bool{}
+	for id := range ids {
+		unique[id] = true
+	}
+	require.Len(t, unique, 1)
+}
+
+func TestEndpointDoesNotCoalesceByDefault(t *testing.T) {
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{Spec: &model.Spec{}})
+		require.NoError(t, err)
+		ids = append(ids, job.ID())
+	}
+	require.NotEqual(t, ids[0], ids[1])
+}
+
+func TestSubmissionDeduplicatorExpires(t *testing.T) {
+	now := time.Now()
+	dedup := newSubmissionDeduplicator(time.Minute)
+
+	jobID, _, found := dedup.reserve("hash", "job-1", now)
+	require.False(t, found)
+	require.Equal(t, "job-1", jobID)
+
+	jobID, settled, found := dedup.reserve("hash", "job-2", now.Add(30*time.Second))
+	require.True(t, found)
+	require.Equal(t, "job-1", jobID)
+	select {
+	case <-settled:
+		require.Fail(t, "settled before the job was created")
+	default:
+	}
+	dedup.created("hash", "job-1")
+	<-settled
+
+	jobID, _, found = dedup.reserve("hash", "job-3", now.Add(time.Minute))
+	require.False(t, found)
+	require.Equal(t, "job-3", jobID)
+
+	// releasing a job that is no longer remembered for the spec leaves the current one in place
+	dedup.release("hash", "job-1")
+	jobID, _, found = dedup.reserve("hash", "job-4", now.Add(time.Minute))
+	require.True(t, found)
+	require.Equal(t, "job-3", jobID)
+}
+
+func TestEndpointStripsDangerousEnvironmentVariables(t *testing.T) {
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy)
+
+	job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{Spec: &model.Spec{
+		Engine: model.EngineDocker,
+		Docker: model.JobSpecDocker{EnvironmentVariables: []string{"LD_PRELOAD=/evil.so", "HELLO=world"}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"HELLO=world"}, job.Spec.Docker.EnvironmentVariables)
+}
@@ -2,8 +2,13 @@ package requester
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/exp/maps"
 )
 
 // ErrNotEnoughNodes is returned when not enough nodes in the network to run a job
@@ -47,3 +52,169 @@ func NewErrJobAlreadyTerminal(jobID string) ErrJobAlreadyTerminal {
 func (e ErrJobAlreadyTerminal) Error() string {
 	return fmt.Errorf("job %s is already in a terminal state", e.JobID).Error()
 }
+
+// ErrCircuitOpen is returned when a spec is submitted while its circuit is open, because too many of its jobs failed.
+type ErrCircuitOpen struct {
+	Failures int
+	Ended    int
+	// Until is when submissions of the spec are accepted again.
+	Until       time.Time
+	LastFailure string
+}
+
+func NewErrCircuitOpen(failures, ended int, until time.Time, lastFailure string) ErrCircuitOpen {
+	return ErrCircuitOpen{Failures: failures, Ended: ended, Until: until, LastFailure: lastFailure}
+}
+
+func (e ErrCircuitOpen) Error() string {
+	return fmt.Sprintf(
+		"rejecting job because %d of the last %d jobs with the same spec failed, most recently with: %s. "+
+			"Fix the spec, or wait until %s to submit it again",
+		e.Failures, e.Ended, e.LastFailure, e.Until.UTC().Format(time.RFC3339),
+	)
+}
+
+// ErrOverloaded is returned when a job is submitted while the requester is orchestrating as many executions as its
+// concurrency ceiling allows, and the job can't wait for some of them to end.
+type ErrOverloaded struct {
+	InFlight int
+	Max      int
+}
+
+func NewErrOverloaded(inFlight, max int) ErrOverloaded {
+	return ErrOverloaded{InFlight: inFlight, Max: max}
+}
+
+func (e ErrOverloaded) Error() string {
+	return fmt.Sprintf("rejecting job because the requester is orchestrating %d executions, at its ceiling of %d. "+
+		"Submit the job again later", e.InFlight, e.Max)
+}
+
+// UnsupportedRequirement describes a job requirement, such as an execution engine,
+// that not enough compute nodes in the network support.
+type UnsupportedRequirement struct {
+	// Kind is the type of requirement, e.g. engine, verifier or publisher.
+	Kind string
+	// Required is the value the job asked for.
+	Required string
+	// SupportingNodes are the nodes that support the required value.
+	SupportingNodes []string
+	// Available maps every value supported by the network to the nodes supporting it.
+	Available map[string][]string
+}
+
+// ErrUnsupportedJobRequirements is returned when the job requires an engine, verifier or
+// publisher that not enough compute nodes in the network support.
+type ErrUnsupportedJobRequirements struct {
+	RequestedNodes int
+	Requirements   []UnsupportedRequirement
+}
+
+func NewErrUnsupportedJobRequirements(requestedNodes int, requirements []UnsupportedRequirement) ErrUnsupportedJobRequirements {
+	return ErrUnsupportedJobRequirements{
+		RequestedNodes: requestedNodes,
+		Requirements:   requirements,
+	}
+}
+
+func (e ErrUnsupportedJobRequirements) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "not enough nodes to run job. requested: %d, unsupported requirements:", e.RequestedNodes)
+	for _, requirement := range e.Requirements {
+		fmt.Fprintf(&sb, "\n  %s %q is supported by %d node(s)", requirement.Kind, requirement.Required, len(requirement.SupportingNodes))
+		if len(requirement.SupportingNodes) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(requirement.SupportingNodes, ", "))
+		}
+
+		values := maps.Keys(requirement.Available)
+		if len(values) == 0 {
+			fmt.Fprintf(&sb, "; no connected node reports any supported %ss", requirement.Kind)
+			continue
+		}
+		sort.Strings(values)
+		available := make([]string, 0, len(values))
+		for _, value := range values {
+			available = append(available, fmt.Sprintf("%s (%s)", value, strings.Join(requirement.Available[value], ", ")))
+		}
+		fmt.Fprintf(&sb, "; available %ss: %s", requirement.Kind, strings.Join(available, ", "))
+	}
+	return sb.String()
+}
+
+// ErrImpossibleResources is returned when a job requests more of a resource than any known compute node can ever give
+// a single job, so no node would ever bid on it.
+type ErrImpossibleResources struct {
+	Resource  string
+	Requested string
+	// Largest is the most of the resource that any of the known nodes can give a single job.
+	Largest string
+	Nodes   int
+}
+
+func NewErrImpossibleResources(resource, requested, largest string, nodes int) ErrImpossibleResources {
+	return ErrImpossibleResources{Resource: resource, Requested: requested, Largest: largest, Nodes: nodes}
+}
+
+func (e ErrImpossibleResources) Error() string {
+	return fmt.Sprintf("no node can ever satisfy %s %s: the most any of the %d known compute node(s) can give a job is %s",
+		e.Requested, e.Resource, e.Nodes, e.Largest)
+}
+
+// InputSize is the size of an input of a job.
+type InputSize struct {
+	Input string `json:"Input"`
+	Size  uint64 `json:"Size"`
+}
+
+// ShardingSuggestion is how to split the inputs of a job across jobs that each fit on a node.
+type ShardingSuggestion struct {
+	// Jobs are the inputs of each job to submit instead.
+	Jobs [][]string `json:"Jobs"`
+	// TooLarge are the inputs that don't fit on any node on their own, and have to be split within themselves.
+	TooLarge []string `json:"TooLarge,omitempty"`
+}
+
+// ErrInputsTooLarge is returned when the inputs of a job take more disk than any of the known compute nodes with enough
+// memory for the job gives a single job, so that the job would never be scheduled.
+type ErrInputsTooLarge struct {
+	Total uint64 `json:"Total"`
+	// Largest is the most disk that any of the known nodes with enough memory for the job gives a single job.
+	Largest uint64 `json:"Largest"`
+	// Memory is the memory the job requests, which the nodes are limited to. Zero if the job does not request any.
+	Memory     uint64             `json:"Memory,omitempty"`
+	Nodes      int                `json:"Nodes"`
+	Inputs     []InputSize        `json:"Inputs"`
+	Suggestion ShardingSuggestion `json:"Suggestion"`
+}
+
+func NewErrInputsTooLarge(total, largest, memory uint64, nodes int, inputs []InputSize) ErrInputsTooLarge {
+	return ErrInputsTooLarge{
+		Total:      total,
+		Largest:    largest,
+		Memory:     memory,
+		Nodes:      nodes,
+		Inputs:     inputs,
+		Suggestion: suggestSharding(inputs, largest),
+	}
+}
+
+func (e ErrInputsTooLarge) Error() string {
+	var sb strings.Builder
+	withMemory := ""
+	if e.Memory > 0 {
+		withMemory = fmt.Sprintf(" with the %s of memory the job requests", datasize.ByteSize(e.Memory).HR())
+	}
+	fmt.Fprintf(&sb, "the inputs of the job take %s, but the most disk any of the %d known compute node(s)%s can give a job is %s.",
+		datasize.ByteSize(e.Total).HR(), e.Nodes, withMemory, datasize.ByteSize(e.Largest).HR())
+	if len(e.Suggestion.Jobs) > 1 {
+		fmt.Fprintf(&sb, " Split the inputs across %d jobs:", len(e.Suggestion.Jobs))
+		for i, inputs := range e.Suggestion.Jobs {
+			fmt.Fprintf(&sb, "\n  job %d: %s", i+1, strings.Join(inputs, ", "))
+		}
+	}
+	if len(e.Suggestion.TooLarge) > 0 {
+		fmt.Fprintf(&sb, "\n  too large for any node on their own, split them within themselves: %s",
+			strings.Join(e.Suggestion.TooLarge, ", "))
+	}
+	return sb.String()
+}
@@ -2,6 +2,8 @@ package requester
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
@@ -60,6 +62,8 @@ func (e EventEmitter) EmitBidReceived(
 	// we flip senders to mimic a bid was received instead of being asked
 	event.SourceNodeID = result.RoutingMetadata.SourcePeerID
 	event.TargetNodeID = "" // localdb don't assume a target node for events coming from compute nodes
+	// accepted bids can carry warnings from the compute node, such as vulnerabilities found in the job's image
+	event.Status = result.Reason
 	e.EmitEventSilently(ctx, event)
 }
 
@@ -110,6 +114,49 @@ func (e EventEmitter) EmitPublishComplete(ctx context.Context, response compute.
 	e.EmitEventSilently(ctx, event)
 }
 
+func (e EventEmitter) EmitResultsExpired(ctx context.Context, execution model.ExecutionState) {
+	routingMetadata := compute.RoutingMetadata{
+		SourcePeerID: execution.NodeID,
+	}
+	executionMetadata := compute.ExecutionMetadata{
+		JobID:       execution.JobID,
+		ExecutionID: execution.ComputeReference,
+	}
+	event := e.constructEvent(routingMetadata, executionMetadata, model.JobEventResultsExpired)
+	event.PublishedResult = execution.PublishedResult
+	event.Status = fmt.Sprintf("results expired at %s", execution.PublishedResult.ExpiresAt.Format(time.RFC3339))
+	e.EmitEventSilently(ctx, event)
+}
+
+// EmitExecutionMigrated records that the requester canceled an execution to move it away from its compute node.
+func (e EventEmitter) EmitExecutionMigrated(ctx context.Context, requesterID string, execution model.ExecutionState, status string) {
+	routingMetadata := compute.RoutingMetadata{
+		SourcePeerID: requesterID,
+		TargetPeerID: execution.NodeID,
+	}
+	executionMetadata := compute.ExecutionMetadata{
+		JobID:       execution.JobID,
+		ExecutionID: execution.ComputeReference,
+	}
+	event := e.constructEvent(routingMetadata, executionMetadata, model.JobEventMigrated)
+	event.Status = status
+	e.EmitEventSilently(ctx, event)
+}
+
+// EmitResidencyEnforced records that the requester excluded compute nodes outside the data residency of the job from
+// running it, for the job history to show where the job was not allowed to run.
+func (e EventEmitter) EmitResidencyEnforced(ctx context.Context, job model.Job, excluded []string) {
+	event := model.JobEvent{
+		JobID:        job.Metadata.ID,
+		SourceNodeID: job.Metadata.Requester.RequesterNodeID,
+		EventName:    model.JobEventResidencyEnforced,
+		Status: fmt.Sprintf("excluded %d node(s) outside residency %s: %s",
+			len(excluded), strings.Join(job.Spec.Residency, ", "), strings.Join(excluded, ", ")),
+		EventTime: time.Now(),
+	}
+	e.EmitEventSilently(ctx, event)
+}
+
 func (e EventEmitter) EmitComputeFailure(ctx context.Context, executionID model.ExecutionID, err error) {
 	// incoming error routing metadata
 	routingMetadata := compute.RoutingMetadata{
@@ -120,7 +167,11 @@ func (e EventEmitter) EmitComputeFailure(ctx context.Context, executionID model.
 		JobID:       executionID.JobID,
 		ExecutionID: executionID.ExecutionID,
 	}
-	event := e.constructEvent(routingMetadata, executionMetadata, model.JobEventComputeError)
+	eventName := model.JobEventComputeError
+	if computeError, ok := err.(compute.ComputeError); ok && computeError.Preempted {
+		eventName = model.JobEventPreempted
+	}
+	event := e.constructEvent(routingMetadata, executionMetadata, eventName)
 	event.Status = err.Error()
 	e.EmitEventSilently(ctx, event)
 }
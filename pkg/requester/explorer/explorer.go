@@ -0,0 +1,304 @@
+// Package explorer serves a read-only view of a requester node that is safe to expose without authentication, so that
+// operators can run public status pages and network explorers off a production requester. It serves aggregate stats
+// of the jobs, the most recent public jobs with their fields redacted by a policy, and counts of the compute nodes.
+package explorer
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/routing"
+)
+
+const (
+	// DefaultMaxJobs is the number of recent public jobs listed, unless configured otherwise.
+	DefaultMaxJobs = 50
+	// DefaultCacheTTL is how long responses are served from the cache, unless configured otherwise, which bounds the
+	// load anonymous callers can put on the requester.
+	DefaultCacheTTL = 30 * time.Second
+)
+
+// Config configures the explorer endpoints of a requester node.
+type Config struct {
+	// Enabled serves the explorer endpoints without authentication.
+	Enabled bool
+	// JobSelectors are the label selectors, such as visibility=public, of the jobs that are public. No job is public
+	// if empty, so that jobs are only exposed once operators choose which.
+	JobSelectors []string
+	// MaxJobs is the number of recent public jobs listed. DefaultMaxJobs if zero.
+	MaxJobs int
+	// Policy is how the fields of the listed jobs are redacted. DefaultPolicy if nil.
+	Policy Policy
+	// CacheTTL is how long responses are served from the cache. DefaultCacheTTL if zero.
+	CacheTTL time.Duration
+	// RedactionSecret keys the hashes of the redacted fields, so that they can't be reversed by hashing guesses of
+	// their values. A random secret is used if empty, which changes the hashes when the node restarts.
+	RedactionSecret []byte
+}
+
+// Stats are aggregate stats of the public jobs.
+type Stats struct {
+	Jobs         int            `json:"Jobs"`
+	JobsByState  map[string]int `json:"JobsByState"`
+	JobsByEngine map[string]int `json:"JobsByEngine"`
+	GeneratedAt  time.Time      `json:"GeneratedAt"`
+}
+
+// Job is a public job, with the fields that the policy hides left empty.
+type Job struct {
+	ID                   string    `json:"ID,omitempty"`
+	ClientID             string    `json:"ClientID,omitempty"`
+	Namespace            string    `json:"Namespace,omitempty"`
+	CreatedAt            time.Time `json:"CreatedAt"`
+	UpdatedAt            time.Time `json:"UpdatedAt"`
+	State                string    `json:"State"`
+	Engine               string    `json:"Engine"`
+	Image                string    `json:"Image,omitempty"`
+	Entrypoint           []string  `json:"Entrypoint,omitempty"`
+	EnvironmentVariables []string  `json:"EnvironmentVariables,omitempty"`
+	Inputs               []string  `json:"Inputs,omitempty"`
+	Outputs              []string  `json:"Outputs,omitempty"`
+	Annotations          []string  `json:"Annotations,omitempty"`
+	NodeIDs              []string  `json:"NodeIDs,omitempty"`
+}
+
+// NodeStats are counts of the compute nodes known to the requester.
+type NodeStats struct {
+	ComputeNodes       int            `json:"ComputeNodes"`
+	NodesByEngine      map[string]int `json:"NodesByEngine"`
+	RunningExecutions  int            `json:"RunningExecutions"`
+	EnqueuedExecutions int            `json:"EnqueuedExecutions"`
+	GeneratedAt        time.Time      `json:"GeneratedAt"`
+}
+
+type ExplorerParams struct {
+	JobStore jobstore.Store
+	// NodeInfoStore lists the compute nodes. No nodes are counted if nil.
+	NodeInfoStore routing.NodeInfoStore
+	Config        Config
+}
+
+// Explorer computes the responses of the explorer endpoints, and caches them.
+type Explorer struct {
+	jobStore      jobstore.Store
+	nodeInfoStore routing.NodeInfoStore
+	selectors     []jobstore.LabelSelector
+	maxJobs       int
+	policy        Policy
+	secret        []byte
+
+	stats cache[Stats]
+	jobs  cache[[]Job]
+	nodes cache[NodeStats]
+}
+
+func NewExplorer(params ExplorerParams) (*Explorer, error) {
+	var selectors []jobstore.LabelSelector
+	for _, value := range params.Config.JobSelectors {
+		selector, err := jobstore.ParseLabelSelector(value)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	maxJobs := params.Config.MaxJobs
+	if maxJobs <= 0 {
+		maxJobs = DefaultMaxJobs
+	}
+	policy := params.Config.Policy
+	if policy == nil {
+		policy = DefaultPolicy
+	}
+	ttl := params.Config.CacheTTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	secret := params.Config.RedactionSecret
+	if len(secret) == 0 {
+		secret = make([]byte, 32) //nolint:gomnd
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return &Explorer{
+		jobStore:      params.JobStore,
+		nodeInfoStore: params.NodeInfoStore,
+		selectors:     selectors,
+		maxJobs:       maxJobs,
+		policy:        policy,
+		secret:        secret,
+		stats:         cache[Stats]{ttl: ttl},
+		jobs:          cache[[]Job]{ttl: ttl},
+		nodes:         cache[NodeStats]{ttl: ttl},
+	}, nil
+}
+
+// Stats returns aggregate stats of the public jobs.
+func (e *Explorer) Stats(ctx context.Context) (Stats, error) {
+	return e.stats.get(func() (Stats, error) {
+		stats := Stats{
+			JobsByState:  make(map[string]int),
+			JobsByEngine: make(map[string]int),
+			GeneratedAt:  time.Now(),
+		}
+		if len(e.selectors) == 0 {
+			return stats, nil
+		}
+		result, err := e.jobStore.SearchJobs(ctx, jobstore.JobSearchQuery{Labels: e.selectors, ReturnAll: true})
+		if err != nil {
+			return Stats{}, err
+		}
+		stats.Jobs = result.Total
+		for _, job := range result.Jobs {
+			state, err := e.jobStore.GetJobState(ctx, job.ID())
+			if err != nil {
+				return Stats{}, err
+			}
+			stats.JobsByState[state.State.String()]++
+			stats.JobsByEngine[job.Spec.Engine.String()]++
+		}
+		return stats, nil
+	})
+}
+
+// Jobs returns the most recent public jobs, redacted by the policy.
+func (e *Explorer) Jobs(ctx context.Context) ([]Job, error) {
+	return e.jobs.get(func() ([]Job, error) {
+		if len(e.selectors) == 0 {
+			return []Job{}, nil
+		}
+		result, err := e.jobStore.SearchJobs(ctx, jobstore.JobSearchQuery{
+			Labels:    e.selectors,
+			ReturnAll: true,
+			Limit:     e.maxJobs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		jobs := make([]Job, 0, len(result.Jobs))
+		for _, job := range result.Jobs {
+			state, err := e.jobStore.GetJobState(ctx, job.ID())
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, e.policy.RedactJob(job, state, e.secret))
+		}
+		return jobs, nil
+	})
+}
+
+// Nodes returns counts of the compute nodes known to the requester.
+func (e *Explorer) Nodes(ctx context.Context) (NodeStats, error) {
+	return e.nodes.get(func() (NodeStats, error) {
+		stats := NodeStats{NodesByEngine: make(map[string]int), GeneratedAt: time.Now()}
+		if e.nodeInfoStore == nil {
+			return stats, nil
+		}
+		nodeInfos, err := e.nodeInfoStore.List(ctx)
+		if err != nil {
+			return NodeStats{}, err
+		}
+		for _, nodeInfo := range nodeInfos {
+			if !nodeInfo.IsComputeNode() || nodeInfo.ComputeNodeInfo == nil {
+				continue
+			}
+			stats.ComputeNodes++
+			stats.RunningExecutions += nodeInfo.ComputeNodeInfo.RunningExecutions
+			stats.EnqueuedExecutions += nodeInfo.ComputeNodeInfo.EnqueuedExecutions
+			for _, engine := range nodeInfo.ComputeNodeInfo.ExecutionEngines {
+				stats.NodesByEngine[engine.String()]++
+			}
+		}
+		return stats, nil
+	})
+}
+
+// RedactJob returns the public view of the job, with its fields redacted by the policy and hashed with the secret.
+func (p Policy) RedactJob(job model.Job, state model.JobState, secret []byte) Job {
+	spec := job.Spec
+	var entrypoint []string
+	switch spec.Engine {
+	case model.EngineDocker:
+		entrypoint = spec.Docker.Entrypoint
+	case model.EngineProcess:
+		entrypoint = spec.Process.Entrypoint
+	case model.EngineWasm:
+		entrypoint = []string{spec.Wasm.EntryPoint}
+	}
+	var image string
+	if spec.Engine == model.EngineDocker {
+		image = spec.Docker.Image
+	}
+	var inputs, outputs, nodeIDs []string
+	for _, input := range spec.Inputs {
+		inputs = append(inputs, describeStorage(input))
+	}
+	for _, output := range spec.Outputs {
+		outputs = append(outputs, output.Name)
+	}
+	for _, execution := range state.Executions {
+		nodeIDs = append(nodeIDs, execution.NodeID)
+	}
+
+	return Job{
+		ID:        p.redact(FieldJobID, job.ID(), secret),
+		ClientID:  p.redact(FieldClientID, job.Metadata.ClientID, secret),
+		Namespace: p.redact(FieldNamespace, job.Metadata.Namespace, secret),
+		CreatedAt: job.Metadata.CreatedAt,
+		UpdatedAt: state.UpdateTime,
+		State:     state.State.String(),
+		Engine:    spec.Engine.String(),
+		Image:     p.redact(FieldImage, image, secret),
+		// the values of environment variables are never shown, as they are where secrets are usually passed
+		EnvironmentVariables: p.redactAll(FieldEnvironment, spec.EnvironmentVariableNames(), secret),
+		Entrypoint:           p.redactAll(FieldEntrypoint, entrypoint, secret),
+		Inputs:               p.redactAll(FieldInputs, inputs, secret),
+		Outputs:              p.redactAll(FieldOutputs, outputs, secret),
+		Annotations:          p.redactAll(FieldAnnotations, spec.Annotations, secret),
+		NodeIDs:              p.redactAll(FieldNodeIDs, nodeIDs, secret),
+	}
+}
+
+// describeStorage returns where an input comes from.
+func describeStorage(spec model.StorageSpec) string {
+	switch {
+	case spec.CID != "":
+		return "ipfs://" + spec.CID
+	case spec.URL != "":
+		return spec.URL
+	case spec.Repo != "":
+		return spec.Repo
+	case spec.S3 != nil:
+		return "s3://" + spec.S3.Bucket + "/" + spec.S3.Key
+	default:
+		return spec.StorageSource.String()
+	}
+}
+
+// cache holds a response for its ttl.
+type cache[T any] struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	value     T
+	expiresAt time.Time
+}
+
+// get returns the cached value, or computes it if it expired. Errors are not cached.
+func (c *cache[T]) get(compute func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expiresAt) {
+		return c.value, nil
+	}
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+	c.value = value
+	c.expiresAt = time.Now().Add(c.ttl)
+	return value, nil
+}
@@ -0,0 +1,141 @@
+//go:build unit || !integration
+
+package explorer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type ExplorerSuite struct {
+	suite.Suite
+	ctx   context.Context
+	store jobstore.Store
+}
+
+func TestExplorerSuite(t *testing.T) {
+	suite.Run(t, new(ExplorerSuite))
+}
+
+func (s *ExplorerSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = inmemory.NewJobStore()
+}
+
+func (s *ExplorerSuite) createJob(id string, createdAt time.Time, annotations ...string) {
+	s.Require().NoError(s.store.CreateJob(s.ctx, model.Job{
+		Metadata: model.Metadata{ID: id, ClientID: "client-" + id, CreatedAt: createdAt},
+		Spec: model.Spec{
+			Engine:      model.EngineDocker,
+			Docker:      model.JobSpecDocker{Image: "ubuntu", Entrypoint: []string{"echo", "secret"}},
+			Annotations: annotations,
+		},
+	}))
+}
+
+func (s *ExplorerSuite) newExplorer(config Config) *Explorer {
+	explorer, err := NewExplorer(ExplorerParams{JobStore: s.store, Config: config})
+	s.Require().NoError(err)
+	return explorer
+}
+
+func (s *ExplorerSuite) TestOnlyPublicJobs() {
+	now := time.Now()
+	s.createJob("old", now.Add(-time.Hour), "visibility=public")
+	s.createJob("new", now, "visibility=public")
+	s.createJob("private", now)
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    "new",
+		NewState: model.JobStateCompleted,
+	}))
+	explorer := s.newExplorer(Config{JobSelectors: []string{"visibility=public"}, MaxJobs: 1})
+
+	stats, err := explorer.Stats(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(2, stats.Jobs)
+	s.Equal(map[string]int{"Completed": 1, "New": 1}, stats.JobsByState)
+	s.Equal(map[string]int{"Docker": 2}, stats.JobsByEngine)
+
+	jobs, err := explorer.Jobs(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(jobs, 1)
+	s.Equal("Completed", jobs[0].State)
+	s.Equal("ubuntu", jobs[0].Image)
+	s.NotEqual("new", jobs[0].ID, "job IDs are hashed by default")
+	s.Len(jobs[0].ID, hashLength)
+	s.Empty(jobs[0].Entrypoint, "entrypoints are hidden by default")
+	s.Empty(jobs[0].Annotations)
+}
+
+func (s *ExplorerSuite) TestNoPublicJobsWithoutSelectors() {
+	s.createJob("job", time.Now(), "visibility=public")
+	explorer := s.newExplorer(Config{})
+
+	stats, err := explorer.Stats(s.ctx)
+	s.Require().NoError(err)
+	s.Zero(stats.Jobs)
+	jobs, err := explorer.Jobs(s.ctx)
+	s.Require().NoError(err)
+	s.Empty(jobs)
+}
+
+func (s *ExplorerSuite) TestCachesResponses() {
+	s.createJob("first", time.Now(), "visibility=public")
+	explorer := s.newExplorer(Config{JobSelectors: []string{"visibility=public"}, CacheTTL: time.Hour})
+	stats, err := explorer.Stats(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(1, stats.Jobs)
+
+	s.createJob("second", time.Now(), "visibility=public")
+	stats, err = explorer.Stats(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(1, stats.Jobs)
+}
+
+func (s *ExplorerSuite) TestNodesWithoutStore() {
+	stats, err := s.newExplorer(Config{}).Nodes(s.ctx)
+	s.Require().NoError(err)
+	s.Zero(stats.ComputeNodes)
+}
+
+func (s *ExplorerSuite) TestInvalidSelector() {
+	_, err := NewExplorer(ExplorerParams{JobStore: s.store, Config: Config{JobSelectors: []string{"=value"}}})
+	s.Error(err)
+}
+
+func (s *ExplorerSuite) TestParsePolicy() {
+	policy, err := ParsePolicy([]string{"entrypoint=show", "image=hash", "node-ids=hide"})
+	s.Require().NoError(err)
+	s.Equal(RedactionShow, policy[FieldEntrypoint])
+	s.Equal(RedactionHash, policy[FieldImage])
+	s.Equal(RedactionHash, policy[FieldClientID], "other fields keep their default")
+	s.Equal(RedactionShow, DefaultPolicy[FieldImage], "the default policy is not changed")
+
+	spec := model.Spec{
+		Engine: model.EngineDocker,
+		Docker: model.JobSpecDocker{Image: "ubuntu", Entrypoint: []string{"echo"}},
+	}
+	job := policy.RedactJob(model.Job{Spec: spec}, model.JobState{Executions: []model.ExecutionState{{NodeID: "node"}}}, []byte("secret"))
+	s.Equal([]string{"echo"}, job.Entrypoint)
+	s.NotEqual("ubuntu", job.Image)
+	s.NotEmpty(job.Image)
+	s.Empty(job.NodeIDs)
+
+	// hashes depend on the secret of the node, so they can't be reversed without it
+	sameSecret := policy.RedactJob(model.Job{Spec: spec}, model.JobState{}, []byte("secret"))
+	otherSecret := policy.RedactJob(model.Job{Spec: spec}, model.JobState{}, []byte("other secret"))
+	s.Equal(job.Image, sameSecret.Image)
+	s.NotEqual(job.Image, otherSecret.Image)
+
+	for _, invalid := range []string{"image", "unknown=show", "image=blur"} {
+		_, err = ParsePolicy([]string{invalid})
+		s.Error(err, invalid)
+	}
+}
@@ -0,0 +1,129 @@
+package explorer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Redaction is how a field of the jobs listed by the explorer is shown.
+type Redaction string
+
+const (
+	// RedactionShow shows the field as it is.
+	RedactionShow Redaction = "show"
+	// RedactionHash replaces the field with a hash of its value keyed by a secret of the node, so that dashboards can
+	// tell values apart, such as the jobs of the same client, without learning them.
+	RedactionHash Redaction = "hash"
+	// RedactionHide leaves the field out.
+	RedactionHide Redaction = "hide"
+)
+
+// Field is a field of the jobs listed by the explorer that can be redacted.
+type Field string
+
+const (
+	FieldJobID       Field = "job-id"
+	FieldClientID    Field = "client-id"
+	FieldNamespace   Field = "namespace"
+	FieldImage       Field = "image"
+	FieldEntrypoint  Field = "entrypoint"
+	FieldEnvironment Field = "environment"
+	FieldInputs      Field = "inputs"
+	FieldOutputs     Field = "outputs"
+	FieldAnnotations Field = "annotations"
+	FieldNodeIDs     Field = "node-ids"
+)
+
+// Fields are the fields that can be redacted.
+var Fields = []Field{
+	FieldJobID,
+	FieldClientID,
+	FieldNamespace,
+	FieldImage,
+	FieldEntrypoint,
+	FieldEnvironment,
+	FieldInputs,
+	FieldOutputs,
+	FieldAnnotations,
+	FieldNodeIDs,
+}
+
+// hashLength is the number of hex characters kept from the hashes of redacted values.
+const hashLength = 16
+
+// Policy is how each field of the jobs listed by the explorer is redacted. Fields that are not in the policy are
+// hidden.
+type Policy map[Field]Redaction
+
+// DefaultPolicy only shows what a public status page needs, and hashes the identifiers that let jobs be grouped.
+var DefaultPolicy = Policy{
+	FieldJobID:       RedactionHash,
+	FieldClientID:    RedactionHash,
+	FieldNamespace:   RedactionHide,
+	FieldImage:       RedactionShow,
+	FieldEntrypoint:  RedactionHide,
+	FieldEnvironment: RedactionHide,
+	FieldInputs:      RedactionHide,
+	FieldOutputs:     RedactionHide,
+	FieldAnnotations: RedactionHide,
+	FieldNodeIDs:     RedactionHash,
+}
+
+// ParsePolicy returns the default policy with the redactions given as FIELD=REDACTION, such as image=hash.
+func ParsePolicy(values []string) (Policy, error) {
+	policy := make(Policy, len(DefaultPolicy))
+	for field, redaction := range DefaultPolicy {
+		policy[field] = redaction
+	}
+	for _, value := range values {
+		field, redaction, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid explorer redaction %q, expected FIELD=REDACTION", value)
+		}
+		if !slices.Contains(Fields, Field(field)) {
+			return nil, fmt.Errorf("invalid explorer redaction %q: unknown field %s, expected one of %s", value, field, Fields)
+		}
+		switch Redaction(redaction) {
+		case RedactionShow, RedactionHash, RedactionHide:
+			policy[Field(field)] = Redaction(redaction)
+		default:
+			return nil, fmt.Errorf("invalid explorer redaction %q: expected show, hash or hide", value)
+		}
+	}
+	return policy, nil
+}
+
+// redact returns the value as the policy shows the field, or an empty string if the field is hidden. Values are
+// hashed with an HMAC keyed by the secret, as short values such as IDs could otherwise be found by hashing guesses.
+func (p Policy) redact(field Field, value string, secret []byte) string {
+	switch p[field] {
+	case RedactionShow:
+		return value
+	case RedactionHash:
+		if value == "" {
+			return ""
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+	default:
+		return ""
+	}
+}
+
+// redactAll returns the values as the policy shows the field, or nil if the field is hidden.
+func (p Policy) redactAll(field Field, values []string, secret []byte) []string {
+	if p[field] != RedactionShow && p[field] != RedactionHash {
+		return nil
+	}
+	redacted := make([]string, 0, len(values))
+	for _, value := range values {
+		redacted = append(redacted, p.redact(field, value, secret))
+	}
+	return redacted
+}
@@ -10,17 +10,21 @@ import (
 )
 
 type HousekeepingParams struct {
-	Endpoint Endpoint
-	JobStore jobstore.Store
-	NodeID   string
-	Interval time.Duration
+	Endpoint     Endpoint
+	JobStore     jobstore.Store
+	EventEmitter EventEmitter
+	NodeID       string
+	Interval     time.Duration
 }
 
 type Housekeeping struct {
-	endpoint Endpoint
-	jobStore jobstore.Store
-	nodeID   string
-	interval time.Duration
+	endpoint     Endpoint
+	jobStore     jobstore.Store
+	eventEmitter EventEmitter
+	nodeID       string
+	interval     time.Duration
+	// startedAt is when each in progress job started on the monotonic clock of this node
+	startedAt map[string]time.Time
 
 	stopChannel chan struct{}
 	stopOnce    sync.Once
@@ -28,11 +32,13 @@ type Housekeeping struct {
 
 func NewHousekeeping(params HousekeepingParams) *Housekeeping {
 	h := &Housekeeping{
-		endpoint:    params.Endpoint,
-		jobStore:    params.JobStore,
-		nodeID:      params.NodeID,
-		interval:    params.Interval,
-		stopChannel: make(chan struct{}),
+		endpoint:     params.Endpoint,
+		jobStore:     params.JobStore,
+		eventEmitter: params.EventEmitter,
+		nodeID:       params.NodeID,
+		interval:     params.Interval,
+		startedAt:    make(map[string]time.Time),
+		stopChannel:  make(chan struct{}),
 	}
 
 	go h.housekeepingBackgroundTask()
@@ -42,34 +48,12 @@ func NewHousekeeping(params HousekeepingParams) *Housekeeping {
 func (h *Housekeeping) housekeepingBackgroundTask() {
 	ctx := context.Background()
 	ticker := time.NewTicker(h.interval)
+	lastExpiryCheck := time.Now()
 	for {
 		select {
 		case <-ticker.C:
-			jobs, err := h.jobStore.GetInProgressJobs(ctx)
-			if err != nil {
-				log.Ctx(ctx).Err(err).Msg("failed to get in progress jobs")
-				continue
-			}
-			now := time.Now()
-			for _, jobDescription := range jobs {
-				// in case the job store is shared between multiple nodes, we only want to clean up jobs that are owned by this node
-				if jobDescription.Job.Metadata.Requester.RequesterNodeID != h.nodeID {
-					continue
-				}
-				// cancel jobs that have been in progress beyond the timeout period
-				if now.Sub(jobDescription.State.CreateTime).Seconds() > jobDescription.Job.Spec.Timeout {
-					log.Ctx(ctx).Info().Msgf("job %s timed out. Canceling", jobDescription.Job.Metadata.ID)
-					go func(jobID string) {
-						_, innerErr := h.endpoint.CancelJob(ctx, CancelJobRequest{
-							JobID:  jobID,
-							Reason: "timed out",
-						})
-						if innerErr != nil {
-							log.Ctx(ctx).Err(innerErr).Msgf("failed to cancel job %s", jobID)
-						}
-					}(jobDescription.Job.Metadata.ID)
-				}
-			}
+			lastExpiryCheck = h.emitExpiredResults(ctx, lastExpiryCheck)
+			h.cancelTimedOutJobs(ctx, time.Now())
 		case <-h.stopChannel:
 			log.Ctx(ctx).Debug().Msg("stopped housekeeping task")
 			ticker.Stop()
@@ -78,6 +62,88 @@ func (h *Housekeeping) housekeepingBackgroundTask() {
 	}
 }
 
+// cancelTimedOutJobs cancels this node's jobs that have been in progress beyond their timeout.
+func (h *Housekeeping) cancelTimedOutJobs(ctx context.Context, now time.Time) {
+	jobs, err := h.jobStore.GetInProgressJobs(ctx)
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to get in progress jobs")
+		return
+	}
+	inProgress := make(map[string]struct{}, len(jobs))
+	for _, jobDescription := range jobs {
+		// in case the job store is shared between multiple nodes, we only want to clean up jobs that are owned by this node
+		if jobDescription.Job.Metadata.Requester.RequesterNodeID != h.nodeID {
+			continue
+		}
+		jobID := jobDescription.Job.Metadata.ID
+		inProgress[jobID] = struct{}{}
+
+		// cancel jobs that have been in progress beyond the timeout period
+		if h.jobAge(jobID, jobDescription.State.CreateTime, now).Seconds() > jobDescription.Job.Spec.Timeout {
+			log.Ctx(ctx).Info().Msgf("job %s timed out. Canceling", jobID)
+			go func(jobID string) {
+				_, innerErr := h.endpoint.CancelJob(ctx, CancelJobRequest{
+					JobID:  jobID,
+					Reason: "timed out",
+				})
+				if innerErr != nil {
+					log.Ctx(ctx).Err(innerErr).Msgf("failed to cancel job %s", jobID)
+				}
+			}(jobID)
+		}
+	}
+	for jobID := range h.startedAt {
+		if _, ok := inProgress[jobID]; !ok {
+			delete(h.startedAt, jobID)
+		}
+	}
+}
+
+// jobAge returns how long a job has been in progress. The wall clock is only read the first time the job is seen, to
+// find when it was created, and the age is measured on the monotonic clock of this node from then on. This way a wall
+// clock that jumps forward while the job is in progress cannot time the job out early.
+func (h *Housekeeping) jobAge(jobID string, createTime time.Time, now time.Time) time.Duration {
+	startedAt, ok := h.startedAt[jobID]
+	if !ok {
+		age := now.Sub(createTime)
+		if age < 0 {
+			age = 0
+		}
+		startedAt = now.Add(-age)
+		h.startedAt[jobID] = startedAt
+	}
+	return now.Sub(startedAt)
+}
+
+// emitExpiredResults emits an event for each published result of this node's jobs that expired since the last check,
+// and returns the time of this check.
+func (h *Housekeeping) emitExpiredResults(ctx context.Context, lastCheck time.Time) time.Time {
+	now := time.Now()
+	jobs, err := h.jobStore.GetJobs(ctx, jobstore.JobQuery{ReturnAll: true})
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to get jobs")
+		return lastCheck
+	}
+	for _, j := range jobs {
+		if j.Metadata.Requester.RequesterNodeID != h.nodeID {
+			continue
+		}
+		jobState, err := h.jobStore.GetJobState(ctx, j.ID())
+		if err != nil {
+			log.Ctx(ctx).Err(err).Msgf("failed to get state of job %s", j.ID())
+			continue
+		}
+		for _, execution := range jobState.Executions {
+			expiresAt := execution.PublishedResult.ExpiresAt
+			if expiresAt != nil && expiresAt.After(lastCheck) && !expiresAt.After(now) {
+				log.Ctx(ctx).Debug().Msgf("results of execution %s expired", execution.ComputeReference)
+				h.eventEmitter.EmitResultsExpired(ctx, execution)
+			}
+		}
+	}
+	return now
+}
+
 func (h *Housekeeping) Stop() {
 	h.stopOnce.Do(func() {
 		h.stopChannel <- struct{}{}
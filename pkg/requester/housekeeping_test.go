@@ -0,0 +1,25 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHousekeepingJobAge(t *testing.T) {
+	h := &Housekeeping{startedAt: make(map[string]time.Time)}
+	now := time.Now()
+
+	// the age is first taken from the creation time of the job
+	require.Equal(t, time.Minute, h.jobAge("job", now.Add(-time.Minute), now))
+
+	// and is then measured from when the job was first seen, whatever the creation time says
+	require.Equal(t, time.Minute+time.Second, h.jobAge("job", now.Add(-time.Hour), now.Add(time.Second)))
+
+	// a creation time in the future does not make the job older than it is
+	require.Equal(t, time.Duration(0), h.jobAge("future", now.Add(time.Hour), now))
+	require.Equal(t, time.Second, h.jobAge("future", now.Add(time.Hour), now.Add(time.Second)))
+}
@@ -0,0 +1,158 @@
+package requester
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
+)
+
+const (
+	// inputSizeWait bounds how long sizing the inputs of a job can delay its submission. Inputs that are not sized in
+	// time are left out of the check, and keep being sized in the background so that later submissions are checked.
+	inputSizeWait = 2 * time.Second
+	// inputSizeTimeout bounds how long an input is sized for in the background.
+	inputSizeTimeout = 30 * time.Second
+	// inputSizeCacheTTL is how long the size of an input is remembered for.
+	inputSizeCacheTTL = 10 * time.Minute
+	// maxCachedInputSizes is the most input sizes remembered at once.
+	maxCachedInputSizes = 10000
+)
+
+// inputSizer sizes the inputs of submitted jobs through their storage providers, without holding up submissions for
+// longer than they can wait. Sizes are cached, so that inputs submitted again are not sized again.
+type inputSizer struct {
+	storages storage.StorageProvider
+
+	mu      sync.Mutex
+	sizes   map[string]cachedInputSize
+	pending map[string]chan struct{}
+}
+
+type cachedInputSize struct {
+	size    uint64
+	expires time.Time
+}
+
+func newInputSizer(storages storage.StorageProvider) *inputSizer {
+	return &inputSizer{
+		storages: storages,
+		sizes:    make(map[string]cachedInputSize),
+		pending:  make(map[string]chan struct{}),
+	}
+}
+
+// Sizes returns the sizes of the inputs, in the same order, with zero for the inputs that could not be sized within
+// wait.
+func (s *inputSizer) Sizes(ctx context.Context, inputs []model.StorageSpec, wait time.Duration) []uint64 {
+	keys := make([]string, len(inputs))
+	done := make([]<-chan struct{}, len(inputs))
+	for i, input := range inputs {
+		keys[i], done[i] = s.start(ctx, input)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	expired := false
+	sizes := make([]uint64, len(inputs))
+	for i := range inputs {
+		if !expired {
+			select {
+			case <-done[i]:
+			case <-timer.C:
+				expired = true
+			case <-ctx.Done():
+				expired = true
+			}
+		}
+		sizes[i] = s.cached(keys[i])
+	}
+	return sizes
+}
+
+// start sizes the input in the background unless its size is cached or already being sized, and returns its cache
+// key and a channel closed once it is sized.
+func (s *inputSizer) start(ctx context.Context, input model.StorageSpec) (string, <-chan struct{}) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		closed := make(chan struct{})
+		close(closed)
+		return "", closed
+	}
+	key := string(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.sizes[key]; ok && time.Now().Before(cached.expires) {
+		closed := make(chan struct{})
+		close(closed)
+		return key, closed
+	}
+	if pending, ok := s.pending[key]; ok {
+		return key, pending
+	}
+	pending := make(chan struct{})
+	s.pending[key] = pending
+
+	// sizing outlives the submission that started it
+	sizeCtx, cancel := context.WithTimeout(pkgUtil.NewDetachedContext(ctx), inputSizeTimeout)
+	go func() {
+		defer cancel()
+		size := s.size(sizeCtx, input)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if size > 0 {
+			s.evict()
+			s.sizes[key] = cachedInputSize{size: size, expires: time.Now().Add(inputSizeCacheTTL)}
+		}
+		delete(s.pending, key)
+		close(pending)
+	}()
+	return key, pending
+}
+
+func (s *inputSizer) size(ctx context.Context, input model.StorageSpec) uint64 {
+	provider, err := s.storages.Get(ctx, input.StorageSource)
+	if err != nil {
+		return 0
+	}
+	size, err := provider.GetVolumeSize(ctx, input)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Str("Input", inputName(input)).Msg("could not size input")
+		return 0
+	}
+	return size
+}
+
+// cached returns the cached size of the input, or zero if it is not known.
+func (s *inputSizer) cached(key string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sizes[key].size
+}
+
+// evict makes room for a size in the cache, dropping the expired sizes, or any size if none expired. It is called
+// with the lock held.
+func (s *inputSizer) evict() {
+	if len(s.sizes) < maxCachedInputSizes {
+		return
+	}
+	now := time.Now()
+	for key, cached := range s.sizes {
+		if now.After(cached.expires) {
+			delete(s.sizes, key)
+		}
+	}
+	for key := range s.sizes {
+		if len(s.sizes) < maxCachedInputSizes {
+			break
+		}
+		delete(s.sizes, key)
+	}
+}
@@ -0,0 +1,39 @@
+package jobtransform
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// NewDangerousEnvStripper removes the environment variables that change how the dynamic linker loads programs from
+// docker and process jobs, whatever the compute nodes running them allow.
+func NewDangerousEnvStripper() Transformer {
+	return func(ctx context.Context, job *model.Job) (modified bool, err error) {
+		switch job.Spec.Engine {
+		case model.EngineDocker:
+			job.Spec.Docker.EnvironmentVariables, modified = stripDangerousEnv(ctx, job.Spec.Engine, job.Spec.Docker.EnvironmentVariables)
+		case model.EngineProcess:
+			job.Spec.Process.EnvironmentVariables, modified = stripDangerousEnv(ctx, job.Spec.Engine, job.Spec.Process.EnvironmentVariables)
+		}
+		return modified, nil
+	}
+}
+
+// stripDangerousEnv returns the environment variables without the dangerous ones, and whether any was removed.
+func stripDangerousEnv(ctx context.Context, engine model.Engine, envs []string) ([]string, bool) {
+	kept := make([]string, 0, len(envs))
+	for _, env := range envs {
+		if name := model.EnvironmentVariableName(env); model.IsDangerousEnvironmentVariable(name) {
+			log.Ctx(ctx).Warn().Str("Variable", name).Str("Engine", engine.String()).Msg("Stripping dangerous environment variable")
+			continue
+		}
+		kept = append(kept, env)
+	}
+	if len(kept) == len(envs) {
+		return envs, false
+	}
+	return kept, true
+}
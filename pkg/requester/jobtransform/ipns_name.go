@@ -0,0 +1,58 @@
+package jobtransform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// NewIPNSNameResolver resolves the IPNS names and DNSLink domains that IPFS inputs are given by to the CID they point
+// to when the job is submitted, so that every execution of the job reads the same data. The name is kept alongside the
+// CID to record where the data came from. Inputs that already have a CID are left as they are.
+func NewIPNSNameResolver(provider storage.StorageProvider) Transformer {
+	return func(ctx context.Context, j *model.Job) (modified bool, err error) {
+		var resolver storage.NameResolver
+		for i, input := range j.Spec.Inputs {
+			if input.StorageSource != model.StorageSourceIPFS || input.IPNSName == "" || input.CID != "" {
+				continue
+			}
+			if resolver == nil {
+				if resolver, err = nameResolver(ctx, provider); err != nil {
+					return false, err
+				}
+			}
+
+			cid, err := resolver.ResolveName(ctx, input.IPNSName)
+			if err != nil {
+				return false, fmt.Errorf("failed to resolve input %s: %w", input.IPNSName, err)
+			}
+			log.Ctx(ctx).Debug().
+				Str("Name", input.IPNSName).
+				Str("CID", cid).
+				Msg("resolved IPNS name of input")
+
+			j.Spec.Inputs[i].CID = cid
+			modified = true
+		}
+		return modified, nil
+	}
+}
+
+func nameResolver(ctx context.Context, provider storage.StorageProvider) (storage.NameResolver, error) {
+	if provider == nil || !provider.Has(ctx, model.StorageSourceIPFS) {
+		return nil, errors.New("requester can't resolve IPNS names without IPFS storage")
+	}
+	ipfsStorage, err := provider.Get(ctx, model.StorageSourceIPFS)
+	if err != nil {
+		return nil, err
+	}
+	resolver, ok := ipfsStorage.(storage.NameResolver)
+	if !ok {
+		return nil, errors.New("requester's IPFS storage can't resolve IPNS names")
+	}
+	return resolver, nil
+}
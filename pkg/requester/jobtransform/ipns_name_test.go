@@ -0,0 +1,66 @@
+//go:build unit || !integration
+
+package jobtransform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+	"github.com/stretchr/testify/require"
+)
+
+// namedStorage resolves the names it knows of to their CID.
+type namedStorage struct {
+	*noop.NoopStorage
+	names map[string]string
+}
+
+func (s namedStorage) ResolveName(_ context.Context, name string) (string, error) {
+	cid, ok := s.names[name]
+	if !ok {
+		return "", fmt.Errorf("unknown name %s", name)
+	}
+	return cid, nil
+}
+
+func TestIPNSNameResolver(t *testing.T) {
+	provider := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceIPFS: namedStorage{
+			NoopStorage: noop.NewNoopStorage(),
+			names:       map[string]string{"datasets.example.com": "QmLatest"},
+		},
+	})
+	transform := NewIPNSNameResolver(provider)
+
+	j := &model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, IPNSName: "datasets.example.com", SubPath: "2023"},
+		{StorageSource: model.StorageSourceIPFS, IPNSName: "datasets.example.com", CID: "QmPinned"},
+		{StorageSource: model.StorageSourceIPFS, CID: "QmOther"},
+	}}}
+	modified, err := transform(context.Background(), j)
+	require.NoError(t, err)
+	require.True(t, modified)
+	require.Equal(t, model.StorageSpec{
+		StorageSource: model.StorageSourceIPFS, IPNSName: "datasets.example.com", CID: "QmLatest", SubPath: "2023",
+	}, j.Spec.Inputs[0])
+	require.Equal(t, "QmPinned", j.Spec.Inputs[1].CID, "inputs that were already resolved are kept as they are")
+	require.Equal(t, "QmOther", j.Spec.Inputs[2].CID)
+
+	modified, err = transform(context.Background(), j)
+	require.NoError(t, err)
+	require.False(t, modified)
+
+	j = &model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, IPNSName: "missing.example.com"},
+	}}}
+	_, err = transform(context.Background(), j)
+	require.ErrorContains(t, err, "failed to resolve input missing.example.com")
+
+	noIPFS := NewIPNSNameResolver(model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{}))
+	_, err = noIPFS(context.Background(), j)
+	require.ErrorContains(t, err, "without IPFS storage")
+}
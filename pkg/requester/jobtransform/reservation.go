@@ -0,0 +1,32 @@
+package jobtransform
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// ReservationResolver finds the capacity reservation of a client that jobs are submitted with.
+type ReservationResolver interface {
+	ResolveReservation(ctx context.Context, token string, clientID string) (model.CapacityReservation, error)
+}
+
+// NewReservationResolver replaces the reservation token jobs are submitted with by the ID of the reservation, so that
+// the token is not shared with the network. Jobs can only run on the reservations of their client.
+func NewReservationResolver(resolver ReservationResolver) Transformer {
+	return func(ctx context.Context, j *model.Job) (modified bool, err error) {
+		if j.Spec.Reservation == "" {
+			return false, nil
+		}
+		if resolver == nil {
+			return false, errors.New("requester does not take capacity reservations")
+		}
+		reservation, err := resolver.ResolveReservation(ctx, j.Spec.Reservation, j.Metadata.ClientID)
+		if err != nil {
+			return false, err
+		}
+		j.Spec.Reservation = reservation.ID
+		return true, nil
+	}
+}
@@ -0,0 +1,45 @@
+package jobtransform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// urlInputProbeTimeout is how long the requester waits for the server of a URL input to answer.
+const urlInputProbeTimeout = 10 * time.Second
+
+// URLInputsExist checks the URL inputs of the job exist when it is submitted, so that jobs whose inputs are missing
+// are rejected once by the requester rather than by every compute node they are offered to. Only inputs the server
+// says don't exist reject the job, as other errors can be transient and are retried when fetching.
+func URLInputsExist(provider storage.StorageProvider) Transformer {
+	return func(ctx context.Context, j *model.Job) (modified bool, err error) {
+		for _, input := range j.Spec.Inputs {
+			if input.StorageSource != model.StorageSourceURLDownload {
+				continue
+			}
+			if provider == nil || !provider.Has(ctx, model.StorageSourceURLDownload) {
+				return false, nil
+			}
+			urlStorage, err := provider.Get(ctx, model.StorageSourceURLDownload)
+			if err != nil {
+				return false, err
+			}
+
+			probeCtx, cancel := context.WithTimeout(ctx, urlInputProbeTimeout)
+			_, err = storage.Probe(probeCtx, urlStorage, input)
+			cancel()
+			if errors.Is(err, storage.ErrInputNotFound) {
+				return false, fmt.Errorf("input %s is not available: %w", input.URL, err)
+			} else if err != nil {
+				log.Ctx(ctx).Debug().Err(err).Msgf("failed to probe input %s, assuming it is available", input.URL)
+			}
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,50 @@
+//go:build unit || !integration
+
+package jobtransform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLInputsExist(t *testing.T) {
+	var probed []string
+	urlStorage := noop.NewNoopStorageWithConfig(noop.StorageConfig{ExternalHooks: noop.StorageConfigExternalHooks{
+		GetVolumeSize: func(_ context.Context, volume model.StorageSpec) (uint64, error) {
+			probed = append(probed, volume.URL)
+			switch volume.URL {
+			case "http://example.com/missing":
+				return 0, fmt.Errorf("%w: 404 Not Found", storage.ErrInputNotFound)
+			case "http://example.com/flaky":
+				return 0, errors.New("connection reset")
+			}
+			return 1024, nil
+		},
+	}})
+	transform := URLInputsExist(model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceURLDownload: urlStorage,
+	}))
+
+	j := &model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		{StorageSource: model.StorageSourceURLDownload, URL: "http://example.com/data"},
+		{StorageSource: model.StorageSourceURLDownload, URL: "http://example.com/flaky"},
+		{StorageSource: model.StorageSourceIPFS, CID: "QmOther"},
+	}}}
+	modified, err := transform(context.Background(), j)
+	require.NoError(t, err, "inputs that fail to be probed are assumed to be available")
+	require.False(t, modified)
+	require.Equal(t, []string{"http://example.com/data", "http://example.com/flaky"}, probed)
+
+	j.Spec.Inputs = append(j.Spec.Inputs, model.StorageSpec{
+		StorageSource: model.StorageSourceURLDownload, URL: "http://example.com/missing",
+	})
+	_, err = transform(context.Background(), j)
+	require.ErrorIs(t, err, storage.ErrInputNotFound)
+}
@@ -0,0 +1,275 @@
+// Package mqtt bridges a requester node to an MQTT broker, so that fleets of edge devices using MQTT can follow and
+// submit jobs without polling the HTTP API.
+//
+// The topics of the bridge are under a prefix, bacalhau by default:
+//   - <prefix>/jobs/<job-id>/events/<event>: the lifecycle events of jobs, such as Created, BidAccepted or
+//     ResultsPublished, published as JSON
+//   - <prefix>/submit: signed jobs to submit, in the format of the submit endpoint of the API, with the bearer token
+//     of the client as the token field if the requester authenticates its clients
+//   - <prefix>/clients/<client-id>/submissions: the outcome of each job submitted by the client through the bridge
+//
+// The topics of the jobs and clients of a namespace are under <prefix>/namespaces/<namespace> instead, so that the
+// broker can restrict who subscribes to them.
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+)
+
+// DefaultTopicPrefix is the prefix of the topics of the bridge, unless configured otherwise.
+const DefaultTopicPrefix = "bacalhau"
+
+// DefaultQueueSize is how many job events wait to be published, unless configured otherwise. Events are dropped when
+// the queue is full, such as while the broker is unreachable.
+const DefaultQueueSize = 1024
+
+// Config configures the bridge between a requester node and an MQTT broker.
+type Config struct {
+	// Broker is the URL of the broker, such as tcp://broker:1883, ssl://broker:8883 or ws://broker:80/mqtt, with the
+	// username and password of the requester as the user info of the URL if the broker requires them. The bridge is
+	// disabled if empty.
+	Broker string
+	// TopicPrefix is the prefix of the topics of the bridge. DefaultTopicPrefix if empty.
+	TopicPrefix string
+	// QoS is the quality of service messages are published and subscribed to with, which is 0, 1 or 2.
+	QoS byte
+	// AcceptSubmissions submits the signed jobs published to the submit topic of the bridge.
+	AcceptSubmissions bool
+}
+
+// Enabled returns whether the requester is bridged to a broker at all.
+func (c Config) Enabled() bool {
+	return c.Broker != ""
+}
+
+// Client is a connection to an MQTT broker.
+type Client interface {
+	// Publish publishes the payload to the topic.
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe calls the handler with the payload of every message published to the topic.
+	Subscribe(ctx context.Context, topic string, handler func(ctx context.Context, payload []byte)) error
+	// Close disconnects from the broker.
+	Close(ctx context.Context) error
+}
+
+// SubmissionResponse is published to the submissions topic of a client once a job it published to the submit topic
+// is submitted, or fails to be.
+type SubmissionResponse struct {
+	Job   *model.Job `json:"Job,omitempty"`
+	Error string     `json:"Error,omitempty"`
+}
+
+type BridgeParams struct {
+	Client Client
+	// Endpoint submits the jobs published to the submit topic. Submissions are not accepted if nil.
+	Endpoint requester.Endpoint
+	// SpecLimits are the maximum sizes of the parts of the jobs submitted through the bridge.
+	SpecLimits job.SpecLimits
+	// EnvPolicy is the environment variables the jobs submitted through the bridge can set.
+	EnvPolicy model.EnvironmentVariablePolicy
+	// PriorityRange is the priorities the jobs submitted through the bridge can have.
+	PriorityRange job.PriorityRange
+	// Authenticator validates the bearer tokens submissions are published with, and maps them to the namespace of
+	// the jobs. Submissions are not authenticated if nil, as with the API.
+	Authenticator publicapi.Authenticator
+	// JobStore looks up the namespace of jobs, to publish their events to the topics of the namespace.
+	JobStore    jobstore.Store
+	TopicPrefix string
+	// QueueSize is how many job events wait to be published. DefaultQueueSize if zero.
+	QueueSize int
+}
+
+// Bridge republishes the job events of the requester to the broker, and submits the jobs published to it.
+type Bridge struct {
+	client   Client
+	endpoint requester.Endpoint
+	policy   job.SubmissionPolicy
+	auth     publicapi.Authenticator
+	jobStore jobstore.Store
+	prefix   string
+	events   chan model.JobEvent
+}
+
+func NewBridge(params BridgeParams) *Bridge {
+	prefix := strings.TrimSuffix(params.TopicPrefix, "/")
+	if prefix == "" {
+		prefix = DefaultTopicPrefix
+	}
+	queueSize := params.QueueSize
+	if queueSize == 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Bridge{
+		client:   params.Client,
+		endpoint: params.Endpoint,
+		policy: job.SubmissionPolicy{
+			SpecLimits:    params.SpecLimits,
+			EnvPolicy:     params.EnvPolicy,
+			PriorityRange: params.PriorityRange,
+		},
+		auth:     params.Authenticator,
+		jobStore: params.JobStore,
+		prefix:   prefix,
+		events:   make(chan model.JobEvent, queueSize),
+	}
+}
+
+// Start publishes the queued job events until the context is done, and subscribes to the submit topic if the bridge
+// accepts submissions.
+func (b *Bridge) Start(ctx context.Context) error {
+	go b.publishEvents(ctx)
+	if b.endpoint == nil {
+		return nil
+	}
+	return b.client.Subscribe(ctx, b.submitTopic(), b.submit)
+}
+
+// HandleJobEvent implements eventhandler.JobEventHandler, queueing the event to be published to the topic of the
+// job. Events are dropped rather than waiting for room in the queue, so that the requester keeps handling events when
+// the broker is slow or unreachable.
+func (b *Bridge) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	select {
+	case b.events <- event:
+	default:
+		log.Ctx(ctx).Warn().Str("JobID", event.JobID).Str("Event", event.EventName.String()).
+			Msg("dropping job event, as too many are waiting to be published to MQTT broker")
+	}
+	return nil
+}
+
+// publishEvents publishes the queued job events one at a time until the context is done.
+func (b *Bridge) publishEvents(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-b.events:
+			b.publishEvent(ctx, event)
+		}
+	}
+}
+
+func (b *Bridge) publishEvent(ctx context.Context, event model.JobEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to marshal job event for MQTT broker")
+		return
+	}
+	namespace, err := b.namespaceOf(ctx, event.JobID)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Str("JobID", event.JobID).Msg("not publishing event of unknown job to MQTT broker")
+		return
+	}
+	topic := b.eventTopic(namespace, event)
+	if err = b.client.Publish(ctx, topic, payload); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("Topic", topic).Msg("failed to publish job event to MQTT broker")
+	}
+}
+
+// namespaceOf returns the namespace of the job, which is empty if the bridge has no job store to find it in.
+func (b *Bridge) namespaceOf(ctx context.Context, jobID string) (string, error) {
+	if b.jobStore == nil {
+		return "", nil
+	}
+	j, err := b.jobStore.GetJob(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	return j.Metadata.Namespace, nil
+}
+
+// submissionMessage is a signed job published to the submit topic, with the bearer token of the client.
+type submissionMessage struct {
+	Token string `json:"token"`
+}
+
+// submit submits the signed job of the payload, and publishes the outcome to the submissions topic of its client.
+func (b *Bridge) submit(ctx context.Context, payload []byte) {
+	jobCreatePayload, err := publicapi.UnmarshalSigned[model.JobCreatePayload](ctx, bytes.NewReader(payload))
+	if err != nil {
+		// the client is not known unless the signature is valid, so there is no one to respond to
+		log.Ctx(ctx).Warn().Err(err).Msg("ignoring invalid job submission published to MQTT broker")
+		return
+	}
+
+	var response SubmissionResponse
+	jobCreatePayload.Namespace, err = b.authenticate(ctx, payload)
+	if err == nil {
+		err = job.VerifySubmission(ctx, &jobCreatePayload, b.policy)
+	}
+	if err == nil {
+		response.Job, err = b.endpoint.SubmitJob(ctx, jobCreatePayload)
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to marshal MQTT submission response")
+		return
+	}
+	topic := b.submissionsTopic(jobCreatePayload.Namespace, jobCreatePayload.ClientID)
+	if err = b.client.Publish(ctx, topic, body); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("Topic", topic).Msg("failed to publish submission response to MQTT broker")
+	}
+}
+
+// authenticate validates the bearer token the submission was published with, the same way the API does for the
+// submit endpoint, and returns the namespace of the client.
+func (b *Bridge) authenticate(ctx context.Context, payload []byte) (string, error) {
+	if b.auth == nil {
+		return "", nil
+	}
+	var message submissionMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return "", err
+	}
+	if message.Token == "" {
+		return "", errors.New("missing bearer token")
+	}
+	principal, err := b.auth.Authenticate(ctx, message.Token)
+	if err != nil {
+		return "", fmt.Errorf("invalid bearer token: %w", err)
+	}
+	if !principal.HasScope(publicapi.ScopeSubmit) {
+		return "", fmt.Errorf("%s is not granted the %s scope", principal.Subject, publicapi.ScopeSubmit)
+	}
+	return principal.Namespace, nil
+}
+
+// namespacePrefix returns the prefix of the topics of the namespace.
+func (b *Bridge) namespacePrefix(namespace string) string {
+	if namespace == "" {
+		return b.prefix
+	}
+	// wildcards and separators of topics are escaped, so that a namespace is always a single level of the topic
+	escaped := strings.ReplaceAll(url.PathEscape(namespace), "+", "%2B")
+	return fmt.Sprintf("%s/namespaces/%s", b.prefix, escaped)
+}
+
+func (b *Bridge) eventTopic(namespace string, event model.JobEvent) string {
+	return fmt.Sprintf("%s/jobs/%s/events/%s", b.namespacePrefix(namespace), event.JobID, event.EventName)
+}
+
+func (b *Bridge) submitTopic() string {
+	return b.prefix + "/submit"
+}
+
+func (b *Bridge) submissionsTopic(namespace, clientID string) string {
+	return fmt.Sprintf("%s/clients/%s/submissions", b.namespacePrefix(namespace), clientID)
+}
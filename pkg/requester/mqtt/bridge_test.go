@@ -0,0 +1,298 @@
+//go:build unit || !integration
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+// fakeClient records what is published, and calls the handlers of its subscriptions directly.
+type fakeClient struct {
+	mu            sync.Mutex
+	published     map[string][][]byte
+	subscriptions map[string]func(context.Context, []byte)
+	publishErr    error
+}
+
+func (c *fakeClient) Publish(_ context.Context, topic string, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.publishErr != nil {
+		return c.publishErr
+	}
+	c.published[topic] = append(c.published[topic], payload)
+	return nil
+}
+
+// publishedTo returns what was published to the topic so far.
+func (c *fakeClient) publishedTo(topic string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.published[topic]
+}
+
+func (c *fakeClient) Subscribe(_ context.Context, topic string, handler func(context.Context, []byte)) error {
+	c.subscriptions[topic] = handler
+	return nil
+}
+
+func (c *fakeClient) Close(context.Context) error {
+	return nil
+}
+
+// fakeEndpoint submits every job with the same ID.
+type fakeEndpoint struct {
+	requester.Endpoint
+	submitted []model.JobCreatePayload
+}
+
+func (e *fakeEndpoint) SubmitJob(_ context.Context, payload model.JobCreatePayload) (*model.Job, error) {
+	e.submitted = append(e.submitted, payload)
+	return &model.Job{Metadata: model.Metadata{ID: "job-id", ClientID: payload.ClientID}, Spec: *payload.Spec}, nil
+}
+
+// fakeAuthenticator accepts the tokens it knows of.
+type fakeAuthenticator map[string]publicapi.Principal
+
+func (a fakeAuthenticator) Authenticate(_ context.Context, token string) (publicapi.Principal, error) {
+	principal, ok := a[token]
+	if !ok {
+		return publicapi.Principal{}, errors.New("unknown token")
+	}
+	return principal, nil
+}
+
+type BridgeSuite struct {
+	suite.Suite
+	client   *fakeClient
+	endpoint *fakeEndpoint
+	ctx      context.Context
+}
+
+func TestBridgeSuite(t *testing.T) {
+	suite.Run(t, new(BridgeSuite))
+}
+
+func (s *BridgeSuite) SetupTest() {
+	system.InitConfigForTesting(s.T())
+	s.client = &fakeClient{published: map[string][][]byte{}, subscriptions: map[string]func(context.Context, []byte){}}
+	s.endpoint = &fakeEndpoint{}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.T().Cleanup(cancel)
+	s.ctx = ctx
+}
+
+func (s *BridgeSuite) newBridge(endpoint requester.Endpoint, configure ...func(*BridgeParams)) *Bridge {
+	params := BridgeParams{
+		Client:      s.client,
+		Endpoint:    endpoint,
+		SpecLimits:  job.DefaultSpecLimits,
+		TopicPrefix: "fleet/",
+	}
+	for _, c := range configure {
+		c(&params)
+	}
+	bridge := NewBridge(params)
+	s.Require().NoError(bridge.Start(s.ctx))
+	return bridge
+}
+
+// eventuallyPublished waits for a message to be published to the topic, and returns what was published.
+func (s *BridgeSuite) eventuallyPublished(topic string) [][]byte {
+	s.Require().Eventually(func() bool { return len(s.client.publishedTo(topic)) > 0 }, time.Second, 10*time.Millisecond)
+	return s.client.publishedTo(topic)
+}
+
+func (s *BridgeSuite) TestPublishesJobEvents() {
+	bridge := s.newBridge(nil)
+	event := model.JobEvent{JobID: "job-id", EventName: model.JobEventResultsPublished, Status: "done"}
+	s.Require().NoError(bridge.HandleJobEvent(context.Background(), event))
+
+	published := s.eventuallyPublished("fleet/jobs/job-id/events/ResultsPublished")
+	s.Require().Len(published, 1)
+	var received model.JobEvent
+	s.Require().NoError(json.Unmarshal(published[0], &received))
+	s.Equal(event.Status, received.Status)
+	s.Empty(s.client.subscriptions, "submissions are not accepted without an endpoint")
+
+	// events are still handled when the broker is unreachable
+	s.client.mu.Lock()
+	s.client.publishErr = errors.New("not connected")
+	s.client.mu.Unlock()
+	s.NoError(bridge.HandleJobEvent(context.Background(), event))
+}
+
+func (s *BridgeSuite) TestDropsJobEventsWhenTheQueueIsFull() {
+	// the bridge is not started, so nothing is taken off the queue
+	bridge := NewBridge(BridgeParams{Client: s.client, QueueSize: 1})
+	event := model.JobEvent{JobID: "job-id", EventName: model.JobEventCreated}
+	s.NoError(bridge.HandleJobEvent(context.Background(), event))
+	s.NoError(bridge.HandleJobEvent(context.Background(), event))
+	s.Len(bridge.events, 1)
+}
+
+func (s *BridgeSuite) TestPublishesJobEventsToTheirNamespace() {
+	jobStore := inmemory.NewJobStore()
+	s.Require().NoError(jobStore.CreateJob(context.Background(), model.Job{
+		Metadata: model.Metadata{ID: "job-id", Namespace: "team/a+b"},
+	}))
+	bridge := s.newBridge(nil, func(params *BridgeParams) {
+		params.JobStore = jobStore
+	})
+
+	s.Require().NoError(bridge.HandleJobEvent(context.Background(), model.JobEvent{JobID: "job-id", EventName: model.JobEventCreated}))
+	s.Len(s.eventuallyPublished("fleet/namespaces/team%2Fa%2Bb/jobs/job-id/events/Created"), 1)
+	s.Empty(s.client.publishedTo("fleet/jobs/job-id/events/Created"))
+}
+
+func (s *BridgeSuite) TestSubmitsJobs() {
+	s.newBridge(s.endpoint)
+	submit := s.client.subscriptions["fleet/submit"]
+	s.Require().NotNil(submit)
+
+	j, err := model.NewJobWithSaneProductionDefaults()
+	s.Require().NoError(err)
+	j.Spec.Engine = model.EngineWasm
+	j.Spec.Wasm.EntryModule = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe"}
+	j.Spec.Verifier = model.VerifierNoop
+	j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+	request, err := publicapi.SignRequest(model.JobCreatePayload{
+		ClientID:   system.GetClientID(),
+		APIVersion: j.APIVersion,
+		Spec:       &j.Spec,
+	})
+	s.Require().NoError(err)
+	payload, err := json.Marshal(request)
+	s.Require().NoError(err)
+	submit(context.Background(), payload)
+
+	s.Require().Len(s.endpoint.submitted, 1)
+	s.Empty(s.endpoint.submitted[0].Namespace)
+	responses := s.client.published["fleet/clients/"+system.GetClientID()+"/submissions"]
+	s.Require().Len(responses, 1)
+	var response SubmissionResponse
+	s.Require().NoError(json.Unmarshal(responses[0], &response))
+	s.Empty(response.Error)
+	s.Equal("job-id", response.Job.Metadata.ID)
+}
+
+func (s *BridgeSuite) TestRejectsInvalidSubmissions() {
+	s.newBridge(s.endpoint)
+	submit := s.client.subscriptions["fleet/submit"]
+
+	// unsigned jobs are ignored, as there is no client to respond to
+	submit(context.Background(), []byte(`{"payload": {"ClientID": "someone"}}`))
+	s.Empty(s.endpoint.submitted)
+	s.Empty(s.client.published)
+
+	// signed jobs that are invalid are responded to with the error
+	request, err := publicapi.SignRequest(model.JobCreatePayload{ClientID: system.GetClientID()})
+	s.Require().NoError(err)
+	payload, err := json.Marshal(request)
+	s.Require().NoError(err)
+	submit(context.Background(), payload)
+
+	s.Empty(s.endpoint.submitted)
+	responses := s.client.published["fleet/clients/"+system.GetClientID()+"/submissions"]
+	s.Require().Len(responses, 1)
+	var response SubmissionResponse
+	s.Require().NoError(json.Unmarshal(responses[0], &response))
+	s.Nil(response.Job)
+	s.NotEmpty(response.Error)
+}
+
+func (s *BridgeSuite) TestAuthenticatesSubmissions() {
+	s.newBridge(s.endpoint, func(params *BridgeParams) {
+		params.Authenticator = fakeAuthenticator{
+			"submitter": {Subject: "alice", Namespace: "research", Scopes: []publicapi.Scope{publicapi.ScopeSubmit}},
+			"reader":    {Subject: "bob", Namespace: "research", Scopes: []publicapi.Scope{publicapi.ScopeRead}},
+		}
+	})
+	submit := s.client.subscriptions["fleet/submit"]
+
+	j, err := model.NewJobWithSaneProductionDefaults()
+	s.Require().NoError(err)
+	j.Spec.Engine = model.EngineWasm
+	j.Spec.Wasm.EntryModule = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe"}
+	j.Spec.Verifier = model.VerifierNoop
+	j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+	request, err := publicapi.SignRequest(model.JobCreatePayload{
+		ClientID:   system.GetClientID(),
+		APIVersion: j.APIVersion,
+		Spec:       &j.Spec,
+	})
+	s.Require().NoError(err)
+	publish := func(token string) {
+		message := map[string]interface{}{
+			"payload":           request.Payload,
+			"signature":         request.ClientSignature,
+			"client_public_key": request.ClientPublicKey,
+		}
+		if token != "" {
+			message["token"] = token
+		}
+		payload, err := json.Marshal(message)
+		s.Require().NoError(err)
+		submit(context.Background(), payload)
+	}
+
+	// submissions without a token, with an unknown one or without the submit scope are rejected
+	for _, token := range []string{"", "unknown", "reader"} {
+		publish(token)
+	}
+	s.Empty(s.endpoint.submitted)
+	s.Len(s.client.publishedTo("fleet/clients/"+system.GetClientID()+"/submissions"), 3)
+
+	publish("submitter")
+	s.Require().Len(s.endpoint.submitted, 1)
+	s.Equal("research", s.endpoint.submitted[0].Namespace)
+	responses := s.client.publishedTo("fleet/namespaces/research/clients/" + system.GetClientID() + "/submissions")
+	s.Require().Len(responses, 1)
+	var response SubmissionResponse
+	s.Require().NoError(json.Unmarshal(responses[0], &response))
+	s.Empty(response.Error)
+}
+
+func (s *BridgeSuite) TestRejectsSubmissionsOverTheSpecLimits() {
+	s.newBridge(s.endpoint)
+	submit := s.client.subscriptions["fleet/submit"]
+
+	j, err := model.NewJobWithSaneProductionDefaults()
+	s.Require().NoError(err)
+	j.Spec.Engine = model.EngineWasm
+	j.Spec.Wasm.EntryModule = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe"}
+	j.Spec.Wasm.Parameters = []string{strings.Repeat("a", int(job.DefaultSpecLimits.MaxEntrypointSize.Bytes())+1)}
+	j.Spec.Verifier = model.VerifierNoop
+	j.Spec.PublisherSpec = model.PublisherSpec{Type: model.PublisherNoop}
+	request, err := publicapi.SignRequest(model.JobCreatePayload{
+		ClientID:   system.GetClientID(),
+		APIVersion: j.APIVersion,
+		Spec:       &j.Spec,
+	})
+	s.Require().NoError(err)
+	payload, err := json.Marshal(request)
+	s.Require().NoError(err)
+	submit(context.Background(), payload)
+
+	s.Empty(s.endpoint.submitted)
+	responses := s.client.published["fleet/clients/"+system.GetClientID()+"/submissions"]
+	s.Require().Len(responses, 1)
+	var response SubmissionResponse
+	s.Require().NoError(json.Unmarshal(responses[0], &response))
+	s.Contains(response.Error, "entrypoint")
+}
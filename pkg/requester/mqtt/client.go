@@ -0,0 +1,113 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/zerolog/log"
+)
+
+// connectTimeout is how long the requester waits for the broker when it starts.
+const connectTimeout = 30 * time.Second
+
+// publishTimeout is how long messages are given to be published, which holds up the handling of job events.
+const publishTimeout = 5 * time.Second
+
+// disconnectQuiesce is how long, in milliseconds, messages being sent are given before disconnecting.
+const disconnectQuiesce = 250
+
+type pahoClient struct {
+	ctx    context.Context
+	client paho.Client
+	qos    byte
+
+	// subscriptions are subscribed to again when the client reconnects, as its session does not outlive connections
+	subscriptions   map[string]paho.MessageHandler
+	subscriptionsMu sync.Mutex
+}
+
+// NewClient connects to the broker of the config. The client reconnects whenever the connection is lost, and calls
+// the handlers of its subscriptions with the context.
+func NewClient(ctx context.Context, config Config, clientID string) (Client, error) {
+	if config.QoS > 2 { //nolint:gomnd
+		return nil, fmt.Errorf("invalid MQTT QoS %d, expected 0, 1 or 2", config.QoS)
+	}
+	broker, err := url.Parse(config.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT broker: %w", err)
+	}
+	c := &pahoClient{
+		ctx:           ctx,
+		qos:           config.QoS,
+		subscriptions: make(map[string]paho.MessageHandler),
+	}
+
+	options := paho.NewClientOptions().
+		SetClientID(clientID).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetOrderMatters(false).
+		SetOnConnectHandler(c.resubscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Ctx(ctx).Warn().Err(err).Msg("lost connection to MQTT broker, reconnecting")
+		})
+	if broker.User != nil {
+		options.SetUsername(broker.User.Username())
+		if password, ok := broker.User.Password(); ok {
+			options.SetPassword(password)
+		}
+		broker.User = nil
+	}
+	options.AddBroker(broker.String())
+
+	c.client = paho.NewClient(options)
+	if err = wait(ctx, c.client.Connect(), connectTimeout); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", broker.Redacted(), err)
+	}
+	return c, nil
+}
+
+func (c *pahoClient) Publish(ctx context.Context, topic string, payload []byte) error {
+	return wait(ctx, c.client.Publish(topic, c.qos, false, payload), publishTimeout)
+}
+
+func (c *pahoClient) Subscribe(ctx context.Context, topic string, handler func(context.Context, []byte)) error {
+	messageHandler := func(_ paho.Client, message paho.Message) {
+		handler(c.ctx, message.Payload())
+	}
+	c.subscriptionsMu.Lock()
+	c.subscriptions[topic] = messageHandler
+	c.subscriptionsMu.Unlock()
+	return wait(ctx, c.client.Subscribe(topic, c.qos, messageHandler), connectTimeout)
+}
+
+// resubscribe subscribes to the topics of the client again once it is connected. Tokens are not waited for, as that
+// would block the client.
+func (c *pahoClient) resubscribe(client paho.Client) {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	for topic, handler := range c.subscriptions {
+		client.Subscribe(topic, c.qos, handler)
+	}
+}
+
+func (c *pahoClient) Close(context.Context) error {
+	c.client.Disconnect(disconnectQuiesce)
+	return nil
+}
+
+// wait waits for the token to complete, and returns its error.
+func wait(ctx context.Context, token paho.Token, timeout time.Duration) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
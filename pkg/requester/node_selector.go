@@ -3,10 +3,12 @@ package requester
 import (
 	"context"
 	"sort"
+	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 )
 
 type NodeSelectorParams struct {
@@ -49,6 +51,10 @@ func (s *NodeSelector) SelectNodes(ctx context.Context, job model.Job, minCount,
 	log.Ctx(ctx).Debug().Msgf("ranked %d nodes for job %s", len(rankedNodes), job.ID())
 
 	if len(rankedNodes) < minCount {
+		// fail with a descriptive error if the network lacks support for what the job requires
+		if err = s.checkRequirements(ctx, job, minCount); err != nil {
+			return nil, err
+		}
 		err = NewErrNotEnoughNodes(minCount, len(rankedNodes))
 		return nil, err
 	}
@@ -60,3 +66,161 @@ func (s *NodeSelector) SelectNodes(ctx context.Context, job model.Job, minCount,
 	selectedNodes := rankedNodes[:system.Min(len(rankedNodes), desiredCount)]
 	return selectedNodes, nil
 }
+
+// checkRequirements returns ErrUnsupportedJobRequirements if fewer than minCount compute nodes
+// support the engine, verifier or publishers required by the job.
+func (s *NodeSelector) checkRequirements(ctx context.Context, job model.Job, minCount int) error {
+	nodes, err := s.nodeDiscoverer.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var unsupported []UnsupportedRequirement
+	if requirement, ok := checkRequirement(nodes, minCount, "engine", job.Spec.Engine,
+		func(info model.ComputeNodeInfo) []model.Engine { return info.ExecutionEngines }); !ok {
+		unsupported = append(unsupported, requirement)
+	}
+	if requirement, ok := checkRequirement(nodes, minCount, "verifier", job.Spec.Verifier,
+		func(info model.ComputeNodeInfo) []model.Verifier { return info.Verifiers }); !ok {
+		unsupported = append(unsupported, requirement)
+	}
+	// the results of each output can be published with a publisher of its own
+	for _, publisher := range job.Spec.AllPublishers() {
+		if requirement, ok := checkRequirement(nodes, minCount, "publisher", publisher,
+			func(info model.ComputeNodeInfo) []model.Publisher { return info.Publishers }); !ok {
+			unsupported = append(unsupported, requirement)
+		}
+	}
+
+	if requirement, ok := checkResidency(nodes, minCount, job.Spec); !ok {
+		unsupported = append(unsupported, requirement)
+	}
+	unsupported = append(unsupported, checkEngineRequirements(nodes, minCount, job.Spec)...)
+
+	if len(unsupported) > 0 {
+		return NewErrUnsupportedJobRequirements(minCount, unsupported)
+	}
+	return nil
+}
+
+// checkResidency reports which nodes are in the jurisdictions the job must stay in, and whether at least minCount are.
+func checkResidency(nodes []model.NodeInfo, minCount int, spec model.Spec) (UnsupportedRequirement, bool) {
+	if len(spec.Residency) == 0 {
+		return UnsupportedRequirement{}, true
+	}
+	requirement := UnsupportedRequirement{
+		Kind:      "jurisdiction",
+		Required:  strings.Join(spec.Residency, ", "),
+		Available: make(map[string][]string),
+	}
+	for _, node := range nodes {
+		if !node.IsComputeNode() || node.Jurisdiction() == "" {
+			continue
+		}
+		nodeID := node.PeerInfo.ID.String()
+		requirement.Available[node.Jurisdiction()] = append(requirement.Available[node.Jurisdiction()], nodeID)
+		if spec.AllowsJurisdiction(node.Jurisdiction()) {
+			requirement.SupportingNodes = append(requirement.SupportingNodes, nodeID)
+		}
+	}
+	return requirement, len(requirement.SupportingNodes) >= minCount
+}
+
+// checkEngineRequirements reports the minimum engine version and the engine features of the job that fewer than
+// minCount nodes with its engine support. Nodes that do not report the version of their engine are given the benefit
+// of the doubt.
+func checkEngineRequirements(nodes []model.NodeInfo, minCount int, spec model.Spec) []UnsupportedRequirement {
+	requirements := spec.EngineRequirements
+	if requirements.IsEmpty() {
+		return nil
+	}
+	version := UnsupportedRequirement{
+		Kind:      "engine version",
+		Required:  requirements.MinVersion + " or newer",
+		Available: make(map[string][]string),
+	}
+	features := make([]UnsupportedRequirement, len(requirements.Features))
+	for i, feature := range requirements.Features {
+		features[i] = UnsupportedRequirement{Kind: "engine feature", Required: feature, Available: make(map[string][]string)}
+	}
+
+	unknown := 0
+	for _, node := range nodes {
+		if !node.IsComputeNode() {
+			continue
+		}
+		if node.ComputeNodeInfo == nil {
+			unknown++
+			continue
+		}
+		info, ok := node.ComputeNodeInfo.GetEngineInfo(spec.Engine)
+		if !ok {
+			if slices.Contains(node.ComputeNodeInfo.ExecutionEngines, spec.Engine) {
+				unknown++
+			}
+			continue
+		}
+		nodeID := node.PeerInfo.ID.String()
+		if info.Version != "" {
+			version.Available[info.Version] = append(version.Available[info.Version], nodeID)
+		}
+		if info.HasMinVersion(requirements.MinVersion) {
+			version.SupportingNodes = append(version.SupportingNodes, nodeID)
+		}
+		for i := range features {
+			for _, feature := range info.Features {
+				features[i].Available[feature] = append(features[i].Available[feature], nodeID)
+			}
+			if slices.Contains(info.Features, features[i].Required) {
+				features[i].SupportingNodes = append(features[i].SupportingNodes, nodeID)
+			}
+		}
+	}
+
+	var unsupported []UnsupportedRequirement
+	if requirements.MinVersion != "" && len(version.SupportingNodes)+unknown < minCount {
+		unsupported = append(unsupported, version)
+	}
+	for _, feature := range features {
+		if len(feature.SupportingNodes)+unknown < minCount {
+			unsupported = append(unsupported, feature)
+		}
+	}
+	return unsupported
+}
+
+// checkRequirement reports which nodes support the required key, and whether at least minCount do.
+// Compute nodes that do not advertise their capabilities are given the benefit of the doubt.
+func checkRequirement[Key model.ProviderKey](
+	nodes []model.NodeInfo,
+	minCount int,
+	kind string,
+	required Key,
+	getProvidedKeys func(model.ComputeNodeInfo) []Key,
+) (UnsupportedRequirement, bool) {
+	requirement := UnsupportedRequirement{
+		Kind:      kind,
+		Required:  required.String(),
+		Available: make(map[string][]string),
+	}
+
+	supporting := 0
+	for _, node := range nodes {
+		if !node.IsComputeNode() {
+			continue
+		}
+		if node.ComputeNodeInfo == nil {
+			supporting++
+			continue
+		}
+		nodeID := node.PeerInfo.ID.String()
+		for _, key := range getProvidedKeys(*node.ComputeNodeInfo) {
+			requirement.Available[key.String()] = append(requirement.Available[key.String()], nodeID)
+			if key == required {
+				requirement.SupportingNodes = append(requirement.SupportingNodes, nodeID)
+				supporting++
+			}
+		}
+	}
+	return requirement, supporting >= minCount
+}
@@ -0,0 +1,227 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedNodeDiscoverer struct {
+	nodes []model.NodeInfo
+}
+
+func (d fixedNodeDiscoverer) ListNodes(context.Context) ([]model.NodeInfo, error) {
+	return d.nodes, nil
+}
+
+func (d fixedNodeDiscoverer) FindNodes(_ context.Context, job model.Job) ([]model.NodeInfo, error) {
+	var nodes []model.NodeInfo
+	for _, node := range d.nodes {
+		if node.ComputeNodeInfo == nil {
+			continue
+		}
+		for _, engine := range node.ComputeNodeInfo.ExecutionEngines {
+			if engine == job.Spec.Engine {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// rankAllNodes ranks every node it is given as suitable.
+type rankAllNodes struct{}
+
+func (rankAllNodes) RankNodes(_ context.Context, _ model.Job, nodes []model.NodeInfo) ([]NodeRank, error) {
+	ranks := make([]NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = NodeRank{NodeInfo: node, Rank: 1}
+	}
+	return ranks, nil
+}
+
+func newComputeNode(id string, engines []model.Engine, publishers []model.Publisher) model.NodeInfo {
+	return model.NodeInfo{
+		PeerInfo: peer.AddrInfo{ID: peer.ID(id)},
+		NodeType: model.NodeTypeCompute,
+		ComputeNodeInfo: &model.ComputeNodeInfo{
+			ExecutionEngines: engines,
+			Verifiers:        []model.Verifier{model.VerifierNoop},
+			Publishers:       publishers,
+		},
+	}
+}
+
+func newNodeSelectorTestJob(engine model.Engine, publisher model.Publisher) model.Job {
+	return model.Job{Spec: model.Spec{
+		Engine:        engine,
+		Verifier:      model.VerifierNoop,
+		PublisherSpec: model.PublisherSpec{Type: publisher},
+	}}
+}
+
+func TestSelectNodesUnsupportedRequirements(t *testing.T) {
+	selector := NewNodeSelector(NodeSelectorParams{
+		NodeDiscoverer: fixedNodeDiscoverer{nodes: []model.NodeInfo{
+			newComputeNode("docker-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs}),
+			newComputeNode("wasm-node", []model.Engine{model.EngineWasm}, []model.Publisher{model.PublisherEstuary}),
+		}},
+		NodeRanker: rankAllNodes{},
+	})
+
+	_, err := selector.SelectNodes(context.Background(), newNodeSelectorTestJob(model.EngineNoop, model.PublisherS3), 1, 1)
+	var unsupportedErr ErrUnsupportedJobRequirements
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Equal(t, 1, unsupportedErr.RequestedNodes)
+	require.Len(t, unsupportedErr.Requirements, 2)
+
+	engine := unsupportedErr.Requirements[0]
+	require.Equal(t, "engine", engine.Kind)
+	require.Equal(t, model.EngineNoop.String(), engine.Required)
+	require.Empty(t, engine.SupportingNodes)
+	require.Equal(t, map[string][]string{
+		model.EngineDocker.String(): {peer.ID("docker-node").String()},
+		model.EngineWasm.String():   {peer.ID("wasm-node").String()},
+	}, engine.Available)
+
+	publisher := unsupportedErr.Requirements[1]
+	require.Equal(t, "publisher", publisher.Kind)
+	require.Equal(t, model.PublisherS3.String(), publisher.Required)
+	require.Contains(t, err.Error(), `engine "Noop" is supported by 0 node(s)`)
+	require.Contains(t, err.Error(), "available publishers:")
+}
+
+func TestSelectNodesUnsupportedOutputPublisher(t *testing.T) {
+	selector := NewNodeSelector(NodeSelectorParams{
+		NodeDiscoverer: fixedNodeDiscoverer{nodes: []model.NodeInfo{
+			newComputeNode("docker-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs}),
+		}},
+		NodeRanker: rankAllNodes{},
+	})
+
+	job := newNodeSelectorTestJob(model.EngineDocker, model.PublisherIpfs)
+	job.Spec.Outputs = []model.StorageSpec{
+		{Name: "outputs", Path: "/outputs"},
+		{Name: "archive", Path: "/archive", Publisher: &model.PublisherSpec{Type: model.PublisherS3}},
+	}
+	err := selector.checkRequirements(context.Background(), job, 1)
+	var unsupportedErr ErrUnsupportedJobRequirements
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Len(t, unsupportedErr.Requirements, 1)
+	require.Equal(t, "publisher", unsupportedErr.Requirements[0].Kind)
+	require.Equal(t, model.PublisherS3.String(), unsupportedErr.Requirements[0].Required)
+
+	job.Spec.Outputs[1].Publisher.Type = model.PublisherIpfs
+	require.NoError(t, selector.checkRequirements(context.Background(), job, 1))
+}
+
+func TestSelectNodesNotEnoughNodes(t *testing.T) {
+	selector := NewNodeSelector(NodeSelectorParams{
+		NodeDiscoverer: fixedNodeDiscoverer{nodes: []model.NodeInfo{
+			newComputeNode("docker-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs}),
+		}},
+		NodeRanker: rankAllNodes{},
+	})
+
+	job := newNodeSelectorTestJob(model.EngineDocker, model.PublisherIpfs)
+	nodes, err := selector.SelectNodes(context.Background(), job, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	// the requirements are supported, just not by enough nodes
+	_, err = selector.SelectNodes(context.Background(), job, 2, 2)
+	var unsupportedErr ErrUnsupportedJobRequirements
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Equal(t, []string{peer.ID("docker-node").String()}, unsupportedErr.Requirements[0].SupportingNodes)
+}
+
+// rankResidentNodes ranks the nodes in the jurisdictions of the job as suitable.
+type rankResidentNodes struct{}
+
+func (rankResidentNodes) RankNodes(_ context.Context, job model.Job, nodes []model.NodeInfo) ([]NodeRank, error) {
+	ranks := make([]NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = NodeRank{NodeInfo: node, Rank: 1}
+		if !job.Spec.AllowsJurisdiction(node.Jurisdiction()) {
+			ranks[i].Rank = -1
+		}
+	}
+	return ranks, nil
+}
+
+func TestSelectNodesResidency(t *testing.T) {
+	euNode := newComputeNode("eu-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	euNode.Labels = map[string]string{model.JurisdictionLabel: "eu"}
+	usNode := newComputeNode("us-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	usNode.Labels = map[string]string{model.JurisdictionLabel: "us"}
+	selector := NewNodeSelector(NodeSelectorParams{
+		NodeDiscoverer: fixedNodeDiscoverer{nodes: []model.NodeInfo{euNode, usNode}},
+		NodeRanker:     rankResidentNodes{},
+	})
+
+	job := newNodeSelectorTestJob(model.EngineDocker, model.PublisherIpfs)
+	job.Spec.Residency = []string{"eu"}
+	nodes, err := selector.SelectNodes(context.Background(), job, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, euNode.PeerInfo.ID, nodes[0].NodeInfo.PeerInfo.ID)
+
+	job.Spec.Residency = []string{"ch"}
+	_, err = selector.SelectNodes(context.Background(), job, 1, 1)
+	var unsupportedErr ErrUnsupportedJobRequirements
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Len(t, unsupportedErr.Requirements, 1)
+	require.Equal(t, "jurisdiction", unsupportedErr.Requirements[0].Kind)
+	require.Equal(t, map[string][]string{
+		"eu": {euNode.PeerInfo.ID.String()},
+		"us": {usNode.PeerInfo.ID.String()},
+	}, unsupportedErr.Requirements[0].Available)
+}
+
+func TestSelectNodesEngineRequirements(t *testing.T) {
+	oldNode := newComputeNode("old-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	oldNode.ComputeNodeInfo.EngineInfo = []model.EngineInfo{{Engine: model.EngineDocker, Version: "20.10.7"}}
+	gpuNode := newComputeNode("gpu-node", []model.Engine{model.EngineDocker}, []model.Publisher{model.PublisherIpfs})
+	gpuNode.ComputeNodeInfo.EngineInfo = []model.EngineInfo{
+		{Engine: model.EngineDocker, Version: "24.0.5", Features: []string{model.EngineFeatureNvidiaRuntime}},
+	}
+	selector := NewNodeSelector(NodeSelectorParams{
+		NodeDiscoverer: fixedNodeDiscoverer{nodes: []model.NodeInfo{oldNode, gpuNode}},
+		NodeRanker:     rankAllNodes{},
+	})
+
+	job := newNodeSelectorTestJob(model.EngineDocker, model.PublisherIpfs)
+	job.Spec.EngineRequirements = &model.EngineRequirements{
+		MinVersion: "25.0",
+		Features:   []string{model.EngineFeatureNvidiaRuntime},
+	}
+	err := selector.checkRequirements(context.Background(), job, 2)
+	var unsupportedErr ErrUnsupportedJobRequirements
+	require.ErrorAs(t, err, &unsupportedErr)
+	require.Len(t, unsupportedErr.Requirements, 2)
+
+	version := unsupportedErr.Requirements[0]
+	require.Equal(t, "engine version", version.Kind)
+	require.Equal(t, "25.0 or newer", version.Required)
+	require.Empty(t, version.SupportingNodes)
+	require.Equal(t, map[string][]string{
+		"20.10.7": {oldNode.PeerInfo.ID.String()},
+		"24.0.5":  {gpuNode.PeerInfo.ID.String()},
+	}, version.Available)
+
+	feature := unsupportedErr.Requirements[1]
+	require.Equal(t, "engine feature", feature.Kind)
+	require.Equal(t, []string{gpuNode.PeerInfo.ID.String()}, feature.SupportingNodes)
+	require.Contains(t, err.Error(), `engine feature "nvidia-runtime" is supported by 1 node(s)`)
+
+	// nodes that don't report their engine version may still meet the requirements
+	job.Spec.EngineRequirements = &model.EngineRequirements{Features: []string{model.EngineFeatureNvidiaRuntime}}
+	require.NoError(t, selector.checkRequirements(context.Background(), job, 1))
+	oldNode.ComputeNodeInfo.EngineInfo = nil
+	require.NoError(t, selector.checkRequirements(context.Background(), job, 2))
+}
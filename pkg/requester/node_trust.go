@@ -0,0 +1,112 @@
+package requester
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultMinVerifications is how many results of a node must have been verified before it can be trusted.
+	DefaultMinVerifications = 3
+	// DefaultMinVerifiedRatio is the share of the verified results of a node that must have passed verification for
+	// it to be trusted.
+	DefaultMinVerifiedRatio = 0.9
+)
+
+// NodeTrust tells whether the results of a node can be relied on without comparing them with the results of other
+// nodes. Deals with adaptive concurrency run an additional execution when the only result of a job comes from a node
+// that is not trusted.
+type NodeTrust interface {
+	IsTrusted(ctx context.Context, nodeID string) bool
+}
+
+type VerificationTrustParams struct {
+	JobStore jobstore.Store
+	// MinVerifications is DefaultMinVerifications if zero.
+	MinVerifications int
+	// MinVerifiedRatio is DefaultMinVerifiedRatio if zero.
+	MinVerifiedRatio float64
+}
+
+// VerificationTrust trusts the nodes whose results passed verification often enough. Results of jobs that use the
+// noop verifier are accepted without being compared, so they count as a track record of results delivered, and nodes
+// running only those jobs earn trust as well.
+type VerificationTrust struct {
+	jobStore         jobstore.Store
+	minVerifications int
+	minVerifiedRatio float64
+
+	mu    sync.RWMutex
+	nodes map[string]*verificationCounts
+}
+
+type verificationCounts struct {
+	accepted int
+	rejected int
+}
+
+func NewVerificationTrust(params VerificationTrustParams) *VerificationTrust {
+	if params.MinVerifications <= 0 {
+		params.MinVerifications = DefaultMinVerifications
+	}
+	if params.MinVerifiedRatio <= 0 {
+		params.MinVerifiedRatio = DefaultMinVerifiedRatio
+	}
+	return &VerificationTrust{
+		jobStore:         params.JobStore,
+		minVerifications: params.MinVerifications,
+		minVerifiedRatio: params.MinVerifiedRatio,
+		nodes:            make(map[string]*verificationCounts),
+	}
+}
+
+// HandleJobEvent records the results of nodes that were accepted or rejected by the verifier of their job.
+func (t *VerificationTrust) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	if event.EventName != model.JobEventResultsAccepted && event.EventName != model.JobEventResultsRejected {
+		return nil
+	}
+	if event.TargetNodeID == "" {
+		return nil
+	}
+	if _, err := t.jobStore.GetJob(ctx, event.JobID); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msgf("not recording verification of job %s", event.JobID)
+		return nil
+	}
+	t.Record(event.TargetNodeID, event.EventName == model.JobEventResultsAccepted)
+	return nil
+}
+
+// Record adds the outcome of the verification of a result of the node.
+func (t *VerificationTrust) Record(nodeID string, verified bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts, ok := t.nodes[nodeID]
+	if !ok {
+		counts = &verificationCounts{}
+		t.nodes[nodeID] = counts
+	}
+	if verified {
+		counts.accepted++
+	} else {
+		counts.rejected++
+	}
+}
+
+// IsTrusted implements NodeTrust
+func (t *VerificationTrust) IsTrusted(_ context.Context, nodeID string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	counts, ok := t.nodes[nodeID]
+	if !ok {
+		return false
+	}
+	total := counts.accepted + counts.rejected
+	return total >= t.minVerifications && float64(counts.accepted)/float64(total) >= t.minVerifiedRatio
+}
+
+// compile-time check that VerificationTrust implements the expected interfaces
+var _ NodeTrust = (*VerificationTrust)(nil)
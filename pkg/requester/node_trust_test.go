@@ -0,0 +1,51 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationTrust(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	trust := NewVerificationTrust(VerificationTrustParams{JobStore: store, MinVerifications: 2, MinVerifiedRatio: 0.6})
+
+	verifiedJob := model.Job{Metadata: model.Metadata{ID: "verified-job"}, Spec: model.Spec{Verifier: model.VerifierDeterministic}}
+	noopJob := model.Job{Metadata: model.Metadata{ID: "noop-job"}, Spec: model.Spec{Verifier: model.VerifierNoop}}
+	require.NoError(t, store.CreateJob(ctx, verifiedJob))
+	require.NoError(t, store.CreateJob(ctx, noopJob))
+	verification := func(job model.Job, node string, eventName model.JobEventType) {
+		require.NoError(t, trust.HandleJobEvent(ctx, model.JobEvent{
+			JobID:        job.ID(),
+			SourceNodeID: "requester",
+			TargetNodeID: node,
+			EventName:    eventName,
+		}))
+	}
+
+	// nodes are not trusted until enough of their results were verified
+	verification(verifiedJob, "node", model.JobEventResultsAccepted)
+	require.False(t, trust.IsTrusted(ctx, "node"))
+	verification(verifiedJob, "node", model.JobEventResultsAccepted)
+	require.True(t, trust.IsTrusted(ctx, "node"))
+
+	// nodes whose results are rejected too often are no longer trusted
+	verification(verifiedJob, "node", model.JobEventResultsRejected)
+	require.True(t, trust.IsTrusted(ctx, "node"))
+	verification(verifiedJob, "node", model.JobEventResultsRejected)
+	require.False(t, trust.IsTrusted(ctx, "node"))
+
+	// nodes running jobs whose results are never compared earn trust from the results they delivered
+	verification(noopJob, "noop-node", model.JobEventResultsAccepted)
+	require.False(t, trust.IsTrusted(ctx, "noop-node"))
+	verification(noopJob, "noop-node", model.JobEventResultsAccepted)
+	require.True(t, trust.IsTrusted(ctx, "noop-node"))
+
+	require.False(t, trust.IsTrusted(ctx, "unknown"))
+}
@@ -0,0 +1,238 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// channelNameRegex matches the names channels can have, which are safe to use in job annotations.
+var channelNameRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ParseChannel parses a channel of the form NAME=TARGET, where TARGET is one of:
+//   - slack:URL, an incoming webhook of Slack
+//   - webhook:URL, an HTTP endpoint the notification is posted to as JSON
+//   - smtp://[USER[:PASSWORD]@]HOST[:PORT]?from=ADDRESS&to=ADDRESS[,ADDRESS...], a mail server notifications are
+//     emailed through
+func ParseChannel(value string) (string, Channel, error) {
+	name, target, found := strings.Cut(value, "=")
+	if !found || target == "" {
+		return "", nil, fmt.Errorf("invalid notification channel %q, expected NAME=TARGET", value)
+	}
+	if !channelNameRegex.MatchString(name) || name == ChannelNone {
+		return "", nil, fmt.Errorf("invalid notification channel name %q, expected letters, digits, '.', '_' or '-'", name)
+	}
+
+	var channel Channel
+	var err error
+	if webhookURL, found := strings.CutPrefix(target, "slack:"); found {
+		channel, err = newSlackChannel(webhookURL)
+	} else if webhookURL, found := strings.CutPrefix(target, "webhook:"); found {
+		channel, err = newWebhookChannel(webhookURL)
+	} else if strings.HasPrefix(target, "smtp://") {
+		channel, err = newEmailChannel(target)
+	} else {
+		err = fmt.Errorf("unknown target %q, expected slack:URL, webhook:URL or smtp://", target)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid notification channel %s: %w", name, err)
+	}
+	return name, channel, nil
+}
+
+func parseHTTPURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an HTTP URL", value)
+	}
+	return u, nil
+}
+
+// postJSON posts the payload to the URL, and fails if it is not accepted.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024)) //nolint:gomnd
+		return fmt.Errorf("%s answered %s: %s", req.URL.Redacted(), res.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// slackChannel posts notifications to an incoming webhook of Slack.
+type slackChannel struct {
+	url    string
+	client *http.Client
+}
+
+func newSlackChannel(webhookURL string) (*slackChannel, error) {
+	u, err := parseHTTPURL(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	return &slackChannel{url: u.String(), client: http.DefaultClient}, nil
+}
+
+func (c *slackChannel) Send(ctx context.Context, message Message) error {
+	return postJSON(ctx, c.client, c.url, map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", message.Subject, message.Text),
+	})
+}
+
+// webhookChannel posts notifications to an HTTP endpoint, as the JSON encoding of the Message.
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookChannel(webhookURL string) (*webhookChannel, error) {
+	u, err := parseHTTPURL(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookChannel{url: u.String(), client: http.DefaultClient}, nil
+}
+
+func (c *webhookChannel) Send(ctx context.Context, message Message) error {
+	return postJSON(ctx, c.client, c.url, message)
+}
+
+// emailChannel emails notifications through a mail server, using STARTTLS if the server supports it.
+type emailChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+const defaultSMTPPort = "25"
+
+func newEmailChannel(target string) (*emailChannel, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("missing mail server host")
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultSMTPPort
+	}
+	channel := &emailChannel{
+		addr: net.JoinHostPort(u.Hostname(), port),
+		from: u.Query().Get("from"),
+	}
+	if channel.from == "" {
+		return nil, fmt.Errorf("missing from address")
+	}
+	for _, to := range strings.Split(u.Query().Get("to"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			channel.to = append(channel.to, to)
+		}
+	}
+	if len(channel.to) == 0 {
+		return nil, fmt.Errorf("missing to address")
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		channel.auth = smtp.PlainAuth("", u.User.Username(), password, u.Hostname())
+	}
+	return channel, nil
+}
+
+func (c *emailChannel) Send(ctx context.Context, message Message) error {
+	// net/smtp has no context support, so the deadline of the context is only enforced on the connection
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck // closed by the client on success
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	host, _, _ := net.SplitHostPort(c.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close() //nolint:errcheck // closed by Quit on success
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if c.auth != nil {
+		if err = client.Auth(c.auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(c.from); err != nil {
+		return err
+	}
+	for _, to := range c.to {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(c.email(message, time.Now())); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// email returns the message as an email, with CRLF line endings.
+func (c *emailChannel) email(message Message, at time.Time) []byte {
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", c.from},
+		{"To", strings.Join(c.to, ", ")},
+		{"Subject", strings.ReplaceAll(message.Subject, "\n", " ")},
+		{"Date", at.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+	text := strings.ReplaceAll(message.Text, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	return buf.Bytes()
+}
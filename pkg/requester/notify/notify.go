@@ -0,0 +1,417 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// Outcome is how a job ended, as far as notifications are concerned.
+type Outcome string
+
+const (
+	OutcomeCompleted Outcome = "completed"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Outcomes are the outcomes notifications can be sent on.
+var Outcomes = []Outcome{OutcomeCompleted, OutcomeFailed}
+
+func ParseOutcome(value string) (Outcome, error) {
+	for _, outcome := range Outcomes {
+		if strings.EqualFold(value, string(outcome)) {
+			return outcome, nil
+		}
+	}
+	return "", fmt.Errorf("unknown notification outcome %q, expected %s or %s", value, OutcomeCompleted, OutcomeFailed)
+}
+
+// outcomeOf returns the outcome of a job that reached the state, and false for states that are not notified.
+// Cancelled jobs are not notified, as they were cancelled by their owner.
+func outcomeOf(state model.JobStateType) (Outcome, bool) {
+	switch state {
+	case model.JobStateCompleted, model.JobStateCompletedPartially:
+		return OutcomeCompleted, true
+	case model.JobStateError:
+		return OutcomeFailed, true
+	default:
+		return "", false
+	}
+}
+
+// Annotations of jobs that override the notifications configured on the requester. Job annotations can't contain
+// URLs, so jobs pick channels configured on the requester by name, and can't make it send notifications elsewhere.
+const (
+	// AnnotationChannel, followed by the name of a channel, sends the notifications of the job to that channel
+	// instead of the channels of the requester. It can be repeated.
+	AnnotationChannel = "notify:"
+	// AnnotationOutcome, followed by an outcome, sends notifications on that outcome instead of the outcomes of the
+	// requester. It can be repeated.
+	AnnotationOutcome = "notify-on:"
+	// ChannelNone disables the notifications of a job when used as its channel.
+	ChannelNone = "none"
+)
+
+// DefaultTemplate renders the text of notifications.
+const DefaultTemplate = `Job {{.JobID}} {{.Outcome}}{{if .Comment}}: {{.Comment}}{{end}}
+Describe it with: {{.DescribeCommand}}{{if .JobURL}}
+See it at: {{.JobURL}}{{end}}{{range .Results}}
+Results of execution {{.ExecutionID}} on node {{.NodeID}}: {{.CID}}{{if .URL}} {{.URL}}{{end}}{{end}}{{if .Results}}
+Download them with: {{.GetCommand}}{{end}}
+`
+
+const subjectTemplate = `Bacalhau job {{.JobID}} {{.Outcome}}`
+
+// DefaultIPFSGateway is the gateway the results stored on IPFS are linked to in notifications.
+const DefaultIPFSGateway = "https://ipfs.io"
+
+// DefaultTimeout is how long a notification is given to be sent to a channel.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultAttempts is how many times a notification is sent to a channel before giving up on it.
+const DefaultAttempts = 3
+
+// DefaultRetryBackoff is how long to wait before sending a notification to a channel again. The wait doubles on every
+// retry.
+const DefaultRetryBackoff = 5 * time.Second
+
+// Notification is what is known of a job when it ends, and what templates are rendered with.
+type Notification struct {
+	JobID   string
+	Outcome Outcome
+	// State is the state the job ended in.
+	State string
+	// Comment is why the job reached its state, such as the error of a failed job.
+	Comment         string
+	ClientID        string
+	RequesterNodeID string
+	Annotations     []string
+	Time            time.Time
+	// DescribeCommand and GetCommand are the CLI commands that describe the job and download its results.
+	DescribeCommand string
+	GetCommand      string
+	// JobURL links to the job, if the requester is configured with a link.
+	JobURL  string
+	Results []Result
+}
+
+// Result is the result an execution of the job published.
+type Result struct {
+	ExecutionID string
+	NodeID      string
+	CID         string
+	// URL links to the result on an IPFS gateway, or is where the result was published to if it is not on IPFS.
+	URL string
+}
+
+// Message is a rendered notification.
+type Message struct {
+	Subject      string
+	Text         string
+	Notification Notification
+}
+
+// Channel is where notifications are sent to.
+type Channel interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Config configures the notifications a requester node sends when jobs end.
+type Config struct {
+	// Channels are where notifications are sent, by name. Notifications are not sent if empty.
+	Channels map[string]Channel
+	// Outcomes are the outcomes notifications are sent on, unless jobs ask for others. All outcomes if empty.
+	Outcomes []Outcome
+	// Template renders the text of notifications from a Notification. DefaultTemplate if empty.
+	Template string
+	// JobURL is a template of a link to jobs, e.g. on a dashboard, rendered from a Notification. No link if empty.
+	JobURL string
+	// IPFSGateway is the gateway the results stored on IPFS are linked to. DefaultIPFSGateway if empty.
+	IPFSGateway string
+	// Timeout is how long notifications are given to be sent to each channel. DefaultTimeout if zero.
+	Timeout time.Duration
+	// Attempts is how many times notifications are sent to a channel that fails to take them. DefaultAttempts if zero.
+	Attempts int
+	// RetryBackoff is how long to wait before the first retry. DefaultRetryBackoff if zero.
+	RetryBackoff time.Duration
+}
+
+// Enabled returns whether notifications are sent at all.
+func (c Config) Enabled() bool {
+	return len(c.Channels) > 0
+}
+
+type NotifierParams struct {
+	JobStore jobstore.Store
+	NodeID   string
+	Config   Config
+}
+
+// Notifier sends notifications about jobs that ended to the channels of the requester, or to the channels the jobs
+// picked with their annotations.
+type Notifier struct {
+	jobStore     jobstore.Store
+	nodeID       string
+	channels     map[string]Channel
+	outcomes     []Outcome
+	ipfsGateway  string
+	timeout      time.Duration
+	attempts     int
+	retryBackoff time.Duration
+
+	text    *template.Template
+	subject *template.Template
+	jobURL  *template.Template
+}
+
+func NewNotifier(params NotifierParams) (*Notifier, error) {
+	config := params.Config
+	if config.Template == "" {
+		config.Template = DefaultTemplate
+	}
+	if len(config.Outcomes) == 0 {
+		config.Outcomes = Outcomes
+	}
+	if config.IPFSGateway == "" {
+		config.IPFSGateway = DefaultIPFSGateway
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Attempts == 0 {
+		config.Attempts = DefaultAttempts
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+
+	n := &Notifier{
+		jobStore:     params.JobStore,
+		nodeID:       params.NodeID,
+		channels:     config.Channels,
+		outcomes:     config.Outcomes,
+		ipfsGateway:  strings.TrimSuffix(config.IPFSGateway, "/"),
+		timeout:      config.Timeout,
+		attempts:     config.Attempts,
+		retryBackoff: config.RetryBackoff,
+	}
+	var err error
+	if n.text, err = parseTemplate("notification", config.Template); err != nil {
+		return nil, err
+	}
+	if n.subject, err = parseTemplate("subject", subjectTemplate); err != nil {
+		return nil, err
+	}
+	if config.JobURL != "" {
+		if n.jobURL, err = parseTemplate("job URL", config.JobURL); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Notify sends the notification of a job that reached the state, in the background, if the job wants to be notified
+// of that outcome.
+func (n *Notifier) Notify(ctx context.Context, jobID string, state model.JobStateType, comment string) {
+	outcome, ok := outcomeOf(state)
+	if !ok {
+		return
+	}
+	go func() {
+		ctx := log.Ctx(ctx).WithContext(context.Background())
+		if err := n.notify(ctx, jobID, outcome, state, comment); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to notify that job %s %s", jobID, outcome)
+		}
+	}()
+}
+
+func (n *Notifier) notify(ctx context.Context, jobID string, outcome Outcome, state model.JobStateType, comment string) error {
+	job, err := n.jobStore.GetJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	channels, err := n.channelsOf(job, outcome)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+	jobState, err := n.jobStore.GetJobState(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	message, err := n.render(n.notification(job, jobState, outcome, state, comment))
+	if err != nil {
+		return err
+	}
+	// channels are sent to concurrently, so that the retries of one don't hold up the others
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []string
+	for _, name := range channels {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := n.send(ctx, name, message); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+			}
+		}(name)
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to send to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// send sends the message to the channel, retrying with a backoff until it is sent or the attempts run out.
+func (n *Notifier) send(ctx context.Context, name string, message Message) error {
+	backoff := n.retryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, n.timeout)
+		err = n.channels[name].Send(sendCtx, message)
+		cancel()
+		if err == nil || attempt >= n.attempts {
+			return err
+		}
+		log.Ctx(ctx).Debug().Err(err).Msgf("failed to send notification of job %s to %s, retrying in %s",
+			message.Notification.JobID, name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// channelsOf returns the names of the channels the notification of the job is sent to on the outcome, which are
+// those of its annotations if it has any.
+func (n *Notifier) channelsOf(job model.Job, outcome Outcome) ([]string, error) {
+	var channels []string
+	outcomes := n.outcomes
+	var jobOutcomes []Outcome
+	for _, annotation := range job.Spec.Annotations {
+		if name, found := strings.CutPrefix(annotation, AnnotationChannel); found {
+			channels = append(channels, name)
+		} else if value, found := strings.CutPrefix(annotation, AnnotationOutcome); found {
+			jobOutcome, err := ParseOutcome(value)
+			if err != nil {
+				return nil, err
+			}
+			jobOutcomes = append(jobOutcomes, jobOutcome)
+		}
+	}
+	if len(jobOutcomes) > 0 {
+		outcomes = jobOutcomes
+	}
+	if !containsOutcome(outcomes, outcome) {
+		return nil, nil
+	}
+
+	if len(channels) == 0 {
+		for name := range n.channels {
+			channels = append(channels, name)
+		}
+		sort.Strings(channels)
+		return channels, nil
+	}
+	for _, name := range channels {
+		if name == ChannelNone {
+			return nil, nil
+		}
+		if _, ok := n.channels[name]; !ok {
+			return nil, fmt.Errorf("job %s asks to be notified on unknown channel %q", job.ID(), name)
+		}
+	}
+	return channels, nil
+}
+
+func containsOutcome(outcomes []Outcome, outcome Outcome) bool {
+	for _, o := range outcomes {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) notification(
+	job model.Job, jobState model.JobState, outcome Outcome, state model.JobStateType, comment string) Notification {
+	notification := Notification{
+		JobID:           job.ID(),
+		Outcome:         outcome,
+		State:           state.String(),
+		Comment:         comment,
+		ClientID:        job.Metadata.ClientID,
+		RequesterNodeID: n.nodeID,
+		Annotations:     job.Spec.Annotations,
+		Time:            time.Now().UTC(),
+		DescribeCommand: "bacalhau describe " + job.ID(),
+		GetCommand:      "bacalhau get " + job.ID(),
+	}
+	for _, execution := range jobState.Executions {
+		if execution.State != model.ExecutionStateCompleted {
+			continue
+		}
+		published := execution.PublishedResult
+		result := Result{
+			ExecutionID: execution.ComputeReference,
+			NodeID:      execution.NodeID,
+			CID:         published.CID,
+			URL:         published.URL,
+		}
+		if published.CID != "" {
+			result.URL = n.ipfsGateway + "/ipfs/" + published.CID
+		}
+		notification.Results = append(notification.Results, result)
+	}
+	return notification
+}
+
+func (n *Notifier) render(notification Notification) (Message, error) {
+	if n.jobURL != nil {
+		jobURL, err := execute(n.jobURL, notification)
+		if err != nil {
+			return Message{}, err
+		}
+		notification.JobURL = strings.TrimSpace(jobURL)
+	}
+	subject, err := execute(n.subject, notification)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := execute(n.text, notification)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject, Text: text, Notification: notification}, nil
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,166 @@
+//go:build unit || !integration
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChannel keeps the messages sent to it.
+type recordingChannel struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+	// failures is how many sends fail with err before the channel takes messages, failing every send if zero.
+	failures int
+}
+
+func (c *recordingChannel) Send(_ context.Context, message Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, message)
+	if c.failures > 0 && len(c.messages) > c.failures {
+		return nil
+	}
+	return c.err
+}
+
+func (c *recordingChannel) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+func TestNotifyingStore(t *testing.T) {
+	ctx := context.Background()
+	team, ops := &recordingChannel{}, &recordingChannel{err: errors.New("unavailable")}
+	store := inmemory.NewJobStore()
+	notifier, err := NewNotifier(NotifierParams{
+		JobStore: store,
+		NodeID:   "QmRequester",
+		Config: Config{
+			Channels:    map[string]Channel{"team": team, "ops": ops},
+			Outcomes:    []Outcome{OutcomeFailed},
+			JobURL:      "https://dashboard.example.com/jobs/{{.JobID}}",
+			IPFSGateway: "https://gateway.example.com/",
+			// notifications are sent to failing channels a bounded number of times
+			Attempts:     2,
+			RetryBackoff: time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+	notifyingStore := NewNotifyingStore(store, notifier)
+
+	endJob := func(id string, state model.JobStateType, annotations ...string) {
+		job := model.Job{Metadata: model.Metadata{ID: id, ClientID: "client"}, Spec: model.Spec{Annotations: annotations}}
+		require.NoError(t, notifyingStore.CreateJob(ctx, job))
+		require.NoError(t, notifyingStore.CreateExecution(ctx, model.ExecutionState{
+			JobID:            id,
+			NodeID:           "QmCompute",
+			ComputeReference: "e-" + id,
+			State:            model.ExecutionStateCompleted,
+			PublishedResult:  model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmResult"},
+		}))
+		require.NoError(t, notifyingStore.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+			JobID: id, NewState: model.JobStateInProgress,
+		}))
+		require.NoError(t, notifyingStore.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+			JobID: id, NewState: state, Comment: "the reason",
+		}))
+	}
+	waitForMessages := func(channel *recordingChannel, count int) []Message {
+		require.Eventually(t, func() bool { return len(channel.Messages()) >= count }, 5*time.Second, 10*time.Millisecond)
+		return channel.Messages()
+	}
+
+	// failed jobs are notified to every channel of the requester
+	endJob("failed-job", model.JobStateError)
+	messages := waitForMessages(team, 1)
+	waitForMessages(ops, 2)
+	require.Equal(t, "Bacalhau job failed-job failed", messages[0].Subject)
+	require.Equal(t, `Job failed-job failed: the reason
+Describe it with: bacalhau describe failed-job
+See it at: https://dashboard.example.com/jobs/failed-job
+Results of execution e-failed-job on node QmCompute: QmResult https://gateway.example.com/ipfs/QmResult
+Download them with: bacalhau get failed-job
+`, messages[0].Text)
+	require.Equal(t, "QmRequester", messages[0].Notification.RequesterNodeID)
+	require.Equal(t, "client", messages[0].Notification.ClientID)
+
+	// jobs can pick their channels and outcomes, or opt out
+	endJob("completed-job", model.JobStateCompleted, "notify:team", "notify-on:completed")
+	endJob("opted-out-job", model.JobStateError, "notify:none")
+	endJob("default-job", model.JobStateCompleted)
+	endJob("cancelled-job", model.JobStateCancelled)
+	messages = waitForMessages(team, 2)
+	require.Equal(t, "completed-job", messages[1].Notification.JobID)
+	require.Equal(t, OutcomeCompleted, messages[1].Notification.Outcome)
+	require.Equal(t, "Completed", messages[1].Notification.State)
+
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, team.Messages(), 2)
+	require.Len(t, ops.Messages(), 2)
+}
+
+func TestNotifyRetries(t *testing.T) {
+	ctx := context.Background()
+	flaky := &recordingChannel{err: errors.New("unavailable"), failures: 2}
+	store := inmemory.NewJobStore()
+	require.NoError(t, store.CreateJob(ctx, model.Job{Metadata: model.Metadata{ID: "job"}}))
+	notifier, err := NewNotifier(NotifierParams{
+		JobStore: store,
+		Config:   Config{Channels: map[string]Channel{"flaky": flaky}, RetryBackoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.notify(ctx, "job", OutcomeFailed, model.JobStateError, ""))
+	require.Len(t, flaky.Messages(), DefaultAttempts)
+
+	// channels that keep failing are given up on
+	flaky.failures = len(flaky.Messages()) + DefaultAttempts
+	require.Error(t, notifier.notify(ctx, "job", OutcomeFailed, model.JobStateError, ""))
+	require.Len(t, flaky.Messages(), 2*DefaultAttempts)
+}
+
+func TestChannelsOf(t *testing.T) {
+	notifier, err := NewNotifier(NotifierParams{Config: Config{
+		Channels: map[string]Channel{"b": &recordingChannel{}, "a": &recordingChannel{}},
+	}})
+	require.NoError(t, err)
+	channelsOf := func(outcome Outcome, annotations ...string) ([]string, error) {
+		return notifier.channelsOf(model.Job{Spec: model.Spec{Annotations: annotations}}, outcome)
+	}
+
+	channels, err := channelsOf(OutcomeCompleted, "unrelated")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, channels)
+
+	channels, err = channelsOf(OutcomeFailed, "notify:b")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, channels)
+
+	channels, err = channelsOf(OutcomeFailed, "notify-on:completed")
+	require.NoError(t, err)
+	require.Empty(t, channels)
+
+	_, err = channelsOf(OutcomeFailed, "notify:unknown")
+	require.Error(t, err)
+	_, err = channelsOf(OutcomeFailed, "notify-on:sometimes")
+	require.Error(t, err)
+}
+
+func TestNewNotifier_InvalidTemplate(t *testing.T) {
+	_, err := NewNotifier(NotifierParams{Config: Config{Template: "{{.JobID"}})
+	require.Error(t, err)
+	_, err = NewNotifier(NotifierParams{Config: Config{JobURL: "{{end}}"}})
+	require.Error(t, err)
+}
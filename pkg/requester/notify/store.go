@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+)
+
+// NotifyingStore is a job store that notifies when jobs reach a terminal state. Every change of the state of a job
+// goes through the store, so jobs are notified however they end.
+type NotifyingStore struct {
+	jobstore.Store
+	notifier *Notifier
+}
+
+func NewNotifyingStore(store jobstore.Store, notifier *Notifier) *NotifyingStore {
+	return &NotifyingStore{Store: store, notifier: notifier}
+}
+
+func (s *NotifyingStore) UpdateJobState(ctx context.Context, request jobstore.UpdateJobStateRequest) error {
+	err := s.Store.UpdateJobState(ctx, request)
+	if err == nil && request.NewState.IsTerminal() {
+		s.notifier.Notify(ctx, request.JobID, request.NewState, request.Comment)
+	}
+	return err
+}
+
+// compile-time interface check
+var _ jobstore.Store = (*NotifyingStore)(nil)
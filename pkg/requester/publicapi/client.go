@@ -2,27 +2,31 @@ package publicapi
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
 
-// APIRetryCount - for some queries (like read events and read state)
-// we want to fail early (10 seconds should be ample time)
-// but retry a number of times - this is to avoid network
-// flakes failing the canary
+// APIRetryCount is how many times a part of an upload is sent before giving up. Transient failures of other
+// requests are retried by the underlying APIClient.
 const APIRetryCount = 5
-const APIShortTimeoutSeconds = 10
 
 // RequesterAPIClient is a utility for interacting with a node's API server.
 type RequesterAPIClient struct {
@@ -53,11 +57,7 @@ func (apiClient *RequesterAPIClient) List(
 	sortReverse bool,
 ) (
 	[]*model.JobWithInfo, error) {
-	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.List")
-	defer span.End()
-
-	req := listRequest{
-		ClientID:    system.GetClientID(),
+	return apiClient.ListJobs(ctx, ListRequest{
 		MaxJobs:     maxJobs,
 		JobID:       idFilter,
 		IncludeTags: includeTags,
@@ -65,8 +65,16 @@ func (apiClient *RequesterAPIClient) List(
 		ReturnAll:   returnAll,
 		SortBy:      sortBy,
 		SortReverse: sortReverse,
-	}
+	})
+}
 
+// ListJobs lists the jobs matching the request, which can filter them by labels, states and creation time. The
+// request is sent with the ID of the client.
+func (apiClient *RequesterAPIClient) ListJobs(ctx context.Context, req ListRequest) ([]*model.JobWithInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.List")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
 	var res listResponse
 	if err := apiClient.Post(ctx, APIPrefix+"list", req, &res); err != nil {
 		e := err
@@ -76,6 +84,36 @@ func (apiClient *RequesterAPIClient) List(
 	return res.Jobs, nil
 }
 
+// Search finds the jobs whose labels match all the label selectors, e.g. project=alpha or team=ml-*.
+func (apiClient *RequesterAPIClient) Search(
+	ctx context.Context, labels []string, maxJobs int, returnAll bool) (SearchResponse, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Search")
+	defer span.End()
+
+	query := url.Values{"label": labels}
+	query.Set("client_id", system.GetClientID())
+	query.Set("limit", strconv.Itoa(maxJobs))
+	query.Set("return_all", strconv.FormatBool(returnAll))
+	var res SearchResponse
+	if err := apiClient.APIClient.Get(ctx, APIPrefix+SearchRoute, query, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Nodes lists the compute nodes known to the requester, with the load they reported recently.
+func (apiClient *RequesterAPIClient) Nodes(ctx context.Context) ([]model.NodeInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Nodes")
+	defer span.End()
+
+	req := NodesRequest{ClientID: system.GetClientID()}
+	var res NodesResponse
+	if err := apiClient.Post(ctx, APIPrefix+"nodes", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Nodes, nil
+}
+
 // Cancel will request that the job with the specified ID is stopped. The JobInfo will be returned if the cancel
 // was submitted. If no match is found, Cancel returns false with a nil error.
 func (apiClient *RequesterAPIClient) Cancel(ctx context.Context, jobID string, reason string) (*model.JobState, error) {
@@ -115,6 +153,123 @@ func (apiClient *RequesterAPIClient) Cancel(ctx context.Context, jobID string, r
 	return res.State, nil
 }
 
+// Update amends the spec of a running job. The update must be based on the current spec version of the job, and
+// the updated job is returned.
+func (apiClient *RequesterAPIClient) Update(ctx context.Context, update model.JobUpdatePayload) (*model.Job, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Update")
+	defer span.End()
+
+	if update.JobID == "" {
+		return &model.Job{}, fmt.Errorf("jobID must be non-empty in an Update call")
+	}
+	update.ClientID = system.GetClientID()
+
+	var res updateResponse
+	if err := apiClient.PatchSigned(ctx, APIPrefix+"update", update, &res); err != nil {
+		return &model.Job{}, err
+	}
+
+	return res.Job, nil
+}
+
+// Migrate asks the requester to move the executions of a compute node that have not started yet to other nodes.
+func (apiClient *RequesterAPIClient) Migrate(ctx context.Context, req MigrateRequest) (MigrateResponse, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Migrate")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	req.IssuedAt = time.Now()
+	req.Nonce = uuid.NewString()
+	var res MigrateResponse
+	if err := apiClient.PostSigned(ctx, APIPrefix+MigrateRoute, req, &res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// AdminNode asks the requester to forward an admin request to a compute node, and returns the resulting state of the
+// node.
+func (apiClient *RequesterAPIClient) AdminNode(ctx context.Context, req NodeAdminRequest) (NodeAdminResponse, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.AdminNode")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	req.IssuedAt = time.Now()
+	req.Nonce = uuid.NewString()
+	var res NodeAdminResponse
+	if err := apiClient.PostSigned(ctx, APIPrefix+AdminNodeRoute, req, &res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Export asks the requester for a snapshot of all its jobs.
+func (apiClient *RequesterAPIClient) Export(ctx context.Context) (jobstore.Snapshot, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Export")
+	defer span.End()
+
+	req := ExportRequest{ClientID: system.GetClientID()}
+	var res jobstore.Snapshot
+	if err := apiClient.PostSigned(ctx, APIPrefix+ExportRoute, req, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Import asks the requester to import a snapshot of the jobs of another requester.
+func (apiClient *RequesterAPIClient) Import(ctx context.Context, req ImportRequest) (jobstore.ImportSnapshotResult, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Import")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	var res jobstore.ImportSnapshotResult
+	if err := apiClient.PostSigned(ctx, APIPrefix+ImportRoute, req, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Reserve asks the requester to reserve capacity across the network for the jobs of the client during a time window.
+func (apiClient *RequesterAPIClient) Reserve(ctx context.Context, req ReserveRequest) (model.CapacityReservation, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Reserve")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+	var res model.CapacityReservation
+	if err := apiClient.PostSigned(ctx, APIPrefix+ReserveRoute, req, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Release asks the requester to release a capacity reservation.
+func (apiClient *RequesterAPIClient) Release(ctx context.Context, reservationID string) (model.CapacityReservation, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Release")
+	defer span.End()
+
+	req := ReleaseRequest{ClientID: system.GetClientID(), ReservationID: reservationID}
+	var res model.CapacityReservation
+	if err := apiClient.PostSigned(ctx, APIPrefix+ReleaseRoute, req, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Reservations lists the capacity reservations of the requester that have not ended yet.
+func (apiClient *RequesterAPIClient) Reservations(ctx context.Context) ([]model.CapacityReservation, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Reservations")
+	defer span.End()
+
+	req := ReservationsRequest{ClientID: system.GetClientID()}
+	var res []model.CapacityReservation
+	if err := apiClient.PostSigned(ctx, APIPrefix+ReservationsRoute, req, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 // Get returns job data for a particular job ID. If no match is found, Get returns false with a nil error.
 func (apiClient *RequesterAPIClient) Get(ctx context.Context, jobID string) (*model.JobWithInfo, bool, error) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Get")
@@ -144,26 +299,35 @@ func (apiClient *RequesterAPIClient) GetJobState(ctx context.Context, jobID stri
 		return model.JobState{}, fmt.Errorf("jobID must be non-empty in a GetJobStates call")
 	}
 
-	req := stateRequest{
+	return apiClient.getJobState(ctx, stateRequest{
 		ClientID: system.GetClientID(),
 		JobID:    jobID,
+	})
+}
+
+// GetJobStateAt returns the state the job was in at the given point in time.
+func (apiClient *RequesterAPIClient) GetJobStateAt(ctx context.Context, jobID string, at time.Time) (model.JobState, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.GetJobStateAt")
+	defer span.End()
+
+	if jobID == "" {
+		return model.JobState{}, fmt.Errorf("jobID must be non-empty in a GetJobStateAt call")
 	}
 
+	return apiClient.getJobState(ctx, stateRequest{
+		ClientID: system.GetClientID(),
+		JobID:    jobID,
+		At:       &at,
+	})
+}
+
+func (apiClient *RequesterAPIClient) getJobState(ctx context.Context, req stateRequest) (model.JobState, error) {
 	var res stateResponse
-	var outerErr error
-
-	for i := 0; i < APIRetryCount; i++ {
-		shortTimeoutCtx, cancelFn := context.WithTimeout(ctx, time.Second*APIShortTimeoutSeconds)
-		defer cancelFn()
-		err := apiClient.Post(shortTimeoutCtx, APIPrefix+"states", req, &res)
-		if err == nil {
-			return res.State, nil
-		} else {
-			log.Ctx(ctx).Debug().Err(err).Msg("apiclient read state error")
-			outerErr = err
-		}
+	if err := apiClient.Post(ctx, APIPrefix+"states", req, &res); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("apiclient read state error")
+		return model.JobState{}, err
 	}
-	return model.JobState{}, outerErr
+	return res.State, nil
 }
 
 func (apiClient *RequesterAPIClient) GetJobStateResolver() *job.StateResolver {
@@ -177,7 +341,108 @@ func (apiClient *RequesterAPIClient) GetJobStateResolver() *job.StateResolver {
 	stateLoader := func(ctx context.Context, jobID string) (model.JobState, error) {
 		return apiClient.GetJobState(ctx, jobID)
 	}
-	return job.NewStateResolver(jobLoader, stateLoader)
+	resolver := job.NewStateResolver(jobLoader, stateLoader)
+	resolver.SetWatcher(apiClient.WatchJob)
+	return resolver
+}
+
+// WatchJob follows the events of the job over a websocket, and signals the returned channel whenever one arrives. The
+// channel is closed when the context is done or the connection is lost.
+func (apiClient *RequesterAPIClient) WatchJob(ctx context.Context, jobID string) (<-chan struct{}, error) {
+	conn, err := apiClient.dialWebsocket(ctx, "websocket/events", url.Values{"job_id": []string{jobID}})
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// FollowJobStates passes the changes to the state of the job and of its executions to handle as they happen, starting
+// with the changes the job went through before the call. It returns once the job has ended, with the error of the
+// context once it is done, or with the first error returned by handle.
+func (apiClient *RequesterAPIClient) FollowJobStates(ctx context.Context, jobID string, handle func(model.JobHistory) error) error {
+	if jobID == "" {
+		return fmt.Errorf("jobID must be non-empty in a FollowJobStates call")
+	}
+	conn, err := apiClient.dialWebsocket(ctx, StatesRoute, url.Values{"job_id": []string{jobID}})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var event model.JobHistory
+		if err = conn.ReadJSON(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return fmt.Errorf("failed to follow the state of job %s: %w", jobID, err)
+		}
+		if err = handle(event); err != nil {
+			return err
+		}
+	}
+}
+
+// dialWebsocket opens a websocket to the route with the default headers of the client. Errors the server responds
+// with instead of upgrading the connection are returned as they are for other requests.
+func (apiClient *RequesterAPIClient) dialWebsocket(ctx context.Context, route string, query url.Values) (*websocket.Conn, error) {
+	u, _ := url.Parse(apiClient.APIClient.BaseURI.String())
+	u.Scheme = "ws"
+	if apiClient.APIClient.BaseURI.Scheme == "https" {
+		u.Scheme = "wss"
+	}
+	u.Path = APIPrefix + route
+	u.RawQuery = query.Encode()
+	header := http.Header{}
+	for name, value := range apiClient.DefaultHeaders {
+		header.Set(name, value)
+	}
+
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if res != nil {
+		defer res.Body.Close()
+	}
+	if err != nil && res != nil && res.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(res.Body)
+		var serverError *bacerrors.ErrorResponse
+		if model.JSONUnmarshalWithMax(body, &serverError) == nil && serverError != nil {
+			return nil, serverError
+		}
+		if message := strings.TrimSpace(string(body)); message != "" {
+			return nil, fmt.Errorf("%s: %s", res.Status, message)
+		}
+	}
+	return conn, err
 }
 
 func (apiClient *RequesterAPIClient) GetEvents(
@@ -197,25 +462,37 @@ func (apiClient *RequesterAPIClient) GetEvents(
 		Options:  options,
 	}
 
-	// Test if the context has been canceled before making the request.
 	var res eventsResponse
-	var outerErr error
-
-	for i := 0; i < APIRetryCount; i++ {
-		shortTimeoutCtx, cancelFn := context.WithTimeout(ctx, time.Second*APIShortTimeoutSeconds)
-		defer cancelFn()
-		err = apiClient.Post(shortTimeoutCtx, APIPrefix+"events", req, &res)
-		if err == nil {
-			return res.Events, nil
-		} else {
-			log.Ctx(ctx).Debug().Err(err).Msg("apiclient read events error")
-			outerErr = err
-			if strings.Contains(err.Error(), "context canceled") {
-				outerErr = bacerrors.NewContextCanceledError(ctx.Err().Error())
-			}
-		}
+	if err = apiClient.Post(ctx, APIPrefix+"events", req, &res); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("apiclient read events error")
+		return nil, err
+	}
+	return res.Events, nil
+}
+
+// GetCloudEvents returns the history of the job as CloudEvents.
+func (apiClient *RequesterAPIClient) GetCloudEvents(
+	ctx context.Context,
+	jobID string,
+	options EventFilterOptions) (events []model.CloudEvent, err error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.GetCloudEvents")
+	defer span.End()
+
+	if jobID == "" {
+		return nil, fmt.Errorf("jobID must be non-empty in a GetCloudEvents call")
+	}
+
+	req := eventsRequest{
+		ClientID: system.GetClientID(),
+		JobID:    jobID,
+		Options:  options,
+		Format:   EventFormatCloudEvents,
+	}
+	if err = apiClient.Post(ctx, APIPrefix+"events", req, &events); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("apiclient read cloud events error")
+		return nil, err
 	}
-	return nil, outerErr
+	return events, nil
 }
 
 func (apiClient *RequesterAPIClient) GetResults(ctx context.Context, jobID string) (results []model.PublishedResult, err error) {
@@ -251,6 +528,7 @@ func (apiClient *RequesterAPIClient) Submit(
 		ClientID:   system.GetClientID(),
 		APIVersion: j.APIVersion,
 		Spec:       &j.Spec,
+		Name:       j.Metadata.Name,
 	}
 
 	var res submitResponse
@@ -286,7 +564,8 @@ func (apiClient *RequesterAPIClient) Logs(
 	jobID string,
 	executionID string,
 	withHistory bool,
-	follow bool) (*websocket.Conn, error) {
+	follow bool,
+	tail int) (*websocket.Conn, error) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Logs")
 	defer span.End()
 
@@ -319,8 +598,42 @@ func (apiClient *RequesterAPIClient) Logs(
 		ExecutionID: executionID,
 		WithHistory: withHistory,
 		Follow:      follow,
+		Tail:        tail,
 	}
 
+	return dialSigned(ctx, apiClient, "logs", jobID, payload)
+}
+
+// Attach connects to the stdin, stdout and stderr of an execution of an interactive job submitted by this client.
+// AttachInput messages written to the connection go to the stdin of the execution, and its output is read as it is
+// from Logs.
+func (apiClient *RequesterAPIClient) Attach(ctx context.Context, jobID string, executionID string) (*websocket.Conn, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Attach")
+	defer span.End()
+
+	if jobID == "" || executionID == "" {
+		return nil, fmt.Errorf("jobID and executionID must be non-empty in an attach call")
+	}
+
+	jobInfo, found, err := apiClient.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, bacerrors.NewJobNotFound(jobID)
+	}
+
+	payload := model.AttachPayload{
+		ClientID:    system.GetClientID(),
+		JobID:       jobInfo.State.JobID,
+		ExecutionID: executionID,
+	}
+	return dialSigned(ctx, apiClient, "attach", payload.JobID, payload)
+}
+
+// dialSigned opens a websocket to the route, and sends the signed payload that starts the request. The job is also
+// given in the URL, for the request to be routed to the requester that owns it before the payload is sent.
+func dialSigned(ctx context.Context, apiClient *RequesterAPIClient, route string, jobID string, payload any) (*websocket.Conn, error) {
 	req, err := publicapi.SignRequest(payload)
 	if err != nil {
 		return nil, err
@@ -328,7 +641,8 @@ func (apiClient *RequesterAPIClient) Logs(
 
 	u, _ := url.Parse(apiClient.APIClient.BaseURI.String())
 	u.Scheme = "ws"
-	u.Path = APIPrefix + "logs"
+	u.Path = APIPrefix + route
+	u.RawQuery = url.Values{"job_id": []string{jobID}}.Encode()
 
 	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil) //nolint:bodyclose
 	if err != nil {
@@ -338,7 +652,7 @@ func (apiClient *RequesterAPIClient) Logs(
 
 	err = c.WriteJSON(req)
 	if err != nil {
-		log.Ctx(ctx).Error().Msg("Failed to write the JSON for the start of the logs request")
+		log.Ctx(ctx).Error().Msgf("Failed to write the JSON for the start of the %s request", route)
 		return nil, err
 	}
 
@@ -357,3 +671,90 @@ func (apiClient *RequesterAPIClient) Debug(ctx context.Context) (map[string]mode
 
 	return res, nil
 }
+
+// DebugJob returns the info of the nodes a job was scheduled on, and the recent logs of the requester about the job.
+func (apiClient *RequesterAPIClient) DebugJob(ctx context.Context, jobID string) (JobDebugInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.DebugJob")
+	defer span.End()
+
+	req := debugJobRequest{
+		ClientID: system.GetClientID(),
+		JobID:    jobID,
+	}
+	var res JobDebugInfo
+	if err := apiClient.Post(ctx, APIPrefix+"debug/job", req, &res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Upload stages size bytes of data on the requester node in parts, and returns the storage spec jobs can use to mount
+// it. Parts that fail to upload are retried from where the node's copy of the upload ends, so large uploads survive
+// flaky connections.
+func (apiClient *RequesterAPIClient) Upload(ctx context.Context, data io.ReaderAt, size int64) (model.StorageSpec, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Upload")
+	defer span.End()
+
+	clientID := system.GetClientID()
+	var initRes uploadInitResponse
+	if err := apiClient.Post(ctx, APIPrefix+"upload/init", uploadInitRequest{ClientID: clientID, Size: size}, &initRes); err != nil {
+		return model.StorageSpec{}, err
+	}
+
+	hash := sha256.New()
+	part := make([]byte, system.Min(initRes.MaxPartSize, size))
+	for offset := int64(0); offset < size; {
+		n, err := data.ReadAt(part[:system.Min(int64(len(part)), size-offset)], offset)
+		if err != nil && err != io.EOF {
+			return model.StorageSpec{}, err
+		}
+		if n == 0 {
+			return model.StorageSpec{}, fmt.Errorf("data ended after %d of %d bytes", offset, size)
+		}
+		if err = apiClient.uploadPart(ctx, clientID, initRes.UploadID, offset, part[:n]); err != nil {
+			return model.StorageSpec{}, err
+		}
+		hash.Write(part[:n])
+		offset += int64(n)
+	}
+
+	var completeRes uploadCompleteResponse
+	err := apiClient.Post(ctx, APIPrefix+"upload/complete", uploadCompleteRequest{
+		ClientID: clientID,
+		UploadID: initRes.UploadID,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+	}, &completeRes)
+	return completeRes.Spec, err
+}
+
+// uploadPart uploads a part, retrying if it fails and the node did not receive it.
+func (apiClient *RequesterAPIClient) uploadPart(ctx context.Context, clientID, uploadID string, offset int64, part []byte) error {
+	sum := sha256.Sum256(part)
+	query := url.Values{
+		"client_id": {clientID},
+		"upload_id": {uploadID},
+		"offset":    {strconv.FormatInt(offset, 10)},
+		"checksum":  {hex.EncodeToString(sum[:])},
+	}
+
+	var err error
+	for attempt := 0; attempt < APIRetryCount; attempt++ {
+		var partRes uploadStatusResponse
+		if err = apiClient.PostBytes(ctx, APIPrefix+"upload/part", query, part, &partRes); err == nil {
+			return nil
+		}
+		log.Ctx(ctx).Debug().Err(err).Msgf("failed to upload part at offset %d of upload %s", offset, uploadID)
+
+		// the part may have been received even though the response was lost
+		var statusRes uploadStatusResponse
+		statusErr := apiClient.Post(ctx, APIPrefix+"upload/status", uploadStatusRequest{ClientID: clientID, UploadID: uploadID}, &statusRes)
+		if statusErr == nil && statusRes.Offset == offset+int64(len(part)) {
+			return nil
+		}
+		if statusErr == nil && statusRes.Offset != offset {
+			return fmt.Errorf("upload %s continues at offset %d, expected %d", uploadID, statusRes.Offset, offset)
+		}
+	}
+	return err
+}
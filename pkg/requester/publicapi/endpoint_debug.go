@@ -3,7 +3,15 @@ package publicapi
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 )
 
@@ -33,3 +41,96 @@ func (s *RequesterAPIServer) debug(res http.ResponseWriter, req *http.Request) {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+type debugJobRequest struct {
+	ClientID string `json:"ClientID" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+	JobID    string `json:"JobID" example:"9304c616-291f-41ad-b862-54e133c0149e"`
+}
+
+// JobDebugInfo is what the requester node knows about a job beyond its spec, state and history, to help debug it.
+type JobDebugInfo struct {
+	// Nodes is the latest info of the compute nodes the job was scheduled on.
+	Nodes []model.NodeInfo `json:"Nodes"`
+	// Logs are the recent log lines of the requester node that mention the job, with the secrets of the job redacted.
+	Logs []string `json:"Logs"`
+	// Scheduler are the decisions the scheduler of the requester node made about the job, oldest first.
+	Scheduler []requester.SchedulerAuditEntry `json:"Scheduler"`
+}
+
+// debugJob godoc
+//
+//	@ID				pkg/requester/publicapi/debugJob
+//	@Summary		Returns the info of the nodes a job was scheduled on, and the recent logs and decisions of this node about the job.
+//	@Description	The secrets of the job are redacted from the logs and decisions. Only admins can debug jobs.
+//	@Tags			Health
+//	@Accept			json
+//	@Produce		json
+//	@Param			debugJobRequest	body		debugJobRequest	true	" "
+//	@Success		200				{object}	JobDebugInfo
+//	@Failure		400				{object}	string
+//	@Failure		404				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/requester/debug/job [post]
+func (s *RequesterAPIServer) debugJob(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var debugReq debugJobRequest
+	if err := json.NewDecoder(req.Body).Decode(&debugReq); err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, debugReq.ClientID)
+	res.Header().Set(handlerwrapper.HTTPHeaderJobID, debugReq.JobID)
+	if !s.resolveJobOrFail(ctx, res, &debugReq.JobID) {
+		return
+	}
+
+	j, err := s.jobStore.GetJob(ctx, debugReq.JobID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusNotFound)
+		return
+	}
+	jobState, err := s.jobStore.GetJobState(ctx, debugReq.JobID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusNotFound)
+		return
+	}
+
+	// logs and decisions are whatever was logged, so the secrets of the job are replaced wherever they appear
+	var replacements []string
+	for _, secret := range job.Secrets(j) {
+		replacements = append(replacements, secret, job.RedactedValue)
+	}
+	redact := strings.NewReplacer(replacements...)
+
+	info := JobDebugInfo{Nodes: []model.NodeInfo{}, Logs: []string{}, Scheduler: []requester.SchedulerAuditEntry{}}
+	for _, line := range logger.RecentLogsMatching(jobState.JobID) {
+		info.Logs = append(info.Logs, redact.Replace(line))
+	}
+	for _, entry := range s.schedulerAudit.Entries(jobState.JobID) {
+		entry.Reason = redact.Replace(entry.Reason)
+		info.Scheduler = append(info.Scheduler, entry)
+	}
+	seen := make(map[string]bool)
+	for _, execution := range jobState.Executions {
+		if seen[execution.NodeID] || s.nodeInfoStore == nil {
+			continue
+		}
+		seen[execution.NodeID] = true
+		peerID, err := peer.Decode(execution.NodeID)
+		if err != nil {
+			continue
+		}
+		nodeInfo, err := s.nodeInfoStore.Get(ctx, peerID)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("no info of node %s to debug job %s", execution.NodeID, jobState.JobID)
+			continue
+		}
+		info.Nodes = append(info.Nodes, nodeInfo)
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(info)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,99 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+)
+
+// NodeAdminRequest is the signed payload sent to administer a compute node through the requester, such as when the
+// API of the node is not reachable. All changes are optional, and a request with no changes simply returns the current
+// status of the node.
+type NodeAdminRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	// NodeID is the compute node to administer.
+	NodeID string `json:"NodeID" validate:"required"`
+
+	// IssuedAt and Nonce stop the request from being replayed. Requests issued too long ago are rejected, and each
+	// nonce is only accepted once.
+	IssuedAt time.Time `json:"IssuedAt" validate:"required"`
+	Nonce    string    `json:"Nonce" validate:"required"`
+
+	compute.NodeAdminChanges
+}
+
+func (r NodeAdminRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type nodeAdminRequest = publicapi.SignedRequest[NodeAdminRequest] //nolint:unused // Swagger wants this
+
+// NodeAdminResponse describes the state of the compute node after the admin request has been applied.
+type NodeAdminResponse = compute.AdminNodeResponse
+
+// adminNode godoc
+//
+//	@ID				pkg/requester/publicapi/adminNode
+//	@Summary		Inspects and adjusts the configuration of a compute node.
+//	@Description	Forwards the changes to the compute node, which applies them if the requester is one of its trusted
+//	@Description	requesters and the client was passed to --admin-client-id of both the requester and the node. Each request
+//	@Description	is only accepted once, within minutes of being issued.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			nodeAdminRequest	body		nodeAdminRequest	true	" "
+//	@Success		200					{object}	NodeAdminResponse
+//	@Failure		400					{object}	string
+//	@Failure		401					{object}	string
+//	@Failure		403					{object}	string
+//	@Failure		404					{object}	string
+//	@Failure		500					{object}	string
+//	@Router			/requester/admin/node [post]
+func (s *RequesterAPIServer) adminNode(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	request, ok := unmarshalAdmin[NodeAdminRequest](s, res, req)
+	if !ok {
+		return
+	}
+	if s.computeEndpoint == nil {
+		err := errors.New("compute nodes can't be administered through this requester")
+		publicapi.HTTPError(ctx, res, err, http.StatusForbidden)
+		return
+	}
+	if err := s.adminReplays.Check(request.Nonce, request.IssuedAt, time.Now()); err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("rejected admin request: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	nodeID, err := s.resolver.ResolveNodeID(ctx, request.NodeID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, resolveErrorStatus(err))
+		return
+	}
+
+	response, err := s.computeEndpoint.AdminNode(ctx, compute.AdminNodeRequest{
+		RoutingMetadata: compute.RoutingMetadata{
+			SourcePeerID: s.nodeID,
+			TargetPeerID: nodeID,
+		},
+		NodeAdminChanges: request.NodeAdminChanges,
+		ClientID:         request.ClientID,
+	})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("failed to administer node %s: %w", nodeID, err), http.StatusBadRequest)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(response)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
@@ -16,6 +16,10 @@ func (s *RequesterAPIServer) approve(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if !s.resolveJobOrFail(ctx, res, &approval.JobID) {
+		return
+	}
+
 	ctx = log.Ctx(ctx).With().Str("JobID", approval.JobID).Logger().WithContext(ctx)
 	err = s.requester.ApproveJob(ctx, approval)
 	if err != nil {
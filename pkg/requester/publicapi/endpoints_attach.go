@@ -0,0 +1,161 @@
+package publicapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/logstream"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type attachRequest = publicapi.SignedRequest[model.AttachPayload] //nolint:unused // Swagger wants this
+
+// AttachInput is what clients attached to an execution send over the websocket: data for the stdin of the execution,
+// or Close to close its stdin.
+type AttachInput struct {
+	Data  string
+	Close bool
+}
+
+// attach godoc
+//
+//	@ID				pkg/requester/publicapi/attach
+//	@Summary		Attaches to the stdin, stdout and stderr of an execution of an interactive job
+//	@Description	Streams AttachInput messages to the stdin of the execution, and its output back as it is for logs.
+//	@Description	Only the client that submitted the job can attach to it.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			attachRequest	body		attachRequest	true	" "
+//	@Success		200				{object}	string
+//	@Failure		400				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/requester/attach [post]
+//
+//nolint:funlen
+func (s *RequesterAPIServer) attach(res http.ResponseWriter, req *http.Request) {
+	var upgrader = websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		errorResponse := bacerrors.ErrorToErrorResponse(errors.Errorf("failed to upgrade websocket connection: %s", err))
+		http.Error(res, errorResponse, http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	ctx := req.Context()
+	closeWith := func(code int, err error) {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, bacerrors.ErrorToErrorResponse(err)))
+	}
+
+	var srequest json.RawMessage
+	if err = conn.ReadJSON(&srequest); err != nil {
+		closeWith(websocket.CloseAbnormalClosure, errors.Errorf("error reading signed request: %s", err))
+		return
+	}
+	payload, err := publicapi.UnmarshalSigned[model.AttachPayload](ctx, bytes.NewReader(srequest))
+	if err != nil {
+		closeWith(websocket.CloseAbnormalClosure, errors.New("failed to decode request"))
+		return
+	}
+
+	ctx = system.AddJobIDToBaggage(ctx, payload.JobID)
+
+	payload.JobID, err = s.resolveJob(ctx, payload.JobID)
+	var job model.Job
+	if err == nil {
+		job, err = s.jobStore.GetJob(ctx, payload.JobID)
+	}
+	if err != nil {
+		closeWith(websocket.CloseAbnormalClosure, err)
+		return
+	}
+
+	// Attaching writes to the stdin of the job, so unlike logs it is not open to clients watching the job.
+	if job.Metadata.ClientID != payload.ClientID {
+		log.Ctx(ctx).Debug().Msgf("Mismatched ClientIDs for attach, existing job: %s and attach request: %s",
+			job.Metadata.ClientID, payload.ClientID)
+		closeWith(websocket.ClosePolicyViolation, errors.Errorf("mismatched client id: %s", payload.ClientID))
+		return
+	}
+	if !job.Spec.Interactive {
+		closeWith(websocket.ClosePolicyViolation, errors.Errorf("job %s is not interactive", job.ID()))
+		return
+	}
+
+	// The log server of the compute node running the execution also attaches to it
+	response, err := s.requester.ReadLogs(ctx, requester.ReadLogsRequest{
+		JobID:       job.ID(),
+		ExecutionID: payload.ExecutionID,
+		Follow:      true,
+	})
+	if err != nil {
+		closeWith(websocket.CloseAbnormalClosure, errors.Errorf("failed to find execution: %s", err))
+		return
+	}
+	if response.ExecutionComplete {
+		closeWith(websocket.CloseNormalClosure, errors.Errorf("execution %s has finished", payload.ExecutionID))
+		return
+	}
+
+	if s.host == nil {
+		closeWith(websocket.CloseInternalServerErr, errors.New("requester can't attach to executions"))
+		return
+	}
+	client, err := logstream.NewAttachClient(ctx, s.host, response.Address)
+	if err != nil {
+		closeWith(websocket.CloseInternalServerErr, errors.Errorf("attach client create failure: %s", err))
+		return
+	}
+	defer client.Close()
+
+	if err = client.Connect(ctx, payload.ExecutionID); err != nil {
+		closeWith(websocket.CloseInternalServerErr, errors.Errorf("attach connect failure: %s", err))
+		return
+	}
+
+	go func() {
+		for {
+			var input AttachInput
+			if readErr := conn.ReadJSON(&input); readErr != nil {
+				_ = client.CloseStdin()
+				return
+			}
+			if input.Data != "" {
+				if _, writeErr := client.Write([]byte(input.Data)); writeErr != nil {
+					log.Ctx(ctx).Debug().Err(writeErr).Msg("failed to write to stdin of execution")
+					return
+				}
+			}
+			if input.Close {
+				_ = client.CloseStdin()
+				return
+			}
+		}
+	}()
+
+	for {
+		frame, err := client.ReadDataFrame(ctx)
+		if err != nil {
+			if err != io.EOF {
+				log.Ctx(ctx).Debug().Err(err).Msg("Attached stream read failure")
+			}
+			break
+		}
+		if err = s.writeDataFrame(ctx, conn, frame); err != nil {
+			break
+		}
+	}
+
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
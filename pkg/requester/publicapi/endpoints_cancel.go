@@ -46,6 +46,10 @@ func (s *RequesterAPIServer) cancel(res http.ResponseWriter, req *http.Request)
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, jobCancelPayload.ClientID)
 	ctx = system.AddJobIDToBaggage(ctx, jobCancelPayload.ClientID)
 
+	if !s.resolveJobOrFail(ctx, res, &jobCancelPayload.JobID) {
+		return
+	}
+
 	// Get the job, check it exists and check it belongs to the same client
 	job, err := s.jobStore.GetJob(ctx, jobCancelPayload.JobID)
 	if err != nil {
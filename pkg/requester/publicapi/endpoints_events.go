@@ -2,6 +2,7 @@ package publicapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
@@ -9,10 +10,16 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
 )
 
+// EventFormatCloudEvents is the format to ask events in to get them as CloudEvents, rather than as the job history and
+// events of bacalhau.
+const EventFormatCloudEvents = "cloudevents"
+
 type eventsRequest struct {
 	ClientID string             `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
 	JobID    string             `json:"job_id" example:"9304c616-291f-41ad-b862-54e133c0149e"`
 	Options  EventFilterOptions `json:"filters"` // Records the number of seconds since the unix epoch (UTC)
+	// Format of the events in the response. If set to cloudevents, the response is a batch of CloudEvents.
+	Format string `json:"format,omitempty" example:"cloudevents"`
 }
 
 type eventsResponse struct {
@@ -46,13 +53,39 @@ func (s *RequesterAPIServer) events(res http.ResponseWriter, req *http.Request)
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, eventsReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, eventsReq.JobID)
 
+	cloudEvents, err := parseEventFormat(eventsReq.Format)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	ctx := req.Context()
+	if !s.resolveJobOrFail(ctx, res, &eventsReq.JobID) {
+		return
+	}
 	events, err := s.jobStore.GetJobHistory(ctx, eventsReq.JobID, eventsReq.Options)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if cloudEvents {
+		batch := make([]model.CloudEvent, 0, len(events))
+		for _, event := range events {
+			cloudEvent, err := model.NewJobHistoryCloudEvent(s.eventSource, event)
+			if err != nil {
+				continue
+			}
+			batch = append(batch, cloudEvent)
+		}
+		res.Header().Set("Content-Type", model.CloudEventsBatchContentType)
+		res.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(res).Encode(batch); err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	res.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(res).Encode(eventsResponse{
 		Events: events,
@@ -62,3 +95,15 @@ func (s *RequesterAPIServer) events(res http.ResponseWriter, req *http.Request)
 		return
 	}
 }
+
+// parseEventFormat returns whether events are asked for as CloudEvents.
+func parseEventFormat(format string) (bool, error) {
+	switch format {
+	case "":
+		return false, nil
+	case EventFormatCloudEvents:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown event format %q, expected %q or none", format, EventFormatCloudEvents)
+	}
+}
@@ -0,0 +1,74 @@
+package publicapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+)
+
+// explorerStats godoc
+//
+//	@ID				pkg/requester/publicapi/explorerStats
+//	@Summary		Returns aggregate stats of the public jobs.
+//	@Description	Counts the public jobs by state and engine. Served without authentication when the explorer is
+//	@Description	enabled with --explorer, and cached for a short while.
+//	@Tags			Explorer
+//	@Produce		json
+//	@Success		200	{object}	explorer.Stats
+//	@Failure		405	{object}	string
+//	@Failure		500	{object}	string
+//	@Router			/requester/explorer/stats [get]
+func (s *RequesterAPIServer) explorerStats(res http.ResponseWriter, req *http.Request) {
+	serveExplorer(res, req, s.explorer.Stats)
+}
+
+// explorerJobs godoc
+//
+//	@ID				pkg/requester/publicapi/explorerJobs
+//	@Summary		Lists the most recent public jobs, redacted.
+//	@Description	Lists the most recent public jobs, most recent first, with their fields shown, hashed or hidden
+//	@Description	by the redactions passed to --explorer-redact. Served without authentication when the explorer is
+//	@Description	enabled with --explorer, and cached for a short while.
+//	@Tags			Explorer
+//	@Produce		json
+//	@Success		200	{object}	[]explorer.Job
+//	@Failure		405	{object}	string
+//	@Failure		500	{object}	string
+//	@Router			/requester/explorer/jobs [get]
+func (s *RequesterAPIServer) explorerJobs(res http.ResponseWriter, req *http.Request) {
+	serveExplorer(res, req, s.explorer.Jobs)
+}
+
+// explorerNodes godoc
+//
+//	@ID				pkg/requester/publicapi/explorerNodes
+//	@Summary		Returns counts of the compute nodes.
+//	@Description	Counts the compute nodes known to the requester by engine, and the executions they run. Served
+//	@Description	without authentication when the explorer is enabled with --explorer, and cached for a short while.
+//	@Tags			Explorer
+//	@Produce		json
+//	@Success		200	{object}	explorer.NodeStats
+//	@Failure		405	{object}	string
+//	@Failure		500	{object}	string
+//	@Router			/requester/explorer/nodes [get]
+func (s *RequesterAPIServer) explorerNodes(res http.ResponseWriter, req *http.Request) {
+	serveExplorer(res, req, s.explorer.Nodes)
+}
+
+// serveExplorer writes the response of an explorer endpoint. Dashboards on any origin can read it, as it is public.
+func serveExplorer[T any](res http.ResponseWriter, req *http.Request, get func(context.Context) (T, error)) {
+	ctx := req.Context()
+	if req.Method != http.MethodGet {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := get(ctx)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJSON(res, req, body)
+}
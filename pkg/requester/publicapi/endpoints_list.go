@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/rs/zerolog/log"
 )
@@ -21,6 +24,12 @@ type listRequest struct {
 	ReturnAll   bool                `json:"return_all" `
 	SortBy      string              `json:"sort_by" example:"created_at"`
 	SortReverse bool                `json:"sort_reverse"`
+	// Labels are selectors the labels of the jobs must all match, such as team=ml or team=ml-*.
+	Labels []string `json:"labels,omitempty" example:"['team=ml']"`
+	// States are the states the jobs must be in, or any state if empty.
+	States []model.JobStateType `json:"states,omitempty" example:"['Completed']"`
+	// CreatedAfter excludes the jobs created before it.
+	CreatedAfter *time.Time `json:"created_after,omitempty"`
 }
 
 type ListRequest = listRequest
@@ -56,7 +65,20 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, listReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, listReq.JobID)
 
-	jobList, err := s.getJobsList(ctx, listReq)
+	if listReq.JobID != "" && !s.resolveJobOrFail(ctx, res, &listReq.JobID) {
+		return
+	}
+	labels := make([]jobstore.LabelSelector, 0, len(listReq.Labels))
+	for _, label := range listReq.Labels {
+		selector, err := jobstore.ParseLabelSelector(label)
+		if err != nil {
+			publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+			return
+		}
+		labels = append(labels, selector)
+	}
+
+	jobList, err := s.getJobsList(ctx, listReq, labels)
 	if err != nil {
 		_, ok := err.(*bacerrors.JobNotFound)
 		if ok {
@@ -75,7 +97,7 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 		}
 		jobWithInfos[i] = &model.JobWithInfo{
 			Job:   job,
-			State: jobState,
+			State: s.withQueuePositions(ctx, jobState),
 		}
 	}
 	res.WriteHeader(http.StatusOK)
@@ -88,9 +110,10 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (s *RequesterAPIServer) getJobsList(ctx context.Context, listReq ListRequest) ([]model.Job, error) {
-	list, err := s.jobStore.GetJobs(ctx, jobstore.JobQuery{
+func (s *RequesterAPIServer) getJobsList(ctx context.Context, listReq ListRequest, labels []jobstore.LabelSelector) ([]model.Job, error) {
+	query := jobstore.JobQuery{
 		ClientID:    listReq.ClientID,
+		Namespace:   publicapi.RequestNamespace(ctx),
 		ID:          listReq.JobID,
 		Limit:       listReq.MaxJobs,
 		IncludeTags: listReq.IncludeTags,
@@ -98,9 +121,49 @@ func (s *RequesterAPIServer) getJobsList(ctx context.Context, listReq ListReques
 		ReturnAll:   listReq.ReturnAll,
 		SortBy:      listReq.SortBy,
 		SortReverse: listReq.SortReverse,
-	})
+		Labels:      labels,
+		States:      listReq.States,
+	}
+	if listReq.CreatedAfter != nil {
+		query.CreatedAfter = *listReq.CreatedAfter
+	}
+	list, err := s.jobStore.GetJobs(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	return list, nil
 }
+
+// mergeListResponses merges the jobs listed by every requester, in the order and up to the number asked for.
+func mergeListResponses(_ *http.Request, body []byte, responses [][]byte) (any, error) {
+	var listReq ListRequest
+	if err := json.Unmarshal(body, &listReq); err != nil {
+		return nil, err
+	}
+	merged := ListResponse{Jobs: []*model.JobWithInfo{}}
+	for _, response := range responses {
+		var list ListResponse
+		if err := json.Unmarshal(response, &list); err != nil {
+			return nil, err
+		}
+		merged.Jobs = append(merged.Jobs, list.Jobs...)
+	}
+	sort.SliceStable(merged.Jobs, func(i, j int) bool {
+		a, b := merged.Jobs[i].Job.Metadata, merged.Jobs[j].Job.Metadata
+		if listReq.SortReverse {
+			a, b = b, a
+		}
+		switch listReq.SortBy {
+		case "id":
+			return a.ID < b.ID
+		case "created_at":
+			return a.CreatedAt.Before(b.CreatedAt)
+		default:
+			return false
+		}
+	})
+	if listReq.MaxJobs > 0 && len(merged.Jobs) > listReq.MaxJobs {
+		merged.Jobs = merged.Jobs[:listReq.MaxJobs]
+	}
+	return merged, nil
+}
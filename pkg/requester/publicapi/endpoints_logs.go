@@ -10,6 +10,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/logstream"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
@@ -21,9 +22,13 @@ import (
 
 type logRequest = publicapi.SignedRequest[model.LogsPayload] //nolint:unused // Swagger wants this
 
+// Msg is a frame of the output of an execution, as sent to websocket clients.
 type Msg struct {
 	Tag  uint8
 	Data string
+	// Seq is the sequence number of the frame in the stream, starting at 1, or zero from requesters that don't number
+	// frames.
+	Seq uint64 `json:",omitempty"`
 }
 
 // logs godoc
@@ -79,7 +84,11 @@ func (s *RequesterAPIServer) logs(res http.ResponseWriter, req *http.Request) {
 	ctx = system.AddJobIDToBaggage(ctx, payload.ClientID)
 
 	// Get the job, check it exists and check it belongs to the same client
-	job, err := s.jobStore.GetJob(ctx, payload.JobID)
+	payload.JobID, err = s.resolveJob(ctx, payload.JobID)
+	var job model.Job
+	if err == nil {
+		job, err = s.jobStore.GetJob(ctx, payload.JobID)
+	}
 	if err != nil {
 		log.Ctx(ctx).Debug().Msgf("Missing job: %s", err)
 		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseAbnormalClosure, err.Error()))
@@ -88,8 +97,8 @@ func (s *RequesterAPIServer) logs(res http.ResponseWriter, req *http.Request) {
 
 	// We can compare the payload's client ID against the existing job's metadata
 	// as we have confirmed the public key that the request was signed with matches
-	// the client ID the request claims.
-	if job.Metadata.ClientID != payload.ClientID {
+	// the client ID the request claims. Clients watching the job were given it for their own submission.
+	if !job.Metadata.IsVisibleTo(payload.ClientID) {
 		log.Ctx(ctx).Debug().Msgf("Mismatched ClientIDs for logs, existing job: %s and log request: %s",
 			job.Metadata.ClientID, payload.ClientID)
 
@@ -112,7 +121,7 @@ func (s *RequesterAPIServer) logs(res http.ResponseWriter, req *http.Request) {
 	}
 
 	if response.ExecutionComplete {
-		s.writeTerminatedJobOutput(ctx, conn, job.ID(), payload.ExecutionID)
+		s.writeTerminatedJobOutput(ctx, conn, job.ID(), payload.ExecutionID, payload.Tail)
 		return
 	}
 
@@ -124,7 +133,7 @@ func (s *RequesterAPIServer) logs(res http.ResponseWriter, req *http.Request) {
 	}
 	defer client.Close()
 
-	err = client.Connect(ctx, payload.ExecutionID, payload.WithHistory, payload.Follow)
+	err = client.Connect(ctx, payload.ExecutionID, payload.WithHistory, payload.Follow, payload.Tail)
 	if err != nil {
 		errorResponse := bacerrors.ErrorToErrorResponse(errors.Errorf("logstream connect failure: %s", err))
 		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, errorResponse))
@@ -153,10 +162,11 @@ func (s *RequesterAPIServer) logs(res http.ResponseWriter, req *http.Request) {
 	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 }
 
-func (s *RequesterAPIServer) writeDataFrame(ctx context.Context, conn *websocket.Conn, frame logger.DataFrame) error {
+func (s *RequesterAPIServer) writeDataFrame(ctx context.Context, conn *websocket.Conn, frame logframe.DataFrame) error {
 	msg := Msg{
 		Tag:  uint8(frame.Tag),
 		Data: string(frame.Data),
+		Seq:  frame.Seq,
 	}
 
 	err := conn.WriteJSON(msg)
@@ -170,32 +180,30 @@ func (s *RequesterAPIServer) writeTerminatedJobOutput(
 	ctx context.Context,
 	conn *websocket.Conn,
 	jobID string,
-	executionID string) {
+	executionID string,
+	tail int) {
 	jobState, err := s.jobStore.GetJobState(ctx, jobID)
 	if err != nil {
 		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
 		return
 	}
 
+	var seq uint64
+	writeOutput := func(tag logframe.StreamTag, output string) {
+		if output == "" {
+			return
+		}
+		seq++
+		frame := logframe.NewDataFrameFromData(tag, []byte(output))
+		frame.Seq = seq
+		_ = s.writeDataFrame(ctx, conn, frame)
+	}
+
 	for _, exec := range jobState.Executions {
 		if exec.ComputeReference == executionID {
-			if exec.RunOutput.STDOUT != "" {
-				df := logger.DataFrame{
-					Tag:  logger.StdoutStreamTag,
-					Size: len(exec.RunOutput.STDOUT),
-					Data: []byte(exec.RunOutput.STDOUT),
-				}
-				_ = s.writeDataFrame(ctx, conn, df)
-			}
-
-			if exec.RunOutput.STDERR != "" {
-				df := logger.DataFrame{
-					Tag:  logger.StderrStreamTag,
-					Size: len(exec.RunOutput.STDERR),
-					Data: []byte(exec.RunOutput.STDERR),
-				}
-				_ = s.writeDataFrame(ctx, conn, df)
-			}
+			// the output of a terminated execution isn't interleaved, so each stream is tailed on its own
+			writeOutput(logframe.StdoutStreamTag, logger.TailLines(exec.RunOutput.STDOUT, tail))
+			writeOutput(logframe.StderrStreamTag, logger.TailLines(exec.RunOutput.STDERR, tail))
 		}
 	}
 
@@ -0,0 +1,160 @@
+package publicapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// MigrateRequest is the signed payload sent to move the executions of a compute node that have not started yet to
+// other nodes, such as when the node is degraded but still reachable.
+type MigrateRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	// NodeID is the compute node to move executions away from.
+	NodeID string `json:"NodeID" validate:"required"`
+
+	// JobID restricts the migration to the executions of a single job when set.
+	JobID string `json:"JobID,omitempty"`
+
+	// Reason is recorded in the history of the jobs whose executions are moved.
+	Reason string `json:"Reason,omitempty"`
+
+	// DryRun lists the executions that would be moved and where to, without moving them.
+	DryRun bool `json:"DryRun,omitempty"`
+
+	// IssuedAt and Nonce stop the request from being replayed. Requests issued too long ago are rejected, and each
+	// nonce is only accepted once.
+	IssuedAt time.Time `json:"IssuedAt" validate:"required"`
+	Nonce    string    `json:"Nonce" validate:"required"`
+}
+
+func (r MigrateRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type migrateRequest = publicapi.SignedRequest[MigrateRequest] //nolint:unused // Swagger wants this
+
+// MigrateResponse lists the executions that were moved, and the jobs whose executions could not be moved.
+type MigrateResponse struct {
+	Migrated []requester.MigratedExecution `json:"Migrated"`
+	Failed   map[string]string             `json:"Failed,omitempty"`
+}
+
+// migrate godoc
+//
+//	@ID				pkg/requester/publicapi/migrate
+//	@Summary		Moves the queued executions of a compute node to other nodes.
+//	@Description	Asks other nodes to bid in place of the executions of a node that are still waiting for a bid or waiting
+//	@Description	in the queue of the node, and cancels each execution once its bid is accepted. Only clients passed to --admin-client-id can migrate, and
+//	@Description	each request is only accepted once, within minutes of being issued.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			migrateRequest	body		migrateRequest	true	" "
+//	@Success		200				{object}	MigrateResponse
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		404				{object}	string
+//	@Failure		409				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/requester/admin/migrate [post]
+func (s *RequesterAPIServer) migrate(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	request, ok := unmarshalAdmin[MigrateRequest](s, res, req)
+	if !ok {
+		return
+	}
+	if err := s.adminReplays.Check(request.Nonce, request.IssuedAt, time.Now()); err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("rejected migrate request: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	nodeID, err := s.resolver.ResolveNodeID(ctx, request.NodeID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, resolveErrorStatus(err))
+		return
+	}
+	request.NodeID = nodeID
+	if request.JobID != "" && !s.resolveJobOrFail(ctx, res, &request.JobID) {
+		return
+	}
+
+	result, err := s.requester.MigrateExecutions(ctx, requester.MigrateExecutionsRequest{
+		NodeID:             request.NodeID,
+		JobID:              request.JobID,
+		QueuedExecutionIDs: s.queuedExecutionIDs(ctx, request.NodeID),
+		ClientID:           request.ClientID,
+		Reason:             request.Reason,
+		DryRun:             request.DryRun,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.As(err, &requester.ErrJobAlreadyTerminal{}) {
+			status = http.StatusConflict
+		} else if errors.As(err, &jobstore.ErrJobNotFound{}) {
+			status = http.StatusNotFound
+		}
+		publicapi.HTTPError(ctx, res, fmt.Errorf("failed to migrate executions: %w", err), status)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(MigrateResponse{
+		Migrated: result.Migrated,
+		Failed:   result.Failed,
+	})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// queuedExecutionIDs returns the executions the node last published as waiting in its queue, which have been accepted
+// but not started yet.
+func (s *RequesterAPIServer) queuedExecutionIDs(ctx context.Context, nodeID string) []string {
+	if s.nodeInfoStore == nil {
+		return nil
+	}
+	peerID, err := peer.Decode(nodeID)
+	if err != nil {
+		return nil
+	}
+	nodeInfo, err := s.nodeInfoStore.Get(ctx, peerID)
+	if err != nil || nodeInfo.ComputeNodeInfo == nil {
+		return nil
+	}
+	executionIDs := make([]string, 0, len(nodeInfo.ComputeNodeInfo.ExecutionQueue))
+	for _, queued := range nodeInfo.ComputeNodeInfo.ExecutionQueue {
+		executionIDs = append(executionIDs, queued.ExecutionID)
+	}
+	return executionIDs
+}
+
+// mergeMigrateResponses merges the executions every requester moved away from the node, or failed to.
+func mergeMigrateResponses(_ *http.Request, _ []byte, responses [][]byte) (any, error) {
+	merged := MigrateResponse{Migrated: []requester.MigratedExecution{}}
+	for _, response := range responses {
+		var migrate MigrateResponse
+		if err := json.Unmarshal(response, &migrate); err != nil {
+			return nil, err
+		}
+		merged.Migrated = append(merged.Migrated, migrate.Migrated...)
+		for executionID, reason := range migrate.Failed {
+			if merged.Failed == nil {
+				merged.Failed = make(map[string]string)
+			}
+			merged.Failed[executionID] = reason
+		}
+	}
+	return merged, nil
+}
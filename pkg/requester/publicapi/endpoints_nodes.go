@@ -0,0 +1,65 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+)
+
+type NodesRequest struct {
+	ClientID string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+}
+
+type NodesResponse struct {
+	// Nodes are the compute nodes known to the requester, with the load they reported recently.
+	Nodes []model.NodeInfo `json:"nodes"`
+}
+
+// nodes godoc
+//
+//	@ID			pkg/requester/publicapi/nodes
+//	@Summary	Lists the compute nodes known to the requester, with their recent load.
+//	@Tags		Utils
+//	@Accept		json
+//	@Produce	json
+//	@Param		NodesRequest	body		NodesRequest	true	" "
+//	@Success	200				{object}	NodesResponse
+//	@Failure	400				{object}	string
+//	@Failure	500				{object}	string
+//	@Router		/requester/nodes [post]
+func (s *RequesterAPIServer) nodes(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var nodesReq NodesRequest
+	if err := json.NewDecoder(req.Body).Decode(&nodesReq); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, nodesReq.ClientID)
+
+	nodes := []model.NodeInfo{}
+	if s.nodeInfoStore != nil {
+		nodeInfos, err := s.nodeInfoStore.List(ctx)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, nodeInfo := range nodeInfos {
+			if nodeInfo.IsComputeNode() {
+				nodes = append(nodes, nodeInfo)
+			}
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].PeerInfo.ID < nodes[j].PeerInfo.ID
+		})
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(res).Encode(NodesResponse{Nodes: nodes})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
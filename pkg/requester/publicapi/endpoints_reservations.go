@@ -0,0 +1,176 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/reservation"
+)
+
+// ReserveRequest is the signed payload sent to reserve capacity across the network for the jobs of the client during
+// a time window. Jobs submitted by the client with the token of the reservation run on the reserved capacity.
+type ReserveRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	// CPU and GPU are the capacity to reserve, e.g. 500m or 16 CPUs and 2 GPUs.
+	CPU string `json:"CPU,omitempty"`
+	GPU string `json:"GPU,omitempty"`
+
+	// Start is when the capacity starts to be held. Now if not set.
+	Start time.Time `json:"Start,omitempty"`
+	End   time.Time `json:"End" validate:"required"`
+}
+
+func (r ReserveRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type reserveRequest = publicapi.SignedRequest[ReserveRequest] //nolint:unused // Swagger wants this
+
+// ReleaseRequest is the signed payload sent to release a capacity reservation before its time window is over.
+type ReleaseRequest struct {
+	ClientID      string `json:"ClientID" validate:"required"`
+	ReservationID string `json:"ReservationID" validate:"required"`
+}
+
+func (r ReleaseRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type releaseRequest = publicapi.SignedRequest[ReleaseRequest] //nolint:unused // Swagger wants this
+
+// ReservationsRequest is the signed payload sent to list the capacity reservations.
+type ReservationsRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+}
+
+func (r ReservationsRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type reservationsRequest = publicapi.SignedRequest[ReservationsRequest] //nolint:unused // Swagger wants this
+
+// reserve godoc
+//
+//	@ID				pkg/requester/publicapi/reserve
+//	@Summary		Reserves capacity across the network for a time window.
+//	@Description	Asks compute nodes to hold the capacity for the jobs of the client, and returns the token to submit
+//	@Description	them with. Only clients passed to --admin-client-id can reserve capacity.
+//	@Tags			Reservation
+//	@Accept			json
+//	@Produce		json
+//	@Param			reserveRequest	body		reserveRequest	true	" "
+//	@Success		200				{object}	model.CapacityReservation
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		409				{object}	string
+//	@Router			/requester/admin/reserve [post]
+func (s *RequesterAPIServer) reserve(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if !s.reservationsEnabled(res, req) {
+		return
+	}
+	request, ok := unmarshalAdmin[ReserveRequest](s, res, req)
+	if !ok {
+		return
+	}
+
+	resources := capacity.ParseResourceUsageConfig(model.ResourceUsageConfig{CPU: request.CPU, GPU: request.GPU})
+	start := request.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	result, err := s.reservations.Reserve(ctx, reservation.ReserveRequest{
+		ClientID:  request.ClientID,
+		Resources: resources,
+		Start:     start,
+		End:       request.End,
+	})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("failed to reserve capacity: %w", err), http.StatusConflict)
+		return
+	}
+	writeJSON(res, req, result)
+}
+
+// release godoc
+//
+//	@ID				pkg/requester/publicapi/release
+//	@Summary		Releases a capacity reservation.
+//	@Description	Asks the compute nodes holding the capacity of the reservation to offer it to every job again.
+//	@Description	Only clients passed to --admin-client-id can release reservations.
+//	@Tags			Reservation
+//	@Accept			json
+//	@Produce		json
+//	@Param			releaseRequest	body		releaseRequest	true	" "
+//	@Success		200				{object}	model.CapacityReservation
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		404				{object}	string
+//	@Router			/requester/admin/release [post]
+func (s *RequesterAPIServer) release(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if !s.reservationsEnabled(res, req) {
+		return
+	}
+	request, ok := unmarshalAdmin[ReleaseRequest](s, res, req)
+	if !ok {
+		return
+	}
+
+	result, err := s.reservations.Release(ctx, request.ReservationID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(res, req, result)
+}
+
+// reservations godoc
+//
+//	@ID				pkg/requester/publicapi/reservations
+//	@Summary		Lists the capacity reservations.
+//	@Description	Lists the capacity reservations that have not ended yet, without their tokens.
+//	@Description	Only clients passed to --admin-client-id can list reservations.
+//	@Tags			Reservation
+//	@Accept			json
+//	@Produce		json
+//	@Param			reservationsRequest	body		reservationsRequest	true	" "
+//	@Success		200					{object}	[]model.CapacityReservation
+//	@Failure		400					{object}	string
+//	@Failure		401					{object}	string
+//	@Failure		403					{object}	string
+//	@Router			/requester/admin/reservations [post]
+func (s *RequesterAPIServer) listReservations(res http.ResponseWriter, req *http.Request) {
+	if !s.reservationsEnabled(res, req) {
+		return
+	}
+	if _, ok := unmarshalAdmin[ReservationsRequest](s, res, req); !ok {
+		return
+	}
+	writeJSON(res, req, s.reservations.List(req.Context()))
+}
+
+func writeJSON(res http.ResponseWriter, req *http.Request, body interface{}) {
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(body); err != nil {
+		publicapi.HTTPError(req.Context(), res, err, http.StatusInternalServerError)
+	}
+}
+
+// reservationsEnabled fails the request if the requester does not take capacity reservations.
+func (s *RequesterAPIServer) reservationsEnabled(res http.ResponseWriter, req *http.Request) bool {
+	if s.reservations == nil {
+		publicapi.HTTPError(req.Context(), res, errors.New("capacity reservations are not enabled on this node"), http.StatusForbidden)
+		return false
+	}
+	return true
+}
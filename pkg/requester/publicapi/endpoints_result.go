@@ -45,6 +45,9 @@ func (s *RequesterAPIServer) results(res http.ResponseWriter, req *http.Request)
 
 	ctx = system.AddJobIDToBaggage(ctx, stateReq.JobID)
 	system.AddJobIDFromBaggageToSpan(ctx, oteltrace.SpanFromContext(ctx))
+	if !s.resolveJobOrFail(ctx, res, &stateReq.JobID) {
+		return
+	}
 
 	stateResolver := jobstore.GetStateResolver(s.jobStore)
 	results, err := stateResolver.GetResults(ctx, stateReq.JobID)
@@ -0,0 +1,131 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+)
+
+type SearchResponse struct {
+	// Jobs are the most recent jobs matching the search, up to the limit.
+	Jobs []*model.JobWithInfo `json:"jobs"`
+	// Total is the number of jobs matching the search.
+	Total int `json:"total"`
+	// Counts is the number of matching jobs with each label matched by the search.
+	Counts map[string]int `json:"counts"`
+}
+
+// search godoc
+//
+//	@ID				pkg/requester/publicapi/search
+//	@Summary		Searches jobs by label.
+//	@Description	Finds the jobs whose labels, which are their annotations of the form key=value, match all the label
+//	@Description	selectors. Each selector is key, key=value, key=prefix* or prefix*. The number of matching jobs with
+//	@Description	each matched label is returned along with the most recent jobs.
+//	@Tags			Job
+//	@Produce		json
+//	@Param			label		query		[]string	false	"Label selector, e.g. project=alpha. Can be repeated."	collectionFormat(multi)
+//	@Param			client_id	query		string		false	"Only search the jobs of the client."
+//	@Param			return_all	query		bool		false	"Search the jobs of every client."
+//	@Param			limit		query		int			false	"Most jobs to return. The total and counts include every matching job."
+//	@Success		200			{object}	SearchResponse
+//	@Failure		400			{object}	string
+//	@Failure		405			{object}	string
+//	@Failure		500			{object}	string
+//	@Router			/requester/search [get]
+//
+//nolint:lll
+func (s *RequesterAPIServer) search(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if req.Method != http.MethodGet {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	query := req.URL.Query()
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, query.Get("client_id"))
+
+	searchQuery := jobstore.JobSearchQuery{
+		ClientID:  query.Get("client_id"),
+		Namespace: publicapi.RequestNamespace(ctx),
+	}
+	for _, label := range query["label"] {
+		selector, err := jobstore.ParseLabelSelector(label)
+		if err != nil {
+			publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+			return
+		}
+		searchQuery.Labels = append(searchQuery.Labels, selector)
+	}
+	var err error
+	if value := query.Get("return_all"); value != "" {
+		if searchQuery.ReturnAll, err = strconv.ParseBool(value); err != nil {
+			publicapi.HTTPError(ctx, res, fmt.Errorf("invalid return_all: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if searchQuery.Limit, err = strconv.Atoi(value); err != nil || searchQuery.Limit < 0 {
+			publicapi.HTTPError(ctx, res, fmt.Errorf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := s.jobStore.SearchJobs(ctx, searchQuery)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+	response := SearchResponse{
+		Jobs:   make([]*model.JobWithInfo, 0, len(result.Jobs)),
+		Total:  result.Total,
+		Counts: result.Counts,
+	}
+	for _, job := range result.Jobs {
+		jobState, err := s.jobStore.GetJobState(ctx, job.Metadata.ID)
+		if err != nil {
+			publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+			return
+		}
+		response.Jobs = append(response.Jobs, &model.JobWithInfo{
+			Job:   job,
+			State: s.withQueuePositions(ctx, jobState),
+		})
+	}
+	writeJSON(res, req, response)
+}
+
+// mergeSearchResponses merges the jobs found by every requester, most recent first and up to the limit, and adds up
+// their counts.
+func mergeSearchResponses(req *http.Request, _ []byte, responses [][]byte) (any, error) {
+	merged := SearchResponse{Jobs: []*model.JobWithInfo{}, Counts: map[string]int{}}
+	for _, response := range responses {
+		var search SearchResponse
+		if err := json.Unmarshal(response, &search); err != nil {
+			return nil, err
+		}
+		merged.Jobs = append(merged.Jobs, search.Jobs...)
+		merged.Total += search.Total
+		for label, count := range search.Counts {
+			merged.Counts[label] += count
+		}
+	}
+	sort.Slice(merged.Jobs, func(i, j int) bool {
+		a, b := merged.Jobs[i].Job.Metadata, merged.Jobs[j].Job.Metadata
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	// the limit was checked by every requester
+	if limit, _ := strconv.Atoi(req.URL.Query().Get("limit")); limit > 0 && len(merged.Jobs) > limit {
+		merged.Jobs = merged.Jobs[:limit]
+	}
+	return merged, nil
+}
@@ -0,0 +1,109 @@
+package publicapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+)
+
+// ExportRequest is the signed payload sent to export every job of the requester as a snapshot, to move the jobs to
+// another requester or to recover them after the host of the requester is lost.
+type ExportRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+}
+
+func (r ExportRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type exportRequest = publicapi.SignedRequest[ExportRequest] //nolint:unused // Swagger wants this
+
+// ImportRequest is the signed payload sent to import a snapshot exported by another requester.
+type ImportRequest struct {
+	ClientID string `json:"ClientID" validate:"required"`
+
+	Snapshot jobstore.Snapshot `json:"Snapshot"`
+
+	// OnConflict is what happens to jobs of the snapshot that the requester already has: skip, overwrite or fail.
+	OnConflict jobstore.ConflictPolicy `json:"OnConflict,omitempty"`
+
+	// AllowNewer imports snapshots exported by a newer release of bacalhau than the requester's.
+	AllowNewer bool `json:"AllowNewer,omitempty"`
+}
+
+func (r ImportRequest) GetClientID() string {
+	return r.ClientID
+}
+
+type importRequest = publicapi.SignedRequest[ImportRequest] //nolint:unused // Swagger wants this
+
+// export godoc
+//
+//	@ID				pkg/requester/publicapi/export
+//	@Summary		Exports every job of the requester as a snapshot.
+//	@Description	Returns the specs, states, executions and history of all jobs, to be imported into another requester.
+//	@Description	Only clients passed to --admin-client-id can export jobs.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			exportRequest	body		exportRequest	true	" "
+//	@Success		200				{object}	jobstore.Snapshot
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/requester/admin/export [post]
+func (s *RequesterAPIServer) export(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if _, ok := unmarshalAdmin[ExportRequest](s, res, req); !ok {
+		return
+	}
+
+	snapshot, err := jobstore.ExportSnapshot(ctx, s.jobStore, s.nodeID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("failed to export jobs: %w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(res, req, snapshot)
+}
+
+// import godoc
+//
+//	@ID				pkg/requester/publicapi/import
+//	@Summary		Imports a snapshot of the jobs of another requester.
+//	@Description	Adds the jobs of a snapshot exported by another requester, with their states, executions and history.
+//	@Description	Only clients passed to --admin-client-id can import jobs.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			importRequest	body		importRequest	true	" "
+//	@Success		200				{object}	jobstore.ImportSnapshotResult
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		403				{object}	string
+//	@Failure		409				{object}	string
+//	@Router			/requester/admin/import [post]
+func (s *RequesterAPIServer) importSnapshot(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	request, ok := unmarshalAdmin[ImportRequest](s, res, req)
+	if !ok {
+		return
+	}
+
+	result, err := jobstore.ImportSnapshot(ctx, s.jobStore, request.Snapshot, jobstore.ImportSnapshotOptions{
+		OnConflict: request.OnConflict,
+		AllowNewer: request.AllowNewer,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.As(err, &jobstore.ErrSnapshotConflict{}) {
+			status = http.StatusConflict
+		}
+		publicapi.HTTPError(ctx, res, fmt.Errorf("failed to import jobs: %w", err), status)
+		return
+	}
+	writeJSON(res, req, result)
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 type stateRequest struct {
 	ClientID string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
 	JobID    string `json:"job_id" example:"9304c616-291f-41ad-b862-54e133c0149e"`
+	// At optionally requests the state the job was in at a point in time, rather than its current state.
+	At *time.Time `json:"at,omitempty" example:"2023-05-01T12:00:00Z"`
 }
 
 type stateResponse struct {
@@ -42,6 +46,9 @@ func (s *RequesterAPIServer) states(res http.ResponseWriter, req *http.Request)
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, stateReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, stateReq.JobID)
 	ctx = system.AddJobIDToBaggage(ctx, stateReq.JobID)
+	if !s.resolveJobOrFail(ctx, res, &stateReq.JobID) {
+		return
+	}
 
 	js, err := getJobStateFromRequest(ctx, s, stateReq)
 	if err != nil {
@@ -60,5 +67,46 @@ func (s *RequesterAPIServer) states(res http.ResponseWriter, req *http.Request)
 }
 
 func getJobStateFromRequest(ctx context.Context, apiServer *RequesterAPIServer, stateReq stateRequest) (model.JobState, error) {
-	return apiServer.jobStore.GetJobState(ctx, stateReq.JobID)
+	if stateReq.At != nil {
+		return apiServer.jobStore.GetJobStateAt(ctx, stateReq.JobID, *stateReq.At)
+	}
+	js, err := apiServer.jobStore.GetJobState(ctx, stateReq.JobID)
+	if err != nil {
+		return js, err
+	}
+	return apiServer.withQueuePositions(ctx, js), nil
+}
+
+// withQueuePositions returns a copy of the job state where the executions that are waiting for capacity on their
+// compute node are annotated with their position in the node's queue, as last published by the node.
+func (s *RequesterAPIServer) withQueuePositions(ctx context.Context, js model.JobState) model.JobState {
+	if s.nodeInfoStore == nil {
+		return js
+	}
+	// the executions are shared with the job store, so they must not be modified in place
+	executions := make([]model.ExecutionState, len(js.Executions))
+	copy(executions, js.Executions)
+	js.Executions = executions
+
+	for i, execution := range js.Executions {
+		if execution.State != model.ExecutionStateBidAccepted {
+			continue
+		}
+		peerID, err := peer.Decode(execution.NodeID)
+		if err != nil {
+			continue
+		}
+		nodeInfo, err := s.nodeInfoStore.Get(ctx, peerID)
+		if err != nil || nodeInfo.ComputeNodeInfo == nil {
+			continue
+		}
+		for _, queued := range nodeInfo.ComputeNodeInfo.ExecutionQueue {
+			if queued.ExecutionID == execution.ComputeReference {
+				queued := queued
+				js.Executions[i].Queue = &queued
+				break
+			}
+		}
+	}
+	return js
 }
@@ -2,13 +2,20 @@ package publicapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
@@ -17,6 +24,8 @@ type submitRequest = publicapi.SignedRequest[model.JobCreatePayload] //nolint:un
 
 type submitResponse struct {
 	Job *model.Job `json:"job"`
+	// PinnedImage is the image of the job pinned to its digest, if the requester resolved the tag it was submitted with.
+	PinnedImage string `json:"pinned_image,omitempty"`
 }
 
 // submit godoc
@@ -30,6 +39,9 @@ type submitResponse struct {
 //	@Param					submitRequest	body		submitRequest	true	" "
 //	@Success				200				{object}	submitResponse
 //	@Failure				400				{object}	string
+//	@Failure				409				{object}	string
+//	@Failure				413				{object}	string
+//	@Failure				429				{object}	string
 //	@Failure				500				{object}	string
 //	@Router					/requester/submit [post]
 func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request) {
@@ -42,11 +54,31 @@ func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request)
 
 	jobCreatePayload, err := publicapi.UnmarshalSigned[model.JobCreatePayload](ctx, req.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			publicapi.HTTPError(ctx, res, err, http.StatusRequestEntityTooLarge)
+			return
+		}
 		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
 		return
 	}
 
-	if err := job.VerifyJobCreatePayload(ctx, &jobCreatePayload); err != nil {
+	if principal, ok := publicapi.PrincipalFromContext(ctx); ok {
+		jobCreatePayload.Namespace = principal.Namespace
+	}
+
+	// malformed specs are rejected with the details of every problem, before the job is created
+	err = job.VerifySubmission(ctx, &jobCreatePayload, job.SubmissionPolicy{
+		SpecLimits:    s.specLimits,
+		EnvPolicy:     s.envPolicy,
+		PriorityRange: s.priorityRange,
+	})
+	if err != nil {
+		var tooLarge *bacerrors.JobSpecTooLarge
+		if errors.As(err, &tooLarge) {
+			publicapi.HTTPError(ctx, res, err, http.StatusRequestEntityTooLarge)
+			return
+		}
 		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
 		return
 	}
@@ -56,13 +88,36 @@ func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request)
 	ctx = system.AddJobIDToBaggage(ctx, j.Metadata.ID)
 	system.AddJobIDFromBaggageToSpan(ctx, oteltrace.SpanFromContext(ctx))
 
+	var circuitErr requester.ErrCircuitOpen
+	if errors.As(err, &circuitErr) {
+		retryAfter := int(math.Ceil(time.Until(circuitErr.Until).Seconds()))
+		res.Header().Set("Retry-After", strconv.Itoa(system.Max(retryAfter, 1)))
+		publicapi.HTTPError(ctx, res, err, http.StatusTooManyRequests)
+		return
+	}
+	if errors.As(err, &requester.ErrOverloaded{}) {
+		publicapi.HTTPError(ctx, res, err, http.StatusTooManyRequests)
+		return
+	}
+	if errors.As(err, &requester.ErrImpossibleResources{}) || errors.As(err, &requester.ErrInputsTooLarge{}) {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+	if errors.As(err, &jobstore.ErrJobNameTaken{}) {
+		publicapi.HTTPError(ctx, res, err, http.StatusConflict)
+		return
+	}
 	if err != nil {
 		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
 		return
 	}
 
 	res.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(res).Encode(submitResponse{Job: j})
+	response := submitResponse{Job: j}
+	if j.Spec.Engine == model.EngineDocker && j.Spec.Docker.Image != jobCreatePayload.Spec.Docker.Image {
+		response.PinnedImage = j.Spec.Docker.Image
+	}
+	err = json.NewEncoder(res).Encode(response)
 	if err != nil {
 		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
 		return
@@ -0,0 +1,98 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+type updateRequest = publicapi.SignedRequest[model.JobUpdatePayload] //nolint:unused // Swagger wants this
+
+type updateResponse struct {
+	Job *model.Job `json:"job"`
+}
+
+// update godoc
+//
+//	@ID				pkg/requester/publicapi/update
+//	@Summary		Amends the spec of a running job.
+//	@Description	Adds or removes annotations, extends the timeout or changes the retry limit of a running job, without
+//	@Description	disturbing its executions. The update is rejected with a conflict if the spec version it is based on is stale.
+//	@Tags			Job
+//	@Accept			json
+//	@Produce		json
+//	@Param			updateRequest	body		updateRequest	true	" "
+//	@Success		200				{object}	updateResponse
+//	@Failure		400				{object}	string
+//	@Failure		401				{object}	string
+//	@Failure		404				{object}	string
+//	@Failure		409				{object}	string
+//	@Failure		500				{object}	string
+//	@Router			/requester/update [patch]
+func (s *RequesterAPIServer) update(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if req.Method != http.MethodPatch {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobUpdatePayload, err := publicapi.UnmarshalSigned[model.JobUpdatePayload](ctx, req.Body)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, jobUpdatePayload.ClientID)
+	ctx = system.AddJobIDToBaggage(ctx, jobUpdatePayload.JobID)
+	if !s.resolveJobOrFail(ctx, res, &jobUpdatePayload.JobID) {
+		return
+	}
+
+	job, err := s.jobStore.GetJob(ctx, jobUpdatePayload.JobID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("missing job: %w", err), http.StatusNotFound)
+		return
+	}
+
+	// Only the client that submitted the job can update it.
+	if job.Metadata.ClientID != jobUpdatePayload.ClientID {
+		err = fmt.Errorf("mismatched ClientIDs for update, existing job: %s and update request: %s",
+			job.Metadata.ClientID, jobUpdatePayload.ClientID)
+		publicapi.HTTPError(ctx, res, err, http.StatusUnauthorized)
+		return
+	}
+
+	result, err := s.requester.UpdateJob(ctx, requester.UpdateJobRequest{
+		JobID:              job.ID(),
+		SpecVersion:        jobUpdatePayload.SpecVersion,
+		AddAnnotations:     jobUpdatePayload.AddAnnotations,
+		RemoveAnnotations:  jobUpdatePayload.RemoveAnnotations,
+		Timeout:            jobUpdatePayload.Timeout,
+		MaxRetries:         jobUpdatePayload.MaxRetries,
+		MaxRetriesPerShard: jobUpdatePayload.MaxRetriesPerShard,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.As(err, &jobstore.ErrInvalidJobSpecVersion{}) || errors.As(err, &jobstore.ErrJobAlreadyTerminal{}) {
+			status = http.StatusConflict
+		}
+		publicapi.HTTPError(ctx, res, err, status)
+		return
+	}
+
+	res.Header().Set(handlerwrapper.HTTPHeaderJobID, job.ID())
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(updateResponse{Job: &result.Job})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,267 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+)
+
+type uploadInitRequest struct {
+	ClientID string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+	// Size is the total size of the upload in bytes
+	Size int64 `json:"size"`
+}
+
+type uploadInitResponse struct {
+	UploadID string `json:"upload_id"`
+	// MaxPartSize is the largest part in bytes that can be uploaded at once
+	MaxPartSize int64 `json:"max_part_size"`
+}
+
+type uploadStatusRequest struct {
+	ClientID string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+	UploadID string `json:"upload_id"`
+}
+
+type uploadStatusResponse struct {
+	UploadID string `json:"upload_id"`
+	// Offset is the number of bytes received, where the next part should start
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+}
+
+type uploadCompleteRequest struct {
+	ClientID string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+	UploadID string `json:"upload_id"`
+	// Checksum is the hex encoded SHA-256 of the whole upload. Optional.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+type uploadCompleteResponse struct {
+	Spec model.StorageSpec `json:"spec"`
+}
+
+// uploadInit godoc
+//
+//	@ID				pkg/requester/publicapi/uploadInit
+//	@Summary		Starts a multipart upload of a job input.
+//	@Description	Starts a multipart upload that is streamed into IPFS storage as its parts are received.
+//	@Tags			Upload
+//	@Accept			json
+//	@Produce		json
+//	@Param			uploadInitRequest	body		uploadInitRequest	true	" "
+//	@Success		200					{object}	uploadInitResponse
+//	@Failure		400					{object}	string
+//	@Failure		429					{object}	string
+//	@Failure		500					{object}	string
+//	@Router			/requester/upload/init [post]
+func (s *RequesterAPIServer) uploadInit(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var initReq uploadInitRequest
+	if err := json.NewDecoder(req.Body).Decode(&initReq); err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, initReq.ClientID)
+
+	if initReq.Size <= 0 {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("invalid upload size %d", initReq.Size), http.StatusBadRequest)
+		return
+	}
+
+	ipfsStorage, err := s.storageProviders.Get(ctx, model.StorageSourceIPFS)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+	uploader, ok := ipfsStorage.(storage.StreamUploader)
+	if !ok {
+		publicapi.HTTPError(ctx, res, errors.New("uploads are not supported by this node"), http.StatusInternalServerError)
+		return
+	}
+
+	up, err := s.uploads.init(uploadOwner(req, initReq.ClientID), initReq.Size, uploader)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusTooManyRequests)
+		return
+	}
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(uploadInitResponse{
+		UploadID:    up.id,
+		MaxPartSize: int64(maxUploadPartSize.Bytes()),
+	})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// uploadPart godoc
+//
+//	@ID				pkg/requester/publicapi/uploadPart
+//	@Summary		Uploads a part of a multipart upload.
+//	@Description	Appends the request body to the upload. Parts must be uploaded in order, starting at the offset
+//	@Description	returned by the status endpoint, and are rejected if their SHA-256 does not match the checksum.
+//	@Tags			Upload
+//	@Accept			application/octet-stream
+//	@Produce		json
+//	@Param			client_id	query		string	true	"ID of the client that started the upload"
+//	@Param			upload_id	query		string	true	"ID of the upload"
+//	@Param			offset		query		int		true	"Offset of the part in the upload"
+//	@Param			checksum	query		string	true	"Hex encoded SHA-256 of the part"
+//	@Success		200			{object}	uploadStatusResponse
+//	@Failure		400			{object}	string
+//	@Failure		404			{object}	string
+//	@Failure		409			{object}	string
+//	@Failure		500			{object}	string
+//	@Router			/requester/upload/part [post]
+func (s *RequesterAPIServer) uploadPart(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	query := req.URL.Query()
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, query.Get("client_id"))
+
+	up, statusCode, err := s.getUpload(uploadOwner(req, query.Get("client_id")), query.Get("upload_id"))
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, statusCode)
+		return
+	}
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, fmt.Errorf("invalid offset: %w", err), http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	err = up.writePart(offset, data, query.Get("checksum"))
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		if errors.As(err, new(errUnexpectedOffset)) {
+			statusCode = http.StatusConflict
+		}
+		publicapi.HTTPError(ctx, res, err, statusCode)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(uploadStatusResponse{UploadID: up.id, Offset: up.received(), Size: up.size})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// uploadStatus godoc
+//
+//	@ID				pkg/requester/publicapi/uploadStatus
+//	@Summary		Returns how much of a multipart upload has been received.
+//	@Description	Returns the offset at which an interrupted upload should be resumed.
+//	@Tags			Upload
+//	@Accept			json
+//	@Produce		json
+//	@Param			uploadStatusRequest	body		uploadStatusRequest	true	" "
+//	@Success		200					{object}	uploadStatusResponse
+//	@Failure		400					{object}	string
+//	@Failure		404					{object}	string
+//	@Router			/requester/upload/status [post]
+func (s *RequesterAPIServer) uploadStatus(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var statusReq uploadStatusRequest
+	if err := json.NewDecoder(req.Body).Decode(&statusReq); err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, statusReq.ClientID)
+
+	up, statusCode, err := s.getUpload(uploadOwner(req, statusReq.ClientID), statusReq.UploadID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, statusCode)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(uploadStatusResponse{UploadID: up.id, Offset: up.received(), Size: up.size})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// uploadComplete godoc
+//
+//	@ID				pkg/requester/publicapi/uploadComplete
+//	@Summary		Completes a multipart upload.
+//	@Description	Completes an upload once all of its parts have been received, and returns the storage spec
+//	@Description	that jobs can use to mount the uploaded data.
+//	@Tags			Upload
+//	@Accept			json
+//	@Produce		json
+//	@Param			uploadCompleteRequest	body		uploadCompleteRequest	true	" "
+//	@Success		200						{object}	uploadCompleteResponse
+//	@Failure		400						{object}	string
+//	@Failure		404						{object}	string
+//	@Failure		500						{object}	string
+//	@Router			/requester/upload/complete [post]
+func (s *RequesterAPIServer) uploadComplete(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var completeReq uploadCompleteRequest
+	if err := json.NewDecoder(req.Body).Decode(&completeReq); err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, completeReq.ClientID)
+
+	up, statusCode, err := s.getUpload(uploadOwner(req, completeReq.ClientID), completeReq.UploadID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, statusCode)
+		return
+	}
+
+	spec, err := up.complete(ctx, completeReq.Checksum)
+	if up.finished() {
+		s.uploads.remove(up.id)
+	}
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(uploadCompleteResponse{Spec: spec})
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// uploadOwner identifies the client behind an upload request. Clients are identified by their authenticated identity
+// if the node authenticates its clients, as the client ID of a request is whatever the client says it is.
+func uploadOwner(req *http.Request, clientID string) string {
+	if principal, ok := publicapi.PrincipalFromContext(req.Context()); ok {
+		return "subject:" + principal.Subject
+	}
+	return "client:" + clientID
+}
+
+// getUpload returns the upload started by the owner, or the error and status code to respond with.
+func (s *RequesterAPIServer) getUpload(owner, uploadID string) (*upload, int, error) {
+	up, err := s.uploads.get(uploadID)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	if up.owner != owner {
+		return nil, http.StatusUnauthorized, fmt.Errorf("upload %s was started by a different client", uploadID)
+	}
+	return up, http.StatusOK, nil
+}
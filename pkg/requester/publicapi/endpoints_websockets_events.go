@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
@@ -14,8 +15,20 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
+// eventsSubscriber is a websocket connection following job events, in the format it asked for.
+type eventsSubscriber struct {
+	conn        *websocket.Conn
+	cloudEvents bool
+}
+
 // TODO: Godoc
 func (s *RequesterAPIServer) websocketJobEvents(res http.ResponseWriter, req *http.Request) {
+	cloudEvents, err := parseEventFormat(req.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(res, req, nil)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
@@ -29,16 +42,24 @@ func (s *RequesterAPIServer) websocketJobEvents(res http.ResponseWriter, req *ht
 	// get job_id from query string
 	jobID := req.URL.Query().Get("job_id")
 
+	// callers restricted to a namespace can only follow the events of their own jobs
+	if jobID == "" && publicapi.RequestNamespace(req.Context()) != "" {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "job_id is required to follow events"))
+		return
+	}
+	if jobID != "" {
+		if jobID, err = s.resolveJob(req.Context(), jobID); err != nil {
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()))
+			return
+		}
+	}
+
 	func() {
 		s.websocketsMutex.Lock()
 		defer s.websocketsMutex.Unlock()
 
-		sockets, ok := s.websockets[jobID]
-		if !ok {
-			sockets = []*websocket.Conn{}
-			s.websockets[jobID] = sockets
-		}
-		s.websockets[jobID] = append(sockets, conn)
+		s.websockets[jobID] = append(s.websockets[jobID], &eventsSubscriber{conn: conn, cloudEvents: cloudEvents})
 	}()
 
 	if jobID != "" {
@@ -49,7 +70,13 @@ func (s *RequesterAPIServer) websocketJobEvents(res http.ResponseWriter, req *ht
 			return
 		}
 		for _, event := range events {
-			err := conn.WriteJSON(event)
+			var message interface{} = event
+			if cloudEvents {
+				if message, err = model.NewJobHistoryCloudEvent(s.eventSource, event); err != nil {
+					continue
+				}
+			}
+			err := conn.WriteJSON(message)
 			if err != nil {
 				log.Ctx(req.Context()).Error().Msgf("error writing event JSON: %s\n", err.Error())
 			}
@@ -70,16 +97,36 @@ func (s *RequesterAPIServer) HandleJobEvent(ctx context.Context, event model.Job
 	s.websocketsMutex.Lock()
 	defer s.websocketsMutex.Unlock()
 
+	// encode the event as a CloudEvent only once, and only if a subscriber wants it
+	var cloudEvent *model.CloudEvent
+	encodeCloudEvent := func() (*model.CloudEvent, error) {
+		if cloudEvent == nil {
+			encoded, err := model.NewJobEventCloudEvent(s.eventSource, event)
+			if err != nil {
+				return nil, err
+			}
+			cloudEvent = &encoded
+		}
+		return cloudEvent, nil
+	}
+
 	dispatchAndCleanup := func(jobId string) {
 		connections, ok := s.websockets[jobId]
 		if !ok {
 			return
 		}
 		errIdxs := []int{}
-		for idx, connection := range connections {
+		for idx, subscriber := range connections {
+			var message interface{} = event
+			if subscriber.cloudEvents {
+				if message, err = encodeCloudEvent(); err != nil {
+					log.Ctx(ctx).Error().Err(err).Msgf("error encoding event of job %s as a CloudEvent", event.JobID)
+					continue
+				}
+			}
 			// TODO: dispatch to subscribers in parallel, to avoid one slow
 			// reader slowing all the others down.
-			err := connection.WriteJSON(event)
+			err := subscriber.conn.WriteJSON(message)
 			if err != nil {
 				log.Ctx(ctx).Error().Msgf(
 					"error writing event to subscriber '%s'/%d: %s, closing ws\n",
@@ -89,7 +136,7 @@ func (s *RequesterAPIServer) HandleJobEvent(ctx context.Context, event model.Job
 				// close the connection, if possible, to allow the other side to
 				// retry. Ignore errors from closing, since we are going to
 				// delete this connection anyway.
-				connection.Close()
+				subscriber.conn.Close()
 			}
 		}
 		// reverse errIdxs (so we don't mess up the indexes for cleanup) and
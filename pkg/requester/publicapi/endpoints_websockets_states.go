@@ -0,0 +1,102 @@
+package publicapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// websocketJobStates godoc
+//
+//	@ID			pkg/requester/publicapi/websocketJobStates
+//	@Summary	Streams the changes to the state of a job and of its executions until the job ends.
+//	@Description	Each change is sent as a JSON model.JobHistory message, starting with the changes the job went through
+//	@Description	before the connection. The connection is closed normally once the job has ended.
+//	@Tags		Job
+//	@Param		job_id	query	string	true	"ID of the job, or a reference resolving to a single job"
+//	@Success	101		{object}	string
+//	@Failure	400		{object}	string
+//	@Failure	404		{object}	string
+//	@Router		/requester/websocket/states [get]
+//
+//nolint:lll
+func (s *RequesterAPIServer) websocketJobStates(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	jobID := req.URL.Query().Get("job_id")
+	if jobID == "" {
+		http.Error(res, "job_id is required", http.StatusBadRequest)
+		return
+	}
+	if !s.resolveJobOrFail(ctx, res, &jobID) {
+		return
+	}
+
+	// the job is watched before its history is read, so that no change is missed in between
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes, err := s.jobStore.WatchJob(ctx, jobID)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		// the upgrader has already responded with the error
+		log.Ctx(ctx).Debug().Err(err).Msg("failed to upgrade websocket connection")
+		return
+	}
+	defer conn.Close()
+	closeWith := func(code int, text string) {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+	}
+
+	// clients don't send anything but close messages, which are read to notice when they go away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sent := 0
+	for {
+		// the state is read before the history, so that the history of a job that has ended holds the change ending it
+		state, err := s.jobStore.GetJobState(ctx, jobID)
+		if err != nil {
+			closeWith(websocket.CloseInternalServerErr, err.Error())
+			return
+		}
+		history, err := s.jobStore.GetJobHistory(ctx, jobID, jobstore.JobHistoryFilterOptions{})
+		if err != nil {
+			closeWith(websocket.CloseInternalServerErr, err.Error())
+			return
+		}
+		for ; sent < len(history); sent++ {
+			if err = conn.WriteJSON(history[sent]); err != nil {
+				log.Ctx(ctx).Debug().Err(err).Msgf("failed to send the state of job %s", jobID)
+				return
+			}
+		}
+		if state.State.IsTerminal() {
+			closeWith(websocket.CloseNormalClosure, fmt.Sprintf("job ended as %s", state.State))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+		}
+	}
+}
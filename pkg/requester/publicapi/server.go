@@ -3,19 +3,45 @@ package publicapi
 import (
 	"net/http"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/explorer"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/reservation"
+	"github.com/bacalhau-project/bacalhau/pkg/resolver"
+	"github.com/bacalhau-project/bacalhau/pkg/routing"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	sync "github.com/bacalhau-project/golang-mutex-tracer"
-	"github.com/gorilla/websocket"
+	"github.com/c2h5oh/datasize"
+	"github.com/libp2p/go-libp2p/core/host"
 )
 
 const (
 	APIPrefix     = "requester/"
 	ApprovalRoute = "approve"
 	VerifyRoute   = "verify"
+	SearchRoute   = "search"
+	MigrateRoute  = "admin/migrate"
+	ExportRoute   = "admin/export"
+	ImportRoute   = "admin/import"
+
+	ReserveRoute      = "admin/reserve"
+	ReleaseRoute      = "admin/release"
+	ReservationsRoute = "admin/reservations"
+	AdminNodeRoute    = "admin/node"
+
+	ExplorerStatsRoute = "explorer/stats"
+	ExplorerJobsRoute  = "explorer/jobs"
+	ExplorerNodesRoute = "explorer/nodes"
+
+	// StatesRoute streams the changes to the state of a job over a websocket.
+	StatesRoute = "websocket/states"
+
+	// maxImportSize is the largest snapshot that can be imported, which holds every job of a requester
+	maxImportSize = 1 * datasize.GB
 )
 
 type RequesterAPIServerParams struct {
@@ -24,6 +50,37 @@ type RequesterAPIServerParams struct {
 	DebugInfoProviders []model.DebugInfoProvider
 	JobStore           jobstore.Store
 	StorageProviders   storage.StorageProvider
+	SpecLimits         job.SpecLimits
+	// EnvPolicy is the environment variables the jobs submitted can set.
+	EnvPolicy model.EnvironmentVariablePolicy
+	// PriorityRange is the priorities the jobs submitted can have.
+	PriorityRange job.PriorityRange
+	// SchedulerAudit is the decisions of the scheduler returned to debug jobs. None are returned if nil.
+	SchedulerAudit *requester.SchedulerAudit
+	// NodeInfoStore is used to list the compute nodes, and to report the queue position of executions waiting on them.
+	// Optional.
+	NodeInfoStore routing.NodeInfoStore
+	// AdminClientIDs are the clients allowed to use the admin API. The admin API is disabled if empty.
+	AdminClientIDs []string
+	// Reservations makes the capacity reservations of the admin API. Capacity can't be reserved if nil.
+	Reservations *reservation.Manager
+	// NodeID identifies the requester as the source of the events it serves as CloudEvents, and of the snapshots it
+	// exports.
+	NodeID string
+	// Explorer serves the explorer endpoints without authentication. They are not served if nil.
+	Explorer *explorer.Explorer
+	// Sharding partitions the ownership of jobs among the requesters sharing the cluster, and the requests for jobs
+	// owned by a peer are proxied to it. Requests are never proxied if it is not enabled.
+	Sharding requester.ShardingConfig
+	// Resolver resolves the references to jobs and nodes in requests, which can be their ID, a prefix of their ID or
+	// the name of a job. Optional, defaults to resolving them among the jobs of JobStore and the nodes of NodeInfoStore.
+	Resolver resolver.Resolver
+	// ComputeEndpoint forwards the admin requests of clients to compute nodes. Compute nodes can't be administered
+	// through the requester if nil, and only accept the requests of requesters they trust.
+	ComputeEndpoint compute.Endpoint
+	// Host is the libp2p host of the requester, which attach streams are opened from so that compute nodes can tell
+	// they come from the requester of the job. Clients can't attach to executions if nil.
+	Host host.Host
 }
 
 type RequesterAPIServer struct {
@@ -32,39 +89,125 @@ type RequesterAPIServer struct {
 	debugInfoProviders []model.DebugInfoProvider
 	jobStore           jobstore.Store
 	storageProviders   storage.StorageProvider
+	specLimits         job.SpecLimits
+	envPolicy          model.EnvironmentVariablePolicy
+	priorityRange      job.PriorityRange
+	schedulerAudit     *requester.SchedulerAudit
+	nodeInfoStore      routing.NodeInfoStore
+	adminClientIDs     []string
+	adminReplays       *publicapi.ReplayGuard
+	reservations       *reservation.Manager
+	nodeID             string
+	eventSource        string
+	explorer           *explorer.Explorer
+	sharding           requester.ShardingConfig
+	resolver           resolver.Resolver
+	computeEndpoint    compute.Endpoint
+	host               host.Host
+	uploads            *uploads
 	// jobId or "" (for all events) -> connections for that subscription
-	websockets      map[string][]*websocket.Conn
+	websockets      map[string][]*eventsSubscriber
 	websocketsMutex sync.RWMutex
 }
 
 func NewRequesterAPIServer(params RequesterAPIServerParams) *RequesterAPIServer {
+	idResolver := params.Resolver
+	if idResolver == nil {
+		idResolver = resolver.NewStoreResolver(resolver.StoreResolverParams{
+			JobStore:      params.JobStore,
+			NodeInfoStore: params.NodeInfoStore,
+		})
+	}
 	return &RequesterAPIServer{
 		apiServer:          params.APIServer,
 		requester:          params.Requester,
 		debugInfoProviders: params.DebugInfoProviders,
 		jobStore:           params.JobStore,
 		storageProviders:   params.StorageProviders,
-		websockets:         make(map[string][]*websocket.Conn),
+		specLimits:         params.SpecLimits,
+		envPolicy:          params.EnvPolicy,
+		priorityRange:      params.PriorityRange,
+		schedulerAudit:     params.SchedulerAudit,
+		nodeInfoStore:      params.NodeInfoStore,
+		adminClientIDs:     params.AdminClientIDs,
+		adminReplays:       publicapi.NewReplayGuard(publicapi.AdminRequestTTL),
+		reservations:       params.Reservations,
+		nodeID:             params.NodeID,
+		eventSource:        model.CloudEventSource(params.NodeID),
+		explorer:           params.Explorer,
+		sharding:           params.Sharding,
+		resolver:           idResolver,
+		computeEndpoint:    params.ComputeEndpoint,
+		host:               params.Host,
+		uploads:            newUploads(),
+		websockets:         make(map[string][]*eventsSubscriber),
 	}
 }
 
+// Close stops the background tasks of the server, and aborts the uploads in progress.
+func (s *RequesterAPIServer) Close() {
+	s.uploads.stop()
+}
+
 func (s *RequesterAPIServer) RegisterAllHandlers() error {
+	// the requests for a single job are proxied to the requester that owns it, and those listing jobs are sent to
+	// every requester
+	router, err := newShardRouter(s.sharding, s.nodeID)
+	if err != nil {
+		return err
+	}
+
 	handlerConfigs := []publicapi.HandlerConfig{
-		{Path: "/" + APIPrefix + "list", Handler: http.HandlerFunc(s.list)},
-		{Path: "/" + APIPrefix + "states", Handler: http.HandlerFunc(s.states)},
-		{Path: "/" + APIPrefix + "results", Handler: http.HandlerFunc(s.results)},
-		{Path: "/" + APIPrefix + "events", Handler: http.HandlerFunc(s.events)},
-		{Path: "/" + APIPrefix + "submit", Handler: http.HandlerFunc(s.submit)},
-		{Path: "/" + APIPrefix + ApprovalRoute, Handler: http.HandlerFunc(s.approve)},
-		{Path: "/" + APIPrefix + VerifyRoute, Handler: http.HandlerFunc(s.verify)},
-		{Path: "/" + APIPrefix + "cancel", Handler: http.HandlerFunc(s.cancel)},
-		{Path: "/" + APIPrefix + "websocket/events", Handler: http.HandlerFunc(s.websocketJobEvents), Raw: true},
-		{Path: "/" + APIPrefix + "logs", Handler: http.HandlerFunc(s.logs), Raw: true},
-		{Path: "/" + APIPrefix + "debug", Handler: http.HandlerFunc(s.debug)},
+		{Path: "/" + APIPrefix + "list", Handler: router.fanOut(s.list, mergeListResponses), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + SearchRoute, Handler: router.fanOut(s.search, mergeSearchResponses), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "states", Handler: router.route(s.states), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "results", Handler: router.route(s.results), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "events", Handler: router.route(s.events), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "nodes", Handler: http.HandlerFunc(s.nodes), Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "submit", Handler: router.route(s.submit), Scope: publicapi.ScopeSubmit},
+		{Path: "/" + APIPrefix + ApprovalRoute, Handler: router.route(s.approve), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + VerifyRoute, Handler: router.route(s.verify), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + "cancel", Handler: router.route(s.cancel), Scope: publicapi.ScopeSubmit},
+		{Path: "/" + APIPrefix + "update", Handler: router.route(s.update), Scope: publicapi.ScopeSubmit},
+		{Path: "/" + APIPrefix + MigrateRoute, Handler: router.fanOut(s.migrate, mergeMigrateResponses), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + ExportRoute, Handler: http.HandlerFunc(s.export), Scope: publicapi.ScopeAdmin},
+		{
+			Path:                 "/" + APIPrefix + ImportRoute,
+			Handler:              http.HandlerFunc(s.importSnapshot),
+			MaxBytesToReadInBody: maxImportSize,
+			Scope:                publicapi.ScopeAdmin,
+		},
+		{Path: "/" + APIPrefix + ReserveRoute, Handler: http.HandlerFunc(s.reserve), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + ReleaseRoute, Handler: http.HandlerFunc(s.release), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + ReservationsRoute, Handler: http.HandlerFunc(s.listReservations), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + AdminNodeRoute, Handler: http.HandlerFunc(s.adminNode), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + "websocket/events", Handler: router.route(s.websocketJobEvents), Raw: true, Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + StatesRoute, Handler: router.route(s.websocketJobStates), Raw: true, Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "logs", Handler: router.route(s.logs), Raw: true, Scope: publicapi.ScopeRead},
+		{Path: "/" + APIPrefix + "attach", Handler: router.route(s.attach), Raw: true, Scope: publicapi.ScopeSubmit},
+		{Path: "/" + APIPrefix + "debug", Handler: http.HandlerFunc(s.debug), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + "debug/job", Handler: router.route(s.debugJob), Scope: publicapi.ScopeAdmin},
+		{Path: "/" + APIPrefix + "upload/init", Handler: http.HandlerFunc(s.uploadInit), Scope: publicapi.ScopeSubmit},
+		{
+			Path:                 "/" + APIPrefix + "upload/part",
+			Handler:              http.HandlerFunc(s.uploadPart),
+			MaxBytesToReadInBody: maxUploadPartSize,
+			Scope:                publicapi.ScopeSubmit,
+		},
+		{Path: "/" + APIPrefix + "upload/status", Handler: http.HandlerFunc(s.uploadStatus), Scope: publicapi.ScopeSubmit},
+		{Path: "/" + APIPrefix + "upload/complete", Handler: http.HandlerFunc(s.uploadComplete), Scope: publicapi.ScopeSubmit},
+	}
+	// the explorer endpoints have no scope, so that they are public even when clients are authenticated
+	if s.explorer != nil {
+		handlerConfigs = append(handlerConfigs,
+			publicapi.HandlerConfig{Path: "/" + APIPrefix + ExplorerStatsRoute, Handler: http.HandlerFunc(s.explorerStats)},
+			publicapi.HandlerConfig{Path: "/" + APIPrefix + ExplorerJobsRoute, Handler: http.HandlerFunc(s.explorerJobs)},
+			publicapi.HandlerConfig{Path: "/" + APIPrefix + ExplorerNodesRoute, Handler: http.HandlerFunc(s.explorerNodes)},
+		)
 	}
 	// register URIs at root prefix for backward compatibility before migrating to API versioning
 	// we should remove these eventually, or have throttling limits shared across versions
-	err := s.apiServer.RegisterHandlers(publicapi.LegacyAPIPrefix, handlerConfigs...)
+	err = s.apiServer.RegisterHandlers(publicapi.LegacyAPIPrefix, handlerConfigs...)
 	if err != nil {
 		return err
 	}
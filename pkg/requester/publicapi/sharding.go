@@ -0,0 +1,361 @@
+package publicapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// HTTPHeaderForwardedBy is set on the requests proxied to the requester that owns their job, to the ID of the
+	// requester that proxied them, so that they are never proxied again.
+	HTTPHeaderForwardedBy = "X-Bacalhau-Forwarded-By"
+	// HTTPHeaderForwardedAt is the Unix time a request was proxied at.
+	HTTPHeaderForwardedAt = "X-Bacalhau-Forwarded-At"
+	// HTTPHeaderForwardedSignature authenticates a proxied request as coming from a peer requester. It is the HMAC of
+	// the request and of when it was proxied, with the secret the requesters share.
+	HTTPHeaderForwardedSignature = "X-Bacalhau-Forwarded-Signature"
+
+	// forwardedRequestTTL is how long after it was proxied a request is accepted by the peer it was proxied to.
+	forwardedRequestTTL = time.Minute
+)
+
+// shardRouter proxies the requests for jobs owned by a peer requester to that peer.
+type shardRouter struct {
+	sharding requester.ShardingConfig
+	nodeID   string
+	// peers are the URLs of the peers, and proxies the proxies to them, by shard. Those of this requester are nil.
+	peers   []*url.URL
+	proxies []*httputil.ReverseProxy
+	client  *http.Client
+}
+
+// newShardRouter returns a router to the peers of the requester, or nil if jobs are not sharded.
+func newShardRouter(sharding requester.ShardingConfig, nodeID string) (*shardRouter, error) {
+	if !sharding.Enabled() {
+		return nil, nil
+	}
+	if err := sharding.Validate(); err != nil {
+		return nil, err
+	}
+	router := &shardRouter{
+		sharding: sharding,
+		nodeID:   nodeID,
+		peers:    make([]*url.URL, len(sharding.Peers)),
+		proxies:  make([]*httputil.ReverseProxy, len(sharding.Peers)),
+		client:   &http.Client{},
+	}
+	for shard, peer := range sharding.Peers {
+		if shard == sharding.Shard {
+			continue
+		}
+		peerURL, err := url.Parse(peer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid requester peer URL %q: %w", peer, err)
+		}
+		router.peers[shard] = peerURL
+		router.proxies[shard] = httputil.NewSingleHostReverseProxy(peerURL)
+	}
+	return router, nil
+}
+
+// route wraps the handler of a job endpoint so that requests for jobs owned by a peer are proxied to it. The job is
+// read from the job_id query parameter, or else from the body of the request, which is left as it was for the
+// handler or the peer.
+func (r *shardRouter) route(handler http.HandlerFunc) http.HandlerFunc {
+	if r == nil {
+		return handler
+	}
+	return func(res http.ResponseWriter, req *http.Request) {
+		body, forwarded, ok := r.readRequest(res, req)
+		if !ok {
+			return
+		}
+		if forwarded {
+			handler(res, req)
+			return
+		}
+		shard, ok := r.ownerOf(jobRefOf(req, body))
+		if !ok || shard == r.sharding.Shard {
+			handler(res, req)
+			return
+		}
+		r.proxy(shard, res, req, body)
+	}
+}
+
+// mergeFunc merges the responses of every requester to a request with the body, into the response to the client.
+type mergeFunc func(req *http.Request, body []byte, responses [][]byte) (any, error)
+
+// fanOut wraps the handler of an endpoint listing jobs so that requests for a job are routed to its owner, like
+// route, and the other requests are sent to every requester and their responses merged.
+func (r *shardRouter) fanOut(handler http.HandlerFunc, merge mergeFunc) http.HandlerFunc {
+	if r == nil {
+		return handler
+	}
+	return func(res http.ResponseWriter, req *http.Request) {
+		body, forwarded, ok := r.readRequest(res, req)
+		if !ok {
+			return
+		}
+		if forwarded {
+			handler(res, req)
+			return
+		}
+		if shard, ok := r.ownerOf(jobRefOf(req, body)); ok {
+			if shard == r.sharding.Shard {
+				handler(res, req)
+			} else {
+				r.proxy(shard, res, req, body)
+			}
+			return
+		}
+
+		responses := make([][]byte, len(r.sharding.Peers))
+		errs := make([]error, len(r.sharding.Peers))
+		var wg sync.WaitGroup
+		for shard := range r.sharding.Peers {
+			wg.Add(1)
+			go func(shard int) {
+				defer wg.Done()
+				if shard == r.sharding.Shard {
+					responses[shard], errs[shard] = serveLocally(handler, req, body)
+				} else {
+					responses[shard], errs[shard] = r.send(req.Context(), shard, req, body)
+				}
+			}(shard)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				status := http.StatusBadGateway
+				var shardErr *shardError
+				if errors.As(err, &shardErr) {
+					status = shardErr.status
+				}
+				publicapi.HTTPError(req.Context(), res, err, status)
+				return
+			}
+		}
+		merged, err := merge(req, body, responses)
+		if err != nil {
+			publicapi.HTTPError(req.Context(), res, err, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(res, req, merged)
+	}
+}
+
+// readRequest reads the body of the request, which is left as it was for the handler or the peer, and checks whether
+// it was proxied by a peer. Requests claiming to be proxied are rejected unless they were signed by a peer.
+func (r *shardRouter) readRequest(res http.ResponseWriter, req *http.Request) ([]byte, bool, bool) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		publicapi.HTTPError(req.Context(), res, err, http.StatusBadRequest)
+		return nil, false, false
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	forwardedBy := req.Header.Get(HTTPHeaderForwardedBy)
+	if forwardedBy == "" {
+		return body, false, true
+	}
+	if err = r.verify(req, forwardedBy, body, time.Now()); err != nil {
+		log.Ctx(req.Context()).Warn().Err(err).Msgf("rejecting request claiming to be proxied by requester %s", forwardedBy)
+		publicapi.HTTPError(req.Context(), res, err, http.StatusForbidden)
+		return nil, false, false
+	}
+	return body, true, true
+}
+
+// ownerOf returns the shard of the requester that owns the job the reference is to, and false if the request is for
+// no job in particular. References that are not at least a short ID can only be names, which never look like IDs, and
+// the jobs with a name are owned by the owner of the name.
+func (r *shardRouter) ownerOf(ref string) (int, bool) {
+	switch {
+	case ref == "":
+		return 0, false
+	case model.IsJobIDPrefix(ref):
+		return r.sharding.ShardOf(ref), true
+	default:
+		return r.sharding.ShardOfName(ref), true
+	}
+}
+
+// proxy sends the request to the peer that owns its job, and its response back to the client.
+func (r *shardRouter) proxy(shard int, res http.ResponseWriter, req *http.Request, body []byte) {
+	log.Ctx(req.Context()).Debug().Msgf("proxying %s request to requester %s", req.URL.Path, r.sharding.Peers[shard])
+	r.sign(req, body, time.Now())
+	req.ContentLength = int64(len(body))
+	r.proxies[shard].ServeHTTP(res, req)
+}
+
+// send sends a copy of the request to the peer, and returns the body of its response.
+func (r *shardRouter) send(ctx context.Context, shard int, req *http.Request, body []byte) ([]byte, error) {
+	peerURL := *r.peers[shard]
+	peerURL.Path = req.URL.Path
+	peerURL.RawQuery = req.URL.RawQuery
+	peerReq, err := http.NewRequestWithContext(ctx, req.Method, peerURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	peerReq.Header = req.Header.Clone()
+	r.sign(peerReq, body, time.Now())
+
+	peerRes, err := r.client.Do(peerReq)
+	if err != nil {
+		return nil, fmt.Errorf("requester %s: %w", r.sharding.Peers[shard], err)
+	}
+	defer peerRes.Body.Close()
+	response, err := io.ReadAll(peerRes.Body)
+	if err != nil {
+		return nil, fmt.Errorf("requester %s: %w", r.sharding.Peers[shard], err)
+	}
+	if peerRes.StatusCode != http.StatusOK {
+		return nil, &shardError{peer: r.sharding.Peers[shard], status: peerRes.StatusCode, body: response}
+	}
+	return response, nil
+}
+
+// serveLocally handles the request with the handler, and returns the body of the response.
+func serveLocally(handler http.HandlerFunc, req *http.Request, body []byte) ([]byte, error) {
+	localReq := req.Clone(req.Context())
+	localReq.Body = io.NopCloser(bytes.NewReader(body))
+	recorder := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	handler(recorder, localReq)
+	if recorder.status != http.StatusOK {
+		return nil, &shardError{status: recorder.status, body: recorder.body.Bytes()}
+	}
+	return recorder.body.Bytes(), nil
+}
+
+// bufferedResponse keeps the response of a handler, to be merged with the responses of the peers.
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.wroteHeader = true
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(data)
+}
+
+// shardError is the response of a requester that failed to handle its part of a request sent to every requester.
+type shardError struct {
+	peer   string
+	status int
+	body   []byte
+}
+
+func (e *shardError) Error() string {
+	if e.peer == "" {
+		return strings.TrimSpace(string(e.body))
+	}
+	return fmt.Sprintf("requester %s: %s", e.peer, strings.TrimSpace(string(e.body)))
+}
+
+// sign marks the request as proxied by this requester.
+func (r *shardRouter) sign(req *http.Request, body []byte, at time.Time) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(HTTPHeaderForwardedBy, r.nodeID)
+	req.Header.Set(HTTPHeaderForwardedAt, timestamp)
+	req.Header.Set(HTTPHeaderForwardedSignature, r.signature(req, r.nodeID, timestamp, body))
+}
+
+// verify checks that the request was signed by a peer, recently enough not to be a replay.
+func (r *shardRouter) verify(req *http.Request, forwardedBy string, body []byte, now time.Time) error {
+	timestamp := req.Header.Get(HTTPHeaderForwardedAt)
+	at, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("proxied request has no valid time")
+	}
+	if age := now.Sub(time.Unix(at, 0)); age > forwardedRequestTTL || age < -forwardedRequestTTL {
+		return fmt.Errorf("proxied request expired %s ago", age-forwardedRequestTTL)
+	}
+	expected := r.signature(req, forwardedBy, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(HTTPHeaderForwardedSignature))) {
+		return errors.New("proxied request is not signed by a peer requester")
+	}
+	return nil
+}
+
+// signature is the HMAC of what the request does, who proxied it and when, with the secret of the cluster.
+func (r *shardRouter) signature(req *http.Request, forwardedBy string, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(r.sharding.Secret))
+	for _, part := range []string{forwardedBy, timestamp, req.Method, req.URL.RequestURI(), hex.EncodeToString(bodyHash[:])} {
+		_, _ = mac.Write([]byte(part))
+		_, _ = mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// jobRefOf returns the reference to the job a request is for, from the job_id query parameter of websocket and GET
+// requests, or else from the body of the request.
+func jobRefOf(req *http.Request, body []byte) string {
+	if ref := req.URL.Query().Get("job_id"); ref != "" {
+		return ref
+	}
+	return jobIDFromBody(body)
+}
+
+// jobIDFromBody returns the reference to the job a request is for, from the fields the job endpoints take it in,
+// either in the request itself or in the payload of a signed request. Submissions are for the job they name.
+func jobIDFromBody(body []byte) string {
+	var request struct {
+		JobID      string `json:"job_id"`
+		ListJobID  string `json:"id"`
+		DebugJobID string `json:"JobID"`
+		Payload    struct {
+			JobID         string `json:"JobID"`
+			Name          string `json:"Name"`
+			Verifications []struct {
+				ExecutionID model.ExecutionID `json:"ExecutionID"`
+			} `json:"verifications"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return ""
+	}
+	refs := []string{request.JobID, request.ListJobID, request.DebugJobID, request.Payload.JobID, request.Payload.Name}
+	if len(request.Payload.Verifications) > 0 {
+		refs = append(refs, request.Payload.Verifications[0].ExecutionID.JobID)
+	}
+	for _, ref := range refs {
+		if ref != "" {
+			return ref
+		}
+	}
+	return ""
+}
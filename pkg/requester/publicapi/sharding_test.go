@@ -0,0 +1,168 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// jobsByOwner returns a job ID owned by the requester and one that is not, and the same for job names.
+func jobsByOwner(sharding requester.ShardingConfig) (owned, notOwned, ownedName, notOwnedName string) {
+	for owned == "" || notOwned == "" {
+		jobID := uuid.NewString()
+		if sharding.Owns(jobID) {
+			owned = jobID
+		} else {
+			notOwned = jobID
+		}
+	}
+	for i := 0; ownedName == "" || notOwnedName == ""; i++ {
+		name := fmt.Sprintf("job-%d", i)
+		if sharding.OwnsName(name) {
+			ownedName = name
+		} else {
+			notOwnedName = name
+		}
+	}
+	return owned, notOwned, ownedName, notOwnedName
+}
+
+func TestShardRouter(t *testing.T) {
+	var peerBodies, peerPaths, forwardedBy []string
+	var peerRouter *shardRouter
+	peer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		peerBodies = append(peerBodies, string(body))
+		peerPaths = append(peerPaths, req.URL.RequestURI())
+		forwardedBy = append(forwardedBy, req.Header.Get(HTTPHeaderForwardedBy))
+		if err := peerRouter.verify(req, req.Header.Get(HTTPHeaderForwardedBy), body, time.Now()); err != nil {
+			http.Error(res, err.Error(), http.StatusForbidden)
+			return
+		}
+		_, _ = res.Write([]byte("peer"))
+	}))
+	defer peer.Close()
+
+	sharding := requester.ShardingConfig{Shard: 0, Peers: []string{"http://localhost:1", peer.URL}, Secret: "secret"}
+	router, err := newShardRouter(sharding, "requester-0")
+	require.NoError(t, err)
+	peerSharding := sharding
+	peerSharding.Shard = 1
+	peerRouter, err = newShardRouter(peerSharding, "requester-1")
+	require.NoError(t, err)
+
+	handler := router.route(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte("local"))
+	})
+	owned, notOwned, ownedName, notOwnedName := jobsByOwner(sharding)
+
+	serve := func(target string, body string, forward func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+		if forward != nil {
+			forward(req)
+		}
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res
+	}
+	const states = "/api/v1/requester/states"
+
+	require.Equal(t, "local", serve(states, fmt.Sprintf(`{"job_id": %q}`, owned), nil).Body.String())
+	require.Equal(t, "local", serve(states, `{"client_id": "client"}`, nil).Body.String())
+	require.Equal(t, "local", serve(states, fmt.Sprintf(`{"job_id": %q}`, ownedName), nil).Body.String())
+
+	body := fmt.Sprintf(`{"job_id": %q}`, notOwned)
+	forwarded := serve(states, body, func(req *http.Request) { peerRouter.sign(req, []byte(body), time.Now()) })
+	require.Equal(t, "local", forwarded.Body.String(), "requests proxied by a peer are not proxied again")
+
+	res := serve(states, body, func(req *http.Request) { req.Header.Set(HTTPHeaderForwardedBy, "requester-1") })
+	require.Equal(t, http.StatusForbidden, res.Code, "clients cannot claim their requests were proxied")
+	res = serve(states, body, func(req *http.Request) { peerRouter.sign(req, []byte(body), time.Now().Add(-time.Hour)) })
+	require.Equal(t, http.StatusForbidden, res.Code, "proxied requests expire")
+	res = serve(states, body, func(req *http.Request) { peerRouter.sign(req, []byte(`{"job_id": "other"}`), time.Now()) })
+	require.Equal(t, http.StatusForbidden, res.Code, "the signature covers the body")
+
+	body = fmt.Sprintf(`{"payload": {"ClientID": "client", "JobID": %q}, "signature": "sig"}`, notOwned)
+	require.Equal(t, "peer", serve(states, body, nil).Body.String())
+	require.Equal(t, []string{body}, peerBodies, "the body is proxied unchanged")
+	require.Equal(t, []string{states}, peerPaths)
+	require.Equal(t, []string{"requester-0"}, forwardedBy)
+	require.Equal(t, "peer", serve(states, fmt.Sprintf(`{"id": %q}`, notOwned[:8]), nil).Body.String(),
+		"short IDs are routed to their owner")
+	require.Equal(t, "peer", serve(states, fmt.Sprintf(`{"job_id": %q}`, notOwnedName), nil).Body.String(),
+		"names are routed to their owner")
+	require.Equal(t, "peer", serve(states, fmt.Sprintf(`{"payload": {"Name": %q}}`, notOwnedName), nil).Body.String(),
+		"named jobs are submitted to the owner of their name")
+
+	logs := "/api/v1/requester/logs?job_id=" + notOwned
+	require.Equal(t, "peer", serve(logs, "", nil).Body.String(), "websockets are routed by the job in their URL")
+	require.Equal(t, logs, peerPaths[len(peerPaths)-1])
+
+	router, err = newShardRouter(requester.ShardingConfig{}, "requester-0")
+	require.NoError(t, err)
+	require.Nil(t, router, "requests are not routed if jobs are not sharded")
+}
+
+func TestShardRouterFansOutLists(t *testing.T) {
+	created := time.Now()
+	listed := func(id string, age time.Duration) *model.JobWithInfo {
+		return &model.JobWithInfo{Job: model.Job{Metadata: model.Metadata{ID: id, CreatedAt: created.Add(-age)}}}
+	}
+
+	var peerRouter *shardRouter
+	peer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if err := peerRouter.verify(req, req.Header.Get(HTTPHeaderForwardedBy), body, time.Now()); err != nil {
+			http.Error(res, err.Error(), http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(res).Encode(ListResponse{Jobs: []*model.JobWithInfo{listed("b", 2*time.Minute)}})
+	}))
+	defer peer.Close()
+
+	sharding := requester.ShardingConfig{Shard: 0, Peers: []string{"http://localhost:1", peer.URL}, Secret: "secret"}
+	router, err := newShardRouter(sharding, "requester-0")
+	require.NoError(t, err)
+	peerSharding := sharding
+	peerSharding.Shard = 1
+	peerRouter, err = newShardRouter(peerSharding, "requester-1")
+	require.NoError(t, err)
+
+	handler := router.fanOut(func(res http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(res).Encode(ListResponse{
+			Jobs: []*model.JobWithInfo{listed("a", time.Minute), listed("c", 3*time.Minute)},
+		})
+	}, mergeListResponses)
+
+	list := func(request ListRequest) []string {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(http.MethodPost, "/api/v1/requester/list", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var response ListResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		ids := make([]string, 0, len(response.Jobs))
+		for _, job := range response.Jobs {
+			ids = append(ids, job.Job.Metadata.ID)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"c", "b", "a"}, list(ListRequest{SortBy: "created_at"}))
+	require.Equal(t, []string{"a", "b"}, list(ListRequest{SortBy: "created_at", SortReverse: true, MaxJobs: 2}),
+		"the jobs of every requester are limited together")
+	require.Equal(t, []string{"a", "b", "c"}, list(ListRequest{SortBy: "id"}))
+}
@@ -0,0 +1,266 @@
+package publicapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	realsync "sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+	"github.com/c2h5oh/datasize"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxUploadPartSize is the largest part that can be uploaded at once. Parts are held in memory until their
+	// checksum is verified, so that a part is either fully added to the upload or not at all.
+	maxUploadPartSize = 16 * datasize.MB
+
+	// uploadIdleTimeout is how long an upload can go without receiving parts before it is aborted.
+	uploadIdleTimeout = time.Hour
+	// uploadIdleCheckInterval is how often idle uploads are looked for.
+	uploadIdleCheckInterval = time.Minute
+
+	// maxUploadsPerOwner is how many uploads a client can have in progress at once.
+	maxUploadsPerOwner = 8
+	// maxUploadBytesPerOwner is the total size of the uploads a client can have in progress at once.
+	maxUploadBytesPerOwner = 64 * datasize.GB
+)
+
+// errTooManyUploads is returned when a client starts an upload that would take it past the limits on the uploads
+// it can have in progress.
+type errTooManyUploads struct {
+	uploads int
+	bytes   int64
+}
+
+func (e errTooManyUploads) Error() string {
+	return fmt.Sprintf("too many uploads in progress: %d uploads of %d bytes in total, at most %d uploads of %d bytes "+
+		"are allowed. Complete them or wait for them to time out", e.uploads, e.bytes, maxUploadsPerOwner, maxUploadBytesPerOwner.Bytes())
+}
+
+// upload is a multipart upload in progress. Parts are written in order straight into the stream being added
+// to storage, so the upload is never assembled on the local disk.
+type upload struct {
+	mu sync.Mutex
+	id string
+	// owner identifies the client that started the upload, which is the only one that can use it
+	owner  string
+	size   int64
+	offset int64
+	hash   hash.Hash
+	writer *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{}
+	result model.StorageSpec
+	err    error
+	// updatedAt is the time the last part was received, in Unix nanoseconds
+	updatedAt atomic.Int64
+}
+
+// uploads tracks the multipart uploads in progress on the requester node, and aborts those that are idle in the
+// background.
+type uploads struct {
+	mu      sync.Mutex
+	uploads map[string]*upload
+
+	stopChannel chan struct{}
+	stopOnce    realsync.Once
+}
+
+func newUploads() *uploads {
+	u := &uploads{
+		uploads:     make(map[string]*upload),
+		stopChannel: make(chan struct{}),
+	}
+	go u.abortIdleBackgroundTask()
+	return u
+}
+
+// init starts a new upload of size bytes for the owner, streaming it to the uploader as parts are received. It
+// returns an errTooManyUploads if the owner has too many uploads in progress already.
+func (u *uploads) init(owner string, size int64, uploader storage.StreamUploader) (*upload, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	u.abortIdle(now)
+	var count int
+	var total int64
+	for _, up := range u.uploads {
+		if up.owner == owner {
+			count++
+			total += up.size
+		}
+	}
+	if count >= maxUploadsPerOwner || uint64(total+size) > maxUploadBytesPerOwner.Bytes() {
+		return nil, errTooManyUploads{uploads: count, bytes: total}
+	}
+
+	// uploads are not bound to the request that started them
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+	up := &upload{
+		id:     uuid.NewString(),
+		owner:  owner,
+		size:   size,
+		hash:   sha256.New(),
+		writer: writer,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	up.updatedAt.Store(now.UnixNano())
+	go func() {
+		defer close(up.done)
+		up.result, up.err = uploader.UploadStream(ctx, reader)
+		// fail any part still being written if the storage stopped reading early
+		_ = reader.CloseWithError(fmt.Errorf("upload %s has stopped", up.id))
+	}()
+
+	u.uploads[up.id] = up
+	return up, nil
+}
+
+// abortIdle aborts uploads that have not received a part since the idle timeout. Must be called with the lock held.
+func (u *uploads) abortIdle(now time.Time) {
+	for id, up := range u.uploads {
+		if now.Sub(time.Unix(0, up.updatedAt.Load())) > uploadIdleTimeout {
+			log.Debug().Msgf("aborting idle upload %s", id)
+			up.abort(fmt.Errorf("upload %s timed out", id))
+			delete(u.uploads, id)
+		}
+	}
+}
+
+func (u *uploads) abortIdleBackgroundTask() {
+	ticker := time.NewTicker(uploadIdleCheckInterval)
+	for {
+		select {
+		case now := <-ticker.C:
+			u.mu.Lock()
+			u.abortIdle(now)
+			u.mu.Unlock()
+		case <-u.stopChannel:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// stop stops looking for idle uploads, and aborts the uploads in progress.
+func (u *uploads) stop() {
+	u.stopOnce.Do(func() {
+		close(u.stopChannel)
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		for id, up := range u.uploads {
+			up.abort(fmt.Errorf("upload %s was stopped", id))
+			delete(u.uploads, id)
+		}
+	})
+}
+
+func (u *uploads) get(id string) (*upload, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	up, ok := u.uploads[id]
+	if !ok {
+		return nil, fmt.Errorf("upload %s not found", id)
+	}
+	return up, nil
+}
+
+func (u *uploads) remove(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.uploads, id)
+}
+
+// errUnexpectedOffset is returned when a part does not start where the previous part ended.
+type errUnexpectedOffset struct {
+	expected int64
+	actual   int64
+}
+
+func (e errUnexpectedOffset) Error() string {
+	return fmt.Sprintf("unexpected part offset %d, upload continues at offset %d", e.actual, e.expected)
+}
+
+// writePart verifies the part and appends it to the upload. Parts must be written in order.
+func (up *upload) writePart(offset int64, data []byte, checksum string) error {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != checksum {
+		return fmt.Errorf("checksum mismatch for part at offset %d", offset)
+	}
+	if offset != up.offset {
+		return errUnexpectedOffset{expected: up.offset, actual: offset}
+	}
+	if offset+int64(len(data)) > up.size {
+		return fmt.Errorf("part at offset %d of %d bytes exceeds upload size %d", offset, len(data), up.size)
+	}
+
+	if _, err := up.writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write part at offset %d: %w", offset, err)
+	}
+	up.hash.Write(data)
+	up.offset += int64(len(data))
+	up.updatedAt.Store(time.Now().UnixNano())
+	return nil
+}
+
+// complete finishes the upload once all parts have been written, and waits for storage to return its spec.
+func (up *upload) complete(ctx context.Context, checksum string) (model.StorageSpec, error) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	if up.offset != up.size {
+		return model.StorageSpec{}, fmt.Errorf("upload is incomplete: received %d of %d bytes", up.offset, up.size)
+	}
+	if checksum != "" && hex.EncodeToString(up.hash.Sum(nil)) != checksum {
+		err := fmt.Errorf("checksum mismatch for upload %s", up.id)
+		up.abort(err)
+		return model.StorageSpec{}, err
+	}
+
+	if err := up.writer.Close(); err != nil {
+		return model.StorageSpec{}, err
+	}
+	select {
+	case <-up.done:
+		return up.result, up.err
+	case <-ctx.Done():
+		return model.StorageSpec{}, ctx.Err()
+	}
+}
+
+// received returns how many bytes of the upload have been received.
+func (up *upload) received() int64 {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	return up.offset
+}
+
+// finished returns true once the upload has stopped streaming to storage, successfully or not.
+func (up *upload) finished() bool {
+	select {
+	case <-up.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// abort stops streaming the upload to storage.
+func (up *upload) abort(err error) {
+	_ = up.writer.CloseWithError(err)
+	up.cancel()
+}
@@ -0,0 +1,88 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	noop_storage "github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUploadPartsAreWrittenInOrder(t *testing.T) {
+	u := newUploads()
+	defer u.stop()
+	up, err := u.init("client", 10, noop_storage.NewNoopStorage())
+	require.NoError(t, err)
+
+	first, second := []byte("hello"), []byte("world")
+	require.Error(t, up.writePart(0, first, checksum(second)), "parts with a bad checksum should be rejected")
+	require.Equal(t, int64(0), up.received())
+
+	require.NoError(t, up.writePart(0, first, checksum(first)))
+	require.Equal(t, int64(5), up.received())
+
+	// a retried part that was already received should be rejected, telling the client where to resume
+	var offsetErr errUnexpectedOffset
+	require.ErrorAs(t, up.writePart(0, first, checksum(first)), &offsetErr)
+	require.Equal(t, int64(5), offsetErr.expected)
+
+	_, err = up.complete(context.Background(), "")
+	require.Error(t, err, "incomplete uploads should not complete")
+	require.False(t, up.finished())
+
+	require.Error(t, up.writePart(5, []byte("world!"), checksum([]byte("world!"))), "parts should not exceed the upload size")
+	require.NoError(t, up.writePart(5, second, checksum(second)))
+
+	spec, err := up.complete(context.Background(), checksum([]byte("helloworld")))
+	require.NoError(t, err)
+	require.Equal(t, "test", spec.CID)
+	require.True(t, up.finished())
+}
+
+func TestUploadCompleteChecksumMismatch(t *testing.T) {
+	u := newUploads()
+	defer u.stop()
+	up, err := u.init("client", 5, noop_storage.NewNoopStorage())
+	require.NoError(t, err)
+	require.NoError(t, up.writePart(0, []byte("hello"), checksum([]byte("hello"))))
+
+	_, err = up.complete(context.Background(), checksum([]byte("world")))
+	require.Error(t, err)
+	<-up.done
+	require.Error(t, up.err, "the stream should be aborted rather than stored")
+}
+
+func TestUploadsAreLimitedPerOwner(t *testing.T) {
+	u := newUploads()
+	defer u.stop()
+	storage := noop_storage.NewNoopStorage()
+
+	for i := 0; i < maxUploadsPerOwner; i++ {
+		_, err := u.init("client", 1, storage)
+		require.NoError(t, err)
+	}
+	_, err := u.init("client", 1, storage)
+	require.ErrorAs(t, err, &errTooManyUploads{})
+	_, err = u.init("other", 1, storage)
+	require.NoError(t, err, "the uploads of other clients are limited separately")
+
+	_, err = u.init("large", int64(maxUploadBytesPerOwner.Bytes())+1, storage)
+	require.ErrorAs(t, err, &errTooManyUploads{})
+
+	// idle uploads are aborted, which makes room for new ones
+	u.mu.Lock()
+	u.abortIdle(time.Now().Add(uploadIdleTimeout + time.Minute))
+	u.mu.Unlock()
+	_, err = u.init("client", 1, storage)
+	require.NoError(t, err)
+}
@@ -1 +1,67 @@
 package publicapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"golang.org/x/exp/slices"
+)
+
+// resolveJob returns the ID of the job the reference is to, which is its ID, a prefix of its ID or its name, among the
+// jobs of the namespace of the authenticated caller of the request. Jobs of other namespaces are reported as not
+// found, so that callers can't tell which jobs exist outside of their namespace.
+func (s *RequesterAPIServer) resolveJob(ctx context.Context, ref string) (string, error) {
+	return s.resolver.ResolveJobID(ctx, publicapi.RequestNamespace(ctx), ref)
+}
+
+// resolveJobOrFail replaces the reference to a job with the ID of the job. It writes an error and returns false if
+// the reference is not to exactly one job of the namespace of the authenticated caller of the request.
+func (s *RequesterAPIServer) resolveJobOrFail(ctx context.Context, res http.ResponseWriter, ref *string) bool {
+	jobID, err := s.resolveJob(ctx, *ref)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, resolveErrorStatus(err))
+		return false
+	}
+	*ref = jobID
+	return true
+}
+
+// resolveErrorStatus returns the status of the response to a reference that could not be resolved.
+func resolveErrorStatus(err error) int {
+	var ambiguous *bacerrors.AmbiguousReference
+	if errors.As(err, &ambiguous) {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+// unmarshalAdmin unmarshals a signed request of the admin API, and writes an error and returns false if the admin API
+// is disabled or the request was not signed by one of the admin clients.
+func unmarshalAdmin[Request publicapi.ContainsClientID](
+	s *RequesterAPIServer, res http.ResponseWriter, req *http.Request) (Request, bool) {
+	ctx := req.Context()
+	var request Request
+	if len(s.adminClientIDs) == 0 {
+		err := errors.New("admin API is not enabled on this node")
+		publicapi.HTTPError(ctx, res, err, http.StatusForbidden)
+		return request, false
+	}
+
+	request, err := publicapi.UnmarshalSigned[Request](ctx, req.Body)
+	if err != nil {
+		publicapi.HTTPError(ctx, res, err, http.StatusBadRequest)
+		return request, false
+	}
+
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, request.GetClientID())
+	if !slices.Contains(s.adminClientIDs, request.GetClientID()) {
+		err = errors.New("admin request submitted by unknown client")
+		publicapi.HTTPError(ctx, res, err, http.StatusUnauthorized)
+		return request, false
+	}
+	return request, true
+}
@@ -66,6 +66,10 @@ func (q *queue) CancelJob(ctx context.Context, req CancelJobRequest) (CancelJobR
 	return CancelJobResult{}, err
 }
 
+func (q *queue) MigrateExecutions(ctx context.Context, req MigrateExecutionsRequest) (MigrateExecutionsResult, error) {
+	return q.scheduler.MigrateExecutions(ctx, req)
+}
+
 func (q *queue) VerifyExecutions(ctx context.Context, results []verifier.VerifierResult) (succeeded, failed []verifier.VerifierResult) {
 	return q.scheduler.VerifyExecutions(ctx, results)
 }
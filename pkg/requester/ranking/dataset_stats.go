@@ -0,0 +1,210 @@
+package ranking
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultMaxDatasets is the number of datasets DatasetStats keeps statistics for by default.
+	DefaultMaxDatasets = 1000
+
+	// maxRunDuration is how long an accepted execution is waited for before its start is forgotten.
+	maxRunDuration = 24 * time.Hour
+
+	// minSampleWeight is the weight of the latest run in the averages of a node once it has run enough jobs over a
+	// dataset, so that the averages follow nodes that get faster or slower over time.
+	minSampleWeight = 0.25
+)
+
+type DatasetStatsParams struct {
+	JobStore jobstore.Store
+	// MaxDatasets is how many datasets statistics are kept for. The datasets that were least recently run are
+	// forgotten first. DefaultMaxDatasets if zero.
+	MaxDatasets int
+}
+
+// DatasetStats keeps, for each input dataset, how long the nodes that ran jobs over it took to fetch it and to run,
+// so that jobs over the same dataset can be scheduled on the nodes that handled it the fastest. Datasets are
+// identified by their CID, or by their URL for inputs without a CID.
+type DatasetStats struct {
+	jobStore    jobstore.Store
+	maxDatasets int
+
+	mu       sync.RWMutex
+	datasets map[string]*datasetStats
+	// acceptedAt is when the executions that have not proposed a result yet were accepted, by execution ID
+	acceptedAt map[string]time.Time
+}
+
+type datasetStats struct {
+	nodes   map[string]*NodeDatasetStats
+	lastRun time.Time
+}
+
+// NodeDatasetStats are the running averages of the jobs a node ran over a dataset.
+type NodeDatasetStats struct {
+	// Runs is the number of runs the averages are based on.
+	Runs int
+	// FetchDuration is how long fetching the inputs of the jobs took on average.
+	FetchDuration time.Duration
+	// RunDuration is how long the jobs ran on average once their inputs were fetched.
+	RunDuration time.Duration
+}
+
+// Total is how long the node took on average to fetch the dataset and run a job over it.
+func (s NodeDatasetStats) Total() time.Duration {
+	return s.FetchDuration + s.RunDuration
+}
+
+func (s *NodeDatasetStats) add(fetchDuration, runDuration time.Duration) {
+	s.Runs++
+	weight := 1 / float64(s.Runs)
+	if weight < minSampleWeight {
+		weight = minSampleWeight
+	}
+	s.FetchDuration += time.Duration(weight * float64(fetchDuration-s.FetchDuration))
+	s.RunDuration += time.Duration(weight * float64(runDuration-s.RunDuration))
+}
+
+func NewDatasetStats(params DatasetStatsParams) *DatasetStats {
+	if params.MaxDatasets <= 0 {
+		params.MaxDatasets = DefaultMaxDatasets
+	}
+	return &DatasetStats{
+		jobStore:    params.JobStore,
+		maxDatasets: params.MaxDatasets,
+		datasets:    make(map[string]*datasetStats),
+		acceptedAt:  make(map[string]time.Time),
+	}
+}
+
+// HandleJobEvent records when executions are accepted, and the durations of the runs whose results are proposed by
+// compute nodes.
+func (d *DatasetStats) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	if event.EventName == model.JobEventBidAccepted {
+		d.trackAccepted(event)
+		return nil
+	}
+	if event.EventName != model.JobEventResultsProposed {
+		return nil
+	}
+
+	d.mu.Lock()
+	acceptedAt, found := d.acceptedAt[event.ExecutionID]
+	delete(d.acceptedAt, event.ExecutionID)
+	d.mu.Unlock()
+	if !found || event.RunOutput == nil || event.SourceNodeID == "" {
+		return nil
+	}
+
+	job, err := d.jobStore.GetJob(ctx, event.JobID)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msgf("not recording dataset statistics of job %s", event.JobID)
+		return nil
+	}
+	datasets := jobDatasets(job)
+	if len(datasets) == 0 {
+		return nil
+	}
+
+	fetchDuration := event.RunOutput.InputsFetchDuration
+	runDuration := event.EventTime.Sub(acceptedAt) - fetchDuration
+	if runDuration < 0 {
+		runDuration = 0
+	}
+	d.Record(datasets, event.SourceNodeID, fetchDuration, runDuration, event.EventTime)
+	return nil
+}
+
+// trackAccepted remembers when the execution of the event was accepted, which is when the node started to run it, and
+// forgets the executions that were accepted too long ago to still be running.
+func (d *DatasetStats) trackAccepted(event model.JobEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for executionID, acceptedAt := range d.acceptedAt {
+		if event.EventTime.Sub(acceptedAt) > maxRunDuration {
+			delete(d.acceptedAt, executionID)
+		}
+	}
+	d.acceptedAt[event.ExecutionID] = event.EventTime
+}
+
+// Record adds a run of a job over the datasets by the node. The fetch duration of the job is attributed to each of
+// its datasets, as nodes only report how long fetching all the inputs took.
+func (d *DatasetStats) Record(datasets []string, nodeID string, fetchDuration, runDuration time.Duration, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, dataset := range datasets {
+		stats, ok := d.datasets[dataset]
+		if !ok {
+			stats = &datasetStats{nodes: make(map[string]*NodeDatasetStats)}
+			d.datasets[dataset] = stats
+		}
+		nodeStats, ok := stats.nodes[nodeID]
+		if !ok {
+			nodeStats = &NodeDatasetStats{}
+			stats.nodes[nodeID] = nodeStats
+		}
+		nodeStats.add(fetchDuration, runDuration)
+		if at.After(stats.lastRun) {
+			stats.lastRun = at
+		}
+	}
+	d.evict()
+}
+
+// evict forgets the datasets that were least recently run once there are too many of them.
+func (d *DatasetStats) evict() {
+	if len(d.datasets) <= d.maxDatasets {
+		return
+	}
+	datasets := make([]string, 0, len(d.datasets))
+	for dataset := range d.datasets {
+		datasets = append(datasets, dataset)
+	}
+	sort.Slice(datasets, func(i, j int) bool {
+		return d.datasets[datasets[i]].lastRun.Before(d.datasets[datasets[j]].lastRun)
+	})
+	for _, dataset := range datasets[:len(datasets)-d.maxDatasets] {
+		delete(d.datasets, dataset)
+	}
+}
+
+// Get returns the statistics of the nodes that ran jobs over the dataset, by node ID.
+func (d *DatasetStats) Get(dataset string) map[string]NodeDatasetStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	stats, ok := d.datasets[dataset]
+	if !ok {
+		return nil
+	}
+	nodes := make(map[string]NodeDatasetStats, len(stats.nodes))
+	for nodeID, nodeStats := range stats.nodes {
+		nodes[nodeID] = *nodeStats
+	}
+	return nodes
+}
+
+// jobDatasets returns the datasets the job reads. Parts of a CID selected by a sub-path are datasets of their own.
+func jobDatasets(job model.Job) []string {
+	var datasets []string
+	for _, input := range job.Spec.Inputs {
+		if input.CID != "" {
+			ipfsPath, err := input.IPFSPath()
+			if err != nil {
+				continue
+			}
+			datasets = append(datasets, ipfsPath)
+		} else if input.URL != "" {
+			datasets = append(datasets, input.URL)
+		}
+	}
+	return datasets
+}
@@ -0,0 +1,78 @@
+package ranking
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+)
+
+// datasetsMaxRank is the rank of the node that handled the datasets of a job the fastest.
+const datasetsMaxRank = 20
+
+type DatasetsNodeRankerParams struct {
+	Stats *DatasetStats
+}
+
+// DatasetsNodeRanker ranks nodes based on how fast they fetched the inputs of previous jobs over the same datasets,
+// and ran those jobs, so that repeated workloads over a dataset converge on the nodes that are well placed for it.
+type DatasetsNodeRanker struct {
+	stats *DatasetStats
+}
+
+func NewDatasetsNodeRanker(params DatasetsNodeRankerParams) *DatasetsNodeRanker {
+	return &DatasetsNodeRanker{
+		stats: params.Stats,
+	}
+}
+
+// RankNodes ranks nodes based on their average time to fetch the datasets of the job and run a job over them:
+// - Rank 20: Node is the fastest of the nodes that ran jobs over the datasets.
+// - Rank 1-19: Node ran jobs over the datasets, ranked relative to the fastest node.
+// - Rank 0: Node never ran a job over the datasets, or the job has no inputs.
+func (s *DatasetsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	totals := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, dataset := range jobDatasets(job) {
+		for nodeID, stats := range s.stats.Get(dataset) {
+			totals[nodeID] += stats.Total()
+			counts[nodeID]++
+		}
+	}
+
+	// average over the datasets each node has statistics for, and find the fastest of the nodes being ranked
+	estimates := make(map[string]time.Duration, len(totals))
+	fastest := time.Duration(math.MaxInt64)
+	for _, node := range nodes {
+		nodeID := node.PeerInfo.ID.String()
+		if counts[nodeID] == 0 {
+			continue
+		}
+		estimates[nodeID] = totals[nodeID] / time.Duration(counts[nodeID])
+		if estimates[nodeID] < fastest {
+			fastest = estimates[nodeID]
+		}
+	}
+
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rank := 0
+		nodeID := node.PeerInfo.ID.String()
+		if estimate, ok := estimates[nodeID]; ok {
+			rank = datasetsMaxRank
+			if estimate > 0 {
+				rank = int(math.Round(datasetsMaxRank * float64(fastest) / float64(estimate)))
+			}
+			if rank < 1 {
+				rank = 1
+			}
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,132 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type DatasetsNodeRankerSuite struct {
+	suite.Suite
+	jobStore jobstore.Store
+	stats    *DatasetStats
+	ranker   *DatasetsNodeRanker
+	nodes    []model.NodeInfo
+	job      model.Job
+}
+
+func (s *DatasetsNodeRankerSuite) SetupTest() {
+	s.jobStore = inmemory.NewJobStore()
+	s.stats = NewDatasetStats(DatasetStatsParams{JobStore: s.jobStore, MaxDatasets: 2})
+	s.ranker = NewDatasetsNodeRanker(DatasetsNodeRankerParams{Stats: s.stats})
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("fast")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("slow")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("new")}},
+	}
+	s.job = model.Job{
+		Metadata: model.Metadata{ID: "dataset-job"},
+		Spec:     model.Spec{Inputs: []model.StorageSpec{{CID: "QmDataset"}}},
+	}
+}
+
+func TestDatasetsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(DatasetsNodeRankerSuite))
+}
+
+func (s *DatasetsNodeRankerSuite) TestRankNodes() {
+	now := time.Now()
+	s.stats.Record([]string{"QmDataset"}, peer.ID("fast").String(), time.Second, time.Second, now)
+	s.stats.Record([]string{"QmDataset"}, peer.ID("slow").String(), 3*time.Second, 5*time.Second, now)
+
+	ranks, err := s.ranker.RankNodes(context.Background(), s.job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "fast", 20)
+	assertEquals(s.T(), ranks, "slow", 5)
+	assertEquals(s.T(), ranks, "new", 0)
+
+	// the fastest node being ranked gets the highest rank, even if a faster node is not available
+	ranks, err = s.ranker.RankNodes(context.Background(), s.job, s.nodes[1:])
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "slow", 20)
+}
+
+func (s *DatasetsNodeRankerSuite) TestRankNodes_NoInputs() {
+	s.stats.Record([]string{"QmDataset"}, peer.ID("fast").String(), time.Second, time.Second, time.Now())
+
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "fast", 0)
+	assertEquals(s.T(), ranks, "slow", 0)
+	assertEquals(s.T(), ranks, "new", 0)
+}
+
+func (s *DatasetsNodeRankerSuite) TestRecord_Averages() {
+	nodeID := peer.ID("fast").String()
+	s.stats.Record([]string{"QmDataset"}, nodeID, 2*time.Second, 4*time.Second, time.Now())
+	s.stats.Record([]string{"QmDataset"}, nodeID, 4*time.Second, 8*time.Second, time.Now())
+
+	stats := s.stats.Get("QmDataset")[nodeID]
+	s.Equal(2, stats.Runs)
+	s.Equal(3*time.Second, stats.FetchDuration)
+	s.Equal(6*time.Second, stats.RunDuration)
+}
+
+func (s *DatasetsNodeRankerSuite) TestRecord_EvictsLeastRecentlyRun() {
+	now := time.Now()
+	s.stats.Record([]string{"QmOld"}, "node", time.Second, time.Second, now)
+	s.stats.Record([]string{"QmRecent"}, "node", time.Second, time.Second, now.Add(time.Minute))
+	s.stats.Record([]string{"QmNew"}, "node", time.Second, time.Second, now.Add(2*time.Minute))
+
+	s.Nil(s.stats.Get("QmOld"))
+	s.NotNil(s.stats.Get("QmRecent"))
+	s.NotNil(s.stats.Get("QmNew"))
+}
+
+func (s *DatasetsNodeRankerSuite) TestHandleJobEvent() {
+	ctx := context.Background()
+	nodeID := peer.ID("fast").String()
+	s.NoError(s.jobStore.CreateJob(ctx, s.job))
+	acceptedAt := time.Now()
+	s.NoError(s.stats.HandleJobEvent(ctx, model.JobEvent{
+		JobID:        s.job.ID(),
+		ExecutionID:  "e-1",
+		TargetNodeID: nodeID,
+		EventName:    model.JobEventBidAccepted,
+		EventTime:    acceptedAt,
+	}))
+
+	s.NoError(s.stats.HandleJobEvent(ctx, model.JobEvent{
+		JobID:        s.job.ID(),
+		ExecutionID:  "e-1",
+		SourceNodeID: nodeID,
+		EventName:    model.JobEventResultsProposed,
+		EventTime:    acceptedAt.Add(10 * time.Second),
+		RunOutput:    &model.RunCommandResult{InputsFetchDuration: 4 * time.Second},
+	}))
+
+	stats := s.stats.Get("QmDataset")[nodeID]
+	s.Equal(1, stats.Runs)
+	s.Equal(4*time.Second, stats.FetchDuration)
+	s.Equal(6*time.Second, stats.RunDuration)
+
+	// results of executions that were not seen being accepted are ignored
+	s.NoError(s.stats.HandleJobEvent(ctx, model.JobEvent{
+		JobID:        s.job.ID(),
+		ExecutionID:  "e-2",
+		SourceNodeID: nodeID,
+		EventName:    model.JobEventResultsProposed,
+		EventTime:    acceptedAt.Add(time.Minute),
+		RunOutput:    &model.RunCommandResult{},
+	}))
+	s.Equal(1, s.stats.Get("QmDataset")[nodeID].Runs)
+}
@@ -0,0 +1,42 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+// EngineRequirementsNodeRanker filters out nodes whose engine does not meet the engine requirements of the job, based
+// on the version and features of the engine they report.
+// - Rank 10: Node reports an engine meeting the requirements of the job.
+// - Rank 0: The job has no engine requirements, or the node does not report the version and features of its engine.
+// - Rank -1: Node reports an engine that does not meet the requirements of the job.
+type EngineRequirementsNodeRanker struct{}
+
+func NewEngineRequirementsNodeRanker() *EngineRequirementsNodeRanker {
+	return &EngineRequirementsNodeRanker{}
+}
+
+func (s *EngineRequirementsNodeRanker) RankNodes(
+	ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = requester.NodeRank{NodeInfo: node, Rank: 0}
+		if job.Spec.EngineRequirements.IsEmpty() || node.ComputeNodeInfo == nil {
+			continue
+		}
+		info, ok := node.ComputeNodeInfo.GetEngineInfo(job.Spec.Engine)
+		if !ok {
+			continue
+		}
+		if unmet := info.Check(job.Spec.EngineRequirements); !unmet.IsEmpty() {
+			log.Ctx(ctx).Debug().Msgf("filtering node %s: %s", node.PeerInfo.ID, unmet)
+			ranks[i].Rank = -1
+		} else {
+			ranks[i].Rank = 10
+		}
+	}
+	return ranks, nil
+}
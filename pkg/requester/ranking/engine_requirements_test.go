@@ -0,0 +1,80 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type EngineRequirementsNodeRankerSuite struct {
+	suite.Suite
+	ranker *EngineRequirementsNodeRanker
+	nodes  []model.NodeInfo
+}
+
+func (s *EngineRequirementsNodeRankerSuite) SetupTest() {
+	s.ranker = NewEngineRequirementsNodeRanker()
+	s.nodes = []model.NodeInfo{
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("gpu")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{EngineInfo: []model.EngineInfo{{
+				Engine: model.EngineDocker, Version: "24.0.5", Features: []string{model.EngineFeatureNvidiaRuntime},
+			}}},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("old")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{EngineInfo: []model.EngineInfo{{
+				Engine: model.EngineDocker, Version: "20.10.7",
+			}}},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("unreported")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{ExecutionEngines: []model.Engine{model.EngineDocker}},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("unknown")},
+		},
+	}
+}
+
+func TestEngineRequirementsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(EngineRequirementsNodeRankerSuite))
+}
+
+func (s *EngineRequirementsNodeRankerSuite) TestRankNodes_MinVersion() {
+	job := model.Job{Spec: model.Spec{
+		Engine:             model.EngineDocker,
+		EngineRequirements: &model.EngineRequirements{MinVersion: "23.0"},
+	}}
+	ranks, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "gpu", 10)
+	assertEquals(s.T(), ranks, "old", -1)
+	assertEquals(s.T(), ranks, "unreported", 0)
+	assertEquals(s.T(), ranks, "unknown", 0)
+}
+
+func (s *EngineRequirementsNodeRankerSuite) TestRankNodes_Features() {
+	job := model.Job{Spec: model.Spec{
+		Engine:             model.EngineDocker,
+		EngineRequirements: &model.EngineRequirements{Features: []string{model.EngineFeatureNvidiaRuntime}},
+	}}
+	ranks, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "gpu", 10)
+	assertEquals(s.T(), ranks, "old", -1)
+	assertEquals(s.T(), ranks, "unreported", 0)
+}
+
+func (s *EngineRequirementsNodeRankerSuite) TestRankNodes_NoRequirements() {
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{Spec: model.Spec{Engine: model.EngineDocker}}, s.nodes)
+	s.NoError(err)
+	for _, node := range s.nodes {
+		assertEquals(s.T(), ranks, string(node.PeerInfo.ID), 0)
+	}
+}
@@ -31,7 +31,7 @@ func NewVerifiersNodeRanker() *featureNodeRanker[model.Verifier] {
 
 func NewPublishersNodeRanker() *featureNodeRanker[model.Publisher] {
 	return &featureNodeRanker[model.Publisher]{
-		getJobRequirement:   func(j model.Job) []model.Publisher { return []model.Publisher{j.Spec.PublisherSpec.Type} },
+		getJobRequirement:   func(j model.Job) []model.Publisher { return j.Spec.AllPublishers() },
 		getNodeProvidedKeys: func(ni model.ComputeNodeInfo) []model.Publisher { return ni.Publishers },
 	}
 }
@@ -0,0 +1,52 @@
+package ranking
+
+import (
+	"context"
+	"math"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+// loadMaxRank is the rank of a node that is idle.
+const loadMaxRank = 10
+
+// LoadNodeRanker ranks nodes based on the load they reported recently, so that jobs are scheduled on the nodes that
+// are the least busy rather than on the nodes with the largest capacity.
+type LoadNodeRanker struct {
+}
+
+func NewLoadNodeRanker() *LoadNodeRanker {
+	return &LoadNodeRanker{}
+}
+
+// RankNodes ranks nodes based on their average load over the load history kept by the requester:
+// - Rank 10: Node is idle, or did not report its load, so that nodes of older versions are not ranked below busy nodes.
+// - Rank 1-9: Node is busy, ranked by the fraction of its capacity that is free, divided by its queue depth plus one.
+// - Rank -1: Node reported less free disk space than the job requires.
+func (s *LoadNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	jobDisk := capacity.ParseResourceUsageConfig(job.Spec.Resources).Disk
+	for i, node := range nodes {
+		rank := loadMaxRank
+		if load, ok := model.AverageLoad(node.LoadHistory); ok {
+			if jobDisk > 0 && load.DiskFree > 0 && load.DiskFree < jobDisk {
+				log.Ctx(ctx).Trace().Msgf("filtering node %s that has %d bytes of free disk space", node.PeerInfo.ID, load.DiskFree)
+				rank = -1
+			} else {
+				free := 1 - load.Utilization()
+				rank = int(math.Round(loadMaxRank * free / float64(1+load.QueueDepth)))
+				if rank < 1 {
+					rank = 1
+				}
+			}
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,66 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoadNodeRankerSuite struct {
+	suite.Suite
+	ranker *LoadNodeRanker
+}
+
+func (s *LoadNodeRankerSuite) SetupTest() {
+	s.ranker = NewLoadNodeRanker()
+}
+
+func TestLoadNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(LoadNodeRankerSuite))
+}
+
+func (s *LoadNodeRankerSuite) TestRankNodes() {
+	node := func(id string, history ...model.NodeLoad) model.NodeInfo {
+		return model.NodeInfo{PeerInfo: peer.AddrInfo{ID: peer.ID(id)}, LoadHistory: history}
+	}
+	nodes := []model.NodeInfo{
+		node("idle", model.NodeLoad{}),
+		// the load is averaged over the history
+		node("half", model.NodeLoad{CPUUtilization: 0.2}, model.NodeLoad{CPUUtilization: 0.8}),
+		// the most used resource counts
+		node("memory", model.NodeLoad{CPUUtilization: 0.1, MemoryUtilization: 0.8}),
+		node("queued", model.NodeLoad{CPUUtilization: 0.2, QueueDepth: 3}),
+		node("full", model.NodeLoad{CPUUtilization: 1, MemoryUtilization: 1}),
+		node("unknown"),
+	}
+
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, nodes)
+	s.NoError(err)
+	s.Equal(len(nodes), len(ranks))
+	assertEquals(s.T(), ranks, "idle", 10)
+	assertEquals(s.T(), ranks, "half", 5)
+	assertEquals(s.T(), ranks, "memory", 2)
+	assertEquals(s.T(), ranks, "queued", 2)
+	assertEquals(s.T(), ranks, "full", 1)
+	assertEquals(s.T(), ranks, "unknown", 10)
+}
+
+func (s *LoadNodeRankerSuite) TestRankNodesDiskFree() {
+	job := model.Job{Spec: model.Spec{Resources: model.ResourceUsageConfig{Disk: "1Gb"}}}
+	nodes := []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("small")}, LoadHistory: []model.NodeLoad{{DiskFree: 1 << 20}}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("large")}, LoadHistory: []model.NodeLoad{{DiskFree: 1 << 40}}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("unreported")}, LoadHistory: []model.NodeLoad{{}}},
+	}
+
+	ranks, err := s.ranker.RankNodes(context.Background(), job, nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "small", -1)
+	assertEquals(s.T(), ranks, "large", 10)
+	assertEquals(s.T(), ranks, "unreported", 10)
+}
@@ -25,7 +25,8 @@ func NewPreviousExecutionsNodeRanker(params PreviousExecutionsNodeRankerParams)
 // nodes when handling retries:
 // - Rank 30: Node has never executed the job.
 // - Rank 0: Node has already executed the job.
-// - Rank -1: Node has executed the job more than once, has rejected a bid, or produced a invalid result
+// - Rank -1: Node has executed the job more than once, has rejected a bid, produced a invalid result, or had an execution
+// migrated away from it
 func (s *PreviousExecutionsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
 	ranks := make([]requester.NodeRank, len(nodes))
 	previousExecutors := make(map[string]int)
@@ -40,7 +41,8 @@ func (s *PreviousExecutionsNodeRanker) RankNodes(ctx context.Context, job model.
 			if !execution.State.IsDiscarded() {
 				toFilterOut[execution.NodeID] = true
 			}
-			if execution.State == model.ExecutionStateAskForBidRejected || execution.State == model.ExecutionStateResultRejected {
+			if execution.State == model.ExecutionStateAskForBidRejected || execution.State == model.ExecutionStateResultRejected ||
+				execution.Migrated {
 				toFilterOut[execution.NodeID] = true
 			}
 		}
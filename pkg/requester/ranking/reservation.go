@@ -0,0 +1,59 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+// ReservationProvider returns the capacity reservations jobs run on.
+type ReservationProvider interface {
+	GetReservation(ctx context.Context, reservationID string) (model.CapacityReservation, bool)
+}
+
+type ReservationNodeRankerParams struct {
+	Reservations ReservationProvider
+}
+
+type ReservationNodeRanker struct {
+	reservations ReservationProvider
+}
+
+func NewReservationNodeRanker(params ReservationNodeRankerParams) *ReservationNodeRanker {
+	return &ReservationNodeRanker{
+		reservations: params.Reservations,
+	}
+}
+
+// RankNodes ranks nodes based on the capacity reservation of the job:
+// - Rank 10: Node holds capacity for the reservation of the job.
+// - Rank -1: Node does not hold capacity for the reservation of the job.
+// - Rank 0: Job is not run on a reservation, or its reservation ended or was released.
+func (s *ReservationNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	var reservation model.CapacityReservation
+	var reserved bool
+	if job.Spec.Reservation != "" {
+		reservation, reserved = s.reservations.GetReservation(ctx, job.Spec.Reservation)
+		// jobs only run on the reservations of their client
+		reserved = reserved && reservation.ClientID == job.Metadata.ClientID
+	}
+	for i, node := range nodes {
+		rank := 0
+		if reserved {
+			if _, holds := reservation.Allocation(node.PeerInfo.ID.String()); holds {
+				rank = 10
+			} else {
+				log.Ctx(ctx).Trace().Msgf("filtering node %s doesn't hold reservation %s", node.PeerInfo.ID, reservation.ID)
+				rank = -1
+			}
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
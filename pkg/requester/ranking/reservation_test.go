@@ -0,0 +1,67 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type fixedReservations map[string]model.CapacityReservation
+
+func (r fixedReservations) GetReservation(_ context.Context, reservationID string) (model.CapacityReservation, bool) {
+	reservation, ok := r[reservationID]
+	return reservation, ok
+}
+
+type ReservationNodeRankerSuite struct {
+	suite.Suite
+	ranker *ReservationNodeRanker
+	nodes  []model.NodeInfo
+}
+
+func (s *ReservationNodeRankerSuite) SetupTest() {
+	s.ranker = NewReservationNodeRanker(ReservationNodeRankerParams{
+		Reservations: fixedReservations{
+			"reservation": {
+				ID:          "reservation",
+				Allocations: []model.ReservationAllocation{{NodeID: peer.ID("holder").String()}},
+			},
+		},
+	})
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("holder")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("other")}},
+	}
+}
+
+func TestReservationNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(ReservationNodeRankerSuite))
+}
+
+func (s *ReservationNodeRankerSuite) TestRankNodes_Reservation() {
+	job := model.Job{Spec: model.Spec{Reservation: "reservation"}}
+	ranks, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "holder", 10)
+	assertEquals(s.T(), ranks, "other", -1)
+}
+
+func (s *ReservationNodeRankerSuite) TestRankNodes_NoReservation() {
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "holder", 0)
+	assertEquals(s.T(), ranks, "other", 0)
+}
+
+func (s *ReservationNodeRankerSuite) TestRankNodes_EndedReservation() {
+	job := model.Job{Spec: model.Spec{Reservation: "ended"}}
+	ranks, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "holder", 0)
+	assertEquals(s.T(), ranks, "other", 0)
+}
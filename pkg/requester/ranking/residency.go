@@ -0,0 +1,85 @@
+package ranking
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+)
+
+// ResidencyAuditor records that nodes were excluded from running a job because of its data residency.
+type ResidencyAuditor interface {
+	EmitResidencyEnforced(ctx context.Context, job model.Job, excluded []string)
+}
+
+type ResidencyNodeRankerParams struct {
+	Auditor ResidencyAuditor
+}
+
+// maxAuditedJobs is the most jobs whose excluded nodes are remembered at once.
+const maxAuditedJobs = 10000
+
+type ResidencyNodeRanker struct {
+	auditor ResidencyAuditor
+
+	mu sync.Mutex
+	// audited are the nodes last recorded as excluded from running each job
+	audited map[string]string
+}
+
+func NewResidencyNodeRanker(params ResidencyNodeRankerParams) *ResidencyNodeRanker {
+	return &ResidencyNodeRanker{
+		auditor: params.Auditor,
+		audited: make(map[string]string),
+	}
+}
+
+// RankNodes ranks nodes based on the data residency of the job and the jurisdiction of the node:
+// - Rank -1: Node is outside the jurisdictions of the job, or declares none.
+// - Rank 0: Node is in one of the jurisdictions of the job, or the job has no residency.
+// Whenever the nodes excluded from running a job change, the auditor records which ones and the jurisdiction they
+// declare.
+func (s *ResidencyNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	var excluded []string
+	for i, node := range nodes {
+		rank := 0
+		if !job.Spec.AllowsJurisdiction(node.Jurisdiction()) {
+			rank = -1
+			jurisdiction := node.Jurisdiction()
+			if jurisdiction == "" {
+				jurisdiction = "no jurisdiction"
+			}
+			excluded = append(excluded, fmt.Sprintf("%s (%s)", node.PeerInfo.ID, jurisdiction))
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	if s.auditor != nil && len(job.Spec.Residency) > 0 && s.changed(job.ID(), excluded) && len(excluded) > 0 {
+		s.auditor.EmitResidencyEnforced(ctx, job, excluded)
+	}
+	return ranks, nil
+}
+
+// changed remembers the nodes excluded from running the job, and returns true if they differ from the last ones.
+func (s *ResidencyNodeRanker) changed(jobID string, excluded []string) bool {
+	key := strings.Join(excluded, "\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.audited[jobID]; ok && last == key {
+		return false
+	}
+	if len(s.audited) >= maxAuditedJobs {
+		for id := range s.audited {
+			delete(s.audited, id)
+			break
+		}
+	}
+	s.audited[jobID] = key
+	return true
+}
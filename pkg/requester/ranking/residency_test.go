@@ -0,0 +1,84 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingAuditor struct {
+	excluded [][]string
+}
+
+func (a *recordingAuditor) EmitResidencyEnforced(_ context.Context, _ model.Job, excluded []string) {
+	a.excluded = append(a.excluded, excluded)
+}
+
+type ResidencyNodeRankerSuite struct {
+	suite.Suite
+	auditor *recordingAuditor
+	ranker  *ResidencyNodeRanker
+	nodes   []model.NodeInfo
+}
+
+func (s *ResidencyNodeRankerSuite) SetupTest() {
+	s.auditor = &recordingAuditor{}
+	s.ranker = NewResidencyNodeRanker(ResidencyNodeRankerParams{Auditor: s.auditor})
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("eu")}, Labels: map[string]string{model.JurisdictionLabel: "eu"}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("us")}, Labels: map[string]string{model.JurisdictionLabel: "us"}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("undeclared")}},
+	}
+}
+
+func TestResidencyNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(ResidencyNodeRankerSuite))
+}
+
+func (s *ResidencyNodeRankerSuite) TestRankNodes_Residency() {
+	job := model.Job{Spec: model.Spec{Residency: []string{"eu"}}}
+	ranks, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "eu", 0)
+	assertEquals(s.T(), ranks, "us", -1)
+	assertEquals(s.T(), ranks, "undeclared", -1)
+
+	s.Require().Len(s.auditor.excluded, 1)
+	s.Equal([]string{
+		peer.ID("us").String() + " (us)",
+		peer.ID("undeclared").String() + " (no jurisdiction)",
+	}, s.auditor.excluded[0])
+}
+
+func (s *ResidencyNodeRankerSuite) TestRankNodes_AuditsChangesOnly() {
+	job := model.Job{Metadata: model.Metadata{ID: "job"}, Spec: model.Spec{Residency: []string{"eu"}}}
+	_, err := s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	_, err = s.ranker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Len(s.auditor.excluded, 1, "ranking the same nodes again is not audited again")
+
+	_, err = s.ranker.RankNodes(context.Background(), job, s.nodes[:2])
+	s.NoError(err)
+	s.Require().Len(s.auditor.excluded, 2)
+	s.Equal([]string{peer.ID("us").String() + " (us)"}, s.auditor.excluded[1])
+
+	other := model.Job{Metadata: model.Metadata{ID: "other"}, Spec: model.Spec{Residency: []string{"eu"}}}
+	_, err = s.ranker.RankNodes(context.Background(), other, s.nodes[:2])
+	s.NoError(err)
+	s.Len(s.auditor.excluded, 3, "the nodes excluded from each job are audited")
+}
+
+func (s *ResidencyNodeRankerSuite) TestRankNodes_NoResidency() {
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "eu", 0)
+	assertEquals(s.T(), ranks, "us", 0)
+	assertEquals(s.T(), ranks, "undeclared", 0)
+	s.Empty(s.auditor.excluded)
+}
@@ -0,0 +1,415 @@
+package reservation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// tokenBytes is the number of random bytes of reservation tokens.
+const tokenBytes = 32
+
+type ManagerParams struct {
+	// NodeID is the ID of the requester node, which compute nodes are asked to hold capacity on behalf of.
+	NodeID          string
+	NodeDiscoverer  requester.NodeDiscoverer
+	ComputeEndpoint compute.Endpoint
+	// StateFile is where reservations are persisted, so that their jobs can still be submitted after the requester
+	// restarts. Reservations are kept in memory only if empty.
+	StateFile string
+}
+
+// ReserveRequest asks for capacity across the network, for the jobs of a client during a time window.
+type ReserveRequest struct {
+	ClientID  string
+	Resources model.ResourceUsageData
+	Start     time.Time
+	End       time.Time
+}
+
+// Manager makes capacity reservations by asking compute nodes to hold parts of the reserved capacity, and keeps track
+// of them so that the jobs submitted with their token run on the nodes that hold it. Reservations are persisted to the
+// state file with the hashes of their tokens rather than the tokens, and compute nodes forget them once their time
+// window is over.
+type Manager struct {
+	nodeID          string
+	nodeDiscoverer  requester.NodeDiscoverer
+	computeEndpoint compute.Endpoint
+	stateFile       string
+
+	mu           sync.RWMutex
+	reservations map[string]model.CapacityReservation
+	// tokens maps the hashes of the tokens of reservations to their IDs
+	tokens map[string]string
+}
+
+// storedReservation is a reservation as persisted to the state file.
+type storedReservation struct {
+	Reservation model.CapacityReservation
+	TokenHash   string
+}
+
+func NewManager(params ManagerParams) (*Manager, error) {
+	m := &Manager{
+		nodeID:          params.NodeID,
+		nodeDiscoverer:  params.NodeDiscoverer,
+		computeEndpoint: params.ComputeEndpoint,
+		stateFile:       params.StateFile,
+		reservations:    make(map[string]model.CapacityReservation),
+		tokens:          make(map[string]string),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reserve splits the requested capacity across the compute nodes, starting with those that have the most capacity
+// that is not reserved yet so that the reservation is held by as few nodes as possible. The reservation fails if the
+// nodes can't hold all of it, in which case the parts that were held are released. Jobs of the reservation must fit
+// in what a single node holds to run on it.
+func (m *Manager) Reserve(ctx context.Context, request ReserveRequest) (model.CapacityReservation, error) {
+	if request.Resources.IsZero() {
+		return model.CapacityReservation{}, fmt.Errorf("no capacity to reserve")
+	}
+	if !request.Start.Before(request.End) {
+		return model.CapacityReservation{}, fmt.Errorf("reservation must start before it ends")
+	}
+	if !request.End.After(time.Now()) {
+		return model.CapacityReservation{}, fmt.Errorf("reservation must end in the future")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return model.CapacityReservation{}, err
+	}
+	reservation := model.CapacityReservation{
+		ID:        uuid.NewString(),
+		ClientID:  request.ClientID,
+		Token:     token,
+		Resources: request.Resources,
+		Start:     request.Start,
+		End:       request.End,
+	}
+	if err = reservation.CheckDuration(time.Now()); err != nil {
+		return model.CapacityReservation{}, err
+	}
+
+	nodes, err := m.nodeDiscoverer.ListNodes(ctx)
+	if err != nil {
+		return model.CapacityReservation{}, err
+	}
+	candidates := make([]candidate, 0, len(nodes))
+	for _, node := range nodes {
+		if node.IsComputeNode() && node.ComputeNodeInfo != nil {
+			info := node.ComputeNodeInfo
+			candidates = append(candidates, candidate{
+				nodeID:     node.PeerInfo.ID.String(),
+				reservable: floorSub(info.MaxCapacity, info.ReservedCapacity),
+			})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ri, rj := candidates[i].reservable, candidates[j].reservable
+		if ri.GPU != rj.GPU {
+			return ri.GPU > rj.GPU
+		}
+		if ri.CPU != rj.CPU {
+			return ri.CPU > rj.CPU
+		}
+		return candidates[i].nodeID < candidates[j].nodeID
+	})
+
+	remaining := request.Resources
+	var rejections []string
+	for _, c := range candidates {
+		if remaining.IsZero() {
+			break
+		}
+		part := share(remaining, c.reservable)
+		if !holdsAny(remaining, part) {
+			continue
+		}
+		allocation := reservation
+		allocation.Token = ""
+		allocation.Resources = part
+		response, err := m.computeEndpoint.ReserveCapacity(ctx, compute.ReserveCapacityRequest{
+			RoutingMetadata: compute.RoutingMetadata{SourcePeerID: m.nodeID, TargetPeerID: c.nodeID},
+			Reservation:     allocation,
+		})
+		if err == nil && !response.Accepted {
+			err = fmt.Errorf("%s", response.Reason)
+		}
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("node %s did not hold %s for reservation %s", c.nodeID, part, reservation.ID)
+			rejections = append(rejections, fmt.Sprintf("%s: %s", c.nodeID, err))
+			continue
+		}
+		reservation.Allocations = append(reservation.Allocations, model.ReservationAllocation{NodeID: c.nodeID, Resources: part})
+		remaining = floorSub(remaining, part)
+	}
+
+	if !remaining.IsZero() {
+		m.release(ctx, reservation)
+		err = fmt.Errorf("not enough capacity to reserve %s, %s could not be held", request.Resources, remaining)
+		if len(rejections) > 0 {
+			err = fmt.Errorf("%w: %v", err, rejections)
+		}
+		return model.CapacityReservation{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokenHash := hashToken(reservation.Token)
+	m.reservations[reservation.ID] = withoutToken(reservation)
+	m.tokens[tokenHash] = reservation.ID
+	if err = m.save(); err != nil {
+		delete(m.reservations, reservation.ID)
+		delete(m.tokens, tokenHash)
+		m.release(ctx, reservation)
+		return model.CapacityReservation{}, err
+	}
+	return reservation, nil
+}
+
+// Release releases the capacity held for the reservation on every node holding it.
+func (m *Manager) Release(ctx context.Context, reservationID string) (model.CapacityReservation, error) {
+	m.mu.Lock()
+	reservation, ok := m.reservations[reservationID]
+	if ok {
+		m.forget(reservationID)
+		if err := m.save(); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to persist the release of reservation %s", reservationID)
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return model.CapacityReservation{}, fmt.Errorf("reservation %s not found", reservationID)
+	}
+	m.release(ctx, reservation)
+	return reservation, nil
+}
+
+func (m *Manager) release(ctx context.Context, reservation model.CapacityReservation) {
+	for _, allocation := range reservation.Allocations {
+		_, err := m.computeEndpoint.ReleaseCapacity(ctx, compute.ReleaseCapacityRequest{
+			RoutingMetadata: compute.RoutingMetadata{SourcePeerID: m.nodeID, TargetPeerID: allocation.NodeID},
+			ReservationID:   reservation.ID,
+		})
+		if err != nil {
+			// the node forgets the reservation anyway once its time window is over
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to release reservation %s on node %s", reservation.ID, allocation.NodeID)
+		}
+	}
+}
+
+// List returns the reservations that have not ended yet, by start time. Their tokens are not returned.
+func (m *Manager) List(_ context.Context) []model.CapacityReservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune(time.Now())
+	reservations := make([]model.CapacityReservation, 0, len(m.reservations))
+	for _, reservation := range m.reservations {
+		reservations = append(reservations, reservation)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if !reservations[i].Start.Equal(reservations[j].Start) {
+			return reservations[i].Start.Before(reservations[j].Start)
+		}
+		return reservations[i].ID < reservations[j].ID
+	})
+	return reservations
+}
+
+// GetReservation returns the reservation with the given ID, and false if there is none or it ended.
+func (m *Manager) GetReservation(_ context.Context, reservationID string) (model.CapacityReservation, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	reservation, ok := m.reservations[reservationID]
+	if !ok || reservation.IsExpired(time.Now()) {
+		return model.CapacityReservation{}, false
+	}
+	return reservation, true
+}
+
+// ResolveReservation returns the reservation with the given token, if it belongs to the client and has not ended.
+func (m *Manager) ResolveReservation(_ context.Context, token string, clientID string) (model.CapacityReservation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	reservation, ok := m.reservations[m.tokens[hashToken(token)]]
+	if !ok || reservation.ClientID != clientID {
+		return model.CapacityReservation{}, fmt.Errorf("unknown capacity reservation token")
+	}
+	if reservation.IsExpired(time.Now()) {
+		return model.CapacityReservation{}, fmt.Errorf("capacity reservation %s ended at %s", reservation.ID, reservation.End)
+	}
+	return reservation, nil
+}
+
+// prune forgets the reservations that ended. It is called with the lock held.
+func (m *Manager) prune(now time.Time) {
+	pruned := false
+	for id, reservation := range m.reservations {
+		if reservation.IsExpired(now) {
+			m.forget(id)
+			pruned = true
+		}
+	}
+	if pruned {
+		if err := m.save(); err != nil {
+			log.Warn().Err(err).Msg("failed to persist the reservations that ended")
+		}
+	}
+}
+
+// forget removes the reservation and its token. It is called with the lock held.
+func (m *Manager) forget(reservationID string) {
+	delete(m.reservations, reservationID)
+	for tokenHash, id := range m.tokens {
+		if id == reservationID {
+			delete(m.tokens, tokenHash)
+		}
+	}
+}
+
+// load reads the reservations that have not ended yet from the state file, if there is one.
+func (m *Manager) load() error {
+	if m.stateFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(m.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read reservations: %w", err)
+	}
+	var stored []storedReservation
+	if err = json.Unmarshal(b, &stored); err != nil {
+		return fmt.Errorf("failed to read reservations from %s: %w", m.stateFile, err)
+	}
+	now := time.Now()
+	for _, s := range stored {
+		if !s.Reservation.IsExpired(now) {
+			m.reservations[s.Reservation.ID] = s.Reservation
+			m.tokens[s.TokenHash] = s.Reservation.ID
+		}
+	}
+	return nil
+}
+
+// save writes the reservations to the state file, if there is one. It is called with the lock held.
+func (m *Manager) save() error {
+	if m.stateFile == "" {
+		return nil
+	}
+	stored := make([]storedReservation, 0, len(m.reservations))
+	for tokenHash, id := range m.tokens {
+		if reservation, ok := m.reservations[id]; ok {
+			stored = append(stored, storedReservation{Reservation: reservation, TokenHash: tokenHash})
+		}
+	}
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	// written next to the state file, readable only by the node, and renamed over it so that it is never left half
+	// written
+	tmp, err := os.CreateTemp(filepath.Dir(m.stateFile), filepath.Base(m.stateFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to persist reservations: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to persist reservations: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to persist reservations: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), m.stateFile); err != nil {
+		return fmt.Errorf("failed to persist reservations: %w", err)
+	}
+	return nil
+}
+
+type candidate struct {
+	nodeID     string
+	reservable model.ResourceUsageData
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating reservation token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the hash reservations are looked up by from their token, so that tokens are not kept.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func withoutToken(reservation model.CapacityReservation) model.CapacityReservation {
+	reservation.Token = ""
+	return reservation
+}
+
+// share returns the part of wanted that fits in available, resource by resource.
+func share(wanted, available model.ResourceUsageData) model.ResourceUsageData {
+	part := wanted
+	if available.CPU < part.CPU {
+		part.CPU = available.CPU
+	}
+	if available.Memory < part.Memory {
+		part.Memory = available.Memory
+	}
+	if available.Disk < part.Disk {
+		part.Disk = available.Disk
+	}
+	if available.GPU < part.GPU {
+		part.GPU = available.GPU
+	}
+	if available.Fuel < part.Fuel {
+		part.Fuel = available.Fuel
+	}
+	return part
+}
+
+// floorSub subtracts b from a, flooring every resource at zero.
+func floorSub(a, b model.ResourceUsageData) model.ResourceUsageData {
+	b = share(b, a)
+	return model.ResourceUsageData{
+		CPU:    a.CPU - b.CPU,
+		Memory: a.Memory - b.Memory,
+		Disk:   a.Disk - b.Disk,
+		GPU:    a.GPU - b.GPU,
+		Fuel:   a.Fuel - b.Fuel,
+	}
+}
+
+// holdsAny returns true if the part holds some of every resource that remains to be reserved, so that nodes are not
+// asked to hold a part of a reservation of CPUs and GPUs with only CPUs while nodes with GPUs are left.
+func holdsAny(requested, part model.ResourceUsageData) bool {
+	return (requested.CPU == 0 || part.CPU > 0) &&
+		(requested.Memory == 0 || part.Memory > 0) &&
+		(requested.Disk == 0 || part.Disk > 0) &&
+		(requested.GPU == 0 || part.GPU > 0) &&
+		(requested.Fuel == 0 || part.Fuel > 0)
+}
@@ -0,0 +1,183 @@
+//go:build unit || !integration
+
+package reservation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type fixedDiscoverer []model.NodeInfo
+
+func (d fixedDiscoverer) ListNodes(context.Context) ([]model.NodeInfo, error) {
+	return d, nil
+}
+
+func (d fixedDiscoverer) FindNodes(context.Context, model.Job) ([]model.NodeInfo, error) {
+	return d, nil
+}
+
+// fakeEndpoint holds reservations on the nodes that are not in rejecting.
+type fakeEndpoint struct {
+	compute.Endpoint
+	rejecting map[string]bool
+	held      map[string]model.ResourceUsageData
+}
+
+func (e *fakeEndpoint) ReserveCapacity(
+	_ context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	if e.rejecting[request.TargetPeerID] {
+		return compute.ReserveCapacityResponse{Reason: "full"}, nil
+	}
+	e.held[request.TargetPeerID] = request.Reservation.Resources
+	return compute.ReserveCapacityResponse{Accepted: true}, nil
+}
+
+func (e *fakeEndpoint) ReleaseCapacity(
+	_ context.Context, request compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	_, held := e.held[request.TargetPeerID]
+	delete(e.held, request.TargetPeerID)
+	return compute.ReleaseCapacityResponse{Released: held}, nil
+}
+
+type ManagerSuite struct {
+	suite.Suite
+	endpoint *fakeEndpoint
+	manager  *Manager
+}
+
+func TestManagerSuite(t *testing.T) {
+	suite.Run(t, new(ManagerSuite))
+}
+
+func computeNode(id string, maxCapacity, reserved model.ResourceUsageData) model.NodeInfo {
+	return model.NodeInfo{
+		PeerInfo: peer.AddrInfo{ID: peer.ID(id)},
+		NodeType: model.NodeTypeCompute,
+		ComputeNodeInfo: &model.ComputeNodeInfo{
+			MaxCapacity:      maxCapacity,
+			ReservedCapacity: reserved,
+		},
+	}
+}
+
+func nodeID(id string) string {
+	return peer.ID(id).String()
+}
+
+func (s *ManagerSuite) SetupTest() {
+	s.endpoint = &fakeEndpoint{rejecting: map[string]bool{}, held: map[string]model.ResourceUsageData{}}
+	var err error
+	s.manager, err = NewManager(ManagerParams{
+		NodeID: "requester",
+		NodeDiscoverer: fixedDiscoverer{
+			computeNode("cpu", model.ResourceUsageData{CPU: 8}, model.ResourceUsageData{}),
+			computeNode("gpu", model.ResourceUsageData{CPU: 8, GPU: 2}, model.ResourceUsageData{}),
+			computeNode("busy", model.ResourceUsageData{CPU: 8}, model.ResourceUsageData{CPU: 4}),
+		},
+		ComputeEndpoint: s.endpoint,
+		StateFile:       filepath.Join(s.T().TempDir(), "reservations.json"),
+	})
+	s.Require().NoError(err)
+}
+
+func (s *ManagerSuite) reserve(resources model.ResourceUsageData) (model.CapacityReservation, error) {
+	now := time.Now()
+	return s.manager.Reserve(context.Background(), ReserveRequest{
+		ClientID:  "client",
+		Resources: resources,
+		Start:     now,
+		End:       now.Add(time.Hour),
+	})
+}
+
+func (s *ManagerSuite) TestReserve() {
+	ctx := context.Background()
+	reservation, err := s.reserve(model.ResourceUsageData{CPU: 12, GPU: 2})
+	s.Require().NoError(err)
+	s.NotEmpty(reservation.Token)
+	s.Equal([]model.ReservationAllocation{
+		{NodeID: nodeID("gpu"), Resources: model.ResourceUsageData{CPU: 8, GPU: 2}},
+		{NodeID: nodeID("cpu"), Resources: model.ResourceUsageData{CPU: 4}},
+	}, reservation.Allocations)
+	s.Len(s.endpoint.held, 2)
+
+	resolved, err := s.manager.ResolveReservation(ctx, reservation.Token, "client")
+	s.Require().NoError(err)
+	s.Equal(reservation.ID, resolved.ID)
+	s.Empty(resolved.Token)
+	_, err = s.manager.ResolveReservation(ctx, reservation.Token, "someone-else")
+	s.Error(err)
+	_, err = s.manager.ResolveReservation(ctx, reservation.ID, "client")
+	s.Error(err)
+
+	s.Len(s.manager.List(ctx), 1)
+	_, err = s.manager.Release(ctx, reservation.ID)
+	s.Require().NoError(err)
+	s.Empty(s.endpoint.held)
+	s.Empty(s.manager.List(ctx))
+	_, ok := s.manager.GetReservation(ctx, reservation.ID)
+	s.False(ok)
+}
+
+func (s *ManagerSuite) TestReserve_SkipsRejectingNodes() {
+	s.endpoint.rejecting[nodeID("gpu")] = true
+	reservation, err := s.reserve(model.ResourceUsageData{CPU: 10})
+	s.Require().NoError(err)
+	s.Equal([]model.ReservationAllocation{
+		{NodeID: nodeID("cpu"), Resources: model.ResourceUsageData{CPU: 8}},
+		{NodeID: nodeID("busy"), Resources: model.ResourceUsageData{CPU: 2}},
+	}, reservation.Allocations)
+}
+
+func (s *ManagerSuite) TestReserve_NotEnoughCapacity() {
+	_, err := s.reserve(model.ResourceUsageData{CPU: 24})
+	s.Error(err)
+	s.Empty(s.endpoint.held, "the parts that were held should be released")
+	s.Empty(s.manager.List(context.Background()))
+}
+
+func (s *ManagerSuite) TestReserve_TooLong() {
+	now := time.Now()
+	_, err := s.manager.Reserve(context.Background(), ReserveRequest{
+		ClientID:  "client",
+		Resources: model.ResourceUsageData{CPU: 1},
+		Start:     now,
+		End:       now.Add(model.MaxReservationDuration + time.Hour),
+	})
+	s.Error(err)
+	s.Empty(s.endpoint.held)
+}
+
+func (s *ManagerSuite) TestReserve_SurvivesRestart() {
+	ctx := context.Background()
+	reservation, err := s.reserve(model.ResourceUsageData{CPU: 4})
+	s.Require().NoError(err)
+
+	restarted, err := NewManager(ManagerParams{
+		NodeID:          "requester",
+		ComputeEndpoint: s.endpoint,
+		StateFile:       s.manager.stateFile,
+	})
+	s.Require().NoError(err)
+	resolved, err := restarted.ResolveReservation(ctx, reservation.Token, "client")
+	s.Require().NoError(err)
+	s.Equal(reservation.ID, resolved.ID)
+
+	// tokens are not persisted, only their hashes
+	b, err := os.ReadFile(s.manager.stateFile)
+	s.Require().NoError(err)
+	s.NotContains(string(b), reservation.Token)
+
+	_, err = restarted.Release(ctx, reservation.ID)
+	s.Require().NoError(err)
+	s.Empty(s.endpoint.held)
+}
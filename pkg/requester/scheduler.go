@@ -2,6 +2,7 @@ package requester
 
 import (
 	"context"
+	"fmt"
 	"net/url"
 	"time"
 
@@ -22,6 +23,9 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultMaxPreemptions is how many executions of a job can be preempted before the job fails, unless configured.
+const DefaultMaxPreemptions = 10
+
 type BaseSchedulerParams struct {
 	ID                   string
 	Host                 host.Host
@@ -34,6 +38,14 @@ type BaseSchedulerParams struct {
 	StorageProviders     storage.StorageProvider
 	EventEmitter         EventEmitter
 	GetVerifyCallback    func() *url.URL
+	// NodeTrust decides whether jobs with adaptive deals need another execution when a single node proposed a
+	// result. No node is trusted if nil.
+	NodeTrust NodeTrust
+	// MaxPreemptions is how many executions of a job can be preempted before the job fails. Defaults to
+	// DefaultMaxPreemptions if zero.
+	MaxPreemptions int
+	// Audit records the decisions of the scheduler about jobs. They are not recorded if nil.
+	Audit *SchedulerAudit
 }
 
 type BaseScheduler struct {
@@ -48,6 +60,9 @@ type BaseScheduler struct {
 	storageProviders     storage.StorageProvider
 	eventEmitter         EventEmitter
 	getVerifyCallback    func() *url.URL
+	nodeTrust            NodeTrust
+	maxPreemptions       int
+	audit                *SchedulerAudit
 	mu                   sync.Mutex
 }
 
@@ -64,6 +79,12 @@ func NewBaseScheduler(params BaseSchedulerParams) *BaseScheduler {
 		storageProviders:     params.StorageProviders,
 		eventEmitter:         params.EventEmitter,
 		getVerifyCallback:    params.GetVerifyCallback,
+		nodeTrust:            params.NodeTrust,
+		maxPreemptions:       params.MaxPreemptions,
+		audit:                params.Audit,
+	}
+	if res.maxPreemptions <= 0 {
+		res.maxPreemptions = DefaultMaxPreemptions
 	}
 
 	// TODO: replace with job level lock
@@ -77,12 +98,16 @@ func NewBaseScheduler(params BaseSchedulerParams) *BaseScheduler {
 func (s *BaseScheduler) StartJob(ctx context.Context, req StartJobRequest) (err error) {
 	defer func() {
 		if err != nil {
-			s.stopJob(ctx, req.Job.ID(), err.Error(), false)
+			s.stopJob(ctx, req.Job.ID(), err.Error(), false, 0)
 		}
 	}()
 
-	// find nodes that can execute the job
-	minBids := system.Max(req.Job.Spec.Deal.MinBids, req.Job.Spec.Deal.Concurrency)
+	// find nodes that can execute the job. Jobs with adaptive deals start with a single execution.
+	concurrency := req.Job.Spec.Deal.Concurrency
+	if req.Job.Spec.Deal.Adaptive {
+		concurrency = 1
+	}
+	minBids := system.Max(req.Job.Spec.Deal.MinBids, concurrency)
 	desiredBids := minBids * s.overAskForBidsFactor
 	selectedNodes, err := s.nodeSelector.SelectNodes(ctx, req.Job, minBids, desiredBids)
 	if err != nil {
@@ -119,15 +144,187 @@ func (s *BaseScheduler) CancelJob(ctx context.Context, request CancelJobRequest)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.stopJob(ctx, jobState.JobID, request.Reason, request.UserTriggered)
+	s.stopJob(ctx, jobState.JobID, request.Reason, request.UserTriggered, 0)
 	return CancelJobResult{}, nil
 }
 
+// MigrateExecutions asks other nodes to bid in place of the executions of a node that have not started yet, and
+// cancels each execution once the bid of the node taking it over is accepted. Replacement nodes are selected before
+// anything is asked, so that the executions of a job stay where they are if there are not enough nodes to move them
+// to, and executions also stay where they are if the nodes taking them over reject their bids.
+func (s *BaseScheduler) MigrateExecutions(
+	ctx context.Context, request MigrateExecutionsRequest) (MigrateExecutionsResult, error) {
+	log.Ctx(ctx).Info().Msgf("Requester node %s received MigrateExecutions away from node %s by client %s with reason %s",
+		s.id, request.NodeID, request.ClientID, request.Reason)
+
+	var jobIDs []string
+	if request.JobID != "" {
+		jobState, err := s.jobStore.GetJobState(ctx, request.JobID)
+		if err != nil {
+			return MigrateExecutionsResult{}, err
+		}
+		if jobState.State.IsTerminal() {
+			return MigrateExecutionsResult{}, NewErrJobAlreadyTerminal(request.JobID)
+		}
+		jobIDs = append(jobIDs, request.JobID)
+	} else {
+		jobs, err := s.jobStore.GetInProgressJobs(ctx)
+		if err != nil {
+			return MigrateExecutionsResult{}, err
+		}
+		for _, job := range jobs {
+			jobIDs = append(jobIDs, job.Job.ID())
+		}
+	}
+
+	queued := make(map[string]bool, len(request.QueuedExecutionIDs))
+	for _, executionID := range request.QueuedExecutionIDs {
+		queued[executionID] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := MigrateExecutionsResult{Failed: make(map[string]string)}
+	for _, jobID := range jobIDs {
+		migrated, err := s.migrateJobExecutions(ctx, jobID, request, queued)
+		result.Migrated = append(result.Migrated, migrated...)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to migrate executions of job %s away from node %s", jobID, request.NodeID)
+			result.Failed[jobID] = err.Error()
+		}
+	}
+	return result, nil
+}
+
+func (s *BaseScheduler) migrateJobExecutions(
+	ctx context.Context, jobID string, request MigrateExecutionsRequest, queued map[string]bool) ([]MigratedExecution, error) {
+	jobState, err := s.jobStore.GetJobState(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if jobState.State.IsTerminal() {
+		return nil, nil
+	}
+
+	// executions that another node was already asked to take over are not migrated again
+	pendingMigrations := make(map[string]bool)
+	for _, execution := range jobState.Executions {
+		if execution.IsPendingMigration() {
+			pendingMigrations[execution.Migration.From] = true
+		}
+	}
+	var executions []model.ExecutionState
+	for _, execution := range jobState.Executions {
+		if execution.NodeID != request.NodeID || pendingMigrations[execution.ComputeReference] {
+			continue
+		}
+		switch execution.State {
+		case model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted:
+			executions = append(executions, execution)
+		case model.ExecutionStateBidAccepted:
+			// accepted executions may already be running, unless the node says they are still waiting in its queue
+			if queued[execution.ComputeReference] {
+				executions = append(executions, execution)
+			}
+		}
+	}
+	if len(executions) == 0 {
+		return nil, nil
+	}
+
+	job, err := s.jobStore.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	// the node ranker excludes nodes that already have an active execution of the job, including the node we are
+	// migrating away from
+	selectedNodes, err := s.nodeSelector.SelectNodes(ctx, job, len(executions), len(executions))
+	if err != nil {
+		return nil, err
+	}
+
+	status := fmt.Sprintf("migrated away from node %s by client %s", request.NodeID, request.ClientID)
+	if request.Reason != "" {
+		status += ": " + request.Reason
+	}
+	migrated := make([]MigratedExecution, 0, len(executions))
+	for i, execution := range executions {
+		migrated = append(migrated, MigratedExecution{
+			JobID:        jobID,
+			ExecutionID:  execution.ComputeReference,
+			State:        execution.State,
+			TargetNodeID: selectedNodes[i].NodeInfo.PeerInfo.ID.String(),
+		})
+	}
+	if !request.DryRun {
+		// the selected nodes are ranked on the capacity they last published, so they bid before anything is canceled,
+		// and an execution is only canceled once the bid of the node taking it over is accepted
+		s.askForBids(ctx, trace.LinkFromContext(ctx), job, selectedNodes[:len(executions)], executions, status)
+	}
+	return migrated, nil
+}
+
+// completeMigration cancels the execution the replacement takes over, once the bid of the replacement is accepted, and
+// returns the canceled execution. It returns false if the migrated execution can't be canceled anymore, such as when
+// it already completed, in which case the replacement is not needed.
+func (s *BaseScheduler) completeMigration(
+	ctx context.Context, jobState model.JobState, replacement model.ExecutionState) (model.ExecutionState, bool) {
+	var execution model.ExecutionState
+	for _, candidate := range jobState.Executions {
+		if candidate.ComputeReference == replacement.Migration.From {
+			execution = candidate
+			break
+		}
+	}
+	switch execution.State {
+	case model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted, model.ExecutionStateBidAccepted:
+	default:
+		return execution, false
+	}
+
+	status := replacement.Migration.Status
+	err := s.jobStore.UpdateExecution(ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		Condition: jobstore.UpdateExecutionCondition{
+			ExpectedState:   execution.State,
+			ExpectedVersion: execution.Version,
+		},
+		NewValues: model.ExecutionState{
+			State:    model.ExecutionStateCanceled,
+			Status:   status,
+			Migrated: true,
+		},
+		Comment: status,
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("failed to cancel migrated execution %s", execution)
+		return execution, false
+	}
+	s.notifyCancel(ctx, status, execution)
+	s.eventEmitter.EmitExecutionMigrated(ctx, s.id, execution, status)
+	s.audit.Record(SchedulerAuditEntry{
+		JobID:       execution.JobID,
+		Decision:    SchedulerDecisionMigrate,
+		NodeID:      execution.NodeID,
+		ExecutionID: execution.ComputeReference,
+		Reason:      fmt.Sprintf("%s, to node %s", status, replacement.NodeID),
+	})
+	return execution, true
+}
+
 //////////////////////////////
 //   Compute Proxy Methods  //
 //////////////////////////////
 
 func (s *BaseScheduler) notifyAskForBid(ctx context.Context, link trace.Link, job model.Job, nodes []NodeRank) {
+	s.askForBids(ctx, link, job, nodes, nil, "")
+}
+
+// askForBids asks the nodes to bid on the job. If migrated is set, the node at each index is asked to take over the
+// migrated execution at the same index, with its shard, and the migrated execution is canceled with migrationStatus
+// once the bid is accepted.
+func (s *BaseScheduler) askForBids(
+	ctx context.Context, link trace.Link, job model.Job, nodes []NodeRank, migrated []model.ExecutionState, migrationStatus string) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester.Scheduler.StartJob",
 		trace.WithLinks(link), // link to any api traces
 		trace.WithSpanKind(trace.SpanKindInternal),
@@ -138,26 +335,52 @@ func (s *BaseScheduler) notifyAskForBid(ctx context.Context, link trace.Link, jo
 	)
 	defer span.End()
 
+	// new executions take the shards of the job that have no execution, such as the shards of failed executions
+	jobState, err := s.jobStore.GetJobState(ctx, job.ID())
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error getting job state")
+		return
+	}
+	shards := vacantShards(jobState, len(nodes))
+
 	// persist the intent to ask the node for a bid, which is helpful to avoid asking an unresponsive node again during retries.
 	// we persist the intent for all nodes before asking any node to bid, so that we don't fail the job if the first node we ask rejects the
 	// the bid before we persist the intent to ask the other nodes.
-	for _, node := range nodes {
+	for i, node := range nodes {
 		executionID := model.ExecutionID{
 			JobID:       job.Metadata.ID,
 			NodeID:      node.NodeInfo.PeerInfo.ID.String(),
 			ExecutionID: "e-" + uuid.NewString(),
 		}
 
-		err := s.jobStore.CreateExecution(ctx, model.ExecutionState{
+		execution := model.ExecutionState{
 			JobID:            executionID.JobID,
 			NodeID:           executionID.NodeID,
 			ComputeReference: executionID.ExecutionID,
 			State:            model.ExecutionStateAskForBid,
-		})
+		}
+		reason := fmt.Sprintf("ranked %d", node.Rank)
+		if migrated != nil {
+			execution.Shard = migrated[i].Shard
+			execution.Migration = &model.ExecutionMigration{From: migrated[i].ComputeReference, Status: migrationStatus}
+			reason += fmt.Sprintf(" to take over execution %s", migrated[i].ComputeReference)
+		} else {
+			execution.Shard = shards[i]
+		}
+		reason += fmt.Sprintf(" for shard %d", execution.Shard)
+
+		err := s.jobStore.CreateExecution(ctx, execution)
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msg("error creating execution")
 			return
 		}
+		s.audit.Record(SchedulerAuditEntry{
+			JobID:       executionID.JobID,
+			Decision:    SchedulerDecisionAskForBid,
+			NodeID:      executionID.NodeID,
+			ExecutionID: executionID.ExecutionID,
+			Reason:      reason,
+		})
 
 		newCtx := util.NewDetachedContext(ctx)
 		go s.doNotifyAskForBid(newCtx, job, executionID)
@@ -363,15 +586,75 @@ func (s *BaseScheduler) verifyResult(
 	if err != nil {
 		return nil, nil, err
 	}
-
 	succeeded, failed = s.VerifyExecutions(ctx, verificationResults)
 	return succeeded, failed, nil
 }
 
+// rejectOversizedJobResults looks up the jobs and executions of the results to reject the oversized ones. Results
+// whose size can't be checked are rejected as well.
+func (s *BaseScheduler) rejectOversizedJobResults(ctx context.Context, verificationResults []verifier.VerifierResult) {
+	resultsByJob := make(map[string][]int)
+	for i, result := range verificationResults {
+		resultsByJob[result.ExecutionID.JobID] = append(resultsByJob[result.ExecutionID.JobID], i)
+	}
+	for jobID, indexes := range resultsByJob {
+		results := make([]verifier.VerifierResult, 0, len(indexes))
+		for _, i := range indexes {
+			results = append(results, verificationResults[i])
+		}
+		job, err := s.jobStore.GetJob(ctx, jobID)
+		var jobState model.JobState
+		if err == nil {
+			jobState, err = s.jobStore.GetJobState(ctx, jobID)
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("Rejecting results of job %s: failed to check their size", jobID)
+			for i := range results {
+				results[i].Verified = false
+			}
+		} else {
+			rejectOversizedResults(ctx, job, jobState.Executions, results)
+		}
+		for j, i := range indexes {
+			verificationResults[i] = results[j]
+		}
+	}
+}
+
+// rejectOversizedResults marks results that are larger than the job or the compute node that ran them allow as not
+// verified, so that they are never published.
+func rejectOversizedResults(
+	ctx context.Context,
+	job model.Job,
+	executionStates []model.ExecutionState,
+	verificationResults []verifier.VerifierResult,
+) {
+	oversized := make(map[model.ExecutionID]bool)
+	for _, execution := range executionStates {
+		if execution.RunOutput == nil {
+			continue
+		}
+		limit := job.Spec.GetResultSizeLimit(execution.ResultSizeLimit)
+		if limit > 0 && execution.RunOutput.ResultSize > limit {
+			log.Ctx(ctx).Warn().Msgf("Rejecting result of %s: size of %d bytes exceeds limit of %d bytes",
+				execution, execution.RunOutput.ResultSize, limit)
+			oversized[execution.ID()] = true
+		}
+	}
+	for i := range verificationResults {
+		if oversized[verificationResults[i].ExecutionID] {
+			verificationResults[i].Verified = false
+		}
+	}
+}
+
+// VerifyExecutions accepts or rejects results as the verifier decided, whether it decided synchronously or reported
+// back later, but never accepts results that are larger than allowed.
 func (s *BaseScheduler) VerifyExecutions(
 	ctx context.Context,
 	verificationResults []verifier.VerifierResult,
 ) (succeeded, failed []verifier.VerifierResult) {
+	s.rejectOversizedJobResults(ctx, verificationResults)
 	for _, verificationResult := range verificationResults {
 		if verificationResult.Verified {
 			s.updateAndNotifyResultAccepted(ctx, verificationResult)
@@ -409,9 +692,13 @@ func (s *BaseScheduler) OnBidComplete(ctx context.Context, response compute.BidR
 			ExpectedState: model.ExecutionStateAskForBid,
 		},
 		NewValues: model.ExecutionState{
-			AcceptedAskForBid: response.Accepted,
-			State:             newState,
-			Status:            response.Reason,
+			AcceptedAskForBid:   response.Accepted,
+			ResultSizeLimit:     response.ResultSizeLimit,
+			InputsSize:          response.InputsSize,
+			InputsFetchEstimate: response.InputsFetchEstimate,
+			InputsEstimated:     response.InputsEstimated,
+			State:               newState,
+			Status:              response.Reason,
 		},
 	})
 	if err != nil {
@@ -445,6 +732,7 @@ func (s *BaseScheduler) OnRunComplete(ctx context.Context, result compute.RunRes
 		NewValues: model.ExecutionState{
 			VerificationProposal: result.ResultProposal,
 			RunOutput:            result.RunCommandResult,
+			FailureReason:        result.FailureReason,
 			State:                model.ExecutionStateResultProposed,
 		},
 	})
@@ -459,6 +747,7 @@ func (s *BaseScheduler) OnRunComplete(ctx context.Context, result compute.RunRes
 func (s *BaseScheduler) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s received PublishComplete for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
+	result.PublishResult = s.withEnvironmentMetadata(ctx, result)
 	s.eventEmitter.EmitPublishComplete(ctx, result)
 	// TODO: #831 verify that the published results are the same as the ones we expect, or let the verifier
 	//  publish the result and not all the compute nodes.
@@ -474,8 +763,9 @@ func (s *BaseScheduler) OnPublishComplete(ctx context.Context, result compute.Pu
 			ExpectedState: model.ExecutionStateResultAccepted,
 		},
 		NewValues: model.ExecutionState{
-			PublishedResult: result.PublishResult,
-			State:           model.ExecutionStateCompleted,
+			PublishedResult:  result.PublishResult,
+			PublishedVolumes: result.PublishedVolumes,
+			State:            model.ExecutionStateCompleted,
 		},
 	})
 	if err != nil {
@@ -486,6 +776,35 @@ func (s *BaseScheduler) OnPublishComplete(ctx context.Context, result compute.Pu
 	s.TransitionJobState(ctx, result.JobID)
 }
 
+// withEnvironmentMetadata returns the published result with the environment the execution ran in added to its
+// metadata, so that the environment can be cited alongside the results.
+func (s *BaseScheduler) withEnvironmentMetadata(ctx context.Context, result compute.PublishResult) model.StorageSpec {
+	published := result.PublishResult
+	jobState, err := s.jobStore.GetJobState(ctx, result.JobID)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("[OnPublishComplete] failed to get job state to add environment metadata")
+		return published
+	}
+	for _, execution := range jobState.Executions {
+		if execution.ComputeReference != result.ExecutionID || execution.NodeID != result.SourcePeerID {
+			continue
+		}
+		if execution.RunOutput == nil || execution.RunOutput.Environment == nil {
+			break
+		}
+		metadata := make(map[string]string, len(published.Metadata))
+		for key, value := range published.Metadata {
+			metadata[key] = value
+		}
+		for key, value := range execution.RunOutput.Environment.Metadata() {
+			metadata[key] = value
+		}
+		published.Metadata = metadata
+		break
+	}
+	return published
+}
+
 func (s *BaseScheduler) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s received CancelComplete for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
@@ -502,6 +821,13 @@ func (s *BaseScheduler) OnComputeFailure(ctx context.Context, result compute.Com
 }
 
 func (s *BaseScheduler) handleExecutionFailure(ctx context.Context, executionID model.ExecutionID, failure error) {
+	computeError, isComputeError := failure.(compute.ComputeError)
+	preempted := isComputeError && computeError.Preempted
+	var failureReason *model.FailureReason
+	if isComputeError {
+		failureReason = computeError.FailureReason
+	}
+
 	// update execution state
 	err := s.jobStore.UpdateExecution(ctx, jobstore.UpdateExecutionRequest{
 		ExecutionID: executionID,
@@ -512,8 +838,10 @@ func (s *BaseScheduler) handleExecutionFailure(ctx context.Context, executionID
 			},
 		},
 		NewValues: model.ExecutionState{
-			State:  model.ExecutionStateFailed,
-			Status: failure.Error(),
+			State:         model.ExecutionStateFailed,
+			Status:        failure.Error(),
+			Preempted:     preempted,
+			FailureReason: failureReason,
 		},
 		Comment: failure.Error(),
 	})
@@ -526,19 +854,25 @@ func (s *BaseScheduler) handleExecutionFailure(ctx context.Context, executionID
 	s.TransitionJobState(ctx, executionID.JobID)
 }
 
-// make sure to call this function with the lock held
-func (s *BaseScheduler) stopJob(ctx context.Context, jobID, reason string, userRequested bool) {
+// make sure to call this function with the lock held. A non-zero expectedVersion leaves the job running if it
+// changed since the version the decision to stop it was based on, as the change triggers another transition.
+func (s *BaseScheduler) stopJob(ctx context.Context, jobID, reason string, userRequested bool, expectedVersion int) {
 	if userRequested {
 		log.Ctx(ctx).Info().Msgf("stopping job %s because the user requested it", jobID)
 	} else {
 		log.Ctx(ctx).Error().Err(errors.New(reason)).Msgf("error completing job %s", jobID)
 	}
 
-	cancelledExecutions, err := jobstore.StopJob(ctx, s.jobStore, jobID, reason, userRequested)
+	cancelledExecutions, err := jobstore.StopJob(ctx, s.jobStore, jobID, reason, userRequested, expectedVersion)
+	if errors.As(err, &jobstore.ErrInvalidJobVersion{}) {
+		log.Ctx(ctx).Debug().Err(err).Msgf("[stopJob] job changed since it was checked, not stopping it")
+		return
+	}
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("[stopJob] failed to stop job")
 	}
 
+	s.audit.Record(SchedulerAuditEntry{JobID: jobID, Decision: SchedulerDecisionStop, Reason: reason})
 	for _, execution := range cancelledExecutions {
 		s.notifyCancel(ctx, reason, execution)
 	}
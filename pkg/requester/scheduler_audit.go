@@ -0,0 +1,96 @@
+package requester
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSchedulerAuditMaxJobs is how many of the latest scheduled jobs the scheduler audit keeps the decisions of.
+	DefaultSchedulerAuditMaxJobs = 1000
+	// schedulerAuditMaxEntriesPerJob is how many of the latest decisions on a job the scheduler audit keeps.
+	schedulerAuditMaxEntriesPerJob = 100
+)
+
+// SchedulerDecision is a kind of decision the scheduler makes about a job.
+type SchedulerDecision string
+
+const (
+	// SchedulerDecisionAskForBid is the scheduler asking a node to bid on the job.
+	SchedulerDecisionAskForBid SchedulerDecision = "ask-for-bid"
+	// SchedulerDecisionRetry is the scheduler retrying failed executions of the job on other nodes.
+	SchedulerDecisionRetry SchedulerDecision = "retry"
+	// SchedulerDecisionMigrate is the scheduler moving an execution of the job away from a node.
+	SchedulerDecisionMigrate SchedulerDecision = "migrate"
+	// SchedulerDecisionStop is the scheduler stopping the job.
+	SchedulerDecisionStop SchedulerDecision = "stop"
+)
+
+// SchedulerAuditEntry records a decision the scheduler made about a job, and why.
+type SchedulerAuditEntry struct {
+	Time     time.Time         `json:"Time"`
+	JobID    string            `json:"JobID"`
+	Decision SchedulerDecision `json:"Decision"`
+	// NodeID is the node the decision is about, if any.
+	NodeID string `json:"NodeID,omitempty"`
+	// ExecutionID is the execution the decision is about, if any.
+	ExecutionID string `json:"ExecutionID,omitempty"`
+	// Reason explains the decision.
+	Reason string `json:"Reason,omitempty"`
+}
+
+// SchedulerAudit keeps the latest decisions the scheduler made about the latest jobs in memory, so that they can be
+// included in debug bundles. The decisions of the oldest jobs are forgotten first.
+type SchedulerAudit struct {
+	mu      sync.Mutex
+	maxJobs int
+	entries map[string][]SchedulerAuditEntry
+	// jobs are the IDs of the jobs with entries, oldest first
+	jobs []string
+}
+
+// NewSchedulerAudit returns an audit of the decisions about the last maxJobs jobs, or DefaultSchedulerAuditMaxJobs
+// if zero.
+func NewSchedulerAudit(maxJobs int) *SchedulerAudit {
+	if maxJobs <= 0 {
+		maxJobs = DefaultSchedulerAuditMaxJobs
+	}
+	return &SchedulerAudit{
+		maxJobs: maxJobs,
+		entries: make(map[string][]SchedulerAuditEntry),
+	}
+}
+
+// Record adds the entry to the decisions about its job. It does nothing on a nil audit.
+func (a *SchedulerAudit) Record(entry SchedulerAuditEntry) {
+	if a == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entries, ok := a.entries[entry.JobID]
+	if !ok {
+		if len(a.jobs) >= a.maxJobs {
+			delete(a.entries, a.jobs[0])
+			a.jobs = a.jobs[1:]
+		}
+		a.jobs = append(a.jobs, entry.JobID)
+	}
+	if len(entries) >= schedulerAuditMaxEntriesPerJob {
+		entries = entries[1:]
+	}
+	a.entries[entry.JobID] = append(entries, entry)
+}
+
+// Entries returns the decisions recorded about the job, oldest first.
+func (a *SchedulerAudit) Entries(jobID string) []SchedulerAuditEntry {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]SchedulerAuditEntry(nil), a.entries[jobID]...)
+}
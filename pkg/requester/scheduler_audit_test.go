@@ -0,0 +1,39 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerAudit(t *testing.T) {
+	audit := NewSchedulerAudit(2)
+	audit.Record(SchedulerAuditEntry{JobID: "a", Decision: SchedulerDecisionAskForBid, NodeID: "node-1"})
+	audit.Record(SchedulerAuditEntry{JobID: "a", Decision: SchedulerDecisionRetry, NodeID: "node-1"})
+	audit.Record(SchedulerAuditEntry{JobID: "b", Decision: SchedulerDecisionAskForBid})
+
+	entries := audit.Entries("a")
+	require.Len(t, entries, 2)
+	require.Equal(t, SchedulerDecisionAskForBid, entries[0].Decision)
+	require.Equal(t, SchedulerDecisionRetry, entries[1].Decision)
+	require.False(t, entries[0].Time.IsZero())
+
+	audit.Record(SchedulerAuditEntry{JobID: "c", Decision: SchedulerDecisionStop})
+	require.Empty(t, audit.Entries("a"), "the decisions about the oldest job are forgotten first")
+	require.Len(t, audit.Entries("b"), 1)
+	require.Len(t, audit.Entries("c"), 1)
+
+	for i := 0; i < schedulerAuditMaxEntriesPerJob+1; i++ {
+		audit.Record(SchedulerAuditEntry{JobID: "c", Decision: SchedulerDecisionRetry, Reason: fmt.Sprint(i)})
+	}
+	entries = audit.Entries("c")
+	require.Len(t, entries, schedulerAuditMaxEntriesPerJob)
+	require.Equal(t, fmt.Sprint(schedulerAuditMaxEntriesPerJob), entries[len(entries)-1].Reason)
+
+	var disabled *SchedulerAudit
+	disabled.Record(SchedulerAuditEntry{JobID: "a"})
+	require.Empty(t, disabled.Entries("a"))
+}
@@ -73,4 +73,9 @@ func (m *mockScheduler) VerifyExecutions(
 	return results, nil
 }
 
+// MigrateExecutions implements Scheduler
+func (*mockScheduler) MigrateExecutions(ctx context.Context, req MigrateExecutionsRequest) (MigrateExecutionsResult, error) {
+	panic("unimplemented")
+}
+
 var _ Scheduler = (*mockScheduler)(nil)
@@ -2,11 +2,14 @@ package requester
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
@@ -43,6 +46,7 @@ func (s *BaseScheduler) transitionJobStateLockFree(ctx context.Context, jobID st
 	}
 
 	s.checkForFailedExecutions(ctx, job, jobState)
+	s.checkForEscalation(ctx, job, jobState)
 	s.checkForPendingBids(ctx, job, jobState)
 	s.checkForPendingResults(ctx, job, jobState)
 	s.checkForCompletedExecutions(ctx, job, jobState)
@@ -54,6 +58,7 @@ func (s *BaseScheduler) checkForFailedExecutions(ctx context.Context, job model.
 	var receivedBidsCount int
 	var publishedOrPublishingCount int
 	var nonDiscardedExecutionsCount int
+	var failedExecutionsCount int
 	var lastFailedExecution model.ExecutionState
 	for _, execution := range jobState.Executions {
 		if execution.HasAcceptedAskForBid() {
@@ -65,22 +70,31 @@ func (s *BaseScheduler) checkForFailedExecutions(ctx context.Context, job model.
 		if !execution.State.IsDiscarded() {
 			nonDiscardedExecutionsCount++
 		}
+		// failing to take over a migrated execution leaves the migrated execution in place, so it is not a failure
+		if execution.State == model.ExecutionStateFailed && !execution.Preempted && execution.Migration == nil {
+			failedExecutionsCount++
+		}
 		if execution.State == model.ExecutionStateFailed && lastFailedExecution.UpdateTime.Before(execution.UpdateTime) {
 			lastFailedExecution = execution
 		}
 	}
 
-	// calculate how many executions we still need, and evaluate if we can ask more nodes to bid
+	// calculate how many executions we still need, and evaluate if we can ask more nodes to bid.
+	// jobs with adaptive deals need a single execution, and more are asked for by checkForEscalation.
+	concurrency := job.Spec.Deal.GetConcurrency()
+	if job.Spec.Deal.Adaptive {
+		concurrency = 1
+	}
 	var minExecutions int
 	if job.Spec.Deal.MinBids > 0 && receivedBidsCount < job.Spec.Deal.MinBids {
 		// if we are still queuing bids, then we need at least MinBids to start accepting bids
-		minExecutions = system.Max(job.Spec.Deal.GetConcurrency(), job.Spec.Deal.MinBids)
+		minExecutions = system.Max(concurrency, job.Spec.Deal.MinBids)
 	} else if publishedOrPublishingCount > 0 {
 		// if at least a single execution was published or still publishing, then we don't need to retry in case some executions failed to publish
 		minExecutions = 1
 	} else {
 		// by default, we need executions as many as the job concurrency
-		minExecutions = job.Spec.Deal.GetConcurrency()
+		minExecutions = concurrency
 	}
 
 	if nonDiscardedExecutionsCount < minExecutions {
@@ -99,17 +113,27 @@ func (s *BaseScheduler) checkForFailedExecutions(ctx context.Context, job model.
 				if finalErr != nil {
 					errMsg = finalErr.Error()
 				}
-				s.stopJob(ctx, job.ID(), errMsg, false)
+				s.stopJob(ctx, job.ID(), errMsg, false, jobState.Version)
 			}
 		}()
-		if s.retryStrategy.ShouldRetry(ctx, RetryRequest{JobID: job.ID()}) {
-			desiredNodeCount := minExecutions - nonDiscardedExecutionsCount
+		desiredNodeCount := minExecutions - nonDiscardedExecutionsCount
+		var retry bool
+		retry, finalErr = s.shouldRetryFailure(ctx, job, jobState, lastFailedExecution, desiredNodeCount)
+		if retry {
 			rankedNodes, err := s.nodeSelector.SelectNodes(ctx, job, desiredNodeCount, desiredNodeCount)
 			if err != nil {
 				log.Ctx(ctx).Error().Err(err).Msg("[transitionJobState] failed to find enough nodes to retry")
 				finalErr = err // So the deferred function can use it for the jobstate
 				return
 			}
+			s.audit.Record(SchedulerAuditEntry{
+				JobID:       job.ID(),
+				Decision:    SchedulerDecisionRetry,
+				NodeID:      lastFailedExecution.NodeID,
+				ExecutionID: lastFailedExecution.ComputeReference,
+				Reason: fmt.Sprintf("%d of %d executions are left after execution failed with: %s, asking %d more nodes",
+					nonDiscardedExecutionsCount, minExecutions, lastFailedExecution.Status, desiredNodeCount),
+			})
 			s.notifyAskForBid(ctx, trace.LinkFromContext(ctx), job, rankedNodes[:desiredNodeCount])
 			retried = true
 			return
@@ -117,6 +141,169 @@ func (s *BaseScheduler) checkForFailedExecutions(ctx context.Context, job model.
 	}
 }
 
+// shouldRetry returns true if failed executions of the job should be retried to fill the shards. Retry limits set on
+// the job take precedence over the retry strategy of the requester, and are read on every check so that they can be
+// changed while the job is running.
+func (s *BaseScheduler) shouldRetry(ctx context.Context, job model.Job, jobState model.JobState, shards []int) bool {
+	var failedExecutionsCount int
+	failedShardExecutionsCount := make(map[int]int)
+	for _, execution := range jobState.Executions {
+		if execution.State == model.ExecutionStateFailed && !execution.Preempted {
+			failedExecutionsCount++
+			failedShardExecutionsCount[execution.Shard]++
+		}
+	}
+	if job.Spec.MaxRetriesPerShard != nil {
+		for _, shard := range shards {
+			if failedShardExecutionsCount[shard] > *job.Spec.MaxRetriesPerShard {
+				return false
+			}
+		}
+	}
+	if job.Spec.MaxRetries != nil {
+		return failedExecutionsCount <= *job.Spec.MaxRetries
+	}
+	return s.retryStrategy.ShouldRetry(ctx, RetryRequest{JobID: job.ID()})
+}
+
+// shouldRetryFailure returns true if the last failed execution of the job should be retried by desiredNodeCount new
+// executions, or the reason the job fails if a preempted execution is not. A preempted execution didn't fail because
+// of the job, so it does not count against the retry limits, but the job fails once it was preempted too often.
+func (s *BaseScheduler) shouldRetryFailure(
+	ctx context.Context, job model.Job, jobState model.JobState, lastFailedExecution model.ExecutionState, desiredNodeCount int,
+) (bool, error) {
+	if !lastFailedExecution.Preempted {
+		return s.shouldRetry(ctx, job, jobState, vacantShards(jobState, desiredNodeCount)), nil
+	}
+	var preemptions int
+	for _, execution := range jobState.Executions {
+		if execution.State == model.ExecutionStateFailed && execution.Preempted {
+			preemptions++
+		}
+	}
+	if preemptions > s.maxPreemptions {
+		return false, fmt.Errorf("executions of the job were preempted %d times, more than the limit of %d",
+			preemptions, s.maxPreemptions)
+	}
+	return true, nil
+}
+
+// vacantShards returns the lowest count shards of the job that no execution is running or has completed, which are
+// the shards new executions of the job take.
+func vacantShards(jobState model.JobState, count int) []int {
+	occupied := make(map[int]bool)
+	for _, execution := range jobState.Executions {
+		if !execution.State.IsDiscarded() {
+			occupied[execution.Shard] = true
+		}
+	}
+	shards := make([]int, 0, count)
+	for shard := 0; len(shards) < count; shard++ {
+		if !occupied[shard] {
+			shards = append(shards, shard)
+		}
+	}
+	return shards
+}
+
+// checkForEscalation asks more nodes to run jobs with adaptive deals when the results proposed so far are not enough
+// to rely on. If no node can run an additional execution, the results proposed so far are verified as they are.
+func (s *BaseScheduler) checkForEscalation(ctx context.Context, job model.Job, jobState model.JobState) {
+	if !job.Spec.Deal.Adaptive {
+		return
+	}
+	var nonDiscardedExecutionsCount int
+	var failedExecutionsCount int
+	for _, execution := range jobState.Executions {
+		if !execution.State.IsDiscarded() {
+			nonDiscardedExecutionsCount++
+		}
+		if execution.State == model.ExecutionStateFailed && !execution.Preempted {
+			failedExecutionsCount++
+		}
+	}
+	// the first execution is asked for and retried by checkForFailedExecutions
+	targetConcurrency := s.targetConcurrency(ctx, job, jobState)
+	desiredNodeCount := targetConcurrency - nonDiscardedExecutionsCount
+	if targetConcurrency <= 1 || desiredNodeCount <= 0 {
+		return
+	}
+
+	proposed := jobState.GroupExecutionsByState()[model.ExecutionStateResultProposed]
+	// additional executions that failed are retried like any other execution
+	if failedExecutionsCount > 0 && !s.shouldRetry(ctx, job, jobState, vacantShards(jobState, desiredNodeCount)) {
+		s.verifyPendingResults(ctx, job, proposed)
+		return
+	}
+	rankedNodes, err := s.nodeSelector.SelectNodes(ctx, job, desiredNodeCount, desiredNodeCount)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("[transitionJobState] no node to run an additional execution, verifying results as they are")
+		s.verifyPendingResults(ctx, job, proposed)
+		return
+	}
+	log.Ctx(ctx).Debug().Msgf("escalating job %s to %d executions", job.ID(), nonDiscardedExecutionsCount+desiredNodeCount)
+	s.notifyAskForBid(ctx, trace.LinkFromContext(ctx), job, rankedNodes[:desiredNodeCount])
+}
+
+// targetConcurrency returns how many executions of the job should be active. It is the concurrency of the deal,
+// unless the deal is adaptive: then a single execution is run at first, and one more every time the results proposed
+// by all the active executions are not enough to rely on, up to the concurrency of the deal.
+func (s *BaseScheduler) targetConcurrency(ctx context.Context, job model.Job, jobState model.JobState) int {
+	if !job.Spec.Deal.Adaptive {
+		return job.Spec.Deal.Concurrency
+	}
+	var activeExecutionsCount int
+	var running bool
+	var proposed []model.ExecutionState
+	for _, execution := range jobState.Executions {
+		if execution.State.IsActive() {
+			activeExecutionsCount++
+		}
+		switch execution.State {
+		case model.ExecutionStateBidAccepted:
+			running = true
+		case model.ExecutionStateResultProposed:
+			proposed = append(proposed, execution)
+		}
+	}
+	if activeExecutionsCount == 0 {
+		return 1
+	}
+	if running || len(proposed) == 0 || activeExecutionsCount >= job.Spec.Deal.GetConcurrency() {
+		return activeExecutionsCount
+	}
+	if s.needsMoreResults(ctx, job, proposed) {
+		return activeExecutionsCount + 1
+	}
+	return activeExecutionsCount
+}
+
+// needsMoreResults returns true if the proposed results of a job are not enough to rely on, because its verifier
+// needs more results to verify it, or because a single result was proposed by a node that is not trusted.
+func (s *BaseScheduler) needsMoreResults(ctx context.Context, job model.Job, proposed []model.ExecutionState) bool {
+	if len(proposed) == 1 && (s.nodeTrust == nil || !s.nodeTrust.IsTrusted(ctx, proposed[0].NodeID)) {
+		return true
+	}
+	jobVerifier, err := s.verifiers.Get(ctx, job.Spec.Verifier)
+	if err != nil {
+		return false
+	}
+	estimator, ok := jobVerifier.(verifier.SampleEstimator)
+	if !ok {
+		return false
+	}
+	needsMore, err := estimator.NeedsMoreSamples(ctx, verifier.VerifierRequest{
+		JobID:      job.ID(),
+		Executions: proposed,
+		Deal:       job.Spec.Deal,
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("[transitionJobState] failed to check whether the verifier needs more results")
+		return false
+	}
+	return needsMore
+}
+
 // checkForPendingBids checks if any bid is still pending a response, if minBids criteria is met, and accept/reject bids accordingly.
 func (s *BaseScheduler) checkForPendingBids(ctx context.Context, job model.Job, jobState model.JobState) {
 	executionsByState := jobState.GroupExecutionsByState()
@@ -131,10 +318,44 @@ func (s *BaseScheduler) checkForPendingBids(ctx context.Context, job model.Job,
 		}
 	}
 
+	// executions taking over migrated executions replace them, rather than adding to the executions of the job
+	canceled := make(map[string]bool)
+	for _, candidate := range executionsByState[model.ExecutionStateAskForBidAccepted] {
+		if candidate.Migration == nil {
+			continue
+		}
+		migrated, ok := s.completeMigration(ctx, jobState, candidate)
+		if !ok {
+			s.updateAndNotifyBidRejected(ctx, candidate)
+			continue
+		}
+		s.updateAndNotifyBidAccepted(ctx, candidate)
+		canceled[migrated.ComputeReference] = true
+		if !migrated.State.IsActive() {
+			activeExecutionsCount++
+		}
+	}
+	var candidates []model.ExecutionState
+	for _, candidate := range executionsByState[model.ExecutionStateAskForBidAccepted] {
+		if candidate.Migration == nil && !canceled[candidate.ComputeReference] {
+			candidates = append(candidates, candidate)
+		}
+	}
+
 	if receivedBidsCount >= job.Spec.Deal.MinBids {
+		concurrency := s.targetConcurrency(ctx, job, jobState)
+		// prefer the nodes that expect to fetch the inputs of the job the fastest, and those that could not estimate
+		// it last
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].InputsEstimated != candidates[j].InputsEstimated {
+				return candidates[i].InputsEstimated
+			}
+			return candidates[i].InputsFetchEstimate < candidates[j].InputsFetchEstimate
+		})
+
 		// TODO: we should verify a bid acceptance was received by the compute node before rejecting other bids
-		for _, candidate := range executionsByState[model.ExecutionStateAskForBidAccepted] {
-			if activeExecutionsCount < job.Spec.Deal.Concurrency {
+		for _, candidate := range candidates {
+			if activeExecutionsCount < concurrency {
 				s.updateAndNotifyBidAccepted(ctx, candidate)
 				activeExecutionsCount++
 			} else {
@@ -148,16 +369,24 @@ func (s *BaseScheduler) checkForPendingBids(ctx context.Context, job model.Job,
 func (s *BaseScheduler) checkForPendingResults(ctx context.Context, job model.Job, jobState model.JobState) {
 	executionsByState := jobState.GroupExecutionsByState()
 	awaitingVerification := len(executionsByState[model.ExecutionStateResultProposed])
-	if awaitingVerification >= job.Spec.Deal.Concurrency {
-		succeeded, failed, err := s.verifyResult(ctx, job, executionsByState[model.ExecutionStateResultProposed])
-		log.Ctx(ctx).Debug().Err(err).Int("Succeeded", len(succeeded)).Int("Failed", len(failed)).Msg("Attempted to verify results")
-		if err != nil {
-			s.stopJob(ctx, job.ID(), fmt.Sprintf("failed to verify job %s: %s", job.ID(), err), false)
-			return
-		}
-		if len(failed) > 0 {
-			s.transitionJobStateLockFree(ctx, job.ID())
-		}
+	if awaitingVerification >= s.targetConcurrency(ctx, job, jobState) {
+		s.verifyPendingResults(ctx, job, executionsByState[model.ExecutionStateResultProposed])
+	}
+}
+
+// verifyPendingResults verifies the proposed results, and accept/reject results accordingly.
+func (s *BaseScheduler) verifyPendingResults(ctx context.Context, job model.Job, proposed []model.ExecutionState) {
+	if len(proposed) == 0 {
+		return
+	}
+	succeeded, failed, err := s.verifyResult(ctx, job, proposed)
+	log.Ctx(ctx).Debug().Err(err).Int("Succeeded", len(succeeded)).Int("Failed", len(failed)).Msg("Attempted to verify results")
+	if err != nil {
+		s.stopJob(ctx, job.ID(), fmt.Sprintf("failed to verify job %s: %s", job.ID(), err), false, 0)
+		return
+	}
+	if len(failed) > 0 {
+		s.transitionJobStateLockFree(ctx, job.ID())
 	}
 }
 
@@ -178,11 +407,17 @@ func (s *BaseScheduler) checkForCompletedExecutions(ctx context.Context, job mod
 		if completedCount < job.Spec.Deal.GetConfidence() {
 			newState = model.JobStateCompletedPartially
 		}
+		// executions that changed since the state was read, such as a retry created for a failed one, trigger
+		// another transition that completes the job if it still should be
 		err := s.jobStore.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
-			JobID:    job.ID(),
-			NewState: newState,
+			JobID:     job.ID(),
+			NewState:  newState,
+			Condition: jobstore.UpdateJobCondition{ExpectedVersion: jobState.Version},
 		})
-		if err != nil {
+		if errors.As(err, &jobstore.ErrInvalidJobVersion{}) {
+			log.Ctx(ctx).Debug().Err(err).Msgf("[checkForCompletedExecutions] job changed since it was checked")
+			return
+		} else if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msgf("[checkForCompletedExecutions] failed to update job state")
 			return
 		} else {
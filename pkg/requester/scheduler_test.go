@@ -0,0 +1,670 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/eventhandler"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+func TestRejectOversizedResults(t *testing.T) {
+	job := model.Job{Spec: model.Spec{ResultSizeLimit: 100}}
+	execution := func(node string, resultSize, nodeLimit uint64) model.ExecutionState {
+		return model.ExecutionState{
+			JobID:           "job",
+			NodeID:          node,
+			ResultSizeLimit: nodeLimit,
+			RunOutput:       &model.RunCommandResult{ResultSize: resultSize},
+		}
+	}
+	executions := []model.ExecutionState{
+		execution("within-job-limit", 100, 0),
+		execution("exceeds-job-limit", 101, 0),
+		execution("exceeds-node-limit", 60, 50),
+		{JobID: "job", NodeID: "no-run-output"},
+	}
+	results := make([]verifier.VerifierResult, 0, len(executions))
+	for _, e := range executions {
+		results = append(results, verifier.VerifierResult{ExecutionID: e.ID(), Verified: true})
+	}
+
+	rejectOversizedResults(context.Background(), job, executions, results)
+
+	verified := make(map[string]bool)
+	for _, result := range results {
+		verified[result.ExecutionID.NodeID] = result.Verified
+	}
+	require.Equal(t, map[string]bool{
+		"within-job-limit":   true,
+		"exceeds-job-limit":  false,
+		"exceeds-node-limit": false,
+		"no-run-output":      true,
+	}, verified)
+}
+
+func TestVerifyExecutionsRejectsOversizedResults(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	job := model.Job{Metadata: model.Metadata{ID: "job"}, Spec: model.Spec{ResultSizeLimit: 100}}
+	require.NoError(t, store.CreateJob(ctx, job))
+	for _, execution := range []model.ExecutionState{
+		{ComputeReference: "small", RunOutput: &model.RunCommandResult{ResultSize: 100}},
+		{ComputeReference: "oversized", RunOutput: &model.RunCommandResult{ResultSize: 101}},
+	} {
+		execution.JobID = job.ID()
+		execution.NodeID = "node"
+		execution.State = model.ExecutionStateResultProposed
+		require.NoError(t, store.CreateExecution(ctx, execution))
+	}
+
+	computeEndpoint := &recordingComputeEndpoint{}
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		ID:              "requester",
+		JobStore:        store,
+		ComputeEndpoint: computeEndpoint,
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error { return nil }),
+		}),
+	})
+
+	// asynchronous verifiers report their verdicts straight to VerifyExecutions, long after the results were proposed
+	succeeded, failed := scheduler.VerifyExecutions(ctx, []verifier.VerifierResult{
+		{ExecutionID: model.ExecutionID{JobID: job.ID(), NodeID: "node", ExecutionID: "small"}, Verified: true},
+		{ExecutionID: model.ExecutionID{JobID: job.ID(), NodeID: "node", ExecutionID: "oversized"}, Verified: true},
+	})
+	require.Len(t, succeeded, 1)
+	require.Equal(t, "small", succeeded[0].ExecutionID.ExecutionID)
+	require.Len(t, failed, 1)
+	require.Equal(t, "oversized", failed[0].ExecutionID.ExecutionID)
+
+	jobState, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	states := make(map[string]model.ExecutionStateType)
+	for _, execution := range jobState.Executions {
+		states[execution.ComputeReference] = execution.State
+	}
+	require.Equal(t, map[string]model.ExecutionStateType{
+		"small":     model.ExecutionStateResultAccepted,
+		"oversized": model.ExecutionStateResultRejected,
+	}, states)
+	require.Eventually(t, func() bool {
+		computeEndpoint.mu.Lock()
+		defer computeEndpoint.mu.Unlock()
+		return len(computeEndpoint.accepted) == 1 && len(computeEndpoint.rejected) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStaleJobStateIsNotApplied(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	job := model.Job{Metadata: model.Metadata{ID: "job"}}
+	require.NoError(t, store.CreateJob(ctx, job))
+	require.NoError(t, store.CreateExecution(ctx, model.ExecutionState{
+		JobID: job.ID(), NodeID: "node", ComputeReference: "completed", State: model.ExecutionStateCompleted,
+	}))
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		ID:              "requester",
+		JobStore:        store,
+		ComputeEndpoint: &recordingComputeEndpoint{},
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error { return nil }),
+		}),
+	})
+
+	// a callback creates an execution between the scheduler reading the state and acting on it
+	read, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	require.NoError(t, store.CreateExecution(ctx, model.ExecutionState{
+		JobID: job.ID(), NodeID: "node", ComputeReference: "running", State: model.ExecutionStateBidAccepted,
+	}))
+
+	scheduler.checkForCompletedExecutions(ctx, job, read)
+	scheduler.stopJob(ctx, job.ID(), "no more executions", false, read.Version)
+
+	jobState, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	require.Equal(t, model.JobStateNew, jobState.State)
+	require.Equal(t, model.ExecutionStateBidAccepted, jobState.Executions[1].State)
+}
+
+// recordingComputeEndpoint records the executions the scheduler asks compute nodes to bid on or cancel.
+type recordingComputeEndpoint struct {
+	compute.Endpoint
+	mu       gosync.Mutex
+	asked    []string
+	canceled []string
+	extended []compute.ExtendTimeoutRequest
+	accepted []string
+	rejected []string
+	// acceptedBids and rejectedBids are the executions whose bids were accepted or rejected
+	acceptedBids []string
+	rejectedBids []string
+}
+
+func (e *recordingComputeEndpoint) BidAccepted(
+	_ context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.acceptedBids = append(e.acceptedBids, request.ExecutionID)
+	return compute.BidAcceptedResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) BidRejected(
+	_ context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rejectedBids = append(e.rejectedBids, request.ExecutionID)
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) AskForBid(_ context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.asked = append(e.asked, request.RoutingMetadata.TargetPeerID)
+	return compute.AskForBidResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) CancelExecution(
+	_ context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.canceled = append(e.canceled, request.ExecutionID)
+	return compute.CancelExecutionResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) ResultAccepted(
+	_ context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.accepted = append(e.accepted, request.ExecutionID)
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) ResultRejected(
+	_ context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rejected = append(e.rejected, request.ExecutionID)
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) ExtendTimeout(
+	_ context.Context, request compute.ExtendTimeoutRequest) (compute.ExtendTimeoutResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.extended = append(e.extended, request)
+	return compute.ExtendTimeoutResponse{}, nil
+}
+
+func (e *recordingComputeEndpoint) calls() (asked, canceled []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.asked...), append([]string{}, e.canceled...)
+}
+
+// activeExecutionsRanker excludes nodes that have an active execution of the job.
+type activeExecutionsRanker struct {
+	store jobstore.Store
+}
+
+func (r activeExecutionsRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]NodeRank, error) {
+	jobState, err := r.store.GetJobState(ctx, job.ID())
+	if err != nil {
+		return nil, err
+	}
+	ranks := make([]NodeRank, 0, len(nodes))
+	for _, node := range nodes {
+		rank := NodeRank{NodeInfo: node, Rank: 1}
+		for _, execution := range jobState.Executions {
+			if execution.NodeID == node.PeerInfo.ID.String() && !execution.State.IsDiscarded() {
+				rank.Rank = -1
+			}
+		}
+		ranks = append(ranks, rank)
+	}
+	return ranks, nil
+}
+
+func TestMigrateExecutions(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	nodeID := func(name string) string { return peer.ID(name).String() }
+	var nodes []model.NodeInfo
+	for _, name := range []string{"degraded", "healthy", "spare-1", "spare-2"} {
+		nodes = append(nodes, model.NodeInfo{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID(name)},
+			ComputeNodeInfo: &model.ComputeNodeInfo{ExecutionEngines: []model.Engine{model.EngineWasm}},
+		})
+	}
+
+	job := model.Job{Metadata: model.Metadata{ID: "migrate-job"}, Spec: model.Spec{Engine: model.EngineWasm}}
+	require.NoError(t, store.CreateJob(ctx, job))
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+	for _, execution := range []model.ExecutionState{
+		{ComputeReference: "waiting-for-bid", NodeID: nodeID("degraded"), State: model.ExecutionStateAskForBid},
+		{ComputeReference: "queued", NodeID: nodeID("degraded"), State: model.ExecutionStateBidAccepted},
+		{ComputeReference: "running", NodeID: nodeID("degraded"), State: model.ExecutionStateBidAccepted},
+		{ComputeReference: "elsewhere", NodeID: nodeID("healthy"), State: model.ExecutionStateBidAccepted},
+	} {
+		execution.JobID = job.ID()
+		require.NoError(t, store.CreateExecution(ctx, execution))
+	}
+
+	var events []model.JobEvent
+	computeEndpoint := &recordingComputeEndpoint{}
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		ID:       "requester",
+		JobStore: store,
+		NodeSelector: *NewNodeSelector(NodeSelectorParams{
+			NodeDiscoverer: fixedNodeDiscoverer{nodes: nodes},
+			NodeRanker:     activeExecutionsRanker{store: store},
+		}),
+		ComputeEndpoint: computeEndpoint,
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(ctx context.Context, event model.JobEvent) error {
+				events = append(events, event)
+				return nil
+			}),
+		}),
+	})
+	request := MigrateExecutionsRequest{
+		NodeID:             nodeID("degraded"),
+		QueuedExecutionIDs: []string{"queued"},
+		ClientID:           "admin",
+		Reason:             "disk failing",
+		DryRun:             true,
+	}
+
+	// a dry run reports where executions would go without touching them
+	result, err := scheduler.MigrateExecutions(ctx, request)
+	require.NoError(t, err)
+	require.Empty(t, result.Failed)
+	require.Len(t, result.Migrated, 2)
+	jobState, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	require.Len(t, jobState.Executions, 4)
+	require.Empty(t, events)
+
+	request.DryRun = false
+	result, err = scheduler.MigrateExecutions(ctx, request)
+	require.NoError(t, err)
+	require.Empty(t, result.Failed)
+	migrated := make(map[string]string)
+	for _, execution := range result.Migrated {
+		migrated[execution.ExecutionID] = execution.TargetNodeID
+	}
+	require.ElementsMatch(t, []string{"waiting-for-bid", "queued"}, maps.Keys(migrated))
+	require.ElementsMatch(t, []string{nodeID("spare-1"), nodeID("spare-2")}, maps.Values(migrated))
+
+	// nothing is canceled until the nodes taking over the executions accept to bid on them
+	getStates := func() map[string]model.ExecutionState {
+		jobState, err := store.GetJobState(ctx, job.ID())
+		require.NoError(t, err)
+		states := make(map[string]model.ExecutionState)
+		for _, execution := range jobState.Executions {
+			states[execution.ComputeReference] = execution
+		}
+		return states
+	}
+	states := getStates()
+	require.Len(t, states, 6)
+	replacements := make(map[string]model.ExecutionState)
+	for _, execution := range states {
+		if execution.Migration != nil {
+			require.Equal(t, model.ExecutionStateAskForBid, execution.State)
+			require.Equal(t, migrated[execution.Migration.From], execution.NodeID)
+			require.Contains(t, execution.Migration.Status, "disk failing")
+			replacements[execution.Migration.From] = execution
+		}
+	}
+	require.Len(t, replacements, 2)
+	require.Equal(t, model.ExecutionStateAskForBid, states["waiting-for-bid"].State)
+	require.Equal(t, model.ExecutionStateBidAccepted, states["queued"].State)
+	require.Empty(t, events)
+	require.Eventually(t, func() bool {
+		asked, _ := computeEndpoint.calls()
+		return len(asked) == 2
+	}, time.Second, 10*time.Millisecond)
+	asked, canceled := computeEndpoint.calls()
+	require.ElementsMatch(t, maps.Values(migrated), asked)
+	require.Empty(t, canceled)
+
+	// migrating again does not ask other nodes to take over the same executions
+	result, err = scheduler.MigrateExecutions(ctx, request)
+	require.NoError(t, err)
+	require.Empty(t, result.Migrated)
+	require.Len(t, getStates(), 6)
+
+	// the queued execution moves once its replacement bids, and the other stays as its replacement has no capacity
+	bid := func(replacement model.ExecutionState, accepted bool) {
+		scheduler.OnBidComplete(ctx, compute.BidResult{
+			RoutingMetadata:   compute.RoutingMetadata{SourcePeerID: replacement.NodeID},
+			ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: replacement.ComputeReference, JobID: job.ID()},
+			Accepted:          accepted,
+		})
+	}
+	bid(replacements["queued"], true)
+	bid(replacements["waiting-for-bid"], false)
+	states = getStates()
+	require.Equal(t, model.ExecutionStateCanceled, states["queued"].State)
+	require.True(t, states["queued"].Migrated)
+	require.Contains(t, states["queued"].Status, "disk failing")
+	require.Equal(t, model.ExecutionStateBidAccepted, states[replacements["queued"].ComputeReference].State)
+	require.Equal(t, model.ExecutionStateAskForBid, states["waiting-for-bid"].State)
+	require.Equal(t, model.ExecutionStateAskForBidRejected, states[replacements["waiting-for-bid"].ComputeReference].State)
+	require.Equal(t, model.ExecutionStateBidAccepted, states["running"].State)
+	require.Equal(t, model.ExecutionStateBidAccepted, states["elsewhere"].State)
+
+	var migratedEvents []model.JobEvent
+	for _, event := range events {
+		if event.EventName == model.JobEventMigrated {
+			migratedEvents = append(migratedEvents, event)
+		}
+	}
+	require.Len(t, migratedEvents, 1)
+	require.Equal(t, "queued", migratedEvents[0].ExecutionID)
+	require.Eventually(t, func() bool {
+		_, canceled := computeEndpoint.calls()
+		computeEndpoint.mu.Lock()
+		defer computeEndpoint.mu.Unlock()
+		return len(canceled) == 1 && len(computeEndpoint.acceptedBids) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, canceled = computeEndpoint.calls()
+	require.Equal(t, []string{"queued"}, canceled)
+	require.Equal(t, []string{replacements["queued"].ComputeReference}, computeEndpoint.acceptedBids)
+}
+
+func TestPendingBidsPreferFastestEstimatedInputs(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	job := model.Job{
+		Metadata: model.Metadata{ID: "ranked-job"},
+		Spec:     model.Spec{Engine: model.EngineWasm, Deal: model.Deal{Concurrency: 1}},
+	}
+	require.NoError(t, store.CreateJob(ctx, job))
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+	for _, execution := range []model.ExecutionState{
+		{ComputeReference: "unknown", NodeID: "node-0"},
+		{ComputeReference: "slow", NodeID: "node-1", InputsFetchEstimate: 2 * time.Second, InputsEstimated: true},
+		{ComputeReference: "fast", NodeID: "node-2", InputsFetchEstimate: time.Second, InputsEstimated: true},
+	} {
+		execution.JobID = job.ID()
+		execution.State = model.ExecutionStateAskForBidAccepted
+		execution.AcceptedAskForBid = true
+		require.NoError(t, store.CreateExecution(ctx, execution))
+	}
+
+	computeEndpoint := &recordingComputeEndpoint{}
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		ID:              "requester",
+		JobStore:        store,
+		ComputeEndpoint: computeEndpoint,
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error { return nil }),
+		}),
+	})
+	scheduler.TransitionJobState(ctx, job.ID())
+
+	// bids without an estimate come last, rather than first as if they had nothing to fetch
+	require.Eventually(t, func() bool {
+		computeEndpoint.mu.Lock()
+		defer computeEndpoint.mu.Unlock()
+		return len(computeEndpoint.acceptedBids) == 1 && len(computeEndpoint.rejectedBids) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"fast"}, computeEndpoint.acceptedBids)
+}
+
+// samplingVerifier is a verifier that needs more results until enough of them were proposed.
+type samplingVerifier struct {
+	verifier.Verifier
+	samples int
+}
+
+func (v samplingVerifier) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (v samplingVerifier) NeedsMoreSamples(_ context.Context, request verifier.VerifierRequest) (bool, error) {
+	return len(request.Executions) < v.samples, nil
+}
+
+// trustedNodes trusts a fixed set of nodes.
+type trustedNodes map[string]bool
+
+func (t trustedNodes) IsTrusted(_ context.Context, nodeID string) bool {
+	return t[nodeID]
+}
+
+func TestTargetConcurrency(t *testing.T) {
+	ctx := context.Background()
+	execution := func(node string, state model.ExecutionStateType) model.ExecutionState {
+		return model.ExecutionState{JobID: "adaptive-job", NodeID: node, State: state}
+	}
+	deterministicJob := model.Job{Spec: model.Spec{
+		Verifier: model.VerifierDeterministic,
+		Deal:     model.Deal{Concurrency: 3, Adaptive: true},
+	}}
+	noopJob := model.Job{Spec: model.Spec{
+		Verifier: model.VerifierNoop,
+		Deal:     model.Deal{Concurrency: 3, Adaptive: true},
+	}}
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		Verifiers: model.NewMappedProvider(map[model.Verifier]verifier.Verifier{
+			model.VerifierDeterministic: samplingVerifier{samples: 2},
+		}),
+		NodeTrust: trustedNodes{"trusted": true},
+	})
+
+	for _, tc := range []struct {
+		name       string
+		job        model.Job
+		executions []model.ExecutionState
+		expected   int
+	}{
+		{
+			name:     "fixed deals run as many executions as their concurrency",
+			job:      model.Job{Spec: model.Spec{Deal: model.Deal{Concurrency: 3}}},
+			expected: 3,
+		},
+		{
+			name:     "adaptive deals start with a single execution",
+			job:      noopJob,
+			expected: 1,
+		},
+		{
+			name:       "results of trusted nodes are enough",
+			job:        noopJob,
+			executions: []model.ExecutionState{execution("trusted", model.ExecutionStateResultProposed)},
+			expected:   1,
+		},
+		{
+			name:       "results of nodes that are not trusted need another execution",
+			job:        noopJob,
+			executions: []model.ExecutionState{execution("unknown", model.ExecutionStateResultProposed)},
+			expected:   2,
+		},
+		{
+			name: "no escalation while executions are running",
+			job:  noopJob,
+			executions: []model.ExecutionState{
+				execution("unknown", model.ExecutionStateResultProposed),
+				execution("other", model.ExecutionStateBidAccepted),
+			},
+			expected: 2,
+		},
+		{
+			name: "the verifier needs more results",
+			job:  deterministicJob,
+			executions: []model.ExecutionState{
+				execution("trusted", model.ExecutionStateResultProposed),
+			},
+			expected: 2,
+		},
+		{
+			name: "the verifier has enough results",
+			job:  deterministicJob,
+			executions: []model.ExecutionState{
+				execution("trusted", model.ExecutionStateResultProposed),
+				execution("unknown", model.ExecutionStateResultProposed),
+			},
+			expected: 2,
+		},
+		{
+			name: "escalation stops at the concurrency of the deal",
+			job:  model.Job{Spec: model.Spec{Deal: model.Deal{Concurrency: 1, Adaptive: true}}},
+			executions: []model.ExecutionState{
+				execution("unknown", model.ExecutionStateResultProposed),
+			},
+			expected: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobState := model.JobState{JobID: "adaptive-job", Executions: tc.executions}
+			require.Equal(t, tc.expected, scheduler.targetConcurrency(ctx, tc.job, jobState))
+		})
+	}
+}
+
+func TestAdaptiveEscalation(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	var nodes []model.NodeInfo
+	for _, name := range []string{"first", "second"} {
+		nodes = append(nodes, model.NodeInfo{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID(name)},
+			ComputeNodeInfo: &model.ComputeNodeInfo{ExecutionEngines: []model.Engine{model.EngineWasm}},
+		})
+	}
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "adaptive-job"},
+		Spec: model.Spec{
+			Engine:   model.EngineWasm,
+			Verifier: model.VerifierNoop,
+			Deal:     model.Deal{Concurrency: 3, Adaptive: true},
+		},
+	}
+	require.NoError(t, store.CreateJob(ctx, job))
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+	require.NoError(t, store.CreateExecution(ctx, model.ExecutionState{
+		JobID:            job.ID(),
+		NodeID:           peer.ID("first").String(),
+		ComputeReference: "e-first",
+		State:            model.ExecutionStateResultProposed,
+	}))
+
+	computeEndpoint := &recordingComputeEndpoint{}
+	scheduler := NewBaseScheduler(BaseSchedulerParams{
+		ID:       "requester",
+		JobStore: store,
+		NodeSelector: *NewNodeSelector(NodeSelectorParams{
+			NodeDiscoverer: fixedNodeDiscoverer{nodes: nodes},
+			NodeRanker:     activeExecutionsRanker{store: store},
+		}),
+		ComputeEndpoint: computeEndpoint,
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error { return nil }),
+		}),
+	})
+
+	// the only result comes from a node that is not trusted, so another node is asked to run the job
+	scheduler.TransitionJobState(ctx, job.ID())
+	require.Eventually(t, func() bool {
+		asked, _ := computeEndpoint.calls()
+		return len(asked) == 1
+	}, time.Second, 10*time.Millisecond)
+	asked, _ := computeEndpoint.calls()
+	require.Equal(t, []string{peer.ID("second").String()}, asked)
+
+	// the additional execution is not asked for again while it is pending
+	scheduler.TransitionJobState(ctx, job.ID())
+	jobState, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	require.Len(t, jobState.Executions, 2)
+	require.Equal(t, model.ExecutionStateResultProposed, jobState.Executions[0].State)
+}
+
+// fixedRetryStrategy always gives the same answer.
+type fixedRetryStrategy bool
+
+func (s fixedRetryStrategy) ShouldRetry(context.Context, RetryRequest) bool {
+	return bool(s)
+}
+
+func TestShouldRetry(t *testing.T) {
+	jobState := model.JobState{Executions: []model.ExecutionState{
+		{ComputeReference: "first", Shard: 0, State: model.ExecutionStateFailed},
+		{ComputeReference: "second", Shard: 0, State: model.ExecutionStateFailed},
+		{ComputeReference: "preempted", Shard: 0, State: model.ExecutionStateFailed, Preempted: true},
+		{ComputeReference: "running", Shard: 1, State: model.ExecutionStateBidAccepted},
+		{ComputeReference: "replaced", Shard: 2, State: model.ExecutionStateFailed},
+		{ComputeReference: "replacement", Shard: 2, State: model.ExecutionStateBidAccepted},
+	}}
+	shards := vacantShards(jobState, 2)
+	require.Equal(t, []int{0, 3}, shards)
+
+	limit := func(n int) *int { return &n }
+	for _, tc := range []struct {
+		name               string
+		maxRetries         *int
+		maxRetriesPerShard *int
+		expected           bool
+	}{
+		{name: "retry strategy decides without limits", expected: true},
+		{name: "zero max retries disables retries", maxRetries: limit(0), expected: false},
+		{name: "within max retries", maxRetries: limit(3), expected: true},
+		{name: "beyond max retries", maxRetries: limit(2), expected: false},
+		{name: "beyond max retries of a shard", maxRetriesPerShard: limit(1), expected: false},
+		{name: "within max retries of every shard", maxRetriesPerShard: limit(2), expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scheduler := &BaseScheduler{retryStrategy: fixedRetryStrategy(true)}
+			job := model.Job{Spec: model.Spec{MaxRetries: tc.maxRetries, MaxRetriesPerShard: tc.maxRetriesPerShard}}
+			require.Equal(t, tc.expected, scheduler.shouldRetry(context.Background(), job, jobState, shards))
+		})
+	}
+}
+
+func TestShouldRetryPreemptedExecutions(t *testing.T) {
+	preempted := model.ExecutionState{ComputeReference: "preempted", State: model.ExecutionStateFailed, Preempted: true}
+	jobState := model.JobState{Executions: []model.ExecutionState{preempted, preempted}}
+	// retries of a job that are not allowed do not apply to preemptions
+	job := model.Job{Spec: model.Spec{MaxRetries: new(int)}}
+
+	scheduler := &BaseScheduler{retryStrategy: fixedRetryStrategy(false), maxPreemptions: 2}
+	retry, err := scheduler.shouldRetryFailure(context.Background(), job, jobState, preempted, 1)
+	require.NoError(t, err)
+	require.True(t, retry)
+
+	jobState.Executions = append(jobState.Executions, preempted)
+	retry, err = scheduler.shouldRetryFailure(context.Background(), job, jobState, preempted, 1)
+	require.Error(t, err, "jobs preempted more often than the limit fail")
+	require.False(t, retry)
+
+	failed := model.ExecutionState{ComputeReference: "failed", State: model.ExecutionStateFailed}
+	jobState.Executions = append(jobState.Executions, failed)
+	retry, err = scheduler.shouldRetryFailure(context.Background(), job, jobState, failed, 1)
+	require.NoError(t, err)
+	require.False(t, retry, "failures that are not preemptions use the retry limits")
+}
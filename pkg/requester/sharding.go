@@ -0,0 +1,78 @@
+package requester
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// ShardingConfig partitions the ownership of jobs among requesters that share a cluster, by the hash of their job
+// IDs. Each requester only creates and schedules the jobs it owns, and the requests for the jobs owned by its peers
+// are proxied to them. Named jobs are created by the owner of their name, so that names are unique across the cluster
+// and requests for a job given by its name are routed to its owner too.
+type ShardingConfig struct {
+	// Shard is the index of this requester in Peers.
+	Shard int
+	// Peers are the URLs of the APIs of the requesters sharing the cluster, in shard order, including this requester.
+	// Jobs are not sharded if there are fewer than two.
+	Peers []string
+	// Secret is shared by the requesters of the cluster to authenticate the requests they proxy to each other.
+	Secret string
+}
+
+// Enabled returns whether jobs are sharded among several requesters.
+func (c ShardingConfig) Enabled() bool {
+	return len(c.Peers) > 1
+}
+
+// Validate checks that this requester is one of the peers, and that the URLs of the peers can be parsed.
+func (c ShardingConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Shard < 0 || c.Shard >= len(c.Peers) {
+		return fmt.Errorf("requester shard %d is not the index of one of the %d requester peers", c.Shard, len(c.Peers))
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("requester peers need a shared secret to authenticate the requests they proxy to each other")
+	}
+	for _, peer := range c.Peers {
+		if u, err := url.Parse(peer); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid requester peer URL %q", peer)
+		}
+	}
+	return nil
+}
+
+// ShardOf returns the shard of the requester that owns the job. Jobs are partitioned by the hash of their short ID,
+// so that the requests for a job given by its short ID are routed to its owner too.
+func (c ShardingConfig) ShardOf(jobID string) int {
+	if !c.Enabled() {
+		return c.Shard
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(model.ShortID(jobID)))
+	return int(hash.Sum32() % uint32(len(c.Peers)))
+}
+
+// Owns returns whether this requester owns the job.
+func (c ShardingConfig) Owns(jobID string) bool {
+	return c.ShardOf(jobID) == c.Shard
+}
+
+// ShardOfName returns the shard of the requester that creates the jobs with the name, and so owns them.
+func (c ShardingConfig) ShardOfName(name string) int {
+	if !c.Enabled() {
+		return c.Shard
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return int(hash.Sum32() % uint32(len(c.Peers)))
+}
+
+// OwnsName returns whether this requester creates the jobs with the name.
+func (c ShardingConfig) OwnsName(name string) bool {
+	return c.ShardOfName(name) == c.Shard
+}
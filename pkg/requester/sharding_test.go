@@ -0,0 +1,80 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardingConfig(t *testing.T) {
+	require.False(t, ShardingConfig{}.Enabled())
+	require.True(t, ShardingConfig{}.Owns(uuid.NewString()), "a requester owns every job if jobs are not sharded")
+
+	peers := []string{"http://requester-0:1234", "http://requester-1:1234", "http://requester-2:1234"}
+	require.NoError(t, ShardingConfig{Shard: 2, Peers: peers, Secret: "secret"}.Validate())
+	require.Error(t, ShardingConfig{Shard: 3, Peers: peers, Secret: "secret"}.Validate())
+	require.Error(t, ShardingConfig{Shard: 0, Peers: []string{"requester-0", "requester-1"}, Secret: "secret"}.Validate())
+	require.Error(t, ShardingConfig{Shard: 2, Peers: peers}.Validate(), "proxied requests must be authenticated")
+
+	counts := make([]int, len(peers))
+	for i := 0; i < 3000; i++ {
+		jobID := uuid.NewString()
+		shard := ShardingConfig{Peers: peers}.ShardOf(jobID)
+		counts[shard]++
+		require.Equal(t, shard, ShardingConfig{Peers: peers}.ShardOf(model.ShortID(jobID)), "short IDs have the same owner")
+
+		owners := 0
+		for s := range peers {
+			if (ShardingConfig{Shard: s, Peers: peers}).Owns(jobID) {
+				owners++
+			}
+		}
+		require.Equal(t, 1, owners)
+	}
+	for _, count := range counts {
+		require.InDelta(t, 1000, count, 150, "jobs are spread evenly across the shards")
+	}
+}
+
+func TestEndpointOnlyCreatesOwnedJobs(t *testing.T) {
+	sharding := ShardingConfig{Shard: 1, Peers: []string{"http://requester-0:1234", "http://requester-1:1234"}}
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.Sharding = sharding
+	})
+	for i := 0; i < 20; i++ {
+		job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{Spec: &model.Spec{}})
+		require.NoError(t, err)
+		require.True(t, sharding.Owns(job.ID()))
+	}
+}
+
+func TestEndpointOnlyCreatesOwnedNames(t *testing.T) {
+	sharding := ShardingConfig{Shard: 1, Peers: []string{"http://requester-0:1234", "http://requester-1:1234"}}
+	strategy := mockBidStrategy{response: bidstrategy.BidStrategyResponse{ShouldBid: true}}
+	endpoint, _ := getTestEndpoint(t, &strategy, func(params *BaseEndpointParams) {
+		params.Sharding = sharding
+	})
+
+	var owned, notOwned string
+	for i := 0; owned == "" || notOwned == ""; i++ {
+		name := fmt.Sprintf("job-%d", i)
+		if sharding.OwnsName(name) {
+			owned = name
+		} else {
+			notOwned = name
+		}
+	}
+	job, err := endpoint.SubmitJob(context.Background(), model.JobCreatePayload{Spec: &model.Spec{}, Name: owned})
+	require.NoError(t, err)
+	require.True(t, sharding.Owns(job.ID()))
+	_, err = endpoint.SubmitJob(context.Background(), model.JobCreatePayload{Spec: &model.Spec{}, Name: notOwned})
+	require.Error(t, err, "jobs named after another requester's names are created by it")
+}
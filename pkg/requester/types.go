@@ -17,10 +17,14 @@ type Endpoint interface {
 	ApproveJob(context.Context, bidstrategy.ModerateJobRequest) error
 	// CancelJob cancels an existing job.
 	CancelJob(context.Context, CancelJobRequest) (CancelJobResult, error)
+	// UpdateJob amends the spec of a running job, without disturbing its executions.
+	UpdateJob(context.Context, UpdateJobRequest) (UpdateJobResult, error)
 	// VerifyExecutions approves or rejects the publishing of an execution.
 	VerifyExecutions(context.Context, external.ExternalVerificationResponse) error
 	// ReadLogs retrieves the logs for an execution
 	ReadLogs(context.Context, ReadLogsRequest) (ReadLogsResponse, error)
+	// MigrateExecutions moves executions that have not started yet away from a compute node.
+	MigrateExecutions(context.Context, MigrateExecutionsRequest) (MigrateExecutionsResult, error)
 }
 
 // Scheduler distributes jobs to the compute nodes and tracks the executions.
@@ -28,6 +32,7 @@ type Scheduler interface {
 	StartJob(context.Context, StartJobRequest) error
 	CancelJob(context.Context, CancelJobRequest) (CancelJobResult, error)
 	VerifyExecutions(context.Context, []verifier.VerifierResult) (succeeded, failed []verifier.VerifierResult)
+	MigrateExecutions(context.Context, MigrateExecutionsRequest) (MigrateExecutionsResult, error)
 }
 
 type Queue interface {
@@ -67,6 +72,54 @@ type CancelJobRequest struct {
 
 type CancelJobResult struct{}
 
+// UpdateJobRequest amends the spec of a running job. Fields left empty are not changed.
+type UpdateJobRequest struct {
+	JobID string
+	// SpecVersion is the version of the spec the update is based on
+	SpecVersion       int
+	AddAnnotations    []string
+	RemoveAnnotations []string
+	// Timeout is the new timeout of the job in seconds, and can only extend it
+	Timeout            float64
+	MaxRetries         *int
+	MaxRetriesPerShard *int
+}
+
+type UpdateJobResult struct {
+	Job model.Job
+}
+
+// MigrateExecutionsRequest moves the executions of a compute node that have not started yet to other nodes. These are
+// the executions still waiting for a bid, and the accepted ones the node reports as queued.
+type MigrateExecutionsRequest struct {
+	NodeID string
+	// JobID restricts the migration to the executions of a single job, if set.
+	JobID string
+	// QueuedExecutionIDs are the executions the node last reported as waiting in its queue. Nodes only report the
+	// head of long queues, so executions further back are left in place until a later migration.
+	QueuedExecutionIDs []string
+	// ClientID is the admin that asked for the migration, and is recorded with the reason in the history of the jobs.
+	ClientID string
+	Reason   string
+	// DryRun lists the executions that would be migrated and where to, without moving them.
+	DryRun bool
+}
+
+type MigrateExecutionsResult struct {
+	Migrated []MigratedExecution
+	// Failed holds the reason executions of a job could not be migrated, by job ID.
+	Failed map[string]string
+}
+
+// MigratedExecution is an execution moved away from a node, and the node asked to bid in its place. The execution is
+// canceled once the bid of that node is accepted.
+type MigratedExecution struct {
+	JobID        string
+	ExecutionID  string
+	State        model.ExecutionStateType
+	TargetNodeID string
+}
+
 type ReadLogsRequest struct {
 	JobID       string
 	ExecutionID string
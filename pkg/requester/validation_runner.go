@@ -0,0 +1,116 @@
+package requester
+
+import (
+	"context"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/command"
+)
+
+const defaultValidationPollInterval = time.Second
+
+type ValidationJobRunnerParams struct {
+	Endpoint  Endpoint
+	JobStore  jobstore.Store
+	Scheduler *BaseScheduler
+	// PollInterval is how often the state of validation jobs is checked.
+	PollInterval time.Duration
+}
+
+// ValidationJobRunner runs the validation jobs of the command verifier through the requester node, and reports
+// their verdicts to its scheduler.
+type ValidationJobRunner struct {
+	endpoint     Endpoint
+	jobStore     jobstore.Store
+	scheduler    *BaseScheduler
+	pollInterval time.Duration
+}
+
+func NewValidationJobRunner(params ValidationJobRunnerParams) *ValidationJobRunner {
+	pollInterval := params.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultValidationPollInterval
+	}
+	return &ValidationJobRunner{
+		endpoint:     params.Endpoint,
+		jobStore:     params.JobStore,
+		scheduler:    params.Scheduler,
+		pollInterval: pollInterval,
+	}
+}
+
+// GetJob implements command.JobRunner
+func (r *ValidationJobRunner) GetJob(ctx context.Context, jobID string) (model.Job, error) {
+	return r.jobStore.GetJob(ctx, jobID)
+}
+
+// RunJob implements command.JobRunner
+func (r *ValidationJobRunner) RunJob(ctx context.Context, tag string, job model.Job) (model.JobState, error) {
+	jobID, err := r.submitJob(ctx, tag, job)
+	if err != nil {
+		return model.JobState{}, err
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		state, err := r.jobStore.GetJobState(ctx, jobID)
+		if err != nil {
+			return state, err
+		}
+		if state.State.IsTerminal() {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return state, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// submitJob returns the ID of the job tagged with tag, submitting job if there is none.
+func (r *ValidationJobRunner) submitJob(ctx context.Context, tag string, job model.Job) (string, error) {
+	existing, err := r.jobStore.GetJobs(ctx, jobstore.JobQuery{
+		ClientID:    job.Metadata.ClientID,
+		IncludeTags: []model.IncludedTag{model.IncludedTag(tag)},
+		Limit:       1,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return existing[0].ID(), nil
+	}
+
+	submitted, err := r.endpoint.SubmitJob(ctx, model.JobCreatePayload{
+		ClientID:   job.Metadata.ClientID,
+		APIVersion: job.APIVersion,
+		Spec:       &job.Spec,
+	})
+	if err != nil {
+		return "", err
+	}
+	return submitted.ID(), nil
+}
+
+// VerifyExecutions implements command.JobRunner
+func (r *ValidationJobRunner) VerifyExecutions(ctx context.Context, results []verifier.VerifierResult) {
+	_, failed := r.scheduler.VerifyExecutions(ctx, results)
+
+	// rejected executions may need to be retried
+	jobIDs := make(map[string]struct{})
+	for _, result := range failed {
+		jobIDs[result.ExecutionID.JobID] = struct{}{}
+	}
+	for jobID := range jobIDs {
+		r.scheduler.TransitionJobState(ctx, jobID)
+	}
+}
+
+// compile-time check that ValidationJobRunner implements the expected interfaces
+var _ command.JobRunner = (*ValidationJobRunner)(nil)
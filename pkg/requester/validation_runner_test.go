@@ -0,0 +1,52 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type submitRecordingEndpoint struct {
+	Endpoint
+	submitted int
+}
+
+func (e *submitRecordingEndpoint) SubmitJob(context.Context, model.JobCreatePayload) (*model.Job, error) {
+	e.submitted++
+	return nil, context.Canceled
+}
+
+func TestValidationJobRunnerWaitsForSubmittedJob(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewJobStore()
+	endpoint := &submitRecordingEndpoint{}
+	runner := NewValidationJobRunner(ValidationJobRunnerParams{Endpoint: endpoint, JobStore: store})
+
+	// the validation job was submitted before the requester node restarted
+	validation := model.Job{
+		Metadata: model.Metadata{ID: "validation", ClientID: "client"},
+		Spec:     model.Spec{Annotations: []string{"verification-of-execution:job:node:execution"}},
+	}
+	require.NoError(t, store.CreateJob(ctx, validation))
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    validation.ID(),
+		NewState: model.JobStateCompleted,
+	}))
+
+	state, err := runner.RunJob(ctx, "verification-of-execution:job:node:execution", validation)
+	require.NoError(t, err)
+	require.Equal(t, validation.ID(), state.JobID)
+	require.Zero(t, endpoint.submitted)
+
+	// validation jobs of other executions are submitted
+	_, err = runner.RunJob(ctx, "verification-of-execution:job:node:other", validation)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, endpoint.submitted)
+}
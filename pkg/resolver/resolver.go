@@ -0,0 +1,155 @@
+// Package resolver resolves the references users give to jobs and nodes to their full IDs. A reference is the full ID,
+// a prefix of the ID at least model.ShortIDLength long, such as the short ID, or, for jobs, the name the job was
+// submitted with.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/routing"
+)
+
+const (
+	kindJob  = "job"
+	kindNode = "node"
+)
+
+// Resolver resolves references to jobs and nodes to their full IDs.
+type Resolver interface {
+	// ResolveJobID returns the ID of the job the reference is to, among the jobs of the namespace, or of every
+	// namespace if it is empty. It returns a bacerrors.JobNotFound if no job matches the reference, and a
+	// bacerrors.AmbiguousReference if more than one does.
+	ResolveJobID(ctx context.Context, namespace string, ref string) (string, error)
+	// ResolveNodeID returns the ID of the node the reference is to. It returns an ErrNodeNotFound if no node matches
+	// the reference, and a bacerrors.AmbiguousReference if more than one does.
+	ResolveNodeID(ctx context.Context, ref string) (string, error)
+}
+
+// ErrNodeNotFound is returned when no node matches a reference.
+type ErrNodeNotFound struct {
+	Ref string
+}
+
+func NewErrNodeNotFound(ref string) ErrNodeNotFound {
+	return ErrNodeNotFound{Ref: ref}
+}
+
+func (e ErrNodeNotFound) Error() string {
+	return "node not found: " + e.Ref
+}
+
+type StoreResolverParams struct {
+	JobStore jobstore.Store
+	// NodeInfoStore lists the nodes references are resolved among. Nodes are only referred to by their full ID if nil.
+	NodeInfoStore routing.NodeInfoStore
+}
+
+// StoreResolver resolves references among the jobs of a job store and the nodes of a node info store.
+type StoreResolver struct {
+	jobStore      jobstore.Store
+	nodeInfoStore routing.NodeInfoStore
+}
+
+func NewStoreResolver(params StoreResolverParams) *StoreResolver {
+	return &StoreResolver{
+		jobStore:      params.JobStore,
+		nodeInfoStore: params.NodeInfoStore,
+	}
+}
+
+// ResolveJobID looks the reference up with the ID of a query of the job store, which matches jobs the same way as
+// MatchJob.
+func (r *StoreResolver) ResolveJobID(ctx context.Context, namespace string, ref string) (string, error) {
+	jobs, err := r.jobStore.GetJobs(ctx, jobstore.JobQuery{ID: ref, Namespace: namespace})
+	if err != nil {
+		return "", err
+	}
+	if len(jobs) == 0 {
+		return "", bacerrors.NewJobNotFound(ref)
+	}
+	return jobs[0].Metadata.ID, nil
+}
+
+func (r *StoreResolver) ResolveNodeID(ctx context.Context, ref string) (string, error) {
+	if r.nodeInfoStore == nil {
+		return ref, nil
+	}
+	nodes, err := r.nodeInfoStore.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	return MatchNode(ref, nodes)
+}
+
+// MatchJob returns the ID of the job the reference is to among the jobs of the namespace, or of every namespace if it
+// is empty. The full ID of a job always matches it alone, even if it is the name of another job, and otherwise the
+// reference matches the jobs it is the name of or a prefix of the ID of.
+func MatchJob(ref string, namespace string, jobs []model.Job) (string, error) {
+	var candidates []model.Job
+	for _, j := range jobs {
+		if namespace != "" && j.Metadata.Namespace != namespace {
+			continue
+		}
+		if j.Metadata.ID == ref {
+			return j.Metadata.ID, nil
+		}
+		if j.Metadata.Name == ref || isPrefix(ref, j.Metadata.ID) {
+			candidates = append(candidates, j)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", bacerrors.NewJobNotFound(ref)
+	case 1:
+		return candidates[0].Metadata.ID, nil
+	default:
+		names := make([]string, 0, len(candidates))
+		for _, j := range candidates {
+			if j.Metadata.Name != "" {
+				names = append(names, fmt.Sprintf("%s (%s)", j.Metadata.ID, j.Metadata.Name))
+			} else {
+				names = append(names, j.Metadata.ID)
+			}
+		}
+		sort.Strings(names)
+		return "", bacerrors.NewAmbiguousReference(kindJob, ref, names)
+	}
+}
+
+// MatchNode returns the ID of the node the reference is to, which is the node whose ID it is or a prefix of.
+func MatchNode(ref string, nodes []model.NodeInfo) (string, error) {
+	var candidates []string
+	for _, node := range nodes {
+		id := node.PeerInfo.ID.String()
+		if id == ref {
+			return id, nil
+		}
+		if isPrefix(ref, id) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", NewErrNodeNotFound(ref)
+	case 1:
+		return candidates[0], nil
+	default:
+		sort.Strings(candidates)
+		return "", bacerrors.NewAmbiguousReference(kindNode, ref, candidates)
+	}
+}
+
+// isPrefix returns true if the reference is a prefix of the ID long enough to refer to it.
+func isPrefix(ref string, id string) bool {
+	return len(ref) >= model.ShortIDLength && strings.HasPrefix(id, ref)
+}
+
+var _ Resolver = (*StoreResolver)(nil)
@@ -0,0 +1,91 @@
+//go:build unit || !integration
+
+package resolver
+
+import (
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchJob(t *testing.T) {
+	jobs := []model.Job{
+		{Metadata: model.Metadata{ID: "4d3e2a1b-0000-4f78-8353-623f5f26df08", Namespace: "research", Name: "training-run"}},
+		{Metadata: model.Metadata{ID: "4d3e2a1b-1111-4f78-8353-623f5f26df08", Namespace: "research"}},
+		{Metadata: model.Metadata{ID: "92d5d4ee-3765-4f78-8353-623f5f26df08", Namespace: "finance", Name: "training-run"}},
+		{Metadata: model.Metadata{ID: "c0ffee00-3765-4f78-8353-623f5f26df08", Name: "92d5d4ee-3765-4f78-8353-623f5f26df08"}},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		ref        string
+		namespace  string
+		expected   string
+		candidates []string
+	}{
+		{name: "full ID", ref: "92d5d4ee-3765-4f78-8353-623f5f26df08", expected: "92d5d4ee-3765-4f78-8353-623f5f26df08"},
+		{name: "short ID", ref: "92d5d4ee", expected: "92d5d4ee-3765-4f78-8353-623f5f26df08"},
+		{name: "longer prefix", ref: "4d3e2a1b-1", expected: "4d3e2a1b-1111-4f78-8353-623f5f26df08"},
+		{name: "name in namespace", ref: "training-run", namespace: "finance", expected: "92d5d4ee-3765-4f78-8353-623f5f26df08"},
+		{name: "too short prefix", ref: "92d5d4"},
+		{name: "other namespace", ref: "92d5d4ee", namespace: "research"},
+		{
+			name:       "ambiguous short ID",
+			ref:        "4d3e2a1b",
+			namespace:  "research",
+			candidates: []string{"4d3e2a1b-0000-4f78-8353-623f5f26df08 (training-run)", "4d3e2a1b-1111-4f78-8353-623f5f26df08"},
+		},
+		{
+			name:       "ambiguous name",
+			ref:        "training-run",
+			candidates: []string{"4d3e2a1b-0000-4f78-8353-623f5f26df08 (training-run)", "92d5d4ee-3765-4f78-8353-623f5f26df08 (training-run)"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := MatchJob(tc.ref, tc.namespace, jobs)
+			switch {
+			case tc.expected != "":
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, id)
+			case tc.candidates != nil:
+				var ambiguous *bacerrors.AmbiguousReference
+				require.ErrorAs(t, err, &ambiguous)
+				require.Equal(t, tc.candidates, ambiguous.GetCandidates())
+			default:
+				require.ErrorAs(t, err, new(*bacerrors.JobNotFound))
+			}
+		})
+	}
+}
+
+func TestMatchNode(t *testing.T) {
+	var nodes []model.NodeInfo
+	for _, id := range []string{
+		"QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N",
+		"QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5M",
+		"QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL",
+	} {
+		peerID, err := peer.Decode(id)
+		require.NoError(t, err)
+		nodes = append(nodes, model.NodeInfo{PeerInfo: peer.AddrInfo{ID: peerID}})
+	}
+
+	id, err := MatchNode("QmdZQ7Zb", nodes)
+	require.NoError(t, err)
+	require.Equal(t, "QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL", id)
+
+	id, err = MatchNode("QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5M", nodes)
+	require.NoError(t, err)
+	require.Equal(t, "QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5M", id)
+
+	_, err = MatchNode("QmYyQSo1", nodes)
+	var ambiguous *bacerrors.AmbiguousReference
+	require.ErrorAs(t, err, &ambiguous)
+	require.Len(t, ambiguous.GetCandidates(), 2)
+
+	_, err = MatchNode("Qm", nodes)
+	require.ErrorAs(t, err, &ErrNodeNotFound{})
+}
@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -20,20 +21,35 @@ type nodeInfoWrapper struct {
 
 type NodeInfoStoreParams struct {
 	TTL time.Duration
+	// MaxClockSkew is how far the clock of a node can be from the clock of this node before a warning is added to its
+	// info. The clocks are not compared if zero.
+	MaxClockSkew time.Duration
+	// MaxDeliveryDelay is how long info can take to be gossiped to this node, such as the interval at which it is
+	// published. Info that was published up to that long before it was received is not taken as a clock that is behind.
+	MaxDeliveryDelay time.Duration
+	// LoadWindow is how long the load reported by nodes is kept in their load history. Only the latest load is kept if
+	// zero.
+	LoadWindow time.Duration
 }
 
 type NodeInfoStore struct {
-	ttl             time.Duration
-	nodeInfoMap     map[peer.ID]nodeInfoWrapper
-	engineNodeIDMap map[model.Engine]map[peer.ID]struct{}
-	mu              sync.RWMutex
+	ttl              time.Duration
+	maxClockSkew     time.Duration
+	maxDeliveryDelay time.Duration
+	loadWindow       time.Duration
+	nodeInfoMap      map[peer.ID]nodeInfoWrapper
+	engineNodeIDMap  map[model.Engine]map[peer.ID]struct{}
+	mu               sync.RWMutex
 }
 
 func NewNodeInfoStore(params NodeInfoStoreParams) *NodeInfoStore {
 	res := &NodeInfoStore{
-		ttl:             params.TTL,
-		nodeInfoMap:     make(map[peer.ID]nodeInfoWrapper),
-		engineNodeIDMap: make(map[model.Engine]map[peer.ID]struct{}),
+		ttl:              params.TTL,
+		maxClockSkew:     params.MaxClockSkew,
+		maxDeliveryDelay: params.MaxDeliveryDelay,
+		loadWindow:       params.LoadWindow,
+		nodeInfoMap:      make(map[peer.ID]nodeInfoWrapper),
+		engineNodeIDMap:  make(map[model.Engine]map[peer.ID]struct{}),
 	}
 	res.mu.EnableTracerWithOpts(sync.Opts{
 		Threshold: 10 * time.Millisecond,
@@ -43,6 +59,7 @@ func NewNodeInfoStore(params NodeInfoStoreParams) *NodeInfoStore {
 }
 
 func (r *NodeInfoStore) Add(ctx context.Context, nodeInfo model.NodeInfo) error {
+	receivedAt := time.Now()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -58,6 +75,9 @@ func (r *NodeInfoStore) Add(ctx context.Context, nodeInfo model.NodeInfo) error
 		log.Ctx(ctx).Debug().Msgf("Adding new node %s to in-memory nodeInfo store", nodeInfo.PeerInfo.ID)
 	}
 
+	r.checkClockSkew(ctx, &nodeInfo, existingNodeInfo.NodeInfo, receivedAt)
+	r.recordLoad(&nodeInfo, existingNodeInfo.NodeInfo, receivedAt)
+
 	// TODO: use data structure that maintains nodes in descending order based on available capacity.
 	if nodeInfo.ComputeNodeInfo != nil {
 		for _, engine := range nodeInfo.ComputeNodeInfo.ExecutionEngines {
@@ -78,6 +98,74 @@ func (r *NodeInfoStore) Add(ctx context.Context, nodeInfo model.NodeInfo) error
 	return nil
 }
 
+// checkClockSkew records how far the clock of the node that published the info is from the clock of this node, and
+// adds a warning to the info if it is further than the configured tolerance. The skew is only logged when the node
+// goes out of tolerance, to not log it every time the node publishes its info.
+//
+// The info is received some time after it was published, so a timestamp ahead of the time it was received is ahead
+// by at least the skew, while a timestamp behind it is behind by the skew plus the time the info took to be
+// delivered. Timestamps behind by up to the delivery delay are therefore not taken as skew, and timestamps behind by
+// more are only counted beyond it.
+func (r *NodeInfoStore) checkClockSkew(ctx context.Context, nodeInfo *model.NodeInfo, previous model.NodeInfo, receivedAt time.Time) {
+	// skew and warnings are set by the receiving node, and are never trusted from the node itself
+	nodeInfo.ClockSkew = 0
+	nodeInfo.Warnings = nil
+	if r.maxClockSkew == 0 || nodeInfo.Timestamp.IsZero() {
+		return
+	}
+
+	nodeInfo.ClockSkew = r.estimateClockSkew(nodeInfo.Timestamp.Sub(receivedAt))
+	if !r.exceedsClockSkew(nodeInfo.ClockSkew) {
+		return
+	}
+	nodeInfo.Warnings = append(nodeInfo.Warnings, fmt.Sprintf(
+		"clock is off by %s from the node that received this info, more than the tolerated %s",
+		nodeInfo.ClockSkew.Round(time.Millisecond), r.maxClockSkew))
+	if !r.exceedsClockSkew(previous.ClockSkew) {
+		log.Ctx(ctx).Warn().Msgf("clock of node %s is off by %s from the clock of this node, more than the tolerated %s",
+			nodeInfo.PeerInfo.ID, nodeInfo.ClockSkew.Round(time.Millisecond), r.maxClockSkew)
+	}
+}
+
+// recordLoad adds the load reported in the info to the load history of the node, and forgets the loads that are older
+// than the load window. Loads are timed by when they were received, so that they can be compared across nodes whose
+// clocks are out of sync.
+func (r *NodeInfoStore) recordLoad(nodeInfo *model.NodeInfo, previous model.NodeInfo, receivedAt time.Time) {
+	// the history is kept by the receiving node, and is never trusted from the node itself
+	history := make([]model.NodeLoad, 0, len(previous.LoadHistory)+1)
+	for _, load := range previous.LoadHistory {
+		if receivedAt.Sub(load.Time) < r.loadWindow {
+			history = append(history, load)
+		}
+	}
+	if nodeInfo.ComputeNodeInfo != nil && nodeInfo.ComputeNodeInfo.Load != nil {
+		load := *nodeInfo.ComputeNodeInfo.Load
+		load.Time = receivedAt
+		history = append(history, load)
+	}
+	nodeInfo.LoadHistory = nil
+	if len(history) > 0 {
+		nodeInfo.LoadHistory = history
+	}
+}
+
+// estimateClockSkew returns the smallest skew the difference between the timestamp of info and the time it was
+// received can be explained by, given the delivery delay.
+func (r *NodeInfoStore) estimateClockSkew(delta time.Duration) time.Duration {
+	switch {
+	case delta >= 0:
+		return delta
+	case delta >= -r.maxDeliveryDelay:
+		return 0
+	default:
+		return delta + r.maxDeliveryDelay
+	}
+}
+
+func (r *NodeInfoStore) exceedsClockSkew(skew time.Duration) bool {
+	return skew > r.maxClockSkew || skew < -r.maxClockSkew
+}
+
 func (r *NodeInfoStore) Get(ctx context.Context, peerID peer.ID) (model.NodeInfo, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
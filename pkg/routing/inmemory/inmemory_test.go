@@ -161,6 +161,91 @@ func (s *InMemoryNodeInfoStoreSuite) Test_Eviction() {
 	s.IsType(requester.ErrNodeNotFound{}, err)
 }
 
+func (s *InMemoryNodeInfoStoreSuite) Test_ClockSkew() {
+	s.store = NewNodeInfoStore(NodeInfoStoreParams{
+		TTL:              1 * time.Hour,
+		MaxClockSkew:     5 * time.Second,
+		MaxDeliveryDelay: 10 * time.Second,
+	})
+	ctx := context.Background()
+
+	inSync := generateNodeInfo("node1", model.EngineDocker)
+	inSync.Timestamp = time.Now()
+	ahead := generateNodeInfo("node2", model.EngineDocker)
+	ahead.Timestamp = time.Now().Add(time.Minute)
+	behind := generateNodeInfo("node3", model.EngineDocker)
+	behind.Timestamp = time.Now().Add(-time.Minute)
+	behind.Warnings = []string{"warnings are set by the receiving node"}
+	// info delivered late is not taken as a clock that is behind
+	delayed := generateNodeInfo("node4", model.EngineDocker)
+	delayed.Timestamp = time.Now().Add(-8 * time.Second)
+	s.NoError(s.store.Add(ctx, inSync))
+	s.NoError(s.store.Add(ctx, delayed))
+	s.NoError(s.store.Add(ctx, ahead))
+	s.NoError(s.store.Add(ctx, behind))
+
+	res, err := s.store.Get(ctx, inSync.PeerInfo.ID)
+	s.NoError(err)
+	s.Less(res.ClockSkew.Abs(), 5*time.Second)
+	s.Empty(res.Warnings)
+
+	res, err = s.store.Get(ctx, ahead.PeerInfo.ID)
+	s.NoError(err)
+	s.Greater(res.ClockSkew, 55*time.Second)
+	s.Len(res.Warnings, 1)
+
+	res, err = s.store.Get(ctx, delayed.PeerInfo.ID)
+	s.NoError(err)
+	s.Zero(res.ClockSkew)
+	s.Empty(res.Warnings)
+
+	// only the part of the delay beyond the delivery delay counts
+	res, err = s.store.Get(ctx, behind.PeerInfo.ID)
+	s.NoError(err)
+	s.Less(res.ClockSkew, -45*time.Second)
+	s.Greater(res.ClockSkew, -55*time.Second)
+	s.Len(res.Warnings, 1)
+	s.Contains(res.Warnings[0], "clock is off by")
+}
+
+func (s *InMemoryNodeInfoStoreSuite) Test_LoadHistory() {
+	s.store = NewNodeInfoStore(NodeInfoStoreParams{
+		TTL:        1 * time.Hour,
+		LoadWindow: 100 * time.Millisecond,
+	})
+	ctx := context.Background()
+	withLoad := func(cpu float64) model.NodeInfo {
+		nodeInfo := generateNodeInfo("node1", model.EngineDocker)
+		nodeInfo.ComputeNodeInfo.Load = &model.NodeLoad{CPUUtilization: cpu}
+		// the history is set by the receiving node
+		nodeInfo.LoadHistory = []model.NodeLoad{{CPUUtilization: 1}}
+		return nodeInfo
+	}
+	cpuHistory := func() []float64 {
+		res, err := s.store.Get(ctx, peer.ID("node1"))
+		s.NoError(err)
+		var cpu []float64
+		for _, load := range res.LoadHistory {
+			s.False(load.Time.IsZero())
+			cpu = append(cpu, load.CPUUtilization)
+		}
+		return cpu
+	}
+
+	s.NoError(s.store.Add(ctx, withLoad(0.2)))
+	s.NoError(s.store.Add(ctx, withLoad(0.6)))
+	s.Equal([]float64{0.2, 0.6}, cpuHistory())
+
+	// nodes that don't report their load keep the history they have
+	s.NoError(s.store.Add(ctx, generateNodeInfo("node1", model.EngineDocker)))
+	s.Equal([]float64{0.2, 0.6}, cpuHistory())
+
+	// loads older than the window are forgotten
+	time.Sleep(150 * time.Millisecond)
+	s.NoError(s.store.Add(ctx, withLoad(0.4)))
+	s.Equal([]float64{0.4}, cpuHistory())
+}
+
 func generateNodeInfo(id string, engines ...model.Engine) model.NodeInfo {
 	return model.NodeInfo{
 		PeerInfo: peer.AddrInfo{
@@ -2,6 +2,7 @@ package routing
 
 import (
 	"context"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -47,7 +48,8 @@ func (n *NodeInfoProvider) GetNodeInfo(ctx context.Context) model.NodeInfo {
 			ID:    n.h.ID(),
 			Addrs: n.identityService.OwnObservedAddrs(),
 		},
-		Labels: n.labels,
+		Labels:    n.labels,
+		Timestamp: time.Now().UTC(),
 	}
 	if n.computeInfoProvider != nil {
 		info := n.computeInfoProvider.GetComputeInfo(ctx)
@@ -107,6 +107,26 @@ func (e *RequestHandler) ExecutionLogs(
 	return e.computeProxy.ExecutionLogs(ctx, request)
 }
 
+func (e *RequestHandler) ReserveCapacity(
+	ctx context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	return e.computeProxy.ReserveCapacity(ctx, request)
+}
+
+func (e *RequestHandler) ReleaseCapacity(
+	ctx context.Context, request compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	return e.computeProxy.ReleaseCapacity(ctx, request)
+}
+
+func (e *RequestHandler) ExtendTimeout(
+	ctx context.Context, request compute.ExtendTimeoutRequest) (compute.ExtendTimeoutResponse, error) {
+	return e.computeProxy.ExtendTimeout(ctx, request)
+}
+
+func (e *RequestHandler) AdminNode(
+	ctx context.Context, request compute.AdminNodeRequest) (compute.AdminNodeResponse, error) {
+	return e.computeProxy.AdminNode(ctx, request)
+}
+
 func (e *RequestHandler) OnBidComplete(ctx context.Context, result compute.BidResult) {
 	e.executionStore[result.ExecutionMetadata.ExecutionID] = result.ExecutionMetadata
 	if result.Accepted {
@@ -2,7 +2,11 @@ package ipfs
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -12,6 +16,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/rs/zerolog/log"
+	"go.uber.org/multierr"
 )
 
 // a storage driver runs the downloads content
@@ -20,11 +25,17 @@ import (
 // a job to run - it will remove the folder/file once complete
 
 type StorageProvider struct {
-	localDir   string
-	ipfsClient ipfs.Client
+	localDir string
+	backends ipfs.Backends
 }
 
 func NewStorage(cm *system.CleanupManager, cl ipfs.Client) (*StorageProvider, error) {
+	return NewStorageWithBackends(cm, ipfs.Backends{ipfs.NewDefaultBackend(cl)})
+}
+
+// NewStorageWithBackends returns a storage provider that fetches inputs from the fetch backends and uploads to the
+// publish backends, failing over between them in order.
+func NewStorageWithBackends(cm *system.CleanupManager, backends ipfs.Backends) (*StorageProvider, error) {
 	// TODO: consolidate the various config inputs into one package otherwise they are scattered across the codebase
 	dir, err := os.MkdirTemp(config.GetStoragePath(), "bacalhau-ipfs")
 	if err != nil {
@@ -39,67 +50,159 @@ func NewStorage(cm *system.CleanupManager, cl ipfs.Client) (*StorageProvider, er
 	})
 
 	storageHandler := &StorageProvider{
-		ipfsClient: cl,
-		localDir:   dir,
+		backends: backends,
+		localDir: dir,
 	}
 
-	log.Trace().Msgf("IPFS API Copy driver created with address: %s", cl.APIAddress())
+	for _, backend := range backends {
+		log.Trace().Msgf("IPFS API Copy driver created with backend %s at address: %s", backend.Name, backend.Client.APIAddress())
+	}
 	return storageHandler, nil
 }
 
 func (s *StorageProvider) IsInstalled(ctx context.Context) (bool, error) {
-	_, err := s.ipfsClient.ID(ctx)
+	_, err := s.backends.Fetchers().Try(func(backend ipfs.Backend) error {
+		_, err := backend.Client.ID(ctx)
+		return err
+	})
 	return err == nil, err
 }
 
 func (s *StorageProvider) HasStorageLocally(ctx context.Context, volume model.StorageSpec) (bool, error) {
-	return s.ipfsClient.HasCID(ctx, volume.CID)
+	var errs error
+	for _, backend := range s.backends.Fetchers() {
+		found, err := backend.Client.HasCID(ctx, volume.CID)
+		if found {
+			return true, nil
+		}
+		errs = multierr.Append(errs, err)
+	}
+	return false, errs
 }
 
+// GetVolumeSize returns the size of the data of the volume, which is only the size of the selected part of the CID if
+// the volume has a sub-path.
 func (s *StorageProvider) GetVolumeSize(ctx context.Context, volume model.StorageSpec) (uint64, error) {
+	ipfsPath, err := volume.IPFSPath()
+	if err != nil {
+		return 0, err
+	}
+
 	// we wrap this in a timeout because if the CID is not present on the network this seems to hang
 	ctx, cancel := context.WithTimeout(ctx, config.GetVolumeSizeRequestTimeout(ctx))
 	defer cancel()
 
-	return s.ipfsClient.GetCidSize(ctx, volume.CID)
+	var size uint64
+	_, err = s.backends.Fetchers().Try(func(backend ipfs.Backend) (err error) {
+		size, err = backend.Client.GetCidSize(ctx, ipfsPath)
+		return err
+	})
+	return size, err
 }
 
-func (s *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error) {
-	stat, err := s.ipfsClient.Stat(ctx, storageSpec.CID)
-	if err != nil {
-		return storage.StorageVolume{}, fmt.Errorf("failed to stat %s: %w", storageSpec.CID, err)
+// ProbeStorage implements storage.Prober. Sizing a CID resolves it from the network, which fails once the context
+// is done if no provider of the CID can be found.
+func (s *StorageProvider) ProbeStorage(ctx context.Context, volume model.StorageSpec) (uint64, error) {
+	size, err := s.GetVolumeSize(ctx, volume)
+	if err != nil && (errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil) {
+		return 0, fmt.Errorf("%w: no provider of CID %s could be found: %s", storage.ErrInputNotFound, volume.CID, err)
 	}
+	return size, err
+}
 
-	if stat.Type != ipfs.IPLDFile && stat.Type != ipfs.IPLDDirectory {
-		return storage.StorageVolume{}, fmt.Errorf("unknown ipld file type for %s: %v", storageSpec.CID, stat.Type)
-	}
+// ResolveName implements storage.NameResolver, resolving the name through the first IPFS backend that can.
+func (s *StorageProvider) ResolveName(ctx context.Context, name string) (string, error) {
+	var cid string
+	_, err := s.backends.Fetchers().Try(func(backend ipfs.Backend) (err error) {
+		cid, err = backend.Client.ResolveName(ctx, name)
+		return err
+	})
+	return cid, err
+}
 
+func (s *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error) {
 	var volume storage.StorageVolume
-	volume, err = s.getFileFromIPFS(ctx, storageSpec)
+	backend, err := s.backends.Fetchers().Try(func(backend ipfs.Backend) (err error) {
+		volume, err = s.getFileFromIPFS(ctx, backend.Client, storageSpec)
+		return err
+	})
 	if err != nil {
 		return storage.StorageVolume{}, fmt.Errorf("failed to copy %s to volume: %w", storageSpec.Path, err)
 	}
 
+	log.Ctx(ctx).Info().Msgf("Fetched %s from IPFS backend %s", storageSpec.CID, backend.Name)
 	return volume, nil
 }
 
 func (s *StorageProvider) CleanupStorage(_ context.Context, storageSpec model.StorageSpec, _ storage.StorageVolume) error {
-	return os.RemoveAll(filepath.Join(s.localDir, storageSpec.CID))
+	return os.RemoveAll(s.volumeDir(storageSpec))
+}
+
+// volumeDir returns where the data of the volume is fetched to. Volumes selecting different parts of the same CID are
+// fetched to different directories, so that a part is never mistaken for the whole CID.
+func (s *StorageProvider) volumeDir(storageSpec model.StorageSpec) string {
+	if storageSpec.SubPath == "" {
+		return filepath.Join(s.localDir, storageSpec.CID)
+	}
+	hash := sha256.Sum256([]byte(storageSpec.SubPath))
+	return filepath.Join(s.localDir, storageSpec.CID+"-"+hex.EncodeToString(hash[:8]))
 }
 
 func (s *StorageProvider) Upload(ctx context.Context, localPath string) (model.StorageSpec, error) {
-	cid, err := s.ipfsClient.Put(ctx, localPath)
+	var cid string
+	backend, err := s.backends.Publishers().Try(func(backend ipfs.Backend) (err error) {
+		cid, err = backend.Client.Put(ctx, localPath)
+		return err
+	})
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	log.Ctx(ctx).Info().Msgf("Uploaded %s to IPFS backend %s", localPath, backend.Name)
+	return model.StorageSpec{
+		StorageSource: model.StorageSourceIPFS,
+		CID:           cid,
+	}, nil
+}
+
+// UploadStream adds the stream to the first IPFS backend that results can be published to. Unlike Upload, it does
+// not fail over to other backends as the stream cannot be replayed.
+func (s *StorageProvider) UploadStream(ctx context.Context, r io.Reader) (model.StorageSpec, error) {
+	backends := s.backends.Publishers()
+	if len(backends) == 0 {
+		return model.StorageSpec{}, errors.New("no IPFS backend is available to upload to")
+	}
+	cid, err := backends[0].Client.PutReader(ctx, r)
 	if err != nil {
 		return model.StorageSpec{}, err
 	}
+	log.Ctx(ctx).Info().Msgf("Uploaded stream to IPFS backend %s", backends[0].Name)
 	return model.StorageSpec{
 		StorageSource: model.StorageSourceIPFS,
 		CID:           cid,
 	}, nil
 }
 
-func (s *StorageProvider) getFileFromIPFS(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error) {
-	outputPath := filepath.Join(s.localDir, storageSpec.CID)
+func (s *StorageProvider) getFileFromIPFS(
+	ctx context.Context,
+	client ipfs.Client,
+	storageSpec model.StorageSpec,
+) (storage.StorageVolume, error) {
+	// only the blocks along the sub-path and below it are fetched, rather than the whole DAG of the CID
+	ipfsPath, err := storageSpec.IPFSPath()
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+
+	stat, err := client.Stat(ctx, ipfsPath)
+	if err != nil {
+		return storage.StorageVolume{}, fmt.Errorf("failed to stat %s: %w", ipfsPath, err)
+	}
+
+	if stat.Type != ipfs.IPLDFile && stat.Type != ipfs.IPLDDirectory {
+		return storage.StorageVolume{}, fmt.Errorf("unknown ipld file type for %s: %v", ipfsPath, stat.Type)
+	}
+
+	outputPath := s.volumeDir(storageSpec)
 
 	// If the output path already exists, we already have the data, as
 	// ipfsClient.Get(...) renames the result path atomically after it has
@@ -109,9 +212,10 @@ func (s *StorageProvider) getFileFromIPFS(ctx context.Context, storageSpec model
 		return storage.StorageVolume{}, err
 	}
 	if !ok {
-		err = s.ipfsClient.Get(ctx, storageSpec.CID, outputPath)
+		err = client.Get(ctx, ipfsPath, outputPath)
 		if err != nil {
-			return storage.StorageVolume{}, err
+			// don't leave a partial download behind for the next backend to mistake for the data
+			return storage.StorageVolume{}, multierr.Append(err, os.RemoveAll(outputPath))
 		}
 	}
 
@@ -126,3 +230,5 @@ func (s *StorageProvider) getFileFromIPFS(ctx context.Context, storageSpec model
 
 // Compile time interface check:
 var _ storage.Storage = (*StorageProvider)(nil)
+var _ storage.StreamUploader = (*StorageProvider)(nil)
+var _ storage.NameResolver = (*StorageProvider)(nil)
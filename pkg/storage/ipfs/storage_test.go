@@ -5,6 +5,9 @@ package ipfs
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,7 +47,7 @@ func TestGetVolumeSize(t *testing.T) {
 		t.Run(testString, func(t *testing.T) {
 			storage := getIpfsStorage(t)
 
-			cid, err := ipfs.AddTextToNodes(ctx, []byte(testString), storage.ipfsClient)
+			cid, err := ipfs.AddTextToNodes(ctx, []byte(testString), storage.backends[0].Client)
 			require.NoError(t, err)
 
 			result, err := storage.GetVolumeSize(ctx, model.StorageSpec{
@@ -69,7 +72,7 @@ func TestPrepareStorageRespectsTimeouts(t *testing.T) {
 			defer cancel()
 			storage := getIpfsStorage(t)
 
-			cid, err := ipfs.AddTextToNodes(ctx, []byte("testString"), storage.ipfsClient)
+			cid, err := ipfs.AddTextToNodes(ctx, []byte("testString"), storage.backends[0].Client)
 			require.NoError(t, err)
 
 			_, err = storage.PrepareStorage(ctx, model.StorageSpec{
@@ -91,7 +94,7 @@ func TestGetVolumeSizeRespectsTimeout(t *testing.T) {
 			ctx := context.Background()
 			storage := getIpfsStorage(t)
 
-			cid, err := ipfs.AddTextToNodes(ctx, []byte("testString"), storage.ipfsClient)
+			cid, err := ipfs.AddTextToNodes(ctx, []byte("testString"), storage.backends[0].Client)
 			require.NoError(t, err)
 
 			ctx = config.SetVolumeSizeRequestTimeout(ctx, testDuration)
@@ -104,3 +107,57 @@ func TestGetVolumeSizeRespectsTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadStream(t *testing.T) {
+	ctx := context.Background()
+	storage := getIpfsStorage(t)
+
+	data := "hello from an uploaded stream"
+	spec, err := storage.UploadStream(ctx, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, model.StorageSourceIPFS, spec.StorageSource)
+
+	size, err := storage.GetVolumeSize(ctx, spec)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(data))+IpfsMetadataSize, size)
+}
+
+func TestPrepareStorageSubPath(t *testing.T) {
+	ctx := context.Background()
+	storage := getIpfsStorage(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "selected", "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "selected", "nested", "file.txt"), []byte("selected"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte(strings.Repeat("other", 100)), 0644))
+	cid, err := storage.backends[0].Client.Put(ctx, dir)
+	require.NoError(t, err)
+
+	spec := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, SubPath: "selected", Path: "/inputs"}
+	volume, err := storage.PrepareStorage(ctx, spec)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(volume.Source, "nested", "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "selected", string(content))
+	require.NoFileExists(t, filepath.Join(volume.Source, "other.txt"))
+
+	// the whole CID is fetched to a directory of its own
+	whole, err := storage.PrepareStorage(ctx, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, Path: "/inputs"})
+	require.NoError(t, err)
+	require.NotEqual(t, volume.Source, whole.Source)
+	require.FileExists(t, filepath.Join(whole.Source, "other.txt"))
+
+	// the size is only the size of the selected part
+	selectedSize, err := storage.GetVolumeSize(ctx, spec)
+	require.NoError(t, err)
+	wholeSize, err := storage.GetVolumeSize(ctx, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid})
+	require.NoError(t, err)
+	require.Less(t, selectedSize, wholeSize)
+
+	require.NoError(t, storage.CleanupStorage(ctx, spec, volume))
+	require.NoDirExists(t, volume.Source)
+	require.DirExists(t, whole.Source)
+
+	_, err = storage.PrepareStorage(ctx, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid, SubPath: "../escape"})
+	require.Error(t, err)
+}
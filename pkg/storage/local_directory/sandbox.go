@@ -0,0 +1,133 @@
+package localdirectory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// SandboxViolation is returned when a job asks for a local path that it is not allowed to mount.
+type SandboxViolation struct {
+	SourcePath string
+	Reason     string
+}
+
+func (v *SandboxViolation) Error() string {
+	return fmt.Sprintf("local path %s is not allowed: %s", v.SourcePath, v.Reason)
+}
+
+// Sandbox decides which local paths can be mounted into jobs. Paths are canonicalized and their symlinks resolved
+// before being matched against the allowed paths, so that a job can't escape the allowed paths through `..` or a
+// symlink pointing outside of them.
+type Sandbox struct {
+	allowedPaths []AllowedPath
+}
+
+func NewSandbox(allowedPaths []AllowedPath) *Sandbox {
+	canonical := make([]AllowedPath, len(allowedPaths))
+	for i, allowedPath := range allowedPaths {
+		canonical[i] = allowedPath
+		canonical[i].Path = canonicalPattern(allowedPath.Path)
+	}
+	return &Sandbox{allowedPaths: canonical}
+}
+
+// Resolve returns the canonical source path of the spec, and the allowed path it matches regardless of namespace.
+// The returned error is a *SandboxViolation if no allowed path matches, or wraps os.ErrNotExist if the source
+// path doesn't exist.
+func (s *Sandbox) Resolve(spec model.StorageSpec) (string, AllowedPath, error) {
+	return s.resolve(spec, func(AllowedPath) bool { return true })
+}
+
+// Authorize checks that a job of the given namespace can mount the spec, logging a security event if not. It returns
+// the canonical source path of the spec, and the allowed path it matches.
+func (s *Sandbox) Authorize(ctx context.Context, spec model.StorageSpec, namespace string) (string, AllowedPath, error) {
+	resolved, allowedPath, err := s.resolve(spec, func(allowedPath AllowedPath) bool {
+		return allowedPath.Namespace == "" || allowedPath.Namespace == namespace
+	})
+	LogViolation(ctx, err, namespace)
+	return resolved, allowedPath, err
+}
+
+type namespaceContextKey struct{}
+
+// ContextWithNamespace returns a context for preparing the local paths mounted by a job of the given namespace, which
+// is the client ID of the job. Paths prepared without a namespace can only be the ones allowed for any job.
+func ContextWithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceContextKey{}, namespace)
+}
+
+func namespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceContextKey{}).(string)
+	return namespace
+}
+
+func (s *Sandbox) resolve(spec model.StorageSpec, inNamespace func(AllowedPath) bool) (string, AllowedPath, error) {
+	if !filepath.IsAbs(spec.SourcePath) {
+		return "", AllowedPath{}, &SandboxViolation{SourcePath: spec.SourcePath, Reason: "path must be absolute"}
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(spec.SourcePath))
+	if err != nil {
+		return "", AllowedPath{}, err
+	}
+
+	reason := "path is not in the allowed paths"
+	for _, allowedPath := range s.allowedPaths {
+		match, err := doublestar.PathMatch(allowedPath.Path, resolved)
+		if err != nil || !match {
+			continue
+		}
+		if spec.ReadWrite && !allowedPath.ReadWrite {
+			reason = "path is only allowed to be mounted read-only"
+			continue
+		}
+		if !inNamespace(allowedPath) {
+			reason = "path is not allowed for the job's namespace"
+			continue
+		}
+		return resolved, allowedPath, nil
+	}
+	return "", AllowedPath{}, &SandboxViolation{SourcePath: spec.SourcePath, Reason: reason}
+}
+
+// LogViolation logs a security event if err is a *SandboxViolation.
+func LogViolation(ctx context.Context, err error, namespace string) {
+	var violation *SandboxViolation
+	if errors.As(err, &violation) {
+		log.Ctx(ctx).Warn().
+			Str("security_event", "local_path_violation").
+			Str("source_path", violation.SourcePath).
+			Str("namespace", namespace).
+			Msg(violation.Reason)
+	}
+}
+
+// canonicalPattern cleans the pattern and resolves the symlinks of its literal prefix, so that it matches
+// resolved source paths.
+func canonicalPattern(pattern string) string {
+	if !filepath.IsAbs(pattern) {
+		return pattern
+	}
+	pattern = filepath.Clean(pattern)
+	base, rest := pattern, ""
+	if strings.ContainsAny(pattern, "*?[{\\") {
+		base, rest = doublestar.SplitPattern(filepath.ToSlash(pattern))
+		base = filepath.FromSlash(base)
+	}
+	resolved, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Msgf("failed to resolve allowed local path %s", pattern)
+		}
+		return pattern
+	}
+	return filepath.Join(resolved, rest)
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
-	"github.com/bmatcuk/doublestar/v4"
 	"github.com/rs/zerolog/log"
 )
 
@@ -17,11 +16,13 @@ type StorageProviderParams struct {
 }
 type StorageProvider struct {
 	allowedPaths []AllowedPath
+	sandbox      *Sandbox
 }
 
 func NewStorageProvider(params StorageProviderParams) (*StorageProvider, error) {
 	storageHandler := &StorageProvider{
 		allowedPaths: params.AllowedPaths,
+		sandbox:      NewSandbox(params.AllowedPaths),
 	}
 	log.Debug().Msgf("Local directory driver created with allowedPaths: %s", storageHandler.allowedPaths)
 
@@ -33,23 +34,16 @@ func (driver *StorageProvider) IsInstalled(context.Context) (bool, error) {
 }
 
 func (driver *StorageProvider) HasStorageLocally(_ context.Context, volume model.StorageSpec) (bool, error) {
-	if !driver.isInAllowedPaths(volume) {
-		return false, nil
-	}
-
-	if _, err := os.Stat(volume.SourcePath); errors.Is(err, os.ErrNotExist) {
-		return false, nil
-	}
-	return true, nil
+	_, _, err := driver.sandbox.Resolve(volume)
+	return err == nil, nil
 }
 
-func (driver *StorageProvider) GetVolumeSize(_ context.Context, volume model.StorageSpec) (uint64, error) {
-	if !driver.isInAllowedPaths(volume) {
-		return 0, errors.New("volume not in allowed paths")
-	}
-	// check if the volume exists
-	if _, err := os.Stat(volume.SourcePath); errors.Is(err, os.ErrNotExist) {
+func (driver *StorageProvider) GetVolumeSize(ctx context.Context, volume model.StorageSpec) (uint64, error) {
+	if _, _, err := driver.sandbox.Resolve(volume); errors.Is(err, os.ErrNotExist) {
 		return 0, errors.New("volume does not exist")
+	} else if err != nil {
+		LogViolation(ctx, err, "")
+		return 0, err
 	}
 	// We only query the volume size to make sure we have enough disk space to pull mount the volume locally from a remote location.
 	// In this case the data is already local and attempting to query the size would be a waste of time.
@@ -57,16 +51,19 @@ func (driver *StorageProvider) GetVolumeSize(_ context.Context, volume model.Sto
 }
 
 func (driver *StorageProvider) PrepareStorage(
-	_ context.Context,
+	ctx context.Context,
 	storageSpec model.StorageSpec,
 ) (storage.StorageVolume, error) {
-	if !driver.isInAllowedPaths(storageSpec) {
-		return storage.StorageVolume{}, errors.New("volume not in allowed paths")
+	// the namespace is checked again when the job runs, as the allowed paths may have changed since it was bid on
+	source, allowedPath, err := driver.sandbox.Authorize(ctx, storageSpec, namespaceFromContext(ctx))
+	if err != nil {
+		return storage.StorageVolume{}, err
 	}
+	// bind the resolved path, so that the mount can't be redirected by swapping a symlink after the check
 	return storage.StorageVolume{
 		Type:     storage.StorageVolumeConnectorBind,
-		ReadOnly: !storageSpec.ReadWrite,
-		Source:   storageSpec.SourcePath,
+		ReadOnly: !(storageSpec.ReadWrite && allowedPath.ReadWrite),
+		Source:   source,
 		Target:   storageSpec.Path,
 	}, nil
 }
@@ -79,18 +76,5 @@ func (driver *StorageProvider) Upload(context.Context, string) (model.StorageSpe
 	return model.StorageSpec{}, fmt.Errorf("not implemented")
 }
 
-func (driver *StorageProvider) isInAllowedPaths(storageSpec model.StorageSpec) bool {
-	for _, allowedPath := range driver.allowedPaths {
-		if storageSpec.ReadWrite && !allowedPath.ReadWrite {
-			continue
-		}
-		match, err := doublestar.PathMatch(allowedPath.Path, storageSpec.SourcePath)
-		if match && err == nil {
-			return true
-		}
-	}
-	return false
-}
-
 // Compile time interface check:
 var _ storage.Storage = (*StorageProvider)(nil)
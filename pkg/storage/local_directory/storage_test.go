@@ -247,6 +247,41 @@ func (s *LocalDirectorySuite) TestPrepareStorage() {
 
 }
 
+func (s *LocalDirectorySuite) TestPrepareStorageInNamespace() {
+	tmpDir := s.T().TempDir()
+	storageProvider, err := NewStorageProvider(StorageProviderParams{AllowedPaths: ParseAllowPaths([]string{tmpDir + ":ro@team"})})
+	s.Require().NoError(err)
+
+	_, err = storageProvider.PrepareStorage(context.Background(), s.prepareStorageSpec(tmpDir))
+	s.Require().Error(err, "jobs outside of the namespace can't mount the path")
+	_, err = storageProvider.PrepareStorage(ContextWithNamespace(context.Background(), "other"), s.prepareStorageSpec(tmpDir))
+	s.Require().Error(err)
+
+	volume, err := storageProvider.PrepareStorage(ContextWithNamespace(context.Background(), "team"), s.prepareStorageSpec(tmpDir))
+	s.Require().NoError(err)
+	s.Require().Equal(tmpDir, volume.Source)
+}
+
+func (s *LocalDirectorySuite) TestParseAllowPath() {
+	for _, tc := range []struct {
+		path     string
+		expected AllowedPath
+	}{
+		{path: "/data", expected: AllowedPath{Path: "/data"}},
+		{path: "/data:rw", expected: AllowedPath{Path: "/data", ReadWrite: true}},
+		{path: "/data:ro@team", expected: AllowedPath{Path: "/data", Namespace: "team"}},
+		{path: "/data:rw@team", expected: AllowedPath{Path: "/data", ReadWrite: true, Namespace: "team"}},
+		{path: "/data/user@host", expected: AllowedPath{Path: "/data/user@host"}},
+		{path: "/data/user@host:rw", expected: AllowedPath{Path: "/data/user@host", ReadWrite: true}},
+		{path: "/data/user@host:ro@team", expected: AllowedPath{Path: "/data/user@host", Namespace: "team"}},
+		{path: "/data:rw@team/sub", expected: AllowedPath{Path: "/data:rw@team/sub"}},
+	} {
+		s.Run(tc.path, func() {
+			s.Require().Equal(tc.expected, ParseAllowPath(tc.path))
+		})
+	}
+}
+
 func (s *LocalDirectorySuite) prepareStorageSpec(sourcePath string) model.StorageSpec {
 	readWrite := false
 	if strings.HasSuffix(sourcePath, ":rw") {
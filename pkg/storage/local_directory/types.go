@@ -5,6 +5,8 @@ import "strings"
 type AllowedPath struct {
 	Path      string
 	ReadWrite bool
+	// Namespace is the client ID of the jobs allowed to mount the path. Any job can mount the path if empty.
+	Namespace string
 }
 
 // string representation of the object.
@@ -13,24 +15,37 @@ func (obj AllowedPath) String() string {
 	if obj.ReadWrite {
 		suffix = "rw"
 	}
+	if obj.Namespace != "" {
+		suffix += "@" + obj.Namespace
+	}
 	return obj.Path + ":" + suffix
 }
 
+// ParseAllowPath parses an allowed path in the PATH[:ro|:rw[@NAMESPACE]] format. The namespace can only follow an
+// access mode, so that paths containing '@' are not mistaken for namespaced paths.
 func ParseAllowPath(path string) AllowedPath {
+	var namespace string
+	if i := strings.LastIndex(path, "@"); i >= 0 && !strings.Contains(path[i:], "/") &&
+		(strings.HasSuffix(path[:i], ":ro") || strings.HasSuffix(path[:i], ":rw")) {
+		path, namespace = path[:i], path[i+1:]
+	}
 	if strings.HasSuffix(path, ":rw") {
 		return AllowedPath{
 			Path:      strings.TrimSuffix(path, ":rw"),
 			ReadWrite: true,
+			Namespace: namespace,
 		}
 	} else if strings.HasSuffix(path, ":ro") {
 		return AllowedPath{
 			Path:      strings.TrimSuffix(path, ":ro"),
 			ReadWrite: false,
+			Namespace: namespace,
 		}
 	} else {
 		return AllowedPath{
 			Path:      path,
 			ReadWrite: false,
+			Namespace: namespace,
 		}
 	}
 }
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+)
+
+// InputManifestPath is where executors mount the manifest of the inputs of an execution, so that the code of the job
+// can find exactly which data it was given without walking the filesystem.
+const InputManifestPath = "/bacalhau/inputs.json"
+
+// MaxInputManifestFiles is how many files of the inputs of an execution are listed in its manifest at most.
+const MaxInputManifestFiles = 10000
+
+// InputManifest lists the inputs of an execution, ordered by the path they are mounted at.
+type InputManifest struct {
+	Inputs []InputManifestEntry `json:"inputs"`
+}
+
+// InputManifestEntry describes an input volume: where its data came from, where it is mounted and the files in it.
+type InputManifestEntry struct {
+	Name          string               `json:"name,omitempty"`
+	StorageSource string               `json:"storageSource"`
+	CID           string               `json:"cid,omitempty"`
+	URL           string               `json:"url,omitempty"`
+	Repo          string               `json:"repo,omitempty"`
+	S3            *model.S3StorageSpec `json:"s3,omitempty"`
+	SourcePath    string               `json:"sourcePath,omitempty"`
+	Path          string               `json:"path"`
+	Size          uint64               `json:"size"`
+	Files         []InputManifestFile  `json:"files"`
+	// Truncated is set when the volume has more files than listed, in which case the size is that of the files
+	// listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// InputManifestFile is a file of an input volume, at the path it has inside the execution.
+type InputManifestFile struct {
+	Path string `json:"path"`
+	Size uint64 `json:"size"`
+}
+
+// NewInputManifest describes the prepared input volumes of an execution. At most maxFiles files are listed across the
+// volumes, in the order of the paths the volumes are mounted at, so that inputs with very many files don't make every
+// execution walk all of them. Volumes that have more files than listed are marked as truncated. Zero is no limit.
+func NewInputManifest(volumes map[*model.StorageSpec]StorageVolume, maxFiles int) (InputManifest, error) {
+	type input struct {
+		entry  InputManifestEntry
+		source string
+	}
+	inputs := make([]input, 0, len(volumes))
+	for spec, volume := range volumes {
+		entry := InputManifestEntry{
+			Name:          spec.Name,
+			StorageSource: spec.StorageSource.String(),
+			CID:           spec.CID,
+			URL:           spec.URL,
+			Repo:          spec.Repo,
+			S3:            spec.S3,
+			SourcePath:    spec.SourcePath,
+			Path:          volume.Target,
+			Files:         []InputManifestFile{},
+		}
+		if spec.StorageSource == model.StorageSourceInline {
+			// the data of inline inputs is in their URL, and already listed in the files
+			entry.URL = ""
+		}
+		inputs = append(inputs, input{entry: entry, source: volume.Source})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].entry.Path < inputs[j].entry.Path })
+
+	manifest := InputManifest{Inputs: make([]InputManifestEntry, 0, len(inputs))}
+	listed := 0
+	for _, input := range inputs {
+		entry := input.entry
+		err := filepath.WalkDir(input.source, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if maxFiles > 0 && listed >= maxFiles {
+				entry.Truncated = true
+				return filepath.SkipAll
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(input.source, p)
+			if err != nil {
+				return err
+			}
+			entry.Files = append(entry.Files, InputManifestFile{
+				Path: path.Join(entry.Path, filepath.ToSlash(rel)),
+				Size: uint64(info.Size()),
+			})
+			entry.Size += uint64(info.Size())
+			listed++
+			return nil
+		})
+		if err != nil {
+			return InputManifest{}, err
+		}
+		manifest.Inputs = append(manifest.Inputs, entry)
+	}
+	return manifest, nil
+}
+
+// WriteInputManifest writes the manifest of the prepared input volumes of an execution to a temporary file, readable
+// by any user the execution runs as, and returns its path. The caller removes the file once the execution is done.
+func WriteInputManifest(volumes map[*model.StorageSpec]StorageVolume) (string, error) {
+	manifest, err := NewInputManifest(volumes, MaxInputManifestFiles)
+	if err != nil {
+		return "", err
+	}
+	data, err := model.JSONMarshalIndentWithMax(manifest, 2) //nolint:gomnd
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp("", "bacalhau-inputs-*.json")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), util.OS_ALL_R|util.OS_USER_W)
+	}
+	if err != nil {
+		os.Remove(file.Name()) //nolint:errcheck
+		return "", err
+	}
+	return file.Name(), nil
+}
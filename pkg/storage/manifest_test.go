@@ -0,0 +1,89 @@
+//go:build unit || !integration
+
+package storage_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shard", "nested"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shard", "a.csv"), []byte("12345"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shard", "nested", "b.csv"), []byte("123"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inline.txt"), []byte("hi"), os.ModePerm))
+
+	volumes := map[*model.StorageSpec]storage.StorageVolume{
+		{StorageSource: model.StorageSourceIPFS, CID: "QmShard", Path: "/inputs"}: {
+			Type:   storage.StorageVolumeConnectorBind,
+			Source: filepath.Join(dir, "shard"),
+			Target: "/inputs",
+		},
+		{StorageSource: model.StorageSourceInline, URL: "data:text/plain;base64,aGk=", Path: "/config"}: {
+			Type:   storage.StorageVolumeConnectorBind,
+			Source: filepath.Join(dir, "inline.txt"),
+			Target: "/config",
+		},
+	}
+
+	manifest, err := storage.NewInputManifest(volumes, 0)
+	require.NoError(t, err)
+	require.Equal(t, storage.InputManifest{Inputs: []storage.InputManifestEntry{
+		{
+			StorageSource: model.StorageSourceInline.String(),
+			Path:          "/config",
+			Size:          2,
+			Files:         []storage.InputManifestFile{{Path: "/config", Size: 2}},
+		},
+		{
+			StorageSource: model.StorageSourceIPFS.String(),
+			CID:           "QmShard",
+			Path:          "/inputs",
+			Size:          8,
+			Files: []storage.InputManifestFile{
+				{Path: "/inputs/a.csv", Size: 5},
+				{Path: "/inputs/nested/b.csv", Size: 3},
+			},
+		},
+	}}, manifest)
+
+	path, err := storage.WriteInputManifest(volumes)
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(path) })
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written storage.InputManifest
+	require.NoError(t, json.Unmarshal(data, &written))
+	require.Equal(t, manifest, written)
+}
+
+func TestInputManifestIsLimited(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), os.ModePerm))
+		for _, file := range []string{"1", "2"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name, file), []byte("1"), os.ModePerm))
+		}
+	}
+	volumes := map[*model.StorageSpec]storage.StorageVolume{
+		{StorageSource: model.StorageSourceIPFS, CID: "QmB"}: {Source: filepath.Join(dir, "b"), Target: "/b"},
+		{StorageSource: model.StorageSourceIPFS, CID: "QmA"}: {Source: filepath.Join(dir, "a"), Target: "/a"},
+	}
+
+	manifest, err := storage.NewInputManifest(volumes, 3)
+	require.NoError(t, err)
+	require.Len(t, manifest.Inputs, 2)
+	require.False(t, manifest.Inputs[0].Truncated)
+	require.Len(t, manifest.Inputs[0].Files, 2)
+	require.True(t, manifest.Inputs[1].Truncated, "the files are listed in the order of the mount paths")
+	require.Equal(t, []storage.InputManifestFile{{Path: "/b/1", Size: 1}}, manifest.Inputs[1].Files)
+	require.Equal(t, uint64(1), manifest.Inputs[1].Size)
+}
@@ -2,6 +2,7 @@ package noop
 
 import (
 	"context"
+	"io"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
@@ -13,6 +14,7 @@ type StorageHandlerGetVolumeSize func(ctx context.Context, volume model.StorageS
 type StorageHandlerPrepareStorage func(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error)
 type StorageHandlerCleanupStorage func(ctx context.Context, storageSpec model.StorageSpec, volume storage.StorageVolume) error
 type StorageHandlerUpload func(ctx context.Context, localPath string) (model.StorageSpec, error)
+type StorageHandlerUploadStream func(ctx context.Context, r io.Reader) (model.StorageSpec, error)
 type StorageHandlerExplode func(ctx context.Context, storageSpec model.StorageSpec) ([]model.StorageSpec, error)
 
 type StorageConfigExternalHooks struct {
@@ -22,6 +24,7 @@ type StorageConfigExternalHooks struct {
 	PrepareStorage    StorageHandlerPrepareStorage
 	CleanupStorage    StorageHandlerCleanupStorage
 	Upload            StorageHandlerUpload
+	UploadStream      StorageHandlerUploadStream
 	Explode           StorageHandlerExplode
 }
 
@@ -101,6 +104,21 @@ func (s *NoopStorage) Upload(ctx context.Context, localPath string) (model.Stora
 	}, nil
 }
 
+func (s *NoopStorage) UploadStream(ctx context.Context, r io.Reader) (model.StorageSpec, error) {
+	if s.Config.ExternalHooks.UploadStream != nil {
+		handler := s.Config.ExternalHooks.UploadStream
+		return handler(ctx, r)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return model.StorageSpec{}, err
+	}
+	return model.StorageSpec{
+		StorageSource: model.StorageSourceIPFS,
+		CID:           "test",
+		Path:          "/",
+	}, nil
+}
+
 func (s *NoopStorage) Explode(ctx context.Context, spec model.StorageSpec) ([]model.StorageSpec, error) {
 	if s.Config.ExternalHooks.Explode != nil {
 		handler := s.Config.ExternalHooks.Explode
@@ -120,3 +138,4 @@ func (s *NoopStorage) CleanupStorage(ctx context.Context, storageSpec model.Stor
 
 // Compile time interface check:
 var _ storage.Storage = (*NoopStorage)(nil)
+var _ storage.StreamUploader = (*NoopStorage)(nil)
@@ -0,0 +1,284 @@
+package prefetch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+)
+
+// DefaultTTL is how long prefetched inputs are kept for an execution that does not say otherwise, unless configured
+// otherwise.
+const DefaultTTL = 30 * time.Minute
+
+type PrefetcherParams struct {
+	// Storages is the provider used to fetch the inputs.
+	Storages storage.StorageProvider
+	// Budget is the maximum number of bytes that can be prefetched at any given time.
+	Budget uint64
+	// DefaultTTL is how long inputs are kept for executions prefetched without a TTL before they are cleaned up if
+	// not claimed. Defaults to DefaultTTL.
+	DefaultTTL time.Duration
+}
+
+// Prefetcher optimistically fetches the inputs of executions that a compute node has bid on, so that they
+// are already available locally by the time the bid is accepted. Inputs are only prefetched while they fit
+// in the budget of the prefetcher, and are cleaned up if the bid is rejected, the execution canceled, or they are
+// not claimed before their TTL expires.
+//
+// Prefetcher is itself a storage provider that should be handed to the executors, so that they pick up
+// prefetched volumes instead of fetching the same inputs again. Once picked up, a volume is owned by the
+// executor and no longer counts against the budget.
+type Prefetcher struct {
+	storages   storage.StorageProvider
+	budget     uint64
+	defaultTTL time.Duration
+
+	mu      sync.Mutex
+	used    uint64
+	fetches map[string][]*fetch    // by execution ID
+	expiry  map[string]*time.Timer // by execution ID
+}
+
+// fetch is a single input being prefetched for an execution.
+type fetch struct {
+	jobID   string
+	key     string
+	spec    model.StorageSpec
+	size    uint64
+	storage storage.Storage
+	cancel  context.CancelFunc
+	done    chan struct{}
+	volume  storage.StorageVolume
+	err     error
+}
+
+func NewPrefetcher(params PrefetcherParams) *Prefetcher {
+	p := &Prefetcher{
+		storages:   params.Storages,
+		budget:     params.Budget,
+		defaultTTL: params.DefaultTTL,
+		fetches:    make(map[string][]*fetch),
+		expiry:     make(map[string]*time.Timer),
+	}
+	if p.defaultTTL <= 0 {
+		p.defaultTTL = DefaultTTL
+	}
+	return p
+}
+
+// Prefetch starts fetching the inputs of the execution in the background. Inputs that can't be sized,
+// or that don't fit in the remaining budget, are skipped and will be fetched by the executor as usual.
+// Inputs that are not claimed within the TTL, or the default TTL of the prefetcher if zero, are cleaned up. Inputs are
+// only claimed when preparing the storage of the same job, with its ID set on the context with ContextWithJobID.
+func (p *Prefetcher) Prefetch(
+	ctx context.Context, executionID string, jobID string, inputs []model.StorageSpec, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = p.defaultTTL
+	}
+
+	for _, input := range inputs {
+		s, err := p.storages.Get(ctx, input.StorageSource)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("not prefetching input %s of execution %s", input.Name, executionID)
+			continue
+		}
+		size, err := s.GetVolumeSize(ctx, input)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("not prefetching input %s of execution %s", input.Name, executionID)
+			continue
+		}
+
+		// the fetch must outlive the bid request, so it is not bound to its context
+		fetchCtx, cancel := context.WithCancel(context.Background())
+		f := &fetch{
+			jobID:   jobID,
+			key:     specKey(input),
+			spec:    input,
+			size:    size,
+			storage: s,
+			cancel:  cancel,
+			done:    make(chan struct{}),
+		}
+
+		p.mu.Lock()
+		if p.used+size > p.budget {
+			p.mu.Unlock()
+			cancel()
+			log.Ctx(ctx).Debug().Msgf("not prefetching input %s of execution %s as it exceeds the prefetch budget", input.Name, executionID)
+			continue
+		}
+		p.used += size
+		p.fetches[executionID] = append(p.fetches[executionID], f)
+		p.expireAfter(executionID, ttl)
+		p.mu.Unlock()
+
+		go func() {
+			defer close(f.done)
+			f.volume, f.err = f.storage.PrepareStorage(fetchCtx, f.spec)
+		}()
+	}
+}
+
+// Cancel stops fetching the inputs of the execution and cleans up the ones that were already fetched.
+func (p *Prefetcher) Cancel(ctx context.Context, executionID string) {
+	p.mu.Lock()
+	fetches := p.fetches[executionID]
+	p.forget(executionID)
+	p.mu.Unlock()
+
+	for _, f := range fetches {
+		p.discard(ctx, f)
+	}
+}
+
+// expireAfter cleans up the inputs of the execution that are still unclaimed once the TTL expires, which releases
+// the budget held for executions the requester never got back to. It must be called with the lock held.
+func (p *Prefetcher) expireAfter(executionID string, ttl time.Duration) {
+	if timer, ok := p.expiry[executionID]; ok {
+		timer.Stop()
+	}
+	p.expiry[executionID] = time.AfterFunc(ttl, func() {
+		ctx := context.Background()
+		log.Ctx(ctx).Debug().Msgf("prefetched inputs of execution %s expired before being claimed", executionID)
+		p.Cancel(ctx, executionID)
+	})
+}
+
+// forget drops the inputs of the execution and stops their expiry. It must be called with the lock held.
+func (p *Prefetcher) forget(executionID string) {
+	delete(p.fetches, executionID)
+	if timer, ok := p.expiry[executionID]; ok {
+		timer.Stop()
+		delete(p.expiry, executionID)
+	}
+}
+
+// Used returns the number of bytes currently being prefetched or held by prefetched volumes.
+func (p *Prefetcher) Used() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.used
+}
+
+// Get implements storage.StorageProvider
+func (p *Prefetcher) Get(ctx context.Context, key model.StorageSourceType) (storage.Storage, error) {
+	s, err := p.storages.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &prefetchedStorage{Storage: s, prefetcher: p}, nil
+}
+
+// Has implements storage.StorageProvider
+func (p *Prefetcher) Has(ctx context.Context, key model.StorageSourceType) bool {
+	return p.storages.Has(ctx, key)
+}
+
+type jobIDContextKey struct{}
+
+// ContextWithJobID returns a context for preparing the inputs of the given job, which claims the inputs prefetched for
+// it. Inputs prepared without a job ID never claim prefetched inputs.
+func ContextWithJobID(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobIDContextKey{}, jobID)
+}
+
+func jobIDFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDContextKey{}).(string)
+	return jobID
+}
+
+// claim hands over a prefetch of the given input of the job, if any, to the caller. Inputs prefetched for other jobs
+// are never claimed, even if they are identical, as they are cleaned up with the job they were prefetched for.
+func (p *Prefetcher) claim(jobID string, spec model.StorageSpec) *fetch {
+	if jobID == "" {
+		return nil
+	}
+	key := specKey(spec)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for executionID, fetches := range p.fetches {
+		for i, f := range fetches {
+			if f.jobID != jobID || f.key != key {
+				continue
+			}
+			p.fetches[executionID] = append(fetches[:i], fetches[i+1:]...)
+			if len(p.fetches[executionID]) == 0 {
+				p.forget(executionID)
+			}
+			p.used -= f.size
+			return f
+		}
+	}
+	return nil
+}
+
+func (p *Prefetcher) discard(ctx context.Context, f *fetch) {
+	f.cancel()
+	<-f.done
+	if f.err == nil {
+		if err := f.storage.CleanupStorage(ctx, f.spec, f.volume); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to clean up prefetched input %s", f.spec.Name)
+		}
+	}
+
+	p.mu.Lock()
+	p.used -= f.size
+	p.mu.Unlock()
+}
+
+func specKey(spec model.StorageSpec) string {
+	key, err := json.Marshal(spec)
+	if err != nil {
+		// specs are plain data, but never match anything if they can't be serialized
+		return ""
+	}
+	return string(key)
+}
+
+// prefetchedStorage prepares storage from a prefetched volume when one is available.
+type prefetchedStorage struct {
+	storage.Storage
+	prefetcher *Prefetcher
+}
+
+func (s *prefetchedStorage) PrepareStorage(ctx context.Context, spec model.StorageSpec) (storage.StorageVolume, error) {
+	f := s.prefetcher.claim(jobIDFromContext(ctx), spec)
+	if f == nil {
+		return s.Storage.PrepareStorage(ctx, spec)
+	}
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		f.cancel()
+		go func() {
+			<-f.done
+			if f.err == nil {
+				_ = f.storage.CleanupStorage(context.Background(), f.spec, f.volume)
+			}
+		}()
+		return storage.StorageVolume{}, ctx.Err()
+	}
+
+	if f.err != nil {
+		log.Ctx(ctx).Debug().Err(f.err).Msgf("prefetching input %s failed, fetching it again", spec.Name)
+		return s.Storage.PrepareStorage(ctx, spec)
+	}
+	return f.volume, nil
+}
+
+// ProbeStorage implements storage.Prober, probing with the wrapped storage.
+func (s *prefetchedStorage) ProbeStorage(ctx context.Context, spec model.StorageSpec) (uint64, error) {
+	return storage.Probe(ctx, s.Storage, spec)
+}
+
+// Compile time interface check:
+var _ storage.StorageProvider = (*Prefetcher)(nil)
+var _ storage.Storage = (*prefetchedStorage)(nil)
+var _ storage.Prober = (*prefetchedStorage)(nil)
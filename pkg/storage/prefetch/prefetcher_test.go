@@ -0,0 +1,157 @@
+//go:build unit || !integration
+
+package prefetch
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+)
+
+type countingStorage struct {
+	prepared atomic.Int32
+	cleaned  atomic.Int32
+	storage  *noop.NoopStorage
+}
+
+func newCountingStorage(size uint64) *countingStorage {
+	s := &countingStorage{}
+	s.storage = noop.NewNoopStorageWithConfig(noop.StorageConfig{
+		ExternalHooks: noop.StorageConfigExternalHooks{
+			GetVolumeSize: func(context.Context, model.StorageSpec) (uint64, error) {
+				return size, nil
+			},
+			PrepareStorage: func(_ context.Context, spec model.StorageSpec) (storage.StorageVolume, error) {
+				s.prepared.Add(1)
+				return storage.StorageVolume{Type: storage.StorageVolumeConnectorBind, Source: spec.CID, Target: spec.Path}, nil
+			},
+			CleanupStorage: func(context.Context, model.StorageSpec, storage.StorageVolume) error {
+				s.cleaned.Add(1)
+				return nil
+			},
+		},
+	})
+	return s
+}
+
+func newTestPrefetcher(s *countingStorage, budget uint64) *Prefetcher {
+	return NewPrefetcher(PrefetcherParams{
+		Storages: model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+			model.StorageSourceIPFS: s.storage,
+		}),
+		Budget: budget,
+	})
+}
+
+var testInputs = []model.StorageSpec{
+	{StorageSource: model.StorageSourceIPFS, CID: "a", Path: "/inputs/a"},
+	{StorageSource: model.StorageSourceIPFS, CID: "b", Path: "/inputs/b"},
+}
+
+func TestPrefetchedInputsAreReused(t *testing.T) {
+	ctx := ContextWithJobID(context.Background(), "j1")
+	s := newCountingStorage(10)
+	prefetcher := newTestPrefetcher(s, 100)
+
+	prefetcher.Prefetch(ctx, "e1", "j1", testInputs, 0)
+	require.Equal(t, uint64(20), prefetcher.Used())
+
+	delegate, err := prefetcher.Get(ctx, model.StorageSourceIPFS)
+	require.NoError(t, err)
+	volumes, err := storage.ParallelPrepareStorage(ctx, prefetcher, testInputs)
+	require.NoError(t, err)
+	require.Len(t, volumes, 2)
+	require.Equal(t, int32(2), s.prepared.Load())
+	require.Equal(t, uint64(0), prefetcher.Used())
+
+	// once claimed, inputs are fetched again
+	_, err = delegate.PrepareStorage(ctx, testInputs[0])
+	require.NoError(t, err)
+	require.Equal(t, int32(3), s.prepared.Load())
+
+	// nothing is left to clean up
+	prefetcher.Cancel(ctx, "e1")
+	require.Equal(t, int32(0), s.cleaned.Load())
+}
+
+func TestPrefetchedInputsAreClaimedByTheirJob(t *testing.T) {
+	ctx := context.Background()
+	s := newCountingStorage(10)
+	prefetcher := newTestPrefetcher(s, 100)
+
+	prefetcher.Prefetch(ctx, "e1", "j1", testInputs[:1], 0)
+	require.Eventually(t, func() bool { return s.prepared.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// an identical job, or one prepared without a job ID, fetches its own inputs
+	for _, jobCtx := range []context.Context{ctx, ContextWithJobID(ctx, "j2")} {
+		_, err := storage.ParallelPrepareStorage(jobCtx, prefetcher, testInputs[:1])
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(3), s.prepared.Load())
+	require.Equal(t, uint64(10), prefetcher.Used())
+
+	_, err := storage.ParallelPrepareStorage(ContextWithJobID(ctx, "j1"), prefetcher, testInputs[:1])
+	require.NoError(t, err)
+	require.Equal(t, int32(3), s.prepared.Load())
+	require.Equal(t, uint64(0), prefetcher.Used())
+}
+
+func TestPrefetchRespectsBudget(t *testing.T) {
+	ctx := context.Background()
+	s := newCountingStorage(10)
+	prefetcher := newTestPrefetcher(s, 15)
+
+	prefetcher.Prefetch(ctx, "e1", "j1", testInputs, 0)
+	require.Equal(t, uint64(10), prefetcher.Used())
+
+	prefetcher.Prefetch(ctx, "e2", "j2", testInputs, 0)
+	require.Equal(t, uint64(10), prefetcher.Used())
+}
+
+func TestCancelCleansUpPrefetchedInputs(t *testing.T) {
+	ctx := context.Background()
+	s := newCountingStorage(10)
+	prefetcher := newTestPrefetcher(s, 100)
+
+	prefetcher.Prefetch(ctx, "e1", "j1", testInputs, 0)
+	prefetcher.Prefetch(ctx, "e2", "j2", testInputs[:1], 0)
+	require.Equal(t, uint64(30), prefetcher.Used())
+
+	prefetcher.Cancel(ctx, "e1")
+	require.Equal(t, int32(2), s.cleaned.Load())
+	require.Equal(t, uint64(10), prefetcher.Used())
+
+	prefetcher.Cancel(ctx, "e2")
+	require.Equal(t, int32(3), s.cleaned.Load())
+	require.Equal(t, uint64(0), prefetcher.Used())
+}
+
+func TestUnclaimedPrefetchesExpire(t *testing.T) {
+	ctx := ContextWithJobID(context.Background(), "j2")
+	s := newCountingStorage(10)
+	prefetcher := newTestPrefetcher(s, 100)
+
+	prefetcher.Prefetch(ctx, "e1", "j1", testInputs, 50*time.Millisecond)
+	prefetcher.Prefetch(ctx, "e2", "j2", testInputs[:1], time.Hour)
+	require.Equal(t, uint64(30), prefetcher.Used())
+
+	// the expired inputs are cleaned up and release their budget, while the others are kept
+	require.Eventually(t, func() bool {
+		return s.cleaned.Load() == 2 && prefetcher.Used() == 10
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// claiming the remaining inputs stops their expiry
+	_, err := storage.ParallelPrepareStorage(ctx, prefetcher, testInputs[:1])
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), prefetcher.Used())
+	prefetcher.mu.Lock()
+	defer prefetcher.mu.Unlock()
+	require.Empty(t, prefetcher.expiry)
+}
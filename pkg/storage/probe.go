@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// ErrInputNotFound is returned when probing an input that does not exist or can't be found.
+var ErrInputNotFound = errors.New("input not found")
+
+// Probe checks the input is available from the storage and returns its size, without fetching it. Storages that
+// can't probe inputs are asked for the size of the volume instead.
+func Probe(ctx context.Context, s Storage, spec model.StorageSpec) (uint64, error) {
+	if prober, ok := s.(Prober); ok {
+		return prober.ProbeStorage(ctx, spec)
+	}
+	return s.GetVolumeSize(ctx, spec)
+}
@@ -3,6 +3,7 @@ package tracing
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
@@ -64,4 +65,36 @@ func (t *tracingStorage) Upload(ctx context.Context, s string) (model.StorageSpe
 	return t.delegate.Upload(ctx, s)
 }
 
+func (t *tracingStorage) UploadStream(ctx context.Context, r io.Reader) (model.StorageSpec, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), fmt.Sprintf("%s.UploadStream", t.name))
+	defer span.End()
+
+	uploader, ok := t.delegate.(storage.StreamUploader)
+	if !ok {
+		return model.StorageSpec{}, fmt.Errorf("%s does not support uploading streams", t.name)
+	}
+	return uploader.UploadStream(ctx, r)
+}
+
+func (t *tracingStorage) ProbeStorage(ctx context.Context, spec model.StorageSpec) (uint64, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), fmt.Sprintf("%s.ProbeStorage", t.name))
+	defer span.End()
+
+	return storage.Probe(ctx, t.delegate, spec)
+}
+
+func (t *tracingStorage) ResolveName(ctx context.Context, name string) (string, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), fmt.Sprintf("%s.ResolveName", t.name))
+	defer span.End()
+
+	resolver, ok := t.delegate.(storage.NameResolver)
+	if !ok {
+		return "", fmt.Errorf("%s does not support resolving names", t.name)
+	}
+	return resolver.ResolveName(ctx, name)
+}
+
 var _ storage.Storage = &tracingStorage{}
+var _ storage.StreamUploader = &tracingStorage{}
+var _ storage.Prober = &tracingStorage{}
+var _ storage.NameResolver = &tracingStorage{}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"io"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
@@ -25,6 +26,27 @@ type Storage interface {
 	Upload(context.Context, string) (model.StorageSpec, error)
 }
 
+// StreamUploader is implemented by storages that can store data read from a stream,
+// without it first being written to the local filesystem.
+type StreamUploader interface {
+	// given a stream of data - "store" it and return a StorageSpec
+	UploadStream(context.Context, io.Reader) (model.StorageSpec, error)
+}
+
+// Prober is implemented by storages that can check an input is available, and size it, without fetching it.
+type Prober interface {
+	// ProbeStorage returns the size in bytes of the input, or zero if it can't be known without fetching it.
+	// The error wraps ErrInputNotFound if the input does not exist or can't be found.
+	ProbeStorage(context.Context, model.StorageSpec) (uint64, error)
+}
+
+// NameResolver is implemented by storages whose inputs can be given by a mutable name, which is resolved to the
+// immutable data it points to when the job is submitted.
+type NameResolver interface {
+	// ResolveName returns the CID the IPNS name, or the domain with a DNSLink record, currently points to.
+	ResolveName(ctx context.Context, name string) (string, error)
+}
+
 // a storage entity that is consumed are produced by a job
 // input storage specs are turned into storage volumes by drivers
 // for example - the input storage spec might be ipfs cid XXX
@@ -102,6 +102,38 @@ func (sp *StorageProvider) GetVolumeSize(context.Context, model.StorageSpec) (ui
 	return 0, nil
 }
 
+// ProbeStorage implements storage.Prober with a HEAD request to the URL. Servers that don't support HEAD requests
+// are assumed to serve the file, whose size is then unknown.
+func (sp *StorageProvider) ProbeStorage(ctx context.Context, storageSpec model.StorageSpec) (uint64, error) {
+	u, err := IsURLSupported(storageSpec.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := sp.client.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", storageSpec.URL, err)
+	}
+	defer closer.DrainAndCloseWithLogOnError(ctx, "url probe", res.Body)
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return 0, fmt.Errorf("%w: %s returned %s", storage.ErrInputNotFound, storageSpec.URL, res.Status)
+	case res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented:
+		return 0, nil
+	case res.StatusCode >= http.StatusBadRequest:
+		return 0, fmt.Errorf("%s returned %s", storageSpec.URL, res.Status)
+	}
+	if res.ContentLength < 0 {
+		return 0, nil
+	}
+	return uint64(res.ContentLength), nil
+}
+
 // PrepareStorage will download the file from the URL
 func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error) {
 	u, err := IsURLSupported(storageSpec.URL)
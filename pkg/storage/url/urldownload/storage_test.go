@@ -13,6 +13,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/suite"
@@ -341,3 +342,43 @@ func (s *StorageSuite) TestPrepareStorageURL() {
 		})
 	}
 }
+
+func (s *StorageSuite) TestProbeStorage() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Require().Equal(http.MethodHead, r.Method)
+		switch r.URL.Path {
+		case "/data.txt":
+			w.Header().Set("Content-Length", "42")
+		case "/nohead.txt":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case "/forbidden.txt":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	sp := newStorage(s.T().TempDir())
+
+	probe := func(path string) (uint64, error) {
+		return sp.ProbeStorage(context.Background(), model.StorageSpec{
+			StorageSource: model.StorageSourceURLDownload,
+			URL:           ts.URL + path,
+		})
+	}
+
+	size, err := probe("/data.txt")
+	s.Require().NoError(err)
+	s.Equal(uint64(42), size)
+
+	size, err = probe("/nohead.txt")
+	s.Require().NoError(err)
+	s.Zero(size)
+
+	_, err = probe("/forbidden.txt")
+	s.Require().Error(err)
+	s.NotErrorIs(err, storage.ErrInputNotFound)
+
+	_, err = probe("/missing.txt")
+	s.ErrorIs(err, storage.ErrInputNotFound)
+}
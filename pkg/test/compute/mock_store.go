@@ -7,13 +7,14 @@ import (
 )
 
 type CallbackStore struct {
-	GetExecutionFn         func(ctx context.Context, id string) (store.Execution, error)
-	GetExecutionsFn        func(ctx context.Context, id string) ([]store.Execution, error)
-	GetExecutionHistoryFn  func(ctx context.Context, id string) ([]store.ExecutionHistory, error)
-	CreateExecutionFn      func(ctx context.Context, execution store.Execution) error
-	UpdateExecutionStateFn func(ctx context.Context, request store.UpdateExecutionStateRequest) error
-	DeleteExecutionFn      func(ctx context.Context, id string) error
-	GetExecutionCountFn    func(ctx context.Context) (uint, error)
+	GetExecutionFn           func(ctx context.Context, id string) (store.Execution, error)
+	GetExecutionsFn          func(ctx context.Context, id string) ([]store.Execution, error)
+	GetExecutionHistoryFn    func(ctx context.Context, id string) ([]store.ExecutionHistory, error)
+	CreateExecutionFn        func(ctx context.Context, execution store.Execution) error
+	UpdateExecutionStateFn   func(ctx context.Context, request store.UpdateExecutionStateRequest) error
+	UpdateExecutionTimeoutFn func(ctx context.Context, id string, timeout float64) error
+	DeleteExecutionFn        func(ctx context.Context, id string) error
+	GetExecutionCountFn      func(ctx context.Context) (uint, error)
 }
 
 func (m *CallbackStore) GetExecution(ctx context.Context, id string) (store.Execution, error) {
@@ -36,6 +37,10 @@ func (m *CallbackStore) UpdateExecutionState(ctx context.Context, request store.
 	return m.UpdateExecutionStateFn(ctx, request)
 }
 
+func (m *CallbackStore) UpdateExecutionTimeout(ctx context.Context, id string, timeout float64) error {
+	return m.UpdateExecutionTimeoutFn(ctx, id, timeout)
+}
+
 func (m *CallbackStore) DeleteExecution(ctx context.Context, id string) error {
 	return m.DeleteExecutionFn(ctx, id)
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/devstack"
 	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	_ "github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -339,14 +340,7 @@ func (suite *ComputeNodeResourceLimitsSuite) TestParallelGPU() {
 		},
 	}
 
-	resolver := job.NewStateResolver(
-		func(ctx context.Context, id string) (model.Job, error) {
-			return stack.Nodes[0].RequesterNode.JobStore.GetJob(ctx, id)
-		},
-		func(ctx context.Context, id string) (model.JobState, error) {
-			return stack.Nodes[0].RequesterNode.JobStore.GetJobState(ctx, id)
-		},
-	)
+	resolver := jobstore.GetStateResolver(stack.Nodes[0].RequesterNode.JobStore)
 
 	for i := 0; i < nodeCount; i++ {
 		for j := 0; j < jobsPerNode; j++ {
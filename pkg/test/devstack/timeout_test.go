@@ -67,7 +67,12 @@ func (suite *DevstackTimeoutSuite) TestRunningTimeout() {
 				ExecutorConfig: noop.ExecutorConfig{
 					ExternalHooks: noop.ExecutorConfigExternalHooks{
 						JobHandler: func(ctx context.Context, job model.Job, resultsDir string) (*model.RunCommandResult, error) {
-							time.Sleep(testCase.sleepTime)
+							// stop when the job is cancelled, so that nothing is left running once it timed out
+							select {
+							case <-ctx.Done():
+								return nil, ctx.Err()
+							case <-time.After(testCase.sleepTime):
+							}
 							return executor.WriteJobResults(resultsDir, strings.NewReader(""), strings.NewReader(""), 0, nil)
 						},
 					},
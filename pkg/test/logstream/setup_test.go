@@ -43,7 +43,7 @@ func (s *LogStreamTestSuite) TearDownSuite() {
 
 func waitForOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool, exec executor.Executor) (io.Reader, error) {
 	for i := 0; i < 10; i++ {
-		reader, err := exec.GetOutputStream(ctx, executionID, withHistory, follow)
+		reader, err := exec.GetOutputStream(ctx, executionID, withHistory, follow, 0)
 		if err != nil {
 			if strings.Contains(err.Error(), "not implemented") {
 				return nil, err
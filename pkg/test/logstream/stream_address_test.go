@@ -62,11 +62,12 @@ func (s *LogStreamTestSuite) TestStreamAddress() {
 	require.NoError(s.T(), err)
 	defer client.Close()
 
-	client.Connect(s.ctx, execution.ID, true, true)
+	client.Connect(s.ctx, execution.ID, true, true, 0)
 
 	frame, err := client.ReadDataFrame(s.ctx)
 	require.NoError(s.T(), err)
 	require.NotNil(s.T(), frame)
 
 	require.Equal(s.T(), string(frame.Data), "logstreamoutput\n")
+	require.Equal(s.T(), uint64(1), frame.Seq)
 }
@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/docker"
-	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/logger/logframe"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
 	"github.com/google/uuid"
@@ -49,7 +49,7 @@ func (s *LogStreamTestSuite) TestDockerOutputStream() {
 		require.NoError(s.T(), err)
 		require.NotNil(s.T(), reader)
 
-		dataframe, err := logger.NewDataFrameFromReader(reader)
+		dataframe, err := logframe.ReadMuxedFrame(reader)
 		require.NoError(s.T(), err)
 
 		require.Contains(s.T(), string(dataframe.Data), "logstreamoutput")
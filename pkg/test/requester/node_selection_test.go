@@ -10,6 +10,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/devstack"
 	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
@@ -80,14 +81,7 @@ func (s *NodeSelectionSuite) SetupSuite() {
 	s.compute2 = stack.Nodes[2]
 	s.compute3 = stack.Nodes[3]
 	s.client = publicapi.NewRequesterAPIClient(s.requester.APIServer.Address, s.requester.APIServer.Port)
-	s.stateResolver = job.NewStateResolver(
-		func(ctx context.Context, id string) (model.Job, error) {
-			return s.requester.RequesterNode.JobStore.GetJob(ctx, id)
-		},
-		func(ctx context.Context, id string) (model.JobState, error) {
-			return s.requester.RequesterNode.JobStore.GetJobState(ctx, id)
-		},
-	)
+	s.stateResolver = jobstore.GetStateResolver(s.requester.RequesterNode.JobStore)
 	s.computeNodes = []*node.Node{s.compute1, s.compute2, s.compute3}
 
 	testutils.WaitForNodeDiscovery(s.T(), s.requester, 4)
@@ -5,6 +5,7 @@ package publicapi
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -73,3 +74,20 @@ func (s *ServerSuite) TestSubmitRejectsJobWithSigilHeader() {
 	_, err = s.client.Submit(context.Background(), j)
 	require.Error(s.T(), err)
 }
+
+func (s *ServerSuite) TestNodes() {
+	ctx := context.Background()
+
+	// the node learns about itself from the info it publishes, with its load
+	var nodes []model.NodeInfo
+	require.Eventually(s.T(), func() bool {
+		var err error
+		nodes, err = s.client.Nodes(ctx)
+		require.NoError(s.T(), err)
+		return len(nodes) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Equal(s.T(), s.node.Host.ID(), nodes[0].PeerInfo.ID)
+	require.NotNil(s.T(), nodes[0].ComputeNodeInfo.Load)
+	require.NotEmpty(s.T(), nodes[0].LoadHistory)
+	require.Zero(s.T(), nodes[0].LoadHistory[0].RunningExecutions)
+}
@@ -0,0 +1,55 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/devstack"
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	noop_storage "github.com/bacalhau-project/bacalhau/pkg/storage/noop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpload(t *testing.T) {
+	logger.ConfigureTestLogging(t)
+
+	uploaded := make(chan []byte, 1)
+	injector := devstack.NewNoopNodeDependencyInjector()
+	injector.StorageProvidersFactory = devstack.NewNoopStorageProvidersFactoryWithConfig(noop_storage.StorageConfig{
+		ExternalHooks: noop_storage.StorageConfigExternalHooks{
+			UploadStream: func(ctx context.Context, r io.Reader) (model.StorageSpec, error) {
+				data, err := io.ReadAll(r)
+				uploaded <- data
+				return model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "uploaded"}, err
+			},
+		},
+	})
+	n, c := setupNodeForTestWithInjector(t, publicapi.APIServerConfig{}, injector)
+	defer n.CleanupManager.Cleanup(context.Background())
+
+	// larger than a single part
+	data := make([]byte, 40*1024*1024+123)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	spec, err := c.Upload(context.Background(), bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, "uploaded", spec.CID)
+	require.Equal(t, data, <-uploaded)
+}
+
+func TestUploadFailsOnShortData(t *testing.T) {
+	logger.ConfigureTestLogging(t)
+	n, c := setupNodeForTest(t)
+	defer n.CleanupManager.Cleanup(context.Background())
+
+	_, err := c.Upload(context.Background(), bytes.NewReader([]byte("short")), 100)
+	require.Error(t, err)
+}
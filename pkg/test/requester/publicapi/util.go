@@ -29,6 +29,15 @@ func setupNodeForTest(t *testing.T) (*node.Node, *requester_publicapi.RequesterA
 
 //nolint:unused // used in tests
 func setupNodeForTestWithConfig(t *testing.T, config publicapi.APIServerConfig) (*node.Node, *requester_publicapi.RequesterAPIClient) {
+	return setupNodeForTestWithInjector(t, config, devstack.NewNoopNodeDependencyInjector())
+}
+
+//nolint:unused // used in tests
+func setupNodeForTestWithInjector(
+	t *testing.T,
+	config publicapi.APIServerConfig,
+	injector node.NodeDependencyInjector,
+) (*node.Node, *requester_publicapi.RequesterAPIClient) {
 	system.InitConfigForTesting(t)
 	ctx := context.Background()
 
@@ -50,7 +59,7 @@ func setupNodeForTestWithConfig(t *testing.T, config publicapi.APIServerConfig)
 		APIServerConfig:     config,
 		IsRequesterNode:     true,
 		IsComputeNode:       true,
-		DependencyInjector:  devstack.NewNoopNodeDependencyInjector(),
+		DependencyInjector:  injector,
 	}
 
 	n, err := node.NewNode(ctx, nodeConfig)
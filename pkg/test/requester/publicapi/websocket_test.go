@@ -109,3 +109,37 @@ func (s *WebsocketSuite) TestWebsocketSingleJob() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), "Created", event.EventName.String())
 }
+
+func (s *WebsocketSuite) TestFollowJobStates() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	j, err := s.client.Submit(ctx, testutils.MakeNoopJob())
+	require.NoError(s.T(), err)
+
+	var jobStates []model.JobStateType
+	err = s.client.FollowJobStates(ctx, j.Metadata.ID, func(event model.JobHistory) error {
+		require.Equal(s.T(), j.Metadata.ID, event.JobID)
+		if event.Type == model.JobHistoryTypeJobLevel {
+			jobStates = append(jobStates, event.JobState.New)
+		}
+		return nil
+	})
+	require.NoError(s.T(), err)
+	require.NotEmpty(s.T(), jobStates)
+	require.Equal(s.T(), model.JobStateCompleted, jobStates[len(jobStates)-1])
+
+	// The history of a job that has already ended is replayed before the connection is closed.
+	var replayed int
+	err = s.client.FollowJobStates(ctx, j.Metadata.ID, func(event model.JobHistory) error {
+		replayed++
+		return nil
+	})
+	require.NoError(s.T(), err)
+	require.NotZero(s.T(), replayed)
+}
+
+func (s *WebsocketSuite) TestFollowJobStatesUnknownJob() {
+	err := s.client.FollowJobStates(context.Background(), "unknown-job", func(model.JobHistory) error { return nil })
+	require.Error(s.T(), err)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
@@ -155,14 +156,7 @@ func (s *RetriesSuite) SetupSuite() {
 
 	s.requester = stack.Nodes[0]
 	s.client = publicapi.NewRequesterAPIClient(s.requester.APIServer.Address, s.requester.APIServer.Port)
-	s.stateResolver = job.NewStateResolver(
-		func(ctx context.Context, id string) (model.Job, error) {
-			return s.requester.RequesterNode.JobStore.GetJob(ctx, id)
-		},
-		func(ctx context.Context, id string) (model.JobState, error) {
-			return s.requester.RequesterNode.JobStore.GetJobState(ctx, id)
-		},
-	)
+	s.stateResolver = jobstore.GetStateResolver(s.requester.RequesterNode.JobStore)
 	testutils.WaitForNodeDiscovery(s.T(), s.requester, len(nodeOverrides))
 }
 
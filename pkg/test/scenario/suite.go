@@ -8,7 +8,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/devstack"
 	"github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader"
-	"github.com/bacalhau-project/bacalhau/pkg/downloader/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/util"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
@@ -168,13 +168,7 @@ func (s *ScenarioRunner) RunScenario(scenario Scenario) (resultsDir string) {
 			LocalIPFS:      true,
 		}
 
-		ipfsDownloader := ipfs.NewIPFSDownloader(cm, downloaderSettings)
-		s.Require().NoError(err)
-
-		downloaderProvider := model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
-			model.StorageSourceIPFS: ipfsDownloader,
-		})
-
+		downloaderProvider := util.NewStandardDownloaders(cm, downloaderSettings)
 		err = downloader.DownloadResults(s.Ctx, results, downloaderProvider, downloaderSettings)
 		s.Require().NoError(err)
 
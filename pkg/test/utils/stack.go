@@ -128,6 +128,11 @@ func SetupTestWithNoopExecutor(
 	stack, err := devstack.NewDevStack(ctx, cm, options, computeConfig, requesterConfig, injector, nodeOverrides...)
 	require.NoError(t, err)
 
+	// Cleanups run in reverse order, so the invariants are checked before the stack is shut down.
+	t.Cleanup(func() {
+		RequireStackInvariants(ctx, t, stack)
+	})
+
 	// Wait for nodes to have announced their presence.
 	for !allNodesDiscovered(t, stack) {
 		time.Sleep(time.Second)
@@ -136,6 +141,24 @@ func SetupTestWithNoopExecutor(
 	return stack
 }
 
+// invariantsTimeout is how long the nodes of a stack get to settle before their invariants must hold.
+const invariantsTimeout = 10 * time.Second
+
+// RequireStackInvariants fails the test if the invariants of the stack, such as every execution having released its
+// capacity, don't hold once the nodes have had time to finish handling the jobs of the test.
+func RequireStackInvariants(ctx context.Context, t testing.TB, stack *devstack.DevStack, checks ...devstack.InvariantCheck) {
+	if ctx.Err() != nil {
+		return
+	}
+	var err error
+	for deadline := time.Now().Add(invariantsTimeout); ; time.Sleep(100 * time.Millisecond) {
+		if err = stack.CheckInvariants(ctx, checks...); err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	require.NoError(t, err, "devstack invariants do not hold")
+}
+
 // Returns whether the requester node(s) in the stack have discovered all of the
 // other nodes in the stack and have complete information for them (i.e. each
 // node has actually announced itself.)
@@ -3,6 +3,7 @@ package bprotocol
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
@@ -39,9 +40,43 @@ func NewCallbackHandler(params CallbackHandlerParams) *CallbackHandler {
 	host.SetStreamHandler(OnPublishComplete, handleCallback(host, handler.callback.OnPublishComplete))
 	host.SetStreamHandler(OnCancelComplete, handleCallback(host, handler.callback.OnCancelComplete))
 	host.SetStreamHandler(OnComputeFailure, handleCallback(host, handler.callback.OnComputeFailure))
+	host.SetStreamHandler(OnCallbackBatch, handleCallback(host, handler.handleBatch))
 	return handler
 }
 
+// handleBatch unbatches the callbacks of a batch and hands them to the callback one by one, in order.
+func (h *CallbackHandler) handleBatch(ctx context.Context, batch callbackBatch) {
+	for _, callback := range batch.Callbacks {
+		var err error
+		switch callback.ProtocolID {
+		case OnBidComplete:
+			err = dispatchCallback(ctx, callback.Data, h.callback.OnBidComplete)
+		case OnRunComplete:
+			err = dispatchCallback(ctx, callback.Data, h.callback.OnRunComplete)
+		case OnPublishComplete:
+			err = dispatchCallback(ctx, callback.Data, h.callback.OnPublishComplete)
+		case OnCancelComplete:
+			err = dispatchCallback(ctx, callback.Data, h.callback.OnCancelComplete)
+		case OnComputeFailure:
+			err = dispatchCallback(ctx, callback.Data, h.callback.OnComputeFailure)
+		default:
+			err = fmt.Errorf("unknown callback protocol %s", callback.ProtocolID)
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error handling batched callback")
+		}
+	}
+}
+
+func dispatchCallback[Request any](ctx context.Context, data []byte, f callbackHandler[Request]) error {
+	request := new(Request)
+	if err := json.Unmarshal(data, request); err != nil {
+		return fmt.Errorf("error decoding %s: %w", reflect.TypeOf(request), err)
+	}
+	f(ctx, *request)
+	return nil
+}
+
 func handleCallback[Request any](host host.Host, f callbackHandler[Request]) func(network.Stream) {
 	return func(stream network.Stream) {
 		ctx := logger.ContextWithNodeIDLogger(context.Background(), host.ID().String())
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -15,9 +18,39 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// DefaultOfflineRetryInterval is how often buffered callbacks are retried while the requester is unreachable.
+const DefaultOfflineRetryInterval = 5 * time.Second
+
+// DefaultMaxBatchSize is the maximum number of callbacks sent together to a requester unless configured otherwise.
+const DefaultMaxBatchSize = 100
+
+// offlineSendTimeout bounds each attempt at delivering a buffered callback, so that an unreachable requester does not
+// hold up the callbacks behind it for long.
+const offlineSendTimeout = 10 * time.Second
+
+// maxSendAttempts is how many times a callback is sent before it is dropped when it is not buffered while the
+// requester is unreachable.
+const maxSendAttempts = 3
+
+// sendRetryBackoff is how long the first retry of a failed callback waits, doubling with each retry.
+const sendRetryBackoff = 200 * time.Millisecond
+
+// closeFlushTimeout bounds how long closing the proxy waits for the callbacks it holds to be delivered.
+const closeFlushTimeout = 10 * time.Second
+
 type CallbackProxyParams struct {
 	Host          host.Host
 	LocalCallback compute.Callback
+	// OfflineBufferSize is the maximum number of callbacks per requester that are buffered while the requester
+	// is unreachable, and delivered once it is reachable again. Callbacks are dropped if zero.
+	OfflineBufferSize int
+	// OfflineRetryInterval is how often buffered callbacks are retried. Defaults to DefaultOfflineRetryInterval.
+	OfflineRetryInterval time.Duration
+	// BatchInterval is how long callbacks to a requester are held so that they are sent together in a single message,
+	// which cuts the number of messages of jobs with many executions. Callbacks are sent on their own if zero.
+	BatchInterval time.Duration
+	// MaxBatchSize is the maximum number of callbacks sent together. Defaults to DefaultMaxBatchSize.
+	MaxBatchSize int
 }
 
 // CallbackProxy is a proxy for a compute.Callback that can be used to send compute callbacks to the requester node,
@@ -25,14 +58,69 @@ type CallbackProxyParams struct {
 // The proxy can forward callbacks to a remote requester node, or locally if the node is the requester and a
 // LocalCallback is provided.
 type CallbackProxy struct {
-	host          host.Host
-	localCallback compute.Callback
+	host                 host.Host
+	localCallback        compute.Callback
+	offlineBufferSize    int
+	offlineRetryInterval time.Duration
+	batchInterval        time.Duration
+	maxBatchSize         int
+
+	// ctx is canceled once the proxy is closed, which stops delivering buffered callbacks
+	ctx    context.Context
+	cancel context.CancelFunc
+	// closing is closed when the proxy starts closing, which sends the callbacks waiting to be batched right away and
+	// gives buffered callbacks a last attempt at being delivered
+	closing chan struct{}
+	// drains counts the goroutines delivering queued callbacks
+	drains sync.WaitGroup
+
+	mu     sync.Mutex
+	queues map[peer.ID]*peerQueue
+	closed bool
+}
+
+// peerQueue holds the callbacks to a requester that are waiting for the ones before them to be delivered.
+type peerQueue struct {
+	callbacks []pendingCallback
+	// draining is whether a goroutine is delivering the callbacks
+	draining bool
+	// offline is whether the last attempt at delivering a callback failed
+	offline bool
+	// sending is the number of callbacks at the head of the queue being sent, which can't be coalesced anymore
+	sending int
+	// failures is the number of attempts in a row at sending the callbacks at the head of the queue that failed
+	failures int
+	// full is closed when the queue holds a full batch, which ends the wait for more callbacks
+	full chan struct{}
+}
+
+// pendingCallback is a callback waiting to be sent to its requester.
+type pendingCallback struct {
+	protocolID  protocol.ID
+	data        []byte
+	name        string
+	executionID string
 }
 
 func NewCallbackProxy(params CallbackProxyParams) *CallbackProxy {
+	ctx, cancel := context.WithCancel(logger.ContextWithNodeIDLogger(context.Background(), params.Host.ID().String()))
 	proxy := &CallbackProxy{
-		host:          params.Host,
-		localCallback: params.LocalCallback,
+		host:                 params.Host,
+		localCallback:        params.LocalCallback,
+		offlineBufferSize:    params.OfflineBufferSize,
+		offlineRetryInterval: params.OfflineRetryInterval,
+		batchInterval:        params.BatchInterval,
+		maxBatchSize:         params.MaxBatchSize,
+		ctx:                  ctx,
+		cancel:               cancel,
+		closing:              make(chan struct{}),
+		queues:               make(map[peer.ID]*peerQueue),
+	}
+	if proxy.offlineRetryInterval == 0 {
+		proxy.offlineRetryInterval = DefaultOfflineRetryInterval
+	}
+	if proxy.maxBatchSize <= 0 {
+		proxy.maxBatchSize = DefaultMaxBatchSize
 	}
 	return proxy
 }
@@ -42,31 +130,31 @@ func (p *CallbackProxy) RegisterLocalComputeCallback(callback compute.Callback)
 }
 
 func (p *CallbackProxy) OnBidComplete(ctx context.Context, result compute.BidResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnBidComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, result.ExecutionMetadata, OnBidComplete, result, func(ctx2 context.Context) {
 		p.localCallback.OnBidComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnRunComplete(ctx context.Context, result compute.RunResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnRunComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, result.ExecutionMetadata, OnRunComplete, result, func(ctx2 context.Context) {
 		p.localCallback.OnRunComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnPublishComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, result.ExecutionMetadata, OnPublishComplete, result, func(ctx2 context.Context) {
 		p.localCallback.OnPublishComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnCancelComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, result.ExecutionMetadata, OnCancelComplete, result, func(ctx2 context.Context) {
 		p.localCallback.OnCancelComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnComputeFailure, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, result.ExecutionMetadata, OnComputeFailure, result, func(ctx2 context.Context) {
 		p.localCallback.OnComputeFailure(ctx2, result)
 	})
 }
@@ -75,6 +163,7 @@ func proxyCallbackRequest(
 	ctx context.Context,
 	p *CallbackProxy,
 	resultInfo compute.RoutingMetadata,
+	executionInfo compute.ExecutionMetadata,
 	protocolID protocol.ID,
 	request interface{},
 	selfDialFunc func(ctx2 context.Context)) {
@@ -102,27 +191,260 @@ func proxyCallbackRequest(
 			return
 		}
 
-		// opening a stream to the destination peer
-		stream, err := p.host.NewStream(ctx, peerID, protocolID)
-		if err != nil {
-			log.Ctx(ctx).Err(errors.WithStack(err)).Msgf("%s: failed to open stream to peer %s", reflect.TypeOf(request), targetPeerID)
-			return
+		p.deliver(ctx, peerID, pendingCallback{
+			protocolID:  protocolID,
+			data:        data,
+			name:        reflect.TypeOf(request).String(),
+			executionID: executionInfo.ExecutionID,
+		})
+	}
+}
+
+// deliver sends the callback to the requester. When batching or offline buffering is enabled, callbacks are queued
+// per requester and delivered in order, so that a callback that can't be delivered holds back the ones after it until
+// the requester is reachable again, without holding back callbacks to other requesters.
+func (p *CallbackProxy) deliver(ctx context.Context, peerID peer.ID, callback pendingCallback) {
+	p.mu.Lock()
+	queue, ok := p.queues[peerID]
+	// callbacks to requesters whose queue is not being delivered are sent right away once the proxy is closing
+	if (p.offlineBufferSize == 0 && p.batchInterval == 0) || (p.closed && (!ok || !queue.draining)) {
+		p.mu.Unlock()
+		if err := p.sendWithRetry(ctx, peerID, callback); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("%s: failed to send callback to peer %s", callback.name, peerID)
+		}
+		return
+	}
+	defer p.mu.Unlock()
+	if !ok {
+		queue = &peerQueue{}
+		p.queues[peerID] = queue
+	}
+	if p.batchInterval > 0 {
+		p.coalesce(queue, callback)
+	}
+	if queue.offline && len(queue.callbacks) >= p.offlineBufferSize {
+		log.Ctx(ctx).Error().Msgf("%s: dropping callback to peer %s as %d callbacks are already buffered",
+			callback.name, peerID, len(queue.callbacks))
+		return
+	}
+	queue.callbacks = append(queue.callbacks, callback)
+	if queue.full != nil && len(queue.callbacks) >= p.maxBatchSize {
+		close(queue.full)
+		queue.full = nil
+	}
+	if !queue.draining {
+		queue.draining = true
+		p.drains.Add(1)
+		go p.drain(peerID, queue)
+	}
+}
+
+// coalesce drops the queued callbacks of the execution that the callback supersedes: an earlier callback of the same
+// kind, or any earlier callback once the execution failed or was canceled, as the requester only acts on the latest.
+// Callbacks being sent are left alone.
+func (p *CallbackProxy) coalesce(queue *peerQueue, callback pendingCallback) {
+	if callback.executionID == "" {
+		return
+	}
+	terminal := callback.protocolID == OnComputeFailure || callback.protocolID == OnCancelComplete
+	kept := queue.callbacks[:queue.sending]
+	for _, queued := range queue.callbacks[queue.sending:] {
+		if queued.executionID == callback.executionID && (terminal || queued.protocolID == callback.protocolID) {
+			continue
+		}
+		kept = append(kept, queued)
+	}
+	queue.callbacks = kept
+}
+
+// drain delivers the callbacks queued for the peer in order, in batches when batching is enabled, retrying
+// periodically while the peer is unreachable, until the queue is empty or the proxy is closed. Callbacks are sent
+// without holding the lock.
+func (p *CallbackProxy) drain(peerID peer.ID, queue *peerQueue) {
+	defer p.drains.Done()
+	ctx := p.ctx
+	for {
+		p.mu.Lock()
+		if p.batchInterval > 0 && !queue.offline && len(queue.callbacks) < p.maxBatchSize && !p.closed {
+			// give the callbacks that follow closely a chance to be sent in the same batch, until the batch is full
+			full := make(chan struct{})
+			queue.full = full
+			p.mu.Unlock()
+			select {
+			case <-time.After(p.batchInterval):
+			case <-full:
+			case <-p.closing:
+			case <-ctx.Done():
+			}
+			p.mu.Lock()
+			queue.full = nil
 		}
-		defer closer.CloseWithLogOnError("stream", stream)
-		if scopingErr := stream.Scope().SetService(ComputeServiceName); scopingErr != nil {
-			log.Ctx(ctx).Error().Err(errors.WithStack(scopingErr)).Msg("error attaching stream to requester service")
-			_ = stream.Reset() //nolint:errcheck
+		if len(queue.callbacks) == 0 || ctx.Err() != nil {
+			queue.draining = false
+			if len(queue.callbacks) == 0 {
+				delete(p.queues, peerID)
+			}
+			p.mu.Unlock()
 			return
 		}
+		batch := queue.callbacks[:1]
+		if p.batchInterval > 0 {
+			batch = queue.callbacks[:system.Min(len(queue.callbacks), p.maxBatchSize)]
+		}
+		batch = append([]pendingCallback{}, batch...)
+		queue.sending = len(batch)
+		p.mu.Unlock()
 
-		// write the request to the stream
-		_, err = stream.Write(data)
-		if err != nil {
-			_ = stream.Reset() //nolint:errcheck
-			log.Ctx(ctx).Error().Err(errors.WithStack(err)).Msgf("%s: failed to write request to peer %s", reflect.TypeOf(request), targetPeerID)
+		sendCtx, cancel := context.WithTimeout(ctx, offlineSendTimeout)
+		sent, err := p.sendBatch(sendCtx, peerID, batch)
+		cancel()
+
+		p.mu.Lock()
+		queue.sending = 0
+		queue.callbacks = queue.callbacks[sent:]
+		if err == nil || sent > 0 {
+			queue.failures = 0
+		}
+		if err == nil {
+			if queue.offline {
+				queue.offline = false
+				log.Ctx(ctx).Info().Msgf("peer %s is reachable again, delivering buffered callbacks", peerID)
+			}
+			p.mu.Unlock()
+			continue
+		}
+		if p.offlineBufferSize == 0 {
+			// callbacks are not buffered while the peer is unreachable, but are retried a few times before being dropped
+			queue.failures++
+			if queue.failures < maxSendAttempts && !p.closed {
+				backoff := sendRetryBackoff << (queue.failures - 1)
+				log.Ctx(ctx).Debug().Err(err).Msgf("failed to send %d callbacks to peer %s, retrying in %s", len(batch)-sent, peerID, backoff)
+				p.mu.Unlock()
+				select {
+				case <-time.After(backoff):
+				case <-p.closing:
+				case <-ctx.Done():
+				}
+				continue
+			}
+			log.Ctx(ctx).Error().Err(err).Msgf("failed to send %d callbacks to peer %s", len(batch)-sent, peerID)
+			queue.callbacks = queue.callbacks[len(batch)-sent:]
+			queue.failures = 0
+			p.mu.Unlock()
+			continue
+		}
+		if p.closed {
+			// the last attempt at delivering the buffered callbacks before closing failed
+			log.Ctx(ctx).Error().Err(err).Msgf("dropping %d callbacks to peer %s as the proxy is closing", len(queue.callbacks), peerID)
+			queue.draining = false
+			p.mu.Unlock()
 			return
 		}
+		if !queue.offline {
+			queue.offline = true
+			log.Ctx(ctx).Warn().Err(err).Msgf("%s: failed to send callback to peer %s, buffering callbacks until the peer is reachable",
+				batch[sent].name, peerID)
+		} else {
+			log.Ctx(ctx).Debug().Err(err).Msgf("peer %s still unreachable with %d buffered callbacks", peerID, len(queue.callbacks))
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-time.After(p.offlineRetryInterval):
+		case <-p.closing:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// sendBatch sends the callbacks in a single message if the peer supports batches, and one by one otherwise, and
+// returns how many were sent.
+func (p *CallbackProxy) sendBatch(ctx context.Context, peerID peer.ID, callbacks []pendingCallback) (int, error) {
+	if len(callbacks) > 1 {
+		if supported, err := p.host.Peerstore().SupportsProtocols(peerID, OnCallbackBatch); err == nil && len(supported) > 0 {
+			batch := callbackBatch{Callbacks: make([]batchedCallback, 0, len(callbacks))}
+			for _, callback := range callbacks {
+				batch.Callbacks = append(batch.Callbacks, batchedCallback{ProtocolID: callback.protocolID, Data: callback.data})
+			}
+			data, err := json.Marshal(batch)
+			if err != nil {
+				return 0, err
+			}
+			if err = p.send(ctx, peerID, pendingCallback{protocolID: OnCallbackBatch, data: data}); err != nil {
+				return 0, err
+			}
+			return len(callbacks), nil
+		}
+	}
+	for i, callback := range callbacks {
+		if err := p.send(ctx, peerID, callback); err != nil {
+			return i, err
+		}
+	}
+	return len(callbacks), nil
+}
+
+// Close sends the callbacks waiting to be batched, and gives buffered callbacks a last attempt at being delivered,
+// waiting up to closeFlushTimeout for them to be sent. Callbacks that still can't be delivered are dropped.
+func (p *CallbackProxy) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.closing)
+	}
+	p.mu.Unlock()
+
+	flushed := make(chan struct{})
+	go func() {
+		p.drains.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(closeFlushTimeout):
+		log.Ctx(p.ctx).Warn().Msgf("dropping the callbacks that were not delivered within %s of closing", closeFlushTimeout)
+	}
+	p.cancel()
+}
+
+// sendWithRetry sends the callback, retrying a few times with a growing backoff if it fails, so that a requester that
+// is briefly unreachable does not miss it.
+func (p *CallbackProxy) sendWithRetry(ctx context.Context, peerID peer.ID, callback pendingCallback) error {
+	backoff := sendRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.send(ctx, peerID, callback)
+		if err == nil || attempt >= maxSendAttempts {
+			return err
+		}
+		log.Ctx(ctx).Debug().Err(err).Msgf("%s: failed to send callback to peer %s, retrying in %s", callback.name, peerID, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (p *CallbackProxy) send(ctx context.Context, peerID peer.ID, callback pendingCallback) error {
+	// opening a stream to the destination peer
+	stream, err := p.host.NewStream(ctx, peerID, callback.protocolID)
+	if err != nil {
+		return errors.Wrap(err, "failed to open stream")
+	}
+	defer closer.CloseWithLogOnError("stream", stream)
+	if scopingErr := stream.Scope().SetService(ComputeServiceName); scopingErr != nil {
+		_ = stream.Reset() //nolint:errcheck
+		return errors.Wrap(scopingErr, "error attaching stream to requester service")
+	}
+
+	// write the request to the stream
+	_, err = stream.Write(callback.data)
+	if err != nil {
+		_ = stream.Reset() //nolint:errcheck
+		return errors.Wrap(err, "failed to write request")
 	}
+	return nil
 }
 
 // Compile-time interface check:
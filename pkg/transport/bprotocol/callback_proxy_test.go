@@ -0,0 +1,331 @@
+//go:build unit || !integration
+
+package bprotocol
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
+)
+
+func TestCallbackProxyBuffersWhileRequesterIsUnreachable(t *testing.T) {
+	ctx := context.Background()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		Host:                 computeNode,
+		OfflineBufferSize:    10,
+		OfflineRetryInterval: 100 * time.Millisecond,
+	})
+
+	// the requester is not handling callbacks yet
+	routing := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: routing})
+	proxy.OnPublishComplete(ctx, compute.PublishResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: routing})
+
+	received := make(chan string, 2)
+	NewCallbackHandler(CallbackHandlerParams{
+		Host: requesterNode,
+		Callback: compute.CallbackMock{
+			OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+				received <- "run:" + result.ExecutionID
+			},
+			OnPublishCompleteHandler: func(ctx context.Context, result compute.PublishResult) {
+				received <- "publish:" + result.ExecutionID
+			},
+		},
+	})
+
+	var events []string
+	for len(events) < 2 {
+		select {
+		case event := <-received:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "buffered callbacks were not delivered", events)
+		}
+	}
+	// callbacks are delivered in order, but handled concurrently by the requester
+	require.ElementsMatch(t, []string{"run:e1", "publish:e1"}, events)
+}
+
+func TestCallbackProxyDoesNotHoldBackOtherRequesters(t *testing.T) {
+	ctx := context.Background()
+
+	offlineNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer offlineNode.Close()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, offlineNode, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		Host:                 computeNode,
+		OfflineBufferSize:    10,
+		OfflineRetryInterval: time.Hour,
+	})
+
+	received := make(chan string, 1)
+	NewCallbackHandler(CallbackHandlerParams{
+		Host: requesterNode,
+		Callback: compute.CallbackMock{
+			OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+				received <- result.ExecutionID
+			},
+		},
+	})
+
+	// the offline requester does not handle callbacks, so its callback waits for the next retry
+	offline := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: offlineNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: offline})
+	online := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e2"}, RoutingMetadata: online})
+
+	select {
+	case executionID := <-received:
+		require.Equal(t, "e2", executionID)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "callback to the reachable requester was held back")
+	}
+
+	// closing the proxy stops retrying the buffered callback
+	proxy.Close()
+	require.Eventually(t, func() bool {
+		proxy.mu.Lock()
+		defer proxy.mu.Unlock()
+		queue, ok := proxy.queues[offlineNode.ID()]
+		return ok && !queue.draining && len(queue.callbacks) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCallbackProxyBatchesAndCoalescesCallbacks(t *testing.T) {
+	ctx := context.Background()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	NewCallbackHandler(CallbackHandlerParams{
+		Host: requesterNode,
+		Callback: compute.CallbackMock{
+			OnBidCompleteHandler: func(ctx context.Context, result compute.BidResult) {
+				record("bid:" + result.ExecutionID + ":" + result.Reason)
+			},
+			OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+				record("run:" + result.ExecutionID)
+			},
+			OnPublishCompleteHandler: func(ctx context.Context, result compute.PublishResult) {
+				record("publish:" + result.ExecutionID)
+			},
+			OnComputeFailureHandler: func(ctx context.Context, result compute.ComputeError) {
+				record("failure:" + result.ExecutionID)
+			},
+		},
+	})
+	// wait for the compute node to learn that the requester handles batches
+	require.Eventually(t, func() bool {
+		supported, err := computeNode.Peerstore().SupportsProtocols(requesterNode.ID(), OnCallbackBatch)
+		return err == nil && len(supported) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		Host:          computeNode,
+		BatchInterval: 200 * time.Millisecond,
+	})
+	defer proxy.Close()
+
+	routing := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	execution := func(id string) compute.ExecutionMetadata {
+		return compute.ExecutionMetadata{ExecutionID: id}
+	}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: execution("e1"), RoutingMetadata: routing})
+	proxy.OnPublishComplete(ctx, compute.PublishResult{ExecutionMetadata: execution("e1"), RoutingMetadata: routing})
+	// the failure supersedes the completion of the same execution
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: execution("e2"), RoutingMetadata: routing})
+	proxy.OnComputeFailure(ctx, compute.ComputeError{ExecutionMetadata: execution("e2"), RoutingMetadata: routing})
+	// the later bid supersedes the earlier one
+	proxy.OnBidComplete(ctx, compute.BidResult{ExecutionMetadata: execution("e3"), RoutingMetadata: routing, Reason: "first"})
+	proxy.OnBidComplete(ctx, compute.BidResult{ExecutionMetadata: execution("e3"), RoutingMetadata: routing, Reason: "second"})
+
+	expected := []string{"run:e1", "publish:e1", "failure:e2", "bid:e3:second"}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) >= len(expected)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the callbacks were sent in a single batch, and so are handled in order
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, expected, events)
+}
+
+func TestCallbackProxySendsFullBatchesRightAway(t *testing.T) {
+	ctx := context.Background()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	received := make(chan string, 2)
+	NewCallbackHandler(CallbackHandlerParams{
+		Host: requesterNode,
+		Callback: compute.CallbackMock{
+			OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+				received <- result.ExecutionID
+			},
+		},
+	})
+
+	// the batch interval is long enough for the test to time out if the full batch waited for it
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		Host:          computeNode,
+		BatchInterval: time.Hour,
+		MaxBatchSize:  2,
+	})
+	defer proxy.Close()
+
+	routing := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: routing})
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e2"}, RoutingMetadata: routing})
+
+	var executionIDs []string
+	for len(executionIDs) < 2 {
+		select {
+		case executionID := <-received:
+			executionIDs = append(executionIDs, executionID)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "full batch was held back", executionIDs)
+		}
+	}
+	require.ElementsMatch(t, []string{"e1", "e2"}, executionIDs)
+}
+
+func TestCallbackProxyFlushesCallbacksWhenClosed(t *testing.T) {
+	ctx := context.Background()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	received := make(chan string, 2)
+	NewCallbackHandler(CallbackHandlerParams{
+		Host: requesterNode,
+		Callback: compute.CallbackMock{
+			OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+				received <- result.ExecutionID
+			},
+		},
+	})
+
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		Host:          computeNode,
+		BatchInterval: time.Hour,
+	})
+
+	routing := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: routing})
+
+	// closing sends the callback waiting for its batch to fill up, rather than dropping it
+	closed := make(chan struct{})
+	go func() {
+		proxy.Close()
+		close(closed)
+	}()
+	select {
+	case executionID := <-received:
+		require.Equal(t, "e1", executionID)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "callback was not flushed when closing")
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "closing did not return once callbacks were flushed")
+	}
+
+	// callbacks sent once closed are not held back either
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e2"}, RoutingMetadata: routing})
+	select {
+	case executionID := <-received:
+		require.Equal(t, "e2", executionID)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "callback sent after closing was held back")
+	}
+}
+
+func TestCallbackProxyRetriesFailedCallbacks(t *testing.T) {
+	ctx := context.Background()
+
+	requesterNode, err := libp2p.NewHostForTest(ctx)
+	require.NoError(t, err)
+	defer requesterNode.Close()
+
+	computeNode, err := libp2p.NewHostForTest(ctx, requesterNode)
+	require.NoError(t, err)
+	defer computeNode.Close()
+
+	proxy := NewCallbackProxy(CallbackProxyParams{Host: computeNode})
+	defer proxy.Close()
+
+	// the requester only handles callbacks once the first attempt at sending it failed
+	received := make(chan string, 1)
+	go func() {
+		time.Sleep(sendRetryBackoff / 2)
+		NewCallbackHandler(CallbackHandlerParams{
+			Host: requesterNode,
+			Callback: compute.CallbackMock{
+				OnRunCompleteHandler: func(ctx context.Context, result compute.RunResult) {
+					received <- result.ExecutionID
+				},
+			},
+		})
+	}()
+
+	routing := compute.RoutingMetadata{SourcePeerID: computeNode.ID().String(), TargetPeerID: requesterNode.ID().String()}
+	proxy.OnRunComplete(ctx, compute.RunResult{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "e1"}, RoutingMetadata: routing})
+
+	select {
+	case executionID := <-received:
+		require.Equal(t, "e1", executionID)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "failed callback was not retried")
+	}
+}
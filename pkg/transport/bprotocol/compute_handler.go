@@ -10,27 +10,45 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 )
 
 type ComputeHandlerParams struct {
 	Host            host.Host
 	ComputeEndpoint compute.Endpoint
+	// TrustedRequesters are the IDs of the requester peers allowed to reserve and release capacity on the node, and to
+	// forward the requests of its admins. Capacity can't be reserved, nor the node administered, by remote peers if
+	// empty.
+	TrustedRequesters []string
 }
 
 // ComputeHandler is a handler for compute requests that registers for incoming libp2p requests to Bacalhau compute
 // protocol, and delegates the requests to the compute endpoint.
 type ComputeHandler struct {
-	host            host.Host
-	computeEndpoint compute.Endpoint
+	host              host.Host
+	computeEndpoint   compute.Endpoint
+	trustedRequesters map[peer.ID]bool
 }
 
 type handlerWithResponse[Request, Response any] func(context.Context, Request) (Response, error)
 
+// remotePeerKey is the key of the context value holding the peer a request was received from.
+type remotePeerKey struct{}
+
 func NewComputeHandler(params ComputeHandlerParams) *ComputeHandler {
 	handler := &ComputeHandler{
-		host:            params.Host,
-		computeEndpoint: params.ComputeEndpoint,
+		host:              params.Host,
+		computeEndpoint:   params.ComputeEndpoint,
+		trustedRequesters: make(map[peer.ID]bool, len(params.TrustedRequesters)),
+	}
+	for _, requester := range params.TrustedRequesters {
+		requesterID, err := peer.Decode(requester)
+		if err != nil {
+			log.Error().Err(err).Msgf("ignoring invalid trusted requester %s", requester)
+			continue
+		}
+		handler.trustedRequesters[requesterID] = true
 	}
 
 	host := handler.host
@@ -41,6 +59,10 @@ func NewComputeHandler(params ComputeHandlerParams) *ComputeHandler {
 	host.SetStreamHandler(ResultRejectedProtocolID, handleWith(host, handler.computeEndpoint.ResultRejected))
 	host.SetStreamHandler(CancelProtocolID, handleWith(host, handler.computeEndpoint.CancelExecution))
 	host.SetStreamHandler(ExecutionLogsID, handleWith(host, handler.computeEndpoint.ExecutionLogs))
+	host.SetStreamHandler(ReserveCapacityID, handleFromPeers(host, handler.trustedRequesters, handler.reserveCapacity))
+	host.SetStreamHandler(ReleaseCapacityID, handleFromPeers(host, handler.trustedRequesters, handler.releaseCapacity))
+	host.SetStreamHandler(ExtendTimeoutID, handleWith(host, handler.computeEndpoint.ExtendTimeout))
+	host.SetStreamHandler(AdminNodeID, handleFromPeers(host, handler.trustedRequesters, handler.adminNode))
 	log.Debug().Msgf("ComputeHandler started on host %s", handler.host.ID().String())
 	return handler
 }
@@ -52,6 +74,47 @@ func handleWith[Request, Response any](host host.Host, f handlerWithResponse[Req
 	}
 }
 
+// handleFromPeers is like handleWith, but only serves requests from the given peers. The peer a request is received
+// from is kept in the context, so that requests can't claim to come from another peer.
+func handleFromPeers[Request, Response any](
+	host host.Host, peers map[peer.ID]bool, f handlerWithResponse[Request, Response]) func(network.Stream) {
+	return func(stream network.Stream) {
+		remote := stream.Conn().RemotePeer()
+		if !peers[remote] {
+			log.Warn().Msgf("refusing %s request from peer %s, which is not a trusted requester", stream.Protocol(), remote)
+			_ = stream.Reset()
+			return
+		}
+		ctx := logger.ContextWithNodeIDLogger(context.Background(), host.ID().String())
+		handleStream(context.WithValue(ctx, remotePeerKey{}, remote), stream, f)
+	}
+}
+
+// reserveCapacity holds capacity for the requester the request was received from.
+func (h *ComputeHandler) reserveCapacity(
+	ctx context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	request.SourcePeerID = remotePeer(ctx)
+	return h.computeEndpoint.ReserveCapacity(ctx, request)
+}
+
+// releaseCapacity releases capacity held for the requester the request was received from.
+func (h *ComputeHandler) releaseCapacity(
+	ctx context.Context, request compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	request.SourcePeerID = remotePeer(ctx)
+	return h.computeEndpoint.ReleaseCapacity(ctx, request)
+}
+
+// adminNode applies the changes of an admin forwarded by the requester the request was received from.
+func (h *ComputeHandler) adminNode(ctx context.Context, request compute.AdminNodeRequest) (compute.AdminNodeResponse, error) {
+	request.SourcePeerID = remotePeer(ctx)
+	return h.computeEndpoint.AdminNode(ctx, request)
+}
+
+func remotePeer(ctx context.Context) string {
+	remote, _ := ctx.Value(remotePeerKey{}).(peer.ID)
+	return remote.String()
+}
+
 func handleStream[Request, Response any](ctx context.Context, stream network.Stream, f handlerWithResponse[Request, Response]) {
 	if err := stream.Scope().SetService(ComputeServiceName); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("error attaching stream to compute service")
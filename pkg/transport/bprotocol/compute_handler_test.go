@@ -15,9 +15,10 @@ import (
 
 type ComputeProxyTestSuite struct {
 	suite.Suite
-	ctx     context.Context
-	handler *ComputeHandler
-	proxy   *ComputeProxy
+	ctx            context.Context
+	handler        *ComputeHandler
+	proxy          *ComputeProxy
+	untrustedProxy *ComputeProxy
 }
 
 func TestComputeProxyTestSuite(t *testing.T) {
@@ -33,13 +34,20 @@ func (s *ComputeProxyTestSuite) SetupSuite() {
 	proxyNode, err := libp2p.NewHostForTest(s.ctx, computeNode)
 	require.NoError(s.T(), err)
 
+	untrustedNode, err := libp2p.NewHostForTest(s.ctx, computeNode)
+	require.NoError(s.T(), err)
+
 	s.handler = NewComputeHandler(ComputeHandlerParams{
-		Host:            computeNode,
-		ComputeEndpoint: &TestEndpoint{},
+		Host:              computeNode,
+		ComputeEndpoint:   &TestEndpoint{},
+		TrustedRequesters: []string{proxyNode.ID().String()},
 	})
 	s.proxy = NewComputeProxy(ComputeProxyParams{
 		Host: proxyNode,
 	})
+	s.untrustedProxy = NewComputeProxy(ComputeProxyParams{
+		Host: untrustedNode,
+	})
 }
 
 type TestEndpoint struct{}
@@ -65,9 +73,22 @@ func (t *TestEndpoint) CancelExecution(context.Context, compute.CancelExecutionR
 func (t *TestEndpoint) ExecutionLogs(context.Context, compute.ExecutionLogsRequest) (compute.ExecutionLogsResponse, error) {
 	return compute.ExecutionLogsResponse{}, errors.New("No test implemenation")
 }
+func (t *TestEndpoint) ReserveCapacity(_ context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	return compute.ReserveCapacityResponse{Accepted: true, Reason: request.SourcePeerID}, nil
+}
+func (t *TestEndpoint) ReleaseCapacity(context.Context, compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	return compute.ReleaseCapacityResponse{}, errors.New("No test implemenation")
+}
+func (t *TestEndpoint) ExtendTimeout(context.Context, compute.ExtendTimeoutRequest) (compute.ExtendTimeoutResponse, error) {
+	return compute.ExtendTimeoutResponse{}, errors.New("No test implemenation")
+}
+func (t *TestEndpoint) AdminNode(_ context.Context, request compute.AdminNodeRequest) (compute.AdminNodeResponse, error) {
+	return compute.AdminNodeResponse{BiddingEnabled: request.SourcePeerID != ""}, nil
+}
 
 func (s *ComputeProxyTestSuite) TeardownSuite() {
 	s.proxy.host.Close()
+	s.untrustedProxy.host.Close()
 }
 
 // Gets the metadata for calling the compute node of the test
@@ -97,3 +118,27 @@ func (s *ComputeProxyTestSuite) TestSimpleSuccess() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), "test", response.ExecutionID)
 }
+
+func (s *ComputeProxyTestSuite) TestReserveCapacity_TrustedRequestersOnly() {
+	// the requester is the peer the request comes from, whatever the request claims
+	metadata := s.getRoutingMetadataForCompute()
+	metadata.SourcePeerID = "someone-else"
+	response, err := s.proxy.ReserveCapacity(s.ctx, compute.ReserveCapacityRequest{RoutingMetadata: metadata})
+	require.NoError(s.T(), err)
+	require.True(s.T(), response.Accepted)
+	require.Equal(s.T(), s.proxy.host.ID().String(), response.Reason)
+
+	metadata.SourcePeerID = s.untrustedProxy.host.ID().String()
+	_, err = s.untrustedProxy.ReserveCapacity(s.ctx, compute.ReserveCapacityRequest{RoutingMetadata: metadata})
+	require.Error(s.T(), err)
+}
+
+func (s *ComputeProxyTestSuite) TestAdminNode_TrustedRequestersOnly() {
+	metadata := s.getRoutingMetadataForCompute()
+	response, err := s.proxy.AdminNode(s.ctx, compute.AdminNodeRequest{RoutingMetadata: metadata, ClientID: "admin"})
+	require.NoError(s.T(), err)
+	require.True(s.T(), response.BiddingEnabled)
+
+	_, err = s.untrustedProxy.AdminNode(s.ctx, compute.AdminNodeRequest{RoutingMetadata: metadata, ClientID: "admin"})
+	require.Error(s.T(), err)
+}
@@ -116,6 +116,54 @@ func (p *ComputeProxy) ExecutionLogs(
 		ctx, p.host, request.TargetPeerID, ExecutionLogsID, request)
 }
 
+func (p *ComputeProxy) ReserveCapacity(
+	ctx context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ReserveCapacityResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ReserveCapacity(ctx, request)
+	}
+	return proxyRequest[compute.ReserveCapacityRequest, compute.ReserveCapacityResponse](
+		ctx, p.host, request.TargetPeerID, ReserveCapacityID, request)
+}
+
+func (p *ComputeProxy) ReleaseCapacity(
+	ctx context.Context, request compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ReleaseCapacityResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ReleaseCapacity(ctx, request)
+	}
+	return proxyRequest[compute.ReleaseCapacityRequest, compute.ReleaseCapacityResponse](
+		ctx, p.host, request.TargetPeerID, ReleaseCapacityID, request)
+}
+
+func (p *ComputeProxy) ExtendTimeout(
+	ctx context.Context, request compute.ExtendTimeoutRequest) (compute.ExtendTimeoutResponse, error) {
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ExtendTimeoutResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ExtendTimeout(ctx, request)
+	}
+	return proxyRequest[compute.ExtendTimeoutRequest, compute.ExtendTimeoutResponse](
+		ctx, p.host, request.TargetPeerID, ExtendTimeoutID, request)
+}
+
+func (p *ComputeProxy) AdminNode(
+	ctx context.Context, request compute.AdminNodeRequest) (compute.AdminNodeResponse, error) {
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.AdminNodeResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.AdminNode(ctx, request)
+	}
+	return proxyRequest[compute.AdminNodeRequest, compute.AdminNodeResponse](
+		ctx, p.host, request.TargetPeerID, AdminNodeID, request)
+}
+
 func proxyRequest[Request any, Response any](
 	ctx context.Context,
 	h host.Host,
@@ -9,6 +9,10 @@ const (
 	ResultRejectedProtocolID = "/bacalhau/compute/result_rejected/1.0.0"
 	CancelProtocolID         = "/bacalhau/compute/cancel/1.0.0"
 	ExecutionLogsID          = "/bacalhau/compute/executionlogs/1.0.0"
+	ReserveCapacityID        = "/bacalhau/compute/reserve_capacity/1.0.0"
+	ReleaseCapacityID        = "/bacalhau/compute/release_capacity/1.0.0"
+	ExtendTimeoutID          = "/bacalhau/compute/extend_timeout/1.0.0"
+	AdminNodeID              = "/bacalhau/compute/admin/1.0.0"
 
 	CallbackServiceName = "bacalhau.callback"
 	OnBidComplete       = "/bacalhau/callback/on_bid_complete/1.0.0"
@@ -16,4 +20,5 @@ const (
 	OnPublishComplete   = "/bacalhau/callback/on_publish_complete/1.0.0"
 	OnCancelComplete    = "/bacalhau/callback/on_cancel_complete/1.0.0"
 	OnComputeFailure    = "/bacalhau/callback/on_compute_failure/1.0.0"
+	OnCallbackBatch     = "/bacalhau/callback/batch/1.0.0"
 )
@@ -1,6 +1,11 @@
 package bprotocol
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
 
 type Result[T any] struct {
 	Response T
@@ -16,3 +21,14 @@ func (r *Result[T]) Rehydrate() (T, error) {
 
 	return r.Response, e
 }
+
+// callbackBatch carries callbacks to a requester in a single message, in the order they happened.
+type callbackBatch struct {
+	Callbacks []batchedCallback
+}
+
+// batchedCallback is a callback of a batch, with the protocol it is otherwise sent over.
+type batchedCallback struct {
+	ProtocolID protocol.ID
+	Data       json.RawMessage
+}
@@ -123,6 +123,54 @@ func (p *ComputeProxy) ExecutionLogs(
 		ctx, p.host, p.simulatorNodeID, bprotocol.CancelProtocolID, request)
 }
 
+func (p *ComputeProxy) ReserveCapacity(
+	ctx context.Context, request compute.ReserveCapacityRequest) (compute.ReserveCapacityResponse, error) {
+	if p.simulatorNodeID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ReserveCapacityResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ReserveCapacity(ctx, request)
+	}
+	return proxyRequest[compute.ReserveCapacityRequest, compute.ReserveCapacityResponse](
+		ctx, p.host, p.simulatorNodeID, bprotocol.ReserveCapacityID, request)
+}
+
+func (p *ComputeProxy) ReleaseCapacity(
+	ctx context.Context, request compute.ReleaseCapacityRequest) (compute.ReleaseCapacityResponse, error) {
+	if p.simulatorNodeID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ReleaseCapacityResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ReleaseCapacity(ctx, request)
+	}
+	return proxyRequest[compute.ReleaseCapacityRequest, compute.ReleaseCapacityResponse](
+		ctx, p.host, p.simulatorNodeID, bprotocol.ReleaseCapacityID, request)
+}
+
+func (p *ComputeProxy) ExtendTimeout(
+	ctx context.Context, request compute.ExtendTimeoutRequest) (compute.ExtendTimeoutResponse, error) {
+	if p.simulatorNodeID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ExtendTimeoutResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ExtendTimeout(ctx, request)
+	}
+	return proxyRequest[compute.ExtendTimeoutRequest, compute.ExtendTimeoutResponse](
+		ctx, p.host, p.simulatorNodeID, bprotocol.ExtendTimeoutID, request)
+}
+
+func (p *ComputeProxy) AdminNode(
+	ctx context.Context, request compute.AdminNodeRequest) (compute.AdminNodeResponse, error) {
+	if p.simulatorNodeID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.AdminNodeResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.AdminNode(ctx, request)
+	}
+	return proxyRequest[compute.AdminNodeRequest, compute.AdminNodeResponse](
+		ctx, p.host, p.simulatorNodeID, bprotocol.AdminNodeID, request)
+}
+
 func proxyRequest[Request any, Response any](
 	ctx context.Context,
 	h host.Host,
@@ -0,0 +1,232 @@
+package archivefs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-unixfsnode"
+	"github.com/ipfs/go-unixfsnode/data"
+	unixfsfile "github.com/ipfs/go-unixfsnode/file"
+	"github.com/ipld/go-car/v2/blockstore"
+	dagpb "github.com/ipld/go-codec-dagpb"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/schema"
+)
+
+// carFS is a view of the UnixFS DAG rooted at the single root of a CAR file. The blocks of the CAR are indexed when
+// it is opened, and read from it as the DAG is walked.
+type carFS struct {
+	store   *blockstore.ReadOnly
+	ls      ipld.LinkSystem
+	root    cid.Cid
+	modTime time.Time
+}
+
+// carNode is a node of the DAG, which is a file, a directory or a symbolic link.
+type carNode struct {
+	info *fileInfo
+	// raw is the content of files stored as a single raw block.
+	raw []byte
+	// pbnode is the UnixFS node of files and directories that are not raw blocks.
+	pbnode dagpb.PBNode
+}
+
+func newCarFS(archive string) (*carFS, error) {
+	stat, err := os.Stat(archive)
+	if err != nil {
+		return nil, err
+	}
+	store, err := blockstore.OpenReadOnly(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index CAR archive %s: %w", archive, err)
+	}
+	roots, err := store.Roots()
+	if err == nil && len(roots) != 1 {
+		err = fmt.Errorf("CAR archive %s has %d roots, expected one", archive, len(roots))
+	}
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	c := &carFS{store: store, root: roots[0], modTime: stat.ModTime()}
+	c.ls = cidlink.DefaultLinkSystem()
+	c.ls.StorageReadOpener = func(lctx ipld.LinkContext, l ipld.Link) (io.Reader, error) {
+		cl, ok := l.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("not a cidlink")
+		}
+		block, err := store.Get(lctx.Ctx, cl.Cid)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(block.RawData()), nil
+	}
+	return c, nil
+}
+
+// Open implements fs.FS
+func (c *carFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	ctx := context.Background()
+	node, err := c.resolve(ctx, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if node.info.IsDir() {
+		entries, err := c.list(ctx, node)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &dir{info: node.info, entries: entries}, nil
+	}
+	if node.raw != nil {
+		return newFile(bytes.NewReader(node.raw), node.info, nil), nil
+	}
+	contents, err := unixfsfile.NewUnixFSFile(ctx, node.pbnode, &c.ls)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	reader, err := contents.AsLargeBytes()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return newFile(reader, node.info, nil), nil
+}
+
+// resolve walks the DAG from the root to the node at the name.
+func (c *carFS) resolve(ctx context.Context, name string) (*carNode, error) {
+	node, err := c.load(ctx, cidlink.Link{Cid: c.root}, ".")
+	if err != nil || name == "." {
+		return node, err
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !node.info.IsDir() {
+			return nil, fs.ErrNotExist
+		}
+		directory, err := unixfsnode.Reify(ipld.LinkContext{Ctx: ctx}, node.pbnode, &c.ls)
+		if err != nil {
+			return nil, err
+		}
+		value, err := directory.LookupByString(component)
+		if errors.As(err, &schema.ErrNoSuchField{}) {
+			return nil, fs.ErrNotExist
+		} else if err != nil {
+			return nil, err
+		}
+		link, err := value.AsLink()
+		if err != nil {
+			return nil, err
+		}
+		if node, err = c.load(ctx, link, component); err != nil {
+			return nil, err
+		}
+	}
+	if node.info.Mode()&fs.ModeSymlink != 0 {
+		return nil, fs.ErrNotExist
+	}
+	return node, nil
+}
+
+// list returns the entries of the directory, leaving out symbolic links.
+func (c *carFS) list(ctx context.Context, node *carNode) ([]fs.DirEntry, error) {
+	directory, err := unixfsnode.Reify(ipld.LinkContext{Ctx: ctx}, node.pbnode, &c.ls)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fs.DirEntry
+	iterator := directory.MapIterator()
+	for !iterator.Done() {
+		key, value, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		name, err := key.AsString()
+		if err != nil {
+			return nil, err
+		}
+		// names come from the DAG, so leave out the ones that are not valid file names
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			continue
+		}
+		link, err := value.AsLink()
+		if err != nil {
+			return nil, err
+		}
+		child, err := c.load(ctx, link, name)
+		if err != nil {
+			return nil, err
+		}
+		if child.info.Mode()&fs.ModeSymlink == 0 {
+			entries = append(entries, child.info)
+		}
+	}
+	return entries, nil
+}
+
+// load loads the top block of the node at the link, which is enough to tell what it is and how large it is.
+func (c *carFS) load(ctx context.Context, link ipld.Link, name string) (*carNode, error) {
+	n, err := c.ls.Load(ipld.LinkContext{Ctx: ctx}, link, basicnode.Prototype.Any)
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{name: name, mode: 0o444, modTime: c.modTime}
+
+	// raw leaves hold the file contents directly
+	if n.Kind() == ipld.Kind_Bytes {
+		contents, err := n.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		info.size = int64(len(contents))
+		return &carNode{info: info, raw: contents}, nil
+	}
+
+	builder := dagpb.Type.PBNode.NewBuilder()
+	if err = builder.AssignNode(n); err != nil {
+		return nil, fmt.Errorf("%s is neither raw nor dag-pb: %w", link, err)
+	}
+	pbnode := builder.Build().(dagpb.PBNode) //nolint:errcheck // the builder always builds a PBNode
+	if !pbnode.FieldData().Exists() {
+		return nil, fmt.Errorf("%s is not a UnixFS node", link)
+	}
+	ufsNode, err := data.DecodeUnixFSData(pbnode.FieldData().Must().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	switch ufsNode.DataType.Int() {
+	case data.Data_Directory, data.Data_HAMTShard:
+		info.mode = fs.ModeDir | 0o555
+	case data.Data_File, data.Data_Raw:
+		if ufsNode.FileSize.Exists() {
+			info.size = ufsNode.FileSize.Must().Int()
+		} else if ufsNode.Data.Exists() {
+			info.size = int64(len(ufsNode.Data.Must().Bytes()))
+		}
+	case data.Data_Symlink:
+		info.mode = fs.ModeSymlink | 0o444
+	default:
+		return nil, fmt.Errorf("%s has unsupported UnixFS type %s", link, data.DataTypeNames[ufsNode.DataType.Int()])
+	}
+	return &carNode{info: info, pbnode: pbnode}, nil
+}
+
+// Close implements io.Closer
+func (c *carFS) Close() error {
+	return c.store.Close()
+}
@@ -0,0 +1,220 @@
+// archivefs provides read-only fs.FS views of the contents of tar archives, optionally gzipped, and of CAR files
+// holding a UnixFS DAG. Files are read out of the archive as they are opened, rather than the archive being extracted
+// to disk first:
+//
+//   - plain tar archives are indexed once, and files are read at their offset in the archive
+//   - gzipped tar archives are indexed once, and files are decompressed from the start of the archive when opened
+//   - CAR files are indexed once, and the blocks of files are read as they are read
+//
+// Symbolic links in archives are not followed, and are left out of their views.
+package archivefs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	carv2 "github.com/ipld/go-car/v2"
+)
+
+// Format is the format of an archive.
+type Format string
+
+const (
+	FormatTar     Format = "tar"
+	FormatTarGzip Format = "tar.gz"
+	FormatCAR     Format = "car"
+)
+
+// ErrNotArchive is returned for files that are not in a supported archive format.
+var ErrNotArchive = errors.New("not a tar, gzipped tar or CAR archive")
+
+// FS is a read-only view of the contents of an archive, which must be closed once it is no longer used.
+type FS interface {
+	fs.FS
+	io.Closer
+}
+
+// Open returns a view of the contents of the archive at the path, whose format is detected from its content.
+func Open(archive string) (FS, error) {
+	format, err := DetectFormat(archive)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatTar:
+		return newTarFS(archive, false)
+	case FormatTarGzip:
+		return newTarFS(archive, true)
+	default:
+		return newCarFS(archive)
+	}
+}
+
+const (
+	tarMagicOffset = 257
+	tarBlockSize   = 512
+)
+
+// DetectFormat returns the format of the archive at the path, or ErrNotArchive.
+func DetectFormat(archive string) (Format, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	if magic, _ := reader.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", err
+		}
+		if isTar(gz) {
+			return FormatTarGzip, nil
+		}
+		return "", ErrNotArchive
+	}
+	if header, _ := reader.Peek(tarBlockSize); isTar(bytes.NewReader(header)) {
+		return FormatTar, nil
+	}
+	if _, err = carv2.ReadVersion(reader); err == nil {
+		return FormatCAR, nil
+	}
+	return "", ErrNotArchive
+}
+
+// isTar returns true if the reader starts with a ustar or GNU tar header.
+func isTar(r io.Reader) bool {
+	header := make([]byte, tarBlockSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return false
+	}
+	return bytes.HasPrefix(header[tarMagicOffset:], []byte("ustar"))
+}
+
+// fileInfo describes the files and directories of archives.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string               { return i.name }
+func (i *fileInfo) Size() int64                { return i.size }
+func (i *fileInfo) Mode() fs.FileMode          { return i.mode }
+func (i *fileInfo) ModTime() time.Time         { return i.modTime }
+func (i *fileInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i *fileInfo) Sys() interface{}           { return nil }
+func (i *fileInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i *fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// readOnly strips the write permissions of the mode, as views of archives are read-only.
+func readOnly(mode fs.FileMode) fs.FileMode {
+	return mode &^ 0o222
+}
+
+// newFile returns an open file of an archive reading its content from the reader, which can be seeked if the
+// reader can. The closer is closed with the file, if any.
+func newFile(reader io.Reader, info *fileInfo, closer io.Closer) fs.File {
+	f := &file{Reader: reader, info: info, closer: closer}
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		return &seekableFile{file: f, seeker: seeker}
+	}
+	return f
+}
+
+// file is an open file of an archive.
+type file struct {
+	io.Reader
+	info   *fileInfo
+	closer io.Closer
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// seekableFile is an open file of an archive whose content can be read from any offset. It keeps track of the
+// offset itself, as not every reader of archives seeks relative to the current offset correctly.
+type seekableFile struct {
+	*file
+	seeker io.ReadSeeker
+	offset int64
+}
+
+func (f *seekableFile) Read(p []byte) (int, error) {
+	n, err := f.seeker.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *seekableFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	case io.SeekStart:
+	default:
+		return f.offset, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return f.offset, fmt.Errorf("%s can't be seeked to negative offset %d", f.info.name, offset)
+	}
+	if _, err := f.seeker.Seek(offset, io.SeekStart); err != nil {
+		return f.offset, err
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// dir is an open directory of an archive.
+type dir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,183 @@
+//go:build unit || !integration
+
+package archivefs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/ipfs/car"
+)
+
+// largeFileSize is large enough for the file to span many blocks of a CAR.
+const largeFileSize = 1024*1024 + 7
+
+type archiveFSSuite struct {
+	suite.Suite
+	dir   string
+	large []byte
+}
+
+func TestArchiveFSSuite(t *testing.T) {
+	suite.Run(t, new(archiveFSSuite))
+}
+
+func (s *archiveFSSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.large = make([]byte, largeFileSize)
+	for i := range s.large {
+		s.large[i] = byte(i % 251)
+	}
+}
+
+func (s *archiveFSSuite) writeTar(name string, gzipped bool) string {
+	archive := filepath.Join(s.dir, name)
+	f, err := os.Create(archive)
+	s.Require().NoError(err)
+	defer f.Close()
+
+	var w io.Writer = f
+	if gzipped {
+		gz := gzip.NewWriter(f)
+		defer func() { s.Require().NoError(gz.Close()) }()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	defer func() { s.Require().NoError(tw.Close()) }()
+
+	writeFile := func(name string, content []byte) {
+		s.Require().NoError(tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0o644,
+		}))
+		_, err := tw.Write(content)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "data/", Mode: 0o755}))
+	writeFile("data/hello.txt", []byte("hello"))
+	writeFile("data/large.bin", s.large)
+	// the parent directory of this file is not in the archive
+	writeFile("./nested/deep/file.txt", []byte("deep"))
+	s.Require().NoError(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "data/link", Linkname: "hello.txt"}))
+	s.Require().NoError(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeLink, Name: "hardlink.txt", Linkname: "data/hello.txt"}))
+	// later entries replace earlier ones
+	writeFile("data/hello.txt", []byte("hello again"))
+	return archive
+}
+
+func (s *archiveFSSuite) requireContents(archiveFS fs.FS) {
+	s.Require().NoError(fstest.TestFS(archiveFS, "data/hello.txt", "data/large.bin", "nested/deep/file.txt", "hardlink.txt"))
+
+	content, err := fs.ReadFile(archiveFS, "data/hello.txt")
+	s.Require().NoError(err)
+	s.Require().Equal("hello again", string(content))
+
+	content, err = fs.ReadFile(archiveFS, "data/large.bin")
+	s.Require().NoError(err)
+	s.Require().Equal(s.large, content)
+
+	content, err = fs.ReadFile(archiveFS, "hardlink.txt")
+	s.Require().NoError(err)
+	s.Require().Equal("hello", string(content))
+
+	_, err = archiveFS.Open("data/link")
+	s.Require().ErrorIs(err, fs.ErrNotExist)
+
+	stat, err := fs.Stat(archiveFS, "data/large.bin")
+	s.Require().NoError(err)
+	s.Require().Equal(int64(largeFileSize), stat.Size())
+	s.Require().Zero(stat.Mode().Perm() & 0o222)
+}
+
+func (s *archiveFSSuite) TestTar() {
+	archive := s.writeTar("archive.tar", false)
+	format, err := DetectFormat(archive)
+	s.Require().NoError(err)
+	s.Require().Equal(FormatTar, format)
+
+	archiveFS, err := Open(archive)
+	s.Require().NoError(err)
+	defer archiveFS.Close()
+	s.requireContents(archiveFS)
+
+	// files of plain tar archives are read in place, so they can be seeked
+	f, err := archiveFS.Open("data/large.bin")
+	s.Require().NoError(err)
+	defer f.Close()
+	_, err = f.(io.Seeker).Seek(largeFileSize-3, io.SeekStart)
+	s.Require().NoError(err)
+	tail, err := io.ReadAll(f)
+	s.Require().NoError(err)
+	s.Require().Equal(s.large[largeFileSize-3:], tail)
+}
+
+func (s *archiveFSSuite) TestTarGzip() {
+	archive := s.writeTar("archive.tar.gz", true)
+	format, err := DetectFormat(archive)
+	s.Require().NoError(err)
+	s.Require().Equal(FormatTarGzip, format)
+
+	archiveFS, err := Open(archive)
+	s.Require().NoError(err)
+	defer archiveFS.Close()
+	s.requireContents(archiveFS)
+}
+
+func (s *archiveFSSuite) TestCAR() {
+	input := filepath.Join(s.dir, "input")
+	s.Require().NoError(os.MkdirAll(filepath.Join(input, "data"), 0o755))
+	s.Require().NoError(os.MkdirAll(filepath.Join(input, "nested", "deep"), 0o755))
+	s.Require().NoError(os.WriteFile(filepath.Join(input, "data", "hello.txt"), []byte("hello again"), 0o644))
+	s.Require().NoError(os.WriteFile(filepath.Join(input, "data", "large.bin"), s.large, 0o644))
+	s.Require().NoError(os.WriteFile(filepath.Join(input, "nested", "deep", "file.txt"), []byte("deep"), 0o644))
+	s.Require().NoError(os.WriteFile(filepath.Join(input, "hardlink.txt"), []byte("hello"), 0o644))
+	s.Require().NoError(os.Symlink("hello.txt", filepath.Join(input, "data", "link")))
+
+	for _, version := range []int{1, 2} {
+		archive := filepath.Join(s.dir, "archive.car")
+		_, err := car.CreateCar(context.Background(), input, archive, version)
+		s.Require().NoError(err)
+
+		format, err := DetectFormat(archive)
+		s.Require().NoError(err)
+		s.Require().Equal(FormatCAR, format)
+
+		archiveFS, err := Open(archive)
+		s.Require().NoError(err)
+		s.requireContents(archiveFS)
+		s.Require().NoError(archiveFS.Close())
+		s.Require().NoError(os.Remove(archive))
+	}
+}
+
+func (s *archiveFSSuite) TestNotArchive() {
+	for name, content := range map[string][]byte{
+		"text.txt": []byte("just some text"),
+		"empty":    nil,
+		"gzip.gz":  gzipped(s.T(), []byte("not a tar")),
+	} {
+		file := filepath.Join(s.dir, name)
+		s.Require().NoError(os.WriteFile(file, content, 0o644))
+		_, err := Open(file)
+		s.Require().ErrorIs(err, ErrNotArchive, name)
+	}
+}
+
+func gzipped(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,233 @@
+package archivefs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// tarEntry is a file or directory indexed in a tar archive.
+type tarEntry struct {
+	info *fileInfo
+	// index is the position of the header of the file in the archive, used to find it again when streaming.
+	index int
+	// offset is where the content of the file starts in the archive, or -1 if the file can't be read in place and
+	// has to be streamed from the start of the archive.
+	offset int64
+	// children are the names of the entries of a directory.
+	children map[string]*tarEntry
+}
+
+type tarFS struct {
+	archive string
+	gzipped bool
+	root    *tarEntry
+	entries map[string]*tarEntry
+}
+
+func newTarFS(archive string, gzipped bool) (*tarFS, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tarFS{
+		archive: archive,
+		gzipped: gzipped,
+		root: &tarEntry{
+			info:     &fileInfo{name: ".", mode: fs.ModeDir | 0o555, modTime: stat.ModTime()},
+			children: map[string]*tarEntry{},
+		},
+	}
+	t.entries = map[string]*tarEntry{".": t.root}
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// the reader reads headers from the file without buffering, so the position of the file after a header is
+	// where the content of that file starts
+	reader := tar.NewReader(r)
+	for index := 0; ; index++ {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to index tar archive %s: %w", archive, err)
+		}
+
+		offset := int64(-1)
+		if !gzipped && !isSparse(header) {
+			if offset, err = f.Seek(0, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		t.add(header, index, offset)
+	}
+}
+
+// isSparse returns true if the content of the file is not stored as is in the archive.
+func isSparse(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tarFS) add(header *tar.Header, index int, offset int64) {
+	name := path.Clean(strings.TrimLeft(header.Name, "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		entry := t.dir(name)
+		entry.info.mode = fs.ModeDir | readOnly(header.FileInfo().Mode().Perm())
+		entry.info.modTime = header.ModTime
+	case tar.TypeReg, tar.TypeGNUSparse:
+		t.put(name, &tarEntry{
+			info: &fileInfo{
+				name:    path.Base(name),
+				size:    header.Size,
+				mode:    readOnly(header.FileInfo().Mode().Perm()),
+				modTime: header.ModTime,
+			},
+			index:  index,
+			offset: offset,
+		})
+	case tar.TypeLink:
+		// hard links share the content of a file earlier in the archive
+		target, ok := t.entries[path.Clean(strings.TrimLeft(header.Linkname, "/"))]
+		if !ok || target.children != nil {
+			return
+		}
+		info := *target.info
+		info.name = path.Base(name)
+		t.put(name, &tarEntry{info: &info, index: target.index, offset: target.offset})
+	}
+}
+
+// dir returns the directory at the name, adding it and its parents if they are not in the index yet.
+func (t *tarFS) dir(name string) *tarEntry {
+	if entry, ok := t.entries[name]; ok && entry.children != nil {
+		return entry
+	}
+	entry := &tarEntry{
+		info:     &fileInfo{name: path.Base(name), mode: fs.ModeDir | 0o555, modTime: t.root.info.modTime},
+		children: map[string]*tarEntry{},
+	}
+	t.put(name, entry)
+	return entry
+}
+
+// put adds the entry at the name, replacing the entry already there as later entries of tar archives win.
+func (t *tarFS) put(name string, entry *tarEntry) {
+	parent := t.dir(path.Dir(name))
+	parent.children[path.Base(name)] = entry
+	t.entries[name] = entry
+}
+
+// Open implements fs.FS
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if entry.children != nil {
+		names := make([]string, 0, len(entry.children))
+		for child := range entry.children {
+			names = append(names, child)
+		}
+		sort.Strings(names)
+		entries := make([]fs.DirEntry, 0, len(names))
+		for _, child := range names {
+			entries = append(entries, entry.children[child].info)
+		}
+		return &dir{info: entry.info, entries: entries}, nil
+	}
+
+	f, err := os.Open(t.archive)
+	if err != nil {
+		return nil, err
+	}
+	if entry.offset >= 0 {
+		return newFile(io.NewSectionReader(f, entry.offset, entry.info.size), entry.info, f), nil
+	}
+
+	reader, closer, err := t.stream(f, entry.index)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return newFile(reader, entry.info, closer), nil
+}
+
+// stream reads the archive from the start up to the header at the index, and returns a reader of its content. The
+// file is closed if that fails.
+func (t *tarFS) stream(f *os.File, index int) (io.Reader, io.Closer, error) {
+	var r io.Reader = f
+	closer := closers{f}
+	if t.gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		r = gz
+		closer = closers{gz, f}
+	}
+
+	reader := tar.NewReader(r)
+	for i := 0; i <= index; i++ {
+		if _, err := reader.Next(); err != nil {
+			closer.Close()
+			return nil, nil, fmt.Errorf("failed to find file in tar archive %s: %w", t.archive, err)
+		}
+	}
+	return reader, closer, nil
+}
+
+// Close implements io.Closer. Files are opened as they are read, so there is nothing to close.
+func (t *tarFS) Close() error {
+	return nil
+}
+
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var err error
+	for _, closer := range c {
+		err = multierr.Append(err, closer.Close())
+	}
+	return err
+}
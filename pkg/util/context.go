@@ -12,6 +12,16 @@ func NewDetachedContext(parent context.Context) context.Context {
 	return detachedContext{parent: parent}
 }
 
+// CleanupGracePeriod is how long cleaning up after a canceled operation is given, such as stopping a container or
+// unpinning a partial result, so that cancellations complete in bounded time.
+const CleanupGracePeriod = 10 * time.Second
+
+// NewCleanupContext produces a context detached from its parent that times out after CleanupGracePeriod, to clean up
+// after work whose context may already have been canceled.
+func NewCleanupContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(NewDetachedContext(parent), CleanupGracePeriod)
+}
+
 var _ context.Context = detachedContext{}
 
 type detachedContext struct {
@@ -5,6 +5,7 @@ package util
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,3 +35,16 @@ func TestDetachedContext_Deadline_separateFromParent(t *testing.T) {
 	assert.Error(t, parentCtx.Err())
 	assert.NoError(t, ctx.Err())
 }
+
+func TestCleanupContext_outlivesParentWithinGracePeriod(t *testing.T) {
+	parentCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctx, cleanupCancel := NewCleanupContext(parentCtx)
+	defer cleanupCancel()
+
+	assert.NoError(t, ctx.Err())
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(CleanupGracePeriod), deadline, time.Second)
+}
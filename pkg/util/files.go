@@ -0,0 +1,47 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+)
+
+// CopyFile copies the contents of source to target, which is created with perm if it does not exist yet
+// and truncated otherwise.
+func CopyFile(source, target string, perm fs.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError("file", in)
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError("file", out)
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// WriteSymlink creates the symlink, unless it already exists. Link targets that come from the network can't be
+// trusted, so links that are absolute or lead outside of root are refused.
+func WriteSymlink(linkTarget, target, root string) error {
+	if filepath.IsAbs(linkTarget) {
+		return fmt.Errorf("failed to write symlink '%s': absolute target %q", target, linkTarget)
+	}
+	rel, err := filepath.Rel(root, filepath.Join(filepath.Dir(target), linkTarget))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("failed to write symlink '%s': target %q is outside of '%s'", target, linkTarget, root)
+	}
+	if existing, err := os.Readlink(target); err == nil && existing == linkTarget {
+		return nil
+	}
+	return os.Symlink(linkTarget, target)
+}
@@ -0,0 +1,48 @@
+//go:build unit || !integration
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSymlink(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir"), 0755))
+
+	require.NoError(t, WriteSymlink("../file", filepath.Join(root, "dir", "inside"), root))
+	require.NoError(t, WriteSymlink("../file", filepath.Join(root, "dir", "inside"), root), "existing links are kept")
+	require.NoError(t, WriteSymlink("dir", filepath.Join(root, "sibling"), root))
+	require.Error(t, WriteSymlink("../../file", filepath.Join(root, "dir", "escape"), root))
+	require.Error(t, WriteSymlink("..", filepath.Join(root, "parent"), root))
+	require.Error(t, WriteSymlink("/etc/passwd", filepath.Join(root, "absolute"), root))
+	require.NoFileExists(t, filepath.Join(root, "dir", "escape"))
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	require.NoError(t, os.WriteFile(source, []byte("contents"), 0600))
+
+	target := filepath.Join(dir, "target")
+	require.NoError(t, CopyFile(source, target, 0640))
+	contents, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "contents", string(contents))
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// existing files are truncated
+	require.NoError(t, os.WriteFile(source, []byte("new"), 0600))
+	require.NoError(t, CopyFile(source, target, 0640))
+	contents, err = os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(contents))
+
+	require.Error(t, CopyFile(filepath.Join(dir, "missing"), target, 0640))
+}
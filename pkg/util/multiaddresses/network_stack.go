@@ -0,0 +1,133 @@
+package multiaddresses
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/exp/slices"
+)
+
+// NetworkStack is the IP versions that a node listens on and advertises.
+type NetworkStack string
+
+const (
+	NetworkStackIPv4 NetworkStack = "ipv4"
+	NetworkStackIPv6 NetworkStack = "ipv6"
+	NetworkStackDual NetworkStack = "dual"
+)
+
+// NetworkStacks lists the valid network stacks, for use in flag descriptions.
+var NetworkStacks = []NetworkStack{NetworkStackIPv4, NetworkStackIPv6, NetworkStackDual}
+
+// ParseNetworkStack parses a network stack name, defaulting to dual-stack if it is empty.
+func ParseNetworkStack(value string) (NetworkStack, error) {
+	if value == "" {
+		return NetworkStackDual, nil
+	}
+	stack := NetworkStack(strings.ToLower(value))
+	if !slices.Contains(NetworkStacks, stack) {
+		return "", fmt.Errorf("unknown network stack %q, expected one of %v", value, NetworkStacks)
+	}
+	return stack, nil
+}
+
+func (s NetworkStack) String() string {
+	return string(s)
+}
+
+func (s NetworkStack) hasIPv4() bool {
+	return s != NetworkStackIPv6
+}
+
+func (s NetworkStack) hasIPv6() bool {
+	return s != NetworkStackIPv4
+}
+
+// UnspecifiedHost returns the host to bind servers to so that they accept connections on every interface of the
+// stack. Binding to the IPv6 unspecified address also accepts IPv4 connections, so it is used for dual-stack too.
+func (s NetworkStack) UnspecifiedHost() string {
+	if s == NetworkStackIPv4 {
+		return "0.0.0.0"
+	}
+	return "::"
+}
+
+// LoopbackHost returns the host to reach servers on the local machine through, preferring IPv4 unless it is disabled.
+func (s NetworkStack) LoopbackHost() string {
+	if s == NetworkStackIPv6 {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
+
+// ListenAddresses returns the TCP and QUIC multiaddresses to listen on every interface of the stack with the port.
+func (s NetworkStack) ListenAddresses(port int) []string {
+	var addrs []string
+	if s.hasIPv4() {
+		addrs = append(addrs,
+			fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port),
+			fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic", port),
+			fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", port),
+		)
+	}
+	if s.hasIPv6() {
+		addrs = append(addrs,
+			fmt.Sprintf("/ip6/::/tcp/%d", port),
+			fmt.Sprintf("/ip6/::/udp/%d/quic", port),
+			fmt.Sprintf("/ip6/::/udp/%d/quic-v1", port),
+		)
+	}
+	return addrs
+}
+
+// Supports returns true if the multiaddress uses an IP version of the stack. Addresses that are not IP addresses,
+// such as DNS names, are always supported.
+func (s NetworkStack) Supports(m multiaddr.Multiaddr) bool {
+	if _, err := m.ValueForProtocol(multiaddr.P_IP4); err == nil {
+		return s.hasIPv4()
+	}
+	if _, err := m.ValueForProtocol(multiaddr.P_IP6); err == nil {
+		return s.hasIPv6()
+	}
+	return true
+}
+
+// Filter returns the multiaddresses that are supported by the stack, dropping those that are invalid.
+func (s NetworkStack) Filter(addrs []string) []string {
+	var filtered []string
+	for _, addr := range addrs {
+		m, err := multiaddr.NewMultiaddr(addr)
+		if err == nil && s.Supports(m) {
+			filtered = append(filtered, addr)
+		}
+	}
+	return filtered
+}
+
+// HostComponent returns the multiaddress component for a host, which is /ip4 or /ip6 for IP addresses depending on
+// their version, and /dns for names.
+func HostComponent(host string) string {
+	host = strings.Trim(host, "[]")
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "/dns/" + host
+	case ip.To4() != nil:
+		return "/ip4/" + ip.String()
+	default:
+		return "/ip6/" + ip.String()
+	}
+}
+
+// IsLoopback returns true if the multiaddress is an IPv4 or IPv6 loopback address.
+func IsLoopback(m multiaddr.Multiaddr) bool {
+	for _, code := range []int{multiaddr.P_IP4, multiaddr.P_IP6} {
+		if value, err := m.ValueForProtocol(code); err == nil {
+			ip := net.ParseIP(value)
+			return ip != nil && ip.IsLoopback()
+		}
+	}
+	return false
+}
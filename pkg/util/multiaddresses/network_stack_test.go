@@ -0,0 +1,81 @@
+//go:build unit || !integration
+
+package multiaddresses
+
+import (
+	"testing"
+
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworkStack(t *testing.T) {
+	for value, expected := range map[string]NetworkStack{
+		"":     NetworkStackDual,
+		"ipv4": NetworkStackIPv4,
+		"IPv6": NetworkStackIPv6,
+		"dual": NetworkStackDual,
+	} {
+		stack, err := ParseNetworkStack(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, stack)
+	}
+
+	_, err := ParseNetworkStack("ipx")
+	require.Error(t, err)
+}
+
+func TestNetworkStackListenAddresses(t *testing.T) {
+	require.Equal(t, []string{
+		"/ip4/0.0.0.0/tcp/1234", "/ip4/0.0.0.0/udp/1234/quic", "/ip4/0.0.0.0/udp/1234/quic-v1",
+	}, NetworkStackIPv4.ListenAddresses(1234))
+	require.Equal(t, []string{
+		"/ip6/::/tcp/1234", "/ip6/::/udp/1234/quic", "/ip6/::/udp/1234/quic-v1",
+	}, NetworkStackIPv6.ListenAddresses(1234))
+	require.Len(t, NetworkStackDual.ListenAddresses(1234), 6)
+
+	for _, stack := range NetworkStacks {
+		for _, addr := range stack.ListenAddresses(1234) {
+			_, err := multiaddr.NewMultiaddr(addr)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestNetworkStackFilter(t *testing.T) {
+	addrs := []string{"/ip4/127.0.0.1/tcp/1", "/ip6/::1/tcp/1", "/dns/example.com/tcp/1", "not-a-multiaddr"}
+	require.Equal(t, []string{"/ip4/127.0.0.1/tcp/1", "/dns/example.com/tcp/1"}, NetworkStackIPv4.Filter(addrs))
+	require.Equal(t, []string{"/ip6/::1/tcp/1", "/dns/example.com/tcp/1"}, NetworkStackIPv6.Filter(addrs))
+	require.Equal(t, addrs[:3], NetworkStackDual.Filter(addrs))
+}
+
+func TestHostComponent(t *testing.T) {
+	require.Equal(t, "/ip4/192.168.1.1", HostComponent("192.168.1.1"))
+	require.Equal(t, "/ip6/2001:db8::1", HostComponent("2001:db8::1"))
+	require.Equal(t, "/ip6/2001:db8::1", HostComponent("[2001:db8::1]"))
+	require.Equal(t, "/ip4/10.0.0.1", HostComponent("::ffff:10.0.0.1"))
+	require.Equal(t, "/dns/example.com", HostComponent("example.com"))
+
+	for _, host := range []string{"192.168.1.1", "2001:db8::1", "example.com"} {
+		_, err := multiaddr.NewMultiaddr(HostComponent(host) + "/tcp/0")
+		require.NoError(t, err)
+	}
+}
+
+func TestSortLocalhostFirst(t *testing.T) {
+	parse := func(addrs ...string) []multiaddr.Multiaddr {
+		var res []multiaddr.Multiaddr
+		for _, addr := range addrs {
+			res = append(res, multiaddr.StringCast(addr))
+		}
+		return res
+	}
+
+	sorted := SortLocalhostFirst(parse("/ip6/2001:db8::1/tcp/1", "/ip4/10.0.0.1/tcp/1", "/ip6/::1/tcp/1", "/ip4/127.0.0.1/tcp/1"))
+	require.Equal(t, "/ip4/127.0.0.1/tcp/1", sorted[0].String())
+	require.Equal(t, "/ip6/::1/tcp/1", sorted[1].String())
+
+	// IPv6-only hosts connect over the IPv6 loopback
+	sorted = SortLocalhostFirst(parse("/ip6/2001:db8::1/tcp/1", "/ip6/::1/udp/1/quic", "/ip6/::1/tcp/1"))
+	require.Equal(t, "/ip6/::1/tcp/1", sorted[0].String())
+}
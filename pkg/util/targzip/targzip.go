@@ -26,7 +26,17 @@ func Compress(ctx context.Context, src string, buf io.Writer) error {
 }
 
 func Decompress(src io.Reader, dst string) error {
-	return decompress(src, dst, MaximumContextSize)
+	return decompress(src, dst, MaximumContextSize, 0)
+}
+
+// DecompressWithMaxBytes decompresses the archive into dst, failing if any file in it is bigger than max.
+func DecompressWithMaxBytes(src io.Reader, dst string, max datasize.ByteSize) error {
+	return decompress(src, dst, max, 0)
+}
+
+// DecompressWithMaxTotalBytes decompresses the archive into dst, failing if the files in it add up to more than max.
+func DecompressWithMaxTotalBytes(src io.Reader, dst string, max datasize.ByteSize) error {
+	return decompress(src, dst, max, max)
 }
 
 func UncompressedSize(src io.Reader) (datasize.ByteSize, error) {
@@ -147,7 +157,9 @@ func compress(ctx context.Context, src string, buf io.Writer, max datasize.ByteS
 	return nil
 }
 
-func decompress(src io.Reader, dst string, max datasize.ByteSize) error {
+// decompress extracts the archive into dst, failing if any file in it is bigger than max or, if maxTotal is not zero,
+// if its files add up to more than maxTotal.
+func decompress(src io.Reader, dst string, max, maxTotal datasize.ByteSize) error {
 	// ensure destination directory exists
 	err := os.Mkdir(dst, worldReadOwnerWritePermission)
 	if err != nil {
@@ -161,6 +173,7 @@ func decompress(src io.Reader, dst string, max datasize.ByteSize) error {
 	}
 	// untar
 	tr := tar.NewReader(zr)
+	var total datasize.ByteSize
 
 	// uncompress each element
 	for {
@@ -200,6 +213,10 @@ func decompress(src io.Reader, dst string, max datasize.ByteSize) error {
 			if header.Size > int64(max) {
 				return fmt.Errorf("file %s bigger than max size %s", header.Name, max.HumanReadable())
 			}
+			total += datasize.ByteSize(header.Size)
+			if maxTotal > 0 && total > maxTotal {
+				return fmt.Errorf("archive bigger than max size %s", maxTotal.HumanReadable())
+			}
 			fileToWrite, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
 			if err != nil {
 				return err
@@ -0,0 +1,221 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/results"
+)
+
+// JobRunner runs the validation jobs of the command verifier on behalf of a requester node.
+type JobRunner interface {
+	// GetJob returns the job whose results are being verified.
+	GetJob(ctx context.Context, jobID string) (model.Job, error)
+	// RunJob waits for the job tagged with tag to reach a terminal state, submitting job first unless a job with the
+	// tag was already submitted, such as before the requester node restarted.
+	RunJob(ctx context.Context, tag string, job model.Job) (model.JobState, error)
+	// VerifyExecutions reports the verdicts of validation jobs back to the requester node.
+	VerifyExecutions(ctx context.Context, results []verifier.VerifierResult)
+}
+
+// CommandVerifier verifies the results of each execution by running a user supplied validation job against them,
+// and accepts the results if the validation job exits with code 0.
+//
+// Compute nodes publish their results as part of the proposal, so that the validation job can read them. Validation
+// jobs take time to run, so the requester node is told about the verdicts asynchronously through its JobRunner.
+//
+// Which executions are being validated is only kept in memory, but validation jobs are tagged with the execution they
+// validate. After a restart, the requester node asks again for the executions that were not verified, and the
+// validation jobs already submitted for them are waited for rather than submitted again.
+type CommandVerifier struct {
+	publishers publisher.PublisherProvider
+	results    *results.Results
+
+	mu         sync.Mutex
+	runner     JobRunner
+	inProgress map[model.ExecutionID]struct{}
+}
+
+func NewCommandVerifier(publishers publisher.PublisherProvider) (*CommandVerifier, error) {
+	results, err := results.NewResults()
+	if err != nil {
+		return nil, err
+	}
+	return &CommandVerifier{
+		publishers: publishers,
+		results:    results,
+		inProgress: make(map[model.ExecutionID]struct{}),
+	}, nil
+}
+
+// SetJobRunner sets the runner used to run validation jobs. Only requester nodes have one, as they are the only
+// ones verifying results.
+func (v *CommandVerifier) SetJobRunner(runner JobRunner) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.runner = runner
+}
+
+// IsInstalled implements verifier.Verifier
+func (v *CommandVerifier) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+// GetResultPath implements verifier.Verifier
+func (v *CommandVerifier) GetResultPath(ctx context.Context, executionID string, job model.Job) (string, error) {
+	_, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.CommandVerifier.GetResultPath")
+	defer span.End()
+
+	return v.results.EnsureResultsDir(executionID)
+}
+
+// GetProposal implements verifier.Verifier
+func (v *CommandVerifier) GetProposal(ctx context.Context, job model.Job, executionID string, resultPath string) ([]byte, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.CommandVerifier.GetProposal")
+	defer span.End()
+
+	store, err := v.publishers.Get(ctx, job.Spec.PublisherSpec.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := store.PublishResult(ctx, executionID, job, resultPath)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&spec)
+}
+
+// Verify implements verifier.Verifier
+func (v *CommandVerifier) Verify(
+	ctx context.Context,
+	request verifier.VerifierRequest,
+) ([]verifier.VerifierResult, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.CommandVerifier.Verify")
+	defer span.End()
+
+	err := verifier.ValidateExecutions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	runner := v.runner
+	v.mu.Unlock()
+	if runner == nil {
+		return nil, errors.New("the command verifier can only verify results on requester nodes")
+	}
+
+	job, err := runner.GetJob(ctx, request.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Spec.VerifierCommand == nil {
+		return nil, fmt.Errorf("job %s has no validation job for the command verifier", request.JobID)
+	}
+
+	var rejected []verifier.VerifierResult
+	for _, execution := range request.Executions { //nolint:gocritic
+		var resultSpec model.StorageSpec
+		if err = json.Unmarshal(execution.VerificationProposal, &resultSpec); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("rejecting results of execution %s with an invalid proposal", execution.ID())
+			rejected = append(rejected, verifier.VerifierResult{ExecutionID: execution.ID(), Verified: false})
+			continue
+		}
+
+		// the requester keeps asking for verification until results are verified, so only validate them once
+		if !v.startValidation(execution.ID()) {
+			continue
+		}
+		go v.validate(util.NewDetachedContext(ctx), runner, job, execution.ID(), resultSpec)
+	}
+
+	return rejected, nil
+}
+
+func (v *CommandVerifier) startValidation(executionID model.ExecutionID) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.inProgress[executionID]; ok {
+		return false
+	}
+	v.inProgress[executionID] = struct{}{}
+	return true
+}
+
+func (v *CommandVerifier) validate(
+	ctx context.Context,
+	runner JobRunner,
+	job model.Job,
+	executionID model.ExecutionID,
+	resultSpec model.StorageSpec,
+) {
+	defer func() {
+		v.mu.Lock()
+		delete(v.inProgress, executionID)
+		v.mu.Unlock()
+	}()
+
+	state, err := runner.RunJob(ctx, ValidationTag(executionID), newValidationJob(job, executionID, resultSpec))
+	verified := err == nil && passed(state)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("failed to run validation job for execution %s", executionID)
+	}
+	log.Ctx(ctx).Debug().Msgf("validation job %s of execution %s passed: %t", state.JobID, executionID, verified)
+
+	runner.VerifyExecutions(ctx, []verifier.VerifierResult{{ExecutionID: executionID, Verified: verified}})
+}
+
+// ValidationTag returns the annotation of the validation job of an execution.
+func ValidationTag(executionID model.ExecutionID) string {
+	return "verification-of-execution:" + executionID.String()
+}
+
+// newValidationJob returns the job that validates the results of an execution of job.
+func newValidationJob(job model.Job, executionID model.ExecutionID, resultSpec model.StorageSpec) model.Job {
+	command := job.Spec.VerifierCommand
+	resultSpec.Path = model.VerifierCommandResultsPath
+	return model.Job{
+		APIVersion: job.APIVersion,
+		Metadata: model.Metadata{
+			ClientID: job.Metadata.ClientID,
+		},
+		Spec: model.Spec{
+			Engine:        command.Engine,
+			Verifier:      model.VerifierNoop,
+			PublisherSpec: model.PublisherSpec{Type: model.PublisherNoop},
+			Docker:        command.Docker,
+			Wasm:          command.Wasm,
+			Resources:     command.Resources,
+			Timeout:       command.Timeout,
+			Inputs:        []model.StorageSpec{resultSpec},
+			Annotations:   []string{"verification-of:" + job.ID(), ValidationTag(executionID)},
+			Deal:          model.Deal{Concurrency: 1},
+		},
+	}
+}
+
+// passed returns true if the validation job completed with exit code 0.
+func passed(state model.JobState) bool {
+	if state.State != model.JobStateCompleted {
+		return false
+	}
+	for _, execution := range state.Executions { //nolint:gocritic
+		if execution.State == model.ExecutionStateCompleted && execution.RunOutput != nil && execution.RunOutput.ExitCode == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var _ verifier.Verifier = (*CommandVerifier)(nil)
@@ -0,0 +1,193 @@
+//go:build unit || !integration
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+)
+
+type fakeRunner struct {
+	job      model.Job
+	exitCode int
+	release  chan struct{}
+	ran      chan model.Job
+	results  chan verifier.VerifierResult
+
+	mu        sync.Mutex
+	submitted map[string]int
+}
+
+func newFakeRunner(job model.Job, exitCode int) *fakeRunner {
+	return &fakeRunner{
+		job:       job,
+		exitCode:  exitCode,
+		release:   make(chan struct{}),
+		ran:       make(chan model.Job, 10),
+		results:   make(chan verifier.VerifierResult, 10),
+		submitted: make(map[string]int),
+	}
+}
+
+func (r *fakeRunner) GetJob(context.Context, string) (model.Job, error) {
+	return r.job, nil
+}
+
+func (r *fakeRunner) RunJob(_ context.Context, tag string, job model.Job) (model.JobState, error) {
+	r.mu.Lock()
+	if r.submitted[tag] == 0 {
+		r.ran <- job
+	}
+	r.submitted[tag]++
+	r.mu.Unlock()
+	<-r.release
+	return model.JobState{
+		JobID: "validation",
+		State: model.JobStateCompleted,
+		Executions: []model.ExecutionState{{
+			State:     model.ExecutionStateCompleted,
+			RunOutput: &model.RunCommandResult{ExitCode: r.exitCode},
+		}},
+	}, nil
+}
+
+func (r *fakeRunner) VerifyExecutions(_ context.Context, results []verifier.VerifierResult) {
+	for _, result := range results {
+		r.results <- result
+	}
+}
+
+func newTestJob() model.Job {
+	return model.Job{
+		Metadata: model.Metadata{ID: "job", ClientID: "client"},
+		Spec: model.Spec{
+			Verifier: model.VerifierCommand,
+			VerifierCommand: &model.VerifierCommandSpec{
+				Engine: model.EngineDocker,
+				Docker: model.JobSpecDocker{Image: "validator", Entrypoint: []string{"sh", "-c", "true"}},
+			},
+			Deal: model.Deal{Concurrency: 1},
+		},
+	}
+}
+
+func newTestRequest(t *testing.T, job model.Job) verifier.VerifierRequest {
+	proposal, err := json.Marshal(model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmResults"})
+	require.NoError(t, err)
+	return verifier.VerifierRequest{
+		JobID: job.ID(),
+		Deal:  job.Spec.Deal,
+		Executions: []model.ExecutionState{{
+			JobID:                job.ID(),
+			NodeID:               "node",
+			ComputeReference:     "execution",
+			State:                model.ExecutionStateResultProposed,
+			VerificationProposal: proposal,
+		}},
+	}
+}
+
+func TestCommandVerifierRunsValidationJob(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		exitCode int
+		verified bool
+	}{
+		{name: "passing", exitCode: 0, verified: true},
+		{name: "failing", exitCode: 1, verified: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			job := newTestJob()
+			runner := newFakeRunner(job, tc.exitCode)
+			v, err := NewCommandVerifier(nil)
+			require.NoError(t, err)
+			v.SetJobRunner(runner)
+
+			results, err := v.Verify(ctx, newTestRequest(t, job))
+			require.NoError(t, err)
+			require.Empty(t, results)
+
+			validation := <-runner.ran
+			require.Equal(t, job.Spec.VerifierCommand.Docker, validation.Spec.Docker)
+			require.Equal(t, model.VerifierNoop, validation.Spec.Verifier)
+			require.Len(t, validation.Spec.Inputs, 1)
+			require.Equal(t, "QmResults", validation.Spec.Inputs[0].CID)
+			require.Equal(t, model.VerifierCommandResultsPath, validation.Spec.Inputs[0].Path)
+			close(runner.release)
+
+			result := <-runner.results
+			require.Equal(t, "execution", result.ExecutionID.ExecutionID)
+			require.Equal(t, tc.verified, result.Verified)
+		})
+	}
+}
+
+func TestCommandVerifierValidatesOnce(t *testing.T) {
+	ctx := context.Background()
+	job := newTestJob()
+	runner := newFakeRunner(job, 0)
+	v, err := NewCommandVerifier(nil)
+	require.NoError(t, err)
+	v.SetJobRunner(runner)
+
+	_, err = v.Verify(ctx, newTestRequest(t, job))
+	require.NoError(t, err)
+	<-runner.ran
+
+	// asking again while the validation job runs doesn't start another one
+	_, err = v.Verify(ctx, newTestRequest(t, job))
+	require.NoError(t, err)
+	require.Empty(t, runner.ran)
+
+	close(runner.release)
+	<-runner.results
+}
+
+func TestCommandVerifierWaitsForValidationJobsSubmittedBeforeRestart(t *testing.T) {
+	ctx := context.Background()
+	job := newTestJob()
+	runner := newFakeRunner(job, 0)
+	v, err := NewCommandVerifier(nil)
+	require.NoError(t, err)
+	v.SetJobRunner(runner)
+
+	request := newTestRequest(t, job)
+	_, err = v.Verify(ctx, request)
+	require.NoError(t, err)
+	validation := <-runner.ran
+	require.Contains(t, validation.Spec.Annotations, ValidationTag(request.Executions[0].ID()))
+
+	// a restarted verifier knows nothing of the validation job in progress, so it waits for it by its tag
+	restarted, err := NewCommandVerifier(nil)
+	require.NoError(t, err)
+	restarted.SetJobRunner(runner)
+	_, err = restarted.Verify(ctx, request)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		runner.mu.Lock()
+		defer runner.mu.Unlock()
+		return runner.submitted[ValidationTag(request.Executions[0].ID())] == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Empty(t, runner.ran, "the validation job is not submitted again")
+
+	close(runner.release)
+	require.True(t, (<-runner.results).Verified)
+	require.True(t, (<-runner.results).Verified)
+}
+
+func TestCommandVerifierRequiresRunner(t *testing.T) {
+	v, err := NewCommandVerifier(nil)
+	require.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), newTestRequest(t, newTestJob()))
+	require.Error(t, err)
+}
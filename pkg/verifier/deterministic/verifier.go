@@ -118,11 +118,43 @@ func (deterministicVerifier *DeterministicVerifier) Verify(
 		return nil, err
 	}
 
+	hashGroups := deterministicVerifier.getHashGroups(ctx, request.Executions)
+	largestGroupHash, isVoidResult := winningGroup(hashGroups, request.Deal.Confidence)
+	if !isVoidResult {
+		for _, passedVerificationResult := range hashGroups[largestGroupHash] {
+			passedVerificationResult.Verified = true
+		}
+	}
+
+	var allResults []verifier.VerifierResult
+
+	for _, verificationResultList := range hashGroups {
+		for _, verificationResult := range verificationResultList {
+			allResults = append(allResults, *verificationResult)
+		}
+	}
+
+	return allResults, nil
+}
+
+// NeedsMoreSamples returns true if no group of identical results is large enough to be verified yet, e.g. because
+// there is a single result, or a draw between the largest groups.
+func (deterministicVerifier *DeterministicVerifier) NeedsMoreSamples(
+	ctx context.Context,
+	request verifier.VerifierRequest,
+) (bool, error) {
+	hashGroups := deterministicVerifier.getHashGroups(ctx, request.Executions)
+	_, isVoidResult := winningGroup(hashGroups, request.Deal.Confidence)
+	return isVoidResult, nil
+}
+
+// winningGroup returns the hash of the largest group of identical results, and whether the results are void because
+// no group can be verified.
+func winningGroup(hashGroups map[string][]*verifier.VerifierResult, confidence int) (string, bool) {
 	largestGroupHash := ""
 	largestGroupSize := 0
 	isVoidResult := false
 	groupSizeCounts := map[int]int{}
-	hashGroups := deterministicVerifier.getHashGroups(ctx, request.Executions)
 
 	for hash, group := range hashGroups {
 		if len(group) > largestGroupSize {
@@ -144,7 +176,6 @@ func (deterministicVerifier *DeterministicVerifier) Verify(
 
 	// this means that the winning group size does not
 	// meet the confidence threshold
-	confidence := request.Deal.Confidence
 	if confidence > 0 && largestGroupSize < confidence {
 		isVoidResult = true
 	}
@@ -154,22 +185,9 @@ func (deterministicVerifier *DeterministicVerifier) Verify(
 		isVoidResult = true
 	}
 
-	if !isVoidResult {
-		for _, passedVerificationResult := range hashGroups[largestGroupHash] {
-			passedVerificationResult.Verified = true
-		}
-	}
-
-	var allResults []verifier.VerifierResult
-
-	for _, verificationResultList := range hashGroups {
-		for _, verificationResult := range verificationResultList {
-			allResults = append(allResults, *verificationResult)
-		}
-	}
-
-	return allResults, nil
+	return largestGroupHash, isVoidResult
 }
 
 // Compile-time check that deterministicVerifier implements the correct interface:
 var _ verifier.Verifier = (*DeterministicVerifier)(nil)
+var _ verifier.SampleEstimator = (*DeterministicVerifier)(nil)
@@ -69,3 +69,12 @@ type Verifier interface {
 		request VerifierRequest,
 	) ([]VerifierResult, error)
 }
+
+// SampleEstimator is implemented by verifiers that compare the results of several executions, so that deals with
+// adaptive concurrency only run additional executions when the verifier needs them. Verifiers that don't implement it
+// can verify any number of results.
+type SampleEstimator interface {
+	// NeedsMoreSamples returns true if the results proposed by the executions of the request can't be verified yet,
+	// and results of more executions could allow it.
+	NeedsMoreSamples(ctx context.Context, request VerifierRequest) (bool, error)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/command"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier/deterministic"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier/external"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier/noop"
@@ -36,6 +37,12 @@ func NewStandardVerifiers(
 		verifiers.Add(model.VerifierDeterministic, deterministicVerifier)
 	}
 
+	commandVerifier, err := command.NewCommandVerifier(publishers)
+	rerr = multierr.Append(rerr, err)
+	if err == nil {
+		verifiers.Add(model.VerifierCommand, commandVerifier)
+	}
+
 	if externalWebhook != nil {
 		externalVerifier, err := external.NewExternalVerifier(publishers, externalWebhook)
 		rerr = multierr.Append(rerr, err)